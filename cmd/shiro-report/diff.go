@@ -0,0 +1,286 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	diffStatusNew        = "new"
+	diffStatusFixed      = "fixed"
+	diffStatusPersisting = "persisting"
+
+	diffFormatMarkdown = "markdown"
+	diffFormatJSON     = "json"
+
+	diffFingerprintErrorMaxLen = 160
+	diffMarkdownClusterLimit   = 50
+)
+
+var diffNumberPattern = regexp.MustCompile(`\d+`)
+
+// diffCase is the subset of report.json / reports.index.json case fields used
+// by diff mode. Only fields both files carry may feed the fingerprint, so a
+// base index and a head report agree on which cases are the same bug.
+type diffCase struct {
+	ID                           string `json:"id"`
+	CaseID                       string `json:"case_id"`
+	Oracle                       string `json:"oracle"`
+	Timestamp                    string `json:"timestamp"`
+	ErrorReason                  string `json:"error_reason"`
+	Error                        string `json:"error"`
+	GroundTruthDSGMismatchReason string `json:"groundtruth_dsg_mismatch_reason"`
+	PlanSignature                string `json:"plan_signature"`
+	PlanSigFormat                string `json:"plan_signature_format"`
+	Expected                     string `json:"expected"`
+	Actual                       string `json:"actual"`
+	Flaky                        bool   `json:"flaky"`
+	ReportURL                    string `json:"report_url"`
+}
+
+type diffSite struct {
+	Source string     `json:"source"`
+	Cases  []diffCase `json:"cases"`
+}
+
+// diffCluster groups cases that share a fingerprint.
+type diffCluster struct {
+	Fingerprint string `json:"fingerprint"`
+	Oracle      string `json:"oracle"`
+	ErrorReason string `json:"error_reason,omitempty"`
+	Key         string `json:"key,omitempty"`
+	Status      string `json:"status"`
+	BaseCount   int    `json:"base_count"`
+	HeadCount   int    `json:"head_count"`
+	ExampleID   string `json:"example_case_id,omitempty"`
+	ExampleURL  string `json:"example_report_url,omitempty"`
+}
+
+// reportDiff is the delta between a base and head report.
+type reportDiff struct {
+	Base       string        `json:"base"`
+	Head       string        `json:"head"`
+	BaseCases  int           `json:"base_cases"`
+	HeadCases  int           `json:"head_cases"`
+	New        []diffCluster `json:"new"`
+	Fixed      []diffCluster `json:"fixed"`
+	Persisting []diffCluster `json:"persisting"`
+}
+
+// runDiff implements `shiro-report diff -base <file> -head <file>`.
+func runDiff(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	basePath := fs.String("base", "", "base report.json or reports.index.json")
+	headPath := fs.String("head", "", "head report.json or reports.index.json")
+	format := fs.String("format", diffFormatMarkdown, "output format: markdown or json")
+	output := fs.String("output", "", "write the diff to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if strings.TrimSpace(*basePath) == "" || strings.TrimSpace(*headPath) == "" {
+		return fmt.Errorf("diff requires -base and -head")
+	}
+	base, err := loadDiffSite(*basePath)
+	if err != nil {
+		return fmt.Errorf("load base: %w", err)
+	}
+	head, err := loadDiffSite(*headPath)
+	if err != nil {
+		return fmt.Errorf("load head: %w", err)
+	}
+	diff := buildReportDiff(base.Cases, head.Cases)
+	diff.Base = *basePath
+	diff.Head = *headPath
+
+	var payload []byte
+	switch strings.ToLower(strings.TrimSpace(*format)) {
+	case diffFormatJSON:
+		payload, err = json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			return err
+		}
+		payload = append(payload, '\n')
+	case diffFormatMarkdown:
+		payload = []byte(renderDiffMarkdown(diff))
+	default:
+		return fmt.Errorf("unknown diff format %q", *format)
+	}
+	if strings.TrimSpace(*output) != "" {
+		return os.WriteFile(*output, payload, 0o644)
+	}
+	_, err = stdout.Write(payload)
+	return err
+}
+
+func loadDiffSite(path string) (diffSite, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return diffSite{}, err
+	}
+	var site diffSite
+	if err := json.Unmarshal(data, &site); err != nil {
+		return diffSite{}, err
+	}
+	return site, nil
+}
+
+// buildReportDiff classifies fingerprint clusters as new, fixed, or persisting.
+func buildReportDiff(base []diffCase, head []diffCase) reportDiff {
+	baseClusters := clusterDiffCases(base)
+	headClusters := clusterDiffCases(head)
+	diff := reportDiff{BaseCases: len(base), HeadCases: len(head)}
+	for fp, cluster := range headClusters {
+		if prev, ok := baseClusters[fp]; ok {
+			cluster.BaseCount = prev.HeadCount
+			cluster.Status = diffStatusPersisting
+			diff.Persisting = append(diff.Persisting, cluster)
+			continue
+		}
+		cluster.Status = diffStatusNew
+		diff.New = append(diff.New, cluster)
+	}
+	for fp, cluster := range baseClusters {
+		if _, ok := headClusters[fp]; ok {
+			continue
+		}
+		cluster.BaseCount = cluster.HeadCount
+		cluster.HeadCount = 0
+		cluster.Status = diffStatusFixed
+		diff.Fixed = append(diff.Fixed, cluster)
+	}
+	sortDiffClusters(diff.New)
+	sortDiffClusters(diff.Fixed)
+	sortDiffClusters(diff.Persisting)
+	return diff
+}
+
+// clusterDiffCases groups cases by fingerprint; counts land in HeadCount.
+func clusterDiffCases(cases []diffCase) map[string]diffCluster {
+	out := make(map[string]diffCluster, len(cases))
+	for _, c := range cases {
+		oracle, reason, key := diffFingerprintParts(c)
+		fp := diffFingerprint(c)
+		cluster, ok := out[fp]
+		if !ok {
+			cluster = diffCluster{Fingerprint: fp, Oracle: oracle, ErrorReason: reason, Key: key}
+		}
+		cluster.HeadCount++
+		id := strings.TrimSpace(c.CaseID)
+		if id == "" {
+			id = strings.TrimSpace(c.ID)
+		}
+		if cluster.ExampleID == "" || (id != "" && id < cluster.ExampleID) {
+			cluster.ExampleID = id
+			cluster.ExampleURL = strings.TrimSpace(c.ReportURL)
+		}
+		out[fp] = cluster
+	}
+	return out
+}
+
+// diffFingerprint joins the fingerprint parts of a case into its cluster key.
+func diffFingerprint(c diffCase) string {
+	oracle, reason, key := diffFingerprintParts(c)
+	return oracle + "|" + reason + "|" + key
+}
+
+// diffFingerprintParts picks a stable cluster key for a case: the normalized
+// error, otherwise the ground-truth DSG mismatch reason. Wrong results without
+// either are keyed on the plan signature, or on the number-masked
+// expected/actual shape when no plan was captured, so distinct mismatches of
+// one oracle stay apart.
+func diffFingerprintParts(c diffCase) (oracle string, reason string, key string) {
+	oracle = strings.TrimSpace(c.Oracle)
+	reason = strings.TrimSpace(c.ErrorReason)
+	if errText := normalizeDiffError(c.Error); errText != "" {
+		return oracle, reason, errText
+	}
+	if dsg := strings.TrimSpace(c.GroundTruthDSGMismatchReason); dsg != "" {
+		return oracle, reason, dsg
+	}
+	if sig := strings.TrimSpace(c.PlanSignature); sig != "" {
+		if format := strings.TrimSpace(c.PlanSigFormat); format != "" {
+			return oracle, reason, format + ":" + sig
+		}
+		return oracle, reason, sig
+	}
+	expected := normalizeDiffError(c.Expected)
+	actual := normalizeDiffError(c.Actual)
+	if expected == "" && actual == "" {
+		return oracle, reason, ""
+	}
+	return oracle, reason, expected + " -> " + actual
+}
+
+// normalizeDiffError keeps the first line of an error or result and masks
+// numbers so table, column, and connection ids do not split clusters.
+func normalizeDiffError(errText string) string {
+	errText = strings.TrimSpace(errText)
+	if idx := strings.IndexByte(errText, '\n'); idx >= 0 {
+		errText = strings.TrimSpace(errText[:idx])
+	}
+	errText = diffNumberPattern.ReplaceAllString(errText, "N")
+	if len(errText) > diffFingerprintErrorMaxLen {
+		errText = errText[:diffFingerprintErrorMaxLen]
+	}
+	return errText
+}
+
+func sortDiffClusters(clusters []diffCluster) {
+	sort.Slice(clusters, func(i, j int) bool {
+		ci := max(clusters[i].HeadCount, clusters[i].BaseCount)
+		cj := max(clusters[j].HeadCount, clusters[j].BaseCount)
+		if ci != cj {
+			return ci > cj
+		}
+		return clusters[i].Fingerprint < clusters[j].Fingerprint
+	})
+}
+
+// renderDiffMarkdown formats the diff for a CI comment.
+func renderDiffMarkdown(diff reportDiff) string {
+	var b strings.Builder
+	b.WriteString("### Shiro report diff\n\n")
+	fmt.Fprintf(&b, "base: `%s` (%d cases) -> head: `%s` (%d cases)\n\n", diff.Base, diff.BaseCases, diff.Head, diff.HeadCases)
+	b.WriteString("| status | clusters |\n| --- | ---: |\n")
+	fmt.Fprintf(&b, "| new | %d |\n| fixed | %d |\n| persisting | %d |\n", len(diff.New), len(diff.Fixed), len(diff.Persisting))
+	writeDiffMarkdownSection(&b, "New", diff.New)
+	writeDiffMarkdownSection(&b, "Fixed", diff.Fixed)
+	writeDiffMarkdownSection(&b, "Persisting", diff.Persisting)
+	return b.String()
+}
+
+func writeDiffMarkdownSection(b *strings.Builder, title string, clusters []diffCluster) {
+	if len(clusters) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n#### %s (%d)\n\n", title, len(clusters))
+	b.WriteString("| oracle | reason | key | base | head | example |\n| --- | --- | --- | ---: | ---: | --- |\n")
+	for i, c := range clusters {
+		if i >= diffMarkdownClusterLimit {
+			fmt.Fprintf(b, "\n_%d more clusters omitted._\n", len(clusters)-diffMarkdownClusterLimit)
+			break
+		}
+		example := c.ExampleID
+		if c.ExampleURL != "" && example != "" {
+			example = fmt.Sprintf("[%s](%s)", example, c.ExampleURL)
+		}
+		fmt.Fprintf(b, "| %s | %s | %s | %d | %d | %s |\n",
+			escapeDiffCell(c.Oracle), escapeDiffCell(c.ErrorReason), escapeDiffCell(c.Key), c.BaseCount, c.HeadCount, example)
+	}
+}
+
+func escapeDiffCell(text string) string {
+	text = strings.ReplaceAll(text, "|", "\\|")
+	text = strings.ReplaceAll(text, "\n", " ")
+	if text == "" {
+		return "-"
+	}
+	return "`" + strings.ReplaceAll(text, "`", "'") + "`"
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildReportDiffClassifiesClusters(t *testing.T) {
+	base := []diffCase{
+		{CaseID: "b1", Oracle: "NoREC"},
+		{CaseID: "b2", Oracle: "TLP", Error: "Error 1105: index out of range [3] with length 3", ErrorReason: "runtime"},
+		{CaseID: "b3", Oracle: "GroundTruth", GroundTruthDSGMismatchReason: "dsg_row_count"},
+	}
+	head := []diffCase{
+		{CaseID: "h2", Oracle: "TLP", Error: "Error 1105: index out of range [5] with length 5", ErrorReason: "runtime"},
+		{CaseID: "h1", Oracle: "TLP", Error: "Error 1105: index out of range [4] with length 4", ErrorReason: "runtime"},
+		{CaseID: "h3", Oracle: "GroundTruth", GroundTruthDSGMismatchReason: "dsg_join_sig"},
+	}
+	diff := buildReportDiff(base, head)
+	if len(diff.New) != 1 || diff.New[0].Key != "dsg_join_sig" {
+		t.Fatalf("unexpected new clusters: %+v", diff.New)
+	}
+	if len(diff.Fixed) != 2 {
+		t.Fatalf("unexpected fixed clusters: %+v", diff.Fixed)
+	}
+	if len(diff.Persisting) != 1 {
+		t.Fatalf("unexpected persisting clusters: %+v", diff.Persisting)
+	}
+	persisting := diff.Persisting[0]
+	if persisting.BaseCount != 1 || persisting.HeadCount != 2 || persisting.ExampleID != "h1" {
+		t.Fatalf("unexpected persisting cluster: %+v", persisting)
+	}
+	for _, c := range diff.Fixed {
+		if c.HeadCount != 0 || c.BaseCount != 1 || c.Status != diffStatusFixed {
+			t.Fatalf("unexpected fixed cluster: %+v", c)
+		}
+	}
+}
+
+func TestBuildReportDiffMatchesIndexAndFullSummaries(t *testing.T) {
+	dir := t.TempDir()
+	basePath := filepath.Join(dir, "reports.index.json")
+	headPath := filepath.Join(dir, "report.json")
+	writeDiffFixture(t, basePath, SiteIndexData{Cases: []CaseIndexEntry{
+		{CaseID: "b1", Oracle: "TLP", ErrorReason: "runtime", Error: "Error 1105: index out of range [3]"},
+		{CaseID: "b2", Oracle: "NoREC", ErrorReason: "result_mismatch", PlanSignature: "plan-a"},
+	}})
+	writeDiffFixture(t, headPath, SiteData{Cases: []CaseEntry{
+		{
+			CaseID: "h1", Oracle: "TLP", ErrorReason: "runtime", Error: "Error 1105: index out of range [9]",
+			Details: map[string]any{"error_signature": "runtime|1105|index out of range"},
+		},
+		{CaseID: "h2", Oracle: "NoREC", ErrorReason: "result_mismatch", PlanSignature: "plan-b"},
+	}})
+	base, err := loadDiffSite(basePath)
+	if err != nil {
+		t.Fatalf("load base: %v", err)
+	}
+	head, err := loadDiffSite(headPath)
+	if err != nil {
+		t.Fatalf("load head: %v", err)
+	}
+	diff := buildReportDiff(base.Cases, head.Cases)
+	if len(diff.Persisting) != 1 || diff.Persisting[0].Oracle != "TLP" {
+		t.Fatalf("expected the runtime error to persist across index and summary inputs: %+v", diff.Persisting)
+	}
+	if len(diff.Fixed) != 1 || diff.Fixed[0].Key != "plan-a" {
+		t.Fatalf("expected plan-a to be fixed: %+v", diff.Fixed)
+	}
+	if len(diff.New) != 1 || diff.New[0].Key != "plan-b" {
+		t.Fatalf("expected plan-b to be new: %+v", diff.New)
+	}
+}
+
+func TestDiffFingerprintSeparatesMismatches(t *testing.T) {
+	a := diffCase{Oracle: "TLP", ErrorReason: "result_mismatch", Expected: "cnt=3", Actual: "cnt=4"}
+	b := diffCase{Oracle: "TLP", ErrorReason: "result_mismatch", Expected: "cnt=12 sum=5", Actual: "cnt=13 sum=7"}
+	c := diffCase{Oracle: "TLP", ErrorReason: "result_mismatch", Expected: "cnt=8", Actual: "cnt=9"}
+	if diffFingerprint(a) == diffFingerprint(b) {
+		t.Fatalf("different result shapes should not share a fingerprint: %s", diffFingerprint(a))
+	}
+	if diffFingerprint(a) != diffFingerprint(c) {
+		t.Fatalf("result shapes should mask numbers: %s vs %s", diffFingerprint(a), diffFingerprint(c))
+	}
+	withPlan := diffCase{Oracle: "TLP", ErrorReason: "result_mismatch", PlanSignature: "abc", PlanSigFormat: "plan_digest", Expected: "cnt=3"}
+	if got := diffFingerprint(withPlan); got != "TLP|result_mismatch|plan_digest:abc" {
+		t.Fatalf("plan signature should key the mismatch: %s", got)
+	}
+}
+
+func TestNormalizeDiffError(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "", want: ""},
+		{in: "Error 1054: Unknown column 't1.c3'\nstack", want: "Error N: Unknown column 'tN.cN'"},
+	}
+	for _, tt := range tests {
+		if got := normalizeDiffError(tt.in); got != tt.want {
+			t.Fatalf("normalizeDiffError(%q)=%q want=%q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRunDiffMarkdownAndJSON(t *testing.T) {
+	dir := t.TempDir()
+	basePath := filepath.Join(dir, "base.json")
+	headPath := filepath.Join(dir, "head.json")
+	writeDiffFixture(t, basePath, SiteData{Cases: []CaseEntry{{CaseID: "b1", Oracle: "NoREC", PlanSignature: "p1"}}})
+	writeDiffFixture(t, headPath, SiteIndexData{Cases: []CaseIndexEntry{{CaseID: "h1", Oracle: "TLP", Error: "boom | bad", ReportURL: "https://example.com/h1"}}})
+
+	var out bytes.Buffer
+	if err := runDiff([]string{"-base", basePath, "-head", headPath}, &out); err != nil {
+		t.Fatalf("runDiff markdown: %v", err)
+	}
+	md := out.String()
+	for _, want := range []string{"| new | 1 |", "| fixed | 1 |", "[h1](https://example.com/h1)", "boom \\| bad"} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("markdown missing %q:\n%s", want, md)
+		}
+	}
+
+	jsonPath := filepath.Join(dir, "diff.json")
+	if err := runDiff([]string{"-base", basePath, "-head", headPath, "-format", "json", "-output", jsonPath}, &out); err != nil {
+		t.Fatalf("runDiff json: %v", err)
+	}
+	data, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatalf("read diff json: %v", err)
+	}
+	var diff reportDiff
+	if err := json.Unmarshal(data, &diff); err != nil {
+		t.Fatalf("decode diff json: %v", err)
+	}
+	if len(diff.New) != 1 || len(diff.Fixed) != 1 || len(diff.Persisting) != 0 {
+		t.Fatalf("unexpected diff: %+v", diff)
+	}
+
+	if err := runDiff([]string{"-base", basePath}, &out); err == nil {
+		t.Fatalf("expected error without -head")
+	}
+}
+
+func writeDiffFixture(t *testing.T, path string, payload any) {
+	t.Helper()
+	data, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("marshal fixture: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+}
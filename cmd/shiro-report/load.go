@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"shiro/internal/uploader"
+)
+
+// loadProgressEvery is how many loaded cases pass between progress lines.
+const loadProgressEvery = 500
+
+// loadStoreCases reads every case with a summary.json under prefix. Cases are
+// read by opts.Workers goroutines and returned in summary key order; cases
+// whose summary cannot be read or parsed are skipped and reported to
+// opts.Progress.
+func loadStoreCases(ctx context.Context, store uploader.ObjectStore, prefix string, opts loadOptions) ([]CaseEntry, error) {
+	keys, err := store.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	objectSet := make(map[string]struct{}, len(keys))
+	var summaryKeys []string
+	for _, key := range keys {
+		objectSet[key] = struct{}{}
+		if strings.HasSuffix(key, "/summary.json") {
+			summaryKeys = append(summaryKeys, key)
+		}
+	}
+
+	entries := make([]CaseEntry, len(summaryKeys))
+	loaded := make([]bool, len(summaryKeys))
+	skipped := make([]error, len(summaryKeys))
+	progress := newLoadProgress(opts, len(summaryKeys))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < loadWorkerCount(opts.Workers, len(summaryKeys)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				dir := strings.TrimSuffix(summaryKeys[i], "/summary.json")
+				entry, err := readCaseFromStore(ctx, store, dir, opts, objectSet)
+				progress.done(err != nil)
+				if err != nil {
+					skipped[i] = err
+					continue
+				}
+				entry.Dir = store.Location(dir)
+				if strings.TrimSpace(entry.ID) == "" {
+					entry.ID = path.Base(dir)
+				}
+				entries[i] = entry
+				loaded[i] = true
+			}
+		}()
+	}
+	for i := range summaryKeys {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	progress.finish()
+
+	cases := make([]CaseEntry, 0, len(summaryKeys))
+	for i, entry := range entries {
+		if loaded[i] {
+			cases = append(cases, entry)
+		} else if skipped[i] != nil {
+			opts.skipCase(strings.TrimSuffix(summaryKeys[i], "/summary.json"), skipped[i])
+		}
+	}
+	return cases, nil
+}
+
+// loadWorkerCount clamps the worker pool to at least one goroutine and at
+// most one per case.
+func loadWorkerCount(workers int, cases int) int {
+	if workers > cases {
+		workers = cases
+	}
+	return max(workers, 1)
+}
+
+// storeGet reads key with the per-request timeout applied.
+func (o loadOptions) storeGet(ctx context.Context, store uploader.ObjectStore, key string, maxBytes int) ([]byte, bool, error) {
+	if o.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.RequestTimeout)
+		defer cancel()
+	}
+	return store.Get(ctx, key, maxBytes)
+}
+
+// loadProgress counts loaded cases and prints a line to opts.Progress every
+// loadProgressEvery cases and once at the end.
+type loadProgress struct {
+	opts   loadOptions
+	total  int
+	start  time.Time
+	count  atomic.Int64
+	failed atomic.Int64
+}
+
+func newLoadProgress(opts loadOptions, total int) *loadProgress {
+	return &loadProgress{opts: opts, total: total, start: time.Now()}
+}
+
+func (p *loadProgress) done(failed bool) {
+	if failed {
+		p.failed.Add(1)
+	}
+	if n := p.count.Add(1); n%loadProgressEvery == 0 && int(n) < p.total {
+		p.print(n)
+	}
+}
+
+func (p *loadProgress) finish() {
+	p.print(p.count.Load())
+}
+
+func (p *loadProgress) print(n int64) {
+	if p.opts.Progress == nil {
+		return
+	}
+	elapsed := time.Since(p.start)
+	fmt.Fprintf(p.opts.Progress, "loaded %d/%d cases (%d failed) in %s\n", n, p.total, p.failed.Load(), elapsed.Round(time.Millisecond))
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"shiro/internal/uploader"
+)
+
+// slowStore serves summary.json objects after a delay and tracks how many
+// reads run at once. Keys listed in hang block until the context ends.
+type slowStore struct {
+	keys     []string
+	delay    time.Duration
+	hang     map[string]bool
+	mu       sync.Mutex
+	inflight int
+	peak     int
+}
+
+func (s *slowStore) Location(key string) string { return "mem://" + key }
+
+func (s *slowStore) List(context.Context, string) ([]string, error) { return s.keys, nil }
+
+func (s *slowStore) Get(ctx context.Context, key string, _ int) ([]byte, bool, error) {
+	s.mu.Lock()
+	s.inflight++
+	s.peak = max(s.peak, s.inflight)
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.inflight--
+		s.mu.Unlock()
+	}()
+	if s.hang[key] {
+		<-ctx.Done()
+		return nil, false, ctx.Err()
+	}
+	time.Sleep(s.delay)
+	if !strings.HasSuffix(key, "/summary.json") {
+		return nil, false, uploader.ErrNotExist
+	}
+	id := strings.TrimSuffix(key, "/summary.json")
+	return []byte(fmt.Sprintf(`{"case_id":%q}`, id)), false, nil
+}
+
+func (s *slowStore) Put(context.Context, string, io.Reader, int64, string) error { return nil }
+
+func (s *slowStore) Close() error { return nil }
+
+func TestLoadStoreCasesParallel(t *testing.T) {
+	store := &slowStore{delay: 2 * time.Millisecond, hang: map[string]bool{"c03/summary.json": true}}
+	for i := 0; i < 40; i++ {
+		store.keys = append(store.keys, fmt.Sprintf("c%02d/summary.json", i))
+	}
+	var progress bytes.Buffer
+	opts := loadOptions{MaxBytes: 1024, Workers: 8, RequestTimeout: 50 * time.Millisecond, Progress: &progress}
+	cases, err := loadStoreCases(context.Background(), store, "", opts)
+	if err != nil {
+		t.Fatalf("loadStoreCases() failed: %v", err)
+	}
+	if len(cases) != 39 {
+		t.Fatalf("expected the timed-out case to be skipped, got %d cases", len(cases))
+	}
+	for i, c := range cases {
+		want := i
+		if i >= 3 {
+			want = i + 1
+		}
+		if c.CaseID != fmt.Sprintf("c%02d", want) {
+			t.Fatalf("cases out of key order at %d: %s", i, c.CaseID)
+		}
+	}
+	if peak := store.peak; peak < 2 || peak > 8 {
+		t.Fatalf("expected parallel reads bounded by 8 workers, peak %d", peak)
+	}
+	if got := progress.String(); !strings.HasPrefix(got, "loaded 40/40 cases (1 failed) in ") {
+		t.Fatalf("unexpected progress output: %q", got)
+	}
+	if got := progress.String(); !strings.Contains(got, "skip case dir=c03 err=") {
+		t.Fatalf("expected the skipped case to be reported: %q", got)
+	}
+}
+
+func TestLoadWorkerCount(t *testing.T) {
+	for _, tc := range []struct{ workers, cases, want int }{
+		{0, 10, 1},
+		{-3, 10, 1},
+		{16, 4, 4},
+		{16, 0, 1},
+		{8, 100, 8},
+	} {
+		if got := loadWorkerCount(tc.workers, tc.cases); got != tc.want {
+			t.Fatalf("loadWorkerCount(%d, %d) = %d, want %d", tc.workers, tc.cases, got, tc.want)
+		}
+	}
+}
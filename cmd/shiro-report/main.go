@@ -5,12 +5,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"sort"
@@ -20,16 +20,9 @@ import (
 	"shiro/internal/config"
 	"shiro/internal/report"
 	"shiro/internal/runinfo"
+	"shiro/internal/sqlfmt"
+	"shiro/internal/uploader"
 	"shiro/internal/util"
-
-	"cloud.google.com/go/storage"
-	"github.com/aws/aws-sdk-go-v2/aws"
-	awsconfig "github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/credentials"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
-	"github.com/aws/aws-sdk-go-v2/service/s3/types"
-	"google.golang.org/api/iterator"
-	"google.golang.org/api/option"
 )
 
 // FileContent holds inlined report file content.
@@ -41,35 +34,39 @@ type FileContent struct {
 
 // CaseEntry represents a report case entry.
 type CaseEntry struct {
-	ID                           string                 `json:"id"`
-	Dir                          string                 `json:"dir"`
-	Oracle                       string                 `json:"oracle"`
-	Timestamp                    string                 `json:"timestamp"`
-	TiDBVersion                  string                 `json:"tidb_version"`
-	TiDBCommit                   string                 `json:"tidb_commit"`
-	ErrorReason                  string                 `json:"error_reason"`
-	PlanSignature                string                 `json:"plan_signature"`
-	PlanSigFormat                string                 `json:"plan_signature_format"`
-	Expected                     string                 `json:"expected"`
-	Actual                       string                 `json:"actual"`
-	Error                        string                 `json:"error"`
-	GroundTruthDSGMismatchReason string                 `json:"groundtruth_dsg_mismatch_reason"`
-	Flaky                        bool                   `json:"flaky"`
-	NoRECOptimizedSQL            string                 `json:"norec_optimized_sql"`
-	NoRECUnoptimizedSQL          string                 `json:"norec_unoptimized_sql"`
-	NoRECPredicate               string                 `json:"norec_predicate"`
-	CaseID                       string                 `json:"case_id"`
-	CaseDir                      string                 `json:"case_dir"`
-	ArchiveName                  string                 `json:"archive_name"`
-	ArchiveCodec                 string                 `json:"archive_codec"`
-	ArchiveURL                   string                 `json:"archive_url"`
-	ReportURL                    string                 `json:"report_url"`
-	SQL                          []string               `json:"sql"`
-	PlanReplay                   string                 `json:"plan_replayer"`
-	UploadLocation               string                 `json:"upload_location"`
-	RunInfo                      *runinfo.BasicInfo     `json:"run_info,omitempty"`
-	Details                      map[string]any         `json:"details"`
-	Files                        map[string]FileContent `json:"files"`
+	ID                           string                       `json:"id"`
+	Dir                          string                       `json:"dir"`
+	Oracle                       string                       `json:"oracle"`
+	Title                        string                       `json:"title"`
+	Timestamp                    string                       `json:"timestamp"`
+	TiDBVersion                  string                       `json:"tidb_version"`
+	TiDBCommit                   string                       `json:"tidb_commit"`
+	Topology                     string                       `json:"topology,omitempty"`
+	ErrorReason                  string                       `json:"error_reason"`
+	PlanSignature                string                       `json:"plan_signature"`
+	PlanSigFormat                string                       `json:"plan_signature_format"`
+	Expected                     string                       `json:"expected"`
+	Actual                       string                       `json:"actual"`
+	Error                        string                       `json:"error"`
+	GroundTruthDSGMismatchReason string                       `json:"groundtruth_dsg_mismatch_reason"`
+	Flaky                        bool                         `json:"flaky"`
+	NoRECOptimizedSQL            string                       `json:"norec_optimized_sql"`
+	NoRECUnoptimizedSQL          string                       `json:"norec_unoptimized_sql"`
+	NoRECPredicate               string                       `json:"norec_predicate"`
+	CaseID                       string                       `json:"case_id"`
+	CaseDir                      string                       `json:"case_dir"`
+	ArchiveName                  string                       `json:"archive_name"`
+	ArchiveCodec                 string                       `json:"archive_codec"`
+	ArchiveURL                   string                       `json:"archive_url"`
+	ReportURL                    string                       `json:"report_url"`
+	SQL                          []string                     `json:"sql"`
+	PlanReplay                   string                       `json:"plan_replayer"`
+	UploadLocation               string                       `json:"upload_location"`
+	RunInfo                      *runinfo.BasicInfo           `json:"run_info,omitempty"`
+	Details                      map[string]any               `json:"details"`
+	TypedDetails                 *report.CaseDetails          `json:"typed_details,omitempty"`
+	Files                        map[string]FileContent       `json:"files"`
+	OracleApplicability          []report.OracleApplicability `json:"oracle_applicability,omitempty"`
 }
 
 // SiteData is the JSON payload for the static site.
@@ -90,42 +87,66 @@ type SiteIndexData struct {
 
 // CaseIndexEntry contains summary metadata and a detail URL for a case.
 type CaseIndexEntry struct {
-	ID                           string `json:"id"`
-	Dir                          string `json:"dir"`
-	Oracle                       string `json:"oracle"`
-	Timestamp                    string `json:"timestamp"`
-	TiDBVersion                  string `json:"tidb_version"`
-	TiDBCommit                   string `json:"tidb_commit"`
-	ErrorReason                  string `json:"error_reason"`
-	PlanSignature                string `json:"plan_signature"`
-	PlanSigFormat                string `json:"plan_signature_format"`
-	Expected                     string `json:"expected"`
-	Actual                       string `json:"actual"`
-	Error                        string `json:"error"`
-	GroundTruthDSGMismatchReason string `json:"groundtruth_dsg_mismatch_reason"`
-	Flaky                        bool   `json:"flaky"`
-	NoRECPredicate               string `json:"norec_predicate"`
-	CaseID                       string `json:"case_id"`
-	CaseDir                      string `json:"case_dir"`
-	ArchiveName                  string `json:"archive_name"`
-	ArchiveCodec                 string `json:"archive_codec"`
-	ArchiveURL                   string `json:"archive_url"`
-	ReportURL                    string `json:"report_url"`
-	UploadLocation               string `json:"upload_location"`
-	SummaryURL                   string `json:"summary_url"`
-	SearchBlob                   string `json:"search_blob"`
-	DetailLoaded                 bool   `json:"detail_loaded"`
+	ID                           string                       `json:"id"`
+	Dir                          string                       `json:"dir"`
+	Oracle                       string                       `json:"oracle"`
+	Title                        string                       `json:"title"`
+	Timestamp                    string                       `json:"timestamp"`
+	TiDBVersion                  string                       `json:"tidb_version"`
+	TiDBCommit                   string                       `json:"tidb_commit"`
+	Topology                     string                       `json:"topology,omitempty"`
+	ErrorReason                  string                       `json:"error_reason"`
+	PlanSignature                string                       `json:"plan_signature"`
+	PlanSigFormat                string                       `json:"plan_signature_format"`
+	Expected                     string                       `json:"expected"`
+	Actual                       string                       `json:"actual"`
+	Error                        string                       `json:"error"`
+	GroundTruthDSGMismatchReason string                       `json:"groundtruth_dsg_mismatch_reason"`
+	Flaky                        bool                         `json:"flaky"`
+	NoRECPredicate               string                       `json:"norec_predicate"`
+	CaseID                       string                       `json:"case_id"`
+	CaseDir                      string                       `json:"case_dir"`
+	ArchiveName                  string                       `json:"archive_name"`
+	ArchiveCodec                 string                       `json:"archive_codec"`
+	ArchiveURL                   string                       `json:"archive_url"`
+	ReportURL                    string                       `json:"report_url"`
+	UploadLocation               string                       `json:"upload_location"`
+	SummaryURL                   string                       `json:"summary_url"`
+	SearchBlob                   string                       `json:"search_blob"`
+	DetailLoaded                 bool                         `json:"detail_loaded"`
+	OracleApplicability          []report.OracleApplicability `json:"oracle_applicability,omitempty"`
 }
 
 type loadOptions struct {
 	MaxBytes              int
 	MaxZipBytes           int
 	ArtifactPublicBaseURL string
+	// SignArtifactURL returns a pre-signed URL for an object under an upload
+	// location. It is only set when -artifact-url-ttl enables signing.
+	SignArtifactURL func(uploadLocation, name string) string
+	// Workers is the number of cases read from an object store at once.
+	Workers int
+	// RequestTimeout bounds each object store read; 0 leaves reads unbounded.
+	RequestTimeout time.Duration
+	// Progress receives periodic load progress lines and skipped cases when
+	// set.
+	Progress io.Writer
+}
+
+// skipCase reports a case whose summary cannot be read or parsed, so a
+// truncated summary.json does not vanish from the report without a trace.
+func (o loadOptions) skipCase(dir string, err error) {
+	if o.Progress == nil {
+		return
+	}
+	fmt.Fprintf(o.Progress, "skip case dir=%s err=%v\n", dir, err)
 }
 
 type publishOptions struct {
-	S3            config.S3Config
-	GCS           config.GCSConfig
+	S3  config.S3Config
+	GCS config.GCSConfig
+	// Dir publishes into a local directory store when no bucket is set.
+	Dir           string
 	PublicBaseURL string
 }
 
@@ -150,13 +171,38 @@ type workerSyncCase struct {
 	UploadLocation string `json:"upload_location"`
 	ReportURL      string `json:"report_url"`
 	ArchiveURL     string `json:"archive_url"`
+	// Fingerprint, RunID, TiDBVersion, TiDBCommit, and Flaky let the worker
+	// aggregate repeated observations of one bug across runs.
+	Fingerprint string `json:"fingerprint"`
+	RunID       string `json:"run_id"`
+	TiDBVersion string `json:"tidb_version"`
+	TiDBCommit  string `json:"tidb_commit"`
+	Flaky       bool   `json:"flaky"`
 }
 
 const reportIndexVersion = 1
 
 func main() {
-	input := flag.String("input", ".report", "input directory, gs://bucket/prefix, or legacy s3://bucket/prefix")
-	output := flag.String("output", "web/public", "output directory for report.json/reports.json")
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		if err := runDiff(os.Args[2:], os.Stdout); err != nil {
+			fail("diff: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:], os.Stdout); err != nil {
+			fail("migrate: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "verify" {
+		if err := runVerify(os.Args[2:], os.Stdout); err != nil {
+			fail("verify: %v", err)
+		}
+		return
+	}
+	input := flag.String("input", ".report", "input directory, gs://bucket/prefix, legacy s3://bucket/prefix, or file:///dir of a local storage remote")
+	output := flag.String("output", "web/public", "output directory for report.json/reports.json/trends.json")
 	configPath := flag.String("config", "config.yaml", "path to config file (for GCS/S3 access)")
 	maxBytes := flag.Int("max-bytes", 64*1024, "max bytes to read per case file")
 	maxZipBytes := flag.Int("max-zip-bytes", 20*1024*1024, "max bytes to read for plan_replayer.zip")
@@ -164,54 +210,75 @@ func main() {
 	publishRegion := flag.String("publish-region", "auto", "region for publish endpoint")
 	publishBucket := flag.String("publish-bucket", "", "target bucket for publishing report manifests")
 	publishPrefix := flag.String("publish-prefix", "", "target prefix for publishing report manifests")
-	publishAccessKey := flag.String("publish-access-key-id", "", "access key for publishing report manifests")
-	publishSecret := flag.String("publish-secret-access-key", "", "secret key for publishing report manifests")
-	publishSessionToken := flag.String("publish-session-token", "", "session token for publishing report manifests")
+	publishAccessKey := flag.String("publish-access-key-id", "", "access key for publishing report manifests (or a vault:, aws-sm:, or gcp-sm: secret reference)")
+	publishSecret := flag.String("publish-secret-access-key", "", "secret key for publishing report manifests (or a vault:, aws-sm:, or gcp-sm: secret reference)")
+	publishSessionToken := flag.String("publish-session-token", "", "session token for publishing report manifests (or a vault:, aws-sm:, or gcp-sm: secret reference)")
 	publishUsePathStyle := flag.Bool("publish-use-path-style", true, "whether to use path-style S3 addressing for publish endpoint")
 	publishPublicBaseURL := flag.String("publish-public-base-url", "", "public base URL for published manifests (S3/GCS)")
 	publishGCSBucket := flag.String("publish-gcs-bucket", "", "target GCS bucket for publishing report manifests")
 	publishGCSPrefix := flag.String("publish-gcs-prefix", "", "target prefix for publishing report manifests")
 	publishGCSCredentialsFile := flag.String("publish-gcs-credentials-file", "", "service account JSON for GCS publish (optional, uses ADC when empty)")
+	publishDir := flag.String("publish-dir", "", "local directory for publishing report manifests when no bucket is set (for tests and air-gapped setups)")
 	artifactPublicBaseURL := flag.String("artifact-public-base-url", "", "public HTTP(S) base URL used to derive per-case report/archive links from gs:// or s3:// upload locations")
+	loadWorkers := flag.Int("load-workers", 16, "number of cases read from GCS/S3 at once")
+	loadTimeout := flag.Duration("load-timeout", 30*time.Second, "timeout for each GCS/S3 object read while loading cases (0 disables)")
+	artifactURLTTL := flag.Duration("artifact-url-ttl", 0, "when -artifact-public-base-url is empty, pre-sign per-case report/archive links for private gs:// or s3:// buckets with this lifetime (0 disables, max 168h)")
 	workerSyncEndpoint := flag.String("worker-sync-endpoint", "", "cloudflare worker sync endpoint for D1 metadata upsert")
-	workerSyncToken := flag.String("worker-sync-token", "", "bearer token used for worker sync endpoint")
+	workerSyncToken := flag.String("worker-sync-token", "", "bearer token used for worker sync endpoint (or a vault:, aws-sm:, or gcp-sm: secret reference)")
+	siteConfigPath := flag.String("site-config", "", "YAML or JSON file with site title, locale, labels, hidden fields, and case links")
+	var siteLinks siteLinkFlags
+	flag.Var(&siteLinks, "site-case-link", "per-case link as label=url-template, for example 'Jira=https://jira.example.com/search?q={case_id}' (repeatable)")
 	flag.Parse()
 
+	siteCfg, err := loadSiteConfig(strings.TrimSpace(*siteConfigPath), siteLinks)
+	if err != nil {
+		fail("load site config: %v", err)
+	}
+
 	opts := loadOptions{
 		MaxBytes:              *maxBytes,
 		MaxZipBytes:           *maxZipBytes,
 		ArtifactPublicBaseURL: strings.TrimSpace(*artifactPublicBaseURL),
+		Workers:               *loadWorkers,
+		RequestTimeout:        *loadTimeout,
+		Progress:              os.Stderr,
 	}
 	ctx := context.Background()
-
-	var cases []CaseEntry
-	var err error
-	if strings.HasPrefix(*input, "gs://") {
-		cfg, loadErr := config.Load(*configPath)
+	if err := resolveSecretFlags(ctx, publishAccessKey, publishSecret, publishSessionToken, workerSyncToken); err != nil {
+		fail("%v", err)
+	}
+	if err := validateArtifactURLTTL(*artifactURLTTL); err != nil {
+		fail("%v", err)
+	}
+	if opts.ArtifactPublicBaseURL == "" && *artifactURLTTL > 0 {
+		cfg, loadErr := loadConfig(ctx, *configPath)
 		if loadErr != nil {
 			fail("load config: %v", loadErr)
 		}
-		bucket, prefix, parseErr := parseGCSURI(*input)
-		if parseErr != nil {
-			fail("parse gcs input: %v", parseErr)
-		}
-		if !cfg.Storage.GCS.Enabled {
-			fail("gcs input requested but storage.gcs.enabled is false")
+		signer, signErr := newArtifactSigner(ctx, cfg.Storage, *artifactURLTTL)
+		if signErr != nil {
+			fail("artifact url signer: %v", signErr)
 		}
-		cases, err = loadGCSCases(ctx, cfg.Storage.GCS, bucket, prefix, opts)
-	} else if strings.HasPrefix(*input, "s3://") {
-		cfg, loadErr := config.Load(*configPath)
-		if loadErr != nil {
-			fail("load config: %v", loadErr)
-		}
-		bucket, prefix, parseErr := parseS3URI(*input)
-		if parseErr != nil {
-			fail("parse s3 input: %v", parseErr)
+		defer signer.Close()
+		opts.SignArtifactURL = signer.signFunc(ctx)
+	}
+
+	var cases []CaseEntry
+	if isStoreURL(*input) {
+		var storageCfg config.StorageConfig
+		if !isFileURL(*input) {
+			cfg, loadErr := loadConfig(ctx, *configPath)
+			if loadErr != nil {
+				fail("load config: %v", loadErr)
+			}
+			storageCfg = cfg.Storage
 		}
-		if !cfg.Storage.S3.Enabled {
-			fail("s3 input requested but storage.s3.enabled is false")
+		store, prefix, openErr := uploader.OpenLocation(ctx, *input, storageCfg)
+		if openErr != nil {
+			fail("open input: %v", openErr)
 		}
-		cases, err = loadS3Cases(ctx, cfg.Storage.S3, bucket, prefix, opts)
+		cases, err = loadStoreCases(ctx, store, prefix, opts)
+		util.CloseWithErr(store, "input store")
 	} else {
 		cases, err = loadLocalCases(*input, opts)
 	}
@@ -231,6 +298,15 @@ func main() {
 	if err := writeJSON(*output, site); err != nil {
 		fail("write json: %v", err)
 	}
+	if err := writeSiteConfig(*output, siteCfg); err != nil {
+		fail("write site config: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(*output, report.DetailsSchemaFile), report.DetailsSchema(), 0o644); err != nil {
+		fail("write details schema: %v", err)
+	}
+	if err := writeTrends(*output, site); err != nil {
+		fail("write trends: %v", err)
+	}
 
 	publishCfg := publishOptions{
 		S3: config.S3Config{
@@ -250,6 +326,7 @@ func main() {
 			Prefix:          strings.TrimSpace(*publishGCSPrefix),
 			CredentialsFile: strings.TrimSpace(*publishGCSCredentialsFile),
 		},
+		Dir:           strings.TrimSpace(*publishDir),
 		PublicBaseURL: strings.TrimSpace(*publishPublicBaseURL),
 	}
 	manifestURL, err := publishReports(ctx, publishCfg, *output)
@@ -300,6 +377,7 @@ func loadLocalCases(root string, opts loadOptions) ([]CaseEntry, error) {
 		}
 		entry, err := readCaseFromDir(dir, opts)
 		if err != nil {
+			opts.skipCase(dir, err)
 			continue
 		}
 		entry.Dir = dir
@@ -317,8 +395,8 @@ func readCaseFromDir(dir string, opts loadOptions) (CaseEntry, error) {
 	if err != nil {
 		return CaseEntry{}, err
 	}
-	var summary report.Summary
-	if err := json.Unmarshal(data, &summary); err != nil {
+	summary, err := report.DecodeSummary(data, dir)
+	if err != nil {
 		return CaseEntry{}, err
 	}
 	files := map[string]FileContent{}
@@ -327,6 +405,9 @@ func readCaseFromDir(dir string, opts loadOptions) (CaseEntry, error) {
 	files["inserts.sql"] = mustReadFile(filepath.Join(dir, "inserts.sql"), opts.MaxBytes)
 	files["data.tsv"] = mustReadFile(filepath.Join(dir, "data.tsv"), opts.MaxBytes)
 	files["report.json"] = mustReadFile(filepath.Join(dir, "report.json"), opts.MaxBytes)
+	if summary.PlansFile != "" {
+		files[report.PlansFile] = mustReadFile(filepath.Join(dir, report.PlansFile), opts.MaxBytes)
+	}
 	if _, err := os.Stat(filepath.Join(dir, "plan_replayer.zip")); err == nil {
 		files["plan_replayer.zip"] = FileContent{Name: "plan_replayer.zip", Content: "(binary)", Truncated: true}
 	}
@@ -337,15 +418,23 @@ func readCaseFromDir(dir string, opts loadOptions) (CaseEntry, error) {
 	if commit == "" {
 		commit = extractCommitFromPlanReplayer(filepath.Join(dir, "plan_replayer.zip"), opts.MaxZipBytes)
 	}
-	caseID := caseIDFromSummary(summary, filepath.Base(dir))
+	return caseEntryFromSummary(summary, filepath.Base(dir), commit, files, opts), nil
+}
+
+// caseEntryFromSummary builds the report entry of a case from its summary and
+// the inlined files, whichever storage the case was read from.
+func caseEntryFromSummary(summary report.Summary, fallbackID, commit string, files map[string]FileContent, opts loadOptions) CaseEntry {
+	caseID := caseIDFromSummary(summary, fallbackID)
 	caseDir := caseDirFromSummary(summary, caseID)
-	reportURL, archiveURL := deriveObjectURLs(summary.UploadLocation, summary.ArchiveName, opts.ArtifactPublicBaseURL)
+	reportURL, archiveURL := opts.objectURLs(summary.UploadLocation, summary.ArchiveName)
 	return CaseEntry{
 		ID:                           caseID,
 		Oracle:                       summary.Oracle,
+		Title:                        summaryTitle(summary),
 		Timestamp:                    summary.Timestamp,
 		TiDBVersion:                  summary.TiDBVersion,
 		TiDBCommit:                   commit,
+		Topology:                     summary.Topology.String(),
 		ErrorReason:                  summaryErrorReason(summary),
 		PlanSignature:                summary.PlanSignature,
 		PlanSigFormat:                summary.PlanSigFormat,
@@ -354,8 +443,8 @@ func readCaseFromDir(dir string, opts loadOptions) (CaseEntry, error) {
 		Error:                        summary.Error,
 		GroundTruthDSGMismatchReason: summary.GroundTruthDSGMismatchReason,
 		Flaky:                        summary.Flaky,
-		NoRECOptimizedSQL:            summary.NoRECOptimizedSQL,
-		NoRECUnoptimizedSQL:          summary.NoRECUnoptimizedSQL,
+		NoRECOptimizedSQL:            sqlfmt.Format(summary.NoRECOptimizedSQL),
+		NoRECUnoptimizedSQL:          sqlfmt.Format(summary.NoRECUnoptimizedSQL),
 		NoRECPredicate:               summary.NoRECPredicate,
 		CaseID:                       caseID,
 		CaseDir:                      caseDir,
@@ -363,13 +452,15 @@ func readCaseFromDir(dir string, opts loadOptions) (CaseEntry, error) {
 		ArchiveCodec:                 summary.ArchiveCodec,
 		ArchiveURL:                   archiveURL,
 		ReportURL:                    reportURL,
-		SQL:                          summary.SQL,
+		SQL:                          sqlfmt.Statements(summary.SQL),
 		PlanReplay:                   summary.PlanReplay,
 		UploadLocation:               summary.UploadLocation,
 		RunInfo:                      summary.RunInfo,
 		Details:                      summary.Details,
+		TypedDetails:                 caseTypedDetails(summary),
+		OracleApplicability:          summary.OracleApplicability,
 		Files:                        files,
-	}, nil
+	}
 }
 
 func mustReadFile(path string, maxBytes int) FileContent {
@@ -469,9 +560,11 @@ func buildSiteIndex(site SiteData) SiteIndexData {
 			ID:                           c.ID,
 			Dir:                          c.Dir,
 			Oracle:                       c.Oracle,
+			Title:                        c.Title,
 			Timestamp:                    c.Timestamp,
 			TiDBVersion:                  c.TiDBVersion,
 			TiDBCommit:                   c.TiDBCommit,
+			Topology:                     c.Topology,
 			ErrorReason:                  c.ErrorReason,
 			PlanSignature:                c.PlanSignature,
 			PlanSigFormat:                c.PlanSigFormat,
@@ -491,6 +584,7 @@ func buildSiteIndex(site SiteData) SiteIndexData {
 			SummaryURL:                   summaryURL,
 			SearchBlob:                   buildSearchBlob(c),
 			DetailLoaded:                 detailLoaded,
+			OracleApplicability:          c.OracleApplicability,
 		})
 	}
 	return SiteIndexData{
@@ -526,6 +620,7 @@ func casePathComponent(caseID string) string {
 func buildSearchBlob(c CaseEntry) string {
 	parts := []string{
 		c.Oracle,
+		c.Title,
 		c.ErrorReason,
 		c.Error,
 		c.Expected,
@@ -536,6 +631,7 @@ func buildSearchBlob(c CaseEntry) string {
 		c.NoRECPredicate,
 		c.TiDBVersion,
 		c.TiDBCommit,
+		c.Topology,
 		c.PlanSignature,
 		c.PlanSigFormat,
 		c.CaseID,
@@ -605,367 +701,41 @@ func parseGCSURI(input string) (bucket string, prefix string, err error) {
 	return bucket, prefix, nil
 }
 
-func loadS3Cases(ctx context.Context, cfg config.S3Config, bucket, prefix string, opts loadOptions) ([]CaseEntry, error) {
-	client, err := s3ClientFromConfig(ctx, cfg)
-	if err != nil {
-		return nil, err
-	}
-	keys, objectSet, err := listSummaryKeys(ctx, client, bucket, prefix)
-	if err != nil {
-		return nil, err
-	}
-	cases := make([]CaseEntry, 0, len(keys))
-	for _, key := range keys {
-		dir := strings.TrimSuffix(key, "/summary.json")
-		entry, err := readCaseFromS3(ctx, client, bucket, dir, opts, objectSet)
-		if err != nil {
-			continue
-		}
-		entry.Dir = "s3://" + bucket + "/" + dir
-		if strings.TrimSpace(entry.ID) == "" {
-			entry.ID = filepath.Base(dir)
-		}
-		cases = append(cases, entry)
-	}
-	return cases, nil
-}
-
-func listSummaryKeys(ctx context.Context, client *s3.Client, bucket, prefix string) ([]string, map[string]struct{}, error) {
-	var keys []string
-	objectSet := make(map[string]struct{})
-	paginator := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{
-		Bucket: aws.String(bucket),
-		Prefix: aws.String(prefix),
-	})
-	for paginator.HasMorePages() {
-		page, err := paginator.NextPage(ctx)
-		if err != nil {
-			return nil, nil, err
-		}
-		for _, obj := range page.Contents {
-			key := aws.ToString(obj.Key)
-			objectSet[key] = struct{}{}
-			if strings.HasSuffix(key, "/summary.json") {
-				keys = append(keys, key)
-			}
-		}
-	}
-	return keys, objectSet, nil
-}
-
-func readCaseFromS3(ctx context.Context, client *s3.Client, bucket, dir string, opts loadOptions, objectSet map[string]struct{}) (CaseEntry, error) {
-	summaryKey := dir + "/summary.json"
-	summaryData, _, err := readObjectLimited(ctx, client, bucket, summaryKey, opts.MaxBytes)
+func readCaseFromStore(ctx context.Context, store uploader.ObjectStore, dir string, opts loadOptions, objectSet map[string]struct{}) (CaseEntry, error) {
+	summaryData, _, err := opts.storeGet(ctx, store, dir+"/summary.json", opts.MaxBytes)
 	if err != nil {
 		return CaseEntry{}, err
 	}
-	var summary report.Summary
-	if err := json.Unmarshal([]byte(summaryData), &summary); err != nil {
+	summary, err := report.DecodeSummary(summaryData, dir)
+	if err != nil {
 		return CaseEntry{}, err
 	}
 	files := map[string]FileContent{}
-	files["case.sql"] = readObjectFile(ctx, client, bucket, dir+"/case.sql", opts.MaxBytes)
-	files["schema.sql"] = readObjectFile(ctx, client, bucket, dir+"/schema.sql", opts.MaxBytes)
-	files["inserts.sql"] = readObjectFile(ctx, client, bucket, dir+"/inserts.sql", opts.MaxBytes)
-	files["data.tsv"] = readObjectFile(ctx, client, bucket, dir+"/data.tsv", opts.MaxBytes)
-	files["report.json"] = readObjectFile(ctx, client, bucket, dir+"/report.json", opts.MaxBytes)
-	if _, ok := objectSet[dir+"/plan_replayer.zip"]; ok {
-		files["plan_replayer.zip"] = FileContent{Name: "plan_replayer.zip", Content: "(binary)", Truncated: true}
-	}
-	archiveKey := dir + "/" + report.CaseArchiveName
-	if _, ok := objectSet[archiveKey]; ok {
-		files[report.CaseArchiveName] = FileContent{Name: report.CaseArchiveName, Content: "(binary)", Truncated: true}
-	}
-	commit := extractCommit(summary.TiDBVersion)
-	if commit == "" {
-		commit = extractCommitFromPlanReplayerS3(ctx, client, bucket, dir+"/plan_replayer.zip", opts.MaxZipBytes)
-	}
-	caseID := caseIDFromSummary(summary, filepath.Base(dir))
-	caseDir := caseDirFromSummary(summary, caseID)
-	reportURL, archiveURL := deriveObjectURLs(summary.UploadLocation, summary.ArchiveName, opts.ArtifactPublicBaseURL)
-	return CaseEntry{
-		ID:                           caseID,
-		Oracle:                       summary.Oracle,
-		Timestamp:                    summary.Timestamp,
-		TiDBVersion:                  summary.TiDBVersion,
-		TiDBCommit:                   commit,
-		ErrorReason:                  summaryErrorReason(summary),
-		PlanSignature:                summary.PlanSignature,
-		PlanSigFormat:                summary.PlanSigFormat,
-		Expected:                     summary.Expected,
-		Actual:                       summary.Actual,
-		Error:                        summary.Error,
-		GroundTruthDSGMismatchReason: summary.GroundTruthDSGMismatchReason,
-		Flaky:                        summary.Flaky,
-		NoRECOptimizedSQL:            summary.NoRECOptimizedSQL,
-		NoRECUnoptimizedSQL:          summary.NoRECUnoptimizedSQL,
-		NoRECPredicate:               summary.NoRECPredicate,
-		CaseID:                       caseID,
-		CaseDir:                      caseDir,
-		ArchiveName:                  summary.ArchiveName,
-		ArchiveCodec:                 summary.ArchiveCodec,
-		ArchiveURL:                   archiveURL,
-		ReportURL:                    reportURL,
-		SQL:                          summary.SQL,
-		PlanReplay:                   summary.PlanReplay,
-		UploadLocation:               summary.UploadLocation,
-		RunInfo:                      summary.RunInfo,
-		Details:                      summary.Details,
-		Files:                        files,
-	}, nil
-}
-
-func readObjectFile(ctx context.Context, client *s3.Client, bucket, key string, maxBytes int) FileContent {
-	content, truncated, err := readObjectLimited(ctx, client, bucket, key, maxBytes)
-	if err != nil {
-		return FileContent{Name: filepath.Base(key)}
-	}
-	return FileContent{Name: filepath.Base(key), Content: content, Truncated: truncated}
-}
-
-func readObjectBytesLimited(ctx context.Context, client *s3.Client, bucket, key string, maxBytes int) ([]byte, bool, error) {
-	resp, err := client.GetObject(ctx, &s3.GetObjectInput{
-		Bucket: aws.String(bucket),
-		Key:    aws.String(key),
-	})
-	if err != nil {
-		return nil, false, err
-	}
-	defer util.CloseWithErr(resp.Body, "s3 response body")
-	limit := int64(maxBytes) + 1
-	data, err := io.ReadAll(io.LimitReader(resp.Body, limit))
-	if err != nil {
-		return nil, false, err
-	}
-	truncated := len(data) > maxBytes
-	if truncated {
-		data = data[:maxBytes]
-	}
-	return data, truncated, nil
-}
-
-func readObjectLimited(ctx context.Context, client *s3.Client, bucket, key string, maxBytes int) (string, bool, error) {
-	resp, err := client.GetObject(ctx, &s3.GetObjectInput{
-		Bucket: aws.String(bucket),
-		Key:    aws.String(key),
-	})
-	if err != nil {
-		var nsk *types.NoSuchKey
-		if strings.Contains(err.Error(), "NotFound") || strings.Contains(err.Error(), "NoSuchKey") || errors.As(err, &nsk) {
-			return "", false, fmt.Errorf("missing object %s", key)
-		}
-		return "", false, err
+	for _, name := range []string{"case.sql", "schema.sql", "inserts.sql", "data.tsv", "report.json"} {
+		files[name] = readStoreFile(ctx, store, dir+"/"+name, opts)
 	}
-	defer util.CloseWithErr(resp.Body, "s3 response body")
-	limit := int64(maxBytes) + 1
-	data, err := io.ReadAll(io.LimitReader(resp.Body, limit))
-	if err != nil {
-		return "", false, err
+	if summary.PlansFile != "" {
+		files[report.PlansFile] = readStoreFile(ctx, store, dir+"/"+report.PlansFile, opts)
 	}
-	truncated := len(data) > maxBytes
-	if truncated {
-		data = data[:maxBytes]
-	}
-	return string(data), truncated, nil
-}
-
-func loadGCSCases(ctx context.Context, cfg config.GCSConfig, bucket, prefix string, opts loadOptions) ([]CaseEntry, error) {
-	client, err := gcsClientFromConfig(ctx, cfg)
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		if err := client.Close(); err != nil {
-			util.Warnf("gcs client close failed: %v", err)
-		}
-	}()
-	keys, objectSet, err := listGCSKeys(ctx, client, bucket, prefix)
-	if err != nil {
-		return nil, err
-	}
-	cases := make([]CaseEntry, 0, len(keys))
-	for _, key := range keys {
-		dir := strings.TrimSuffix(key, "/summary.json")
-		entry, err := readCaseFromGCS(ctx, client, bucket, dir, opts, objectSet)
-		if err != nil {
-			continue
-		}
-		entry.Dir = "gs://" + bucket + "/" + dir
-		if strings.TrimSpace(entry.ID) == "" {
-			entry.ID = filepath.Base(dir)
-		}
-		cases = append(cases, entry)
-	}
-	return cases, nil
-}
-
-func listGCSKeys(ctx context.Context, client *storage.Client, bucket, prefix string) ([]string, map[string]struct{}, error) {
-	var keys []string
-	objectSet := make(map[string]struct{})
-	it := client.Bucket(bucket).Objects(ctx, &storage.Query{Prefix: prefix})
-	for {
-		attrs, err := it.Next()
-		if errors.Is(err, iterator.Done) {
-			break
-		}
-		if err != nil {
-			return nil, nil, err
-		}
-		key := attrs.Name
-		objectSet[key] = struct{}{}
-		if strings.HasSuffix(key, "/summary.json") {
-			keys = append(keys, key)
-		}
-	}
-	return keys, objectSet, nil
-}
-
-func readCaseFromGCS(ctx context.Context, client *storage.Client, bucket, dir string, opts loadOptions, objectSet map[string]struct{}) (CaseEntry, error) {
-	summaryKey := dir + "/summary.json"
-	summaryData, _, err := readGCSObjectLimited(ctx, client, bucket, summaryKey, opts.MaxBytes)
-	if err != nil {
-		return CaseEntry{}, err
-	}
-	var summary report.Summary
-	if err := json.Unmarshal([]byte(summaryData), &summary); err != nil {
-		return CaseEntry{}, err
-	}
-	files := map[string]FileContent{}
-	files["case.sql"] = readGCSObjectFile(ctx, client, bucket, dir+"/case.sql", opts.MaxBytes)
-	files["schema.sql"] = readGCSObjectFile(ctx, client, bucket, dir+"/schema.sql", opts.MaxBytes)
-	files["inserts.sql"] = readGCSObjectFile(ctx, client, bucket, dir+"/inserts.sql", opts.MaxBytes)
-	files["data.tsv"] = readGCSObjectFile(ctx, client, bucket, dir+"/data.tsv", opts.MaxBytes)
-	files["report.json"] = readGCSObjectFile(ctx, client, bucket, dir+"/report.json", opts.MaxBytes)
 	if _, ok := objectSet[dir+"/plan_replayer.zip"]; ok {
 		files["plan_replayer.zip"] = FileContent{Name: "plan_replayer.zip", Content: "(binary)", Truncated: true}
 	}
-	archiveKey := dir + "/" + report.CaseArchiveName
-	if _, ok := objectSet[archiveKey]; ok {
+	if _, ok := objectSet[dir+"/"+report.CaseArchiveName]; ok {
 		files[report.CaseArchiveName] = FileContent{Name: report.CaseArchiveName, Content: "(binary)", Truncated: true}
 	}
 	commit := extractCommit(summary.TiDBVersion)
 	if commit == "" {
-		commit = extractCommitFromPlanReplayerGCS(ctx, client, bucket, dir+"/plan_replayer.zip", opts.MaxZipBytes)
-	}
-	caseID := caseIDFromSummary(summary, filepath.Base(dir))
-	caseDir := caseDirFromSummary(summary, caseID)
-	reportURL, archiveURL := deriveObjectURLs(summary.UploadLocation, summary.ArchiveName, opts.ArtifactPublicBaseURL)
-	return CaseEntry{
-		ID:                           caseID,
-		Oracle:                       summary.Oracle,
-		Timestamp:                    summary.Timestamp,
-		TiDBVersion:                  summary.TiDBVersion,
-		TiDBCommit:                   commit,
-		ErrorReason:                  summaryErrorReason(summary),
-		PlanSignature:                summary.PlanSignature,
-		PlanSigFormat:                summary.PlanSigFormat,
-		Expected:                     summary.Expected,
-		Actual:                       summary.Actual,
-		Error:                        summary.Error,
-		GroundTruthDSGMismatchReason: summary.GroundTruthDSGMismatchReason,
-		Flaky:                        summary.Flaky,
-		NoRECOptimizedSQL:            summary.NoRECOptimizedSQL,
-		NoRECUnoptimizedSQL:          summary.NoRECUnoptimizedSQL,
-		NoRECPredicate:               summary.NoRECPredicate,
-		CaseID:                       caseID,
-		CaseDir:                      caseDir,
-		ArchiveName:                  summary.ArchiveName,
-		ArchiveCodec:                 summary.ArchiveCodec,
-		ArchiveURL:                   archiveURL,
-		ReportURL:                    reportURL,
-		SQL:                          summary.SQL,
-		PlanReplay:                   summary.PlanReplay,
-		UploadLocation:               summary.UploadLocation,
-		Details:                      summary.Details,
-		Files:                        files,
-	}, nil
-}
-
-func readGCSObjectFile(ctx context.Context, client *storage.Client, bucket, key string, maxBytes int) FileContent {
-	content, truncated, err := readGCSObjectLimited(ctx, client, bucket, key, maxBytes)
-	if err != nil {
-		return FileContent{Name: filepath.Base(key)}
+		commit = extractCommitFromPlanReplayerStore(ctx, store, dir+"/plan_replayer.zip", opts)
 	}
-	return FileContent{Name: filepath.Base(key), Content: content, Truncated: truncated}
+	return caseEntryFromSummary(summary, path.Base(dir), commit, files, opts), nil
 }
 
-func readGCSObjectBytesLimited(ctx context.Context, client *storage.Client, bucket, key string, maxBytes int) ([]byte, bool, error) {
-	rc, err := client.Bucket(bucket).Object(key).NewReader(ctx)
+func readStoreFile(ctx context.Context, store uploader.ObjectStore, key string, opts loadOptions) FileContent {
+	data, truncated, err := opts.storeGet(ctx, store, key, opts.MaxBytes)
 	if err != nil {
-		return nil, false, err
+		return FileContent{Name: path.Base(key)}
 	}
-	defer util.CloseWithErr(rc, "gcs response body")
-	limit := int64(maxBytes) + 1
-	data, err := io.ReadAll(io.LimitReader(rc, limit))
-	if err != nil {
-		return nil, false, err
-	}
-	truncated := len(data) > maxBytes
-	if truncated {
-		data = data[:maxBytes]
-	}
-	return data, truncated, nil
-}
-
-func readGCSObjectLimited(ctx context.Context, client *storage.Client, bucket, key string, maxBytes int) (string, bool, error) {
-	rc, err := client.Bucket(bucket).Object(key).NewReader(ctx)
-	if err != nil {
-		if errors.Is(err, storage.ErrObjectNotExist) {
-			return "", false, fmt.Errorf("missing object %s", key)
-		}
-		return "", false, err
-	}
-	defer util.CloseWithErr(rc, "gcs response body")
-	limit := int64(maxBytes) + 1
-	data, err := io.ReadAll(io.LimitReader(rc, limit))
-	if err != nil {
-		return "", false, err
-	}
-	truncated := len(data) > maxBytes
-	if truncated {
-		data = data[:maxBytes]
-	}
-	return string(data), truncated, nil
-}
-
-func gcsClientFromConfig(ctx context.Context, cfg config.GCSConfig) (*storage.Client, error) {
-	opts := []option.ClientOption{}
-	if strings.TrimSpace(cfg.CredentialsFile) != "" {
-		opts = append(opts, option.WithCredentialsFile(strings.TrimSpace(cfg.CredentialsFile)))
-	}
-	return storage.NewClient(ctx, opts...)
-}
-
-func s3ClientFromConfig(ctx context.Context, cfg config.S3Config) (*s3.Client, error) {
-	opts := []func(*awsconfig.LoadOptions) error{}
-	if cfg.Region != "" {
-		opts = append(opts, awsconfig.WithRegion(cfg.Region))
-	}
-	if cfg.Endpoint != "" {
-		//nolint:staticcheck // AWS SDK v2 global endpoint resolver is deprecated, but required for custom S3 endpoints.
-		resolver := aws.EndpointResolverWithOptionsFunc(func(service, _ string, _ ...any) (aws.Endpoint, error) {
-			if service == s3.ServiceID {
-				//nolint:staticcheck // AWS SDK v2 global endpoint resolver is deprecated, but required for custom S3 endpoints.
-				return aws.Endpoint{URL: cfg.Endpoint, HostnameImmutable: true}, nil
-			}
-			//nolint:staticcheck // AWS SDK v2 global endpoint resolver is deprecated, but required for custom S3 endpoints.
-			return aws.Endpoint{}, &aws.EndpointNotFoundError{}
-		})
-		//nolint:staticcheck // AWS SDK v2 global endpoint resolver is deprecated, but required for custom S3 endpoints.
-		opts = append(opts, awsconfig.WithEndpointResolverWithOptions(resolver))
-	}
-	if cfg.AccessKeyID != "" || cfg.SecretAccessKey != "" || cfg.SessionToken != "" {
-		creds := credentials.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.SecretAccessKey, cfg.SessionToken)
-		opts = append(opts, awsconfig.WithCredentialsProvider(creds))
-	}
-	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
-	if err != nil {
-		return nil, err
-	}
-	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
-		o.UsePathStyle = cfg.UsePathStyle
-	})
-	return client, nil
+	return FileContent{Name: path.Base(key), Content: string(data), Truncated: truncated}
 }
 
 func caseIDFromSummary(summary report.Summary, fallback string) string {
@@ -997,6 +767,22 @@ func deriveObjectURLs(uploadLocation, archiveName, artifactPublicBaseURL string)
 	return reportURL, archiveURL
 }
 
+// objectURLs derives the report/archive links of a case. Objects in buckets
+// without a public base URL fall back to pre-signed URLs when signing is on.
+func (o loadOptions) objectURLs(uploadLocation, archiveName string) (reportURL string, archiveURL string) {
+	reportURL, archiveURL = deriveObjectURLs(uploadLocation, archiveName, o.ArtifactPublicBaseURL)
+	if o.SignArtifactURL == nil || strings.TrimSpace(uploadLocation) == "" {
+		return reportURL, archiveURL
+	}
+	if reportURL == "" {
+		reportURL = o.SignArtifactURL(uploadLocation, "report.json")
+	}
+	if archiveURL == "" && strings.TrimSpace(archiveName) != "" {
+		archiveURL = o.SignArtifactURL(uploadLocation, strings.TrimSpace(archiveName))
+	}
+	return reportURL, archiveURL
+}
+
 func deriveUploadObjectURL(uploadLocation, name, artifactPublicBaseURL string) string {
 	trimmedName := strings.TrimSpace(name)
 	if trimmedName == "" {
@@ -1033,6 +819,17 @@ func isGCSURL(url string) bool {
 	return strings.HasPrefix(lower, "gs://")
 }
 
+func isFileURL(url string) bool {
+	lower := strings.ToLower(strings.TrimSpace(url))
+	return strings.HasPrefix(lower, "file://")
+}
+
+// isStoreURL reports whether an input is read through an object store rather
+// than as a plain report directory.
+func isStoreURL(url string) bool {
+	return isGCSURL(url) || isS3URL(url) || isFileURL(url)
+}
+
 func objectURL(base, name string) string {
 	trimmedBase := strings.TrimRight(strings.TrimSpace(base), "/")
 	trimmedName := strings.TrimLeft(strings.TrimSpace(name), "/")
@@ -1058,6 +855,26 @@ func deriveCloudObjectURL(uploadLocation, name, artifactPublicBaseURL string, pa
 	return objectURL(publicBase, key)
 }
 
+// summaryTitle returns the case title, or a coarse one for summaries written
+// before titles existed.
+func summaryTitle(summary report.Summary) string {
+	if title := strings.TrimSpace(summary.Title); title != "" {
+		return title
+	}
+	oracle := strings.TrimSpace(summary.Oracle)
+	if oracle == "" {
+		oracle = "case"
+	}
+	kind := "mismatch"
+	if strings.TrimSpace(summary.Error) != "" {
+		kind = "error"
+	}
+	if reason := summaryErrorReason(summary); reason != "" {
+		return fmt.Sprintf("%s %s (%s)", oracle, kind, reason)
+	}
+	return oracle + " " + kind
+}
+
 func summaryErrorReason(summary report.Summary) string {
 	if reason := strings.TrimSpace(summary.ErrorReason); reason != "" {
 		return reason
@@ -1076,6 +893,15 @@ func summaryErrorReason(summary report.Summary) string {
 	return strings.TrimSpace(reason)
 }
 
+// caseTypedDetails returns the typed details of a summary, deriving them from
+// the details map for cases written before typed_details existed.
+func caseTypedDetails(summary report.Summary) *report.CaseDetails {
+	if summary.TypedDetails != nil {
+		return summary.TypedDetails
+	}
+	return report.ParseCaseDetails(summary.Details)
+}
+
 func collectPublishFiles(output string) ([]string, error) {
 	files := []string{"report.json", "reports.json", "reports.index.json"}
 	seen := map[string]struct{}{
@@ -1083,6 +909,12 @@ func collectPublishFiles(output string) ([]string, error) {
 		"reports.json":       {},
 		"reports.index.json": {},
 	}
+	for _, name := range []string{siteConfigFile, report.DetailsSchemaFile, trendsFile} {
+		if _, err := os.Stat(filepath.Join(output, name)); err == nil {
+			files = append(files, name)
+			seen[name] = struct{}{}
+		}
+	}
 	summaryRoot := filepath.Join(output, "cases")
 	if _, err := os.Stat(summaryRoot); err != nil {
 		if os.IsNotExist(err) {
@@ -1117,53 +949,35 @@ func collectPublishFiles(output string) ([]string, error) {
 	return files, nil
 }
 
-func publishReports(ctx context.Context, opts publishOptions, output string) (string, error) {
+// openPublishStore opens the first configured publish target in gcs, s3,
+// local directory order, or returns a nil store when none is set.
+func openPublishStore(ctx context.Context, opts publishOptions) (uploader.ObjectStore, string, error) {
 	gcsEnabled := opts.GCS.Enabled && strings.TrimSpace(opts.GCS.Bucket) != ""
 	s3Enabled := opts.S3.Enabled && strings.TrimSpace(opts.S3.Bucket) != ""
-	if !gcsEnabled && !s3Enabled {
-		return "", nil
-	}
-	publishFiles, err := collectPublishFiles(output)
-	if err != nil {
-		return "", err
-	}
-	if gcsEnabled {
+	switch {
+	case gcsEnabled:
 		if s3Enabled {
 			util.Warnf("publish targets include both gcs and s3; using gcs")
 		}
-		client, err := gcsClientFromConfig(ctx, opts.GCS)
-		if err != nil {
-			return "", err
-		}
-		defer func() {
-			if err := client.Close(); err != nil {
-				util.Warnf("gcs client close failed: %v", err)
-			}
-		}()
-		for _, name := range publishFiles {
-			data, err := os.ReadFile(filepath.Join(output, filepath.FromSlash(name)))
-			if err != nil {
-				return "", err
-			}
-			key := objectKey(opts.GCS.Prefix, name)
-			writer := client.Bucket(opts.GCS.Bucket).Object(key).NewWriter(ctx)
-			writer.ContentType = "application/json"
-			_, copyErr := io.Copy(writer, bytes.NewReader(data))
-			closeErr := writer.Close()
-			if copyErr != nil {
-				return "", copyErr
-			}
-			if closeErr != nil {
-				return "", closeErr
-			}
-		}
-		reportKey := objectKey(opts.GCS.Prefix, "reports.json")
-		if strings.TrimSpace(opts.PublicBaseURL) != "" {
-			return objectURL(opts.PublicBaseURL, reportKey), nil
-		}
-		return fmt.Sprintf("gs://%s/%s", opts.GCS.Bucket, reportKey), nil
+		store, err := uploader.NewGCSStore(ctx, opts.GCS)
+		return store, opts.GCS.Prefix, err
+	case s3Enabled:
+		store, err := uploader.NewS3Store(ctx, opts.S3)
+		return store, opts.S3.Prefix, err
+	case opts.Dir != "":
+		store, err := uploader.NewDirStore(opts.Dir)
+		return store, "", err
+	}
+	return nil, "", nil
+}
+
+func publishReports(ctx context.Context, opts publishOptions, output string) (string, error) {
+	store, prefix, err := openPublishStore(ctx, opts)
+	if err != nil || store == nil {
+		return "", err
 	}
-	client, err := s3ClientFromConfig(ctx, opts.S3)
+	defer util.CloseWithErr(store, "publish store")
+	publishFiles, err := collectPublishFiles(output)
 	if err != nil {
 		return "", err
 	}
@@ -1172,23 +986,16 @@ func publishReports(ctx context.Context, opts publishOptions, output string) (st
 		if err != nil {
 			return "", err
 		}
-		key := objectKey(opts.S3.Prefix, name)
-		_, err = client.PutObject(ctx, &s3.PutObjectInput{
-			Bucket:        aws.String(opts.S3.Bucket),
-			Key:           aws.String(key),
-			Body:          bytes.NewReader(data),
-			ContentLength: aws.Int64(int64(len(data))),
-			ContentType:   aws.String("application/json"),
-		})
-		if err != nil {
+		key := objectKey(prefix, name)
+		if err := store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), "application/json"); err != nil {
 			return "", err
 		}
 	}
-	reportKey := objectKey(opts.S3.Prefix, "reports.json")
+	reportKey := objectKey(prefix, "reports.json")
 	if strings.TrimSpace(opts.PublicBaseURL) != "" {
 		return objectURL(opts.PublicBaseURL, reportKey), nil
 	}
-	return fmt.Sprintf("s3://%s/%s", opts.S3.Bucket, reportKey), nil
+	return store.Location(reportKey), nil
 }
 
 func objectKey(prefix, name string) string {
@@ -1200,6 +1007,30 @@ func objectKey(prefix, name string) string {
 	return trimmedPrefix + "/" + trimmedName
 }
 
+// caseFingerprint is the cluster key of `shiro-report diff`, so the worker
+// and the diff agree on which cases are the same bug.
+func caseFingerprint(c CaseEntry) string {
+	return diffFingerprint(diffCase{
+		Oracle:                       c.Oracle,
+		ErrorReason:                  c.ErrorReason,
+		Error:                        c.Error,
+		GroundTruthDSGMismatchReason: c.GroundTruthDSGMismatchReason,
+		PlanSignature:                c.PlanSignature,
+		PlanSigFormat:                c.PlanSigFormat,
+		Expected:                     c.Expected,
+		Actual:                       c.Actual,
+	})
+}
+
+// caseRunID names the run that produced a case: the CI run id the runner
+// recorded, or empty so the worker does not count the case as a run.
+func caseRunID(c CaseEntry) string {
+	if c.RunInfo == nil {
+		return ""
+	}
+	return strings.TrimSpace(c.RunInfo.RunID)
+}
+
 func syncWorkerMetadata(ctx context.Context, opts workerSyncOptions, manifestURL string, site SiteData) error {
 	if strings.TrimSpace(opts.Endpoint) == "" {
 		return nil
@@ -1225,6 +1056,11 @@ func syncWorkerMetadata(ctx context.Context, opts workerSyncOptions, manifestURL
 			UploadLocation: strings.TrimSpace(c.UploadLocation),
 			ReportURL:      strings.TrimSpace(c.ReportURL),
 			ArchiveURL:     strings.TrimSpace(c.ArchiveURL),
+			Fingerprint:    caseFingerprint(c),
+			RunID:          caseRunID(c),
+			TiDBVersion:    strings.TrimSpace(c.TiDBVersion),
+			TiDBCommit:     strings.TrimSpace(c.TiDBCommit),
+			Flaky:          c.Flaky,
 		})
 	}
 	body, err := json.Marshal(payload)
@@ -1295,16 +1131,8 @@ func extractCommitFromPlanReplayer(zipPath string, maxBytes int) string {
 	return extractCommitFromPlanReplayerData(data)
 }
 
-func extractCommitFromPlanReplayerS3(ctx context.Context, client *s3.Client, bucket, key string, maxBytes int) string {
-	data, truncated, err := readObjectBytesLimited(ctx, client, bucket, key, maxBytes)
-	if err != nil || truncated {
-		return ""
-	}
-	return extractCommitFromPlanReplayerData(data)
-}
-
-func extractCommitFromPlanReplayerGCS(ctx context.Context, client *storage.Client, bucket, key string, maxBytes int) string {
-	data, truncated, err := readGCSObjectBytesLimited(ctx, client, bucket, key, maxBytes)
+func extractCommitFromPlanReplayerStore(ctx context.Context, store uploader.ObjectStore, key string, opts loadOptions) string {
+	data, truncated, err := opts.storeGet(ctx, store, key, opts.MaxZipBytes)
 	if err != nil || truncated {
 		return ""
 	}
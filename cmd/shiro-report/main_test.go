@@ -1,14 +1,20 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"slices"
 	"strings"
 	"testing"
 
+	"shiro/internal/config"
 	"shiro/internal/report"
+	"shiro/internal/runinfo"
+	"shiro/internal/uploader"
 )
 
 func TestSummaryErrorReason(t *testing.T) {
@@ -50,6 +56,22 @@ func TestSummaryErrorReason(t *testing.T) {
 	}
 }
 
+func TestSummaryTitle(t *testing.T) {
+	tests := []struct {
+		summary report.Summary
+		want    string
+	}{
+		{summary: report.Summary{Oracle: "DQP", Title: "DQP mismatch: HASH_JOIN vs base on 2-way inner join"}, want: "DQP mismatch: HASH_JOIN vs base on 2-way inner join"},
+		{summary: report.Summary{Oracle: "TLP", Error: "boom", ErrorReason: "tlp:sql_error_1105"}, want: "TLP error (tlp:sql_error_1105)"},
+		{summary: report.Summary{Oracle: "NoREC"}, want: "NoREC mismatch"},
+	}
+	for _, tt := range tests {
+		if got := summaryTitle(tt.summary); got != tt.want {
+			t.Fatalf("summaryTitle()=%q want=%q", got, tt.want)
+		}
+	}
+}
+
 func TestObjectKey(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -301,6 +323,47 @@ func TestBuildSiteIndexMarksMissingSummaryAsLoaded(t *testing.T) {
 	}
 }
 
+func TestBuildSiteIndexCarriesOracleApplicability(t *testing.T) {
+	coverage := []report.OracleApplicability{
+		{Oracle: "NoREC", Runs: 3, Skips: 3, SkipReasons: map[string]int64{"norec:guardrail": 3}},
+		{Oracle: "TLP", Runs: 2, Effective: 2},
+	}
+	site := SiteData{
+		Cases: []CaseEntry{
+			{
+				ID:                  "case-1",
+				CaseID:              "case-1",
+				Oracle:              "TLP",
+				OracleApplicability: coverage,
+			},
+		},
+	}
+	index := buildSiteIndex(site)
+	if len(index.Cases) != 1 {
+		t.Fatalf("unexpected index case count: %d", len(index.Cases))
+	}
+	got := index.Cases[0].OracleApplicability
+	if len(got) != 2 || got[0].Oracle != "NoREC" || got[0].SkipReasons["norec:guardrail"] != 3 {
+		t.Fatalf("unexpected oracle applicability in index: %+v", got)
+	}
+}
+
+func TestCaseEntryCarriesTopology(t *testing.T) {
+	summary := report.Summary{
+		CaseID:   "case-1",
+		Oracle:   "TLP",
+		Topology: report.NewClusterTopology([][2]string{{"tidb", "8.5.0"}, {"tikv", "8.5.0"}, {"tiflash", "8.5.0"}}),
+	}
+	entry := caseEntryFromSummary(summary, "case-1", "", nil, loadOptions{})
+	if entry.Topology != "tidb=1 tikv=1 tiflash=1" {
+		t.Fatalf("unexpected topology: %q", entry.Topology)
+	}
+	index := buildSiteIndex(SiteData{Cases: []CaseEntry{entry}})
+	if index.Cases[0].Topology != entry.Topology || !strings.Contains(index.Cases[0].SearchBlob, "tiflash=1") {
+		t.Fatalf("index entry should carry the topology: %+v", index.Cases[0])
+	}
+}
+
 func TestCollectPublishFilesIncludesIndexAndCaseSummaries(t *testing.T) {
 	output := t.TempDir()
 	paths := []string{
@@ -337,3 +400,116 @@ func TestCollectPublishFilesIncludesIndexAndCaseSummaries(t *testing.T) {
 		t.Fatalf("unexpected publish files: got=%v want=%v", files, want)
 	}
 }
+
+func TestLocalRemotePipeline(t *testing.T) {
+	ctx := context.Background()
+	caseDir := filepath.Join(t.TempDir(), "case-uuid")
+	if err := os.MkdirAll(caseDir, 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	summary := report.Summary{Oracle: "NoREC", CaseID: "case-uuid", Timestamp: "2026-10-16T00:00:00Z", TiDBVersion: "commit: 0123456789abcdef"}
+	raw, err := json.Marshal(summary)
+	if err != nil {
+		t.Fatalf("marshal summary: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(caseDir, "summary.json"), raw, 0o644); err != nil {
+		t.Fatalf("write summary: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(caseDir, "case.sql"), []byte("SELECT 1;"), 0o644); err != nil {
+		t.Fatalf("write case: %v", err)
+	}
+
+	remote := t.TempDir()
+	storage := config.StorageConfig{Local: config.LocalStorageConfig{Enabled: true, Dir: remote, Prefix: "reports"}}
+	store, prefix, err := uploader.NewStore(ctx, storage)
+	if err != nil {
+		t.Fatalf("NewStore() failed: %v", err)
+	}
+	if _, err := uploader.NewStoreUploader(store, prefix).UploadDir(ctx, caseDir); err != nil {
+		t.Fatalf("UploadDir() failed: %v", err)
+	}
+
+	input := "file://" + filepath.ToSlash(remote)
+	inputStore, inputPrefix, err := uploader.OpenLocation(ctx, input, config.StorageConfig{})
+	if err != nil {
+		t.Fatalf("OpenLocation() failed: %v", err)
+	}
+	cases, err := loadStoreCases(ctx, inputStore, inputPrefix, loadOptions{MaxBytes: 1024, MaxZipBytes: 1024})
+	if err != nil {
+		t.Fatalf("loadStoreCases() failed: %v", err)
+	}
+	if len(cases) != 1 {
+		t.Fatalf("expected one case, got %d", len(cases))
+	}
+	got := cases[0]
+	if got.CaseID != "case-uuid" || got.TiDBCommit != "0123456789abcdef" || got.Files["case.sql"].Content != "SELECT 1;" {
+		t.Fatalf("unexpected case entry: %+v", got)
+	}
+	if want := input + "/reports/case-uuid"; got.Dir != want {
+		t.Fatalf("case dir = %q, want %q", got.Dir, want)
+	}
+
+	output := t.TempDir()
+	if err := writeJSON(output, SiteData{Source: input, Cases: cases}); err != nil {
+		t.Fatalf("writeJSON() failed: %v", err)
+	}
+	publishDir := t.TempDir()
+	manifestURL, err := publishReports(ctx, publishOptions{Dir: publishDir}, output)
+	if err != nil {
+		t.Fatalf("publishReports() failed: %v", err)
+	}
+	if want := "file://" + filepath.ToSlash(filepath.Join(publishDir, "reports.json")); manifestURL != want {
+		t.Fatalf("manifest url = %q, want %q", manifestURL, want)
+	}
+	for _, name := range []string{"reports.json", "reports.index.json", filepath.Join("cases", "case-uuid", "summary.json")} {
+		if _, err := os.Stat(filepath.Join(publishDir, name)); err != nil {
+			t.Fatalf("expected published %s: %v", name, err)
+		}
+	}
+}
+
+func TestSyncWorkerMetadataSendsFingerprintHistoryFields(t *testing.T) {
+	var got workerSyncPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode payload: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	site := SiteData{Cases: []CaseEntry{
+		{
+			CaseID:      "case-a",
+			Oracle:      "NoREC",
+			ErrorReason: "result_mismatch",
+			TiDBVersion: "v7.5.0",
+			TiDBCommit:  "abc1234",
+			Flaky:       true,
+			RunInfo:     &runinfo.BasicInfo{RunID: "42"},
+			Error:       "Error 1105: index out of range [3]",
+		},
+		{CaseID: "case-b", Oracle: "NoREC", ErrorReason: "result_mismatch", Error: "Error 1105: index out of range [7]"},
+		{CaseID: "case-c", Oracle: "NoREC", ErrorReason: "result_mismatch", PlanSignature: "plan-c", Expected: "cnt=1", Actual: "cnt=2"},
+		{CaseID: "case-d", Oracle: "NoREC", ErrorReason: "result_mismatch", PlanSignature: "plan-d", Expected: "cnt=1", Actual: "cnt=2"},
+	}}
+	if err := syncWorkerMetadata(context.Background(), workerSyncOptions{Endpoint: server.URL}, "", site); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if len(got.Cases) != 4 {
+		t.Fatalf("unexpected synced cases: %+v", got.Cases)
+	}
+	a, b := got.Cases[0], got.Cases[1]
+	if a.Fingerprint != "NoREC|result_mismatch|Error N: index out of range [N]" || a.Fingerprint != b.Fingerprint {
+		t.Fatalf("unexpected fingerprints: %q %q", a.Fingerprint, b.Fingerprint)
+	}
+	if c, d := got.Cases[2], got.Cases[3]; c.Fingerprint != "NoREC|result_mismatch|plan-c" || c.Fingerprint == d.Fingerprint {
+		t.Fatalf("result mismatches with different plans should not merge: %q %q", c.Fingerprint, d.Fingerprint)
+	}
+	if a.RunID != "42" || b.RunID != "" {
+		t.Fatalf("unexpected run ids: %q %q", a.RunID, b.RunID)
+	}
+	if a.TiDBVersion != "v7.5.0" || a.TiDBCommit != "abc1234" || !a.Flaky || b.Flaky {
+		t.Fatalf("unexpected version fields: %+v", a)
+	}
+}
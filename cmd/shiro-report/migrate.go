@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"shiro/internal/report"
+)
+
+// runMigrate implements `shiro-report migrate -input <dir>`. It upgrades the
+// summary.json and report.json of every case directory under the input to
+// the current schema version. Staged cases of a running fuzzer are skipped.
+func runMigrate(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	input := flags.String("input", ".report", "local directory holding case directories, searched recursively")
+	dryRun := flags.Bool("dry-run", false, "report the cases that would be migrated without writing them")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	root := strings.TrimSpace(*input)
+	if root == "" {
+		return fmt.Errorf("migrate requires -input")
+	}
+	dirs, err := migrateCaseDirs(root)
+	if err != nil {
+		return err
+	}
+	verb := "migrated"
+	if *dryRun {
+		verb = "would migrate"
+	}
+	migrated := 0
+	var failed []error
+	for _, dir := range dirs {
+		result, err := report.MigrateCaseDir(dir, *dryRun)
+		if err != nil {
+			failed = append(failed, fmt.Errorf("%s: %w", dir, err))
+			continue
+		}
+		if result.Migrated {
+			migrated++
+			fmt.Fprintf(stdout, "%s %s: v%d -> v%d\n", verb, dir, result.From, report.SummarySchemaVersion)
+		}
+	}
+	fmt.Fprintf(stdout, "checked %d cases, %s %d, failed %d\n", len(dirs), verb, migrated, len(failed))
+	return errors.Join(failed...)
+}
+
+// migrateCaseDirs returns the directories under root that hold a
+// summary.json, in lexical order.
+func migrateCaseDirs(root string) ([]string, error) {
+	var dirs []string
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if entry.Name() == report.StagingDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.Name() == "summary.json" {
+			dirs = append(dirs, filepath.Dir(path))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dirs, nil
+}
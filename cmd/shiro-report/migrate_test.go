@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"shiro/internal/report"
+)
+
+func TestRunMigrate(t *testing.T) {
+	root := t.TempDir()
+	legacy := filepath.Join(root, "run-1", "case_0001_a")
+	current := filepath.Join(root, "run-1", "case_0002_b")
+	staged := filepath.Join(root, report.StagingDir, "case_0003_c")
+	for _, dir := range []string{legacy, current, staged} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+	writeFile := func(dir, content string) {
+		if err := os.WriteFile(filepath.Join(dir, "summary.json"), []byte(content), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	writeFile(legacy, `{"oracle":"NoREC"}`)
+	writeFile(current, `{"schema_version":2,"oracle":"TLP"}`)
+	writeFile(staged, `{"oracle":"DQP"}`)
+
+	var out bytes.Buffer
+	if err := runMigrate([]string{"-input", root, "-dry-run"}, &out); err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if !strings.Contains(out.String(), "would migrate "+legacy+": v1 -> v2") || !strings.Contains(out.String(), "checked 2 cases, would migrate 1, failed 0") {
+		t.Fatalf("unexpected dry run output:\n%s", out.String())
+	}
+	out.Reset()
+	if err := runMigrate([]string{"-input", root}, &out); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	summary, err := readCaseFromDir(legacy, loadOptions{MaxBytes: 1 << 16})
+	if err != nil || summary.CaseID != "a" {
+		t.Fatalf("unexpected migrated case %+v err=%v", summary, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(staged, "summary.json")); string(data) != `{"oracle":"DQP"}` {
+		t.Fatalf("staged case was migrated: %s", data)
+	}
+
+	writeFile(current, `{"schema_version":7}`)
+	out.Reset()
+	if err := runMigrate([]string{"-input", root}, &out); err == nil {
+		t.Fatalf("expected error for a newer schema version")
+	}
+	if !strings.Contains(out.String(), "failed 1") {
+		t.Fatalf("unexpected output:\n%s", out.String())
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+
+	"shiro/internal/config"
+	"shiro/internal/secrets"
+)
+
+// loadConfig loads the fuzzer config for its storage settings and resolves
+// the secret references in it.
+func loadConfig(ctx context.Context, path string) (config.Config, error) {
+	cfg, err := config.Load(path)
+	if err != nil {
+		return config.Config{}, err
+	}
+	if err := secrets.ResolveConfig(ctx, &cfg); err != nil {
+		return config.Config{}, err
+	}
+	return cfg, nil
+}
+
+// resolveSecretFlags replaces flag values that are secret references, such
+// as -worker-sync-token vault:secret/data/shiro#worker_token, with the
+// secrets they name.
+func resolveSecretFlags(ctx context.Context, values ...*string) error {
+	var r secrets.Resolver
+	for _, value := range values {
+		resolved, err := r.Resolve(ctx, *value)
+		if err != nil {
+			return err
+		}
+		*value = resolved
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"shiro/internal/config"
+	"shiro/internal/uploader"
+	"shiro/internal/util"
+
+	"cloud.google.com/go/storage"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// artifactURLTTLMax is the longest lifetime S3 and GCS accept for V4 signed URLs.
+const artifactURLTTLMax = 7 * 24 * time.Hour
+
+// artifactSigner pre-signs GET URLs for report/archive objects in private
+// buckets. A nil client disables signing for that scheme.
+type artifactSigner struct {
+	ttl    time.Duration
+	s3     *s3.PresignClient
+	gcs    *storage.Client
+	now    func() time.Time
+	warned map[string]bool
+}
+
+func validateArtifactURLTTL(ttl time.Duration) error {
+	if ttl < 0 {
+		return fmt.Errorf("artifact-url-ttl must be >= 0")
+	}
+	if ttl > artifactURLTTLMax {
+		return fmt.Errorf("artifact-url-ttl %s exceeds the %s limit of signed URLs", ttl, artifactURLTTLMax)
+	}
+	return nil
+}
+
+// newArtifactSigner builds clients for the storage backends enabled in cfg.
+func newArtifactSigner(ctx context.Context, cfg config.StorageConfig, ttl time.Duration) (*artifactSigner, error) {
+	signer := &artifactSigner{ttl: ttl, now: time.Now, warned: map[string]bool{}}
+	if cfg.S3.Enabled {
+		client, err := uploader.NewS3Client(ctx, cfg.S3)
+		if err != nil {
+			return nil, fmt.Errorf("s3 signer: %w", err)
+		}
+		signer.s3 = s3.NewPresignClient(client)
+	}
+	if cfg.GCS.Enabled {
+		client, err := uploader.NewGCSClient(ctx, cfg.GCS)
+		if err != nil {
+			return nil, fmt.Errorf("gcs signer: %w", err)
+		}
+		signer.gcs = client
+	}
+	return signer, nil
+}
+
+// Close releases the GCS client.
+func (s *artifactSigner) Close() {
+	if s.gcs != nil {
+		util.CloseWithErr(s.gcs, "gcs signer client")
+	}
+}
+
+// signFunc returns a loadOptions.SignArtifactURL callback bound to ctx.
+func (s *artifactSigner) signFunc(ctx context.Context) func(uploadLocation, name string) string {
+	return func(uploadLocation, name string) string {
+		return s.sign(ctx, uploadLocation, name)
+	}
+}
+
+// sign returns a pre-signed URL for name under an s3:// or gs:// upload
+// location, or "" when the scheme is not configured or signing fails.
+func (s *artifactSigner) sign(ctx context.Context, uploadLocation, name string) string {
+	location := strings.TrimSpace(uploadLocation)
+	switch {
+	case isS3URL(location) && s.s3 != nil:
+		bucket, prefix, err := parseS3URI(location)
+		if err != nil {
+			return ""
+		}
+		req, err := s.s3.PresignGetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(objectKey(prefix, name)),
+		}, s3.WithPresignExpires(s.ttl))
+		if err != nil {
+			s.warnOnce("s3", err)
+			return ""
+		}
+		return req.URL
+	case isGCSURL(location) && s.gcs != nil:
+		bucket, prefix, err := parseGCSURI(location)
+		if err != nil {
+			return ""
+		}
+		signed, err := s.gcs.Bucket(bucket).SignedURL(objectKey(prefix, name), &storage.SignedURLOptions{
+			Scheme:  storage.SigningSchemeV4,
+			Method:  http.MethodGet,
+			Expires: s.now().Add(s.ttl),
+		})
+		if err != nil {
+			s.warnOnce("gcs", err)
+			return ""
+		}
+		return signed
+	}
+	return ""
+}
+
+// warnOnce logs the first signing failure per backend; later cases usually
+// fail for the same credential problem.
+func (s *artifactSigner) warnOnce(backend string, err error) {
+	if s.warned[backend] {
+		return
+	}
+	s.warned[backend] = true
+	util.Warnf("sign %s artifact url failed: %v", backend, err)
+}
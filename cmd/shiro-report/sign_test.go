@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"shiro/internal/config"
+)
+
+func TestLoadOptionsObjectURLsSigning(t *testing.T) {
+	var signed []string
+	opts := loadOptions{SignArtifactURL: func(uploadLocation, name string) string {
+		signed = append(signed, name)
+		return "https://signed.example.com/" + name + "?sig=1"
+	}}
+	reportURL, archiveURL := opts.objectURLs("s3://bucket/abc/", "case.tar.zst")
+	if reportURL != "https://signed.example.com/report.json?sig=1" || archiveURL != "https://signed.example.com/case.tar.zst?sig=1" {
+		t.Fatalf("unexpected signed urls: report=%q archive=%q", reportURL, archiveURL)
+	}
+
+	signed = nil
+	reportURL, archiveURL = opts.objectURLs("https://cdn.example.com/abc/", "")
+	if reportURL != "https://cdn.example.com/abc/report.json" || archiveURL != "" || len(signed) != 0 {
+		t.Fatalf("http upload location should not be signed: report=%q archive=%q signed=%v", reportURL, archiveURL, signed)
+	}
+
+	opts.ArtifactPublicBaseURL = "https://cdn.example.com"
+	reportURL, _ = opts.objectURLs("gs://bucket/abc/", "")
+	if reportURL != "https://cdn.example.com/abc/report.json" || len(signed) != 0 {
+		t.Fatalf("public base url should win over signing: report=%q signed=%v", reportURL, signed)
+	}
+
+	if reportURL, _ := (loadOptions{}).objectURLs("s3://bucket/abc/", ""); reportURL != "" {
+		t.Fatalf("signing disabled should leave private urls empty: %q", reportURL)
+	}
+}
+
+func TestArtifactSignerS3(t *testing.T) {
+	cfg := config.StorageConfig{S3: config.S3Config{
+		Enabled:         true,
+		Endpoint:        "https://r2.example.com",
+		Region:          "auto",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "secret",
+		UsePathStyle:    true,
+	}}
+	signer, err := newArtifactSigner(context.Background(), cfg, time.Hour)
+	if err != nil {
+		t.Fatalf("newArtifactSigner: %v", err)
+	}
+	defer signer.Close()
+	raw := signer.sign(context.Background(), "s3://bucket/runs/case-1/", "report.json")
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host != "r2.example.com" || !strings.HasSuffix(parsed.Path, "/bucket/runs/case-1/report.json") {
+		t.Fatalf("unexpected signed url: %q err=%v", raw, err)
+	}
+	query := parsed.Query()
+	if query.Get("X-Amz-Expires") != "3600" || query.Get("X-Amz-Signature") == "" {
+		t.Fatalf("missing presign parameters: %q", raw)
+	}
+	if got := signer.sign(context.Background(), "gs://bucket/runs/case-1/", "report.json"); got != "" {
+		t.Fatalf("gcs is not configured, expected empty url, got %q", got)
+	}
+}
+
+func TestValidateArtifactURLTTL(t *testing.T) {
+	for _, ttl := range []time.Duration{0, time.Hour, artifactURLTTLMax} {
+		if err := validateArtifactURLTTL(ttl); err != nil {
+			t.Fatalf("ttl %s: unexpected error %v", ttl, err)
+		}
+	}
+	for _, ttl := range []time.Duration{-time.Second, artifactURLTTLMax + time.Second} {
+		if err := validateArtifactURLTTL(ttl); err == nil {
+			t.Fatalf("ttl %s: expected error", ttl)
+		}
+	}
+}
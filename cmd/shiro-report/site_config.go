@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	siteConfigFile    = "site-config.json"
+	siteConfigVersion = 1
+)
+
+// siteCaseLinkPlaceholders are the case fields a case link template may use.
+// Values are URL-escaped by the site before substitution.
+var siteCaseLinkPlaceholders = map[string]struct{}{
+	"case_id":        {},
+	"oracle":         {},
+	"error_reason":   {},
+	"tidb_commit":    {},
+	"plan_signature": {},
+}
+
+var siteCaseLinkPlaceholderPattern = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// SiteConfig customizes the static site without changing its assets. It is
+// written to site-config.json next to the report manifests.
+type SiteConfig struct {
+	Version int `json:"version" yaml:"-"`
+	// Title replaces the page heading.
+	Title string `json:"title,omitempty" yaml:"title"`
+	// Locale is the BCP 47 tag the site uses for its lang attribute and dates.
+	Locale string `json:"locale,omitempty" yaml:"locale"`
+	// Labels overrides UI strings by key (for example "expected", "actual_sql").
+	Labels map[string]string `json:"labels,omitempty" yaml:"labels"`
+	// HiddenFields lists case fields the site should not render.
+	HiddenFields []string `json:"hidden_fields,omitempty" yaml:"hidden_fields"`
+	// CaseLinks adds one link per case, expanded from a URL template.
+	CaseLinks []SiteCaseLink `json:"case_links,omitempty" yaml:"case_links"`
+}
+
+// SiteCaseLink is a per-case link such as a deep link to an issue tracker.
+// URL may reference {case_id}, {oracle}, {error_reason}, {tidb_commit}, and
+// {plan_signature}.
+type SiteCaseLink struct {
+	Label string `json:"label" yaml:"label"`
+	URL   string `json:"url" yaml:"url"`
+}
+
+// siteLinkFlags collects repeated -site-case-link label=url flags.
+type siteLinkFlags []SiteCaseLink
+
+func (f *siteLinkFlags) String() string {
+	parts := make([]string, 0, len(*f))
+	for _, link := range *f {
+		parts = append(parts, link.Label+"="+link.URL)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (f *siteLinkFlags) Set(value string) error {
+	label, url, ok := strings.Cut(value, "=")
+	if !ok {
+		return fmt.Errorf("expected label=url, got %q", value)
+	}
+	*f = append(*f, SiteCaseLink{Label: strings.TrimSpace(label), URL: strings.TrimSpace(url)})
+	return nil
+}
+
+// loadSiteConfig reads a YAML or JSON site config, appends links given on the
+// command line, and validates the result. An empty path yields the defaults.
+func loadSiteConfig(path string, extraLinks []SiteCaseLink) (SiteConfig, error) {
+	cfg := SiteConfig{}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return SiteConfig{}, err
+		}
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return SiteConfig{}, fmt.Errorf("parse %s: %w", path, err)
+		}
+	}
+	cfg.CaseLinks = append(cfg.CaseLinks, extraLinks...)
+	cfg.Version = siteConfigVersion
+	cfg.Title = strings.TrimSpace(cfg.Title)
+	cfg.Locale = strings.TrimSpace(cfg.Locale)
+	cfg.HiddenFields = normalizeHiddenFields(cfg.HiddenFields)
+	if err := validateSiteCaseLinks(cfg.CaseLinks); err != nil {
+		return SiteConfig{}, err
+	}
+	return cfg, nil
+}
+
+func normalizeHiddenFields(fields []string) []string {
+	seen := make(map[string]struct{}, len(fields))
+	out := make([]string, 0, len(fields))
+	for _, field := range fields {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field == "" {
+			continue
+		}
+		if _, ok := seen[field]; ok {
+			continue
+		}
+		seen[field] = struct{}{}
+		out = append(out, field)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// validateSiteCaseLinks rejects links the site could not render safely:
+// templates must be absolute http(s) URLs and use known placeholders only.
+func validateSiteCaseLinks(links []SiteCaseLink) error {
+	for _, link := range links {
+		if link.Label == "" {
+			return fmt.Errorf("case link %q: label is empty", link.URL)
+		}
+		if !isHTTPURL(link.URL) {
+			return fmt.Errorf("case link %q: url must start with http:// or https://", link.Label)
+		}
+		for _, match := range siteCaseLinkPlaceholderPattern.FindAllStringSubmatch(link.URL, -1) {
+			if _, ok := siteCaseLinkPlaceholders[match[1]]; !ok {
+				return fmt.Errorf("case link %q: unknown placeholder {%s}", link.Label, match[1])
+			}
+		}
+	}
+	return nil
+}
+
+func writeSiteConfig(output string, cfg SiteConfig) error {
+	if err := os.MkdirAll(output, 0o755); err != nil {
+		return err
+	}
+	return writeJSONFile(filepath.Join(output, siteConfigFile), cfg)
+}
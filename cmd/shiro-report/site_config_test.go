@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLoadSiteConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "site.yaml")
+	data := `title: Nightly fuzz
+locale: zh-CN
+labels:
+  expected: 期望结果
+hidden_fields: [" TiDB_Version ", plan_signature, tidb_version]
+case_links:
+  - label: Jira
+    url: https://jira.example.com/issues/?jql=text~"{case_id}"
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("write site config: %v", err)
+	}
+	cfg, err := loadSiteConfig(path, []SiteCaseLink{{Label: "Grafana", URL: "https://grafana.example.com/d/x?var-commit={tidb_commit}"}})
+	if err != nil {
+		t.Fatalf("loadSiteConfig() failed: %v", err)
+	}
+	if cfg.Version != siteConfigVersion || cfg.Title != "Nightly fuzz" || cfg.Locale != "zh-CN" {
+		t.Fatalf("unexpected config header: %+v", cfg)
+	}
+	if cfg.Labels["expected"] != "期望结果" {
+		t.Fatalf("unexpected labels: %v", cfg.Labels)
+	}
+	if !slices.Equal(cfg.HiddenFields, []string{"plan_signature", "tidb_version"}) {
+		t.Fatalf("unexpected hidden fields: %v", cfg.HiddenFields)
+	}
+	if len(cfg.CaseLinks) != 2 || cfg.CaseLinks[1].Label != "Grafana" {
+		t.Fatalf("unexpected case links: %+v", cfg.CaseLinks)
+	}
+
+	output := t.TempDir()
+	if err := writeSiteConfig(output, cfg); err != nil {
+		t.Fatalf("writeSiteConfig() failed: %v", err)
+	}
+	raw, err := os.ReadFile(filepath.Join(output, siteConfigFile))
+	if err != nil {
+		t.Fatalf("read site config: %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatalf("unmarshal site config: %v", err)
+	}
+	for _, key := range []string{"version", "title", "locale", "labels", "hidden_fields", "case_links"} {
+		if _, ok := decoded[key]; !ok {
+			t.Fatalf("site config missing %q: %s", key, raw)
+		}
+	}
+	files, err := collectPublishFiles(output)
+	if err != nil {
+		t.Fatalf("collectPublishFiles() failed: %v", err)
+	}
+	if !slices.Contains(files, siteConfigFile) {
+		t.Fatalf("site config not published: %v", files)
+	}
+}
+
+func TestLoadSiteConfigRejectsUnsafeLinks(t *testing.T) {
+	cases := []struct {
+		link SiteCaseLink
+		want string
+	}{
+		{link: SiteCaseLink{Label: "x", URL: "javascript:alert(1)"}, want: "http"},
+		{link: SiteCaseLink{Label: "x", URL: "https://example.com/{sql}"}, want: "unknown placeholder {sql}"},
+		{link: SiteCaseLink{URL: "https://example.com/{case_id}"}, want: "label is empty"},
+	}
+	for _, tc := range cases {
+		_, err := loadSiteConfig("", []SiteCaseLink{tc.link})
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("loadSiteConfig(%+v) err=%v want %q", tc.link, err, tc.want)
+		}
+	}
+}
+
+func TestSiteLinkFlags(t *testing.T) {
+	var links siteLinkFlags
+	if err := links.Set("Jira = https://jira.example.com/browse?q={case_id}"); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+	if err := links.Set("missing-separator"); err == nil {
+		t.Fatalf("expected error for flag without '='")
+	}
+	if len(links) != 1 || links[0].Label != "Jira" || links[0].URL != "https://jira.example.com/browse?q={case_id}" {
+		t.Fatalf("unexpected links: %+v", links)
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	trendsFile    = "trends.json"
+	trendsVersion = 1
+	trendsBucket  = "day"
+
+	// trendsSeriesLimit caps each dimension; smaller series fold into "other".
+	trendsSeriesLimit = 20
+	trendsOtherKey    = "other"
+	trendsUnknownKey  = "unknown"
+)
+
+// TrendsData is the trends.json payload: daily case counts per dimension.
+// Every series count slice is aligned with Buckets.
+type TrendsData struct {
+	GeneratedAt   string        `json:"generated_at"`
+	Source        string        `json:"source"`
+	Version       int           `json:"version"`
+	Bucket        string        `json:"bucket"`
+	Buckets       []string      `json:"buckets"`
+	Total         []int         `json:"total"`
+	Undated       int           `json:"undated"`
+	ByOracle      []TrendSeries `json:"by_oracle"`
+	ByErrorReason []TrendSeries `json:"by_error_reason"`
+	ByTiDBCommit  []TrendSeries `json:"by_tidb_commit"`
+}
+
+// TrendSeries is one line of a trend chart.
+type TrendSeries struct {
+	Key       string `json:"key"`
+	Total     int    `json:"total"`
+	FirstSeen string `json:"first_seen"`
+	LastSeen  string `json:"last_seen"`
+	Counts    []int  `json:"counts"`
+}
+
+// buildTrends buckets cases by UTC day. Cases without a parseable timestamp
+// are only counted in Undated.
+func buildTrends(site SiteData) TrendsData {
+	out := TrendsData{
+		GeneratedAt: site.GeneratedAt,
+		Source:      site.Source,
+		Version:     trendsVersion,
+		Bucket:      trendsBucket,
+	}
+	type dated struct {
+		day string
+		c   *CaseEntry
+	}
+	entries := make([]dated, 0, len(site.Cases))
+	days := make(map[string]struct{})
+	for i := range site.Cases {
+		day, ok := trendDay(site.Cases[i].Timestamp)
+		if !ok {
+			out.Undated++
+			continue
+		}
+		entries = append(entries, dated{day: day, c: &site.Cases[i]})
+		days[day] = struct{}{}
+	}
+	out.Buckets = fillTrendDays(days)
+	index := make(map[string]int, len(out.Buckets))
+	for i, day := range out.Buckets {
+		index[day] = i
+	}
+	out.Total = make([]int, len(out.Buckets))
+	byOracle := make(map[string][]int)
+	byReason := make(map[string][]int)
+	byCommit := make(map[string][]int)
+	for _, entry := range entries {
+		idx := index[entry.day]
+		out.Total[idx]++
+		addTrendCount(byOracle, trendKey(entry.c.Oracle), idx, len(out.Buckets))
+		addTrendCount(byReason, trendKey(entry.c.ErrorReason), idx, len(out.Buckets))
+		addTrendCount(byCommit, trendKey(entry.c.TiDBCommit), idx, len(out.Buckets))
+	}
+	out.ByOracle = trendSeriesList(byOracle, out.Buckets)
+	out.ByErrorReason = trendSeriesList(byReason, out.Buckets)
+	out.ByTiDBCommit = trendSeriesList(byCommit, out.Buckets)
+	return out
+}
+
+func trendDay(ts string) (string, bool) {
+	ts = strings.TrimSpace(ts)
+	if ts == "" {
+		return "", false
+	}
+	parsed, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return "", false
+	}
+	return parsed.UTC().Format(time.DateOnly), true
+}
+
+// fillTrendDays returns every day from the first to the last seen day, so
+// days without cases chart as zero instead of being skipped.
+func fillTrendDays(days map[string]struct{}) []string {
+	if len(days) == 0 {
+		return []string{}
+	}
+	sorted := make([]string, 0, len(days))
+	for day := range days {
+		sorted = append(sorted, day)
+	}
+	sort.Strings(sorted)
+	first, _ := time.Parse(time.DateOnly, sorted[0])
+	last, _ := time.Parse(time.DateOnly, sorted[len(sorted)-1])
+	out := make([]string, 0, int(last.Sub(first).Hours()/24)+1)
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		out = append(out, day.Format(time.DateOnly))
+	}
+	return out
+}
+
+func trendKey(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return trendsUnknownKey
+	}
+	return value
+}
+
+func addTrendCount(series map[string][]int, key string, idx int, size int) {
+	counts, ok := series[key]
+	if !ok {
+		counts = make([]int, size)
+		series[key] = counts
+	}
+	counts[idx]++
+}
+
+// trendSeriesList sorts series by total (then key) and folds everything past
+// trendsSeriesLimit into a single "other" series.
+func trendSeriesList(series map[string][]int, buckets []string) []TrendSeries {
+	out := make([]TrendSeries, 0, len(series))
+	for key, counts := range series {
+		out = append(out, newTrendSeries(key, counts, buckets))
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Total != out[j].Total {
+			return out[i].Total > out[j].Total
+		}
+		return out[i].Key < out[j].Key
+	})
+	if len(out) <= trendsSeriesLimit {
+		return out
+	}
+	other := make([]int, len(buckets))
+	for _, item := range out[trendsSeriesLimit-1:] {
+		for i, count := range item.Counts {
+			other[i] += count
+		}
+	}
+	out = append(out[:trendsSeriesLimit-1], newTrendSeries(trendsOtherKey, other, buckets))
+	return out
+}
+
+func newTrendSeries(key string, counts []int, buckets []string) TrendSeries {
+	item := TrendSeries{Key: key, Counts: counts}
+	for i, count := range counts {
+		if count == 0 {
+			continue
+		}
+		item.Total += count
+		if item.FirstSeen == "" {
+			item.FirstSeen = buckets[i]
+		}
+		item.LastSeen = buckets[i]
+	}
+	return item
+}
+
+func writeTrends(output string, site SiteData) error {
+	return writeJSONFile(filepath.Join(output, trendsFile), buildTrends(site))
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestBuildTrends(t *testing.T) {
+	site := SiteData{
+		GeneratedAt: "2026-10-16T00:00:00Z",
+		Source:      ".report",
+		Cases: []CaseEntry{
+			{Oracle: "DQP", ErrorReason: "result_mismatch", TiDBCommit: "abc", Timestamp: "2026-10-13T23:30:00-02:00"},
+			{Oracle: "DQP", ErrorReason: "result_mismatch", TiDBCommit: "abc", Timestamp: "2026-10-14T10:00:00Z"},
+			{Oracle: "TLP", ErrorReason: "", TiDBCommit: "def", Timestamp: "2026-10-16T08:00:00Z"},
+			{Oracle: "TLP", Timestamp: "not-a-time"},
+		},
+	}
+	trends := buildTrends(site)
+	if trends.Version != trendsVersion || trends.Bucket != trendsBucket || trends.Source != ".report" {
+		t.Fatalf("unexpected trends header: %+v", trends)
+	}
+	if want := []string{"2026-10-14", "2026-10-15", "2026-10-16"}; !slices.Equal(trends.Buckets, want) {
+		t.Fatalf("buckets=%v want=%v", trends.Buckets, want)
+	}
+	if want := []int{2, 0, 1}; !slices.Equal(trends.Total, want) {
+		t.Fatalf("total=%v want=%v", trends.Total, want)
+	}
+	if trends.Undated != 1 {
+		t.Fatalf("undated=%d want=1", trends.Undated)
+	}
+	if len(trends.ByOracle) != 2 || trends.ByOracle[0].Key != "DQP" || !slices.Equal(trends.ByOracle[0].Counts, []int{2, 0, 0}) {
+		t.Fatalf("unexpected by_oracle: %+v", trends.ByOracle)
+	}
+	if trends.ByOracle[1].FirstSeen != "2026-10-16" || trends.ByOracle[1].LastSeen != "2026-10-16" {
+		t.Fatalf("unexpected TLP first/last seen: %+v", trends.ByOracle[1])
+	}
+	if len(trends.ByErrorReason) != 2 || trends.ByErrorReason[1].Key != trendsUnknownKey {
+		t.Fatalf("unexpected by_error_reason: %+v", trends.ByErrorReason)
+	}
+	if len(trends.ByTiDBCommit) != 2 || trends.ByTiDBCommit[0].Key != "abc" || trends.ByTiDBCommit[0].Total != 2 {
+		t.Fatalf("unexpected by_tidb_commit: %+v", trends.ByTiDBCommit)
+	}
+}
+
+func TestBuildTrendsFoldsOther(t *testing.T) {
+	var cases []CaseEntry
+	for i := 0; i < trendsSeriesLimit+5; i++ {
+		cases = append(cases, CaseEntry{Oracle: fmt.Sprintf("O%02d", i), Timestamp: "2026-10-16T00:00:00Z"})
+	}
+	trends := buildTrends(SiteData{Cases: cases})
+	if len(trends.ByOracle) != trendsSeriesLimit {
+		t.Fatalf("by_oracle len=%d want=%d", len(trends.ByOracle), trendsSeriesLimit)
+	}
+	other := trends.ByOracle[len(trends.ByOracle)-1]
+	if other.Key != trendsOtherKey || other.Total != 6 {
+		t.Fatalf("unexpected other series: %+v", other)
+	}
+}
+
+func TestWriteTrends(t *testing.T) {
+	output := t.TempDir()
+	if err := writeTrends(output, SiteData{}); err != nil {
+		t.Fatalf("writeTrends() failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(output, trendsFile))
+	if err != nil {
+		t.Fatalf("read trends: %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("decode trends: %v", err)
+	}
+	if buckets, ok := decoded["buckets"].([]any); !ok || len(buckets) != 0 {
+		t.Fatalf("expected empty buckets array, got %v", decoded["buckets"])
+	}
+}
@@ -0,0 +1,402 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+
+	"shiro/internal/config"
+	"shiro/internal/report"
+	"shiro/internal/uploader"
+	"shiro/internal/util"
+)
+
+const (
+	verifyMissingFile     = "missing_file"
+	verifyInvalidJSON     = "invalid_json"
+	verifyArchiveChecksum = "archive_checksum"
+	verifyArchiveCorrupt  = "archive_corrupt"
+	verifyDanglingRef     = "dangling_reference"
+
+	verifyFormatText = "text"
+	verifyFormatJSON = "json"
+
+	verifyMaxBytesDefault  = 256 << 20
+	verifySummaryFileName  = "summary.json"
+	verifyDetailFileSuffix = "_file"
+)
+
+// verifyRequiredFiles are written for every case, whatever the oracle.
+var verifyRequiredFiles = []string{"case.sql", "schema.sql"}
+
+// verifyProblem is one broken artifact of a case with the action that
+// repairs it.
+type verifyProblem struct {
+	Case   string `json:"case"`
+	Kind   string `json:"kind"`
+	File   string `json:"file,omitempty"`
+	Detail string `json:"detail"`
+	Repair string `json:"repair"`
+}
+
+// verifyResult is the outcome of verifying every case under an input.
+type verifyResult struct {
+	Input    string          `json:"input"`
+	Cases    int             `json:"cases"`
+	Broken   int             `json:"broken_cases"`
+	Problems []verifyProblem `json:"problems"`
+	// Unchecked lists objects larger than -max-bytes, whose content was not
+	// verified.
+	Unchecked []string `json:"unchecked,omitempty"`
+}
+
+// verifyCaseFiles is the view of one case directory that verifyCase reads:
+// the names of its top-level files and a reader for them. Only top-level
+// files are uploaded, so nested ones such as min/ are not checked.
+type verifyCaseFiles struct {
+	dir   string
+	names map[string]struct{}
+	read  func(name string) ([]byte, bool, error)
+	// uploadKey maps an upload_location to a directory key of the input and
+	// reports whether objects exist under it; nil when the input cannot tell.
+	uploadKey func(location string) (key string, exists bool, ok bool)
+}
+
+func (f verifyCaseFiles) has(name string) bool {
+	_, ok := f.names[name]
+	return ok
+}
+
+// runVerify implements `shiro-report verify -input <dir or URL>`. It checks
+// every case directory under the input for required files, parseable JSON,
+// matching archive checksums, and summary references to files that do not
+// exist, and prints the problems with their repair. It fails when any case is
+// broken, so a publish job can stop before the site silently drops cases.
+func runVerify(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("verify", flag.ContinueOnError)
+	input := flags.String("input", ".report", "input directory, gs://bucket/prefix, legacy s3://bucket/prefix, or file:///dir of a local storage remote")
+	configPath := flags.String("config", "config.yaml", "path to config file (for GCS/S3 access)")
+	format := flags.String("format", verifyFormatText, "output format: text or json")
+	maxBytes := flags.Int("max-bytes", verifyMaxBytesDefault, "max bytes read per object; larger objects are listed as unchecked")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	location := strings.TrimSpace(*input)
+	if location == "" {
+		return fmt.Errorf("verify requires -input")
+	}
+	outputFormat := strings.ToLower(strings.TrimSpace(*format))
+	if outputFormat != verifyFormatText && outputFormat != verifyFormatJSON {
+		return fmt.Errorf("unknown verify format %q", *format)
+	}
+	ctx := context.Background()
+	var cases []verifyCaseFiles
+	var err error
+	if isStoreURL(location) {
+		var storageCfg config.StorageConfig
+		if !isFileURL(location) {
+			cfg, loadErr := loadConfig(ctx, *configPath)
+			if loadErr != nil {
+				return fmt.Errorf("load config: %w", loadErr)
+			}
+			storageCfg = cfg.Storage
+		}
+		store, prefix, openErr := uploader.OpenLocation(ctx, location, storageCfg)
+		if openErr != nil {
+			return fmt.Errorf("open input: %w", openErr)
+		}
+		defer util.CloseWithErr(store, "verify store")
+		cases, err = verifyStoreCases(ctx, store, prefix, *maxBytes)
+	} else {
+		cases, err = verifyLocalCases(location, *maxBytes)
+	}
+	if err != nil {
+		return err
+	}
+
+	result := verifyResult{Input: location, Cases: len(cases), Problems: []verifyProblem{}}
+	for _, files := range cases {
+		problems, unchecked := verifyCase(files)
+		if len(problems) > 0 {
+			result.Broken++
+		}
+		result.Problems = append(result.Problems, problems...)
+		result.Unchecked = append(result.Unchecked, unchecked...)
+	}
+
+	switch outputFormat {
+	case verifyFormatJSON:
+		payload, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		if _, err := stdout.Write(append(payload, '\n')); err != nil {
+			return err
+		}
+	default:
+		for _, problem := range result.Problems {
+			target := problem.Case
+			if problem.File != "" {
+				target += "/" + problem.File
+			}
+			fmt.Fprintf(stdout, "%s: %s: %s\n  repair: %s\n", target, problem.Kind, problem.Detail, problem.Repair)
+		}
+		for _, name := range result.Unchecked {
+			fmt.Fprintf(stdout, "%s: unchecked, larger than -max-bytes\n", name)
+		}
+		fmt.Fprintf(stdout, "checked %d cases, %d broken, %d problems\n", result.Cases, result.Broken, len(result.Problems))
+	}
+	if len(result.Problems) > 0 {
+		return fmt.Errorf("%d problems in %d of %d cases", len(result.Problems), result.Broken, result.Cases)
+	}
+	return nil
+}
+
+// verifyLocalCases returns the case directories under root, skipping staged
+// cases of a running fuzzer.
+func verifyLocalCases(root string, maxBytes int) ([]verifyCaseFiles, error) {
+	dirs, err := migrateCaseDirs(root)
+	if err != nil {
+		return nil, err
+	}
+	cases := make([]verifyCaseFiles, 0, len(dirs))
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		names := make(map[string]struct{}, len(entries))
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				names[entry.Name()] = struct{}{}
+			}
+		}
+		caseDir := dir
+		cases = append(cases, verifyCaseFiles{
+			dir:   caseDir,
+			names: names,
+			read: func(name string) ([]byte, bool, error) {
+				data, truncated, err := readFileLimited(filepath.Join(caseDir, name), maxBytes)
+				return []byte(data), truncated, err
+			},
+		})
+	}
+	return cases, nil
+}
+
+// verifyStoreCases returns the case directories under prefix of store. An
+// upload_location inside the store is resolved against the listed keys.
+func verifyStoreCases(ctx context.Context, store uploader.ObjectStore, prefix string, maxBytes int) ([]verifyCaseFiles, error) {
+	keys, err := store.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	dirFiles := map[string]map[string]struct{}{}
+	var dirs []string
+	for _, key := range keys {
+		dir, name := path.Split(key)
+		dir = strings.TrimSuffix(dir, "/")
+		if dirFiles[dir] == nil {
+			dirFiles[dir] = map[string]struct{}{}
+		}
+		dirFiles[dir][name] = struct{}{}
+		if name == verifySummaryFileName && !strings.Contains("/"+dir+"/", "/"+report.StagingDir+"/") {
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Strings(dirs)
+	root := strings.TrimRight(store.Location(""), "/") + "/"
+	uploadKey := func(location string) (string, bool, bool) {
+		if !strings.HasPrefix(location, root) {
+			return "", false, false
+		}
+		key := strings.TrimRight(strings.TrimPrefix(location, root), "/")
+		if !strings.HasPrefix(key, prefix) {
+			return key, false, false
+		}
+		_, exists := dirFiles[key]
+		return key, exists, true
+	}
+	cases := make([]verifyCaseFiles, 0, len(dirs))
+	for _, dir := range dirs {
+		caseDir := dir
+		cases = append(cases, verifyCaseFiles{
+			dir:   store.Location(caseDir),
+			names: dirFiles[caseDir],
+			read: func(name string) ([]byte, bool, error) {
+				return store.Get(ctx, caseDir+"/"+name, maxBytes)
+			},
+			uploadKey: uploadKey,
+		})
+	}
+	return cases, nil
+}
+
+// verifyCase checks one case directory and returns its problems and the
+// files too large to check.
+func verifyCase(files verifyCaseFiles) ([]verifyProblem, []string) {
+	var problems []verifyProblem
+	var unchecked []string
+	add := func(kind, file, detail, repair string) {
+		problems = append(problems, verifyProblem{Case: files.dir, Kind: kind, File: file, Detail: detail, Repair: repair})
+	}
+	readAll := func(name string) ([]byte, bool) {
+		data, truncated, err := files.read(name)
+		if err != nil {
+			add(verifyMissingFile, name, err.Error(), "re-upload the file from the fuzzer's report directory")
+			return nil, false
+		}
+		if truncated {
+			unchecked = append(unchecked, files.dir+"/"+name)
+			return nil, false
+		}
+		return data, true
+	}
+
+	data, ok := readAll(verifySummaryFileName)
+	if !ok {
+		return problems, unchecked
+	}
+	summary, err := report.DecodeSummary(data, path.Base(files.dir))
+	if err != nil {
+		repair := "restore summary.json from the case archive or the fuzzer's report directory"
+		if !files.has(report.CaseArchiveName) {
+			repair = "restore summary.json from the fuzzer's report directory, or delete the case"
+		}
+		add(verifyInvalidJSON, verifySummaryFileName, err.Error(), repair)
+		return problems, unchecked
+	}
+
+	for _, name := range verifyRequiredFiles {
+		if files.has(name) {
+			continue
+		}
+		repair := "re-upload " + name + " from the fuzzer's report directory"
+		if files.has(summary.ArchiveName) {
+			repair = "extract " + name + " from " + summary.ArchiveName + " and re-upload it"
+		}
+		add(verifyMissingFile, name, name+" is missing", repair)
+	}
+
+	names := make([]string, 0, len(files.names))
+	for name := range files.names {
+		if strings.HasSuffix(name, ".json") && name != verifySummaryFileName {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		content, ok := readAll(name)
+		if ok && !json.Valid(content) {
+			add(verifyInvalidJSON, name, name+" is not valid JSON", "re-upload "+name+" from the fuzzer's report directory")
+		}
+	}
+
+	for _, ref := range verifySummaryRefs(summary) {
+		if !files.has(ref.name) {
+			add(verifyDanglingRef, ref.name, ref.field+" names "+ref.name+", which does not exist", "re-upload "+ref.name+" or clear "+ref.field+" in summary.json")
+		}
+	}
+	if location := strings.TrimSpace(summary.UploadLocation); location != "" && files.uploadKey != nil {
+		if key, exists, ok := files.uploadKey(location); ok && !exists {
+			add(verifyDanglingRef, verifySummaryFileName, "upload_location "+location+" holds no objects (key "+key+")", "set upload_location in summary.json to "+files.dir)
+		}
+	}
+
+	if summary.ArchiveName != "" && files.has(summary.ArchiveName) {
+		if archive, ok := readAll(summary.ArchiveName); ok {
+			if err := verifyArchive(archive, summary.ArchiveSHA256); err != nil {
+				kind := verifyArchiveCorrupt
+				if errors.Is(err, errArchiveChecksum) {
+					kind = verifyArchiveChecksum
+				}
+				add(kind, summary.ArchiveName, err.Error(), "re-upload "+summary.ArchiveName+" from the fuzzer's report directory")
+			}
+		}
+	}
+	return problems, unchecked
+}
+
+type verifyRef struct {
+	field string
+	name  string
+}
+
+// verifySummaryRefs returns the files a summary points at: the archive, the
+// plans file, the plan replayer dump, and every *_file detail.
+func verifySummaryRefs(summary report.Summary) []verifyRef {
+	var refs []verifyRef
+	if summary.ArchiveName != "" {
+		refs = append(refs, verifyRef{field: "archive_name", name: summary.ArchiveName})
+	}
+	if summary.PlansFile != "" {
+		refs = append(refs, verifyRef{field: "plans_file", name: summary.PlansFile})
+	}
+	if replay := strings.TrimSpace(summary.PlanReplay); replay != "" {
+		refs = append(refs, verifyRef{field: "plan_replayer", name: filepath.Base(replay)})
+	}
+	keys := make([]string, 0, len(summary.Details))
+	for key := range summary.Details {
+		if strings.HasSuffix(key, verifyDetailFileSuffix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if name, ok := summary.Details[key].(string); ok && strings.TrimSpace(name) != "" {
+			refs = append(refs, verifyRef{field: "details." + key, name: name})
+		}
+	}
+	return refs
+}
+
+var errArchiveChecksum = errors.New("archive checksum mismatch")
+
+// verifyArchive checks a zstd-compressed tar archive against its recorded
+// sha256, when the summary has one, and reads every entry to the end.
+func verifyArchive(data []byte, wantSHA256 string) error {
+	if wantSHA256 != "" {
+		sum := sha256.Sum256(data)
+		if got := hex.EncodeToString(sum[:]); !strings.EqualFold(got, wantSHA256) {
+			return fmt.Errorf("%w: sha256 %s, summary records %s", errArchiveChecksum, got, wantSHA256)
+		}
+	}
+	zr, err := zstd.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	tr := tar.NewReader(zr)
+	entries := 0
+	for {
+		_, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("read archive entry %d: %w", entries+1, err)
+		}
+		if _, err := io.Copy(io.Discard, tr); err != nil {
+			return fmt.Errorf("read archive entry %d: %w", entries+1, err)
+		}
+		entries++
+	}
+	if entries == 0 {
+		return fmt.Errorf("archive has no entries")
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"shiro/internal/report"
+)
+
+func writeVerifyCase(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+}
+
+func writeVerifySummary(t *testing.T, dir string, summary report.Summary) {
+	t.Helper()
+	data, err := json.Marshal(summary)
+	if err != nil {
+		t.Fatalf("marshal summary: %v", err)
+	}
+	writeVerifyCase(t, dir, map[string]string{"summary.json": string(data)})
+}
+
+func TestRunVerify(t *testing.T) {
+	root := t.TempDir()
+	reporter := report.New(root, 0)
+
+	good := filepath.Join(root, "case_0001_a")
+	writeVerifyCase(t, good, map[string]string{"case.sql": "SELECT 1;\n", "schema.sql": "CREATE TABLE t0 (c0 INT);\n", "session.json": `{"sql_mode":""}`})
+	goodSummary := report.Summary{Oracle: "NoREC", ArchiveName: report.CaseArchiveName, ArchiveCodec: report.CaseArchiveCodec, Details: map[string]any{"session_state_file": "session.json"}}
+	writeVerifySummary(t, good, goodSummary)
+	if _, _, err := reporter.WriteCaseArchive(report.Case{Dir: good}); err != nil {
+		t.Fatalf("archive: %v", err)
+	}
+	sum, err := reporter.ArchiveSHA256(report.Case{Dir: good})
+	if err != nil || len(sum) != 64 {
+		t.Fatalf("ArchiveSHA256() = %q, %v", sum, err)
+	}
+	goodSummary.ArchiveSHA256 = sum
+	writeVerifySummary(t, good, goodSummary)
+
+	var out bytes.Buffer
+	if err := runVerify([]string{"-input", root}, &out); err != nil {
+		t.Fatalf("verify of a healthy case failed: %v\n%s", err, out.String())
+	}
+	if got := out.String(); got != "checked 1 cases, 0 broken, 0 problems\n" {
+		t.Fatalf("unexpected output: %q", got)
+	}
+
+	broken := filepath.Join(root, "case_0002_b")
+	writeVerifyCase(t, broken, map[string]string{"case.sql": "SELECT 1;\n", "report.json": `{"oracle":`, report.CaseArchiveName: "not zstd"})
+	writeVerifySummary(t, broken, report.Summary{
+		Oracle:        "TLP",
+		ArchiveName:   report.CaseArchiveName,
+		ArchiveSHA256: strings.Repeat("0", 64),
+		PlansFile:     report.PlansFile,
+		PlanReplay:    "/tmp/.staging/case_0002_b/plan_replayer.zip",
+		Details:       map[string]any{"cert_stats_file": "cert_stats.txt"},
+	})
+	garbled := filepath.Join(root, "case_0003_c")
+	writeVerifyCase(t, garbled, map[string]string{"summary.json": "{", "case.sql": "SELECT 1;\n", "schema.sql": ""})
+	writeVerifyCase(t, filepath.Join(root, report.StagingDir, "case_0004_d"), map[string]string{"summary.json": "{"})
+
+	out.Reset()
+	if err := runVerify([]string{"-input", root, "-format", "json"}, &out); err == nil {
+		t.Fatalf("expected verify to fail on broken cases")
+	}
+	var result verifyResult
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		t.Fatalf("decode output: %v\n%s", err, out.String())
+	}
+	if result.Cases != 3 || result.Broken != 2 {
+		t.Fatalf("unexpected counts: %+v", result)
+	}
+	var got []string
+	for _, problem := range result.Problems {
+		if problem.Repair == "" {
+			t.Fatalf("problem without repair: %+v", problem)
+		}
+		got = append(got, filepath.Base(problem.Case)+" "+problem.Kind+" "+problem.File)
+	}
+	sort.Strings(got)
+	want := []string{
+		"case_0002_b archive_checksum case.tar.zst",
+		"case_0002_b dangling_reference cert_stats.txt",
+		"case_0002_b dangling_reference plan_replayer.zip",
+		"case_0002_b dangling_reference plans.json",
+		"case_0002_b invalid_json report.json",
+		"case_0002_b missing_file schema.sql",
+		"case_0003_c invalid_json summary.json",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected problems:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if err := verifyArchive([]byte("not zstd"), ""); err == nil {
+		t.Fatalf("expected a corrupt archive to fail")
+	}
+}
+
+func TestRunVerifyStoreUploadLocation(t *testing.T) {
+	root := t.TempDir()
+	caseDir := filepath.Join(root, "run-1", "case_0001_a")
+	writeVerifyCase(t, caseDir, map[string]string{"case.sql": "SELECT 1;\n", "schema.sql": ""})
+	writeVerifySummary(t, caseDir, report.Summary{Oracle: "NoREC", UploadLocation: "file://" + filepath.ToSlash(caseDir)})
+	moved := filepath.Join(root, "run-1", "case_0002_b")
+	writeVerifyCase(t, moved, map[string]string{"case.sql": "SELECT 1;\n", "schema.sql": ""})
+	writeVerifySummary(t, moved, report.Summary{Oracle: "TLP", UploadLocation: "file://" + filepath.ToSlash(filepath.Join(root, "run-0", "case_0002_b"))})
+
+	var out bytes.Buffer
+	err := runVerify([]string{"-input", "file://" + filepath.ToSlash(root)}, &out)
+	if err == nil || err.Error() != "1 problems in 1 of 2 cases" {
+		t.Fatalf("unexpected verify error: %v\n%s", err, out.String())
+	}
+	if got := out.String(); !strings.Contains(got, "case_0002_b/summary.json: dangling_reference: upload_location") || !strings.Contains(got, "repair: set upload_location in summary.json to file://") {
+		t.Fatalf("unexpected output:\n%s", got)
+	}
+}
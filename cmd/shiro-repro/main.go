@@ -14,6 +14,21 @@ func main() {
 	dsn := flag.String("dsn", "", "database DSN")
 	database := flag.String("database", "shiro_repro", "database name for reproduction")
 	useMin := flag.Bool("use_min", true, "prefer min/repro.sql if present")
+	interactive := flag.Bool("interactive", false, "load the case and open an interactive shell over its statements")
+	timeTravel := flag.String("time_travel", "", "replay on the original data instead of the dumps: snapshot (read at the case TSO via tidb_snapshot) or flashback (FLASHBACK CLUSTER to the case TSO)")
+	snapshotTSO := flag.Uint64("snapshot_tso", 0, "TSO for -time_travel, overriding details.snapshot_tso/case_tso")
+	sourceDatabase := flag.String("source_database", "", "database for -time_travel, overriding details.case_database")
+	confirmFlashback := flag.Bool("confirm_flashback", false, "allow -time_travel=flashback to rewind the whole cluster")
+	var sessionInit []string
+	flag.Func("session_init", "statement to run on every repro connection instead of the case session.json session_init; repeatable, an empty value runs none", func(value string) error {
+		if sessionInit == nil {
+			sessionInit = []string{}
+		}
+		if value != "" {
+			sessionInit = append(sessionInit, value)
+		}
+		return nil
+	})
 	flag.Parse()
 
 	if *caseDir == "" || *dsn == "" {
@@ -23,10 +38,16 @@ func main() {
 	}
 
 	opts := repro.Options{
-		CaseDir:  *caseDir,
-		DSN:      *dsn,
-		Database: *database,
-		UseMin:   *useMin,
+		CaseDir:          *caseDir,
+		DSN:              *dsn,
+		Database:         *database,
+		UseMin:           *useMin,
+		Interactive:      *interactive,
+		TimeTravel:       *timeTravel,
+		SnapshotTSO:      *snapshotTSO,
+		SourceDatabase:   *sourceDatabase,
+		ConfirmFlashback: *confirmFlashback,
+		SessionInit:      sessionInit,
 	}
 	if err := repro.Run(context.Background(), opts); err != nil {
 		fmt.Fprintf(os.Stderr, "repro failed: %v\n", err)
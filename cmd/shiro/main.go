@@ -2,11 +2,13 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,6 +16,7 @@ import (
 	"shiro/internal/db"
 	"shiro/internal/runinfo"
 	"shiro/internal/runner"
+	"shiro/internal/secrets"
 	"shiro/internal/util"
 
 	"gopkg.in/yaml.v3"
@@ -21,6 +24,8 @@ import (
 
 func main() {
 	configPath := flag.String("config", "config.yaml", "path to config file")
+	focusPlanSignature := flag.String("focus-plan-signature", "", "converge QPG on plans whose hash or operator signature starts with this prefix")
+	verifyMin := flag.Bool("verify-min", false, "replay min/repro.sql after minimization and keep the full case when it does not reproduce")
 	flag.Parse()
 
 	absConfigPath, absErr := filepath.Abs(*configPath)
@@ -29,6 +34,10 @@ func main() {
 		fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
 		os.Exit(1)
 	}
+	applyFocusPlanSignature(&cfg, *focusPlanSignature)
+	if *verifyMin {
+		cfg.Minimize.VerifyMin = true
+	}
 	if err := util.InitLogging(cfg.Logging.LogFile); err != nil {
 		fmt.Fprintf(os.Stderr, "failed to init logging: %v\n", err)
 	}
@@ -50,38 +59,92 @@ func main() {
 	if data, err := yaml.Marshal(&cfg); err == nil {
 		util.Detailf("config:\n%s", string(data))
 	}
+	if err := secrets.ResolveConfig(context.Background(), &cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to resolve secrets: %v\n", err)
+		os.Exit(1)
+	}
 
+	// One chaos schedule serves every worker and target, so experiments do
+	// not overlap and every case sees the faults that were active.
+	chaos := runner.NewChaos(cfg.Chaos)
+	if len(cfg.Targets) == 0 {
+		err = runTarget(cfg, nil, chaos)
+	} else {
+		err = runTargets(cfg, chaos)
+	}
+	chaos.Stop()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runTargets fuzzes every configured target in this process. Each target
+// gets cfg.Workers runners, and one scheduler interleaves their iterations
+// so that at most cfg.Workers run at once.
+func runTargets(cfg config.Config, chaos *runner.Chaos) error {
+	weights := make(map[string]int, len(cfg.Targets))
+	for _, target := range cfg.Targets {
+		weights[target.Name] = target.Weight
+	}
+	scheduler := runner.NewTargetScheduler(cfg.Workers, weights)
+	var wg sync.WaitGroup
+	errs := make([]error, len(cfg.Targets))
+	for i, target := range cfg.Targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			targetCfg := cfg.ForTarget(target)
+			util.Infof("target %s using database %s output_dir=%s weight=%d", target.Name, targetCfg.Database, targetCfg.PlanReplayer.OutputDir, target.Weight)
+			if err := runTarget(targetCfg, scheduler, chaos); err != nil {
+				errs[i] = fmt.Errorf("target %s: %w", target.Name, err)
+			}
+		}()
+	}
+	wg.Wait()
+	granted := scheduler.Granted()
+	for _, target := range cfg.Targets {
+		util.Infof("target %s finished iterations=%d", target.Name, granted[target.Name])
+	}
+	return errors.Join(errs...)
+}
+
+// runTarget fuzzes the database of cfg with cfg.Workers runners. A non-nil
+// scheduler interleaves their iterations with those of other targets. The
+// shared chaos schedule starts once the first target is ready.
+func runTarget(cfg config.Config, scheduler *runner.TargetScheduler, chaos *runner.Chaos) error {
+	if err := runner.WaitReady(context.Background(), cfg); err != nil {
+		return fmt.Errorf("cluster readiness failed: %w", err)
+	}
+	if err := runner.SetupResourceGroup(context.Background(), &cfg); err != nil {
+		return fmt.Errorf("resource group setup failed: %w", err)
+	}
+	chaos.Start(context.Background())
+	if err := setGlobalTimeZone(cfg.DSN); err != nil {
+		return fmt.Errorf("failed to set global time_zone: %w", err)
+	}
+	runner.RecoverOutputDir(cfg.PlanReplayer.OutputDir, cfg.MaxDataDumpRows)
 	if cfg.Workers == 1 {
-		if err := setGlobalTimeZone(cfg.DSN); err != nil {
-			fmt.Fprintf(os.Stderr, "failed to set global time_zone: %v\n", err)
-			os.Exit(1)
-		}
 		if err := db.EnsureDatabase(context.Background(), cfg.DSN, cfg.Database); err != nil {
-			fmt.Fprintf(os.Stderr, "failed to ensure database: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("failed to ensure database: %w", err)
 		}
-		exec, err := db.Open(cfg.DSN)
+		exec, err := db.Open(cfg.DSN, cfg.SessionInit...)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "failed to connect to db: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("failed to connect to db: %w", err)
 		}
 		defer util.CloseWithErr(exec, "db exec")
 
 		r := runner.New(cfg, exec)
-		ctx := context.Background()
-		if err := r.Run(ctx); err != nil {
-			fmt.Fprintf(os.Stderr, "run failed: %v\n", err)
-			os.Exit(1)
+		r.SetTargetScheduler(scheduler)
+		r.SetChaos(chaos)
+		if err := r.Run(context.Background()); err != nil {
+			return fmt.Errorf("run failed: %w", err)
 		}
-		return
+		return nil
 	}
 
 	var wg sync.WaitGroup
 	errCh := make(chan error, cfg.Workers)
-	if err := setGlobalTimeZone(cfg.DSN); err != nil {
-		fmt.Fprintf(os.Stderr, "failed to set global time_zone: %v\n", err)
-		os.Exit(1)
-	}
 	for i := 0; i < cfg.Workers; i++ {
 		wg.Add(1)
 		go func(worker int) {
@@ -93,7 +156,7 @@ func main() {
 				errCh <- err
 				return
 			}
-			exec, err := db.Open(workerCfg.DSN)
+			exec, err := db.Open(workerCfg.DSN, workerCfg.SessionInit...)
 			if err != nil {
 				errCh <- err
 				return
@@ -101,6 +164,8 @@ func main() {
 			defer util.CloseWithErr(exec, "db exec")
 			util.Infof("worker %d using database %s", worker, workerCfg.Database)
 			r := runner.New(workerCfg, exec)
+			r.SetTargetScheduler(scheduler)
+			r.SetChaos(chaos)
 			if err := r.Run(context.Background()); err != nil {
 				errCh <- err
 			}
@@ -110,10 +175,22 @@ func main() {
 	close(errCh)
 	for err := range errCh {
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "run failed: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("run failed: %w", err)
 		}
 	}
+	return nil
+}
+
+// applyFocusPlanSignature lets the CLI flag override qpg.focus_plan_signature.
+// Focus mode depends on plan observation, so it also enables QPG.
+func applyFocusPlanSignature(cfg *config.Config, signature string) {
+	signature = strings.TrimSpace(signature)
+	if signature != "" {
+		cfg.QPG.FocusPlanSignature = signature
+	}
+	if cfg.QPG.FocusPlanSignature != "" {
+		cfg.QPG.Enabled = true
+	}
 }
 
 func setGlobalTimeZone(dsn string) error {
@@ -1,8 +1,13 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"unicode"
 
 	"shiro/internal/runinfo"
 
@@ -11,36 +16,671 @@ import (
 
 // Config captures all runtime options for the fuzz runner.
 type Config struct {
-	DSN                 string             `yaml:"dsn"`
-	Database            string             `yaml:"database"`
-	Seed                int64              `yaml:"seed"`
-	Iterations          int                `yaml:"iterations"`
-	Workers             int                `yaml:"workers"`
-	PlanCacheOnly       bool               `yaml:"plan_cache_only"`
-	PlanCacheProb       int                `yaml:"plan_cache_prob"`
-	NonPreparedProb     int                `yaml:"non_prepared_plan_cache_prob"`
-	PlanCacheMeaningful bool               `yaml:"plan_cache_meaningful_predicates"`
-	MaxTables           int                `yaml:"max_tables"`
-	MaxJoinTables       int                `yaml:"max_join_tables"`
-	MaxColumns          int                `yaml:"max_columns"`
-	MaxRowsPerTable     int                `yaml:"max_rows_per_table"`
-	MaxDataDumpRows     int                `yaml:"max_data_dump_rows"`
-	MaxInsertStatements int                `yaml:"max_insert_statements"`
-	StatementTimeoutMs  int                `yaml:"statement_timeout_ms"`
-	PlanReplayer        PlanReplayer       `yaml:"plan_replayer"`
-	Storage             StorageConfig      `yaml:"storage"`
-	Features            Features           `yaml:"features"`
-	Weights             Weights            `yaml:"weights"`
-	Adaptive            Adaptive           `yaml:"adaptive"`
-	Logging             Logging            `yaml:"logging"`
-	Oracles             OracleConfig       `yaml:"oracles"`
-	MPP                 MPPConfig          `yaml:"mpp"`
-	QPG                 QPGConfig          `yaml:"qpg"`
-	KQE                 KQEConfig          `yaml:"kqe"`
-	TQS                 TQSConfig          `yaml:"tqs"`
-	Signature           SignatureConfig    `yaml:"signature"`
-	Minimize            MinimizeConfig     `yaml:"minimize"`
-	RunInfo             *runinfo.BasicInfo `yaml:"-"`
+	DSN string `yaml:"dsn"`
+	// Targets lists several databases to fuzz in one process. Each target
+	// gets its own runners, and a scheduler interleaves their iterations.
+	Targets []TargetConfig `yaml:"targets"`
+	// Target names the target a runner fuzzes; ForTarget sets it.
+	Target string `yaml:"-"`
+	// DSNPassword replaces the password in DSN when set. Like the storage
+	// credentials and the chaos token, it may be a secret reference that
+	// secrets.ResolveConfig fetches at startup.
+	DSNPassword   string   `yaml:"dsn_password"`
+	SessionInit   []string `yaml:"session_init"`
+	Database      string   `yaml:"database"`
+	Seed          int64    `yaml:"seed"`
+	Iterations    int      `yaml:"iterations"`
+	Workers       int      `yaml:"workers"`
+	PlanCacheOnly bool     `yaml:"plan_cache_only"`
+	// FocusAreas names features touched by an upcoming release, such as
+	// "window functions" or "partition pruning". Each one turns on its
+	// generator features and raises its feature and oracle weights.
+	FocusAreas      []string `yaml:"focus_areas"`
+	NonPreparedProb int      `yaml:"non_prepared_plan_cache_prob"`
+	// PlanCacheProb is the percent of query actions that ran a plan cache
+	// check before PlanCache became an oracle (default 50). While it and
+	// weights.oracles.plan_cache are positive, the weight is replaced by the
+	// one that keeps that share; 0 uses the weight as configured.
+	PlanCacheProb       int  `yaml:"plan_cache_prob"`
+	PlanCacheMeaningful bool `yaml:"plan_cache_meaningful_predicates"`
+	MaxTables           int  `yaml:"max_tables"`
+	MaxJoinTables       int  `yaml:"max_join_tables"`
+	MaxColumns          int  `yaml:"max_columns"`
+	MaxRowsPerTable     int  `yaml:"max_rows_per_table"`
+	MaxDataDumpRows     int  `yaml:"max_data_dump_rows"`
+	MaxInsertStatements int  `yaml:"max_insert_statements"`
+	InsertBatchRows     int  `yaml:"insert_batch_rows"`
+	// InsertFillBatchRows is the rows per INSERT that fill a new table up to
+	// MaxRowsPerTable and top tables up when a scale step raises it.
+	InsertFillBatchRows int `yaml:"insert_fill_batch_rows"`
+	// InsertNullRowProb and InsertDefaultRowProb are the percent chances that
+	// a row of a multi-row INSERT writes NULL or DEFAULT into every nullable
+	// column outside the primary and foreign keys.
+	InsertNullRowProb    int                    `yaml:"insert_null_row_prob"`
+	InsertDefaultRowProb int                    `yaml:"insert_default_row_prob"`
+	ScaleSchedule        []ScaleStep            `yaml:"scale_schedule"`
+	StatementTimeoutMs   int                    `yaml:"statement_timeout_ms"`
+	PlanReplayer         PlanReplayer           `yaml:"plan_replayer"`
+	Storage              StorageConfig          `yaml:"storage"`
+	Features             Features               `yaml:"features"`
+	Weights              Weights                `yaml:"weights"`
+	Adaptive             Adaptive               `yaml:"adaptive"`
+	Logging              Logging                `yaml:"logging"`
+	Oracles              OracleConfig           `yaml:"oracles"`
+	MPP                  MPPConfig              `yaml:"mpp"`
+	QPG                  QPGConfig              `yaml:"qpg"`
+	KQE                  KQEConfig              `yaml:"kqe"`
+	TQS                  TQSConfig              `yaml:"tqs"`
+	Signature            SignatureConfig        `yaml:"signature"`
+	PlanStability        PlanStabilityConfig    `yaml:"plan_stability"`
+	ClusterImpact        ClusterImpactConfig    `yaml:"cluster_impact"`
+	JUnit                JUnitConfig            `yaml:"junit"`
+	Stop                 StopConfig             `yaml:"stop"`
+	Minimize             MinimizeConfig         `yaml:"minimize"`
+	ValueGenerators      []ValueGeneratorConfig `yaml:"value_generators"`
+	DataProfiles         []DataProfileConfig    `yaml:"data_profiles"`
+	Hooks                HooksConfig            `yaml:"hooks"`
+	TiDBLogs             TiDBLogsConfig         `yaml:"tidb_logs"`
+	Workload             WorkloadConfig         `yaml:"workload"`
+	Hang                 HangConfig             `yaml:"hang"`
+	Readiness            ReadinessConfig        `yaml:"readiness"`
+	Selectivity          SelectivityConfig      `yaml:"selectivity"`
+	StateSnapshot        StateSnapshotConfig    `yaml:"state_snapshot"`
+	Pacing               PacingConfig           `yaml:"pacing"`
+	Latency              LatencyConfig          `yaml:"latency"`
+	Chaos                ChaosConfig            `yaml:"chaos"`
+	ResourceGroup        ResourceGroupConfig    `yaml:"resource_group"`
+	Shadow               ShadowConfig           `yaml:"shadow"`
+	RunInfo              *runinfo.BasicInfo     `yaml:"-"`
+}
+
+// TiDBLogsConfig fetches the tail of tidb-server logs into panic and internal
+// error cases. Each source sets exactly one of Path (a mounted log file), URL
+// (a sidecar answering GET with the log tail; `lines=N` is added to the query),
+// or Command (run through `sh -c` with SHIRO_LOG_LINES set, for example an ssh tail).
+type TiDBLogsConfig struct {
+	Lines          int             `yaml:"lines"`
+	TimeoutSeconds int             `yaml:"timeout_seconds"`
+	Sources        []TiDBLogSource `yaml:"sources"`
+}
+
+// PacingConfig slows the iteration loop while the cluster is unhealthy. Once
+// at least ErrorPercent of the last Window statements failed with a transient
+// error (timeout, lost connection, unavailable region or TiKV), every
+// iteration sleeps, starting at MinDelayMs and doubling up to MaxDelayMs. The
+// delay halves once the rate is below half the threshold, and full speed
+// resumes when it drops under MinDelayMs.
+type PacingConfig struct {
+	Enabled      bool `yaml:"enabled"`
+	Window       int  `yaml:"window"`
+	ErrorPercent int  `yaml:"error_percent"`
+	MinDelayMs   int  `yaml:"min_delay_ms"`
+	MaxDelayMs   int  `yaml:"max_delay_ms"`
+}
+
+// LatencyConfig records client-side statement latency histograms per oracle
+// and statement class. A statement whose normalized digest already ran
+// MinSamples times turns into a latency regression case when it takes at
+// least RegressionFactor times the fastest earlier run and at least
+// MinRegressionMs. At most MaxDigests digests are tracked and MaxCases cases
+// are reported per run.
+type LatencyConfig struct {
+	Enabled          bool `yaml:"enabled"`
+	RegressionFactor int  `yaml:"regression_factor"`
+	MinSamples       int  `yaml:"min_samples"`
+	MinRegressionMs  int  `yaml:"min_regression_ms"`
+	MaxDigests       int  `yaml:"max_digests"`
+	MaxCases         int  `yaml:"max_cases"`
+}
+
+// ChaosConfig injects chaos-mesh experiments on a schedule. Every
+// IntervalSeconds the next experiment in Experiments starts through the
+// chaos-mesh dashboard API at DashboardURL and is removed after
+// DurationSeconds, so clean and faulty time slices alternate. Built-in
+// templates select pods of the TiDB Operator cluster Cluster in Namespace.
+// Cases record the experiments active when they were captured.
+type ChaosConfig struct {
+	Enabled         bool              `yaml:"enabled"`
+	DashboardURL    string            `yaml:"dashboard_url"`
+	Token           string            `yaml:"token"`
+	Namespace       string            `yaml:"namespace"`
+	Cluster         string            `yaml:"cluster"`
+	IntervalSeconds int               `yaml:"interval_seconds"`
+	DurationSeconds int               `yaml:"duration_seconds"`
+	Experiments     []ChaosExperiment `yaml:"experiments"`
+}
+
+// ChaosExperiment is one chaos-mesh experiment. Template names a built-in
+// experiment (ChaosTemplateLeaderTransfer, ChaosTemplateNetworkPartition, or
+// ChaosTemplateIODelay) whose spec Spec keys override. Without a template,
+// Kind and Spec give a raw chaos-mesh experiment, such as a StressChaos.
+type ChaosExperiment struct {
+	Name     string         `yaml:"name"`
+	Template string         `yaml:"template"`
+	Kind     string         `yaml:"kind"`
+	Spec     map[string]any `yaml:"spec"`
+}
+
+// Built-in chaos experiment templates.
+const (
+	ChaosTemplateLeaderTransfer   = "leader_transfer"
+	ChaosTemplateNetworkPartition = "network_partition"
+	ChaosTemplateIODelay          = "io_delay"
+)
+
+// ResourceGroupConfig runs the fuzzer's sessions in a TiDB resource group
+// limited to RUPerSec request units per second, so a run cannot starve other
+// tenants of a shared cluster. The group Name is created or altered at
+// startup, and every fuzzing connection joins it. Priority is low, medium, or
+// high; Burstable lets the group use idle capacity beyond its limit.
+type ResourceGroupConfig struct {
+	Enabled   bool   `yaml:"enabled"`
+	Name      string `yaml:"name"`
+	RUPerSec  int    `yaml:"ru_per_sec"`
+	Priority  string `yaml:"priority"`
+	Burstable bool   `yaml:"burstable"`
+}
+
+// ShadowConfig replays every captured case on a second, lightweight TiDB,
+// such as a tidb-server started with --store=unistore, and records in the case
+// whether the bug reproduces there. A bug that reproduces without TiKV and
+// TiFlash is in TiDB itself. Each runner replays in <database>_shadow on the
+// shadow server, with a budget of TimeoutSeconds per case.
+type ShadowConfig struct {
+	Enabled        bool   `yaml:"enabled"`
+	DSN            string `yaml:"dsn"`
+	TimeoutSeconds int    `yaml:"timeout_seconds"`
+}
+
+// StateSnapshotConfig exports the whole database into panic and data
+// corruption cases, so storage-layer bugs can be restored exactly. Tool is
+// dumpling (written to <case_dir>/state_snapshot and uploaded with the case)
+// or br (backed up to <BRStorage>/<case_id>, which needs PDAddr). Command
+// replaces the built-in command line and runs through `sh -c` with the
+// SHIRO_SNAPSHOT_* variables set. Databases above MaxMB are skipped, and at
+// most MaxPerRun snapshots are taken per run.
+type StateSnapshotConfig struct {
+	Enabled        bool   `yaml:"enabled"`
+	Tool           string `yaml:"tool"`
+	Command        string `yaml:"command"`
+	PDAddr         string `yaml:"pd_addr"`
+	BRStorage      string `yaml:"br_storage"`
+	MaxMB          int    `yaml:"max_mb"`
+	MaxPerRun      int    `yaml:"max_per_run"`
+	TimeoutSeconds int    `yaml:"timeout_seconds"`
+}
+
+// State snapshot tools.
+const (
+	StateSnapshotDumpling = "dumpling"
+	StateSnapshotBR       = "br"
+)
+
+// WorkloadConfig runs a small OLTP read/write mix on dedicated tables in the
+// <database>_bg schema while the oracles run, so plan cache, statistics, and
+// transaction code paths see concurrent load. Each worker runs transactions of
+// TxnStatements statements, ReadPercent of them reads, at up to TxnPerSecond
+// transactions per second (0 means unthrottled).
+type WorkloadConfig struct {
+	Enabled       bool `yaml:"enabled"`
+	Workers       int  `yaml:"workers"`
+	Tables        int  `yaml:"tables"`
+	Rows          int  `yaml:"rows"`
+	ReadPercent   int  `yaml:"read_percent"`
+	TxnStatements int  `yaml:"txn_statements"`
+	TxnPerSecond  int  `yaml:"txn_per_second"`
+}
+
+// ScaleStep changes the schema and data budgets once the run reaches
+// AtIteration, so one campaign can start small for query throughput and grow
+// later to stress volume. Zero fields keep the value of the previous step.
+// Without InsertBatchRows, the INSERT batch size grows with MaxRowsPerTable.
+type ScaleStep struct {
+	AtIteration     int `yaml:"at_iteration"`
+	MaxTables       int `yaml:"max_tables"`
+	MaxRowsPerTable int `yaml:"max_rows_per_table"`
+	InsertBatchRows int `yaml:"insert_batch_rows"`
+}
+
+// normalizeScaleSchedule drops steps that change nothing, clamps negative
+// values, and orders steps by iteration.
+func normalizeScaleSchedule(steps []ScaleStep) []ScaleStep {
+	if len(steps) == 0 {
+		return nil
+	}
+	out := make([]ScaleStep, 0, len(steps))
+	for _, step := range steps {
+		step.AtIteration = max(step.AtIteration, 0)
+		step.MaxTables = max(step.MaxTables, 0)
+		step.MaxRowsPerTable = max(step.MaxRowsPerTable, 0)
+		step.InsertBatchRows = max(step.InsertBatchRows, 0)
+		if step.MaxTables == 0 && step.MaxRowsPerTable == 0 && step.InsertBatchRows == 0 {
+			continue
+		}
+		out = append(out, step)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].AtIteration < out[j].AtIteration
+	})
+	return out
+}
+
+// TiDBLogSource is one tidb-server log location.
+type TiDBLogSource struct {
+	Name    string `yaml:"name"`
+	Path    string `yaml:"path"`
+	URL     string `yaml:"url"`
+	Command string `yaml:"command"`
+}
+
+// ValueGeneratorConfig maps matching columns to a built-in value generator preset.
+// Column is a regular expression matched against the column name or table.column;
+// Types optionally narrows the preset to some column types (for example "varchar").
+type ValueGeneratorConfig struct {
+	Column string   `yaml:"column"`
+	Types  []string `yaml:"types"`
+	Preset string   `yaml:"preset"`
+}
+
+// normalizeValueGenerators trims rule fields and drops rules without a preset.
+func normalizeValueGenerators(rules []ValueGeneratorConfig) []ValueGeneratorConfig {
+	if len(rules) == 0 {
+		return nil
+	}
+	out := make([]ValueGeneratorConfig, 0, len(rules))
+	for _, rule := range rules {
+		rule.Preset = strings.ToLower(strings.TrimSpace(rule.Preset))
+		if rule.Preset == "" {
+			continue
+		}
+		rule.Column = strings.TrimSpace(rule.Column)
+		types := make([]string, 0, len(rule.Types))
+		for _, t := range rule.Types {
+			if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+				types = append(types, t)
+			}
+		}
+		rule.Types = types
+		out = append(out, rule)
+	}
+	return out
+}
+
+// DataProfileConfig shapes generated INSERT data for matching tables.
+// Table is a regular expression on the table name (empty matches all tables);
+// the first matching profile wins. Probabilities are percentages (0-100).
+type DataProfileConfig struct {
+	Name            string  `yaml:"name"`
+	Table           string  `yaml:"table"`
+	NullProb        int     `yaml:"null_prob"`
+	ZipfS           float64 `yaml:"zipf_s"`
+	HotValues       int     `yaml:"hot_values"`
+	CorrelationProb int     `yaml:"correlation_prob"`
+}
+
+// normalizeDataProfiles fills profile names, clamps probabilities, and disables
+// Zipf skew when the exponent is not greater than 1.
+func normalizeDataProfiles(profiles []DataProfileConfig) []DataProfileConfig {
+	if len(profiles) == 0 {
+		return nil
+	}
+	out := make([]DataProfileConfig, 0, len(profiles))
+	for i, profile := range profiles {
+		profile.Name = strings.TrimSpace(profile.Name)
+		if profile.Name == "" {
+			profile.Name = fmt.Sprintf("profile_%d", i)
+		}
+		profile.Table = strings.TrimSpace(profile.Table)
+		profile.NullProb = clampPercent(profile.NullProb)
+		profile.CorrelationProb = clampPercent(profile.CorrelationProb)
+		if profile.ZipfS <= 1 {
+			profile.ZipfS = 0
+			profile.HotValues = 0
+		} else if profile.HotValues <= 1 {
+			profile.HotValues = dataProfileHotValuesDefault
+		}
+		out = append(out, profile)
+	}
+	return out
+}
+
+func clampPercent(v int) int {
+	return min(max(v, 0), 100)
+}
+
+// normalizeFixControls trims the configured tidb_opt_fix_control IDs and
+// drops duplicates and entries that are not a number.
+func normalizeFixControls(ids []string) []string {
+	var out []string
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id == "" || strings.Trim(id, "0123456789") != "" || slices.Contains(out, id) {
+			continue
+		}
+		out = append(out, id)
+	}
+	return out
+}
+
+// clampLargeRowLimit replaces an unset limit with its default and keeps it
+// within [lo, hi].
+func clampLargeRowLimit(v int, def int, lo int, hi int) int {
+	if v <= 0 {
+		return def
+	}
+	return min(max(v, lo), hi)
+}
+
+// HooksConfig lists shell or SQL hooks run at runner lifecycle points.
+type HooksConfig struct {
+	RunStart       []HookConfig `yaml:"run_start"`
+	DatabaseRotate []HookConfig `yaml:"database_rotate"`
+	CaseCaptured   []HookConfig `yaml:"case_captured"`
+}
+
+// HookConfig describes one hook. Shell runs through `sh -c`; SQL statements run
+// on the fuzz connection. Both may be set; failures are logged and never stop the run.
+type HookConfig struct {
+	Name           string   `yaml:"name"`
+	Shell          string   `yaml:"shell"`
+	SQL            []string `yaml:"sql"`
+	TimeoutSeconds int      `yaml:"timeout_seconds"`
+}
+
+// Empty reports whether no hook is configured.
+func (h HooksConfig) Empty() bool {
+	return len(h.RunStart) == 0 && len(h.DatabaseRotate) == 0 && len(h.CaseCaptured) == 0
+}
+
+// normalizeTiDBLogSources trims source fields, drops sources without a
+// location, and fills default names.
+func normalizeTiDBLogSources(sources []TiDBLogSource) []TiDBLogSource {
+	if len(sources) == 0 {
+		return nil
+	}
+	out := make([]TiDBLogSource, 0, len(sources))
+	for i, src := range sources {
+		src.Path = strings.TrimSpace(src.Path)
+		src.URL = strings.TrimSpace(src.URL)
+		src.Command = strings.TrimSpace(src.Command)
+		if src.Path == "" && src.URL == "" && src.Command == "" {
+			continue
+		}
+		src.Name = strings.TrimSpace(src.Name)
+		if src.Name == "" {
+			src.Name = fmt.Sprintf("tidb_%d", i)
+		}
+		out = append(out, src)
+	}
+	return out
+}
+
+// NormalizeSessionInit trims the session_init statements and drops empty
+// ones. A bare variable assignment such as "tidb_mem_quota_query=1073741824"
+// becomes "SET SESSION tidb_mem_quota_query=1073741824", and an @@-prefixed
+// one only gets SET. Anything else, for example "SET NAMES utf8mb4", runs as
+// written.
+func NormalizeSessionInit(statements []string) []string {
+	if len(statements) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(statements))
+	for _, stmt := range statements {
+		stmt = strings.TrimSpace(strings.TrimRight(strings.TrimSpace(stmt), ";"))
+		if stmt == "" {
+			continue
+		}
+		if isSessionAssignment(stmt) {
+			if strings.HasPrefix(stmt, "@@") {
+				stmt = "SET " + stmt
+			} else {
+				stmt = "SET SESSION " + stmt
+			}
+		}
+		out = append(out, stmt)
+	}
+	return out
+}
+
+// isSessionAssignment reports whether stmt starts with "name=", where name is a
+// plain or @@-prefixed variable name.
+func isSessionAssignment(stmt string) bool {
+	eq := strings.IndexByte(stmt, '=')
+	if eq <= 0 {
+		return false
+	}
+	name := strings.TrimSpace(stmt[:eq])
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r != '_' && r != '@' && r != '.' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeWorkload fills unset sizes and clamps the read mix and rate.
+func normalizeWorkload(w *WorkloadConfig) {
+	if w.Workers <= 0 {
+		w.Workers = workloadWorkersDefault
+	}
+	if w.Tables <= 0 {
+		w.Tables = workloadTablesDefault
+	}
+	if w.Rows <= 0 {
+		w.Rows = workloadRowsDefault
+	}
+	if w.TxnStatements <= 0 {
+		w.TxnStatements = workloadTxnStatementsDefault
+	}
+	w.ReadPercent = min(max(w.ReadPercent, 0), 100)
+	w.TxnPerSecond = max(w.TxnPerSecond, 0)
+}
+
+// normalizePacing replaces unset values with their defaults and keeps the
+// maximum delay at or above the minimum.
+func normalizePacing(p *PacingConfig) {
+	if p.Window <= 0 {
+		p.Window = pacingWindowDefault
+	}
+	if p.ErrorPercent <= 0 || p.ErrorPercent > 100 {
+		p.ErrorPercent = pacingErrorPercentDefault
+	}
+	if p.MinDelayMs <= 0 {
+		p.MinDelayMs = pacingMinDelayMsDefault
+	}
+	if p.MaxDelayMs <= 0 {
+		p.MaxDelayMs = pacingMaxDelayMsDefault
+	}
+	p.MaxDelayMs = max(p.MaxDelayMs, p.MinDelayMs)
+}
+
+// normalizeLatency replaces unset values with their defaults. A factor below
+// 2 would flag ordinary jitter, so it is raised to 2.
+func normalizeLatency(l *LatencyConfig) {
+	if l.RegressionFactor <= 0 {
+		l.RegressionFactor = latencyFactorDefault
+	}
+	l.RegressionFactor = max(l.RegressionFactor, 2)
+	if l.MinSamples <= 0 {
+		l.MinSamples = latencyMinSamplesDefault
+	}
+	if l.MinRegressionMs <= 0 {
+		l.MinRegressionMs = latencyMinMsDefault
+	}
+	if l.MaxDigests <= 0 {
+		l.MaxDigests = latencyMaxDigestsDefault
+	}
+	if l.MaxCases < 0 {
+		l.MaxCases = 0
+	}
+}
+
+// normalizeChaos fills unset values with their defaults, keeps each
+// experiment within its slice, and runs every built-in template when no
+// experiment is listed.
+func normalizeChaos(c *ChaosConfig) {
+	c.DashboardURL = strings.TrimRight(strings.TrimSpace(c.DashboardURL), "/")
+	c.Namespace = strings.TrimSpace(c.Namespace)
+	if c.Namespace == "" {
+		c.Namespace = chaosNamespaceDefault
+	}
+	c.Cluster = strings.TrimSpace(c.Cluster)
+	if c.IntervalSeconds <= 0 {
+		c.IntervalSeconds = chaosIntervalDefault
+	}
+	if c.DurationSeconds <= 0 {
+		c.DurationSeconds = chaosDurationDefault
+	}
+	c.DurationSeconds = min(c.DurationSeconds, c.IntervalSeconds)
+	if len(c.Experiments) == 0 {
+		for _, template := range []string{ChaosTemplateLeaderTransfer, ChaosTemplateNetworkPartition, ChaosTemplateIODelay} {
+			c.Experiments = append(c.Experiments, ChaosExperiment{Template: template})
+		}
+	}
+	for i := range c.Experiments {
+		exp := &c.Experiments[i]
+		exp.Template = strings.ToLower(strings.TrimSpace(exp.Template))
+		exp.Kind = strings.TrimSpace(exp.Kind)
+		exp.Name = strings.TrimSpace(exp.Name)
+		if exp.Name == "" {
+			exp.Name = exp.Template
+		}
+		if exp.Name == "" {
+			exp.Name = strings.ToLower(exp.Kind)
+		}
+	}
+}
+
+// normalizeResourceGroup fills unset values with their defaults and falls
+// back to low priority for unknown priorities.
+func normalizeResourceGroup(g *ResourceGroupConfig) {
+	g.Name = strings.TrimSpace(g.Name)
+	if g.Name == "" {
+		g.Name = resourceGroupNameDefault
+	}
+	if g.RUPerSec <= 0 {
+		g.RUPerSec = resourceGroupRUDefault
+	}
+	g.Priority = strings.ToLower(strings.TrimSpace(g.Priority))
+	switch g.Priority {
+	case "low", "medium", "high":
+	default:
+		g.Priority = "low"
+	}
+}
+
+// normalizeStateSnapshot falls back to dumpling for unknown tools and
+// replaces unset limits with their defaults.
+func normalizeStateSnapshot(s *StateSnapshotConfig) {
+	s.Tool = strings.ToLower(strings.TrimSpace(s.Tool))
+	if s.Tool != StateSnapshotBR {
+		s.Tool = StateSnapshotDumpling
+	}
+	s.Command = strings.TrimSpace(s.Command)
+	s.PDAddr = strings.TrimSpace(s.PDAddr)
+	s.BRStorage = strings.TrimRight(strings.TrimSpace(s.BRStorage), "/")
+	if s.MaxMB <= 0 {
+		s.MaxMB = stateSnapshotMaxMBDefault
+	}
+	if s.MaxPerRun <= 0 {
+		s.MaxPerRun = stateSnapshotMaxPerRunDefault
+	}
+	if s.TimeoutSeconds <= 0 {
+		s.TimeoutSeconds = stateSnapshotTimeoutDefault
+	}
+}
+
+// normalizeOracleBudget replaces unset sizes with their defaults.
+func normalizeOracleBudget(b *OracleBudgetConfig) {
+	if b.Window <= 0 {
+		b.Window = oracleBudgetWindowDefault
+	}
+	b.MinProductivePercent = clampPercent(b.MinProductivePercent)
+	if b.CooldownRuns <= 0 {
+		b.CooldownRuns = oracleBudgetCooldownDefault
+	}
+	if b.ProbeRuns <= 0 {
+		b.ProbeRuns = oracleBudgetProbeRunsDefault
+	}
+	b.ProbeRuns = min(b.ProbeRuns, b.Window)
+}
+
+// normalizeOracleDrift clamps the probability and drops unknown kinds; an
+// empty list falls back to every kind.
+func normalizeOracleDrift(d *OracleDriftConfig) {
+	d.Probability = clampPercent(d.Probability)
+	kinds := make([]string, 0, len(d.Kinds))
+	for _, kind := range d.Kinds {
+		kind = strings.ToLower(strings.TrimSpace(kind))
+		switch kind {
+		case DriftAnalyze, DriftAddIndex, DriftPlanCacheFlush:
+			if !slices.Contains(kinds, kind) {
+				kinds = append(kinds, kind)
+			}
+		}
+	}
+	if len(kinds) == 0 {
+		kinds = []string{DriftAnalyze, DriftAddIndex, DriftPlanCacheFlush}
+	}
+	d.Kinds = kinds
+}
+
+// normalizeSelectivity clamps the percentages and falls back to the default
+// mix when no bucket has a positive weight.
+func normalizeSelectivity(s *SelectivityConfig) {
+	s.SampleProb = min(max(s.SampleProb, 0), 100)
+	if s.RareMaxPercent <= 0 || s.RareMaxPercent >= 100 {
+		s.RareMaxPercent = selectivityRareMaxDefault
+	}
+	s.Mix.Empty = max(s.Mix.Empty, 0)
+	s.Mix.Rare = max(s.Mix.Rare, 0)
+	s.Mix.Moderate = max(s.Mix.Moderate, 0)
+	s.Mix.All = max(s.Mix.All, 0)
+	if s.Mix.Empty+s.Mix.Rare+s.Mix.Moderate+s.Mix.All == 0 {
+		s.Mix = selectivityMixDefault
+	}
+}
+
+func normalizeHooks(hooks HooksConfig) HooksConfig {
+	return HooksConfig{
+		RunStart:       normalizeHookList("run_start", hooks.RunStart),
+		DatabaseRotate: normalizeHookList("database_rotate", hooks.DatabaseRotate),
+		CaseCaptured:   normalizeHookList("case_captured", hooks.CaseCaptured),
+	}
+}
+
+// normalizeHookList trims hook fields, drops hooks without a command, and
+// fills default names and timeouts.
+func normalizeHookList(stage string, hooks []HookConfig) []HookConfig {
+	if len(hooks) == 0 {
+		return nil
+	}
+	out := make([]HookConfig, 0, len(hooks))
+	for i, hook := range hooks {
+		hook.Shell = strings.TrimSpace(hook.Shell)
+		stmts := make([]string, 0, len(hook.SQL))
+		for _, stmt := range hook.SQL {
+			if stmt = strings.TrimSpace(stmt); stmt != "" {
+				stmts = append(stmts, stmt)
+			}
+		}
+		hook.SQL = stmts
+		if hook.Shell == "" && len(hook.SQL) == 0 {
+			continue
+		}
+		hook.Name = strings.TrimSpace(hook.Name)
+		if hook.Name == "" {
+			hook.Name = fmt.Sprintf("%s_%d", stage, i)
+		}
+		if hook.TimeoutSeconds <= 0 {
+			hook.TimeoutSeconds = hookTimeoutSecondsDefault
+		}
+		out = append(out, hook)
+	}
+	return out
 }
 
 // PlanReplayer controls plan replayer dumping and download.
@@ -50,6 +690,9 @@ type PlanReplayer struct {
 	OutputDir           string `yaml:"output_dir"`
 	TimeoutSeconds      int    `yaml:"timeout_seconds"`
 	MaxDownloadBytes    int64  `yaml:"max_download_bytes"`
+	// MinFreeDiskMB switches case capture to metadata-only (no data dumps or
+	// plan replayer zips) while free space under OutputDir is below it. 0 disables.
+	MinFreeDiskMB int64 `yaml:"min_free_disk_mb"`
 }
 
 // Features toggles SQL capabilities in generation.
@@ -84,10 +727,23 @@ type Features struct {
 	ForeignKeys          bool `yaml:"foreign_keys"`
 	CheckConstraints     bool `yaml:"check_constraints"`
 	PartitionTables      bool `yaml:"partition_tables"`
+	// LargeRowTables turns weights.features.large_row_prob percent of the
+	// generated tables into wide or many-index tables within the
+	// oracles.large_row bounds.
+	LargeRowTables bool `yaml:"large_row_tables"`
+	// ClusteredIndex declares every generated primary key CLUSTERED or
+	// NONCLUSTERED and varies its columns (composite and string-leading keys).
+	ClusteredIndex       bool `yaml:"clustered_index"`
 	NotExists            bool `yaml:"not_exists"`
 	NotIn                bool `yaml:"not_in"`
 	NonPreparedPlanCache bool `yaml:"non_prepared_plan_cache"`
 	DSG                  bool `yaml:"dsg"`
+	// ExtendedTypes adds BIT, ENUM, SET, and YEAR columns to generated tables.
+	ExtendedTypes bool `yaml:"extended_types"`
+	// ForeignKeyViolationProb is the percent chance that an INSERT into a
+	// child table references a missing parent key, so FK enforcement is
+	// tested too. TiDB must reject such statements.
+	ForeignKeyViolationProb int `yaml:"foreign_key_violation_prob"`
 }
 
 // Weights controls weighted selections for actions and features.
@@ -98,11 +754,13 @@ type Weights struct {
 	Features FeatureWeights `yaml:"features"`
 }
 
-// ActionWeights sets probabilities for DDL/DML/Query.
+// ActionWeights sets probabilities for DDL/DML/Query. Storyline picks a
+// scripted multi-step schema evolution on a fresh table instead of one DDL.
 type ActionWeights struct {
-	DDL   int `yaml:"ddl"`
-	DML   int `yaml:"dml"`
-	Query int `yaml:"query"`
+	DDL       int `yaml:"ddl"`
+	DML       int `yaml:"dml"`
+	Query     int `yaml:"query"`
+	Storyline int `yaml:"storyline"`
 }
 
 // DMLWeights sets probabilities for DML operations.
@@ -114,15 +772,32 @@ type DMLWeights struct {
 
 // OracleWeights sets probabilities for oracle selection.
 type OracleWeights struct {
-	NoREC       int `yaml:"norec"`
-	TLP         int `yaml:"tlp"`
-	EET         int `yaml:"eet"`
-	DQP         int `yaml:"dqp"`
-	PQS         int `yaml:"pqs"`
-	CODDTest    int `yaml:"coddtest"`
-	DQE         int `yaml:"dqe"`
-	Impo        int `yaml:"impo"`
-	GroundTruth int `yaml:"groundtruth"`
+	NoREC          int `yaml:"norec"`
+	TLP            int `yaml:"tlp"`
+	EET            int `yaml:"eet"`
+	DQP            int `yaml:"dqp"`
+	PQS            int `yaml:"pqs"`
+	CODDTest       int `yaml:"coddtest"`
+	DQE            int `yaml:"dqe"`
+	Impo           int `yaml:"impo"`
+	GroundTruth    int `yaml:"groundtruth"`
+	TxnRYW         int `yaml:"txn_ryw"`
+	CTEInline      int `yaml:"cte_inline"`
+	FKCascade      int `yaml:"fk_cascade"`
+	Savepoint      int `yaml:"savepoint"`
+	TiFlashOnly    int `yaml:"tiflash_only"`
+	AutoID         int `yaml:"auto_id"`
+	ResultType     int `yaml:"result_type"`
+	PlanCache      int `yaml:"plan_cache"`
+	BatchDML       int `yaml:"batch_dml"`
+	DecimalArith   int `yaml:"decimal_arith"`
+	FullGroupBy    int `yaml:"full_group_by"`
+	LargeRow       int `yaml:"large_row"`
+	FullJoin       int `yaml:"full_join"`
+	Privilege      int `yaml:"privilege"`
+	CursorFetch    int `yaml:"cursor_fetch"`
+	InList         int `yaml:"in_list"`
+	PartitionRange int `yaml:"partition_range"`
 }
 
 // FeatureWeights sets feature generation weights.
@@ -140,11 +815,16 @@ type FeatureWeights struct {
 	DistinctProb             int `yaml:"distinct_prob"`
 	WindowProb               int `yaml:"window_prob"`
 	PartitionProb            int `yaml:"partition_prob"`
+	LargeRowProb             int `yaml:"large_row_prob"`
+	ClusteredPKProb          int `yaml:"clustered_pk_prob"`
+	CompositePKProb          int `yaml:"composite_pk_prob"`
 	NotExistsProb            int `yaml:"not_exists_prob"`
 	NotInProb                int `yaml:"not_in_prob"`
 	IndexPrefixProb          int `yaml:"index_prefix_prob"`
 	TemplateJoinOnlyWeight   int `yaml:"template_join_only_weight"`
 	TemplateJoinFilterWeight int `yaml:"template_join_filter_weight"`
+	AliasAmbiguityProb       int `yaml:"alias_ambiguity_prob"`
+	NullAwareProb            int `yaml:"null_aware_prob"`
 }
 
 // Logging controls stdout logging behavior.
@@ -152,9 +832,29 @@ type Logging struct {
 	Verbose               bool              `yaml:"verbose"`
 	ReportIntervalSeconds int               `yaml:"report_interval_seconds"`
 	LogFile               string            `yaml:"log_file"`
+	SQLLog                SQLLogConfig      `yaml:"sql_log"`
+	QuerySample           QuerySampleConfig `yaml:"query_sample"`
 	Metrics               MetricsThresholds `yaml:"metrics"`
 }
 
+// SQLLogConfig controls the on-disk statement log used for postmortems.
+type SQLLogConfig struct {
+	Enabled   bool   `yaml:"enabled"`
+	Dir       string `yaml:"dir"`
+	MaxSizeMB int    `yaml:"max_size_mb"`
+	MaxFiles  int    `yaml:"max_files"`
+}
+
+// QuerySampleConfig persists a random sample of non-bug oracle runs (query,
+// plan, signatures, skip reason) for generator tuning. Rate is the per-run
+// probability; 0 disables sampling. Dir defaults to <plan_replayer.output_dir>/sampled.
+type QuerySampleConfig struct {
+	Rate       float64 `yaml:"rate"`
+	Dir        string  `yaml:"dir"`
+	MaxSamples int     `yaml:"max_samples"`
+	Explain    bool    `yaml:"explain"`
+}
+
 // TQSConfig configures TQS-style DSG + ground-truth generation.
 type TQSConfig struct {
 	Enabled     bool    `yaml:"enabled"`
@@ -185,10 +885,15 @@ type OracleConfig struct {
 	DisableMPP                      bool              `yaml:"disable_mpp"`
 	MPPTiFlashReplica               int               `yaml:"mpp_tiflash_replica"`
 	DQPExternalHints                []string          `yaml:"dqp_external_hints"`
+	DQPHintProbe                    bool              `yaml:"dqp_hint_probe"`
 	DQPBaseHintPick                 int               `yaml:"dqp_base_hint_pick_limit"`
 	DQPSetVarHintPick               int               `yaml:"dqp_set_var_hint_pick_max"`
 	DQPComplexitySetOpsThreshold    int               `yaml:"dqp_complexity_set_ops_threshold"`
 	DQPComplexityDerivedThreshold   int               `yaml:"dqp_complexity_derived_threshold"`
+	DQPFixControlProb               int               `yaml:"dqp_fix_control_prob"`
+	DQPFixControls                  []string          `yaml:"dqp_fix_controls"`
+	DQPFixControlPerQuery           int               `yaml:"dqp_fix_control_per_query"`
+	InListMaxItems                  int               `yaml:"in_list_max_items"`
 	EETComplexityJoinTableThreshold int               `yaml:"eet_complexity_join_tables_threshold"`
 	CODDCaseWhenMax                 int               `yaml:"coddtest_case_when_max"`
 	CertMinBaseRows                 float64           `yaml:"cert_min_base_rows"`
@@ -198,20 +903,99 @@ type OracleConfig struct {
 	ImpoTimeoutMs                   int               `yaml:"impo_timeout_ms"`
 	ImpoDisableStage1               bool              `yaml:"impo_disable_stage1"`
 	ImpoKeepLRJoin                  bool              `yaml:"impo_keep_lr_join"`
+	ResultMaxRows                   int64             `yaml:"result_max_rows"`
+	ResultMaxBytes                  int64             `yaml:"result_max_bytes"`
+	ClassifyMismatch                bool              `yaml:"classify_mismatch"`
+	PanicDiagnostics                bool              `yaml:"panic_diagnostics"`
+	SnapshotPairs                   bool              `yaml:"snapshot_pairs"`
+	PipelineDepth                   int               `yaml:"pipeline_depth"`
 	EETRewrites                     EETRewriteWeights `yaml:"eet_rewrites"`
+	// Compat overrides rows of the per-oracle SQL feature compatibility
+	// matrix, keyed by lowercase oracle name (for example "tlp").
+	Compat map[string]OracleCompatOverride `yaml:"compat"`
+	// LargeRow shapes the LargeRow oracle's stress tables.
+	LargeRow LargeRowConfig `yaml:"large_row"`
+	// Budget parks oracles that rarely produce a productive run.
+	Budget OracleBudgetConfig `yaml:"budget"`
+	// Drift injects metadata changes between the two sides of pair oracles.
+	Drift OracleDriftConfig `yaml:"drift"`
+}
+
+// Drift injection kinds.
+const (
+	DriftAnalyze        = "analyze"
+	DriftAddIndex       = "add_index"
+	DriftPlanCacheFlush = "plan_cache_flush"
+)
+
+// OracleDriftConfig makes Probability percent of NoREC, TLP, DQP, and EET runs
+// execute one benign metadata change between their first and second
+// comparison query: ANALYZE TABLE, ADD INDEX on a column the query does not
+// name (dropped after the run), or an instance plan cache flush. Kinds lists
+// the changes to pick from.
+type OracleDriftConfig struct {
+	Enabled     bool     `yaml:"enabled"`
+	Probability int      `yaml:"probability"`
+	Kinds       []string `yaml:"kinds"`
+}
+
+// OracleBudgetConfig parks an oracle whose last Window runs were productive
+// (neither skipped nor failed with a whitelisted SQL error) less than
+// MinProductivePercent of the time. A parked oracle gets no weight for
+// CooldownRuns oracle runs, then probes for ProbeRuns runs; a failed probe
+// parks it again with a doubled cooldown.
+type OracleBudgetConfig struct {
+	Enabled              bool `yaml:"enabled"`
+	Window               int  `yaml:"window"`
+	MinProductivePercent int  `yaml:"min_productive_percent"`
+	CooldownRuns         int  `yaml:"cooldown_runs"`
+	ProbeRuns            int  `yaml:"probe_runs"`
+}
+
+// LargeRowConfig bounds the tables the LargeRow oracle creates: the column
+// count of wide rows, the TEXT/BLOB bytes of one long row, and the secondary
+// index count of many-index tables. The column and index counts also bound
+// the generator's large-row tables. Values are clamped below TiDB's column,
+// entry-size, and index limits.
+type LargeRowConfig struct {
+	MaxColumns      int `yaml:"max_columns"`
+	MaxPayloadBytes int `yaml:"max_payload_bytes"`
+	MaxIndexes      int `yaml:"max_indexes"`
+}
+
+// OracleCompatOverride overrides one row of the SQL feature compatibility
+// matrix. Nil fields keep the built-in value; true allows a feature and false
+// disallows it. MaxJoins of 0 removes the join cap.
+type OracleCompatOverride struct {
+	Subquery  *bool `yaml:"subquery"`
+	Aggregate *bool `yaml:"aggregate"`
+	Window    *bool `yaml:"window"`
+	Limit     *bool `yaml:"limit"`
+	OrderBy   *bool `yaml:"order_by"`
+	Distinct  *bool `yaml:"distinct"`
+	GroupBy   *bool `yaml:"group_by"`
+	Having    *bool `yaml:"having"`
+	CTE       *bool `yaml:"cte"`
+	SetOps    *bool `yaml:"set_ops"`
+	MaxJoins  *int  `yaml:"max_joins"`
 }
 
 // MPPConfig controls MPP-specific exploration switches.
 //
 // Enable=false disables Shiro-managed MPP paths (TiFlash replica setup and DQP
 // MPP set-var hints). TiFlashReplica configures table-level TiFlash replicas.
+// TiFlashMode sets ALTER TABLE ... SET TIFLASH MODE (normal or fast) once a
+// replica is available; empty keeps the server default. ColumnarOnly routes
+// every query action to the TiFlashOnly oracle.
 //
 // Note: legacy oracle-level keys are still accepted for compatibility:
 // - oracles.disable_mpp
 // - oracles.mpp_tiflash_replica
 type MPPConfig struct {
-	Enable         *bool `yaml:"enable"`
-	TiFlashReplica *int  `yaml:"tiflash_replica"`
+	Enable         *bool  `yaml:"enable"`
+	TiFlashReplica *int   `yaml:"tiflash_replica"`
+	TiFlashMode    string `yaml:"tiflash_mode"`
+	ColumnarOnly   bool   `yaml:"columnar_only"`
 }
 
 // EETRewriteWeights controls rewrite selection inside the EET oracle.
@@ -241,6 +1025,11 @@ type QPGConfig struct {
 	NoNewJoinOrderThreshold int                       `yaml:"no_new_join_order_threshold"`
 	OverrideTTL             int                       `yaml:"override_ttl"`
 	TemplateOverride        QPGTemplateOverrideConfig `yaml:"template_override"`
+	// FocusPlanSignature switches QPG from novelty seeking to convergence on plans
+	// whose hash or operator signature starts with this prefix.
+	FocusPlanSignature string `yaml:"focus_plan_signature"`
+	// FocusMissReset drops the remembered focus weights after this many consecutive misses.
+	FocusMissReset int `yaml:"focus_miss_reset"`
 }
 
 // QPGTemplateOverrideConfig configures template-level QPG adaptive overrides.
@@ -267,12 +1056,109 @@ type SignatureConfig struct {
 	PlanCacheRoundScale int `yaml:"plan_cache_round_scale"`
 }
 
+// PlanStabilityConfig controls plan-flip detection on a fixed probe query set.
+// Probe plans are re-recorded after every DDL/ANALYZE and rechecked every
+// CheckInterval iterations; a plan change without a schema or statistics
+// change is captured as a low-severity CERT case, at most MaxCases per run
+// (0 only logs the flips).
+type PlanStabilityConfig struct {
+	Enabled       bool `yaml:"enabled"`
+	Probes        int  `yaml:"probes"`
+	CheckInterval int  `yaml:"check_interval"`
+	MaxCases      int  `yaml:"max_cases"`
+}
+
+// SelectivityConfig steers generated WHERE predicates toward a mix of
+// selectivities. SampleProb percent of generated queries also run two
+// COUNT(*) probes, with and without the WHERE clause; each measurement falls
+// into the empty, rare (at most RareMaxPercent of the rows), moderate, or all
+// bucket, and the generator aims the next predicates at the bucket furthest
+// below its Mix weight.
+type SelectivityConfig struct {
+	Enabled        bool           `yaml:"enabled"`
+	SampleProb     int            `yaml:"sample_prob"`
+	RareMaxPercent int            `yaml:"rare_max_percent"`
+	Mix            SelectivityMix `yaml:"mix"`
+}
+
+// SelectivityMix weights the selectivity buckets.
+type SelectivityMix struct {
+	Empty    int `yaml:"empty"`
+	Rare     int `yaml:"rare"`
+	Moderate int `yaml:"moderate"`
+	All      int `yaml:"all"`
+}
+
+// HangConfig controls the timeout escalation ladder. A read-only statement
+// that hits statement_timeout_ms is re-run once on a quarantined connection
+// with TimeoutSeconds; if it still does not finish, the run is reported as an
+// <oracle>:hang case. MaxChecks caps escalations per run (0 means unlimited).
+// GoroutineURL is the tidb-server goroutine dump endpoint linked from and
+// fetched into hang cases; empty derives it from the plan replayer download
+// host.
+type HangConfig struct {
+	Enabled        bool   `yaml:"enabled"`
+	TimeoutSeconds int    `yaml:"timeout_seconds"`
+	MaxChecks      int    `yaml:"max_checks"`
+	GoroutineURL   string `yaml:"goroutine_url"`
+}
+
+// ReadinessConfig waits for the cluster before a run starts: the server
+// answers, PD and at least MinTiKVStores/MinTiFlashStores stores are Up, and
+// schema and statistics are loaded. WarmupQueries sacrificial SELECTs then run
+// on the initial tables before iterations are counted. When the cluster is
+// still not ready after TimeoutSeconds, the run starts with a warning, or
+// fails with FailOnTimeout. A zero store count skips that check.
+type ReadinessConfig struct {
+	Enabled             bool `yaml:"enabled"`
+	TimeoutSeconds      int  `yaml:"timeout_seconds"`
+	PollIntervalSeconds int  `yaml:"poll_interval_seconds"`
+	MinTiKVStores       int  `yaml:"min_tikv_stores"`
+	MinTiFlashStores    int  `yaml:"min_tiflash_stores"`
+	WarmupQueries       int  `yaml:"warmup_queries"`
+	FailOnTimeout       bool `yaml:"fail_on_timeout"`
+}
+
+// ClusterImpactConfig adds a cluster-impact section to the run summary. At run
+// end the runner reads STATEMENTS_SUMMARY for the digests it issued and lists
+// the TopN most expensive ones by latency, memory, and coprocessor tasks.
+type ClusterImpactConfig struct {
+	Enabled bool `yaml:"enabled"`
+	TopN    int  `yaml:"top_n"`
+}
+
+// JUnitConfig writes the run outcome as JUnit XML when the runner exits, one
+// test per oracle, so CI test UIs list fuzz failures. Dir defaults to the
+// working directory.
+type JUnitConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Dir     string `yaml:"dir"`
+}
+
+// StopConfig lets external orchestration stop a campaign cleanly. Once File
+// exists, or ObjectKey exists under the storage prefix of the enabled
+// backend, every worker finishes its current iteration, writes its reports,
+// and exits 0. The object is polled every PollSeconds (default 30).
+type StopConfig struct {
+	File        string `yaml:"file"`
+	ObjectKey   string `yaml:"object_key"`
+	PollSeconds int    `yaml:"poll_seconds"`
+}
+
 // MinimizeConfig configures case minimization.
 type MinimizeConfig struct {
 	Enabled        bool `yaml:"enabled"`
 	MaxRounds      int  `yaml:"max_rounds"`
 	TimeoutSeconds int  `yaml:"timeout_seconds"`
 	MergeInserts   bool `yaml:"merge_inserts"`
+	// VerifyMin replays min/repro.sql in a scratch database after
+	// minimization and drops the min files when the bug does not reproduce.
+	VerifyMin bool `yaml:"verify_min"`
+	// Neighborhood replays up to NeighborhoodMutants local mutations of each
+	// captured case (join swaps, negated or dropped predicates, integer
+	// literals moved by one) and records which still fail.
+	Neighborhood        bool `yaml:"neighborhood"`
+	NeighborhoodMutants int  `yaml:"neighborhood_mutants"`
 }
 
 // Adaptive configures bandit-based adaptation.
@@ -288,13 +1174,15 @@ type Adaptive struct {
 
 // StorageConfig holds external storage settings.
 type StorageConfig struct {
-	S3  S3Config  `yaml:"s3"`
-	GCS GCSConfig `yaml:"gcs"`
+	S3    S3Config           `yaml:"s3"`
+	GCS   GCSConfig          `yaml:"gcs"`
+	Local LocalStorageConfig `yaml:"local"`
 }
 
-// CloudEnabled reports whether any cloud storage backend is enabled.
-func (s StorageConfig) CloudEnabled() bool {
-	return s.GCS.Enabled || s.S3.Enabled
+// RemoteEnabled reports whether any storage backend is enabled, including a
+// local directory standing in for a bucket.
+func (s StorageConfig) RemoteEnabled() bool {
+	return s.GCS.Enabled || s.S3.Enabled || s.Local.Enabled
 }
 
 // S3Config configures S3 uploads (legacy and S3-compatible endpoints).
@@ -310,12 +1198,23 @@ type S3Config struct {
 	UsePathStyle    bool   `yaml:"use_path_style"`
 }
 
-// GCSConfig configures GCS uploads.
+// GCSConfig configures GCS uploads. CredentialsJSON holds service account
+// JSON, usually as a secret reference; it takes precedence over
+// CredentialsFile.
 type GCSConfig struct {
 	Enabled         bool   `yaml:"enabled"`
 	Bucket          string `yaml:"bucket"`
 	Prefix          string `yaml:"prefix"`
 	CredentialsFile string `yaml:"credentials_file"`
+	CredentialsJSON string `yaml:"credentials_json"`
+}
+
+// LocalStorageConfig configures uploads to a plain directory. It is used by
+// tests and air-gapped setups in place of S3 or GCS.
+type LocalStorageConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Dir     string `yaml:"dir"`
+	Prefix  string `yaml:"prefix"`
 }
 
 // Load reads configuration from a YAML file.
@@ -328,21 +1227,72 @@ func Load(path string) (Config, error) {
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return Config{}, err
 	}
+	if err := checkFocusAreas(cfg.FocusAreas); err != nil {
+		return Config{}, err
+	}
 	normalizeConfig(&cfg)
+	if err := checkTargets(cfg.Targets); err != nil {
+		return Config{}, err
+	}
 	cfg.RunInfo = runinfo.FromEnv()
 	return cfg, nil
 }
 
+// Default returns the normalized default config, the same one Load returns
+// for an empty file.
+func Default() Config {
+	cfg := defaultConfig()
+	normalizeConfig(&cfg)
+	return cfg
+}
+
 const (
 	// ViewMaxDefault is the default upper bound of generated views.
 	ViewMaxDefault = 3
 
+	// TiFlashModeNormal and TiFlashModeFast are the accepted mpp.tiflash_mode values.
+	TiFlashModeNormal = "normal"
+	TiFlashModeFast   = "fast"
+
 	dqpBaseHintPickLimitDefault             = 4
 	dqpSetVarHintPickMaxDefault             = 4
 	dqpComplexitySetOpsThresholdDefault     = 2
 	dqpComplexityDerivedThresholdDefault    = 4
+	dqpFixControlPerQueryDefault            = 4
+	dqpFixControlPerQueryMax                = 32
+	inListMaxItemsDefault                   = 1000
+	inListMaxItemsMin                       = 100
+	inListMaxItemsMax                       = 10000
+	shadowTimeoutSecondsDefault             = 60
 	eetComplexityJoinTablesThresholdDefault = 5
 	coddtestCaseWhenMaxDefault              = 2
+	pipelineDepthMax                        = 16
+
+	insertFillBatchRowsDefault = 25
+	insertFillBatchRowsMax     = 1000
+	insertMixedRowProbDefault  = 5
+
+	neighborhoodMutantsDefault = 16
+	neighborhoodMutantsMax     = 64
+
+	// The LargeRow bounds stay below TiDB's defaults: 1017 columns per table,
+	// a 6 MiB txn-entry-size-limit, and 64 indexes including the primary key.
+	largeRowMaxColumnsDefault = 256
+	largeRowMaxColumnsMin     = 16
+	largeRowMaxColumnsMax     = 1000
+	largeRowMaxPayloadDefault = 5 << 20
+	largeRowMaxPayloadMin     = 1 << 10
+	largeRowMaxPayloadMax     = 6<<20 - 64<<10
+	largeRowMaxIndexesDefault = 32
+	largeRowMaxIndexesMin     = 2
+	largeRowMaxIndexesMax     = 63
+
+	oracleBudgetWindowDefault     = 200
+	oracleBudgetMinPercentDefault = 2
+	oracleBudgetCooldownDefault   = 2000
+	oracleBudgetProbeRunsDefault  = 20
+
+	oracleDriftProbabilityDefault = 10
 
 	qpgNoJoinThresholdDefault         = 3
 	qpgNoAggThresholdDefault          = 3
@@ -352,6 +1302,7 @@ const (
 	qpgNoNewJoinTypeThresholdDefault  = 3
 	qpgNoNewJoinOrderThresholdDefault = 3
 	qpgOverrideTTLDefault             = 5
+	qpgFocusMissResetDefault          = 20
 
 	qpgTemplateNoNewJoinOrderThresholdDefault = 3
 	qpgTemplateNoNewShapeThresholdDefault     = 4
@@ -362,18 +1313,71 @@ const (
 	qpgTemplateSemiWeightBoostDefault         = 5
 	qpgTemplateEnabledProbDefault             = 55
 	qpgTemplateOverrideTTLDefault             = 5
+
+	hookTimeoutSecondsDefault     = 30
+	tidbLogLinesDefault           = 500
+	tidbLogTimeoutDefault         = 10
+	hangTimeoutSecondsDefault     = 120
+	pacingWindowDefault           = 200
+	pacingErrorPercentDefault     = 20
+	pacingMinDelayMsDefault       = 100
+	pacingMaxDelayMsDefault       = 5000
+	latencyFactorDefault          = 10
+	latencyMinSamplesDefault      = 3
+	latencyMinMsDefault           = 500
+	latencyMaxDigestsDefault      = 10000
+	latencyMaxCasesDefault        = 5
+	chaosNamespaceDefault         = "tidb-cluster"
+	chaosIntervalDefault          = 300
+	chaosDurationDefault          = 60
+	resourceGroupNameDefault      = "shiro"
+	resourceGroupRUDefault        = 2000
+	stateSnapshotMaxMBDefault     = 512
+	stateSnapshotMaxPerRunDefault = 3
+	stateSnapshotTimeoutDefault   = 600
+	hangMaxChecksDefault          = 5
+	readinessTimeoutDefault       = 300
+	readinessPollDefault          = 5
+	workloadWorkersDefault        = 2
+	workloadTablesDefault         = 2
+	workloadRowsDefault           = 1000
+	workloadTxnStatementsDefault  = 4
+	dataProfileHotValuesDefault   = 16
+	selectivitySampleProbDefault  = 10
+	selectivityRareMaxDefault     = 10
 )
 
+var selectivityMixDefault = SelectivityMix{Empty: 10, Rare: 30, Moderate: 40, All: 20}
+
+// planCacheWeightFromProb returns the plan_cache weight that gives PlanCache
+// prob percent of the oracle runs under the other weights of w. Oracles the
+// runner cannot schedule with features, such as PartitionRange without
+// partition tables, do not count.
+func planCacheWeightFromProb(prob int, w OracleWeights, features Features) int {
+	prob = min(prob, 99)
+	others := w.NoREC + w.TLP + w.EET + w.DQP + w.PQS + w.CODDTest + w.DQE + w.Impo +
+		w.GroundTruth + w.TxnRYW + w.CTEInline + w.FKCascade + w.Savepoint + w.TiFlashOnly +
+		w.AutoID + w.ResultType + w.BatchDML + w.DecimalArith + w.FullGroupBy + w.LargeRow +
+		w.FullJoin + w.Privilege + w.CursorFetch + w.InList
+	if features.PartitionTables {
+		others += w.PartitionRange
+	}
+	return max((prob*others+(100-prob)/2)/(100-prob), 1)
+}
+
 func normalizeConfig(cfg *Config) {
 	if cfg.Adaptive.Enabled && !cfg.Adaptive.AdaptActions && !cfg.Adaptive.AdaptOracles && !cfg.Adaptive.AdaptDML && !cfg.Adaptive.AdaptFeatures {
 		cfg.Adaptive.AdaptOracles = true
 	}
-	if cfg.PlanCacheProb <= 0 {
-		cfg.PlanCacheProb = 50
-	}
 	if cfg.NonPreparedProb <= 0 {
 		cfg.NonPreparedProb = 50
 	}
+	// Derive the plan cache weight first, so focus areas boost it like the
+	// other oracle weights.
+	if cfg.PlanCacheProb > 0 && cfg.Weights.Oracles.PlanCache > 0 {
+		cfg.Weights.Oracles.PlanCache = planCacheWeightFromProb(cfg.PlanCacheProb, cfg.Weights.Oracles, cfg.Features)
+	}
+	applyFocusAreas(cfg)
 	if cfg.MaxJoinTables > 0 && cfg.Weights.Features.JoinCount > cfg.MaxJoinTables {
 		cfg.Weights.Features.JoinCount = cfg.MaxJoinTables
 	}
@@ -381,6 +1385,98 @@ func normalizeConfig(cfg *Config) {
 		cfg.DSN = ensureDatabaseInDSN(cfg.DSN, cfg.Database)
 	}
 	applyMPPOverrides(cfg)
+	cfg.ValueGenerators = normalizeValueGenerators(cfg.ValueGenerators)
+	cfg.DataProfiles = normalizeDataProfiles(cfg.DataProfiles)
+	cfg.InsertBatchRows = max(cfg.InsertBatchRows, 0)
+	if cfg.InsertFillBatchRows <= 0 {
+		cfg.InsertFillBatchRows = insertFillBatchRowsDefault
+	}
+	cfg.InsertFillBatchRows = min(cfg.InsertFillBatchRows, insertFillBatchRowsMax)
+	cfg.InsertNullRowProb = clampPercent(cfg.InsertNullRowProb)
+	cfg.InsertDefaultRowProb = clampPercent(cfg.InsertDefaultRowProb)
+	if cfg.Minimize.NeighborhoodMutants <= 0 {
+		cfg.Minimize.NeighborhoodMutants = neighborhoodMutantsDefault
+	}
+	cfg.Minimize.NeighborhoodMutants = min(cfg.Minimize.NeighborhoodMutants, neighborhoodMutantsMax)
+	cfg.ScaleSchedule = normalizeScaleSchedule(cfg.ScaleSchedule)
+	cfg.SessionInit = NormalizeSessionInit(cfg.SessionInit)
+	normalizeTargets(cfg)
+	cfg.Features.ForeignKeyViolationProb = min(max(cfg.Features.ForeignKeyViolationProb, 0), 100)
+	if strings.TrimSpace(cfg.Logging.SQLLog.Dir) == "" {
+		cfg.Logging.SQLLog.Dir = "logs/sql"
+	}
+	if cfg.Logging.SQLLog.MaxSizeMB <= 0 {
+		cfg.Logging.SQLLog.MaxSizeMB = 64
+	}
+	if cfg.Logging.SQLLog.MaxFiles < 0 {
+		cfg.Logging.SQLLog.MaxFiles = 0
+	}
+	if cfg.Logging.QuerySample.Rate < 0 {
+		cfg.Logging.QuerySample.Rate = 0
+	}
+	if cfg.Logging.QuerySample.Rate > 1 {
+		cfg.Logging.QuerySample.Rate = 1
+	}
+	if strings.TrimSpace(cfg.Logging.QuerySample.Dir) == "" {
+		cfg.Logging.QuerySample.Dir = filepath.Join(cfg.PlanReplayer.OutputDir, "sampled")
+	}
+	if cfg.Logging.QuerySample.MaxSamples <= 0 {
+		cfg.Logging.QuerySample.MaxSamples = 10000
+	}
+	cfg.Hooks = normalizeHooks(cfg.Hooks)
+	cfg.TiDBLogs.Sources = normalizeTiDBLogSources(cfg.TiDBLogs.Sources)
+	if cfg.TiDBLogs.Lines <= 0 {
+		cfg.TiDBLogs.Lines = tidbLogLinesDefault
+	}
+	if cfg.TiDBLogs.TimeoutSeconds <= 0 {
+		cfg.TiDBLogs.TimeoutSeconds = tidbLogTimeoutDefault
+	}
+	normalizeWorkload(&cfg.Workload)
+	if cfg.Shadow.TimeoutSeconds <= 0 {
+		cfg.Shadow.TimeoutSeconds = shadowTimeoutSecondsDefault
+	}
+	normalizeStateSnapshot(&cfg.StateSnapshot)
+	normalizePacing(&cfg.Pacing)
+	normalizeLatency(&cfg.Latency)
+	normalizeChaos(&cfg.Chaos)
+	normalizeResourceGroup(&cfg.ResourceGroup)
+	if cfg.Hang.TimeoutSeconds <= 0 {
+		cfg.Hang.TimeoutSeconds = hangTimeoutSecondsDefault
+	}
+	if cfg.Hang.MaxChecks < 0 {
+		cfg.Hang.MaxChecks = 0
+	}
+	cfg.Hang.GoroutineURL = strings.TrimSpace(cfg.Hang.GoroutineURL)
+	if cfg.Readiness.TimeoutSeconds <= 0 {
+		cfg.Readiness.TimeoutSeconds = readinessTimeoutDefault
+	}
+	if cfg.Readiness.PollIntervalSeconds <= 0 {
+		cfg.Readiness.PollIntervalSeconds = readinessPollDefault
+	}
+	cfg.Readiness.MinTiKVStores = max(0, cfg.Readiness.MinTiKVStores)
+	cfg.Readiness.MinTiFlashStores = max(0, cfg.Readiness.MinTiFlashStores)
+	cfg.Readiness.WarmupQueries = max(0, cfg.Readiness.WarmupQueries)
+	if cfg.PlanReplayer.MinFreeDiskMB < 0 {
+		cfg.PlanReplayer.MinFreeDiskMB = 0
+	}
+	if cfg.PlanStability.Probes <= 0 {
+		cfg.PlanStability.Probes = 8
+	}
+	if cfg.PlanStability.CheckInterval <= 0 {
+		cfg.PlanStability.CheckInterval = 50
+	}
+	if cfg.ClusterImpact.TopN <= 0 {
+		cfg.ClusterImpact.TopN = 20
+	}
+	cfg.Stop.File = strings.TrimSpace(cfg.Stop.File)
+	cfg.Stop.ObjectKey = strings.Trim(strings.TrimSpace(cfg.Stop.ObjectKey), "/")
+	if cfg.Stop.PollSeconds <= 0 {
+		cfg.Stop.PollSeconds = 30
+	}
+	if cfg.PlanStability.MaxCases < 0 {
+		cfg.PlanStability.MaxCases = 0
+	}
+	normalizeSelectivity(&cfg.Selectivity)
 	if cfg.Features.ViewMax <= 0 {
 		cfg.Features.ViewMax = ViewMaxDefault
 	}
@@ -411,6 +1507,16 @@ func normalizeConfig(cfg *Config) {
 	if cfg.Oracles.DQPComplexityDerivedThreshold <= 0 {
 		cfg.Oracles.DQPComplexityDerivedThreshold = dqpComplexityDerivedThresholdDefault
 	}
+	cfg.Oracles.DQPFixControlProb = clampPercent(cfg.Oracles.DQPFixControlProb)
+	cfg.Oracles.DQPFixControls = normalizeFixControls(cfg.Oracles.DQPFixControls)
+	if cfg.Oracles.DQPFixControlPerQuery <= 0 {
+		cfg.Oracles.DQPFixControlPerQuery = dqpFixControlPerQueryDefault
+	}
+	cfg.Oracles.DQPFixControlPerQuery = min(cfg.Oracles.DQPFixControlPerQuery, dqpFixControlPerQueryMax)
+	if cfg.Oracles.InListMaxItems <= 0 {
+		cfg.Oracles.InListMaxItems = inListMaxItemsDefault
+	}
+	cfg.Oracles.InListMaxItems = min(max(cfg.Oracles.InListMaxItems, inListMaxItemsMin), inListMaxItemsMax)
 	if cfg.Oracles.EETComplexityJoinTableThreshold <= 0 {
 		cfg.Oracles.EETComplexityJoinTableThreshold = eetComplexityJoinTablesThresholdDefault
 	}
@@ -421,9 +1527,38 @@ func normalizeConfig(cfg *Config) {
 		cfg.Oracles.MPPTiFlashReplica = 1
 	}
 	syncMPPConfig(cfg)
+	cfg.MPP.TiFlashMode = normalizeTiFlashMode(cfg.MPP.TiFlashMode)
 	if cfg.Oracles.CODDCaseWhenMax <= 0 {
 		cfg.Oracles.CODDCaseWhenMax = coddtestCaseWhenMaxDefault
 	}
+	if cfg.Oracles.ResultMaxRows < 0 {
+		cfg.Oracles.ResultMaxRows = 0
+	}
+	if cfg.Oracles.PipelineDepth <= 0 {
+		cfg.Oracles.PipelineDepth = 1
+	}
+	if cfg.Oracles.PipelineDepth > pipelineDepthMax {
+		cfg.Oracles.PipelineDepth = pipelineDepthMax
+	}
+	cfg.Oracles.LargeRow.MaxColumns = clampLargeRowLimit(cfg.Oracles.LargeRow.MaxColumns, largeRowMaxColumnsDefault, largeRowMaxColumnsMin, largeRowMaxColumnsMax)
+	cfg.Oracles.LargeRow.MaxPayloadBytes = clampLargeRowLimit(cfg.Oracles.LargeRow.MaxPayloadBytes, largeRowMaxPayloadDefault, largeRowMaxPayloadMin, largeRowMaxPayloadMax)
+	cfg.Oracles.LargeRow.MaxIndexes = clampLargeRowLimit(cfg.Oracles.LargeRow.MaxIndexes, largeRowMaxIndexesDefault, largeRowMaxIndexesMin, largeRowMaxIndexesMax)
+	normalizeOracleBudget(&cfg.Oracles.Budget)
+	normalizeOracleDrift(&cfg.Oracles.Drift)
+	if len(cfg.Oracles.Compat) > 0 {
+		compat := make(map[string]OracleCompatOverride, len(cfg.Oracles.Compat))
+		for name, override := range cfg.Oracles.Compat {
+			if override.MaxJoins != nil && *override.MaxJoins < 0 {
+				zero := 0
+				override.MaxJoins = &zero
+			}
+			compat[strings.ToLower(strings.TrimSpace(name))] = override
+		}
+		cfg.Oracles.Compat = compat
+	}
+	if cfg.Oracles.ResultMaxBytes < 0 {
+		cfg.Oracles.ResultMaxBytes = 0
+	}
 	if cfg.QPG.NoJoinThreshold <= 0 {
 		cfg.QPG.NoJoinThreshold = qpgNoJoinThresholdDefault
 	}
@@ -448,6 +1583,10 @@ func normalizeConfig(cfg *Config) {
 	if cfg.QPG.OverrideTTL <= 0 {
 		cfg.QPG.OverrideTTL = qpgOverrideTTLDefault
 	}
+	cfg.QPG.FocusPlanSignature = strings.TrimSpace(cfg.QPG.FocusPlanSignature)
+	if cfg.QPG.FocusMissReset <= 0 {
+		cfg.QPG.FocusMissReset = qpgFocusMissResetDefault
+	}
 	if cfg.QPG.TemplateOverride.NoNewJoinOrderThreshold <= 0 {
 		cfg.QPG.TemplateOverride.NoNewJoinOrderThreshold = qpgTemplateNoNewJoinOrderThresholdDefault
 	}
@@ -502,86 +1641,42 @@ func syncMPPConfig(cfg *Config) {
 	cfg.MPP.TiFlashReplica = &replica
 }
 
-func ensureDatabaseInDSN(dsn string, dbName string) string {
-	if dsn == "" || dbName == "" {
-		return dsn
-	}
-	slash := strings.Index(dsn, "/")
-	if slash < 0 {
-		return dsn
-	}
-	query := strings.Index(dsn[slash+1:], "?")
-	if query >= 0 {
-		query = slash + 1 + query
-	}
-	afterSlash := dsn[slash+1:]
-	if query >= 0 {
-		afterSlash = dsn[slash+1 : query]
-	}
-	if strings.TrimSpace(afterSlash) != "" {
-		return dsn
-	}
-	if query >= 0 {
-		return dsn[:slash+1] + dbName + dsn[query:]
-	}
-	return dsn + dbName
-}
-
-// UpdateDatabaseInDSN replaces the database name in the DSN path with dbName.
-// It preserves query parameters, if any.
-func UpdateDatabaseInDSN(dsn string, dbName string) string {
-	if dsn == "" || dbName == "" {
-		return dsn
-	}
-	slash := strings.Index(dsn, "/")
-	if slash < 0 {
-		return dsn
-	}
-	query := strings.Index(dsn[slash+1:], "?")
-	if query >= 0 {
-		query = slash + 1 + query
-		return dsn[:slash+1] + dbName + dsn[query:]
-	}
-	return dsn[:slash+1] + dbName
-}
-
-// AdminDSN strips the database name from a DSN while preserving query parameters.
-func AdminDSN(dsn string) string {
-	if dsn == "" {
-		return dsn
-	}
-	slash := strings.Index(dsn, "/")
-	if slash < 0 {
-		return dsn
+// normalizeTiFlashMode returns the lower-case TiFlash table mode, or empty
+// for unknown values.
+func normalizeTiFlashMode(mode string) string {
+	switch mode = strings.ToLower(strings.TrimSpace(mode)); mode {
+	case TiFlashModeNormal, TiFlashModeFast:
+		return mode
+	default:
+		return ""
 	}
-	query := strings.Index(dsn[slash+1:], "?")
-	if query >= 0 {
-		query = slash + 1 + query
-		return dsn[:slash+1] + dsn[query:]
-	}
-	return dsn[:slash+1]
 }
 
 func defaultConfig() Config {
 	return Config{
-		DSN:                 "root:@tcp(127.0.0.1:4000)/",
-		Database:            "shiro_fuzz",
-		Iterations:          1000,
-		Workers:             1,
-		PlanCacheProb:       50,
-		NonPreparedProb:     50,
-		PlanCacheMeaningful: true,
-		MaxTables:           5,
-		MaxJoinTables:       15,
-		MaxColumns:          8,
-		MaxRowsPerTable:     50,
-		MaxDataDumpRows:     50,
-		MaxInsertStatements: 200,
-		StatementTimeoutMs:  15000,
+		DSN:                  "root:@tcp(127.0.0.1:4000)/",
+		Database:             "shiro_fuzz",
+		Iterations:           1000,
+		Workers:              1,
+		NonPreparedProb:      50,
+		PlanCacheProb:        50,
+		PlanCacheMeaningful:  true,
+		MaxTables:            5,
+		MaxJoinTables:        15,
+		MaxColumns:           8,
+		MaxRowsPerTable:      50,
+		MaxDataDumpRows:      50,
+		MaxInsertStatements:  200,
+		InsertFillBatchRows:  insertFillBatchRowsDefault,
+		InsertNullRowProb:    insertMixedRowProbDefault,
+		InsertDefaultRowProb: insertMixedRowProbDefault,
+		StatementTimeoutMs:   15000,
 		Features: Features{
 			Views:                true,
 			ViewMax:              ViewMaxDefault,
 			PartitionTables:      true,
+			LargeRowTables:       true,
+			ClusteredIndex:       true,
 			NonPreparedPlanCache: true,
 			NotExists:            true,
 			NotIn:                true,
@@ -603,16 +1698,26 @@ func defaultConfig() Config {
 			DownloadURLTemplate: "http://127.0.0.1:10080/plan_replayer/dump/%s.zip",
 			TimeoutSeconds:      30,
 			MaxDownloadBytes:    50 << 20,
+			MinFreeDiskMB:       1024,
 		},
 		Weights: Weights{
-			Actions:  ActionWeights{DDL: 1, DML: 1, Query: 10},
+			Actions:  ActionWeights{DDL: 1, DML: 1, Query: 10, Storyline: 1},
 			DML:      DMLWeights{Insert: 3, Update: 1, Delete: 1},
-			Oracles:  OracleWeights{NoREC: 4, TLP: 3, EET: 2, DQP: 3, PQS: 2, CODDTest: 2, DQE: 2, Impo: 2, GroundTruth: 5},
-			Features: FeatureWeights{JoinCount: 5, CTECount: 4, CTECountMax: 3, SubqCount: 5, AggProb: 50, DecimalAggProb: 70, GroupByProb: 30, HavingProb: 20, OrderByProb: 40, LimitProb: 40, DistinctProb: 20, WindowProb: 20, PartitionProb: 30, NotExistsProb: 40, NotInProb: 40, IndexPrefixProb: 30, TemplateJoinOnlyWeight: 4, TemplateJoinFilterWeight: 6},
+			Oracles:  OracleWeights{NoREC: 4, TLP: 3, EET: 2, DQP: 3, PQS: 2, CODDTest: 2, DQE: 2, Impo: 2, GroundTruth: 5, TxnRYW: 1, CTEInline: 1, FKCascade: 1, Savepoint: 1, TiFlashOnly: 1, AutoID: 1, ResultType: 1, PlanCache: 2, BatchDML: 1, DecimalArith: 1, FullGroupBy: 1, LargeRow: 1, FullJoin: 1, Privilege: 0, CursorFetch: 1, InList: 1, PartitionRange: 1},
+			Features: FeatureWeights{JoinCount: 5, CTECount: 4, CTECountMax: 3, SubqCount: 5, AggProb: 50, DecimalAggProb: 70, GroupByProb: 30, HavingProb: 20, OrderByProb: 40, LimitProb: 40, DistinctProb: 20, WindowProb: 20, PartitionProb: 30, LargeRowProb: 5, ClusteredPKProb: 50, CompositePKProb: 30, NotExistsProb: 40, NotInProb: 40, IndexPrefixProb: 30, TemplateJoinOnlyWeight: 4, TemplateJoinFilterWeight: 6, AliasAmbiguityProb: 15, NullAwareProb: 10},
 		},
 		Logging: Logging{
 			ReportIntervalSeconds: 30,
 			LogFile:               "logs/shiro.log",
+			SQLLog: SQLLogConfig{
+				Dir:       "logs/sql",
+				MaxSizeMB: 64,
+				MaxFiles:  16,
+			},
+			QuerySample: QuerySampleConfig{
+				MaxSamples: 10000,
+				Explain:    true,
+			},
 			Metrics: MetricsThresholds{
 				SQLValidMinRatio:           0.95,
 				ImpoInvalidColumnsMaxRatio: 0.05,
@@ -630,6 +1735,8 @@ func defaultConfig() Config {
 			DQPSetVarHintPick:               dqpSetVarHintPickMaxDefault,
 			DQPComplexitySetOpsThreshold:    dqpComplexitySetOpsThresholdDefault,
 			DQPComplexityDerivedThreshold:   dqpComplexityDerivedThresholdDefault,
+			DQPFixControlPerQuery:           dqpFixControlPerQueryDefault,
+			InListMaxItems:                  inListMaxItemsDefault,
 			EETComplexityJoinTableThreshold: eetComplexityJoinTablesThresholdDefault,
 			CODDCaseWhenMax:                 coddtestCaseWhenMaxDefault,
 			CertMinBaseRows:                 20,
@@ -637,7 +1744,21 @@ func defaultConfig() Config {
 			ImpoMaxRows:                     50,
 			ImpoMaxMutations:                64,
 			ImpoTimeoutMs:                   2000,
+			ClassifyMismatch:                true,
+			PanicDiagnostics:                true,
+			PipelineDepth:                   1,
 			EETRewrites:                     EETRewriteWeights{DoubleNot: 4, AndTrue: 3, OrFalse: 3, NumericIdentity: 2, StringIdentity: 2, DateIdentity: 2},
+			Budget: OracleBudgetConfig{
+				Enabled:              true,
+				Window:               oracleBudgetWindowDefault,
+				MinProductivePercent: oracleBudgetMinPercentDefault,
+				CooldownRuns:         oracleBudgetCooldownDefault,
+				ProbeRuns:            oracleBudgetProbeRunsDefault,
+			},
+			Drift: OracleDriftConfig{
+				Probability: oracleDriftProbabilityDefault,
+				Kinds:       []string{DriftAnalyze, DriftAddIndex, DriftPlanCacheFlush},
+			},
 		},
 		Adaptive: Adaptive{Enabled: true, UCBExploration: 1.5, WindowSize: 50000},
 		QPG: QPGConfig{
@@ -655,6 +1776,7 @@ func defaultConfig() Config {
 			NoNewJoinTypeThreshold:  qpgNoNewJoinTypeThresholdDefault,
 			NoNewJoinOrderThreshold: qpgNoNewJoinOrderThresholdDefault,
 			OverrideTTL:             qpgOverrideTTLDefault,
+			FocusMissReset:          qpgFocusMissResetDefault,
 			TemplateOverride: QPGTemplateOverrideConfig{
 				NoNewJoinOrderThreshold: qpgTemplateNoNewJoinOrderThresholdDefault,
 				NoNewShapeThreshold:     qpgTemplateNoNewShapeThresholdDefault,
@@ -674,11 +1796,67 @@ func defaultConfig() Config {
 			RoundScale:          6,
 			PlanCacheRoundScale: 4,
 		},
-		Minimize: MinimizeConfig{
+		PlanStability: PlanStabilityConfig{
+			Probes:        8,
+			CheckInterval: 50,
+			MaxCases:      5,
+		},
+		ClusterImpact: ClusterImpactConfig{
+			TopN: 20,
+		},
+		Shadow: ShadowConfig{TimeoutSeconds: shadowTimeoutSecondsDefault},
+		Workload: WorkloadConfig{
+			Workers:       workloadWorkersDefault,
+			Tables:        workloadTablesDefault,
+			Rows:          workloadRowsDefault,
+			ReadPercent:   70,
+			TxnStatements: workloadTxnStatementsDefault,
+			TxnPerSecond:  20,
+		},
+		Pacing: PacingConfig{
+			Enabled:      true,
+			Window:       pacingWindowDefault,
+			ErrorPercent: pacingErrorPercentDefault,
+			MinDelayMs:   pacingMinDelayMsDefault,
+			MaxDelayMs:   pacingMaxDelayMsDefault,
+		},
+		Latency: LatencyConfig{
+			Enabled:          true,
+			RegressionFactor: latencyFactorDefault,
+			MinSamples:       latencyMinSamplesDefault,
+			MinRegressionMs:  latencyMinMsDefault,
+			MaxDigests:       latencyMaxDigestsDefault,
+			MaxCases:         latencyMaxCasesDefault,
+		},
+		StateSnapshot: StateSnapshotConfig{
+			Tool:           StateSnapshotDumpling,
+			MaxMB:          stateSnapshotMaxMBDefault,
+			MaxPerRun:      stateSnapshotMaxPerRunDefault,
+			TimeoutSeconds: stateSnapshotTimeoutDefault,
+		},
+		Selectivity: SelectivityConfig{
+			SampleProb:     selectivitySampleProbDefault,
+			RareMaxPercent: selectivityRareMaxDefault,
+			Mix:            selectivityMixDefault,
+		},
+		Hang: HangConfig{
 			Enabled:        true,
-			MaxRounds:      16,
-			TimeoutSeconds: 60,
-			MergeInserts:   true,
+			TimeoutSeconds: hangTimeoutSecondsDefault,
+			MaxChecks:      hangMaxChecksDefault,
+		},
+		Readiness: ReadinessConfig{
+			Enabled:             true,
+			TimeoutSeconds:      readinessTimeoutDefault,
+			PollIntervalSeconds: readinessPollDefault,
+			MinTiKVStores:       1,
+			WarmupQueries:       5,
+		},
+		Minimize: MinimizeConfig{
+			Enabled:             true,
+			MaxRounds:           16,
+			TimeoutSeconds:      60,
+			MergeInserts:        true,
+			NeighborhoodMutants: neighborhoodMutantsDefault,
 		},
 	}
 }
@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -34,12 +35,13 @@ func TestLoadDefaults(t *testing.T) {
 	if cfg.MaxJoinTables != 15 {
 		t.Fatalf("unexpected max join tables: %d", cfg.MaxJoinTables)
 	}
-	if cfg.Weights.Actions.DDL != 1 || cfg.Weights.Actions.DML != 1 || cfg.Weights.Actions.Query != 10 {
+	if cfg.Weights.Actions.DDL != 1 || cfg.Weights.Actions.DML != 1 || cfg.Weights.Actions.Query != 10 || cfg.Weights.Actions.Storyline != 1 {
 		t.Fatalf(
-			"unexpected default action weights: ddl=%d dml=%d query=%d",
+			"unexpected default action weights: ddl=%d dml=%d query=%d storyline=%d",
 			cfg.Weights.Actions.DDL,
 			cfg.Weights.Actions.DML,
 			cfg.Weights.Actions.Query,
+			cfg.Weights.Actions.Storyline,
 		)
 	}
 	if cfg.Logging.ReportIntervalSeconds != 30 {
@@ -51,6 +53,9 @@ func TestLoadDefaults(t *testing.T) {
 	if cfg.Oracles.DQPBaseHintPick != dqpBaseHintPickLimitDefault {
 		t.Fatalf("unexpected dqp base hint pick limit: %d", cfg.Oracles.DQPBaseHintPick)
 	}
+	if cfg.Oracles.DQPFixControlProb != 0 || cfg.Oracles.DQPFixControlPerQuery != dqpFixControlPerQueryDefault {
+		t.Fatalf("unexpected dqp fix control defaults: %d/%d", cfg.Oracles.DQPFixControlProb, cfg.Oracles.DQPFixControlPerQuery)
+	}
 	if cfg.Oracles.DQPSetVarHintPick != dqpSetVarHintPickMaxDefault {
 		t.Fatalf("unexpected dqp set-var hint pick max: %d", cfg.Oracles.DQPSetVarHintPick)
 	}
@@ -75,9 +80,128 @@ func TestLoadDefaults(t *testing.T) {
 	if cfg.MPP.TiFlashReplica == nil || *cfg.MPP.TiFlashReplica != 1 {
 		t.Fatalf("unexpected mpp.tiflash_replica default: %v", cfg.MPP.TiFlashReplica)
 	}
+	if cfg.MPP.TiFlashMode != "" || cfg.MPP.ColumnarOnly {
+		t.Fatalf("unexpected mpp tiflash_mode/columnar_only defaults: %q %v", cfg.MPP.TiFlashMode, cfg.MPP.ColumnarOnly)
+	}
+	if cfg.Weights.Oracles.TiFlashOnly != 1 {
+		t.Fatalf("unexpected tiflash_only weight default: %d", cfg.Weights.Oracles.TiFlashOnly)
+	}
+	if cfg.Weights.Oracles.AutoID != 1 {
+		t.Fatalf("unexpected auto_id weight default: %d", cfg.Weights.Oracles.AutoID)
+	}
+	if cfg.Weights.Oracles.ResultType != 1 {
+		t.Fatalf("unexpected result_type weight default: %d", cfg.Weights.Oracles.ResultType)
+	}
+	if cfg.PlanCacheProb != 50 || cfg.Weights.Oracles.PlanCache != planCacheWeightFromProb(50, cfg.Weights.Oracles, cfg.Features) {
+		t.Fatalf("unexpected plan_cache weight default: prob=%d weight=%d", cfg.PlanCacheProb, cfg.Weights.Oracles.PlanCache)
+	}
+	if cfg.Weights.Oracles.BatchDML != 1 {
+		t.Fatalf("unexpected batch_dml weight default: %d", cfg.Weights.Oracles.BatchDML)
+	}
+	if cfg.Weights.Oracles.DecimalArith != 1 {
+		t.Fatalf("unexpected decimal_arith weight default: %d", cfg.Weights.Oracles.DecimalArith)
+	}
+	if cfg.Weights.Oracles.FullGroupBy != 1 {
+		t.Fatalf("unexpected full_group_by weight default: %d", cfg.Weights.Oracles.FullGroupBy)
+	}
+	if cfg.Weights.Oracles.FullJoin != 1 {
+		t.Fatalf("unexpected full_join weight default: %d", cfg.Weights.Oracles.FullJoin)
+	}
+	if cfg.Minimize.VerifyMin {
+		t.Fatalf("expected minimize.verify_min disabled by default")
+	}
+	if cfg.Features.ForeignKeyViolationProb != 0 {
+		t.Fatalf("expected no foreign key violations by default: %d", cfg.Features.ForeignKeyViolationProb)
+	}
+	if cfg.JUnit.Enabled || cfg.JUnit.Dir != "" {
+		t.Fatalf("expected junit output off by default: %+v", cfg.JUnit)
+	}
+	if cfg.Stop.File != "" || cfg.Stop.ObjectKey != "" || cfg.Stop.PollSeconds != 30 {
+		t.Fatalf("unexpected stop defaults: %+v", cfg.Stop)
+	}
+	if cfg.Oracles.DQPHintProbe {
+		t.Fatalf("expected dqp_hint_probe off by default")
+	}
+	if cfg.Weights.Oracles.CursorFetch != 1 {
+		t.Fatalf("expected cursor_fetch weight 1 by default: %d", cfg.Weights.Oracles.CursorFetch)
+	}
+	if cfg.Weights.Oracles.InList != 1 || cfg.Oracles.InListMaxItems != inListMaxItemsDefault {
+		t.Fatalf("unexpected in_list defaults: weight=%d max_items=%d", cfg.Weights.Oracles.InList, cfg.Oracles.InListMaxItems)
+	}
+	if cfg.Weights.Oracles.PartitionRange != 1 {
+		t.Fatalf("expected partition_range weight 1 by default: %d", cfg.Weights.Oracles.PartitionRange)
+	}
+	if cfg.Weights.Oracles.Privilege != 0 {
+		t.Fatalf("expected privilege weight off by default: %d", cfg.Weights.Oracles.Privilege)
+	}
+	if cfg.Weights.Oracles.LargeRow != 1 {
+		t.Fatalf("unexpected large_row weight default: %d", cfg.Weights.Oracles.LargeRow)
+	}
+	if !cfg.Features.LargeRowTables || cfg.Weights.Features.LargeRowProb != 5 {
+		t.Fatalf("unexpected large-row table defaults: %t %d", cfg.Features.LargeRowTables, cfg.Weights.Features.LargeRowProb)
+	}
+	if cfg.Oracles.LargeRow != (LargeRowConfig{MaxColumns: 256, MaxPayloadBytes: 5 << 20, MaxIndexes: 32}) {
+		t.Fatalf("unexpected large_row defaults: %+v", cfg.Oracles.LargeRow)
+	}
+	if cfg.Oracles.Budget != (OracleBudgetConfig{Enabled: true, Window: 200, MinProductivePercent: 2, CooldownRuns: 2000, ProbeRuns: 20}) {
+		t.Fatalf("unexpected oracle budget defaults: %+v", cfg.Oracles.Budget)
+	}
+	if drift := cfg.Oracles.Drift; drift.Enabled || drift.Probability != 10 || strings.Join(drift.Kinds, ",") != "analyze,add_index,plan_cache_flush" {
+		t.Fatalf("unexpected oracle drift defaults: %+v", drift)
+	}
+	if len(cfg.Oracles.Compat) != 0 {
+		t.Fatalf("expected no oracle compat overrides by default: %+v", cfg.Oracles.Compat)
+	}
+	if len(cfg.SessionInit) != 0 {
+		t.Fatalf("expected no session init statements by default: %v", cfg.SessionInit)
+	}
+	if cfg.InsertBatchRows != 0 || len(cfg.ScaleSchedule) != 0 {
+		t.Fatalf("expected no insert batch override or scale schedule by default: %d %+v", cfg.InsertBatchRows, cfg.ScaleSchedule)
+	}
+	if cfg.InsertFillBatchRows != 25 || cfg.InsertNullRowProb != 5 || cfg.InsertDefaultRowProb != 5 {
+		t.Fatalf("unexpected insert fill defaults: %d %d %d", cfg.InsertFillBatchRows, cfg.InsertNullRowProb, cfg.InsertDefaultRowProb)
+	}
+	if cfg.Storage.Local.Enabled || cfg.Storage.RemoteEnabled() {
+		t.Fatalf("expected storage backends disabled by default: %+v", cfg.Storage)
+	}
+	if cfg.Weights.Features.AliasAmbiguityProb != 15 {
+		t.Fatalf("unexpected alias_ambiguity_prob default: %d", cfg.Weights.Features.AliasAmbiguityProb)
+	}
+	if !cfg.Readiness.Enabled || cfg.Readiness.TimeoutSeconds != readinessTimeoutDefault || cfg.Readiness.PollIntervalSeconds != readinessPollDefault ||
+		cfg.Readiness.MinTiKVStores != 1 || cfg.Readiness.MinTiFlashStores != 0 || cfg.Readiness.WarmupQueries != 5 || cfg.Readiness.FailOnTimeout {
+		t.Fatalf("unexpected readiness defaults: %+v", cfg.Readiness)
+	}
+	if cfg.Selectivity.Enabled || cfg.Selectivity.SampleProb != selectivitySampleProbDefault || cfg.Selectivity.RareMaxPercent != selectivityRareMaxDefault ||
+		cfg.Selectivity.Mix != selectivityMixDefault {
+		t.Fatalf("unexpected selectivity defaults: %+v", cfg.Selectivity)
+	}
+	if cfg.StateSnapshot != (StateSnapshotConfig{Tool: StateSnapshotDumpling, MaxMB: 512, MaxPerRun: 3, TimeoutSeconds: 600}) {
+		t.Fatalf("unexpected state_snapshot defaults: %+v", cfg.StateSnapshot)
+	}
+	if cfg.Pacing != (PacingConfig{Enabled: true, Window: 200, ErrorPercent: 20, MinDelayMs: 100, MaxDelayMs: 5000}) {
+		t.Fatalf("unexpected pacing defaults: %+v", cfg.Pacing)
+	}
+	if cfg.Latency != (LatencyConfig{Enabled: true, RegressionFactor: 10, MinSamples: 3, MinRegressionMs: 500, MaxDigests: 10000, MaxCases: 5}) {
+		t.Fatalf("unexpected latency defaults: %+v", cfg.Latency)
+	}
+	if cfg.Chaos.Enabled || cfg.Chaos.Namespace != "tidb-cluster" || cfg.Chaos.IntervalSeconds != 300 || cfg.Chaos.DurationSeconds != 60 || len(cfg.Chaos.Experiments) != 3 {
+		t.Fatalf("unexpected chaos defaults: %+v", cfg.Chaos)
+	}
+	if cfg.ResourceGroup.Enabled || cfg.ResourceGroup.Name != "shiro" || cfg.ResourceGroup.RUPerSec != 2000 || cfg.ResourceGroup.Priority != "low" || cfg.ResourceGroup.Burstable {
+		t.Fatalf("unexpected resource group defaults: %+v", cfg.ResourceGroup)
+	}
+	if cfg.Weights.Features.NullAwareProb != 10 {
+		t.Fatalf("unexpected null_aware_prob default: %d", cfg.Weights.Features.NullAwareProb)
+	}
+	if !cfg.Hang.Enabled || cfg.Hang.TimeoutSeconds != hangTimeoutSecondsDefault || cfg.Hang.MaxChecks != hangMaxChecksDefault {
+		t.Fatalf("unexpected hang defaults: %+v", cfg.Hang)
+	}
 	if !cfg.QPG.Enabled {
 		t.Fatalf("expected qpg enabled by default")
 	}
+	if !cfg.Features.ClusteredIndex || cfg.Weights.Features.ClusteredPKProb != 50 || cfg.Weights.Features.CompositePKProb != 30 {
+		t.Fatalf("unexpected clustered index defaults: %v %d %d", cfg.Features.ClusteredIndex, cfg.Weights.Features.ClusteredPKProb, cfg.Weights.Features.CompositePKProb)
+	}
 	if cfg.Features.ViewMax != ViewMaxDefault {
 		t.Fatalf("unexpected default view_max: %d", cfg.Features.ViewMax)
 	}
@@ -87,6 +211,24 @@ func TestLoadDefaults(t *testing.T) {
 	if cfg.Oracles.DowngradeMissingColumnToSkip {
 		t.Fatalf("expected missing-column downgrade skip to be disabled by default")
 	}
+	if !cfg.Oracles.ClassifyMismatch {
+		t.Fatalf("expected mismatch classification to be enabled by default")
+	}
+	if !cfg.Oracles.PanicDiagnostics {
+		t.Fatalf("expected panic diagnostics to be enabled by default")
+	}
+	if cfg.Oracles.SnapshotPairs {
+		t.Fatalf("expected snapshot-pinned comparisons to be disabled by default")
+	}
+	if cfg.Oracles.PipelineDepth != 1 {
+		t.Fatalf("expected oracle pipelining to be off by default, got depth %d", cfg.Oracles.PipelineDepth)
+	}
+	if cfg.Workload.Enabled || cfg.Workload.Workers != workloadWorkersDefault || cfg.Workload.ReadPercent != 70 {
+		t.Fatalf("unexpected workload defaults: %+v", cfg.Workload)
+	}
+	if cfg.Shadow.Enabled || cfg.Shadow.TimeoutSeconds != shadowTimeoutSecondsDefault {
+		t.Fatalf("unexpected shadow defaults: %+v", cfg.Shadow)
+	}
 }
 
 func TestLoadOverrides(t *testing.T) {
@@ -240,6 +382,8 @@ func TestNormalizeQPGThresholds(t *testing.T) {
   no_new_join_type_threshold: -2
   no_new_join_order_threshold: 0
   override_ttl: -1
+  focus_plan_signature: "  HashJoin;IndexLookUp  "
+  focus_miss_reset: 0
 `
 	if _, err := tmp.WriteString(content); err != nil {
 		t.Fatalf("write temp file: %v", err)
@@ -275,6 +419,12 @@ func TestNormalizeQPGThresholds(t *testing.T) {
 	if cfg.QPG.OverrideTTL != qpgOverrideTTLDefault {
 		t.Fatalf("unexpected override_ttl: %d", cfg.QPG.OverrideTTL)
 	}
+	if cfg.QPG.FocusPlanSignature != "HashJoin;IndexLookUp" {
+		t.Fatalf("unexpected focus_plan_signature: %q", cfg.QPG.FocusPlanSignature)
+	}
+	if cfg.QPG.FocusMissReset != qpgFocusMissResetDefault {
+		t.Fatalf("unexpected focus_miss_reset: %d", cfg.QPG.FocusMissReset)
+	}
 }
 
 func TestLoadDQPExternalHints(t *testing.T) {
@@ -289,6 +439,10 @@ func TestLoadDQPExternalHints(t *testing.T) {
   dqp_set_var_hint_pick_max: 7
   dqp_complexity_set_ops_threshold: 5
   dqp_complexity_derived_threshold: 6
+  dqp_fix_control_prob: 150
+  dqp_fix_controls: [" 99999 ", "x1", "99999", "44830"]
+  dqp_fix_control_per_query: 64
+  in_list_max_items: 50000
   eet_complexity_join_tables_threshold: 9
   dqp_external_hints:
     - "SET_VAR(tidb_opt_partial_ordered_index_for_topn='COST')"
@@ -317,6 +471,15 @@ func TestLoadDQPExternalHints(t *testing.T) {
 	if cfg.Oracles.DQPBaseHintPick != 6 {
 		t.Fatalf("unexpected dqp base hint pick limit: %d", cfg.Oracles.DQPBaseHintPick)
 	}
+	if cfg.Oracles.DQPFixControlProb != 100 || cfg.Oracles.DQPFixControlPerQuery != dqpFixControlPerQueryMax {
+		t.Fatalf("unexpected dqp fix control prob/per query: %d/%d", cfg.Oracles.DQPFixControlProb, cfg.Oracles.DQPFixControlPerQuery)
+	}
+	if got := strings.Join(cfg.Oracles.DQPFixControls, ","); got != "99999,44830" {
+		t.Fatalf("unexpected dqp fix controls: %s", got)
+	}
+	if cfg.Oracles.InListMaxItems != inListMaxItemsMax {
+		t.Fatalf("unexpected in_list max items: %d", cfg.Oracles.InListMaxItems)
+	}
 	if cfg.Oracles.DQPSetVarHintPick != 7 {
 		t.Fatalf("unexpected dqp set-var hint pick max: %d", cfg.Oracles.DQPSetVarHintPick)
 	}
@@ -337,6 +500,40 @@ func TestLoadDQPExternalHints(t *testing.T) {
 	}
 }
 
+func TestLoadOracleCompatOverrides(t *testing.T) {
+	tmp, err := os.CreateTemp(t.TempDir(), "config-*.yaml")
+	if err != nil {
+		t.Fatalf("create temp file: %v", err)
+	}
+	content := `oracles:
+  compat:
+    " TLP ":
+      window: false
+      max_joins: -1
+    dqp:
+      set_ops: true
+`
+	if _, err := tmp.WriteString(content); err != nil {
+		t.Fatalf("write temp file: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		t.Fatalf("close temp file: %v", err)
+	}
+
+	cfg, err := Load(tmp.Name())
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	tlp, ok := cfg.Oracles.Compat["tlp"]
+	if !ok || tlp.Window == nil || *tlp.Window || tlp.MaxJoins == nil || *tlp.MaxJoins != 0 {
+		t.Fatalf("unexpected tlp override: %+v", cfg.Oracles.Compat)
+	}
+	dqp, ok := cfg.Oracles.Compat["dqp"]
+	if !ok || dqp.SetOps == nil || !*dqp.SetOps || dqp.Limit != nil {
+		t.Fatalf("unexpected dqp override: %+v", dqp)
+	}
+}
+
 func TestLoadMPPBlockOverridesLegacyOracleSettings(t *testing.T) {
 	tmp, err := os.CreateTemp(t.TempDir(), "config-*.yaml")
 	if err != nil {
@@ -531,3 +728,343 @@ func TestNormalizeDQPComplexityThresholds(t *testing.T) {
 		t.Fatalf("unexpected normalized eet complexity join-table threshold: %d", cfg.Oracles.EETComplexityJoinTableThreshold)
 	}
 }
+
+func TestLoadValueGenerators(t *testing.T) {
+	tmp, err := os.CreateTemp(t.TempDir(), "config-*.yaml")
+	if err != nil {
+		t.Fatalf("create temp file: %v", err)
+	}
+	content := `value_generators:
+  - column: " (?i)email "
+    types: [" VARCHAR ", ""]
+    preset: " Email "
+  - column: "c0"
+    preset: ""
+`
+	if _, err := tmp.WriteString(content); err != nil {
+		t.Fatalf("write temp file: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		t.Fatalf("close temp file: %v", err)
+	}
+
+	cfg, err := Load(tmp.Name())
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if len(cfg.ValueGenerators) != 1 {
+		t.Fatalf("unexpected value generators: %+v", cfg.ValueGenerators)
+	}
+	rule := cfg.ValueGenerators[0]
+	if rule.Column != "(?i)email" || rule.Preset != "email" {
+		t.Fatalf("unexpected normalized rule: %+v", rule)
+	}
+	if len(rule.Types) != 1 || rule.Types[0] != "varchar" {
+		t.Fatalf("unexpected normalized types: %v", rule.Types)
+	}
+}
+
+func TestLoadHooks(t *testing.T) {
+	tmp, err := os.CreateTemp(t.TempDir(), "config-*.yaml")
+	if err != nil {
+		t.Fatalf("create temp file: %v", err)
+	}
+	content := `hooks:
+  run_start:
+    - sql: [" SELECT 1 ", ""]
+  case_captured:
+    - name: " logs "
+      shell: " tail -n 10 tidb.log "
+      timeout_seconds: 5
+    - name: empty
+`
+	if _, err := tmp.WriteString(content); err != nil {
+		t.Fatalf("write temp file: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		t.Fatalf("close temp file: %v", err)
+	}
+
+	cfg, err := Load(tmp.Name())
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if len(cfg.Hooks.RunStart) != 1 || len(cfg.Hooks.DatabaseRotate) != 0 || len(cfg.Hooks.CaseCaptured) != 1 {
+		t.Fatalf("unexpected hooks: %+v", cfg.Hooks)
+	}
+	start := cfg.Hooks.RunStart[0]
+	if start.Name != "run_start_0" || start.TimeoutSeconds != hookTimeoutSecondsDefault || len(start.SQL) != 1 || start.SQL[0] != "SELECT 1" {
+		t.Fatalf("unexpected run_start hook: %+v", start)
+	}
+	caseHook := cfg.Hooks.CaseCaptured[0]
+	if caseHook.Name != "logs" || caseHook.Shell != "tail -n 10 tidb.log" || caseHook.TimeoutSeconds != 5 {
+		t.Fatalf("unexpected case_captured hook: %+v", caseHook)
+	}
+}
+
+func TestLoadTiDBLogs(t *testing.T) {
+	tmp, err := os.CreateTemp(t.TempDir(), "config-*.yaml")
+	if err != nil {
+		t.Fatalf("create temp file: %v", err)
+	}
+	content := `tidb_logs:
+  sources:
+    - path: " /var/log/tidb.log "
+    - name: empty
+    - name: remote
+      command: ssh tidb tail -n 10 tidb.log
+`
+	if _, err := tmp.WriteString(content); err != nil {
+		t.Fatalf("write temp file: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		t.Fatalf("close temp file: %v", err)
+	}
+
+	cfg, err := Load(tmp.Name())
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	logs := cfg.TiDBLogs
+	if logs.Lines != tidbLogLinesDefault || logs.TimeoutSeconds != tidbLogTimeoutDefault || len(logs.Sources) != 2 {
+		t.Fatalf("unexpected tidb_logs: %+v", logs)
+	}
+	if logs.Sources[0].Name != "tidb_0" || logs.Sources[0].Path != "/var/log/tidb.log" || logs.Sources[1].Name != "remote" {
+		t.Fatalf("unexpected tidb_logs sources: %+v", logs.Sources)
+	}
+}
+
+func TestLoadDataProfiles(t *testing.T) {
+	tmp, err := os.CreateTemp(t.TempDir(), "config-*.yaml")
+	if err != nil {
+		t.Fatalf("create temp file: %v", err)
+	}
+	content := `data_profiles:
+  - table: " ^t0$ "
+    null_prob: 150
+    zipf_s: 1.2
+    correlation_prob: -5
+  - name: flat
+    zipf_s: 0.8
+    hot_values: 4
+`
+	if _, err := tmp.WriteString(content); err != nil {
+		t.Fatalf("write temp file: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		t.Fatalf("close temp file: %v", err)
+	}
+
+	cfg, err := Load(tmp.Name())
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if len(cfg.DataProfiles) != 2 {
+		t.Fatalf("unexpected data profiles: %+v", cfg.DataProfiles)
+	}
+	skewed := cfg.DataProfiles[0]
+	if skewed.Name != "profile_0" || skewed.Table != "^t0$" || skewed.NullProb != 100 || skewed.CorrelationProb != 0 || skewed.HotValues != dataProfileHotValuesDefault {
+		t.Fatalf("unexpected skewed profile: %+v", skewed)
+	}
+	flat := cfg.DataProfiles[1]
+	if flat.Name != "flat" || flat.ZipfS != 0 || flat.HotValues != 0 {
+		t.Fatalf("unexpected flat profile: %+v", flat)
+	}
+}
+
+func TestLoadScaleSchedule(t *testing.T) {
+	tmp, err := os.CreateTemp(t.TempDir(), "config-*.yaml")
+	if err != nil {
+		t.Fatalf("create temp file: %v", err)
+	}
+	content := `scale_schedule:
+  - at_iteration: 5000
+    max_tables: 8
+    max_rows_per_table: 2000
+  - at_iteration: 10
+  - at_iteration: -3
+    max_tables: 3
+    insert_batch_rows: -1
+`
+	if _, err := tmp.WriteString(content); err != nil {
+		t.Fatalf("write temp file: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		t.Fatalf("close temp file: %v", err)
+	}
+
+	cfg, err := Load(tmp.Name())
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	want := []ScaleStep{
+		{AtIteration: 0, MaxTables: 3},
+		{AtIteration: 5000, MaxTables: 8, MaxRowsPerTable: 2000},
+	}
+	if !reflect.DeepEqual(cfg.ScaleSchedule, want) {
+		t.Fatalf("unexpected scale schedule: %+v", cfg.ScaleSchedule)
+	}
+}
+
+func TestNormalizeInsertFill(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.InsertFillBatchRows = 5000
+	cfg.InsertNullRowProb = -1
+	cfg.InsertDefaultRowProb = 150
+	normalizeConfig(&cfg)
+	if cfg.InsertFillBatchRows != insertFillBatchRowsMax || cfg.InsertNullRowProb != 0 || cfg.InsertDefaultRowProb != 100 {
+		t.Fatalf("unexpected insert fill clamps: %d %d %d", cfg.InsertFillBatchRows, cfg.InsertNullRowProb, cfg.InsertDefaultRowProb)
+	}
+	cfg.InsertFillBatchRows = 0
+	normalizeConfig(&cfg)
+	if cfg.InsertFillBatchRows != insertFillBatchRowsDefault {
+		t.Fatalf("unset fill batch must take the default: %d", cfg.InsertFillBatchRows)
+	}
+}
+
+func TestPlanCacheProbKeepsItsShare(t *testing.T) {
+	cfg := defaultConfig()
+	others := cfg.Weights.Oracles
+	others.PlanCache = 0
+	normalizeConfig(&cfg)
+	if cfg.Weights.Oracles.PlanCache != planCacheWeightFromProb(50, others, cfg.Features) || cfg.Weights.Oracles.PlanCache < 10 {
+		t.Fatalf("default plan_cache_prob must keep half of the runs: %d", cfg.Weights.Oracles.PlanCache)
+	}
+	cfg.PlanCacheProb = 0
+	cfg.Weights.Oracles.PlanCache = 2
+	normalizeConfig(&cfg)
+	if cfg.Weights.Oracles.PlanCache != 2 {
+		t.Fatalf("plan_cache_prob 0 must keep the weight: %d", cfg.Weights.Oracles.PlanCache)
+	}
+	cfg.PlanCacheProb = 50
+	cfg.Weights.Oracles.PlanCache = 0
+	normalizeConfig(&cfg)
+	if cfg.Weights.Oracles.PlanCache != 0 {
+		t.Fatalf("plan_cache weight 0 must stay disabled: %d", cfg.Weights.Oracles.PlanCache)
+	}
+	cfg.Weights.Oracles = OracleWeights{NoREC: 6, TLP: 4, PlanCache: 2}
+	cfg.PlanCacheProb = 50
+	normalizeConfig(&cfg)
+	if cfg.Weights.Oracles.PlanCache != 10 {
+		t.Fatalf("plan_cache_prob 50 must match the other weights: %d", cfg.Weights.Oracles.PlanCache)
+	}
+	cfg.PlanCacheProb = 20
+	normalizeConfig(&cfg)
+	if cfg.Weights.Oracles.PlanCache != 3 {
+		t.Fatalf("plan_cache_prob 20 must give a fifth of the runs: %d", cfg.Weights.Oracles.PlanCache)
+	}
+
+	cfg.PlanCacheProb = 50
+	cfg.Weights.Oracles = OracleWeights{NoREC: 6, TLP: 4, PartitionRange: 5, PlanCache: 2}
+	cfg.Features.PartitionTables = false
+	normalizeConfig(&cfg)
+	if cfg.Weights.Oracles.PlanCache != 10 {
+		t.Fatalf("partition_tables=false must leave PartitionRange out of the share: %d", cfg.Weights.Oracles.PlanCache)
+	}
+	cfg.Weights.Oracles = OracleWeights{NoREC: 6, TLP: 4, PartitionRange: 5, PlanCache: 2}
+	cfg.Features.PartitionTables = true
+	normalizeConfig(&cfg)
+	if cfg.Weights.Oracles.PlanCache != 15 {
+		t.Fatalf("partition_tables=true must count PartitionRange in the share: %d", cfg.Weights.Oracles.PlanCache)
+	}
+}
+
+func TestNormalizeNeighborhoodMutants(t *testing.T) {
+	cfg := defaultConfig()
+	if cfg.Minimize.Neighborhood || cfg.Minimize.NeighborhoodMutants != neighborhoodMutantsDefault {
+		t.Fatalf("unexpected neighborhood defaults: %+v", cfg.Minimize)
+	}
+	cfg.Minimize.NeighborhoodMutants = 500
+	normalizeConfig(&cfg)
+	if cfg.Minimize.NeighborhoodMutants != neighborhoodMutantsMax {
+		t.Fatalf("expected mutants capped, got %d", cfg.Minimize.NeighborhoodMutants)
+	}
+	cfg.Minimize.NeighborhoodMutants = -1
+	normalizeConfig(&cfg)
+	if cfg.Minimize.NeighborhoodMutants != neighborhoodMutantsDefault {
+		t.Fatalf("expected default mutants, got %d", cfg.Minimize.NeighborhoodMutants)
+	}
+}
+
+func TestNormalizeWorkload(t *testing.T) {
+	w := WorkloadConfig{ReadPercent: 150, TxnPerSecond: -1}
+	normalizeWorkload(&w)
+	if w.Workers != workloadWorkersDefault || w.Tables != workloadTablesDefault || w.Rows != workloadRowsDefault || w.TxnStatements != workloadTxnStatementsDefault {
+		t.Fatalf("unexpected workload sizes: %+v", w)
+	}
+	if w.ReadPercent != 100 || w.TxnPerSecond != 0 {
+		t.Fatalf("unexpected workload clamps: %+v", w)
+	}
+}
+
+func TestClampLargeRowLimit(t *testing.T) {
+	if got := clampLargeRowLimit(0, largeRowMaxColumnsDefault, largeRowMaxColumnsMin, largeRowMaxColumnsMax); got != largeRowMaxColumnsDefault {
+		t.Fatalf("unset limit must take the default: %d", got)
+	}
+	if got := clampLargeRowLimit(4, largeRowMaxColumnsDefault, largeRowMaxColumnsMin, largeRowMaxColumnsMax); got != largeRowMaxColumnsMin {
+		t.Fatalf("small limit must be raised: %d", got)
+	}
+	if got := clampLargeRowLimit(64<<20, largeRowMaxPayloadDefault, largeRowMaxPayloadMin, largeRowMaxPayloadMax); got != largeRowMaxPayloadMax {
+		t.Fatalf("payload must stay under the entry size limit: %d", got)
+	}
+}
+
+func TestNormalizeTiFlashMode(t *testing.T) {
+	tests := map[string]string{
+		"":         "",
+		"fast":     TiFlashModeFast,
+		" FAST ":   TiFlashModeFast,
+		"Normal":   TiFlashModeNormal,
+		"columnar": "",
+	}
+	for in, want := range tests {
+		if got := normalizeTiFlashMode(in); got != want {
+			t.Fatalf("normalizeTiFlashMode(%q)=%q want=%q", in, got, want)
+		}
+	}
+}
+
+func TestNormalizeSessionInit(t *testing.T) {
+	got := NormalizeSessionInit([]string{
+		" tidb_mem_quota_query=1073741824; ",
+		"@@sql_mode = ''",
+		"",
+		"SET NAMES utf8mb4",
+		"set session tidb_opt_agg_push_down=1",
+	})
+	want := []string{
+		"SET SESSION tidb_mem_quota_query=1073741824",
+		"SET @@sql_mode = ''",
+		"SET NAMES utf8mb4",
+		"set session tidb_opt_agg_push_down=1",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected session init: %q", got)
+	}
+}
+
+func TestNormalizeOracleDrift(t *testing.T) {
+	drift := OracleDriftConfig{Probability: 150, Kinds: []string{" ANALYZE", "bogus", "analyze", "plan_cache_flush"}}
+	normalizeOracleDrift(&drift)
+	if drift.Probability != 100 || strings.Join(drift.Kinds, ",") != "analyze,plan_cache_flush" {
+		t.Fatalf("unexpected normalized drift: %+v", drift)
+	}
+	drift = OracleDriftConfig{Kinds: []string{"bogus"}}
+	normalizeOracleDrift(&drift)
+	if len(drift.Kinds) != 3 {
+		t.Fatalf("expected every kind for an empty list: %+v", drift)
+	}
+}
+
+func TestNormalizeSelectivity(t *testing.T) {
+	s := SelectivityConfig{SampleProb: 120, RareMaxPercent: 100, Mix: SelectivityMix{Empty: -5}}
+	normalizeSelectivity(&s)
+	if s.SampleProb != 100 || s.RareMaxPercent != selectivityRareMaxDefault || s.Mix != selectivityMixDefault {
+		t.Fatalf("unexpected normalized selectivity: %+v", s)
+	}
+	s = SelectivityConfig{SampleProb: -1, RareMaxPercent: 5, Mix: SelectivityMix{Empty: -1, All: 3}}
+	normalizeSelectivity(&s)
+	if s.SampleProb != 0 || s.RareMaxPercent != 5 || s.Mix != (SelectivityMix{All: 3}) {
+		t.Fatalf("unexpected normalized selectivity: %+v", s)
+	}
+}
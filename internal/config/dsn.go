@@ -0,0 +1,120 @@
+package config
+
+import "strings"
+
+// splitDSN splits a DSN into the part up to and including the database path
+// separator, the database name, and the query parameters (with the leading
+// '?'). Like the MySQL driver it uses the last '/', because passwords and unix
+// socket paths may contain '/'.
+func splitDSN(dsn string) (head string, dbName string, params string, ok bool) {
+	slash := strings.LastIndex(dsn, "/")
+	if slash < 0 {
+		return dsn, "", "", false
+	}
+	head = dsn[:slash+1]
+	rest := dsn[slash+1:]
+	if query := strings.Index(rest, "?"); query >= 0 {
+		return head, rest[:query], rest[query:], true
+	}
+	return head, rest, "", true
+}
+
+func ensureDatabaseInDSN(dsn string, dbName string) string {
+	if dsn == "" || dbName == "" {
+		return dsn
+	}
+	head, current, params, ok := splitDSN(dsn)
+	if !ok || strings.TrimSpace(current) != "" {
+		return dsn
+	}
+	return head + dbName + params
+}
+
+// UpdateDatabaseInDSN replaces the database name in the DSN path with dbName.
+// It preserves query parameters, if any.
+func UpdateDatabaseInDSN(dsn string, dbName string) string {
+	if dsn == "" || dbName == "" {
+		return dsn
+	}
+	head, _, params, ok := splitDSN(dsn)
+	if !ok {
+		return dsn
+	}
+	return head + dbName + params
+}
+
+// AdminDSN strips the database name from a DSN while preserving query parameters.
+func AdminDSN(dsn string) string {
+	if dsn == "" {
+		return dsn
+	}
+	head, _, params, ok := splitDSN(dsn)
+	if !ok {
+		return dsn
+	}
+	return head + params
+}
+
+// SplitDSNHosts expands a DSN whose address lists several comma-separated
+// endpoints, for example root@tcp(10.0.0.1:4000,[::1]:4000)/db, into one DSN
+// per endpoint. Any other DSN, including unix sockets, is returned as is.
+func SplitDSNHosts(dsn string) []string {
+	head, dbName, params, ok := splitDSN(dsn)
+	if !ok {
+		return []string{dsn}
+	}
+	open := strings.LastIndex(head, "(")
+	closing := strings.LastIndex(head, ")")
+	if open < 0 || closing < open {
+		return []string{dsn}
+	}
+	addrs := strings.Split(head[open+1:closing], ",")
+	if len(addrs) < 2 {
+		return []string{dsn}
+	}
+	out := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		addr = strings.TrimSpace(addr)
+		if addr == "" {
+			continue
+		}
+		out = append(out, head[:open+1]+addr+head[closing:]+dbName+params)
+	}
+	if len(out) == 0 {
+		return []string{dsn}
+	}
+	return out
+}
+
+// SetDSNPassword replaces the password of the user in the DSN. Like the MySQL
+// driver it splits the user info at the last '@' before the database path and
+// the user at the first ':'. A DSN without user info is returned as is.
+func SetDSNPassword(dsn string, password string) string {
+	head, dbName, params, ok := splitDSN(dsn)
+	if !ok {
+		return dsn
+	}
+	at := strings.LastIndex(head, "@")
+	if at < 0 {
+		return dsn
+	}
+	user, _, _ := strings.Cut(head[:at], ":")
+	return user + ":" + password + head[at:] + dbName + params
+}
+
+// SetDSNUser replaces the user and password of the DSN, adding user info when
+// it has none. Every endpoint of a multi-endpoint DSN is kept.
+func SetDSNUser(dsn string, user string, password string) string {
+	head, dbName, params, ok := splitDSN(dsn)
+	if !ok {
+		return dsn
+	}
+	info := user
+	if password != "" {
+		info += ":" + password
+	}
+	if at := strings.LastIndex(head, "@"); at >= 0 {
+		head = head[at+1:]
+	}
+	return info + "@" + head + dbName + params
+}
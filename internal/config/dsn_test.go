@@ -0,0 +1,88 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDSNDatabaseHelpers(t *testing.T) {
+	cases := []struct {
+		dsn     string
+		update  string
+		admin   string
+		ensured string
+	}{
+		{
+			dsn:     "root:@tcp(127.0.0.1:4000)/old?parseTime=true",
+			update:  "root:@tcp(127.0.0.1:4000)/shiro?parseTime=true",
+			admin:   "root:@tcp(127.0.0.1:4000)/?parseTime=true",
+			ensured: "root:@tcp(127.0.0.1:4000)/old?parseTime=true",
+		},
+		{
+			dsn:     "root@unix(/tmp/tidb.sock)/",
+			update:  "root@unix(/tmp/tidb.sock)/shiro",
+			admin:   "root@unix(/tmp/tidb.sock)/",
+			ensured: "root@unix(/tmp/tidb.sock)/shiro",
+		},
+		{
+			dsn:     "root:p/w@tcp([::1]:4000,[fe80::2]:4000)/old",
+			update:  "root:p/w@tcp([::1]:4000,[fe80::2]:4000)/shiro",
+			admin:   "root:p/w@tcp([::1]:4000,[fe80::2]:4000)/",
+			ensured: "root:p/w@tcp([::1]:4000,[fe80::2]:4000)/old",
+		},
+	}
+	for _, tc := range cases {
+		if got := UpdateDatabaseInDSN(tc.dsn, "shiro"); got != tc.update {
+			t.Fatalf("UpdateDatabaseInDSN(%q)=%q want=%q", tc.dsn, got, tc.update)
+		}
+		if got := AdminDSN(tc.dsn); got != tc.admin {
+			t.Fatalf("AdminDSN(%q)=%q want=%q", tc.dsn, got, tc.admin)
+		}
+		if got := ensureDatabaseInDSN(tc.dsn, "shiro"); got != tc.ensured {
+			t.Fatalf("ensureDatabaseInDSN(%q)=%q want=%q", tc.dsn, got, tc.ensured)
+		}
+	}
+}
+
+func TestSplitDSNHosts(t *testing.T) {
+	got := SplitDSNHosts("root:@tcp(10.0.0.1:4000, [::1]:4000)/db?timeout=5s")
+	want := []string{"root:@tcp(10.0.0.1:4000)/db?timeout=5s", "root:@tcp([::1]:4000)/db?timeout=5s"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("SplitDSNHosts=%q want=%q", got, want)
+	}
+	for _, dsn := range []string{"root:@tcp(127.0.0.1:4000)/db", "root@unix(/tmp/tidb.sock)/db", "root@/db"} {
+		if got := SplitDSNHosts(dsn); len(got) != 1 || got[0] != dsn {
+			t.Fatalf("SplitDSNHosts(%q)=%q want unchanged", dsn, got)
+		}
+	}
+}
+
+func TestSetDSNPassword(t *testing.T) {
+	cases := map[string]string{
+		"root:@tcp(127.0.0.1:4000)/db?timeout=5s": "root:s@cr/t@tcp(127.0.0.1:4000)/db?timeout=5s",
+		"root:old@tcp(h1:4000,h2:4000)/":          "root:s@cr/t@tcp(h1:4000,h2:4000)/",
+		"shiro@unix(/tmp/tidb.sock)/":             "shiro:s@cr/t@unix(/tmp/tidb.sock)/",
+		"tcp(127.0.0.1:4000)/db":                  "tcp(127.0.0.1:4000)/db",
+	}
+	for dsn, want := range cases {
+		if got := SetDSNPassword(dsn, "s@cr/t"); got != want {
+			t.Fatalf("SetDSNPassword(%q)=%q want=%q", dsn, got, want)
+		}
+	}
+}
+
+func TestSetDSNUser(t *testing.T) {
+	cases := map[string]string{
+		"root:pw@tcp(h1:4000,h2:4000)/db?timeout=5s": "shiro_r:s3cret@tcp(h1:4000,h2:4000)/db?timeout=5s",
+		"root@unix(/tmp/tidb.sock)/":                 "shiro_r:s3cret@unix(/tmp/tidb.sock)/",
+		"tcp(127.0.0.1:4000)/db":                     "shiro_r:s3cret@tcp(127.0.0.1:4000)/db",
+	}
+	for dsn, want := range cases {
+		if got := SetDSNUser(dsn, "shiro_r", "s3cret"); got != want {
+			t.Fatalf("SetDSNUser(%q)=%q want=%q", dsn, got, want)
+		}
+	}
+	if got := SetDSNUser("root:pw@tcp(h1:4000)/", "u", ""); got != "u@tcp(h1:4000)/" {
+		t.Fatalf("SetDSNUser without password=%q", got)
+	}
+}
@@ -0,0 +1,212 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	// focusProbFloor is the lowest feature probability a focus area leaves;
+	// lower probabilities are doubled up to it.
+	focusProbFloor = 60
+	// focusCountBoost is added to the join, CTE, and subquery counts.
+	focusCountBoost = 2
+	// focusOracleFactor multiplies the weight of the oracles a focus area
+	// names. Oracles weighted 0 stay off.
+	focusOracleFactor = 3
+)
+
+// focusArea is one entry of focus_areas: the generator features it turns on,
+// the feature weights it raises, and the oracles it favors.
+type focusArea struct {
+	features func(*Features)
+	weights  func(*FeatureWeights)
+	oracles  func(*OracleWeights) []*int
+}
+
+// focusAreas holds the known focus areas by canonical name. Names follow the
+// wording of TiDB release notes, so a campaign can copy them from there.
+var focusAreas = map[string]focusArea{
+	"window_functions": {
+		features: func(f *Features) { f.WindowFuncs, f.WindowFrames = true, true },
+		weights:  func(w *FeatureWeights) { boostProb(&w.WindowProb) },
+		oracles:  func(o *OracleWeights) []*int { return []*int{&o.EET, &o.CODDTest, &o.NoREC} },
+	},
+	"partition_pruning": {
+		features: func(f *Features) { f.PartitionTables = true },
+		weights:  func(w *FeatureWeights) { boostProb(&w.PartitionProb) },
+		oracles:  func(o *OracleWeights) []*int { return []*int{&o.TLP, &o.NoREC, &o.DQP, &o.PartitionRange} },
+	},
+	"cte_inline": {
+		features: func(f *Features) { f.CTE = true },
+		weights:  func(w *FeatureWeights) { boostCount(&w.CTECount) },
+		oracles:  func(o *OracleWeights) []*int { return []*int{&o.CTEInline} },
+	},
+	"recursive_cte": {
+		features: func(f *Features) { f.CTE, f.RecursiveCTE = true, true },
+		weights:  func(w *FeatureWeights) { boostCount(&w.CTECount) },
+		oracles:  func(o *OracleWeights) []*int { return []*int{&o.CTEInline, &o.EET} },
+	},
+	"subqueries": {
+		features: func(f *Features) {
+			f.Subqueries, f.CorrelatedSubq, f.QuantifiedSubqueries = true, true, true
+			f.NotExists, f.NotIn = true, true
+		},
+		weights: func(w *FeatureWeights) {
+			boostCount(&w.SubqCount)
+			boostProb(&w.NotExistsProb)
+			boostProb(&w.NotInProb)
+			boostProb(&w.NullAwareProb)
+		},
+		oracles: func(o *OracleWeights) []*int { return []*int{&o.DQP, &o.NoREC, &o.CODDTest} },
+	},
+	"aggregation": {
+		features: func(f *Features) {
+			f.Aggregates, f.GroupBy, f.Having, f.GroupByRollup = true, true, true, true
+		},
+		weights: func(w *FeatureWeights) {
+			boostProb(&w.AggProb)
+			boostProb(&w.GroupByProb)
+			boostProb(&w.HavingProb)
+		},
+		oracles: func(o *OracleWeights) []*int { return []*int{&o.TLP, &o.FullGroupBy} },
+	},
+	"joins": {
+		features: func(f *Features) { f.Joins, f.NaturalJoins, f.FullJoinEmulation = true, true, true },
+		weights:  func(w *FeatureWeights) { boostCount(&w.JoinCount) },
+		oracles:  func(o *OracleWeights) []*int { return []*int{&o.DQP, &o.EET, &o.FullJoin} },
+	},
+	"set_operations": {
+		features: func(f *Features) { f.SetOperations = true },
+		oracles:  func(o *OracleWeights) []*int { return []*int{&o.EET, &o.CODDTest} },
+	},
+	"indexes": {
+		features: func(f *Features) { f.Indexes, f.ClusteredIndex = true, true },
+		weights: func(w *FeatureWeights) {
+			boostProb(&w.IndexPrefixProb)
+			boostProb(&w.ClusteredPKProb)
+			boostProb(&w.CompositePKProb)
+		},
+		oracles: func(o *OracleWeights) []*int { return []*int{&o.DQE, &o.DQP, &o.InList} },
+	},
+	"plan_cache": {
+		features: func(f *Features) { f.PlanCache, f.NonPreparedPlanCache = true, true },
+		oracles:  func(o *OracleWeights) []*int { return []*int{&o.PlanCache} },
+	},
+	"foreign_keys": {
+		features: func(f *Features) { f.ForeignKeys = true },
+		oracles:  func(o *OracleWeights) []*int { return []*int{&o.FKCascade} },
+	},
+	"decimal": {
+		weights: func(w *FeatureWeights) { boostProb(&w.DecimalAggProb) },
+		oracles: func(o *OracleWeights) []*int { return []*int{&o.DecimalArith, &o.ResultType} },
+	},
+	"transactions": {
+		oracles: func(o *OracleWeights) []*int { return []*int{&o.TxnRYW, &o.Savepoint, &o.BatchDML} },
+	},
+	"tiflash": {
+		oracles: func(o *OracleWeights) []*int { return []*int{&o.TiFlashOnly} },
+	},
+	"cursor_fetch": {
+		oracles: func(o *OracleWeights) []*int { return []*int{&o.CursorFetch} },
+	},
+}
+
+// focusAreaAliases maps other common release-note wordings to canonical names.
+var focusAreaAliases = map[string]string{
+	"window":              "window_functions",
+	"partition":           "partition_pruning",
+	"partitions":          "partition_pruning",
+	"cte":                 "cte_inline",
+	"subquery":            "subqueries",
+	"aggregate":           "aggregation",
+	"join":                "joins",
+	"index":               "indexes",
+	"index_selection":     "indexes",
+	"prepared_plan_cache": "plan_cache",
+	"foreign_key":         "foreign_keys",
+	"mpp":                 "tiflash",
+	"transaction":         "transactions",
+}
+
+// FocusAreaNames returns the canonical focus area names in lexical order.
+func FocusAreaNames() []string {
+	names := make([]string, 0, len(focusAreas))
+	for name := range focusAreas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// canonicalFocusArea returns the canonical name of a focus area, matching
+// case-insensitively with spaces and hyphens read as underscores, or empty
+// for unknown names.
+func canonicalFocusArea(name string) string {
+	key := strings.ToLower(strings.TrimSpace(name))
+	key = strings.NewReplacer(" ", "_", "-", "_").Replace(key)
+	if alias, ok := focusAreaAliases[key]; ok {
+		key = alias
+	}
+	if _, ok := focusAreas[key]; !ok {
+		return ""
+	}
+	return key
+}
+
+// checkFocusAreas rejects focus area names that match no known area, so a
+// typo does not leave a release campaign unfocused.
+func checkFocusAreas(names []string) error {
+	var unknown []string
+	for _, name := range names {
+		if strings.TrimSpace(name) != "" && canonicalFocusArea(name) == "" {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown focus_areas %q; known areas: %s", unknown, strings.Join(FocusAreaNames(), ", "))
+	}
+	return nil
+}
+
+// normalizeFocusAreas returns the canonical names of the known areas, without
+// duplicates, in config order.
+func normalizeFocusAreas(names []string) []string {
+	var out []string
+	for _, name := range names {
+		if key := canonicalFocusArea(name); key != "" && !slices.Contains(out, key) {
+			out = append(out, key)
+		}
+	}
+	return out
+}
+
+// applyFocusAreas turns on the features of each focus area and raises its
+// feature and oracle weights. Boosts of overlapping areas stack.
+func applyFocusAreas(cfg *Config) {
+	cfg.FocusAreas = normalizeFocusAreas(cfg.FocusAreas)
+	for _, name := range cfg.FocusAreas {
+		area := focusAreas[name]
+		if area.features != nil {
+			area.features(&cfg.Features)
+		}
+		if area.weights != nil {
+			area.weights(&cfg.Weights.Features)
+		}
+		if area.oracles != nil {
+			for _, weight := range area.oracles(&cfg.Weights.Oracles) {
+				*weight *= focusOracleFactor
+			}
+		}
+	}
+}
+
+func boostProb(p *int) {
+	*p = clampPercent(max(*p*2, focusProbFloor))
+}
+
+func boostCount(n *int) {
+	*n += focusCountBoost
+}
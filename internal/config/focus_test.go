@@ -0,0 +1,53 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApplyFocusAreas(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.FocusAreas = []string{"Window Functions", "partition-pruning", "cte inline", "window"}
+	normalizeConfig(&cfg)
+	if strings.Join(cfg.FocusAreas, ",") != "window_functions,partition_pruning,cte_inline" {
+		t.Fatalf("unexpected focus areas: %v", cfg.FocusAreas)
+	}
+	if !cfg.Features.WindowFuncs || !cfg.Features.WindowFrames || !cfg.Features.CTE || !cfg.Features.PartitionTables {
+		t.Fatalf("expected focus features on: %+v", cfg.Features)
+	}
+	w := cfg.Weights
+	if w.Features.WindowProb != 60 || w.Features.PartitionProb != 60 || w.Features.CTECount != 6 {
+		t.Fatalf("unexpected feature weights: %+v", w.Features)
+	}
+	// NoREC is favored by two areas, so its boosts stack.
+	if w.Oracles.CTEInline != 3 || w.Oracles.EET != 6 || w.Oracles.NoREC != 36 || w.Oracles.GroundTruth != 5 {
+		t.Fatalf("unexpected oracle weights: %+v", w.Oracles)
+	}
+}
+
+func TestApplyFocusAreasKeepsDisabledOracles(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.Weights.Oracles.CursorFetch = 0
+	cfg.Weights.Features.AggProb = 90
+	cfg.FocusAreas = []string{"cursor_fetch", "aggregation"}
+	normalizeConfig(&cfg)
+	if cfg.Weights.Oracles.CursorFetch != 0 {
+		t.Fatalf("expected cursor_fetch to stay off, got %d", cfg.Weights.Oracles.CursorFetch)
+	}
+	if cfg.Weights.Features.AggProb != 100 {
+		t.Fatalf("expected agg prob clamped to 100, got %d", cfg.Weights.Features.AggProb)
+	}
+}
+
+func TestLoadRejectsUnknownFocusArea(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("focus_areas: [\"window functions\", \"vector search\"]\n"), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	_, err := Load(path)
+	if err == nil || !strings.Contains(err.Error(), "vector search") {
+		t.Fatalf("expected unknown focus area error, got %v", err)
+	}
+}
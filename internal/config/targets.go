@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+)
+
+var targetNamePattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// TargetConfig is one database a multi-target run fuzzes in the same
+// process. An empty DSN, DSNPassword, or SessionInit inherits the top-level
+// one, and an empty Database becomes <database>_<name>. Weight is the share
+// of iterations the target gets relative to the others (default 1).
+type TargetConfig struct {
+	Name        string   `yaml:"name"`
+	DSN         string   `yaml:"dsn"`
+	DSNPassword string   `yaml:"dsn_password"`
+	Database    string   `yaml:"database"`
+	SessionInit []string `yaml:"session_init"`
+	Weight      int      `yaml:"weight"`
+}
+
+// normalizeTargets fills the inherited fields of each target and points its
+// DSN at its database. It runs after the top-level DSN and database are
+// normalized.
+func normalizeTargets(cfg *Config) {
+	for i := range cfg.Targets {
+		target := &cfg.Targets[i]
+		if target.Name == "" {
+			target.Name = fmt.Sprintf("t%d", i)
+		}
+		if target.DSN == "" {
+			target.DSN = cfg.DSN
+		}
+		if target.DSNPassword == "" {
+			target.DSNPassword = cfg.DSNPassword
+		}
+		if target.Database == "" {
+			target.Database = cfg.Database + "_" + target.Name
+		}
+		target.DSN = UpdateDatabaseInDSN(target.DSN, target.Database)
+		if target.SessionInit == nil {
+			target.SessionInit = cfg.SessionInit
+		} else {
+			target.SessionInit = NormalizeSessionInit(target.SessionInit)
+		}
+		target.Weight = max(target.Weight, 1)
+	}
+}
+
+// checkTargets rejects target names that cannot name a directory, and
+// targets sharing a name or a database, whose cases and run files would
+// overwrite each other.
+func checkTargets(targets []TargetConfig) error {
+	var names, databases []string
+	for _, target := range targets {
+		if !targetNamePattern.MatchString(target.Name) {
+			return fmt.Errorf("target name %q must use only letters, digits, and underscores", target.Name)
+		}
+		if slices.Contains(names, target.Name) {
+			return fmt.Errorf("duplicate target name %q", target.Name)
+		}
+		if slices.Contains(databases, target.Database) {
+			return fmt.Errorf("targets share database %q; give target %q its own database", target.Database, target.Name)
+		}
+		names = append(names, target.Name)
+		databases = append(databases, target.Database)
+	}
+	return nil
+}
+
+// ForTarget returns the config of the runners that fuzz target: its
+// connection settings over the shared ones, with cases and query samples
+// under a directory named after the target. Uploaded cases already have
+// unique ids, so they share the storage prefix and the stop object.
+func (c Config) ForTarget(target TargetConfig) Config {
+	out := c
+	out.Targets = nil
+	out.Target = target.Name
+	out.DSN = target.DSN
+	out.DSNPassword = target.DSNPassword
+	out.Database = target.Database
+	out.SessionInit = target.SessionInit
+	out.PlanReplayer.OutputDir = filepath.Join(c.PlanReplayer.OutputDir, target.Name)
+	out.Logging.QuerySample.Dir = filepath.Join(c.Logging.QuerySample.Dir, target.Name)
+	return out
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadTargets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := strings.Join([]string{
+		"dsn: root:@tcp(127.0.0.1:4000)/",
+		"dsn_password: vault:secret/data/shiro#password",
+		"database: shiro",
+		"session_init: [\"SET @@tidb_enable_index_merge = 1\"]",
+		"plan_replayer:",
+		"  output_dir: reports",
+		"targets:",
+		"  - name: main",
+		"  - name: next",
+		"    dsn: root:@tcp(10.0.0.2:4000)/?charset=utf8mb4",
+		"    dsn_password: plain",
+		"    database: shiro_next",
+		"    session_init: []",
+		"    weight: 3",
+		"",
+	}, "\n")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	shared, next := cfg.Targets[0], cfg.Targets[1]
+	if shared.DSN != "root:@tcp(127.0.0.1:4000)/shiro_main" || shared.Database != "shiro_main" || shared.Weight != 1 || shared.DSNPassword != cfg.DSNPassword || len(shared.SessionInit) != 1 {
+		t.Fatalf("unexpected inherited target: %+v", shared)
+	}
+	if next.DSN != "root:@tcp(10.0.0.2:4000)/shiro_next?charset=utf8mb4" || next.DSNPassword != "plain" || next.Weight != 3 || len(next.SessionInit) != 0 {
+		t.Fatalf("unexpected explicit target: %+v", next)
+	}
+
+	targetCfg := cfg.ForTarget(next)
+	if targetCfg.Target != "next" || targetCfg.Database != "shiro_next" || targetCfg.DSN != next.DSN || len(targetCfg.Targets) != 0 {
+		t.Fatalf("unexpected target config: target=%q database=%q dsn=%q", targetCfg.Target, targetCfg.Database, targetCfg.DSN)
+	}
+	if targetCfg.PlanReplayer.OutputDir != filepath.Join("reports", "next") || targetCfg.Logging.QuerySample.Dir != filepath.Join("reports", "sampled", "next") {
+		t.Fatalf("unexpected target dirs: %q %q", targetCfg.PlanReplayer.OutputDir, targetCfg.Logging.QuerySample.Dir)
+	}
+	if cfg.PlanReplayer.OutputDir != "reports" || len(cfg.Targets) != 2 {
+		t.Fatalf("ForTarget changed the shared config")
+	}
+}
+
+func TestCheckTargets(t *testing.T) {
+	cases := []struct {
+		targets []TargetConfig
+		want    string
+	}{
+		{targets: []TargetConfig{{Name: "a/b", Database: "x"}}, want: "letters, digits, and underscores"},
+		{targets: []TargetConfig{{Name: "a", Database: "x"}, {Name: "a", Database: "y"}}, want: "duplicate target name"},
+		{targets: []TargetConfig{{Name: "a", Database: "x"}, {Name: "b", Database: "x"}}, want: "share database"},
+	}
+	for _, tc := range cases {
+		if err := checkTargets(tc.targets); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("checkTargets(%+v) = %v, want %q", tc.targets, err, tc.want)
+		}
+	}
+	if err := checkTargets([]TargetConfig{{Name: "a", Database: "x"}, {Name: "b", Database: "y"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
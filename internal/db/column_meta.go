@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"shiro/internal/util"
+)
+
+// ColumnMeta is the result-set metadata the driver reports for one column.
+// Precision/Scale are only set for types with a decimal size, and Length only
+// for variable-length types.
+type ColumnMeta struct {
+	Name      string
+	Type      string
+	Nullable  bool
+	Precision int64
+	Scale     int64
+	Length    int64
+}
+
+// String renders the metadata as "name TYPE(p,s) NULL".
+func (m ColumnMeta) String() string {
+	var b strings.Builder
+	b.WriteString(m.Name)
+	b.WriteString(" ")
+	b.WriteString(m.Type)
+	switch {
+	case m.Precision != 0 || m.Scale != 0:
+		fmt.Fprintf(&b, "(%d,%d)", m.Precision, m.Scale)
+	case m.Length != 0:
+		fmt.Fprintf(&b, "(%d)", m.Length)
+	}
+	if m.Nullable {
+		b.WriteString(" NULL")
+	} else {
+		b.WriteString(" NOT NULL")
+	}
+	return b.String()
+}
+
+// QueryColumnMeta runs a query and returns its result-set column metadata.
+// The rows are discarded; only the column definitions sent before them are read.
+func (d *DB) QueryColumnMeta(ctx context.Context, query string) ([]ColumnMeta, error) {
+	if err := d.validate(query); err != nil {
+		return nil, err
+	}
+	var (
+		rows *sql.Rows
+		err  error
+	)
+	if d.SnapshotTSO != 0 {
+		conn, release, connErr := d.snapshotConn(ctx)
+		if connErr != nil {
+			return nil, connErr
+		}
+		defer release()
+		rows, err = conn.QueryContext(ctx, query)
+	} else {
+		rows, err = d.DB.QueryContext(ctx, query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer util.CloseWithErr(rows, "column meta rows")
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	metas := make([]ColumnMeta, 0, len(types))
+	for _, ct := range types {
+		meta := ColumnMeta{Name: ct.Name(), Type: ct.DatabaseTypeName()}
+		if nullable, ok := ct.Nullable(); ok {
+			meta.Nullable = nullable
+		}
+		if precision, scale, ok := ct.DecimalSize(); ok {
+			meta.Precision, meta.Scale = precision, scale
+		}
+		if length, ok := ct.Length(); ok {
+			meta.Length = length
+		}
+		metas = append(metas, meta)
+	}
+	return metas, rows.Err()
+}
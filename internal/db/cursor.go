@@ -0,0 +1,498 @@
+package db
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"shiro/internal/config"
+)
+
+const (
+	cursorComQuit        = 0x01
+	cursorComQuery       = 0x03
+	cursorComStmtPrepare = 0x16
+	cursorComStmtExecute = 0x17
+	cursorComStmtClose   = 0x19
+	cursorComStmtFetch   = 0x1c
+
+	cursorClientLongPassword     = 0x00000001
+	cursorClientConnectWithDB    = 0x00000008
+	cursorClientProtocol41       = 0x00000200
+	cursorClientTransactions     = 0x00002000
+	cursorClientSecureConnection = 0x00008000
+	cursorClientPluginAuth       = 0x00080000
+
+	cursorStatusCursorExists = 0x0040
+	cursorStatusLastRowSent  = 0x0080
+
+	cursorTypeReadOnly   = 0x01
+	cursorCollationUTF8  = 45 // utf8mb4_general_ci, the go-sql-driver default
+	cursorMaxPacket      = 1<<24 - 1
+	cursorNativePassword = "mysql_native_password"
+)
+
+// CursorConn is a bare MySQL protocol connection that executes prepared
+// statements with a read-only cursor and reads the rows in batches with
+// COM_STMT_FETCH, which go-sql-driver/mysql does not implement. It supports
+// plain TCP or unix sockets with mysql_native_password authentication only,
+// and its statements bypass statement tracing.
+type CursorConn struct {
+	conn net.Conn
+	br   *bufio.Reader
+	seq  byte
+}
+
+// CursorResult is the signature of one prepared statement execution: the row
+// count and the XOR of the CRC32 of every binary row packet. Both execution
+// modes return rows in the binary protocol, so equal rows hash equally.
+// Fetches counts the COM_STMT_FETCH round trips.
+type CursorResult struct {
+	Signature
+	Fetches int
+}
+
+// DialCursor connects to dsn and runs sessionInit. A DSN that lists several
+// endpoints dials the one the connection pools of those servers use, and
+// fails over like them.
+func DialCursor(ctx context.Context, dsn string, sessionInit ...string) (*CursorConn, error) {
+	hosts := config.SplitDSNHosts(dsn)
+	cfgs := make([]*mysql.Config, 0, len(hosts))
+	addrs := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		cfg, err := mysql.ParseDSN(host)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.TLS != nil || (cfg.TLSConfig != "" && cfg.TLSConfig != "false") {
+			return nil, errors.New("cursor conn: TLS is not supported")
+		}
+		cfgs = append(cfgs, cfg)
+		addrs = append(addrs, cfg.Addr)
+	}
+	var c *CursorConn
+	var err error
+	if len(cfgs) == 1 {
+		c, err = dialCursor(ctx, cfgs[0])
+	} else {
+		err = sharedEndpointSet(addrs).connect(ctx, func(idx int) error {
+			var dialErr error
+			c, dialErr = dialCursor(ctx, cfgs[idx])
+			return dialErr
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, stmt := range sessionInit {
+		if err := c.Exec(ctx, stmt); err != nil {
+			_ = c.Close()
+			return nil, fmt.Errorf("session init %q: %w", stmt, err)
+		}
+	}
+	return c, nil
+}
+
+// dialCursor connects and authenticates to the single endpoint of cfg.
+func dialCursor(ctx context.Context, cfg *mysql.Config) (*CursorConn, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, cfg.Net, cfg.Addr)
+	if err != nil {
+		return nil, err
+	}
+	c := &CursorConn{conn: conn, br: bufio.NewReader(conn)}
+	stop := c.watch(ctx)
+	err = c.handshake(cfg)
+	stop()
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Close sends COM_QUIT and closes the socket.
+func (c *CursorConn) Close() error {
+	c.seq = 0
+	_ = c.writePacket([]byte{cursorComQuit})
+	return c.conn.Close()
+}
+
+// Exec runs a text-protocol statement and discards any rows it returns.
+func (c *CursorConn) Exec(ctx context.Context, query string) error {
+	defer c.watch(ctx)()
+	if err := c.command(cursorComQuery, []byte(query)); err != nil {
+		return err
+	}
+	payload, err := c.readPacket()
+	if err != nil {
+		return err
+	}
+	switch payload[0] {
+	case 0x00:
+		return nil
+	case 0xff:
+		return parseCursorErr(payload)
+	}
+	if err := c.skipUntilEOF(); err != nil {
+		return err
+	}
+	_, _, err = c.readRows(nil)
+	return err
+}
+
+// Prepare prepares query and returns the statement id. Statements with
+// placeholders are rejected, since Execute binds no parameters.
+func (c *CursorConn) Prepare(ctx context.Context, query string) (uint32, error) {
+	defer c.watch(ctx)()
+	if err := c.command(cursorComStmtPrepare, []byte(query)); err != nil {
+		return 0, err
+	}
+	payload, err := c.readPacket()
+	if err != nil {
+		return 0, err
+	}
+	if payload[0] == 0xff {
+		return 0, parseCursorErr(payload)
+	}
+	if payload[0] != 0x00 || len(payload) < 12 {
+		return 0, errors.New("cursor conn: malformed prepare response")
+	}
+	id := binary.LittleEndian.Uint32(payload[1:5])
+	columns := binary.LittleEndian.Uint16(payload[5:7])
+	params := binary.LittleEndian.Uint16(payload[7:9])
+	if params > 0 {
+		if err := c.skipUntilEOF(); err != nil {
+			return 0, err
+		}
+	}
+	if columns > 0 {
+		if err := c.skipUntilEOF(); err != nil {
+			return 0, err
+		}
+	}
+	if params > 0 {
+		c.CloseStmt(id)
+		return 0, fmt.Errorf("cursor conn: statement has %d placeholders", params)
+	}
+	return id, nil
+}
+
+// Execute runs a prepared statement. With fetchSize 0 the server sends every
+// row in the execute response; otherwise it opens a read-only cursor and the
+// rows are fetched fetchSize at a time.
+func (c *CursorConn) Execute(ctx context.Context, id uint32, fetchSize int) (CursorResult, error) {
+	defer c.watch(ctx)()
+	var result CursorResult
+	body := make([]byte, 9)
+	binary.LittleEndian.PutUint32(body[0:4], id)
+	if fetchSize > 0 {
+		body[4] = cursorTypeReadOnly
+	}
+	binary.LittleEndian.PutUint32(body[5:9], 1)
+	if err := c.command(cursorComStmtExecute, body); err != nil {
+		return result, err
+	}
+	payload, err := c.readPacket()
+	if err != nil {
+		return result, err
+	}
+	switch payload[0] {
+	case 0x00:
+		return result, nil
+	case 0xff:
+		return result, parseCursorErr(payload)
+	}
+	status, err := c.skipUntilEOFStatus()
+	if err != nil {
+		return result, err
+	}
+	if status&cursorStatusCursorExists == 0 {
+		_, _, err = c.readRows(&result.Signature)
+		return result, err
+	}
+	fetch := make([]byte, 8)
+	binary.LittleEndian.PutUint32(fetch[0:4], id)
+	binary.LittleEndian.PutUint32(fetch[4:8], uint32(fetchSize))
+	for {
+		if err := c.command(cursorComStmtFetch, fetch); err != nil {
+			return result, err
+		}
+		result.Fetches++
+		status, _, err := c.readRows(&result.Signature)
+		if err != nil {
+			return result, err
+		}
+		if status&cursorStatusLastRowSent != 0 || status&cursorStatusCursorExists == 0 {
+			return result, nil
+		}
+	}
+}
+
+// CloseStmt deallocates a prepared statement. The server sends no response.
+func (c *CursorConn) CloseStmt(id uint32) {
+	body := make([]byte, 4)
+	binary.LittleEndian.PutUint32(body, id)
+	_ = c.command(cursorComStmtClose, body)
+}
+
+// watch applies the deadline and cancellation of ctx to the socket until the
+// returned function is called.
+func (c *CursorConn) watch(ctx context.Context) func() {
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = c.conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() {
+		_ = c.conn.SetDeadline(time.Now())
+	})
+	return func() {
+		stop()
+		_ = c.conn.SetDeadline(time.Time{})
+	}
+}
+
+func (c *CursorConn) handshake(cfg *mysql.Config) error {
+	payload, err := c.readPacket()
+	if err != nil {
+		return err
+	}
+	if payload[0] == 0xff {
+		return parseCursorErr(payload)
+	}
+	salt, plugin, err := parseCursorHandshake(payload)
+	if err != nil {
+		return err
+	}
+	if plugin == "" {
+		plugin = cursorNativePassword
+	}
+	var auth []byte
+	if plugin == cursorNativePassword {
+		auth = scrambleNativePassword(salt, cfg.Passwd)
+	}
+	flags := uint32(cursorClientLongPassword | cursorClientProtocol41 | cursorClientTransactions | cursorClientSecureConnection | cursorClientPluginAuth)
+	if cfg.DBName != "" {
+		flags |= cursorClientConnectWithDB
+	}
+	resp := make([]byte, 32, 64+len(cfg.User)+len(cfg.DBName))
+	binary.LittleEndian.PutUint32(resp[0:4], flags)
+	resp[8] = cursorCollationUTF8
+	resp = append(resp, cfg.User...)
+	resp = append(resp, 0, byte(len(auth)))
+	resp = append(resp, auth...)
+	if cfg.DBName != "" {
+		resp = append(resp, cfg.DBName...)
+		resp = append(resp, 0)
+	}
+	resp = append(resp, plugin...)
+	resp = append(resp, 0)
+	if err := c.writePacket(resp); err != nil {
+		return err
+	}
+	for {
+		payload, err := c.readPacket()
+		if err != nil {
+			return err
+		}
+		switch payload[0] {
+		case 0x00:
+			return nil
+		case 0xff:
+			return parseCursorErr(payload)
+		case 0xfe:
+			name, data, _ := strings.Cut(string(payload[1:]), "\x00")
+			if name != cursorNativePassword {
+				return fmt.Errorf("cursor conn: unsupported auth plugin %s", name)
+			}
+			if err := c.writePacket(scrambleNativePassword([]byte(strings.TrimSuffix(data, "\x00")), cfg.Passwd)); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("cursor conn: unsupported auth plugin %s", plugin)
+		}
+	}
+}
+
+// parseCursorHandshake returns the 20-byte auth salt and the auth plugin of a
+// protocol 10 handshake.
+func parseCursorHandshake(payload []byte) ([]byte, string, error) {
+	malformed := errors.New("cursor conn: malformed handshake")
+	if len(payload) < 1 || payload[0] != 10 {
+		return nil, "", malformed
+	}
+	pos := 1
+	end := strings.IndexByte(string(payload[pos:]), 0)
+	if end < 0 {
+		return nil, "", malformed
+	}
+	pos += end + 1 + 4 // server version, connection id
+	if len(payload) < pos+8+1+2 {
+		return nil, "", malformed
+	}
+	salt := append([]byte{}, payload[pos:pos+8]...)
+	pos += 8 + 1 + 2 // salt part 1, filler, capability low
+	if len(payload) < pos+1+2+2+1+10 {
+		return salt, "", nil
+	}
+	pos += 1 + 2 + 2 // charset, status, capability high
+	saltLen := int(payload[pos])
+	pos += 1 + 10
+	part := max(13, saltLen-8)
+	if len(payload) < pos+part {
+		return nil, "", malformed
+	}
+	salt = append(salt, payload[pos:pos+part-1]...)
+	pos += part
+	plugin := ""
+	if pos < len(payload) {
+		plugin, _, _ = strings.Cut(string(payload[pos:]), "\x00")
+	}
+	return salt, plugin, nil
+}
+
+// scrambleNativePassword computes
+// SHA1(password) XOR SHA1(salt + SHA1(SHA1(password))).
+func scrambleNativePassword(salt []byte, password string) []byte {
+	if password == "" {
+		return nil
+	}
+	if len(salt) > 20 {
+		salt = salt[:20]
+	}
+	stage1 := sha1.Sum([]byte(password))
+	stage2 := sha1.Sum(stage1[:])
+	h := sha1.New()
+	h.Write(salt)
+	h.Write(stage2[:])
+	out := h.Sum(nil)
+	for i := range out {
+		out[i] ^= stage1[i]
+	}
+	return out
+}
+
+func (c *CursorConn) command(cmd byte, body []byte) error {
+	c.seq = 0
+	return c.writePacket(append([]byte{cmd}, body...))
+}
+
+func (c *CursorConn) writePacket(payload []byte) error {
+	if len(payload) >= cursorMaxPacket {
+		return errors.New("cursor conn: packet too large")
+	}
+	buf := make([]byte, 4+len(payload))
+	buf[0] = byte(len(payload))
+	buf[1] = byte(len(payload) >> 8)
+	buf[2] = byte(len(payload) >> 16)
+	buf[3] = c.seq
+	copy(buf[4:], payload)
+	c.seq++
+	_, err := c.conn.Write(buf)
+	return err
+}
+
+// readPacket reads one logical packet, joining the 16MB fragments of large
+// rows.
+func (c *CursorConn) readPacket() ([]byte, error) {
+	var out []byte
+	header := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(c.br, header); err != nil {
+			return nil, err
+		}
+		size := int(header[0]) | int(header[1])<<8 | int(header[2])<<16
+		c.seq = header[3] + 1
+		chunk := make([]byte, size)
+		if _, err := io.ReadFull(c.br, chunk); err != nil {
+			return nil, err
+		}
+		out = append(out, chunk...)
+		if size < cursorMaxPacket {
+			break
+		}
+	}
+	if len(out) == 0 {
+		return nil, errors.New("cursor conn: empty packet")
+	}
+	return out, nil
+}
+
+func isCursorEOF(payload []byte) bool {
+	return payload[0] == 0xfe && len(payload) < 9
+}
+
+func (c *CursorConn) skipUntilEOF() error {
+	_, err := c.skipUntilEOFStatus()
+	return err
+}
+
+// skipUntilEOFStatus skips column or parameter definitions and returns the
+// status flags of the closing EOF packet.
+func (c *CursorConn) skipUntilEOFStatus() (uint16, error) {
+	for {
+		payload, err := c.readPacket()
+		if err != nil {
+			return 0, err
+		}
+		if payload[0] == 0xff {
+			return 0, parseCursorErr(payload)
+		}
+		if isCursorEOF(payload) {
+			return eofStatus(payload), nil
+		}
+	}
+}
+
+// readRows reads row packets up to the EOF packet, adding each to sig when it
+// is not nil, and returns the EOF status flags and the row count.
+func (c *CursorConn) readRows(sig *Signature) (uint16, int, error) {
+	rows := 0
+	for {
+		payload, err := c.readPacket()
+		if err != nil {
+			return 0, rows, err
+		}
+		if payload[0] == 0xff {
+			return 0, rows, parseCursorErr(payload)
+		}
+		if isCursorEOF(payload) {
+			return eofStatus(payload), rows, nil
+		}
+		rows++
+		if sig != nil {
+			sig.Count++
+			sig.Checksum ^= int64(crc32.ChecksumIEEE(payload))
+		}
+	}
+}
+
+func eofStatus(payload []byte) uint16 {
+	if len(payload) < 5 {
+		return 0
+	}
+	return binary.LittleEndian.Uint16(payload[3:5])
+}
+
+func parseCursorErr(payload []byte) error {
+	if len(payload) < 3 {
+		return errors.New("cursor conn: malformed error packet")
+	}
+	out := &mysql.MySQLError{Number: binary.LittleEndian.Uint16(payload[1:3])}
+	msg := payload[3:]
+	if len(msg) >= 6 && msg[0] == '#' {
+		copy(out.SQLState[:], msg[1:6])
+		msg = msg[6:]
+	}
+	out.Message = string(msg)
+	return out
+}
@@ -0,0 +1,227 @@
+package db
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// fakeCursorServer speaks enough of the MySQL protocol to serve one prepared
+// statement that returns rows, with or without a cursor. dropCursorRow makes
+// cursor fetches skip the last row.
+type fakeCursorServer struct {
+	rows          int
+	dropCursorRow bool
+
+	mu         sync.Mutex
+	queries    []string
+	fetchSizes []uint32
+}
+
+func (s *fakeCursorServer) serve(t *testing.T, conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	br := bufio.NewReader(conn)
+	handshake := []byte{10}
+	handshake = append(handshake, "8.0.11-TiDB\x00"...)
+	handshake = append(handshake, 1, 0, 0, 0)
+	handshake = append(handshake, "abcdefgh"...)
+	handshake = append(handshake, 0, 0xff, 0xff, 45, 2, 0, 0xff, 0xff, 21)
+	handshake = append(handshake, make([]byte, 10)...)
+	handshake = append(handshake, "ijklmnopqrst\x00"...)
+	handshake = append(handshake, "mysql_native_password\x00"...)
+	writeFakePacket(conn, 0, handshake)
+	if _, _, err := readFakePacket(br); err != nil {
+		return
+	}
+	writeFakePacket(conn, 2, []byte{0, 0, 0, 2, 0, 0, 0})
+	cursorRows := s.rows
+	if s.dropCursorRow {
+		cursorRows--
+	}
+	sent := 0
+	for {
+		payload, _, err := readFakePacket(br)
+		if err != nil {
+			return
+		}
+		switch payload[0] {
+		case 0x01:
+			return
+		case 0x03:
+			s.mu.Lock()
+			s.queries = append(s.queries, string(payload[1:]))
+			s.mu.Unlock()
+			writeFakePacket(conn, 1, []byte{0, 0, 0, 2, 0, 0, 0})
+		case 0x16:
+			if strings.Contains(string(payload[1:]), "missing") {
+				writeFakePacket(conn, 1, append([]byte{0xff, 0x7a, 0x04, '#', '4', '2', 'S', '0', '2'}, "Table 'missing' doesn't exist"...))
+				continue
+			}
+			writeFakePacket(conn, 1, []byte{0, 7, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+			writeFakePacket(conn, 2, []byte("coldef"))
+			writeFakePacket(conn, 3, []byte{0xfe, 0, 0, 2, 0})
+		case 0x17:
+			writeFakePacket(conn, 1, []byte{1})
+			writeFakePacket(conn, 2, []byte("coldef"))
+			if payload[5] == 0 {
+				writeFakePacket(conn, 3, []byte{0xfe, 0, 0, 2, 0})
+				seq := byte(4)
+				for i := 0; i < s.rows; i++ {
+					writeFakePacket(conn, seq, []byte{0, 0, byte(i)})
+					seq++
+				}
+				writeFakePacket(conn, seq, []byte{0xfe, 0, 0, 2, 0})
+				continue
+			}
+			sent = 0
+			writeFakePacket(conn, 3, []byte{0xfe, 0, 0, 0x42, 0})
+		case 0x1c:
+			size := binary.LittleEndian.Uint32(payload[5:9])
+			s.mu.Lock()
+			s.fetchSizes = append(s.fetchSizes, size)
+			s.mu.Unlock()
+			seq := byte(1)
+			for n := uint32(0); n < size && sent < cursorRows; n++ {
+				writeFakePacket(conn, seq, []byte{0, 0, byte(sent)})
+				seq++
+				sent++
+			}
+			status := byte(0x42)
+			if sent == cursorRows {
+				status |= 0x80
+			}
+			writeFakePacket(conn, seq, []byte{0xfe, 0, 0, status, 0})
+		case 0x19:
+		default:
+			t.Errorf("unexpected command %#x", payload[0])
+			return
+		}
+	}
+}
+
+func writeFakePacket(w io.Writer, seq byte, payload []byte) {
+	header := []byte{byte(len(payload)), byte(len(payload) >> 8), byte(len(payload) >> 16), seq}
+	_, _ = w.Write(append(header, payload...))
+}
+
+func readFakePacket(r io.Reader) ([]byte, byte, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, 0, err
+	}
+	payload := make([]byte, int(header[0])|int(header[1])<<8|int(header[2])<<16)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, 0, err
+	}
+	return payload, header[3], nil
+}
+
+func startFakeCursorServer(t *testing.T, server *fakeCursorServer) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		server.serve(t, conn)
+	}()
+	return fmt.Sprintf("root:pw@tcp(%s)/shiro", ln.Addr().String())
+}
+
+func TestCursorConnExecuteMatchesFullFetch(t *testing.T) {
+	server := &fakeCursorServer{rows: 5}
+	ctx := context.Background()
+	conn, err := DialCursor(ctx, startFakeCursorServer(t, server), "SET SESSION sql_mode = ''")
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	id, err := conn.Prepare(ctx, "SELECT c0 FROM t0")
+	if err != nil {
+		t.Fatalf("prepare: %v", err)
+	}
+	if id != 7 {
+		t.Fatalf("unexpected statement id %d", id)
+	}
+	full, err := conn.Execute(ctx, id, 0)
+	if err != nil {
+		t.Fatalf("full execute: %v", err)
+	}
+	cursor, err := conn.Execute(ctx, id, 2)
+	if err != nil {
+		t.Fatalf("cursor execute: %v", err)
+	}
+	if full.Count != 5 || full.Fetches != 0 {
+		t.Fatalf("unexpected full result %+v", full)
+	}
+	if cursor.Signature != full.Signature || cursor.Fetches != 3 {
+		t.Fatalf("cursor result %+v does not match full result %+v", cursor, full)
+	}
+	conn.CloseStmt(id)
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if len(server.queries) != 1 || server.queries[0] != "SET SESSION sql_mode = ''" {
+		t.Fatalf("unexpected session init queries %v", server.queries)
+	}
+	if len(server.fetchSizes) != 3 || server.fetchSizes[0] != 2 {
+		t.Fatalf("unexpected fetch sizes %v", server.fetchSizes)
+	}
+}
+
+func TestCursorConnDetectsMissingRow(t *testing.T) {
+	server := &fakeCursorServer{rows: 4, dropCursorRow: true}
+	ctx := context.Background()
+	conn, err := DialCursor(ctx, startFakeCursorServer(t, server))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	if _, err := conn.Prepare(ctx, "SELECT c0 FROM missing"); err == nil {
+		t.Fatalf("expected prepare error")
+	} else {
+		var mysqlErr *mysql.MySQLError
+		if !errors.As(err, &mysqlErr) || mysqlErr.Number != 1146 || string(mysqlErr.SQLState[:]) != "42S02" {
+			t.Fatalf("unexpected prepare error %v", err)
+		}
+	}
+	id, err := conn.Prepare(ctx, "SELECT c0 FROM t0")
+	if err != nil {
+		t.Fatalf("prepare: %v", err)
+	}
+	full, err := conn.Execute(ctx, id, 0)
+	if err != nil {
+		t.Fatalf("full execute: %v", err)
+	}
+	cursor, err := conn.Execute(ctx, id, 1)
+	if err != nil {
+		t.Fatalf("cursor execute: %v", err)
+	}
+	if cursor.Count != 3 || full.Count != 4 || cursor.Checksum == full.Checksum {
+		t.Fatalf("expected cursor fetch to miss a row: full=%+v cursor=%+v", full, cursor)
+	}
+}
+
+func TestScrambleNativePassword(t *testing.T) {
+	if got := scrambleNativePassword([]byte("abcdefghijklmnopqrst"), ""); got != nil {
+		t.Fatalf("expected empty scramble for empty password, got %x", got)
+	}
+	got := hex.EncodeToString(scrambleNativePassword([]byte("abcdefghijklmnopqrst"), "pw"))
+	if got != "12909d72d875523f8c9d6da44cbd3a6c1ce794db" {
+		t.Fatalf("unexpected scramble %s", got)
+	}
+}
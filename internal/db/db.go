@@ -7,6 +7,7 @@ import (
 	"strings"
 	"sync"
 
+	"shiro/internal/config"
 	"shiro/internal/util"
 
 	_ "github.com/go-sql-driver/mysql"
@@ -17,6 +18,16 @@ type DB struct {
 	*sql.DB
 	Validate func(string) error
 	Observe  func(string, error, *SQLSubqueryFeatures)
+	Guard    ResultGuard
+	// SnapshotTSO, when non-zero, runs QuerySignature,
+	// QuerySignatureWithWarnings, and QueryCount at this TSO, so both sides of
+	// a comparison read the same data even under concurrent writes.
+	SnapshotTSO uint64
+	// AfterFirstRead, when set, runs once after the next successful
+	// QuerySignature, QuerySignatureWithWarnings, or QueryCount with that
+	// query, and is then cleared. Pair oracles read their first side first,
+	// so the hook runs between the two sides of a comparison.
+	AfterFirstRead func(ctx context.Context, query string)
 
 	observeMu       sync.Mutex
 	observeFeatures map[string][]SQLSubqueryFeatures
@@ -38,8 +49,17 @@ type SQLSubqueryFeatures struct {
 	HasNotExists      bool
 }
 
-// Open creates a DB connection from a DSN.
-func Open(dsn string) (*DB, error) {
+// Open creates a DB connection from a DSN. A DSN that lists several endpoints,
+// such as root@tcp(h1:4000,h2:4000)/db, opens a pool that fails over between them.
+// The sessionInit statements run on every new connection of the pool.
+func Open(dsn string, sessionInit ...string) (*DB, error) {
+	if len(config.SplitDSNHosts(dsn)) > 1 || len(sessionInit) > 0 {
+		connector, err := newConnector(dsn)
+		if err != nil {
+			return nil, err
+		}
+		return &DB{DB: sql.OpenDB(withSessionInit(connector, sessionInit))}, nil
+	}
 	db, err := sql.Open("mysql", dsn)
 	if err != nil {
 		return nil, err
@@ -47,6 +67,12 @@ func Open(dsn string) (*DB, error) {
 	return &DB{DB: db}, nil
 }
 
+// Fork returns a handle on the same connection pool with its own SnapshotTSO,
+// AfterFirstRead hook, and observed-feature queue, so concurrent oracle runs do not share them.
+func (d *DB) Fork() *DB {
+	return &DB{DB: d.DB, Validate: d.Validate, Observe: d.Observe, Guard: d.Guard}
+}
+
 // ExecContext runs a statement after validation.
 func (d *DB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
 	if err := d.validate(query); err != nil {
@@ -80,16 +106,43 @@ func (d *DB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error
 }
 
 // QuerySignature executes a signature query and returns count/checksum.
+// With Guard.MaxRows set, it returns ErrResultTruncated when the query
+// aggregates more rows than the guard allows.
 func (d *DB) QuerySignature(ctx context.Context, query string) (Signature, error) {
 	if err := d.validate(query); err != nil {
 		return Signature{}, err
 	}
-	row := d.DB.QueryRowContext(ctx, query)
+	guarded, limited := d.guardSignature(query)
+	if d.SnapshotTSO != 0 {
+		conn, release, err := d.snapshotConn(ctx)
+		if err != nil {
+			return Signature{}, err
+		}
+		defer release()
+		sig, err := querySignatureOnConn(ctx, conn, guarded)
+		if err != nil {
+			return Signature{}, err
+		}
+		d.afterRead(ctx, query)
+		return d.checkSignatureGuard(sig, limited)
+	}
+	row := d.DB.QueryRowContext(ctx, guarded)
 	var sig Signature
 	if err := row.Scan(&sig.Count, &sig.Checksum); err != nil {
 		return Signature{}, err
 	}
-	return sig, nil
+	d.afterRead(ctx, query)
+	return d.checkSignatureGuard(sig, limited)
+}
+
+// afterRead runs and clears the AfterFirstRead hook.
+func (d *DB) afterRead(ctx context.Context, query string) {
+	hook := d.AfterFirstRead
+	if hook == nil {
+		return
+	}
+	d.AfterFirstRead = nil
+	hook(ctx, query)
 }
 
 // QuerySignatureWithWarnings executes a signature query and returns count/checksum
@@ -98,24 +151,60 @@ func (d *DB) QuerySignatureWithWarnings(ctx context.Context, query string) (Sign
 	if err := d.validate(query); err != nil {
 		return Signature{}, nil, err
 	}
-	conn, err := d.Conn(ctx)
-	if err != nil {
-		return Signature{}, nil, err
+	var conn *sql.Conn
+	if d.SnapshotTSO != 0 {
+		snapConn, release, err := d.snapshotConn(ctx)
+		if err != nil {
+			return Signature{}, nil, err
+		}
+		defer release()
+		conn = snapConn
+	} else {
+		plainConn, err := d.Conn(ctx)
+		if err != nil {
+			return Signature{}, nil, err
+		}
+		defer util.CloseWithErr(plainConn, "query signature conn")
+		conn = plainConn
 	}
-	defer util.CloseWithErr(conn, "query signature conn")
 
-	sig, err := querySignatureOnConn(ctx, conn, query)
+	guarded, limited := d.guardSignature(query)
+	sig, err := querySignatureOnConn(ctx, conn, guarded)
 	if err != nil {
 		return Signature{}, nil, err
 	}
-	warnings, warnErr := queryWarningsOnConn(ctx, conn)
+	if sig, err = d.checkSignatureGuard(sig, limited); err != nil {
+		return sig, nil, err
+	}
+	warnings, warnErr := WarningsOnConn(ctx, conn)
 	if warnErr != nil {
 		util.Detailf("show warnings failed after signature query: %v", warnErr)
 		return sig, nil, warnErr
 	}
+	d.afterRead(ctx, query)
 	return sig, warnings, nil
 }
 
+// guardSignature applies Guard.MaxRows to a signature query. Validation and
+// observation see the original query; only the executed text carries the LIMIT.
+func (d *DB) guardSignature(query string) (string, bool) {
+	if d.Guard.MaxRows <= 0 {
+		return query, false
+	}
+	guarded, ok := guardSignatureSQL(query, d.Guard.MaxRows)
+	if !ok {
+		return query, false
+	}
+	return guarded, true
+}
+
+func (d *DB) checkSignatureGuard(sig Signature, limited bool) (Signature, error) {
+	if limited && sig.Count > d.Guard.MaxRows {
+		return sig, fmt.Errorf("%w: more than %d rows", ErrResultTruncated, d.Guard.MaxRows)
+	}
+	return sig, nil
+}
+
 func querySignatureOnConn(ctx context.Context, conn *sql.Conn, query string) (Signature, error) {
 	row := conn.QueryRowContext(ctx, query)
 	var sig Signature
@@ -125,7 +214,8 @@ func querySignatureOnConn(ctx context.Context, conn *sql.Conn, query string) (Si
 	return sig, nil
 }
 
-func queryWarningsOnConn(ctx context.Context, conn *sql.Conn) ([]string, error) {
+// WarningsOnConn returns SHOW WARNINGS on conn as "level:code:message" strings.
+func WarningsOnConn(ctx context.Context, conn *sql.Conn) ([]string, error) {
 	rows, err := conn.QueryContext(ctx, "SHOW WARNINGS")
 	if err != nil {
 		return nil, err
@@ -149,11 +239,22 @@ func (d *DB) QueryCount(ctx context.Context, query string) (int64, error) {
 	if err := d.validate(query); err != nil {
 		return 0, err
 	}
-	row := d.DB.QueryRowContext(ctx, query)
+	var row *sql.Row
+	if d.SnapshotTSO != 0 {
+		conn, release, err := d.snapshotConn(ctx)
+		if err != nil {
+			return 0, err
+		}
+		defer release()
+		row = conn.QueryRowContext(ctx, query)
+	} else {
+		row = d.DB.QueryRowContext(ctx, query)
+	}
 	var count int64
 	if err := row.Scan(&count); err != nil {
 		return 0, err
 	}
+	d.afterRead(ctx, query)
 	return count, nil
 }
 
@@ -201,6 +302,12 @@ func (d *DB) QueryPlanRows(ctx context.Context, query string) (float64, error) {
 	return 0, fmt.Errorf("estRows not found in explain output")
 }
 
+// ValidateSQL runs the Validate and Observe hooks for a statement that does
+// not go through DB, such as one prepared on a pinned connection.
+func (d *DB) ValidateSQL(query string) error {
+	return d.validate(query)
+}
+
 func (d *DB) validate(query string) error {
 	if d.Validate == nil {
 		if d.Observe != nil {
@@ -0,0 +1,186 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"shiro/internal/config"
+	"shiro/internal/util"
+)
+
+const (
+	failoverBackoffMin = time.Second
+	failoverBackoffMax = time.Minute
+)
+
+// endpointSets holds one *endpointSet per address list, so every pool and
+// dedicated connection to the same servers sticks to the same endpoint.
+var endpointSets sync.Map
+
+// newConnector builds the driver connector for a DSN. DSNs that list several
+// endpoints get a failover connector over one MySQL connector per endpoint.
+func newConnector(dsn string) (driver.Connector, error) {
+	hosts := config.SplitDSNHosts(dsn)
+	if len(hosts) == 1 {
+		cfg, err := mysql.ParseDSN(dsn)
+		if err != nil {
+			return nil, err
+		}
+		return mysql.NewConnector(cfg)
+	}
+	addrs := make([]string, 0, len(hosts))
+	connectors := make([]driver.Connector, 0, len(hosts))
+	for _, host := range hosts {
+		cfg, err := mysql.ParseDSN(host)
+		if err != nil {
+			return nil, err
+		}
+		connector, err := mysql.NewConnector(cfg)
+		if err != nil {
+			return nil, err
+		}
+		addrs = append(addrs, cfg.Addr)
+		connectors = append(connectors, connector)
+	}
+	return &failoverConnector{endpoints: sharedEndpointSet(addrs), connectors: connectors}, nil
+}
+
+// sharedEndpointSet returns the endpoint set of addrs, creating it on first use.
+func sharedEndpointSet(addrs []string) *endpointSet {
+	set, _ := endpointSets.LoadOrStore(strings.Join(addrs, ","), newEndpointSet(addrs))
+	return set.(*endpointSet)
+}
+
+// endpointSet tracks the health of several servers and the one in use. New
+// connections stick to the current endpoint, so one pool and its session
+// state stay on one server; only a failed connect moves them to the next
+// healthy endpoint. An endpoint that fails to connect is skipped for an
+// exponential backoff; when every endpoint is backing off, all of them are
+// still tried, soonest recovery first, so a cluster-wide restart does not
+// need a rerun.
+type endpointSet struct {
+	mu        sync.Mutex
+	addrs     []string
+	failures  []int
+	downUntil []time.Time
+	current   int
+	now       func() time.Time
+}
+
+func newEndpointSet(addrs []string) *endpointSet {
+	return &endpointSet{
+		addrs:     addrs,
+		failures:  make([]int, len(addrs)),
+		downUntil: make([]time.Time, len(addrs)),
+		now:       time.Now,
+	}
+}
+
+// connect calls try with endpoint indexes in order until one succeeds and
+// makes that endpoint current.
+func (s *endpointSet) connect(ctx context.Context, try func(int) error) error {
+	var lastErr error
+	for _, idx := range s.order() {
+		err := try(idx)
+		if err == nil {
+			s.markUp(idx)
+			return nil
+		}
+		lastErr = err
+		s.markDown(idx, err)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if lastErr == nil {
+		lastErr = errors.New("no database endpoints")
+	}
+	return lastErr
+}
+
+// order returns the healthy endpoints starting at the current one, followed
+// by the endpoints in backoff ordered by recovery time.
+func (s *endpointSet) order() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	n := len(s.addrs)
+	healthy := make([]int, 0, n)
+	var down []int
+	for i := 0; i < n; i++ {
+		idx := (s.current + i) % n
+		if now.Before(s.downUntil[idx]) {
+			down = append(down, idx)
+			continue
+		}
+		healthy = append(healthy, idx)
+	}
+	for i := 1; i < len(down); i++ {
+		for j := i; j > 0 && s.downUntil[down[j]].Before(s.downUntil[down[j-1]]); j-- {
+			down[j], down[j-1] = down[j-1], down[j]
+		}
+	}
+	return append(healthy, down...)
+}
+
+func (s *endpointSet) markUp(idx int) {
+	s.mu.Lock()
+	recovered := s.failures[idx] > 0
+	switched := s.current != idx
+	s.failures[idx] = 0
+	s.downUntil[idx] = time.Time{}
+	s.current = idx
+	s.mu.Unlock()
+	if recovered {
+		util.Infof("db endpoint recovered addr=%s", s.addrs[idx])
+	}
+	if switched {
+		util.Infof("db endpoint switched addr=%s", s.addrs[idx])
+	}
+}
+
+func (s *endpointSet) markDown(idx int, err error) {
+	s.mu.Lock()
+	s.failures[idx]++
+	failures := s.failures[idx]
+	backoff := failoverBackoffMin << min(failures-1, 6)
+	if backoff > failoverBackoffMax {
+		backoff = failoverBackoffMax
+	}
+	s.downUntil[idx] = s.now().Add(backoff)
+	s.mu.Unlock()
+	util.Warnf("db endpoint down addr=%s failures=%d backoff=%s err=%v", s.addrs[idx], failures, backoff, err)
+}
+
+// failoverConnector opens connections to the current endpoint of its set.
+// Pooled connections to a restarted server fail with driver.ErrBadConn and
+// database/sql reconnects through this connector.
+type failoverConnector struct {
+	endpoints  *endpointSet
+	connectors []driver.Connector
+}
+
+func (c *failoverConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	var conn driver.Conn
+	err := c.endpoints.connect(ctx, func(idx int) error {
+		var err error
+		conn, err = c.connectors[idx].Connect(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (c *failoverConnector) Driver() driver.Driver {
+	return &mysql.MySQLDriver{}
+}
+
+var _ driver.Connector = (*failoverConnector)(nil)
@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeConnector struct {
+	name  string
+	err   error
+	calls int
+}
+
+func (c *fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	return fakeConn{name: c.name}, nil
+}
+
+func (c *fakeConnector) Driver() driver.Driver { return nil }
+
+type fakeConn struct {
+	driver.Conn
+	name string
+}
+
+func TestFailoverConnectorSticksAndBacksOff(t *testing.T) {
+	a := &fakeConnector{name: "a"}
+	b := &fakeConnector{name: "b"}
+	now := time.Unix(1000, 0)
+	set := newEndpointSet([]string{"a", "b"})
+	set.now = func() time.Time { return now }
+	c := &failoverConnector{endpoints: set, connectors: []driver.Connector{a, b}}
+	ctx := context.Background()
+
+	connectName := func() string {
+		t.Helper()
+		conn, err := c.Connect(ctx)
+		if err != nil {
+			t.Fatalf("connect: %v", err)
+		}
+		return conn.(fakeConn).name
+	}
+	for i := 0; i < 3; i++ {
+		if name := connectName(); name != "a" {
+			t.Fatalf("connection %d left the current endpoint: %s", i, name)
+		}
+	}
+
+	a.err = errors.New("connection refused")
+	for i := 0; i < 3; i++ {
+		if name := connectName(); name != "b" {
+			t.Fatalf("failover went to %s", name)
+		}
+	}
+	if a.calls != 4 {
+		t.Fatalf("failed endpoint retried while b is current: calls=%d", a.calls)
+	}
+
+	// a recovers but b stays current until it fails.
+	a.err = nil
+	now = now.Add(failoverBackoffMax)
+	if name := connectName(); name != "b" {
+		t.Fatalf("recovered endpoint stole the pool: %s", name)
+	}
+
+	b.err = errors.New("connection refused")
+	a.err = errors.New("connection refused")
+	if _, err := c.Connect(ctx); err == nil {
+		t.Fatalf("expected error when every endpoint is down")
+	}
+	a.err = nil
+	if name := connectName(); name != "a" {
+		t.Fatalf("endpoints in backoff not retried: %s", name)
+	}
+}
+
+func TestSharedEndpointSet(t *testing.T) {
+	first := sharedEndpointSet([]string{"shared-a:4000", "shared-b:4000"})
+	if sharedEndpointSet([]string{"shared-a:4000", "shared-b:4000"}) != first {
+		t.Fatalf("pools to the same servers must share one endpoint set")
+	}
+	if sharedEndpointSet([]string{"shared-b:4000", "shared-a:4000"}) == first {
+		t.Fatalf("a different address list must get its own set")
+	}
+	first.markUp(1)
+	if order := first.order(); order[0] != 1 {
+		t.Fatalf("current endpoint not tried first: %v", order)
+	}
+}
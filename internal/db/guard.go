@@ -0,0 +1,157 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrResultTruncated reports that a result exceeded the configured size guard,
+// so the verification that needed it is inconclusive.
+var ErrResultTruncated = errors.New("result exceeds size guard")
+
+// ErrStopScan is returned by a ScanRows callback to stop the scan early
+// without an error.
+var ErrStopScan = errors.New("stop scan")
+
+// ResultGuard bounds the results the signature and row-scan helpers read.
+// Zero values disable the corresponding limit.
+type ResultGuard struct {
+	// MaxRows caps the rows a signature query aggregates and a row scan
+	// reads.
+	MaxRows int64
+	// MaxBytes caps the bytes a streaming row scan reads before it aborts.
+	MaxBytes int64
+}
+
+// ScanRows reads rows and calls fn with the raw values of each row; the
+// values are only valid during the call. It returns ErrResultTruncated as
+// soon as the scan reads more than MaxRows rows or MaxBytes bytes, so no
+// caller buffers an oversized result.
+func (g ResultGuard) ScanRows(rows *sql.Rows, fn func(values []sql.RawBytes) error) error {
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	values := make([]sql.RawBytes, len(cols))
+	scanArgs := make([]any, len(values))
+	for i := range values {
+		scanArgs[i] = &values[i]
+	}
+	var count, bytesRead int64
+	for rows.Next() {
+		if err := rows.Scan(scanArgs...); err != nil {
+			return err
+		}
+		count++
+		if g.MaxRows > 0 && count > g.MaxRows {
+			return fmt.Errorf("%w: more than %d rows", ErrResultTruncated, g.MaxRows)
+		}
+		for _, v := range values {
+			bytesRead += int64(len(v))
+		}
+		if g.BytesExceeded(bytesRead) {
+			return fmt.Errorf("%w: more than %d bytes", ErrResultTruncated, g.MaxBytes)
+		}
+		if err := fn(values); err != nil {
+			if errors.Is(err, ErrStopScan) {
+				return nil
+			}
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// BytesExceeded reports whether a row scan that has read n bytes should abort.
+func (g ResultGuard) BytesExceeded(n int64) bool {
+	return g.MaxBytes > 0 && n > g.MaxBytes
+}
+
+var signatureAliasPattern = regexp.MustCompile(`^\s+(?i:AS\s+)?([A-Za-z_][A-Za-z0-9_]*)\s*$`)
+
+// guardSignatureSQL rewrites a signature query of the form
+// SELECT <aggregates> FROM (<inner>) <alias> so the derived table reads at most
+// maxRows+1 rows:
+//
+//	SELECT <aggregates> FROM (SELECT * FROM (<inner>) <alias> LIMIT maxRows+1) <alias>
+//
+// The extra row lets the caller tell an exact result from a truncated one. It
+// returns false when the query does not end with a derived table.
+func guardSignatureSQL(query string, maxRows int64) (string, bool) {
+	open, closeIdx := lastTopLevelGroup(query)
+	if open < 0 {
+		return "", false
+	}
+	match := signatureAliasPattern.FindStringSubmatch(query[closeIdx+1:])
+	if match == nil {
+		return "", false
+	}
+	prefix := strings.TrimRight(query[:open], " \t\n")
+	if !strings.HasSuffix(strings.ToUpper(prefix), " FROM") {
+		return "", false
+	}
+	alias := match[1]
+	inner := query[open+1 : closeIdx]
+	return fmt.Sprintf("%s (SELECT * FROM (%s) %s LIMIT %d) %s", prefix, inner, alias, maxRows+1, alias), true
+}
+
+// lastTopLevelGroup returns the offsets of the last parenthesized group at
+// nesting depth zero, skipping quoted strings, identifiers, and comments.
+func lastTopLevelGroup(query string) (open int, closeIdx int) {
+	open, closeIdx = -1, -1
+	start := -1
+	depth := 0
+	for i := 0; i < len(query); i++ {
+		switch ch := query[i]; ch {
+		case '\'', '"', '`':
+			i = skipQuoted(query, i, ch)
+		case '/':
+			if i+1 < len(query) && query[i+1] == '*' {
+				end := strings.Index(query[i+2:], "*/")
+				if end < 0 {
+					return -1, -1
+				}
+				i += end + 3
+			}
+		case '(':
+			if depth == 0 {
+				start = i
+			}
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return -1, -1
+			}
+			if depth == 0 {
+				open, closeIdx = start, i
+			}
+		}
+	}
+	if depth != 0 {
+		return -1, -1
+	}
+	return open, closeIdx
+}
+
+// skipQuoted returns the offset of the quote that closes the one at start.
+func skipQuoted(query string, start int, quote byte) int {
+	for i := start + 1; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case quote:
+			if i+1 < len(query) && query[i+1] == quote {
+				i++
+				continue
+			}
+			return i
+		}
+	}
+	return len(query)
+}
@@ -0,0 +1,153 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestGuardSignatureSQL(t *testing.T) {
+	cases := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			name:  "derived",
+			query: "SELECT COUNT(*) AS cnt, IFNULL(BIT_XOR(CRC32(CONCAT_WS('#', q.c0))),0) AS checksum FROM (SELECT t0.c0 AS c0 FROM t0 WHERE (t0.c1 = ')')) q",
+			want:  "SELECT COUNT(*) AS cnt, IFNULL(BIT_XOR(CRC32(CONCAT_WS('#', q.c0))),0) AS checksum FROM (SELECT * FROM (SELECT t0.c0 AS c0 FROM t0 WHERE (t0.c1 = ')')) q LIMIT 11) q",
+		},
+		{
+			name:  "with_union",
+			query: "WITH cte AS (SELECT 1 AS a) SELECT COUNT(*) AS cnt, 0 AS checksum FROM (SELECT a FROM cte UNION ALL SELECT a FROM cte) u",
+			want:  "WITH cte AS (SELECT 1 AS a) SELECT COUNT(*) AS cnt, 0 AS checksum FROM (SELECT * FROM (SELECT a FROM cte UNION ALL SELECT a FROM cte) u LIMIT 11) u",
+		},
+		{
+			name:  "hint",
+			query: "SELECT /*+ SET_VAR(tidb_opt_force_inline_cte=ON) */ COUNT(*) AS cnt, 0 AS checksum FROM (SELECT 1) AS q",
+			want:  "SELECT /*+ SET_VAR(tidb_opt_force_inline_cte=ON) */ COUNT(*) AS cnt, 0 AS checksum FROM (SELECT * FROM (SELECT 1) q LIMIT 11) q",
+		},
+	}
+	for _, tc := range cases {
+		got, ok := guardSignatureSQL(tc.query, 10)
+		if !ok || got != tc.want {
+			t.Fatalf("%s: guardSignatureSQL()=%q,%v want=%q", tc.name, got, ok, tc.want)
+		}
+	}
+	for _, query := range []string{
+		"SELECT COUNT(*) AS cnt, 0 AS checksum FROM t0 WHERE (t0.c0 > 1)",
+		"SELECT COUNT(*) FROM t0 WHERE t0.c0 IN (1, 2)",
+		"SELECT COUNT(*) FROM (SELECT 1) q WHERE 1",
+		"SELECT COUNT(*) FROM (SELECT 1 q",
+	} {
+		if got, ok := guardSignatureSQL(query, 10); ok {
+			t.Fatalf("guardSignatureSQL(%q) should not rewrite, got %q", query, got)
+		}
+	}
+}
+
+func TestCheckSignatureGuard(t *testing.T) {
+	d := &DB{Guard: ResultGuard{MaxRows: 10}}
+	if _, err := d.checkSignatureGuard(Signature{Count: 10}, true); err != nil {
+		t.Fatalf("exact limit should pass: %v", err)
+	}
+	if _, err := d.checkSignatureGuard(Signature{Count: 11}, true); err == nil {
+		t.Fatalf("expected truncation error")
+	}
+	if _, err := d.checkSignatureGuard(Signature{Count: 11}, false); err != nil {
+		t.Fatalf("unguarded query should pass: %v", err)
+	}
+	if !(ResultGuard{MaxBytes: 5}).BytesExceeded(6) || (ResultGuard{}).BytesExceeded(1<<40) {
+		t.Fatalf("unexpected BytesExceeded result")
+	}
+}
+
+// guardRowsConnector serves every query with the same rows.
+type guardRowsConnector struct {
+	rows [][]string
+}
+
+func (c guardRowsConnector) Connect(context.Context) (driver.Conn, error) {
+	return guardRowsConn{rows: c.rows}, nil
+}
+
+func (c guardRowsConnector) Driver() driver.Driver { return nil }
+
+type guardRowsConn struct {
+	driver.Conn
+	rows [][]string
+}
+
+func (c guardRowsConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return &guardRows{rows: c.rows}, nil
+}
+
+func (guardRowsConn) Close() error { return nil }
+
+type guardRows struct {
+	rows [][]string
+	next int
+}
+
+func (*guardRows) Columns() []string { return []string{"c0"} }
+
+func (*guardRows) Close() error { return nil }
+
+func (r *guardRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows) {
+		return io.EOF
+	}
+	for i, v := range r.rows[r.next] {
+		dest[i] = []byte(v)
+	}
+	r.next++
+	return nil
+}
+
+func TestResultGuardScanRows(t *testing.T) {
+	conn := sql.OpenDB(guardRowsConnector{rows: [][]string{{"aaaa"}, {"bbbb"}, {"cccc"}}})
+	defer func() { _ = conn.Close() }()
+	scan := func(guard ResultGuard, fn func([]sql.RawBytes) error) (int, error) {
+		rows, err := conn.QueryContext(context.Background(), "SELECT c0 FROM t")
+		if err != nil {
+			t.Fatalf("query: %v", err)
+		}
+		defer func() { _ = rows.Close() }()
+		seen := 0
+		err = guard.ScanRows(rows, func(values []sql.RawBytes) error {
+			seen++
+			return fn(values)
+		})
+		return seen, err
+	}
+	next := func([]sql.RawBytes) error { return nil }
+
+	if seen, err := scan(ResultGuard{MaxRows: 3, MaxBytes: 12}, next); err != nil || seen != 3 {
+		t.Fatalf("scan at the limits: seen=%d err=%v", seen, err)
+	}
+	if seen, err := scan(ResultGuard{MaxBytes: 10}, next); !errors.Is(err, ErrResultTruncated) || seen != 2 {
+		t.Fatalf("scan over the byte limit: seen=%d err=%v", seen, err)
+	}
+	if seen, err := scan(ResultGuard{MaxRows: 1}, next); !errors.Is(err, ErrResultTruncated) || seen != 1 {
+		t.Fatalf("scan over the row limit: seen=%d err=%v", seen, err)
+	}
+	stop := func([]sql.RawBytes) error { return ErrStopScan }
+	if seen, err := scan(ResultGuard{MaxBytes: 1}, stop); !errors.Is(err, ErrResultTruncated) || seen != 0 {
+		t.Fatalf("first row over the byte limit: seen=%d err=%v", seen, err)
+	}
+	if seen, err := scan(ResultGuard{}, stop); err != nil || seen != 1 {
+		t.Fatalf("stopped scan: seen=%d err=%v", seen, err)
+	}
+
+	rows, err := conn.QueryContext(context.Background(), "SELECT c0 FROM t")
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	defer func() { _ = rows.Close() }()
+	if _, err := (ResultGuard{MaxBytes: 10}).SignatureFromRows(rows, 0); !errors.Is(err, ErrResultTruncated) {
+		t.Fatalf("SignatureFromRows over the byte limit: %v", err)
+	}
+}
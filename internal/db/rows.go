@@ -0,0 +1,118 @@
+package db
+
+import (
+	"database/sql"
+	"hash/crc32"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DrainRows reads and discards the remaining rows within the guard.
+func (g ResultGuard) DrainRows(rows *sql.Rows) error {
+	return g.ScanRows(rows, func([]sql.RawBytes) error { return nil })
+}
+
+// SignatureFromRows hashes result rows client-side into a count and an XOR of
+// per-row CRC32 checksums, the same shape QuerySignature computes in SQL. With
+// roundScale > 0, numeric values are rounded to that many decimals first.
+func (g ResultGuard) SignatureFromRows(rows *sql.Rows, roundScale int) (Signature, error) {
+	sig := Signature{}
+	err := g.ScanRows(rows, func(values []sql.RawBytes) error {
+		sig.Count++
+		sig.Checksum ^= rowChecksum(values, roundScale)
+		return nil
+	})
+	if err != nil {
+		return Signature{}, err
+	}
+	return sig, nil
+}
+
+// SignatureAndSampleFromRows is SignatureFromRows that also returns the column
+// names and up to limit normalized rows for reports.
+func (g ResultGuard) SignatureAndSampleFromRows(rows *sql.Rows, limit int, roundScale int) (Signature, []string, [][]string, error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		return Signature{}, nil, nil, err
+	}
+	sig := Signature{}
+	samples := make([][]string, 0, limit)
+	err = g.ScanRows(rows, func(values []sql.RawBytes) error {
+		sig.Count++
+		sig.Checksum ^= rowChecksum(values, roundScale)
+		if len(samples) < limit {
+			row := make([]string, len(values))
+			for i, v := range values {
+				row[i] = normalizeSignatureValue(v, roundScale)
+			}
+			samples = append(samples, row)
+		}
+		return nil
+	})
+	if err != nil {
+		return Signature{}, nil, nil, err
+	}
+	return sig, cols, samples, nil
+}
+
+// rowChecksum is the CRC32 of the '#'-joined normalized values of one row.
+func rowChecksum(values []sql.RawBytes, roundScale int) int64 {
+	var b strings.Builder
+	for i, v := range values {
+		if i > 0 {
+			b.WriteByte('#')
+		}
+		b.WriteString(normalizeSignatureValue(v, roundScale))
+	}
+	return int64(crc32.ChecksumIEEE([]byte(b.String())))
+}
+
+func normalizeSignatureValue(raw []byte, roundScale int) string {
+	if raw == nil {
+		return "NULL"
+	}
+	text := string(raw)
+	if roundScale <= 0 {
+		return text
+	}
+	if !looksNumeric(text) {
+		return text
+	}
+	val, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return text
+	}
+	scale := math.Pow10(roundScale)
+	val = math.Round(val*scale) / scale
+	return strconv.FormatFloat(val, 'f', roundScale, 64)
+}
+
+func looksNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	hasDigit := false
+	for i, r := range s {
+		if r >= '0' && r <= '9' {
+			hasDigit = true
+			continue
+		}
+		switch r {
+		case '+', '-', '.', 'e', 'E':
+			if (r == 'e' || r == 'E') && !hasDigit {
+				return false
+			}
+			if (r == '+' || r == '-') && i > 0 && s[i-1] != 'e' && s[i-1] != 'E' {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	last := s[len(s)-1]
+	if last == 'e' || last == 'E' || last == '+' || last == '-' {
+		return false
+	}
+	return hasDigit
+}
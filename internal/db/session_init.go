@@ -0,0 +1,49 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+)
+
+// sessionInitConnector runs a fixed list of statements on every new
+// connection before database/sql hands it out, so session defaults such as
+// sql_mode survive pool churn and reconnects.
+type sessionInitConnector struct {
+	base       driver.Connector
+	statements []string
+}
+
+// withSessionInit wraps connector so statements run on each new connection.
+// It returns connector unchanged when there is nothing to run.
+func withSessionInit(connector driver.Connector, statements []string) driver.Connector {
+	if len(statements) == 0 {
+		return connector
+	}
+	return &sessionInitConnector{base: connector, statements: statements}
+}
+
+func (c *sessionInitConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.base.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	execer, ok := conn.(driver.ExecerContext)
+	if !ok {
+		_ = conn.Close()
+		return nil, fmt.Errorf("session init: driver connection cannot execute statements")
+	}
+	for _, stmt := range c.statements {
+		if _, err := execer.ExecContext(ctx, stmt, nil); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("session init %q: %w", stmt, err)
+		}
+	}
+	return conn, nil
+}
+
+func (c *sessionInitConnector) Driver() driver.Driver {
+	return c.base.Driver()
+}
+
+var _ driver.Connector = (*sessionInitConnector)(nil)
@@ -0,0 +1,62 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"slices"
+	"testing"
+)
+
+type execConn struct {
+	driver.Conn
+	executed *[]string
+	failOn   string
+	closed   *bool
+}
+
+func (c execConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	*c.executed = append(*c.executed, query)
+	if query == c.failOn {
+		return nil, errors.New("unknown system variable")
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (c execConn) Close() error {
+	*c.closed = true
+	return nil
+}
+
+type execConnector struct {
+	conn execConn
+}
+
+func (c execConnector) Connect(context.Context) (driver.Conn, error) { return c.conn, nil }
+
+func (c execConnector) Driver() driver.Driver { return nil }
+
+func TestSessionInitConnector(t *testing.T) {
+	var executed []string
+	var closed bool
+	base := execConnector{conn: execConn{executed: &executed, closed: &closed}}
+	if withSessionInit(base, nil) != driver.Connector(base) {
+		t.Fatalf("expected the connector to stay unwrapped without statements")
+	}
+	stmts := []string{"SET SESSION sql_mode=''", "SET NAMES utf8mb4"}
+	if _, err := withSessionInit(base, stmts).Connect(context.Background()); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if !slices.Equal(executed, stmts) || closed {
+		t.Fatalf("executed=%v closed=%v", executed, closed)
+	}
+
+	executed = nil
+	base.conn.failOn = stmts[0]
+	if _, err := withSessionInit(base, stmts).Connect(context.Background()); err == nil {
+		t.Fatalf("expected session init failure")
+	}
+	if len(executed) != 1 || !closed {
+		t.Fatalf("failed init should stop and close the connection: executed=%v closed=%v", executed, closed)
+	}
+}
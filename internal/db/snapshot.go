@@ -0,0 +1,41 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+
+	"shiro/internal/util"
+)
+
+// snapshotConn returns a connection that reads at d.SnapshotTSO through
+// tidb_snapshot. The release function clears the variable before the
+// connection goes back to the pool; if that fails, the connection is discarded
+// so no later statement inherits the stale snapshot.
+func (d *DB) snapshotConn(ctx context.Context) (*sql.Conn, func(), error) {
+	conn, err := d.Conn(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf("SET @@tidb_snapshot = '%d'", d.SnapshotTSO)); err != nil {
+		discardConn(conn)
+		return nil, nil, err
+	}
+	release := func() {
+		if _, err := conn.ExecContext(ctx, "SET @@tidb_snapshot = ''"); err != nil {
+			util.Detailf("reset tidb_snapshot failed, discarding conn: %v", err)
+			discardConn(conn)
+			return
+		}
+		util.CloseWithErr(conn, "snapshot conn")
+	}
+	return conn, release, nil
+}
+
+// discardConn closes conn and tells database/sql not to reuse the underlying
+// driver connection.
+func discardConn(conn *sql.Conn) {
+	_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+	_ = conn.Close()
+}
@@ -0,0 +1,150 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingConnector hands out connections that log every statement and answer
+// queries with a single row: (1, 2) for "SELECT 1, 2" and (1) otherwise.
+type recordingConnector struct {
+	mu       sync.Mutex
+	stmts    []string
+	failSet  string
+	connects int
+}
+
+func (c *recordingConnector) Connect(context.Context) (driver.Conn, error) {
+	c.mu.Lock()
+	c.connects++
+	c.mu.Unlock()
+	return &recordingConn{connector: c}, nil
+}
+
+func (c *recordingConnector) Driver() driver.Driver { return nil }
+
+func (c *recordingConnector) record(query string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stmts = append(c.stmts, query)
+	if c.failSet != "" && query == c.failSet {
+		return errors.New("set failed")
+	}
+	return nil
+}
+
+type recordingConn struct {
+	connector *recordingConnector
+}
+
+func (c *recordingConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *recordingConn) Close() error                        { return nil }
+func (c *recordingConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c *recordingConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	if err := c.connector.record(query); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (c *recordingConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	if err := c.connector.record(query); err != nil {
+		return nil, err
+	}
+	if query == "SELECT 1, 2" {
+		return &oneRow{cols: []string{"a", "b"}, values: []driver.Value{int64(1), int64(2)}}, nil
+	}
+	return &oneRow{cols: []string{"a"}, values: []driver.Value{int64(1)}}, nil
+}
+
+type oneRow struct {
+	cols   []string
+	values []driver.Value
+	done   bool
+}
+
+func (r *oneRow) Columns() []string { return r.cols }
+func (r *oneRow) Close() error      { return nil }
+func (r *oneRow) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	copy(dest, r.values)
+	return nil
+}
+
+func TestQuerySignatureAtSnapshot(t *testing.T) {
+	connector := &recordingConnector{}
+	d := &DB{DB: sql.OpenDB(connector), SnapshotTSO: 42}
+	defer d.Close()
+
+	sig, err := d.QuerySignature(context.Background(), "SELECT 1, 2")
+	if err != nil {
+		t.Fatalf("QuerySignature() failed: %v", err)
+	}
+	if sig != (Signature{Count: 1, Checksum: 2}) {
+		t.Fatalf("unexpected signature: %+v", sig)
+	}
+	want := []string{"SET @@tidb_snapshot = '42'", "SELECT 1, 2", "SET @@tidb_snapshot = ''"}
+	if strings.Join(connector.stmts, "|") != strings.Join(want, "|") {
+		t.Fatalf("statements=%q want=%q", connector.stmts, want)
+	}
+
+	d.SnapshotTSO = 0
+	connector.stmts = nil
+	if _, err := d.QueryCount(context.Background(), "SELECT 1"); err != nil {
+		t.Fatalf("QueryCount() failed: %v", err)
+	}
+	if len(connector.stmts) != 1 || connector.stmts[0] != "SELECT 1" {
+		t.Fatalf("unpinned count should not touch tidb_snapshot: %q", connector.stmts)
+	}
+}
+
+func TestSnapshotResetFailureDiscardsConn(t *testing.T) {
+	connector := &recordingConnector{failSet: "SET @@tidb_snapshot = ''"}
+	d := &DB{DB: sql.OpenDB(connector), SnapshotTSO: 7}
+	defer d.Close()
+	d.SetMaxIdleConns(1)
+
+	if _, err := d.QueryCount(context.Background(), "SELECT 1"); err != nil {
+		t.Fatalf("QueryCount() failed: %v", err)
+	}
+	d.SnapshotTSO = 0
+	if _, err := d.QueryCount(context.Background(), "SELECT 1"); err != nil {
+		t.Fatalf("QueryCount() failed: %v", err)
+	}
+	if connector.connects != 2 {
+		t.Fatalf("connection with a stale snapshot was reused: connects=%d", connector.connects)
+	}
+}
+
+func TestAfterFirstReadRunsOnce(t *testing.T) {
+	connector := &recordingConnector{}
+	d := &DB{DB: sql.OpenDB(connector)}
+	defer d.Close()
+	var reads []string
+	d.AfterFirstRead = func(_ context.Context, query string) {
+		reads = append(reads, query)
+	}
+	if _, err := d.QuerySignature(context.Background(), "SELECT 1, 2"); err != nil {
+		t.Fatalf("QuerySignature() failed: %v", err)
+	}
+	if _, err := d.QueryCount(context.Background(), "SELECT 1"); err != nil {
+		t.Fatalf("QueryCount() failed: %v", err)
+	}
+	if len(reads) != 1 || reads[0] != "SELECT 1, 2" || d.AfterFirstRead != nil {
+		t.Fatalf("hook should run once after the first read: %q", reads)
+	}
+	d.AfterFirstRead = func(context.Context, string) {}
+	if d.Fork().AfterFirstRead != nil {
+		t.Fatalf("fork should not inherit the hook")
+	}
+}
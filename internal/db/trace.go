@@ -0,0 +1,351 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// StatementTrace describes one statement sent to the server on a traced DB.
+type StatementTrace struct {
+	Kind         string
+	Tag          string
+	Time         time.Time
+	ConnID       uint64
+	ServerConnID int64
+	SQL          string
+	Args         []any
+	Duration     time.Duration
+	Err          error
+}
+
+// StatementTracer receives every statement executed on a traced DB, including
+// statements run on dedicated connections and prepared statements.
+type StatementTracer interface {
+	TraceStatement(trace StatementTrace)
+}
+
+var traceConnSeq atomic.Uint64
+
+type statementTagKey struct{}
+
+// WithStatementTag labels the statements run with ctx on a traced DB, for
+// example with the name of the oracle issuing them.
+func WithStatementTag(ctx context.Context, tag string) context.Context {
+	return context.WithValue(ctx, statementTagKey{}, tag)
+}
+
+func statementTag(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	tag, _ := ctx.Value(statementTagKey{}).(string)
+	return tag
+}
+
+// JoinTracers returns a tracer that forwards every trace to each non-nil
+// tracer in order, or nil when none is left.
+func JoinTracers(tracers ...StatementTracer) StatementTracer {
+	out := make(multiTracer, 0, len(tracers))
+	for _, tracer := range tracers {
+		if tracer != nil {
+			out = append(out, tracer)
+		}
+	}
+	switch len(out) {
+	case 0:
+		return nil
+	case 1:
+		return out[0]
+	}
+	return out
+}
+
+type multiTracer []StatementTracer
+
+func (m multiTracer) TraceStatement(trace StatementTrace) {
+	for _, tracer := range m {
+		tracer.TraceStatement(trace)
+	}
+}
+
+// OpenTraced creates a DB whose connections report every statement to tracer.
+// The sessionInit statements run on every new connection and are traced too.
+func OpenTraced(dsn string, tracer StatementTracer, sessionInit ...string) (*DB, error) {
+	if tracer == nil {
+		return Open(dsn, sessionInit...)
+	}
+	connector, err := newConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	traced := &tracingConnector{base: connector, tracer: tracer}
+	return &DB{DB: sql.OpenDB(withSessionInit(traced, sessionInit))}, nil
+}
+
+type tracingConnector struct {
+	base   driver.Connector
+	tracer StatementTracer
+}
+
+func (c *tracingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.base.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tc := &tracingConn{Conn: conn, tracer: c.tracer, id: traceConnSeq.Add(1)}
+	tc.serverID = queryServerConnID(ctx, conn)
+	return tc, nil
+}
+
+func (c *tracingConnector) Driver() driver.Driver {
+	return c.base.Driver()
+}
+
+// queryServerConnID reads CONNECTION_ID() so traces can be matched with server logs.
+func queryServerConnID(ctx context.Context, conn driver.Conn) int64 {
+	queryer, ok := conn.(driver.QueryerContext)
+	if !ok {
+		return 0
+	}
+	rows, err := queryer.QueryContext(ctx, "SELECT CONNECTION_ID()", nil)
+	if err != nil {
+		return 0
+	}
+	defer func() { _ = rows.Close() }()
+	dest := make([]driver.Value, 1)
+	if err := rows.Next(dest); err != nil {
+		return 0
+	}
+	switch v := dest[0].(type) {
+	case int64:
+		return v
+	case uint64:
+		return int64(v)
+	case []byte:
+		var id int64
+		for _, b := range v {
+			if b < '0' || b > '9' {
+				return 0
+			}
+			id = id*10 + int64(b-'0')
+		}
+		return id
+	default:
+		return 0
+	}
+}
+
+type tracingConn struct {
+	driver.Conn
+	tracer   StatementTracer
+	id       uint64
+	serverID int64
+}
+
+// Statement trace kinds.
+const (
+	TraceKindExec     = "exec"
+	TraceKindQuery    = "query"
+	TraceKindPrepare  = "prepare"
+	TraceKindExecute  = "execute"
+	TraceKindBegin    = "begin"
+	TraceKindCommit   = "commit"
+	TraceKindRollback = "rollback"
+)
+
+func (c *tracingConn) trace(kind string, tag string, query string, args []driver.NamedValue, start time.Time, err error) {
+	if errors.Is(err, driver.ErrSkip) {
+		return
+	}
+	var values []any
+	if len(args) > 0 {
+		values = make([]any, 0, len(args))
+		for _, arg := range args {
+			values = append(values, arg.Value)
+		}
+	}
+	c.tracer.TraceStatement(StatementTrace{
+		Kind:         kind,
+		Tag:          tag,
+		Time:         start,
+		ConnID:       c.id,
+		ServerConnID: c.serverID,
+		SQL:          query,
+		Args:         values,
+		Duration:     time.Since(start),
+		Err:          err,
+	})
+}
+
+func (c *tracingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	res, err := execer.ExecContext(ctx, query, args)
+	c.trace(TraceKindExec, statementTag(ctx), query, args, start, err)
+	return res, err
+}
+
+func (c *tracingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := queryer.QueryContext(ctx, query, args)
+	c.trace(TraceKindQuery, statementTag(ctx), query, args, start, err)
+	return rows, err
+}
+
+func (c *tracingConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var (
+		stmt driver.Stmt
+		err  error
+	)
+	start := time.Now()
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	c.trace(TraceKindPrepare, statementTag(ctx), query, nil, start, err)
+	if err != nil {
+		return nil, err
+	}
+	return &tracingStmt{Stmt: stmt, conn: c, query: query}, nil
+}
+
+func (c *tracingConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *tracingConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	start := time.Now()
+	var (
+		tx  driver.Tx
+		err error
+	)
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		tx, err = beginner.BeginTx(ctx, opts)
+	} else {
+		//nolint:staticcheck // fallback for drivers without BeginTx
+		tx, err = c.Conn.Begin()
+	}
+	tag := statementTag(ctx)
+	c.trace(TraceKindBegin, tag, "BEGIN", nil, start, err)
+	if err != nil {
+		return nil, err
+	}
+	return &tracingTx{Tx: tx, conn: c, tag: tag}, nil
+}
+
+func (c *tracingConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *tracingConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *tracingConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *tracingConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type tracingTx struct {
+	driver.Tx
+	conn *tracingConn
+	tag  string
+}
+
+func (t *tracingTx) Commit() error {
+	start := time.Now()
+	err := t.Tx.Commit()
+	t.conn.trace(TraceKindCommit, t.tag, "COMMIT", nil, start, err)
+	return err
+}
+
+func (t *tracingTx) Rollback() error {
+	start := time.Now()
+	err := t.Tx.Rollback()
+	t.conn.trace(TraceKindRollback, t.tag, "ROLLBACK", nil, start, err)
+	return err
+}
+
+type tracingStmt struct {
+	driver.Stmt
+	conn  *tracingConn
+	query string
+}
+
+func (s *tracingStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	var (
+		res driver.Result
+		err error
+	)
+	if execer, ok := s.Stmt.(driver.StmtExecContext); ok {
+		res, err = execer.ExecContext(ctx, args)
+	} else {
+		err = errors.New("driver statement does not support ExecContext")
+	}
+	s.conn.trace(TraceKindExecute, statementTag(ctx), s.query, args, start, err)
+	return res, err
+}
+
+func (s *tracingStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	var (
+		rows driver.Rows
+		err  error
+	)
+	if queryer, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = queryer.QueryContext(ctx, args)
+	} else {
+		err = errors.New("driver statement does not support QueryContext")
+	}
+	s.conn.trace(TraceKindExecute, statementTag(ctx), s.query, args, start, err)
+	return rows, err
+}
+
+func (s *tracingStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+var (
+	_ driver.Connector          = (*tracingConnector)(nil)
+	_ driver.ExecerContext      = (*tracingConn)(nil)
+	_ driver.QueryerContext     = (*tracingConn)(nil)
+	_ driver.ConnPrepareContext = (*tracingConn)(nil)
+	_ driver.ConnBeginTx        = (*tracingConn)(nil)
+	_ driver.Pinger             = (*tracingConn)(nil)
+	_ driver.SessionResetter    = (*tracingConn)(nil)
+	_ driver.Validator          = (*tracingConn)(nil)
+	_ driver.NamedValueChecker  = (*tracingConn)(nil)
+	_ driver.StmtExecContext    = (*tracingStmt)(nil)
+	_ driver.StmtQueryContext   = (*tracingStmt)(nil)
+)
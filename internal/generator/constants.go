@@ -11,13 +11,30 @@ const (
 	preparedExtraPredicateProb = 60
 	// PreparedAggExtraProb is the chance to add extra aggregate items in prepared queries.
 	preparedAggExtraProb = 50
+	// preparedLimitMax bounds the LIMIT ? argument.
+	preparedLimitMax = 20
+	// preparedInListMin/Max bound the number of IN (?, ...) placeholders.
+	preparedInListMin = 2
+	preparedInListMax = 5
+	// preparedStringCaseProb is the chance to upper-case a string argument so
+	// case-insensitive collations see a differently spelled value.
+	preparedStringCaseProb = 50
 )
 
+// preparedCollations are applied to string placeholders with COLLATE.
+var preparedCollations = []string{"utf8mb4_bin", "utf8mb4_general_ci", "utf8mb4_unicode_ci"}
+
 const (
-	// InsertRowCountMax is the maximum number of rows in a single INSERT.
+	// InsertRowCountMax is the default maximum number of rows in a single
+	// INSERT; insert_batch_rows overrides it.
 	InsertRowCountMax = 3
 	// DMLSubqueryProb is the chance to allow subqueries in DML predicates.
 	DMLSubqueryProb = 30
+	// DMLOrderLimitProb is the chance to add ORDER BY pk LIMIT n to an
+	// UPDATE or DELETE on a table with a primary key.
+	DMLOrderLimitProb = 20
+	// DMLLimitMax caps n in the ORDER BY ... LIMIT n tail of DML.
+	DMLLimitMax = 5
 )
 
 const (
@@ -35,10 +52,25 @@ const (
 	CompositeIndexMaxPerTable = 2
 	// CompositeIndexColsMaxProb is the chance to use max columns when possible.
 	CompositeIndexColsMaxProb = 50
+	// LargeRowBytesMax keeps the estimated row size of a widened table under
+	// the 65535-byte row size limit.
+	LargeRowBytesMax = 60000
 	// PartitionCountExtraMax controls how many partitions above the minimum.
 	PartitionCountExtraMax = 3
 	// PartitionCountMin is the minimum number of partitions.
 	PartitionCountMin = 2
+	// ForeignKeyActionProb is the chance to add ON DELETE (and, independently,
+	// ON UPDATE) referential actions to a generated foreign key.
+	ForeignKeyActionProb = 50
+	// ForeignKeyViolationTries bounds the attempts to draw a child value that
+	// is not a recorded parent key.
+	ForeignKeyViolationTries = 8
+	// CompositePKStringProb is the chance to prefer a VARCHAR column for the
+	// second primary key column.
+	CompositePKStringProb = 50
+	// CompositePKLeadingProb is the chance the second primary key column
+	// leads the key instead of id.
+	CompositePKLeadingProb = 50
 )
 
 const (
@@ -202,6 +234,10 @@ const (
 	DateYearMin = 2023
 	// DateYearMax is the maximum year for date literals.
 	DateYearMax = 2026
+	// YearLiteralMin is the minimum value for YEAR literals.
+	YearLiteralMin = 1990
+	// YearLiteralMax is the maximum value for YEAR literals.
+	YearLiteralMax = 2030
 	// DateSampleMax caps per-column date literal samples from INSERTs.
 	DateSampleMax = 32
 	// BoolLiteralTrueProb is the chance to emit TRUE-like literal for boolean.
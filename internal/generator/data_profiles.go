@@ -0,0 +1,157 @@
+package generator
+
+import (
+	"maps"
+	"math/rand"
+	"regexp"
+
+	"shiro/internal/config"
+	"shiro/internal/schema"
+	"shiro/internal/util"
+)
+
+type dataProfileRule struct {
+	cfg     config.DataProfileConfig
+	pattern *regexp.Regexp
+}
+
+// tableDataProfile is the per-table state of a data profile: the Zipf sampler
+// and the hot-value pool of each column.
+type tableDataProfile struct {
+	cfg  config.DataProfileConfig
+	zipf *rand.Zipf
+	hot  map[dataProfileColumn][]LiteralExpr
+}
+
+// dataProfileColumn keys hot-value pools; the type is part of the key so an
+// ALTER that changes a column type starts a fresh pool.
+type dataProfileColumn struct {
+	name string
+	typ  schema.ColumnType
+}
+
+// registerConfiguredDataProfiles compiles config.DataProfiles. Profiles with an
+// invalid table pattern are logged and skipped.
+func (g *Generator) registerConfiguredDataProfiles(profiles []config.DataProfileConfig) {
+	for _, profile := range profiles {
+		rule := dataProfileRule{cfg: profile}
+		if profile.Table != "" {
+			re, err := regexp.Compile(profile.Table)
+			if err != nil {
+				util.Warnf("data profile skipped name=%s table=%s err=%v", profile.Name, profile.Table, err)
+				continue
+			}
+			rule.pattern = re
+		}
+		g.dataProfiles = append(g.dataProfiles, rule)
+	}
+}
+
+// DataProfile returns the data profile applied to a table, if any.
+func (g *Generator) DataProfile(table string) (config.DataProfileConfig, bool) {
+	profile := g.tableDataProfile(table)
+	if profile == nil {
+		return config.DataProfileConfig{}, false
+	}
+	return profile.cfg, true
+}
+
+// DataProfilesForQuery maps the base tables referenced in the FROM clause of a
+// query to the names of their data profiles. It returns nil when none apply.
+func (g *Generator) DataProfilesForQuery(query *SelectQuery) map[string]string {
+	if g == nil || query == nil || len(g.dataProfiles) == 0 {
+		return nil
+	}
+	tables := make([]string, 0, len(query.From.Joins)+1)
+	tables = append(tables, query.From.BaseTable)
+	for _, join := range query.From.Joins {
+		tables = append(tables, join.Table)
+	}
+	var out map[string]string
+	for _, table := range tables {
+		if table == "" {
+			continue
+		}
+		profile, ok := g.DataProfile(table)
+		if !ok {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]string, len(tables))
+		}
+		out[table] = profile.Name
+	}
+	return out
+}
+
+// tableDataProfile returns the cached profile state for a table, binding the
+// first matching profile on first use.
+func (g *Generator) tableDataProfile(table string) *tableDataProfile {
+	if g == nil || len(g.dataProfiles) == 0 {
+		return nil
+	}
+	if profile, ok := g.tableProfiles[table]; ok {
+		return profile
+	}
+	var profile *tableDataProfile
+	for _, rule := range g.dataProfiles {
+		if rule.pattern != nil && !rule.pattern.MatchString(table) {
+			continue
+		}
+		profile = &tableDataProfile{cfg: rule.cfg}
+		if rule.cfg.ZipfS > 1 && rule.cfg.HotValues > 1 {
+			profile.zipf = rand.NewZipf(g.Rand, rule.cfg.ZipfS, 1, uint64(rule.cfg.HotValues-1))
+			profile.hot = make(map[dataProfileColumn][]LiteralExpr)
+		}
+		break
+	}
+	if g.tableProfiles == nil {
+		g.tableProfiles = make(map[string]*tableDataProfile)
+	}
+	g.tableProfiles[table] = profile
+	return profile
+}
+
+// fork copies the profile for a generator fork. The hot-value pools are never
+// changed once filled, so the copy shares them; the Zipf source is rebuilt on
+// r because it advances its random source on every draw.
+func (p *tableDataProfile) fork(r *rand.Rand) *tableDataProfile {
+	if p == nil {
+		return nil
+	}
+	out := &tableDataProfile{cfg: p.cfg}
+	if p.zipf != nil {
+		out.zipf = rand.NewZipf(r, p.cfg.ZipfS, 1, uint64(p.cfg.HotValues-1))
+		out.hot = maps.Clone(p.hot)
+	}
+	return out
+}
+
+// profileLiteral draws a column literal under the table profile: NULL with
+// NullProb for nullable columns, a copy of an earlier same-typed value in the
+// row with CorrelationProb, a Zipf-skewed hot value when skew is enabled, and
+// the built-in literal otherwise. row holds the earlier non-NULL values by type.
+func (g *Generator) profileLiteral(profile *tableDataProfile, col schema.Column, row map[schema.ColumnType]LiteralExpr) LiteralExpr {
+	if profile == nil {
+		return g.literalForColumn(col)
+	}
+	if col.Nullable && util.Chance(g.Rand, profile.cfg.NullProb) {
+		return LiteralExpr{Value: nil}
+	}
+	if prev, ok := row[col.Type]; ok && util.Chance(g.Rand, profile.cfg.CorrelationProb) {
+		return prev
+	}
+	if profile.zipf == nil {
+		return g.literalForColumn(col)
+	}
+	key := dataProfileColumn{name: col.Name, typ: col.Type}
+	pool := profile.hot[key]
+	if len(pool) == 0 {
+		pool = make([]LiteralExpr, 0, profile.cfg.HotValues)
+		for i := 0; i < profile.cfg.HotValues; i++ {
+			pool = append(pool, g.literalForColumn(col))
+		}
+		profile.hot[key] = pool
+	}
+	return pool[profile.zipf.Uint64()]
+}
@@ -0,0 +1,90 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"shiro/internal/config"
+	"shiro/internal/schema"
+)
+
+func newDataProfileTestGenerator(t *testing.T, profiles []config.DataProfileConfig) *Generator {
+	t.Helper()
+	cfg, err := config.Load("../../config.example.yaml")
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	cfg.DataProfiles = profiles
+	return New(cfg, &schema.State{}, 11)
+}
+
+func TestDataProfileMatching(t *testing.T) {
+	gen := newDataProfileTestGenerator(t, []config.DataProfileConfig{
+		{Name: "bad", Table: "("},
+		{Name: "hot", Table: "^t0$"},
+		{Name: "rest", Table: "^t"},
+	})
+	if len(gen.dataProfiles) != 2 {
+		t.Fatalf("unexpected profiles: %d", len(gen.dataProfiles))
+	}
+	cases := map[string]string{"t0": "hot", "t1": "rest", "v0": ""}
+	for table, want := range cases {
+		profile, ok := gen.DataProfile(table)
+		if ok != (want != "") || profile.Name != want {
+			t.Fatalf("DataProfile(%q)=%q,%v want=%q", table, profile.Name, ok, want)
+		}
+	}
+	query := &SelectQuery{From: FromClause{BaseTable: "t0", Joins: []Join{{Table: "t1"}, {Table: "v0"}}}}
+	got := gen.DataProfilesForQuery(query)
+	if len(got) != 2 || got["t0"] != "hot" || got["t1"] != "rest" {
+		t.Fatalf("DataProfilesForQuery()=%v", got)
+	}
+}
+
+func TestProfileLiteralNullSkewAndCorrelation(t *testing.T) {
+	gen := newDataProfileTestGenerator(t, []config.DataProfileConfig{
+		{Name: "nulls", Table: "^t_null$", NullProb: 100},
+		{Name: "skew", Table: "^t_skew$", ZipfS: 2, HotValues: 3},
+		{Name: "corr", Table: "^t_corr$", CorrelationProb: 100},
+	})
+	nullable := schema.Column{Name: "c0", Type: schema.TypeInt, Nullable: true}
+	if lit := gen.profileLiteral(gen.tableDataProfile("t_null"), nullable, nil); lit.Value != nil {
+		t.Fatalf("expected NULL literal, got %v", lit.Value)
+	}
+	notNull := schema.Column{Name: "c1", Type: schema.TypeInt}
+	if lit := gen.profileLiteral(gen.tableDataProfile("t_null"), notNull, nil); lit.Value == nil {
+		t.Fatalf("non-nullable column got NULL")
+	}
+
+	skew := gen.tableDataProfile("t_skew")
+	seen := map[any]int{}
+	for i := 0; i < 200; i++ {
+		seen[gen.profileLiteral(skew, notNull, nil).Value]++
+	}
+	if len(seen) > 3 {
+		t.Fatalf("expected at most 3 hot values, got %d", len(seen))
+	}
+
+	corr := gen.tableDataProfile("t_corr")
+	row := map[schema.ColumnType]LiteralExpr{schema.TypeInt: {Value: 42}}
+	if lit := gen.profileLiteral(corr, notNull, row); lit.Value != 42 {
+		t.Fatalf("expected correlated value 42, got %v", lit.Value)
+	}
+}
+
+func TestInsertSQLUsesDataProfile(t *testing.T) {
+	gen := newDataProfileTestGenerator(t, []config.DataProfileConfig{{Name: "nulls", NullProb: 100}})
+	tbl := &schema.Table{
+		Name:   "t0",
+		HasPK:  true,
+		NextID: 1,
+		Columns: []schema.Column{
+			{Name: "id", Type: schema.TypeBigInt},
+			{Name: "c0", Type: schema.TypeInt, Nullable: true},
+		},
+	}
+	sql := gen.InsertSQL(tbl)
+	if !strings.Contains(sql, ", NULL)") {
+		t.Fatalf("expected NULL values in insert, got: %s", sql)
+	}
+}
@@ -102,6 +102,36 @@ func (e GroupByOrdinalExpr) Deterministic() bool {
 	return e.Expr.Deterministic()
 }
 
+// NameRefExpr renders a bare name that the server must resolve while preserving
+// the expression it stands for: a select-list alias reused in ORDER BY/HAVING,
+// or a merged USING column without a table qualifier (Using is set).
+type NameRefExpr struct {
+	Name  string
+	Expr  Expr
+	Using bool
+}
+
+// Build emits the bare name.
+func (e NameRefExpr) Build(b *SQLBuilder) {
+	b.Write(e.Name)
+}
+
+// Columns reports the column references used by the resolved expression.
+func (e NameRefExpr) Columns() []ColumnRef {
+	if e.Expr == nil {
+		return nil
+	}
+	return e.Expr.Columns()
+}
+
+// Deterministic reports whether the resolved expression is deterministic.
+func (e NameRefExpr) Deterministic() bool {
+	if e.Expr == nil {
+		return true
+	}
+	return e.Expr.Deterministic()
+}
+
 // ParamExpr renders a prepared statement parameter.
 type ParamExpr struct {
 	Value any
@@ -51,6 +51,9 @@ type QueryFeatures struct {
 	HasIntervalArith              bool
 	HasNaturalJoin                bool
 	HasFullJoinEmulation          bool
+	// HasNameRefs is true when the query references a select alias or a
+	// merged USING column by its bare name.
+	HasNameRefs                   bool
 	FullJoinEmulationAttempted    bool
 	FullJoinEmulationRejectReason string
 	// HasRecursiveCTE is true when the query owns a WITH RECURSIVE clause.
@@ -65,6 +68,20 @@ type QueryFeatures struct {
 	SubqueryAttempts             int64
 	SubqueryBuilt                int64
 	SubqueryFailed               int64
+	// NullPredicates and PredicateLeaves give the NULL density of the WHERE
+	// clause and join conditions (see NullDensity).
+	NullPredicates  int
+	PredicateLeaves int
+	// PredicateNodes, SubqueryDepth, and SQLLength describe the size of the
+	// query for the query shape histograms; setLastFeatures fills them.
+	PredicateNodes int
+	SubqueryDepth  int
+	SQLLength      int
+	// SelectivityTarget is the bucket the predicates aimed at; the probe SQL
+	// is set only when the query was sampled for measurement.
+	SelectivityTarget   SelectivityBucket
+	SelectivityMatchSQL string
+	SelectivityTotalSQL string
 }
 
 // AnalyzeQuery summarizes a query for fast-path guards and shared checks.
@@ -146,6 +163,7 @@ func AnalyzeQueryFeatures(query *SelectQuery) QueryFeatures {
 		observeExprFeatures(&features, item.Expr)
 	}
 	observeExprFeatures(&features, query.Where)
+	features.NullPredicates, features.PredicateLeaves = NullDensity(query.Where)
 	observeExprFeatures(&features, query.Having)
 	for _, expr := range query.GroupBy {
 		observeExprFeatures(&features, expr)
@@ -174,6 +192,9 @@ func AnalyzeQueryFeatures(query *SelectQuery) QueryFeatures {
 		}
 		if join.On != nil {
 			observeExprFeatures(&features, join.On)
+			nulls, leaves := NullDensity(join.On)
+			features.NullPredicates += nulls
+			features.PredicateLeaves += leaves
 		}
 	}
 	for _, op := range query.SetOps {
@@ -191,6 +212,9 @@ func observeExprFeatures(features *QueryFeatures, expr Expr) {
 		return
 	case GroupByOrdinalExpr:
 		observeExprFeatures(features, e.Expr)
+	case NameRefExpr:
+		features.HasNameRefs = true
+		observeExprFeatures(features, e.Expr)
 	case UnaryExpr:
 		if strings.EqualFold(strings.TrimSpace(e.Op), "NOT") {
 			switch inner := e.Expr.(type) {
@@ -356,6 +380,7 @@ func mergeQueryFeatureFlags(dst *QueryFeatures, src QueryFeatures) {
 	dst.HasIntervalArith = dst.HasIntervalArith || src.HasIntervalArith
 	dst.HasNaturalJoin = dst.HasNaturalJoin || src.HasNaturalJoin
 	dst.HasFullJoinEmulation = dst.HasFullJoinEmulation || src.HasFullJoinEmulation
+	dst.HasNameRefs = dst.HasNameRefs || src.HasNameRefs
 	dst.FullJoinEmulationAttempted = dst.FullJoinEmulationAttempted || src.FullJoinEmulationAttempted
 	if dst.FullJoinEmulationRejectReason == "" {
 		dst.FullJoinEmulationRejectReason = src.FullJoinEmulationRejectReason
@@ -451,6 +476,11 @@ func ExprHasAggregate(expr Expr) bool {
 			return false
 		}
 		return ExprHasAggregate(e.Expr)
+	case NameRefExpr:
+		if e.Expr == nil {
+			return false
+		}
+		return ExprHasAggregate(e.Expr)
 	case SubqueryExpr:
 		return exprHasAggregateQuery(e.Query)
 	case ExistsExpr:
@@ -616,6 +646,11 @@ func exprHasExistsSubquery(expr Expr) (hasExists bool, hasNotExists bool) {
 			return false, false
 		}
 		return exprHasExistsSubquery(e.Expr)
+	case NameRefExpr:
+		if e.Expr == nil {
+			return false, false
+		}
+		return exprHasExistsSubquery(e.Expr)
 	default:
 		return false, false
 	}
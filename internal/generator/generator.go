@@ -3,6 +3,7 @@ package generator
 import (
 	"fmt"
 	"math/rand"
+	"slices"
 	"time"
 
 	"shiro/internal/config"
@@ -18,10 +19,12 @@ type Generator struct {
 	Template                   *TemplateWeights
 	LastFeatures               *QueryFeatures
 	LastAnalysis               *QueryAnalysis
+	LastDMLOrderLimit          *DMLOrderLimit
 	builderBuilds              int64
 	builderAttemptsTotal       int64
 	builderAttemptHistogram    map[int]int64
 	builderFailureReasons      map[string]int64
+	builderRejections          map[string]int64
 	Seed                       int64
 	Truth                      any
 	TQSWalker                  TQSWalker
@@ -44,6 +47,11 @@ type Generator struct {
 	disallowScalarSubq         bool
 	subqueryConstraintDisallow bool
 	dateSamples                map[string]map[string][]string
+	valueGenerators            []valueGeneratorRule
+	dataProfiles               []dataProfileRule
+	tableProfiles              map[string]*tableDataProfile
+	selectivityTarget          SelectivityBucket
+	selectivityProbe           bool
 }
 
 // PredicateMode controls predicate generation.
@@ -99,6 +107,9 @@ type BuilderStats struct {
 	Attempts          int64
 	AttemptsHistogram map[int]int64
 	FailureReasons    map[string]int64
+	// Rejections counts rejected candidates per constraint, across successful
+	// and failed builds.
+	Rejections map[string]int64
 }
 
 // (constants moved to constants.go)
@@ -108,7 +119,7 @@ func New(cfg config.Config, state *schema.State, seed int64) *Generator {
 	if seed == 0 {
 		seed = time.Now().UnixNano()
 	}
-	return &Generator{
+	g := &Generator{
 		Rand:         rand.New(rand.NewSource(seed)),
 		Config:       cfg,
 		State:        state,
@@ -116,6 +127,41 @@ func New(cfg config.Config, state *schema.State, seed int64) *Generator {
 		maxDepth:     3,
 		maxSubqDepth: 3,
 	}
+	g.registerConfiguredValueGenerators(cfg.ValueGenerators)
+	g.registerConfiguredDataProfiles(cfg.DataProfiles)
+	return g
+}
+
+// Fork returns a generator for a concurrent read-only oracle run over state.
+// The fork has its own random source, builder stats, and sample caches, so the
+// parent keeps generating while the fork runs. Data profiles are copied and
+// draw their skew from the fork's random source. Forks are meant for SELECT
+// generation only: value generators, Truth, and the TQS walker stay shared.
+func (g *Generator) Fork(state *schema.State) *Generator {
+	fork := *g
+	fork.Rand = rand.New(rand.NewSource(g.Rand.Int63()))
+	fork.State = state
+	fork.LastFeatures = nil
+	fork.LastAnalysis = nil
+	fork.LastDMLOrderLimit = nil
+	fork.builderBuilds = 0
+	fork.builderAttemptsTotal = 0
+	fork.builderAttemptHistogram = nil
+	fork.builderFailureReasons = nil
+	fork.builderRejections = nil
+	fork.tableProfiles = make(map[string]*tableDataProfile, len(g.tableProfiles))
+	for table, profile := range g.tableProfiles {
+		fork.tableProfiles[table] = profile.fork(fork.Rand)
+	}
+	fork.dateSamples = make(map[string]map[string][]string, len(g.dateSamples))
+	for table, columns := range g.dateSamples {
+		copied := make(map[string][]string, len(columns))
+		for column, samples := range columns {
+			copied[column] = slices.Clone(samples)
+		}
+		fork.dateSamples[table] = copied
+	}
+	return &fork
 }
 
 // SetAdaptiveWeights overrides feature weights for adaptive sampling.
@@ -238,6 +284,9 @@ func (g *Generator) ResetBuilderStats() {
 	for k := range g.builderFailureReasons {
 		delete(g.builderFailureReasons, k)
 	}
+	for k := range g.builderRejections {
+		delete(g.builderRejections, k)
+	}
 }
 
 // BuilderStats returns a snapshot of builder metrics.
@@ -261,10 +310,16 @@ func (g *Generator) BuilderStats() BuilderStats {
 			out.FailureReasons[k] = v
 		}
 	}
+	if len(g.builderRejections) > 0 {
+		out.Rejections = make(map[string]int64, len(g.builderRejections))
+		for k, v := range g.builderRejections {
+			out.Rejections[k] = v
+		}
+	}
 	return out
 }
 
-func (g *Generator) recordBuilderStats(attempts int, reason string) {
+func (g *Generator) recordBuilderStats(attempts int, reason string, rejections map[string]int) {
 	if g == nil || attempts <= 0 {
 		return
 	}
@@ -280,6 +335,12 @@ func (g *Generator) recordBuilderStats(attempts int, reason string) {
 		}
 		g.builderFailureReasons[reason]++
 	}
+	if len(rejections) > 0 && g.builderRejections == nil {
+		g.builderRejections = make(map[string]int64)
+	}
+	for k, v := range rejections {
+		g.builderRejections[k] += int64(v)
+	}
 }
 
 func (g *Generator) trackPredicatePair(fromJoinGraph bool) {
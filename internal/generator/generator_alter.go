@@ -0,0 +1,166 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+
+	"shiro/internal/schema"
+)
+
+// widenedColumnTypes lists the type changes ModifyColumnSQL may apply. Every
+// target holds all values of the source type, so the ALTER never fails on
+// existing rows; some (INT to VARCHAR, DATE to DATETIME) still change how the
+// column compares and therefore which plans are valid.
+var widenedColumnTypes = map[schema.ColumnType][]schema.ColumnType{
+	schema.TypeInt:    {schema.TypeBigInt, schema.TypeDecimal, schema.TypeVarchar},
+	schema.TypeBigInt: {schema.TypeVarchar},
+	schema.TypeFloat:  {schema.TypeDouble},
+	schema.TypeDate:   {schema.TypeDatetime},
+}
+
+// ModifyColumnSQL emits ALTER TABLE ... MODIFY COLUMN widening one column and
+// updates table metadata. Primary key and foreign key columns are left alone.
+func (g *Generator) ModifyColumnSQL(tbl *schema.Table) (string, bool) {
+	if tbl == nil {
+		return "", false
+	}
+	candidates := make([]int, 0, len(tbl.Columns))
+	for i, col := range tbl.Columns {
+		if !alterableColumn(*tbl, col.Name) {
+			continue
+		}
+		if len(widenedColumnTypes[col.Type]) > 0 {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", false
+	}
+	idx := candidates[g.Rand.Intn(len(candidates))]
+	targets := widenedColumnTypes[tbl.Columns[idx].Type]
+	tbl.Columns = slices.Clone(tbl.Columns)
+	col := &tbl.Columns[idx]
+	col.Type = targets[g.Rand.Intn(len(targets))]
+	line := fmt.Sprintf("%s %s", col.Name, col.SQLType())
+	if !col.Nullable {
+		line += " NOT NULL"
+	}
+	return fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s", tbl.Name, line), true
+}
+
+// addedColumnTypes are the types AddColumnSQL picks. FLOAT and DOUBLE are
+// left out because their defaults do not compare equal to the literal, and
+// SET because it stores members in definition order.
+var addedColumnTypes = []schema.ColumnType{
+	schema.TypeInt,
+	schema.TypeBigInt,
+	schema.TypeDecimal,
+	schema.TypeVarchar,
+	schema.TypeDate,
+	schema.TypeDatetime,
+	schema.TypeTimestamp,
+	schema.TypeBool,
+}
+
+// AddColumnSQL emits ALTER TABLE ... ADD COLUMN for a new column that is NOT
+// NULL with a literal DEFAULT, nullable with one, or nullable without one,
+// and appends it to the table metadata. It also returns a predicate on the
+// new column that every row existing before the ALTER must satisfy, so the
+// backfill can be checked right away.
+func (g *Generator) AddColumnSQL(tbl *schema.Table) (string, string, bool) {
+	if tbl == nil || tbl.IsView {
+		return "", "", false
+	}
+	types := addedColumnTypes
+	if g.Config.Features.ExtendedTypes {
+		types = append(slices.Clone(types), schema.TypeEnum, schema.TypeYear)
+	}
+	col := schema.Column{Name: nextColumnName(*tbl), Type: types[g.Rand.Intn(len(types))], Nullable: true}
+	line := fmt.Sprintf("%s %s", col.Name, col.SQLType())
+	predicate := col.Name + " IS NULL"
+	switch g.Rand.Intn(3) {
+	case 0:
+		col.Nullable = false
+		value := exprSQLText(g.literalForColumn(col))
+		line += " NOT NULL DEFAULT " + value
+		predicate = fmt.Sprintf("%s = %s", col.Name, value)
+	case 1:
+		value := exprSQLText(g.literalForColumn(col))
+		line += " DEFAULT " + value
+		predicate = fmt.Sprintf("%s = %s", col.Name, value)
+	}
+	tbl.Columns = append(slices.Clone(tbl.Columns), col)
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", tbl.Name, line), predicate, true
+}
+
+// nextColumnName returns the first cN name the table does not use.
+func nextColumnName(tbl schema.Table) string {
+	for i := len(tbl.Columns); ; i++ {
+		name := fmt.Sprintf("c%d", i)
+		if !slices.ContainsFunc(tbl.Columns, func(col schema.Column) bool { return col.Name == name }) {
+			return name
+		}
+	}
+}
+
+// PartitionTableSQL emits ALTER TABLE ... PARTITION BY HASH(id), which
+// partitions a plain table or adds a partition to a hash-partitioned one.
+// Generated unique keys always include id, so the partition key stays valid.
+func (g *Generator) PartitionTableSQL(tbl *schema.Table) (string, bool) {
+	if tbl == nil || tbl.IsView || !tbl.HasPK {
+		return "", false
+	}
+	count := g.Rand.Intn(PartitionCountExtraMax) + PartitionCountMin
+	if tbl.Partitioned && tbl.PartitionCount >= count {
+		count = tbl.PartitionCount + 1
+	}
+	tbl.Partitioned = true
+	tbl.PartitionCount = count
+	return fmt.Sprintf("ALTER TABLE %s PARTITION BY HASH(id) PARTITIONS %d", tbl.Name, count), true
+}
+
+// DropColumnSQL emits ALTER TABLE ... DROP COLUMN and updates table metadata.
+// Columns covered by a composite index are skipped because older TiDB
+// versions reject the drop, and at least two columns are kept.
+func (g *Generator) DropColumnSQL(tbl *schema.Table) (string, bool) {
+	if tbl == nil || len(tbl.Columns) <= 2 {
+		return "", false
+	}
+	candidates := make([]int, 0, len(tbl.Columns))
+	for i, col := range tbl.Columns {
+		if !alterableColumn(*tbl, col.Name) || inCompositeIndex(*tbl, col.Name) {
+			continue
+		}
+		candidates = append(candidates, i)
+	}
+	if len(candidates) == 0 {
+		return "", false
+	}
+	idx := candidates[g.Rand.Intn(len(candidates))]
+	name := tbl.Columns[idx].Name
+	tbl.Columns = slices.Delete(slices.Clone(tbl.Columns), idx, idx+1)
+	return fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", tbl.Name, name), true
+}
+
+// alterableColumn reports whether a column is outside the primary key and
+// every foreign key, so changing or dropping it needs no key rewrite.
+func alterableColumn(tbl schema.Table, name string) bool {
+	if name == "id" || slices.Contains(tbl.PrimaryKeyColumns(), name) {
+		return false
+	}
+	for _, fk := range tbl.ForeignKeys {
+		if fk.Column == name {
+			return false
+		}
+	}
+	return true
+}
+
+func inCompositeIndex(tbl schema.Table, name string) bool {
+	for _, idx := range tbl.Indexes {
+		if len(idx.Columns) > 1 && slices.Contains(idx.Columns, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pingcap/tidb/pkg/parser"
+
+	"shiro/internal/config"
+	"shiro/internal/schema"
+)
+
+func TestAlterTableSQL(t *testing.T) {
+	gen := New(config.Config{}, &schema.State{}, 3)
+	base := schema.Table{
+		Name:       "t0",
+		HasPK:      true,
+		PrimaryKey: []string{"id", "c0"},
+		Columns: []schema.Column{
+			{Name: "id", Type: schema.TypeInt},
+			{Name: "c0", Type: schema.TypeInt},
+			{Name: "c1", Type: schema.TypeInt, Nullable: true},
+			{Name: "c2", Type: schema.TypeVarchar},
+			{Name: "c3", Type: schema.TypeDate},
+		},
+		Indexes: []schema.Index{{Name: "idx_c2_c3", Columns: []string{"c2", "c3"}}},
+	}
+	var stmts []string
+
+	tbl := base
+	sql, ok := gen.ModifyColumnSQL(&tbl)
+	stmts = append(stmts, sql)
+	if !ok || !strings.HasPrefix(sql, "ALTER TABLE t0 MODIFY COLUMN ") {
+		t.Fatalf("ModifyColumnSQL()=%q ok=%v", sql, ok)
+	}
+	changed := 0
+	for i, col := range tbl.Columns {
+		if col.Type != base.Columns[i].Type {
+			changed++
+			if col.Name == "id" || col.Name == "c0" {
+				t.Fatalf("primary key column %s must not change type", col.Name)
+			}
+		}
+	}
+	if changed != 1 {
+		t.Fatalf("expected one widened column, got %d", changed)
+	}
+	if base.Columns[1].Type != schema.TypeInt || base.Columns[2].Type != schema.TypeInt || base.Columns[4].Type != schema.TypeDate {
+		t.Fatalf("ModifyColumnSQL mutated the caller's columns: %+v", base.Columns)
+	}
+
+	tbl = base
+	sql, ok = gen.DropColumnSQL(&tbl)
+	stmts = append(stmts, sql)
+	if !ok || sql != "ALTER TABLE t0 DROP COLUMN c1" {
+		t.Fatalf("DropColumnSQL()=%q ok=%v", sql, ok)
+	}
+	if len(tbl.Columns) != 4 || len(base.Columns) != 5 {
+		t.Fatalf("unexpected columns after drop: %+v", tbl.Columns)
+	}
+
+	seen := map[string]bool{}
+	for i := 0; i < 30; i++ {
+		tbl = base
+		var predicate string
+		sql, predicate, ok = gen.AddColumnSQL(&tbl)
+		stmts = append(stmts, sql)
+		if !ok || !strings.HasPrefix(sql, "ALTER TABLE t0 ADD COLUMN c5 ") || len(tbl.Columns) != 6 || len(base.Columns) != 5 {
+			t.Fatalf("AddColumnSQL()=%q ok=%v columns=%+v", sql, ok, tbl.Columns)
+		}
+		added := tbl.Columns[5]
+		switch {
+		case strings.Contains(sql, " NOT NULL DEFAULT "):
+			seen["not_null"] = true
+			if added.Nullable || !strings.HasPrefix(predicate, "c5 = ") {
+				t.Fatalf("unexpected NOT NULL column %+v predicate=%q", added, predicate)
+			}
+		case strings.Contains(sql, " DEFAULT "):
+			seen["default"] = true
+			if !added.Nullable || !strings.HasPrefix(predicate, "c5 = ") {
+				t.Fatalf("unexpected defaulted column %+v predicate=%q", added, predicate)
+			}
+		default:
+			seen["null"] = true
+			if !added.Nullable || predicate != "c5 IS NULL" {
+				t.Fatalf("unexpected nullable column %+v predicate=%q", added, predicate)
+			}
+		}
+	}
+	if len(seen) != 3 {
+		t.Fatalf("expected all ADD COLUMN variants, got %v", seen)
+	}
+
+	tbl = base
+	sql, ok = gen.PartitionTableSQL(&tbl)
+	stmts = append(stmts, sql)
+	if !ok || !tbl.Partitioned || tbl.PartitionCount < PartitionCountMin {
+		t.Fatalf("PartitionTableSQL()=%q table=%+v", sql, tbl)
+	}
+	first := tbl.PartitionCount
+	if _, ok := gen.PartitionTableSQL(&tbl); !ok || tbl.PartitionCount <= first {
+		t.Fatalf("expected more partitions than %d, got %d", first, tbl.PartitionCount)
+	}
+	p := parser.New()
+	for _, stmt := range stmts {
+		if _, _, err := p.Parse(stmt, "", ""); err != nil {
+			t.Fatalf("parse failed: %v\nsql=%s", err, stmt)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package generator
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"shiro/internal/schema"
@@ -10,23 +11,59 @@ import (
 
 // (constants moved to constants.go)
 
-// InsertSQL emits an INSERT statement and advances auto IDs.
+// insertRowCountMax returns the row cap of one INSERT.
+func (g *Generator) insertRowCountMax() int {
+	if g.Config.InsertBatchRows > 0 {
+		return g.Config.InsertBatchRows
+	}
+	return InsertRowCountMax
+}
+
+// InsertSQL emits an INSERT of 1..insert_batch_rows rows; see InsertRowsSQL.
 func (g *Generator) InsertSQL(tbl *schema.Table) string {
 	if tbl == nil {
 		return ""
 	}
-	rowCount := g.Rand.Intn(InsertRowCountMax) + 1
+	sql, _ := g.InsertRowsSQL(tbl, g.Rand.Intn(g.insertRowCountMax())+1)
+	return sql
+}
+
+// InsertRowsSQL emits a multi-row INSERT of up to rowCount rows and returns
+// it with the number of rows it holds. It advances auto IDs. Foreign key
+// columns reference existing parent keys, except in one row of the statements
+// picked by features.foreign_key_violation_prob; rows without a parent key
+// are left out. In a statement of several rows, a row may write NULL or
+// DEFAULT into every nullable non-key column (insert_null_row_prob and
+// insert_default_row_prob). Inserted values are recorded in the schema state
+// as parent keys for later child rows.
+func (g *Generator) InsertRowsSQL(tbl *schema.Table, rowCount int) (string, int) {
+	if tbl == nil || rowCount <= 0 {
+		return "", 0
+	}
 	cols := make([]string, 0, len(tbl.Columns))
 	for _, col := range tbl.Columns {
 		cols = append(cols, col.Name)
 	}
 	values := make([]string, 0, rowCount)
+	profile := g.tableDataProfile(tbl.Name)
+	violateRow := -1
+	if len(tbl.ForeignKeys) > 0 && util.Chance(g.Rand, g.Config.Features.ForeignKeyViolationProb) {
+		violateRow = g.Rand.Intn(rowCount)
+	}
+	keyCols := tbl.PrimaryKeyColumns()
 	for i := 0; i < rowCount; i++ {
+		violate := i == violateRow
+		fill := g.insertRowFill(rowCount)
 		vals := make([]string, 0, len(tbl.Columns))
+		var rowLiterals map[schema.ColumnType]LiteralExpr
+		if profile != nil {
+			rowLiterals = make(map[schema.ColumnType]LiteralExpr, len(tbl.Columns))
+		}
 		rowValid := true
 		for _, col := range tbl.Columns {
 			if fk, ok := foreignKeyByColumn(*tbl, col.Name); ok {
-				val, consumeID, ok := g.foreignKeyInsertValue(tbl, col, fk)
+				val, consumeID, violated, ok := g.foreignKeyInsertValue(tbl, col, fk, violate)
+				violate = violate && !violated
 				if !ok {
 					rowValid = false
 					break
@@ -42,13 +79,29 @@ func (g *Generator) InsertSQL(tbl *schema.Table) string {
 				tbl.NextID++
 				continue
 			}
-			lit := g.literalForColumn(col)
+			if fill != "" && col.Nullable && !slices.Contains(keyCols, col.Name) {
+				vals = append(vals, fill)
+				continue
+			}
+			lit, ok := g.customLiteral(tbl.Name, col)
+			if !ok {
+				lit = g.profileLiteral(profile, col, rowLiterals)
+			}
+			if rowLiterals != nil && lit.Value != nil {
+				if _, seen := rowLiterals[col.Type]; !seen {
+					rowLiterals[col.Type] = lit
+				}
+			}
 			if col.Type == schema.TypeDate || col.Type == schema.TypeDatetime || col.Type == schema.TypeTimestamp {
 				if v, ok := lit.Value.(string); ok {
 					g.recordDateSample(tbl.Name, col.Name, v)
 				}
 			}
-			vals = append(vals, g.exprSQL(lit))
+			literal := g.exprSQL(lit)
+			if lit.Value != nil {
+				g.State.RecordKey(g.Rand, tbl.Name, col.Name, literal)
+			}
+			vals = append(vals, literal)
 		}
 		if !rowValid {
 			continue
@@ -56,9 +109,25 @@ func (g *Generator) InsertSQL(tbl *schema.Table) string {
 		values = append(values, fmt.Sprintf("(%s)", strings.Join(vals, ", ")))
 	}
 	if len(values) == 0 {
+		return "", 0
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", tbl.Name, strings.Join(cols, ", "), strings.Join(values, ", ")), len(values)
+}
+
+// insertRowFill returns "NULL" or "DEFAULT" for a row of a multi-row INSERT
+// that skips generated values, or "" for a regular row.
+func (g *Generator) insertRowFill(rowCount int) string {
+	if rowCount < 2 {
+		return ""
+	}
+	switch {
+	case util.Chance(g.Rand, g.Config.InsertNullRowProb):
+		return "NULL"
+	case util.Chance(g.Rand, g.Config.InsertDefaultRowProb):
+		return "DEFAULT"
+	default:
 		return ""
 	}
-	return fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", tbl.Name, strings.Join(cols, ", "), strings.Join(values, ", "))
 }
 
 // UpdateSQL emits an UPDATE statement and returns predicate metadata.
@@ -80,7 +149,8 @@ func (g *Generator) UpdateSQL(tbl schema.Table) (sql string, predicate Expr, set
 	}
 	builder := SQLBuilder{}
 	predicate.Build(&builder)
-	sql = fmt.Sprintf("UPDATE %s SET %s = %s WHERE %s", tbl.Name, col.Name, g.exprSQL(setExpr), builder.String())
+	g.LastDMLOrderLimit = g.pickDMLOrderLimit(tbl)
+	sql = fmt.Sprintf("UPDATE %s SET %s = %s WHERE %s%s", tbl.Name, col.Name, g.exprSQL(setExpr), builder.String(), g.LastDMLOrderLimit.SQL())
 	return sql, predicate, setExpr, colRef
 }
 
@@ -90,7 +160,47 @@ func (g *Generator) DeleteSQL(tbl schema.Table) (string, Expr) {
 	predicate := g.GeneratePredicate([]schema.Table{tbl}, g.maxDepth, allowSubquery, g.maxSubqDepth)
 	builder := SQLBuilder{}
 	predicate.Build(&builder)
-	return fmt.Sprintf("DELETE FROM %s WHERE %s", tbl.Name, builder.String()), predicate
+	g.LastDMLOrderLimit = g.pickDMLOrderLimit(tbl)
+	return fmt.Sprintf("DELETE FROM %s WHERE %s%s", tbl.Name, builder.String(), g.LastDMLOrderLimit.SQL()), predicate
+}
+
+// DMLOrderLimit is the ORDER BY ... LIMIT tail of a single-table UPDATE or
+// DELETE. OrderBy is the primary key, so the limited rows are deterministic.
+type DMLOrderLimit struct {
+	OrderBy []string
+	Desc    bool
+	Limit   int
+}
+
+// SQL renders the tail with a leading space; a nil tail renders as "".
+func (o *DMLOrderLimit) SQL() string {
+	if o == nil || len(o.OrderBy) == 0 {
+		return ""
+	}
+	dir := ""
+	if o.Desc {
+		dir = " DESC"
+	}
+	cols := make([]string, 0, len(o.OrderBy))
+	for _, name := range o.OrderBy {
+		cols = append(cols, name+dir)
+	}
+	return fmt.Sprintf(" ORDER BY %s LIMIT %d", strings.Join(cols, ", "), o.Limit)
+}
+
+// pickDMLOrderLimit returns an ORDER BY ... LIMIT tail for tbl, or nil. Only
+// tables with a primary key get one: ordering by a non-unique column would
+// leave the limited rows up to the plan.
+func (g *Generator) pickDMLOrderLimit(tbl schema.Table) *DMLOrderLimit {
+	pk := tbl.PrimaryKeyColumns()
+	if len(pk) == 0 || !util.Chance(g.Rand, DMLOrderLimitProb) {
+		return nil
+	}
+	return &DMLOrderLimit{
+		OrderBy: pk,
+		Desc:    util.Chance(g.Rand, 50),
+		Limit:   1 + g.Rand.Intn(DMLLimitMax),
+	}
 }
 
 func foreignKeyByColumn(tbl schema.Table, columnName string) (schema.ForeignKey, bool) {
@@ -102,28 +212,62 @@ func foreignKeyByColumn(tbl schema.Table, columnName string) (schema.ForeignKey,
 	return schema.ForeignKey{}, false
 }
 
-func (g *Generator) foreignKeyInsertValue(tbl *schema.Table, col schema.Column, fk schema.ForeignKey) (value string, consumeID bool, ok bool) {
+// foreignKeyInsertValue picks the value of a foreign key column. It draws
+// an existing parent key, or a missing one when violate is set and the column
+// is not the child id; violated reports whether it did.
+func (g *Generator) foreignKeyInsertValue(tbl *schema.Table, col schema.Column, fk schema.ForeignKey, violate bool) (value string, consumeID bool, violated bool, ok bool) {
 	if g == nil || g.State == nil || tbl == nil {
-		return "", false, false
+		return "", false, false, false
 	}
 	parent, ok := g.State.TableByName(fk.RefTable)
 	if !ok {
-		return "", false, false
+		return "", false, false, false
 	}
 	parentRows := parent.NextID - 1
+	if violate && col.Name != "id" {
+		if value, ok := g.foreignKeyViolationValue(parent, col, fk); ok {
+			return value, false, true, true
+		}
+	}
 	if parentRows <= 0 {
 		if col.Nullable {
-			return "NULL", false, true
+			return "NULL", false, false, true
 		}
-		return "", false, false
+		return "", false, false, false
 	}
 	// For id->id references, keep monotonic child ids while ensuring the parent row exists.
 	if col.Name == "id" && fk.RefColumn == "id" {
 		if tbl.NextID <= parentRows {
-			return fmt.Sprintf("%d", tbl.NextID), true, true
+			return fmt.Sprintf("%d", tbl.NextID), true, false, true
+		}
+		return "", false, false, false
+	}
+	// Spread child rows over the parent ids so joins match more than one parent.
+	if fk.RefColumn == "id" {
+		return fmt.Sprintf("%d", 1+g.Rand.Int63n(parentRows)), false, false, true
+	}
+	if value, ok := g.State.KeySample(g.Rand, parent.Name, fk.RefColumn); ok {
+		return value, false, false, true
+	}
+	// Without recorded parent values, pick an existing parent value.
+	return fmt.Sprintf("(SELECT %s FROM %s ORDER BY id LIMIT 1)", fk.RefColumn, fk.RefTable), false, false, true
+}
+
+// foreignKeyViolationValue returns a value with no parent row: an id past the
+// parent's ids, or a fresh literal that is not a recorded parent key.
+func (g *Generator) foreignKeyViolationValue(parent schema.Table, col schema.Column, fk schema.ForeignKey) (string, bool) {
+	if fk.RefColumn == "id" {
+		return fmt.Sprintf("%d", max(parent.NextID, 1)+g.Rand.Int63n(1000)), true
+	}
+	for i := 0; i < ForeignKeyViolationTries; i++ {
+		lit := g.literalForColumn(col)
+		if lit.Value == nil {
+			continue
+		}
+		value := g.exprSQL(lit)
+		if !g.State.HasKey(parent.Name, fk.RefColumn, value) {
+			return value, true
 		}
-		return "", false, false
 	}
-	// For non-id references, pick an existing parent value.
-	return fmt.Sprintf("(SELECT %s FROM %s ORDER BY id LIMIT 1)", fk.RefColumn, fk.RefTable), false, true
+	return "", false
 }
@@ -1,6 +1,7 @@
 package generator
 
 import (
+	"strconv"
 	"strings"
 	"testing"
 
@@ -116,3 +117,134 @@ func newDMLFKTestGenerator(t *testing.T, state *schema.State) *Generator {
 	}
 	return New(cfg, state, 21)
 }
+
+func TestAddForeignKeySQLRendersActions(t *testing.T) {
+	state := &schema.State{
+		Tables: []schema.Table{
+			{Name: "t0", HasPK: true, NextID: 3, Columns: []schema.Column{{Name: "id", Type: schema.TypeBigInt}}},
+			{Name: "t1", HasPK: true, NextID: 2, Columns: []schema.Column{{Name: "id", Type: schema.TypeBigInt}}},
+		},
+	}
+	gen := newDMLFKTestGenerator(t, state)
+	withAction := 0
+	for i := 0; i < 50; i++ {
+		sql, fk := gen.AddForeignKeySQL(state)
+		if fk == nil {
+			continue
+		}
+		if !strings.HasSuffix(sql, fk.ActionSQL()) {
+			t.Fatalf("foreign key SQL %q does not end with %q", sql, fk.ActionSQL())
+		}
+		if fk.OnDelete == schema.FKActionSetNull || fk.OnUpdate == schema.FKActionSetNull {
+			t.Fatalf("unexpected SET NULL on NOT NULL child column: %s", sql)
+		}
+		if fk.HasAction() {
+			withAction++
+		}
+	}
+	if withAction == 0 {
+		t.Fatalf("expected some foreign keys with referential actions")
+	}
+}
+
+func TestInsertSQLDrawsForeignKeysFromParentKeys(t *testing.T) {
+	state := &schema.State{
+		Tables: []schema.Table{
+			{
+				Name:   "t0",
+				HasPK:  true,
+				NextID: 1,
+				Columns: []schema.Column{
+					{Name: "id", Type: schema.TypeBigInt},
+					{Name: "c0", Type: schema.TypeVarchar, Nullable: true},
+				},
+			},
+			{
+				Name:   "t1",
+				HasPK:  true,
+				NextID: 1,
+				Columns: []schema.Column{
+					{Name: "id", Type: schema.TypeBigInt},
+					{Name: "c0", Type: schema.TypeBigInt},
+					{Name: "c1", Type: schema.TypeVarchar},
+				},
+				ForeignKeys: []schema.ForeignKey{
+					{Name: "fk_4", Table: "t1", Column: "c0", RefTable: "t0", RefColumn: "id"},
+					{Name: "fk_5", Table: "t1", Column: "c1", RefTable: "t0", RefColumn: "c0"},
+				},
+			},
+		},
+	}
+	gen := newDMLFKTestGenerator(t, state)
+	for i := 0; i < 5; i++ {
+		gen.InsertSQL(&state.Tables[0])
+	}
+	parentIDs := state.Tables[0].NextID - 1
+	ids := map[string]bool{}
+	for i := 0; i < 20; i++ {
+		for _, row := range insertRows(t, gen.InsertSQL(&state.Tables[1])) {
+			id, err := strconv.ParseInt(row[1], 10, 64)
+			if err != nil || id < 1 || id > parentIDs {
+				t.Fatalf("c0=%s is not a parent id in [1,%d]", row[1], parentIDs)
+			}
+			ids[row[1]] = true
+			if !state.HasKey("t0", "c0", row[2]) {
+				t.Fatalf("c1=%s is not a recorded parent key", row[2])
+			}
+		}
+	}
+	if len(ids) < 2 {
+		t.Fatalf("expected child rows spread over parent ids, got %v", ids)
+	}
+}
+
+func TestInsertSQLForeignKeyViolation(t *testing.T) {
+	state := &schema.State{
+		Tables: []schema.Table{
+			{Name: "t0", HasPK: true, NextID: 4, Columns: []schema.Column{{Name: "id", Type: schema.TypeBigInt}}},
+			{
+				Name:   "t1",
+				HasPK:  true,
+				NextID: 1,
+				Columns: []schema.Column{
+					{Name: "id", Type: schema.TypeBigInt},
+					{Name: "c0", Type: schema.TypeBigInt},
+				},
+				ForeignKeys: []schema.ForeignKey{
+					{Name: "fk_6", Table: "t1", Column: "c0", RefTable: "t0", RefColumn: "id"},
+				},
+			},
+		},
+	}
+	gen := newDMLFKTestGenerator(t, state)
+	gen.Config.Features.ForeignKeyViolationProb = 100
+	for i := 0; i < 10; i++ {
+		missing := 0
+		for _, row := range insertRows(t, gen.InsertSQL(&state.Tables[1])) {
+			if id, err := strconv.ParseInt(row[1], 10, 64); err == nil && id >= state.Tables[0].NextID {
+				missing++
+			}
+		}
+		if missing != 1 {
+			t.Fatalf("expected exactly one row with a missing parent key, got %d", missing)
+		}
+	}
+}
+
+// insertRows splits the VALUES rows of a generated INSERT into their items.
+func insertRows(t *testing.T, sql string) [][]string {
+	t.Helper()
+	_, values, ok := strings.Cut(sql, " VALUES (")
+	if !ok {
+		t.Fatalf("unexpected insert SQL: %s", sql)
+	}
+	var rows [][]string
+	for _, row := range strings.Split(strings.TrimSuffix(values, ")"), "), (") {
+		items := strings.Split(row, ", ")
+		for i := range items {
+			items[i] = strings.TrimSpace(items[i])
+		}
+		rows = append(rows, items)
+	}
+	return rows
+}
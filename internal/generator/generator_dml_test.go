@@ -0,0 +1,112 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"shiro/internal/config"
+	"shiro/internal/schema"
+)
+
+func TestInsertSQLHonorsBatchRows(t *testing.T) {
+	gen := New(config.Config{}, &schema.State{}, 1)
+	if got := gen.insertRowCountMax(); got != InsertRowCountMax {
+		t.Fatalf("default insert row cap = %d, want %d", got, InsertRowCountMax)
+	}
+	gen.Config.InsertBatchRows = 1
+	tbl := &schema.Table{Name: "t0", HasPK: true, NextID: 1, Columns: []schema.Column{{Name: "id", Type: schema.TypeBigInt}}}
+	for i := 0; i < 10; i++ {
+		gen.InsertSQL(tbl)
+	}
+	if tbl.NextID != 11 {
+		t.Fatalf("insert_batch_rows=1 should insert one row per statement, next id %d", tbl.NextID)
+	}
+}
+
+func TestInsertRowsSQLMixesNullAndDefaultRows(t *testing.T) {
+	gen := New(config.Config{InsertNullRowProb: 50, InsertDefaultRowProb: 100}, &schema.State{}, 1)
+	tbl := &schema.Table{
+		Name:       "t0",
+		HasPK:      true,
+		PrimaryKey: []string{"id", "c0"},
+		NextID:     1,
+		Columns: []schema.Column{
+			{Name: "id", Type: schema.TypeBigInt},
+			{Name: "c0", Type: schema.TypeInt, Nullable: true},
+			{Name: "c1", Type: schema.TypeInt, Nullable: true},
+			{Name: "c2", Type: schema.TypeInt},
+		},
+	}
+	sql, rows := gen.InsertRowsSQL(tbl, 20)
+	if rows != 20 || tbl.NextID != 21 {
+		t.Fatalf("expected 20 rows, got %d (next id %d): %s", rows, tbl.NextID, sql)
+	}
+	if !strings.Contains(sql, ", NULL, ") || !strings.Contains(sql, ", DEFAULT, ") {
+		t.Fatalf("expected NULL and DEFAULT rows: %s", sql)
+	}
+	if strings.Contains(sql, "NULL)") || strings.Contains(sql, "DEFAULT)") || strings.Contains(sql, "(1, NULL") {
+		t.Fatalf("key and NOT NULL columns must keep generated values: %s", sql)
+	}
+	if single, _ := gen.InsertRowsSQL(tbl, 1); strings.Contains(single, "DEFAULT") || strings.Contains(single, "NULL") {
+		t.Fatalf("single-row inserts keep generated values: %s", single)
+	}
+}
+
+func TestDMLOrderLimitUsesPrimaryKey(t *testing.T) {
+	tbl := schema.Table{
+		Name:       "t0",
+		HasPK:      true,
+		PrimaryKey: []string{"id", "c0"},
+		Columns: []schema.Column{
+			{Name: "id", Type: schema.TypeBigInt},
+			{Name: "c0", Type: schema.TypeInt},
+			{Name: "c1", Type: schema.TypeInt, Nullable: true},
+		},
+	}
+	state := &schema.State{Tables: []schema.Table{tbl}}
+	gen := newDMLFKTestGenerator(t, state)
+	limited := 0
+	for i := 0; i < 200; i++ {
+		var sql string
+		if i%2 == 0 {
+			sql, _, _, _ = gen.UpdateSQL(tbl)
+		} else {
+			sql, _ = gen.DeleteSQL(tbl)
+		}
+		tail := gen.LastDMLOrderLimit
+		if tail == nil {
+			if strings.Contains(sql, " LIMIT ") {
+				t.Fatalf("unexpected limit without a recorded tail: %s", sql)
+			}
+			continue
+		}
+		limited++
+		if tail.Limit < 1 || tail.Limit > DMLLimitMax || strings.Join(tail.OrderBy, ",") != "id,c0" {
+			t.Fatalf("unexpected tail: %+v", tail)
+		}
+		if !strings.HasSuffix(sql, tail.SQL()) {
+			t.Fatalf("statement %q does not end with %q", sql, tail.SQL())
+		}
+	}
+	if limited == 0 {
+		t.Fatalf("expected some ORDER BY ... LIMIT statements")
+	}
+
+	tbl.HasPK = false
+	for i := 0; i < 50; i++ {
+		if sql, _ := gen.DeleteSQL(tbl); gen.LastDMLOrderLimit != nil || strings.Contains(sql, " LIMIT ") {
+			t.Fatalf("table without primary key got a limit: %s", sql)
+		}
+	}
+}
+
+func TestDMLOrderLimitSQL(t *testing.T) {
+	var none *DMLOrderLimit
+	if got := none.SQL(); got != "" {
+		t.Fatalf("nil tail rendered %q", got)
+	}
+	tail := &DMLOrderLimit{OrderBy: []string{"id", "c0"}, Desc: true, Limit: 3}
+	if got := tail.SQL(); got != " ORDER BY id DESC, c0 DESC LIMIT 3" {
+		t.Fatalf("unexpected tail SQL: %q", got)
+	}
+}
@@ -3,6 +3,7 @@ package generator
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"shiro/internal/schema"
 	"shiro/internal/util"
@@ -118,6 +119,9 @@ func (g *Generator) exprType(expr Expr) (schema.ColumnType, bool) {
 			return 0, false
 		}
 	case FuncExpr:
+		if strings.EqualFold(v.Name, "NULLIF") && len(v.Args) > 0 {
+			return g.exprType(v.Args[0])
+		}
 		if g.isNumericFunc(v.Name) {
 			return schema.TypeInt, true
 		}
@@ -127,6 +131,11 @@ func (g *Generator) exprType(expr Expr) (schema.ColumnType, bool) {
 			return 0, false
 		}
 		return g.exprType(v.Expr)
+	case NameRefExpr:
+		if v.Expr == nil {
+			return 0, false
+		}
+		return g.exprType(v.Expr)
 	default:
 		return 0, false
 	}
@@ -482,6 +491,9 @@ func (g *Generator) randomColumnType() schema.ColumnType {
 		schema.TypeTimestamp,
 		schema.TypeBool,
 	}
+	if g.Config.Features.ExtendedTypes {
+		types = append(types, schema.TypeBit, schema.TypeEnum, schema.TypeSet, schema.TypeYear)
+	}
 	return types[g.Rand.Intn(len(types))]
 }
 
@@ -512,6 +524,9 @@ func (g *Generator) isNumericType(t schema.ColumnType) bool {
 }
 
 func (g *Generator) pickComparison() string {
+	if op, ok := g.selectivityComparison(); ok {
+		return op
+	}
 	ops := []string{"=", "<", ">", "<=", ">=", "!=", "<=>"}
 	return ops[g.Rand.Intn(len(ops))]
 }
@@ -612,11 +627,32 @@ func (g *Generator) literalForColumn(col schema.Column) LiteralExpr {
 			return LiteralExpr{Value: 1}
 		}
 		return LiteralExpr{Value: 0}
+	case schema.TypeBit:
+		return LiteralExpr{Value: g.Rand.Intn(1 << schema.BitWidth)}
+	case schema.TypeYear:
+		return LiteralExpr{Value: util.RandIntRange(g.Rand, YearLiteralMin, YearLiteralMax)}
+	case schema.TypeEnum:
+		return LiteralExpr{Value: schema.EnumMembers[g.Rand.Intn(len(schema.EnumMembers))]}
+	case schema.TypeSet:
+		return LiteralExpr{Value: g.setLiteral()}
 	default:
 		return LiteralExpr{Value: g.Rand.Intn(SmallIntLiteralMax)}
 	}
 }
 
+// setLiteral picks a non-empty member subset. Members are emitted in
+// definition order, which is how the server renders SET values.
+func (g *Generator) setLiteral() string {
+	mask := g.Rand.Intn(1<<len(schema.SetMembers)-1) + 1
+	members := make([]string, 0, len(schema.SetMembers))
+	for i, m := range schema.SetMembers {
+		if mask&(1<<i) != 0 {
+			members = append(members, m)
+		}
+	}
+	return strings.Join(members, ",")
+}
+
 // orderedArgs expects comparable values of the same type and returns them ordered.
 // If types differ, it returns inputs unchanged.
 func orderedArgs(a, b any) (left any, right any) {
@@ -641,6 +677,11 @@ func orderedArgs(a, b any) (left any, right any) {
 		if ok && v > vb {
 			return vb, v
 		}
+	case time.Time:
+		vb, ok := b.(time.Time)
+		if ok && v.After(vb) {
+			return vb, v
+		}
 	}
 	return a, b
 }
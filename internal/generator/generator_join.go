@@ -2580,9 +2580,13 @@ func (g *Generator) naturalJoinAllowed(left []schema.Table, right schema.Table)
 
 func (g *Generator) joinCondition(left []schema.Table, right schema.Table) Expr {
 	if l, r, ok := g.pickJoinColumnPair(left, right); ok {
-		eq := BinaryExpr{Left: ColumnExpr{Ref: l}, Op: "=", Right: ColumnExpr{Ref: r}}
+		eq := Expr(BinaryExpr{Left: ColumnExpr{Ref: l}, Op: "=", Right: ColumnExpr{Ref: r}})
 		policy := strings.ToLower(strings.TrimSpace(g.Config.Oracles.JoinOnPolicy))
 		if policy == "complex" {
+			// The simple policy keeps plain equi-joins for ground-truth checks.
+			if util.Chance(g.Rand, g.nullAwareProb()) {
+				eq = g.nullAwareJoinKey(l, r)
+			}
 			tables := append([]schema.Table{}, left...)
 			tables = append(tables, right)
 			extra := g.GeneratePredicate(tables, 1, false, 0)
@@ -2887,6 +2891,14 @@ func (g *Generator) maybeEmulateFullJoin(query *SelectQuery) {
 	g.fullJoinEmulationReject = ""
 }
 
+// EmulateFullJoin rewrites a one-join query in place into the FULL OUTER JOIN
+// emulation the generator emits: the LEFT JOIN arm UNION ALL the RIGHT JOIN
+// arm filtered to rows without a left match. It returns the reject reason when
+// the query cannot be rewritten.
+func (g *Generator) EmulateFullJoin(query *SelectQuery) (bool, string) {
+	return g.applyFullJoinEmulationWithReason(query)
+}
+
 func (g *Generator) applyFullJoinEmulation(query *SelectQuery) bool {
 	ok, _ := g.applyFullJoinEmulationWithReason(query)
 	return ok
@@ -59,6 +59,9 @@ func (g *Generator) GeneratePredicate(tables []schema.Table, depth int, allowSub
 		g.subqueryFailed++
 	}
 	if depth <= 0 {
+		if expr, ok := g.maybeNullAwarePredicate(tables, false); ok {
+			return expr
+		}
 		left, right := g.generateComparablePair(tables, allowSubquery, subqDepth)
 		return BinaryExpr{Left: left, Op: g.pickComparison(), Right: right}
 	}
@@ -77,13 +80,16 @@ func (g *Generator) GeneratePredicate(tables []schema.Table, depth int, allowSub
 	}
 	choice := g.Rand.Intn(3)
 	if choice == 0 {
+		if expr, ok := g.maybeNullAwarePredicate(tables, false); ok {
+			return expr
+		}
 		left, right := g.generateComparablePair(tables, allowSubquery, subqDepth)
 		return BinaryExpr{Left: left, Op: g.pickComparison(), Right: right}
 	}
 	left := g.GeneratePredicate(tables, depth-1, allowSubquery, subqDepth)
 	right := g.GeneratePredicate(tables, depth-1, allowSubquery, subqDepth)
 	op := "AND"
-	if util.Chance(g.Rand, PredicateOrProb) {
+	if util.Chance(g.Rand, g.predicateOrProb()) {
 		op = "OR"
 	}
 	return BinaryExpr{Left: left, Op: op, Right: right}
@@ -92,6 +98,9 @@ func (g *Generator) GeneratePredicate(tables []schema.Table, depth int, allowSub
 // GenerateSimplePredicate builds a deterministic predicate composed of comparisons joined by AND.
 func (g *Generator) GenerateSimplePredicate(tables []schema.Table, depth int) Expr {
 	if depth <= 0 {
+		if expr, ok := g.maybeNullAwarePredicate(tables, true); ok {
+			return expr
+		}
 		left, right := g.generateComparablePair(tables, false, 0)
 		return BinaryExpr{Left: left, Op: g.pickComparison(), Right: right}
 	}
@@ -103,6 +112,9 @@ func (g *Generator) GenerateSimplePredicate(tables []schema.Table, depth int) Ex
 // GenerateSimplePredicateColumns builds an AND-only predicate with column comparisons only.
 func (g *Generator) GenerateSimplePredicateColumns(tables []schema.Table, depth int) Expr {
 	if depth <= 0 {
+		if expr, ok := g.maybeNullAwarePredicate(tables, true); ok {
+			return expr
+		}
 		return g.generateComparableColumnPredicate(tables)
 	}
 	left := g.GenerateSimplePredicateColumns(tables, depth-1)
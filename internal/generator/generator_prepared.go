@@ -3,6 +3,7 @@ package generator
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"shiro/internal/schema"
 	"shiro/internal/util"
@@ -15,14 +16,16 @@ func (g *Generator) GeneratePreparedQuery() PreparedQuery {
 	if len(g.State.Tables) == 0 {
 		return PreparedQuery{}
 	}
-	candidates := make([]func() PreparedQuery, 0, 4)
-	weights := make([]int, 0, 4)
+	candidates := make([]func() PreparedQuery, 0, 8)
+	weights := make([]int, 0, 8)
 	candidates = append(candidates, g.preparedSingleTable)
 	weights = append(weights, 1)
 	candidates = append(candidates, g.preparedJoinQuery)
 	weights = append(weights, 4)
 	candidates = append(candidates, g.preparedAggregateQuery)
 	weights = append(weights, 2)
+	candidates = append(candidates, g.preparedLimitQuery, g.preparedInListQuery, g.preparedTemporalQuery, g.preparedCollationQuery)
+	weights = append(weights, 1, 1, 1, 1)
 	if !g.Config.PlanCacheOnly {
 		candidates = append(candidates, g.preparedCTEQuery)
 		weights = append(weights, 3)
@@ -70,7 +73,7 @@ func (g *Generator) GeneratePreparedArgsForQuery(prev []any, types []schema.Colu
 	}
 	for i := 0; i < len(out); {
 		if i+1 < len(out) && i+1 < len(types) && types[i] == types[i+1] && isOrderableType(types[i]) {
-			a, b := g.orderedArgsForType(types[i])
+			a, b := g.orderedArgsForType(types[i], prev[i])
 			out[i], out[i+1] = a, b
 			i += 2
 			continue
@@ -193,6 +196,131 @@ func (g *Generator) preparedAggregateQuery() PreparedQuery {
 	return PreparedQuery{SQL: query, Args: args, ArgTypes: argTypes}
 }
 
+// preparedLimitQuery binds the LIMIT count. ORDER BY id makes the limited
+// row set deterministic. The LIMIT argument comes last, after an even number
+// of range arguments, so argument mutation never pairs it with a column value.
+func (g *Generator) preparedLimitQuery() PreparedQuery {
+	tbl := g.pickPreparedTable()
+	if !tableHasColumn(tbl, "id") {
+		return PreparedQuery{}
+	}
+	cols := g.collectNonIDColumns(tbl)
+	if len(cols) == 0 {
+		return PreparedQuery{}
+	}
+	col := cols[g.Rand.Intn(len(cols))]
+	arg1 := g.literalForColumn(col).Value
+	arg2 := g.literalForColumn(col).Value
+	arg1, arg2 = g.orderPlanCacheArgs(arg1, arg2)
+	query := fmt.Sprintf("SELECT id, %s FROM %s WHERE %s >= ? AND %s <= ? ORDER BY id LIMIT ?", col.Name, tbl.Name, col.Name, col.Name)
+	return PreparedQuery{
+		SQL:      query,
+		Args:     []any{arg1, arg2, g.Rand.Intn(preparedLimitMax) + 1},
+		ArgTypes: []schema.ColumnType{col.Type, col.Type, schema.TypeInt},
+	}
+}
+
+// preparedInListQuery binds every IN-list element.
+func (g *Generator) preparedInListQuery() PreparedQuery {
+	tbl := g.pickPreparedTable()
+	cols := g.collectNonIDColumns(tbl)
+	if len(cols) == 0 {
+		return PreparedQuery{}
+	}
+	col := cols[g.Rand.Intn(len(cols))]
+	n := util.RandIntRange(g.Rand, preparedInListMin, preparedInListMax)
+	placeholders := make([]string, n)
+	args := make([]any, n)
+	argTypes := make([]schema.ColumnType, n)
+	for i := range placeholders {
+		placeholders[i] = "?"
+		args[i] = g.literalForColumn(col).Value
+		argTypes[i] = col.Type
+	}
+	not := ""
+	if util.Chance(g.Rand, 30) {
+		not = "NOT "
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s %sIN (%s)", col.Name, tbl.Name, col.Name, not, strings.Join(placeholders, ", "))
+	return PreparedQuery{SQL: query, Args: args, ArgTypes: argTypes}
+}
+
+// preparedTemporalQuery binds DATE/DATETIME/TIMESTAMP ranges as time.Time, so
+// the binary protocol sends MYSQL_TYPE_DATETIME instead of a string.
+func (g *Generator) preparedTemporalQuery() PreparedQuery {
+	tbl := g.pickPreparedTable()
+	cols := make([]schema.Column, 0, len(tbl.Columns))
+	for _, col := range tbl.Columns {
+		if isTemporalType(col.Type) {
+			cols = append(cols, col)
+		}
+	}
+	if len(cols) == 0 {
+		return PreparedQuery{}
+	}
+	col := cols[g.Rand.Intn(len(cols))]
+	arg1, arg2 := g.orderPlanCacheArgs(g.temporalArg(col.Type), g.temporalArg(col.Type))
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s >= ? AND %s < ?", col.Name, tbl.Name, col.Name, col.Name)
+	return PreparedQuery{SQL: query, Args: []any{arg1, arg2}, ArgTypes: []schema.ColumnType{col.Type, col.Type}}
+}
+
+// preparedCollationQuery compares a string column with a placeholder under an
+// explicit collation. Arguments may be upper-cased so _ci collations match
+// values that utf8mb4_bin does not.
+func (g *Generator) preparedCollationQuery() PreparedQuery {
+	tbl := g.pickPreparedTable()
+	cols := make([]schema.Column, 0, len(tbl.Columns))
+	for _, col := range tbl.Columns {
+		if col.Type == schema.TypeVarchar {
+			cols = append(cols, col)
+		}
+	}
+	if len(cols) == 0 {
+		return PreparedQuery{}
+	}
+	col := cols[g.Rand.Intn(len(cols))]
+	collation := preparedCollations[g.Rand.Intn(len(preparedCollations))]
+	arg1 := g.stringArg()
+	arg2 := g.stringArg()
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ? COLLATE %s OR %s > ? COLLATE %s", col.Name, tbl.Name, col.Name, collation, col.Name, collation)
+	return PreparedQuery{SQL: query, Args: []any{arg1, arg2}, ArgTypes: []schema.ColumnType{col.Type, col.Type}}
+}
+
+func (g *Generator) stringArg() string {
+	value := g.literalForColumn(schema.Column{Type: schema.TypeVarchar}).Value.(string)
+	if util.Chance(g.Rand, preparedStringCaseProb) {
+		return strings.ToUpper(value)
+	}
+	return value
+}
+
+// temporalArg returns a time.Time literal for a temporal column type.
+func (g *Generator) temporalArg(t schema.ColumnType) time.Time {
+	value, _ := g.literalForColumn(schema.Column{Type: t}).Value.(string)
+	layout := time.DateTime
+	if t == schema.TypeDate {
+		layout = time.DateOnly
+	}
+	parsed, err := time.ParseInLocation(layout, value, time.UTC)
+	if err != nil {
+		return time.Date(DateYearMin, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return parsed
+}
+
+func isTemporalType(t schema.ColumnType) bool {
+	return t == schema.TypeDate || t == schema.TypeDatetime || t == schema.TypeTimestamp
+}
+
+func tableHasColumn(tbl schema.Table, name string) bool {
+	for _, col := range tbl.Columns {
+		if col.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
 func (g *Generator) nonPreparedSingleTable() PreparedQuery {
 	tbl, ok := g.pickNonPartitionedTable()
 	if !ok || len(tbl.Columns) == 0 {
@@ -392,7 +520,13 @@ func (g *Generator) nextArgForType(t schema.ColumnType, prev any) any {
 			return v + float64(g.Rand.Intn(NextArgFloatDeltaMax)+1)
 		}
 		return g.literalForColumn(schema.Column{Type: t}).Value
-	case schema.TypeVarchar, schema.TypeDate, schema.TypeDatetime, schema.TypeTimestamp:
+	case schema.TypeDate, schema.TypeDatetime, schema.TypeTimestamp:
+		// Keep the protocol type of the previous argument.
+		if _, ok := prev.(time.Time); ok {
+			return g.temporalArg(t)
+		}
+		return g.literalForColumn(schema.Column{Type: t}).Value
+	case schema.TypeVarchar, schema.TypeBit, schema.TypeEnum, schema.TypeSet, schema.TypeYear:
 		return g.literalForColumn(schema.Column{Type: t}).Value
 	case schema.TypeBool:
 		if v, ok := prev.(int); ok {
@@ -414,10 +548,13 @@ func (g *Generator) orderPlanCacheArgs(a, b any) (left any, right any) {
 	return orderedArgs(a, b)
 }
 
-func (g *Generator) orderedArgsForType(t schema.ColumnType) (left any, right any) {
+func (g *Generator) orderedArgsForType(t schema.ColumnType, prev any) (left any, right any) {
 	col := schema.Column{Type: t}
 	a := g.literalForColumn(col).Value
 	b := g.literalForColumn(col).Value
+	if _, ok := prev.(time.Time); ok {
+		a, b = g.temporalArg(t), g.temporalArg(t)
+	}
 	a, b = orderedArgs(a, b)
 	for a == b {
 		b = g.nextArgForType(t, b)
@@ -425,6 +562,9 @@ func (g *Generator) orderedArgsForType(t schema.ColumnType) (left any, right any
 			break
 		}
 		b = g.literalForColumn(col).Value
+		if _, ok := prev.(time.Time); ok {
+			b = g.temporalArg(t)
+		}
 		if a != b {
 			break
 		}
@@ -434,7 +574,7 @@ func (g *Generator) orderedArgsForType(t schema.ColumnType) (left any, right any
 
 func isOrderableType(t schema.ColumnType) bool {
 	switch t {
-	case schema.TypeInt, schema.TypeBigInt, schema.TypeFloat, schema.TypeDouble, schema.TypeDecimal:
+	case schema.TypeInt, schema.TypeBigInt, schema.TypeFloat, schema.TypeDouble, schema.TypeDecimal, schema.TypeBit, schema.TypeYear:
 		return true
 	case schema.TypeVarchar, schema.TypeDate, schema.TypeDatetime, schema.TypeTimestamp:
 		return true
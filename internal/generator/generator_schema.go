@@ -36,7 +36,7 @@ func (g *Generator) GenerateTable() schema.Table {
 		partitionCount = g.Rand.Intn(PartitionCountExtraMax) + PartitionCountMin
 	}
 
-	return schema.Table{
+	tbl := schema.Table{
 		Name:           g.NextTableName(),
 		Columns:        cols,
 		Indexes:        indexes,
@@ -45,6 +45,151 @@ func (g *Generator) GenerateTable() schema.Table {
 		Partitioned:    partitioned,
 		PartitionCount: partitionCount,
 	}
+	g.maybeLargeRowTable(&tbl)
+	if g.Config.Features.ClusteredIndex {
+		g.randomizePrimaryKey(&tbl)
+	}
+	return tbl
+}
+
+// maybeLargeRowTable turns weights.features.large_row_prob percent of the
+// generated tables into wide or many-index tables, so every oracle runs over
+// the large rows and index fan-out the small random schema never has.
+// oracles.large_row bounds the column and index counts.
+func (g *Generator) maybeLargeRowTable(tbl *schema.Table) {
+	if !g.Config.Features.LargeRowTables || g.Config.Weights.Features.LargeRowProb <= 0 {
+		return
+	}
+	if !util.Chance(g.Rand, g.Config.Weights.Features.LargeRowProb) {
+		return
+	}
+	limits := g.Config.Oracles.LargeRow
+	if g.Rand.Intn(2) == 0 {
+		g.widenTable(tbl, limits.MaxColumns)
+		return
+	}
+	g.addManyIndexes(tbl, limits.MaxIndexes)
+}
+
+// widenTable adds unindexed columns until the table has up to maxColumns,
+// falling back to INT columns and then stopping once the estimated row size
+// reaches LargeRowBytesMax.
+func (g *Generator) widenTable(tbl *schema.Table, maxColumns int) {
+	if maxColumns <= len(tbl.Columns) {
+		return
+	}
+	target := len(tbl.Columns) + g.Rand.Intn(maxColumns-len(tbl.Columns)) + 1
+	rowBytes := 0
+	for _, col := range tbl.Columns {
+		rowBytes += largeRowColumnBytes(col.Type)
+	}
+	for len(tbl.Columns) < target {
+		colType := g.randomColumnType()
+		if rowBytes+largeRowColumnBytes(colType) > LargeRowBytesMax {
+			colType = schema.TypeInt
+		}
+		if rowBytes+largeRowColumnBytes(colType) > LargeRowBytesMax {
+			return
+		}
+		rowBytes += largeRowColumnBytes(colType)
+		tbl.Columns = append(tbl.Columns, schema.Column{
+			Name:     nextColumnName(*tbl),
+			Type:     colType,
+			Nullable: util.Chance(g.Rand, ColumnNullableProb),
+		})
+	}
+}
+
+// largeRowColumnBytes estimates the bytes a column takes in the row size
+// limit: four bytes per VARCHAR character under utf8mb4, at most eight for
+// the other types.
+func largeRowColumnBytes(t schema.ColumnType) int {
+	if t == schema.TypeVarchar {
+		return 64*4 + 2
+	}
+	return 8
+}
+
+// addManyIndexes indexes single columns and then adds composite indexes until
+// the table has up to maxIndexes secondary indexes.
+func (g *Generator) addManyIndexes(tbl *schema.Table, maxIndexes int) {
+	count := len(tbl.Indexes)
+	for _, col := range tbl.Columns {
+		if col.HasIndex {
+			count++
+		}
+	}
+	if maxIndexes <= count {
+		return
+	}
+	target := count + g.Rand.Intn(maxIndexes-count) + 1
+	for _, i := range g.Rand.Perm(len(tbl.Columns)) {
+		if count >= target {
+			return
+		}
+		if col := &tbl.Columns[i]; col.Name != "id" && !col.HasIndex {
+			col.HasIndex = true
+			count++
+		}
+	}
+	for attempts := 0; count < target && attempts < maxIndexes*2; attempts++ {
+		if idx, ok := g.buildCompositeIndex(tbl); ok {
+			tbl.Indexes = append(tbl.Indexes, idx)
+			count++
+		}
+	}
+}
+
+// randomizePrimaryKey picks CLUSTERED or NONCLUSTERED and sometimes widens
+// the key with a second column, leading with it half of the time so string
+// and temporal common handles are covered. The key keeps id, which makes it
+// unique and keeps PARTITION BY HASH(id) valid.
+func (g *Generator) randomizePrimaryKey(tbl *schema.Table) {
+	tbl.PKClustering = schema.PKNonClustered
+	if util.Chance(g.Rand, g.Config.Weights.Features.ClusteredPKProb) {
+		tbl.PKClustering = schema.PKClustered
+	}
+	if !util.Chance(g.Rand, g.Config.Weights.Features.CompositePKProb) {
+		return
+	}
+	candidates := make([]int, 0, len(tbl.Columns))
+	varchars := make([]int, 0, len(tbl.Columns))
+	for i, col := range tbl.Columns {
+		if col.Name == "id" || !primaryKeyColumnType(col.Type) {
+			continue
+		}
+		candidates = append(candidates, i)
+		if col.Type == schema.TypeVarchar {
+			varchars = append(varchars, i)
+		}
+	}
+	if len(varchars) > 0 && util.Chance(g.Rand, CompositePKStringProb) {
+		candidates = varchars
+	}
+	if len(candidates) == 0 {
+		return
+	}
+	col := &tbl.Columns[candidates[g.Rand.Intn(len(candidates))]]
+	col.Nullable = false
+	if util.Chance(g.Rand, CompositePKLeadingProb) {
+		tbl.PrimaryKey = []string{col.Name, "id"}
+		// Foreign keys reference id, which needs an index of its own once it
+		// is no longer the key prefix.
+		tbl.Columns[0].HasIndex = true
+	} else {
+		tbl.PrimaryKey = []string{"id", col.Name}
+	}
+}
+
+// primaryKeyColumnType reports whether a column type can join a generated
+// primary key. Approximate and set-like types are left out.
+func primaryKeyColumnType(t schema.ColumnType) bool {
+	switch t {
+	case schema.TypeInt, schema.TypeBigInt, schema.TypeDecimal, schema.TypeVarchar, schema.TypeDate, schema.TypeDatetime:
+		return true
+	default:
+		return false
+	}
 }
 
 // CreateTableSQL renders a CREATE TABLE statement for a schema table.
@@ -58,7 +203,7 @@ func (g *Generator) CreateTableSQL(tbl schema.Table) string {
 		parts = append(parts, line)
 	}
 	if tbl.HasPK {
-		parts = append(parts, "PRIMARY KEY (id)")
+		parts = append(parts, tbl.PrimaryKeySQL())
 	}
 	indexKeys := map[string]struct{}{}
 	for _, col := range tbl.Columns {
@@ -244,7 +389,7 @@ func (g *Generator) CreateViewSQL() (string, *schema.Table) {
 	if len(cols) == 0 {
 		return "", nil
 	}
-	view := &schema.Table{Name: viewName, Columns: cols, IsView: true}
+	view := &schema.Table{Name: viewName, Columns: cols, IsView: true, ViewDeterministic: g.ViewDefinitionDeterministic(query)}
 	return fmt.Sprintf("CREATE VIEW %s AS %s", viewName, query.SQLString()), view
 }
 
@@ -325,8 +470,22 @@ func (g *Generator) AddForeignKeySQL(state *schema.State) (string, *schema.Forei
 		RefTable:  parent.Name,
 		RefColumn: parentCol.Name,
 	}
+	if util.Chance(g.Rand, ForeignKeyActionProb) {
+		fk.OnDelete = g.pickForeignKeyAction(childCol)
+	}
+	if util.Chance(g.Rand, ForeignKeyActionProb) {
+		fk.OnUpdate = g.pickForeignKeyAction(childCol)
+	}
 	return fmt.Sprintf(
-		"ALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s(%s)",
-		child.Name, name, childCol.Name, parent.Name, parentCol.Name,
+		"ALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s(%s)%s",
+		child.Name, name, childCol.Name, parent.Name, parentCol.Name, fk.ActionSQL(),
 	), &fk
 }
+
+// pickForeignKeyAction picks CASCADE or, for nullable child columns, SET NULL.
+func (g *Generator) pickForeignKeyAction(childCol schema.Column) string {
+	if childCol.Nullable && g.Rand.Intn(2) == 0 {
+		return schema.FKActionSetNull
+	}
+	return schema.FKActionCascade
+}
@@ -209,6 +209,7 @@ func (g *Generator) GenerateSelectQuery() *SelectQuery {
 	// Current set-operation modeling does not track expression-level ORDER BY/LIMIT.
 	// Keep this normalized so we do not accidentally bind ORDER/LIMIT to one branch.
 	clearSetOperationOrderLimit(query)
+	g.maybeApplyNameRefs(query)
 
 	if !g.validateQueryScope(query) {
 		return nil
@@ -477,6 +478,13 @@ func (g *Generator) setLastFeatures(query *SelectQuery, allowPredicateSubquery b
 	queryFeatures.SubqueryFailed = g.subqueryFailed
 	queryFeatures.FullJoinEmulationAttempted = g.fullJoinEmulationAttempted
 	queryFeatures.FullJoinEmulationRejectReason = g.fullJoinEmulationReject
+	queryFeatures.PredicateNodes = QueryPredicateNodes(query)
+	queryFeatures.SubqueryDepth = QuerySubqueryDepth(query)
+	queryFeatures.SQLLength = len(query.SQLString())
+	queryFeatures.SelectivityTarget = g.selectivityTarget
+	if g.selectivityProbe {
+		queryFeatures.SelectivityMatchSQL, queryFeatures.SelectivityTotalSQL, _ = SelectivityProbeSQL(query)
+	}
 	g.LastFeatures = &queryFeatures
 	g.setQueryAnalysisWithFeatures(query, queryFeatures)
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/pingcap/tidb/pkg/parser"
 	_ "github.com/pingcap/tidb/pkg/types/parser_driver"
@@ -125,6 +126,184 @@ func TestCreateTablePartitionedSQL(t *testing.T) {
 	}
 }
 
+func TestCreateTablePrimaryKeyClustering(t *testing.T) {
+	cfg, err := config.Load("../../config.example.yaml")
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	cfg.Features.ClusteredIndex = true
+	cfg.Weights.Features.CompositePKProb = 100
+	state := schema.State{}
+	gen := New(cfg, &state, 7)
+	p := parser.New()
+	seen := map[string]bool{}
+	for i := 0; i < 50; i++ {
+		tbl := gen.GenerateTable()
+		seen[tbl.PKClustering] = true
+		cols := tbl.PrimaryKeyColumns()
+		if len(cols) == 2 {
+			if cols[0] != "id" && cols[1] != "id" {
+				t.Fatalf("primary key must keep id: %v", cols)
+			}
+			for _, name := range cols {
+				if col, ok := tbl.ColumnByName(name); !ok || col.Nullable {
+					t.Fatalf("primary key column %s missing or nullable", name)
+				}
+			}
+			if cols[0] != "id" && !tbl.Columns[0].HasIndex {
+				t.Fatalf("expected an id index when id does not lead the key")
+			}
+		}
+		sql := gen.CreateTableSQL(tbl)
+		if !strings.Contains(sql, tbl.PrimaryKeySQL()) || !strings.Contains(sql, tbl.PKClustering) {
+			t.Fatalf("missing primary key clause: %s", sql)
+		}
+		if _, _, err := p.Parse(sql, "", ""); err != nil {
+			t.Fatalf("parse failed: %v\nsql=%s", err, sql)
+		}
+	}
+	if !seen[schema.PKClustered] || !seen[schema.PKNonClustered] {
+		t.Fatalf("expected both clustering kinds, got %v", seen)
+	}
+
+	cfg.Features.ClusteredIndex = false
+	tbl := New(cfg, &state, 7).GenerateTable()
+	if got := tbl.PrimaryKeySQL(); got != "PRIMARY KEY (id)" {
+		t.Fatalf("PrimaryKeySQL()=%q with clustered_index disabled", got)
+	}
+}
+
+func TestLargeRowTables(t *testing.T) {
+	cfg, err := config.Load("../../config.example.yaml")
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	cfg.Features.LargeRowTables = true
+	cfg.Weights.Features.LargeRowProb = 100
+	cfg.Features.PartitionTables = false
+	state := schema.State{}
+	gen := New(cfg, &state, 5)
+	p := parser.New()
+	wide, manyIndex := false, false
+	for i := 0; i < 20; i++ {
+		tbl := gen.GenerateTable()
+		rowBytes, indexes := 0, len(tbl.Indexes)
+		for _, col := range tbl.Columns {
+			rowBytes += largeRowColumnBytes(col.Type)
+			if col.HasIndex {
+				indexes++
+			}
+		}
+		if len(tbl.Columns) > cfg.Oracles.LargeRow.MaxColumns || rowBytes > LargeRowBytesMax {
+			t.Fatalf("wide table over its bounds: %d columns, %d bytes", len(tbl.Columns), rowBytes)
+		}
+		if indexes > cfg.Oracles.LargeRow.MaxIndexes {
+			t.Fatalf("too many indexes: %d", indexes)
+		}
+		wide = wide || len(tbl.Columns) > cfg.MaxColumns+1
+		manyIndex = manyIndex || indexes > cfg.MaxColumns
+		if _, _, err := p.Parse(gen.CreateTableSQL(tbl), "", ""); err != nil {
+			t.Fatalf("parse failed: %v", err)
+		}
+	}
+	if !wide || !manyIndex {
+		t.Fatalf("expected both shapes, wide=%t many_index=%t", wide, manyIndex)
+	}
+
+	cfg.Features.LargeRowTables = false
+	tbl := New(cfg, &state, 5).GenerateTable()
+	if len(tbl.Columns) > cfg.MaxColumns {
+		t.Fatalf("large-row tables disabled but got %d columns", len(tbl.Columns))
+	}
+}
+
+func TestGeneratorFork(t *testing.T) {
+	cfg, err := config.Load("../../config.example.yaml")
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	state := schema.State{}
+	gen := New(cfg, &state, 11)
+	for i := 0; i < 2; i++ {
+		tbl := gen.GenerateTable()
+		state.Tables = append(state.Tables, tbl)
+	}
+	gen.recordDateSample("t0", "c0", "2024-01-01")
+	snapshot := schema.State{Tables: append([]schema.Table(nil), state.Tables...)}
+	fork := gen.Fork(&snapshot)
+	if fork.State != &snapshot || fork.Rand == gen.Rand {
+		t.Fatalf("fork must use its own state and random source")
+	}
+	for i := 0; i < 5; i++ {
+		if query := fork.GenerateSelectQuery(); query == nil {
+			t.Fatalf("fork failed to generate a query")
+		}
+	}
+	fork.recordDateSample("t0", "c0", "2025-01-01")
+	if gen.LastFeatures != nil || gen.BuilderStats().Builds != 0 {
+		t.Fatalf("fork leaked query metadata into the parent")
+	}
+	if fork.LastFeatures == nil {
+		t.Fatalf("expected fork query features")
+	}
+	if samples := gen.dateSamples["t0"]["c0"]; len(samples) != 1 {
+		t.Fatalf("fork mutated parent date samples: %v", samples)
+	}
+}
+
+func TestExtendedColumnTypes(t *testing.T) {
+	cfg, err := config.Load("../../config.example.yaml")
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	cfg.Features.ExtendedTypes = true
+	state := schema.State{}
+	gen := New(cfg, &state, 3)
+	tbl := schema.Table{
+		Name: "t0",
+		Columns: []schema.Column{
+			{Name: "id", Type: schema.TypeBigInt},
+			{Name: "c0", Type: schema.TypeBit, HasIndex: true},
+			{Name: "c1", Type: schema.TypeEnum, HasIndex: true},
+			{Name: "c2", Type: schema.TypeSet},
+			{Name: "c3", Type: schema.TypeYear, HasIndex: true},
+		},
+		HasPK:  true,
+		NextID: 1,
+	}
+	p := parser.New()
+	for _, sql := range []string{gen.CreateTableSQL(tbl), gen.InsertSQL(&tbl)} {
+		if _, _, err := p.Parse(sql, "", ""); err != nil {
+			t.Fatalf("parse failed: %v\nsql=%s", err, sql)
+		}
+	}
+	for i := 0; i < 50; i++ {
+		if v := gen.literalForColumn(schema.Column{Type: schema.TypeBit}).Value.(int); v < 0 || v >= 1<<schema.BitWidth {
+			t.Fatalf("bit literal %d out of range", v)
+		}
+		if v := gen.literalForColumn(schema.Column{Type: schema.TypeYear}).Value.(int); v < YearLiteralMin || v > YearLiteralMax {
+			t.Fatalf("year literal %d out of range", v)
+		}
+		set := gen.literalForColumn(schema.Column{Type: schema.TypeSet}).Value.(string)
+		last := -1
+		for _, member := range strings.Split(set, ",") {
+			idx := -1
+			for j, m := range schema.SetMembers {
+				if m == member {
+					idx = j
+				}
+			}
+			if idx <= last {
+				t.Fatalf("set literal %q not in member order", set)
+			}
+			last = idx
+		}
+	}
+	if TypeCategory(schema.TypeEnum) != TypeCategory(schema.TypeVarchar) || TypeCategory(schema.TypeBit) != TypeCategory(schema.TypeInt) {
+		t.Fatalf("unexpected extended type categories")
+	}
+}
+
 func TestNormalizeSelectItemAliases(t *testing.T) {
 	items := []SelectItem{
 		{Expr: LiteralExpr{Value: 1}, Alias: "dup"},
@@ -635,6 +814,63 @@ func TestGenerateNonPreparedPlanCacheQuery(t *testing.T) {
 	}
 }
 
+func TestPreparedPlaceholderShapes(t *testing.T) {
+	cfg, err := config.Load("../../config.example.yaml")
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	cfg.PlanCacheMeaningful = true
+	state := schema.State{
+		Tables: []schema.Table{
+			{
+				Name: "t0",
+				Columns: []schema.Column{
+					{Name: "id", Type: schema.TypeBigInt},
+					{Name: "c0", Type: schema.TypeInt},
+					{Name: "c1", Type: schema.TypeVarchar},
+					{Name: "c2", Type: schema.TypeDate},
+					{Name: "c3", Type: schema.TypeDatetime},
+				},
+			},
+		},
+	}
+	gen := New(cfg, &state, 5)
+	p := parser.New()
+	builders := map[string]func() PreparedQuery{
+		"limit":     gen.preparedLimitQuery,
+		"in_list":   gen.preparedInListQuery,
+		"temporal":  gen.preparedTemporalQuery,
+		"collation": gen.preparedCollationQuery,
+	}
+	for name, build := range builders {
+		for i := 0; i < 20; i++ {
+			pq := build()
+			if pq.SQL == "" {
+				t.Fatalf("%s: expected query", name)
+			}
+			if len(pq.Args) != len(pq.ArgTypes) || strings.Count(pq.SQL, "?") != len(pq.Args) {
+				t.Fatalf("%s: placeholder/arg mismatch: sql=%s args=%v", name, pq.SQL, pq.Args)
+			}
+			if _, _, err := p.Parse(pq.SQL, "", ""); err != nil {
+				t.Fatalf("%s: parse failed: %v\nsql=%s", name, err, pq.SQL)
+			}
+			next := gen.GeneratePreparedArgsForQuery(pq.Args, pq.ArgTypes)
+			switch name {
+			case "limit":
+				if limit, ok := next[2].(int); !ok || limit <= 0 {
+					t.Fatalf("limit arg should stay a positive int: %v", next)
+				}
+			case "temporal":
+				for _, arg := range append(append([]any{}, pq.Args...), next...) {
+					if _, ok := arg.(time.Time); !ok {
+						t.Fatalf("temporal arg should be time.Time: %#v", arg)
+					}
+				}
+			}
+		}
+	}
+}
+
 func TestPreparedCandidateTablesPlanCacheOnlySkipsViews(t *testing.T) {
 	cfg, err := config.Load("../../config.example.yaml")
 	if err != nil {
@@ -736,6 +972,8 @@ func hasNonGroupColumn(expr Expr, groupSet map[string]bool) bool {
 		return !groupSet[exprString(v)]
 	case GroupByOrdinalExpr:
 		return hasNonGroupColumn(v.Expr, groupSet)
+	case NameRefExpr:
+		return hasNonGroupColumn(v.Expr, groupSet)
 	case FuncExpr:
 		if isAggregateFunc(v.Name) {
 			return false
@@ -806,6 +1044,11 @@ func exprString(expr Expr) string {
 			return exprString(v.Expr)
 		}
 	}
+	if v, ok := expr.(NameRefExpr); ok {
+		if v.Expr != nil {
+			return exprString(v.Expr)
+		}
+	}
 	var b SQLBuilder
 	expr.Build(&b)
 	return b.String()
@@ -896,3 +1139,28 @@ func typesCompatible(gen *Generator, left, right Expr) bool {
 func isTimeType(t schema.ColumnType) bool {
 	return t == schema.TypeDate || t == schema.TypeDatetime || t == schema.TypeTimestamp
 }
+
+func TestGeneratorForkCopiesDataProfiles(t *testing.T) {
+	cfg, err := config.Load("../../config.example.yaml")
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	cfg.DataProfiles = []config.DataProfileConfig{{Name: "skew", ZipfS: 1.5, HotValues: 4}}
+	state := schema.State{}
+	gen := New(cfg, &state, 5)
+	col := schema.Column{Name: "c0", Type: schema.TypeInt}
+	parent := gen.tableDataProfile("t0")
+	gen.profileLiteral(parent, col, nil)
+	fork := gen.Fork(&state)
+	forked := fork.tableProfiles["t0"]
+	if forked == nil || forked == parent {
+		t.Fatalf("fork must own a copy of the table profile")
+	}
+	if forked.zipf == parent.zipf {
+		t.Fatalf("fork must draw skew from its own random source")
+	}
+	fork.profileLiteral(forked, schema.Column{Name: "c1", Type: schema.TypeInt}, nil)
+	if _, ok := parent.hot[dataProfileColumn{name: "c1", typ: schema.TypeInt}]; ok {
+		t.Fatalf("fork mutated the parent hot-value pools")
+	}
+}
@@ -0,0 +1,70 @@
+package generator
+
+import (
+	"fmt"
+
+	"shiro/internal/schema"
+	"shiro/internal/util"
+)
+
+const (
+	// inListDupProb is the percent of IN-list items that repeat an earlier
+	// item, so the server has to deduplicate while building ranges.
+	inListDupProb = 5
+	// inListNullProb is the percent of IN lists that carry one NULL item.
+	inListNullProb = 20
+)
+
+// LargeInListPredicate builds `col IN (v1, ..., vn)` over a comparable column
+// of tables, with n literals in random order. Integer and string items spread
+// beyond the usual literal range so most of them are distinct, a few repeat
+// earlier items, and some lists carry a NULL. It reports false when tables
+// have no comparable column.
+func (g *Generator) LargeInListPredicate(tables []schema.Table, n int) (InExpr, bool) {
+	col, ok := g.pickComparableColumn(tables)
+	if !ok || n <= 0 {
+		return InExpr{}, false
+	}
+	items := make([]Expr, 0, n)
+	for len(items) < n {
+		if len(items) > 0 && util.Chance(g.Rand, inListDupProb) {
+			items = append(items, items[g.Rand.Intn(len(items))])
+			continue
+		}
+		items = append(items, g.inListLiteral(col, n))
+	}
+	if util.Chance(g.Rand, inListNullProb) {
+		items[g.Rand.Intn(len(items))] = LiteralExpr{Value: nil}
+	}
+	return InExpr{Left: ColumnExpr{Ref: col}, List: items}, true
+}
+
+// inListLiteral returns one IN-list item for col. Integers and strings are
+// drawn from a range that grows with the list length n, so long lists are
+// not just the same hundred literals repeated.
+func (g *Generator) inListLiteral(col ColumnRef, n int) LiteralExpr {
+	switch col.Type {
+	case schema.TypeInt, schema.TypeBigInt:
+		return LiteralExpr{Value: g.Rand.Intn(NumericLiteralMax+2*n) - n}
+	case schema.TypeVarchar:
+		return LiteralExpr{Value: fmt.Sprintf("s%d", g.Rand.Intn(StringLiteralMax+n))}
+	default:
+		return g.literalForColumnRef(col)
+	}
+}
+
+// ExpandInList rewrites in as the equivalent OR chain
+// `(col = v1) OR (col = v2) OR ...`. Both forms agree under three-valued
+// logic, including NULL items and negation.
+func ExpandInList(in InExpr) Expr {
+	var out Expr
+	for _, item := range in.List {
+		eq := BinaryExpr{Left: in.Left, Op: "=", Right: item}
+		if out == nil {
+			out = eq
+			continue
+		}
+		out = BinaryExpr{Left: out, Op: "OR", Right: eq}
+	}
+	return out
+}
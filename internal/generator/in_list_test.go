@@ -0,0 +1,57 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pingcap/tidb/pkg/parser"
+
+	"shiro/internal/schema"
+)
+
+func TestLargeInListPredicate(t *testing.T) {
+	gen := newTestGenerator(t)
+	tables := []schema.Table{{Name: "t0", Columns: []schema.Column{{Name: "c0", Type: schema.TypeInt}}}}
+	in, ok := gen.LargeInListPredicate(tables, 1000)
+	if !ok || len(in.List) != 1000 {
+		t.Fatalf("expected 1000 items, got ok=%v len=%d", ok, len(in.List))
+	}
+	distinct := make(map[any]struct{})
+	for _, item := range in.List {
+		lit, ok := item.(LiteralExpr)
+		if !ok {
+			t.Fatalf("unexpected item %T", item)
+		}
+		distinct[lit.Value] = struct{}{}
+	}
+	if len(distinct) <= NumericLiteralMax {
+		t.Fatalf("expected the items to spread beyond the literal range, got %d distinct", len(distinct))
+	}
+	if _, ok := gen.LargeInListPredicate(nil, 10); ok {
+		t.Fatalf("expected no predicate without columns")
+	}
+}
+
+func TestExpandInList(t *testing.T) {
+	col := ColumnExpr{Ref: ColumnRef{Table: "t0", Name: "c0", Type: schema.TypeInt}}
+	in := InExpr{Left: col, List: []Expr{LiteralExpr{Value: 1}, LiteralExpr{Value: nil}, LiteralExpr{Value: 1}}}
+	if got := exprString(ExpandInList(in)); got != "(((t0.c0 = 1) OR (t0.c0 = NULL)) OR (t0.c0 = 1))" {
+		t.Fatalf("unexpected OR chain: %s", got)
+	}
+
+	gen := newTestGenerator(t)
+	large, ok := gen.LargeInListPredicate([]schema.Table{{Name: "t0", Columns: []schema.Column{{Name: "c1", Type: schema.TypeVarchar}}}}, 2000)
+	if !ok {
+		t.Fatalf("expected a predicate")
+	}
+	p := parser.New()
+	for _, pred := range []Expr{large, ExpandInList(large)} {
+		sql := "SELECT * FROM t0 WHERE " + exprString(pred)
+		if _, err := p.ParseOneStmt(sql, "", ""); err != nil {
+			t.Fatalf("parse %s...: %v", sql[:min(len(sql), 80)], err)
+		}
+	}
+	if got := strings.Count(exprString(ExpandInList(large)), " OR "); got != 1999 {
+		t.Fatalf("expected 1999 ORs, got %d", got)
+	}
+}
@@ -0,0 +1,297 @@
+package generator
+
+import (
+	"shiro/internal/schema"
+	"shiro/internal/util"
+)
+
+// nameRefOrderByProb is the chance to reuse a select alias for one eligible ORDER BY key.
+const nameRefOrderByProb = 60
+
+// maybeApplyNameRefs makes a finished query lean on server-side name
+// resolution instead of fully qualified references. It unqualifies merged
+// USING columns, renames one select alias to a FROM table name, and reuses
+// select aliases in ORDER BY and HAVING. Every rewrite keeps the result
+// unchanged, so oracles compare the same semantics through hostile names.
+func (g *Generator) maybeApplyNameRefs(query *SelectQuery) {
+	if query == nil || len(query.SetOps) > 0 || !util.Chance(g.Rand, g.aliasAmbiguityProb()) {
+		return
+	}
+	tables := g.scopeTablesForQuery(query)
+	g.bareUsingColumns(query, tables)
+	g.shadowTableNameAlias(query)
+	g.reuseOrderByAliases(query)
+	g.reuseHavingAliases(query, tables)
+}
+
+func (g *Generator) aliasAmbiguityProb() int {
+	if g == nil {
+		return 0
+	}
+	return g.Config.Weights.Features.AliasAmbiguityProb
+}
+
+// bareUsingColumns replaces qualified references to a USING column with the
+// bare merged column. Only the side whose value the merged column carries is
+// rewritten (left for INNER/LEFT, right for RIGHT), and only when exactly the
+// two joined tables own the column, so the bare name cannot be ambiguous.
+func (g *Generator) bareUsingColumns(query *SelectQuery, tables []schema.Table) {
+	if len(query.From.Joins) == 0 {
+		return
+	}
+	owners := make(map[string][]string)
+	for _, tbl := range tables {
+		for _, col := range tbl.Columns {
+			owners[col.Name] = append(owners[col.Name], tbl.Name)
+		}
+	}
+	aliases := selectAliasSet(query.Items)
+	bare := make(map[ColumnRef]struct{})
+	for _, join := range query.From.Joins {
+		if join.Natural || join.Lateral || join.Type == JoinCross {
+			continue
+		}
+		right := join.tableName()
+		for _, col := range joinUsingColumns(join) {
+			// A select alias with the same name wins in ORDER BY and HAVING.
+			if _, ok := aliases[col]; ok {
+				continue
+			}
+			holders := owners[col]
+			if len(holders) != 2 || holders[1] != right {
+				continue
+			}
+			side := holders[0]
+			if join.Type == JoinRight {
+				side = right
+			}
+			bare[ColumnRef{Table: side, Name: col}] = struct{}{}
+		}
+	}
+	if len(bare) == 0 {
+		return
+	}
+	rewriteQueryScopeExprs(query, func(expr Expr) (Expr, bool) {
+		col, ok := expr.(ColumnExpr)
+		if !ok {
+			return nil, false
+		}
+		if _, ok := bare[ColumnRef{Table: col.Ref.Table, Name: col.Ref.Name}]; !ok {
+			return nil, false
+		}
+		return NameRefExpr{Name: col.Ref.Name, Expr: col, Using: true}, true
+	})
+}
+
+// shadowTableNameAlias renames one select item after a table in FROM, e.g.
+// "t0.c1 AS t0". Column aliases and table names live in separate namespaces.
+func (g *Generator) shadowTableNameAlias(query *SelectQuery) {
+	if len(query.Items) == 0 {
+		return
+	}
+	names := []string{query.From.baseName()}
+	for _, join := range query.From.Joins {
+		names = append(names, join.tableName())
+	}
+	name := names[g.Rand.Intn(len(names))]
+	if name == "" {
+		return
+	}
+	if _, ok := selectAliasSet(query.Items)[name]; ok {
+		return
+	}
+	idx := g.Rand.Intn(len(query.Items))
+	if query.Items[idx].Alias == "" {
+		return
+	}
+	query.Items[idx].Alias = name
+}
+
+// reuseOrderByAliases turns ORDER BY ordinals and keys equal to a select
+// expression into the item alias. ORDER BY searches the select list before
+// FROM, so a c<N> alias also shadows the table column of the same name.
+func (g *Generator) reuseOrderByAliases(query *SelectQuery) {
+	for i, ob := range query.OrderBy {
+		if !util.Chance(g.Rand, nameRefOrderByProb) {
+			continue
+		}
+		if ord, ok := OrderByOrdinalIndex(ob.Expr, len(query.Items)); ok {
+			item := query.Items[ord-1]
+			if item.Alias != "" {
+				query.OrderBy[i].Expr = NameRefExpr{Name: item.Alias, Expr: item.Expr}
+			}
+			continue
+		}
+		if item, ok := g.selectItemForExpr(query.Items, ob.Expr); ok {
+			query.OrderBy[i].Expr = NameRefExpr{Name: item.Alias, Expr: item.Expr}
+		}
+	}
+}
+
+// reuseHavingAliases replaces HAVING operands equal to a select expression
+// with the item alias. HAVING searches FROM before the select list, so aliases
+// that collide with a column name are left alone.
+func (g *Generator) reuseHavingAliases(query *SelectQuery, tables []schema.Table) {
+	if query.Having == nil {
+		return
+	}
+	columns := make(map[string]struct{})
+	for _, tbl := range tables {
+		for _, col := range tbl.Columns {
+			columns[col.Name] = struct{}{}
+		}
+	}
+	items := make([]SelectItem, 0, len(query.Items))
+	for _, item := range query.Items {
+		if _, ok := columns[item.Alias]; ok {
+			continue
+		}
+		if _, ok := item.Expr.(WindowExpr); ok {
+			continue
+		}
+		items = append(items, item)
+	}
+	query.Having = rewriteScopeExpr(query.Having, func(expr Expr) (Expr, bool) {
+		item, ok := g.selectItemForExpr(items, expr)
+		if !ok {
+			return nil, false
+		}
+		return NameRefExpr{Name: item.Alias, Expr: item.Expr}, true
+	})
+}
+
+func (g *Generator) selectItemForExpr(items []SelectItem, expr Expr) (SelectItem, bool) {
+	switch expr.(type) {
+	case nil, LiteralExpr, ParamExpr, NameRefExpr:
+		return SelectItem{}, false
+	}
+	sql := g.exprSQL(expr)
+	for _, item := range items {
+		if item.Alias == "" || item.Expr == nil {
+			continue
+		}
+		if g.exprSQL(item.Expr) == sql {
+			return item, true
+		}
+	}
+	return SelectItem{}, false
+}
+
+func selectAliasSet(items []SelectItem) map[string]struct{} {
+	out := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		if item.Alias != "" {
+			out[item.Alias] = struct{}{}
+		}
+	}
+	return out
+}
+
+// QualifyUsingNameRefs restores the qualified column behind every bare USING
+// reference in query. Callers that rewrite USING joins into ON must run it,
+// since the bare name is ambiguous once the columns are no longer merged.
+func QualifyUsingNameRefs(query *SelectQuery) {
+	if query == nil {
+		return
+	}
+	rewriteQueryScopeExprs(query, func(expr Expr) (Expr, bool) {
+		ref, ok := expr.(NameRefExpr)
+		if !ok || !ref.Using {
+			return nil, false
+		}
+		return ref.Expr, true
+	})
+}
+
+// rewriteQueryScopeExprs applies rewriteScopeExpr to every clause that
+// resolves names against the query's own FROM clause.
+func rewriteQueryScopeExprs(query *SelectQuery, fn func(Expr) (Expr, bool)) {
+	for i := range query.Items {
+		query.Items[i].Expr = rewriteScopeExpr(query.Items[i].Expr, fn)
+	}
+	query.Where = rewriteScopeExpr(query.Where, fn)
+	for i := range query.GroupBy {
+		query.GroupBy[i] = rewriteScopeExpr(query.GroupBy[i], fn)
+	}
+	query.Having = rewriteScopeExpr(query.Having, fn)
+	for i := range query.WindowDefs {
+		query.WindowDefs[i].PartitionBy = rewriteScopeExprs(query.WindowDefs[i].PartitionBy, fn)
+		query.WindowDefs[i].OrderBy = rewriteScopeOrderBy(query.WindowDefs[i].OrderBy, fn)
+	}
+	query.OrderBy = rewriteScopeOrderBy(query.OrderBy, fn)
+}
+
+// rewriteScopeExpr applies fn top-down and stops at the first replacement.
+// It does not enter subqueries, whose names resolve against their own FROM.
+// Slices are copied so expressions shared between clauses stay untouched.
+func rewriteScopeExpr(expr Expr, fn func(Expr) (Expr, bool)) Expr {
+	if expr == nil {
+		return nil
+	}
+	if out, ok := fn(expr); ok {
+		return out
+	}
+	switch e := expr.(type) {
+	case UnaryExpr:
+		e.Expr = rewriteScopeExpr(e.Expr, fn)
+		return e
+	case BinaryExpr:
+		e.Left = rewriteScopeExpr(e.Left, fn)
+		e.Right = rewriteScopeExpr(e.Right, fn)
+		return e
+	case FuncExpr:
+		e.Args = rewriteScopeExprs(e.Args, fn)
+		return e
+	case CaseExpr:
+		whens := make([]CaseWhen, len(e.Whens))
+		for i, w := range e.Whens {
+			whens[i] = CaseWhen{When: rewriteScopeExpr(w.When, fn), Then: rewriteScopeExpr(w.Then, fn)}
+		}
+		e.Whens = whens
+		e.Else = rewriteScopeExpr(e.Else, fn)
+		return e
+	case InExpr:
+		e.Left = rewriteScopeExpr(e.Left, fn)
+		e.List = rewriteScopeExprs(e.List, fn)
+		return e
+	case CompareSubqueryExpr:
+		e.Left = rewriteScopeExpr(e.Left, fn)
+		return e
+	case WindowExpr:
+		e.Args = rewriteScopeExprs(e.Args, fn)
+		e.PartitionBy = rewriteScopeExprs(e.PartitionBy, fn)
+		e.OrderBy = rewriteScopeOrderBy(e.OrderBy, fn)
+		return e
+	case GroupByOrdinalExpr:
+		e.Expr = rewriteScopeExpr(e.Expr, fn)
+		return e
+	case NameRefExpr:
+		e.Expr = rewriteScopeExpr(e.Expr, fn)
+		return e
+	default:
+		return expr
+	}
+}
+
+func rewriteScopeExprs(exprs []Expr, fn func(Expr) (Expr, bool)) []Expr {
+	if exprs == nil {
+		return nil
+	}
+	out := make([]Expr, len(exprs))
+	for i, expr := range exprs {
+		out[i] = rewriteScopeExpr(expr, fn)
+	}
+	return out
+}
+
+func rewriteScopeOrderBy(orderBy []OrderBy, fn func(Expr) (Expr, bool)) []OrderBy {
+	if orderBy == nil {
+		return nil
+	}
+	out := make([]OrderBy, len(orderBy))
+	for i, ob := range orderBy {
+		ob.Expr = rewriteScopeExpr(ob.Expr, fn)
+		out[i] = ob
+	}
+	return out
+}
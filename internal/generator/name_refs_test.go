@@ -0,0 +1,134 @@
+package generator
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/pingcap/tidb/pkg/parser"
+
+	"shiro/internal/config"
+	"shiro/internal/schema"
+)
+
+func nameRefTestState() *schema.State {
+	return &schema.State{Tables: []schema.Table{
+		{Name: "t0", Columns: []schema.Column{{Name: "k0", Type: schema.TypeInt}, {Name: "c0", Type: schema.TypeInt}}},
+		{Name: "t1", Columns: []schema.Column{{Name: "k0", Type: schema.TypeInt}, {Name: "c1", Type: schema.TypeInt}}},
+		{Name: "t2", Columns: []schema.Column{{Name: "c2", Type: schema.TypeInt}}},
+	}}
+}
+
+func TestBareUsingColumns(t *testing.T) {
+	gen := &Generator{State: nameRefTestState(), Rand: rand.New(rand.NewSource(1))}
+	for _, tc := range []struct {
+		joinType JoinType
+		side     string
+	}{
+		{JoinInner, "t0"},
+		{JoinLeft, "t0"},
+		{JoinRight, "t1"},
+	} {
+		query := &SelectQuery{
+			From: FromClause{BaseTable: "t0", Joins: []Join{{Type: tc.joinType, Table: "t1", Using: []string{"k0"}}}},
+			Items: []SelectItem{
+				{Expr: ColumnExpr{Ref: ColumnRef{Table: tc.side, Name: "k0"}}, Alias: "c0"},
+				{Expr: ColumnExpr{Ref: ColumnRef{Table: "t0", Name: "c0"}}, Alias: "c1"},
+			},
+			Where: BinaryExpr{Left: ColumnExpr{Ref: ColumnRef{Table: tc.side, Name: "k0"}}, Op: ">", Right: LiteralExpr{Value: 1}},
+		}
+		if gen.validateQueryScope(query) {
+			t.Fatalf("%s: qualified USING column should be hidden", tc.joinType)
+		}
+		gen.bareUsingColumns(query, gen.scopeTablesForQuery(query))
+		sql := query.SQLString()
+		if sql != "SELECT k0 AS c0, t0.c0 AS c1 FROM t0 "+string(tc.joinType)+" t1 USING (k0) WHERE (k0 > 1)" {
+			t.Fatalf("%s: unexpected sql: %s", tc.joinType, sql)
+		}
+		if !gen.validateQueryScope(query) || !AnalyzeQueryFeatures(query).HasNameRefs {
+			t.Fatalf("%s: bare USING column should validate and be tracked: %s", tc.joinType, sql)
+		}
+		QualifyUsingNameRefs(query)
+		if got := exprString(query.Where); got != "("+tc.side+".k0 > 1)" {
+			t.Fatalf("%s: expected qualified column back, got %s", tc.joinType, got)
+		}
+	}
+
+	// The null-extended side and columns owned by a third table stay qualified.
+	query := &SelectQuery{
+		From: FromClause{BaseTable: "t0", Joins: []Join{
+			{Type: JoinLeft, Table: "t1", Using: []string{"k0"}},
+			{Type: JoinInner, Table: "t2", On: LiteralExpr{Value: 1}},
+		}},
+		Items: []SelectItem{{Expr: ColumnExpr{Ref: ColumnRef{Table: "t1", Name: "k0"}}, Alias: "c0"}},
+	}
+	gen.bareUsingColumns(query, gen.scopeTablesForQuery(query))
+	if _, ok := query.Items[0].Expr.(ColumnExpr); !ok {
+		t.Fatalf("right side of LEFT JOIN USING must stay qualified: %s", query.SQLString())
+	}
+}
+
+func TestReuseSelectAliases(t *testing.T) {
+	gen := &Generator{State: nameRefTestState(), Rand: rand.New(rand.NewSource(1))}
+	groupKey := ColumnExpr{Ref: ColumnRef{Table: "t0", Name: "c0"}}
+	count := FuncExpr{Name: "COUNT", Args: []Expr{LiteralExpr{Value: 1}}}
+	query := &SelectQuery{
+		From: FromClause{BaseTable: "t0"},
+		Items: []SelectItem{
+			{Expr: groupKey, Alias: "c0"},
+			{Expr: count, Alias: "cnt"},
+		},
+		GroupBy: []Expr{groupKey},
+		Having: BinaryExpr{
+			Left:  BinaryExpr{Left: groupKey, Op: ">", Right: LiteralExpr{Value: 0}},
+			Op:    "AND",
+			Right: BinaryExpr{Left: count, Op: ">", Right: LiteralExpr{Value: 1}},
+		},
+	}
+	gen.reuseHavingAliases(query, gen.scopeTablesForQuery(query))
+	// c0 is also a column name, and HAVING searches FROM first.
+	if got := exprSQLForTest(query.Having); got != "((t0.c0 > 0) AND (cnt > 1))" {
+		t.Fatalf("unexpected having: %s", got)
+	}
+
+	reused := false
+	for i := 0; i < 20 && !reused; i++ {
+		query.OrderBy = []OrderBy{{Expr: LiteralExpr{Value: 2}}, {Expr: groupKey, Desc: true}}
+		gen.reuseOrderByAliases(query)
+		reused = exprSQLForTest(query.OrderBy[0].Expr) == "cnt" && exprSQLForTest(query.OrderBy[1].Expr) == "c0"
+	}
+	if !reused {
+		t.Fatalf("expected ORDER BY to reuse select aliases")
+	}
+}
+
+func TestGenerateSelectQueryNameRefs(t *testing.T) {
+	cfg, err := config.Load("../../config.example.yaml")
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	cfg.Features.Joins = true
+	cfg.Features.Aggregates = true
+	cfg.Features.GroupBy = true
+	cfg.Features.Having = true
+	cfg.Features.OrderBy = true
+	cfg.Oracles.JoinUsingProb = 100
+	cfg.Weights.Features.AliasAmbiguityProb = 100
+	gen := New(cfg, nameRefTestState(), 11)
+	p := parser.New()
+	withRefs := 0
+	for i := 0; i < 300; i++ {
+		query := gen.GenerateSelectQuery()
+		if query == nil {
+			continue
+		}
+		if _, _, err := p.Parse(query.SQLString(), "", ""); err != nil {
+			t.Fatalf("parse failed: %v\nsql=%s", err, query.SQLString())
+		}
+		if AnalyzeQueryFeatures(query).HasNameRefs {
+			withRefs++
+		}
+	}
+	if withRefs == 0 {
+		t.Fatalf("expected generated queries with bare name references")
+	}
+}
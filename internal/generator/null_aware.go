@@ -0,0 +1,218 @@
+package generator
+
+import (
+	"strings"
+
+	"shiro/internal/schema"
+	"shiro/internal/util"
+)
+
+// nullAwareKind enumerates the null-aware predicate shapes.
+type nullAwareKind int
+
+const (
+	nullAwareNullSafeEq nullAwareKind = iota
+	nullAwareDistinctFrom
+	nullAwareIsNullChain
+	nullAwareInListNull
+	nullAwareNullIf
+	nullAwareKindCount
+)
+
+// nullAwareNullOperandProb is the chance that the right side of a <=> leaf is
+// a NULL literal instead of a column or value.
+const nullAwareNullOperandProb = 30
+
+func (g *Generator) nullAwareProb() int {
+	if g == nil {
+		return 0
+	}
+	return g.Config.Weights.Features.NullAwareProb
+}
+
+// maybeNullAwarePredicate returns a NULL-involving comparison leaf with
+// probability weights.features.null_aware_prob. With columnsOnly it keeps to
+// the shapes the simple column predicate mode allows (column pairs and IS
+// [NOT] NULL checks joined by AND), so TLP's predicate guard accepts them.
+func (g *Generator) maybeNullAwarePredicate(tables []schema.Table, columnsOnly bool) (Expr, bool) {
+	if !util.Chance(g.Rand, g.nullAwareProb()) {
+		return nil, false
+	}
+	kinds := nullAwareKindCount
+	if columnsOnly {
+		kinds = nullAwareInListNull
+	}
+	switch nullAwareKind(g.Rand.Intn(int(kinds))) {
+	case nullAwareNullSafeEq:
+		return g.nullSafeComparison(tables, columnsOnly)
+	case nullAwareDistinctFrom:
+		// TiDB has no IS DISTINCT FROM; NOT (a <=> b) is its two-valued form.
+		expr, ok := g.nullSafeComparison(tables, columnsOnly)
+		if !ok {
+			return nil, false
+		}
+		return UnaryExpr{Op: "NOT", Expr: expr}, true
+	case nullAwareIsNullChain:
+		return g.isNullChain(tables, columnsOnly)
+	case nullAwareInListNull:
+		return g.inListWithNull(tables)
+	default:
+		return g.nullIfComparison(tables)
+	}
+}
+
+func (g *Generator) nullSafeComparison(tables []schema.Table, columnsOnly bool) (Expr, bool) {
+	if !columnsOnly && util.Chance(g.Rand, nullAwareNullOperandProb) {
+		col, ok := g.pickNullableColumn(tables)
+		if !ok {
+			return nil, false
+		}
+		return BinaryExpr{Left: ColumnExpr{Ref: col}, Op: "<=>", Right: LiteralExpr{Value: nil}}, true
+	}
+	if left, right, ok := g.pickComparableColumnPair(tables); ok {
+		return BinaryExpr{Left: ColumnExpr{Ref: left}, Op: "<=>", Right: ColumnExpr{Ref: right}}, true
+	}
+	if columnsOnly {
+		return nil, false
+	}
+	col, ok := g.pickNullableColumn(tables)
+	if !ok {
+		return nil, false
+	}
+	return BinaryExpr{Left: ColumnExpr{Ref: col}, Op: "<=>", Right: g.literalForColumnRef(col)}, true
+}
+
+// isNullChain builds "a IS NULL AND b IS NOT NULL"-style chains of one to
+// three checks. The simple column mode only combines them with AND.
+func (g *Generator) isNullChain(tables []schema.Table, columnsOnly bool) (Expr, bool) {
+	var out Expr
+	for i := g.Rand.Intn(3); i >= 0; i-- {
+		col, ok := g.pickNullableColumn(tables)
+		if !ok {
+			break
+		}
+		op := "IS"
+		if util.Chance(g.Rand, 50) {
+			op = "IS NOT"
+		}
+		check := BinaryExpr{Left: ColumnExpr{Ref: col}, Op: op, Right: LiteralExpr{Value: nil}}
+		if out == nil {
+			out = check
+			continue
+		}
+		join := "AND"
+		if !columnsOnly && util.Chance(g.Rand, PredicateOrProb) {
+			join = "OR"
+		}
+		out = BinaryExpr{Left: out, Op: join, Right: check}
+	}
+	return out, out != nil
+}
+
+// inListWithNull puts a NULL into an IN list. "x NOT IN (..., NULL)" is never
+// TRUE, which exercises NOT IN rewrites that assume two-valued logic.
+func (g *Generator) inListWithNull(tables []schema.Table) (Expr, bool) {
+	col, ok := g.pickNullableColumn(tables)
+	if !ok {
+		return nil, false
+	}
+	size := g.Rand.Intn(PredicateInListMax) + 1
+	list := make([]Expr, 0, size+1)
+	for i := 0; i < size; i++ {
+		list = append(list, g.literalForColumnRef(col))
+	}
+	pos := g.Rand.Intn(len(list) + 1)
+	list = append(list[:pos], append([]Expr{LiteralExpr{Value: nil}}, list[pos:]...)...)
+	expr := Expr(InExpr{Left: ColumnExpr{Ref: col}, List: list})
+	if g.Config.Features.NotIn && util.Chance(g.Rand, g.Config.Weights.Features.NotInProb) {
+		return UnaryExpr{Op: "NOT", Expr: expr}, true
+	}
+	return expr, true
+}
+
+// nullIfComparison compares a NULL-producing NULLIF(col, v) with another
+// operand, so rows equal to v drop out of "=" but match "<=> NULL".
+func (g *Generator) nullIfComparison(tables []schema.Table) (Expr, bool) {
+	col, ok := g.pickNullableColumn(tables)
+	if !ok {
+		return nil, false
+	}
+	left := FuncExpr{Name: "NULLIF", Args: []Expr{ColumnExpr{Ref: col}, g.literalForColumnRef(col)}}
+	ops := []string{"=", "<=>", "<", ">="}
+	return BinaryExpr{Left: left, Op: ops[g.Rand.Intn(len(ops))], Right: g.literalForColumnRef(col)}, true
+}
+
+// pickNullableColumn prefers columns declared NULL, where NULL checks can
+// actually match rows, and falls back to any comparable column.
+func (g *Generator) pickNullableColumn(tables []schema.Table) (ColumnRef, bool) {
+	cols := make([]ColumnRef, 0, 8)
+	for _, tbl := range tables {
+		for _, col := range tbl.Columns {
+			if col.Nullable {
+				cols = append(cols, ColumnRef{Table: tbl.Name, Name: col.Name, Type: col.Type})
+			}
+		}
+	}
+	if len(cols) > 0 {
+		return cols[g.Rand.Intn(len(cols))], true
+	}
+	return g.pickComparableColumn(tables)
+}
+
+// nullAwareJoinKey rewrites an equi-join key into a NULL-involving form:
+// "l <=> r", which also matches NULL keys, or "NULLIF(l, v) = r", whose
+// NULL-producing side drops the rows equal to v.
+func (g *Generator) nullAwareJoinKey(l ColumnRef, r ColumnRef) Expr {
+	if util.Chance(g.Rand, 50) {
+		return BinaryExpr{Left: ColumnExpr{Ref: l}, Op: "<=>", Right: ColumnExpr{Ref: r}}
+	}
+	left := FuncExpr{Name: "NULLIF", Args: []Expr{ColumnExpr{Ref: l}, g.literalForColumnRef(l)}}
+	return BinaryExpr{Left: left, Op: "=", Right: ColumnExpr{Ref: r}}
+}
+
+// NullDensity counts the NULL-involving leaves of expr and its comparison
+// leaves overall. A NULL-involving leaf is a <=>, an IS [NOT] NULL check, an
+// IN list holding NULL, a NULLIF, or a comparison with a NULL literal.
+func NullDensity(expr Expr) (nullLeaves int, leaves int) {
+	switch e := expr.(type) {
+	case nil:
+		return 0, 0
+	case UnaryExpr:
+		return NullDensity(e.Expr)
+	case BinaryExpr:
+		switch strings.ToUpper(strings.TrimSpace(e.Op)) {
+		case "AND", "OR", "XOR":
+			ln, ll := NullDensity(e.Left)
+			rn, rl := NullDensity(e.Right)
+			return ln + rn, ll + rl
+		case "<=>", "IS", "IS NOT":
+			return 1, 1
+		}
+		if isNullLiteralExpr(e.Left) || isNullLiteralExpr(e.Right) || hasNullIf(e.Left) || hasNullIf(e.Right) {
+			return 1, 1
+		}
+		return 0, 1
+	case InExpr:
+		for _, item := range e.List {
+			if isNullLiteralExpr(item) {
+				return 1, 1
+			}
+		}
+		if hasNullIf(e.Left) {
+			return 1, 1
+		}
+		return 0, 1
+	default:
+		return 0, 1
+	}
+}
+
+func isNullLiteralExpr(expr Expr) bool {
+	lit, ok := expr.(LiteralExpr)
+	return ok && lit.Value == nil
+}
+
+func hasNullIf(expr Expr) bool {
+	fn, ok := expr.(FuncExpr)
+	return ok && strings.EqualFold(fn.Name, "NULLIF")
+}
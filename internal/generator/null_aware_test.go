@@ -0,0 +1,112 @@
+package generator
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/pingcap/tidb/pkg/parser"
+
+	"shiro/internal/config"
+	"shiro/internal/schema"
+)
+
+func TestNullDensity(t *testing.T) {
+	a := ColumnExpr{Ref: ColumnRef{Table: "t0", Name: "c0", Type: schema.TypeInt}}
+	b := ColumnExpr{Ref: ColumnRef{Table: "t0", Name: "c1", Type: schema.TypeInt}}
+	expr := BinaryExpr{
+		Left: BinaryExpr{
+			Left:  UnaryExpr{Op: "NOT", Expr: BinaryExpr{Left: a, Op: "<=>", Right: b}},
+			Op:    "AND",
+			Right: BinaryExpr{Left: a, Op: "IS NOT", Right: LiteralExpr{Value: nil}},
+		},
+		Op: "OR",
+		Right: BinaryExpr{
+			Left:  InExpr{Left: a, List: []Expr{LiteralExpr{Value: 1}, LiteralExpr{Value: nil}}},
+			Op:    "AND",
+			Right: BinaryExpr{Left: a, Op: ">", Right: b},
+		},
+	}
+	if nulls, leaves := NullDensity(expr); nulls != 3 || leaves != 4 {
+		t.Fatalf("unexpected density %d/%d", nulls, leaves)
+	}
+	nullIf := BinaryExpr{Left: FuncExpr{Name: "NULLIF", Args: []Expr{a, LiteralExpr{Value: 1}}}, Op: "=", Right: b}
+	if nulls, leaves := NullDensity(nullIf); nulls != 1 || leaves != 1 {
+		t.Fatalf("NULLIF comparison should count as null-aware: %d/%d", nulls, leaves)
+	}
+}
+
+func TestNullAwarePredicateColumnsOnly(t *testing.T) {
+	cfg := config.Config{}
+	cfg.Weights.Features.NullAwareProb = 100
+	gen := &Generator{Config: cfg, State: nameRefTestState(), Rand: rand.New(rand.NewSource(3))}
+	tables := gen.State.Tables
+	for i := 0; i < 200; i++ {
+		expr, ok := gen.maybeNullAwarePredicate(tables, true)
+		if !ok {
+			continue
+		}
+		if nulls, _ := NullDensity(expr); nulls == 0 {
+			t.Fatalf("expected a null-aware leaf: %s", exprSQLForTest(expr))
+		}
+		assertColumnsOnly(t, expr)
+	}
+}
+
+func assertColumnsOnly(t *testing.T, expr Expr) {
+	t.Helper()
+	switch e := expr.(type) {
+	case UnaryExpr:
+		assertColumnsOnly(t, e.Expr)
+	case BinaryExpr:
+		switch e.Op {
+		case "AND":
+			assertColumnsOnly(t, e.Left)
+			assertColumnsOnly(t, e.Right)
+		case "<=>":
+			if _, ok := e.Right.(ColumnExpr); !ok {
+				t.Fatalf("columns-only <=> must compare columns: %s", exprSQLForTest(e))
+			}
+		case "IS", "IS NOT":
+			if _, ok := e.Left.(ColumnExpr); !ok || !isNullLiteralExpr(e.Right) {
+				t.Fatalf("unexpected null check: %s", exprSQLForTest(e))
+			}
+		default:
+			t.Fatalf("unexpected columns-only op %q: %s", e.Op, exprSQLForTest(e))
+		}
+	default:
+		t.Fatalf("unexpected columns-only leaf: %s", exprSQLForTest(expr))
+	}
+}
+
+func TestGenerateSelectQueryNullAware(t *testing.T) {
+	cfg, err := config.Load("../../config.example.yaml")
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	cfg.Features.Joins = true
+	cfg.Weights.Features.NullAwareProb = 100
+	state := nameRefTestState()
+	for i := range state.Tables {
+		for j := range state.Tables[i].Columns {
+			state.Tables[i].Columns[j].Nullable = true
+		}
+	}
+	gen := New(cfg, state, 5)
+	p := parser.New()
+	nullAware := 0
+	for i := 0; i < 200; i++ {
+		query := gen.GenerateSelectQuery()
+		if query == nil {
+			continue
+		}
+		if _, _, err := p.Parse(query.SQLString(), "", ""); err != nil {
+			t.Fatalf("parse failed: %v\nsql=%s", err, query.SQLString())
+		}
+		if AnalyzeQueryFeatures(query).NullPredicates > 0 {
+			nullAware++
+		}
+	}
+	if nullAware == 0 {
+		t.Fatalf("expected generated queries with null-aware predicates")
+	}
+}
@@ -0,0 +1,168 @@
+package generator
+
+// QueryPredicateNodes counts the expression nodes of the WHERE and HAVING
+// clauses and the join conditions of the top-level query. A subquery counts
+// as one node; its own predicates are not included.
+func QueryPredicateNodes(query *SelectQuery) int {
+	if query == nil {
+		return 0
+	}
+	total := exprNodeCount(query.Where) + exprNodeCount(query.Having)
+	for _, join := range query.From.Joins {
+		total += exprNodeCount(join.On)
+	}
+	return total
+}
+
+func exprNodeCount(expr Expr) int {
+	switch e := expr.(type) {
+	case nil:
+		return 0
+	case GroupByOrdinalExpr:
+		return 1 + exprNodeCount(e.Expr)
+	case NameRefExpr:
+		return 1 + exprNodeCount(e.Expr)
+	case UnaryExpr:
+		return 1 + exprNodeCount(e.Expr)
+	case BinaryExpr:
+		return 1 + exprNodeCount(e.Left) + exprNodeCount(e.Right)
+	case FuncExpr:
+		return 1 + exprListNodeCount(e.Args)
+	case CaseExpr:
+		total := 1 + exprNodeCount(e.Else)
+		for _, w := range e.Whens {
+			total += exprNodeCount(w.When) + exprNodeCount(w.Then)
+		}
+		return total
+	case InExpr:
+		return 1 + exprNodeCount(e.Left) + exprListNodeCount(e.List)
+	case *InExpr:
+		if e == nil {
+			return 0
+		}
+		return exprNodeCount(*e)
+	case CompareSubqueryExpr:
+		return 2 + exprNodeCount(e.Left)
+	case *CompareSubqueryExpr:
+		if e == nil {
+			return 0
+		}
+		return exprNodeCount(*e)
+	case WindowExpr:
+		return 1 + exprListNodeCount(e.Args) + exprListNodeCount(e.PartitionBy)
+	case *WindowExpr:
+		if e == nil {
+			return 0
+		}
+		return exprNodeCount(*e)
+	default:
+		return 1
+	}
+}
+
+func exprListNodeCount(exprs []Expr) int {
+	total := 0
+	for _, expr := range exprs {
+		total += exprNodeCount(expr)
+	}
+	return total
+}
+
+// QuerySubqueryDepth returns the deepest nesting of queries inside query:
+// expression subqueries, derived tables, and CTE bodies each add one level,
+// set operation branches do not. A query without subqueries has depth 0.
+func QuerySubqueryDepth(query *SelectQuery) int {
+	if query == nil {
+		return 0
+	}
+	depth := 0
+	nested := func(q *SelectQuery) {
+		if q != nil {
+			depth = max(depth, 1+QuerySubqueryDepth(q))
+		}
+	}
+	for _, cte := range query.With {
+		nested(cte.Query)
+	}
+	nested(query.From.BaseQuery)
+	for _, join := range query.From.Joins {
+		nested(join.TableQuery)
+		depth = max(depth, exprSubqueryDepth(join.On))
+	}
+	for _, item := range query.Items {
+		depth = max(depth, exprSubqueryDepth(item.Expr))
+	}
+	depth = max(depth, exprSubqueryDepth(query.Where), exprSubqueryDepth(query.Having))
+	for _, op := range query.SetOps {
+		depth = max(depth, QuerySubqueryDepth(op.Query))
+	}
+	return depth
+}
+
+func exprSubqueryDepth(expr Expr) int {
+	switch e := expr.(type) {
+	case nil:
+		return 0
+	case SubqueryExpr:
+		return nestedQueryDepth(e.Query)
+	case *SubqueryExpr:
+		if e == nil {
+			return 0
+		}
+		return nestedQueryDepth(e.Query)
+	case ExistsExpr:
+		return nestedQueryDepth(e.Query)
+	case *ExistsExpr:
+		if e == nil {
+			return 0
+		}
+		return nestedQueryDepth(e.Query)
+	case CompareSubqueryExpr:
+		return max(exprSubqueryDepth(e.Left), nestedQueryDepth(e.Query))
+	case *CompareSubqueryExpr:
+		if e == nil {
+			return 0
+		}
+		return exprSubqueryDepth(*e)
+	case InExpr:
+		return max(exprSubqueryDepth(e.Left), exprListSubqueryDepth(e.List))
+	case *InExpr:
+		if e == nil {
+			return 0
+		}
+		return exprSubqueryDepth(*e)
+	case GroupByOrdinalExpr:
+		return exprSubqueryDepth(e.Expr)
+	case NameRefExpr:
+		return exprSubqueryDepth(e.Expr)
+	case UnaryExpr:
+		return exprSubqueryDepth(e.Expr)
+	case BinaryExpr:
+		return max(exprSubqueryDepth(e.Left), exprSubqueryDepth(e.Right))
+	case FuncExpr:
+		return exprListSubqueryDepth(e.Args)
+	case CaseExpr:
+		depth := exprSubqueryDepth(e.Else)
+		for _, w := range e.Whens {
+			depth = max(depth, exprSubqueryDepth(w.When), exprSubqueryDepth(w.Then))
+		}
+		return depth
+	default:
+		return 0
+	}
+}
+
+func exprListSubqueryDepth(exprs []Expr) int {
+	depth := 0
+	for _, expr := range exprs {
+		depth = max(depth, exprSubqueryDepth(expr))
+	}
+	return depth
+}
+
+func nestedQueryDepth(query *SelectQuery) int {
+	if query == nil {
+		return 0
+	}
+	return 1 + QuerySubqueryDepth(query)
+}
@@ -0,0 +1,38 @@
+package generator
+
+import "testing"
+
+func TestQueryShapeCounts(t *testing.T) {
+	col := ColumnExpr{Ref: ColumnRef{Table: "t0", Name: "c0"}}
+	inner := &SelectQuery{
+		Items: []SelectItem{{Expr: col}},
+		From:  FromClause{BaseTable: "t1"},
+		Where: ExistsExpr{Query: &SelectQuery{Items: []SelectItem{{Expr: LiteralExpr{Value: 1}}}, From: FromClause{BaseTable: "t2"}}},
+	}
+	query := &SelectQuery{
+		Items: []SelectItem{{Expr: col}},
+		From: FromClause{
+			BaseTable: "t0",
+			Joins:     []Join{{Type: JoinInner, Table: "t1", On: BinaryExpr{Left: col, Op: "=", Right: col}}},
+		},
+		// (c0 > 1) AND c0 IN (subquery): AND, >, c0, 1, IN, c0, subquery.
+		Where: BinaryExpr{
+			Left:  BinaryExpr{Left: col, Op: ">", Right: LiteralExpr{Value: 1}},
+			Op:    "AND",
+			Right: InExpr{Left: col, List: []Expr{SubqueryExpr{Query: inner}}},
+		},
+	}
+	if got := QueryPredicateNodes(query); got != 10 {
+		t.Fatalf("predicate nodes=%d want=10", got)
+	}
+	if got := QuerySubqueryDepth(query); got != 2 {
+		t.Fatalf("subquery depth=%d want=2", got)
+	}
+	if got := QuerySubqueryDepth(inner.Where.(ExistsExpr).Query); got != 0 {
+		t.Fatalf("flat query depth=%d want=0", got)
+	}
+	query.With = []CTE{{Name: "cte0", Query: &SelectQuery{From: FromClause{BaseQuery: inner}}}}
+	if got := QuerySubqueryDepth(query); got != 3 {
+		t.Fatalf("depth through CTE and derived table=%d want=3", got)
+	}
+}
@@ -145,6 +145,10 @@ func (m scopeManager) validateExpr(expr Expr, scope tableScope, outer tableScope
 			return true
 		}
 		return m.validateExpr(e.Expr, scope, outer)
+	case NameRefExpr:
+		// Bare names resolve against select aliases and merged USING columns,
+		// which qualified scope checks cannot see.
+		return true
 	case SubqueryExpr:
 		return m.validateQuery(e.Query, m.scopeForQuery(e.Query), mergeTableScopes(scope, outer))
 	case ExistsExpr:
@@ -141,8 +141,36 @@ func (b *SelectQueryBuilder) Build() *SelectQuery {
 
 // BuildWithReason returns the query and the last failure reason if it cannot be built.
 func (b *SelectQueryBuilder) BuildWithReason() (*SelectQuery, string, int) {
+	query, diag := b.BuildWithDiagnostics()
+	return query, diag.Reason, diag.Attempts
+}
+
+// BuildDiagnostics describes one build: the attempts it took and how often
+// each constraint rejected a candidate. Reason is the last rejection and is
+// only set when the build failed.
+type BuildDiagnostics struct {
+	Reason     string
+	Attempts   int
+	Rejections map[string]int
+}
+
+// RejectionRates returns each rejection reason's share of the attempts.
+func (d BuildDiagnostics) RejectionRates() map[string]float64 {
+	if d.Attempts <= 0 || len(d.Rejections) == 0 {
+		return nil
+	}
+	out := make(map[string]float64, len(d.Rejections))
+	for reason, count := range d.Rejections {
+		out[reason] = float64(count) / float64(d.Attempts)
+	}
+	return out
+}
+
+// BuildWithDiagnostics generates a query like Build and reports which
+// constraints rejected the candidates along the way.
+func (b *SelectQueryBuilder) BuildWithDiagnostics() (*SelectQuery, BuildDiagnostics) {
 	if b == nil || b.gen == nil {
-		return nil, "builder:nil", 0
+		return nil, BuildDiagnostics{Reason: "builder:nil"}
 	}
 	c := b.constraints
 	maxTries := c.MaxTries
@@ -214,37 +242,55 @@ func (b *SelectQueryBuilder) BuildWithReason() (*SelectQuery, string, int) {
 		}
 	}()
 
+	diag := BuildDiagnostics{}
 	lastReason := ""
+	reject := func(reason string) {
+		lastReason = reason
+		if diag.Rejections == nil {
+			diag.Rejections = make(map[string]int)
+		}
+		diag.Rejections[reason]++
+	}
 	for i := 0; i < maxTries; i++ {
 		query := b.gen.GenerateSelectQuery()
 		if query == nil {
-			lastReason = "constraint:empty_query"
+			reject("constraint:empty_query")
 			continue
 		}
 		if c.RequireWhere && query.Where == nil {
 			if !b.attachPredicate(query, c) {
-				lastReason = "constraint:no_where"
+				reject("constraint:no_where")
 				continue
 			}
 		}
 		if c.PredicateGuard != nil && query.Where != nil && !c.PredicateGuard(query.Where) {
 			if !b.attachPredicate(query, c) || !c.PredicateGuard(query.Where) {
-				lastReason = "constraint:predicate_guard"
+				reject("constraint:predicate_guard")
 				continue
 			}
 		}
 		features := constraintFeaturesFor(query, c)
 		if reason := constraintViolationReason(query, c, features); reason != "" {
-			lastReason = reason
+			reject(reason)
+			continue
+		}
+		if c.RequireDeterministic && b.gen.nondeterministicView(query) != "" {
+			reject("constraint:nondeterministic_view")
+			continue
+		}
+		if c.RequireDeterministic && b.gen.nondeterministicCTE(query) != "" {
+			reject("constraint:nondeterministic_cte")
 			continue
 		}
-		attempts := i + 1
-		b.gen.recordBuilderStats(attempts, "")
+		diag.Attempts = i + 1
+		b.gen.recordBuilderStats(diag.Attempts, "", diag.Rejections)
 		b.gen.setQueryAnalysis(query)
-		return query, "", attempts
+		return query, diag
 	}
-	b.gen.recordBuilderStats(maxTries, lastReason)
-	return nil, lastReason, maxTries
+	diag.Attempts = maxTries
+	diag.Reason = lastReason
+	b.gen.recordBuilderStats(maxTries, lastReason, diag.Rejections)
+	return nil, diag
 }
 
 func constraintFeaturesFor(query *SelectQuery, c SelectQueryConstraints) QueryFeatures {
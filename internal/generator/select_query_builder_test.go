@@ -269,3 +269,52 @@ func isSimpleOperandForTest(expr Expr) bool {
 		return false
 	}
 }
+
+func TestSelectQueryBuilderDiagnostics(t *testing.T) {
+	gen := newTestGenerator(t)
+	gen.ResetBuilderStats()
+	calls := 0
+	query, diag := NewSelectQueryBuilder(gen).
+		QueryGuardWithReason(func(*SelectQuery) (bool, string) {
+			calls++
+			return false, "guard:reject"
+		}).
+		MaxTries(5).
+		BuildWithDiagnostics()
+	if query != nil {
+		t.Fatalf("expected the guard to reject every candidate")
+	}
+	if diag.Attempts != 5 || diag.Reason == "" || calls == 0 {
+		t.Fatalf("unexpected diagnostics: %+v", diag)
+	}
+	total := 0
+	for _, count := range diag.Rejections {
+		total += count
+	}
+	if total != 5 || diag.Rejections["guard:reject"] != calls {
+		t.Fatalf("unexpected rejections: %v (guard calls %d)", diag.Rejections, calls)
+	}
+	if rate := diag.RejectionRates()["guard:reject"]; rate != float64(calls)/5 {
+		t.Fatalf("unexpected rate: %v", rate)
+	}
+	stats := gen.BuilderStats()
+	if stats.Rejections["guard:reject"] != int64(calls) || stats.FailureReasons[diag.Reason] != 1 {
+		t.Fatalf("unexpected builder stats: %+v", stats)
+	}
+
+	gen.ResetBuilderStats()
+	calls = 0
+	query, diag = NewSelectQueryBuilder(gen).
+		QueryGuardWithReason(func(*SelectQuery) (bool, string) {
+			calls++
+			return calls > 2, "guard:warmup"
+		}).
+		MaxTries(20).
+		BuildWithDiagnostics()
+	if query == nil || diag.Reason != "" || diag.Rejections["guard:warmup"] != 2 {
+		t.Fatalf("unexpected successful build: query=%v diag=%+v", query != nil, diag)
+	}
+	if got := gen.BuilderStats().Rejections["guard:warmup"]; got != 2 {
+		t.Fatalf("successful builds must record rejections, got %d", got)
+	}
+}
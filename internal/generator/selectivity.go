@@ -0,0 +1,112 @@
+package generator
+
+import (
+	"fmt"
+
+	"shiro/internal/util"
+)
+
+// SelectivityBucket classifies the share of FROM rows a WHERE clause keeps.
+type SelectivityBucket string
+
+// Selectivity buckets; the empty string means no target.
+const (
+	SelectivityEmpty    SelectivityBucket = "empty"
+	SelectivityRare     SelectivityBucket = "rare"
+	SelectivityModerate SelectivityBucket = "moderate"
+	SelectivityAll      SelectivityBucket = "all"
+)
+
+// SelectivityBuckets lists the buckets in reporting order.
+var SelectivityBuckets = []SelectivityBucket{SelectivityEmpty, SelectivityRare, SelectivityModerate, SelectivityAll}
+
+const (
+	// SelectivityBiasProb is the chance a comparison leaf uses an operator
+	// picked for the selectivity target.
+	SelectivityBiasProb = 70
+	// SelectivityAllOrProb replaces PredicateOrProb when aiming at all rows.
+	SelectivityAllOrProb = 70
+)
+
+// SetSelectivityTarget aims the following predicates at target. With probe
+// set, LastFeatures also carries the COUNT(*) probes measuring the result.
+func (g *Generator) SetSelectivityTarget(target SelectivityBucket, probe bool) {
+	g.selectivityTarget = target
+	g.selectivityProbe = probe
+}
+
+// SelectivityTarget returns the current selectivity target.
+func (g *Generator) SelectivityTarget() SelectivityBucket {
+	return g.selectivityTarget
+}
+
+// selectivityComparison picks a comparison operator that tends toward the
+// target: equality keeps few rows, ranges keep a share, and != keeps most.
+func (g *Generator) selectivityComparison() (string, bool) {
+	if g.selectivityTarget == "" || !util.Chance(g.Rand, SelectivityBiasProb) {
+		return "", false
+	}
+	var ops []string
+	switch g.selectivityTarget {
+	case SelectivityEmpty, SelectivityRare:
+		ops = []string{"=", "<=>"}
+	case SelectivityModerate:
+		ops = []string{"<", ">", "<=", ">="}
+	case SelectivityAll:
+		ops = []string{"!="}
+	default:
+		return "", false
+	}
+	return ops[g.Rand.Intn(len(ops))], true
+}
+
+// predicateOrProb returns the chance to join predicates with OR; narrow
+// targets avoid OR, the all-rows target prefers it.
+func (g *Generator) predicateOrProb() int {
+	switch g.selectivityTarget {
+	case SelectivityEmpty, SelectivityRare:
+		return 0
+	case SelectivityAll:
+		return SelectivityAllOrProb
+	default:
+		return PredicateOrProb
+	}
+}
+
+// SelectivityProbeSQL returns COUNT(*) queries over the FROM clause of query
+// with and without its WHERE clause. Queries without WHERE and set operations
+// are not probed.
+func SelectivityProbeSQL(query *SelectQuery) (matchSQL string, totalSQL string, ok bool) {
+	if query == nil || query.Where == nil || len(query.SetOps) > 0 {
+		return "", "", false
+	}
+	probe := &SelectQuery{
+		With:          query.With,
+		WithRecursive: query.WithRecursive,
+		Items:         []SelectItem{{Expr: LiteralExpr{Value: 1}, Alias: "c"}},
+		From:          query.From,
+		Where:         query.Where,
+	}
+	matchSQL = fmt.Sprintf("SELECT COUNT(*) FROM (%s) q", probe.SQLString())
+	probe.Where = nil
+	totalSQL = fmt.Sprintf("SELECT COUNT(*) FROM (%s) q", probe.SQLString())
+	return matchSQL, totalSQL, true
+}
+
+// ClassifySelectivity buckets matched out of total rows; rare covers up to
+// rareMaxPercent of the rows. An empty FROM clause cannot be classified.
+func ClassifySelectivity(matched int64, total int64, rareMaxPercent int) (SelectivityBucket, bool) {
+	if total <= 0 {
+		return "", false
+	}
+	switch {
+	case matched <= 0:
+		return SelectivityEmpty, true
+	case matched >= total:
+		return SelectivityAll, true
+	case matched*100 <= total*int64(rareMaxPercent):
+		return SelectivityRare, true
+	default:
+		return SelectivityModerate, true
+	}
+}
@@ -0,0 +1,65 @@
+package generator
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestClassifySelectivity(t *testing.T) {
+	for _, tc := range []struct {
+		matched, total int64
+		want           SelectivityBucket
+		ok             bool
+	}{
+		{0, 0, "", false},
+		{0, 50, SelectivityEmpty, true},
+		{5, 50, SelectivityRare, true},
+		{6, 50, SelectivityModerate, true},
+		{50, 50, SelectivityAll, true},
+	} {
+		got, ok := ClassifySelectivity(tc.matched, tc.total, 10)
+		if got != tc.want || ok != tc.ok {
+			t.Fatalf("ClassifySelectivity(%d, %d) = %q, %v", tc.matched, tc.total, got, ok)
+		}
+	}
+}
+
+func TestSelectivityProbeSQL(t *testing.T) {
+	query := &SelectQuery{
+		Items: []SelectItem{{Expr: ColumnExpr{Ref: ColumnRef{Table: "t0", Name: "c0"}}, Alias: "c0"}},
+		From:  FromClause{BaseTable: "t0"},
+		Where: BinaryExpr{Left: ColumnExpr{Ref: ColumnRef{Table: "t0", Name: "c0"}}, Op: "=", Right: LiteralExpr{Value: 1}},
+	}
+	matchSQL, totalSQL, ok := SelectivityProbeSQL(query)
+	if !ok {
+		t.Fatalf("expected a probe for a query with WHERE")
+	}
+	if matchSQL != "SELECT COUNT(*) FROM (SELECT 1 AS c FROM t0 WHERE (t0.c0 = 1)) q" || totalSQL != "SELECT COUNT(*) FROM (SELECT 1 AS c FROM t0) q" {
+		t.Fatalf("unexpected probe SQL:\n%s\n%s", matchSQL, totalSQL)
+	}
+	query.Where = nil
+	if _, _, ok := SelectivityProbeSQL(query); ok {
+		t.Fatalf("queries without WHERE must not be probed")
+	}
+}
+
+func TestSelectivityTargetBiasesOperators(t *testing.T) {
+	g := &Generator{Rand: rand.New(rand.NewSource(1))}
+	g.SetSelectivityTarget(SelectivityEmpty, false)
+	if g.predicateOrProb() != 0 {
+		t.Fatalf("narrow targets must not use OR")
+	}
+	equality := 0
+	for i := 0; i < 200; i++ {
+		if op := g.pickComparison(); op == "=" || op == "<=>" {
+			equality++
+		}
+	}
+	if equality < 140 {
+		t.Fatalf("expected equality comparisons to dominate, got %d/200", equality)
+	}
+	g.SetSelectivityTarget("", false)
+	if g.predicateOrProb() != PredicateOrProb {
+		t.Fatalf("no target must keep the default OR probability")
+	}
+}
@@ -9,9 +9,9 @@ func compatibleColumnType(left, right schema.ColumnType) bool {
 // TypeCategory returns a coarse category for type compatibility checks.
 func TypeCategory(t schema.ColumnType) int {
 	switch t {
-	case schema.TypeInt, schema.TypeBigInt, schema.TypeFloat, schema.TypeDouble, schema.TypeDecimal:
+	case schema.TypeInt, schema.TypeBigInt, schema.TypeFloat, schema.TypeDouble, schema.TypeDecimal, schema.TypeBit, schema.TypeYear:
 		return 0
-	case schema.TypeVarchar:
+	case schema.TypeVarchar, schema.TypeEnum, schema.TypeSet:
 		return 1
 	case schema.TypeDate, schema.TypeDatetime, schema.TypeTimestamp:
 		return 2
@@ -0,0 +1,221 @@
+package generator
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strings"
+	"time"
+
+	"shiro/internal/config"
+	"shiro/internal/schema"
+	"shiro/internal/util"
+)
+
+// ValueGenerator produces literal values for matching columns during data generation.
+// Returning ok=false falls back to the built-in literal for the column type.
+type ValueGenerator interface {
+	Value(r *rand.Rand, table string, col schema.Column) (value any, ok bool)
+}
+
+// ValueGeneratorFunc adapts a function to ValueGenerator.
+type ValueGeneratorFunc func(r *rand.Rand, table string, col schema.Column) (any, bool)
+
+// Value implements ValueGenerator.
+func (f ValueGeneratorFunc) Value(r *rand.Rand, table string, col schema.Column) (any, bool) {
+	return f(r, table, col)
+}
+
+// Value generator presets available from config.
+const (
+	ValuePresetEmail              = "email"
+	ValuePresetIPv4               = "ipv4"
+	ValuePresetUUID               = "uuid"
+	ValuePresetMonotonicInt       = "monotonic_int"
+	ValuePresetMonotonicTimestamp = "monotonic_timestamp"
+)
+
+var (
+	stringColumnTypes   = []schema.ColumnType{schema.TypeVarchar}
+	integerColumnTypes  = []schema.ColumnType{schema.TypeInt, schema.TypeBigInt}
+	temporalColumnTypes = []schema.ColumnType{schema.TypeDate, schema.TypeDatetime, schema.TypeTimestamp}
+)
+
+type valueGeneratorRule struct {
+	name    string
+	pattern *regexp.Regexp
+	types   map[schema.ColumnType]struct{}
+	gen     ValueGenerator
+}
+
+func (r valueGeneratorRule) matches(table string, col schema.Column) bool {
+	if len(r.types) > 0 {
+		if _, ok := r.types[col.Type]; !ok {
+			return false
+		}
+	}
+	if r.pattern == nil {
+		return true
+	}
+	return r.pattern.MatchString(col.Name) || r.pattern.MatchString(table+"."+col.Name)
+}
+
+// RegisterValueGenerator adds a custom generator for columns whose name (or
+// table.column) matches pattern and whose type is in types. An empty pattern
+// matches every column and empty types match every type. Later registrations
+// take precedence over earlier ones.
+func (g *Generator) RegisterValueGenerator(name string, pattern string, types []schema.ColumnType, gen ValueGenerator) error {
+	if gen == nil {
+		return fmt.Errorf("value generator %q is nil", name)
+	}
+	rule := valueGeneratorRule{name: name, gen: gen}
+	if pattern = strings.TrimSpace(pattern); pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("value generator %q: %w", name, err)
+		}
+		rule.pattern = re
+	}
+	if len(types) > 0 {
+		rule.types = make(map[schema.ColumnType]struct{}, len(types))
+		for _, t := range types {
+			rule.types[t] = struct{}{}
+		}
+	}
+	g.valueGenerators = append([]valueGeneratorRule{rule}, g.valueGenerators...)
+	return nil
+}
+
+// customLiteral returns a literal from the first matching registered generator.
+func (g *Generator) customLiteral(table string, col schema.Column) (LiteralExpr, bool) {
+	for _, rule := range g.valueGenerators {
+		if !rule.matches(table, col) {
+			continue
+		}
+		if value, ok := rule.gen.Value(g.Rand, table, col); ok {
+			return LiteralExpr{Value: value}, true
+		}
+	}
+	return LiteralExpr{}, false
+}
+
+// registerConfiguredValueGenerators installs config.ValueGenerators presets.
+// Invalid entries are logged and skipped so a bad rule does not stop a run.
+func (g *Generator) registerConfiguredValueGenerators(rules []config.ValueGeneratorConfig) {
+	for _, rule := range rules {
+		gen, presetTypes, ok := valueGeneratorPreset(rule.Preset)
+		if !ok {
+			util.Warnf("value generator preset unknown preset=%s column=%s", rule.Preset, rule.Column)
+			continue
+		}
+		types := presetTypes
+		if len(rule.Types) > 0 {
+			types = intersectColumnTypes(presetTypes, parseColumnTypeNames(rule.Types))
+			if len(types) == 0 {
+				util.Warnf("value generator types incompatible preset=%s types=%v", rule.Preset, rule.Types)
+				continue
+			}
+		}
+		if err := g.RegisterValueGenerator(rule.Preset, rule.Column, types, gen); err != nil {
+			util.Warnf("value generator skipped: %v", err)
+		}
+	}
+}
+
+// valueGeneratorPreset returns a fresh preset generator and the column types it supports.
+func valueGeneratorPreset(name string) (ValueGenerator, []schema.ColumnType, bool) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case ValuePresetEmail:
+		return ValueGeneratorFunc(func(r *rand.Rand, _ string, _ schema.Column) (any, bool) {
+			return fmt.Sprintf("user%d@example%d.com", r.Intn(100000), r.Intn(10)), true
+		}), stringColumnTypes, true
+	case ValuePresetIPv4:
+		return ValueGeneratorFunc(func(r *rand.Rand, _ string, _ schema.Column) (any, bool) {
+			return fmt.Sprintf("%d.%d.%d.%d", r.Intn(256), r.Intn(256), r.Intn(256), r.Intn(256)), true
+		}), stringColumnTypes, true
+	case ValuePresetUUID:
+		return ValueGeneratorFunc(func(r *rand.Rand, _ string, _ schema.Column) (any, bool) {
+			var b [16]byte
+			_, _ = r.Read(b[:])
+			b[6] = (b[6] & 0x0f) | 0x40
+			b[8] = (b[8] & 0x3f) | 0x80
+			return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), true
+		}), stringColumnTypes, true
+	case ValuePresetMonotonicInt:
+		return newMonotonicValueGenerator(func(step int64, _ schema.Column) any {
+			return step
+		}), integerColumnTypes, true
+	case ValuePresetMonotonicTimestamp:
+		base := time.Date(DateYearMin, 1, 1, 0, 0, 0, 0, time.UTC)
+		return newMonotonicValueGenerator(func(step int64, col schema.Column) any {
+			if col.Type == schema.TypeDate {
+				return base.AddDate(0, 0, int(step)).Format("2006-01-02")
+			}
+			return base.Add(time.Duration(step) * time.Second).Format("2006-01-02 15:04:05")
+		}), temporalColumnTypes, true
+	default:
+		return nil, nil, false
+	}
+}
+
+// newMonotonicValueGenerator keeps one increasing counter per table.column.
+// Steps advance by a small random gap so values stay ordered but not dense.
+func newMonotonicValueGenerator(format func(step int64, col schema.Column) any) ValueGenerator {
+	next := make(map[string]int64)
+	return ValueGeneratorFunc(func(r *rand.Rand, table string, col schema.Column) (any, bool) {
+		key := table + "." + col.Name
+		step := next[key] + 1 + int64(r.Intn(3))
+		next[key] = step
+		return format(step, col), true
+	})
+}
+
+func parseColumnTypeNames(names []string) []schema.ColumnType {
+	out := make([]schema.ColumnType, 0, len(names))
+	for _, name := range names {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "int":
+			out = append(out, schema.TypeInt)
+		case "bigint":
+			out = append(out, schema.TypeBigInt)
+		case "float":
+			out = append(out, schema.TypeFloat)
+		case "double":
+			out = append(out, schema.TypeDouble)
+		case "decimal":
+			out = append(out, schema.TypeDecimal)
+		case "varchar":
+			out = append(out, schema.TypeVarchar)
+		case "date":
+			out = append(out, schema.TypeDate)
+		case "datetime":
+			out = append(out, schema.TypeDatetime)
+		case "timestamp":
+			out = append(out, schema.TypeTimestamp)
+		case "bool", "boolean":
+			out = append(out, schema.TypeBool)
+		case "bit":
+			out = append(out, schema.TypeBit)
+		case "enum":
+			out = append(out, schema.TypeEnum)
+		case "set":
+			out = append(out, schema.TypeSet)
+		case "year":
+			out = append(out, schema.TypeYear)
+		}
+	}
+	return out
+}
+
+func intersectColumnTypes(allowed []schema.ColumnType, requested []schema.ColumnType) []schema.ColumnType {
+	out := make([]schema.ColumnType, 0, len(requested))
+	for _, t := range requested {
+		for _, a := range allowed {
+			if a == t {
+				out = append(out, t)
+				break
+			}
+		}
+	}
+	return out
+}
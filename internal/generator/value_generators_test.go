@@ -0,0 +1,149 @@
+package generator
+
+import (
+	"math/rand"
+	"regexp"
+	"strings"
+	"testing"
+
+	"shiro/internal/config"
+	"shiro/internal/schema"
+)
+
+func newValueGeneratorTestGenerator(t *testing.T, rules []config.ValueGeneratorConfig) *Generator {
+	t.Helper()
+	cfg, err := config.Load("../../config.example.yaml")
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	cfg.ValueGenerators = rules
+	return New(cfg, &schema.State{}, 7)
+}
+
+func TestCustomLiteralPrecedenceAndTypes(t *testing.T) {
+	gen := newValueGeneratorTestGenerator(t, nil)
+	fixed := func(v any) ValueGenerator {
+		return ValueGeneratorFunc(func(_ *rand.Rand, _ string, _ schema.Column) (any, bool) {
+			return v, true
+		})
+	}
+	if err := gen.RegisterValueGenerator("first", "^c", nil, fixed("first")); err != nil {
+		t.Fatalf("register first: %v", err)
+	}
+	if err := gen.RegisterValueGenerator("second", `^t0\.c1$`, []schema.ColumnType{schema.TypeVarchar}, fixed("second")); err != nil {
+		t.Fatalf("register second: %v", err)
+	}
+	cases := []struct {
+		table string
+		col   schema.Column
+		want  any
+		ok    bool
+	}{
+		{table: "t0", col: schema.Column{Name: "c1", Type: schema.TypeVarchar}, want: "second", ok: true},
+		{table: "t0", col: schema.Column{Name: "c1", Type: schema.TypeInt}, want: "first", ok: true},
+		{table: "t1", col: schema.Column{Name: "c1", Type: schema.TypeVarchar}, want: "first", ok: true},
+		{table: "t0", col: schema.Column{Name: "k0", Type: schema.TypeVarchar}, ok: false},
+	}
+	for _, tc := range cases {
+		lit, ok := gen.customLiteral(tc.table, tc.col)
+		if ok != tc.ok {
+			t.Fatalf("customLiteral(%s.%s)=%v want=%v", tc.table, tc.col.Name, ok, tc.ok)
+		}
+		if ok && lit.Value != tc.want {
+			t.Fatalf("customLiteral(%s.%s)=%v want=%v", tc.table, tc.col.Name, lit.Value, tc.want)
+		}
+	}
+}
+
+func TestRegisterValueGeneratorRejectsInvalid(t *testing.T) {
+	gen := newValueGeneratorTestGenerator(t, nil)
+	if err := gen.RegisterValueGenerator("bad", "(", nil, ValueGeneratorFunc(nil)); err == nil {
+		t.Fatalf("expected invalid regex error")
+	}
+	if err := gen.RegisterValueGenerator("nil", "", nil, nil); err == nil {
+		t.Fatalf("expected nil generator error")
+	}
+	if len(gen.valueGenerators) != 0 {
+		t.Fatalf("unexpected registered generators: %d", len(gen.valueGenerators))
+	}
+}
+
+func TestConfiguredValueGeneratorPresets(t *testing.T) {
+	gen := newValueGeneratorTestGenerator(t, []config.ValueGeneratorConfig{
+		{Column: "email", Preset: ValuePresetEmail},
+		{Column: "ip", Preset: ValuePresetIPv4},
+		{Column: "uid", Preset: ValuePresetUUID},
+		{Column: "seq", Preset: ValuePresetMonotonicInt},
+		{Column: "ts", Preset: ValuePresetMonotonicTimestamp},
+		{Column: "bad", Preset: "unknown"},
+		{Column: "email", Types: []string{"int"}, Preset: ValuePresetEmail},
+	})
+	if len(gen.valueGenerators) != 5 {
+		t.Fatalf("unexpected registered generators: %d", len(gen.valueGenerators))
+	}
+	patterns := map[string]*regexp.Regexp{
+		"email": regexp.MustCompile(`^user\d+@example\d\.com$`),
+		"ip":    regexp.MustCompile(`^\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}$`),
+		"uid":   regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`),
+	}
+	for name, re := range patterns {
+		lit, ok := gen.customLiteral("t0", schema.Column{Name: name, Type: schema.TypeVarchar})
+		if !ok {
+			t.Fatalf("expected %s preset to match", name)
+		}
+		if s, _ := lit.Value.(string); !re.MatchString(s) {
+			t.Fatalf("%s preset value=%v", name, lit.Value)
+		}
+	}
+	if _, ok := gen.customLiteral("t0", schema.Column{Name: "email", Type: schema.TypeInt}); ok {
+		t.Fatalf("email preset should not match int columns")
+	}
+
+	seq := schema.Column{Name: "seq", Type: schema.TypeBigInt}
+	var last int64
+	for i := 0; i < 5; i++ {
+		lit, ok := gen.customLiteral("t0", seq)
+		if !ok {
+			t.Fatalf("expected monotonic_int preset to match")
+		}
+		v := lit.Value.(int64)
+		if v <= last {
+			t.Fatalf("monotonic_int not increasing: %d after %d", v, last)
+		}
+		last = v
+	}
+	other, _ := gen.customLiteral("t1", seq)
+	if other.Value.(int64) > 3 {
+		t.Fatalf("monotonic_int counter should be per table: %v", other.Value)
+	}
+
+	ts := schema.Column{Name: "ts", Type: schema.TypeTimestamp}
+	prev := ""
+	for i := 0; i < 5; i++ {
+		lit, _ := gen.customLiteral("t0", ts)
+		v := lit.Value.(string)
+		if v <= prev {
+			t.Fatalf("monotonic_timestamp not increasing: %s after %s", v, prev)
+		}
+		prev = v
+	}
+}
+
+func TestInsertSQLUsesValueGenerator(t *testing.T) {
+	gen := newValueGeneratorTestGenerator(t, []config.ValueGeneratorConfig{
+		{Column: "c0", Preset: ValuePresetEmail},
+	})
+	tbl := &schema.Table{
+		Name:   "t0",
+		HasPK:  true,
+		NextID: 1,
+		Columns: []schema.Column{
+			{Name: "id", Type: schema.TypeBigInt},
+			{Name: "c0", Type: schema.TypeVarchar},
+		},
+	}
+	sql := gen.InsertSQL(tbl)
+	if !strings.Contains(sql, "@example") {
+		t.Fatalf("expected email values in insert, got: %s", sql)
+	}
+}
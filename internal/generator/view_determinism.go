@@ -0,0 +1,211 @@
+package generator
+
+// ViewDefinitionDeterministic reports whether a view over query returns the
+// same rows on every read, so oracles comparing signatures may query it.
+// On top of QueryDeterministic, a LIMIT that may cut through ties and window
+// functions (ROW_NUMBER and friends number ties arbitrarily) make the row set
+// unstable, as does reading an unstable view.
+func (g *Generator) ViewDefinitionDeterministic(query *SelectQuery) bool {
+	if query == nil || !QueryDeterministic(query) {
+		return false
+	}
+	stable := true
+	walkQueryBlocks(query, func(block *SelectQuery) {
+		if !g.limitStable(block) || len(block.WindowDefs) > 0 {
+			stable = false
+		}
+		for _, item := range block.Items {
+			if exprHasWindow(item.Expr) {
+				stable = false
+			}
+		}
+	})
+	return stable && g.nondeterministicView(query) == ""
+}
+
+// nondeterministicCTE returns the name of the first CTE whose body has a
+// LIMIT that may cut through ties, or "" when there is none. CTE bodies order
+// by id to stay stable, but a CTE over another CTE orders by a column that
+// need not be unique.
+func (g *Generator) nondeterministicCTE(query *SelectQuery) string {
+	found := ""
+	walkQueryBlocks(query, func(block *SelectQuery) {
+		for _, cte := range block.With {
+			if found == "" && cte.Query != nil && !g.limitStable(cte.Query) {
+				found = cte.Name
+			}
+		}
+	})
+	return found
+}
+
+// limitStable reports whether a LIMIT returns the same rows on every run:
+// the ORDER BY starts with the id of a base table, which is unique, or it
+// lists every selected expression, so tied rows are identical.
+func (g *Generator) limitStable(block *SelectQuery) bool {
+	if block.Limit == nil {
+		return true
+	}
+	if len(block.OrderBy) == 0 {
+		return false
+	}
+	if col, ok := block.OrderBy[0].Expr.(ColumnExpr); ok && col.Ref.Name == "id" && g.State != nil {
+		if tbl, ok := g.State.TableByName(col.Ref.Table); ok && !tbl.IsView && tbl.HasPK {
+			return true
+		}
+	}
+	ordered := make(map[string]struct{}, len(block.OrderBy))
+	for _, ob := range block.OrderBy {
+		ordered[exprSQLText(ob.Expr)] = struct{}{}
+	}
+	for _, item := range block.Items {
+		if _, ok := ordered[exprSQLText(item.Expr)]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func exprSQLText(expr Expr) string {
+	if expr == nil {
+		return ""
+	}
+	b := SQLBuilder{}
+	expr.Build(&b)
+	return b.String()
+}
+
+// nondeterministicView returns the first view the query reads whose
+// definition is not known to be deterministic, or "" when there is none.
+// Views without a recorded definition count as nondeterministic.
+func (g *Generator) nondeterministicView(query *SelectQuery) string {
+	if g == nil || g.State == nil || query == nil {
+		return ""
+	}
+	found := ""
+	walkQueryBlocks(query, func(block *SelectQuery) {
+		if found != "" {
+			return
+		}
+		names := []string{block.From.BaseTable}
+		for _, join := range block.From.Joins {
+			names = append(names, join.Table)
+		}
+		for _, name := range names {
+			if name == "" {
+				continue
+			}
+			if tbl, ok := g.State.TableByName(name); ok && tbl.IsView && !tbl.ViewDeterministic {
+				found = name
+				return
+			}
+		}
+	})
+	return found
+}
+
+// walkQueryBlocks calls visit for query and every SELECT block nested in it:
+// CTEs, derived tables, set operations, and subqueries in expressions.
+func walkQueryBlocks(query *SelectQuery, visit func(*SelectQuery)) {
+	if query == nil {
+		return
+	}
+	visit(query)
+	for _, cte := range query.With {
+		walkQueryBlocks(cte.Query, visit)
+	}
+	walkQueryBlocks(query.From.BaseQuery, visit)
+	for _, join := range query.From.Joins {
+		walkQueryBlocks(join.TableQuery, visit)
+		walkExprQueries(join.On, visit)
+	}
+	for _, op := range query.SetOps {
+		walkQueryBlocks(op.Query, visit)
+	}
+	for _, item := range query.Items {
+		walkExprQueries(item.Expr, visit)
+	}
+	walkExprQueries(query.Where, visit)
+	walkExprQueries(query.Having, visit)
+	for _, expr := range query.GroupBy {
+		walkExprQueries(expr, visit)
+	}
+	for _, ob := range query.OrderBy {
+		walkExprQueries(ob.Expr, visit)
+	}
+}
+
+func walkExprQueries(expr Expr, visit func(*SelectQuery)) {
+	switch e := expr.(type) {
+	case FuncExpr:
+		for _, arg := range e.Args {
+			walkExprQueries(arg, visit)
+		}
+	case UnaryExpr:
+		walkExprQueries(e.Expr, visit)
+	case BinaryExpr:
+		walkExprQueries(e.Left, visit)
+		walkExprQueries(e.Right, visit)
+	case CaseExpr:
+		for _, w := range e.Whens {
+			walkExprQueries(w.When, visit)
+			walkExprQueries(w.Then, visit)
+		}
+		walkExprQueries(e.Else, visit)
+	case InExpr:
+		walkExprQueries(e.Left, visit)
+		for _, item := range e.List {
+			walkExprQueries(item, visit)
+		}
+	case *InExpr:
+		if e != nil {
+			walkExprQueries(*e, visit)
+		}
+	case CompareSubqueryExpr:
+		walkExprQueries(e.Left, visit)
+		walkQueryBlocks(e.Query, visit)
+	case *CompareSubqueryExpr:
+		if e != nil {
+			walkExprQueries(*e, visit)
+		}
+	case GroupByOrdinalExpr:
+		walkExprQueries(e.Expr, visit)
+	case NameRefExpr:
+		walkExprQueries(e.Expr, visit)
+	case SubqueryExpr:
+		walkQueryBlocks(e.Query, visit)
+	case ExistsExpr:
+		walkQueryBlocks(e.Query, visit)
+	case WindowExpr:
+		for _, arg := range e.Args {
+			walkExprQueries(arg, visit)
+		}
+	}
+}
+
+func exprHasWindow(expr Expr) bool {
+	switch e := expr.(type) {
+	case WindowExpr:
+		return true
+	case FuncExpr:
+		for _, arg := range e.Args {
+			if exprHasWindow(arg) {
+				return true
+			}
+		}
+	case UnaryExpr:
+		return exprHasWindow(e.Expr)
+	case BinaryExpr:
+		return exprHasWindow(e.Left) || exprHasWindow(e.Right)
+	case CaseExpr:
+		for _, w := range e.Whens {
+			if exprHasWindow(w.When) || exprHasWindow(w.Then) {
+				return true
+			}
+		}
+		return exprHasWindow(e.Else)
+	case NameRefExpr:
+		return exprHasWindow(e.Expr)
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package generator
+
+import (
+	"testing"
+
+	"shiro/internal/schema"
+)
+
+func addTestView(gen *Generator, name string, deterministic bool) {
+	gen.State.Tables = append(gen.State.Tables, schema.Table{
+		Name:              name,
+		Columns:           []schema.Column{{Name: "c0", Type: schema.TypeInt}},
+		IsView:            true,
+		ViewDeterministic: deterministic,
+	})
+}
+
+func TestViewDefinitionDeterministic(t *testing.T) {
+	gen := newTestGenerator(t)
+	addTestView(gen, "v0", false)
+	addTestView(gen, "v1", true)
+	limit := 3
+	cases := []struct {
+		name  string
+		query *SelectQuery
+		want  bool
+	}{
+		{
+			name:  "plain",
+			query: &SelectQuery{Items: []SelectItem{{Expr: LiteralExpr{Value: 1}, Alias: "c0"}}, From: FromClause{BaseTable: "t0"}},
+			want:  true,
+		},
+		{
+			name:  "stable view",
+			query: &SelectQuery{Items: []SelectItem{{Expr: LiteralExpr{Value: 1}, Alias: "c0"}}, From: FromClause{BaseTable: "v1"}},
+			want:  true,
+		},
+		{
+			name:  "nondeterministic expr",
+			query: &SelectQuery{Items: []SelectItem{{Expr: nonDetExpr{}, Alias: "c0"}}, From: FromClause{BaseTable: "t0"}},
+			want:  false,
+		},
+		{
+			name: "limit",
+			query: &SelectQuery{
+				Items:   []SelectItem{{Expr: LiteralExpr{Value: 1}, Alias: "c0"}},
+				From:    FromClause{BaseTable: "t0"},
+				OrderBy: []OrderBy{{Expr: ColumnExpr{Ref: ColumnRef{Table: "t0", Name: "c0"}}}},
+				Limit:   &limit,
+			},
+			want: false,
+		},
+		{
+			name:  "window",
+			query: &SelectQuery{Items: []SelectItem{{Expr: WindowExpr{Name: "ROW_NUMBER"}, Alias: "c0"}}, From: FromClause{BaseTable: "t0"}},
+			want:  false,
+		},
+		{
+			name: "unstable view in subquery",
+			query: &SelectQuery{
+				Items: []SelectItem{{Expr: LiteralExpr{Value: 1}, Alias: "c0"}},
+				From:  FromClause{BaseTable: "t0"},
+				Where: ExistsExpr{Query: &SelectQuery{Items: []SelectItem{{Expr: LiteralExpr{Value: 1}}}, From: FromClause{BaseTable: "v0"}}},
+			},
+			want: false,
+		},
+	}
+	for _, tc := range cases {
+		if got := gen.ViewDefinitionDeterministic(tc.query); got != tc.want {
+			t.Fatalf("%s: got %v want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestNondeterministicViewWalksNestedBlocks(t *testing.T) {
+	gen := newTestGenerator(t)
+	addTestView(gen, "v0", false)
+	addTestView(gen, "v1", true)
+	query := &SelectQuery{
+		With:  []CTE{{Name: "cte0", Query: &SelectQuery{Items: []SelectItem{{Expr: LiteralExpr{Value: 1}, Alias: "c0"}}, From: FromClause{BaseTable: "v1"}}}},
+		Items: []SelectItem{{Expr: LiteralExpr{Value: 1}, Alias: "c0"}},
+		From:  FromClause{BaseTable: "cte0"},
+	}
+	if got := gen.nondeterministicView(query); got != "" {
+		t.Fatalf("expected no unstable view, got %q", got)
+	}
+	query.Where = InExpr{
+		Left: ColumnExpr{Ref: ColumnRef{Table: "cte0", Name: "c0"}},
+		List: []Expr{SubqueryExpr{Query: &SelectQuery{Items: []SelectItem{{Expr: LiteralExpr{Value: 1}}}, From: FromClause{BaseTable: "v0"}}}},
+	}
+	if got := gen.nondeterministicView(query); got != "v0" {
+		t.Fatalf("expected v0 from IN subquery, got %q", got)
+	}
+}
+
+func TestNondeterministicCTE(t *testing.T) {
+	gen := newTestGenerator(t)
+	gen.State.Tables[0].HasPK = true
+	limit := 5
+	t0ID := ColumnExpr{Ref: ColumnRef{Table: "t0", Name: "id"}}
+	t0C0 := ColumnExpr{Ref: ColumnRef{Table: "t0", Name: "c0"}}
+	cte0C0 := ColumnExpr{Ref: ColumnRef{Table: "cte_0", Name: "c0"}}
+	cte0C1 := ColumnExpr{Ref: ColumnRef{Table: "cte_0", Name: "c1"}}
+	query := &SelectQuery{
+		With: []CTE{
+			{Name: "cte_0", Query: &SelectQuery{
+				Items:   []SelectItem{{Expr: t0C0, Alias: "c0"}, {Expr: t0ID, Alias: "c1"}},
+				From:    FromClause{BaseTable: "t0"},
+				OrderBy: []OrderBy{{Expr: t0ID}},
+				Limit:   &limit,
+			}},
+			{Name: "cte_1", Query: &SelectQuery{
+				Items:   []SelectItem{{Expr: cte0C0, Alias: "c0"}},
+				From:    FromClause{BaseTable: "cte_0"},
+				OrderBy: []OrderBy{{Expr: cte0C0}},
+				Limit:   &limit,
+			}},
+		},
+		Items: []SelectItem{{Expr: LiteralExpr{Value: 1}, Alias: "c0"}},
+		From:  FromClause{BaseTable: "cte_1"},
+	}
+	if got := gen.nondeterministicCTE(query); got != "" {
+		t.Fatalf("expected stable CTEs, got %q", got)
+	}
+	query.With[1].Query.Items = append(query.With[1].Query.Items, SelectItem{Expr: cte0C1, Alias: "c1"})
+	if got := gen.nondeterministicCTE(query); got != "cte_1" {
+		t.Fatalf("expected cte_1 to cut through ties, got %q", got)
+	}
+}
+
+func TestSelectQueryBuilderRejectsNondeterministicView(t *testing.T) {
+	gen := newTestGenerator(t)
+	addTestView(gen, "v0", false)
+	builder := NewSelectQueryBuilder(gen).RequireDeterministic().MaxTries(50)
+	for i := 0; i < 50; i++ {
+		query, _ := builder.BuildWithDiagnostics()
+		if query == nil {
+			continue
+		}
+		if view := gen.nondeterministicView(query); view != "" {
+			t.Fatalf("builder returned query over unstable view %s: %s", view, query.SQLString())
+		}
+	}
+}
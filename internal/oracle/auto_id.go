@@ -0,0 +1,392 @@
+package oracle
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+
+	"shiro/internal/db"
+	"shiro/internal/generator"
+	"shiro/internal/schema"
+	"shiro/internal/sqlstep"
+	"shiro/internal/util"
+)
+
+const (
+	autoIDTable           = "shiro_autoid"
+	autoIDConnsMin        = 2
+	autoIDConnsMax        = 4
+	autoIDRounds          = 3
+	autoIDInsertsPerRound = 4
+	autoIDBatchProb       = 30
+	autoIDBatchRowsMax    = 3
+	autoIDShardBits       = 5
+	autoIDRebaseGapMax    = 1000
+	autoIDRandomProb      = 40
+	autoIDCacheOneProb    = 50
+	autoIDExplicitProb    = 50
+	autoIDClusteredProb   = 50
+	autoIDErrDupEntry     = 1062
+)
+
+// AutoID allocation modes.
+const (
+	autoIDModeIncrement = "auto_increment"
+	autoIDModeCacheOne  = "auto_increment_cache1"
+	autoIDModeRandom    = "auto_random"
+)
+
+// AutoID implements the auto_increment/auto_random allocation oracle.
+//
+// It creates a scratch table keyed by an AUTO_INCREMENT (default cache or
+// AUTO_ID_CACHE 1) or AUTO_RANDOM column and inserts from several connections
+// at once, in rounds separated by a barrier. Each row records the connection,
+// round, and per-connection sequence that inserted it. Before the last round
+// the allocator is rebased with ALTER TABLE (or, for AUTO_ID_CACHE 1, an
+// explicit id). The rows must then satisfy what TiDB promises:
+//   - every insert succeeds without a duplicate key error, and every row is
+//     stored exactly once;
+//   - AUTO_INCREMENT ids grow within one connection;
+//   - AUTO_ID_CACHE 1 ids grow across rounds and stay above the rebase point;
+//   - AUTO_RANDOM ids are positive and their incremental bits are unique.
+//
+// ADMIN CHECK TABLE must pass at the end.
+//
+// Example:
+//
+//	CREATE TABLE shiro_autoid (id BIGINT NOT NULL AUTO_INCREMENT, ...) AUTO_ID_CACHE 1
+//	INSERT INTO shiro_autoid (c, r, s) VALUES (0, 0, 0)   -- conn 0
+//	INSERT INTO shiro_autoid (c, r, s) VALUES (1, 0, 0)   -- conn 1, concurrently
+//	ALTER TABLE shiro_autoid AUTO_INCREMENT = 523
+//	INSERT ...                                            -- ids must be >= 523
+type AutoID struct{}
+
+// Name returns the oracle identifier.
+func (o AutoID) Name() string { return "AutoID" }
+
+// autoIDRow is one stored row: the allocated id and who inserted it.
+type autoIDRow struct {
+	id    int64
+	conn  int
+	round int
+	seq   int
+}
+
+// autoIDStmt is one planned insert and the number of rows it writes.
+type autoIDStmt struct {
+	sql  string
+	rows int
+}
+
+// autoIDPlan holds the inserts of one round, per connection.
+type autoIDPlan [][]autoIDStmt
+
+// autoIDTableSQL renders the scratch table definition for a mode.
+func autoIDTableSQL(mode string, clustering string) string {
+	idCol := "id BIGINT NOT NULL AUTO_INCREMENT"
+	if mode == autoIDModeRandom {
+		idCol = fmt.Sprintf("id BIGINT NOT NULL AUTO_RANDOM(%d)", autoIDShardBits)
+	}
+	stmt := fmt.Sprintf("CREATE TABLE %s (%s, c INT NOT NULL, r INT NOT NULL, s INT NOT NULL, PRIMARY KEY (id) %s)", autoIDTable, idCol, clustering)
+	if mode == autoIDModeCacheOne {
+		stmt += " AUTO_ID_CACHE 1"
+	}
+	return stmt
+}
+
+// planAutoIDRound builds the inserts of one round. seqs holds the next
+// sequence number of each connection and is advanced in place.
+func planAutoIDRound(gen *generator.Generator, round int, seqs []int) autoIDPlan {
+	plan := make(autoIDPlan, len(seqs))
+	for conn := range seqs {
+		for i := 0; i < autoIDInsertsPerRound; i++ {
+			rows := 1
+			if util.Chance(gen.Rand, autoIDBatchProb) {
+				rows = 2 + gen.Rand.Intn(autoIDBatchRowsMax-1)
+			}
+			values := make([]string, 0, rows)
+			for j := 0; j < rows; j++ {
+				values = append(values, fmt.Sprintf("(%d, %d, %d)", conn, round, seqs[conn]))
+				seqs[conn]++
+			}
+			plan[conn] = append(plan[conn], autoIDStmt{
+				sql:  fmt.Sprintf("INSERT INTO %s (c, r, s) VALUES %s", autoIDTable, strings.Join(values, ", ")),
+				rows: rows,
+			})
+		}
+	}
+	return plan
+}
+
+// runAutoIDRound runs each connection's inserts in its own goroutine and
+// returns the statement that failed first with its error.
+func runAutoIDRound(ctx context.Context, conns []*sql.Conn, plan autoIDPlan) (string, error) {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		errSQL   string
+	)
+	for i, conn := range conns {
+		wg.Add(1)
+		go func(conn *sql.Conn, stmts []autoIDStmt) {
+			defer wg.Done()
+			for _, stmt := range stmts {
+				if _, err := conn.ExecContext(ctx, stmt.sql); err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr, errSQL = err, stmt.sql
+					}
+					mu.Unlock()
+					return
+				}
+			}
+		}(conn, plan[i])
+	}
+	wg.Wait()
+	return errSQL, firstErr
+}
+
+// Run creates the scratch table, runs the insert rounds concurrently, and
+// checks the stored ids. The table is dropped afterwards.
+func (o AutoID) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, _ *schema.State) Result {
+	mode := autoIDModeIncrement
+	switch {
+	case util.Chance(gen.Rand, autoIDRandomProb):
+		mode = autoIDModeRandom
+	case util.Chance(gen.Rand, autoIDCacheOneProb):
+		mode = autoIDModeCacheOne
+	}
+	clustering := schema.PKNonClustered
+	// AUTO_RANDOM requires a clustered primary key.
+	if mode == autoIDModeRandom || util.Chance(gen.Rand, autoIDClusteredProb) {
+		clustering = schema.PKClustered
+	}
+	createSQL := autoIDTableSQL(mode, clustering)
+	metrics := map[string]int64{"autoid_mode_" + mode + "_total": 1}
+	details := map[string]any{"autoid_mode": mode, "autoid_clustering": strings.ToLower(clustering)}
+	steps := []sqlstep.Step{sqlstep.New(sqlstep.KindSetup, "", createSQL)}
+	skip := func(reason string, err error) Result {
+		details["skip_reason"] = "autoid:" + reason
+		if err != nil {
+			sqlErrorDetails(details, "autoid", err)
+		}
+		return Result{OK: true, Oracle: o.Name(), Details: details, Metrics: metrics}
+	}
+
+	dropSQL := "DROP TABLE IF EXISTS " + autoIDTable
+	if _, err := exec.ExecContext(ctx, dropSQL); err != nil {
+		return sqlErrorResult(o.Name(), "autoid", failedSteps(steps, sqlstep.KindSetup, dropSQL), metrics, details, err, dropSQL)
+	}
+	if _, err := exec.ExecContext(ctx, createSQL); err != nil {
+		return skip("unsupported", err)
+	}
+	defer func() {
+		_, _ = exec.ExecContext(context.Background(), dropSQL)
+	}()
+
+	connCount := autoIDConnsMin + gen.Rand.Intn(autoIDConnsMax-autoIDConnsMin+1)
+	conns := make([]*sql.Conn, 0, connCount)
+	defer func() {
+		for _, conn := range conns {
+			util.CloseWithErr(conn, "autoid conn")
+		}
+	}()
+	for len(conns) < connCount {
+		conn, err := exec.Conn(ctx)
+		if err != nil {
+			return Result{OK: true, Oracle: o.Name(), Err: err}
+		}
+		conns = append(conns, conn)
+	}
+
+	seqs := make([]int, connCount)
+	expectedRows := 0
+	var floor int64
+	for round := 0; round < autoIDRounds; round++ {
+		if round == autoIDRounds-1 {
+			rebaseSQL, rebaseFloor, kind, err := autoIDRebase(ctx, exec, gen, mode)
+			if err != nil {
+				return skip("rebase_failed", err)
+			}
+			steps = append(steps, sqlstep.New(sqlstep.KindSetup, "", rebaseSQL))
+			metrics["autoid_rebase_"+kind+"_total"]++
+			details["autoid_rebase"] = kind
+			if kind == "explicit" {
+				expectedRows++
+			}
+			floor = rebaseFloor
+		}
+		plan := planAutoIDRound(gen, round, seqs)
+		for conn, stmts := range plan {
+			for _, stmt := range stmts {
+				steps = append(steps, sqlstep.New(sqlstep.KindSetup, "", fmt.Sprintf("/* conn %d */ %s", conn, stmt.sql)))
+				expectedRows += stmt.rows
+			}
+		}
+		errSQL, err := runAutoIDRound(ctx, conns, plan)
+		if err != nil {
+			if code, ok := mysqlErrCode(err); ok && code == autoIDErrDupEntry {
+				steps = append(steps, sqlstep.New(sqlstep.KindSetup, sqlstep.RoleFailing, errSQL))
+				details["error_sql"] = errSQL
+				return o.mismatch(steps, details, metrics, "duplicate_key", "no duplicate key error", err.Error())
+			}
+			return skip("insert_failed", err)
+		}
+	}
+
+	readSQL := fmt.Sprintf("SELECT id, c, r, s FROM %s ORDER BY c, s", autoIDTable)
+	steps = append(steps, sqlstep.New(sqlstep.KindQuery, sqlstep.RoleActual, readSQL))
+	rows, err := readAutoIDRows(ctx, exec, readSQL)
+	if err != nil {
+		return sqlErrorResult(o.Name(), "autoid", failedSteps(steps, sqlstep.KindQuery, readSQL), metrics, details, err, readSQL)
+	}
+	metrics["autoid_rows_total"] = int64(len(rows))
+	if phase, expected, actual, ok := checkAutoIDRows(mode, rows, expectedRows, floor); !ok {
+		return o.mismatch(steps, details, metrics, phase, expected, actual)
+	}
+
+	checkSQL := "ADMIN CHECK TABLE " + autoIDTable
+	if _, err := exec.ExecContext(ctx, checkSQL); err != nil {
+		if isAdminCheckInconsistentErr(err) {
+			steps = append(steps, sqlstep.New(sqlstep.KindSetup, sqlstep.RoleActual, checkSQL))
+			return o.mismatch(steps, details, metrics, "admin_check", "consistent", err.Error())
+		}
+		metrics["autoid_admin_check_error_total"]++
+	}
+	return Result{OK: true, Oracle: o.Name(), Steps: steps, Details: details, Metrics: metrics}
+}
+
+// autoIDRebase moves the allocator past the current maximum. It returns the
+// statement, the lowest id later rounds may get, and the rebase kind.
+func autoIDRebase(ctx context.Context, exec *db.DB, gen *generator.Generator, mode string) (string, int64, string, error) {
+	var maxID sql.NullInt64
+	column := "id"
+	if mode == autoIDModeRandom {
+		column = fmt.Sprintf("id & %d", autoIDIncrementMask())
+	}
+	if err := exec.QueryRowContext(ctx, fmt.Sprintf("SELECT MAX(%s) FROM %s", column, autoIDTable)).Scan(&maxID); err != nil {
+		return "", 0, "", err
+	}
+	target := maxID.Int64 + 1 + int64(gen.Rand.Intn(autoIDRebaseGapMax))
+	stmt := fmt.Sprintf("ALTER TABLE %s AUTO_INCREMENT = %d", autoIDTable, target)
+	kind := "alter"
+	floor := target
+	switch {
+	case mode == autoIDModeRandom:
+		stmt = fmt.Sprintf("ALTER TABLE %s AUTO_RANDOM_BASE = %d", autoIDTable, target)
+	case mode == autoIDModeCacheOne && util.Chance(gen.Rand, autoIDExplicitProb):
+		stmt = fmt.Sprintf("INSERT INTO %s (id, c, r, s) VALUES (%d, -1, -1, 0)", autoIDTable, target)
+		kind = "explicit"
+		floor = target + 1
+	}
+	if _, err := exec.ExecContext(ctx, stmt); err != nil {
+		return stmt, 0, kind, err
+	}
+	return stmt, floor, kind, nil
+}
+
+func readAutoIDRows(ctx context.Context, exec *db.DB, query string) ([]autoIDRow, error) {
+	rows, err := exec.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer util.CloseWithErr(rows, "autoid rows")
+	var out []autoIDRow
+	for rows.Next() {
+		var row autoIDRow
+		if err := rows.Scan(&row.id, &row.conn, &row.round, &row.seq); err != nil {
+			return nil, err
+		}
+		out = append(out, row)
+	}
+	return out, rows.Err()
+}
+
+// autoIDIncrementMask selects the incremental bits of an AUTO_RANDOM id: the
+// bits below the sign and shard bits.
+func autoIDIncrementMask() int64 {
+	return int64(1)<<(63-autoIDShardBits) - 1
+}
+
+// checkAutoIDRows checks the stored rows against the allocation guarantees of
+// the mode. Rows are ordered by connection and sequence; the explicit rebase
+// row has conn -1. floor is the lowest id the last round may get under
+// AUTO_ID_CACHE 1.
+func checkAutoIDRows(mode string, rows []autoIDRow, expectedRows int, floor int64) (phase string, expected string, actual string, ok bool) {
+	if len(rows) != expectedRows {
+		return "row_count", fmt.Sprintf("rows=%d", expectedRows), fmt.Sprintf("rows=%d", len(rows)), false
+	}
+	seen := make(map[int64]autoIDRow, len(rows))
+	increments := make(map[int64]autoIDRow, len(rows))
+	roundMax := make(map[int]int64)
+	roundMin := make(map[int]int64)
+	for i, row := range rows {
+		if prev, dup := seen[row.id]; dup {
+			return "duplicate_id", "unique ids", fmt.Sprintf("id=%d conn=%d/%d seq=%d/%d", row.id, prev.conn, row.conn, prev.seq, row.seq), false
+		}
+		seen[row.id] = row
+		if row.conn < 0 {
+			continue
+		}
+		if row.id <= 0 {
+			return "non_positive", "id>0", fmt.Sprintf("id=%d conn=%d seq=%d", row.id, row.conn, row.seq), false
+		}
+		if mode == autoIDModeRandom {
+			inc := row.id & autoIDIncrementMask()
+			if prev, dup := increments[inc]; dup {
+				return "duplicate_increment", "unique incremental bits", fmt.Sprintf("increment=%d ids=%d/%d", inc, prev.id, row.id), false
+			}
+			increments[inc] = row
+			continue
+		}
+		if i > 0 && rows[i-1].conn == row.conn && rows[i-1].id >= row.id {
+			return "conn_order", fmt.Sprintf("conn=%d seq=%d id>%d", row.conn, row.seq, rows[i-1].id), fmt.Sprintf("id=%d", row.id), false
+		}
+		if cur, okMax := roundMax[row.round]; !okMax || row.id > cur {
+			roundMax[row.round] = row.id
+		}
+		if cur, okMin := roundMin[row.round]; !okMin || row.id < cur {
+			roundMin[row.round] = row.id
+		}
+	}
+	if mode != autoIDModeCacheOne {
+		return "", "", "", true
+	}
+	for round := 1; round < autoIDRounds; round++ {
+		prevMax, okPrev := roundMax[round-1]
+		curMin, okCur := roundMin[round]
+		if okPrev && okCur && curMin <= prevMax {
+			return "round_order", fmt.Sprintf("round=%d min_id>%d", round, prevMax), fmt.Sprintf("min_id=%d", curMin), false
+		}
+	}
+	if curMin, okCur := roundMin[autoIDRounds-1]; okCur && floor > 0 && curMin < floor {
+		return "rebase_floor", fmt.Sprintf("min_id>=%d", floor), fmt.Sprintf("min_id=%d", curMin), false
+	}
+	return "", "", "", true
+}
+
+// isAdminCheckInconsistentErr reports whether ADMIN CHECK TABLE found an
+// index/row mismatch, as opposed to failing for another reason.
+func isAdminCheckInconsistentErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "inconsistent") || strings.Contains(msg, "mismatch")
+}
+
+func (o AutoID) mismatch(steps []sqlstep.Step, details map[string]any, metrics map[string]int64, phase string, expected string, actual string) Result {
+	details["autoid_phase"] = phase
+	return Result{
+		OK:       false,
+		Oracle:   o.Name(),
+		Steps:    steps,
+		Expected: expected,
+		Actual:   actual,
+		Details:  details,
+		Metrics:  metrics,
+	}
+}
@@ -0,0 +1,108 @@
+package oracle
+
+import (
+	"strings"
+	"testing"
+
+	"shiro/internal/config"
+	"shiro/internal/generator"
+	"shiro/internal/schema"
+)
+
+func TestAutoIDTableSQL(t *testing.T) {
+	got := autoIDTableSQL(autoIDModeCacheOne, schema.PKNonClustered)
+	want := "CREATE TABLE shiro_autoid (id BIGINT NOT NULL AUTO_INCREMENT, c INT NOT NULL, r INT NOT NULL, s INT NOT NULL, PRIMARY KEY (id) NONCLUSTERED) AUTO_ID_CACHE 1"
+	if got != want {
+		t.Fatalf("autoIDTableSQL()=%q want=%q", got, want)
+	}
+	if got := autoIDTableSQL(autoIDModeRandom, schema.PKClustered); !strings.Contains(got, "AUTO_RANDOM(5)") || strings.Contains(got, "AUTO_ID_CACHE") {
+		t.Fatalf("unexpected auto_random table: %s", got)
+	}
+}
+
+func TestPlanAutoIDRound(t *testing.T) {
+	gen := generator.New(config.Config{}, &schema.State{}, 1)
+	seqs := make([]int, 2)
+	plan := planAutoIDRound(gen, 0, seqs)
+	if len(plan) != 2 || len(plan[0]) != autoIDInsertsPerRound {
+		t.Fatalf("unexpected plan shape: %+v", plan)
+	}
+	for conn, stmts := range plan {
+		rows := 0
+		for _, stmt := range stmts {
+			rows += stmt.rows
+			if !strings.HasPrefix(stmt.sql, "INSERT INTO shiro_autoid (c, r, s) VALUES ") {
+				t.Fatalf("unexpected insert: %s", stmt.sql)
+			}
+		}
+		if seqs[conn] != rows {
+			t.Fatalf("conn %d seq=%d want=%d", conn, seqs[conn], rows)
+		}
+	}
+}
+
+func TestCheckAutoIDRows(t *testing.T) {
+	lastRound := autoIDRounds - 1
+	tests := []struct {
+		name     string
+		mode     string
+		rows     []autoIDRow
+		expected int
+		floor    int64
+		phase    string
+	}{
+		{
+			name: "ok",
+			mode: autoIDModeIncrement,
+			rows: []autoIDRow{{id: 1, conn: 0, seq: 0}, {id: 4, conn: 0, seq: 1}, {id: 2, conn: 1, seq: 0}},
+		},
+		{
+			name:     "lost row",
+			mode:     autoIDModeIncrement,
+			rows:     []autoIDRow{{id: 1, conn: 0}},
+			expected: 2,
+			phase:    "row_count",
+		},
+		{
+			name:  "conn order",
+			mode:  autoIDModeIncrement,
+			rows:  []autoIDRow{{id: 5, conn: 0, seq: 0}, {id: 3, conn: 0, seq: 1}},
+			phase: "conn_order",
+		},
+		{
+			name:  "round order",
+			mode:  autoIDModeCacheOne,
+			rows:  []autoIDRow{{id: 5, conn: 0, round: 0, seq: 0}, {id: 3, conn: 1, round: 1, seq: 0}},
+			phase: "round_order",
+		},
+		{
+			name:  "rebase floor",
+			mode:  autoIDModeCacheOne,
+			rows:  []autoIDRow{{id: 100, conn: -1, round: -1}, {id: 5, conn: 0, round: 0}, {id: 50, conn: 0, round: lastRound, seq: 1}},
+			floor: 101,
+			phase: "rebase_floor",
+		},
+		{
+			name:  "random increment",
+			mode:  autoIDModeRandom,
+			rows:  []autoIDRow{{id: 7, conn: 0}, {id: 7 | 1<<(63-autoIDShardBits), conn: 1}},
+			phase: "duplicate_increment",
+		},
+		{
+			name:  "random order ignored",
+			mode:  autoIDModeRandom,
+			rows:  []autoIDRow{{id: 9 | 1<<60, conn: 0, seq: 0}, {id: 10, conn: 0, seq: 1}},
+			phase: "",
+		},
+	}
+	for _, tt := range tests {
+		expected := tt.expected
+		if expected == 0 {
+			expected = len(tt.rows)
+		}
+		phase, _, _, ok := checkAutoIDRows(tt.mode, tt.rows, expected, tt.floor)
+		if phase != tt.phase || ok != (tt.phase == "") {
+			t.Fatalf("%s: phase=%q ok=%v want phase=%q", tt.name, phase, ok, tt.phase)
+		}
+	}
+}
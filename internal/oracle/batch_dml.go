@@ -0,0 +1,255 @@
+package oracle
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"shiro/internal/db"
+	"shiro/internal/generator"
+	"shiro/internal/schema"
+	"shiro/internal/sqlstep"
+	"shiro/internal/util"
+)
+
+const (
+	batchDMLTable        = "shiro_batch_dml"
+	batchDMLRefTable     = "shiro_batch_ref"
+	batchDMLLimitMax     = 8
+	batchDMLDefaultShard = 30
+	batchDMLUpdateProb   = 50
+
+	batchDMLKindDelete = "delete"
+	batchDMLKindUpdate = "update"
+)
+
+// BatchDML implements the non-transactional DML oracle.
+//
+// TiDB splits "BATCH [ON col] LIMIT n DELETE/UPDATE ..." into one transaction
+// per shard range of col (the handle when ON is omitted). It copies a base
+// table twice, runs the batched statement on one copy and the same statement
+// without BATCH on the other, and compares the two tables. The predicate only
+// references the copied table and is deterministic, and the shard column is
+// never updated, so every row is evaluated exactly once either way.
+//
+// Example:
+//
+//	CREATE TABLE shiro_batch_dml LIKE t0
+//	INSERT INTO shiro_batch_dml SELECT * FROM t0
+//	CREATE TABLE shiro_batch_ref LIKE t0
+//	INSERT INTO shiro_batch_ref SELECT * FROM t0
+//	UPDATE shiro_batch_ref SET c1 = (shiro_batch_ref.c1 + 1) WHERE (shiro_batch_ref.c2 > 3)
+//	BATCH ON id LIMIT 2 UPDATE shiro_batch_dml SET c1 = (shiro_batch_dml.c1 + 1) WHERE (shiro_batch_dml.c2 > 3)
+//	-- both tables must hold the same rows
+type BatchDML struct{}
+
+// Name returns the oracle identifier.
+func (o BatchDML) Name() string { return "BatchDML" }
+
+// batchDMLPlan is one generated statement pair.
+type batchDMLPlan struct {
+	kind  string
+	shard string
+	limit int
+	// dml targets batchDMLTable; refDML is the same statement on batchDMLRefTable.
+	dml    string
+	refDML string
+}
+
+// batchSQL renders the non-transactional form of the statement.
+func (p batchDMLPlan) batchSQL() string {
+	on := ""
+	if p.shard != "" {
+		on = "ON " + p.shard + " "
+	}
+	return fmt.Sprintf("BATCH %sLIMIT %d %s", on, p.limit, p.dml)
+}
+
+// Run copies a base table twice, runs the ordinary and the batched statement,
+// and compares the copies. Both copies are dropped afterwards. The statements
+// run on one connection in autocommit mode, which BATCH requires.
+func (o BatchDML) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, state *schema.State) Result {
+	if !state.HasBaseTables() {
+		return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "batch_dml:no_base_tables"}}
+	}
+	baseTables := state.BaseTables()
+	src := baseTables[gen.Rand.Intn(len(baseTables))]
+	if len(src.Columns) == 0 {
+		return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "batch_dml:no_columns"}}
+	}
+	tbl := src
+	tbl.Name = batchDMLTable
+	plan, ok := planBatchDML(gen, tbl)
+	if !ok {
+		return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "batch_dml:predicate_guard"}}
+	}
+	shardName := plan.shard
+	if shardName == "" {
+		shardName = "default"
+	}
+	metrics := map[string]int64{
+		"batch_dml_" + plan.kind + "_total": 1,
+	}
+	if plan.shard == "" {
+		metrics["batch_dml_shard_default_total"] = 1
+	} else {
+		metrics["batch_dml_shard_column_total"] = 1
+	}
+	details := map[string]any{
+		"batch_dml_kind":   plan.kind,
+		"batch_dml_shard":  shardName,
+		"batch_dml_limit":  plan.limit,
+		"batch_dml_source": src.Name,
+	}
+
+	conn, err := exec.Conn(ctx)
+	if err != nil {
+		return Result{OK: true, Oracle: o.Name(), Err: err}
+	}
+	defer util.CloseWithErr(conn, "batch_dml conn")
+
+	var steps []sqlstep.Step
+	skip := func(reason string, err error) Result {
+		details["skip_reason"] = "batch_dml:" + reason
+		if err != nil {
+			sqlErrorDetails(details, "batch_dml", err)
+		}
+		return Result{OK: true, Oracle: o.Name(), Details: details, Metrics: metrics}
+	}
+
+	dropSQL := fmt.Sprintf("DROP TABLE IF EXISTS %s, %s", batchDMLTable, batchDMLRefTable)
+	if _, err := conn.ExecContext(ctx, dropSQL); err != nil {
+		return sqlErrorResult(o.Name(), "batch_dml", failedSteps(steps, sqlstep.KindSetup, dropSQL), metrics, details, err, dropSQL)
+	}
+	defer func() {
+		_, _ = conn.ExecContext(context.Background(), dropSQL)
+	}()
+	for _, stmt := range batchDMLCopySQL(src.Name) {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return skip("copy_failed", err)
+		}
+		steps = append(steps, sqlstep.New(sqlstep.KindSetup, "", stmt))
+	}
+
+	if _, err := conn.ExecContext(ctx, plan.refDML); err != nil {
+		return skip("dml_failed", err)
+	}
+	steps = append(steps, sqlstep.New(sqlstep.KindSetup, "", plan.refDML))
+	batchSQL := plan.batchSQL()
+	jobs, err := queryRowStrings(ctx, conn, exec.Guard, batchSQL)
+	if err != nil {
+		// A failed batch leaves the earlier batches committed, so the tables
+		// legitimately differ; statements TiDB cannot batch end up here too.
+		return skip("batch_failed", err)
+	}
+	steps = append(steps, sqlstep.New(sqlstep.KindSetup, "", batchSQL))
+	if len(jobs) > 0 && len(jobs[0]) > 0 {
+		details["batch_dml_jobs"] = jobs[0][0]
+	}
+
+	refTbl := tbl
+	refTbl.Name = batchDMLRefTable
+	refSQL := txnRYWSignatureSQL(refTbl, "", "1")
+	actualSQL := txnRYWSignatureSQL(tbl, "", "1")
+	expected, err := txnRYWQuerySignature(ctx, conn, refSQL)
+	if err != nil {
+		return sqlErrorResult(o.Name(), "batch_dml", failedSteps(steps, sqlstep.KindQuery, refSQL), metrics, details, err, refSQL)
+	}
+	actual, err := txnRYWQuerySignature(ctx, conn, actualSQL)
+	if err != nil {
+		return sqlErrorResult(o.Name(), "batch_dml", failedSteps(steps, sqlstep.KindQuery, actualSQL), metrics, details, err, actualSQL)
+	}
+	steps = append(steps,
+		sqlstep.New(sqlstep.KindQuery, sqlstep.RoleExpected, refSQL),
+		sqlstep.New(sqlstep.KindQuery, sqlstep.RoleActual, actualSQL),
+	)
+	if expected == actual {
+		return Result{OK: true, Oracle: o.Name(), Steps: steps, Details: details, Metrics: metrics}
+	}
+	refRows, refErr := queryRowStrings(ctx, conn, exec.Guard, fkCascadeChildSQL(refTbl))
+	rows, rowsErr := queryRowStrings(ctx, conn, exec.Guard, fkCascadeChildSQL(tbl))
+	if refErr == nil && rowsErr == nil {
+		missing, unexpected := fkCascadeDiffRows(refRows, rows)
+		details["batch_dml_missing_rows"] = fkCascadeSampleRows(missing)
+		details["batch_dml_unexpected_rows"] = fkCascadeSampleRows(unexpected)
+	}
+	return Result{
+		OK:       false,
+		Oracle:   o.Name(),
+		Steps:    steps,
+		Expected: savepointSigString(expected),
+		Actual:   savepointSigString(actual),
+		Details:  details,
+		Metrics:  metrics,
+	}
+}
+
+// planBatchDML generates a DELETE or UPDATE on tbl and picks its shard column
+// and batch size. It fails when no deterministic predicate was generated.
+func planBatchDML(gen *generator.Generator, tbl schema.Table) (batchDMLPlan, bool) {
+	predicate := gen.GenerateSimplePredicate([]schema.Table{tbl}, 2)
+	if predicate == nil || !predicate.Deterministic() {
+		return batchDMLPlan{}, false
+	}
+	plan := batchDMLPlan{
+		kind:  batchDMLKindDelete,
+		limit: 1 + gen.Rand.Intn(batchDMLLimitMax),
+		dml:   fmt.Sprintf("DELETE FROM %s WHERE %s", tbl.Name, buildExpr(predicate)),
+	}
+	updated := ""
+	if util.Chance(gen.Rand, batchDMLUpdateProb) {
+		// UpdateSQL never picks id or a foreign key column; only its SET
+		// clause is used, the predicate above stays free of subqueries.
+		if _, _, setExpr, col := gen.UpdateSQL(tbl); setExpr != nil {
+			plan.kind = batchDMLKindUpdate
+			plan.dml = fmt.Sprintf("UPDATE %s SET %s = %s WHERE %s", tbl.Name, col.Name, buildExpr(setExpr), buildExpr(predicate))
+			updated = col.Name
+		}
+	}
+	shards := batchDMLShardColumns(tbl, updated)
+	if len(shards) > 0 && !util.Chance(gen.Rand, batchDMLDefaultShard) {
+		plan.shard = shards[gen.Rand.Intn(len(shards))]
+	}
+	plan.refDML = strings.ReplaceAll(plan.dml, batchDMLTable, batchDMLRefTable)
+	return plan, true
+}
+
+// batchDMLShardColumns returns the indexed columns BATCH ON can split by:
+// the leading primary key column and the leading columns of secondary
+// indexes. The updated column is excluded, because TiDB rejects updates of
+// the shard column.
+func batchDMLShardColumns(tbl schema.Table, updated string) []string {
+	var out []string
+	add := func(name string) {
+		if name != "" && name != updated && !slices.Contains(out, name) {
+			out = append(out, name)
+		}
+	}
+	if pk := tbl.PrimaryKeyColumns(); len(pk) > 0 {
+		add(pk[0])
+	}
+	for _, idx := range tbl.Indexes {
+		if len(idx.Columns) > 0 {
+			add(idx.Columns[0])
+		}
+	}
+	for _, col := range tbl.Columns {
+		if col.HasIndex {
+			add(col.Name)
+		}
+	}
+	return out
+}
+
+// batchDMLCopySQL copies src into both scratch tables.
+func batchDMLCopySQL(src string) []string {
+	out := make([]string, 0, 4)
+	for _, name := range []string{batchDMLTable, batchDMLRefTable} {
+		out = append(out,
+			fmt.Sprintf("CREATE TABLE %s LIKE %s", name, src),
+			fmt.Sprintf("INSERT INTO %s SELECT * FROM %s", name, src),
+		)
+	}
+	return out
+}
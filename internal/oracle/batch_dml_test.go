@@ -0,0 +1,79 @@
+package oracle
+
+import (
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+
+	"shiro/internal/config"
+	"shiro/internal/generator"
+	"shiro/internal/schema"
+)
+
+func TestBatchDMLShardColumns(t *testing.T) {
+	tbl := schema.Table{
+		Name:    batchDMLTable,
+		HasPK:   true,
+		Columns: []schema.Column{{Name: "id"}, {Name: "c0", HasIndex: true}, {Name: "c1"}, {Name: "c2"}},
+		Indexes: []schema.Index{{Name: "idx_c1_c2", Columns: []string{"c1", "c2"}}},
+	}
+	if got := batchDMLShardColumns(tbl, ""); !slices.Equal(got, []string{"id", "c1", "c0"}) {
+		t.Fatalf("unexpected shard columns: %v", got)
+	}
+	if got := batchDMLShardColumns(tbl, "c1"); slices.Contains(got, "c1") {
+		t.Fatalf("updated column must not be a shard column: %v", got)
+	}
+	tbl.HasPK = false
+	tbl.Indexes = nil
+	if got := batchDMLShardColumns(tbl, "c0"); len(got) != 0 {
+		t.Fatalf("expected no shard column: %v", got)
+	}
+}
+
+func TestBatchDMLPlanSQL(t *testing.T) {
+	plan := batchDMLPlan{limit: 3, dml: "DELETE FROM shiro_batch_dml WHERE (shiro_batch_dml.c0 > 1)"}
+	if got := plan.batchSQL(); got != "BATCH LIMIT 3 DELETE FROM shiro_batch_dml WHERE (shiro_batch_dml.c0 > 1)" {
+		t.Fatalf("unexpected default shard SQL: %s", got)
+	}
+	plan.shard = "id"
+	if got := plan.batchSQL(); !strings.HasPrefix(got, "BATCH ON id LIMIT 3 DELETE") {
+		t.Fatalf("unexpected shard SQL: %s", got)
+	}
+}
+
+func TestPlanBatchDML(t *testing.T) {
+	cfg := config.Default()
+	tbl := schema.Table{
+		Name:  batchDMLTable,
+		HasPK: true,
+		Columns: []schema.Column{
+			{Name: "id", Type: schema.TypeBigInt},
+			{Name: "c0", Type: schema.TypeInt, Nullable: true, HasIndex: true},
+			{Name: "c1", Type: schema.TypeVarchar, Nullable: true},
+		},
+	}
+	state := &schema.State{Tables: []schema.Table{tbl}}
+	gen := generator.New(cfg, state, 1)
+	gen.Rand = rand.New(rand.NewSource(7))
+	kinds := map[string]bool{}
+	for i := 0; i < 50; i++ {
+		plan, ok := planBatchDML(gen, tbl)
+		if !ok {
+			continue
+		}
+		kinds[plan.kind] = true
+		if plan.limit < 1 || plan.limit > batchDMLLimitMax {
+			t.Fatalf("limit out of range: %d", plan.limit)
+		}
+		if strings.Contains(plan.refDML, batchDMLTable) || !strings.Contains(plan.refDML, batchDMLRefTable) {
+			t.Fatalf("reference statement must only target %s: %s", batchDMLRefTable, plan.refDML)
+		}
+		if plan.kind == batchDMLKindUpdate && plan.shard != "" && strings.Contains(plan.dml, "SET "+plan.shard+" =") {
+			t.Fatalf("shard column %s is updated: %s", plan.shard, plan.dml)
+		}
+	}
+	if !kinds[batchDMLKindDelete] || !kinds[batchDMLKindUpdate] {
+		t.Fatalf("expected both statement kinds, got %v", kinds)
+	}
+}
@@ -1,6 +1,11 @@
 package oracle
 
-import "strings"
+import (
+	"math"
+	"strings"
+
+	"shiro/internal/generator"
+)
 
 func builderSkipReason(prefix string, reason string) string {
 	if reason == "" {
@@ -9,3 +14,23 @@ func builderSkipReason(prefix string, reason string) string {
 	sanitized := strings.NewReplacer(":", "_", " ", "_").Replace(reason)
 	return prefix + ":builder_" + sanitized
 }
+
+// builderFailureDetails describes a failed build: the skip reason, the last
+// rejection, and how often each constraint rejected a candidate, so guard
+// thresholds can be tuned from the case data.
+func builderFailureDetails(prefix string, diag generator.BuildDiagnostics) map[string]any {
+	details := map[string]any{
+		"skip_reason":      builderSkipReason(prefix, diag.Reason),
+		"builder_reason":   diag.Reason,
+		"builder_attempts": diag.Attempts,
+	}
+	if len(diag.Rejections) > 0 {
+		details["builder_rejections"] = diag.Rejections
+		rates := diag.RejectionRates()
+		for reason, rate := range rates {
+			rates[reason] = math.Round(rate*1000) / 1000
+		}
+		details["builder_rejection_rates"] = rates
+	}
+	return details
+}
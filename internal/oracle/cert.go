@@ -3,11 +3,13 @@ package oracle
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 
 	"shiro/internal/db"
 	"shiro/internal/generator"
 	"shiro/internal/schema"
+	"shiro/internal/sqlstep"
 	"shiro/internal/util"
 )
 
@@ -38,13 +40,7 @@ func (o CERT) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, st
 	if o.Tolerance == 0 {
 		o.Tolerance = 0.1
 	}
-	constraints := certSelectConstraints()
-	if profile := ProfileByName("CERT"); profile != nil {
-		applyProfileToSpec(&constraints, profile)
-		if profile.PredicateMode != nil {
-			constraints.PredicateMode = *profile.PredicateMode
-		}
-	}
+	constraints := certSelectConstraints(gen)
 	builder := generator.NewSelectQueryBuilder(gen).WithConstraints(constraints)
 	if constraints.MaxTries > 0 {
 		builder.MaxTries(constraints.MaxTries)
@@ -139,7 +135,7 @@ func (o CERT) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, st
 			return Result{
 				OK:          true,
 				Oracle:      o.Name(),
-				SQL:         []string{baseExplain},
+				Steps:       []sqlstep.Step{sqlstep.New(sqlstep.KindVerify, sqlstep.RoleFailing, baseExplain)},
 				SQLFeatures: observed,
 				Err:         err,
 				Details: map[string]any{
@@ -164,7 +160,7 @@ func (o CERT) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, st
 				return Result{
 					OK:          true,
 					Oracle:      o.Name(),
-					SQL:         []string{baseNoWhereExplain},
+					Steps:       []sqlstep.Step{sqlstep.New(sqlstep.KindVerify, sqlstep.RoleFailing, baseNoWhereExplain)},
 					SQLFeatures: observed,
 					Err:         err,
 					Details: map[string]any{
@@ -204,7 +200,7 @@ func (o CERT) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, st
 	}
 	if o.MinBaseRows > 0 && baseRows < o.MinBaseRows {
 		observed = recordObservedResultSQL(observed, restricted.SQLString(), sqlSubqueryFeaturesFromQuery(restricted))
-		return Result{OK: true, Oracle: o.Name(), SQL: []string{base.SQLString(), restricted.SQLString()}, SQLFeatures: observed, Details: map[string]any{"skip_reason": "cert:base_rows_low"}}
+		return Result{OK: true, Oracle: o.Name(), Steps: comparisonSteps(base.SQLString(), restricted.SQLString()), SQLFeatures: observed, Details: map[string]any{"skip_reason": "cert:base_rows_low"}}
 	}
 	restrictedExplain := "EXPLAIN " + restricted.SQLString()
 	restrictedFeatures := sqlSubqueryFeaturesFromQuery(restricted)
@@ -215,7 +211,7 @@ func (o CERT) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, st
 		return Result{
 			OK:          true,
 			Oracle:      o.Name(),
-			SQL:         []string{restrictedExplain},
+			Steps:       []sqlstep.Step{sqlstep.New(sqlstep.KindVerify, sqlstep.RoleFailing, restrictedExplain)},
 			SQLFeatures: observed,
 			Err:         err,
 			Details: map[string]any{
@@ -227,44 +223,54 @@ func (o CERT) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, st
 	if restrictedRows > baseRows*(1.0+o.Tolerance) {
 		expectedExplain, expectedExplainErr := explainSQL(ctx, exec, base.SQLString())
 		actualExplain, actualExplainErr := explainSQL(ctx, exec, restricted.SQLString())
+		details := map[string]any{
+			"base_est_rows":        baseRows,
+			"restricted_est_rows":  restrictedRows,
+			"replay_kind":          "plan_rows",
+			"replay_expected_sql":  base.SQLString(),
+			"replay_actual_sql":    restricted.SQLString(),
+			"replay_tolerance":     o.Tolerance,
+			"expected_explain":     expectedExplain,
+			"actual_explain":       actualExplain,
+			"expected_explain_err": errString(expectedExplainErr),
+			"actual_explain_err":   errString(actualExplainErr),
+		}
+		// Skewed or NULL-heavy data explains many estimate regressions; keep the
+		// intended distribution next to the plans.
+		if profiles := gen.DataProfilesForQuery(base); len(profiles) > 0 {
+			details["data_profiles"] = profiles
+		}
+		// The runner attaches the statistics of these tables to the case.
+		if names := certTableNames(tablesForQuery(restricted, state)); len(names) > 0 {
+			details["cert_tables"] = names
+		}
 		return Result{
 			OK:          false,
 			Oracle:      o.Name(),
-			SQL:         []string{base.SQLString(), restricted.SQLString()},
+			Steps:       comparisonSteps(base.SQLString(), restricted.SQLString()),
 			SQLFeatures: observed,
 			Expected:    fmt.Sprintf("restricted estRows <= %.2f", baseRows),
 			Actual:      fmt.Sprintf("restricted estRows %.2f", restrictedRows),
-			Details: map[string]any{
-				"base_est_rows":        baseRows,
-				"restricted_est_rows":  restrictedRows,
-				"replay_kind":          "plan_rows",
-				"replay_expected_sql":  base.SQLString(),
-				"replay_actual_sql":    restricted.SQLString(),
-				"replay_tolerance":     o.Tolerance,
-				"expected_explain":     expectedExplain,
-				"actual_explain":       actualExplain,
-				"expected_explain_err": errString(expectedExplainErr),
-				"actual_explain_err":   errString(actualExplainErr),
-			},
+			Details:     details,
 		}
 	}
-	return Result{OK: true, Oracle: o.Name(), SQL: []string{base.SQLString(), restricted.SQLString()}, SQLFeatures: observed}
+	return Result{OK: true, Oracle: o.Name(), Steps: comparisonSteps(base.SQLString(), restricted.SQLString()), SQLFeatures: observed}
 }
 
-func certSelectConstraints() generator.SelectQueryConstraints {
-	return generator.SelectQueryConstraints{
-		RequireWhere:         true,
-		PredicateMode:        generator.PredicateModeSimple,
-		RequireDeterministic: true,
-		DisallowAggregate:    true,
-		DisallowDistinct:     true,
-		DisallowGroupBy:      true,
-		DisallowHaving:       true,
-		DisallowOrderBy:      true,
-		DisallowSetOps:       true,
-		DisallowWindow:       true,
-		MaxTries:             10,
+// certTableNames returns the names of the base tables, skipping views, which
+// have no statistics.
+func certTableNames(tables []schema.Table) []string {
+	var names []string
+	for _, tbl := range tables {
+		if !tbl.IsView && !slices.Contains(names, tbl.Name) {
+			names = append(names, tbl.Name)
+		}
 	}
+	return names
+}
+
+func certSelectConstraints(gen *generator.Generator) generator.SelectQueryConstraints {
+	return querySpecConstraints(gen, "cert", ProfileByName("CERT"), generator.SelectQueryConstraints{MaxTries: 10})
 }
 
 func certBaseTables(tables []schema.Table, state *schema.State) []schema.Table {
@@ -427,9 +433,9 @@ func compatibleJoinColumnType(left schema.ColumnType, right schema.ColumnType) b
 
 func joinTypeCategory(typ schema.ColumnType) int {
 	switch typ {
-	case schema.TypeInt, schema.TypeBigInt, schema.TypeFloat, schema.TypeDouble, schema.TypeDecimal, schema.TypeBool:
+	case schema.TypeInt, schema.TypeBigInt, schema.TypeFloat, schema.TypeDouble, schema.TypeDecimal, schema.TypeBool, schema.TypeBit, schema.TypeYear:
 		return 1
-	case schema.TypeVarchar:
+	case schema.TypeVarchar, schema.TypeEnum, schema.TypeSet:
 		return 2
 	case schema.TypeDate, schema.TypeDatetime, schema.TypeTimestamp:
 		return 3
@@ -9,6 +9,7 @@ import (
 	"shiro/internal/db"
 	"shiro/internal/generator"
 	"shiro/internal/schema"
+	"shiro/internal/sqlstep"
 )
 
 func TestCERTNoTablesSkip(t *testing.T) {
@@ -28,7 +29,7 @@ func TestCERTNoTablesSkip(t *testing.T) {
 }
 
 func TestCERTSelectConstraintsGuardrails(t *testing.T) {
-	c := certSelectConstraints()
+	c := certSelectConstraints(nil)
 	if !c.RequireWhere {
 		t.Fatalf("expected RequireWhere")
 	}
@@ -56,10 +57,18 @@ func TestCERTExplainErrorReturnsSQLFeaturesForReturnedSQL(t *testing.T) {
 	if res.Err == nil {
 		t.Fatalf("expected validation error result")
 	}
-	if len(res.SQL) != 1 {
-		t.Fatalf("expected single EXPLAIN SQL, got %v", res.SQL)
+	if len(res.Steps) != 1 || res.Steps[0].Kind != sqlstep.KindVerify || res.Steps[0].Role != sqlstep.RoleFailing {
+		t.Fatalf("expected single failing EXPLAIN step, got %+v", res.Steps)
 	}
-	if _, ok := res.SQLFeatures[res.SQL[0]]; !ok {
-		t.Fatalf("missing SQL features for returned SQL %q", res.SQL[0])
+	if _, ok := res.SQLFeatures[res.Steps[0].SQL]; !ok {
+		t.Fatalf("missing SQL features for returned SQL %q", res.Steps[0].SQL)
+	}
+}
+
+func TestCERTTableNames(t *testing.T) {
+	tables := []schema.Table{{Name: "t0"}, {Name: "v0", IsView: true}, {Name: "t1"}, {Name: "t0"}}
+	names := certTableNames(tables)
+	if len(names) != 2 || names[0] != "t0" || names[1] != "t1" {
+		t.Fatalf("unexpected cert tables: %v", names)
 	}
 }
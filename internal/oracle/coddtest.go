@@ -10,6 +10,7 @@ import (
 	"shiro/internal/db"
 	"shiro/internal/generator"
 	"shiro/internal/schema"
+	"shiro/internal/sqlstep"
 	"shiro/internal/util"
 )
 
@@ -68,26 +69,14 @@ func (o CODDTest) Run(ctx context.Context, exec *db.DB, gen *generator.Generator
 	predicateGuard := func(expr generator.Expr) bool {
 		return predicateMatches(expr, policy)
 	}
-	constraints := generator.SelectQueryConstraints{
-		RequireWhere:         true,
-		PredicateMode:        generator.PredicateModeSimple,
-		RequireDeterministic: true,
-		DisallowAggregate:    true,
-		MaxJoinCount:         2,
-		MaxJoinCountSet:      true,
-		QueryGuardReason:     queryGuard,
-		PredicateGuard:       predicateGuard,
-	}
-	if profile := ProfileByName("CODDTest"); profile != nil {
-		applyProfileToSpec(&constraints, profile)
-		if profile.PredicateMode != nil {
-			constraints.PredicateMode = *profile.PredicateMode
-		}
-	}
+	constraints := querySpecConstraints(gen, "coddtest", ProfileByName("CODDTest"), generator.SelectQueryConstraints{
+		QueryGuardReason: queryGuard,
+		PredicateGuard:   predicateGuard,
+	})
 	builder := generator.NewSelectQueryBuilder(gen).WithConstraints(constraints)
-	query, reason, attempts := builder.BuildWithReason()
+	query, diag := builder.BuildWithDiagnostics()
 	if query == nil || query.Where == nil {
-		return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": builderSkipReason("coddtest", reason), "builder_reason": reason, "builder_attempts": attempts}}
+		return Result{OK: true, Oracle: o.Name(), Details: builderFailureDetails("coddtest", diag)}
 	}
 	phi := query.Where
 	if !phi.Deterministic() || exprHasSubquery(phi) {
@@ -127,7 +116,11 @@ func onlySupportedCODDColumns(columns []generator.ColumnRef) bool {
 			schema.TypeDate,
 			schema.TypeDatetime,
 			schema.TypeTimestamp,
-			schema.TypeBool:
+			schema.TypeBool,
+			schema.TypeBit,
+			schema.TypeEnum,
+			schema.TypeSet,
+			schema.TypeYear:
 			continue
 		default:
 			return false
@@ -200,7 +193,7 @@ func (o CODDTest) runIndependent(ctx context.Context, exec *db.DB, _ *generator.
 	row := exec.QueryRowContext(ctx, auxSQL)
 	var auxVal sql.RawBytes
 	if err := row.Scan(&auxVal); err != nil {
-		return Result{OK: true, Oracle: o.Name(), SQL: []string{auxSQL}, Err: err}
+		return Result{OK: true, Oracle: o.Name(), Steps: []sqlstep.Step{queryStep(sqlstep.RoleFailing, auxSQL)}, Err: err}
 	}
 	mapped := buildLiteralFromBytes(auxVal, schema.TypeBool)
 
@@ -219,11 +212,11 @@ func (o CODDTest) runIndependent(ctx context.Context, exec *db.DB, _ *generator.
 
 	origSig, err := exec.QuerySignature(ctx, baseSignatureSQL)
 	if err != nil {
-		return Result{OK: true, Oracle: o.Name(), SQL: []string{base.SQLString()}, SQLFeatures: observed, Err: err}
+		return Result{OK: true, Oracle: o.Name(), Steps: []sqlstep.Step{queryStep(sqlstep.RoleFailing, base.SQLString())}, SQLFeatures: observed, Err: err}
 	}
 	foldSig, err := exec.QuerySignature(ctx, foldedSignatureSQL)
 	if err != nil {
-		return Result{OK: true, Oracle: o.Name(), SQL: []string{folded.SQLString()}, SQLFeatures: observed, Err: err}
+		return Result{OK: true, Oracle: o.Name(), Steps: coddtestFailedFoldSteps(base, folded), SQLFeatures: observed, Err: err}
 	}
 	if origSig != foldSig {
 		expectedExplain, expectedExplainErr := explainSQL(ctx, exec, baseSignatureSQL)
@@ -231,7 +224,7 @@ func (o CODDTest) runIndependent(ctx context.Context, exec *db.DB, _ *generator.
 		return Result{
 			OK:          false,
 			Oracle:      o.Name(),
-			SQL:         []string{base.SQLString(), folded.SQLString(), auxSQL},
+			Steps:       coddtestSteps(base, folded, auxSQL),
 			SQLFeatures: observed,
 			Expected:    fmt.Sprintf("cnt=%d checksum=%d", origSig.Count, origSig.Checksum),
 			Actual:      fmt.Sprintf("cnt=%d checksum=%d", foldSig.Count, foldSig.Checksum),
@@ -246,7 +239,7 @@ func (o CODDTest) runIndependent(ctx context.Context, exec *db.DB, _ *generator.
 			},
 		}
 	}
-	return Result{OK: true, Oracle: o.Name(), SQL: []string{base.SQLString(), folded.SQLString(), auxSQL}, SQLFeatures: observed}
+	return Result{OK: true, Oracle: o.Name(), Steps: coddtestSteps(base, folded, auxSQL), SQLFeatures: observed}
 }
 
 func (o CODDTest) runDependent(ctx context.Context, exec *db.DB, gen *generator.Generator, query *generator.SelectQuery, phi generator.Expr, cols []generator.ColumnRef) Result {
@@ -259,29 +252,20 @@ func (o CODDTest) runDependent(ctx context.Context, exec *db.DB, gen *generator.
 	auxSQL := fmt.Sprintf("SELECT %s, %s AS v FROM %s LIMIT 50", strings.Join(colNames, ", "), buildExpr(phi), buildFrom(query))
 	rows, err := exec.QueryContext(ctx, auxSQL)
 	if err != nil {
-		return Result{OK: true, Oracle: o.Name(), SQL: []string{auxSQL}, Err: err}
+		return Result{OK: true, Oracle: o.Name(), Steps: []sqlstep.Step{queryStep(sqlstep.RoleFailing, auxSQL)}, Err: err}
 	}
 	defer util.CloseWithErr(rows, "coddtest rows")
 
 	seen := make(map[string]struct{})
 	caseExpr := generator.CaseExpr{}
-	for rows.Next() {
-		values := make([]sql.RawBytes, len(colNames)+1)
-		scanArgs := make([]any, len(values))
-		for i := range values {
-			scanArgs[i] = &values[i]
-		}
-		if err := rows.Scan(scanArgs...); err != nil {
-			return Result{OK: true, Oracle: o.Name(), SQL: []string{auxSQL}, Err: err}
-		}
-
+	err = exec.Guard.ScanRows(rows, func(values []sql.RawBytes) error {
 		key := coddtestCaseKey(cols, values)
 		if _, ok := seen[key]; ok {
-			continue
+			return nil
 		}
 		seen[key] = struct{}{}
 		if len(caseExpr.Whens) >= caseWhenMax {
-			break
+			return db.ErrStopScan
 		}
 
 		var cond generator.Expr
@@ -296,23 +280,24 @@ func (o CODDTest) runDependent(ctx context.Context, exec *db.DB, gen *generator.
 		}
 		resultVal := buildLiteralFromBytes(values[len(values)-1], schema.TypeBool)
 		caseExpr.Whens = append(caseExpr.Whens, generator.CaseWhen{When: cond, Then: resultVal})
-	}
-	if err := rows.Err(); err != nil {
-		return Result{OK: true, Oracle: o.Name(), SQL: []string{auxSQL}, Err: err}
+		return nil
+	})
+	if err != nil {
+		return Result{OK: true, Oracle: o.Name(), Steps: []sqlstep.Step{queryStep(sqlstep.RoleFailing, auxSQL)}, Err: err}
 	}
 
 	if len(caseExpr.Whens) == 0 {
-		return Result{OK: true, Oracle: o.Name(), SQL: []string{auxSQL}}
+		return Result{OK: true, Oracle: o.Name(), Steps: []sqlstep.Step{queryStep("", auxSQL)}}
 	}
 	caseExpr.Else = generator.LiteralExpr{Value: nil}
 
 	totalSQL := fmt.Sprintf("SELECT COUNT(*) FROM %s", buildFrom(query))
 	total, err := exec.QueryCount(ctx, totalSQL)
 	if err != nil {
-		return Result{OK: true, Oracle: o.Name(), SQL: []string{auxSQL, totalSQL}, Err: err}
+		return Result{OK: true, Oracle: o.Name(), Steps: []sqlstep.Step{queryStep("", auxSQL), queryStep(sqlstep.RoleFailing, totalSQL)}, Err: err}
 	}
 	if total > int64(len(caseExpr.Whens)) {
-		return Result{OK: true, Oracle: o.Name(), SQL: []string{auxSQL, totalSQL}}
+		return Result{OK: true, Oracle: o.Name(), Steps: []sqlstep.Step{queryStep("", auxSQL), queryStep("", totalSQL)}}
 	}
 
 	base := query.Clone()
@@ -330,11 +315,11 @@ func (o CODDTest) runDependent(ctx context.Context, exec *db.DB, gen *generator.
 
 	origSig, err := exec.QuerySignature(ctx, baseSignatureSQL)
 	if err != nil {
-		return Result{OK: true, Oracle: o.Name(), SQL: []string{base.SQLString()}, SQLFeatures: observed, Err: err}
+		return Result{OK: true, Oracle: o.Name(), Steps: []sqlstep.Step{queryStep(sqlstep.RoleFailing, base.SQLString())}, SQLFeatures: observed, Err: err}
 	}
 	foldSig, err := exec.QuerySignature(ctx, foldedSignatureSQL)
 	if err != nil {
-		return Result{OK: true, Oracle: o.Name(), SQL: []string{folded.SQLString()}, SQLFeatures: observed, Err: err}
+		return Result{OK: true, Oracle: o.Name(), Steps: coddtestFailedFoldSteps(base, folded), SQLFeatures: observed, Err: err}
 	}
 	if origSig != foldSig {
 		expectedExplain, expectedExplainErr := explainSQL(ctx, exec, baseSignatureSQL)
@@ -342,7 +327,7 @@ func (o CODDTest) runDependent(ctx context.Context, exec *db.DB, gen *generator.
 		return Result{
 			OK:          false,
 			Oracle:      o.Name(),
-			SQL:         []string{base.SQLString(), folded.SQLString(), auxSQL},
+			Steps:       coddtestSteps(base, folded, auxSQL),
 			SQLFeatures: observed,
 			Expected:    fmt.Sprintf("cnt=%d checksum=%d", origSig.Count, origSig.Checksum),
 			Actual:      fmt.Sprintf("cnt=%d checksum=%d", foldSig.Count, foldSig.Checksum),
@@ -357,7 +342,20 @@ func (o CODDTest) runDependent(ctx context.Context, exec *db.DB, gen *generator.
 			},
 		}
 	}
-	return Result{OK: true, Oracle: o.Name(), SQL: []string{base.SQLString(), folded.SQLString(), auxSQL}, SQLFeatures: observed}
+	return Result{OK: true, Oracle: o.Name(), Steps: coddtestSteps(base, folded, auxSQL), SQLFeatures: observed}
+}
+
+// coddtestSteps records the base query, the folded query checked against it,
+// and the auxiliary query that computed the folding.
+func coddtestSteps(base, folded *generator.SelectQuery, auxSQL string) []sqlstep.Step {
+	return append(comparisonSteps(base.SQLString(), folded.SQLString()), queryStep("", auxSQL))
+}
+
+func coddtestFailedFoldSteps(base, folded *generator.SelectQuery) []sqlstep.Step {
+	return []sqlstep.Step{
+		queryStep(sqlstep.RoleExpected, base.SQLString()),
+		queryStep(sqlstep.RoleFailing, folded.SQLString()),
+	}
 }
 
 func coddtestCaseWhenMax(gen *generator.Generator) int {
@@ -457,11 +455,19 @@ func buildLiteralFromBytes(b sql.RawBytes, colType schema.ColumnType) generator.
 	}
 	text := string(b)
 	switch colType {
-	case schema.TypeInt, schema.TypeBigInt:
+	case schema.TypeInt, schema.TypeBigInt, schema.TypeYear:
 		if v, err := strconv.ParseInt(text, 10, 64); err == nil {
 			return generator.LiteralExpr{Value: v}
 		}
 		return generator.LiteralExpr{Value: text}
+	case schema.TypeBit:
+		// BIT values arrive as big-endian bytes. Compared with a string the
+		// column is a binary string, so rebuild the integer instead.
+		var v uint64
+		for _, c := range b {
+			v = v<<8 | uint64(c)
+		}
+		return generator.LiteralExpr{Value: int64(v)}
 	case schema.TypeFloat, schema.TypeDouble, schema.TypeDecimal:
 		// Preserve exact formatting to avoid float rounding mismatches in CASE mapping.
 		return generator.LiteralExpr{Value: text}
@@ -48,6 +48,18 @@ func TestOnlySupportedCODDColumnsRejectsUnknownType(t *testing.T) {
 	}
 }
 
+func TestBuildLiteralFromBytesExtendedTypes(t *testing.T) {
+	if got := buildLiteralFromBytes(sql.RawBytes{0x01, 0x02}, schema.TypeBit).Value; got != int64(258) {
+		t.Fatalf("bit literal=%v want=258", got)
+	}
+	if got := buildLiteralFromBytes(sql.RawBytes("2024"), schema.TypeYear).Value; got != int64(2024) {
+		t.Fatalf("year literal=%v want=2024", got)
+	}
+	if got := buildLiteralFromBytes(sql.RawBytes("c,a"), schema.TypeSet).Value; got != "c,a" {
+		t.Fatalf("set literal=%v want=c,a", got)
+	}
+}
+
 func TestCODDTestPredicatePrecheckReason(t *testing.T) {
 	state := &schema.State{
 		Tables: []schema.Table{
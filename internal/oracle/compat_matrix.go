@@ -0,0 +1,313 @@
+package oracle
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"shiro/internal/config"
+	"shiro/internal/generator"
+)
+
+// SQLCompat is one row of the oracle SQL feature compatibility matrix. Feature
+// fields set to true allow that feature in generated queries; MaxJoins of 0
+// leaves the join count unbounded.
+type SQLCompat struct {
+	RequireWhere         bool
+	RequireDeterministic bool
+	PredicateMode        generator.PredicateMode
+
+	Subquery  bool
+	Aggregate bool
+	Window    bool
+	Limit     bool
+	OrderBy   bool
+	Distinct  bool
+	GroupBy   bool
+	Having    bool
+	CTE       bool
+	SetOps    bool
+	MaxJoins  int
+}
+
+// compatMatrix declares the static SQL features and predicate mode each
+// builder-backed oracle accepts, keyed by the QuerySpec oracle name. It is
+// the only place these are restricted: profiles of matrix oracles leave them
+// alone, so the rows logged at startup, with their overrides, are what the
+// generator enforces. Dynamic checks (query and predicate guards) stay with
+// the oracle.
+var compatMatrix = map[string]SQLCompat{
+	"cert": {
+		RequireWhere: true, RequireDeterministic: true, PredicateMode: generator.PredicateModeSimple,
+		Subquery: true, Limit: true, CTE: true,
+	},
+	"coddtest": {
+		RequireWhere: true, RequireDeterministic: true, PredicateMode: generator.PredicateModeSimpleColumns,
+		MaxJoins: 2,
+	},
+	"cursor_fetch": {
+		RequireDeterministic: true,
+		Subquery:             true, Aggregate: true, OrderBy: true, Distinct: true, GroupBy: true, Having: true, CTE: true, SetOps: true,
+		MaxJoins: 3,
+	},
+	"dqp": {
+		RequireDeterministic: true, PredicateMode: generator.PredicateModeSimpleColumns,
+		Subquery: true, OrderBy: true,
+		MaxJoins: 3,
+	},
+	"eet": {
+		RequireDeterministic: true,
+		Subquery:             true, Aggregate: true, Window: true, Limit: true, OrderBy: true, Distinct: true, GroupBy: true, Having: true, CTE: true, SetOps: true,
+	},
+	"full_join": {
+		RequireDeterministic: true,
+		Subquery:             true, OrderBy: true, CTE: true,
+		MaxJoins: 1,
+	},
+	"in_list": {
+		RequireDeterministic: true,
+		Subquery:             true, Aggregate: true, OrderBy: true, Distinct: true, GroupBy: true, Having: true, CTE: true,
+		MaxJoins: 3,
+	},
+	"norec": {
+		RequireWhere: true, RequireDeterministic: true, PredicateMode: generator.PredicateModeSimple,
+		Subquery: true, CTE: true,
+	},
+	"privilege": {
+		RequireDeterministic: true,
+		Subquery:             true, Aggregate: true, OrderBy: true, Distinct: true, GroupBy: true, Having: true, CTE: true, SetOps: true,
+		MaxJoins: 3,
+	},
+	"result_type": {
+		RequireDeterministic: true, PredicateMode: generator.PredicateModeSimpleColumns,
+		Subquery: true, Aggregate: true, OrderBy: true, Distinct: true, GroupBy: true, Having: true, CTE: true,
+		MaxJoins: 3,
+	},
+	"tiflash_only": {
+		RequireDeterministic: true, PredicateMode: generator.PredicateModeSimple,
+		Aggregate: true, OrderBy: true, Distinct: true, GroupBy: true, Having: true,
+	},
+	"tlp": {
+		RequireWhere: true, RequireDeterministic: true, PredicateMode: generator.PredicateModeSimpleColumns,
+		Subquery: true,
+	},
+}
+
+// compatOracles maps oracle names to their matrix rows.
+var compatOracles = map[string]string{
+	"CERT":        "cert",
+	"CODDTest":    "coddtest",
+	"CursorFetch": "cursor_fetch",
+	"DQP":         "dqp",
+	"EET":         "eet",
+	"FullJoin":    "full_join",
+	"InList":      "in_list",
+	"NoREC":       "norec",
+	"Privilege":   "privilege",
+	"ResultType":  "result_type",
+	"TiFlashOnly": "tiflash_only",
+	"TLP":         "tlp",
+}
+
+// ResolveOracleCompat returns the resolved compatibility row of the oracle
+// with the given Name(). ok is false for oracles outside the matrix.
+func ResolveOracleCompat(oracleName string, overrides map[string]config.OracleCompatOverride) (SQLCompat, bool) {
+	name, ok := compatOracles[oracleName]
+	if !ok {
+		return SQLCompat{}, false
+	}
+	return ResolveCompat(name, overrides)
+}
+
+// ResolveCompat returns the compatibility row for an oracle with config
+// overrides applied. ok is false for oracles outside the matrix.
+func ResolveCompat(name string, overrides map[string]config.OracleCompatOverride) (SQLCompat, bool) {
+	compat, ok := compatMatrix[name]
+	if !ok {
+		return SQLCompat{}, false
+	}
+	override, ok := overrides[name]
+	if !ok {
+		return compat, true
+	}
+	for _, item := range []struct {
+		dst *bool
+		src *bool
+	}{
+		{&compat.Subquery, override.Subquery},
+		{&compat.Aggregate, override.Aggregate},
+		{&compat.Window, override.Window},
+		{&compat.Limit, override.Limit},
+		{&compat.OrderBy, override.OrderBy},
+		{&compat.Distinct, override.Distinct},
+		{&compat.GroupBy, override.GroupBy},
+		{&compat.Having, override.Having},
+		{&compat.CTE, override.CTE},
+		{&compat.SetOps, override.SetOps},
+	} {
+		if item.src != nil {
+			*item.dst = *item.src
+		}
+	}
+	if override.MaxJoins != nil {
+		compat.MaxJoins = *override.MaxJoins
+	}
+	return compat, true
+}
+
+// UnknownCompatOverrides returns override keys that name no matrix row.
+func UnknownCompatOverrides(overrides map[string]config.OracleCompatOverride) []string {
+	var unknown []string
+	for name := range overrides {
+		if _, ok := compatMatrix[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// FormatCompatMatrix renders the resolved matrix as one line per oracle,
+// sorted by name, for the startup log.
+func FormatCompatMatrix(overrides map[string]config.OracleCompatOverride) []string {
+	names := make([]string, 0, len(compatMatrix))
+	for name := range compatMatrix {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		compat, _ := ResolveCompat(name, overrides)
+		lines = append(lines, fmt.Sprintf("%s %s", name, compat.String()))
+	}
+	return lines
+}
+
+// String lists required clauses, the predicate mode, allowed and denied
+// features, and the join cap.
+func (c SQLCompat) String() string {
+	var require, allow, deny []string
+	if c.RequireWhere {
+		require = append(require, "where")
+	}
+	if c.RequireDeterministic {
+		require = append(require, "deterministic")
+	}
+	for _, item := range []struct {
+		name    string
+		allowed bool
+	}{
+		{"subquery", c.Subquery},
+		{"aggregate", c.Aggregate},
+		{"window", c.Window},
+		{"limit", c.Limit},
+		{"order_by", c.OrderBy},
+		{"distinct", c.Distinct},
+		{"group_by", c.GroupBy},
+		{"having", c.Having},
+		{"cte", c.CTE},
+		{"set_ops", c.SetOps},
+	} {
+		if item.allowed {
+			allow = append(allow, item.name)
+		} else {
+			deny = append(deny, item.name)
+		}
+	}
+	maxJoins := "unbounded"
+	if c.MaxJoins > 0 {
+		maxJoins = fmt.Sprintf("%d", c.MaxJoins)
+	}
+	return fmt.Sprintf("require=%s predicates=%s allow=%s deny=%s max_joins=%s",
+		compatList(require), predicateModeName(c.PredicateMode), compatList(allow), compatList(deny), maxJoins)
+}
+
+// apply copies the static constraints of the row into dst, leaving guards
+// and retry limits untouched.
+func (c SQLCompat) apply(dst *generator.SelectQueryConstraints) {
+	dst.RequireWhere = c.RequireWhere
+	dst.RequireDeterministic = c.RequireDeterministic
+	dst.PredicateMode = c.PredicateMode
+	dst.DisallowSubquery = !c.Subquery
+	dst.DisallowAggregate = !c.Aggregate
+	dst.DisallowWindow = !c.Window
+	dst.DisallowLimit = !c.Limit
+	dst.DisallowOrderBy = !c.OrderBy
+	dst.DisallowDistinct = !c.Distinct
+	dst.DisallowGroupBy = !c.GroupBy
+	dst.DisallowHaving = !c.Having
+	dst.DisallowCTE = !c.CTE
+	dst.DisallowSetOps = !c.SetOps
+	dst.MaxJoinCount = c.MaxJoins
+	dst.MaxJoinCountSet = c.MaxJoins > 0
+}
+
+// ApplyFeatures turns off the generator features the row denies, so queries
+// generated outside the builder during the oracle's run follow the row too.
+// Allowed features keep their configured value.
+func (c SQLCompat) ApplyFeatures(dst *config.Features) {
+	if !c.Subquery {
+		dst.Subqueries = false
+		dst.NotExists = false
+		dst.NotIn = false
+	}
+	if !c.Aggregate {
+		dst.Aggregates = false
+		dst.GroupBy = false
+		dst.Having = false
+	}
+	if !c.GroupBy {
+		dst.GroupBy = false
+	}
+	if !c.Having {
+		dst.Having = false
+	}
+	if !c.Distinct {
+		dst.Distinct = false
+	}
+	if !c.OrderBy {
+		dst.OrderBy = false
+	}
+	if !c.Limit {
+		dst.Limit = false
+	}
+	if !c.Window {
+		dst.WindowFuncs = false
+	}
+	if !c.CTE {
+		dst.CTE = false
+	}
+	if !c.SetOps {
+		dst.SetOperations = false
+	}
+}
+
+func applyCompat(gen *generator.Generator, name string, dst *generator.SelectQueryConstraints) {
+	var overrides map[string]config.OracleCompatOverride
+	if gen != nil {
+		overrides = gen.Config.Oracles.Compat
+	}
+	if compat, ok := ResolveCompat(name, overrides); ok {
+		compat.apply(dst)
+	}
+}
+
+func compatList(items []string) string {
+	if len(items) == 0 {
+		return "-"
+	}
+	return strings.Join(items, ",")
+}
+
+func predicateModeName(mode generator.PredicateMode) string {
+	switch mode {
+	case generator.PredicateModeNone:
+		return "none"
+	case generator.PredicateModeSimple:
+		return "simple"
+	case generator.PredicateModeSimpleColumns:
+		return "simple_columns"
+	default:
+		return "default"
+	}
+}
@@ -0,0 +1,151 @@
+package oracle
+
+import (
+	"strings"
+	"testing"
+
+	"shiro/internal/config"
+	"shiro/internal/generator"
+)
+
+func TestCompatMatrixMatchesOracleGuardrails(t *testing.T) {
+	var dqp generator.SelectQueryConstraints
+	applyCompat(nil, "dqp", &dqp)
+	if !dqp.RequireDeterministic || dqp.PredicateMode != generator.PredicateModeSimpleColumns {
+		t.Fatalf("unexpected dqp requirements: %+v", dqp)
+	}
+	if !dqp.DisallowLimit || !dqp.DisallowWindow || !dqp.DisallowSetOps || !dqp.DisallowAggregate || dqp.DisallowOrderBy {
+		t.Fatalf("unexpected dqp features: %+v", dqp)
+	}
+	if !dqp.MaxJoinCountSet || dqp.MaxJoinCount != 3 {
+		t.Fatalf("unexpected dqp join cap: %d (set=%v)", dqp.MaxJoinCount, dqp.MaxJoinCountSet)
+	}
+	var eet generator.SelectQueryConstraints
+	applyCompat(nil, "eet", &eet)
+	if eet.RequireWhere || eet.DisallowLimit || eet.DisallowSetOps || eet.MaxJoinCountSet {
+		t.Fatalf("unexpected eet constraints: %+v", eet)
+	}
+	var unknown generator.SelectQueryConstraints
+	applyCompat(nil, "profile_test", &unknown)
+	if unknown.RequireDeterministic || unknown.DisallowLimit {
+		t.Fatalf("oracles outside the matrix must keep their constraints: %+v", unknown)
+	}
+}
+
+func TestResolveCompatOverrides(t *testing.T) {
+	overrides := map[string]config.OracleCompatOverride{
+		"tlp": {Window: BoolPtr(true), Subquery: BoolPtr(false), MaxJoins: IntPtr(2)},
+	}
+	compat, ok := ResolveCompat("tlp", overrides)
+	if !ok {
+		t.Fatalf("expected tlp row")
+	}
+	if !compat.Window || compat.Subquery || compat.MaxJoins != 2 || !compat.RequireWhere {
+		t.Fatalf("unexpected tlp row: %+v", compat)
+	}
+	if base := compatMatrix["tlp"]; base.Window || !base.Subquery {
+		t.Fatalf("overrides must not modify the built-in matrix: %+v", base)
+	}
+	if _, ok := ResolveCompat("missing", overrides); ok {
+		t.Fatalf("unexpected row for missing oracle")
+	}
+	if got := UnknownCompatOverrides(map[string]config.OracleCompatOverride{"tlp": {}, "zzz": {}, "aaa": {}}); strings.Join(got, ",") != "aaa,zzz" {
+		t.Fatalf("unexpected unknown overrides: %v", got)
+	}
+}
+
+func TestFormatCompatMatrix(t *testing.T) {
+	lines := FormatCompatMatrix(nil)
+	if len(lines) != len(compatMatrix) {
+		t.Fatalf("expected one line per oracle, got %d", len(lines))
+	}
+	var tiflash string
+	for _, line := range lines {
+		if strings.HasPrefix(line, "tiflash_only ") {
+			tiflash = line
+		}
+	}
+	want := "tiflash_only require=deterministic predicates=simple allow=aggregate,order_by,distinct,group_by,having deny=subquery,window,limit,cte,set_ops max_joins=unbounded"
+	if tiflash != want {
+		t.Fatalf("unexpected tiflash_only line:\n got %s\nwant %s", tiflash, want)
+	}
+}
+
+func TestBuildQueryWithSpecAppliesCompatOverride(t *testing.T) {
+	gen := newProfileTestGenerator(t)
+	gen.Config.Oracles.Compat = map[string]config.OracleCompatOverride{
+		"eet": {SetOps: BoolPtr(false), Subquery: BoolPtr(false)},
+	}
+	for i := 0; i < 20; i++ {
+		query, details := buildQueryWithSpec(gen, QuerySpec{Oracle: "eet", MaxTries: 50})
+		if query == nil {
+			t.Fatalf("expected query, details=%v", details)
+			return
+		}
+		if len(query.SetOps) > 0 || generator.AnalyzeQueryFeatures(query).HasSubquery {
+			t.Fatalf("override not applied: %s", query.SQLString())
+		}
+	}
+}
+
+// compatOfConstraints reads the static builder constraints back as a row.
+func compatOfConstraints(c generator.SelectQueryConstraints) SQLCompat {
+	compat := SQLCompat{
+		RequireWhere:         c.RequireWhere,
+		RequireDeterministic: c.RequireDeterministic,
+		PredicateMode:        c.PredicateMode,
+		Subquery:             !c.DisallowSubquery,
+		Aggregate:            !c.DisallowAggregate,
+		Window:               !c.DisallowWindow,
+		Limit:                !c.DisallowLimit,
+		OrderBy:              !c.DisallowOrderBy,
+		Distinct:             !c.DisallowDistinct,
+		GroupBy:              !c.DisallowGroupBy,
+		Having:               !c.DisallowHaving,
+		CTE:                  !c.DisallowCTE,
+		SetOps:               !c.DisallowSetOps,
+	}
+	if c.MaxJoinCountSet {
+		compat.MaxJoins = c.MaxJoinCount
+	}
+	return compat
+}
+
+func TestCompatRowsMatchEnforcedConstraints(t *testing.T) {
+	feature := func(v bool) config.OracleCompatOverride {
+		return config.OracleCompatOverride{
+			Subquery: BoolPtr(v), Aggregate: BoolPtr(v), Window: BoolPtr(v), Limit: BoolPtr(v), OrderBy: BoolPtr(v),
+			Distinct: BoolPtr(v), GroupBy: BoolPtr(v), Having: BoolPtr(v), CTE: BoolPtr(v), SetOps: BoolPtr(v),
+		}
+	}
+	if len(compatOracles) != len(compatMatrix) {
+		t.Fatalf("every matrix row needs an oracle name: %v", compatOracles)
+	}
+	for oracleName, name := range compatOracles {
+		if _, ok := compatMatrix[name]; !ok {
+			t.Fatalf("%s maps to missing row %q", oracleName, name)
+		}
+		for _, overrides := range []map[string]config.OracleCompatOverride{nil, {name: feature(true)}, {name: feature(false)}} {
+			want, _ := ResolveOracleCompat(oracleName, overrides)
+			gen := &generator.Generator{}
+			gen.Config.Oracles.Compat = overrides
+			got := compatOfConstraints(querySpecConstraints(gen, name, ProfileByName(oracleName), generator.SelectQueryConstraints{}))
+			if got != want {
+				t.Fatalf("%s enforces a different row than it logs:\n got %s\nwant %s", name, got, want)
+			}
+		}
+		profile := ProfileByName(oracleName)
+		if profile == nil {
+			continue
+		}
+		if profile.PredicateMode != nil || (profile.AllowSubquery != nil && !*profile.AllowSubquery) {
+			t.Fatalf("%s profile restricts what its matrix row owns: %+v", oracleName, profile)
+		}
+		f := profile.Features
+		for _, v := range []*bool{f.CTE, f.SetOperations, f.Aggregates, f.GroupBy, f.Having, f.Distinct, f.OrderBy, f.Limit, f.WindowFuncs, f.Subqueries, f.NotExists, f.NotIn} {
+			if v != nil && !*v {
+				t.Fatalf("%s profile disables a feature its matrix row owns: %+v", oracleName, f)
+			}
+		}
+	}
+}
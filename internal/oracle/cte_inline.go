@@ -0,0 +1,197 @@
+package oracle
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"shiro/internal/db"
+	"shiro/internal/generator"
+	"shiro/internal/schema"
+	"shiro/internal/sqlstep"
+)
+
+const (
+	cteInlineName       = "cte0"
+	cteInlineMergeHint  = "/*+ MERGE() */ "
+	cteInlineMaxColumns = 3
+
+	cteInlineShapeSelfJoin       = "self_join"
+	cteInlineShapeUnionAll       = "union_all"
+	cteInlineShapeInSubquery     = "in_subquery"
+	cteInlineShapeScalarSubquery = "scalar_subquery"
+
+	cteInlineBodyProject   = "project"
+	cteInlineBodyAggregate = "aggregate"
+)
+
+var cteInlineShapes = []string{
+	cteInlineShapeSelfJoin,
+	cteInlineShapeUnionAll,
+	cteInlineShapeInSubquery,
+	cteInlineShapeScalarSubquery,
+}
+
+// CTEInline implements the materialized-vs-inline CTE differential oracle.
+//
+// It builds a non-recursive CTE over one base table and an outer query that
+// references the CTE at least twice, so TiDB materializes it by default. The
+// materialized result (tidb_opt_force_inline_cte=OFF) must match the inlined
+// results produced by tidb_opt_force_inline_cte=ON and by a MERGE() hint in the
+// CTE body.
+//
+// Example:
+//
+//	WITH cte0 AS (SELECT t0.c1 AS c0, t0.c2 AS c1 FROM t0 WHERE t0.c3 > 5)
+//	SELECT a.c0 AS c0, b.c1 AS c1 FROM cte0 a JOIN cte0 b ON a.c0 = b.c0
+//	-- compared under SET_VAR(tidb_opt_force_inline_cte=OFF/ON) and MERGE()
+type CTEInline struct{}
+
+// Name returns the oracle identifier.
+func (o CTEInline) Name() string { return "CTEInline" }
+
+type cteInlineVariant struct {
+	name string
+	sql  string
+}
+
+// Run builds one CTE query shape and compares the materialized signature with
+// each inline variant.
+func (o CTEInline) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, state *schema.State) Result {
+	if !gen.Config.Features.CTE {
+		return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "cte_inline:cte_disabled"}}
+	}
+	if !state.HasBaseTables() {
+		return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "cte_inline:no_base_tables"}}
+	}
+	baseTables := state.BaseTables()
+	tbl := baseTables[gen.Rand.Intn(len(baseTables))]
+	if len(tbl.Columns) == 0 {
+		return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "cte_inline:no_columns"}}
+	}
+	predicate := gen.GenerateSimplePredicate([]schema.Table{tbl}, 2)
+	if predicate == nil || !predicate.Deterministic() {
+		return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "cte_inline:predicate_guard"}}
+	}
+	cols := pickCTEInlineColumns(gen, tbl)
+	bodyKind := cteInlineBodyProject
+	if gen.Rand.Intn(3) == 0 {
+		bodyKind = cteInlineBodyAggregate
+	}
+	shape := cteInlineShapes[gen.Rand.Intn(len(cteInlineShapes))]
+	build := func(mergeHint string) (string, []string) {
+		body := cteInlineBodySQL(tbl, cols, bodyKind, buildExpr(predicate), mergeHint)
+		return cteInlineQuerySQL(body, shape)
+	}
+	querySQL, aliases := build("")
+	mergeSQL, _ := build(cteInlineMergeHint)
+	baseSQL := cteInlineSignatureSQL(SetVarForceInlineCTEOff, querySQL, aliases)
+	variants := []cteInlineVariant{
+		{name: "force_inline", sql: cteInlineSignatureSQL(SetVarForceInlineCTEOn, querySQL, aliases)},
+		{name: "merge_hint", sql: cteInlineSignatureSQL(SetVarForceInlineCTEOff, mergeSQL, aliases)},
+	}
+	metrics := map[string]int64{
+		"cte_inline_shape_" + shape + "_total":   1,
+		"cte_inline_body_" + bodyKind + "_total": 1,
+	}
+
+	baseSig, err := exec.QuerySignature(ctx, baseSQL)
+	if err != nil {
+		steps := []sqlstep.Step{sqlstep.New(sqlstep.KindQuery, sqlstep.RoleFailing, baseSQL)}
+		return sqlErrorResult(o.Name(), "cte_inline", steps, metrics, nil, err, baseSQL)
+	}
+	for _, variant := range variants {
+		metrics["cte_inline_variant_"+variant.name+"_total"]++
+		steps := []sqlstep.Step{
+			sqlstep.New(sqlstep.KindQuery, sqlstep.RoleExpected, baseSQL),
+			sqlstep.New(sqlstep.KindQuery, sqlstep.RoleActual, variant.sql),
+		}
+		sig, err := exec.QuerySignature(ctx, variant.sql)
+		if err != nil {
+			steps[1].Role = sqlstep.RoleFailing
+			result := sqlErrorResult(o.Name(), "cte_inline", steps, metrics, nil, err, variant.sql)
+			result.Details["cte_inline_variant"] = variant.name
+			return result
+		}
+		if sig != baseSig {
+			return Result{
+				OK:       false,
+				Oracle:   o.Name(),
+				Steps:    steps,
+				Expected: fmt.Sprintf("cnt=%d checksum=%d", baseSig.Count, baseSig.Checksum),
+				Actual:   fmt.Sprintf("cnt=%d checksum=%d", sig.Count, sig.Checksum),
+				Details: map[string]any{
+					"cte_inline_variant": variant.name,
+					"cte_inline_shape":   shape,
+					"cte_inline_body":    bodyKind,
+				},
+				Metrics: metrics,
+			}
+		}
+	}
+	steps := []sqlstep.Step{sqlstep.New(sqlstep.KindQuery, "", baseSQL)}
+	for _, variant := range variants {
+		steps = append(steps, sqlstep.New(sqlstep.KindQuery, "", variant.sql))
+	}
+	return Result{OK: true, Oracle: o.Name(), Steps: steps, Metrics: metrics}
+}
+
+// pickCTEInlineColumns picks up to three distinct columns, preferring to keep
+// the table order so generated SQL stays readable.
+func pickCTEInlineColumns(gen *generator.Generator, tbl schema.Table) []schema.Column {
+	count := min(cteInlineMaxColumns, len(tbl.Columns))
+	picked := gen.Rand.Perm(len(tbl.Columns))[:count]
+	used := make(map[int]struct{}, count)
+	for _, idx := range picked {
+		used[idx] = struct{}{}
+	}
+	cols := make([]schema.Column, 0, count)
+	for i, col := range tbl.Columns {
+		if _, ok := used[i]; ok {
+			cols = append(cols, col)
+		}
+	}
+	return cols
+}
+
+// cteInlineBodySQL renders the CTE body with output columns c0..cN. The
+// aggregate body groups by the first column and adds COUNT(*) as the last one.
+func cteInlineBodySQL(tbl schema.Table, cols []schema.Column, kind string, predicate string, hint string) string {
+	items := make([]string, 0, len(cols)+1)
+	if kind == cteInlineBodyAggregate {
+		first := fmt.Sprintf("%s.%s", tbl.Name, cols[0].Name)
+		items = append(items, first+" AS c0", "COUNT(*) AS c1")
+		return fmt.Sprintf("SELECT %s%s FROM %s WHERE %s GROUP BY %s", hint, strings.Join(items, ", "), tbl.Name, predicate, first)
+	}
+	for i, col := range cols {
+		items = append(items, fmt.Sprintf("%s.%s AS c%d", tbl.Name, col.Name, i))
+	}
+	return fmt.Sprintf("SELECT %s%s FROM %s WHERE %s", hint, strings.Join(items, ", "), tbl.Name, predicate)
+}
+
+// cteInlineQuerySQL wraps the body in a WITH clause and an outer query that
+// references the CTE twice. It returns the SQL and its output aliases.
+func cteInlineQuerySQL(body string, shape string) (string, []string) {
+	with := fmt.Sprintf("WITH %s AS (%s) ", cteInlineName, body)
+	switch shape {
+	case cteInlineShapeUnionAll:
+		return with + fmt.Sprintf("SELECT c0 FROM %[1]s WHERE c0 IS NOT NULL UNION ALL SELECT c0 FROM %[1]s", cteInlineName), []string{"c0"}
+	case cteInlineShapeInSubquery:
+		return with + fmt.Sprintf("SELECT a.c0 AS c0 FROM %[1]s a WHERE a.c0 IN (SELECT b.c0 FROM %[1]s b WHERE b.c0 IS NOT NULL)", cteInlineName), []string{"c0"}
+	case cteInlineShapeScalarSubquery:
+		return with + fmt.Sprintf("SELECT a.c0 AS c0, (SELECT COUNT(*) FROM %[1]s b WHERE b.c0 = a.c0) AS c1 FROM %[1]s a", cteInlineName), []string{"c0", "c1"}
+	default:
+		return with + fmt.Sprintf("SELECT a.c0 AS c0, b.c0 AS c1 FROM %[1]s a JOIN %[1]s b ON a.c0 = b.c0", cteInlineName), []string{"c0", "c1"}
+	}
+}
+
+// cteInlineSignatureSQL wraps the query in a count/checksum signature with a
+// statement-level SET_VAR hint.
+func cteInlineSignatureSQL(setVar string, query string, aliases []string) string {
+	cols := make([]string, 0, len(aliases))
+	for _, alias := range aliases {
+		cols = append(cols, "q."+alias)
+	}
+	checksum := fmt.Sprintf("IFNULL(BIT_XOR(CRC32(CONCAT_WS('#', %s))),0)", strings.Join(cols, ", "))
+	return fmt.Sprintf("SELECT /*+ %s */ COUNT(*) AS cnt, %s AS checksum FROM (%s) q", setVar, checksum, query)
+}
@@ -0,0 +1,47 @@
+package oracle
+
+import (
+	"strings"
+	"testing"
+
+	"shiro/internal/schema"
+)
+
+func TestCTEInlineQueryShapesReferenceCTETwice(t *testing.T) {
+	body := "SELECT t0.c1 AS c0 FROM t0 WHERE (t0.c1 > 1)"
+	for _, shape := range cteInlineShapes {
+		sqlText, aliases := cteInlineQuerySQL(body, shape)
+		outer, ok := strings.CutPrefix(sqlText, "WITH cte0 AS ("+body+") ")
+		if !ok {
+			t.Fatalf("shape %s missing WITH clause: %s", shape, sqlText)
+		}
+		if got := strings.Count(outer, "cte0"); got < 2 {
+			t.Fatalf("shape %s references cte0 %d times: %s", shape, got, sqlText)
+		}
+		if len(aliases) == 0 || aliases[0] != "c0" {
+			t.Fatalf("shape %s unexpected aliases: %v", shape, aliases)
+		}
+	}
+}
+
+func TestCTEInlineBodyAndSignatureSQL(t *testing.T) {
+	tbl := schema.Table{Name: "t0"}
+	cols := []schema.Column{{Name: "c1", Type: schema.TypeInt}, {Name: "c2", Type: schema.TypeVarchar}}
+	tests := []struct {
+		kind string
+		hint string
+		want string
+	}{
+		{kind: cteInlineBodyProject, want: "SELECT t0.c1 AS c0, t0.c2 AS c1 FROM t0 WHERE (t0.c1 > 1)"},
+		{kind: cteInlineBodyAggregate, hint: cteInlineMergeHint, want: "SELECT /*+ MERGE() */ t0.c1 AS c0, COUNT(*) AS c1 FROM t0 WHERE (t0.c1 > 1) GROUP BY t0.c1"},
+	}
+	for _, tt := range tests {
+		if got := cteInlineBodySQL(tbl, cols, tt.kind, "(t0.c1 > 1)", tt.hint); got != tt.want {
+			t.Fatalf("cteInlineBodySQL(%q)=%q want=%q", tt.kind, got, tt.want)
+		}
+	}
+	want := "SELECT /*+ SET_VAR(tidb_opt_force_inline_cte=ON) */ COUNT(*) AS cnt, IFNULL(BIT_XOR(CRC32(CONCAT_WS('#', q.c0, q.c1))),0) AS checksum FROM (WITH cte0 AS (SELECT 1 AS c0) SELECT 1) q"
+	if got := cteInlineSignatureSQL(SetVarForceInlineCTEOn, "WITH cte0 AS (SELECT 1 AS c0) SELECT 1", []string{"c0", "c1"}); got != want {
+		t.Fatalf("cteInlineSignatureSQL()=%q want=%q", got, want)
+	}
+}
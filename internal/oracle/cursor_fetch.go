@@ -0,0 +1,147 @@
+package oracle
+
+import (
+	"context"
+	"fmt"
+
+	"shiro/internal/config"
+	"shiro/internal/db"
+	"shiro/internal/generator"
+	"shiro/internal/schema"
+	"shiro/internal/sqlstep"
+)
+
+const (
+	cursorFetchBuildMaxTries = 10
+	// cursorFetchLazySQL turns on lazy cursor execution (TiDB v8.3+), where
+	// each COM_STMT_FETCH pulls the next chunk from the executor instead of
+	// reading a result materialized at execute time.
+	cursorFetchLazySQL = "SET SESSION tidb_enable_lazy_cursor_fetch = ON"
+)
+
+// cursorFetchSizes are the rows requested per COM_STMT_FETCH. Small sizes
+// split a result across many fetches and chunk boundaries.
+var cursorFetchSizes = []int{1, 2, 7, 32, 256}
+
+// CursorFetch implements the cursor fetch oracle.
+//
+// It prepares a generated query on its own protocol connection and executes
+// it twice: once with every row sent in the execute response, and once with
+// a read-only cursor whose rows are pulled with COM_STMT_FETCH in batches of
+// a random size, sometimes with lazy cursor fetch on. Both executions use the
+// binary protocol, so the row count and the XOR of the row packet checksums
+// must match.
+//
+// Example:
+//
+//	PREPARE  SELECT t0.c0, t1.c1 FROM t0 JOIN t1 ON ...
+//	EXECUTE                          -- all rows at once
+//	EXECUTE CURSOR_TYPE_READ_ONLY    -- then FETCH 7 until the last row
+type CursorFetch struct {
+	DSN         string
+	SessionInit []string
+}
+
+// NewCursorFetch returns the oracle for the cluster in cfg.
+func NewCursorFetch(cfg config.Config) CursorFetch {
+	return CursorFetch{DSN: cfg.DSN, SessionInit: cfg.SessionInit}
+}
+
+// Name returns the oracle identifier.
+func (o CursorFetch) Name() string { return "CursorFetch" }
+
+// Run compares one query between a full fetch and a cursor fetch.
+func (o CursorFetch) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, _ *schema.State) Result {
+	spec := QuerySpec{
+		Oracle:   "cursor_fetch",
+		MaxTries: cursorFetchBuildMaxTries,
+		SkipReasonOverrides: map[string]string{
+			"constraint:nondeterministic": "cursor_fetch:nondeterministic",
+		},
+	}
+	query, details := buildQueryWithSpec(gen, spec)
+	if query == nil {
+		return Result{OK: true, Oracle: o.Name(), Details: details}
+	}
+	querySQL := query.SQLString()
+	fetchSize := cursorFetchSizes[gen.Rand.Intn(len(cursorFetchSizes))]
+	lazy := gen.Rand.Intn(2) == 0
+	metrics := map[string]int64{"cursor_fetch_total": 1}
+
+	var database string
+	if err := exec.QueryRowContext(ctx, "SELECT DATABASE()").Scan(&database); err != nil {
+		steps := []sqlstep.Step{sqlstep.New(sqlstep.KindVerify, sqlstep.RoleFailing, "SELECT DATABASE()")}
+		return sqlErrorResult(o.Name(), "cursor_fetch", steps, metrics, nil, err, "SELECT DATABASE()")
+	}
+	conn, err := db.DialCursor(ctx, config.UpdateDatabaseInDSN(o.DSN, database), o.SessionInit...)
+	if err != nil {
+		return Result{OK: true, Oracle: o.Name(), Metrics: metrics, Details: map[string]any{"skip_reason": "cursor_fetch:connect_failed", "cursor_fetch_error": err.Error()}}
+	}
+	defer func() { _ = conn.Close() }()
+
+	var steps []sqlstep.Step
+	if exec.SnapshotTSO != 0 {
+		stmt := fmt.Sprintf("SET @@tidb_snapshot = '%d'", exec.SnapshotTSO)
+		steps = append(steps, sqlstep.New(sqlstep.KindSetup, "", stmt))
+		if err := conn.Exec(ctx, stmt); err != nil {
+			steps[len(steps)-1].Role = sqlstep.RoleFailing
+			return sqlErrorResult(o.Name(), "cursor_fetch", steps, metrics, nil, err, stmt)
+		}
+	}
+	if lazy {
+		// Older servers do not know the variable; they still run the eager
+		// cursor path.
+		if err := conn.Exec(ctx, cursorFetchLazySQL); err != nil {
+			lazy = false
+		} else {
+			steps = append(steps, sqlstep.New(sqlstep.KindSetup, "", cursorFetchLazySQL))
+			metrics["cursor_fetch_lazy_total"] = 1
+		}
+	}
+	steps = append(steps,
+		sqlstep.New(sqlstep.KindQuery, sqlstep.RoleExpected, querySQL),
+		sqlstep.New(sqlstep.KindQuery, sqlstep.RoleActual, querySQL),
+	)
+	details = map[string]any{
+		"cursor_fetch_size": fetchSize,
+		"cursor_fetch_lazy": lazy,
+	}
+
+	id, err := conn.Prepare(ctx, querySQL)
+	if err != nil {
+		steps[len(steps)-2].Role = sqlstep.RoleFailing
+		return sqlErrorResult(o.Name(), "cursor_fetch", steps, metrics, nil, err, querySQL)
+	}
+	defer conn.CloseStmt(id)
+	full, err := conn.Execute(ctx, id, 0)
+	if err != nil {
+		steps[len(steps)-2].Role = sqlstep.RoleFailing
+		return sqlErrorResult(o.Name(), "cursor_fetch", steps, metrics, nil, err, querySQL)
+	}
+	cursor, err := conn.Execute(ctx, id, fetchSize)
+	if err != nil {
+		steps[len(steps)-1].Role = sqlstep.RoleFailing
+		result := sqlErrorResult(o.Name(), "cursor_fetch", steps, metrics, nil, err, querySQL)
+		for key, value := range details {
+			result.Details[key] = value
+		}
+		return result
+	}
+	metrics["cursor_fetch_fetches"] = int64(cursor.Fetches)
+	if full.Count > int64(fetchSize) {
+		metrics["cursor_fetch_multi_batch_total"] = 1
+	}
+	details["cursor_fetch_fetches"] = cursor.Fetches
+	if cursor.Signature != full.Signature {
+		return Result{
+			OK:       false,
+			Oracle:   o.Name(),
+			Steps:    steps,
+			Expected: fmt.Sprintf("full fetch cnt=%d checksum=%d", full.Count, full.Checksum),
+			Actual:   fmt.Sprintf("cursor fetch size=%d cnt=%d checksum=%d", fetchSize, cursor.Count, cursor.Checksum),
+			Details:  details,
+			Metrics:  metrics,
+		}
+	}
+	return Result{OK: true, Oracle: o.Name(), Steps: steps, Details: details, Metrics: metrics}
+}
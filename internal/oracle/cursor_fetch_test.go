@@ -0,0 +1,29 @@
+package oracle
+
+import (
+	"testing"
+
+	"shiro/internal/config"
+)
+
+func TestNewCursorFetch(t *testing.T) {
+	cfg := config.Config{DSN: "root@tcp(h1:4000)/shiro", SessionInit: []string{"SET SESSION sql_mode = ''"}}
+	o := NewCursorFetch(cfg)
+	if o.Name() != "CursorFetch" || o.DSN != cfg.DSN || len(o.SessionInit) != 1 {
+		t.Fatalf("unexpected oracle %+v", o)
+	}
+}
+
+func TestCursorFetchQueriesAreDeterministic(t *testing.T) {
+	gen := newProfileTestGenerator(t)
+	for i := 0; i < 20; i++ {
+		query, details := buildQueryWithSpec(gen, QuerySpec{Oracle: "cursor_fetch", MaxTries: 50})
+		if query == nil {
+			t.Fatalf("expected query, details=%v", details)
+			return
+		}
+		if query.Limit != nil || len(query.From.Joins) > 3 {
+			t.Fatalf("unexpected cursor_fetch query: %s", query.SQLString())
+		}
+	}
+}
@@ -0,0 +1,472 @@
+package oracle
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+
+	"shiro/internal/db"
+	"shiro/internal/generator"
+	"shiro/internal/schema"
+	"shiro/internal/sqlstep"
+	"shiro/internal/util"
+)
+
+const (
+	decimalArithTable      = "shiro_decimal_arith"
+	decimalArithRows       = 8
+	decimalArithColumns    = 3
+	decimalArithExprs      = 3
+	decimalArithMaxScale   = 4
+	decimalArithMaxIntDigs = 8
+	decimalArithNullProb   = 10
+	decimalArithHalfProb   = 30
+	decimalArithNestedProb = 40
+	decimalArithLiteralPct = 25
+	// decimalArithMaxResultScale is the largest scale of a DECIMAL result.
+	decimalArithMaxResultScale = 30
+
+	decimalArithVariantTiDB       = "tidb"
+	decimalArithVariantProjection = "tikv_projection"
+	decimalArithVariantSelection  = "tikv_selection"
+)
+
+// decimalArithIncrements are the div_precision_increment values a run picks
+// from; 4 is the server default.
+var decimalArithIncrements = []int{0, 1, 4, 6, 9}
+
+// DecimalArith implements the DECIMAL arithmetic oracle.
+//
+// It fills a scratch table with DECIMAL values it generated itself, evaluates
+// DECIMAL +, -, *, and / expressions over them exactly on the client with
+// math/big, and compares the server results under a random
+// div_precision_increment. The same expressions run evaluated by TiDB, with
+// the projection pushed down to TiKV, and as pushed-down equality selections.
+// Decimal rounding that differs between the evaluation engines shows up as a
+// value that does not match the exact result.
+//
+// Only the root of an expression may divide: MySQL keeps intermediate
+// quotients at word precision, which the client does not model, and compares
+// quotients at that precision too, so division is left out of the selection
+// check.
+//
+// Example:
+//
+//	CREATE TABLE shiro_decimal_arith (id INT PRIMARY KEY, d0 DECIMAL(9,3), d1 DECIMAL(6,1), d2 DECIMAL(4,0))
+//	SET SESSION div_precision_increment = 6
+//	SELECT id, ((d0 * d1) / d2) AS e0 FROM shiro_decimal_arith ORDER BY id
+//	-- every e0 must equal round_half_away((d0 * d1) / d2, 4 + 6)
+type DecimalArith struct{}
+
+// Name returns the oracle identifier.
+func (o DecimalArith) Name() string { return "DecimalArith" }
+
+// decimalArithColumn is one DECIMAL(precision, scale) column of the scratch
+// table.
+type decimalArithColumn struct {
+	name      string
+	precision int
+	scale     int
+}
+
+// decimalArithExpr is a DECIMAL arithmetic expression over the scratch
+// columns and decimal literals. Leaves set column or literal; inner nodes set
+// op and both operands.
+type decimalArithExpr struct {
+	op      string
+	left    *decimalArithExpr
+	right   *decimalArithExpr
+	column  string
+	literal string
+	// scale is the result scale of a leaf.
+	scale int
+}
+
+// decimalArithRow is one scratch row: the SQL literals and their exact values
+// by column, nil for NULL.
+type decimalArithRow struct {
+	id     int
+	values map[string]*big.Rat
+	sql    []string
+}
+
+// Run creates the scratch table, evaluates the expressions client-side, and
+// compares each variant's results. The table is dropped afterwards and the
+// session variables are reset.
+func (o DecimalArith) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, state *schema.State) Result {
+	columns := decimalArithPickColumns(gen.Rand)
+	rows := decimalArithPickRows(gen.Rand, columns)
+	exprs := make([]*decimalArithExpr, 0, decimalArithExprs)
+	for i := 0; i < decimalArithExprs; i++ {
+		exprs = append(exprs, decimalArithPickExpr(gen.Rand, columns, true))
+	}
+	incr := decimalArithIncrements[gen.Rand.Intn(len(decimalArithIncrements))]
+	details := map[string]any{
+		"div_precision_increment": incr,
+		"decimal_arith_columns":   decimalArithColumnsSQL(columns),
+	}
+	metrics := map[string]int64{}
+
+	conn, err := exec.Conn(ctx)
+	if err != nil {
+		return Result{OK: true, Oracle: o.Name(), Err: err}
+	}
+	defer util.CloseWithErr(conn, "decimal_arith conn")
+
+	var steps []sqlstep.Step
+	dropSQL := "DROP TABLE IF EXISTS " + decimalArithTable
+	defer func() {
+		cleanup := context.Background()
+		_, _ = conn.ExecContext(cleanup, dropSQL)
+		_, _ = conn.ExecContext(cleanup, "SET SESSION div_precision_increment = DEFAULT")
+		_, _ = conn.ExecContext(cleanup, "SET SESSION tidb_opt_projection_push_down = DEFAULT")
+	}()
+	for _, stmt := range append([]string{dropSQL}, decimalArithSetupSQL(columns, rows)...) {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return sqlErrorResult(o.Name(), "decimal_arith", failedSteps(steps, sqlstep.KindSetup, stmt), metrics, details, err, stmt)
+		}
+		steps = append(steps, sqlstep.New(sqlstep.KindSetup, "", stmt))
+	}
+	incrSQL := fmt.Sprintf("SET SESSION div_precision_increment = %d", incr)
+	if _, err := conn.ExecContext(ctx, incrSQL); err != nil {
+		return sqlErrorResult(o.Name(), "decimal_arith", failedSteps(steps, sqlstep.KindSetVar, incrSQL), metrics, details, err, incrSQL)
+	}
+	steps = append(steps, sqlstep.New(sqlstep.KindSetVar, "", incrSQL))
+
+	projectionSQL := decimalArithProjectionSQL(exprs)
+	mismatch := func(variant string, query string, setVars []string, expected string, actual string, extra map[string]any) Result {
+		for _, stmt := range setVars {
+			steps = append(steps, sqlstep.New(sqlstep.KindSetVar, "", stmt))
+		}
+		steps = append(steps, sqlstep.New(sqlstep.KindQuery, sqlstep.RoleActual, query))
+		details["decimal_arith_variant"] = variant
+		for k, v := range extra {
+			details[k] = v
+		}
+		return Result{
+			OK:       false,
+			Oracle:   o.Name(),
+			Steps:    steps,
+			Expected: expected,
+			Actual:   actual,
+			Details:  details,
+			Metrics:  metrics,
+		}
+	}
+
+	for _, variant := range []string{decimalArithVariantTiDB, decimalArithVariantProjection} {
+		setVar := "SET SESSION tidb_opt_projection_push_down = OFF"
+		if variant == decimalArithVariantProjection {
+			setVar = "SET SESSION tidb_opt_projection_push_down = ON"
+		}
+		if _, err := conn.ExecContext(ctx, setVar); err != nil {
+			if isUnknownSystemVariable(err) {
+				metrics["decimal_arith_variant_unsupported_total"]++
+				continue
+			}
+			return sqlErrorResult(o.Name(), "decimal_arith", failedSteps(steps, sqlstep.KindSetVar, setVar), metrics, details, err, setVar)
+		}
+		metrics["decimal_arith_variant_"+variant+"_total"]++
+		got, err := decimalArithQuery(ctx, conn, exec.Guard, projectionSQL)
+		if err != nil {
+			return sqlErrorResult(o.Name(), "decimal_arith", failedSteps(steps, sqlstep.KindQuery, projectionSQL), metrics, details, err, projectionSQL)
+		}
+		if row, col, expected, actual, ok := decimalArithCompare(exprs, rows, incr, got); !ok {
+			return mismatch(variant, projectionSQL, []string{setVar}, expected, actual, map[string]any{
+				"decimal_arith_expr": exprs[col].sql(),
+				"decimal_arith_row":  row,
+			})
+		}
+	}
+
+	for _, expr := range exprs {
+		if expr.hasDivision() {
+			continue
+		}
+		for _, row := range rows {
+			value, ok := expr.eval(row.values)
+			if !ok {
+				continue
+			}
+			query := decimalArithSelectionSQL(expr, value, expr.resultScale(incr))
+			metrics["decimal_arith_variant_"+decimalArithVariantSelection+"_total"]++
+			got, err := decimalArithQuery(ctx, conn, exec.Guard, query)
+			if err != nil {
+				return sqlErrorResult(o.Name(), "decimal_arith", failedSteps(steps, sqlstep.KindQuery, query), metrics, details, err, query)
+			}
+			expectedIDs := decimalArithMatchingIDs(expr, rows, value)
+			actualIDs := make([]string, 0, len(got))
+			for _, r := range got {
+				actualIDs = append(actualIDs, r[0])
+			}
+			if !slices.Equal(expectedIDs, actualIDs) {
+				return mismatch(decimalArithVariantSelection, query, nil,
+					"ids="+strings.Join(expectedIDs, ","), "ids="+strings.Join(actualIDs, ","),
+					map[string]any{"decimal_arith_expr": expr.sql(), "decimal_arith_row": row.id})
+			}
+			break
+		}
+	}
+	return Result{OK: true, Oracle: o.Name(), Steps: steps, Details: details, Metrics: metrics}
+}
+
+func decimalArithPickColumns(r *rand.Rand) []decimalArithColumn {
+	columns := make([]decimalArithColumn, 0, decimalArithColumns)
+	for i := 0; i < decimalArithColumns; i++ {
+		scale := r.Intn(decimalArithMaxScale + 1)
+		columns = append(columns, decimalArithColumn{
+			name:      fmt.Sprintf("d%d", i),
+			precision: scale + 1 + r.Intn(decimalArithMaxIntDigs),
+			scale:     scale,
+		})
+	}
+	return columns
+}
+
+func decimalArithPickRows(r *rand.Rand, columns []decimalArithColumn) []decimalArithRow {
+	rows := make([]decimalArithRow, 0, decimalArithRows)
+	for id := 1; id <= decimalArithRows; id++ {
+		row := decimalArithRow{id: id, values: make(map[string]*big.Rat, len(columns))}
+		for _, col := range columns {
+			if util.Chance(r, decimalArithNullProb) {
+				row.values[col.name] = nil
+				row.sql = append(row.sql, "NULL")
+				continue
+			}
+			literal := decimalArithLiteral(r, col.precision-col.scale, col.scale)
+			value, _ := new(big.Rat).SetString(literal)
+			row.values[col.name] = value
+			row.sql = append(row.sql, literal)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// decimalArithLiteral returns a signed decimal literal with up to intDigits
+// integer digits and exactly scale fractional digits. Values ending in 5 are
+// favored, since they sit on rounding boundaries.
+func decimalArithLiteral(r *rand.Rand, intDigits int, scale int) string {
+	var intPart strings.Builder
+	for i := 0; i < 1+r.Intn(max(intDigits, 1)); i++ {
+		intPart.WriteByte(byte('0' + r.Intn(10)))
+	}
+	out := strings.TrimLeft(intPart.String(), "0")
+	if out == "" || intDigits <= 0 {
+		out = "0"
+	}
+	if scale > 0 {
+		frac := make([]byte, scale)
+		for i := range frac {
+			frac[i] = byte('0' + r.Intn(10))
+		}
+		if util.Chance(r, decimalArithHalfProb) {
+			frac[scale-1] = '5'
+		}
+		out += "." + string(frac)
+	}
+	if r.Intn(3) == 0 {
+		return "-" + out
+	}
+	return out
+}
+
+// decimalArithPickExpr builds an expression. Only the root may divide.
+func decimalArithPickExpr(r *rand.Rand, columns []decimalArithColumn, root bool) *decimalArithExpr {
+	ops := []string{"+", "-", "*"}
+	if root {
+		ops = append(ops, "/")
+	}
+	operand := func() *decimalArithExpr {
+		if root && util.Chance(r, decimalArithNestedProb) {
+			return decimalArithPickExpr(r, columns, false)
+		}
+		return decimalArithPickLeaf(r, columns)
+	}
+	return &decimalArithExpr{op: ops[r.Intn(len(ops))], left: operand(), right: operand()}
+}
+
+func decimalArithPickLeaf(r *rand.Rand, columns []decimalArithColumn) *decimalArithExpr {
+	if util.Chance(r, decimalArithLiteralPct) {
+		scale := 1 + r.Intn(decimalArithMaxScale)
+		return &decimalArithExpr{literal: decimalArithLiteral(r, 3, scale), scale: scale}
+	}
+	col := columns[r.Intn(len(columns))]
+	return &decimalArithExpr{column: col.name, scale: col.scale}
+}
+
+func (e *decimalArithExpr) sql() string {
+	switch {
+	case e.op != "":
+		return "(" + e.left.sql() + " " + e.op + " " + e.right.sql() + ")"
+	case e.column != "":
+		return e.column
+	case strings.HasPrefix(e.literal, "-"):
+		return "(" + e.literal + ")"
+	default:
+		return e.literal
+	}
+}
+
+func (e *decimalArithExpr) hasDivision() bool {
+	if e.op == "" {
+		return false
+	}
+	return e.op == "/" || e.left.hasDivision() || e.right.hasDivision()
+}
+
+// resultScale follows the MySQL DECIMAL rules: + and - keep the larger scale,
+// * adds the scales, and / adds div_precision_increment to the dividend's
+// scale, all capped at 30.
+func (e *decimalArithExpr) resultScale(incr int) int {
+	var scale int
+	switch e.op {
+	case "":
+		scale = e.scale
+	case "+", "-":
+		scale = max(e.left.resultScale(incr), e.right.resultScale(incr))
+	case "*":
+		scale = e.left.resultScale(incr) + e.right.resultScale(incr)
+	case "/":
+		scale = e.left.resultScale(incr) + incr
+	}
+	return min(scale, decimalArithMaxResultScale)
+}
+
+// eval returns the exact value of e, or false for NULL. Division by zero is
+// NULL.
+func (e *decimalArithExpr) eval(values map[string]*big.Rat) (*big.Rat, bool) {
+	switch {
+	case e.column != "":
+		v := values[e.column]
+		return v, v != nil
+	case e.op == "":
+		v, ok := new(big.Rat).SetString(e.literal)
+		return v, ok
+	}
+	left, ok := e.left.eval(values)
+	if !ok {
+		return nil, false
+	}
+	right, ok := e.right.eval(values)
+	if !ok {
+		return nil, false
+	}
+	out := new(big.Rat)
+	switch e.op {
+	case "+":
+		out.Add(left, right)
+	case "-":
+		out.Sub(left, right)
+	case "*":
+		out.Mul(left, right)
+	case "/":
+		if right.Sign() == 0 {
+			return nil, false
+		}
+		out.Quo(left, right)
+	}
+	return out, true
+}
+
+// decimalArithRound rounds value to scale digits, halves away from zero, as
+// DECIMAL division does.
+func decimalArithRound(value *big.Rat, scale int) string {
+	return value.FloatString(scale)
+}
+
+func decimalArithColumnsSQL(columns []decimalArithColumn) string {
+	parts := make([]string, 0, len(columns))
+	for _, col := range columns {
+		parts = append(parts, fmt.Sprintf("%s DECIMAL(%d,%d)", col.name, col.precision, col.scale))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func decimalArithSetupSQL(columns []decimalArithColumn, rows []decimalArithRow) []string {
+	create := fmt.Sprintf("CREATE TABLE %s (id INT PRIMARY KEY, %s)", decimalArithTable, decimalArithColumnsSQL(columns))
+	values := make([]string, 0, len(rows))
+	for _, row := range rows {
+		values = append(values, fmt.Sprintf("(%d, %s)", row.id, strings.Join(row.sql, ", ")))
+	}
+	return []string{create, fmt.Sprintf("INSERT INTO %s VALUES %s", decimalArithTable, strings.Join(values, ", "))}
+}
+
+func decimalArithProjectionSQL(exprs []*decimalArithExpr) string {
+	items := make([]string, 0, len(exprs)+1)
+	items = append(items, "id")
+	for i, expr := range exprs {
+		items = append(items, fmt.Sprintf("%s AS e%d", expr.sql(), i))
+	}
+	return fmt.Sprintf("SELECT %s FROM %s ORDER BY id", strings.Join(items, ", "), decimalArithTable)
+}
+
+func decimalArithSelectionSQL(expr *decimalArithExpr, value *big.Rat, scale int) string {
+	return fmt.Sprintf("SELECT id FROM %s WHERE %s = %s ORDER BY id", decimalArithTable, expr.sql(), decimalArithRound(value, scale))
+}
+
+// decimalArithMatchingIDs returns the ids of the rows where expr equals value.
+func decimalArithMatchingIDs(expr *decimalArithExpr, rows []decimalArithRow, value *big.Rat) []string {
+	var ids []string
+	for _, row := range rows {
+		if got, ok := expr.eval(row.values); ok && got.Cmp(value) == 0 {
+			ids = append(ids, strconv.Itoa(row.id))
+		}
+	}
+	return ids
+}
+
+// decimalArithCompare checks the projection rows (id, e0, e1, ...) against
+// the exact results. On a mismatch it returns the row id, the expression
+// index, and the expected and actual values.
+func decimalArithCompare(exprs []*decimalArithExpr, rows []decimalArithRow, incr int, got [][]string) (rowID int, col int, expected string, actual string, ok bool) {
+	if len(got) != len(rows) {
+		return 0, 0, fmt.Sprintf("rows=%d", len(rows)), fmt.Sprintf("rows=%d", len(got)), false
+	}
+	for i, row := range rows {
+		for j, expr := range exprs {
+			want := sqlNullValue
+			if value, ok := expr.eval(row.values); ok {
+				want = decimalArithRound(value, expr.resultScale(incr))
+			}
+			have := ""
+			if j+1 < len(got[i]) {
+				have = got[i][j+1]
+			}
+			if !decimalArithEqual(want, have) {
+				return row.id, j, fmt.Sprintf("e%d=%s", j, decimalArithDisplay(want)), fmt.Sprintf("e%d=%s", j, decimalArithDisplay(have)), false
+			}
+		}
+	}
+	return 0, 0, "", "", true
+}
+
+// decimalArithEqual compares two decimal strings by value; trailing zeros do
+// not matter.
+func decimalArithEqual(want string, have string) bool {
+	if want == sqlNullValue || have == sqlNullValue {
+		return want == have
+	}
+	w, ok := new(big.Rat).SetString(want)
+	if !ok {
+		return false
+	}
+	h, ok := new(big.Rat).SetString(have)
+	return ok && w.Cmp(h) == 0
+}
+
+func decimalArithDisplay(value string) string {
+	if value == sqlNullValue {
+		return "NULL"
+	}
+	return value
+}
+
+func decimalArithQuery(ctx context.Context, conn *sql.Conn, guard db.ResultGuard, query string) ([][]string, error) {
+	return queryRowStrings(ctx, conn, guard, query)
+}
@@ -0,0 +1,99 @@
+package oracle
+
+import (
+	"math/big"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestDecimalArithDivisionRounding(t *testing.T) {
+	col := func(name string, scale int) *decimalArithExpr {
+		return &decimalArithExpr{column: name, scale: scale}
+	}
+	values := map[string]*big.Rat{
+		"d0": big.NewRat(1, 1),
+		"d1": big.NewRat(3, 1),
+		"d2": big.NewRat(2, 1),
+		"d3": big.NewRat(-5, 100),
+		"d4": big.NewRat(0, 1),
+	}
+	cases := []struct {
+		expr *decimalArithExpr
+		incr int
+		want string
+	}{
+		{&decimalArithExpr{op: "/", left: col("d0", 0), right: col("d1", 0)}, 4, "0.3333"},
+		{&decimalArithExpr{op: "/", left: col("d2", 0), right: col("d1", 0)}, 4, "0.6667"},
+		{&decimalArithExpr{op: "/", left: col("d3", 2), right: col("d2", 0)}, 0, "-0.03"},
+		{&decimalArithExpr{op: "/", left: col("d0", 0), right: col("d1", 0)}, 0, "0"},
+		{&decimalArithExpr{op: "*", left: col("d3", 2), right: col("d3", 2)}, 4, "0.0025"},
+		{&decimalArithExpr{op: "+", left: col("d3", 2), right: col("d0", 0)}, 4, "0.95"},
+	}
+	for _, tc := range cases {
+		value, ok := tc.expr.eval(values)
+		if !ok {
+			t.Fatalf("%s: unexpected NULL", tc.expr.sql())
+		}
+		if got := decimalArithRound(value, tc.expr.resultScale(tc.incr)); got != tc.want {
+			t.Fatalf("%s incr=%d: got %s want %s", tc.expr.sql(), tc.incr, got, tc.want)
+		}
+	}
+	div := &decimalArithExpr{op: "/", left: col("d0", 0), right: col("d4", 0)}
+	if _, ok := div.eval(values); ok {
+		t.Fatalf("division by zero must be NULL")
+	}
+	values["d0"] = nil
+	if _, ok := (&decimalArithExpr{op: "+", left: col("d0", 0), right: col("d1", 0)}).eval(values); ok {
+		t.Fatalf("NULL operand must give NULL")
+	}
+}
+
+func TestDecimalArithExprSQL(t *testing.T) {
+	expr := &decimalArithExpr{
+		op:    "/",
+		left:  &decimalArithExpr{op: "*", left: &decimalArithExpr{column: "d0", scale: 2}, right: &decimalArithExpr{literal: "-1.5", scale: 1}},
+		right: &decimalArithExpr{column: "d1", scale: 0},
+	}
+	if got, want := expr.sql(), "((d0 * (-1.5)) / d1)"; got != want {
+		t.Fatalf("sql=%q want %q", got, want)
+	}
+	if got := expr.resultScale(6); got != 9 {
+		t.Fatalf("scale=%d want 9", got)
+	}
+	if !expr.hasDivision() || expr.left.hasDivision() {
+		t.Fatalf("unexpected hasDivision")
+	}
+
+	r := rand.New(rand.NewSource(7))
+	columns := decimalArithPickColumns(r)
+	for i := 0; i < 50; i++ {
+		e := decimalArithPickExpr(r, columns, true)
+		if e.left.hasDivision() || e.right.hasDivision() {
+			t.Fatalf("division below the root: %s", e.sql())
+		}
+	}
+	for i := 0; i < 50; i++ {
+		lit := decimalArithLiteral(r, 3, 2)
+		if _, ok := new(big.Rat).SetString(lit); !ok || !strings.Contains(lit, ".") {
+			t.Fatalf("bad literal %q", lit)
+		}
+	}
+}
+
+func TestDecimalArithCompare(t *testing.T) {
+	expr := &decimalArithExpr{op: "/", left: &decimalArithExpr{column: "d0", scale: 1}, right: &decimalArithExpr{column: "d1", scale: 0}}
+	rows := []decimalArithRow{
+		{id: 1, values: map[string]*big.Rat{"d0": big.NewRat(1, 1), "d1": big.NewRat(3, 1)}},
+		{id: 2, values: map[string]*big.Rat{"d0": big.NewRat(1, 1), "d1": big.NewRat(0, 1)}},
+	}
+	got := [][]string{{"1", "0.33333"}, {"2", sqlNullValue}}
+	if _, _, _, _, ok := decimalArithCompare([]*decimalArithExpr{expr}, rows, 4, got); !ok {
+		t.Fatalf("expected match")
+	}
+	got[0][1] = "0.33334"
+	row, _, expected, actual, ok := decimalArithCompare([]*decimalArithExpr{expr}, rows, 4, got)
+	if ok || row != 1 || expected != "e0=0.33333" || actual != "e0=0.33334" {
+		t.Fatalf("unexpected compare: row=%d expected=%s actual=%s ok=%v", row, expected, actual, ok)
+	}
+}
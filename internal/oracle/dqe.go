@@ -2,11 +2,14 @@ package oracle
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"strings"
 
 	"shiro/internal/db"
 	"shiro/internal/generator"
 	"shiro/internal/schema"
+	"shiro/internal/sqlstep"
 )
 
 // DQE implements the DML query equivalence oracle.
@@ -15,6 +18,12 @@ import (
 // - The expected number of affected rows computed via a COUNT query, and
 // - The actual rows affected by the DML statement.
 // A mismatch indicates a potential execution correctness issue.
+//
+// The DML carries a random access path hint (USE_INDEX, IGNORE_INDEX,
+// USE_INDEX_MERGE, or none). When it changed rows, every secondary index of
+// the table is read index-only with FORCE INDEX and compared with a table
+// scan of the same columns, catching plan variants that leave a stale index
+// entry behind.
 type DQE struct{}
 
 // Name returns the oracle identifier.
@@ -29,6 +38,12 @@ func (o DQE) Name() string { return "DQE" }
 //	Check:  SELECT COUNT(*) FROM t WHERE b > 5 AND NOT (a <=> a + 1)
 //
 // If rows affected != count, execution semantics are wrong.
+//
+// UPDATE and DELETE may carry an ORDER BY pk LIMIT n tail. LIMIT bounds the
+// matched rows, so the count runs over the same limited rows:
+//
+//	Update: UPDATE t SET a = a + 1 WHERE b > 5 ORDER BY id LIMIT 3
+//	Check:  SELECT COUNT(*) FROM (SELECT NOT (a <=> a + 1) AS changed FROM t WHERE b > 5 ORDER BY id LIMIT 3) dqe WHERE dqe.changed
 func (o DQE) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, state *schema.State) Result {
 	if !state.HasBaseTables() {
 		return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "dqe:no_base_tables"}}
@@ -38,23 +53,24 @@ func (o DQE) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, sta
 	choice := gen.Rand.Intn(2)
 
 	if choice == 0 {
-		updateSQL, predicate, setExpr, colRef := pickDQEUpdate(gen, tbl)
+		updateSQL, predicate, setExpr, colRef, orderLimit := pickDQEUpdate(gen, tbl)
 		if updateSQL == "" || predicate == nil || setExpr == nil || colRef.Table == "" {
 			return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "dqe:update_guard"}}
 		}
 		if !predicate.Deterministic() {
 			return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "dqe:predicate_guard"}}
 		}
-		colSQL := fmt.Sprintf("%s.%s", colRef.Table, colRef.Name)
-		setExprSQL := buildExpr(setExpr)
-		countSQL := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s AND NOT (%s <=> %s)", tbl.Name, buildExpr(predicate), colSQL, setExprSQL)
+		countSQL := dqeUpdateCountSQL(tbl.Name, buildExpr(predicate), fmt.Sprintf("%s.%s", colRef.Table, colRef.Name), buildExpr(setExpr), orderLimit)
 		count, err := exec.QueryCount(ctx, countSQL)
 		if err != nil {
-			return Result{OK: true, Oracle: o.Name(), SQL: []string{countSQL}, Err: err}
+			return Result{OK: true, Oracle: o.Name(), Steps: []sqlstep.Step{queryStep(sqlstep.RoleFailing, countSQL)}, Err: err}
 		}
+		indexes := dqeLoadIndexes(ctx, exec, tbl.Name)
+		hint := pickDQEHint(gen, tbl.Name, indexes)
+		updateSQL = dqeWithHint(updateSQL, hint)
 		res, err := exec.ExecContext(ctx, updateSQL)
 		if err != nil {
-			return Result{OK: true, Oracle: o.Name(), SQL: []string{updateSQL}, Err: err}
+			return Result{OK: true, Oracle: o.Name(), Steps: dqeFailedDMLSteps(countSQL, updateSQL), Err: err}
 		}
 		affected, _ := res.RowsAffected()
 		if affected != count {
@@ -63,7 +79,7 @@ func (o DQE) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, sta
 			return Result{
 				OK:       false,
 				Oracle:   o.Name(),
-				SQL:      []string{updateSQL},
+				Steps:    dqeDMLSteps(countSQL, updateSQL),
 				Expected: fmt.Sprintf("rows affected=%d", count),
 				Actual:   fmt.Sprintf("rows affected=%d", affected),
 				Details: map[string]any{
@@ -74,27 +90,31 @@ func (o DQE) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, sta
 					"actual_explain":       actualExplain,
 					"expected_explain_err": errString(expectedExplainErr),
 					"actual_explain_err":   errString(actualExplainErr),
+					"dqe_dml_hint":         hint,
 				},
 			}
 		}
-		return Result{OK: true, Oracle: o.Name(), SQL: []string{updateSQL, countSQL}}
+		return o.checkIndexes(ctx, exec, tbl.Name, indexes, hint, affected, countSQL, updateSQL)
 	}
 
-	deleteSQL, predicate := pickDQEDelete(gen, tbl)
+	deleteSQL, predicate, orderLimit := pickDQEDelete(gen, tbl)
 	if deleteSQL == "" || predicate == nil {
 		return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "dqe:delete_guard"}}
 	}
 	if !predicate.Deterministic() {
 		return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "dqe:predicate_guard"}}
 	}
-	countSQL := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", tbl.Name, buildExpr(predicate))
+	countSQL := dqeDeleteCountSQL(tbl.Name, buildExpr(predicate), orderLimit)
 	count, err := exec.QueryCount(ctx, countSQL)
 	if err != nil {
-		return Result{OK: true, Oracle: o.Name(), SQL: []string{countSQL}, Err: err}
+		return Result{OK: true, Oracle: o.Name(), Steps: []sqlstep.Step{queryStep(sqlstep.RoleFailing, countSQL)}, Err: err}
 	}
+	indexes := dqeLoadIndexes(ctx, exec, tbl.Name)
+	hint := pickDQEHint(gen, tbl.Name, indexes)
+	deleteSQL = dqeWithHint(deleteSQL, hint)
 	res, err := exec.ExecContext(ctx, deleteSQL)
 	if err != nil {
-		return Result{OK: true, Oracle: o.Name(), SQL: []string{deleteSQL}, Err: err}
+		return Result{OK: true, Oracle: o.Name(), Steps: dqeFailedDMLSteps(countSQL, deleteSQL), Err: err}
 	}
 	affected, _ := res.RowsAffected()
 	if affected != count {
@@ -103,7 +123,7 @@ func (o DQE) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, sta
 		return Result{
 			OK:       false,
 			Oracle:   o.Name(),
-			SQL:      []string{deleteSQL},
+			Steps:    dqeDMLSteps(countSQL, deleteSQL),
 			Expected: fmt.Sprintf("rows affected=%d", count),
 			Actual:   fmt.Sprintf("rows affected=%d", affected),
 			Details: map[string]any{
@@ -114,50 +134,235 @@ func (o DQE) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, sta
 				"actual_explain":       actualExplain,
 				"expected_explain_err": errString(expectedExplainErr),
 				"actual_explain_err":   errString(actualExplainErr),
+				"dqe_dml_hint":         hint,
+			},
+		}
+	}
+	return o.checkIndexes(ctx, exec, tbl.Name, indexes, hint, affected, countSQL, deleteSQL)
+}
+
+// dqeIndexCheckMax bounds the secondary indexes compared after one DML.
+const dqeIndexCheckMax = 4
+
+// dqeIndex is a secondary index of the DML table as the server reports it.
+// Generated single-column index names carry a sequence number, so they are
+// read from information_schema rather than derived from schema.State.
+type dqeIndex struct {
+	name    string
+	columns []string
+}
+
+// dqeLoadIndexes returns the secondary indexes of table. Expression indexes
+// are skipped; errors leave the run without hints and index checks.
+func dqeLoadIndexes(ctx context.Context, exec *db.DB, table string) []dqeIndex {
+	rows, err := exec.QueryContext(ctx, fmt.Sprintf(
+		"SELECT INDEX_NAME, COLUMN_NAME FROM information_schema.statistics WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = '%s' AND INDEX_NAME <> 'PRIMARY' ORDER BY INDEX_NAME, SEQ_IN_INDEX", table))
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = rows.Close() }()
+	var indexes []dqeIndex
+	skip := map[string]bool{}
+	err = exec.Guard.ScanRows(rows, func(values []sql.RawBytes) error {
+		name := string(values[0])
+		if values[1] == nil {
+			skip[name] = true
+			return nil
+		}
+		column := string(values[1])
+		if n := len(indexes); n > 0 && indexes[n-1].name == name {
+			indexes[n-1].columns = append(indexes[n-1].columns, column)
+			return nil
+		}
+		indexes = append(indexes, dqeIndex{name: name, columns: []string{column}})
+		return nil
+	})
+	if err != nil {
+		return nil
+	}
+	out := indexes[:0]
+	for _, idx := range indexes {
+		if !skip[idx.name] {
+			out = append(out, idx)
+		}
+	}
+	return out
+}
+
+// pickDQEHint picks the access path hint of the DML; "" runs it unhinted.
+func pickDQEHint(gen *generator.Generator, table string, indexes []dqeIndex) string {
+	candidates := []string{"", fmt.Sprintf(HintUsePrimaryFmt, table)}
+	if len(indexes) > 0 {
+		idx := indexes[gen.Rand.Intn(len(indexes))]
+		candidates = append(candidates,
+			fmt.Sprintf(HintUseIndexFmt, table+", "+idx.name),
+			fmt.Sprintf(HintIgnoreIndexFmt, table+", "+idx.name),
+			fmt.Sprintf(HintUseIndexMergeFmt, table),
+		)
+	}
+	return candidates[gen.Rand.Intn(len(candidates))]
+}
+
+// dqeWithHint places hint after the UPDATE or DELETE keyword.
+func dqeWithHint(dml string, hint string) string {
+	if hint == "" {
+		return dml
+	}
+	for _, verb := range []string{"UPDATE ", "DELETE "} {
+		if rest, ok := strings.CutPrefix(dml, verb); ok {
+			return verb + "/*+ " + hint + " */ " + rest
+		}
+	}
+	return dml
+}
+
+// dqeIndexCheckSQL returns signature queries over the index columns: one
+// reading only the index, one reading the table without any index.
+func dqeIndexCheckSQL(table string, idx dqeIndex) (indexSQL string, tableSQL string) {
+	sig := fmt.Sprintf("COUNT(*) AS cnt, IFNULL(BIT_XOR(CRC32(CONCAT_WS('#', %s))),0) AS checksum", strings.Join(idx.columns, ", "))
+	indexSQL = fmt.Sprintf("SELECT %s FROM %s FORCE INDEX (%s)", sig, table, idx.name)
+	tableSQL = fmt.Sprintf("SELECT %s FROM %s USE INDEX ()", sig, table)
+	return indexSQL, tableSQL
+}
+
+// checkIndexes compares each secondary index with a table scan after a DML
+// that changed rows. countSQL and dml are the passing run's statements.
+func (o DQE) checkIndexes(ctx context.Context, exec *db.DB, table string, indexes []dqeIndex, hint string, affected int64, countSQL string, dml string) Result {
+	metrics := map[string]int64{}
+	if hint != "" {
+		metrics["dqe_dml_hint_total"] = 1
+	}
+	if affected == 0 || len(indexes) == 0 {
+		return Result{OK: true, Oracle: o.Name(), Steps: dqeDMLSteps(countSQL, dml), Metrics: metrics}
+	}
+	dmlStep := sqlstep.New(sqlstep.KindSetup, "", dml)
+	for _, idx := range indexes[:min(len(indexes), dqeIndexCheckMax)] {
+		indexSQL, tableSQL := dqeIndexCheckSQL(table, idx)
+		metrics["dqe_index_check_total"]++
+		tableSig, err := exec.QuerySignature(ctx, tableSQL)
+		if err != nil {
+			return Result{OK: true, Oracle: o.Name(), Steps: []sqlstep.Step{dmlStep, queryStep(sqlstep.RoleFailing, tableSQL)}, Err: err, Metrics: metrics}
+		}
+		indexSig, err := exec.QuerySignature(ctx, indexSQL)
+		if err != nil {
+			return Result{OK: true, Oracle: o.Name(), Steps: []sqlstep.Step{dmlStep, queryStep(sqlstep.RoleExpected, tableSQL), queryStep(sqlstep.RoleFailing, indexSQL)}, Err: err, Metrics: metrics}
+		}
+		if indexSig == tableSig {
+			continue
+		}
+		expectedExplain, expectedExplainErr := explainSQL(ctx, exec, tableSQL)
+		actualExplain, actualExplainErr := explainSQL(ctx, exec, indexSQL)
+		return Result{
+			OK:       false,
+			Oracle:   o.Name(),
+			Steps:    append([]sqlstep.Step{dmlStep}, comparisonSteps(tableSQL, indexSQL)...),
+			Expected: fmt.Sprintf("table scan cnt=%d checksum=%d", tableSig.Count, tableSig.Checksum),
+			Actual:   fmt.Sprintf("index %s cnt=%d checksum=%d", idx.name, indexSig.Count, indexSig.Checksum),
+			Details: map[string]any{
+				"replay_kind":          "signature",
+				"replay_expected_sql":  tableSQL,
+				"replay_actual_sql":    indexSQL,
+				"expected_explain":     expectedExplain,
+				"actual_explain":       actualExplain,
+				"expected_explain_err": errString(expectedExplainErr),
+				"actual_explain_err":   errString(actualExplainErr),
+				"dqe_index":            idx.name,
+				"dqe_dml_hint":         hint,
 			},
+			Metrics: metrics,
 		}
 	}
-	return Result{OK: true, Oracle: o.Name(), SQL: []string{deleteSQL, countSQL}}
+	return Result{OK: true, Oracle: o.Name(), Steps: dqeDMLSteps(countSQL, dml), Metrics: metrics}
+}
+
+// dqeDMLSteps records the count query and the DML whose rows affected are
+// checked against it. The DML is the replay statement, so plan replayer dumps
+// capture the plan under test rather than the count.
+func dqeDMLSteps(countSQL string, dml string) []sqlstep.Step {
+	return []sqlstep.Step{
+		queryStep(sqlstep.RoleExpected, countSQL),
+		queryStep(sqlstep.RoleReplay, dml),
+	}
+}
+
+func dqeFailedDMLSteps(countSQL string, dml string) []sqlstep.Step {
+	return []sqlstep.Step{
+		queryStep(sqlstep.RoleExpected, countSQL),
+		queryStep(sqlstep.RoleFailing, dml),
+	}
+}
+
+// dqeUpdateCountSQL counts the rows the UPDATE changes. With an ORDER BY ...
+// LIMIT tail the limit applies to the matched rows, changed or not, so the
+// change test moves outside the limited derived table.
+func dqeUpdateCountSQL(table, predicate, colSQL, setExprSQL string, orderLimit *generator.DMLOrderLimit) string {
+	if orderLimit == nil {
+		return fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s AND NOT (%s <=> %s)", table, predicate, colSQL, setExprSQL)
+	}
+	return fmt.Sprintf("SELECT COUNT(*) FROM (SELECT NOT (%s <=> %s) AS changed FROM %s WHERE %s%s) dqe WHERE dqe.changed",
+		colSQL, setExprSQL, table, predicate, orderLimit.SQL())
+}
+
+// dqeDeleteCountSQL counts the rows the DELETE removes.
+func dqeDeleteCountSQL(table, predicate string, orderLimit *generator.DMLOrderLimit) string {
+	if orderLimit == nil {
+		return fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", table, predicate)
+	}
+	return fmt.Sprintf("SELECT COUNT(*) FROM (SELECT 1 AS matched FROM %s WHERE %s%s) dqe", table, predicate, orderLimit.SQL())
+}
+
+func dqeMismatchDetails(countSQL, dmlSQL string, orderLimit *generator.DMLOrderLimit, details map[string]any) map[string]any {
+	details["replay_kind"] = "rows_affected"
+	details["replay_expected_sql"] = countSQL
+	details["replay_actual_sql"] = dmlSQL
+	if orderLimit != nil {
+		details["dqe_order_limit"] = strings.TrimSpace(orderLimit.SQL())
+	}
+	return details
 }
 
-func pickDQEUpdate(gen *generator.Generator, tbl schema.Table) (sql string, predicate generator.Expr, setExpr generator.Expr, colRef generator.ColumnRef) {
+func pickDQEUpdate(gen *generator.Generator, tbl schema.Table) (sql string, predicate generator.Expr, setExpr generator.Expr, colRef generator.ColumnRef, orderLimit *generator.DMLOrderLimit) {
 	const maxTries = 5
 	var firstSQL string
 	var firstPred generator.Expr
 	var firstSet generator.Expr
 	var firstRef generator.ColumnRef
+	var firstOrderLimit *generator.DMLOrderLimit
 	for i := 0; i < maxTries; i++ {
 		sql, predicate, setExpr, colRef = gen.UpdateSQL(tbl)
+		orderLimit = gen.LastDMLOrderLimit
 		if i == 0 {
-			firstSQL, firstPred, firstSet, firstRef = sql, predicate, setExpr, colRef
+			firstSQL, firstPred, firstSet, firstRef, firstOrderLimit = sql, predicate, setExpr, colRef, orderLimit
 		}
 		if predicate == nil {
 			continue
 		}
 		hasExists, hasNotExists := generator.ExprHasExistsSubquery(predicate)
 		if hasExists || hasNotExists {
-			return sql, predicate, setExpr, colRef
+			return sql, predicate, setExpr, colRef, orderLimit
 		}
 	}
-	return firstSQL, firstPred, firstSet, firstRef
+	return firstSQL, firstPred, firstSet, firstRef, firstOrderLimit
 }
 
-func pickDQEDelete(gen *generator.Generator, tbl schema.Table) (sql string, predicate generator.Expr) {
+func pickDQEDelete(gen *generator.Generator, tbl schema.Table) (sql string, predicate generator.Expr, orderLimit *generator.DMLOrderLimit) {
 	const maxTries = 5
 	var firstSQL string
 	var firstPred generator.Expr
+	var firstOrderLimit *generator.DMLOrderLimit
 	for i := 0; i < maxTries; i++ {
 		sql, predicate = gen.DeleteSQL(tbl)
+		orderLimit = gen.LastDMLOrderLimit
 		if i == 0 {
-			firstSQL, firstPred = sql, predicate
+			firstSQL, firstPred, firstOrderLimit = sql, predicate, orderLimit
 		}
 		if predicate == nil {
 			continue
 		}
 		hasExists, hasNotExists := generator.ExprHasExistsSubquery(predicate)
 		if hasExists || hasNotExists {
-			return sql, predicate
+			return sql, predicate, orderLimit
 		}
 	}
-	return firstSQL, firstPred
+	return firstSQL, firstPred, firstOrderLimit
 }
@@ -2,6 +2,7 @@ package oracle
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"shiro/internal/config"
@@ -24,3 +25,62 @@ func TestDQENoTablesSkip(t *testing.T) {
 		t.Fatalf("expected skip reason")
 	}
 }
+
+func TestDQECountSQLWithOrderLimit(t *testing.T) {
+	if got := dqeUpdateCountSQL("t0", "(t0.c0 > 1)", "t0.c1", "(t0.c1 + 1)", nil); got != "SELECT COUNT(*) FROM t0 WHERE (t0.c0 > 1) AND NOT (t0.c1 <=> (t0.c1 + 1))" {
+		t.Fatalf("unexpected update count SQL: %s", got)
+	}
+	tail := &generator.DMLOrderLimit{OrderBy: []string{"id"}, Limit: 2}
+	want := "SELECT COUNT(*) FROM (SELECT NOT (t0.c1 <=> (t0.c1 + 1)) AS changed FROM t0 WHERE (t0.c0 > 1) ORDER BY id LIMIT 2) dqe WHERE dqe.changed"
+	if got := dqeUpdateCountSQL("t0", "(t0.c0 > 1)", "t0.c1", "(t0.c1 + 1)", tail); got != want {
+		t.Fatalf("unexpected limited update count SQL: %s", got)
+	}
+	want = "SELECT COUNT(*) FROM (SELECT 1 AS matched FROM t0 WHERE (t0.c0 > 1) ORDER BY id LIMIT 2) dqe"
+	if got := dqeDeleteCountSQL("t0", "(t0.c0 > 1)", tail); got != want {
+		t.Fatalf("unexpected limited delete count SQL: %s", got)
+	}
+	details := dqeMismatchDetails("c", "d", tail, map[string]any{})
+	if details["dqe_order_limit"] != "ORDER BY id LIMIT 2" || details["replay_kind"] != "rows_affected" {
+		t.Fatalf("unexpected details: %v", details)
+	}
+}
+
+func TestDQEHintedDML(t *testing.T) {
+	if got := dqeWithHint("UPDATE t0 SET c0 = 1 WHERE (t0.c1 > 2)", "USE_INDEX(t0, idx_c1_3)"); got != "UPDATE /*+ USE_INDEX(t0, idx_c1_3) */ t0 SET c0 = 1 WHERE (t0.c1 > 2)" {
+		t.Fatalf("unexpected hinted update: %s", got)
+	}
+	if got := dqeWithHint("DELETE FROM t0 WHERE (t0.c1 > 2)", "USE_INDEX_MERGE(t0)"); got != "DELETE /*+ USE_INDEX_MERGE(t0) */ FROM t0 WHERE (t0.c1 > 2)" {
+		t.Fatalf("unexpected hinted delete: %s", got)
+	}
+	if got := dqeWithHint("DELETE FROM t0", ""); got != "DELETE FROM t0" {
+		t.Fatalf("empty hint should keep the DML: %s", got)
+	}
+
+	cfg, err := config.Load("../../config.example.yaml")
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	gen := generator.New(cfg, &schema.State{}, 7)
+	seen := map[string]bool{}
+	for i := 0; i < 200; i++ {
+		seen[pickDQEHint(gen, "t0", []dqeIndex{{name: "idx_c1_3", columns: []string{"c1"}}})] = true
+	}
+	for _, want := range []string{"", "USE_INDEX(t0, PRIMARY)", "USE_INDEX(t0, idx_c1_3)", "IGNORE_INDEX(t0, idx_c1_3)", "USE_INDEX_MERGE(t0)"} {
+		if !seen[want] {
+			t.Fatalf("hint %q never picked: %v", want, seen)
+		}
+	}
+	if hint := pickDQEHint(gen, "t0", nil); strings.Contains(hint, "idx") || strings.Contains(hint, "MERGE") {
+		t.Fatalf("index hint picked without indexes: %s", hint)
+	}
+}
+
+func TestDQEIndexCheckSQL(t *testing.T) {
+	indexSQL, tableSQL := dqeIndexCheckSQL("t0", dqeIndex{name: "idx_c0_c1", columns: []string{"c0", "c1"}})
+	if indexSQL != "SELECT COUNT(*) AS cnt, IFNULL(BIT_XOR(CRC32(CONCAT_WS('#', c0, c1))),0) AS checksum FROM t0 FORCE INDEX (idx_c0_c1)" {
+		t.Fatalf("unexpected index SQL: %s", indexSQL)
+	}
+	if tableSQL != "SELECT COUNT(*) AS cnt, IFNULL(BIT_XOR(CRC32(CONCAT_WS('#', c0, c1))),0) AS checksum FROM t0 USE INDEX ()" {
+		t.Fatalf("unexpected table SQL: %s", tableSQL)
+	}
+}
@@ -7,10 +7,12 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"sync/atomic"
 
 	"shiro/internal/db"
 	"shiro/internal/generator"
 	"shiro/internal/schema"
+	"shiro/internal/sqlstep"
 	"shiro/internal/util"
 )
 
@@ -20,6 +22,18 @@ import (
 // and compares result signatures (COUNT + checksum). Any mismatch suggests a plan-
 // dependent correctness bug in the optimizer or execution engine.
 type DQP struct {
+	// hints are the hints this target's startup probe rejected.
+	hints *hintSupport
+	// fixControlCursor rotates the fix-control campaign through the IDs, so
+	// every ID gets the same share of queries across the target's workers.
+	fixControlCursor *atomic.Uint64
+}
+
+// NewDQP returns a DQP with its own hint support and fix-control rotation.
+// Each runner builds one, so targets on different TiDB versions do not share
+// probe results.
+func NewDQP() DQP {
+	return DQP{hints: newHintSupport(), fixControlCursor: new(atomic.Uint64)}
 }
 
 // Name returns the oracle identifier.
@@ -82,14 +96,7 @@ func (o DQP) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, sta
 		PredicateGuard:  true,
 		MaxTries:        dqpBuildMaxTries,
 		Constraints: generator.SelectQueryConstraints{
-			RequireDeterministic: true,
-			PredicateMode:        generator.PredicateModeSimpleColumns,
-			DisallowLimit:        true,
-			DisallowWindow:       true,
-			DisallowSetOps:       true,
-			MaxJoinCount:         3,
-			MaxJoinCountSet:      true,
-			QueryGuardReason:     dqpComplexityQueryGuardReason(setOpsThreshold, derivedThreshold),
+			QueryGuardReason: dqpComplexityQueryGuardReason(setOpsThreshold, derivedThreshold),
 		},
 		SkipReasonOverrides: map[string]string{
 			"constraint:limit":                    "dqp:limit",
@@ -139,14 +146,37 @@ func (o DQP) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, sta
 		if code != 0 {
 			details["error_code"] = int(code)
 		}
-		return Result{OK: true, Oracle: o.Name(), SQL: []string{baseSQL}, SQLFeatures: observed, Err: err, Details: details}
+		return Result{OK: true, Oracle: o.Name(), Steps: []sqlstep.Step{queryStep(sqlstep.RoleFailing, baseSQL)}, SQLFeatures: observed, Err: err, Details: details}
 	}
 	dqpLogWarnings("base", "", baseSQL, baseWarnings)
+	if dqpFixControlCampaign(gen) {
+		if ids := o.pickFixControls(gen.Config.Oracles); len(ids) > 0 {
+			return o.runFixControls(ctx, exec, query, ids, baseFeatures, observed)
+		}
+	}
 
 	hasCTE := len(query.With) > 0
 	hasPartition := queryHasPartitionedTable(query, state)
-	variants, variantMetrics := buildDQPVariants(query, state, hasSemi, hasCorr, hasAgg, hasSubquery, hasCTE, hasPartition, gen)
+	variants, variantMetrics := buildDQPVariants(o.hints, query, state, hasSemi, hasCorr, hasAgg, hasSubquery, hasCTE, hasPartition, gen)
+	baseExplain, baseExplainErr := explainSQL(ctx, exec, baseSignatureSQL)
 	for _, variant := range variants {
+		if variant.sql == baseSQL {
+			// Hint injection fell back to the base query.
+			variantMetrics.observePlan(variant.hint, false)
+			continue
+		}
+		variantExplain, variantExplainErr := explainSQL(ctx, exec, variant.signatureSQL)
+		if baseExplainErr == nil && variantExplainErr == nil {
+			changed := dqpPlanChanged(baseExplain, variantExplain)
+			variantMetrics.observePlan(variant.hint, changed)
+			if !changed {
+				// Same plan cannot disprove anything; keep it out of the comparison.
+				updateHintBandit(variant.hint, dqpHintReward(false), gen.Config.Adaptive.WindowSize, gen.Config.Adaptive.UCBExploration)
+				continue
+			}
+		} else {
+			variantMetrics.planCheckErrTotal++
+		}
 		recordObservedExecSQL(exec, variant.signatureSQL, baseFeatures)
 		observed = recordObservedResultSQL(observed, variant.sql, baseFeatures)
 		variantSig, warnings, err := exec.QuerySignatureWithWarnings(ctx, variant.signatureSQL)
@@ -157,17 +187,15 @@ func (o DQP) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, sta
 		mismatch := variantSig != baseSig
 		updateHintBandit(variant.hint, dqpHintReward(mismatch), gen.Config.Adaptive.WindowSize, gen.Config.Adaptive.UCBExploration)
 		if mismatch {
-			expectedExplain, expectedExplainErr := explainSQL(ctx, exec, query.SignatureSQL())
-			actualExplain, actualExplainErr := explainSQL(ctx, exec, variant.signatureSQL)
 			details := map[string]any{
 				"hint":                 variant.hint,
 				"replay_kind":          "signature",
 				"replay_expected_sql":  query.SignatureSQL(),
 				"replay_actual_sql":    variant.signatureSQL,
-				"expected_explain":     expectedExplain,
-				"actual_explain":       actualExplain,
-				"expected_explain_err": errString(expectedExplainErr),
-				"actual_explain_err":   errString(actualExplainErr),
+				"expected_explain":     baseExplain,
+				"actual_explain":       variantExplain,
+				"expected_explain_err": errString(baseExplainErr),
+				"actual_explain_err":   errString(variantExplainErr),
 			}
 			if setVarAssignment, ok := dqpReplaySetVarAssignment(variant.hint); ok {
 				details["replay_set_var"] = setVarAssignment
@@ -175,7 +203,7 @@ func (o DQP) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, sta
 			return Result{
 				OK:          false,
 				Oracle:      o.Name(),
-				SQL:         []string{baseSQL, variant.sql},
+				Steps:       comparisonSteps(baseSQL, variant.sql),
 				SQLFeatures: observed,
 				Expected:    fmt.Sprintf("cnt=%d checksum=%d", baseSig.Count, baseSig.Checksum),
 				Actual:      fmt.Sprintf("cnt=%d checksum=%d", variantSig.Count, variantSig.Checksum),
@@ -184,7 +212,7 @@ func (o DQP) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, sta
 			}
 		}
 	}
-	return Result{OK: true, Oracle: o.Name(), SQL: []string{baseSQL}, SQLFeatures: observed, Metrics: variantMetrics.resultMetrics()}
+	return Result{OK: true, Oracle: o.Name(), Steps: []sqlstep.Step{queryStep(sqlstep.RoleExpected, baseSQL)}, SQLFeatures: observed, Metrics: variantMetrics.resultMetrics()}
 }
 
 type dqpComplexityStats struct {
@@ -410,6 +438,37 @@ type dqpVariantMetrics struct {
 	hintLengthMax      int64
 	hintLengthSum      int64
 	hintLengthCount    int64
+	planChangedTotal   int64
+	planSameTotal      int64
+	planCheckErrTotal  int64
+	planChangedByHint  map[string]int64
+	planSameByHint     map[string]int64
+}
+
+// observePlan records whether a variant's EXPLAIN differs from the base plan,
+// both in total and per hint key so hints that never move the plan stand out.
+func (m *dqpVariantMetrics) observePlan(hint string, changed bool) {
+	if m == nil {
+		return
+	}
+	key := dqpHintKey(hint)
+	if changed {
+		m.planChangedTotal++
+		if key != "" {
+			if m.planChangedByHint == nil {
+				m.planChangedByHint = make(map[string]int64)
+			}
+			m.planChangedByHint[key]++
+		}
+		return
+	}
+	m.planSameTotal++
+	if key != "" {
+		if m.planSameByHint == nil {
+			m.planSameByHint = make(map[string]int64)
+		}
+		m.planSameByHint[key]++
+	}
 }
 
 func (m *dqpVariantMetrics) observeVariant(baseSQL string, variantSQL string, hint string) {
@@ -442,13 +501,19 @@ func (m dqpVariantMetrics) resultMetrics() map[string]int64 {
 	if m.hintInjectedTotal == 0 &&
 		m.hintFallbackTotal == 0 &&
 		m.setVarVariantTotal == 0 &&
-		m.hintLengthCount == 0 {
+		m.hintLengthCount == 0 &&
+		m.planChangedTotal == 0 &&
+		m.planSameTotal == 0 &&
+		m.planCheckErrTotal == 0 {
 		return nil
 	}
 	metrics := map[string]int64{
 		"dqp_hint_injected_total":   m.hintInjectedTotal,
 		"dqp_hint_fallback_total":   m.hintFallbackTotal,
 		"dqp_set_var_variant_total": m.setVarVariantTotal,
+		"dqp_plan_changed_total":    m.planChangedTotal,
+		"dqp_plan_same_skip_total":  m.planSameTotal,
+		"dqp_plan_check_err_total":  m.planCheckErrTotal,
 	}
 	if m.hintLengthCount > 0 {
 		metrics["dqp_hint_length_min"] = m.hintLengthMin
@@ -456,9 +521,74 @@ func (m dqpVariantMetrics) resultMetrics() map[string]int64 {
 		metrics["dqp_hint_length_sum"] = m.hintLengthSum
 		metrics["dqp_hint_length_count"] = m.hintLengthCount
 	}
+	for key, count := range m.planChangedByHint {
+		metrics[DQPPlanChangedHintMetricPrefix+key] = count
+	}
+	for key, count := range m.planSameByHint {
+		metrics[DQPPlanSameHintMetricPrefix+key] = count
+	}
 	return metrics
 }
 
+// DQPPlanChangedHintMetricPrefix and DQPPlanSameHintMetricPrefix prefix per-hint
+// plan-change counters in Result.Metrics; the suffix is the dqpHintKey.
+const (
+	DQPPlanChangedHintMetricPrefix = "dqp_plan_changed_hint:"
+	DQPPlanSameHintMetricPrefix    = "dqp_plan_same_hint:"
+)
+
+// dqpPlanChanged reports whether two EXPLAIN outputs differ after trimming
+// whitespace and blank lines.
+func dqpPlanChanged(baseExplain string, variantExplain string) bool {
+	return dqpNormalizeExplain(baseExplain) != dqpNormalizeExplain(variantExplain)
+}
+
+func dqpNormalizeExplain(text string) string {
+	lines := strings.Split(text, "\n")
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		out = append(out, trimmed)
+	}
+	return strings.Join(out, "\n")
+}
+
+// dqpHintKey reduces a hint list to its hint names (and SET_VAR variable names)
+// so counters group by hint kind instead of by table arguments.
+// Example: "HASH_JOIN(t1, t2), SET_VAR(tidb_opt_x=ON)" -> "HASH_JOIN+SET_VAR(tidb_opt_x)".
+func dqpHintKey(hint string) string {
+	tokens := splitTopLevelHintList(hint)
+	keys := make([]string, 0, len(tokens))
+	seen := make(map[string]struct{}, len(tokens))
+	for _, token := range tokens {
+		token = strings.TrimSpace(token)
+		if token == "" {
+			continue
+		}
+		key := token
+		if idx := strings.IndexByte(token, '('); idx >= 0 {
+			key = strings.ToUpper(strings.TrimSpace(token[:idx]))
+			if key == "SET_VAR" {
+				if name, _, ok := dqpParseSingleSetVarHint(token); ok {
+					key = "SET_VAR(" + strings.ToLower(name) + ")"
+				}
+			}
+		} else {
+			key = strings.ToUpper(key)
+		}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, "+")
+}
+
 func dqpHintContainsSetVar(hint string) bool {
 	for _, token := range splitTopLevelHintList(hint) {
 		trimmed := strings.TrimSpace(token)
@@ -524,6 +654,7 @@ type dqpHintTableFactor struct {
 	tableName  string
 	derived    bool
 	hasIndex   bool
+	hasPK      bool
 	indexCount int
 }
 
@@ -543,9 +674,10 @@ func dqpHintTableFactors(query *generator.SelectQuery, state *schema.State) []dq
 			derived:   derived,
 		}
 		if state != nil && !derived && factor.tableName != "" {
-			if tbl, ok := state.TableByName(factor.tableName); ok {
-				factor.hasIndex = tableHasIndex(tbl)
-				factor.indexCount = dqpTableIndexCount(tbl)
+			if traits, ok := lookupTableTraits(state, factor.tableName); ok {
+				factor.hasIndex = traits.hasIndex
+				factor.hasPK = traits.hasPK
+				factor.indexCount = traits.indexCount
 			}
 		}
 		factors = append(factors, factor)
@@ -580,6 +712,7 @@ func dqpDedupHintTableFactors(in []dqpHintTableFactor) []dqpHintTableFactor {
 		if idx, ok := seen[key]; ok {
 			merged := out[idx]
 			merged.hasIndex = merged.hasIndex || factor.hasIndex
+			merged.hasPK = merged.hasPK || factor.hasPK
 			if factor.indexCount > merged.indexCount {
 				merged.indexCount = factor.indexCount
 			}
@@ -716,6 +849,11 @@ func dqpIndexHintCandidates(query *generator.SelectQuery, state *schema.State) [
 			continue
 		}
 		candidates = append(candidates, fmt.Sprintf(HintUseIndexFmt, factor.hintName))
+		if factor.hasPK {
+			// PRIMARY is a handle range on CLUSTERED tables and an index
+			// lookup through _tidb_rowid on NONCLUSTERED ones.
+			candidates = append(candidates, fmt.Sprintf(HintUsePrimaryFmt, factor.hintName))
+		}
 		if dqpShouldUseIndexMergeHint(query, factor) {
 			candidates = append(candidates, fmt.Sprintf(HintUseIndexMergeFmt, factor.hintName))
 		}
@@ -812,7 +950,7 @@ func dqpDedupHints(candidates []string) []string {
 	return out
 }
 
-func buildDQPVariants(query *generator.SelectQuery, state *schema.State, hasSemi bool, hasCorr bool, hasAgg bool, hasSubquery bool, hasCTE bool, hasPartition bool, gen *generator.Generator) ([]dqpVariant, dqpVariantMetrics) {
+func buildDQPVariants(hints *hintSupport, query *generator.SelectQuery, state *schema.State, hasSemi bool, hasCorr bool, hasAgg bool, hasSubquery bool, hasCTE bool, hasPartition bool, gen *generator.Generator) ([]dqpVariant, dqpVariantMetrics) {
 	tables := dqpHintTableNames(query, state)
 	if len(tables) == 0 {
 		tables = make([]string, 0, 1+len(query.From.Joins))
@@ -834,7 +972,7 @@ func buildDQPVariants(query *generator.SelectQuery, state *schema.State, hasSemi
 		HintAggToCop:        {},
 	}
 	externalBaseHints, externalSetVarHints := dqpExternalHintCandidates(gen, tables, noArgHints)
-	baseHints := dqpHintsForBuiltQuery(gen, query, state, hasSemi, hasCorr, hasAgg, noArgHints, externalBaseHints)
+	baseHints := dqpHintsForBuiltQuery(hints, gen, query, state, hasSemi, hasCorr, hasAgg, noArgHints, externalBaseHints)
 	variants := make([]dqpVariant, 0, len(baseHints)+1)
 
 	for _, hintSQL := range baseHints {
@@ -853,7 +991,7 @@ func buildDQPVariants(query *generator.SelectQuery, state *schema.State, hasSemi
 		})
 	}
 
-	setVarHints := dqpSetVarHints(gen, dqpJoinTableCountWithCTE(query), hasJoin, hasSemi, hasCorr, hasSubquery, hasCTE, hasPartition, externalSetVarHints)
+	setVarHints := dqpSetVarHints(hints, gen, dqpJoinTableCountWithCTE(query), hasJoin, hasSemi, hasCorr, hasSubquery, hasCTE, hasPartition, externalSetVarHints)
 	nonMPPSetVarHints := dqpFilterSetVarHints(setVarHints, false)
 	mppSetVarHints := dqpFilterSetVarHints(setVarHints, true)
 	for _, hintSQL := range nonMPPSetVarHints {
@@ -902,7 +1040,7 @@ func buildDQPVariants(query *generator.SelectQuery, state *schema.State, hasSemi
 			group:        dqpVariantGroupCombined,
 		})
 	}
-	for _, hint := range dqpIndexHintCandidates(query, state) {
+	for _, hint := range hints.filter(gen, dqpIndexHintCandidates(query, state)) {
 		cappedHint := dqpLimitHintTokens(hint, dqpMaxHintsPerSQL)
 		if cappedHint == "" {
 			continue
@@ -921,7 +1059,7 @@ func buildDQPVariants(query *generator.SelectQuery, state *schema.State, hasSemi
 	return variants, metrics
 }
 
-func dqpHintsForBuiltQuery(gen *generator.Generator, query *generator.SelectQuery, state *schema.State, hasSemi bool, hasCorr bool, hasAgg bool, noArgHints map[string]struct{}, externalBaseHints []string) []string {
+func dqpHintsForBuiltQuery(hints *hintSupport, gen *generator.Generator, query *generator.SelectQuery, state *schema.State, hasSemi bool, hasCorr bool, hasAgg bool, noArgHints map[string]struct{}, externalBaseHints []string) []string {
 	var candidates []string
 	candidates = append(candidates, dqpJoinHintCandidates(query, state, noArgHints)...)
 	if hasAgg {
@@ -940,10 +1078,10 @@ func dqpHintsForBuiltQuery(gen *generator.Generator, query *generator.SelectQuer
 		candidates = append(candidates, buildHintSQL(HintNoDecorrelate, tables, noArgHints))
 	}
 	candidates = append(candidates, externalBaseHints...)
-	return pickHintsWithBandit(gen, dqpDedupHints(candidates), dqpBaseHintPickLimit(gen))
+	return pickHintsWithBandit(gen, hints.filter(gen, dqpDedupHints(candidates)), dqpBaseHintPickLimit(gen))
 }
 
-func dqpHintsForQuery(gen *generator.Generator, tables []string, hasJoin bool, hasSemi bool, hasCorr bool, hasAgg bool, noArgHints map[string]struct{}, externalBaseHints []string) []string {
+func dqpHintsForQuery(hints *hintSupport, gen *generator.Generator, tables []string, hasJoin bool, hasSemi bool, hasCorr bool, hasAgg bool, noArgHints map[string]struct{}, externalBaseHints []string) []string {
 	var candidates []string
 	if hasJoin {
 		joinHints := []string{
@@ -974,11 +1112,11 @@ func dqpHintsForQuery(gen *generator.Generator, tables []string, hasJoin bool, h
 		candidates = append(candidates, buildHintSQL(HintNoDecorrelate, tables, noArgHints))
 	}
 	candidates = append(candidates, externalBaseHints...)
-	return pickHintsWithBandit(gen, candidates, dqpBaseHintPickLimit(gen))
+	return pickHintsWithBandit(gen, hints.filter(gen, candidates), dqpBaseHintPickLimit(gen))
 }
 
-func dqpSetVarHints(gen *generator.Generator, tableCount int, hasJoin bool, hasSemi bool, hasCorr bool, hasSubquery bool, hasCTE bool, hasPartition bool, externalSetVarHints []string) []string {
-	candidates := dqpSetVarHintCandidates(gen, tableCount, hasJoin, hasSemi, hasCorr, hasSubquery, hasCTE, hasPartition, externalSetVarHints)
+func dqpSetVarHints(hints *hintSupport, gen *generator.Generator, tableCount int, hasJoin bool, hasSemi bool, hasCorr bool, hasSubquery bool, hasCTE bool, hasPartition bool, externalSetVarHints []string) []string {
+	candidates := hints.filter(gen, dqpSetVarHintCandidates(gen, tableCount, hasJoin, hasSemi, hasCorr, hasSubquery, hasCTE, hasPartition, externalSetVarHints))
 	if len(candidates) == 0 {
 		return nil
 	}
@@ -1524,11 +1662,7 @@ func queryHasIndexCandidate(query *generator.SelectQuery, state *schema.State) b
 		tables = append(tables, join.Table)
 	}
 	for _, tbl := range tables {
-		table, ok := state.TableByName(tbl)
-		if !ok {
-			continue
-		}
-		if tableHasIndex(table) {
+		if traits, ok := lookupTableTraits(state, tbl); ok && traits.hasIndex {
 			return true
 		}
 	}
@@ -1544,11 +1678,7 @@ func queryHasPartitionedTable(query *generator.SelectQuery, state *schema.State)
 		tables = append(tables, join.Table)
 	}
 	for _, tbl := range tables {
-		table, ok := state.TableByName(tbl)
-		if !ok {
-			continue
-		}
-		if table.Partitioned {
+		if traits, ok := lookupTableTraits(state, tbl); ok && traits.partitioned {
 			return true
 		}
 	}
@@ -0,0 +1,159 @@
+package oracle
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"shiro/internal/config"
+	"shiro/internal/db"
+	"shiro/internal/generator"
+	"shiro/internal/sqlstep"
+)
+
+// dqpKnownFixControls are the on/off tidb_opt_fix_control IDs defined in
+// TiDB's pkg/planner/util/fixcontrol. IDs that take a number, such as 44823
+// and 45132, are left out because ON and OFF do not toggle them.
+var dqpKnownFixControls = []string{
+	"33031", "43817", "44262", "44389", "44830", "44855", "45822",
+	"46177", "47400", "49736", "52592", "52869", "54337", "56318",
+}
+
+// DQPFixControlRunMetricPrefix and the other DQPFixControl*MetricPrefix
+// constants prefix the per-ID counters of the fix-control campaign in
+// Result.Metrics; the suffix is the fix-control ID.
+const (
+	DQPFixControlRunMetricPrefix         = "dqp_fix_control_run:"
+	DQPFixControlPlanChangedMetricPrefix = "dqp_fix_control_plan_changed:"
+	DQPFixControlMismatchMetricPrefix    = "dqp_fix_control_mismatch:"
+	DQPFixControlErrorMetricPrefix       = "dqp_fix_control_error:"
+)
+
+const dqpModeFixControl = "fix_control"
+
+// DQPFixControlIDs returns the known fix-control IDs followed by the
+// configured extra ones, without duplicates.
+func DQPFixControlIDs(cfg config.OracleConfig) []string {
+	ids := append([]string{}, dqpKnownFixControls...)
+	for _, id := range cfg.DQPFixControls {
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// dqpFixControlHint returns the SET_VAR hint that turns fix control id on
+// or off for one statement.
+func dqpFixControlHint(id string, on bool) string {
+	value := "OFF"
+	if on {
+		value = "ON"
+	}
+	return fmt.Sprintf("SET_VAR(tidb_opt_fix_control='%s:%s')", id, value)
+}
+
+// dqpFixControlCampaign reports whether this DQP run compares fix controls
+// instead of the usual hint variants.
+func dqpFixControlCampaign(gen *generator.Generator) bool {
+	if gen == nil || gen.Rand == nil {
+		return false
+	}
+	prob := gen.Config.Oracles.DQPFixControlProb
+	return prob > 0 && gen.Rand.Intn(100) < prob
+}
+
+// pickFixControls returns the next n fix-control IDs in rotation that this
+// target supports.
+func (o DQP) pickFixControls(cfg config.OracleConfig) []string {
+	var ids []string
+	for _, id := range DQPFixControlIDs(cfg) {
+		if o.hints.supported(dqpFixControlHint(id, true)) {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	n := min(max(cfg.DQPFixControlPerQuery, 1), len(ids))
+	var start uint64
+	if o.fixControlCursor != nil {
+		start = o.fixControlCursor.Add(uint64(n)) - uint64(n)
+	}
+	picked := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		picked = append(picked, ids[(start+uint64(i))%uint64(len(ids))])
+	}
+	return picked
+}
+
+// runFixControls runs query with each fix control of ids forced off and on
+// and compares the two signatures. Pairs whose plans do not differ are
+// skipped, since the same plan cannot disprove anything. Per-ID counts of
+// runs, plan changes, mismatches, and errors go to Result.Metrics.
+func (o DQP) runFixControls(ctx context.Context, exec *db.DB, query *generator.SelectQuery, ids []string, baseFeatures db.SQLSubqueryFeatures, observed map[string]db.SQLSubqueryFeatures) Result {
+	baseSQL := query.SQLString()
+	details := map[string]any{"dqp_mode": dqpModeFixControl}
+	metrics := make(map[string]int64)
+	for _, id := range ids {
+		offHint, onHint := dqpFixControlHint(id, false), dqpFixControlHint(id, true)
+		offSQL, onSQL := injectHint(query, offHint), injectHint(query, onHint)
+		if offSQL == baseSQL || onSQL == baseSQL {
+			continue
+		}
+		metrics[DQPFixControlRunMetricPrefix+id]++
+		offSigSQL, onSigSQL := dqpSignatureSQL(query, offSQL), dqpSignatureSQL(query, onSQL)
+		offExplain, offExplainErr := explainSQL(ctx, exec, offSigSQL)
+		onExplain, onExplainErr := explainSQL(ctx, exec, onSigSQL)
+		if offExplainErr == nil && onExplainErr == nil {
+			if !dqpPlanChanged(offExplain, onExplain) {
+				continue
+			}
+			metrics[DQPFixControlPlanChangedMetricPrefix+id]++
+		}
+		recordObservedExecSQLs(exec, baseFeatures, offSigSQL, onSigSQL)
+		observed = recordObservedResultSQLs(observed, baseFeatures, offSQL, onSQL)
+		offSig, err := exec.QuerySignature(ctx, offSigSQL)
+		if err != nil {
+			metrics[DQPFixControlErrorMetricPrefix+id]++
+			continue
+		}
+		onSig, err := exec.QuerySignature(ctx, onSigSQL)
+		if err != nil {
+			metrics[DQPFixControlErrorMetricPrefix+id]++
+			continue
+		}
+		if offSig == onSig {
+			continue
+		}
+		metrics[DQPFixControlMismatchMetricPrefix+id]++
+		details["fix_control"] = id
+		details["hint"] = onHint
+		details["replay_kind"] = "signature"
+		details["replay_expected_sql"] = offSigSQL
+		details["replay_actual_sql"] = onSigSQL
+		details["expected_explain"] = offExplain
+		details["actual_explain"] = onExplain
+		details["expected_explain_err"] = errString(offExplainErr)
+		details["actual_explain_err"] = errString(onExplainErr)
+		return Result{
+			OK:          false,
+			Oracle:      o.Name(),
+			Steps:       comparisonSteps(offSQL, onSQL),
+			SQLFeatures: observed,
+			Expected:    fmt.Sprintf("cnt=%d checksum=%d", offSig.Count, offSig.Checksum),
+			Actual:      fmt.Sprintf("cnt=%d checksum=%d", onSig.Count, onSig.Checksum),
+			Details:     details,
+			Metrics:     metrics,
+		}
+	}
+	details["fix_controls"] = strings.Join(ids, ",")
+	return Result{OK: true, Oracle: o.Name(), Steps: []sqlstep.Step{queryStep(sqlstep.RoleExpected, baseSQL)}, SQLFeatures: observed, Details: details, Metrics: metrics}
+}
+
+// dqpSignatureSQL wraps a variant of query in the COUNT + checksum query the
+// DQP comparisons run.
+func dqpSignatureSQL(query *generator.SelectQuery, variantSQL string) string {
+	return fmt.Sprintf("SELECT COUNT(*) AS cnt, IFNULL(BIT_XOR(CRC32(CONCAT_WS('#', %s))),0) AS checksum FROM (%s) q", signatureSelectList(query), variantSQL)
+}
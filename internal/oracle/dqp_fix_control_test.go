@@ -0,0 +1,53 @@
+package oracle
+
+import (
+	"slices"
+	"testing"
+
+	"shiro/internal/config"
+)
+
+func TestDQPFixControlIDs(t *testing.T) {
+	cfg := config.OracleConfig{DQPFixControls: []string{"44830", "99999"}}
+	ids := DQPFixControlIDs(cfg)
+	if len(ids) != len(dqpKnownFixControls)+1 || ids[len(ids)-1] != "99999" {
+		t.Fatalf("unexpected fix control ids: %v", ids)
+	}
+	if got := dqpFixControlHint("44830", true); got != "SET_VAR(tidb_opt_fix_control='44830:ON')" {
+		t.Fatalf("unexpected on hint: %s", got)
+	}
+	if got := dqpFixControlHint("44830", false); got != "SET_VAR(tidb_opt_fix_control='44830:OFF')" {
+		t.Fatalf("unexpected off hint: %s", got)
+	}
+	catalog := hintSupportCatalog(config.Config{Oracles: config.OracleConfig{DQPFixControlProb: 10, DQPFixControls: []string{"99999"}}})
+	if !slices.Contains(catalog, dqpFixControlHint("99999", true)) {
+		t.Fatalf("catalog misses the configured fix control: %v", catalog)
+	}
+}
+
+func TestDQPPickFixControlsRotates(t *testing.T) {
+	o := NewDQP()
+	cfg := config.OracleConfig{DQPFixControlPerQuery: 4}
+	seen := make(map[string]int)
+	for i := 0; i < len(dqpKnownFixControls); i++ {
+		picked := o.pickFixControls(cfg)
+		if len(picked) != 4 {
+			t.Fatalf("expected 4 ids, got %v", picked)
+		}
+		for _, id := range picked {
+			seen[id]++
+		}
+	}
+	for _, id := range dqpKnownFixControls {
+		if seen[id] != 4 {
+			t.Fatalf("expected every id picked 4 times, got %v", seen)
+		}
+	}
+
+	o.hints.markUnsupported(dqpFixControlHint("44830", true), "Warning:1231:Variable 'tidb_opt_fix_control' can't be set to the value of '44830:ON'")
+	cfg.DQPFixControlPerQuery = 100
+	picked := o.pickFixControls(cfg)
+	if len(picked) != len(dqpKnownFixControls)-1 || slices.Contains(picked, "44830") {
+		t.Fatalf("expected the unsupported id to be skipped, got %v", picked)
+	}
+}
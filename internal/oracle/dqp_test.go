@@ -111,7 +111,7 @@ func TestDQPSetVarHintsCount(t *testing.T) {
 	state := schema.State{}
 	gen := generator.New(cfg, &state, 2)
 	for i := 0; i < 20; i++ {
-		hints := dqpSetVarHints(gen, 3, true, true, true, true, true, true, nil)
+		hints := dqpSetVarHints(nil, gen, 3, true, true, true, true, true, true, nil)
 		if len(hints) > 2 {
 			t.Fatalf("expected <=2 set_var hints, got %d", len(hints))
 		}
@@ -136,7 +136,7 @@ func TestDQPHintsForQueryCount(t *testing.T) {
 		HintStreamAgg:       {},
 		HintAggToCop:        {},
 	}
-	hints := dqpHintsForQuery(gen, []string{"t1", "t2"}, true, true, true, true, noArgHints, nil)
+	hints := dqpHintsForQuery(nil, gen, []string{"t1", "t2"}, true, true, true, true, noArgHints, nil)
 	if len(hints) > 4 {
 		t.Fatalf("expected <=4 hints, got %d", len(hints))
 	}
@@ -166,11 +166,11 @@ func TestDQPHintPickLimitsFromConfig(t *testing.T) {
 		HintStreamAgg:       {},
 		HintAggToCop:        {},
 	}
-	baseHints := dqpHintsForQuery(gen, []string{"t1", "t2", "t3"}, true, true, true, true, noArgHints, nil)
+	baseHints := dqpHintsForQuery(nil, gen, []string{"t1", "t2", "t3"}, true, true, true, true, noArgHints, nil)
 	if len(baseHints) > 6 {
 		t.Fatalf("expected <=6 base hints, got %d", len(baseHints))
 	}
-	setVarHints := dqpSetVarHints(gen, 3, true, true, true, true, true, true, nil)
+	setVarHints := dqpSetVarHints(nil, gen, 3, true, true, true, true, true, true, nil)
 	if len(setVarHints) > 2 {
 		t.Fatalf("expected <=2 set_var hints, got %d", len(setVarHints))
 	}
@@ -297,7 +297,7 @@ func TestDQPSetVarHintsRequireMPPWhenTiFlashReplicaAvailable(t *testing.T) {
 	state := schema.State{}
 	gen := generator.New(cfg, &state, 41)
 	for i := 0; i < 20; i++ {
-		hints := dqpSetVarHints(gen, 3, true, true, true, true, true, true, nil)
+		hints := dqpSetVarHints(nil, gen, 3, true, true, true, true, true, true, nil)
 		if len(hints) == 0 {
 			t.Fatalf("expected non-empty set-var hints")
 		}
@@ -319,7 +319,7 @@ func TestDQPSetVarHintsKeepMPPAndNonMPPWhenLimitAllows(t *testing.T) {
 	state := schema.State{}
 	gen := generator.New(cfg, &state, 42)
 	for i := 0; i < 20; i++ {
-		hints := dqpSetVarHints(gen, 3, true, true, true, true, true, true, nil)
+		hints := dqpSetVarHints(nil, gen, 3, true, true, true, true, true, true, nil)
 		if !dqpHasSetVarCategory(hints, true) {
 			t.Fatalf("expected MPP set-var hint in %v", hints)
 		}
@@ -719,6 +719,9 @@ func TestDQPIndexHintCandidatesUseIndexMergeByQueryShape(t *testing.T) {
 	if containsHint(andHints, "USE_INDEX_MERGE(t0)") {
 		t.Fatalf("did not expect USE_INDEX_MERGE(t0) without OR predicate, got %v", andHints)
 	}
+	if !containsHint(andHints, "USE_INDEX(t0, PRIMARY)") {
+		t.Fatalf("expected USE_INDEX(t0, PRIMARY) for a table with a primary key, got %v", andHints)
+	}
 }
 
 func TestBuildCombinedHints(t *testing.T) {
@@ -788,7 +791,7 @@ func TestBuildDQPVariantsIncludesMultipleHintGroups(t *testing.T) {
 		},
 	}
 
-	variants, _ := buildDQPVariants(query, nil, true, false, false, false, false, false, nil)
+	variants, _ := buildDQPVariants(nil, query, nil, true, false, false, false, false, false, nil)
 	if len(variants) == 0 {
 		t.Fatalf("expected non-empty variants")
 	}
@@ -843,7 +846,7 @@ func TestBuildDQPVariantsMPPOverlapsCombinedHints(t *testing.T) {
 		},
 	}
 
-	variants, _ := buildDQPVariants(query, nil, false, false, false, false, false, false, gen)
+	variants, _ := buildDQPVariants(nil, query, nil, false, false, false, false, false, false, gen)
 	hasMPPGroup := false
 	hasCombinedMPP := false
 	for _, variant := range variants {
@@ -1206,6 +1209,51 @@ func TestDQPVariantMetricsResultMetrics(t *testing.T) {
 	}
 }
 
+func TestDQPHintKey(t *testing.T) {
+	cases := []struct {
+		hint string
+		want string
+	}{
+		{hint: "", want: ""},
+		{hint: "HASH_JOIN(t1, t2)", want: "HASH_JOIN"},
+		{hint: "straight_join()", want: "STRAIGHT_JOIN"},
+		{hint: "SET_VAR(TiDB_Opt_X=ON), hash_join(t1), HASH_JOIN(t2)", want: "HASH_JOIN+SET_VAR(tidb_opt_x)"},
+	}
+	for _, tc := range cases {
+		if got := dqpHintKey(tc.hint); got != tc.want {
+			t.Fatalf("dqpHintKey(%q)=%q want=%q", tc.hint, got, tc.want)
+		}
+	}
+}
+
+func TestDQPPlanChanged(t *testing.T) {
+	base := "HashJoin_8\troot\n  TableReader_10\troot\n"
+	if dqpPlanChanged(base, "  HashJoin_8\troot\n\nTableReader_10\troot") {
+		t.Fatalf("expected whitespace-only explain difference to be treated as same plan")
+	}
+	if !dqpPlanChanged(base, "MergeJoin_8\troot\nTableReader_10\troot") {
+		t.Fatalf("expected operator difference to be treated as plan change")
+	}
+}
+
+func TestDQPVariantMetricsObservePlan(t *testing.T) {
+	metrics := dqpVariantMetrics{}
+	metrics.observePlan("HASH_JOIN(t1, t2)", true)
+	metrics.observePlan("HASH_JOIN(t2, t3)", false)
+	metrics.observePlan("SET_VAR(tidb_opt_x=ON)", false)
+	metrics.planCheckErrTotal++
+	got := metrics.resultMetrics()
+	if got["dqp_plan_changed_total"] != 1 || got["dqp_plan_same_skip_total"] != 2 || got["dqp_plan_check_err_total"] != 1 {
+		t.Fatalf("unexpected plan totals: %v", got)
+	}
+	if got[DQPPlanChangedHintMetricPrefix+"HASH_JOIN"] != 1 {
+		t.Fatalf("unexpected changed hint counters: %v", got)
+	}
+	if got[DQPPlanSameHintMetricPrefix+"HASH_JOIN"] != 1 || got[DQPPlanSameHintMetricPrefix+"SET_VAR(tidb_opt_x)"] != 1 {
+		t.Fatalf("unexpected same hint counters: %v", got)
+	}
+}
+
 func TestDQPWarningSample(t *testing.T) {
 	warnings := []string{"w1", "w2", "w3", "w4"}
 	sample, omitted := dqpWarningSample(warnings, 2)
@@ -16,6 +16,7 @@ import (
 	"github.com/pingcap/tidb/pkg/parser/opcode"
 	_ "github.com/pingcap/tidb/pkg/types/parser_driver"
 
+	"shiro/internal/sqlstep"
 	"shiro/internal/util"
 )
 
@@ -54,8 +55,6 @@ func (o EET) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, sta
 		PredicateGuard:  true,
 		MaxTries:        eetBuildMaxTries,
 		Constraints: generator.SelectQueryConstraints{
-			RequireDeterministic: true,
-			DisallowSetOps:       true,
 			QueryGuardReason: func(query *generator.SelectQuery) (bool, string) {
 				reason := eetQueryGuardReason(query, policy, complexityThreshold)
 				return reason == "", reason
@@ -64,7 +63,6 @@ func (o EET) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, sta
 		SkipReasonOverrides: map[string]string{
 			"constraint:nondeterministic":     "eet:nondeterministic",
 			"constraint:predicate_guard":      "eet:predicate_guard",
-			"constraint:set_ops":              "eet:set_ops",
 			eetComplexityConstraintJoinTables: "eet:complexity_guard",
 		},
 	}
@@ -129,7 +127,7 @@ func (o EET) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, sta
 		transformedSQL, details, err = applyEETTransform(baseSQL, gen)
 		if err != nil {
 			details["error_reason"] = "eet:parse_error"
-			return Result{OK: true, Oracle: o.Name(), SQL: []string{baseSQL}, Err: err, Details: details}
+			return Result{OK: true, Oracle: o.Name(), Steps: []sqlstep.Step{queryStep(sqlstep.RoleFailing, baseSQL)}, Err: err, Details: details}
 		}
 		if strings.TrimSpace(transformedSQL) == "" || transformedSQL == baseSQL {
 			if _, ok := details["skip_reason"]; !ok {
@@ -138,7 +136,7 @@ func (o EET) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, sta
 			if attempt+1 < eetTransformRetryMax && eetShouldRetryNoTransform(details) {
 				continue
 			}
-			return Result{OK: true, Oracle: o.Name(), SQL: []string{baseSQL}, Details: details}
+			return Result{OK: true, Oracle: o.Name(), Steps: []sqlstep.Step{queryStep("", baseSQL)}, Details: details}
 		}
 		break
 	}
@@ -156,27 +154,27 @@ func (o EET) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, sta
 	if err != nil {
 		if eetIsDistinctOrderByErr(err) {
 			details["skip_reason"] = "eet:distinct_order_by_runtime"
-			return Result{OK: true, Oracle: o.Name(), SQL: []string{baseSQL}, SQLFeatures: observed, Details: details}
+			return Result{OK: true, Oracle: o.Name(), Steps: []sqlstep.Step{queryStep("", baseSQL)}, SQLFeatures: observed, Details: details}
 		}
 		reason, bugHint := eetSignatureErrorDetails(err, "base")
 		details["error_reason"] = reason
 		if bugHint != "" {
 			details["bug_hint"] = bugHint
 		}
-		return Result{OK: true, Oracle: o.Name(), SQL: []string{baseSQL}, SQLFeatures: observed, Err: err, Details: details}
+		return Result{OK: true, Oracle: o.Name(), Steps: []sqlstep.Step{queryStep(sqlstep.RoleFailing, baseSQL)}, SQLFeatures: observed, Err: err, Details: details}
 	}
 	transformedSig, err := exec.QuerySignature(ctx, transformedSigSQL)
 	if err != nil {
 		if eetIsDistinctOrderByErr(err) {
 			details["skip_reason"] = "eet:distinct_order_by_runtime"
-			return Result{OK: true, Oracle: o.Name(), SQL: []string{baseSQL, transformedSQL}, SQLFeatures: observed, Details: details}
+			return Result{OK: true, Oracle: o.Name(), Steps: comparisonSteps(baseSQL, transformedSQL), SQLFeatures: observed, Details: details}
 		}
 		reason, bugHint := eetSignatureErrorDetails(err, "transform")
 		details["error_reason"] = reason
 		if bugHint != "" {
 			details["bug_hint"] = bugHint
 		}
-		return Result{OK: true, Oracle: o.Name(), SQL: []string{transformedSQL}, SQLFeatures: observed, Err: err, Details: details}
+		return Result{OK: true, Oracle: o.Name(), Steps: []sqlstep.Step{queryStep(sqlstep.RoleFailing, transformedSQL)}, SQLFeatures: observed, Err: err, Details: details}
 	}
 
 	if origSig != transformedSig {
@@ -185,7 +183,7 @@ func (o EET) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, sta
 		return Result{
 			OK:          false,
 			Oracle:      o.Name(),
-			SQL:         []string{baseSQL, transformedSQL},
+			Steps:       comparisonSteps(baseSQL, transformedSQL),
 			SQLFeatures: observed,
 			Expected:    fmt.Sprintf("cnt=%d checksum=%d", origSig.Count, origSig.Checksum),
 			Actual:      fmt.Sprintf("cnt=%d checksum=%d", transformedSig.Count, transformedSig.Checksum),
@@ -201,7 +199,7 @@ func (o EET) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, sta
 			},
 		}
 	}
-	return Result{OK: true, Oracle: o.Name(), SQL: []string{baseSQL, transformedSQL}, SQLFeatures: observed, Details: details}
+	return Result{OK: true, Oracle: o.Name(), Steps: comparisonSteps(baseSQL, transformedSQL), SQLFeatures: observed, Details: details}
 }
 
 func eetComplexityJoinTableThreshold(gen *generator.Generator) int {
@@ -285,12 +283,11 @@ func applyEETTransform(sqlText string, gen *generator.Generator) (string, map[st
 	if err != nil {
 		return "", details, err
 	}
+	if setOpr, isSetOpr := stmt.(*ast.SetOprStmt); isSetOpr {
+		return applyEETSetOprTransform(setOpr, gen, details)
+	}
 	sel, ok := stmt.(*ast.SelectStmt)
 	if !ok {
-		if _, isSetOpr := stmt.(*ast.SetOprStmt); isSetOpr {
-			details["skip_reason"] = "eet:set_ops"
-			return "", details, nil
-		}
 		details["skip_reason"] = "eet:non_select"
 		return "", details, nil
 	}
@@ -320,6 +317,90 @@ func applyEETTransform(sqlText string, gen *generator.Generator) (string, map[st
 	return restored, details, nil
 }
 
+// applyEETSetOprTransform rewrites the predicates of every SELECT arm of a
+// set operation. Each arm is rewritten on its own, so a UNION keeps the same
+// rows as long as every arm does. The signature wraps the set operation with
+// the first arm's aliases, so every first-arm field must be aliased.
+func applyEETSetOprTransform(stmt *ast.SetOprStmt, gen *generator.Generator, details map[string]any) (string, map[string]any, error) {
+	arms := collectSetOprSelects(stmt)
+	if len(arms) == 0 {
+		details["skip_reason"] = "eet:non_select"
+		return "", details, nil
+	}
+	if !selectFieldsAliased(arms[0]) {
+		details["skip_reason"] = "eet:set_ops_unaliased"
+		return "", details, nil
+	}
+	var (
+		kinds      []string
+		lastReason string
+	)
+	for _, sel := range arms {
+		if !selectHasPredicate(sel) {
+			continue
+		}
+		resolver := buildColumnTypeResolver(sel, gen)
+		kind, changed, reason := rewriteSelectPredicates(sel, gen, resolver)
+		if !changed {
+			lastReason = reason
+			continue
+		}
+		kinds = append(kinds, string(kind))
+	}
+	details["set_op_arms"] = len(arms)
+	if len(kinds) == 0 {
+		if lastReason == "" {
+			lastReason = "eet:no_predicate"
+		}
+		details["skip_reason"] = lastReason
+		return "", details, nil
+	}
+	details["rewrite"] = kinds[0]
+	details["set_op_arm_rewrites"] = kinds
+	restored, err := restoreEETSQL(stmt)
+	if err != nil {
+		details["error_reason"] = "eet:restore_error"
+		return "", details, err
+	}
+	return restored, details, nil
+}
+
+// collectSetOprSelects returns the SELECT arms of a set operation in order,
+// flattening nested set operations.
+func collectSetOprSelects(stmt *ast.SetOprStmt) []*ast.SelectStmt {
+	if stmt == nil || stmt.SelectList == nil {
+		return nil
+	}
+	return collectSetOprListSelects(stmt.SelectList.Selects)
+}
+
+func collectSetOprListSelects(nodes []ast.Node) []*ast.SelectStmt {
+	var arms []*ast.SelectStmt
+	for _, node := range nodes {
+		switch v := node.(type) {
+		case *ast.SelectStmt:
+			arms = append(arms, v)
+		case *ast.SetOprSelectList:
+			arms = append(arms, collectSetOprListSelects(v.Selects)...)
+		case *ast.SetOprStmt:
+			arms = append(arms, collectSetOprSelects(v)...)
+		}
+	}
+	return arms
+}
+
+func selectFieldsAliased(sel *ast.SelectStmt) bool {
+	if sel == nil || sel.Fields == nil || len(sel.Fields.Fields) == 0 {
+		return false
+	}
+	for _, field := range sel.Fields.Fields {
+		if field.WildCard != nil || field.AsName.L == "" {
+			return false
+		}
+	}
+	return true
+}
+
 func queryHasUsingQualifiedRefs(query *generator.SelectQuery) bool {
 	if query == nil {
 		return false
@@ -1449,7 +1530,7 @@ func literalKindForColumn(col *ast.ColumnNameExpr, resolver *columnTypeResolver)
 		return 0
 	}
 	switch typ {
-	case schema.TypeInt, schema.TypeBigInt, schema.TypeFloat, schema.TypeDouble, schema.TypeDecimal, schema.TypeBool:
+	case schema.TypeInt, schema.TypeBigInt, schema.TypeFloat, schema.TypeDouble, schema.TypeDecimal, schema.TypeBool, schema.TypeBit, schema.TypeYear:
 		return literalNumeric
 	case schema.TypeDate, schema.TypeDatetime, schema.TypeTimestamp:
 		return literalDate
@@ -1475,15 +1556,7 @@ func (r *columnTypeResolver) columnType(col *ast.ColumnNameExpr) (schema.ColumnT
 	if table == "" {
 		return 0, false
 	}
-	tbl, ok := r.state.TableByName(table)
-	if !ok {
-		return 0, false
-	}
-	colDef, ok := tbl.ColumnByName(name)
-	if !ok {
-		return 0, false
-	}
-	return colDef.Type, true
+	return lookupColumnType(r.state, table, name)
 }
 
 func isDateLiteralString(value string) bool {
@@ -105,17 +105,44 @@ func TestApplyEETTransformJoinOn(t *testing.T) {
 	}
 }
 
-func TestApplyEETTransformSetOpsSkip(t *testing.T) {
-	sql := "SELECT a AS a FROM t WHERE a > 1 UNION ALL SELECT a AS a FROM t WHERE a > 2"
+func TestApplyEETTransformSetOps(t *testing.T) {
+	sql := "SELECT a AS a FROM t WHERE a > 1 UNION ALL SELECT b AS a FROM t UNION SELECT a AS a FROM t WHERE a > 2"
+	out, details, err := applyEETTransform(sql, nil)
+	if err != nil {
+		t.Fatalf("transform err: %v", err)
+	}
+	if out == "" || out == sql {
+		t.Fatalf("expected transformed sql, got: %s", out)
+	}
+	if details["skip_reason"] != nil {
+		t.Fatalf("unexpected skip reason: %v", details["skip_reason"])
+	}
+	if details["set_op_arms"] != 3 {
+		t.Fatalf("expected 3 set-op arms, got: %v", details["set_op_arms"])
+	}
+	rewrites, _ := details["set_op_arm_rewrites"].([]string)
+	if len(rewrites) != 2 {
+		t.Fatalf("expected 2 rewritten arms, got: %v", details["set_op_arm_rewrites"])
+	}
+	if _, err := parser.New().ParseOneStmt(out, "", ""); err != nil {
+		t.Fatalf("transformed sql does not parse: %v\n%s", err, out)
+	}
+	if got := strings.Count(strings.ToUpper(out), "UNION"); got != 2 {
+		t.Fatalf("expected set operations to be kept, got: %s", out)
+	}
+}
+
+func TestApplyEETTransformSetOpsUnaliased(t *testing.T) {
+	sql := "SELECT a FROM t WHERE a > 1 UNION ALL SELECT a AS a FROM t WHERE a > 2"
 	out, details, err := applyEETTransform(sql, nil)
 	if err != nil {
 		t.Fatalf("transform err: %v", err)
 	}
 	if out != "" {
-		t.Fatalf("expected empty transformed sql for set-ops, got: %s", out)
+		t.Fatalf("expected empty transformed sql, got: %s", out)
 	}
-	if details["skip_reason"] != "eet:set_ops" {
-		t.Fatalf("expected eet:set_ops skip reason, got: %v", details["skip_reason"])
+	if details["skip_reason"] != "eet:set_ops_unaliased" {
+		t.Fatalf("expected eet:set_ops_unaliased skip reason, got: %v", details["skip_reason"])
 	}
 }
 
@@ -45,3 +45,15 @@ func sqlErrorReason(prefix string, err error) (string, uint16) {
 	}
 	return prefix + ":sql_error", 0
 }
+
+// isUnknownSystemVariable reports whether a SET failed because the server
+// does not know the variable (error 1193), as on older TiDB versions.
+func isUnknownSystemVariable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if code, ok := mysqlErrCode(err); ok && code == 1193 {
+		return true
+	}
+	return strings.Contains(strings.ToLower(err.Error()), "unknown system variable")
+}
@@ -0,0 +1,326 @@
+package oracle
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"shiro/internal/db"
+	"shiro/internal/generator"
+	"shiro/internal/schema"
+	"shiro/internal/sqlstep"
+	"shiro/internal/util"
+)
+
+const (
+	fkCascadeMaxRows = 5000
+	fkCascadeSamples = 5
+
+	fkCascadeMutationDelete = "delete"
+	fkCascadeMutationUpdate = "update"
+)
+
+// FKCascade implements the foreign key referential action oracle.
+//
+// It picks a generated foreign key with ON DELETE/ON UPDATE CASCADE or SET
+// NULL, mutates matching parent rows inside a transaction, and checks the
+// child table against a client-side model of the referential action. The
+// transaction is rolled back, so the run state is unchanged.
+//
+// Example:
+//
+//	BEGIN
+//	SELECT t0.id FROM t0 WHERE t0.c1 > 5           -- parent keys K
+//	SELECT t1.id, t1.c0, ... FROM t1                -- child rows before
+//	DELETE FROM t0 WHERE t0.c1 > 5                  -- ON DELETE CASCADE
+//	SELECT t1.id, t1.c0, ... FROM t1                -- must equal before minus rows with t1.id IN K
+//	ROLLBACK
+type FKCascade struct{}
+
+// Name returns the oracle identifier.
+func (o FKCascade) Name() string { return "FKCascade" }
+
+// fkCascadeTarget is a foreign key with a referential action and its tables.
+type fkCascadeTarget struct {
+	fk       schema.ForeignKey
+	parent   schema.Table
+	child    schema.Table
+	colIndex int
+}
+
+// Run executes the parent mutation on a dedicated connection so BEGIN, the
+// mutation, and all reads share one session.
+func (o FKCascade) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, state *schema.State) Result {
+	if !gen.Config.Features.ForeignKeys {
+		return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "fk_cascade:fk_disabled"}}
+	}
+	targets := fkCascadeTargets(state)
+	if len(targets) == 0 {
+		return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "fk_cascade:no_action_fk"}}
+	}
+	target := targets[gen.Rand.Intn(len(targets))]
+	mutation, action := pickFKCascadeMutation(gen, target.fk)
+	predicate := gen.GenerateSimplePredicate([]schema.Table{target.parent}, 2)
+	if predicate == nil || !predicate.Deterministic() {
+		return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "fk_cascade:predicate_guard"}}
+	}
+	predSQL := buildExpr(predicate)
+	parent := target.parent.Name
+	keySQL := fmt.Sprintf("SELECT %s.%s FROM %s WHERE %s", parent, target.fk.RefColumn, parent, predSQL)
+	childSQL := fkCascadeChildSQL(target.child)
+	metrics := map[string]int64{
+		"fk_cascade_" + mutation + "_" + fkCascadeActionName(action) + "_total": 1,
+	}
+	details := map[string]any{
+		"fk_cascade_fk":       target.fk.Name,
+		"fk_cascade_mutation": mutation,
+		"fk_cascade_action":   action,
+	}
+
+	conn, err := exec.Conn(ctx)
+	if err != nil {
+		return Result{OK: true, Oracle: o.Name(), Err: err}
+	}
+	defer util.CloseWithErr(conn, "fk_cascade conn")
+
+	var steps []sqlstep.Step
+
+	if _, err := conn.ExecContext(ctx, "BEGIN"); err != nil {
+		return sqlErrorResult(o.Name(), "fk_cascade", failedSteps(steps, sqlstep.KindSetup, "BEGIN"), metrics, nil, err, "BEGIN")
+	}
+	steps = append(steps, sqlstep.New(sqlstep.KindSetup, "", "BEGIN"))
+	defer func() {
+		_, _ = conn.ExecContext(context.Background(), "ROLLBACK")
+	}()
+
+	keyRows, err := queryRowStrings(ctx, conn, exec.Guard, keySQL)
+	if err != nil {
+		return sqlErrorResult(o.Name(), "fk_cascade", failedSteps(steps, sqlstep.KindQuery, keySQL), metrics, nil, err, keySQL)
+	}
+	steps = append(steps, sqlstep.New(sqlstep.KindQuery, "", keySQL))
+	keys := make(map[string]struct{}, len(keyRows))
+	for _, row := range keyRows {
+		keys[row[0]] = struct{}{}
+	}
+
+	mutationSQL := fmt.Sprintf("DELETE FROM %s WHERE %s", parent, predSQL)
+	var offset int64
+	if mutation == fkCascadeMutationUpdate {
+		offsetSQL := fmt.Sprintf(
+			"SELECT GREATEST((SELECT IFNULL(MAX(%[1]s.%[2]s),0) FROM %[1]s), (SELECT IFNULL(MAX(%[3]s.%[4]s),0) FROM %[3]s)) + 1",
+			parent, target.fk.RefColumn, target.child.Name, target.fk.Column,
+		)
+		if err := conn.QueryRowContext(ctx, offsetSQL).Scan(&offset); err != nil {
+			return sqlErrorResult(o.Name(), "fk_cascade", failedSteps(steps, sqlstep.KindQuery, offsetSQL), metrics, nil, err, offsetSQL)
+		}
+		steps = append(steps, sqlstep.New(sqlstep.KindQuery, "", offsetSQL))
+		mutationSQL = fmt.Sprintf("UPDATE %[1]s SET %[2]s = %[1]s.%[2]s + %[3]d WHERE %[4]s", parent, target.fk.RefColumn, offset, predSQL)
+	}
+
+	before, err := queryRowStrings(ctx, conn, exec.Guard, childSQL)
+	if err != nil {
+		return sqlErrorResult(o.Name(), "fk_cascade", failedSteps(steps, sqlstep.KindQuery, childSQL), metrics, nil, err, childSQL)
+	}
+	if len(before) > fkCascadeMaxRows {
+		return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "fk_cascade:rows_exceeded"}, Metrics: metrics}
+	}
+	steps = append(steps, sqlstep.New(sqlstep.KindQuery, sqlstep.RoleExpected, childSQL))
+
+	if _, err := conn.ExecContext(ctx, mutationSQL); err != nil {
+		skip := sqlErrorDetails(map[string]any{"skip_reason": "fk_cascade:mutation_failed"}, "fk_cascade", err)
+		return Result{OK: true, Oracle: o.Name(), Details: skip, Metrics: metrics}
+	}
+	steps = append(steps, sqlstep.New(sqlstep.KindSetup, "", mutationSQL))
+
+	after, err := queryRowStrings(ctx, conn, exec.Guard, childSQL)
+	if err != nil {
+		return sqlErrorResult(o.Name(), "fk_cascade", failedSteps(steps, sqlstep.KindQuery, childSQL), metrics, nil, err, childSQL)
+	}
+	steps = append(steps, sqlstep.New(sqlstep.KindQuery, sqlstep.RoleActual, childSQL))
+	steps = append(steps, sqlstep.New(sqlstep.KindSetup, "", "ROLLBACK"))
+
+	expected, changed, err := fkCascadeExpectedRows(before, target.colIndex, keys, mutation, action, offset)
+	if err != nil {
+		details["skip_reason"] = "fk_cascade:model_error"
+		return Result{OK: true, Oracle: o.Name(), Details: details, Metrics: metrics}
+	}
+	metrics["fk_cascade_child_rows_changed_total"] = int64(changed)
+	missing, unexpected := fkCascadeDiffRows(expected, after)
+	if len(missing) == 0 && len(unexpected) == 0 {
+		return Result{OK: true, Oracle: o.Name(), Steps: steps, Metrics: metrics}
+	}
+	details["fk_cascade_parent_keys"] = len(keys)
+	details["fk_cascade_missing_rows"] = fkCascadeSampleRows(missing)
+	details["fk_cascade_unexpected_rows"] = fkCascadeSampleRows(unexpected)
+	return Result{
+		OK:       false,
+		Oracle:   o.Name(),
+		Steps:    steps,
+		Expected: fmt.Sprintf("rows=%d changed=%d", len(expected), changed),
+		Actual:   fmt.Sprintf("rows=%d missing=%d unexpected=%d", len(after), len(missing), len(unexpected)),
+		Details:  details,
+		Metrics:  metrics,
+	}
+}
+
+// fkCascadeTargets returns the foreign keys the oracle can model exactly: the
+// parent key is the BIGINT primary key, and no other referential path links
+// the parent and the child, so the child only changes through this key.
+func fkCascadeTargets(state *schema.State) []fkCascadeTarget {
+	if state == nil {
+		return nil
+	}
+	var targets []fkCascadeTarget
+	for _, child := range state.BaseTables() {
+		for _, fk := range child.ForeignKeys {
+			if !fk.HasAction() || fk.RefColumn != "id" || fk.RefTable == child.Name {
+				continue
+			}
+			parent, ok := state.TableByName(fk.RefTable)
+			if !ok || parent.IsView || !parent.HasPK {
+				continue
+			}
+			colIndex := slices.IndexFunc(child.Columns, func(col schema.Column) bool { return col.Name == fk.Column })
+			if colIndex < 0 || !fkCascadeIntegerType(child.Columns[colIndex].Type) {
+				continue
+			}
+			if fkCascadeReachable(state, fk.RefTable, child.Name, fk) || fkCascadeReachable(state, child.Name, fk.RefTable, fk) {
+				continue
+			}
+			targets = append(targets, fkCascadeTarget{fk: fk, parent: parent, child: child, colIndex: colIndex})
+		}
+	}
+	return targets
+}
+
+// fkCascadeReachable reports whether a change to from can reach to through
+// foreign keys with referential actions, ignoring the skip key.
+func fkCascadeReachable(state *schema.State, from string, to string, skip schema.ForeignKey) bool {
+	seen := map[string]struct{}{from: {}}
+	queue := []string{from}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, tbl := range state.BaseTables() {
+			for _, fk := range tbl.ForeignKeys {
+				if fk.RefTable != cur || !fk.HasAction() || fk == skip {
+					continue
+				}
+				if fk.Table == to {
+					return true
+				}
+				if _, ok := seen[fk.Table]; ok {
+					continue
+				}
+				seen[fk.Table] = struct{}{}
+				queue = append(queue, fk.Table)
+			}
+		}
+	}
+	return false
+}
+
+func fkCascadeIntegerType(t schema.ColumnType) bool {
+	return t == schema.TypeInt || t == schema.TypeBigInt
+}
+
+// pickFKCascadeMutation picks a DELETE or an UPDATE of the parent key among the
+// actions the foreign key declares.
+func pickFKCascadeMutation(gen *generator.Generator, fk schema.ForeignKey) (mutation string, action string) {
+	switch {
+	case fk.OnDelete != "" && fk.OnUpdate != "":
+		if gen.Rand.Intn(2) == 0 {
+			return fkCascadeMutationDelete, fk.OnDelete
+		}
+		return fkCascadeMutationUpdate, fk.OnUpdate
+	case fk.OnDelete != "":
+		return fkCascadeMutationDelete, fk.OnDelete
+	default:
+		return fkCascadeMutationUpdate, fk.OnUpdate
+	}
+}
+
+func fkCascadeActionName(action string) string {
+	return strings.ToLower(strings.ReplaceAll(action, " ", "_"))
+}
+
+func fkCascadeChildSQL(child schema.Table) string {
+	cols := make([]string, 0, len(child.Columns))
+	for _, col := range child.Columns {
+		cols = append(cols, fmt.Sprintf("%s.%s", child.Name, col.Name))
+	}
+	return fmt.Sprintf("SELECT %s FROM %s LIMIT %d", strings.Join(cols, ", "), child.Name, fkCascadeMaxRows+1)
+}
+
+// fkCascadeExpectedRows applies the referential action to the child rows whose
+// key column matches a mutated parent key, and returns the changed-row count.
+func fkCascadeExpectedRows(before [][]string, colIndex int, keys map[string]struct{}, mutation string, action string, offset int64) ([][]string, int, error) {
+	expected := make([][]string, 0, len(before))
+	changed := 0
+	for _, row := range before {
+		if _, ok := keys[row[colIndex]]; !ok {
+			expected = append(expected, row)
+			continue
+		}
+		changed++
+		if mutation == fkCascadeMutationDelete && action == schema.FKActionCascade {
+			continue
+		}
+		next := slices.Clone(row)
+		switch {
+		case action == schema.FKActionSetNull:
+			next[colIndex] = sqlNullValue
+		default:
+			value, err := strconv.ParseInt(row[colIndex], 10, 64)
+			if err != nil {
+				return nil, 0, err
+			}
+			next[colIndex] = strconv.FormatInt(value+offset, 10)
+		}
+		expected = append(expected, next)
+	}
+	return expected, changed, nil
+}
+
+// fkCascadeDiffRows compares two row multisets and returns the rows only in
+// expected (missing) and only in actual (unexpected).
+func fkCascadeDiffRows(expected [][]string, actual [][]string) (missing []string, unexpected []string) {
+	counts := make(map[string]int, len(expected))
+	for _, row := range expected {
+		counts[fkCascadeRowKey(row)]++
+	}
+	for _, row := range actual {
+		key := fkCascadeRowKey(row)
+		if counts[key] > 0 {
+			counts[key]--
+			continue
+		}
+		unexpected = append(unexpected, key)
+	}
+	for key, n := range counts {
+		for i := 0; i < n; i++ {
+			missing = append(missing, key)
+		}
+	}
+	slices.Sort(missing)
+	slices.Sort(unexpected)
+	return missing, unexpected
+}
+
+func fkCascadeRowKey(row []string) string {
+	return strings.Join(row, "#")
+}
+
+// fkCascadeSampleRows returns a few rows for details, rendering NULL markers.
+func fkCascadeSampleRows(rows []string) []string {
+	if len(rows) > fkCascadeSamples {
+		rows = rows[:fkCascadeSamples]
+	}
+	out := make([]string, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, strings.ReplaceAll(row, sqlNullValue, "NULL"))
+	}
+	return out
+}
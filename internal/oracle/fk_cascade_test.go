@@ -0,0 +1,72 @@
+package oracle
+
+import (
+	"slices"
+	"testing"
+
+	"shiro/internal/schema"
+)
+
+func TestFKCascadeExpectedRows(t *testing.T) {
+	before := [][]string{{"1", "a"}, {"2", "b"}, {"3", sqlNullValue}}
+	keys := map[string]struct{}{"2": {}, "3": {}}
+	tests := []struct {
+		mutation string
+		action   string
+		want     [][]string
+	}{
+		{mutation: fkCascadeMutationDelete, action: schema.FKActionCascade, want: [][]string{{"1", "a"}}},
+		{mutation: fkCascadeMutationDelete, action: schema.FKActionSetNull, want: [][]string{{"1", "a"}, {sqlNullValue, "b"}, {sqlNullValue, sqlNullValue}}},
+		{mutation: fkCascadeMutationUpdate, action: schema.FKActionCascade, want: [][]string{{"1", "a"}, {"12", "b"}, {"13", sqlNullValue}}},
+	}
+	for _, tt := range tests {
+		got, changed, err := fkCascadeExpectedRows(before, 0, keys, tt.mutation, tt.action, 10)
+		if err != nil {
+			t.Fatalf("fkCascadeExpectedRows(%s, %s) err=%v", tt.mutation, tt.action, err)
+		}
+		if changed != 2 {
+			t.Fatalf("fkCascadeExpectedRows(%s, %s) changed=%d want=2", tt.mutation, tt.action, changed)
+		}
+		if !slices.EqualFunc(got, tt.want, slices.Equal[[]string]) {
+			t.Fatalf("fkCascadeExpectedRows(%s, %s)=%v want=%v", tt.mutation, tt.action, got, tt.want)
+		}
+	}
+}
+
+func TestFKCascadeDiffRows(t *testing.T) {
+	expected := [][]string{{"1", "a"}, {"1", "a"}, {"2", sqlNullValue}}
+	actual := [][]string{{"1", "a"}, {"2", "NULL"}}
+	missing, unexpected := fkCascadeDiffRows(expected, actual)
+	if len(missing) != 2 || len(unexpected) != 1 {
+		t.Fatalf("unexpected diff missing=%q unexpected=%q", missing, unexpected)
+	}
+	if got := fkCascadeSampleRows(unexpected); got[0] != "2#NULL" {
+		t.Fatalf("fkCascadeSampleRows=%q", got)
+	}
+	if missing, unexpected := fkCascadeDiffRows(expected, expected); len(missing) != 0 || len(unexpected) != 0 {
+		t.Fatalf("expected equal multisets, missing=%q unexpected=%q", missing, unexpected)
+	}
+}
+
+func TestFKCascadeTargetsSkipCycles(t *testing.T) {
+	idCol := []schema.Column{{Name: "id", Type: schema.TypeBigInt}}
+	state := &schema.State{Tables: []schema.Table{
+		{Name: "t0", HasPK: true, Columns: idCol},
+		{Name: "t1", HasPK: true, Columns: idCol, ForeignKeys: []schema.ForeignKey{
+			{Name: "fk_1", Table: "t1", Column: "id", RefTable: "t0", RefColumn: "id", OnDelete: schema.FKActionCascade},
+		}},
+		{Name: "t2", HasPK: true, Columns: idCol, ForeignKeys: []schema.ForeignKey{
+			{Name: "fk_2", Table: "t2", Column: "id", RefTable: "t0", RefColumn: "id"},
+		}},
+	}}
+	targets := fkCascadeTargets(state)
+	if len(targets) != 1 || targets[0].fk.Name != "fk_1" {
+		t.Fatalf("unexpected targets: %+v", targets)
+	}
+	state.Tables[0].ForeignKeys = []schema.ForeignKey{
+		{Name: "fk_3", Table: "t0", Column: "id", RefTable: "t1", RefColumn: "id", OnUpdate: schema.FKActionCascade},
+	}
+	if targets := fkCascadeTargets(state); len(targets) != 0 {
+		t.Fatalf("expected cyclic foreign keys to be skipped, got %+v", targets)
+	}
+}
@@ -0,0 +1,431 @@
+package oracle
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+
+	"shiro/internal/db"
+	"shiro/internal/generator"
+	"shiro/internal/schema"
+	"shiro/internal/sqlstep"
+	"shiro/internal/util"
+)
+
+const (
+	fullGroupByMode = "ONLY_FULL_GROUP_BY"
+	// fullGroupByErrCode is ER_WRONG_FIELD_WITH_GROUP, raised when a selected
+	// column is neither grouped nor functionally dependent on the groups.
+	fullGroupByErrCode = 1055
+	fullGroupByMaxDeps = 2
+
+	fullGroupByShapePK      = "pk"
+	fullGroupByShapeConst   = "const"
+	fullGroupByShapeJoinPK  = "join_pk"
+	fullGroupByShapeInvalid = "invalid"
+
+	fullGroupByExpectAccept = "accept"
+	fullGroupByExpectReject = "reject"
+)
+
+var fullGroupByShapes = []string{
+	fullGroupByShapePK,
+	fullGroupByShapeConst,
+	fullGroupByShapeJoinPK,
+	fullGroupByShapeInvalid,
+}
+
+// FullGroupBy implements the ONLY_FULL_GROUP_BY functional-dependency oracle.
+//
+// It builds GROUP BY queries whose validity depends on functional
+// dependencies: columns determined by a grouped primary key, columns pinned by
+// an equal-to-constant predicate, columns reached through an inner join on
+// another table's primary key, and, as a negative shape, a column that
+// depends on nothing grouped. Each query runs with ONLY_FULL_GROUP_BY on and
+// off on one connection. The valid shapes must be accepted in both modes and
+// match the same query with every selected column grouped; the invalid shape
+// must be rejected with error 1055 when the mode is on, and return one row
+// per group when it is off.
+//
+// Example:
+//
+//	SET SESSION sql_mode = 'ONLY_FULL_GROUP_BY,STRICT_TRANS_TABLES'
+//	SELECT t0.id AS g0, t0.c1 AS g1, COUNT(*) AS cnt FROM t0 GROUP BY t0.id
+//	SELECT t0.id AS g0, t0.c1 AS g1, COUNT(*) AS cnt FROM t0 GROUP BY t0.id, t0.c1
+type FullGroupBy struct{}
+
+// Name returns the oracle identifier.
+func (o FullGroupBy) Name() string { return "FullGroupBy" }
+
+// fullGroupByPlan is one query shape: grouped columns, selected columns that
+// are not grouped, and the join and predicate that make them dependent.
+type fullGroupByPlan struct {
+	shape   string
+	groupBy []string
+	extra   []string
+	from    string
+	where   string
+	// constTable and constColumn name the column a const shape pins; the
+	// value is read from the table before rendering.
+	constTable  string
+	constColumn schema.Column
+}
+
+func (p fullGroupByPlan) expect() string {
+	if p.shape == fullGroupByShapeInvalid {
+		return fullGroupByExpectReject
+	}
+	return fullGroupByExpectAccept
+}
+
+// querySQL renders the query under test.
+func (p fullGroupByPlan) querySQL() string {
+	return p.render(append(append([]string{}, p.groupBy...), p.extra...), p.groupBy)
+}
+
+// referenceSQL renders the query every mode must agree with: the valid shapes
+// group by every selected column, and the invalid shape drops the column that
+// is not grouped.
+func (p fullGroupByPlan) referenceSQL() string {
+	if p.expect() == fullGroupByExpectReject {
+		return p.render(p.groupBy, p.groupBy)
+	}
+	all := append(append([]string{}, p.groupBy...), p.extra...)
+	return p.render(all, all)
+}
+
+func (p fullGroupByPlan) render(selected []string, groupBy []string) string {
+	items := make([]string, 0, len(selected)+1)
+	for i, col := range selected {
+		items = append(items, fmt.Sprintf("%s AS g%d", col, i))
+	}
+	items = append(items, "COUNT(*) AS cnt")
+	var b strings.Builder
+	b.WriteString("SELECT ")
+	b.WriteString(strings.Join(items, ", "))
+	b.WriteString(" FROM ")
+	b.WriteString(p.from)
+	if p.where != "" {
+		b.WriteString(" WHERE ")
+		b.WriteString(p.where)
+	}
+	b.WriteString(" GROUP BY ")
+	b.WriteString(strings.Join(groupBy, ", "))
+	return b.String()
+}
+
+// Run builds one shape and checks acceptance and results with the mode on and
+// off. The session sql_mode is restored afterwards.
+func (o FullGroupBy) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, state *schema.State) Result {
+	shape := fullGroupByShapes[gen.Rand.Intn(len(fullGroupByShapes))]
+	plan, ok := pickFullGroupByPlan(gen, state.BaseTables(), shape)
+	if !ok {
+		return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "full_group_by:no_" + shape + "_candidate"}}
+	}
+	metrics := map[string]int64{"full_group_by_" + shape + "_total": 1}
+	details := map[string]any{
+		"full_group_by_shape":  shape,
+		"full_group_by_expect": plan.expect(),
+	}
+
+	conn, err := exec.Conn(ctx)
+	if err != nil {
+		return Result{OK: true, Oracle: o.Name(), Err: err}
+	}
+	defer util.CloseWithErr(conn, "full_group_by conn")
+
+	var steps []sqlstep.Step
+	if plan.shape == fullGroupByShapeConst {
+		valueSQL := fmt.Sprintf("SELECT %s FROM %s WHERE %s IS NOT NULL LIMIT 1", plan.constColumn.Name, plan.constTable, plan.constColumn.Name)
+		rows, err := queryRowStrings(ctx, conn, exec.Guard, valueSQL)
+		if err != nil {
+			return sqlErrorResult(o.Name(), "full_group_by", failedSteps(steps, sqlstep.KindQuery, valueSQL), metrics, details, err, valueSQL)
+		}
+		if len(rows) == 0 {
+			details["skip_reason"] = "full_group_by:no_const_value"
+			return Result{OK: true, Oracle: o.Name(), Details: details, Metrics: metrics}
+		}
+		plan.where = fmt.Sprintf("%s.%s = %s", plan.constTable, plan.constColumn.Name, fullGroupByLiteral(plan.constColumn.Type, rows[0][0]))
+	}
+
+	var original string
+	if err := conn.QueryRowContext(ctx, "SELECT @@SESSION.sql_mode").Scan(&original); err != nil {
+		return sqlErrorResult(o.Name(), "full_group_by", failedSteps(steps, sqlstep.KindVerify, "SELECT @@SESSION.sql_mode"), metrics, details, err, "SELECT @@SESSION.sql_mode")
+	}
+	defer func() {
+		_, _ = conn.ExecContext(context.Background(), fullGroupBySetModeSQL(original))
+	}()
+	modeOn, modeOff := fullGroupBySQLModes(original)
+	querySQL := plan.querySQL()
+	refSQL := plan.referenceSQL()
+
+	for _, mode := range []struct {
+		name    string
+		sqlMode string
+	}{{"on", modeOn}, {"off", modeOff}} {
+		details["full_group_by_mode"] = mode.name
+		setSQL := fullGroupBySetModeSQL(mode.sqlMode)
+		if _, err := conn.ExecContext(ctx, setSQL); err != nil {
+			return sqlErrorResult(o.Name(), "full_group_by", failedSteps(steps, sqlstep.KindSetVar, setSQL), metrics, details, err, setSQL)
+		}
+		steps = append(steps, sqlstep.New(sqlstep.KindSetVar, "", setSQL))
+		finding := func(expected string, actual string) Result {
+			steps = append(steps,
+				sqlstep.New(sqlstep.KindQuery, sqlstep.RoleExpected, refSQL),
+				sqlstep.New(sqlstep.KindQuery, sqlstep.RoleActual, querySQL),
+			)
+			return Result{
+				OK:       false,
+				Oracle:   o.Name(),
+				Steps:    steps,
+				Expected: expected,
+				Actual:   actual,
+				Details:  details,
+				Metrics:  metrics,
+			}
+		}
+
+		refSig, err := fullGroupBySignature(ctx, conn, refSQL, plan.referenceColumns())
+		if err != nil {
+			return sqlErrorResult(o.Name(), "full_group_by", failedSteps(steps, sqlstep.KindQuery, refSQL), metrics, details, err, refSQL)
+		}
+		sig, err := fullGroupBySignature(ctx, conn, querySQL, len(plan.groupBy)+len(plan.extra))
+		rejected := false
+		if err != nil {
+			code, ok := mysqlErrCode(err)
+			if !ok || code != fullGroupByErrCode {
+				return sqlErrorResult(o.Name(), "full_group_by", failedSteps(steps, sqlstep.KindQuery, querySQL), metrics, details, err, querySQL)
+			}
+			rejected = true
+		}
+		wantReject := mode.name == "on" && plan.expect() == fullGroupByExpectReject
+		switch {
+		case rejected && !wantReject:
+			details["error_code"] = fullGroupByErrCode
+			return finding("accepted", "rejected: "+err.Error())
+		case !rejected && wantReject:
+			return finding("rejected with error 1055", "accepted")
+		case rejected:
+			metrics["full_group_by_rejected_total"]++
+			continue
+		}
+		if plan.expect() == fullGroupByExpectReject {
+			if sig.Count != refSig.Count {
+				return finding(fmt.Sprintf("groups=%d", refSig.Count), fmt.Sprintf("groups=%d", sig.Count))
+			}
+			continue
+		}
+		if sig != refSig {
+			return finding(
+				fmt.Sprintf("cnt=%d checksum=%d", refSig.Count, refSig.Checksum),
+				fmt.Sprintf("cnt=%d checksum=%d", sig.Count, sig.Checksum),
+			)
+		}
+	}
+	delete(details, "full_group_by_mode")
+	steps = append(steps, sqlstep.New(sqlstep.KindQuery, "", querySQL))
+	return Result{OK: true, Oracle: o.Name(), Steps: steps, Details: details, Metrics: metrics}
+}
+
+// referenceColumns is the number of g<i> columns the reference query selects.
+func (p fullGroupByPlan) referenceColumns() int {
+	if p.expect() == fullGroupByExpectReject {
+		return len(p.groupBy)
+	}
+	return len(p.groupBy) + len(p.extra)
+}
+
+// pickFullGroupByPlan picks tables and columns for shape. It reports false
+// when the schema has no candidate.
+func pickFullGroupByPlan(gen *generator.Generator, tables []schema.Table, shape string) (fullGroupByPlan, bool) {
+	var candidates []schema.Table
+	for _, tbl := range tables {
+		if len(fullGroupByNonKeyColumns(tbl)) > 0 {
+			candidates = append(candidates, tbl)
+		}
+	}
+	if len(candidates) == 0 {
+		return fullGroupByPlan{}, false
+	}
+	r := gen.Rand
+	qualify := func(tbl schema.Table, cols []schema.Column) []string {
+		out := make([]string, 0, len(cols))
+		for _, col := range cols {
+			out = append(out, tbl.Name+"."+col.Name)
+		}
+		return out
+	}
+	pickNonKey := func(tbl schema.Table, n int) []schema.Column {
+		cols := fullGroupByNonKeyColumns(tbl)
+		r.Shuffle(len(cols), func(i, j int) { cols[i], cols[j] = cols[j], cols[i] })
+		return cols[:min(n, len(cols))]
+	}
+	pkColumns := func(tbl schema.Table) []string {
+		keys := tbl.PrimaryKeyColumns()
+		out := make([]string, 0, len(keys))
+		for _, key := range keys {
+			out = append(out, tbl.Name+"."+key)
+		}
+		return out
+	}
+
+	switch shape {
+	case fullGroupByShapePK:
+		var keyed []schema.Table
+		for _, tbl := range candidates {
+			if tbl.HasPK {
+				keyed = append(keyed, tbl)
+			}
+		}
+		if len(keyed) == 0 {
+			return fullGroupByPlan{}, false
+		}
+		tbl := keyed[r.Intn(len(keyed))]
+		return fullGroupByPlan{
+			shape:   shape,
+			groupBy: pkColumns(tbl),
+			extra:   qualify(tbl, pickNonKey(tbl, 1+r.Intn(fullGroupByMaxDeps))),
+			from:    tbl.Name,
+		}, true
+	case fullGroupByShapeConst:
+		for _, i := range r.Perm(len(candidates)) {
+			tbl := candidates[i]
+			cols := pickNonKey(tbl, len(tbl.Columns))
+			if len(cols) < 2 {
+				continue
+			}
+			for j, col := range cols {
+				if !fullGroupByConstType(col.Type) {
+					continue
+				}
+				group := cols[(j+1)%len(cols)]
+				return fullGroupByPlan{
+					shape:       shape,
+					groupBy:     []string{tbl.Name + "." + group.Name},
+					extra:       []string{tbl.Name + "." + col.Name},
+					from:        tbl.Name,
+					constTable:  tbl.Name,
+					constColumn: col,
+				}, true
+			}
+		}
+		return fullGroupByPlan{}, false
+	case fullGroupByShapeJoinPK:
+		for _, i := range r.Perm(len(candidates)) {
+			parent := candidates[i]
+			keys := parent.PrimaryKeyColumns()
+			if len(keys) != 1 {
+				continue
+			}
+			key, ok := parent.ColumnByName(keys[0])
+			if !ok {
+				continue
+			}
+			for _, j := range r.Perm(len(tables)) {
+				child := tables[j]
+				if child.Name == parent.Name || !child.HasPK {
+					continue
+				}
+				for _, col := range child.Columns {
+					if col.Type != key.Type {
+						continue
+					}
+					return fullGroupByPlan{
+						shape:   shape,
+						groupBy: pkColumns(child),
+						extra:   qualify(parent, pickNonKey(parent, 1+r.Intn(fullGroupByMaxDeps))),
+						from:    fmt.Sprintf("%s JOIN %s ON %s.%s = %s.%s", child.Name, parent.Name, child.Name, col.Name, parent.Name, key.Name),
+					}, true
+				}
+			}
+		}
+		return fullGroupByPlan{}, false
+	default:
+		for _, i := range r.Perm(len(candidates)) {
+			tbl := candidates[i]
+			cols := pickNonKey(tbl, 2)
+			if len(cols) < 2 {
+				continue
+			}
+			return fullGroupByPlan{
+				shape:   shape,
+				groupBy: []string{tbl.Name + "." + cols[0].Name},
+				extra:   []string{tbl.Name + "." + cols[1].Name},
+				from:    tbl.Name,
+			}, true
+		}
+		return fullGroupByPlan{}, false
+	}
+}
+
+// fullGroupByNonKeyColumns returns the columns outside the primary key. The
+// schema has no unique secondary keys, so none of them determines another.
+func fullGroupByNonKeyColumns(tbl schema.Table) []schema.Column {
+	keys := tbl.PrimaryKeyColumns()
+	out := make([]schema.Column, 0, len(tbl.Columns))
+	for _, col := range tbl.Columns {
+		if !slices.Contains(keys, col.Name) {
+			out = append(out, col)
+		}
+	}
+	return out
+}
+
+// fullGroupByConstType reports whether an equality with a literal pins a
+// column of this type to one value. Strings are left out because a
+// case-insensitive collation lets several values compare equal, and floats
+// because equality on them is unreliable.
+func fullGroupByConstType(typ schema.ColumnType) bool {
+	switch typ {
+	case schema.TypeInt, schema.TypeBigInt, schema.TypeDecimal, schema.TypeBool, schema.TypeYear,
+		schema.TypeDate, schema.TypeDatetime, schema.TypeTimestamp:
+		return true
+	default:
+		return false
+	}
+}
+
+// fullGroupByLiteral renders a value read from the server as a literal of the
+// column type, so the predicate compares without a cast on the column.
+func fullGroupByLiteral(typ schema.ColumnType, value string) string {
+	switch typ {
+	case schema.TypeDate, schema.TypeDatetime, schema.TypeTimestamp:
+		return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+	default:
+		return value
+	}
+}
+
+// fullGroupBySQLModes returns original with ONLY_FULL_GROUP_BY added and with
+// it removed.
+func fullGroupBySQLModes(original string) (on string, off string) {
+	var rest []string
+	for _, mode := range strings.Split(original, ",") {
+		mode = strings.TrimSpace(mode)
+		if mode == "" || strings.EqualFold(mode, fullGroupByMode) {
+			continue
+		}
+		rest = append(rest, mode)
+	}
+	return strings.Join(append([]string{fullGroupByMode}, rest...), ","), strings.Join(rest, ",")
+}
+
+func fullGroupBySetModeSQL(mode string) string {
+	return fmt.Sprintf("SET SESSION sql_mode = '%s'", mode)
+}
+
+func fullGroupBySignature(ctx context.Context, conn *sql.Conn, query string, columns int) (db.Signature, error) {
+	cols := make([]string, 0, columns+1)
+	for i := 0; i < columns; i++ {
+		cols = append(cols, fmt.Sprintf("q.g%d", i))
+	}
+	cols = append(cols, "q.cnt")
+	sigSQL := fmt.Sprintf("SELECT COUNT(*) AS cnt, IFNULL(BIT_XOR(CRC32(CONCAT_WS('#', %s))),0) AS checksum FROM (%s) q", strings.Join(cols, ", "), query)
+	var sig db.Signature
+	if err := conn.QueryRowContext(ctx, sigSQL).Scan(&sig.Count, &sig.Checksum); err != nil {
+		return db.Signature{}, err
+	}
+	return sig, nil
+}
@@ -0,0 +1,98 @@
+package oracle
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"shiro/internal/generator"
+	"shiro/internal/schema"
+)
+
+func TestFullGroupBySQLModes(t *testing.T) {
+	on, off := fullGroupBySQLModes("ONLY_FULL_GROUP_BY,STRICT_TRANS_TABLES,NO_ENGINE_SUBSTITUTION")
+	if on != "ONLY_FULL_GROUP_BY,STRICT_TRANS_TABLES,NO_ENGINE_SUBSTITUTION" {
+		t.Fatalf("on=%q", on)
+	}
+	if off != "STRICT_TRANS_TABLES,NO_ENGINE_SUBSTITUTION" {
+		t.Fatalf("off=%q", off)
+	}
+	on, off = fullGroupBySQLModes("")
+	if on != "ONLY_FULL_GROUP_BY" || off != "" {
+		t.Fatalf("empty mode: on=%q off=%q", on, off)
+	}
+}
+
+func TestFullGroupByPlanSQL(t *testing.T) {
+	plan := fullGroupByPlan{
+		shape:   fullGroupByShapePK,
+		groupBy: []string{"t0.id"},
+		extra:   []string{"t0.c1"},
+		from:    "t0",
+	}
+	if got, want := plan.querySQL(), "SELECT t0.id AS g0, t0.c1 AS g1, COUNT(*) AS cnt FROM t0 GROUP BY t0.id"; got != want {
+		t.Fatalf("query=%q want %q", got, want)
+	}
+	if got, want := plan.referenceSQL(), "SELECT t0.id AS g0, t0.c1 AS g1, COUNT(*) AS cnt FROM t0 GROUP BY t0.id, t0.c1"; got != want {
+		t.Fatalf("reference=%q want %q", got, want)
+	}
+	plan.shape = fullGroupByShapeInvalid
+	plan.where = "t0.c2 = 3"
+	if got, want := plan.referenceSQL(), "SELECT t0.id AS g0, COUNT(*) AS cnt FROM t0 WHERE t0.c2 = 3 GROUP BY t0.id"; got != want {
+		t.Fatalf("invalid reference=%q want %q", got, want)
+	}
+	if plan.expect() != fullGroupByExpectReject || plan.referenceColumns() != 1 {
+		t.Fatalf("unexpected invalid plan: expect=%s columns=%d", plan.expect(), plan.referenceColumns())
+	}
+}
+
+func TestPickFullGroupByPlan(t *testing.T) {
+	tables := []schema.Table{
+		{
+			Name:    "t0",
+			HasPK:   true,
+			Columns: []schema.Column{{Name: "id", Type: schema.TypeInt}, {Name: "c0", Type: schema.TypeVarchar}, {Name: "c1", Type: schema.TypeDate}},
+		},
+		{
+			Name:    "t1",
+			HasPK:   true,
+			Columns: []schema.Column{{Name: "id", Type: schema.TypeInt}, {Name: "c0", Type: schema.TypeInt}},
+		},
+	}
+	gen := &generator.Generator{Rand: rand.New(rand.NewSource(3))}
+	for _, shape := range fullGroupByShapes {
+		for i := 0; i < 20; i++ {
+			plan, ok := pickFullGroupByPlan(gen, tables, shape)
+			if !ok {
+				t.Fatalf("%s: no plan", shape)
+			}
+			for _, col := range plan.extra {
+				if strings.HasSuffix(col, ".id") {
+					t.Fatalf("%s: key column %s selected as dependent", shape, col)
+				}
+			}
+			switch shape {
+			case fullGroupByShapeConst:
+				if plan.constColumn.Name != "c1" && plan.constColumn.Name != "c0" || !fullGroupByConstType(plan.constColumn.Type) {
+					t.Fatalf("const shape pinned %+v", plan.constColumn)
+				}
+			case fullGroupByShapeJoinPK:
+				if !strings.Contains(plan.from, " JOIN ") || !strings.HasSuffix(plan.from, ".id") {
+					t.Fatalf("join shape from=%q", plan.from)
+				}
+			}
+		}
+	}
+	if _, ok := pickFullGroupByPlan(gen, tables[:1], fullGroupByShapeJoinPK); ok {
+		t.Fatalf("join shape needs two tables")
+	}
+}
+
+func TestFullGroupByLiteral(t *testing.T) {
+	if got := fullGroupByLiteral(schema.TypeDecimal, "1.50"); got != "1.50" {
+		t.Fatalf("decimal literal=%q", got)
+	}
+	if got := fullGroupByLiteral(schema.TypeDate, "2024-01-02"); got != "'2024-01-02'" {
+		t.Fatalf("date literal=%q", got)
+	}
+}
@@ -0,0 +1,167 @@
+package oracle
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"shiro/internal/db"
+	"shiro/internal/generator"
+	"shiro/internal/schema"
+	"shiro/internal/sqlstep"
+)
+
+const fullJoinBuildMaxTries = 10
+
+// FullJoin implements the FULL OUTER JOIN emulation oracle.
+//
+// TiDB has no FULL OUTER JOIN, so the generator emulates one over a single
+// join as the LEFT JOIN UNION ALL the RIGHT JOIN filtered to rows without a
+// left match. The oracle builds a one-join query, rewrites it the same way,
+// and runs the LEFT, RIGHT, and INNER forms of the join separately. A full
+// join is the inner rows plus the unmatched rows of each side, so its multiset
+// must equal LEFT + RIGHT - INNER. Rows are compared by count and by an
+// additive checksum that keeps NULL positions.
+//
+// Example:
+//
+//	SELECT COUNT(*), SUM(CRC32(...)) FROM (SELECT ... FROM t0 LEFT JOIN t1 ON ... UNION ALL SELECT ... FROM t0 RIGHT JOIN t1 ON ... WHERE t0.k IS NULL) q
+//	SELECT COUNT(*), SUM(CRC32(...)) FROM (SELECT ... FROM t0 LEFT JOIN t1 ON ...) q
+//	SELECT COUNT(*), SUM(CRC32(...)) FROM (SELECT ... FROM t0 RIGHT JOIN t1 ON ...) q
+//	SELECT COUNT(*), SUM(CRC32(...)) FROM (SELECT ... FROM t0 JOIN t1 ON ...) q
+type FullJoin struct{}
+
+// Name returns the oracle identifier.
+func (o FullJoin) Name() string { return "FullJoin" }
+
+// fullJoinComponents are the join forms the emulation is checked against, in
+// execution order.
+var fullJoinComponents = []generator.JoinType{generator.JoinLeft, generator.JoinRight, generator.JoinInner}
+
+// Run checks one emulated full join against its component joins.
+func (o FullJoin) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, _ *schema.State) Result {
+	if !gen.Config.Features.Joins {
+		return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "full_join:joins_disabled"}}
+	}
+	spec := QuerySpec{
+		Oracle:   "full_join",
+		Profile:  ProfileByName("FullJoin"),
+		MaxTries: fullJoinBuildMaxTries,
+		Constraints: generator.SelectQueryConstraints{
+			QueryGuardReason: fullJoinShapeReason,
+		},
+		SkipReasonOverrides: map[string]string{
+			"constraint:nondeterministic": "full_join:nondeterministic",
+		},
+	}
+	query, details := buildQueryWithSpec(gen, spec)
+	if query == nil {
+		return Result{OK: true, Oracle: o.Name(), Details: details}
+	}
+	if !gen.ValidateQueryScope(query) {
+		return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "full_join:scope_invalid"}}
+	}
+	emulated := query.Clone()
+	if ok, reason := gen.EmulateFullJoin(emulated); !ok {
+		return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "full_join:" + reason}}
+	}
+	metrics := map[string]int64{"full_join_total": 1}
+	if len(query.From.Joins[0].Using) > 0 {
+		metrics["full_join_using_total"] = 1
+	}
+
+	selectList := fullJoinSignatureList(query)
+	emulatedSQL := fullJoinSignatureSQL(selectList, emulated.SQLString())
+	emulatedSig, err := exec.QuerySignature(ctx, emulatedSQL)
+	if err != nil {
+		steps := []sqlstep.Step{sqlstep.New(sqlstep.KindQuery, sqlstep.RoleFailing, emulatedSQL)}
+		return sqlErrorResult(o.Name(), "full_join", steps, metrics, nil, err, emulatedSQL)
+	}
+	steps := []sqlstep.Step{sqlstep.New(sqlstep.KindQuery, sqlstep.RoleActual, emulatedSQL)}
+	sigs := make(map[generator.JoinType]db.Signature, len(fullJoinComponents))
+	for _, joinType := range fullJoinComponents {
+		componentSQL := fullJoinSignatureSQL(selectList, fullJoinComponent(query, joinType).SQLString())
+		sig, err := exec.QuerySignature(ctx, componentSQL)
+		if err != nil {
+			steps = append(steps, sqlstep.New(sqlstep.KindQuery, sqlstep.RoleFailing, componentSQL))
+			return sqlErrorResult(o.Name(), "full_join", steps, metrics, nil, err, componentSQL)
+		}
+		steps = append(steps, sqlstep.New(sqlstep.KindQuery, sqlstep.RoleExpected, componentSQL))
+		sigs[joinType] = sig
+	}
+	expected := fullJoinExpected(sigs[generator.JoinLeft], sigs[generator.JoinRight], sigs[generator.JoinInner])
+	if emulatedSig != expected {
+		return Result{
+			OK:       false,
+			Oracle:   o.Name(),
+			Steps:    steps,
+			Expected: fmt.Sprintf("cnt=%d checksum=%d", expected.Count, expected.Checksum),
+			Actual:   fmt.Sprintf("cnt=%d checksum=%d", emulatedSig.Count, emulatedSig.Checksum),
+			Details: map[string]any{
+				"full_join_left":  fullJoinSigString(sigs[generator.JoinLeft]),
+				"full_join_right": fullJoinSigString(sigs[generator.JoinRight]),
+				"full_join_inner": fullJoinSigString(sigs[generator.JoinInner]),
+				"full_join_using": len(query.From.Joins[0].Using) > 0,
+			},
+			Metrics: metrics,
+		}
+	}
+	return Result{OK: true, Oracle: o.Name(), Steps: steps, Metrics: metrics}
+}
+
+// fullJoinShapeReason accepts queries with exactly one join that the
+// emulation can turn into a RIGHT JOIN.
+func fullJoinShapeReason(query *generator.SelectQuery) (bool, string) {
+	if query == nil || len(query.SetOps) > 0 || len(query.From.Joins) != 1 {
+		return false, "full_join:shape"
+	}
+	join := query.From.Joins[0]
+	if join.Type == generator.JoinCross {
+		return false, "full_join:cross_join"
+	}
+	if join.Lateral {
+		return false, "full_join:lateral"
+	}
+	return true, ""
+}
+
+// fullJoinComponent returns a copy of query with its join replaced by
+// joinType.
+func fullJoinComponent(query *generator.SelectQuery, joinType generator.JoinType) *generator.SelectQuery {
+	component := query.Clone()
+	component.From.Joins[0].Type = joinType
+	return component
+}
+
+// fullJoinExpected combines the component signatures into the full join
+// signature: LEFT + RIGHT - INNER.
+func fullJoinExpected(left, right, inner db.Signature) db.Signature {
+	return db.Signature{
+		Count:    left.Count + right.Count - inner.Count,
+		Checksum: left.Checksum + right.Checksum - inner.Checksum,
+	}
+}
+
+func fullJoinSigString(sig db.Signature) string {
+	return fmt.Sprintf("cnt=%d checksum=%d", sig.Count, sig.Checksum)
+}
+
+// fullJoinSignatureList renders each output column with its NULL flag ahead
+// of it, so NULL-padded rows keep their column positions in CONCAT_WS.
+func fullJoinSignatureList(query *generator.SelectQuery) string {
+	aliases := query.ColumnAliases()
+	if len(aliases) == 0 {
+		return "0"
+	}
+	cols := make([]string, 0, len(aliases)*2)
+	for _, alias := range aliases {
+		cols = append(cols, fmt.Sprintf("ISNULL(q.%s)", alias), fmt.Sprintf("q.%s", alias))
+	}
+	return strings.Join(cols, ", ")
+}
+
+// fullJoinSignatureSQL sums row checksums instead of XORing them, so the
+// signatures of disjoint multisets add up.
+func fullJoinSignatureSQL(selectList string, query string) string {
+	return fmt.Sprintf("SELECT COUNT(*) AS cnt, IFNULL(SUM(CRC32(CONCAT_WS('#', %s))),0) AS checksum FROM (%s) q", selectList, query)
+}
@@ -0,0 +1,106 @@
+package oracle
+
+import (
+	"strings"
+	"testing"
+
+	"shiro/internal/db"
+	"shiro/internal/generator"
+)
+
+func fullJoinTestQuery() *generator.SelectQuery {
+	return &generator.SelectQuery{
+		Items: []generator.SelectItem{
+			{Expr: generator.ColumnExpr{Ref: generator.ColumnRef{Table: "t0", Name: "c0"}}, Alias: "c0"},
+			{Expr: generator.ColumnExpr{Ref: generator.ColumnRef{Table: "t1", Name: "c1"}}, Alias: "c1"},
+		},
+		From: generator.FromClause{
+			BaseTable: "t0",
+			Joins: []generator.Join{{
+				Type:  generator.JoinInner,
+				Table: "t1",
+				On: generator.BinaryExpr{
+					Left:  generator.ColumnExpr{Ref: generator.ColumnRef{Table: "t0", Name: "id"}},
+					Op:    "=",
+					Right: generator.ColumnExpr{Ref: generator.ColumnRef{Table: "t1", Name: "id"}},
+				},
+			}},
+		},
+	}
+}
+
+func TestFullJoinComponentsMatchEmulation(t *testing.T) {
+	query := fullJoinTestQuery()
+	emulated := query.Clone()
+	if ok, reason := (&generator.Generator{}).EmulateFullJoin(emulated); !ok {
+		t.Fatalf("expected emulation, got %s", reason)
+	}
+	left := fullJoinComponent(query, generator.JoinLeft).SQLString()
+	right := fullJoinComponent(query, generator.JoinRight).SQLString()
+	if !strings.HasPrefix(emulated.SQLString(), "("+left+") UNION ALL ") {
+		t.Fatalf("emulation must start with the LEFT JOIN component:\n%s\n%s", emulated.SQLString(), left)
+	}
+	if !strings.Contains(right, "RIGHT JOIN") || strings.Contains(right, "IS NULL") {
+		t.Fatalf("RIGHT JOIN component must not carry the anti filter: %s", right)
+	}
+	if query.From.Joins[0].Type != generator.JoinInner {
+		t.Fatalf("components must not modify the query")
+	}
+}
+
+func TestFullJoinExpected(t *testing.T) {
+	left := db.Signature{Count: 5, Checksum: 500}
+	right := db.Signature{Count: 4, Checksum: 420}
+	inner := db.Signature{Count: 3, Checksum: 300}
+	if got := fullJoinExpected(left, right, inner); got != (db.Signature{Count: 6, Checksum: 620}) {
+		t.Fatalf("unexpected full join signature: %+v", got)
+	}
+	sql := fullJoinSignatureSQL(fullJoinSignatureList(fullJoinTestQuery()), "SELECT 1")
+	want := "SELECT COUNT(*) AS cnt, IFNULL(SUM(CRC32(CONCAT_WS('#', ISNULL(q.c0), q.c0, ISNULL(q.c1), q.c1))),0) AS checksum FROM (SELECT 1) q"
+	if sql != want {
+		t.Fatalf("unexpected signature SQL:\n got %s\nwant %s", sql, want)
+	}
+}
+
+func TestFullJoinShapeReason(t *testing.T) {
+	query := fullJoinTestQuery()
+	if ok, reason := fullJoinShapeReason(query); !ok {
+		t.Fatalf("expected one-join query to pass: %s", reason)
+	}
+	query.From.Joins[0].Lateral = true
+	if _, reason := fullJoinShapeReason(query); reason != "full_join:lateral" {
+		t.Fatalf("unexpected lateral reason: %s", reason)
+	}
+	query.From.Joins[0].Lateral = false
+	query.From.Joins[0].Type = generator.JoinCross
+	if _, reason := fullJoinShapeReason(query); reason != "full_join:cross_join" {
+		t.Fatalf("unexpected cross join reason: %s", reason)
+	}
+	query.From.Joins = append(query.From.Joins, generator.Join{Type: generator.JoinInner, Table: "t2"})
+	if _, reason := fullJoinShapeReason(query); reason != "full_join:shape" {
+		t.Fatalf("unexpected shape reason: %s", reason)
+	}
+}
+
+func TestFullJoinBuildsOneJoinQueries(t *testing.T) {
+	gen := newProfileTestGenerator(t)
+	spec := QuerySpec{
+		Oracle:      "full_join",
+		Profile:     ProfileByName("FullJoin"),
+		MaxTries:    50,
+		Constraints: generator.SelectQueryConstraints{QueryGuardReason: fullJoinShapeReason},
+	}
+	for i := 0; i < 20; i++ {
+		query, _ := buildQueryWithSpec(gen, spec)
+		if query == nil {
+			continue
+		}
+		if ok, reason := fullJoinShapeReason(query); !ok {
+			t.Fatalf("guard not applied (%s): %s", reason, query.SQLString())
+		}
+		features := generator.AnalyzeQueryFeatures(query)
+		if features.HasAggregate || features.HasWindow || query.Limit != nil || query.Distinct {
+			t.Fatalf("compat row not applied: %s", query.SQLString())
+		}
+	}
+}
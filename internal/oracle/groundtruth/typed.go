@@ -10,9 +10,9 @@ import (
 // TypeFamily collapses column types into coarse categories for keying.
 func TypeFamily(t schema.ColumnType) string {
 	switch t {
-	case schema.TypeInt, schema.TypeBigInt, schema.TypeFloat, schema.TypeDouble, schema.TypeDecimal:
+	case schema.TypeInt, schema.TypeBigInt, schema.TypeFloat, schema.TypeDouble, schema.TypeDecimal, schema.TypeBit, schema.TypeYear:
 		return "number"
-	case schema.TypeVarchar:
+	case schema.TypeVarchar, schema.TypeEnum, schema.TypeSet:
 		return "string"
 	case schema.TypeDate, schema.TypeDatetime, schema.TypeTimestamp:
 		return "time"
@@ -29,7 +29,7 @@ func EncodeValue(t schema.ColumnType, v any) (TypedValue, bool) {
 		return TypedValue{Type: TypeFamily(t), Value: "NULL"}, true
 	}
 	switch t {
-	case schema.TypeInt, schema.TypeBigInt:
+	case schema.TypeInt, schema.TypeBigInt, schema.TypeBit, schema.TypeYear:
 		switch n := v.(type) {
 		case int:
 			return TypedValue{Type: "number", Value: strconv.FormatInt(int64(n), 10)}, true
@@ -45,7 +45,7 @@ func EncodeValue(t schema.ColumnType, v any) (TypedValue, bool) {
 		case float32:
 			return TypedValue{Type: "number", Value: strconv.FormatFloat(float64(n), 'g', -1, 64)}, true
 		}
-	case schema.TypeVarchar:
+	case schema.TypeVarchar, schema.TypeEnum, schema.TypeSet:
 		if s, ok := v.(string); ok {
 			return TypedValue{Type: "string", Value: s}, true
 		}
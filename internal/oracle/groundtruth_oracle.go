@@ -11,6 +11,7 @@ import (
 	"shiro/internal/generator"
 	"shiro/internal/oracle/groundtruth"
 	"shiro/internal/schema"
+	"shiro/internal/sqlstep"
 )
 
 // GroundTruth compares join counts against a Go-evaluated truth model.
@@ -101,7 +102,7 @@ func (o GroundTruth) Run(ctx context.Context, exec *db.DB, gen *generator.Genera
 	leftRows, leftSQL, err := fetchRows(ctx, exec, query.From.BaseTable, columnsByTable[query.From.BaseTable], maxRows)
 	if err != nil {
 		if IsSchemaColumnMissingErr(err) {
-			return Result{OK: false, Oracle: o.Name(), SQL: []string{leftSQL}, Err: err}
+			return Result{OK: false, Oracle: o.Name(), Steps: []sqlstep.Step{queryStep(sqlstep.RoleFailing, leftSQL)}, Err: err}
 		}
 		return Result{OK: true, Oracle: o.Name(), Err: err}
 	}
@@ -113,7 +114,7 @@ func (o GroundTruth) Run(ctx context.Context, exec *db.DB, gen *generator.Genera
 		rightRows, rightSQL, err := fetchRows(ctx, exec, join.Table, rightCols, maxRows)
 		if err != nil {
 			if IsSchemaColumnMissingErr(err) {
-				return Result{OK: false, Oracle: o.Name(), SQL: []string{rightSQL}, Err: err}
+				return Result{OK: false, Oracle: o.Name(), Steps: []sqlstep.Step{queryStep(sqlstep.RoleFailing, rightSQL)}, Err: err}
 			}
 			return Result{OK: true, Oracle: o.Name(), Err: err}
 		}
@@ -137,9 +138,9 @@ func (o GroundTruth) Run(ctx context.Context, exec *db.DB, gen *generator.Genera
 	actual, err := exec.QueryCount(ctx, countSQL)
 	if err != nil {
 		if IsSchemaColumnMissingErr(err) {
-			return Result{OK: false, Oracle: o.Name(), SQL: []string{countSQL}, SQLFeatures: observed, Err: err}
+			return Result{OK: false, Oracle: o.Name(), Steps: groundTruthFailedSteps(sqlText, countSQL), SQLFeatures: observed, Err: err}
 		}
-		return Result{OK: true, Oracle: o.Name(), SQL: []string{countSQL}, SQLFeatures: observed, Err: err}
+		return Result{OK: true, Oracle: o.Name(), Steps: groundTruthFailedSteps(sqlText, countSQL), SQLFeatures: observed, Err: err}
 	}
 	truth := &GroundTruthMetrics{
 		Enabled:  true,
@@ -151,7 +152,7 @@ func (o GroundTruth) Run(ctx context.Context, exec *db.DB, gen *generator.Genera
 		return Result{
 			OK:          false,
 			Oracle:      o.Name(),
-			SQL:         []string{sqlText, countSQL},
+			Steps:       groundTruthSteps(sqlText, countSQL),
 			SQLFeatures: observed,
 			Expected:    fmt.Sprintf("truth count=%d", truthCount),
 			Actual:      fmt.Sprintf("db count=%d", actual),
@@ -163,7 +164,7 @@ func (o GroundTruth) Run(ctx context.Context, exec *db.DB, gen *generator.Genera
 			}, confidence),
 		}
 	}
-	return Result{OK: true, Oracle: o.Name(), SQL: []string{sqlText, countSQL}, SQLFeatures: observed, Truth: truth}
+	return Result{OK: true, Oracle: o.Name(), Steps: groundTruthSteps(sqlText, countSQL), SQLFeatures: observed, Truth: truth}
 }
 
 func (o GroundTruth) runWithTruth(ctx context.Context, exec *db.DB, truth *groundtruth.SchemaTruth, query *generator.SelectQuery, state *schema.State, dsgEnabled bool, maxRows int, confidence string) Result {
@@ -548,9 +549,9 @@ func (o GroundTruth) compareTruthCount(ctx context.Context, exec *db.DB, query *
 	actual, err := exec.QueryCount(ctx, countSQL)
 	if err != nil {
 		if IsSchemaColumnMissingErr(err) {
-			return Result{OK: false, Oracle: o.Name(), SQL: []string{countSQL}, SQLFeatures: observed, Err: err}
+			return Result{OK: false, Oracle: o.Name(), Steps: groundTruthFailedSteps(sqlText, countSQL), SQLFeatures: observed, Err: err}
 		}
-		return Result{OK: true, Oracle: o.Name(), SQL: []string{countSQL}, SQLFeatures: observed, Err: err}
+		return Result{OK: true, Oracle: o.Name(), Steps: groundTruthFailedSteps(sqlText, countSQL), SQLFeatures: observed, Err: err}
 	}
 	truthMeta := &GroundTruthMetrics{
 		Enabled:  true,
@@ -562,7 +563,7 @@ func (o GroundTruth) compareTruthCount(ctx context.Context, exec *db.DB, query *
 		return Result{
 			OK:          false,
 			Oracle:      o.Name(),
-			SQL:         []string{sqlText, countSQL},
+			Steps:       groundTruthSteps(sqlText, countSQL),
 			SQLFeatures: observed,
 			Expected:    fmt.Sprintf("truth count=%d", truthCount),
 			Actual:      fmt.Sprintf("db count=%d", actual),
@@ -574,7 +575,23 @@ func (o GroundTruth) compareTruthCount(ctx context.Context, exec *db.DB, query *
 			}, confidence),
 		}
 	}
-	return Result{OK: true, Oracle: o.Name(), SQL: []string{sqlText, countSQL}, SQLFeatures: observed, Truth: truthMeta}
+	return Result{OK: true, Oracle: o.Name(), Steps: groundTruthSteps(sqlText, countSQL), SQLFeatures: observed, Truth: truthMeta}
+}
+
+// groundTruthSteps records the query under test and the count compared with
+// the client-side truth. The query itself is only the replay target.
+func groundTruthSteps(sqlText string, countSQL string) []sqlstep.Step {
+	return []sqlstep.Step{
+		queryStep(sqlstep.RoleReplay, sqlText),
+		queryStep(sqlstep.RoleActual, countSQL),
+	}
+}
+
+func groundTruthFailedSteps(sqlText string, countSQL string) []sqlstep.Step {
+	return []sqlstep.Step{
+		queryStep(sqlstep.RoleReplay, sqlText),
+		queryStep(sqlstep.RoleFailing, countSQL),
+	}
 }
 
 func groundTruthConfidence(dsgEnabled bool, enforceDSG bool, dsgReason string) string {
@@ -983,16 +1000,8 @@ func fetchRows(ctx context.Context, exec *db.DB, table string, cols []string, li
 	if err != nil {
 		return nil, query, err
 	}
-	values := make([]sql.RawBytes, len(colNames))
-	scanArgs := make([]any, len(values))
-	for i := range values {
-		scanArgs[i] = &values[i]
-	}
 	out := make([]rowData, 0, limit)
-	for rows.Next() {
-		if err := rows.Scan(scanArgs...); err != nil {
-			return nil, query, err
-		}
+	err = exec.Guard.ScanRows(rows, func(values []sql.RawBytes) error {
 		row := make(rowData, len(colNames))
 		for i, name := range colNames {
 			cell := cellValue{Val: string(values[i])}
@@ -1003,6 +1012,10 @@ func fetchRows(ctx context.Context, exec *db.DB, table string, cols []string, li
 			row[fmt.Sprintf("%s.%s", table, name)] = cell
 		}
 		out = append(out, row)
+		return nil
+	})
+	if err != nil {
+		return nil, query, err
 	}
 	return out, query, nil
 }
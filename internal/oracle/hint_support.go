@@ -0,0 +1,244 @@
+package oracle
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"shiro/internal/config"
+	"shiro/internal/db"
+	"shiro/internal/generator"
+	"shiro/internal/util"
+)
+
+// hintSupportProbeTable is the derived table the probe hints point at.
+const hintSupportProbeTable = "shiro_hint_probe"
+
+// hintSupportRetryPct is the percent chance a pruned candidate still runs.
+// Ignored hints are test signals too, and a hint the probe rejected can start
+// to apply after a rolling upgrade.
+const hintSupportRetryPct = 5
+
+// hintSupportWarnCodes are the warnings that mean the server did not accept
+// a hint: unsupported hint, hint syntax error, unknown system variable, a
+// variable SET_VAR cannot set, and an invalid value.
+var hintSupportWarnCodes = map[int]struct{}{
+	8061: {},
+	8064: {},
+	1193: {},
+	3637: {},
+	1231: {},
+	1232: {},
+}
+
+// hintSupport records the hints and SET_VAR assignments one target server
+// ignored during the startup probe. Each DQP built by NewDQP owns one, so a
+// multi-target run prunes every cluster by its own probe. A nil hintSupport
+// prunes nothing.
+type hintSupport struct {
+	mu          sync.RWMutex
+	hints       map[string]string
+	setVars     map[string]string
+	setVarNames map[string]string
+}
+
+func newHintSupport() *hintSupport {
+	return &hintSupport{
+		hints:       make(map[string]string),
+		setVars:     make(map[string]string),
+		setVarNames: make(map[string]string),
+	}
+}
+
+// HintSupportReport summarizes one startup probe.
+type HintSupportReport struct {
+	Probed int
+	// Unsupported maps each rejected hint to the warning that rejected it.
+	Unsupported map[string]string
+}
+
+// UnsupportedHints returns the rejected hints, sorted.
+func (r HintSupportReport) UnsupportedHints() []string {
+	out := make([]string, 0, len(r.Unsupported))
+	for hint := range r.Unsupported {
+		out = append(out, hint)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ProbeHintSupport runs each DQP hint and SET_VAR candidate once on a scratch
+// query and records the ones the server ignores with a warning. This DQP
+// prunes them from its candidate lists for the rest of the run.
+func (o DQP) ProbeHintSupport(ctx context.Context, exec *db.DB, cfg config.Config) (HintSupportReport, error) {
+	report := HintSupportReport{Unsupported: make(map[string]string)}
+	conn, err := exec.Conn(ctx)
+	if err != nil {
+		return report, err
+	}
+	defer util.CloseWithErr(conn, "hint support conn")
+	for _, hint := range hintSupportCatalog(cfg) {
+		query := hintSupportProbeSQL(hint)
+		if _, err := conn.ExecContext(ctx, query); err != nil {
+			return report, fmt.Errorf("probe %s: %w", hint, err)
+		}
+		warnings, err := db.WarningsOnConn(ctx, conn)
+		if err != nil {
+			return report, fmt.Errorf("probe %s warnings: %w", hint, err)
+		}
+		report.Probed++
+		if warning, ok := hintSupportRejection(warnings); ok {
+			o.hints.markUnsupported(hint, warning)
+			report.Unsupported[hint] = warning
+		}
+	}
+	return report, nil
+}
+
+// hintSupportCatalog lists one probe per base hint name and per SET_VAR
+// candidate, including the configured external hints.
+func hintSupportCatalog(cfg config.Config) []string {
+	catalog := []string{
+		HintHashJoin, HintNoHashJoin, HintMergeJoin, HintInlJoin, HintInlHashJoin,
+		HintHashJoinBuild, HintHashJoinProbe, HintLeading, HintStraightJoin,
+		HintSemiJoinRewrite, HintNoDecorrelate, HintHashAgg, HintStreamAgg, HintAggToCop,
+		"USE_INDEX", "USE_INDEX_MERGE",
+		SetVarEnableHashJoinOn, SetVarEnableNonEvalScalarSubqueryOn, SetVarEnableSemiJoinRewriteOn,
+		SetVarEnableNoDecorrelateOn, SetVarEnableOuterJoinReorderOn, SetVarEnableInlJoinInnerMultiOn,
+		SetVarAllowMPPOn, SetVarEnforceMPPOn, SetVarPartialOrderedTopNCost, SetVarPartialOrderedTopNDisable,
+		SetVarEnableTojaOn, SetVarForceInlineCTEOn, SetVarPartitionPruneDynamic, SetVarPartitionPruneStatic,
+		SetVarFixControl33031On, SetVarFixControl44830On, SetVarFixControl44855On, SetVarFixControl45132Zero,
+		fmt.Sprintf(SetVarJoinReorderThresholdFmt, 0),
+	}
+	if cfg.Oracles.DQPFixControlProb > 0 {
+		for _, id := range DQPFixControlIDs(cfg.Oracles) {
+			catalog = append(catalog, dqpFixControlHint(id, true))
+		}
+	}
+	for _, raw := range cfg.Oracles.DQPExternalHints {
+		trimmed := strings.TrimSpace(raw)
+		if trimmed == "" || strings.Contains(trimmed, "*/") {
+			continue
+		}
+		if hint, isSetVar, valid := normalizeSetVarHint(trimmed); isSetVar {
+			if valid {
+				catalog = append(catalog, hint)
+			}
+			continue
+		}
+		name, _, _ := strings.Cut(trimmed, "(")
+		catalog = append(catalog, strings.ToUpper(strings.TrimSpace(name)))
+	}
+	return dqpDedupHints(catalog)
+}
+
+// hintSupportProbeSQL wraps one hint around a query that reads no table. Base
+// hint names get the derived table as their argument, so a supported hint
+// only warns about the table, never about itself.
+func hintSupportProbeSQL(hint string) string {
+	if !strings.Contains(hint, "(") {
+		hint = fmt.Sprintf("%s(%s)", hint, hintSupportProbeTable)
+	}
+	return fmt.Sprintf("SELECT /*+ %s */ 1 FROM (SELECT 1 AS c) %s", hint, hintSupportProbeTable)
+}
+
+// hintSupportRejection returns the first warning that rejects the hint
+// itself. Warnings from WarningsOnConn read "level:code:message".
+func hintSupportRejection(warnings []string) (string, bool) {
+	for _, warning := range warnings {
+		parts := strings.SplitN(warning, ":", 3)
+		if len(parts) == 3 {
+			if code, err := strconv.Atoi(parts[1]); err == nil {
+				if _, ok := hintSupportWarnCodes[code]; ok {
+					return warning, true
+				}
+			}
+		}
+		lower := strings.ToLower(warning)
+		if strings.Contains(lower, "is not supported") || strings.Contains(lower, "unknown system variable") ||
+			strings.Contains(lower, "cannot be set using set_var") {
+			return warning, true
+		}
+	}
+	return "", false
+}
+
+func (s *hintSupport) markUnsupported(hint string, warning string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name, assignment, isSetVar := hintSupportKey(hint)
+	if !isSetVar {
+		s.hints[name] = warning
+		return
+	}
+	s.setVars[assignment] = warning
+	if strings.Contains(strings.ToLower(warning), "unknown system variable") {
+		s.setVarNames[name] = warning
+	}
+}
+
+// supported reports whether every hint in a comma-separated hint list passed
+// the probe. Hints that were never probed count as supported.
+func (s *hintSupport) supported(hintList string) bool {
+	if s == nil {
+		return true
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.hints) == 0 && len(s.setVars) == 0 {
+		return true
+	}
+	for _, token := range splitTopLevelHintList(hintList) {
+		name, assignment, isSetVar := hintSupportKey(token)
+		if !isSetVar {
+			if _, ok := s.hints[name]; ok {
+				return false
+			}
+			continue
+		}
+		if _, ok := s.setVars[assignment]; ok {
+			return false
+		}
+		if _, ok := s.setVarNames[name]; ok {
+			return false
+		}
+	}
+	return true
+}
+
+// hintSupportKey returns the upper-case hint name, or for SET_VAR the
+// lower-case variable name and normalized assignment.
+func hintSupportKey(hint string) (name string, assignment string, isSetVar bool) {
+	trimmed := strings.TrimSpace(hint)
+	head, body, _ := strings.Cut(trimmed, "(")
+	head = strings.ToUpper(strings.TrimSpace(head))
+	if head != HintSetVar {
+		return head, "", false
+	}
+	body = strings.TrimSuffix(strings.TrimSpace(body), ")")
+	variable, value, _ := strings.Cut(body, "=")
+	variable = strings.ToLower(strings.TrimSpace(variable))
+	return variable, variable + "=" + strings.ToLower(strings.TrimSpace(value)), true
+}
+
+// filter drops the candidates that use a hint the probe found unsupported,
+// except for the hintSupportRetryPct share that keeps observing them.
+func (s *hintSupport) filter(gen *generator.Generator, candidates []string) []string {
+	out := make([]string, 0, len(candidates))
+	for _, hint := range candidates {
+		if s.supported(hint) || hintSupportRetry(gen) {
+			out = append(out, hint)
+		}
+	}
+	return out
+}
+
+func hintSupportRetry(gen *generator.Generator) bool {
+	return gen != nil && gen.Rand != nil && gen.Rand.Intn(100) < hintSupportRetryPct
+}
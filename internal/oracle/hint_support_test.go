@@ -0,0 +1,99 @@
+package oracle
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"shiro/internal/config"
+	"shiro/internal/generator"
+)
+
+func TestHintSupportRejection(t *testing.T) {
+	cases := []struct {
+		warnings []string
+		rejected bool
+	}{
+		{[]string{"Warning:1815:There are no matching table names for (shiro_hint_probe) in optimizer hint /*+ HASH_JOIN(shiro_hint_probe) */"}, false},
+		{[]string{"Warning:8064:Optimizer hint syntax error at line 1 column 23 near \"NO_SUCH_HINT(shiro_hint_probe) */\""}, true},
+		{[]string{"Warning:1193:Unknown system variable 'tidb_opt_use_toja'"}, true},
+		{[]string{"Warning:3637:Variable 'sql_mode' cannot be set using SET_VAR hint."}, true},
+		{[]string{"Warning:9999:Optimizer hint AGG_TO_COP is not supported by TiDB and is ignored"}, true},
+		{nil, false},
+	}
+	for _, tc := range cases {
+		if _, got := hintSupportRejection(tc.warnings); got != tc.rejected {
+			t.Fatalf("warnings %v: rejected=%v, want %v", tc.warnings, got, tc.rejected)
+		}
+	}
+	if got := hintSupportProbeSQL(HintHashAgg); got != "SELECT /*+ HASH_AGG(shiro_hint_probe) */ 1 FROM (SELECT 1 AS c) shiro_hint_probe" {
+		t.Fatalf("unexpected probe SQL: %s", got)
+	}
+	if got := hintSupportProbeSQL(SetVarEnableTojaOn); got != "SELECT /*+ SET_VAR(tidb_opt_use_toja=ON) */ 1 FROM (SELECT 1 AS c) shiro_hint_probe" {
+		t.Fatalf("unexpected probe SQL: %s", got)
+	}
+}
+
+func TestHintSupportCatalogIncludesExternalHints(t *testing.T) {
+	cfg := config.Config{}
+	cfg.Oracles.DQPExternalHints = []string{"tidb_opt_x=ON", "no_index_join(t1)", "bad */"}
+	catalog := hintSupportCatalog(cfg)
+	for _, want := range []string{HintHashJoin, SetVarEnforceMPPOn, "SET_VAR(tidb_opt_x=ON)", "NO_INDEX_JOIN"} {
+		if !slices.Contains(catalog, want) {
+			t.Fatalf("catalog misses %s: %v", want, catalog)
+		}
+	}
+	for _, hint := range catalog {
+		if hint == "bad */" {
+			t.Fatalf("catalog must skip hints that close the comment")
+		}
+	}
+}
+
+func TestFilterSupportedHints(t *testing.T) {
+	hints := newHintSupport()
+	candidates := []string{
+		"HASH_JOIN(t1, t2)",
+		"AGG_TO_COP()",
+		SetVarEnableTojaOn,
+		SetVarEnableTojaOff,
+		SetVarPartialOrderedTopNCost,
+		SetVarPartialOrderedTopNDisable,
+		"MERGE_JOIN(t1, t2), " + SetVarPartialOrderedTopNCost,
+	}
+	if got := hints.filter(nil, candidates); len(got) != len(candidates) {
+		t.Fatalf("nothing probed, nothing pruned: %v", got)
+	}
+	hints.markUnsupported(HintAggToCop, "Warning:8061:Optimizer hint AGG_TO_COP is not supported")
+	hints.markUnsupported(SetVarEnableTojaOn, "Warning:1193:Unknown system variable 'tidb_opt_use_toja'")
+	hints.markUnsupported("SET_VAR(tidb_opt_partial_ordered_index_for_topn = 'COST')", "Warning:1231:Variable can't be set to the value of 'COST'")
+	got := hints.filter(nil, candidates)
+	want := []string{"HASH_JOIN(t1, t2)", SetVarPartialOrderedTopNDisable}
+	if !slices.Equal(got, want) {
+		t.Fatalf("unexpected filtered hints:\n got %v\nwant %v", got, want)
+	}
+	gen := &generator.Generator{Rand: rand.New(rand.NewSource(1))}
+	retried := 0
+	for i := 0; i < 1000; i++ {
+		if slices.Contains(hints.filter(gen, candidates), "AGG_TO_COP()") {
+			retried++
+		}
+	}
+	if retried == 0 || retried > 100 {
+		t.Fatalf("expected pruned hints to run at a small retry rate, got %d/1000", retried)
+	}
+}
+
+func TestHintSupportIsPerDQP(t *testing.T) {
+	first, second := NewDQP(), NewDQP()
+	first.hints.markUnsupported(HintAggToCop, "Warning:8061:Optimizer hint AGG_TO_COP is not supported")
+	if first.hints.supported("AGG_TO_COP()") {
+		t.Fatalf("expected the probed target to prune AGG_TO_COP")
+	}
+	if !second.hints.supported("AGG_TO_COP()") {
+		t.Fatalf("another target's probe must not prune AGG_TO_COP")
+	}
+	if !(DQP{}).hints.supported("AGG_TO_COP()") {
+		t.Fatalf("a DQP without hint support must prune nothing")
+	}
+}
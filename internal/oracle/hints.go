@@ -19,7 +19,9 @@ const (
 	HintSetVar           = "SET_VAR"
 	HintLeadingFmt       = "LEADING(%s)"
 	HintUseIndexFmt      = "USE_INDEX(%s)"
+	HintIgnoreIndexFmt   = "IGNORE_INDEX(%s)"
 	HintUseIndexMergeFmt = "USE_INDEX_MERGE(%s)"
+	HintUsePrimaryFmt    = "USE_INDEX(%s, PRIMARY)"
 )
 
 // SET_VAR hint strings used by DQP.
@@ -56,4 +58,6 @@ const (
 	SetVarFixControl44855Off             = "SET_VAR(tidb_opt_fix_control='44855:OFF')"
 	SetVarFixControl45132Zero            = "SET_VAR(tidb_opt_fix_control='45132:0')"
 	SetVarJoinReorderThresholdFmt        = "SET_VAR(tidb_opt_join_reorder_threshold=%d)"
+	SetVarReadEnginesTiKV                = "SET_VAR(tidb_isolation_read_engines='tikv,tidb')"
+	SetVarReadEnginesTiFlash             = "SET_VAR(tidb_isolation_read_engines='tiflash,tidb')"
 )
@@ -11,6 +11,7 @@ import (
 	"shiro/internal/generator"
 	"shiro/internal/oracle/impo"
 	"shiro/internal/schema"
+	"shiro/internal/sqlstep"
 
 	"github.com/go-sql-driver/mysql"
 )
@@ -79,7 +80,7 @@ func (o Impo) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, st
 			return Result{
 				OK:       false,
 				Oracle:   o.Name(),
-				SQL:      []string{initSQL},
+				Steps:    []sqlstep.Step{queryStep(sqlstep.RoleFailing, initSQL)},
 				Expected: "base_exec_success",
 				Actual:   fmt.Sprintf("base_exec_error: %s", err.Error()),
 				Details:  details,
@@ -101,7 +102,7 @@ func (o Impo) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, st
 			return Result{
 				OK:       false,
 				Oracle:   o.Name(),
-				SQL:      []string{initSQL},
+				Steps:    []sqlstep.Step{queryStep(sqlstep.RoleFailing, initSQL)},
 				Expected: "base_exec_success",
 				Actual:   fmt.Sprintf("base_exec_error: %s", err.Error()),
 				Details:  details,
@@ -164,9 +165,12 @@ func (o Impo) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, st
 		if err != nil {
 			if IsSchemaColumnMissingErr(err) {
 				return Result{
-					OK:       false,
-					Oracle:   o.Name(),
-					SQL:      []string{initSQL, unit.SQL},
+					OK:     false,
+					Oracle: o.Name(),
+					Steps: []sqlstep.Step{
+						queryStep(sqlstep.RoleExpected, initSQL),
+						queryStep(sqlstep.RoleFailing, unit.SQL),
+					},
 					Expected: "mut_exec_success",
 					Actual:   fmt.Sprintf("mut_exec_error: %s", err.Error()),
 					Details: map[string]any{
@@ -197,7 +201,7 @@ func (o Impo) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, st
 		return Result{
 			OK:       false,
 			Oracle:   o.Name(),
-			SQL:      []string{initSQL, unit.SQL},
+			Steps:    comparisonSteps(initSQL, unit.SQL),
 			Expected: fmt.Sprintf("implication=%s", implicationExpected(unit.IsUpper)),
 			Actual:   fmt.Sprintf("cmp=%s", cmpString(cmp)),
 			Details: map[string]any{
@@ -362,6 +366,7 @@ func rewriteUsingToOn(query *generator.SelectQuery, state *schema.State) {
 	if base, ok := state.TableByName(query.From.BaseTable); ok {
 		leftTables = append(leftTables, base)
 	}
+	rewritten := false
 	for i, join := range query.From.Joins {
 		if len(join.Using) == 0 {
 			if tbl, ok := state.TableByName(join.Table); ok {
@@ -401,9 +406,13 @@ func rewriteUsingToOn(query *generator.SelectQuery, state *schema.State) {
 			join.On = on
 			join.Using = nil
 			query.From.Joins[i] = join
+			rewritten = true
 		}
 		leftTables = append(leftTables, right)
 	}
+	if rewritten {
+		generator.QualifyUsingNameRefs(query)
+	}
 }
 
 func containsScalarSubquery(query *generator.SelectQuery) bool {
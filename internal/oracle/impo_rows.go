@@ -3,6 +3,7 @@ package oracle
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"strings"
 
 	"shiro/internal/db"
@@ -28,33 +29,30 @@ func queryRowSet(ctx context.Context, exec *db.DB, query string, maxRows int) (r
 	if maxRows <= 0 {
 		maxRows = 50
 	}
-	values := make([]sql.RawBytes, len(cols))
-	scanArgs := make([]any, len(cols))
-	for i := range values {
-		scanArgs[i] = &values[i]
-	}
-
 	out := rowSet{columns: len(cols), rows: make([]string, 0)}
 	truncated := false
-	for rows.Next() {
-		if err := rows.Scan(scanArgs...); err != nil {
-			return rowSet{}, false, err
+	err = exec.Guard.ScanRows(rows, func(values []sql.RawBytes) error {
+		// The set is only compared when complete, so stop at the first row past
+		// the cap instead of streaming the rest of the result.
+		if len(out.rows) >= maxRows {
+			truncated = true
+			return db.ErrStopScan
 		}
-		if len(out.rows) < maxRows {
-			parts := make([]string, 0, len(values))
-			for _, v := range values {
-				if v == nil {
-					parts = append(parts, "NULL")
-					continue
-				}
-				parts = append(parts, string(v))
+		parts := make([]string, 0, len(values))
+		for _, v := range values {
+			if v == nil {
+				parts = append(parts, "NULL")
+				continue
 			}
-			out.rows = append(out.rows, strings.Join(parts, "\x1f"))
-		} else {
-			truncated = true
+			parts = append(parts, string(v))
 		}
+		out.rows = append(out.rows, strings.Join(parts, "\x1f"))
+		return nil
+	})
+	if errors.Is(err, db.ErrResultTruncated) {
+		return out, true, nil
 	}
-	return out, truncated, rows.Err()
+	return out, truncated, err
 }
 
 func compareRowSets(base rowSet, other rowSet) (int, error) {
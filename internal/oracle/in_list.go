@@ -0,0 +1,157 @@
+package oracle
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"shiro/internal/db"
+	"shiro/internal/generator"
+	"shiro/internal/schema"
+	"shiro/internal/sqlstep"
+	"shiro/internal/util"
+)
+
+const (
+	inListBuildMaxTries = 10
+	// inListMinItems is the shortest IN list the oracle generates.
+	inListMinItems = 100
+	// inListNegateProb is the percent of runs that compare NOT IN with the
+	// negated OR chain.
+	inListNegateProb = 25
+)
+
+// InList implements the large IN-list oracle.
+//
+// It builds a deterministic query and ANDs a predicate with hundreds or
+// thousands of literals over a column of the base table into its WHERE
+// clause, once as `col IN (...)` and once as the equivalent OR chain of
+// equalities. The IN form goes through the IN-list paths of the optimizer
+// (sorted and deduplicated ranges, point gets, plan cache parameterization),
+// while the OR chain is the plain expression the IN list stands for, so both
+// must return the same rows. A quarter of the runs negate both predicates.
+//
+// Example:
+//
+//	SELECT COUNT(*), ... FROM (SELECT ... FROM t0 WHERE (t0.c0 > 3) AND (t0.c1 IN (7, -12, 7, NULL, ...))) q
+//	SELECT COUNT(*), ... FROM (SELECT ... FROM t0 WHERE (t0.c0 > 3) AND ((((t0.c1 = 7) OR (t0.c1 = -12)) OR ...))) q
+type InList struct{}
+
+// Name returns the oracle identifier.
+func (o InList) Name() string { return "InList" }
+
+// Run compares one large IN list with its OR expansion.
+func (o InList) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, state *schema.State) Result {
+	spec := QuerySpec{
+		Oracle:   "in_list",
+		MaxTries: inListBuildMaxTries,
+		SkipReasonOverrides: map[string]string{
+			"constraint:nondeterministic": "in_list:nondeterministic",
+		},
+	}
+	query, details := buildQueryWithSpec(gen, spec)
+	if query == nil {
+		return Result{OK: true, Oracle: o.Name(), Details: details}
+	}
+	tbl, ok := inListBaseTable(query, state)
+	if !ok {
+		return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "in_list:no_base_table"}}
+	}
+	items := inListSize(gen, gen.Config.Oracles.InListMaxItems)
+	in, ok := gen.LargeInListPredicate([]schema.Table{tbl}, items)
+	if !ok {
+		return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "in_list:no_column"}}
+	}
+	var inPred, orPred generator.Expr = in, generator.ExpandInList(in)
+	negated := util.Chance(gen.Rand, inListNegateProb)
+	if negated {
+		inPred = generator.UnaryExpr{Op: "NOT", Expr: inPred}
+		orPred = generator.UnaryExpr{Op: "NOT", Expr: orPred}
+	}
+	inQuery, orQuery := inListWithPredicate(query, inPred), inListWithPredicate(query, orPred)
+	metrics := map[string]int64{"in_list_total": 1, "in_list_items_sum": int64(items)}
+	if negated {
+		metrics["in_list_negated_total"] = 1
+	}
+
+	selectList := signatureSelectList(query)
+	orSQL := inListSignatureSQL(selectList, orQuery.SQLString())
+	orSig, err := exec.QuerySignature(ctx, orSQL)
+	if err != nil {
+		return sqlErrorResult(o.Name(), "in_list", []sqlstep.Step{sqlstep.New(sqlstep.KindQuery, sqlstep.RoleFailing, orSQL)}, metrics, nil, err, orSQL)
+	}
+	inSQL := inListSignatureSQL(selectList, inQuery.SQLString())
+	steps := []sqlstep.Step{sqlstep.New(sqlstep.KindQuery, sqlstep.RoleExpected, orSQL)}
+	inSig, err := exec.QuerySignature(ctx, inSQL)
+	if err != nil {
+		steps = append(steps, sqlstep.New(sqlstep.KindQuery, sqlstep.RoleFailing, inSQL))
+		return sqlErrorResult(o.Name(), "in_list", steps, metrics, nil, err, inSQL)
+	}
+	steps = append(steps, sqlstep.New(sqlstep.KindQuery, sqlstep.RoleActual, inSQL))
+	if inSig != orSig {
+		col := in.Left.Columns()[0]
+		return Result{
+			OK:       false,
+			Oracle:   o.Name(),
+			Steps:    steps,
+			Expected: fmt.Sprintf("cnt=%d checksum=%d", orSig.Count, orSig.Checksum),
+			Actual:   fmt.Sprintf("cnt=%d checksum=%d", inSig.Count, inSig.Checksum),
+			Details: map[string]any{
+				"in_list_items":   items,
+				"in_list_column":  col.Table + "." + col.Name,
+				"in_list_negated": negated,
+				"replay_kind":     "signature",
+			},
+			Metrics: metrics,
+		}
+	}
+	return Result{OK: true, Oracle: o.Name(), Steps: steps, Metrics: metrics}
+}
+
+// inListBaseTable returns the base table of query under the name its columns
+// are qualified with. Derived tables, CTEs, and names missing from state are
+// rejected.
+func inListBaseTable(query *generator.SelectQuery, state *schema.State) (schema.Table, bool) {
+	if state == nil || query.From.BaseQuery != nil {
+		return schema.Table{}, false
+	}
+	for _, cte := range query.With {
+		if cte.Name == query.From.BaseTable {
+			return schema.Table{}, false
+		}
+	}
+	tbl, ok := state.TableByName(query.From.BaseTable)
+	if !ok {
+		return schema.Table{}, false
+	}
+	if query.From.BaseAlias != "" {
+		tbl.Name = query.From.BaseAlias
+	}
+	return tbl, true
+}
+
+// inListSize picks an IN-list length between inListMinItems and maxItems,
+// uniformly on a log scale so hundreds are as common as thousands.
+func inListSize(gen *generator.Generator, maxItems int) int {
+	if maxItems <= inListMinItems {
+		return inListMinItems
+	}
+	ratio := float64(maxItems) / float64(inListMinItems)
+	return min(int(float64(inListMinItems)*math.Pow(ratio, gen.Rand.Float64())), maxItems)
+}
+
+// inListWithPredicate returns a copy of query with pred ANDed into its WHERE
+// clause.
+func inListWithPredicate(query *generator.SelectQuery, pred generator.Expr) *generator.SelectQuery {
+	out := query.Clone()
+	if out.Where == nil {
+		out.Where = pred
+	} else {
+		out.Where = generator.BinaryExpr{Left: out.Where, Op: "AND", Right: pred}
+	}
+	return out
+}
+
+func inListSignatureSQL(selectList string, query string) string {
+	return fmt.Sprintf("SELECT COUNT(*) AS cnt, IFNULL(BIT_XOR(CRC32(CONCAT_WS('#', %s))),0) AS checksum FROM (%s) q", selectList, query)
+}
@@ -0,0 +1,52 @@
+package oracle
+
+import (
+	"strings"
+	"testing"
+
+	"shiro/internal/generator"
+	"shiro/internal/schema"
+)
+
+func TestInListBaseTable(t *testing.T) {
+	state := &schema.State{Tables: []schema.Table{{Name: "t0", Columns: []schema.Column{{Name: "c0", Type: schema.TypeInt}}}}}
+	query := &generator.SelectQuery{From: generator.FromClause{BaseTable: "t0", BaseAlias: "a"}}
+	tbl, ok := inListBaseTable(query, state)
+	if !ok || tbl.Name != "a" || len(tbl.Columns) != 1 {
+		t.Fatalf("unexpected base table %+v ok=%v", tbl, ok)
+	}
+	query.With = []generator.CTE{{Name: "t0", Query: &generator.SelectQuery{}}}
+	if _, ok := inListBaseTable(query, state); ok {
+		t.Fatalf("expected a CTE named like the table to be rejected")
+	}
+	if _, ok := inListBaseTable(&generator.SelectQuery{From: generator.FromClause{BaseTable: "t9"}}, state); ok {
+		t.Fatalf("expected an unknown table to be rejected")
+	}
+}
+
+func TestInListSizeAndPredicate(t *testing.T) {
+	gen := newProfileTestGenerator(t)
+	for i := 0; i < 200; i++ {
+		if n := inListSize(gen, 2000); n < inListMinItems || n > 2000 {
+			t.Fatalf("size %d out of range", n)
+		}
+	}
+	if n := inListSize(gen, 10); n != inListMinItems {
+		t.Fatalf("expected the minimum size, got %d", n)
+	}
+
+	query, details := buildQueryWithSpec(gen, QuerySpec{Oracle: "in_list", MaxTries: 50})
+	if query == nil {
+		t.Fatalf("expected query, details=%v", details)
+		return
+	}
+	if query.Limit != nil || len(query.SetOps) > 0 {
+		t.Fatalf("unexpected in_list query: %s", query.SQLString())
+	}
+	before := query.SQLString()
+	pred := generator.BinaryExpr{Left: generator.LiteralExpr{Value: 1}, Op: "=", Right: generator.LiteralExpr{Value: 1}}
+	got := inListWithPredicate(query, pred).SQLString()
+	if query.SQLString() != before || !strings.Contains(got, "(1 = 1)") {
+		t.Fatalf("unexpected predicate query: %s", got)
+	}
+}
@@ -0,0 +1,652 @@
+package oracle
+
+import (
+	"context"
+	"crypto/md5"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"shiro/internal/config"
+	"shiro/internal/db"
+	"shiro/internal/generator"
+	"shiro/internal/schema"
+	"shiro/internal/sqlstep"
+	"shiro/internal/util"
+)
+
+const (
+	largeRowTable = "shiro_large_row"
+
+	largeRowShapeWide      = "wide"
+	largeRowShapeLong      = "long"
+	largeRowShapeManyIndex = "many_index"
+
+	largeRowWideRows      = 4
+	largeRowIndexRows     = 24
+	largeRowIndexProbes   = 3
+	largeRowNullProb      = 10
+	largeRowUnitMax       = 16
+	largeRowMultiByteProb = 25
+	// largeRowPacketMargin leaves room for the rest of the statement and
+	// row when a payload is capped by max_allowed_packet.
+	largeRowPacketMargin = 1 << 10
+)
+
+// largeRowLimitErrCodes are the errors a shape gets when it crosses a server
+// limit that the configured bounds did not anticipate: entry too large, row
+// size too large, too many columns, too many keys, and packet too large.
+var largeRowLimitErrCodes = []uint16{8025, 1118, 1117, 1069, 1153, 1301}
+
+// LargeRow implements the large-row stress oracle.
+//
+// The random schema only has a handful of small columns, while executor chunk
+// handling and row encoding bugs cluster around large rows. Each run builds
+// one scratch table of a random shape, writes rows whose content the client
+// knows, mutates them, and reads them back:
+//   - wide: hundreds of INT, BIGINT, and VARCHAR(16) columns, kept under the
+//     65535-byte row size, read by table scan and point get before and after
+//     an update of a quarter of the columns.
+//   - long: LONGTEXT and LONGBLOB payloads of up to
+//     oracles.large_row.max_payload_bytes (capped by max_allowed_packet),
+//     compared by LENGTH, CHAR_LENGTH, and MD5, and found again by a
+//     pushed-down MD5 selection.
+//   - many_index: dozens of single and composite indexes; after updates and
+//     deletes, ADMIN CHECK TABLE must pass and forced index lookups must
+//     return the rows the client expects.
+//
+// Payloads are written with REPEAT and UNHEX, so the recorded SQL stays short.
+// Hitting a server limit is a skip, not a finding.
+//
+// Example:
+//
+//	CREATE TABLE shiro_large_row (id INT PRIMARY KEY, tag INT, t0 LONGTEXT, b0 LONGBLOB)
+//	INSERT INTO shiro_large_row VALUES (1, 7, REPEAT('k3é', 1747626), UNHEX(REPEAT('00ff', 262144)))
+//	SELECT id, tag, LENGTH(t0), CHAR_LENGTH(t0), MD5(t0), LENGTH(b0), MD5(b0) FROM shiro_large_row ORDER BY id
+//	-- every value must match the client's copy of the payload
+type LargeRow struct{}
+
+// Name returns the oracle identifier.
+func (o LargeRow) Name() string { return "LargeRow" }
+
+// largeRowColumn is one scratch column; str columns take quoted literals.
+type largeRowColumn struct {
+	name    string
+	sqlType string
+	str     bool
+}
+
+// largeRowIndex is a secondary index over one or more scratch columns.
+type largeRowIndex struct {
+	name    string
+	columns []int
+}
+
+// largeRowRow is a scratch row as the client expects to read it back, with
+// sqlNullValue for NULL.
+type largeRowRow struct {
+	id     int
+	values []string
+}
+
+// largeRowPayload is one LONGTEXT/LONGBLOB row of the long shape.
+type largeRowPayload struct {
+	id        int
+	tag       int
+	textUnit  string
+	textCount int
+	text      string
+	blobUnit  []byte
+	blobCount int
+	blob      []byte
+	blobNull  bool
+}
+
+// largeRowRun carries the connection and the case record of one run.
+type largeRowRun struct {
+	oracle  string
+	conn    *sql.Conn
+	guard   db.ResultGuard
+	steps   []sqlstep.Step
+	details map[string]any
+	metrics map[string]int64
+}
+
+// Run picks a shape, builds its scratch table, and checks the rows it reads
+// back. The table is dropped afterwards.
+func (o LargeRow) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, _ *schema.State) Result {
+	limits := largeRowLimits(gen.Config.Oracles.LargeRow)
+	shapes := []string{largeRowShapeWide, largeRowShapeLong, largeRowShapeManyIndex}
+	shape := shapes[gen.Rand.Intn(len(shapes))]
+
+	conn, err := exec.Conn(ctx)
+	if err != nil {
+		return Result{OK: true, Oracle: o.Name(), Err: err}
+	}
+	defer util.CloseWithErr(conn, "large_row conn")
+	run := &largeRowRun{
+		oracle:  o.Name(),
+		conn:    conn,
+		guard:   exec.Guard,
+		details: map[string]any{"large_row_shape": shape},
+		metrics: map[string]int64{"large_row_shape_" + shape + "_total": 1},
+	}
+	dropSQL := "DROP TABLE IF EXISTS " + largeRowTable
+	defer func() {
+		_, _ = conn.ExecContext(context.Background(), dropSQL)
+	}()
+	if err := run.exec(ctx, dropSQL); err != nil {
+		return run.fail(err, sqlstep.KindSetup, dropSQL)
+	}
+	switch shape {
+	case largeRowShapeWide:
+		return run.wide(ctx, gen.Rand, limits)
+	case largeRowShapeLong:
+		return run.long(ctx, gen.Rand, limits)
+	default:
+		return run.manyIndex(ctx, gen.Rand, limits)
+	}
+}
+
+// largeRowLimits falls back to the smallest shapes when the config was not
+// normalized, as in library use.
+func largeRowLimits(cfg config.LargeRowConfig) config.LargeRowConfig {
+	cfg.MaxColumns = max(cfg.MaxColumns, 16)
+	cfg.MaxPayloadBytes = max(cfg.MaxPayloadBytes, 1<<10)
+	cfg.MaxIndexes = max(cfg.MaxIndexes, 2)
+	return cfg
+}
+
+func (run *largeRowRun) wide(ctx context.Context, r *rand.Rand, limits config.LargeRowConfig) Result {
+	count := largeRowPickCount(r, limits.MaxColumns)
+	columns := largeRowWideColumns(r, count)
+	run.details["large_row_columns"] = count
+	rows := make([]largeRowRow, 0, largeRowWideRows)
+	for id := 1; id <= largeRowWideRows; id++ {
+		rows = append(rows, largeRowRow{id: id, values: largeRowWideValues(r, columns)})
+	}
+	for _, stmt := range []string{largeRowCreateSQL(columns, nil), largeRowInsertSQL(columns, rows)} {
+		if err := run.exec(ctx, stmt); err != nil {
+			return run.fail(err, sqlstep.KindSetup, stmt)
+		}
+	}
+	scanSQL := fmt.Sprintf("SELECT * FROM %s ORDER BY id", largeRowTable)
+	if res, ok := run.checkRows(ctx, "scan", scanSQL, columns, rows); !ok {
+		return res
+	}
+
+	target := &rows[r.Intn(len(rows))]
+	updated := largeRowWideValues(r, columns)
+	assigns := make([]string, 0, count/4+1)
+	for _, i := range r.Perm(count)[:count/4+1] {
+		target.values[i] = updated[i]
+		assigns = append(assigns, fmt.Sprintf("%s = %s", columns[i].name, largeRowLiteral(columns[i], updated[i])))
+	}
+	updateSQL := fmt.Sprintf("UPDATE %s SET %s WHERE id = %d", largeRowTable, strings.Join(assigns, ", "), target.id)
+	if err := run.exec(ctx, updateSQL); err != nil {
+		return run.fail(err, sqlstep.KindSetup, updateSQL)
+	}
+	pointSQL := fmt.Sprintf("SELECT * FROM %s WHERE id = %d", largeRowTable, target.id)
+	if res, ok := run.checkRows(ctx, "point_get", pointSQL, columns, []largeRowRow{*target}); !ok {
+		return res
+	}
+	if res, ok := run.checkRows(ctx, "scan_after_update", scanSQL, columns, rows); !ok {
+		return res
+	}
+	return run.ok()
+}
+
+func (run *largeRowRun) long(ctx context.Context, r *rand.Rand, limits config.LargeRowConfig) Result {
+	var packet int64
+	packetSQL := "SELECT @@max_allowed_packet"
+	if err := run.conn.QueryRowContext(ctx, packetSQL).Scan(&packet); err != nil {
+		return run.fail(err, sqlstep.KindVerify, packetSQL)
+	}
+	budget := min(int64(limits.MaxPayloadBytes), packet-largeRowPacketMargin)
+	if budget < 1<<10 {
+		run.details["skip_reason"] = "large_row:packet_too_small"
+		return run.ok()
+	}
+	sizes := []int{
+		int(budget) * (3 + r.Intn(2)) / 4,
+		int(budget) / 16,
+		r.Intn(64),
+	}
+	rows := make([]largeRowPayload, 0, len(sizes))
+	var total int64
+	for i, size := range sizes {
+		row := largeRowPickPayload(r, i+1, size)
+		rows = append(rows, row)
+		total += int64(len(row.text) + len(row.blob))
+	}
+	run.details["large_row_payload_bytes"] = len(rows[0].text) + len(rows[0].blob)
+	run.metrics["large_row_payload_bytes_total"] = total
+
+	create := fmt.Sprintf("CREATE TABLE %s (id INT PRIMARY KEY, tag INT, t0 LONGTEXT, b0 LONGBLOB)", largeRowTable)
+	if err := run.exec(ctx, create); err != nil {
+		return run.fail(err, sqlstep.KindSetup, create)
+	}
+	for _, row := range rows {
+		stmt := row.insertSQL()
+		if err := run.exec(ctx, stmt); err != nil {
+			return run.fail(err, sqlstep.KindSetup, stmt)
+		}
+	}
+	digestSQL := fmt.Sprintf("SELECT id, tag, LENGTH(t0), CHAR_LENGTH(t0), MD5(t0), LENGTH(b0), MD5(b0) FROM %s ORDER BY id", largeRowTable)
+	if res, ok := run.checkDigests(ctx, "digest", digestSQL, rows); !ok {
+		return res
+	}
+
+	big := &rows[0]
+	updateSQL := fmt.Sprintf("UPDATE %s SET t0 = CONCAT(t0, 'z'), tag = tag + 1 WHERE id = %d", largeRowTable, big.id)
+	if err := run.exec(ctx, updateSQL); err != nil {
+		return run.fail(err, sqlstep.KindSetup, updateSQL)
+	}
+	big.text += "z"
+	big.tag++
+	if res, ok := run.checkDigests(ctx, "digest_after_update", digestSQL, rows); !ok {
+		return res
+	}
+
+	if big.blobNull {
+		return run.ok()
+	}
+	digest := largeRowMD5(big.blob)
+	selectSQL := fmt.Sprintf("SELECT id FROM %s WHERE MD5(b0) = '%s' ORDER BY id", largeRowTable, digest)
+	var expected []string
+	for _, row := range rows {
+		if !row.blobNull && largeRowMD5(row.blob) == digest {
+			expected = append(expected, strconv.Itoa(row.id))
+		}
+	}
+	if res, ok := run.checkIDs(ctx, "md5_selection", selectSQL, expected); !ok {
+		return res
+	}
+	return run.ok()
+}
+
+func (run *largeRowRun) manyIndex(ctx context.Context, r *rand.Rand, limits config.LargeRowConfig) Result {
+	indexCount := largeRowPickCount(r, limits.MaxIndexes)
+	columns := make([]largeRowColumn, 0, indexCount)
+	for i := 0; i < max(4, indexCount*2/3); i++ {
+		col := largeRowColumn{name: fmt.Sprintf("c%d", i), sqlType: "INT"}
+		if r.Intn(2) == 0 {
+			col.sqlType, col.str = "VARCHAR(32)", true
+		}
+		columns = append(columns, col)
+	}
+	indexes := largeRowPickIndexes(r, len(columns), indexCount)
+	run.details["large_row_columns"] = len(columns)
+	run.details["large_row_indexes"] = len(indexes)
+
+	rows := make([]largeRowRow, 0, largeRowIndexRows)
+	for id := 1; id <= largeRowIndexRows; id++ {
+		values := make([]string, len(columns))
+		for i, col := range columns {
+			values[i] = largeRowIndexValue(r, col)
+		}
+		rows = append(rows, largeRowRow{id: id, values: values})
+	}
+	for _, stmt := range []string{largeRowCreateSQL(columns, indexes), largeRowInsertSQL(columns, rows)} {
+		if err := run.exec(ctx, stmt); err != nil {
+			return run.fail(err, sqlstep.KindSetup, stmt)
+		}
+	}
+
+	colIdx := r.Intn(len(columns))
+	value := largeRowIndexValue(r, columns[colIdx])
+	var updateIDs []string
+	for i := range rows {
+		if r.Intn(3) == 0 {
+			rows[i].values[colIdx] = value
+			updateIDs = append(updateIDs, strconv.Itoa(rows[i].id))
+		}
+	}
+	perm := r.Perm(len(rows))
+	deleted := map[int]bool{rows[perm[0]].id: true, rows[perm[1]].id: true}
+	var mutations []string
+	if len(updateIDs) > 0 {
+		mutations = append(mutations, fmt.Sprintf("UPDATE %s SET %s = %s WHERE id IN (%s)",
+			largeRowTable, columns[colIdx].name, largeRowLiteral(columns[colIdx], value), strings.Join(updateIDs, ", ")))
+	}
+	mutations = append(mutations, fmt.Sprintf("DELETE FROM %s WHERE id IN (%d, %d)", largeRowTable, rows[perm[0]].id, rows[perm[1]].id))
+	for _, stmt := range mutations {
+		if err := run.exec(ctx, stmt); err != nil {
+			return run.fail(err, sqlstep.KindSetup, stmt)
+		}
+	}
+	live := make([]largeRowRow, 0, len(rows))
+	for _, row := range rows {
+		if !deleted[row.id] {
+			live = append(live, row)
+		}
+	}
+
+	checkSQL := "ADMIN CHECK TABLE " + largeRowTable
+	if _, err := run.conn.ExecContext(ctx, checkSQL); err != nil {
+		if isAdminCheckInconsistentErr(err) {
+			run.steps = append(run.steps, sqlstep.New(sqlstep.KindVerify, sqlstep.RoleActual, checkSQL))
+			return run.mismatch("admin_check", "consistent", err.Error())
+		}
+		run.metrics["large_row_admin_check_error_total"]++
+	}
+
+	for _, i := range r.Perm(len(indexes))[:min(largeRowIndexProbes, len(indexes))] {
+		idx := indexes[i]
+		lead := idx.columns[0]
+		probe := live[r.Intn(len(live))].values[lead]
+		cond := fmt.Sprintf("%s = %s", columns[lead].name, largeRowLiteral(columns[lead], probe))
+		if probe == sqlNullValue {
+			cond = columns[lead].name + " IS NULL"
+		}
+		var expected []string
+		for _, row := range live {
+			if row.values[lead] == probe {
+				expected = append(expected, strconv.Itoa(row.id))
+			}
+		}
+		query := fmt.Sprintf("SELECT id FROM %s FORCE INDEX (%s) WHERE %s ORDER BY id", largeRowTable, idx.name, cond)
+		if res, ok := run.checkIDs(ctx, "index_lookup", query, expected); !ok {
+			return res
+		}
+	}
+	return run.ok()
+}
+
+func (run *largeRowRun) exec(ctx context.Context, stmt string) error {
+	if _, err := run.conn.ExecContext(ctx, stmt); err != nil {
+		return err
+	}
+	run.steps = append(run.steps, sqlstep.New(sqlstep.KindSetup, "", stmt))
+	return nil
+}
+
+// checkRows compares full rows (id first) with the client copy.
+func (run *largeRowRun) checkRows(ctx context.Context, check string, query string, columns []largeRowColumn, rows []largeRowRow) (Result, bool) {
+	got, err := queryRowStrings(ctx, run.conn, run.guard, query)
+	if err != nil {
+		return run.fail(err, sqlstep.KindQuery, query), false
+	}
+	run.metrics["large_row_checks_total"]++
+	if len(got) != len(rows) {
+		return run.mismatchQuery(check, query, fmt.Sprintf("rows=%d", len(rows)), fmt.Sprintf("rows=%d", len(got))), false
+	}
+	for i, row := range rows {
+		want := append([]string{strconv.Itoa(row.id)}, row.values...)
+		if len(got[i]) != len(want) {
+			return run.mismatchQuery(check, query, fmt.Sprintf("columns=%d", len(want)), fmt.Sprintf("columns=%d", len(got[i]))), false
+		}
+		for j := range want {
+			if got[i][j] != want[j] {
+				name := "id"
+				if j > 0 {
+					name = columns[j-1].name
+				}
+				run.details["large_row_row"] = row.id
+				run.details["large_row_column"] = name
+				return run.mismatchQuery(check, query,
+					fmt.Sprintf("%s=%s", name, largeRowDisplay(want[j])),
+					fmt.Sprintf("%s=%s", name, largeRowDisplay(got[i][j]))), false
+			}
+		}
+	}
+	return Result{}, true
+}
+
+// checkDigests compares the long shape digest query with the client copy.
+func (run *largeRowRun) checkDigests(ctx context.Context, check string, query string, rows []largeRowPayload) (Result, bool) {
+	got, err := queryRowStrings(ctx, run.conn, run.guard, query)
+	if err != nil {
+		return run.fail(err, sqlstep.KindQuery, query), false
+	}
+	run.metrics["large_row_checks_total"]++
+	want := make([]string, 0, len(rows))
+	for _, row := range rows {
+		want = append(want, strings.Join(row.digest(), "|"))
+	}
+	have := make([]string, 0, len(got))
+	for _, row := range got {
+		have = append(have, strings.Join(row, "|"))
+	}
+	for i := range max(len(want), len(have)) {
+		if i >= len(want) || i >= len(have) || want[i] != have[i] {
+			return run.mismatchQuery(check, query, largeRowDisplayDigests(want), largeRowDisplayDigests(have)), false
+		}
+	}
+	return Result{}, true
+}
+
+func (run *largeRowRun) checkIDs(ctx context.Context, check string, query string, expected []string) (Result, bool) {
+	got, err := queryRowStrings(ctx, run.conn, run.guard, query)
+	if err != nil {
+		return run.fail(err, sqlstep.KindQuery, query), false
+	}
+	run.metrics["large_row_checks_total"]++
+	actual := make([]string, 0, len(got))
+	for _, row := range got {
+		actual = append(actual, row[0])
+	}
+	if !slices.Equal(expected, actual) {
+		return run.mismatchQuery(check, query, "ids="+strings.Join(expected, ","), "ids="+strings.Join(actual, ",")), false
+	}
+	return Result{}, true
+}
+
+// fail reports an error as a skip when it is a server limit and as an
+// errored run otherwise.
+func (run *largeRowRun) fail(err error, kind sqlstep.Kind, stmt string) Result {
+	if _, code := sqlErrorReason("large_row", err); slices.Contains(largeRowLimitErrCodes, code) {
+		run.details["error_sql"] = stmt
+		run.details["error_code"] = int(code)
+		run.details["skip_reason"] = "large_row:server_limit"
+		run.metrics["large_row_server_limit_total"]++
+		return run.ok()
+	}
+	return sqlErrorResult(run.oracle, "large_row", failedSteps(run.steps, kind, stmt), run.metrics, run.details, err, stmt)
+}
+
+func (run *largeRowRun) mismatchQuery(check string, query string, expected string, actual string) Result {
+	run.steps = append(run.steps, sqlstep.New(sqlstep.KindQuery, sqlstep.RoleActual, query))
+	return run.mismatch(check, expected, actual)
+}
+
+func (run *largeRowRun) mismatch(check string, expected string, actual string) Result {
+	run.details["large_row_check"] = check
+	return Result{
+		OK:       false,
+		Oracle:   run.oracle,
+		Steps:    run.steps,
+		Expected: expected,
+		Actual:   actual,
+		Details:  run.details,
+		Metrics:  run.metrics,
+	}
+}
+
+func (run *largeRowRun) ok() Result {
+	return Result{OK: true, Oracle: run.oracle, Steps: run.steps, Details: run.details, Metrics: run.metrics}
+}
+
+// largeRowPickCount returns a count in [limit/2, limit].
+func largeRowPickCount(r *rand.Rand, limit int) int {
+	return limit/2 + r.Intn(limit-limit/2+1)
+}
+
+func largeRowWideColumns(r *rand.Rand, count int) []largeRowColumn {
+	types := []largeRowColumn{{sqlType: "INT"}, {sqlType: "BIGINT"}, {sqlType: "VARCHAR(16)", str: true}}
+	columns := make([]largeRowColumn, 0, count)
+	for i := 0; i < count; i++ {
+		col := types[r.Intn(len(types))]
+		col.name = fmt.Sprintf("c%d", i)
+		columns = append(columns, col)
+	}
+	return columns
+}
+
+func largeRowWideValues(r *rand.Rand, columns []largeRowColumn) []string {
+	values := make([]string, len(columns))
+	for i, col := range columns {
+		switch {
+		case util.Chance(r, largeRowNullProb):
+			values[i] = sqlNullValue
+		case col.str:
+			values[i] = largeRowWord(r, "abcdefghijklmnopqrstuvwxyz0123456789", r.Intn(17))
+		case col.sqlType == "BIGINT":
+			values[i] = strconv.FormatInt(r.Int63()-r.Int63(), 10)
+		default:
+			values[i] = strconv.Itoa(r.Intn(2_000_001) - 1_000_000)
+		}
+	}
+	return values
+}
+
+// largeRowIndexValue draws from a small domain so index probes match several
+// rows.
+func largeRowIndexValue(r *rand.Rand, col largeRowColumn) string {
+	switch {
+	case util.Chance(r, largeRowNullProb):
+		return sqlNullValue
+	case col.str:
+		return largeRowWord(r, "abc", 1+r.Intn(2))
+	default:
+		return strconv.Itoa(r.Intn(17) - 8)
+	}
+}
+
+// largeRowPickIndexes indexes every column on its own, then adds composite
+// indexes over distinct column sets until count indexes exist.
+func largeRowPickIndexes(r *rand.Rand, columns int, count int) []largeRowIndex {
+	indexes := make([]largeRowIndex, 0, count)
+	seen := map[string]struct{}{}
+	for i := 0; i < columns && len(indexes) < count; i++ {
+		indexes = append(indexes, largeRowIndex{name: fmt.Sprintf("idx_%d", len(indexes)), columns: []int{i}})
+		seen[strconv.Itoa(i)] = struct{}{}
+	}
+	for tries := 0; len(indexes) < count && tries < count*8; tries++ {
+		cols := r.Perm(columns)[:2+r.Intn(2)]
+		parts := make([]string, 0, len(cols))
+		for _, c := range cols {
+			parts = append(parts, strconv.Itoa(c))
+		}
+		key := strings.Join(parts, ",")
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		indexes = append(indexes, largeRowIndex{name: fmt.Sprintf("idx_%d", len(indexes)), columns: cols})
+	}
+	return indexes
+}
+
+// largeRowPickPayload splits size bytes between a text payload and a blob
+// payload built from short repeated units.
+func largeRowPickPayload(r *rand.Rand, id int, size int) largeRowPayload {
+	row := largeRowPayload{id: id, tag: r.Intn(100)}
+	textBytes := size * r.Intn(101) / 100
+	row.textUnit = largeRowWord(r, "abcdefghijklmnopqrstuvwxyz0123456789", 1+r.Intn(largeRowUnitMax))
+	if util.Chance(r, largeRowMultiByteProb) {
+		row.textUnit += "é"
+	}
+	row.textCount = textBytes / len(row.textUnit)
+	row.text = strings.Repeat(row.textUnit, row.textCount)
+	if size < 64 && util.Chance(r, 50) {
+		row.blobNull = true
+		return row
+	}
+	row.blobUnit = make([]byte, 1+r.Intn(largeRowUnitMax))
+	for i := range row.blobUnit {
+		row.blobUnit[i] = byte(r.Intn(256))
+	}
+	row.blobCount = (size - textBytes) / len(row.blobUnit)
+	row.blob = []byte(strings.Repeat(string(row.blobUnit), row.blobCount))
+	return row
+}
+
+func (p largeRowPayload) insertSQL() string {
+	blob := "NULL"
+	if !p.blobNull {
+		blob = fmt.Sprintf("UNHEX(REPEAT('%s', %d))", hex.EncodeToString(p.blobUnit), p.blobCount)
+	}
+	return fmt.Sprintf("INSERT INTO %s VALUES (%d, %d, REPEAT('%s', %d), %s)", largeRowTable, p.id, p.tag, p.textUnit, p.textCount, blob)
+}
+
+// digest returns the expected row of the digest query.
+func (p largeRowPayload) digest() []string {
+	out := []string{
+		strconv.Itoa(p.id),
+		strconv.Itoa(p.tag),
+		strconv.Itoa(len(p.text)),
+		strconv.Itoa(utf8.RuneCountInString(p.text)),
+		largeRowMD5([]byte(p.text)),
+	}
+	if p.blobNull {
+		return append(out, sqlNullValue, sqlNullValue)
+	}
+	return append(out, strconv.Itoa(len(p.blob)), largeRowMD5(p.blob))
+}
+
+func largeRowMD5(data []byte) string {
+	sum := md5.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func largeRowDisplay(value string) string {
+	return strings.ReplaceAll(value, sqlNullValue, "NULL")
+}
+
+func largeRowDisplayDigests(rows []string) string {
+	return largeRowDisplay(strings.Join(rows, "; "))
+}
+
+func largeRowWord(r *rand.Rand, alphabet string, n int) string {
+	out := make([]byte, n)
+	for i := range out {
+		out[i] = alphabet[r.Intn(len(alphabet))]
+	}
+	return string(out)
+}
+
+func largeRowLiteral(col largeRowColumn, value string) string {
+	switch {
+	case value == sqlNullValue:
+		return "NULL"
+	case col.str:
+		return "'" + value + "'"
+	default:
+		return value
+	}
+}
+
+func largeRowCreateSQL(columns []largeRowColumn, indexes []largeRowIndex) string {
+	parts := make([]string, 0, len(columns)+len(indexes)+1)
+	parts = append(parts, "id INT PRIMARY KEY")
+	for _, col := range columns {
+		parts = append(parts, col.name+" "+col.sqlType)
+	}
+	for _, idx := range indexes {
+		names := make([]string, 0, len(idx.columns))
+		for _, c := range idx.columns {
+			names = append(names, columns[c].name)
+		}
+		parts = append(parts, fmt.Sprintf("INDEX %s (%s)", idx.name, strings.Join(names, ", ")))
+	}
+	return fmt.Sprintf("CREATE TABLE %s (%s)", largeRowTable, strings.Join(parts, ", "))
+}
+
+func largeRowInsertSQL(columns []largeRowColumn, rows []largeRowRow) string {
+	tuples := make([]string, 0, len(rows))
+	for _, row := range rows {
+		values := make([]string, 0, len(columns)+1)
+		values = append(values, strconv.Itoa(row.id))
+		for i, col := range columns {
+			values = append(values, largeRowLiteral(col, row.values[i]))
+		}
+		tuples = append(tuples, "("+strings.Join(values, ", ")+")")
+	}
+	return fmt.Sprintf("INSERT INTO %s VALUES %s", largeRowTable, strings.Join(tuples, ", "))
+}
@@ -0,0 +1,90 @@
+package oracle
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestLargeRowPayloadDigest(t *testing.T) {
+	row := largeRowPayload{
+		id:        1,
+		tag:       7,
+		textUnit:  "aé",
+		textCount: 2,
+		text:      "aéaé",
+		blobUnit:  []byte{0x00, 0xff},
+		blobCount: 3,
+		blob:      []byte{0x00, 0xff, 0x00, 0xff, 0x00, 0xff},
+	}
+	if got := row.insertSQL(); got != "INSERT INTO shiro_large_row VALUES (1, 7, REPEAT('aé', 2), UNHEX(REPEAT('00ff', 3)))" {
+		t.Fatalf("unexpected insert: %s", got)
+	}
+	digest := row.digest()
+	want := []string{"1", "7", "6", "4", largeRowMD5([]byte("aéaé")), "6", largeRowMD5(row.blob)}
+	if strings.Join(digest, "|") != strings.Join(want, "|") {
+		t.Fatalf("unexpected digest: %v", digest)
+	}
+	if largeRowMD5(nil) != "d41d8cd98f00b204e9800998ecf8427e" {
+		t.Fatalf("unexpected empty md5")
+	}
+	row.blobNull = true
+	if got := row.insertSQL(); !strings.HasSuffix(got, ", NULL)") {
+		t.Fatalf("expected NULL blob: %s", got)
+	}
+	if digest := row.digest(); digest[5] != sqlNullValue || digest[6] != sqlNullValue {
+		t.Fatalf("expected NULL blob digest: %v", digest)
+	}
+}
+
+func TestLargeRowPickPayloadFitsBudget(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	for i := 0; i < 50; i++ {
+		size := 1 + r.Intn(1<<16)
+		row := largeRowPickPayload(r, 1, size)
+		if len(row.text)+len(row.blob) > size {
+			t.Fatalf("payload %d exceeds budget %d", len(row.text)+len(row.blob), size)
+		}
+		if len(row.text) != len(row.textUnit)*row.textCount || len(row.blob) != len(row.blobUnit)*row.blobCount {
+			t.Fatalf("payload does not match its REPEAT form: %+v", row.insertSQL())
+		}
+	}
+}
+
+func TestLargeRowPickIndexes(t *testing.T) {
+	r := rand.New(rand.NewSource(5))
+	indexes := largeRowPickIndexes(r, 10, 16)
+	if len(indexes) != 16 {
+		t.Fatalf("expected 16 indexes, got %d", len(indexes))
+	}
+	seen := map[string]bool{}
+	for i, idx := range indexes {
+		key := fmt.Sprint(idx.columns)
+		if seen[key] {
+			t.Fatalf("duplicate index columns %s", key)
+		}
+		seen[key] = true
+		if i < 10 && (len(idx.columns) != 1 || idx.columns[0] != i) {
+			t.Fatalf("expected single-column index %d first: %+v", i, idx)
+		}
+	}
+	columns := []largeRowColumn{{name: "c0", sqlType: "INT"}, {name: "c1", sqlType: "VARCHAR(32)", str: true}}
+	create := largeRowCreateSQL(columns, []largeRowIndex{{name: "idx_0", columns: []int{1, 0}}})
+	if create != "CREATE TABLE shiro_large_row (id INT PRIMARY KEY, c0 INT, c1 VARCHAR(32), INDEX idx_0 (c1, c0))" {
+		t.Fatalf("unexpected create: %s", create)
+	}
+	insert := largeRowInsertSQL(columns, []largeRowRow{{id: 1, values: []string{"-3", "ab"}}, {id: 2, values: []string{sqlNullValue, ""}}})
+	if insert != "INSERT INTO shiro_large_row VALUES (1, -3, 'ab'), (2, NULL, '')" {
+		t.Fatalf("unexpected insert: %s", insert)
+	}
+}
+
+func TestLargeRowPickCount(t *testing.T) {
+	r := rand.New(rand.NewSource(9))
+	for i := 0; i < 100; i++ {
+		if got := largeRowPickCount(r, 33); got < 16 || got > 33 {
+			t.Fatalf("count %d outside [16, 33]", got)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"shiro/internal/db"
 	"shiro/internal/generator"
 	"shiro/internal/schema"
+	"shiro/internal/sqlstep"
 )
 
 // NoREC implements the NoREC oracle.
@@ -27,7 +28,9 @@ const noRECBuildMaxTries = 10
 // Run generates a simple SELECT with a WHERE predicate and compares the two counts.
 // It skips complex queries (aggregates, GROUP BY, DISTINCT, HAVING, subqueries),
 // because NoREC assumes a flat SELECT with a single predicate. LIMIT is allowed
-// only when paired with ORDER BY and is applied to both forms (top-N).
+// only when paired with ORDER BY and is applied to both forms (top-N). CTEs
+// and views are allowed: both forms keep the WITH clause, and the builder
+// rejects views whose definition is not deterministic.
 //
 // Example:
 //
@@ -41,9 +44,7 @@ func (o NoREC) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, _
 		Profile:  ProfileByName("NoREC"),
 		MaxTries: noRECBuildMaxTries,
 		Constraints: generator.SelectQueryConstraints{
-			RequireWhere:         true,
-			RequireDeterministic: true,
-			QueryGuardReason:     noRECQueryGuardReason,
+			QueryGuardReason: noRECQueryGuardReason,
 		},
 		SkipReasonOverrides: map[string]string{
 			"constraint:aggregate":              "norec:guardrail",
@@ -53,6 +54,8 @@ func (o NoREC) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, _
 			"constraint:set_ops":                "norec:guardrail",
 			"constraint:limit_without_order_by": "norec:guardrail",
 			"constraint:predicate_subquery":     "norec:guardrail",
+			"constraint:nondeterministic_view":  "norec:nondeterministic_view",
+			"constraint:nondeterministic_cte":   "norec:nondeterministic_cte",
 		},
 	}
 	query, details := buildQueryWithSpec(gen, spec)
@@ -60,16 +63,8 @@ func (o NoREC) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, _
 		return Result{OK: true, Oracle: o.Name(), Details: details}
 	}
 	optimized := query.SQLString()
-	optimizedCount := fmt.Sprintf("SELECT COUNT(*) FROM (%s) q", optimized)
-
 	unoptimized := buildWith(query) + buildNoRECQuery(query)
-	orderLimit := buildOrderLimit(query)
-	unoptimizedCount := buildWith(query) + fmt.Sprintf(
-		"SELECT IFNULL(SUM(b),0) FROM (SELECT CASE WHEN %s THEN 1 ELSE 0 END AS b FROM %s%s) q",
-		buildExpr(query.Where),
-		buildFrom(query),
-		orderLimit,
-	)
+	optimizedCount, unoptimizedCount := noRECCountSQL(query)
 	features := sqlSubqueryFeaturesFromQuery(query)
 	var observed map[string]db.SQLSubqueryFeatures
 	recordObservedExecSQLs(exec, features, optimizedCount, unoptimizedCount)
@@ -82,7 +77,8 @@ func (o NoREC) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, _
 		if code != 0 {
 			details["error_code"] = int(code)
 		}
-		return Result{OK: true, Oracle: o.Name(), SQL: []string{optimizedCount, unoptimizedCount}, SQLFeatures: observed, Err: err, Details: details}
+		steps := []sqlstep.Step{queryStep(sqlstep.RoleFailing, optimizedCount)}
+		return Result{OK: true, Oracle: o.Name(), Steps: steps, SQLFeatures: observed, Err: err, Details: details}
 	}
 	unoptCount, err := exec.QueryCount(ctx, unoptimizedCount)
 	if err != nil {
@@ -91,7 +87,11 @@ func (o NoREC) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, _
 		if code != 0 {
 			details["error_code"] = int(code)
 		}
-		return Result{OK: true, Oracle: o.Name(), SQL: []string{optimizedCount, unoptimizedCount}, SQLFeatures: observed, Err: err, Details: details}
+		steps := []sqlstep.Step{
+			queryStep(sqlstep.RoleExpected, optimizedCount),
+			queryStep(sqlstep.RoleFailing, unoptimizedCount),
+		}
+		return Result{OK: true, Oracle: o.Name(), Steps: steps, SQLFeatures: observed, Err: err, Details: details}
 	}
 	if optCount != unoptCount {
 		unoptimizedExplain, _ := explainSQL(ctx, exec, unoptimizedCount)
@@ -99,7 +99,7 @@ func (o NoREC) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, _
 		return Result{
 			OK:          false,
 			Oracle:      o.Name(),
-			SQL:         []string{optimized, unoptimized},
+			Steps:       comparisonSteps(optimized, unoptimized),
 			SQLFeatures: observed,
 			Expected:    fmt.Sprintf("optimized count=%d", optCount),
 			Actual:      fmt.Sprintf("unoptimized count=%d", unoptCount),
@@ -115,7 +115,20 @@ func (o NoREC) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, _
 			},
 		}
 	}
-	return Result{OK: true, Oracle: o.Name(), SQL: []string{optimized, unoptimized}, SQLFeatures: observed}
+	return Result{OK: true, Oracle: o.Name(), Steps: comparisonSteps(optimized, unoptimized), SQLFeatures: observed}
+}
+
+// noRECCountSQL returns the optimized and unoptimized count statements. Both
+// keep the WITH clause of query, so CTEs resolve the same in each form.
+func noRECCountSQL(query *generator.SelectQuery) (string, string) {
+	optimizedCount := fmt.Sprintf("SELECT COUNT(*) FROM (%s) q", query.SQLString())
+	unoptimizedCount := buildWith(query) + fmt.Sprintf(
+		"SELECT IFNULL(SUM(b),0) FROM (SELECT CASE WHEN %s THEN 1 ELSE 0 END AS b FROM %s%s) q",
+		buildExpr(query.Where),
+		buildFrom(query),
+		buildOrderLimit(query),
+	)
+	return optimizedCount, unoptimizedCount
 }
 
 func buildNoRECQuery(query *generator.SelectQuery) string {
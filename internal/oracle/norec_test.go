@@ -9,6 +9,7 @@ import (
 	"shiro/internal/db"
 	"shiro/internal/generator"
 	"shiro/internal/schema"
+	"shiro/internal/sqlstep"
 )
 
 func TestNoRECNoTablesSkip(t *testing.T) {
@@ -27,6 +28,46 @@ func TestNoRECNoTablesSkip(t *testing.T) {
 	}
 }
 
+func TestNoRECProfileAllowsCTE(t *testing.T) {
+	constraints := compatMatrix["norec"]
+	var spec generator.SelectQueryConstraints
+	constraints.apply(&spec)
+	applyProfileToSpec(&spec, ProfileByName("NoREC"))
+	if spec.DisallowCTE {
+		t.Fatalf("expected NoREC to accept CTE queries")
+	}
+	if !spec.RequireDeterministic {
+		t.Fatalf("expected NoREC to require deterministic queries")
+	}
+}
+
+func TestNoRECCountSQLKeepsWith(t *testing.T) {
+	query := &generator.SelectQuery{
+		With: []generator.CTE{{
+			Name: "cte_0",
+			Query: &generator.SelectQuery{
+				Items: []generator.SelectItem{{Expr: generator.ColumnExpr{Ref: generator.ColumnRef{Table: "v0", Name: "c0"}}, Alias: "c0"}},
+				From:  generator.FromClause{BaseTable: "v0"},
+			},
+		}},
+		Items: []generator.SelectItem{{Expr: generator.ColumnExpr{Ref: generator.ColumnRef{Table: "cte_0", Name: "c0"}}, Alias: "c0"}},
+		From:  generator.FromClause{BaseTable: "cte_0"},
+		Where: generator.BinaryExpr{
+			Left:  generator.ColumnExpr{Ref: generator.ColumnRef{Table: "cte_0", Name: "c0"}},
+			Op:    ">",
+			Right: generator.LiteralExpr{Value: 1},
+		},
+	}
+	optimized, unoptimized := noRECCountSQL(query)
+	with := "WITH cte_0 AS (SELECT v0.c0 AS c0 FROM v0) "
+	if want := "SELECT COUNT(*) FROM (" + with + "SELECT cte_0.c0 AS c0 FROM cte_0 WHERE (cte_0.c0 > 1)) q"; optimized != want {
+		t.Fatalf("unexpected optimized count:\n got %s\nwant %s", optimized, want)
+	}
+	if want := with + "SELECT IFNULL(SUM(b),0) FROM (SELECT CASE WHEN (cte_0.c0 > 1) THEN 1 ELSE 0 END AS b FROM cte_0) q"; unoptimized != want {
+		t.Fatalf("unexpected unoptimized count:\n got %s\nwant %s", unoptimized, want)
+	}
+}
+
 func TestNoRECQueryGuardReasonRejectsSetOps(t *testing.T) {
 	query := &generator.SelectQuery{
 		Items: []generator.SelectItem{{Expr: generator.LiteralExpr{Value: 1}, Alias: "c0"}},
@@ -56,6 +97,9 @@ func TestNoRECQueryGuardReasonRejectsSetOps(t *testing.T) {
 
 func TestNoRECErrorReturnsSQLFeaturesForCountQueries(t *testing.T) {
 	gen := newProfileTestGenerator(t)
+	// Keep this seed's first query free of CTEs; TestNoRECCountSQLKeepsWith
+	// covers the WITH clause.
+	gen.Config.Features.CTE = false
 	expectedErr := errors.New("observe failure")
 	exec := &db.DB{
 		Validate: func(string) error {
@@ -67,10 +111,10 @@ func TestNoRECErrorReturnsSQLFeaturesForCountQueries(t *testing.T) {
 	if res.Err == nil {
 		t.Fatalf("expected validation error result")
 	}
-	if len(res.SQL) != 2 {
-		t.Fatalf("expected count SQL pair, got %v", res.SQL)
+	if len(res.Steps) != 1 || res.Steps[0].Role != sqlstep.RoleFailing {
+		t.Fatalf("expected the failing count query, got %+v", res.Steps)
 	}
-	for _, sqlText := range res.SQL {
+	for _, sqlText := range res.SQL() {
 		if _, ok := res.SQLFeatures[sqlText]; !ok {
 			t.Fatalf("missing SQL features for returned SQL %q", sqlText)
 		}
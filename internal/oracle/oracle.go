@@ -3,16 +3,18 @@ package oracle
 import (
 	"context"
 
+	"shiro/internal/config"
 	"shiro/internal/db"
 	"shiro/internal/generator"
 	"shiro/internal/schema"
+	"shiro/internal/sqlstep"
 )
 
 // Result captures an oracle execution outcome.
 type Result struct {
 	OK          bool
 	Oracle      string
-	SQL         []string
+	Steps       []sqlstep.Step
 	SQLFeatures map[string]db.SQLSubqueryFeatures
 	Expected    string
 	Actual      string
@@ -35,3 +37,37 @@ type Oracle interface {
 	Name() string
 	Run(ctx context.Context, exec *db.DB, gen *generator.Generator, state *schema.State) Result
 }
+
+// All returns one instance of every oracle, in the order the runner registers
+// them as bandit arms.
+func All(cfg config.Config) []Oracle {
+	return []Oracle{
+		NoREC{},
+		TLP{},
+		EET{},
+		NewDQP(),
+		PQS{},
+		CERT{MinBaseRows: cfg.Oracles.CertMinBaseRows},
+		CODDTest{},
+		DQE{},
+		Impo{},
+		GroundTruth{},
+		TxnRYW{},
+		CTEInline{},
+		FKCascade{},
+		Savepoint{},
+		TiFlashOnly{},
+		AutoID{},
+		ResultType{},
+		NewPlanCache(cfg),
+		BatchDML{},
+		DecimalArith{},
+		FullGroupBy{},
+		LargeRow{},
+		FullJoin{},
+		NewPrivilege(cfg),
+		NewCursorFetch(cfg),
+		InList{},
+		PartitionRange{},
+	}
+}
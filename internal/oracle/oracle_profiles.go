@@ -134,7 +134,10 @@ func JoinTypePtr(v generator.JoinType) *generator.JoinType {
 	return &v
 }
 
-// Profiles defines the default per-oracle capability profiles.
+// Profiles defines the default per-oracle capability profiles. Oracles in the
+// compatibility matrix take their SQL features, subquery allowance, and
+// predicate mode from their matrix row, so their profiles only carry what
+// the matrix does not cover.
 var Profiles = map[string]Profile{
 	"GroundTruth": {
 		Features: FeatureOverrides{
@@ -166,25 +169,11 @@ var Profiles = map[string]Profile{
 	},
 	"CODDTest": {
 		Features: FeatureOverrides{
-			CTE:               BoolPtr(false),
 			Views:             BoolPtr(false),
 			DerivedTables:     BoolPtr(false),
-			SetOperations:     BoolPtr(false),
 			NaturalJoins:      BoolPtr(false),
 			FullJoinEmulation: BoolPtr(false),
-			Aggregates:        BoolPtr(false),
-			GroupBy:           BoolPtr(false),
-			Having:            BoolPtr(false),
-			Distinct:          BoolPtr(false),
-			OrderBy:           BoolPtr(false),
-			Limit:             BoolPtr(false),
-			WindowFuncs:       BoolPtr(false),
-			Subqueries:        BoolPtr(false),
-			NotExists:         BoolPtr(false),
-			NotIn:             BoolPtr(false),
 		},
-		AllowSubquery:          BoolPtr(false),
-		PredicateMode:          PredicateModePtr(generator.PredicateModeSimpleColumns),
 		MinJoinTables:          IntPtr(1),
 		DisallowScalarSubquery: BoolPtr(true),
 	},
@@ -197,51 +186,23 @@ var Profiles = map[string]Profile{
 	},
 	"NoREC": {
 		Features: FeatureOverrides{
-			CTE:           BoolPtr(false),
-			Aggregates:    BoolPtr(false),
-			GroupBy:       BoolPtr(false),
-			Having:        BoolPtr(false),
-			Distinct:      BoolPtr(false),
-			OrderBy:       BoolPtr(false),
-			Limit:         BoolPtr(false),
-			SetOperations: BoolPtr(false),
-			WindowFuncs:   BoolPtr(false),
-			NaturalJoins:  BoolPtr(false),
+			NaturalJoins: BoolPtr(false),
 		},
 		AllowSubquery: BoolPtr(true),
-		PredicateMode: PredicateModePtr(generator.PredicateModeSimple),
 	},
 	"TLP": {
 		Features: FeatureOverrides{
-			CTE:          BoolPtr(false),
-			Aggregates:   BoolPtr(false),
-			GroupBy:      BoolPtr(false),
-			Having:       BoolPtr(false),
-			Distinct:     BoolPtr(false),
-			OrderBy:      BoolPtr(false),
-			Limit:        BoolPtr(false),
-			WindowFuncs:  BoolPtr(false),
 			NaturalJoins: BoolPtr(false),
 		},
 		AllowSubquery: BoolPtr(true),
-		PredicateMode: PredicateModePtr(generator.PredicateModeSimpleColumns),
 		JoinOnPolicy:  StringPtr("complex"),
 	},
 	"DQP": {
 		Features: FeatureOverrides{
-			CTE:           BoolPtr(false),
 			DerivedTables: BoolPtr(false),
-			SetOperations: BoolPtr(false),
 			NaturalJoins:  BoolPtr(false),
-			Aggregates:    BoolPtr(false),
-			GroupBy:       BoolPtr(false),
-			Having:        BoolPtr(false),
-			Distinct:      BoolPtr(false),
-			Limit:         BoolPtr(false),
-			WindowFuncs:   BoolPtr(false),
 		},
 		AllowSubquery: BoolPtr(true),
-		PredicateMode: PredicateModePtr(generator.PredicateModeSimpleColumns),
 		MinJoinTables: IntPtr(2),
 		JoinOnPolicy:  StringPtr("simple"),
 	},
@@ -274,11 +235,33 @@ var Profiles = map[string]Profile{
 			GroupByCube:         BoolPtr(false),
 			GroupByGroupingSets: BoolPtr(false),
 		},
-		PredicateMode: PredicateModePtr(generator.PredicateModeSimple),
 	},
 	"EET": {
 		Features: FeatureOverrides{
-			SetOperations:       BoolPtr(false),
+			GroupByCube:         BoolPtr(false),
+			GroupByGroupingSets: BoolPtr(false),
+		},
+		AllowSubquery: BoolPtr(true),
+	},
+	"TiFlashOnly": {
+		Features: FeatureOverrides{
+			Views:               BoolPtr(false),
+			DerivedTables:       BoolPtr(false),
+			GroupByCube:         BoolPtr(false),
+			GroupByGroupingSets: BoolPtr(false),
+		},
+		DisallowScalarSubquery: BoolPtr(true),
+	},
+	"FullJoin": {
+		Features: FeatureOverrides{
+			FullJoinEmulation: BoolPtr(false),
+		},
+		MinJoinTables: IntPtr(2),
+		MaxJoinTables: IntPtr(2),
+	},
+	"ResultType": {
+		Features: FeatureOverrides{
+			NaturalJoins:        BoolPtr(false),
 			GroupByCube:         BoolPtr(false),
 			GroupByGroupingSets: BoolPtr(false),
 		},
@@ -0,0 +1,409 @@
+package oracle
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"shiro/internal/db"
+	"shiro/internal/generator"
+	"shiro/internal/schema"
+	"shiro/internal/sqlstep"
+	"shiro/internal/util"
+)
+
+const (
+	partitionRangeTable      = "shiro_partition_range"
+	partitionRangeMinParts   = 3
+	partitionRangeExtraParts = 6
+	partitionRangeRandomRows = 24
+	partitionRangePredicates = 3
+	partitionRangeNullProb   = 10
+	partitionRangeMaxValProb = 50
+	partitionRangeIndexProb  = 50
+
+	partitionRangeSchemeColumns  = "range_columns"
+	partitionRangeSchemeToDays   = "to_days"
+	partitionRangeSchemeYear     = "year"
+	partitionRangeSchemeInterval = "interval"
+
+	partitionRangeDateLayout     = "2006-01-02"
+	partitionRangeDatetimeLayout = "2006-01-02 15:04:05"
+)
+
+var partitionRangeSchemes = []string{
+	partitionRangeSchemeColumns,
+	partitionRangeSchemeToDays,
+	partitionRangeSchemeYear,
+	partitionRangeSchemeInterval,
+}
+
+// partitionRangePruneModes are the tidb_partition_prune_mode values a run
+// picks from.
+var partitionRangePruneModes = []string{"dynamic", "static"}
+
+// PartitionRange implements the date-range partition oracle.
+//
+// The random schema only partitions by HASH(id), so range pruning over
+// temporal columns is never exercised. Each run creates a scratch table
+// RANGE-partitioned on a DATE or DATETIME column, by RANGE COLUMNS, by
+// TO_DAYS, by YEAR, or with INTERVAL partitions, and fills it with values on,
+// just below, and just above the partition bounds. For range predicates that
+// cross those bounds, the COUNT(*) the server computes after pruning must
+// equal the sum of the counts read from each partition by explicit
+// PARTITION() selection.
+//
+// Example:
+//
+//	CREATE TABLE shiro_partition_range (id INT NOT NULL, d DATE) PARTITION BY RANGE (TO_DAYS(d)) (PARTITION p0 VALUES LESS THAN (TO_DAYS('2021-03-01')), ...)
+//	SELECT COUNT(*) FROM shiro_partition_range WHERE (d >= '2021-02-28' AND d < '2021-05-01')
+//	SELECT 0, COUNT(*) FROM shiro_partition_range PARTITION (`p0`) WHERE (d >= '2021-02-28' AND d < '2021-05-01') UNION ALL ...
+//	-- the first count must equal the sum of the per-partition counts
+type PartitionRange struct{}
+
+// Name returns the oracle identifier.
+func (o PartitionRange) Name() string { return "PartitionRange" }
+
+// partitionRangeLayout is the partitioning of one scratch table. Partition i
+// holds values below bounds[i]; with maxValue a last partition holds the
+// rest.
+type partitionRangeLayout struct {
+	scheme   string
+	datetime bool
+	// unit and step give the distance between bounds, as in INTERVAL
+	// (step unit).
+	unit     string
+	step     int
+	bounds   []time.Time
+	maxValue bool
+}
+
+// Run creates the scratch table and compares pruned and per-partition counts
+// for a few predicates. The table is dropped and the prune mode reset
+// afterwards.
+func (o PartitionRange) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, _ *schema.State) Result {
+	layout := partitionRangePickLayout(gen.Rand)
+	index := util.Chance(gen.Rand, partitionRangeIndexProb)
+	pruneMode := partitionRangePruneModes[gen.Rand.Intn(len(partitionRangePruneModes))]
+	details := map[string]any{
+		"partition_range_scheme":      layout.scheme,
+		"partition_range_column_type": layout.columnType(),
+		"partition_range_index":       index,
+	}
+	metrics := map[string]int64{"partition_range_scheme_" + layout.scheme + "_total": 1}
+
+	conn, err := exec.Conn(ctx)
+	if err != nil {
+		return Result{OK: true, Oracle: o.Name(), Err: err}
+	}
+	defer util.CloseWithErr(conn, "partition_range conn")
+
+	var steps []sqlstep.Step
+	dropSQL := "DROP TABLE IF EXISTS " + partitionRangeTable
+	defer func() {
+		cleanup := context.Background()
+		_, _ = conn.ExecContext(cleanup, dropSQL)
+		_, _ = conn.ExecContext(cleanup, "SET SESSION tidb_partition_prune_mode = DEFAULT")
+	}()
+	setup := []string{dropSQL, layout.createSQL(index), layout.insertSQL(partitionRangePickValues(gen.Rand, layout))}
+	for _, stmt := range setup {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return sqlErrorResult(o.Name(), "partition_range", failedSteps(steps, sqlstep.KindSetup, stmt), metrics, details, err, stmt)
+		}
+		steps = append(steps, sqlstep.New(sqlstep.KindSetup, "", stmt))
+	}
+	modeSQL := fmt.Sprintf("SET SESSION tidb_partition_prune_mode = '%s'", pruneMode)
+	if _, err := conn.ExecContext(ctx, modeSQL); err != nil {
+		if !isUnknownSystemVariable(err) {
+			return sqlErrorResult(o.Name(), "partition_range", failedSteps(steps, sqlstep.KindSetVar, modeSQL), metrics, details, err, modeSQL)
+		}
+		pruneMode = "default"
+	} else {
+		steps = append(steps, sqlstep.New(sqlstep.KindSetVar, "", modeSQL))
+	}
+	details["partition_range_prune_mode"] = pruneMode
+
+	namesSQL := fmt.Sprintf("SELECT PARTITION_NAME FROM INFORMATION_SCHEMA.PARTITIONS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = '%s' ORDER BY PARTITION_ORDINAL_POSITION", partitionRangeTable)
+	nameRows, err := queryRowStrings(ctx, conn, exec.Guard, namesSQL)
+	if err != nil {
+		return sqlErrorResult(o.Name(), "partition_range", failedSteps(steps, sqlstep.KindVerify, namesSQL), metrics, details, err, namesSQL)
+	}
+	names := make([]string, 0, len(nameRows))
+	for _, row := range nameRows {
+		if row[0] != sqlNullValue {
+			names = append(names, row[0])
+		}
+	}
+	if len(names) < 2 {
+		// The server created a plain table, as with
+		// tidb_enable_table_partition off.
+		details["skip_reason"] = "partition_range:not_partitioned"
+		return Result{OK: true, Oracle: o.Name(), Steps: steps, Details: details, Metrics: metrics}
+	}
+	details["partition_range_partitions"] = len(names)
+
+	for i := 0; i < partitionRangePredicates; i++ {
+		pred := layout.pickPredicate(gen.Rand)
+		totalSQL := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", partitionRangeTable, pred)
+		var total int64
+		if err := conn.QueryRowContext(ctx, totalSQL).Scan(&total); err != nil {
+			return sqlErrorResult(o.Name(), "partition_range", failedSteps(steps, sqlstep.KindQuery, totalSQL), metrics, details, err, totalSQL)
+		}
+		perSQL := partitionRangePerPartitionSQL(names, pred)
+		perRows, err := queryRowStrings(ctx, conn, exec.Guard, perSQL)
+		if err != nil {
+			return sqlErrorResult(o.Name(), "partition_range", failedSteps(steps, sqlstep.KindQuery, perSQL), metrics, details, err, perSQL)
+		}
+		counts, sum, err := partitionRangeCounts(names, perRows)
+		if err != nil {
+			return sqlErrorResult(o.Name(), "partition_range", failedSteps(steps, sqlstep.KindQuery, perSQL), metrics, details, err, perSQL)
+		}
+		metrics["partition_range_checks_total"]++
+		if total == sum {
+			continue
+		}
+		steps = append(steps,
+			sqlstep.New(sqlstep.KindQuery, sqlstep.RoleExpected, perSQL),
+			sqlstep.New(sqlstep.KindQuery, sqlstep.RoleActual, totalSQL),
+		)
+		details["partition_range_predicate"] = pred
+		details["partition_range_counts"] = counts
+		return Result{
+			OK:       false,
+			Oracle:   o.Name(),
+			Steps:    steps,
+			Expected: fmt.Sprintf("cnt=%d", sum),
+			Actual:   fmt.Sprintf("cnt=%d", total),
+			Details:  details,
+			Metrics:  metrics,
+		}
+	}
+	return Result{OK: true, Oracle: o.Name(), Steps: steps, Details: details, Metrics: metrics}
+}
+
+// partitionRangePickLayout picks a scheme, a column type, and ascending
+// bounds. YEAR partitions are a year apart and start on January 1; the other
+// schemes space their bounds by days, months, or a year.
+func partitionRangePickLayout(r *rand.Rand) partitionRangeLayout {
+	layout := partitionRangeLayout{
+		scheme:   partitionRangeSchemes[r.Intn(len(partitionRangeSchemes))],
+		datetime: r.Intn(2) == 0,
+		maxValue: util.Chance(r, partitionRangeMaxValProb),
+	}
+	start := time.Date(2000+r.Intn(30), time.Month(1+r.Intn(12)), 1+r.Intn(28), 0, 0, 0, 0, time.UTC)
+	switch {
+	case layout.scheme == partitionRangeSchemeYear:
+		layout.unit, layout.step = "YEAR", 1
+		start = time.Date(start.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	case r.Intn(3) == 0:
+		layout.unit, layout.step = "DAY", 1+r.Intn(10)
+	case r.Intn(2) == 0:
+		layout.unit, layout.step = "MONTH", 1+r.Intn(3)
+	default:
+		layout.unit, layout.step = "YEAR", 1
+	}
+	parts := partitionRangeMinParts + r.Intn(partitionRangeExtraParts)
+	for i := 0; i < parts; i++ {
+		layout.bounds = append(layout.bounds, layout.add(start, i))
+	}
+	return layout
+}
+
+// add returns t moved by n partition widths.
+func (l partitionRangeLayout) add(t time.Time, n int) time.Time {
+	switch l.unit {
+	case "DAY":
+		return t.AddDate(0, 0, n*l.step)
+	case "MONTH":
+		return t.AddDate(0, n*l.step, 0)
+	default:
+		return t.AddDate(n*l.step, 0, 0)
+	}
+}
+
+// tick is the smallest distance between two values of the column.
+func (l partitionRangeLayout) tick(t time.Time, n int) time.Time {
+	if l.datetime {
+		return t.Add(time.Duration(n) * time.Second)
+	}
+	return t.AddDate(0, 0, n)
+}
+
+func (l partitionRangeLayout) columnType() string {
+	if l.datetime {
+		return "DATETIME"
+	}
+	return "DATE"
+}
+
+func (l partitionRangeLayout) literal(t time.Time) string {
+	if l.datetime {
+		return "'" + t.Format(partitionRangeDatetimeLayout) + "'"
+	}
+	return "'" + t.Format(partitionRangeDateLayout) + "'"
+}
+
+// bound renders the VALUES LESS THAN operand of bounds[i].
+func (l partitionRangeLayout) bound(i int) string {
+	switch l.scheme {
+	case partitionRangeSchemeToDays:
+		return "TO_DAYS(" + l.literal(l.bounds[i]) + ")"
+	case partitionRangeSchemeYear:
+		return strconv.Itoa(l.bounds[i].Year())
+	default:
+		return l.literal(l.bounds[i])
+	}
+}
+
+func (l partitionRangeLayout) createSQL(index bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "CREATE TABLE %s (id INT NOT NULL, d %s", partitionRangeTable, l.columnType())
+	if index {
+		b.WriteString(", KEY idx_d (d)")
+	}
+	b.WriteString(") PARTITION BY ")
+	if l.scheme == partitionRangeSchemeInterval {
+		fmt.Fprintf(&b, "RANGE COLUMNS (d) INTERVAL (%d %s) FIRST PARTITION LESS THAN (%s) LAST PARTITION LESS THAN (%s)",
+			l.step, l.unit, l.bound(0), l.bound(len(l.bounds)-1))
+		if l.maxValue {
+			b.WriteString(" MAXVALUE PARTITION")
+		}
+		return b.String()
+	}
+	switch l.scheme {
+	case partitionRangeSchemeToDays:
+		b.WriteString("RANGE (TO_DAYS(d)) (")
+	case partitionRangeSchemeYear:
+		b.WriteString("RANGE (YEAR(d)) (")
+	default:
+		b.WriteString("RANGE COLUMNS (d) (")
+	}
+	for i := range l.bounds {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "PARTITION p%d VALUES LESS THAN (%s)", i, l.bound(i))
+	}
+	if l.maxValue {
+		fmt.Fprintf(&b, ", PARTITION p%d VALUES LESS THAN (MAXVALUE)", len(l.bounds))
+	}
+	b.WriteString(")")
+	return b.String()
+}
+
+// partitionRangePickValues returns the column values to insert as SQL
+// literals: every bound with its neighbours one tick away, random values
+// across and below the partitions, and some NULLs. Without a MAXVALUE
+// partition, values stay below the last bound so every insert succeeds.
+func partitionRangePickValues(r *rand.Rand, l partitionRangeLayout) []string {
+	last := l.bounds[len(l.bounds)-1]
+	fits := func(t time.Time) bool { return l.maxValue || t.Before(last) }
+	var values []string
+	for _, b := range l.bounds {
+		for _, t := range []time.Time{l.tick(b, -1), b, l.tick(b, 1)} {
+			if fits(t) {
+				values = append(values, l.literal(t))
+			}
+		}
+	}
+	lo := l.add(l.bounds[0], -1)
+	hi := last
+	if l.maxValue {
+		hi = l.add(last, 1)
+	}
+	span := int64(hi.Sub(lo) / time.Second)
+	for i := 0; i < partitionRangeRandomRows; i++ {
+		if util.Chance(r, partitionRangeNullProb) {
+			values = append(values, "NULL")
+			continue
+		}
+		t := lo.Add(time.Duration(r.Int63n(span)) * time.Second)
+		if !l.datetime {
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		}
+		values = append(values, l.literal(t))
+	}
+	r.Shuffle(len(values), func(i, j int) { values[i], values[j] = values[j], values[i] })
+	return values
+}
+
+func (l partitionRangeLayout) insertSQL(values []string) string {
+	rows := make([]string, 0, len(values))
+	for i, v := range values {
+		rows = append(rows, fmt.Sprintf("(%d, %s)", i+1, v))
+	}
+	return fmt.Sprintf("INSERT INTO %s (id, d) VALUES %s", partitionRangeTable, strings.Join(rows, ", "))
+}
+
+// pickPoint returns a bound, or a value one tick off it, so predicates start
+// and end right at partition edges.
+func (l partitionRangeLayout) pickPoint(r *rand.Rand) time.Time {
+	return l.tick(l.bounds[r.Intn(len(l.bounds))], r.Intn(3)-1)
+}
+
+// pickPredicate returns a range predicate over d whose ends sit on or next to
+// partition bounds, so it usually spans several partitions.
+func (l partitionRangeLayout) pickPredicate(r *rand.Rand) string {
+	lo, hi := l.pickPoint(r), l.pickPoint(r)
+	if hi.Before(lo) {
+		lo, hi = hi, lo
+	}
+	loSQL, hiSQL := l.literal(lo), l.literal(hi)
+	switch r.Intn(7) {
+	case 0:
+		return fmt.Sprintf("(d >= %s AND d < %s)", loSQL, hiSQL)
+	case 1:
+		return fmt.Sprintf("(d > %s AND d <= %s)", loSQL, hiSQL)
+	case 2:
+		return fmt.Sprintf("(d BETWEEN %s AND %s)", loSQL, hiSQL)
+	case 3:
+		return fmt.Sprintf("(d < %s)", hiSQL)
+	case 4:
+		return fmt.Sprintf("(d IS NULL OR d >= %s)", loSQL)
+	case 5:
+		return fmt.Sprintf("(d IN (%s, %s, %s))", loSQL, l.literal(l.pickPoint(r)), hiSQL)
+	default:
+		return fmt.Sprintf("(NOT (d >= %s AND d < %s))", loSQL, hiSQL)
+	}
+}
+
+// partitionRangePerPartitionSQL counts the rows matching pred in each
+// partition, labelled by its position in names.
+func partitionRangePerPartitionSQL(names []string, pred string) string {
+	parts := make([]string, 0, len(names))
+	for i, name := range names {
+		parts = append(parts, fmt.Sprintf("SELECT %d, COUNT(*) FROM %s PARTITION (`%s`) WHERE %s",
+			i, partitionRangeTable, strings.ReplaceAll(name, "`", "``"), pred))
+	}
+	return strings.Join(parts, " UNION ALL ")
+}
+
+// partitionRangeCounts sums the per-partition counts and renders them as
+// "name=count" pairs in partition order.
+func partitionRangeCounts(names []string, rows [][]string) (string, int64, error) {
+	counts := make([]int64, len(names))
+	var sum int64
+	for _, row := range rows {
+		if len(row) != 2 {
+			return "", 0, fmt.Errorf("partition_range: unexpected per-partition row %v", row)
+		}
+		idx, err := strconv.Atoi(row[0])
+		if err != nil || idx < 0 || idx >= len(names) {
+			return "", 0, fmt.Errorf("partition_range: unexpected partition label %q", row[0])
+		}
+		n, err := strconv.ParseInt(row[1], 10, 64)
+		if err != nil {
+			return "", 0, fmt.Errorf("partition_range: unexpected count %q", row[1])
+		}
+		counts[idx] = n
+		sum += n
+	}
+	pairs := make([]string, 0, len(names))
+	for i, name := range names {
+		pairs = append(pairs, fmt.Sprintf("%s=%d", name, counts[i]))
+	}
+	return strings.Join(pairs, ","), sum, nil
+}
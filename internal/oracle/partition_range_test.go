@@ -0,0 +1,104 @@
+package oracle
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPartitionRangeCreateSQL(t *testing.T) {
+	bounds := []time.Time{
+		time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}
+	cases := []struct {
+		layout partitionRangeLayout
+		index  bool
+		want   string
+	}{
+		{
+			layout: partitionRangeLayout{scheme: partitionRangeSchemeColumns, unit: "YEAR", step: 1, bounds: bounds},
+			want:   "CREATE TABLE shiro_partition_range (id INT NOT NULL, d DATE) PARTITION BY RANGE COLUMNS (d) (PARTITION p0 VALUES LESS THAN ('2021-01-01'), PARTITION p1 VALUES LESS THAN ('2022-01-01'), PARTITION p2 VALUES LESS THAN ('2023-01-01'))",
+		},
+		{
+			layout: partitionRangeLayout{scheme: partitionRangeSchemeToDays, datetime: true, unit: "YEAR", step: 1, bounds: bounds[:2], maxValue: true},
+			index:  true,
+			want:   "CREATE TABLE shiro_partition_range (id INT NOT NULL, d DATETIME, KEY idx_d (d)) PARTITION BY RANGE (TO_DAYS(d)) (PARTITION p0 VALUES LESS THAN (TO_DAYS('2021-01-01 00:00:00')), PARTITION p1 VALUES LESS THAN (TO_DAYS('2022-01-01 00:00:00')), PARTITION p2 VALUES LESS THAN (MAXVALUE))",
+		},
+		{
+			layout: partitionRangeLayout{scheme: partitionRangeSchemeYear, unit: "YEAR", step: 1, bounds: bounds[:2]},
+			want:   "CREATE TABLE shiro_partition_range (id INT NOT NULL, d DATE) PARTITION BY RANGE (YEAR(d)) (PARTITION p0 VALUES LESS THAN (2021), PARTITION p1 VALUES LESS THAN (2022))",
+		},
+		{
+			layout: partitionRangeLayout{scheme: partitionRangeSchemeInterval, unit: "YEAR", step: 1, bounds: bounds, maxValue: true},
+			want:   "CREATE TABLE shiro_partition_range (id INT NOT NULL, d DATE) PARTITION BY RANGE COLUMNS (d) INTERVAL (1 YEAR) FIRST PARTITION LESS THAN ('2021-01-01') LAST PARTITION LESS THAN ('2023-01-01') MAXVALUE PARTITION",
+		},
+	}
+	for _, tc := range cases {
+		if got := tc.layout.createSQL(tc.index); got != tc.want {
+			t.Fatalf("%s:\n got %s\nwant %s", tc.layout.scheme, got, tc.want)
+		}
+	}
+}
+
+func TestPartitionRangePickLayoutAndValues(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	for i := 0; i < 200; i++ {
+		layout := partitionRangePickLayout(r)
+		if n := len(layout.bounds); n < partitionRangeMinParts || n >= partitionRangeMinParts+partitionRangeExtraParts {
+			t.Fatalf("unexpected partition count %d", n)
+		}
+		for j := 1; j < len(layout.bounds); j++ {
+			if !layout.bounds[j-1].Before(layout.bounds[j]) {
+				t.Fatalf("bounds not ascending: %v", layout.bounds)
+			}
+		}
+		if layout.scheme == partitionRangeSchemeYear {
+			for _, b := range layout.bounds {
+				if b.Month() != time.January || b.Day() != 1 {
+					t.Fatalf("year bound not on January 1: %v", b)
+				}
+			}
+		}
+		last := layout.literal(layout.bounds[len(layout.bounds)-1])
+		values := partitionRangePickValues(r, layout)
+		if len(values) < len(layout.bounds) {
+			t.Fatalf("too few values: %d", len(values))
+		}
+		for _, v := range values {
+			if v == "NULL" {
+				continue
+			}
+			// Literals of one type compare as strings in date order.
+			if !layout.maxValue && v >= last {
+				t.Fatalf("value %s does not fit below the last bound %s", v, last)
+			}
+		}
+		if pred := layout.pickPredicate(r); !strings.Contains(pred, "d ") {
+			t.Fatalf("unexpected predicate %q", pred)
+		}
+	}
+}
+
+func TestPartitionRangeCounts(t *testing.T) {
+	names := []string{"p0", "P_LT_2024-02-01", "pmax"}
+	query := partitionRangePerPartitionSQL(names, "(d < '2024-02-01')")
+	want := "SELECT 0, COUNT(*) FROM shiro_partition_range PARTITION (`p0`) WHERE (d < '2024-02-01') UNION ALL " +
+		"SELECT 1, COUNT(*) FROM shiro_partition_range PARTITION (`P_LT_2024-02-01`) WHERE (d < '2024-02-01') UNION ALL " +
+		"SELECT 2, COUNT(*) FROM shiro_partition_range PARTITION (`pmax`) WHERE (d < '2024-02-01')"
+	if query != want {
+		t.Fatalf("unexpected per-partition sql:\n got %s\nwant %s", query, want)
+	}
+	counts, sum, err := partitionRangeCounts(names, [][]string{{"2", "0"}, {"0", "3"}, {"1", "4"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sum != 7 || counts != "p0=3,P_LT_2024-02-01=4,pmax=0" {
+		t.Fatalf("unexpected counts %s sum %d", counts, sum)
+	}
+	if _, _, err := partitionRangeCounts(names, [][]string{{"3", "1"}}); err == nil {
+		t.Fatalf("expected an error for an unknown partition label")
+	}
+}
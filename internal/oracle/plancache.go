@@ -0,0 +1,735 @@
+package oracle
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"shiro/internal/config"
+	"shiro/internal/db"
+	"shiro/internal/generator"
+	"shiro/internal/schema"
+	"shiro/internal/sqlstep"
+	"shiro/internal/util"
+)
+
+const (
+	planCacheOnlyName = "PlanCacheOnly"
+	// planCacheOriginSampleLimit bounds the sample rows embedded in reports.
+	planCacheOriginSampleLimit = 10
+)
+
+// PlanCache implements the prepared plan cache oracle.
+//
+// It prepares a generated statement with placeholders on its own connection
+// and executes it with two argument sets. Each execution is compared with the
+// concrete statement that has the arguments inlined:
+//   - the first execution must match and must not come from the plan cache;
+//   - the second execution must match and must reuse the cached plan, unless
+//     SHOW WARNINGS says why the cache was skipped;
+//   - PREPARE / EXECUTE ... USING @p (the text protocol) must return the same
+//     result as the binary protocol.
+//
+// MPP is turned off on the connection to keep MPP plans out of the cache-hit
+// checks. Server errors outside the generator whitelist are reported, because
+// the prepared path must accept whatever the concrete statement accepts.
+//
+// Example:
+//
+//	SELECT c0 FROM t0 WHERE c0 > 3       -- concrete statement
+//	prepare  SELECT c0 FROM t0 WHERE c0 > ?
+//	execute  (3)                         -- must match, last_plan_from_cache=0
+//	execute  (7)                         -- must match c0 > 7, last_plan_from_cache=1
+type PlanCache struct {
+	// RoundScale rounds numeric values before rows are hashed; 0 keeps them
+	// as returned.
+	RoundScale int
+}
+
+// NewPlanCache returns the oracle with signature rounding from cfg. A negative
+// signature.plan_cache_round_scale falls back to signature.round_scale.
+func NewPlanCache(cfg config.Config) PlanCache {
+	scale := cfg.Signature.PlanCacheRoundScale
+	if scale < 0 {
+		scale = max(cfg.Signature.RoundScale, 0)
+	}
+	return PlanCache{RoundScale: scale}
+}
+
+// Name returns the oracle identifier.
+func (o PlanCache) Name() string { return "PlanCache" }
+
+// planCacheRun holds the prepared statement of one run and its connection.
+type planCacheRun struct {
+	name        string
+	exec        *db.DB
+	conn        *sql.Conn
+	connID      int64
+	stmt        *sql.Stmt
+	concreteSQL string
+	roundScale  int
+}
+
+// Run prepares one generated query and checks both executions.
+func (o PlanCache) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, _ *schema.State) Result {
+	pq := gen.GeneratePreparedQuery()
+	if pq.SQL == "" {
+		return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "plancache:no_query"}}
+	}
+	concreteSQL := materializeSQL(pq.SQL, pq.Args)
+	conn, connID, err := openPlanCacheConn(ctx, exec)
+	if err != nil {
+		return Result{OK: true, Oracle: o.Name(), Err: err}
+	}
+	defer util.CloseWithErr(conn, "plan cache conn")
+	concreteSig, err := rowSignatureOnConn(ctx, conn, exec.Guard, concreteSQL, o.RoundScale)
+	if err != nil {
+		return planCacheErrResult(o.Name(), err, []sqlstep.Step{queryStep(sqlstep.RoleFailing, concreteSQL)}, nil)
+	}
+	args2 := gen.GeneratePreparedArgsForQuery(pq.Args, pq.ArgTypes)
+	concreteSig2 := concreteSig
+	if !reflect.DeepEqual(args2, pq.Args) {
+		sql2 := materializeSQL(pq.SQL, args2)
+		concreteSig2, err = rowSignatureOnConn(ctx, conn, exec.Guard, sql2, o.RoundScale)
+		if err != nil {
+			return planCacheErrResult(o.Name(), err, []sqlstep.Step{queryStep(sqlstep.RoleFailing, sql2)}, nil)
+		}
+	}
+	stmt, err := conn.PrepareContext(ctx, pq.SQL)
+	if err != nil {
+		return planCacheErrResult(o.Name(), err, planCachePrepareFailedSteps(pq.SQL), nil)
+	}
+	defer util.CloseWithErr(stmt, "plan cache stmt")
+	run := &planCacheRun{
+		name:        o.Name(),
+		exec:        exec,
+		conn:        conn,
+		connID:      connID,
+		stmt:        stmt,
+		concreteSQL: concreteSQL,
+		roundScale:  o.RoundScale,
+	}
+	steps := planCacheSteps(concreteSQL, pq.SQL, pq.Args, args2, connID)
+	replay := map[string]any{"replay_sql": concreteSQL}
+
+	baseSig, _, _, err := run.execute(ctx, pq.Args)
+	if err != nil {
+		return planCacheErrResult(o.Name(), err, steps, replay)
+	}
+	// Capture hit info right after the first EXECUTE to avoid later SELECTs overwriting it.
+	hit1, err := lastPlanFromCache(ctx, conn)
+	if err != nil {
+		return Result{OK: true, Oracle: o.Name(), Steps: steps, Err: err, Details: replay}
+	}
+	warnings, err := run.warningsAfter(ctx, pq.Args)
+	if err != nil {
+		return Result{OK: true, Oracle: o.Name(), Steps: steps, Err: err, Details: replay}
+	}
+	if len(warnings) > 0 {
+		return Result{OK: true, Oracle: o.Name(), Steps: []sqlstep.Step{queryStep("", concreteSQL)}, Details: map[string]any{"skip_reason": "plancache:first_execute_warnings"}}
+	}
+	if baseSig != concreteSig {
+		return Result{
+			OK:       false,
+			Oracle:   o.Name(),
+			Steps:    steps,
+			Expected: formatPlanCacheSignature(concreteSig),
+			Actual:   formatPlanCacheSignature(baseSig),
+			Details: map[string]any{
+				"phase":      "first_execute",
+				"replay_sql": concreteSQL,
+			},
+		}
+	}
+
+	preparedSig, originCols, originRows, err := run.execute(ctx, args2)
+	if err != nil {
+		return planCacheErrResult(o.Name(), err, steps, replay)
+	}
+	origin := planCacheOrigin(preparedSig, originCols, originRows)
+	hit2, err := lastPlanFromCache(ctx, conn)
+	if err != nil {
+		return Result{OK: true, Oracle: o.Name(), Steps: steps, Err: err, Details: replay}
+	}
+	warnings, err = run.warningsAfter(ctx, args2)
+	if err != nil {
+		return Result{OK: true, Oracle: o.Name(), Steps: steps, Err: err, Details: replay}
+	}
+	// Leave the cached plan as the connection's last plan for EXPLAIN FOR CONNECTION.
+	if err := run.drain(ctx, args2); err != nil {
+		return Result{OK: true, Oracle: o.Name(), Steps: steps, Err: err, Details: replay}
+	}
+	hasWarnings := len(warnings) > 0
+	signatureMismatch := preparedSig != concreteSig2
+
+	if hit1 == 1 {
+		return Result{
+			OK:       false,
+			Oracle:   o.Name(),
+			Steps:    steps,
+			Expected: "last_plan_from_cache=0",
+			Actual:   fmt.Sprintf("last_plan_from_cache=%d", hit1),
+			Details: map[string]any{
+				"origin_result":          origin,
+				"explain_for_connection": run.explain(ctx),
+				"replay_sql":             concreteSQL,
+			},
+		}
+	}
+	if signatureMismatch && !hasWarnings {
+		return Result{
+			OK:       false,
+			Oracle:   o.Name(),
+			Steps:    steps,
+			Expected: formatPlanCacheSignature(concreteSig2),
+			Actual:   formatPlanCacheSignature(preparedSig),
+			Details: map[string]any{
+				"origin_result": origin,
+				"warnings":      warnings,
+				"replay_sql":    concreteSQL,
+			},
+		}
+	}
+	if !signatureMismatch {
+		textSig, ok := preparedTextSignature(ctx, conn, exec.Guard, pq.SQL, pq.Args, args2, o.RoundScale)
+		if ok && textSig != preparedSig {
+			return Result{
+				OK:       false,
+				Oracle:   o.Name(),
+				Steps:    preparedTextSteps(concreteSQL, pq.SQL, pq.Args, args2),
+				Expected: formatPlanCacheSignature(preparedSig),
+				Actual:   formatPlanCacheSignature(textSig),
+				Details: map[string]any{
+					"phase":         "text_protocol",
+					"origin_result": origin,
+					"replay_sql":    concreteSQL,
+				},
+			}
+		}
+	}
+	if hit2 == 1 || hasWarnings {
+		return Result{OK: true, Oracle: o.Name(), Steps: []sqlstep.Step{queryStep("", concreteSQL)}}
+	}
+	return Result{
+		OK:       false,
+		Oracle:   o.Name(),
+		Steps:    steps,
+		Expected: "last_plan_from_cache=1",
+		Actual:   "last_plan_from_cache=0",
+		Details: map[string]any{
+			"origin_result":          origin,
+			"warnings":               warnings,
+			"explain_for_connection": run.explain(ctx),
+			"replay_sql":             concreteSQL,
+		},
+	}
+}
+
+// Metrics that RunOnly reports, one count per run.
+const (
+	PlanCacheOnlyInvalidMetric                = "plan_cache_only_invalid"
+	PlanCacheOnlyExecErrorMetric              = "plan_cache_only_exec_errors"
+	PlanCacheOnlyHitFirstUnexpectedMetric     = "plan_cache_only_hit_first_unexpected"
+	PlanCacheOnlyHitSecondMetric              = "plan_cache_only_hit_second"
+	PlanCacheOnlyMissSecondMetric             = "plan_cache_only_miss_second"
+	PlanCacheOnlyMissSecondWithWarningsMetric = "plan_cache_only_miss_second_with_warnings"
+	PlanCacheOnlyFirstSkipWithWarningsMetric  = "plan_cache_only_first_skip_with_warnings"
+)
+
+// PlanCacheWarningReasonsKey is the Details key holding the distinct cache
+// skip reasons, as returned by PlanCacheWarningReason, that RunOnly saw.
+const PlanCacheWarningReasonsKey = "plan_cache_warning_reasons"
+
+// RunOnly is the plan_cache_only variant of Run. It validates the generated
+// statement, executes it with fresh arguments and then with the generated
+// ones, and only checks the second execution against the concrete
+// statement. Results are named PlanCacheOnly and carry the cache counters in
+// Metrics. When several checks fail, the result mismatch is reported first,
+// then an unexpected first hit, then a second miss without warnings.
+func (o PlanCache) RunOnly(ctx context.Context, exec *db.DB, gen *generator.Generator) Result {
+	metrics := map[string]int64{}
+	skip := func(reason string) Result {
+		return Result{OK: true, Oracle: planCacheOnlyName, Metrics: metrics, Details: map[string]any{"skip_reason": "plancacheonly:" + reason}}
+	}
+	conn, connID, err := openPlanCacheConn(ctx, exec)
+	if err != nil {
+		return Result{OK: true, Oracle: planCacheOnlyName, Err: err}
+	}
+	defer util.CloseWithErr(conn, "plan cache conn")
+	pq := gen.GeneratePreparedQuery()
+	if pq.SQL == "" {
+		return skip("no_query")
+	}
+	if err := exec.ValidateSQL(pq.SQL); err != nil {
+		metrics[PlanCacheOnlyInvalidMetric] = 1
+		return skip("invalid_sql")
+	}
+	concreteSQL := materializeSQL(pq.SQL, pq.Args)
+	concreteSig, err := rowSignatureOnConn(ctx, conn, exec.Guard, concreteSQL, o.RoundScale)
+	if err != nil {
+		return withMetrics(planCacheErrResult(planCacheOnlyName, err, []sqlstep.Step{queryStep(sqlstep.RoleFailing, concreteSQL)}, nil), metrics)
+	}
+	stmt, err := conn.PrepareContext(ctx, pq.SQL)
+	if err != nil {
+		return withMetrics(planCacheErrResult(planCacheOnlyName, err, planCachePrepareFailedSteps(pq.SQL), nil), metrics)
+	}
+	defer util.CloseWithErr(stmt, "plan cache stmt")
+	run := &planCacheRun{
+		name:        planCacheOnlyName,
+		exec:        exec,
+		conn:        conn,
+		connID:      connID,
+		stmt:        stmt,
+		concreteSQL: concreteSQL,
+		roundScale:  o.RoundScale,
+	}
+	args1 := gen.GeneratePreparedArgsForQuery(pq.Args, pq.ArgTypes)
+	steps := planCacheSteps(concreteSQL, pq.SQL, args1, pq.Args, connID)
+	replay := map[string]any{"replay_sql": concreteSQL}
+
+	if _, _, _, err := run.execute(ctx, args1); err != nil {
+		return withMetrics(run.execErrResult(ctx, err, steps, metrics), metrics)
+	}
+	warnings, err := db.WarningsOnConn(ctx, conn)
+	if err != nil {
+		return Result{OK: true, Oracle: planCacheOnlyName, Steps: steps, Err: err, Metrics: metrics}
+	}
+	if len(warnings) > 0 {
+		metrics[PlanCacheOnlyFirstSkipWithWarningsMetric] = 1
+		res := skip("first_execute_warnings")
+		res.Details[PlanCacheWarningReasonsKey] = planCacheWarningReasons(warnings)
+		return res
+	}
+	hit1, err := lastPlanFromCache(ctx, conn)
+	hit1Unexpected := err == nil && hit1 == 1
+	if hit1Unexpected {
+		metrics[PlanCacheOnlyHitFirstUnexpectedMetric] = 1
+	}
+
+	preparedSig, originCols, originRows, err := run.execute(ctx, pq.Args)
+	if err != nil {
+		return withMetrics(run.execErrResult(ctx, err, steps, metrics), metrics)
+	}
+	origin := planCacheOrigin(preparedSig, originCols, originRows)
+	hit2, err := lastPlanFromCache(ctx, conn)
+	if err != nil {
+		return Result{OK: true, Oracle: planCacheOnlyName, Steps: steps, Err: err, Metrics: metrics, Details: replay}
+	}
+	warnings, err = run.warningsAfter(ctx, pq.Args)
+	if err != nil {
+		return Result{OK: true, Oracle: planCacheOnlyName, Steps: steps, Err: err, Metrics: metrics, Details: replay}
+	}
+	if err := run.drain(ctx, pq.Args); err != nil {
+		return Result{OK: true, Oracle: planCacheOnlyName, Steps: steps, Err: err, Metrics: metrics, Details: replay}
+	}
+	hasWarnings := len(warnings) > 0
+	details := map[string]any{
+		"origin_result": origin,
+		"hit_first":     hit1,
+		"hit_second":    hit2,
+		"replay_sql":    concreteSQL,
+	}
+	if hasWarnings {
+		details["warnings"] = warnings
+	}
+	missWithoutWarnings := false
+	if hit2 == 1 {
+		metrics[PlanCacheOnlyHitSecondMetric] = 1
+	} else {
+		metrics[PlanCacheOnlyMissSecondMetric] = 1
+		if hasWarnings {
+			metrics[PlanCacheOnlyMissSecondWithWarningsMetric] = 1
+			details[PlanCacheWarningReasonsKey] = planCacheWarningReasons(warnings)
+		} else {
+			missWithoutWarnings = true
+		}
+	}
+	res := Result{OK: false, Oracle: planCacheOnlyName, Steps: steps, Metrics: metrics, Details: details}
+	switch {
+	case preparedSig != concreteSig && !hasWarnings:
+		res.Expected = formatPlanCacheSignature(concreteSig)
+		res.Actual = formatPlanCacheSignature(preparedSig)
+	case hit1Unexpected:
+		res.Expected = "last_plan_from_cache=0"
+		res.Actual = fmt.Sprintf("last_plan_from_cache=%d", hit1)
+		details["explain_for_connection"] = run.explain(ctx)
+	case missWithoutWarnings:
+		res.Expected = "last_plan_from_cache=1"
+		res.Actual = "last_plan_from_cache=0"
+		details["args_first"] = formatArgs(args1)
+		details["args_second"] = formatArgs(pq.Args)
+		details["miss_without_warnings"] = true
+		details["explain_for_connection"] = run.explain(ctx)
+	default:
+		res.OK = true
+		res.Steps = []sqlstep.Step{queryStep("", concreteSQL)}
+	}
+	return res
+}
+
+func withMetrics(res Result, metrics map[string]int64) Result {
+	if len(metrics) > 0 {
+		res.Metrics = metrics
+	}
+	return res
+}
+
+// execErrResult maps an EXECUTE error in plan_cache_only mode. Errors that
+// are not skipped count as execution errors; server errors also carry the
+// warnings and the connection's last plan.
+func (r *planCacheRun) execErrResult(ctx context.Context, err error, steps []sqlstep.Step, metrics map[string]int64) Result {
+	res := planCacheErrResult(r.name, err, steps, map[string]any{"replay_sql": r.concreteSQL})
+	if res.Err == nil {
+		return res
+	}
+	metrics[PlanCacheOnlyExecErrorMetric] = 1
+	if isMySQLErr(err) {
+		warnings, warnErr := db.WarningsOnConn(ctx, r.conn)
+		res.Details["warnings"] = warnings
+		res.Details["warnings_err"] = warnErr
+		res.Details["explain_for_connection"] = r.explain(ctx)
+	}
+	return res
+}
+
+// openPlanCacheConn takes a connection for one run, reads its id, and turns
+// MPP off on it.
+func openPlanCacheConn(ctx context.Context, exec *db.DB) (*sql.Conn, int64, error) {
+	conn, err := exec.Conn(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	var connID int64
+	if err := conn.QueryRowContext(ctx, "SELECT CONNECTION_ID()").Scan(&connID); err != nil {
+		util.CloseWithErr(conn, "plan cache conn")
+		return nil, 0, err
+	}
+	if err := disablePlanCacheMPP(ctx, conn); err != nil {
+		util.CloseWithErr(conn, "plan cache conn")
+		return nil, 0, err
+	}
+	return conn, connID, nil
+}
+
+// execute runs the prepared statement and hashes its rows.
+func (r *planCacheRun) execute(ctx context.Context, args []any) (db.Signature, []string, [][]string, error) {
+	rows, err := r.stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return db.Signature{}, nil, nil, err
+	}
+	defer util.CloseWithErr(rows, "plan cache rows")
+	return r.exec.Guard.SignatureAndSampleFromRows(rows, planCacheOriginSampleLimit, r.roundScale)
+}
+
+func (r *planCacheRun) drain(ctx context.Context, args []any) error {
+	rows, err := r.stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return err
+	}
+	defer util.CloseWithErr(rows, "plan cache rows")
+	return r.exec.Guard.DrainRows(rows)
+}
+
+// warningsAfter runs the statement again before SHOW WARNINGS, so the
+// warnings belong to an EXECUTE and not to SELECT @@last_plan_from_cache.
+func (r *planCacheRun) warningsAfter(ctx context.Context, args []any) ([]string, error) {
+	if err := r.drain(ctx, args); err != nil {
+		return nil, err
+	}
+	return db.WarningsOnConn(ctx, r.conn)
+}
+
+// explain returns EXPLAIN FOR CONNECTION for the run's connection, read from
+// another connection. Errors leave it empty.
+func (r *planCacheRun) explain(ctx context.Context) string {
+	rows, err := r.exec.QueryContext(ctx, fmt.Sprintf("EXPLAIN FOR CONNECTION %d", r.connID))
+	if err != nil {
+		return ""
+	}
+	defer util.CloseWithErr(rows, "plan cache explain rows")
+	cols, err := rows.Columns()
+	if err != nil {
+		return ""
+	}
+	values := make([]sql.NullString, len(cols))
+	scanArgs := make([]any, len(cols))
+	for i := range values {
+		scanArgs[i] = &values[i]
+	}
+	var b strings.Builder
+	for rows.Next() {
+		if err := rows.Scan(scanArgs...); err != nil {
+			return ""
+		}
+		for i, v := range values {
+			if i > 0 {
+				b.WriteByte('\t')
+			}
+			if v.Valid {
+				b.WriteString(v.String)
+			} else {
+				b.WriteString("NULL")
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// planCacheErrResult maps a statement error. Generator faults from the
+// whitelist skip the run. Other server errors are reported. Client-side
+// errors such as timeouts come back on an OK result, where the runner still
+// reports panics.
+func planCacheErrResult(name string, err error, steps []sqlstep.Step, details map[string]any) Result {
+	prefix := strings.ToLower(name)
+	if code, ok := isWhitelistedSQLError(err); ok {
+		return Result{OK: true, Oracle: name, Steps: steps, Details: map[string]any{"skip_reason": fmt.Sprintf("%s:sql_error_%d", prefix, code)}}
+	}
+	if isUnknownColumnWhereErr(err) {
+		return Result{OK: true, Oracle: name, Steps: steps, Details: map[string]any{"skip_reason": prefix + ":unknown_column_where"}}
+	}
+	if details == nil {
+		details = map[string]any{}
+	}
+	return Result{OK: !isMySQLErr(err), Oracle: name, Steps: steps, Err: err, Details: details}
+}
+
+func isMySQLErr(err error) bool {
+	_, ok := mysqlErrCode(err)
+	return ok
+}
+
+func isUnknownColumnWhereErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "unknown column") && strings.Contains(msg, "in where clause")
+}
+
+func rowSignatureOnConn(ctx context.Context, conn *sql.Conn, guard db.ResultGuard, query string, roundScale int) (db.Signature, error) {
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return db.Signature{}, err
+	}
+	defer util.CloseWithErr(rows, "plan cache rows")
+	return guard.SignatureFromRows(rows, roundScale)
+}
+
+// preparedTextSignature runs the statement through server-side PREPARE and
+// EXECUTE ... USING with user variables, the text-protocol path. It executes
+// argsFirst and then argsSecond, like the binary-protocol run, so the second
+// execution can reuse the cached plan. Errors skip the check.
+func preparedTextSignature(ctx context.Context, conn *sql.Conn, guard db.ResultGuard, preparedSQL string, argsFirst []any, argsSecond []any, roundScale int) (db.Signature, bool) {
+	if _, err := conn.ExecContext(ctx, formatPrepareSQL(preparedSQL)); err != nil {
+		return db.Signature{}, false
+	}
+	defer func() {
+		_, _ = conn.ExecContext(ctx, "DEALLOCATE PREPARE stmt")
+	}()
+	var sig db.Signature
+	for i, args := range [][]any{argsFirst, argsSecond} {
+		stmts := formatExecuteSQLWithVars("stmt", args)
+		for _, stmt := range stmts[:len(stmts)-1] {
+			if _, err := conn.ExecContext(ctx, stmt); err != nil {
+				return db.Signature{}, false
+			}
+		}
+		rows, err := conn.QueryContext(ctx, stmts[len(stmts)-1])
+		if err != nil {
+			return db.Signature{}, false
+		}
+		if i == 0 {
+			err = guard.DrainRows(rows)
+		} else {
+			sig, err = guard.SignatureFromRows(rows, roundScale)
+		}
+		util.CloseWithErr(rows, "plan cache rows")
+		if err != nil {
+			return db.Signature{}, false
+		}
+	}
+	return sig, true
+}
+
+func lastPlanFromCache(ctx context.Context, conn *sql.Conn) (int, error) {
+	var v int
+	if err := conn.QueryRowContext(ctx, "SELECT @@last_plan_from_cache").Scan(&v); err != nil {
+		return 0, err
+	}
+	return v, nil
+}
+
+func planCacheMPPDisableStatements() []string {
+	return []string{
+		"SET SESSION tidb_allow_mpp=OFF",
+		"SET SESSION tidb_enforce_mpp=OFF",
+	}
+}
+
+func shouldIgnorePlanCacheMPPDisableError(err error) bool {
+	// Unknown system variable; keep compatibility with older TiDB versions.
+	return isUnknownSystemVariable(err)
+}
+
+func disablePlanCacheMPP(ctx context.Context, conn *sql.Conn) error {
+	for _, sqlText := range planCacheMPPDisableStatements() {
+		if _, err := conn.ExecContext(ctx, sqlText); err != nil {
+			if shouldIgnorePlanCacheMPPDisableError(err) {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func planCacheOrigin(sig db.Signature, cols []string, rows [][]string) map[string]any {
+	return map[string]any{
+		"signature": formatPlanCacheSignature(sig),
+		"columns":   cols,
+		"rows":      rows,
+	}
+}
+
+func formatPlanCacheSignature(sig db.Signature) string {
+	return fmt.Sprintf("cnt=%d checksum=%d", sig.Count, sig.Checksum)
+}
+
+// PlanCacheWarningReason strips the level, code, and "skip plan-cache:" prefix
+// from a SHOW WARNINGS line, leaving the reason the cache was skipped.
+func PlanCacheWarningReason(warning string) string {
+	parts := strings.SplitN(warning, ":", 3)
+	msg := warning
+	if len(parts) == 3 {
+		msg = parts[2]
+	}
+	msg = strings.ToLower(strings.TrimSpace(msg))
+	msg = strings.TrimSpace(strings.TrimPrefix(msg, "skip plan-cache:"))
+	msg = strings.TrimSpace(strings.TrimPrefix(msg, "skip non-prepared plan-cache:"))
+	if msg == "" {
+		return "unknown"
+	}
+	return msg
+}
+
+// planCacheWarningReasons returns the distinct reasons in warnings, in order.
+func planCacheWarningReasons(warnings []string) []string {
+	seen := make(map[string]struct{}, len(warnings))
+	reasons := make([]string, 0, len(warnings))
+	for _, warning := range warnings {
+		reason := PlanCacheWarningReason(warning)
+		if _, ok := seen[reason]; ok {
+			continue
+		}
+		seen[reason] = struct{}{}
+		reasons = append(reasons, reason)
+	}
+	return reasons
+}
+
+// planCacheSteps replays a run: the concrete statement is the expected side
+// and the second EXECUTE, which may reuse the cached plan, the actual side.
+func planCacheSteps(concreteSQL, preparedSQL string, firstArgs []any, baseArgs []any, connID int64) []sqlstep.Step {
+	lastPlanFromCache := sqlstep.New(sqlstep.KindVerify, "", "SELECT @@last_plan_from_cache")
+	steps := []sqlstep.Step{
+		queryStep(sqlstep.RoleExpected, concreteSQL),
+		sqlstep.New(sqlstep.KindPrepare, "", formatPrepareSQL(preparedSQL)),
+	}
+	steps = append(steps, executeSteps("stmt", firstArgs, "")...)
+	steps = append(steps, lastPlanFromCache)
+	steps = append(steps, executeSteps("stmt", baseArgs, sqlstep.RoleActual)...)
+	steps = append(steps, lastPlanFromCache)
+	steps = append(steps, executeSteps("stmt", baseArgs, "")...)
+	steps = append(steps, sqlstep.New(sqlstep.KindVerify, "", "SHOW WARNINGS"))
+	steps = append(steps, executeSteps("stmt", baseArgs, "")...)
+	return append(steps, sqlstep.New(sqlstep.KindVerify, "", fmt.Sprintf("EXPLAIN FOR CONNECTION %d", connID)))
+}
+
+// preparedTextSteps replays the text protocol check; the second EXECUTE is
+// the actual side.
+func preparedTextSteps(concreteSQL, preparedSQL string, argsFirst []any, argsSecond []any) []sqlstep.Step {
+	steps := []sqlstep.Step{
+		queryStep(sqlstep.RoleExpected, concreteSQL),
+		sqlstep.New(sqlstep.KindPrepare, "", formatPrepareSQL(preparedSQL)),
+	}
+	steps = append(steps, executeSteps("stmt", argsFirst, "")...)
+	steps = append(steps, executeSteps("stmt", argsSecond, sqlstep.RoleActual)...)
+	return append(steps, sqlstep.New(sqlstep.KindSetup, "", "DEALLOCATE PREPARE stmt"))
+}
+
+func planCachePrepareFailedSteps(preparedSQL string) []sqlstep.Step {
+	return []sqlstep.Step{sqlstep.New(sqlstep.KindPrepare, sqlstep.RoleFailing, formatPrepareSQL(preparedSQL))}
+}
+
+// executeSteps types formatExecuteSQLWithVars: the variable assignment, if
+// any, and then the EXECUTE, which carries role.
+func executeSteps(name string, args []any, role sqlstep.Role) []sqlstep.Step {
+	stmts := formatExecuteSQLWithVars(name, args)
+	steps := make([]sqlstep.Step, 0, len(stmts))
+	for _, stmt := range stmts[:len(stmts)-1] {
+		steps = append(steps, sqlstep.New(sqlstep.KindSetVar, "", stmt))
+	}
+	return append(steps, sqlstep.New(sqlstep.KindExecute, role, stmts[len(stmts)-1]))
+}
+
+func formatPrepareSQL(sqlText string) string {
+	return fmt.Sprintf("PREPARE stmt FROM '%s'", strings.ReplaceAll(sqlText, "'", "''"))
+}
+
+func formatExecuteSQLWithVars(name string, args []any) []string {
+	if len(args) == 0 {
+		return []string{fmt.Sprintf("EXECUTE %s", name)}
+	}
+	values := formatArgs(args)
+	setParts := make([]string, len(values))
+	useParts := make([]string, len(values))
+	for i, v := range values {
+		varName := fmt.Sprintf("@p%d", i+1)
+		setParts[i] = fmt.Sprintf("%s=%s", varName, v)
+		useParts[i] = varName
+	}
+	return []string{
+		"SET " + strings.Join(setParts, ", "),
+		fmt.Sprintf("EXECUTE %s USING %s", name, strings.Join(useParts, ", ")),
+	}
+}
+
+func formatArgs(args []any) []string {
+	out := make([]string, 0, len(args))
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case nil:
+			out = append(out, "NULL")
+		case string:
+			out = append(out, fmt.Sprintf("'%s'", strings.ReplaceAll(v, "'", "''")))
+		case time.Time:
+			out = append(out, fmt.Sprintf("'%s'", v.Format(time.DateTime)))
+		default:
+			out = append(out, fmt.Sprintf("%v", v))
+		}
+	}
+	return out
+}
+
+// materializeSQL inlines args into the placeholders of sqlText.
+func materializeSQL(sqlText string, args []any) string {
+	if len(args) == 0 {
+		return sqlText
+	}
+	formatted := formatArgs(args)
+	var b strings.Builder
+	argIdx := 0
+	for _, r := range sqlText {
+		if r == '?' && argIdx < len(formatted) {
+			b.WriteString(formatted[argIdx])
+			argIdx++
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
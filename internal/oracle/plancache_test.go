@@ -0,0 +1,186 @@
+package oracle
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"shiro/internal/config"
+	"shiro/internal/sqlstep"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestPlanCacheWarningReason(t *testing.T) {
+	testCases := []struct {
+		name    string
+		warning string
+		expect  string
+	}{
+		{
+			name:    "prepared skip reason",
+			warning: "Warning:1105:skip plan-cache: sub-queries are un-cacheable",
+			expect:  "sub-queries are un-cacheable",
+		},
+		{
+			name:    "non prepared skip reason",
+			warning: "Warning:1105:skip non-prepared plan-cache: queries that have sub-queries are not supported",
+			expect:  "queries that have sub-queries are not supported",
+		},
+		{
+			name:    "plain warning message",
+			warning: "Warning:1105:query has 'order by ?' is un-cacheable",
+			expect:  "query has 'order by ?' is un-cacheable",
+		},
+		{
+			name:    "empty warning message",
+			warning: "Warning:1105:",
+			expect:  "unknown",
+		},
+		{
+			name:    "invalid warning format",
+			warning: "invalid-warning",
+			expect:  "invalid-warning",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := PlanCacheWarningReason(tc.warning)
+			if got != tc.expect {
+				t.Fatalf("warning reason mismatch: got=%q expect=%q", got, tc.expect)
+			}
+		})
+	}
+}
+
+func TestPlanCacheWarningReasons(t *testing.T) {
+	got := planCacheWarningReasons([]string{
+		"Warning:1105:skip plan-cache: sub-queries are un-cacheable",
+		"Warning:1105:skip plan-cache: sub-queries are un-cacheable",
+		"Warning:1105:query has 'order by ?' is un-cacheable",
+	})
+	want := []string{"sub-queries are un-cacheable", "query has 'order by ?' is un-cacheable"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("unexpected reasons: %q", got)
+	}
+}
+
+func TestPlanCacheMPPDisableStatements(t *testing.T) {
+	got := planCacheMPPDisableStatements()
+	want := []string{
+		"SET SESSION tidb_allow_mpp=OFF",
+		"SET SESSION tidb_enforce_mpp=OFF",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected statements count: got=%d want=%d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("unexpected statement at %d: got=%q want=%q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestShouldIgnorePlanCacheMPPDisableError(t *testing.T) {
+	cases := []struct {
+		name   string
+		err    error
+		expect bool
+	}{
+		{
+			name:   "nil",
+			err:    nil,
+			expect: false,
+		},
+		{
+			name:   "unknown system variable code",
+			err:    &mysql.MySQLError{Number: 1193, Message: "Unknown system variable"},
+			expect: true,
+		},
+		{
+			name:   "unknown system variable text",
+			err:    errors.New("Error 1105 (HY000): Unknown system variable 'tidb_enforce_mpp'"),
+			expect: true,
+		},
+		{
+			name:   "other mysql error",
+			err:    &mysql.MySQLError{Number: 1064, Message: "syntax error"},
+			expect: false,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := shouldIgnorePlanCacheMPPDisableError(tc.err); got != tc.expect {
+				t.Fatalf("unexpected result: got=%t expect=%t", got, tc.expect)
+			}
+		})
+	}
+}
+
+func TestPreparedTextSteps(t *testing.T) {
+	when := time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC)
+	steps := preparedTextSteps("SELECT 1", "SELECT c0 FROM t0 WHERE c0 > ? LIMIT ?", []any{when, 3}, []any{"it's", 4})
+	seq := sqlstep.SQL(steps)
+	want := []string{
+		"SELECT 1",
+		"PREPARE stmt FROM 'SELECT c0 FROM t0 WHERE c0 > ? LIMIT ?'",
+		"SET @p1='2024-02-03 04:05:06', @p2=3",
+		"EXECUTE stmt USING @p1, @p2",
+		"SET @p1='it''s', @p2=4",
+		"EXECUTE stmt USING @p1, @p2",
+		"DEALLOCATE PREPARE stmt",
+	}
+	if strings.Join(seq, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected sequence:\n%s", strings.Join(seq, "\n"))
+	}
+	kinds := []sqlstep.Kind{
+		sqlstep.KindQuery, sqlstep.KindPrepare,
+		sqlstep.KindSetVar, sqlstep.KindExecute,
+		sqlstep.KindSetVar, sqlstep.KindExecute,
+		sqlstep.KindSetup,
+	}
+	for i, kind := range kinds {
+		if steps[i].Kind != kind {
+			t.Fatalf("step %d kind=%q want=%q", i, steps[i].Kind, kind)
+		}
+	}
+	if steps[0].Role != sqlstep.RoleExpected || steps[5].Role != sqlstep.RoleActual {
+		t.Fatalf("unexpected roles: %+v", steps)
+	}
+}
+
+func TestPlanCacheErrResult(t *testing.T) {
+	steps := []sqlstep.Step{queryStep(sqlstep.RoleFailing, "SELECT 1")}
+	skipped := planCacheErrResult("PlanCache", &mysql.MySQLError{Number: 1064, Message: "syntax error"}, steps, nil)
+	if !skipped.OK || skipped.Err != nil || skipped.Details["skip_reason"] != "plancache:sql_error_1064" {
+		t.Fatalf("whitelisted error should skip: %+v", skipped)
+	}
+	reported := planCacheErrResult("PlanCache", &mysql.MySQLError{Number: 1105, Message: "index out of range"}, steps, map[string]any{"replay_sql": "SELECT 1"})
+	if reported.OK || reported.Err == nil || reported.Details["replay_sql"] != "SELECT 1" {
+		t.Fatalf("server error should be reported: %+v", reported)
+	}
+	client := planCacheErrResult("PlanCacheOnly", errors.New("context deadline exceeded"), steps, nil)
+	if !client.OK || client.Err == nil {
+		t.Fatalf("client error should stay OK with the error attached: %+v", client)
+	}
+}
+
+func TestMaterializeSQL(t *testing.T) {
+	got := materializeSQL("SELECT c0 FROM t0 WHERE c0 > ? AND c1 IN (?, ?)", []any{3, "it's", nil})
+	want := "SELECT c0 FROM t0 WHERE c0 > 3 AND c1 IN ('it''s', NULL)"
+	if got != want {
+		t.Fatalf("unexpected sql: %q", got)
+	}
+}
+
+func TestNewPlanCacheRoundScale(t *testing.T) {
+	cfg := config.Config{Signature: config.SignatureConfig{RoundScale: 3, PlanCacheRoundScale: -1}}
+	if got := NewPlanCache(cfg).RoundScale; got != 3 {
+		t.Fatalf("negative plan cache scale should fall back to round_scale, got %d", got)
+	}
+	cfg.Signature.PlanCacheRoundScale = 5
+	if got := NewPlanCache(cfg).RoundScale; got != 5 {
+		t.Fatalf("unexpected plan cache scale: %d", got)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"shiro/internal/db"
 	"shiro/internal/generator"
 	"shiro/internal/schema"
+	"shiro/internal/sqlstep"
 	"shiro/internal/util"
 )
 
@@ -71,7 +72,7 @@ func (o PQS) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, sta
 	}
 	pivot, details, err := pickPQSPivotRow(ctx, exec, gen, state)
 	if err != nil {
-		return Result{OK: true, Oracle: o.Name(), SQL: pqsErrorSQL(err), Err: err, Details: details}
+		return Result{OK: true, Oracle: o.Name(), Steps: pqsErrorSteps(err), Err: err, Details: details}
 	}
 	if pivot == nil {
 		if details == nil {
@@ -173,7 +174,11 @@ func (o PQS) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, sta
 			details["error_code"] = int(code)
 		}
 		updateBandit(true, err, false)
-		return Result{OK: true, Oracle: o.Name(), SQL: []string{querySQL, containSQL}, SQLFeatures: observed, Err: err, Details: attachBandit(details)}
+		steps := []sqlstep.Step{
+			queryStep(sqlstep.RoleExpected, querySQL),
+			queryStep(sqlstep.RoleFailing, containSQL),
+		}
+		return Result{OK: true, Oracle: o.Name(), Steps: steps, SQLFeatures: observed, Err: err, Details: attachBandit(details)}
 	}
 	if !present {
 		replayExpected := fmt.Sprintf("SELECT 1 FROM (%s) pqs WHERE %s LIMIT 1", querySQL, matchSQL)
@@ -182,7 +187,7 @@ func (o PQS) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, sta
 		return Result{
 			OK:          false,
 			Oracle:      o.Name(),
-			SQL:         []string{querySQL, containSQL},
+			Steps:       comparisonSteps(querySQL, containSQL),
 			SQLFeatures: observed,
 			Expected:    "pivot_row_present",
 			Actual:      "pivot_row_missing",
@@ -206,7 +211,7 @@ func (o PQS) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, sta
 		}
 	}
 	updateBandit(true, nil, false)
-	return Result{OK: true, Oracle: o.Name(), SQL: []string{querySQL, containSQL}, SQLFeatures: observed}
+	return Result{OK: true, Oracle: o.Name(), Steps: comparisonSteps(querySQL, containSQL), SQLFeatures: observed}
 }
 
 func pickPQSPivotRow(ctx context.Context, exec *db.DB, gen *generator.Generator, state *schema.State) (*pqsPivotRow, map[string]any, error) {
@@ -309,24 +314,15 @@ func fetchPQSPivotRowByRand(ctx context.Context, exec *db.DB, tbl schema.Table)
 		return nil, pqsWrapError("pivot_rand", query, err)
 	}
 	defer util.CloseWithErr(rows, "pqs pivot rows")
-	if !rows.Next() {
-		if err := rows.Err(); err != nil {
-			return nil, err
-		}
-		return nil, nil
-	}
-	raw := make([]sql.RawBytes, len(colNames))
-	scanArgs := make([]any, len(raw))
-	for i := range raw {
-		scanArgs[i] = &raw[i]
-	}
-	if err := rows.Scan(scanArgs...); err != nil {
-		return nil, pqsWrapError("pivot_rand_scan", query, err)
-	}
-	if err := rows.Err(); err != nil {
+	var pivot *pqsPivotRow
+	err = exec.Guard.ScanRows(rows, func(raw []sql.RawBytes) error {
+		pivot = pqsPivotRowFromRaw([]schema.Table{tbl}, cols, raw)
+		return db.ErrStopScan
+	})
+	if err != nil {
 		return nil, pqsWrapError("pivot_rand_scan", query, err)
 	}
-	return pqsPivotRowFromRaw([]schema.Table{tbl}, cols, raw), nil
+	return pivot, nil
 }
 
 func fetchPQSPivotRowByID(ctx context.Context, exec *db.DB, gen *generator.Generator, tbl schema.Table) (*pqsPivotRow, error) {
@@ -1055,12 +1051,12 @@ func pqsErrorSQLText(err error) string {
 	return ""
 }
 
-func pqsErrorSQL(err error) []string {
+func pqsErrorSteps(err error) []sqlstep.Step {
 	sqlText := pqsErrorSQLText(err)
 	if sqlText == "" {
 		return nil
 	}
-	return []string{sqlText}
+	return []sqlstep.Step{queryStep(sqlstep.RoleFailing, sqlText)}
 }
 
 func pqsPredicateExprForValue(ref generator.ColumnRef, val pqsPivotValue) generator.Expr {
@@ -1080,7 +1076,7 @@ func pqsPredicateExprForValue(ref generator.ColumnRef, val pqsPivotValue) genera
 
 func pqsLiteralValue(col schema.Column, raw string) any {
 	switch col.Type {
-	case schema.TypeInt, schema.TypeBigInt:
+	case schema.TypeInt, schema.TypeBigInt, schema.TypeBit, schema.TypeYear:
 		if v, err := strconv.ParseInt(raw, 10, 64); err == nil {
 			return v
 		}
@@ -1182,10 +1178,15 @@ func pqsSelectColumns(tables []schema.Table) []pqsSelectColumn {
 	cols := make([]pqsSelectColumn, 0, totalCols)
 	for _, tbl := range tables {
 		for _, col := range tbl.Columns {
+			sqlText := fmt.Sprintf("%s.%s", tbl.Name, col.Name)
+			if col.Type == schema.TypeBit {
+				// BIT values are returned as raw bytes; read them as integers.
+				sqlText += "+0"
+			}
 			cols = append(cols, pqsSelectColumn{
 				TableName: tbl.Name,
 				Column:    col,
-				SQL:       fmt.Sprintf("%s.%s", tbl.Name, col.Name),
+				SQL:       sqlText,
 			})
 		}
 	}
@@ -1267,24 +1268,15 @@ func fetchPQSPivotRowByQuery(ctx context.Context, exec *db.DB, tables []schema.T
 		return nil, pqsWrapError("pivot_query", query, err)
 	}
 	defer util.CloseWithErr(rows, "pqs pivot rows")
-	if !rows.Next() {
-		if err := rows.Err(); err != nil {
-			return nil, err
-		}
-		return nil, nil
-	}
-	raw := make([]sql.RawBytes, len(cols))
-	scanArgs := make([]any, len(raw))
-	for i := range raw {
-		scanArgs[i] = &raw[i]
-	}
-	if err := rows.Scan(scanArgs...); err != nil {
-		return nil, pqsWrapError("pivot_query_scan", query, err)
-	}
-	if err := rows.Err(); err != nil {
+	var pivot *pqsPivotRow
+	err = exec.Guard.ScanRows(rows, func(raw []sql.RawBytes) error {
+		pivot = pqsPivotRowFromRaw(tables, cols, raw)
+		return db.ErrStopScan
+	})
+	if err != nil {
 		return nil, pqsWrapError("pivot_query_scan", query, err)
 	}
-	return pqsPivotRowFromRaw(tables, cols, raw), nil
+	return pivot, nil
 }
 
 func fetchPQSJoinPivotRow(ctx context.Context, exec *db.DB, gen *generator.Generator, tables []schema.Table) (*pqsPivotRow, error) {
@@ -301,7 +301,7 @@ func pqsValueFromPivot(ref generator.ColumnRef, pivot *pqsPivotRow) (pqsValue, b
 
 func pqsValueFromRaw(col schema.Column, raw string) (pqsValue, bool) {
 	switch col.Type {
-	case schema.TypeInt, schema.TypeBigInt:
+	case schema.TypeInt, schema.TypeBigInt, schema.TypeBit, schema.TypeYear:
 		if v, err := strconv.ParseInt(raw, 10, 64); err == nil {
 			return pqsValue{Kind: pqsValueInt, Int: v}, true
 		}
@@ -324,6 +324,10 @@ func pqsValueFromRaw(col schema.Column, raw string) (pqsValue, bool) {
 		return pqsValue{}, false
 	case schema.TypeDecimal, schema.TypeVarchar, schema.TypeDate, schema.TypeDatetime, schema.TypeTimestamp:
 		return pqsValue{Kind: pqsValueString, Str: raw}, true
+	case schema.TypeEnum, schema.TypeSet:
+		// Compared with string literals, ENUM and SET values compare as their
+		// string form, not their member position.
+		return pqsValue{Kind: pqsValueString, Str: raw}, true
 	default:
 		return pqsValue{}, false
 	}
@@ -0,0 +1,299 @@
+package oracle
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"sort"
+	"strings"
+	"time"
+
+	"shiro/internal/config"
+	"shiro/internal/db"
+	"shiro/internal/generator"
+	"shiro/internal/schema"
+	"shiro/internal/sqlstep"
+
+	"github.com/pingcap/tidb/pkg/parser"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+)
+
+const (
+	privilegeBuildMaxTries = 10
+	// privilegeRecheckDelay gives other tidb-servers time to reload the
+	// privilege tables before an unexpected outcome is reported.
+	privilegeRecheckDelay = time.Second
+
+	privilegeVariantTableGrants = "table_grants"
+	privilegeVariantDBGrant     = "db_grant"
+	privilegeVariantDenied      = "denied"
+)
+
+// privilegeDeniedCodes are the errors a statement missing a privilege may
+// return: database access, table access, and column access denied.
+var privilegeDeniedCodes = map[uint16]struct{}{
+	1044: {},
+	1142: {},
+	1143: {},
+}
+
+// Privilege implements the privilege-aware oracle.
+//
+// It builds a query, records its signature as the fuzz user, recreates a
+// limited user, and grants it one of three privilege sets:
+//
+//   - table_grants: SELECT on every table and view the query names.
+//   - db_grant: SELECT on the whole database.
+//   - denied: SELECT on every named object but one.
+//
+// The query then runs as the limited user. With the full grants it must
+// succeed with the same signature; with one grant missing it must fail with a
+// privilege error. Views use SQL SECURITY DEFINER, so a grant on the view is
+// enough and its base tables are not granted. CTE names are not objects and
+// need no grant.
+//
+// Example:
+//
+//	GRANT SELECT ON shiro.t0 TO 'shiro_priv_1a2b3c4d'@'%'
+//	GRANT SELECT ON shiro.v0 TO 'shiro_priv_1a2b3c4d'@'%'
+//	SELECT ... FROM t0 JOIN v0 ON ...  -- must match the fuzz user's result
+type Privilege struct {
+	DSN         string
+	SessionInit []string
+	User        string
+	Password    string
+}
+
+// NewPrivilege returns the oracle for the cluster in cfg. The limited user
+// name is stable for a run so a rotated database does not leave users behind.
+func NewPrivilege(cfg config.Config) Privilege {
+	suffix := fmt.Sprintf("%08x", crc32.ChecksumIEEE([]byte(cfg.Database)))
+	return Privilege{
+		DSN:         cfg.DSN,
+		SessionInit: cfg.SessionInit,
+		User:        "shiro_priv_" + suffix,
+		Password:    "Shiro#" + suffix,
+	}
+}
+
+// Name returns the oracle identifier.
+func (o Privilege) Name() string { return "Privilege" }
+
+// Run checks one query under a random privilege set of a limited user.
+func (o Privilege) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, state *schema.State) Result {
+	if !state.HasBaseTables() {
+		return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "privilege:no_base_tables"}}
+	}
+	spec := QuerySpec{
+		Oracle:   "privilege",
+		MaxTries: privilegeBuildMaxTries,
+		SkipReasonOverrides: map[string]string{
+			"constraint:nondeterministic": "privilege:nondeterministic",
+		},
+	}
+	query, details := buildQueryWithSpec(gen, spec)
+	if query == nil {
+		return Result{OK: true, Oracle: o.Name(), Details: details}
+	}
+	querySQL := query.SignatureSQL()
+	objects, err := privilegeObjects(querySQL)
+	if err != nil || len(objects) == 0 {
+		return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "privilege:no_objects"}}
+	}
+	variant := privilegeVariantTableGrants
+	switch gen.Rand.Intn(5) {
+	case 0:
+		variant = privilegeVariantDBGrant
+	case 1, 2:
+		variant = privilegeVariantDenied
+	}
+	denied := ""
+	if variant == privilegeVariantDenied {
+		denied = objects[gen.Rand.Intn(len(objects))]
+	}
+	metrics := map[string]int64{"privilege_total": 1, "privilege_variant_" + variant + "_total": 1}
+	for _, name := range objects {
+		if tbl, ok := state.TableByName(name); ok && tbl.IsView {
+			metrics["privilege_view_total"] = 1
+		}
+	}
+
+	baseSig, err := exec.QuerySignature(ctx, querySQL)
+	if err != nil {
+		steps := []sqlstep.Step{sqlstep.New(sqlstep.KindQuery, sqlstep.RoleFailing, querySQL)}
+		return sqlErrorResult(o.Name(), "privilege", steps, metrics, nil, err, querySQL)
+	}
+	var database string
+	if err := exec.QueryRowContext(ctx, "SELECT DATABASE()").Scan(&database); err != nil {
+		steps := []sqlstep.Step{sqlstep.New(sqlstep.KindVerify, sqlstep.RoleFailing, "SELECT DATABASE()")}
+		return sqlErrorResult(o.Name(), "privilege", steps, metrics, nil, err, "SELECT DATABASE()")
+	}
+	setup := o.setupSQL(database, variant, objects, denied)
+	steps := make([]sqlstep.Step, 0, len(setup)+2)
+	for _, stmt := range setup {
+		steps = append(steps, sqlstep.New(sqlstep.KindSetup, "", stmt))
+		if _, err := exec.ExecContext(ctx, stmt); err != nil {
+			return Result{OK: true, Oracle: o.Name(), Metrics: metrics, Details: map[string]any{"skip_reason": "privilege:setup_failed", "privilege_setup_error": err.Error()}}
+		}
+	}
+	defer func() {
+		_, _ = exec.ExecContext(context.WithoutCancel(ctx), o.dropUserSQL())
+	}()
+	limited, err := db.Open(o.limitedDSN(database), o.SessionInit...)
+	if err != nil {
+		return Result{OK: true, Oracle: o.Name(), Metrics: metrics, Details: map[string]any{"skip_reason": "privilege:connect_failed", "privilege_setup_error": err.Error()}}
+	}
+	defer func() { _ = limited.Close() }()
+	limited.SnapshotTSO = exec.SnapshotTSO
+	steps = append(steps,
+		sqlstep.New(sqlstep.KindQuery, sqlstep.RoleExpected, querySQL),
+		sqlstep.New(sqlstep.KindQuery, sqlstep.RoleActual, querySQL),
+	)
+	details = map[string]any{
+		"privilege_variant": variant,
+		"privilege_user":    o.User,
+		"privilege_objects": objects,
+	}
+	if denied != "" {
+		details["privilege_denied"] = denied
+	}
+
+	sig, err := limited.QuerySignature(ctx, querySQL)
+	outcome := privilegeOutcome(variant, baseSig, sig, err)
+	if outcome != "" {
+		time.Sleep(privilegeRecheckDelay)
+		sig, err = limited.QuerySignature(ctx, querySQL)
+		if privilegeOutcome(variant, baseSig, sig, err) == "" {
+			details["skip_reason"] = "privilege:grant_propagation"
+			return Result{OK: true, Oracle: o.Name(), Steps: steps, Details: details, Metrics: metrics}
+		}
+	}
+	switch outcome {
+	case "":
+		return Result{OK: true, Oracle: o.Name(), Steps: steps, Details: details, Metrics: metrics}
+	case "privilege:error":
+		steps[len(steps)-1].Role = sqlstep.RoleFailing
+		result := sqlErrorResult(o.Name(), "privilege", steps, metrics, nil, err, querySQL)
+		for key, value := range details {
+			result.Details[key] = value
+		}
+		return result
+	}
+	details["privilege_outcome"] = outcome
+	expected := fmt.Sprintf("cnt=%d checksum=%d", baseSig.Count, baseSig.Checksum)
+	actual := fmt.Sprintf("cnt=%d checksum=%d", sig.Count, sig.Checksum)
+	switch {
+	case variant == privilegeVariantDenied:
+		expected = "privilege error for " + denied
+	case err != nil:
+		actual = "error: " + err.Error()
+	}
+	return Result{
+		OK:       false,
+		Oracle:   o.Name(),
+		Steps:    steps,
+		Expected: expected,
+		Actual:   actual,
+		Details:  details,
+		Metrics:  metrics,
+	}
+}
+
+// privilegeOutcome classifies the limited user's result: "" when it is
+// expected, privilege:bypass when a denied query succeeded,
+// privilege:unexpected_denial when a granted query was refused,
+// privilege:mismatch when the signatures differ, and privilege:error for
+// other errors.
+func privilegeOutcome(variant string, base db.Signature, sig db.Signature, err error) string {
+	code, _ := mysqlErrCode(err)
+	_, deniedErr := privilegeDeniedCodes[code]
+	switch {
+	case variant == privilegeVariantDenied && err == nil:
+		return "privilege:bypass"
+	case variant == privilegeVariantDenied && deniedErr:
+		return ""
+	case err != nil && deniedErr:
+		return "privilege:unexpected_denial"
+	case err != nil:
+		return "privilege:error"
+	case sig != base:
+		return "privilege:mismatch"
+	default:
+		return ""
+	}
+}
+
+func (o Privilege) account() string {
+	return fmt.Sprintf("'%s'@'%%'", o.User)
+}
+
+func (o Privilege) dropUserSQL() string {
+	return "DROP USER IF EXISTS " + o.account()
+}
+
+// setupSQL recreates the limited user so no grant of an earlier run remains,
+// then grants the variant's privileges.
+func (o Privilege) setupSQL(database string, variant string, objects []string, denied string) []string {
+	out := []string{
+		o.dropUserSQL(),
+		fmt.Sprintf("CREATE USER %s IDENTIFIED BY '%s'", o.account(), o.Password),
+	}
+	if variant == privilegeVariantDBGrant {
+		return append(out, fmt.Sprintf("GRANT SELECT ON `%s`.* TO %s", database, o.account()))
+	}
+	for _, name := range objects {
+		if name == denied {
+			continue
+		}
+		out = append(out, fmt.Sprintf("GRANT SELECT ON `%s`.`%s` TO %s", database, name, o.account()))
+	}
+	return out
+}
+
+// limitedDSN connects as the limited user to the endpoints of the DSN. The
+// pool it opens shares the endpoint the runner's pool uses.
+func (o Privilege) limitedDSN(database string) string {
+	return config.SetDSNUser(config.UpdateDatabaseInDSN(o.DSN, database), o.User, o.Password)
+}
+
+// privilegeObjects returns the sorted lowercase names of the tables and views
+// a statement reads, leaving out the names its WITH clauses define.
+func privilegeObjects(sqlText string) ([]string, error) {
+	stmt, err := parser.New().ParseOneStmt(sqlText, "", "")
+	if err != nil {
+		return nil, err
+	}
+	visitor := &privilegeObjectVisitor{tables: map[string]struct{}{}, ctes: map[string]struct{}{}}
+	stmt.Accept(visitor)
+	out := make([]string, 0, len(visitor.tables))
+	for name := range visitor.tables {
+		if _, ok := visitor.ctes[name]; !ok {
+			out = append(out, name)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+type privilegeObjectVisitor struct {
+	tables map[string]struct{}
+	ctes   map[string]struct{}
+}
+
+func (v *privilegeObjectVisitor) Enter(in ast.Node) (ast.Node, bool) {
+	switch node := in.(type) {
+	case *ast.WithClause:
+		for _, cte := range node.CTEs {
+			v.ctes[strings.ToLower(cte.Name.O)] = struct{}{}
+		}
+	case *ast.TableName:
+		if node.Schema.O == "" {
+			v.tables[strings.ToLower(node.Name.O)] = struct{}{}
+		}
+	}
+	return in, false
+}
+
+func (v *privilegeObjectVisitor) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
@@ -0,0 +1,95 @@
+package oracle
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"shiro/internal/config"
+	"shiro/internal/db"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestPrivilegeObjects(t *testing.T) {
+	cases := []struct {
+		sql  string
+		want []string
+	}{
+		{"SELECT t0.c0 FROM t0 JOIN T1 ON t0.id = T1.id", []string{"t0", "t1"}},
+		{"WITH cte_0 AS (SELECT c0 FROM t2) SELECT * FROM cte_0 WHERE c0 IN (SELECT c0 FROM v0)", []string{"t2", "v0"}},
+		{"SELECT 1 FROM t0 UNION ALL SELECT 1 FROM t0", []string{"t0"}},
+		{"SELECT 1", []string{}},
+	}
+	for _, tc := range cases {
+		got, err := privilegeObjects(tc.sql)
+		if err != nil {
+			t.Fatalf("parse %q: %v", tc.sql, err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("objects of %q = %v, want %v", tc.sql, got, tc.want)
+		}
+	}
+}
+
+func TestPrivilegeOutcome(t *testing.T) {
+	base := db.Signature{Count: 3, Checksum: 42}
+	denied := &mysql.MySQLError{Number: 1142, Message: "SELECT command denied"}
+	other := &mysql.MySQLError{Number: 1105, Message: "unknown"}
+	cases := []struct {
+		variant string
+		sig     db.Signature
+		err     error
+		want    string
+	}{
+		{privilegeVariantTableGrants, base, nil, ""},
+		{privilegeVariantDBGrant, db.Signature{Count: 2, Checksum: 42}, nil, "privilege:mismatch"},
+		{privilegeVariantTableGrants, db.Signature{}, denied, "privilege:unexpected_denial"},
+		{privilegeVariantTableGrants, db.Signature{}, other, "privilege:error"},
+		{privilegeVariantDenied, db.Signature{}, denied, ""},
+		{privilegeVariantDenied, base, nil, "privilege:bypass"},
+		{privilegeVariantDenied, db.Signature{}, errors.New("bad connection"), "privilege:error"},
+	}
+	for i, tc := range cases {
+		if got := privilegeOutcome(tc.variant, base, tc.sig, tc.err); got != tc.want {
+			t.Fatalf("case %d: outcome = %q, want %q", i, got, tc.want)
+		}
+	}
+}
+
+func TestPrivilegeSetupSQL(t *testing.T) {
+	o := NewPrivilege(config.Config{Database: "shiro"})
+	if !strings.HasPrefix(o.User, "shiro_priv_") || o.User == NewPrivilege(config.Config{Database: "other"}).User {
+		t.Fatalf("unexpected user %q", o.User)
+	}
+	stmts := o.setupSQL("shiro_r1", privilegeVariantDenied, []string{"t0", "v0"}, "v0")
+	if len(stmts) != 3 || !strings.HasPrefix(stmts[0], "DROP USER IF EXISTS") || !strings.HasPrefix(stmts[1], "CREATE USER") {
+		t.Fatalf("unexpected setup: %v", stmts)
+	}
+	if !strings.Contains(stmts[2], "`shiro_r1`.`t0`") {
+		t.Fatalf("expected grant on t0, got %s", stmts[2])
+	}
+	stmts = o.setupSQL("shiro_r1", privilegeVariantDBGrant, []string{"t0"}, "")
+	if got := stmts[len(stmts)-1]; !strings.Contains(got, "ON `shiro_r1`.* TO") {
+		t.Fatalf("expected database grant, got %s", got)
+	}
+}
+
+func TestPrivilegeLimitedDSN(t *testing.T) {
+	o := NewPrivilege(config.Config{DSN: "root:pw@tcp(h1:4000,h2:4000)/shiro?parseTime=true", Database: "shiro"})
+	dsn := o.limitedDSN("shiro_r1")
+	hosts := config.SplitDSNHosts(dsn)
+	if len(hosts) != 2 {
+		t.Fatalf("limited dsn %q must keep every endpoint", dsn)
+	}
+	for i, host := range hosts {
+		cfg, err := mysql.ParseDSN(host)
+		if err != nil {
+			t.Fatalf("parse %q: %v", host, err)
+		}
+		if cfg.User != o.User || cfg.Passwd != o.Password || cfg.Addr != []string{"h1:4000", "h2:4000"}[i] || cfg.DBName != "shiro_r1" {
+			t.Fatalf("unexpected limited dsn %q", dsn)
+		}
+	}
+}
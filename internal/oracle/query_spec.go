@@ -3,6 +3,9 @@ package oracle
 import "shiro/internal/generator"
 
 // QuerySpec describes generator constraints and predicate policies for an oracle.
+// For oracles in the compatibility matrix, the static feature constraints and
+// the predicate mode come from the matrix; Constraints then only carries
+// guards.
 type QuerySpec struct {
 	Oracle              string
 	Constraints         generator.SelectQueryConstraints
@@ -14,12 +17,7 @@ type QuerySpec struct {
 }
 
 func buildQueryWithSpec(gen *generator.Generator, spec QuerySpec) (*generator.SelectQuery, map[string]any) {
-	if spec.Profile != nil {
-		applyProfileToSpec(&spec.Constraints, spec.Profile)
-		if spec.Profile.PredicateMode != nil {
-			spec.Constraints.PredicateMode = *spec.Profile.PredicateMode
-		}
-	}
+	spec.Constraints = querySpecConstraints(gen, spec.Oracle, spec.Profile, spec.Constraints)
 	builder := generator.NewSelectQueryBuilder(gen).WithConstraints(spec.Constraints)
 	if spec.MaxTries > 0 {
 		builder.MaxTries(spec.MaxTries)
@@ -30,21 +28,32 @@ func buildQueryWithSpec(gen *generator.Generator, spec QuerySpec) (*generator.Se
 			return predicateMatches(expr, policy)
 		})
 	}
-	query, reason, attempts := builder.BuildWithReason()
+	query, diag := builder.BuildWithDiagnostics()
 	if query == nil {
-		skipReason := builderSkipReason(spec.Oracle, reason)
-		if override, ok := spec.SkipReasonOverrides[reason]; ok {
-			skipReason = override
-		}
-		return nil, map[string]any{
-			"skip_reason":      skipReason,
-			"builder_reason":   reason,
-			"builder_attempts": attempts,
+		details := builderFailureDetails(spec.Oracle, diag)
+		if override, ok := spec.SkipReasonOverrides[diag.Reason]; ok {
+			details["skip_reason"] = override
 		}
+		return nil, details
 	}
 	return query, nil
 }
 
+// querySpecConstraints returns the constraints the builder enforces for an
+// oracle: dst with the profile applied, then the matrix row with its config
+// overrides. The row goes last so that it, as logged at startup, is what
+// queries follow.
+func querySpecConstraints(gen *generator.Generator, name string, profile *Profile, dst generator.SelectQueryConstraints) generator.SelectQueryConstraints {
+	if profile != nil {
+		applyProfileToSpec(&dst, profile)
+		if profile.PredicateMode != nil {
+			dst.PredicateMode = *profile.PredicateMode
+		}
+	}
+	applyCompat(gen, name, &dst)
+	return dst
+}
+
 func applyProfileToSpec(dst *generator.SelectQueryConstraints, profile *Profile) {
 	if dst == nil || profile == nil {
 		return
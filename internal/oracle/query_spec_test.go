@@ -72,30 +72,11 @@ func TestBuildQueryWithSpecAppliesProfileConstraints(t *testing.T) {
 	}
 }
 
-func TestPredicateModeProfilesForDQPAndTLP(t *testing.T) {
-	dqp := ProfileByName("DQP")
-	if dqp == nil {
-		t.Fatalf("expected DQP profile predicate mode")
-		return
-	}
-	if dqp.PredicateMode == nil {
-		t.Fatalf("expected DQP profile predicate mode")
-		return
-	}
-	if *dqp.PredicateMode != generator.PredicateModeSimpleColumns {
-		t.Fatalf("unexpected DQP predicate mode: %v", *dqp.PredicateMode)
-	}
-	tlp := ProfileByName("TLP")
-	if tlp == nil {
-		t.Fatalf("expected TLP profile predicate mode")
-		return
-	}
-	if tlp.PredicateMode == nil {
-		t.Fatalf("expected TLP profile predicate mode")
-		return
-	}
-	if *tlp.PredicateMode != generator.PredicateModeSimpleColumns {
-		t.Fatalf("unexpected TLP predicate mode: %v", *tlp.PredicateMode)
+func TestPredicateModeRowsForDQPAndTLP(t *testing.T) {
+	for _, name := range []string{"dqp", "tlp"} {
+		if mode := compatMatrix[name].PredicateMode; mode != generator.PredicateModeSimpleColumns {
+			t.Fatalf("unexpected %s predicate mode: %v", name, mode)
+		}
 	}
 }
 
@@ -138,3 +119,21 @@ func newProfileTestGenerator(t *testing.T) *generator.Generator {
 	}
 	return generator.New(cfg, &state, 11)
 }
+
+func TestBuilderFailureDetails(t *testing.T) {
+	details := builderFailureDetails("dqp", generator.BuildDiagnostics{
+		Reason:     "dqp:complexity",
+		Attempts:   3,
+		Rejections: map[string]int{"dqp:complexity": 2, "constraint:limit": 1},
+	})
+	if details["skip_reason"] != "dqp:builder_dqp_complexity" || details["builder_attempts"] != 3 {
+		t.Fatalf("unexpected details: %v", details)
+	}
+	rates, ok := details["builder_rejection_rates"].(map[string]float64)
+	if !ok || rates["dqp:complexity"] != 0.667 || rates["constraint:limit"] != 0.333 {
+		t.Fatalf("unexpected rates: %v", details["builder_rejection_rates"])
+	}
+	if _, ok := builderFailureDetails("dqp", generator.BuildDiagnostics{Attempts: 1})["builder_rejections"]; ok {
+		t.Fatalf("rejections must be omitted when nothing was rejected")
+	}
+}
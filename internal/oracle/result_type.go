@@ -0,0 +1,171 @@
+package oracle
+
+import (
+	"context"
+	"strings"
+
+	"shiro/internal/db"
+	"shiro/internal/generator"
+	"shiro/internal/schema"
+	"shiro/internal/sqlstep"
+)
+
+const resultTypeMaxVariants = 4
+
+const (
+	resultTypeFieldCount    = "column_count"
+	resultTypeFieldType     = "type"
+	resultTypeFieldNullable = "nullable"
+	resultTypeFieldDecimal  = "decimal"
+	resultTypeFieldLength   = "length"
+)
+
+// ResultType implements the result metadata oracle.
+//
+// It runs one query under the base plan and under DQP hint variants and
+// compares the result-set column metadata the server sends: type, nullability,
+// decimal size, and length. Type inference must not depend on the plan, so a
+// divergence is reported even when the values match. Drivers decode rows by
+// this metadata, and checksum-based oracles never see it.
+//
+// Example:
+//
+//	Base:    SELECT t0.c1 + 1.5 AS c0 FROM t0 JOIN t1 ON t0.id = t1.id   -- DECIMAL(12,1)
+//	Variant: SELECT /*+ HASH_JOIN(t0, t1) */ t0.c1 + 1.5 AS c0 FROM ...  -- DOUBLE
+type ResultType struct{}
+
+// Name returns the oracle identifier.
+func (o ResultType) Name() string { return "ResultType" }
+
+// Run builds a query, reads the base column metadata, and compares it with up
+// to resultTypeMaxVariants hinted variants.
+func (o ResultType) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, state *schema.State) Result {
+	spec := QuerySpec{
+		Oracle:          "result_type",
+		Profile:         ProfileByName("ResultType"),
+		PredicatePolicy: predicatePolicyFor(gen),
+		PredicateGuard:  true,
+		MaxTries:        dqpBuildMaxTries,
+		SkipReasonOverrides: map[string]string{
+			"constraint:limit":            "result_type:limit",
+			"constraint:window":           "result_type:window",
+			"constraint:set_ops":          "result_type:set_ops",
+			"constraint:nondeterministic": "result_type:nondeterministic",
+			"constraint:predicate_guard":  "result_type:predicate_guard",
+		},
+	}
+	query, details := buildQueryWithSpec(gen, spec)
+	if query == nil {
+		return Result{OK: true, Oracle: o.Name(), Details: details}
+	}
+	if !gen.ValidateQueryScope(query) {
+		return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "result_type:scope_invalid"}}
+	}
+	hasSubquery := queryHasSubquery(query)
+	hasSemi := queryHasSemiJoinSubquery(query)
+	hasCorr := queryHasCorrelatedSubquery(query)
+	hasAgg := queryHasAggregate(query) || len(query.GroupBy) > 0 || query.Having != nil
+	if len(query.From.Joins) == 0 && !hasAgg && !hasSubquery && !queryHasIndexCandidate(query, state) {
+		return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "result_type:insufficient_features"}}
+	}
+
+	baseSQL := query.SQLString()
+	metrics := map[string]int64{}
+	baseMeta, err := exec.QueryColumnMeta(ctx, baseSQL)
+	if err != nil {
+		steps := []sqlstep.Step{sqlstep.New(sqlstep.KindQuery, sqlstep.RoleFailing, baseSQL)}
+		return sqlErrorResult(o.Name(), "result_type", steps, metrics, nil, err, baseSQL)
+	}
+	variants, _ := buildDQPVariants(nil, query, state, hasSemi, hasCorr, hasAgg, hasSubquery, len(query.With) > 0, queryHasPartitionedTable(query, state), gen)
+	gen.Rand.Shuffle(len(variants), func(i, j int) { variants[i], variants[j] = variants[j], variants[i] })
+	checked := 0
+	for _, variant := range variants {
+		if checked >= resultTypeMaxVariants {
+			break
+		}
+		if variant.sql == baseSQL {
+			continue
+		}
+		checked++
+		metrics["result_type_variant_"+variant.group+"_total"]++
+		meta, err := exec.QueryColumnMeta(ctx, variant.sql)
+		if err != nil {
+			// Variant errors are plan-dependent failures that DQP reports.
+			metrics["result_type_variant_error_total"]++
+			continue
+		}
+		column, field := diffColumnMeta(baseMeta, meta)
+		if field == "" {
+			continue
+		}
+		steps := []sqlstep.Step{
+			sqlstep.New(sqlstep.KindQuery, sqlstep.RoleExpected, baseSQL),
+			sqlstep.New(sqlstep.KindQuery, sqlstep.RoleActual, variant.sql),
+		}
+		details := map[string]any{
+			"hint":              variant.hint,
+			"result_type_field": field,
+		}
+		if column >= 0 {
+			details["result_type_column"] = baseMeta[column].Name
+		}
+		if match, ok := resultTypeValuesMatch(ctx, exec, query.SignatureSQL(), variant.signatureSQL); ok {
+			details["result_type_values_match"] = match
+		}
+		return Result{
+			OK:       false,
+			Oracle:   o.Name(),
+			Steps:    steps,
+			Expected: formatColumnMeta(baseMeta),
+			Actual:   formatColumnMeta(meta),
+			Details:  details,
+			Metrics:  metrics,
+		}
+	}
+	return Result{OK: true, Oracle: o.Name(), Steps: []sqlstep.Step{queryStep(sqlstep.RoleExpected, baseSQL)}, Metrics: metrics}
+}
+
+// diffColumnMeta returns the first column whose metadata differs and the
+// differing field, or an empty field when both sides agree. A column count
+// mismatch returns column -1.
+func diffColumnMeta(expected []db.ColumnMeta, actual []db.ColumnMeta) (int, string) {
+	if len(expected) != len(actual) {
+		return -1, resultTypeFieldCount
+	}
+	for i := range expected {
+		want, got := expected[i], actual[i]
+		switch {
+		case want.Type != got.Type:
+			return i, resultTypeFieldType
+		case want.Nullable != got.Nullable:
+			return i, resultTypeFieldNullable
+		case want.Precision != got.Precision || want.Scale != got.Scale:
+			return i, resultTypeFieldDecimal
+		case want.Length != got.Length:
+			return i, resultTypeFieldLength
+		}
+	}
+	return -1, ""
+}
+
+func formatColumnMeta(metas []db.ColumnMeta) string {
+	parts := make([]string, 0, len(metas))
+	for _, meta := range metas {
+		parts = append(parts, meta.String())
+	}
+	return strings.Join(parts, ", ")
+}
+
+// resultTypeValuesMatch tells whether a metadata mismatch also changed the
+// values. ok is false when either signature query fails.
+func resultTypeValuesMatch(ctx context.Context, exec *db.DB, baseSignatureSQL string, variantSignatureSQL string) (match bool, ok bool) {
+	baseSig, err := exec.QuerySignature(ctx, baseSignatureSQL)
+	if err != nil {
+		return false, false
+	}
+	variantSig, err := exec.QuerySignature(ctx, variantSignatureSQL)
+	if err != nil {
+		return false, false
+	}
+	return baseSig == variantSig, true
+}
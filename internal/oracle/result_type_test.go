@@ -0,0 +1,50 @@
+package oracle
+
+import (
+	"testing"
+
+	"shiro/internal/db"
+)
+
+func TestDiffColumnMeta(t *testing.T) {
+	base := []db.ColumnMeta{
+		{Name: "c0", Type: "DECIMAL", Nullable: true, Precision: 12, Scale: 1},
+		{Name: "c1", Type: "VARCHAR", Length: 20},
+	}
+	clone := func(edit func([]db.ColumnMeta)) []db.ColumnMeta {
+		out := append([]db.ColumnMeta(nil), base...)
+		edit(out)
+		return out
+	}
+	tests := []struct {
+		name       string
+		actual     []db.ColumnMeta
+		wantColumn int
+		wantField  string
+	}{
+		{name: "equal", actual: clone(func([]db.ColumnMeta) {}), wantColumn: -1},
+		{name: "count", actual: base[:1], wantColumn: -1, wantField: resultTypeFieldCount},
+		{name: "type", actual: clone(func(m []db.ColumnMeta) { m[0].Type = "DOUBLE" }), wantColumn: 0, wantField: resultTypeFieldType},
+		{name: "nullable", actual: clone(func(m []db.ColumnMeta) { m[0].Nullable = false }), wantColumn: 0, wantField: resultTypeFieldNullable},
+		{name: "decimal", actual: clone(func(m []db.ColumnMeta) { m[0].Scale = 2 }), wantColumn: 0, wantField: resultTypeFieldDecimal},
+		{name: "length", actual: clone(func(m []db.ColumnMeta) { m[1].Length = 80 }), wantColumn: 1, wantField: resultTypeFieldLength},
+	}
+	for _, tt := range tests {
+		column, field := diffColumnMeta(base, tt.actual)
+		if column != tt.wantColumn || field != tt.wantField {
+			t.Fatalf("%s: diffColumnMeta()=(%d, %q) want=(%d, %q)", tt.name, column, field, tt.wantColumn, tt.wantField)
+		}
+	}
+}
+
+func TestFormatColumnMeta(t *testing.T) {
+	metas := []db.ColumnMeta{
+		{Name: "c0", Type: "DECIMAL", Nullable: true, Precision: 12, Scale: 1},
+		{Name: "c1", Type: "VARCHAR", Length: 20},
+		{Name: "c2", Type: "BIGINT"},
+	}
+	want := "c0 DECIMAL(12,1) NULL, c1 VARCHAR(20) NOT NULL, c2 BIGINT NOT NULL"
+	if got := formatColumnMeta(metas); got != want {
+		t.Fatalf("formatColumnMeta()=%q want=%q", got, want)
+	}
+}
@@ -0,0 +1,361 @@
+package oracle
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"shiro/internal/db"
+	"shiro/internal/generator"
+	"shiro/internal/schema"
+	"shiro/internal/sqlstep"
+	"shiro/internal/util"
+)
+
+const (
+	savepointOpsMin         = 4
+	savepointOpsMax         = 10
+	savepointReuseNameProb  = 20
+	savepointStaleNameProb  = 15
+	savepointErrDoesntExist = 1305
+)
+
+// Savepoint operation kinds.
+const (
+	savepointOpWrite    = "write"
+	savepointOpSave     = "savepoint"
+	savepointOpRollback = "rollback_to"
+	savepointOpRelease  = "release"
+)
+
+// Savepoint implements the savepoint semantics oracle.
+//
+// It opens a transaction and interleaves INSERT/UPDATE/DELETE with SAVEPOINT,
+// ROLLBACK TO SAVEPOINT, and RELEASE SAVEPOINT. A client-side model keeps the
+// savepoint stack and the table signature (count and checksum) recorded at
+// each savepoint:
+//   - ROLLBACK TO sp must restore the signature recorded at sp and drop the
+//     savepoints set after sp.
+//   - RELEASE SAVEPOINT sp must leave the data unchanged and drop sp and the
+//     savepoints set after it.
+//   - SAVEPOINT with an existing name replaces the old savepoint.
+//   - ROLLBACK TO a dropped savepoint must fail.
+//
+// At the end the visible state is read through a table scan and an index read,
+// and after ROLLBACK the table must match the pre-transaction snapshot.
+//
+// Example:
+//
+//	BEGIN
+//	SAVEPOINT sp0                        -- model: sp0 = S0
+//	UPDATE t0 SET c1 = c1 + 1 WHERE ...  -- S1
+//	SAVEPOINT sp1                        -- model: sp1 = S1
+//	DELETE FROM t0 WHERE ...             -- S2
+//	ROLLBACK TO SAVEPOINT sp0            -- must read S0; sp1 is dropped
+//	ROLLBACK TO SAVEPOINT sp1            -- must fail with error 1305
+//	ROLLBACK
+type Savepoint struct{}
+
+// Name returns the oracle identifier.
+func (o Savepoint) Name() string { return "Savepoint" }
+
+// savepointEntry is one savepoint of the model stack.
+type savepointEntry struct {
+	name string
+	sig  db.Signature
+}
+
+// savepointModel tracks the savepoint stack of one transaction. Dropped holds
+// names that were released or rolled past and not set again.
+type savepointModel struct {
+	stack   []savepointEntry
+	dropped map[string]bool
+	current db.Signature
+}
+
+func newSavepointModel(sig db.Signature) *savepointModel {
+	return &savepointModel{dropped: make(map[string]bool), current: sig}
+}
+
+func (m *savepointModel) index(name string) int {
+	for i, entry := range m.stack {
+		if entry.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// save sets a savepoint at the current state. An existing savepoint with the
+// same name is removed first.
+func (m *savepointModel) save(name string) {
+	if idx := m.index(name); idx >= 0 {
+		m.stack = append(m.stack[:idx], m.stack[idx+1:]...)
+	}
+	delete(m.dropped, name)
+	m.stack = append(m.stack, savepointEntry{name: name, sig: m.current})
+}
+
+// rollbackTo restores the state of a savepoint and drops the later ones. It
+// returns false when the savepoint does not exist.
+func (m *savepointModel) rollbackTo(name string) bool {
+	idx := m.index(name)
+	if idx < 0 {
+		return false
+	}
+	for _, entry := range m.stack[idx+1:] {
+		m.dropped[entry.name] = true
+	}
+	m.stack = m.stack[:idx+1]
+	m.current = m.stack[idx].sig
+	return true
+}
+
+// release drops a savepoint and the later ones without changing the state.
+func (m *savepointModel) release(name string) bool {
+	idx := m.index(name)
+	if idx < 0 {
+		return false
+	}
+	for _, entry := range m.stack[idx:] {
+		m.dropped[entry.name] = true
+	}
+	m.stack = m.stack[:idx]
+	return true
+}
+
+func (m *savepointModel) pickLive(r *rand.Rand) string {
+	return m.stack[r.Intn(len(m.stack))].name
+}
+
+func (m *savepointModel) pickDropped(r *rand.Rand) (string, bool) {
+	if len(m.dropped) == 0 {
+		return "", false
+	}
+	names := make([]string, 0, len(m.dropped))
+	for name := range m.dropped {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names[r.Intn(len(names))], true
+}
+
+// pickSavepointOp chooses the next operation kind. Writes dominate so the
+// savepoints have state to restore; rollbacks and releases need a savepoint.
+func pickSavepointOp(r *rand.Rand, live int) string {
+	if live == 0 {
+		if r.Intn(2) == 0 {
+			return savepointOpSave
+		}
+		return savepointOpWrite
+	}
+	switch n := r.Intn(10); {
+	case n < 4:
+		return savepointOpWrite
+	case n < 6:
+		return savepointOpSave
+	case n < 9:
+		return savepointOpRollback
+	default:
+		return savepointOpRelease
+	}
+}
+
+// Run executes the transaction on a dedicated connection so BEGIN, the
+// savepoints, and all reads share one session.
+func (o Savepoint) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, state *schema.State) Result {
+	if !state.HasBaseTables() {
+		return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "savepoint:no_base_tables"}}
+	}
+	baseTables := state.BaseTables()
+	tbl := baseTables[gen.Rand.Intn(len(baseTables))]
+	if len(tbl.Columns) == 0 {
+		return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "savepoint:no_columns"}}
+	}
+	readSQL := txnRYWSignatureSQL(tbl, "", "1")
+	variants := txnRYWVariants(tbl, "1")
+	metrics := map[string]int64{}
+
+	conn, err := exec.Conn(ctx)
+	if err != nil {
+		return Result{OK: true, Oracle: o.Name(), Err: err}
+	}
+	defer util.CloseWithErr(conn, "savepoint conn")
+
+	steps := []sqlstep.Step{sqlstep.New(sqlstep.KindQuery, "", readSQL)}
+	skip := func(reason string, err error) Result {
+		details := map[string]any{"skip_reason": "savepoint:" + reason}
+		if err != nil {
+			sqlErrorDetails(details, "savepoint", err)
+		}
+		return Result{OK: true, Oracle: o.Name(), Details: details, Metrics: metrics}
+	}
+
+	before, err := txnRYWQuerySignature(ctx, conn, readSQL)
+	if err != nil {
+		return sqlErrorResult(o.Name(), "savepoint", failedSteps(steps, sqlstep.KindQuery, readSQL), metrics, nil, err, readSQL)
+	}
+	if _, err := conn.ExecContext(ctx, "BEGIN"); err != nil {
+		return sqlErrorResult(o.Name(), "savepoint", failedSteps(steps, sqlstep.KindSetup, "BEGIN"), metrics, nil, err, "BEGIN")
+	}
+	steps = append(steps, sqlstep.New(sqlstep.KindSetup, "", "BEGIN"))
+	inTxn := true
+	defer func() {
+		if inTxn {
+			_, _ = conn.ExecContext(context.Background(), "ROLLBACK")
+		}
+	}()
+
+	model := newSavepointModel(before)
+	// InsertSQL advances NextID; work on a copy because the writes are rolled back.
+	tblCopy := tbl
+	nextName := 0
+	ops := savepointOpsMin + gen.Rand.Intn(savepointOpsMax-savepointOpsMin+1)
+	rolledBack := false
+	for i := 0; ; i++ {
+		// Every run issues at least one ROLLBACK TO when a savepoint exists.
+		forced := i >= ops
+		if forced && (rolledBack || len(model.stack) == 0) {
+			break
+		}
+		op := pickSavepointOp(gen.Rand, len(model.stack))
+		if forced {
+			op = savepointOpRollback
+		}
+		metrics["savepoint_op_"+op+"_total"]++
+		switch op {
+		case savepointOpWrite:
+			kind, stmt := pickSavepointWrite(gen, &tblCopy)
+			if stmt == "" {
+				continue
+			}
+			if _, err := conn.ExecContext(ctx, stmt); err != nil {
+				return skip("dml_failed", err)
+			}
+			metrics["savepoint_dml_"+kind+"_total"]++
+			steps = append(steps, sqlstep.New(sqlstep.KindSetup, "", stmt))
+			sig, err := txnRYWQuerySignature(ctx, conn, readSQL)
+			if err != nil {
+				return sqlErrorResult(o.Name(), "savepoint", failedSteps(steps, sqlstep.KindQuery, readSQL), metrics, nil, err, readSQL)
+			}
+			model.current = sig
+		case savepointOpSave:
+			name := fmt.Sprintf("sp%d", nextName)
+			if len(model.stack) > 0 && util.Chance(gen.Rand, savepointReuseNameProb) {
+				name = model.pickLive(gen.Rand)
+			} else {
+				nextName++
+			}
+			stmt := "SAVEPOINT " + name
+			if _, err := conn.ExecContext(ctx, stmt); err != nil {
+				return skip("unsupported", err)
+			}
+			steps = append(steps, sqlstep.New(sqlstep.KindSetup, "", stmt))
+			model.save(name)
+		case savepointOpRollback, savepointOpRelease:
+			name := model.pickLive(gen.Rand)
+			stale := false
+			if dropped, ok := model.pickDropped(gen.Rand); ok && op == savepointOpRollback && !forced && util.Chance(gen.Rand, savepointStaleNameProb) {
+				name, stale = dropped, true
+			}
+			stmt := "ROLLBACK TO SAVEPOINT " + name
+			if op == savepointOpRelease {
+				stmt = "RELEASE SAVEPOINT " + name
+			}
+			_, err := conn.ExecContext(ctx, stmt)
+			if stale {
+				if err == nil {
+					steps = append(steps, sqlstep.New(sqlstep.KindSetup, sqlstep.RoleActual, stmt))
+					return o.mismatch(steps, metrics, "dropped_savepoint", name,
+						fmt.Sprintf("error %d", savepointErrDoesntExist), "no error")
+				}
+				if code, ok := mysqlErrCode(err); !ok || code != savepointErrDoesntExist {
+					return sqlErrorResult(o.Name(), "savepoint", failedSteps(steps, sqlstep.KindSetup, stmt), metrics, nil, err, stmt)
+				}
+				metrics["savepoint_dropped_checked_total"]++
+				continue
+			}
+			if err != nil {
+				return sqlErrorResult(o.Name(), "savepoint", failedSteps(steps, sqlstep.KindSetup, stmt), metrics, nil, err, stmt)
+			}
+			steps = append(steps, sqlstep.New(sqlstep.KindSetup, "", stmt))
+			if op == savepointOpRollback {
+				model.rollbackTo(name)
+				rolledBack = true
+			} else {
+				model.release(name)
+			}
+			sig, err := txnRYWQuerySignature(ctx, conn, readSQL)
+			if err != nil {
+				return sqlErrorResult(o.Name(), "savepoint", failedSteps(steps, sqlstep.KindQuery, readSQL), metrics, nil, err, readSQL)
+			}
+			if sig != model.current {
+				steps = append(steps, sqlstep.New(sqlstep.KindQuery, sqlstep.RoleActual, readSQL))
+				return o.mismatch(steps, metrics, op, name, savepointSigString(model.current), savepointSigString(sig))
+			}
+		}
+	}
+
+	for _, variant := range variants {
+		metrics["savepoint_variant_"+variant.name+"_total"]++
+		sig, err := txnRYWQuerySignature(ctx, conn, variant.sql)
+		if err != nil {
+			continue
+		}
+		if sig != model.current {
+			steps = append(steps, sqlstep.New(sqlstep.KindQuery, sqlstep.RoleActual, variant.sql))
+			return o.mismatch(steps, metrics, "final_"+variant.name, "", savepointSigString(model.current), savepointSigString(sig))
+		}
+	}
+
+	if _, err := conn.ExecContext(ctx, "ROLLBACK"); err != nil {
+		return sqlErrorResult(o.Name(), "savepoint", failedSteps(steps, sqlstep.KindSetup, "ROLLBACK"), metrics, nil, err, "ROLLBACK")
+	}
+	inTxn = false
+	steps = append(steps, sqlstep.New(sqlstep.KindSetup, "", "ROLLBACK"))
+	after, err := txnRYWQuerySignature(ctx, conn, readSQL)
+	if err != nil {
+		return sqlErrorResult(o.Name(), "savepoint", failedSteps(steps, sqlstep.KindQuery, readSQL), metrics, nil, err, readSQL)
+	}
+	if after != before {
+		steps[0].Role = sqlstep.RoleExpected
+		steps = append(steps, sqlstep.New(sqlstep.KindQuery, sqlstep.RoleActual, readSQL))
+		return o.mismatch(steps, metrics, "after_rollback", "", savepointSigString(before), savepointSigString(after))
+	}
+	return Result{OK: true, Oracle: o.Name(), Steps: steps, Metrics: metrics}
+}
+
+func (o Savepoint) mismatch(steps []sqlstep.Step, metrics map[string]int64, phase string, name string, expected string, actual string) Result {
+	details := map[string]any{"savepoint_phase": phase}
+	if name != "" {
+		details["savepoint_name"] = name
+	}
+	return Result{
+		OK:       false,
+		Oracle:   o.Name(),
+		Steps:    steps,
+		Expected: expected,
+		Actual:   actual,
+		Details:  details,
+		Metrics:  metrics,
+	}
+}
+
+// pickSavepointWrite returns one INSERT/UPDATE/DELETE on the table copy.
+func pickSavepointWrite(gen *generator.Generator, tbl *schema.Table) (kind string, sqlText string) {
+	switch gen.Rand.Intn(3) {
+	case 0:
+		return "insert", gen.InsertSQL(tbl)
+	case 1:
+		updateSQL, _, _, _ := gen.UpdateSQL(*tbl)
+		return "update", updateSQL
+	default:
+		deleteSQL, _ := gen.DeleteSQL(*tbl)
+		return "delete", deleteSQL
+	}
+}
+
+func savepointSigString(sig db.Signature) string {
+	return fmt.Sprintf("cnt=%d checksum=%d", sig.Count, sig.Checksum)
+}
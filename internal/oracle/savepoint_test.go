@@ -0,0 +1,69 @@
+package oracle
+
+import (
+	"math/rand"
+	"testing"
+
+	"shiro/internal/db"
+)
+
+func TestSavepointModel(t *testing.T) {
+	s0 := db.Signature{Count: 1, Checksum: 10}
+	s1 := db.Signature{Count: 2, Checksum: 20}
+	s2 := db.Signature{Count: 3, Checksum: 30}
+	m := newSavepointModel(s0)
+	m.save("sp0")
+	m.current = s1
+	m.save("sp1")
+	m.current = s2
+	m.save("sp2")
+
+	if !m.rollbackTo("sp1") || m.current != s1 {
+		t.Fatalf("rollback to sp1: current=%+v want=%+v", m.current, s1)
+	}
+	if m.index("sp2") >= 0 || !m.dropped["sp2"] {
+		t.Fatalf("sp2 should be dropped after rollback to sp1: %+v", m.stack)
+	}
+	if m.index("sp1") != 1 {
+		t.Fatalf("sp1 should survive rollback to itself: %+v", m.stack)
+	}
+
+	// Re-setting an existing name moves it to the top with the new state.
+	m.current = s2
+	m.save("sp0")
+	if len(m.stack) != 2 || m.stack[1].name != "sp0" || m.stack[1].sig != s2 {
+		t.Fatalf("unexpected stack after re-save: %+v", m.stack)
+	}
+
+	if !m.release("sp1") || m.current != s2 {
+		t.Fatalf("release changed state: current=%+v", m.current)
+	}
+	if len(m.stack) != 0 || !m.dropped["sp0"] || !m.dropped["sp1"] {
+		t.Fatalf("release should drop sp1 and later savepoints: stack=%+v dropped=%v", m.stack, m.dropped)
+	}
+	if m.rollbackTo("sp1") || m.release("sp0") {
+		t.Fatalf("dropped savepoints should not be found")
+	}
+
+	m.save("sp1")
+	if m.dropped["sp1"] {
+		t.Fatalf("re-set savepoint should not be marked dropped")
+	}
+}
+
+func TestPickSavepointOp(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	seen := map[string]bool{}
+	for i := 0; i < 200; i++ {
+		op := pickSavepointOp(r, 0)
+		if op != savepointOpWrite && op != savepointOpSave {
+			t.Fatalf("op %q picked without savepoints", op)
+		}
+		seen[pickSavepointOp(r, 2)] = true
+	}
+	for _, op := range []string{savepointOpWrite, savepointOpSave, savepointOpRollback, savepointOpRelease} {
+		if !seen[op] {
+			t.Fatalf("op %q never picked with live savepoints", op)
+		}
+	}
+}
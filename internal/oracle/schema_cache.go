@@ -0,0 +1,117 @@
+package oracle
+
+import (
+	"sync"
+
+	"shiro/internal/schema"
+)
+
+// epochCacheMaxEpochs bounds how many schema epochs a cache keeps. Runners
+// in one process (worker mode) each have their own epochs, so a cache holds a
+// few of them instead of thrashing on every switch.
+const epochCacheMaxEpochs = 8
+
+// epochCache memoizes artifacts derived from the schema, keyed by the schema
+// epoch, so oracles stop recomputing them on every run. Epochs change on
+// every DDL; epoch 0 (a state that was never bumped) is not cached. It is
+// safe for concurrent use by pipelined oracle runs.
+type epochCache[K comparable, V any] struct {
+	mu     sync.Mutex
+	epochs map[uint64]map[K]V
+}
+
+// get returns the value cached for key at epoch, building it on a miss.
+func (c *epochCache[K, V]) get(epoch uint64, key K, build func() V) V {
+	if epoch == 0 {
+		return build()
+	}
+	c.mu.Lock()
+	if value, ok := c.epochs[epoch][key]; ok {
+		c.mu.Unlock()
+		return value
+	}
+	c.mu.Unlock()
+	value := build()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.epochs == nil {
+		c.epochs = make(map[uint64]map[K]V)
+	}
+	values := c.epochs[epoch]
+	if values == nil {
+		if len(c.epochs) >= epochCacheMaxEpochs {
+			c.evictOldestLocked()
+		}
+		values = make(map[K]V)
+		c.epochs[epoch] = values
+	}
+	values[key] = value
+	return value
+}
+
+func (c *epochCache[K, V]) evictOldestLocked() {
+	var oldest uint64
+	for epoch := range c.epochs {
+		if oldest == 0 || epoch < oldest {
+			oldest = epoch
+		}
+	}
+	delete(c.epochs, oldest)
+}
+
+// tableTraits are the per-table facts oracles look up while picking hints
+// and rewrites.
+type tableTraits struct {
+	hasPK       bool
+	hasIndex    bool
+	indexCount  int
+	partitioned bool
+	columnTypes map[string]schema.ColumnType
+}
+
+type tableTraitsEntry struct {
+	traits tableTraits
+	ok     bool
+}
+
+var tableTraitsCache epochCache[string, tableTraitsEntry]
+
+// lookupTableTraits returns the traits of the named table, cached per schema
+// epoch.
+func lookupTableTraits(state *schema.State, name string) (tableTraits, bool) {
+	if state == nil || name == "" {
+		return tableTraits{}, false
+	}
+	entry := tableTraitsCache.get(state.Epoch(), name, func() tableTraitsEntry {
+		tbl, ok := state.TableByName(name)
+		if !ok {
+			return tableTraitsEntry{}
+		}
+		return tableTraitsEntry{traits: newTableTraits(tbl), ok: true}
+	})
+	return entry.traits, entry.ok
+}
+
+func newTableTraits(tbl schema.Table) tableTraits {
+	traits := tableTraits{
+		hasPK:       tbl.HasPK,
+		hasIndex:    tableHasIndex(tbl),
+		indexCount:  dqpTableIndexCount(tbl),
+		partitioned: tbl.Partitioned,
+		columnTypes: make(map[string]schema.ColumnType, len(tbl.Columns)),
+	}
+	for _, col := range tbl.Columns {
+		traits.columnTypes[col.Name] = col.Type
+	}
+	return traits
+}
+
+// lookupColumnType returns the type of table.column, cached per schema epoch.
+func lookupColumnType(state *schema.State, table string, column string) (schema.ColumnType, bool) {
+	traits, ok := lookupTableTraits(state, table)
+	if !ok {
+		return 0, false
+	}
+	typ, ok := traits.columnTypes[column]
+	return typ, ok
+}
@@ -0,0 +1,62 @@
+package oracle
+
+import (
+	"testing"
+
+	"shiro/internal/schema"
+)
+
+func TestLookupTableTraitsCachesPerEpoch(t *testing.T) {
+	state := &schema.State{Tables: []schema.Table{{
+		Name:    "t0",
+		HasPK:   true,
+		Columns: []schema.Column{{Name: "id", Type: schema.TypeBigInt}, {Name: "c0", Type: schema.TypeVarchar, HasIndex: true}},
+	}}}
+	state.BumpEpoch()
+	traits, ok := lookupTableTraits(state, "t0")
+	if !ok || !traits.hasPK || !traits.hasIndex || traits.indexCount != 2 {
+		t.Fatalf("unexpected traits: %+v ok=%v", traits, ok)
+	}
+	if typ, ok := lookupColumnType(state, "t0", "c0"); !ok || typ != schema.TypeVarchar {
+		t.Fatalf("unexpected column type: %v ok=%v", typ, ok)
+	}
+
+	// Without a new epoch the cached traits are served.
+	state.Tables[0].Partitioned = true
+	if traits, _ := lookupTableTraits(state, "t0"); traits.partitioned {
+		t.Fatalf("expected cached traits within an epoch")
+	}
+	state.BumpEpoch()
+	if traits, _ := lookupTableTraits(state, "t0"); !traits.partitioned {
+		t.Fatalf("expected rebuilt traits after the epoch bump")
+	}
+	if _, ok := lookupTableTraits(state, "t9"); ok {
+		t.Fatalf("unknown table must not resolve")
+	}
+
+	// Epoch 0 is never cached.
+	fresh := &schema.State{Tables: []schema.Table{{Name: "t0"}}}
+	if _, ok := lookupTableTraits(fresh, "t0"); !ok {
+		t.Fatalf("expected traits of an unbumped state")
+	}
+	fresh.Tables = nil
+	if _, ok := lookupTableTraits(fresh, "t0"); ok {
+		t.Fatalf("epoch 0 lookups must not be cached")
+	}
+}
+
+func TestEpochCacheEvictsOldestEpoch(t *testing.T) {
+	var cache epochCache[string, int]
+	for epoch := uint64(1); epoch <= epochCacheMaxEpochs+1; epoch++ {
+		cache.get(epoch, "k", func() int { return int(epoch) })
+	}
+	if len(cache.epochs) != epochCacheMaxEpochs {
+		t.Fatalf("cache holds %d epochs, want %d", len(cache.epochs), epochCacheMaxEpochs)
+	}
+	if _, ok := cache.epochs[1]; ok {
+		t.Fatalf("oldest epoch should be evicted")
+	}
+	if got := cache.get(2, "k", func() int { return -1 }); got != 2 {
+		t.Fatalf("expected cached value 2, got %d", got)
+	}
+}
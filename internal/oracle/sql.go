@@ -2,6 +2,7 @@ package oracle
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"strings"
@@ -14,6 +15,10 @@ import (
 	"github.com/go-sql-driver/mysql"
 )
 
+// sqlNullValue marks a NULL column in rows read by queryRowStrings, so it
+// stays distinct from the string "NULL".
+const sqlNullValue = "\x00NULL"
+
 type predicatePolicy struct {
 	allowOr       bool
 	allowNot      bool
@@ -1357,6 +1362,11 @@ func exprHasAggregate(expr generator.Expr) bool {
 			return false
 		}
 		return exprHasAggregate(e.Expr)
+	case generator.NameRefExpr:
+		if e.Expr == nil {
+			return false
+		}
+		return exprHasAggregate(e.Expr)
 	case generator.CompareSubqueryExpr:
 		if exprHasAggregate(e.Left) {
 			return true
@@ -1412,6 +1422,11 @@ func exprHasSubquery(expr generator.Expr) bool {
 			return false
 		}
 		return exprHasSubquery(e.Expr)
+	case generator.NameRefExpr:
+		if e.Expr == nil {
+			return false
+		}
+		return exprHasSubquery(e.Expr)
 	default:
 		return false
 	}
@@ -1525,3 +1540,27 @@ func isSimpleOperand(expr generator.Expr) bool {
 		return false
 	}
 }
+
+// queryRowStrings reads all rows as strings, with sqlNullValue for NULL so it
+// stays distinct from the string "NULL". guard bounds the rows and bytes read.
+func queryRowStrings(ctx context.Context, conn *sql.Conn, guard db.ResultGuard, query string) ([][]string, error) {
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer util.CloseWithErr(rows, "oracle rows")
+	var out [][]string
+	err = guard.ScanRows(rows, func(values []sql.RawBytes) error {
+		row := make([]string, len(values))
+		for i, v := range values {
+			if v == nil {
+				row[i] = sqlNullValue
+			} else {
+				row[i] = string(v)
+			}
+		}
+		out = append(out, row)
+		return nil
+	})
+	return out, err
+}
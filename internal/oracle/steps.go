@@ -0,0 +1,64 @@
+package oracle
+
+import (
+	"strings"
+
+	"shiro/internal/sqlstep"
+)
+
+// SQL returns the statement text of the result steps in order.
+func (r Result) SQL() []string {
+	return sqlstep.SQL(r.Steps)
+}
+
+// queryStep records a statement whose result the oracle reads.
+func queryStep(role sqlstep.Role, sqlText string) sqlstep.Step {
+	return sqlstep.New(sqlstep.KindQuery, role, sqlText)
+}
+
+// comparisonSteps records the reference query and the query checked against it.
+func comparisonSteps(expected, actual string) []sqlstep.Step {
+	return []sqlstep.Step{
+		queryStep(sqlstep.RoleExpected, expected),
+		queryStep(sqlstep.RoleActual, actual),
+	}
+}
+
+// sqlErrorResult reports a statement error of oracleName as a skipped run.
+// The error fields are added to details, which may be nil or carry the
+// details the oracle collected so far.
+func sqlErrorResult(oracleName string, reasonPrefix string, steps []sqlstep.Step, metrics map[string]int64, details map[string]any, err error, stmt string) Result {
+	details = sqlErrorDetails(details, reasonPrefix, err)
+	details["error_sql"] = stmt
+	return Result{OK: true, Oracle: oracleName, Steps: steps, Err: err, Details: details, Metrics: metrics}
+}
+
+// sqlErrorDetails records the reason of err, classified under reasonPrefix,
+// and its MySQL error code in details, allocating details when nil.
+func sqlErrorDetails(details map[string]any, reasonPrefix string, err error) map[string]any {
+	if details == nil {
+		details = make(map[string]any)
+	}
+	reason, code := sqlErrorReason(reasonPrefix, err)
+	details["error_reason"] = reason
+	if code != 0 {
+		details["error_code"] = int(code)
+	}
+	return details
+}
+
+// failedSteps marks stmt as the statement that returned the captured error.
+// Oracles record some statements before running them, so a last step with the
+// same text is re-tagged in place; otherwise stmt is appended with kind. Steps
+// that already carry a failing statement are returned unchanged.
+func failedSteps(steps []sqlstep.Step, kind sqlstep.Kind, stmt string) []sqlstep.Step {
+	if _, ok := sqlstep.FindRole(steps, sqlstep.RoleFailing); ok {
+		return steps
+	}
+	out := append([]sqlstep.Step(nil), steps...)
+	if n := len(out); n > 0 && out[n-1].SQL == strings.TrimSpace(stmt) {
+		out[n-1].Role = sqlstep.RoleFailing
+		return out
+	}
+	return append(out, sqlstep.New(kind, sqlstep.RoleFailing, stmt))
+}
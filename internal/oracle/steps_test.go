@@ -0,0 +1,65 @@
+package oracle
+
+import (
+	"reflect"
+	"testing"
+
+	"shiro/internal/sqlstep"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestResultSQLFollowsSteps(t *testing.T) {
+	result := Result{Steps: []sqlstep.Step{
+		sqlstep.New(sqlstep.KindSetVar, "", "SET @p1=1"),
+		queryStep(sqlstep.RoleExpected, "SELECT * FROM t0"),
+		queryStep(sqlstep.RoleActual, "SELECT * FROM t0 WHERE c0 > 1"),
+	}}
+	want := []string{"SET @p1=1", "SELECT * FROM t0", "SELECT * FROM t0 WHERE c0 > 1"}
+	if got := result.SQL(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("SQL()=%v want=%v", got, want)
+	}
+	if got := (Result{}).SQL(); got != nil {
+		t.Fatalf("SQL() without steps=%v", got)
+	}
+}
+
+func TestFailedSteps(t *testing.T) {
+	read := queryStep("", "SELECT * FROM t0")
+	steps := []sqlstep.Step{read}
+	got := failedSteps(steps, sqlstep.KindQuery, " SELECT * FROM t0 ")
+	if len(got) != 1 || got[0].Role != sqlstep.RoleFailing {
+		t.Fatalf("last step should be tagged in place: %+v", got)
+	}
+	if steps[0].Role != "" {
+		t.Fatalf("failedSteps() must not alias its input")
+	}
+
+	got = failedSteps(steps, sqlstep.KindSetup, "BEGIN")
+	if len(got) != 2 || got[1].Kind != sqlstep.KindSetup || got[1].Role != sqlstep.RoleFailing {
+		t.Fatalf("unrecorded statement should be appended: %+v", got)
+	}
+
+	tagged := []sqlstep.Step{queryStep(sqlstep.RoleFailing, "SELECT 1")}
+	if got := failedSteps(tagged, sqlstep.KindSetup, "ROLLBACK"); !reflect.DeepEqual(got, tagged) {
+		t.Fatalf("explicit failing step should win: %+v", got)
+	}
+}
+
+func TestSQLErrorResult(t *testing.T) {
+	err := &mysql.MySQLError{Number: 1105, Message: "boom"}
+	details := map[string]any{"variant": "x"}
+	res := sqlErrorResult("DecimalArith", "decimal_arith", nil, nil, details, err, "SELECT 1")
+	if !res.OK || res.Err != err || res.Oracle != "DecimalArith" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if res.Details["variant"] != "x" || res.Details["error_sql"] != "SELECT 1" || res.Details["error_code"] != 1105 {
+		t.Fatalf("collected details must be kept next to the error fields: %v", res.Details)
+	}
+	if reason, _ := res.Details["error_reason"].(string); reason == "" {
+		t.Fatalf("missing error_reason: %v", res.Details)
+	}
+	if res := sqlErrorResult("TxnRYW", "txn_ryw", nil, nil, nil, err, "BEGIN"); res.Details["error_sql"] != "BEGIN" {
+		t.Fatalf("nil details must be allocated: %v", res.Details)
+	}
+}
@@ -0,0 +1,185 @@
+package oracle
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"shiro/internal/db"
+	"shiro/internal/generator"
+	"shiro/internal/schema"
+	"shiro/internal/sqlstep"
+)
+
+const (
+	tiFlashOnlyBuildMaxTries = 10
+
+	tiFlashOnlyVariantCop = "tiflash_cop"
+	tiFlashOnlyVariantMPP = "tiflash_mpp"
+)
+
+// TiFlashOnly implements the TiKV-vs-TiFlash engine differential oracle.
+//
+// It builds a deterministic query over tables whose TiFlash replicas are
+// available and compares the row-store result (tidb_isolation_read_engines
+// restricted to TiKV) with the columnar results read only from TiFlash, once
+// through coprocessor tasks and once with MPP enforced.
+//
+// Example:
+//
+//	SELECT /*+ SET_VAR(tidb_isolation_read_engines='tikv,tidb') */ COUNT(*), ... FROM (SELECT ... FROM t0 JOIN t1 ...) q
+//	SELECT /*+ SET_VAR(tidb_isolation_read_engines='tiflash,tidb') SET_VAR(tidb_enforce_mpp=ON) */ COUNT(*), ... FROM (...) q
+type TiFlashOnly struct{}
+
+// Name returns the oracle identifier.
+func (o TiFlashOnly) Name() string { return "TiFlashOnly" }
+
+type tiFlashOnlyVariant struct {
+	name  string
+	hints string
+}
+
+var tiFlashOnlyVariants = []tiFlashOnlyVariant{
+	{name: tiFlashOnlyVariantCop, hints: SetVarReadEnginesTiFlash + " " + SetVarAllowMPPOff},
+	{name: tiFlashOnlyVariantMPP, hints: SetVarReadEnginesTiFlash + " " + SetVarEnforceMPPOn},
+}
+
+// Run compares one query between TiKV-only and TiFlash-only reads.
+func (o TiFlashOnly) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, state *schema.State) Result {
+	if gen.Config.Oracles.DisableMPP || gen.Config.Oracles.MPPTiFlashReplica <= 0 {
+		return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "tiflash_only:mpp_disabled"}}
+	}
+	if !stateHasTiFlashReplica(state) {
+		return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "tiflash_only:no_replica"}}
+	}
+	spec := QuerySpec{
+		Oracle:         "tiflash_only",
+		Profile:        ProfileByName("TiFlashOnly"),
+		PredicateGuard: true,
+		MaxTries:       tiFlashOnlyBuildMaxTries,
+		Constraints: generator.SelectQueryConstraints{
+			QueryGuardReason: func(query *generator.SelectQuery) (bool, string) {
+				if !queryTablesHaveTiFlashReplica(query, state) {
+					return false, "tiflash_only:no_replica"
+				}
+				return true, ""
+			},
+		},
+		SkipReasonOverrides: map[string]string{
+			"constraint:limit":            "tiflash_only:limit",
+			"constraint:nondeterministic": "tiflash_only:nondeterministic",
+			"constraint:predicate_guard":  "tiflash_only:predicate_guard",
+		},
+	}
+	query, details := buildQueryWithSpec(gen, spec)
+	if query == nil {
+		return Result{OK: true, Oracle: o.Name(), Details: details}
+	}
+	if !gen.ValidateQueryScope(query) {
+		return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "tiflash_only:scope_invalid"}}
+	}
+	querySQL := query.SQLString()
+	selectList := signatureSelectList(query)
+	baseSQL := tiFlashOnlySignatureSQL(SetVarReadEnginesTiKV, selectList, querySQL)
+	metrics := map[string]int64{}
+	if len(query.From.Joins) > 0 {
+		metrics["tiflash_only_join_total"] = 1
+	}
+	if queryHasAggregate(query) || len(query.GroupBy) > 0 {
+		metrics["tiflash_only_agg_total"] = 1
+	}
+
+	baseSig, err := exec.QuerySignature(ctx, baseSQL)
+	if err != nil {
+		steps := []sqlstep.Step{sqlstep.New(sqlstep.KindQuery, sqlstep.RoleFailing, baseSQL)}
+		return sqlErrorResult(o.Name(), "tiflash_only", steps, metrics, nil, err, baseSQL)
+	}
+	for _, variant := range tiFlashOnlyVariants {
+		variantSQL := tiFlashOnlySignatureSQL(variant.hints, selectList, querySQL)
+		metrics["tiflash_only_variant_"+variant.name+"_total"]++
+		steps := []sqlstep.Step{
+			sqlstep.New(sqlstep.KindQuery, sqlstep.RoleExpected, baseSQL),
+			sqlstep.New(sqlstep.KindQuery, sqlstep.RoleActual, variantSQL),
+		}
+		sig, err := exec.QuerySignature(ctx, variantSQL)
+		if err != nil {
+			if isTiFlashNoAccessPathErr(err) {
+				metrics["tiflash_only_variant_"+variant.name+"_no_plan"]++
+				continue
+			}
+			steps[1].Role = sqlstep.RoleFailing
+			result := sqlErrorResult(o.Name(), "tiflash_only", steps, metrics, nil, err, variantSQL)
+			result.Details["tiflash_variant"] = variant.name
+			return result
+		}
+		if sig != baseSig {
+			return Result{
+				OK:       false,
+				Oracle:   o.Name(),
+				Steps:    steps,
+				Expected: fmt.Sprintf("cnt=%d checksum=%d", baseSig.Count, baseSig.Checksum),
+				Actual:   fmt.Sprintf("cnt=%d checksum=%d", sig.Count, sig.Checksum),
+				Details: map[string]any{
+					"tiflash_variant":     variant.name,
+					"replay_kind":         "signature",
+					"replay_expected_sql": baseSQL,
+					"replay_actual_sql":   variantSQL,
+				},
+				Metrics: metrics,
+			}
+		}
+	}
+	steps := []sqlstep.Step{sqlstep.New(sqlstep.KindQuery, "", baseSQL)}
+	return Result{OK: true, Oracle: o.Name(), Steps: steps, Metrics: metrics}
+}
+
+// tiFlashOnlySignatureSQL wraps the query in a count/checksum signature with
+// statement-level SET_VAR hints.
+func tiFlashOnlySignatureSQL(hints string, selectList string, query string) string {
+	return fmt.Sprintf("SELECT /*+ %s */ COUNT(*) AS cnt, IFNULL(BIT_XOR(CRC32(CONCAT_WS('#', %s))),0) AS checksum FROM (%s) q", hints, selectList, query)
+}
+
+func stateHasTiFlashReplica(state *schema.State) bool {
+	if state == nil {
+		return false
+	}
+	for _, tbl := range state.Tables {
+		if !tbl.IsView && tbl.TiFlashReplica > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// queryTablesHaveTiFlashReplica reports whether every table the query reads
+// has an available TiFlash replica.
+func queryTablesHaveTiFlashReplica(query *generator.SelectQuery, state *schema.State) bool {
+	if query == nil || state == nil || query.From.BaseQuery != nil {
+		return false
+	}
+	tables := []string{query.From.BaseTable}
+	for _, join := range query.From.Joins {
+		if join.TableQuery != nil {
+			return false
+		}
+		tables = append(tables, join.Table)
+	}
+	for _, name := range tables {
+		tbl, ok := state.TableByName(name)
+		if !ok || tbl.IsView || tbl.TiFlashReplica <= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// isTiFlashNoAccessPathErr reports whether the planner could not build a plan
+// that reads only from TiFlash, for example for a column type TiFlash cannot
+// store. It is expected and not a bug.
+func isTiFlashNoAccessPathErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "no access path") || strings.Contains(msg, "can't find a proper physical plan")
+}
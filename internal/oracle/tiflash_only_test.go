@@ -0,0 +1,61 @@
+package oracle
+
+import (
+	"errors"
+	"testing"
+
+	"shiro/internal/generator"
+	"shiro/internal/schema"
+)
+
+func TestTiFlashOnlySignatureSQL(t *testing.T) {
+	want := "SELECT /*+ SET_VAR(tidb_isolation_read_engines='tiflash,tidb') SET_VAR(tidb_enforce_mpp=ON) */ COUNT(*) AS cnt, IFNULL(BIT_XOR(CRC32(CONCAT_WS('#', q.c0))),0) AS checksum FROM (SELECT t0.c1 AS c0 FROM t0) q"
+	got := tiFlashOnlySignatureSQL(tiFlashOnlyVariants[1].hints, "q.c0", "SELECT t0.c1 AS c0 FROM t0")
+	if got != want {
+		t.Fatalf("tiFlashOnlySignatureSQL()=%q want=%q", got, want)
+	}
+}
+
+func TestQueryTablesHaveTiFlashReplica(t *testing.T) {
+	state := &schema.State{Tables: []schema.Table{
+		{Name: "t0", TiFlashReplica: 1},
+		{Name: "t1", TiFlashReplica: 1},
+		{Name: "t2"},
+		{Name: "v0", IsView: true, TiFlashReplica: 1},
+	}}
+	tests := []struct {
+		name  string
+		query *generator.SelectQuery
+		want  bool
+	}{
+		{name: "single", query: &generator.SelectQuery{From: generator.FromClause{BaseTable: "t0"}}, want: true},
+		{name: "join", query: &generator.SelectQuery{From: generator.FromClause{BaseTable: "t0", Joins: []generator.Join{{Table: "t1"}}}}, want: true},
+		{name: "join without replica", query: &generator.SelectQuery{From: generator.FromClause{BaseTable: "t0", Joins: []generator.Join{{Table: "t2"}}}}},
+		{name: "view", query: &generator.SelectQuery{From: generator.FromClause{BaseTable: "v0"}}},
+		{name: "derived", query: &generator.SelectQuery{From: generator.FromClause{BaseQuery: &generator.SelectQuery{}}}},
+		{name: "unknown", query: &generator.SelectQuery{From: generator.FromClause{BaseTable: "t9"}}},
+	}
+	for _, tt := range tests {
+		if got := queryTablesHaveTiFlashReplica(tt.query, state); got != tt.want {
+			t.Fatalf("%s: queryTablesHaveTiFlashReplica=%v want=%v", tt.name, got, tt.want)
+		}
+	}
+	if !stateHasTiFlashReplica(state) {
+		t.Fatalf("expected state to have a tiflash replica")
+	}
+	if stateHasTiFlashReplica(&schema.State{Tables: []schema.Table{{Name: "t2"}}}) {
+		t.Fatalf("expected state without replicas")
+	}
+}
+
+func TestIsTiFlashNoAccessPathErr(t *testing.T) {
+	if !isTiFlashNoAccessPathErr(errors.New("Error 1815: Internal : No access path for table 't0' is found with 'tidb_isolation_read_engines' = 'tiflash,tidb'")) {
+		t.Fatalf("expected no access path error")
+	}
+	if !isTiFlashNoAccessPathErr(errors.New("Error 1815: Internal : Can't find a proper physical plan for this query")) {
+		t.Fatalf("expected no plan error")
+	}
+	if isTiFlashNoAccessPathErr(errors.New("Error 1105: unexpected")) || isTiFlashNoAccessPathErr(nil) {
+		t.Fatalf("unexpected no access path match")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"shiro/internal/db"
 	"shiro/internal/generator"
 	"shiro/internal/schema"
+	"shiro/internal/sqlstep"
 )
 
 // TLP implements the TLP oracle.
@@ -48,13 +49,6 @@ func (o TLP) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, sta
 		PredicatePolicy: policy,
 		PredicateGuard:  true,
 		MaxTries:        tlpBuildMaxTries,
-		Constraints: generator.SelectQueryConstraints{
-			RequireWhere:         true,
-			RequireDeterministic: true,
-			PredicateMode:        generator.PredicateModeSimpleColumns,
-			DisallowLimit:        true,
-			DisallowSetOps:       true,
-		},
 		SkipReasonOverrides: map[string]string{
 			"constraint:nondeterministic": "tlp:nondeterministic",
 			"constraint:predicate_guard":  "tlp:predicate_guard",
@@ -89,13 +83,13 @@ func (o TLP) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, sta
 	origSig, err := exec.QuerySignature(ctx, baseSignatureSQL)
 	if err != nil {
 		if code, ok := isWhitelistedSQLError(err); ok {
-			return Result{OK: true, Oracle: o.Name(), SQL: []string{baseSQL}, SQLFeatures: observed, Details: map[string]any{"skip_reason": fmt.Sprintf("tlp:sql_error_%d", code)}}
+			return Result{OK: true, Oracle: o.Name(), Steps: []sqlstep.Step{queryStep("", baseSQL)}, SQLFeatures: observed, Details: map[string]any{"skip_reason": fmt.Sprintf("tlp:sql_error_%d", code)}}
 		}
 		details := map[string]any{"error_reason": "tlp:base_signature_error"}
 		if code, ok := mysqlErrCode(err); ok {
 			details["error_code"] = int(code)
 		}
-		return Result{OK: true, Oracle: o.Name(), SQL: []string{baseSQL}, SQLFeatures: observed, Err: err, Details: details}
+		return Result{OK: true, Oracle: o.Name(), Steps: []sqlstep.Step{queryStep(sqlstep.RoleFailing, baseSQL)}, SQLFeatures: observed, Err: err, Details: details}
 	}
 
 	q1 := base.Clone()
@@ -123,13 +117,17 @@ func (o TLP) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, sta
 	unionSig, err := exec.QuerySignature(ctx, unionSQL)
 	if err != nil {
 		if code, ok := isWhitelistedSQLError(err); ok {
-			return Result{OK: true, Oracle: o.Name(), SQL: []string{unionSQL}, SQLFeatures: observed, Details: map[string]any{"skip_reason": fmt.Sprintf("tlp:sql_error_%d", code)}}
+			return Result{OK: true, Oracle: o.Name(), Steps: []sqlstep.Step{queryStep("", unionSQL)}, SQLFeatures: observed, Details: map[string]any{"skip_reason": fmt.Sprintf("tlp:sql_error_%d", code)}}
 		}
 		details := map[string]any{"error_reason": "tlp:union_signature_error"}
 		if code, ok := mysqlErrCode(err); ok {
 			details["error_code"] = int(code)
 		}
-		return Result{OK: true, Oracle: o.Name(), SQL: []string{unionSQL}, SQLFeatures: observed, Err: err, Details: details}
+		steps := []sqlstep.Step{
+			queryStep(sqlstep.RoleExpected, baseSQL),
+			queryStep(sqlstep.RoleFailing, unionSQL),
+		}
+		return Result{OK: true, Oracle: o.Name(), Steps: steps, SQLFeatures: observed, Err: err, Details: details}
 	}
 
 	if origSig != unionSig {
@@ -138,7 +136,7 @@ func (o TLP) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, sta
 		return Result{
 			OK:          false,
 			Oracle:      o.Name(),
-			SQL:         []string{baseSQL, unionSQL},
+			Steps:       comparisonSteps(baseSQL, unionSQL),
 			SQLFeatures: observed,
 			Expected:    fmt.Sprintf("cnt=%d checksum=%d", origSig.Count, origSig.Checksum),
 			Actual:      fmt.Sprintf("cnt=%d checksum=%d", unionSig.Count, unionSig.Checksum),
@@ -153,7 +151,7 @@ func (o TLP) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, sta
 			},
 		}
 	}
-	return Result{OK: true, Oracle: o.Name(), SQL: []string{baseSQL, unionSQL}, SQLFeatures: observed}
+	return Result{OK: true, Oracle: o.Name(), Steps: comparisonSteps(baseSQL, unionSQL), SQLFeatures: observed}
 }
 
 func signatureColumns(query *generator.SelectQuery) string {
@@ -0,0 +1,292 @@
+package oracle
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"shiro/internal/db"
+	"shiro/internal/generator"
+	"shiro/internal/schema"
+	"shiro/internal/sqlstep"
+	"shiro/internal/util"
+)
+
+const txnRYWPreparedName = "shiro_txn_ryw_stmt"
+
+// TxnRYW implements the read-your-writes oracle.
+//
+// It opens a transaction, applies one INSERT/UPDATE/DELETE, and then reads the
+// table through several access paths that must all observe the uncommitted
+// write (membuffer union reads). After ROLLBACK the same read must match the
+// pre-transaction snapshot again.
+//
+// Example:
+//
+//	BEGIN
+//	UPDATE t0 SET c1 = c1 + 1 WHERE c0 > 5
+//	SELECT COUNT(*), <checksum> FROM t0 WHERE c2 < 3
+//	SELECT /*+ USE_INDEX(t0) */ ...     -- must match the plain read
+//	EXECUTE stmt                        -- prepared/plan-cache path, must match
+//	ROLLBACK
+//	SELECT ...                          -- must match the pre-BEGIN read
+type TxnRYW struct{}
+
+// Name returns the oracle identifier.
+func (o TxnRYW) Name() string { return "TxnRYW" }
+
+type txnRYWVariant struct {
+	name string
+	sql  string
+}
+
+// Run executes the transaction on a dedicated connection so BEGIN, the write,
+// and all reads share one session.
+func (o TxnRYW) Run(ctx context.Context, exec *db.DB, gen *generator.Generator, state *schema.State) Result {
+	if !state.HasBaseTables() {
+		return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "txn_ryw:no_base_tables"}}
+	}
+	baseTables := state.BaseTables()
+	tbl := baseTables[gen.Rand.Intn(len(baseTables))]
+	if len(tbl.Columns) == 0 {
+		return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "txn_ryw:no_columns"}}
+	}
+	predicate := gen.GenerateSimplePredicate([]schema.Table{tbl}, 2)
+	if predicate == nil || !predicate.Deterministic() {
+		return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "txn_ryw:predicate_guard"}}
+	}
+	dmlKind, dmlSQL := pickTxnRYWWrite(gen, tbl)
+	if dmlSQL == "" {
+		return Result{OK: true, Oracle: o.Name(), Details: map[string]any{"skip_reason": "txn_ryw:dml_guard"}}
+	}
+	readSQL := txnRYWSignatureSQL(tbl, "", buildExpr(predicate))
+	countSQL := fmt.Sprintf("SELECT COUNT(*) FROM %s", tbl.Name)
+	variants := txnRYWVariants(tbl, buildExpr(predicate))
+	metrics := map[string]int64{"txn_ryw_dml_" + dmlKind + "_total": 1}
+
+	conn, err := exec.Conn(ctx)
+	if err != nil {
+		return Result{OK: true, Oracle: o.Name(), Err: err}
+	}
+	defer util.CloseWithErr(conn, "txn_ryw conn")
+
+	steps := []sqlstep.Step{sqlstep.New(sqlstep.KindQuery, "", readSQL)}
+
+	before, err := txnRYWQuerySignature(ctx, conn, readSQL)
+	if err != nil {
+		return sqlErrorResult(o.Name(), "txn_ryw", failedSteps(steps, sqlstep.KindQuery, readSQL), metrics, nil, err, readSQL)
+	}
+	countBefore, err := txnRYWQueryCount(ctx, conn, countSQL)
+	if err != nil {
+		return sqlErrorResult(o.Name(), "txn_ryw", failedSteps(steps, sqlstep.KindQuery, countSQL), metrics, nil, err, countSQL)
+	}
+	if _, err := conn.ExecContext(ctx, "BEGIN"); err != nil {
+		return sqlErrorResult(o.Name(), "txn_ryw", failedSteps(steps, sqlstep.KindSetup, "BEGIN"), metrics, nil, err, "BEGIN")
+	}
+	steps = append(steps, sqlstep.New(sqlstep.KindSetup, "", "BEGIN"))
+	inTxn := true
+	defer func() {
+		if inTxn {
+			_, _ = conn.ExecContext(context.Background(), "ROLLBACK")
+		}
+	}()
+
+	res, err := conn.ExecContext(ctx, dmlSQL)
+	if err != nil {
+		details := sqlErrorDetails(map[string]any{"skip_reason": "txn_ryw:dml_failed"}, "txn_ryw", err)
+		return Result{OK: true, Oracle: o.Name(), Details: details, Metrics: metrics}
+	}
+	affected, _ := res.RowsAffected()
+	steps = append(steps, sqlstep.New(sqlstep.KindSetup, "", dmlSQL))
+
+	countAfter, err := txnRYWQueryCount(ctx, conn, countSQL)
+	if err != nil {
+		return sqlErrorResult(o.Name(), "txn_ryw", failedSteps(steps, sqlstep.KindQuery, countSQL), metrics, nil, err, countSQL)
+	}
+	if want := txnRYWExpectedCount(dmlKind, countBefore, affected); countAfter != want {
+		steps = append(steps, sqlstep.New(sqlstep.KindQuery, sqlstep.RoleActual, countSQL))
+		return o.mismatch(steps, metrics, "row_count", dmlKind,
+			fmt.Sprintf("count=%d", want), fmt.Sprintf("count=%d", countAfter))
+	}
+
+	baseSig, err := txnRYWQuerySignature(ctx, conn, readSQL)
+	if err != nil {
+		return sqlErrorResult(o.Name(), "txn_ryw", failedSteps(steps, sqlstep.KindQuery, readSQL), metrics, nil, err, readSQL)
+	}
+	steps = append(steps, sqlstep.New(sqlstep.KindQuery, "", readSQL))
+	for _, variant := range variants {
+		metrics["txn_ryw_variant_"+variant.name+"_total"]++
+		sig, err := txnRYWQuerySignature(ctx, conn, variant.sql)
+		if err != nil {
+			continue
+		}
+		if sig != baseSig {
+			steps[len(steps)-1].Role = sqlstep.RoleExpected
+			steps = append(steps, sqlstep.New(sqlstep.KindQuery, sqlstep.RoleActual, variant.sql))
+			return o.mismatch(steps, metrics, "in_txn_"+variant.name, dmlKind,
+				fmt.Sprintf("cnt=%d checksum=%d", baseSig.Count, baseSig.Checksum),
+				fmt.Sprintf("cnt=%d checksum=%d", sig.Count, sig.Checksum))
+		}
+	}
+
+	preparedSig, preparedSteps, ok := txnRYWPreparedSignature(ctx, conn, readSQL)
+	if ok {
+		metrics["txn_ryw_variant_prepared_total"]++
+		if preparedSig != baseSig {
+			steps[len(steps)-1].Role = sqlstep.RoleExpected
+			steps = append(steps, preparedSteps...)
+			return o.mismatch(steps, metrics, "in_txn_prepared", dmlKind,
+				fmt.Sprintf("cnt=%d checksum=%d", baseSig.Count, baseSig.Checksum),
+				fmt.Sprintf("cnt=%d checksum=%d", preparedSig.Count, preparedSig.Checksum))
+		}
+	}
+
+	if _, err := conn.ExecContext(ctx, "ROLLBACK"); err != nil {
+		return sqlErrorResult(o.Name(), "txn_ryw", failedSteps(steps, sqlstep.KindSetup, "ROLLBACK"), metrics, nil, err, "ROLLBACK")
+	}
+	inTxn = false
+	steps = append(steps, sqlstep.New(sqlstep.KindSetup, "", "ROLLBACK"))
+	after, err := txnRYWQuerySignature(ctx, conn, readSQL)
+	if err != nil {
+		return sqlErrorResult(o.Name(), "txn_ryw", failedSteps(steps, sqlstep.KindQuery, readSQL), metrics, nil, err, readSQL)
+	}
+	if after != before {
+		steps[0].Role = sqlstep.RoleExpected
+		steps = append(steps, sqlstep.New(sqlstep.KindQuery, sqlstep.RoleActual, readSQL))
+		return o.mismatch(steps, metrics, "after_rollback", dmlKind,
+			fmt.Sprintf("cnt=%d checksum=%d", before.Count, before.Checksum),
+			fmt.Sprintf("cnt=%d checksum=%d", after.Count, after.Checksum))
+	}
+	return Result{OK: true, Oracle: o.Name(), Steps: steps, Metrics: metrics}
+}
+
+func (o TxnRYW) mismatch(steps []sqlstep.Step, metrics map[string]int64, phase string, dmlKind string, expected string, actual string) Result {
+	return Result{
+		OK:       false,
+		Oracle:   o.Name(),
+		Steps:    steps,
+		Expected: expected,
+		Actual:   actual,
+		Details: map[string]any{
+			"txn_ryw_phase":    phase,
+			"txn_ryw_dml_kind": dmlKind,
+		},
+		Metrics: metrics,
+	}
+}
+
+func pickTxnRYWWrite(gen *generator.Generator, tbl schema.Table) (kind string, sqlText string) {
+	switch gen.Rand.Intn(3) {
+	case 0:
+		// InsertSQL advances NextID; work on a copy because the write is rolled back.
+		tblCopy := tbl
+		return "insert", gen.InsertSQL(&tblCopy)
+	case 1:
+		updateSQL, _, _, _ := gen.UpdateSQL(tbl)
+		return "update", updateSQL
+	default:
+		deleteSQL, _ := gen.DeleteSQL(tbl)
+		return "delete", deleteSQL
+	}
+}
+
+func txnRYWExpectedCount(kind string, before int64, affected int64) int64 {
+	switch kind {
+	case "insert":
+		return before + affected
+	case "delete":
+		return before - affected
+	default:
+		return before
+	}
+}
+
+func txnRYWSignatureSQL(tbl schema.Table, hint string, predicate string) string {
+	cols := make([]string, 0, len(tbl.Columns))
+	for _, col := range tbl.Columns {
+		cols = append(cols, fmt.Sprintf("%s.%s", tbl.Name, col.Name))
+	}
+	checksum := fmt.Sprintf("IFNULL(BIT_XOR(CRC32(CONCAT_WS('#', %s))),0)", strings.Join(cols, ", "))
+	return fmt.Sprintf("SELECT %sCOUNT(*) AS cnt, %s AS checksum FROM %s WHERE %s", hint, checksum, tbl.Name, predicate)
+}
+
+// txnRYWVariants returns reads that take different access paths over the
+// membuffer: a forced table scan, a primary key read, and, when available, an
+// index read. The primary key read is a handle range on CLUSTERED tables and
+// an index lookup through _tidb_rowid on NONCLUSTERED ones.
+func txnRYWVariants(tbl schema.Table, predicate string) []txnRYWVariant {
+	variants := []txnRYWVariant{
+		{name: "table_scan", sql: txnRYWSignatureSQL(tbl, fmt.Sprintf("/*+ USE_INDEX(%s) */ ", tbl.Name), predicate)},
+	}
+	if tbl.HasPK {
+		variants = append(variants, txnRYWVariant{
+			name: primaryKeyVariantName(tbl),
+			sql:  txnRYWSignatureSQL(tbl, "/*+ "+fmt.Sprintf(HintUsePrimaryFmt, tbl.Name)+" */ ", predicate),
+		})
+	}
+	for _, idx := range tbl.Indexes {
+		if strings.TrimSpace(idx.Name) == "" {
+			continue
+		}
+		variants = append(variants, txnRYWVariant{
+			name: "index",
+			sql:  txnRYWSignatureSQL(tbl, fmt.Sprintf("/*+ USE_INDEX(%s, %s) */ ", tbl.Name, idx.Name), predicate),
+		})
+		break
+	}
+	return variants
+}
+
+func primaryKeyVariantName(tbl schema.Table) string {
+	switch tbl.PKClustering {
+	case schema.PKClustered:
+		return "primary_clustered"
+	case schema.PKNonClustered:
+		return "primary_nonclustered"
+	default:
+		return "primary"
+	}
+}
+
+// txnRYWPreparedSignature executes the read as a text-protocol prepared
+// statement twice so the second run can take the plan-cache path.
+func txnRYWPreparedSignature(ctx context.Context, conn *sql.Conn, readSQL string) (db.Signature, []sqlstep.Step, bool) {
+	prepareSQL := fmt.Sprintf("PREPARE %s FROM '%s'", txnRYWPreparedName, strings.ReplaceAll(readSQL, "'", "''"))
+	executeSQL := "EXECUTE " + txnRYWPreparedName
+	steps := []sqlstep.Step{
+		sqlstep.New(sqlstep.KindPrepare, "", prepareSQL),
+		sqlstep.New(sqlstep.KindExecute, "", executeSQL),
+		sqlstep.New(sqlstep.KindExecute, sqlstep.RoleActual, executeSQL),
+	}
+	if _, err := conn.ExecContext(ctx, prepareSQL); err != nil {
+		return db.Signature{}, nil, false
+	}
+	defer func() {
+		_, _ = conn.ExecContext(ctx, "DEALLOCATE PREPARE "+txnRYWPreparedName)
+	}()
+	if _, err := txnRYWQuerySignature(ctx, conn, executeSQL); err != nil {
+		return db.Signature{}, nil, false
+	}
+	sig, err := txnRYWQuerySignature(ctx, conn, executeSQL)
+	if err != nil {
+		return db.Signature{}, nil, false
+	}
+	return sig, steps, true
+}
+
+func txnRYWQuerySignature(ctx context.Context, conn *sql.Conn, query string) (db.Signature, error) {
+	var sig db.Signature
+	if err := conn.QueryRowContext(ctx, query).Scan(&sig.Count, &sig.Checksum); err != nil {
+		return db.Signature{}, err
+	}
+	return sig, nil
+}
+
+func txnRYWQueryCount(ctx context.Context, conn *sql.Conn, query string) (int64, error) {
+	var count int64
+	if err := conn.QueryRowContext(ctx, query).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
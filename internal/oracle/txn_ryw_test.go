@@ -0,0 +1,55 @@
+package oracle
+
+import (
+	"strings"
+	"testing"
+
+	"shiro/internal/schema"
+)
+
+func TestTxnRYWExpectedCount(t *testing.T) {
+	cases := []struct {
+		kind     string
+		before   int64
+		affected int64
+		want     int64
+	}{
+		{kind: "insert", before: 3, affected: 2, want: 5},
+		{kind: "delete", before: 3, affected: 2, want: 1},
+		{kind: "update", before: 3, affected: 2, want: 3},
+	}
+	for _, tc := range cases {
+		if got := txnRYWExpectedCount(tc.kind, tc.before, tc.affected); got != tc.want {
+			t.Fatalf("txnRYWExpectedCount(%q)=%d want=%d", tc.kind, got, tc.want)
+		}
+	}
+}
+
+func TestTxnRYWVariants(t *testing.T) {
+	tbl := schema.Table{
+		Name:    "t0",
+		Columns: []schema.Column{{Name: "id", Type: schema.TypeInt}, {Name: "c0", Type: schema.TypeInt}},
+	}
+	variants := txnRYWVariants(tbl, "(t0.c0 > 1)")
+	if len(variants) != 1 || variants[0].name != "table_scan" {
+		t.Fatalf("unexpected variants without indexes: %+v", variants)
+	}
+	if !strings.Contains(variants[0].sql, "/*+ USE_INDEX(t0) */") {
+		t.Fatalf("table scan variant missing hint: %s", variants[0].sql)
+	}
+	tbl.Indexes = []schema.Index{{Name: "idx_c0", Columns: []string{"c0"}}}
+	variants = txnRYWVariants(tbl, "(t0.c0 > 1)")
+	if len(variants) != 2 || !strings.Contains(variants[1].sql, "USE_INDEX(t0, idx_c0)") {
+		t.Fatalf("unexpected index variant: %+v", variants)
+	}
+	tbl.HasPK = true
+	tbl.PKClustering = schema.PKNonClustered
+	variants = txnRYWVariants(tbl, "(t0.c0 > 1)")
+	if len(variants) != 3 || variants[1].name != "primary_nonclustered" || !strings.Contains(variants[1].sql, "USE_INDEX(t0, PRIMARY)") {
+		t.Fatalf("unexpected primary variant: %+v", variants)
+	}
+	want := "SELECT COUNT(*) AS cnt, IFNULL(BIT_XOR(CRC32(CONCAT_WS('#', t0.id, t0.c0))),0) AS checksum FROM t0 WHERE (t0.c0 > 1)"
+	if got := txnRYWSignatureSQL(tbl, "", "(t0.c0 > 1)"); got != want {
+		t.Fatalf("txnRYWSignatureSQL()=%q want=%q", got, want)
+	}
+}
@@ -0,0 +1,249 @@
+package report
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+)
+
+// DetailsSchemaVersion is the version of the typed details layout. Bump it when
+// a field of CaseDetails changes meaning or shape; adding a field does not.
+const DetailsSchemaVersion = 1
+
+// DetailsSchemaFile is the name shiro-report publishes the JSON schema under.
+const DetailsSchemaFile = "details-schema.json"
+
+//go:embed details.schema.json
+var detailsSchema []byte
+
+// DetailsSchema returns the JSON schema of CaseDetails.
+func DetailsSchema() []byte {
+	return append([]byte(nil), detailsSchema...)
+}
+
+// CaseDetails is the typed view of the well-known keys in Summary.Details.
+// Details stays the source of truth; CaseDetails is derived from it whenever a
+// summary is written, so consumers can read fixed fields instead of guessing
+// key names.
+type CaseDetails struct {
+	Version  int             `json:"version"`
+	Replay   *ReplayDetails  `json:"replay,omitempty"`
+	Explains *ExplainDetails `json:"explains,omitempty"`
+	Warnings *WarningDetails `json:"warnings,omitempty"`
+	Args     *ArgDetails     `json:"args,omitempty"`
+	Panic    *PanicDetails   `json:"panic,omitempty"`
+	Hang     *HangDetails    `json:"hang,omitempty"`
+}
+
+// ReplayDetails describes how the minimizer and shiro-repro re-check a case.
+type ReplayDetails struct {
+	// Kind is the comparison replayed: signature, count, exists, rows_affected, ...
+	Kind         string        `json:"kind,omitempty"`
+	SQL          string        `json:"sql,omitempty"`
+	ExpectedSQL  string        `json:"expected_sql,omitempty"`
+	ActualSQL    string        `json:"actual_sql,omitempty"`
+	OriginResult *OriginResult `json:"origin_result,omitempty"`
+}
+
+// OriginResult is the sampled result of the original (for example prepared)
+// execution that a replay is compared with.
+type OriginResult struct {
+	Signature string     `json:"signature,omitempty"`
+	Columns   []string   `json:"columns,omitempty"`
+	Rows      [][]string `json:"rows,omitempty"`
+}
+
+// ExplainDetails holds the EXPLAIN outputs captured for the compared queries.
+type ExplainDetails struct {
+	Expected      string `json:"expected,omitempty"`
+	Actual        string `json:"actual,omitempty"`
+	Optimized     string `json:"optimized,omitempty"`
+	Unoptimized   string `json:"unoptimized,omitempty"`
+	ForConnection string `json:"for_connection,omitempty"`
+	// Diff is the compact structural diff of Expected and Actual (or
+	// Unoptimized and Optimized): operators, access paths, and join order.
+	Diff string `json:"diff,omitempty"`
+}
+
+// WarningDetails holds SHOW WARNINGS output as level:code:message strings.
+type WarningDetails struct {
+	Messages []string `json:"messages,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// ArgDetails holds the formatted arguments of the first and second EXECUTE.
+type ArgDetails struct {
+	First  []string `json:"first,omitempty"`
+	Second []string `json:"second,omitempty"`
+}
+
+// PanicDetails classifies an error TiDB recovered from a panic or reported as
+// an internal failure, with the diagnostics captured for it.
+type PanicDetails struct {
+	// Class is runtime_error, panic, internal_error, assertion, or build_executor.
+	Class         string   `json:"class,omitempty"`
+	Kind          string   `json:"kind,omitempty"`
+	Code          int      `json:"code,omitempty"`
+	Reproduced    *bool    `json:"reproduced,omitempty"`
+	LastQueryInfo string   `json:"last_query_info,omitempty"`
+	Warnings      []string `json:"warnings,omitempty"`
+	StackFile     string   `json:"stack_file,omitempty"`
+}
+
+// HangDetails records the timeout escalation of a statement that hit the
+// statement timeout.
+type HangDetails struct {
+	// Status is completed, hang, or failed.
+	Status        string `json:"status"`
+	SQL           string `json:"sql,omitempty"`
+	TimeoutMs     int    `json:"timeout_ms,omitempty"`
+	ElapsedMs     int    `json:"elapsed_ms,omitempty"`
+	Error         string `json:"error,omitempty"`
+	ExplainFile   string `json:"explain_file,omitempty"`
+	GoroutineURL  string `json:"goroutine_url,omitempty"`
+	GoroutineFile string `json:"goroutine_file,omitempty"`
+}
+
+// ParseCaseDetails builds the typed view of details. It accepts both the values
+// oracles put in the map and their decoded JSON form, so it also works on
+// summaries read back from disk. It returns nil when no known key is set.
+func ParseCaseDetails(details map[string]any) *CaseDetails {
+	if len(details) == 0 {
+		return nil
+	}
+	out := &CaseDetails{Version: DetailsSchemaVersion}
+	replay := ReplayDetails{
+		Kind:         detailText(details, "replay_kind"),
+		SQL:          detailText(details, "replay_sql"),
+		ExpectedSQL:  detailText(details, "replay_expected_sql"),
+		ActualSQL:    detailText(details, "replay_actual_sql"),
+		OriginResult: parseOriginResult(details["origin_result"]),
+	}
+	if replay != (ReplayDetails{}) {
+		out.Replay = &replay
+	}
+	explains := ExplainDetails{
+		Expected:      detailText(details, "expected_explain"),
+		Actual:        detailText(details, "actual_explain"),
+		Optimized:     detailText(details, "optimized_explain"),
+		Unoptimized:   detailText(details, "unoptimized_explain"),
+		ForConnection: detailText(details, "explain_for_connection"),
+		Diff:          detailText(details, "explain_diff"),
+	}
+	if explains != (ExplainDetails{}) {
+		out.Explains = &explains
+	}
+	warnings := WarningDetails{
+		Messages: detailStrings(details["warnings"]),
+		Error:    detailText(details, "warnings_err"),
+	}
+	if len(warnings.Messages) > 0 || warnings.Error != "" {
+		out.Warnings = &warnings
+	}
+	args := ArgDetails{
+		First:  detailStrings(details["args_first"]),
+		Second: detailStrings(details["args_second"]),
+	}
+	if len(args.First) > 0 || len(args.Second) > 0 {
+		out.Args = &args
+	}
+	if class := detailText(details, "panic_class"); class != "" {
+		out.Panic = &PanicDetails{
+			Class:         class,
+			Kind:          detailText(details, "panic_kind"),
+			Code:          detailInt(details["panic_code"]),
+			LastQueryInfo: detailText(details, "panic_last_query_info"),
+			Warnings:      detailStrings(details["panic_warnings"]),
+			StackFile:     detailText(details, "panic_stack_file"),
+		}
+		if reproduced, ok := details["panic_reproduced"].(bool); ok {
+			out.Panic.Reproduced = &reproduced
+		}
+	}
+	if status := detailText(details, "hang_status"); status != "" {
+		out.Hang = &HangDetails{
+			Status:        status,
+			SQL:           detailText(details, "hang_sql"),
+			TimeoutMs:     detailInt(details["hang_timeout_ms"]),
+			ElapsedMs:     detailInt(details["hang_elapsed_ms"]),
+			Error:         detailText(details, "hang_error"),
+			ExplainFile:   detailText(details, "hang_explain_file"),
+			GoroutineURL:  detailText(details, "hang_goroutine_url"),
+			GoroutineFile: detailText(details, "hang_goroutine_file"),
+		}
+	}
+	if out.Replay == nil && out.Explains == nil && out.Warnings == nil && out.Args == nil && out.Panic == nil && out.Hang == nil {
+		return nil
+	}
+	return out
+}
+
+func parseOriginResult(v any) *OriginResult {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil
+	}
+	out := OriginResult{
+		Signature: detailText(m, "signature"),
+		Columns:   detailStrings(m["columns"]),
+	}
+	switch rows := m["rows"].(type) {
+	case [][]string:
+		out.Rows = rows
+	case []any:
+		for _, row := range rows {
+			out.Rows = append(out.Rows, detailStrings(row))
+		}
+	}
+	if out.Signature == "" && len(out.Columns) == 0 && len(out.Rows) == 0 {
+		return nil
+	}
+	return &out
+}
+
+func detailText(details map[string]any, key string) string {
+	switch v := details[key].(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return ""
+	}
+}
+
+func detailInt(v any) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case int64:
+		return int(n)
+	case uint16:
+		return int(n)
+	case float64:
+		return int(n)
+	default:
+		return 0
+	}
+}
+
+func detailStrings(v any) []string {
+	switch vals := v.(type) {
+	case []string:
+		return vals
+	case []any:
+		out := make([]string, 0, len(vals))
+		for _, val := range vals {
+			if s, ok := val.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,159 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestParseCaseDetails(t *testing.T) {
+	details := map[string]any{
+		"replay_kind":            "signature",
+		"replay_sql":             " SELECT 1 ",
+		"replay_expected_sql":    "SELECT a",
+		"replay_actual_sql":      "SELECT b",
+		"expected_explain":       "TableReader",
+		"explain_for_connection": "Point_Get",
+		"explain_diff":           "ops +HashJoin",
+		"warnings":               []string{"Warning:1105:skip plan-cache"},
+		"warnings_err":           errors.New("bad conn"),
+		"args_first":             []string{"1", "'a'"},
+		"origin_result": map[string]any{
+			"signature": "cnt=1 checksum=2",
+			"columns":   []string{"c0"},
+			"rows":      [][]string{{"1"}},
+		},
+		"bug_class": "optimizer",
+	}
+	got := ParseCaseDetails(details)
+	want := &CaseDetails{
+		Version: DetailsSchemaVersion,
+		Replay: &ReplayDetails{
+			Kind:         "signature",
+			SQL:          "SELECT 1",
+			ExpectedSQL:  "SELECT a",
+			ActualSQL:    "SELECT b",
+			OriginResult: &OriginResult{Signature: "cnt=1 checksum=2", Columns: []string{"c0"}, Rows: [][]string{{"1"}}},
+		},
+		Explains: &ExplainDetails{Expected: "TableReader", ForConnection: "Point_Get", Diff: "ops +HashJoin"},
+		Warnings: &WarningDetails{Messages: []string{"Warning:1105:skip plan-cache"}, Error: "bad conn"},
+		Args:     &ArgDetails{First: []string{"1", "'a'"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseCaseDetails()=%+v want %+v", got, want)
+	}
+
+	// Summaries read back from disk hold decoded JSON values.
+	raw, err := json.Marshal(map[string]any{
+		"warnings":      details["warnings"],
+		"args_first":    details["args_first"],
+		"origin_result": details["origin_result"],
+	})
+	if err != nil {
+		t.Fatalf("marshal details: %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatalf("unmarshal details: %v", err)
+	}
+	got = ParseCaseDetails(decoded)
+	if got == nil || !reflect.DeepEqual(got.Replay.OriginResult, want.Replay.OriginResult) ||
+		!slices.Equal(got.Warnings.Messages, want.Warnings.Messages) || !slices.Equal(got.Args.First, want.Args.First) {
+		t.Fatalf("decoded ParseCaseDetails()=%+v", got)
+	}
+
+	panicDetails := ParseCaseDetails(map[string]any{
+		"panic_class":      "runtime_error",
+		"panic_kind":       "nil_pointer",
+		"panic_code":       float64(1105),
+		"panic_reproduced": true,
+		"panic_warnings":   []any{"Warning:1105:x"},
+	})
+	if panicDetails == nil || panicDetails.Panic == nil || panicDetails.Panic.Code != 1105 ||
+		panicDetails.Panic.Reproduced == nil || !*panicDetails.Panic.Reproduced || len(panicDetails.Panic.Warnings) != 1 {
+		t.Fatalf("unexpected panic details: %+v", panicDetails)
+	}
+
+	hangDetails := ParseCaseDetails(map[string]any{
+		"hang_status":         "hang",
+		"hang_sql":            "SELECT 1",
+		"hang_timeout_ms":     int64(120000),
+		"hang_elapsed_ms":     float64(120003),
+		"hang_goroutine_file": "goroutines.txt",
+	})
+	wantHang := &HangDetails{Status: "hang", SQL: "SELECT 1", TimeoutMs: 120000, ElapsedMs: 120003, GoroutineFile: "goroutines.txt"}
+	if hangDetails == nil || !reflect.DeepEqual(hangDetails.Hang, wantHang) {
+		t.Fatalf("unexpected hang details: %+v", hangDetails)
+	}
+
+	if ParseCaseDetails(map[string]any{"bug_class": "executor"}) != nil {
+		t.Fatalf("expected nil typed details without known keys")
+	}
+}
+
+func TestWriteSummaryTypedDetails(t *testing.T) {
+	dir := t.TempDir()
+	r := New(dir, 10)
+	c := Case{ID: "c1", Dir: dir}
+	if err := r.WriteSummary(c, Summary{Details: map[string]any{"replay_kind": "count"}}); err != nil {
+		t.Fatalf("WriteSummary() failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "summary.json"))
+	if err != nil {
+		t.Fatalf("read summary: %v", err)
+	}
+	var summary Summary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		t.Fatalf("unmarshal summary: %v", err)
+	}
+	if summary.TypedDetails == nil || summary.TypedDetails.Version != DetailsSchemaVersion ||
+		summary.TypedDetails.Replay == nil || summary.TypedDetails.Replay.Kind != "count" {
+		t.Fatalf("unexpected typed details: %s", data)
+	}
+}
+
+// TestDetailsSchemaMatchesTypes keeps details.schema.json in sync with the
+// json tags of CaseDetails and its sub-structures.
+func TestDetailsSchemaMatchesTypes(t *testing.T) {
+	var schema map[string]any
+	if err := json.Unmarshal(DetailsSchema(), &schema); err != nil {
+		t.Fatalf("parse schema: %v", err)
+	}
+	var check func(path string, node map[string]any, typ reflect.Type)
+	check = func(path string, node map[string]any, typ reflect.Type) {
+		props, _ := node["properties"].(map[string]any)
+		var schemaKeys, typeKeys []string
+		for key := range props {
+			schemaKeys = append(schemaKeys, key)
+		}
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			typeKeys = append(typeKeys, name)
+			ft := field.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				child, _ := props[name].(map[string]any)
+				check(path+"."+name, child, ft)
+			}
+		}
+		sort.Strings(schemaKeys)
+		sort.Strings(typeKeys)
+		if !slices.Equal(schemaKeys, typeKeys) {
+			t.Fatalf("schema %s properties=%v, type fields=%v", path, schemaKeys, typeKeys)
+		}
+	}
+	check("typed_details", schema, reflect.TypeOf(CaseDetails{}))
+	version := schema["properties"].(map[string]any)["version"].(map[string]any)["const"]
+	if version != float64(DetailsSchemaVersion) {
+		t.Fatalf("schema version=%v want %d", version, DetailsSchemaVersion)
+	}
+}
@@ -0,0 +1,25 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// existingParent walks up from dir until it finds a path that exists, so the
+// free space of a not-yet-created output directory can still be measured.
+func existingParent(dir string) string {
+	if dir == "" {
+		dir = "."
+	}
+	path := filepath.Clean(dir)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
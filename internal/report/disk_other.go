@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !freebsd
+
+package report
+
+import "errors"
+
+// FreeDiskBytes is not supported on this platform.
+func FreeDiskBytes(dir string) (uint64, error) {
+	return 0, errors.New("free disk space is not supported on this platform")
+}
@@ -0,0 +1,19 @@
+//go:build linux || darwin || freebsd
+
+package report
+
+import (
+	"os"
+	"syscall"
+)
+
+// FreeDiskBytes returns the space available to unprivileged users on the
+// filesystem holding dir. A missing dir is resolved through its nearest parent.
+func FreeDiskBytes(dir string) (uint64, error) {
+	path := existingParent(dir)
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, &os.PathError{Op: "statfs", Path: path, Err: err}
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
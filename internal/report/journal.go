@@ -0,0 +1,206 @@
+package report
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"shiro/internal/util"
+)
+
+// Case directories are written under StagingDir and renamed into OutputDir
+// once the case is complete. The journal records every case that was begun
+// and committed, so a run killed mid-case leaves a staged directory that the
+// next run can recover or discard instead of a half-written case that report
+// loaders trip over.
+const (
+	StagingDir      = ".staging"
+	CaseJournalFile = ".case_journal.jsonl"
+
+	journalOpBegin  = "begin"
+	journalOpCommit = "commit"
+)
+
+type journalRecord struct {
+	Op     string `json:"op"`
+	CaseID string `json:"case_id"`
+	Dir    string `json:"dir"`
+	Time   string `json:"ts"`
+}
+
+// CaseRecovery counts what RecoverStagedCases did with interrupted cases.
+type CaseRecovery struct {
+	Recovered int
+	Discarded int
+}
+
+func (r *Reporter) appendJournal(op string, c Case) error {
+	record := journalRecord{Op: op, CaseID: c.ID, Dir: filepath.Base(c.Dir), Time: time.Now().UTC().Format(time.RFC3339)}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(r.OutputDir, CaseJournalFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer util.CloseWithErr(f, "case journal")
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// CommittedDir returns the directory c moves to when it is committed. Paths
+// that outlive the case capture, such as those handed to hooks for links,
+// must use it rather than the staged c.Dir.
+func (r *Reporter) CommittedDir(c Case) string {
+	return filepath.Join(r.OutputDir, filepath.Base(c.Dir))
+}
+
+// CommitCase renames a staged case into OutputDir and journals the commit.
+// The returned Case points at the final directory. Paths recorded in the
+// summary are relative to the case directory, so the rename leaves them valid.
+func (r *Reporter) CommitCase(c Case) (Case, error) {
+	final := r.CommittedDir(c)
+	if c.Dir == final {
+		return c, nil
+	}
+	if err := os.Rename(c.Dir, final); err != nil {
+		return c, err
+	}
+	committed := Case{ID: c.ID, Dir: final}
+	if err := r.appendJournal(journalOpCommit, committed); err != nil {
+		return committed, err
+	}
+	return committed, nil
+}
+
+// RecoverStagedCases handles cases a killed run left under StagingDir. A
+// staged case whose summary.json parses is renamed into OutputDir and marked
+// with details.case_recovered; any other staged case is removed. Staged
+// directories missing from the journal are treated the same way. The journal
+// is truncated afterwards, since no case is in flight at startup.
+func (r *Reporter) RecoverStagedCases() (CaseRecovery, error) {
+	var out CaseRecovery
+	if r == nil || strings.TrimSpace(r.OutputDir) == "" {
+		return out, nil
+	}
+	pending, err := r.readJournal()
+	if err != nil {
+		return out, err
+	}
+	stagingRoot := filepath.Join(r.OutputDir, StagingDir)
+	entries, err := os.ReadDir(stagingRoot)
+	if err != nil && !os.IsNotExist(err) {
+		return out, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		staged := filepath.Join(stagingRoot, entry.Name())
+		caseID := pending[entry.Name()]
+		summary, ok := readStagedSummary(staged)
+		if !ok {
+			if err := os.RemoveAll(staged); err != nil {
+				return out, err
+			}
+			util.Warnf("case journal discarded partial case dir=%s case_id=%s", staged, caseID)
+			out.Discarded++
+			continue
+		}
+		final := filepath.Join(r.OutputDir, entry.Name())
+		if _, err := os.Stat(final); err == nil {
+			return out, fmt.Errorf("recover staged case %s: %s already exists", staged, final)
+		}
+		if err := os.Rename(staged, final); err != nil {
+			return out, err
+		}
+		if summary.Details == nil {
+			summary.Details = map[string]any{}
+		}
+		summary.Details["case_recovered"] = true
+		if err := r.WriteSummary(Case{ID: summary.CaseID, Dir: final}, summary); err != nil {
+			return out, err
+		}
+		util.Warnf("case journal recovered interrupted case dir=%s case_id=%s", final, summary.CaseID)
+		out.Recovered++
+	}
+	if err := os.Remove(filepath.Join(r.OutputDir, CaseJournalFile)); err != nil && !os.IsNotExist(err) {
+		return out, err
+	}
+	return out, nil
+}
+
+// readJournal returns the case IDs of begun but uncommitted cases, keyed by
+// directory name. A torn last line from a kill is ignored.
+func (r *Reporter) readJournal() (map[string]string, error) {
+	pending := map[string]string{}
+	f, err := os.Open(filepath.Join(r.OutputDir, CaseJournalFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return pending, nil
+		}
+		return nil, err
+	}
+	defer util.CloseWithErr(f, "case journal")
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record journalRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+		switch record.Op {
+		case journalOpBegin:
+			pending[record.Dir] = record.CaseID
+		case journalOpCommit:
+			delete(pending, record.Dir)
+		}
+	}
+	return pending, scanner.Err()
+}
+
+func readStagedSummary(dir string) (Summary, bool) {
+	data, err := os.ReadFile(filepath.Join(dir, "summary.json"))
+	if err != nil {
+		return Summary{}, false
+	}
+	var summary Summary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return Summary{}, false
+	}
+	return summary, true
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// over path, so readers see either the old or the new content in full.
+func writeFileAtomic(path string, data []byte) (err error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+	if _, err = tmp.Write(data); err != nil {
+		return errors.Join(err, tmp.Close())
+	}
+	if err = tmp.Sync(); err != nil {
+		return errors.Join(err, tmp.Close())
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,122 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCommitCaseMovesStagedCase(t *testing.T) {
+	outputDir := t.TempDir()
+	reporter := New(outputDir, 16)
+	c, err := reporter.NewCase()
+	if err != nil {
+		t.Fatalf("new case: %v", err)
+	}
+	if filepath.Dir(c.Dir) != filepath.Join(outputDir, StagingDir) {
+		t.Fatalf("expected a staged case dir, got %s", c.Dir)
+	}
+	if err := reporter.WriteSummary(c, Summary{CaseID: c.ID, Oracle: "NoREC"}); err != nil {
+		t.Fatalf("write summary: %v", err)
+	}
+	finalDir := reporter.CommittedDir(c)
+	committed, err := reporter.CommitCase(c)
+	if err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if committed.Dir != finalDir || finalDir != filepath.Join(outputDir, filepath.Base(c.Dir)) {
+		t.Fatalf("unexpected committed dir %s", committed.Dir)
+	}
+	if _, err := os.Stat(c.Dir); !os.IsNotExist(err) {
+		t.Fatalf("expected staged dir to be gone, err=%v", err)
+	}
+	if got, err := readSummaryJSON(filepath.Join(committed.Dir, "summary.json")); err != nil || got.Oracle != "NoREC" {
+		t.Fatalf("unexpected committed summary %+v err=%v", got, err)
+	}
+	recovery, err := reporter.RecoverStagedCases()
+	if err != nil || recovery != (CaseRecovery{}) {
+		t.Fatalf("expected nothing to recover, got %+v err=%v", recovery, err)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, CaseJournalFile)); !os.IsNotExist(err) {
+		t.Fatalf("expected the journal to be truncated, err=%v", err)
+	}
+}
+
+func TestRecoverStagedCases(t *testing.T) {
+	outputDir := t.TempDir()
+	reporter := New(outputDir, 16)
+	complete, err := reporter.NewCase()
+	if err != nil {
+		t.Fatalf("new case: %v", err)
+	}
+	if err := reporter.WriteSummary(complete, Summary{CaseID: complete.ID, MinimizeStatus: "in_progress"}); err != nil {
+		t.Fatalf("write summary: %v", err)
+	}
+	torn, err := reporter.NewCase()
+	if err != nil {
+		t.Fatalf("new case: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(torn.Dir, "summary.json"), []byte(`{"oracle": "TL`), 0o644); err != nil {
+		t.Fatalf("write torn summary: %v", err)
+	}
+	empty, err := reporter.NewCase()
+	if err != nil {
+		t.Fatalf("new case: %v", err)
+	}
+	journal, err := os.OpenFile(filepath.Join(outputDir, CaseJournalFile), os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("open journal: %v", err)
+	}
+	if _, err := journal.WriteString(`{"op":"comm`); err != nil {
+		t.Fatalf("tear journal: %v", err)
+	}
+	_ = journal.Close()
+
+	recovery, err := reporter.RecoverStagedCases()
+	if err != nil {
+		t.Fatalf("recover: %v", err)
+	}
+	if recovery != (CaseRecovery{Recovered: 1, Discarded: 2}) {
+		t.Fatalf("unexpected recovery %+v", recovery)
+	}
+	final := filepath.Join(outputDir, filepath.Base(complete.Dir))
+	got, err := readSummaryJSON(filepath.Join(final, "summary.json"))
+	if err != nil {
+		t.Fatalf("read recovered summary: %v", err)
+	}
+	if got.Details["case_recovered"] != true || got.MinimizeStatus != "in_progress" {
+		t.Fatalf("unexpected recovered summary %+v", got)
+	}
+	for _, dir := range []string{complete.Dir, torn.Dir, empty.Dir} {
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Fatalf("expected staged dir %s to be gone, err=%v", dir, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, filepath.Base(torn.Dir))); !os.IsNotExist(err) {
+		t.Fatalf("expected the torn case to be discarded, err=%v", err)
+	}
+}
+
+func TestWriteFileAtomicReplacesContent(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "summary.json")
+	for _, content := range []string{"first", "second"} {
+		if err := writeFileAtomic(path, []byte(content)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "second" {
+		t.Fatalf("unexpected content %q err=%v", data, err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".tmp-") {
+			t.Fatalf("temp file left behind: %s", entry.Name())
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// SummarySchemaVersion is the version of the summary.json and report.json
+// layout. Summaries without schema_version are version 1. Bump it and append
+// a step to summaryMigrations when a field is renamed, removed, or changes
+// meaning; adding a field does not need a bump.
+const SummarySchemaVersion = 2
+
+// summaryMigration upgrades a raw summary from version from to from+1.
+// caseDir is the case directory, for fields old summaries lack.
+type summaryMigration struct {
+	from  int
+	apply func(raw map[string]any, caseDir string)
+}
+
+var summaryMigrations = []summaryMigration{
+	{from: 1, apply: migrateSummaryV1},
+}
+
+var caseDirNamePattern = regexp.MustCompile(`^case_\d+_(.+)$`)
+
+// migrateSummaryV1 fills case_id and case_dir, which early summaries did not
+// record, from the case directory name. typed_details is rebuilt from details
+// whenever a summary is written.
+func migrateSummaryV1(raw map[string]any, caseDir string) {
+	name := filepath.Base(caseDir)
+	if caseDir == "" || name == "." {
+		return
+	}
+	if s, _ := raw["case_dir"].(string); s == "" {
+		raw["case_dir"] = name
+	}
+	if s, _ := raw["case_id"].(string); s == "" {
+		id := name
+		if m := caseDirNamePattern.FindStringSubmatch(name); m != nil {
+			id = m[1]
+		}
+		raw["case_id"] = id
+	}
+}
+
+// rawSummaryVersion returns the schema_version of a raw summary, 1 when it is
+// missing or zero.
+func rawSummaryVersion(raw map[string]any) (int, error) {
+	value, ok := raw["schema_version"]
+	if !ok || value == nil {
+		return 1, nil
+	}
+	number, ok := value.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("schema_version is %T, not a number", value)
+	}
+	version, err := number.Int64()
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("invalid schema_version %s", number)
+	}
+	return max(int(version), 1), nil
+}
+
+// MigrateSummaryJSON upgrades a raw summary decoded with json.Number values
+// to SummarySchemaVersion in place and returns the version it had. Summaries
+// written by a newer shiro are rejected rather than read with missing fields.
+func MigrateSummaryJSON(raw map[string]any, caseDir string) (int, error) {
+	from, err := rawSummaryVersion(raw)
+	if err != nil {
+		return 0, err
+	}
+	if from > SummarySchemaVersion {
+		return from, fmt.Errorf("summary schema_version %d is newer than supported version %d", from, SummarySchemaVersion)
+	}
+	for _, step := range summaryMigrations {
+		if version, _ := rawSummaryVersion(raw); step.from == version {
+			step.apply(raw, caseDir)
+			raw["schema_version"] = json.Number(fmt.Sprint(step.from + 1))
+		}
+	}
+	return from, nil
+}
+
+// DecodeSummary parses summary.json or report.json data of any supported
+// schema version into the current layout. caseDir is the case directory the
+// data was read from; it may be empty.
+func DecodeSummary(data []byte, caseDir string) (Summary, error) {
+	raw, err := decodeRawSummary(data)
+	if err != nil {
+		return Summary{}, err
+	}
+	if _, err := MigrateSummaryJSON(raw, caseDir); err != nil {
+		return Summary{}, err
+	}
+	return summaryFromRaw(raw)
+}
+
+func decodeRawSummary(data []byte) (map[string]any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var raw map[string]any
+	if err := dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, fmt.Errorf("summary is not a JSON object")
+	}
+	return raw, nil
+}
+
+func summaryFromRaw(raw map[string]any) (Summary, error) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return Summary{}, err
+	}
+	var summary Summary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return Summary{}, err
+	}
+	return summary, nil
+}
+
+// CaseMigration describes the migration of one case directory.
+type CaseMigration struct {
+	Dir      string
+	From     int
+	Migrated bool
+}
+
+// MigrateCaseDir upgrades summary.json and report.json in dir to
+// SummarySchemaVersion. Up-to-date files are left untouched, so the
+// migration can be rerun; with dryRun nothing is written.
+func MigrateCaseDir(dir string, dryRun bool) (CaseMigration, error) {
+	out := CaseMigration{Dir: dir, From: SummarySchemaVersion}
+	for _, name := range []string{"summary.json", "report.json"} {
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return out, err
+		}
+		raw, err := decodeRawSummary(data)
+		if err != nil {
+			return out, fmt.Errorf("%s: %w", name, err)
+		}
+		from, err := MigrateSummaryJSON(raw, dir)
+		if err != nil {
+			return out, fmt.Errorf("%s: %w", name, err)
+		}
+		if from == SummarySchemaVersion {
+			continue
+		}
+		out.From = min(out.From, from)
+		out.Migrated = true
+		if dryRun {
+			continue
+		}
+		summary, err := summaryFromRaw(raw)
+		if err != nil {
+			return out, fmt.Errorf("%s: %w", name, err)
+		}
+		if err := writeSummaryAt(path, summary); err != nil {
+			return out, err
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,88 @@
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const legacySummaryJSON = `{"oracle":"NoREC","sql":["SELECT 1"],"seed":9007199254740993,"details":{"error_reason":"norec:mismatch"}}`
+
+func TestDecodeSummaryMigratesLegacy(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "case_0003_0190a1b2-c3d4")
+	summary, err := DecodeSummary([]byte(legacySummaryJSON), dir)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if summary.SchemaVersion != SummarySchemaVersion {
+		t.Fatalf("schema_version=%d want %d", summary.SchemaVersion, SummarySchemaVersion)
+	}
+	if summary.CaseID != "0190a1b2-c3d4" || summary.CaseDir != "case_0003_0190a1b2-c3d4" {
+		t.Fatalf("unexpected ids case_id=%q case_dir=%q", summary.CaseID, summary.CaseDir)
+	}
+	if summary.Seed != 9007199254740993 {
+		t.Fatalf("seed lost precision: %d", summary.Seed)
+	}
+	if summary.Oracle != "NoREC" || summary.Details["error_reason"] != "norec:mismatch" {
+		t.Fatalf("unexpected summary %+v", summary)
+	}
+}
+
+func TestDecodeSummaryKeepsCurrentAndRejectsNewer(t *testing.T) {
+	current := `{"schema_version":2,"oracle":"TLP","case_id":"abc","case_dir":"kept"}`
+	summary, err := DecodeSummary([]byte(current), "/tmp/case_0001_other")
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if summary.CaseID != "abc" || summary.CaseDir != "kept" {
+		t.Fatalf("current summary was changed: %+v", summary)
+	}
+	if _, err := DecodeSummary([]byte(`{"schema_version":99,"oracle":"TLP"}`), ""); err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Fatalf("expected newer version error, got %v", err)
+	}
+	if _, err := DecodeSummary([]byte(`{"schema_version":"x"}`), ""); err == nil {
+		t.Fatalf("expected invalid version error")
+	}
+}
+
+func TestMigrateCaseDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "case_0001_uuid-1")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	for _, name := range []string{"summary.json", "report.json"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(legacySummaryJSON), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	result, err := MigrateCaseDir(dir, true)
+	if err != nil || !result.Migrated || result.From != 1 {
+		t.Fatalf("dry run: result=%+v err=%v", result, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "summary.json")); string(data) != legacySummaryJSON {
+		t.Fatalf("dry run wrote summary.json: %s", data)
+	}
+	result, err = MigrateCaseDir(dir, false)
+	if err != nil || !result.Migrated {
+		t.Fatalf("migrate: result=%+v err=%v", result, err)
+	}
+	for _, name := range []string{"summary.json", "report.json"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		var raw map[string]any
+		if err := json.Unmarshal(data, &raw); err != nil {
+			t.Fatalf("parse %s: %v", name, err)
+		}
+		if raw["schema_version"] != float64(SummarySchemaVersion) || raw["case_id"] != "uuid-1" {
+			t.Fatalf("unexpected migrated %s: %s", name, data)
+		}
+	}
+	result, err = MigrateCaseDir(dir, false)
+	if err != nil || result.Migrated {
+		t.Fatalf("second migration should be a no-op: result=%+v err=%v", result, err)
+	}
+}
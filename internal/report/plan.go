@@ -0,0 +1,151 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// PlansFile is the case file that holds the canonical plans of a mismatch.
+const PlansFile = "plans.json"
+
+// PlansVersion is the version of the CasePlans layout. Bump it when a field
+// changes meaning or shape; adding a field does not.
+const PlansVersion = 1
+
+// PlanFormatTiDBJSON is the EXPLAIN format canonical plans are parsed from.
+const PlanFormatTiDBJSON = "tidb_json"
+
+// CasePlans holds the canonical plans of both sides of a mismatch case, so
+// tools can diff plans structurally instead of comparing EXPLAIN text.
+type CasePlans struct {
+	Version  int       `json:"version"`
+	Format   string    `json:"format"`
+	Expected *CasePlan `json:"expected,omitempty"`
+	Actual   *CasePlan `json:"actual,omitempty"`
+}
+
+// CasePlan is the canonical plan of one statement. Error is set instead of
+// Roots when EXPLAIN failed or its output could not be parsed.
+type CasePlan struct {
+	SQL   string     `json:"sql"`
+	Roots []PlanNode `json:"roots,omitempty"`
+	Error string     `json:"error,omitempty"`
+}
+
+// PlanNode is one operator of a canonical plan. Operator and the ids inside
+// Conditions drop TiDB's numeric suffixes (HashJoin_8 becomes HashJoin), and
+// estimates are left out, so equal plans compare equal across runs.
+type PlanNode struct {
+	Operator     string `json:"operator"`
+	TaskType     string `json:"task_type,omitempty"`
+	AccessObject string `json:"access_object,omitempty"`
+	// Conditions is the operator info split into its top-level items, such
+	// as predicates, join keys, and ranges.
+	Conditions []string   `json:"conditions,omitempty"`
+	Children   []PlanNode `json:"children,omitempty"`
+}
+
+// tidbJSONPlanNode is one node of EXPLAIN FORMAT='tidb_json' output.
+type tidbJSONPlanNode struct {
+	ID           string             `json:"id"`
+	TaskType     string             `json:"taskType"`
+	AccessObject string             `json:"accessObject"`
+	OperatorInfo string             `json:"operatorInfo"`
+	SubOperators []tidbJSONPlanNode `json:"subOperators"`
+}
+
+var planIDSuffixPattern = regexp.MustCompile(`\b([A-Z][A-Za-z]*)_\d+\b`)
+
+// ParseTiDBJSONPlan parses EXPLAIN FORMAT='tidb_json' output into canonical
+// plan roots. The output is a JSON array with one entry per plan root.
+func ParseTiDBJSONPlan(raw string) ([]PlanNode, error) {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		return nil, fmt.Errorf("empty tidb_json plan")
+	}
+	var nodes []tidbJSONPlanNode
+	if err := json.Unmarshal([]byte(trimmed), &nodes); err != nil {
+		return nil, fmt.Errorf("parse tidb_json plan: %w", err)
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("empty tidb_json plan")
+	}
+	return canonicalPlanNodes(nodes), nil
+}
+
+func canonicalPlanNodes(nodes []tidbJSONPlanNode) []PlanNode {
+	if len(nodes) == 0 {
+		return nil
+	}
+	out := make([]PlanNode, 0, len(nodes))
+	for _, node := range nodes {
+		out = append(out, PlanNode{
+			Operator:     canonicalPlanText(node.ID),
+			TaskType:     strings.TrimSpace(node.TaskType),
+			AccessObject: canonicalPlanText(node.AccessObject),
+			Conditions:   splitOperatorInfo(canonicalPlanText(node.OperatorInfo)),
+			Children:     canonicalPlanNodes(node.SubOperators),
+		})
+	}
+	return out
+}
+
+func canonicalPlanText(text string) string {
+	return planIDSuffixPattern.ReplaceAllString(strings.TrimSpace(text), "$1")
+}
+
+// splitOperatorInfo splits operator info at commas outside parentheses,
+// brackets, and quotes.
+func splitOperatorInfo(info string) []string {
+	if info == "" {
+		return nil
+	}
+	var parts []string
+	depth := 0
+	var quote byte
+	start := 0
+	for i := 0; i < len(info); i++ {
+		c := info[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			if depth > 0 {
+				depth--
+			}
+		case c == ',' && depth == 0:
+			if part := strings.TrimSpace(info[start:i]); part != "" {
+				parts = append(parts, part)
+			}
+			start = i + 1
+		}
+	}
+	if part := strings.TrimSpace(info[start:]); part != "" {
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// WritePlans writes plans.json into the case directory.
+func (r *Reporter) WritePlans(c Case, plans CasePlans) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(plans); err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(c.Dir, PlansFile), buf.Bytes())
+}
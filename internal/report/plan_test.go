@@ -0,0 +1,109 @@
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const tidbJSONPlanSample = `[
+  {
+    "id": "Projection_4",
+    "estRows": "12487.50",
+    "taskType": "root",
+    "operatorInfo": "test.t0.c0, test.t1.c1",
+    "subOperators": [
+      {
+        "id": "HashJoin_8",
+        "estRows": "12487.50",
+        "taskType": "root",
+        "operatorInfo": "inner join, equal:[eq(test.t0.k0, test.t1.k0)], other cond:gt(test.t0.c0, 'a,b')",
+        "subOperators": [
+          {
+            "id": "TableReader_11(Build)",
+            "estRows": "9990.00",
+            "taskType": "root",
+            "operatorInfo": "data:Selection_10",
+            "subOperators": [
+              {
+                "id": "TableFullScan_9",
+                "estRows": "10000.00",
+                "taskType": "cop[tikv]",
+                "accessObject": "table:t1",
+                "operatorInfo": "keep order:false, stats:pseudo"
+              }
+            ]
+          }
+        ]
+      }
+    ]
+  }
+]`
+
+func TestParseTiDBJSONPlan(t *testing.T) {
+	roots, err := ParseTiDBJSONPlan(tidbJSONPlanSample)
+	if err != nil {
+		t.Fatalf("ParseTiDBJSONPlan() failed: %v", err)
+	}
+	want := []PlanNode{{
+		Operator:   "Projection",
+		TaskType:   "root",
+		Conditions: []string{"test.t0.c0", "test.t1.c1"},
+		Children: []PlanNode{{
+			Operator:   "HashJoin",
+			TaskType:   "root",
+			Conditions: []string{"inner join", "equal:[eq(test.t0.k0, test.t1.k0)]", "other cond:gt(test.t0.c0, 'a,b')"},
+			Children: []PlanNode{{
+				Operator:   "TableReader(Build)",
+				TaskType:   "root",
+				Conditions: []string{"data:Selection"},
+				Children: []PlanNode{{
+					Operator:     "TableFullScan",
+					TaskType:     "cop[tikv]",
+					AccessObject: "table:t1",
+					Conditions:   []string{"keep order:false", "stats:pseudo"},
+				}},
+			}},
+		}},
+	}}
+	if !reflect.DeepEqual(roots, want) {
+		got, _ := json.MarshalIndent(roots, "", "  ")
+		t.Fatalf("unexpected canonical plan:\n%s", got)
+	}
+
+	for _, raw := range []string{"", "[]", "id\testRows"} {
+		if _, err := ParseTiDBJSONPlan(raw); err == nil {
+			t.Fatalf("ParseTiDBJSONPlan(%q) should fail", raw)
+		}
+	}
+}
+
+func TestWritePlans(t *testing.T) {
+	dir := t.TempDir()
+	roots, err := ParseTiDBJSONPlan(tidbJSONPlanSample)
+	if err != nil {
+		t.Fatalf("ParseTiDBJSONPlan() failed: %v", err)
+	}
+	plans := CasePlans{
+		Version:  PlansVersion,
+		Format:   PlanFormatTiDBJSON,
+		Expected: &CasePlan{SQL: "SELECT 1", Roots: roots},
+		Actual:   &CasePlan{SQL: "SELECT 2", Error: "explain failed"},
+	}
+	if err := New(dir, 0).WritePlans(Case{Dir: dir}, plans); err != nil {
+		t.Fatalf("WritePlans() failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, PlansFile))
+	if err != nil {
+		t.Fatalf("read plans: %v", err)
+	}
+	var got CasePlans
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("decode plans: %v", err)
+	}
+	if !reflect.DeepEqual(got, plans) {
+		t.Fatalf("plans did not round-trip: %+v", got)
+	}
+}
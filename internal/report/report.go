@@ -4,6 +4,8 @@ import (
 	"archive/tar"
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -18,6 +20,7 @@ import (
 	"shiro/internal/db"
 	"shiro/internal/runinfo"
 	"shiro/internal/schema"
+	"shiro/internal/sqlstep"
 	"shiro/internal/util"
 
 	"github.com/google/uuid"
@@ -40,36 +43,67 @@ type Case struct {
 
 // Summary captures the persisted metadata for a case.
 type Summary struct {
-	Oracle                       string             `json:"oracle"`
-	SQL                          []string           `json:"sql"`
-	Expected                     string             `json:"expected"`
-	Actual                       string             `json:"actual"`
-	Error                        string             `json:"error"`
-	ErrorReason                  string             `json:"error_reason"`
-	ErrorSignature               string             `json:"error_signature"`
-	BugHint                      string             `json:"bug_hint"`
-	GroundTruthDSGMismatchReason string             `json:"groundtruth_dsg_mismatch_reason"`
-	ErrorSQL                     string             `json:"error_sql"`
-	ReplaySQL                    string             `json:"replay_sql"`
-	MinimizeStatus               string             `json:"minimize_status"`
-	Flaky                        bool               `json:"flaky"`
-	Seed                         int64              `json:"seed"`
-	RunInfo                      *runinfo.BasicInfo `json:"run_info,omitempty"`
-	PlanReplay                   string             `json:"plan_replayer"`
-	UploadLocation               string             `json:"upload_location"`
-	CaseID                       string             `json:"case_id"`
-	CaseDir                      string             `json:"case_dir"`
-	ArchiveName                  string             `json:"archive_name"`
-	ArchiveCodec                 string             `json:"archive_codec"`
-	NoRECOptimizedSQL            string             `json:"norec_optimized_sql"`
-	NoRECUnoptimizedSQL          string             `json:"norec_unoptimized_sql"`
-	NoRECPredicate               string             `json:"norec_predicate"`
-	Details                      map[string]any     `json:"details"`
-	GroundTruth                  *TruthSummary      `json:"groundtruth,omitempty"`
-	Timestamp                    string             `json:"timestamp"`
-	TiDBVersion                  string             `json:"tidb_version"`
-	PlanSignature                string             `json:"plan_signature"`
-	PlanSigFormat                string             `json:"plan_signature_format"`
+	SchemaVersion                int                   `json:"schema_version,omitempty"`
+	Oracle                       string                `json:"oracle"`
+	Title                        string                `json:"title,omitempty"`
+	SQL                          []string              `json:"sql"`
+	Steps                        []sqlstep.Step        `json:"steps,omitempty"`
+	Expected                     string                `json:"expected"`
+	Actual                       string                `json:"actual"`
+	Error                        string                `json:"error"`
+	ErrorReason                  string                `json:"error_reason"`
+	ErrorSignature               string                `json:"error_signature"`
+	BugHint                      string                `json:"bug_hint"`
+	Severity                     string                `json:"severity,omitempty"`
+	GroundTruthDSGMismatchReason string                `json:"groundtruth_dsg_mismatch_reason"`
+	ErrorSQL                     string                `json:"error_sql"`
+	ReplaySQL                    string                `json:"replay_sql"`
+	MinimizeStatus               string                `json:"minimize_status"`
+	MinimizeVerify               string                `json:"minimize_verify,omitempty"`
+	Flaky                        bool                  `json:"flaky"`
+	Seed                         int64                 `json:"seed"`
+	RunInfo                      *runinfo.BasicInfo    `json:"run_info,omitempty"`
+	PlanReplay                   string                `json:"plan_replayer"`
+	UploadLocation               string                `json:"upload_location"`
+	CaseID                       string                `json:"case_id"`
+	CaseDir                      string                `json:"case_dir"`
+	ArchiveName                  string                `json:"archive_name"`
+	ArchiveCodec                 string                `json:"archive_codec"`
+	ArchiveSHA256                string                `json:"archive_sha256,omitempty"`
+	NoRECOptimizedSQL            string                `json:"norec_optimized_sql"`
+	NoRECUnoptimizedSQL          string                `json:"norec_unoptimized_sql"`
+	NoRECPredicate               string                `json:"norec_predicate"`
+	Details                      map[string]any        `json:"details"`
+	TypedDetails                 *CaseDetails          `json:"typed_details,omitempty"`
+	GroundTruth                  *TruthSummary         `json:"groundtruth,omitempty"`
+	Timestamp                    string                `json:"timestamp"`
+	TiDBVersion                  string                `json:"tidb_version"`
+	Topology                     *ClusterTopology      `json:"topology,omitempty"`
+	PlanSignature                string                `json:"plan_signature"`
+	PlanSigFormat                string                `json:"plan_signature_format"`
+	PlansFile                    string                `json:"plans_file,omitempty"`
+	OracleApplicability          []OracleApplicability `json:"oracle_applicability,omitempty"`
+	SQLLog                       *SQLLogRef            `json:"sql_log,omitempty"`
+}
+
+// SQLLogRef points at the statement log position when a case was captured.
+// Statements up to and including Seq in File (and earlier parts) precede the case.
+type SQLLogRef struct {
+	Dir  string `json:"dir"`
+	File string `json:"file"`
+	Seq  int64  `json:"seq"`
+}
+
+// OracleApplicability records how an oracle behaved on the database epoch that produced a case.
+// Runs that were skipped keep their skip reasons so a report can tell "never applied" from "found nothing".
+type OracleApplicability struct {
+	Oracle      string           `json:"oracle"`
+	Runs        int64            `json:"runs"`
+	Effective   int64            `json:"effective"`
+	Skips       int64            `json:"skips"`
+	Errors      int64            `json:"errors"`
+	SkipReasons map[string]int64 `json:"skip_reasons,omitempty"`
+	Variants    map[string]int64 `json:"variants,omitempty"`
 }
 
 // TruthSummary captures optional ground-truth evaluation metadata.
@@ -84,7 +118,8 @@ func New(outputDir string, maxRows int) *Reporter {
 	return &Reporter{OutputDir: outputDir, MaxDataDumpRows: maxRows}
 }
 
-// NewCase allocates a new case directory.
+// NewCase allocates a new case directory under StagingDir and journals it.
+// CommitCase moves it into OutputDir once the case is complete.
 func (r *Reporter) NewCase() (Case, error) {
 	r.caseSeq++
 	caseID := uuid.New().String()
@@ -95,12 +130,16 @@ func (r *Reporter) NewCase() (Case, error) {
 	if r.UseUUIDPath {
 		caseDir = caseID
 	}
-	dir := filepath.Join(r.OutputDir, caseDir)
+	dir := filepath.Join(r.OutputDir, StagingDir, caseDir)
 	if err := os.MkdirAll(dir, 0o755); err != nil {
 		return Case{}, err
 	}
-	_ = os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Reproduce Case\n\n- Apply schema: schema.sql\n- Load data: inserts.sql (preferred) or data.tsv\n- Run query: case.sql\n- Plan replayer: plan_replayer.zip (if present)\n"), 0o644)
-	return Case{ID: caseID, Dir: dir}, nil
+	c := Case{ID: caseID, Dir: dir}
+	if err := r.appendJournal(journalOpBegin, c); err != nil {
+		return Case{}, err
+	}
+	_ = writeFileAtomic(filepath.Join(dir, "README.md"), []byte("# Reproduce Case\n\n- Apply schema: schema.sql\n- Load data: inserts.sql (preferred) or data.tsv\n- Run query: case.sql\n- Plan replayer: plan_replayer.zip (if present)\n"))
+	return c, nil
 }
 
 // Case archive artifact metadata used for per-case compressed bundles.
@@ -146,8 +185,8 @@ func (r *Reporter) RecoverInterruptedMinimizeCases(reason string) (int, error) {
 			}
 			return updated, err
 		}
-		var summary Summary
-		if err := json.Unmarshal(data, &summary); err != nil {
+		summary, err := DecodeSummary(data, caseDir)
+		if err != nil {
 			continue
 		}
 		if summary.MinimizeStatus != "in_progress" {
@@ -183,15 +222,22 @@ func (r *Reporter) RecoverInterruptedMinimizeCases(reason string) (int, error) {
 }
 
 func (r *Reporter) writeSummaryFile(c Case, name string, summary Summary) error {
-	f, err := os.Create(filepath.Join(c.Dir, name))
-	if err != nil {
-		return err
-	}
-	defer util.CloseWithErr(f, "summary output")
-	enc := json.NewEncoder(f)
+	return writeSummaryAt(filepath.Join(c.Dir, name), summary)
+}
+
+// writeSummaryAt stamps the current schema version and typed details on
+// summary and writes it to path.
+func writeSummaryAt(path string, summary Summary) error {
+	var buf bytes.Buffer
+	summary.SchemaVersion = SummarySchemaVersion
+	summary.TypedDetails = ParseCaseDetails(summary.Details)
+	enc := json.NewEncoder(&buf)
 	enc.SetIndent("", "  ")
 	enc.SetEscapeHTML(false)
-	return encodeSummaryStable(enc, summary)
+	if err := encodeSummaryStable(enc, summary); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, buf.Bytes())
 }
 
 // WriteSQL writes a SQL file from the provided statements.
@@ -204,7 +250,7 @@ func (r *Reporter) WriteSQL(c Case, name string, statements []string) error {
 			return err
 		}
 	}
-	return os.WriteFile(path, []byte(content), 0o644)
+	return writeFileAtomic(path, []byte(content))
 }
 
 // WriteText writes raw text content into the case directory.
@@ -216,7 +262,7 @@ func (r *Reporter) WriteText(c Case, name string, content string) error {
 			return err
 		}
 	}
-	return os.WriteFile(path, []byte(content), 0o644)
+	return writeFileAtomic(path, []byte(content))
 }
 
 // WriteCaseArchive creates a compressed archive for the case directory.
@@ -293,6 +339,21 @@ func (r *Reporter) WriteCaseArchive(c Case) (name string, codec string, err erro
 	return CaseArchiveName, CaseArchiveCodec, nil
 }
 
+// ArchiveSHA256 returns the hex sha256 of the case archive, which the summary
+// records so a published archive can be checked against it.
+func (r *Reporter) ArchiveSHA256(c Case) (string, error) {
+	file, err := os.Open(filepath.Join(c.Dir, CaseArchiveName))
+	if err != nil {
+		return "", err
+	}
+	defer util.CloseWithErr(file, "archive checksum")
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
 // DumpSchema writes schema.sql for the current state.
 func (r *Reporter) DumpSchema(ctx context.Context, c Case, exec *db.DB, state *schema.State) error {
 	var b strings.Builder
@@ -330,7 +391,7 @@ func (r *Reporter) DumpSchema(ctx context.Context, c Case, exec *db.DB, state *s
 		b.WriteString(";\n\n")
 	}
 	b.WriteString("SET FOREIGN_KEY_CHECKS=1;\n")
-	return os.WriteFile(filepath.Join(c.Dir, "schema.sql"), []byte(b.String()), 0o644)
+	return writeFileAtomic(filepath.Join(c.Dir, "schema.sql"), []byte(b.String()))
 }
 
 func normalizeCreateView(sql string, dbName string) string {
@@ -350,7 +411,7 @@ func (r *Reporter) DumpData(ctx context.Context, c Case, exec *db.DB, state *sch
 	for _, tbl := range sortedTables(state.Tables) {
 		b.WriteString(fmt.Sprintf("-- %s\n", tbl.Name))
 		orderBy := stableOrderBy(tbl)
-		sql := fmt.Sprintf("SELECT * FROM %s", tbl.Name)
+		sql := fmt.Sprintf("SELECT %s FROM %s", dumpSelectList(tbl), tbl.Name)
 		if orderBy != "" {
 			sql += " ORDER BY " + orderBy
 		}
@@ -383,7 +444,31 @@ func (r *Reporter) DumpData(ctx context.Context, c Case, exec *db.DB, state *sch
 		util.CloseWithErr(rows, "schema rows")
 		b.WriteString("\n")
 	}
-	return os.WriteFile(filepath.Join(c.Dir, "data.tsv"), []byte(b.String()), 0o644)
+	return writeFileAtomic(filepath.Join(c.Dir, "data.tsv"), []byte(b.String()))
+}
+
+// dumpSelectList selects every column, reading BIT columns as integers so
+// data.tsv does not hold raw bytes.
+func dumpSelectList(tbl schema.Table) string {
+	hasBit := false
+	for _, col := range tbl.Columns {
+		if col.Type == schema.TypeBit {
+			hasBit = true
+			break
+		}
+	}
+	if !hasBit {
+		return "*"
+	}
+	items := make([]string, 0, len(tbl.Columns))
+	for _, col := range tbl.Columns {
+		if col.Type == schema.TypeBit {
+			items = append(items, fmt.Sprintf("`%s`+0 AS `%s`", col.Name, col.Name))
+			continue
+		}
+		items = append(items, fmt.Sprintf("`%s`", col.Name))
+	}
+	return strings.Join(items, ", ")
 }
 
 func sortedTables(tables []schema.Table) []schema.Table {
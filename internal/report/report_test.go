@@ -127,3 +127,14 @@ func readSummaryJSON(path string) (Summary, error) {
 	}
 	return summary, nil
 }
+
+func TestFreeDiskBytesMissingDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "missing", "reports")
+	free, err := FreeDiskBytes(dir)
+	if err != nil {
+		t.Fatalf("FreeDiskBytes: %v", err)
+	}
+	if free == 0 {
+		t.Fatalf("expected free space for %s", dir)
+	}
+}
@@ -0,0 +1,101 @@
+package report
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// topologyTypeOrder lists the well-known instance types in the order they are
+// reported; other types follow alphabetically.
+var topologyTypeOrder = []string{"pd", "tidb", "tikv", "tiflash"}
+
+// ClusterTopology records the instances of the cluster under test, so cases
+// that only reproduce on multi-node or TiFlash-equipped clusters can be told
+// apart in reports.
+type ClusterTopology struct {
+	Components []TopologyComponent `json:"components"`
+}
+
+// TopologyComponent counts the instances of one type and their distinct
+// versions.
+type TopologyComponent struct {
+	Type     string   `json:"type"`
+	Count    int      `json:"count"`
+	Versions []string `json:"versions,omitempty"`
+}
+
+// NewClusterTopology builds a topology from (TYPE, VERSION) rows of
+// information_schema.CLUSTER_INFO. It returns nil when there are no rows.
+func NewClusterTopology(rows [][2]string) *ClusterTopology {
+	byType := make(map[string]*TopologyComponent)
+	for _, row := range rows {
+		typ := strings.ToLower(strings.TrimSpace(row[0]))
+		if typ == "" {
+			continue
+		}
+		comp := byType[typ]
+		if comp == nil {
+			comp = &TopologyComponent{Type: typ}
+			byType[typ] = comp
+		}
+		comp.Count++
+		if version := strings.TrimSpace(row[1]); version != "" && !slices.Contains(comp.Versions, version) {
+			comp.Versions = append(comp.Versions, version)
+		}
+	}
+	if len(byType) == 0 {
+		return nil
+	}
+	types := make([]string, 0, len(byType))
+	for typ := range byType {
+		types = append(types, typ)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		ri, rj := topologyTypeRank(types[i]), topologyTypeRank(types[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return types[i] < types[j]
+	})
+	topo := &ClusterTopology{Components: make([]TopologyComponent, 0, len(types))}
+	for _, typ := range types {
+		comp := byType[typ]
+		sort.Strings(comp.Versions)
+		topo.Components = append(topo.Components, *comp)
+	}
+	return topo
+}
+
+func topologyTypeRank(typ string) int {
+	if i := slices.Index(topologyTypeOrder, typ); i >= 0 {
+		return i
+	}
+	return len(topologyTypeOrder)
+}
+
+// Count returns the number of instances of typ.
+func (t *ClusterTopology) Count(typ string) int {
+	if t == nil {
+		return 0
+	}
+	for _, comp := range t.Components {
+		if comp.Type == typ {
+			return comp.Count
+		}
+	}
+	return 0
+}
+
+// String renders the instance counts, for example "pd=1 tidb=2 tikv=3 tiflash=1".
+func (t *ClusterTopology) String() string {
+	if t == nil {
+		return ""
+	}
+	parts := make([]string, 0, len(t.Components))
+	for _, comp := range t.Components {
+		parts = append(parts, fmt.Sprintf("%s=%d", comp.Type, comp.Count))
+	}
+	return strings.Join(parts, " ")
+}
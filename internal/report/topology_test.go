@@ -0,0 +1,39 @@
+package report
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNewClusterTopology(t *testing.T) {
+	topo := NewClusterTopology([][2]string{
+		{"tikv", "8.5.0"},
+		{"tiflash", "8.5.0"},
+		{"tidb", "8.5.0-alpha"},
+		{"TiKV", "8.5.0"},
+		{"pd", "8.5.0"},
+		{"tikv", "8.5.1"},
+		{"ticdc", "8.5.0"},
+		{"", "ignored"},
+	})
+	if got := topo.String(); got != "pd=1 tidb=1 tikv=3 tiflash=1 ticdc=1" {
+		t.Fatalf("unexpected topology string: %q", got)
+	}
+	if topo.Count("tikv") != 3 || topo.Count("tiflash") != 1 || topo.Count("tiproxy") != 0 {
+		t.Fatalf("unexpected counts: %+v", topo.Components)
+	}
+	raw, err := json.Marshal(topo.Components[2])
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if string(raw) != `{"type":"tikv","count":3,"versions":["8.5.0","8.5.1"]}` {
+		t.Fatalf("unexpected component json: %s", raw)
+	}
+	if NewClusterTopology(nil) != nil {
+		t.Fatalf("expected nil topology without rows")
+	}
+	var none *ClusterTopology
+	if none.String() != "" || none.Count("tidb") != 0 {
+		t.Fatalf("nil topology should be empty")
+	}
+}
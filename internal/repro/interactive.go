@@ -0,0 +1,526 @@
+package repro
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"shiro/internal/db"
+	"shiro/internal/sqlstep"
+	"shiro/internal/util"
+)
+
+const (
+	shellPrompt      = "shiro> "
+	shellPreviewRows = 10
+	shellMaxLine     = 1 << 20
+)
+
+const shellHelp = `commands:
+  :list                 list statements, ">" marks the next one
+  :show N               print statement N
+  :next [K]             run the next K statements (default 1)
+  :run                  run the remaining statements, stopping at the first error
+  :goto N               move the cursor to statement N without running anything
+  :edit N SQL           replace statement N
+  :restore N            restore statement N to the case text
+  :hint N [HINTS]       add optimizer hints to the first SELECT of statement N; no HINTS clears them
+  :set [VAR=VALUE]      set a session variable; without arguments list the ones set so far
+  :check                run the expected/actual pair (or the failing statement) and print the verdict
+  :reset                reload schema.sql and inserts.sql and move the cursor to statement 1
+  :help                 print this help
+  :quit                 leave the shell
+any other input runs as SQL on the shell session.
+`
+
+var (
+	shellSelectKeyword = regexp.MustCompile(`(?i)\bSELECT\b`)
+	shellLeadingHint   = regexp.MustCompile(`^\s*/\*\+(.*?)\*/`)
+)
+
+// shellSummary carries the summary.json fields the shell needs for verdicts.
+type shellSummary struct {
+	Oracle   string         `json:"oracle"`
+	Expected string         `json:"expected"`
+	Actual   string         `json:"actual"`
+	Details  map[string]any `json:"details"`
+}
+
+// shell is an interactive session over one connection. Edits and hints change
+// the in-memory statements only; the case files are never rewritten.
+type shell struct {
+	conn     *sql.Conn
+	caseDir  string
+	database string
+	out      io.Writer
+
+	summary  shellSummary
+	steps    []sqlstep.Step
+	original []string
+	cursor   int
+	vars     []string
+}
+
+// rowsDigest is an order-insensitive result signature: the row count and the
+// wrapping sum of per-row FNV hashes, so duplicate rows are not cancelled out.
+type rowsDigest struct {
+	Count int
+	Sum   uint64
+}
+
+func (d rowsDigest) String() string {
+	return fmt.Sprintf("rows=%d digest=%016x", d.Count, d.Sum)
+}
+
+// runShell loads the case into a fresh database and reads commands from in
+// until EOF or :quit.
+func runShell(ctx context.Context, exec *db.DB, opts Options, in io.Reader, out io.Writer) error {
+	conn, err := exec.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer util.CloseWithErr(conn, "repro shell conn")
+
+	s := &shell{conn: conn, caseDir: opts.CaseDir, database: opts.Database, out: out}
+	if err := s.load(opts.UseMin); err != nil {
+		return err
+	}
+	if err := s.reset(ctx); err != nil {
+		return err
+	}
+	if s.summary.Oracle != "" {
+		fmt.Fprintf(out, "oracle=%s expected=%q actual=%q\n", s.summary.Oracle, s.summary.Expected, s.summary.Actual)
+	}
+	fmt.Fprintf(out, "statements=%d, type :help for commands\n", len(s.steps))
+	s.list()
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), shellMaxLine)
+	for {
+		fmt.Fprint(out, shellPrompt)
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return scanner.Err()
+		}
+		if s.dispatch(ctx, scanner.Text()) {
+			return nil
+		}
+	}
+}
+
+// load reads the statement sequence and the original verdict. Typed steps are
+// preferred unless min/repro.sql is used; signature replay SQL from details is
+// appended when the steps do not already carry an expected/actual pair.
+func (s *shell) load(useMin bool) error {
+	content, err := os.ReadFile(filepath.Join(s.caseDir, "summary.json"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("summary: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(content, &s.summary); err != nil {
+			return fmt.Errorf("summary: %w", err)
+		}
+	}
+	casePath, label := pickCaseSQL(s.caseDir, useMin)
+	if label == "case" {
+		steps, err := loadCaseSteps(s.caseDir)
+		if err != nil {
+			return fmt.Errorf("summary: %w", err)
+		}
+		s.steps = steps
+	}
+	if len(s.steps) == 0 {
+		content, err := os.ReadFile(casePath)
+		if err != nil {
+			return fmt.Errorf("%s: %w", label, err)
+		}
+		for _, stmt := range splitSQL(string(content)) {
+			s.steps = append(s.steps, sqlstep.New("", "", stmt))
+		}
+	}
+	if _, _, ok := s.verdictPair(); !ok {
+		expected, _ := s.summary.Details["replay_expected_sql"].(string)
+		actual, _ := s.summary.Details["replay_actual_sql"].(string)
+		if strings.TrimSpace(expected) != "" && strings.TrimSpace(actual) != "" {
+			s.steps = append(s.steps,
+				sqlstep.New(sqlstep.KindQuery, sqlstep.RoleExpected, expected),
+				sqlstep.New(sqlstep.KindQuery, sqlstep.RoleActual, actual),
+			)
+		}
+	}
+	s.original = make([]string, len(s.steps))
+	for i, step := range s.steps {
+		s.original[i] = step.SQL
+	}
+	return nil
+}
+
+// dispatch handles one input line and reports whether the shell should exit.
+func (s *shell) dispatch(ctx context.Context, line string) bool {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return false
+	}
+	if !strings.HasPrefix(line, ":") {
+		stmt := strings.TrimSpace(strings.TrimSuffix(line, ";"))
+		_ = s.exec(ctx, sqlstep.New("", "", stmt), "sql")
+		return false
+	}
+	cmd, rest := splitShellWord(line[1:])
+	switch cmd {
+	case "quit", "exit", "q":
+		return true
+	case "help", "h":
+		fmt.Fprint(s.out, shellHelp)
+	case "list", "l":
+		s.list()
+	case "show":
+		if idx, ok := s.stepArg(rest); ok {
+			fmt.Fprintf(s.out, "%s;\n", s.steps[idx].SQL)
+		}
+	case "next", "n":
+		count := 1
+		if rest != "" {
+			n, err := strconv.Atoi(rest)
+			if err != nil || n <= 0 {
+				fmt.Fprintf(s.out, "invalid count %q\n", rest)
+				return false
+			}
+			count = n
+		}
+		for i := 0; i < count && s.cursor < len(s.steps); i++ {
+			s.step(ctx)
+		}
+		if s.cursor >= len(s.steps) {
+			fmt.Fprintln(s.out, "end of statements")
+		}
+	case "run", "r":
+		for s.cursor < len(s.steps) {
+			if !s.step(ctx) {
+				break
+			}
+		}
+	case "goto", "g":
+		if idx, ok := s.stepArg(rest); ok {
+			s.cursor = idx
+		}
+	case "edit", "e":
+		arg, stmt := splitShellWord(rest)
+		stmt = strings.TrimSpace(strings.TrimSuffix(stmt, ";"))
+		if idx, ok := s.stepArg(arg); ok {
+			if stmt == "" {
+				fmt.Fprintln(s.out, "usage: :edit N SQL")
+				return false
+			}
+			s.steps[idx].SQL = stmt
+			fmt.Fprintf(s.out, "%d: %s\n", idx+1, stmt)
+		}
+	case "restore":
+		if idx, ok := s.stepArg(rest); ok {
+			s.steps[idx].SQL = s.original[idx]
+			fmt.Fprintf(s.out, "%d: %s\n", idx+1, s.original[idx])
+		}
+	case "hint":
+		arg, hints := splitShellWord(rest)
+		if idx, ok := s.stepArg(arg); ok {
+			updated, changed := injectHint(s.steps[idx].SQL, hints)
+			if !changed {
+				fmt.Fprintf(s.out, "statement %d unchanged\n", idx+1)
+				return false
+			}
+			s.steps[idx].SQL = updated
+			fmt.Fprintf(s.out, "%d: %s\n", idx+1, updated)
+		}
+	case "set":
+		s.setVar(ctx, rest)
+	case "check", "c":
+		s.check(ctx)
+	case "reset":
+		if err := s.reset(ctx); err != nil {
+			fmt.Fprintf(s.out, "reset failed: %v\n", err)
+		}
+	default:
+		fmt.Fprintf(s.out, "unknown command :%s, type :help\n", cmd)
+	}
+	return false
+}
+
+func (s *shell) list() {
+	for i, step := range s.steps {
+		marker := " "
+		if i == s.cursor {
+			marker = ">"
+		}
+		label := string(step.Kind)
+		if label == "" {
+			label = "sql"
+		}
+		if step.Role != "" {
+			label += "/" + string(step.Role)
+		}
+		edited := ""
+		if step.SQL != s.original[i] {
+			edited = " (edited)"
+		}
+		fmt.Fprintf(s.out, "%s %3d [%s]%s %s\n", marker, i+1, label, edited, step.SQL)
+	}
+}
+
+// stepArg parses a 1-based statement number.
+func (s *shell) stepArg(arg string) (int, bool) {
+	n, err := strconv.Atoi(strings.TrimSpace(arg))
+	if err != nil || n < 1 || n > len(s.steps) {
+		fmt.Fprintf(s.out, "statement number must be between 1 and %d\n", len(s.steps))
+		return 0, false
+	}
+	return n - 1, true
+}
+
+// step runs the statement at the cursor and advances it. Verify failures do
+// not count as errors, matching execSteps.
+func (s *shell) step(ctx context.Context) bool {
+	idx := s.cursor
+	s.cursor++
+	err := s.exec(ctx, s.steps[idx], fmt.Sprintf("step=%d", idx+1))
+	return err == nil || s.steps[idx].Kind == sqlstep.KindVerify
+}
+
+func (s *shell) exec(ctx context.Context, step sqlstep.Step, label string) error {
+	stmt := strings.TrimSpace(step.SQL)
+	if stmt == "" {
+		return nil
+	}
+	if returnsRows(stmt) {
+		cols, rows, err := queryRows(ctx, s.conn, stmt)
+		if err != nil {
+			fmt.Fprintf(s.out, "%s err=%v\n", label, err)
+			return err
+		}
+		fmt.Fprintf(s.out, "%s %s\n", label, digestRows(rows))
+		printRows(s.out, cols, rows)
+		return nil
+	}
+	res, err := s.conn.ExecContext(ctx, stmt)
+	if err != nil {
+		fmt.Fprintf(s.out, "%s err=%v\n", label, err)
+		return err
+	}
+	affected, _ := res.RowsAffected()
+	fmt.Fprintf(s.out, "%s ok affected=%d\n", label, affected)
+	return nil
+}
+
+func (s *shell) setVar(ctx context.Context, arg string) {
+	arg = strings.TrimSpace(strings.TrimSuffix(arg, ";"))
+	if arg == "" {
+		if len(s.vars) == 0 {
+			fmt.Fprintln(s.out, "no session variables set")
+		}
+		for _, v := range s.vars {
+			fmt.Fprintln(s.out, v)
+		}
+		return
+	}
+	name, _, ok := strings.Cut(arg, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		fmt.Fprintln(s.out, "usage: :set VAR=VALUE")
+		return
+	}
+	if _, err := s.conn.ExecContext(ctx, "SET SESSION "+arg); err != nil {
+		fmt.Fprintf(s.out, "set err=%v\n", err)
+		return
+	}
+	for i, v := range s.vars {
+		if prev, _, _ := strings.Cut(v, "="); strings.EqualFold(strings.TrimSpace(prev), name) {
+			s.vars = append(s.vars[:i], s.vars[i+1:]...)
+			break
+		}
+	}
+	s.vars = append(s.vars, arg)
+	fmt.Fprintf(s.out, "set %s\n", arg)
+}
+
+// verdictPair returns the first expected and actual steps.
+func (s *shell) verdictPair() (int, int, bool) {
+	expected, actual := -1, -1
+	for i, step := range s.steps {
+		switch {
+		case step.Role == sqlstep.RoleExpected && expected < 0:
+			expected = i
+		case step.Role == sqlstep.RoleActual && actual < 0:
+			actual = i
+		}
+	}
+	return expected, actual, expected >= 0 && actual >= 0
+}
+
+// check re-evaluates the case on the current session state. With an
+// expected/actual pair the verdict compares row digests; otherwise it reruns
+// the failing statement and reports whether the error still occurs.
+func (s *shell) check(ctx context.Context) {
+	if expected, actual, ok := s.verdictPair(); ok {
+		_, expRows, expErr := queryRows(ctx, s.conn, s.steps[expected].SQL)
+		_, actRows, actErr := queryRows(ctx, s.conn, s.steps[actual].SQL)
+		switch {
+		case expErr != nil || actErr != nil:
+			fmt.Fprintf(s.out, "verdict=error expected_err=%v actual_err=%v\n", expErr, actErr)
+		default:
+			expDigest, actDigest := digestRows(expRows), digestRows(actRows)
+			verdict := "match"
+			if expDigest != actDigest {
+				verdict = "mismatch"
+			}
+			fmt.Fprintf(s.out, "verdict=%s expected(%d)=%s actual(%d)=%s\n", verdict, expected+1, expDigest, actual+1, actDigest)
+		}
+		return
+	}
+	for i, step := range s.steps {
+		if step.Role != sqlstep.RoleFailing {
+			continue
+		}
+		if err := s.exec(ctx, step, fmt.Sprintf("step=%d", i+1)); err != nil {
+			fmt.Fprintln(s.out, "verdict=error_reproduced")
+		} else {
+			fmt.Fprintln(s.out, "verdict=no_error")
+		}
+		return
+	}
+	fmt.Fprintln(s.out, "no expected/actual or failing statement to check")
+}
+
+// reset recreates the database on the shell connection and reloads the case
+// data. Session variables set with :set survive because the connection is kept.
+func (s *shell) reset(ctx context.Context) error {
+	name := "`" + strings.ReplaceAll(s.database, "`", "``") + "`"
+	for _, stmt := range []string{"DROP DATABASE IF EXISTS " + name, "CREATE DATABASE " + name, "USE " + name} {
+		if _, err := s.conn.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	if err := execSQLFile(ctx, s.conn, filepath.Join(s.caseDir, "schema.sql")); err != nil {
+		return fmt.Errorf("schema: %w", err)
+	}
+	if err := execSQLFile(ctx, s.conn, filepath.Join(s.caseDir, "inserts.sql")); err != nil {
+		return fmt.Errorf("inserts: %w", err)
+	}
+	s.cursor = 0
+	return nil
+}
+
+// injectHint adds hints to the optimizer hint block of the first SELECT,
+// creating the block when missing. An empty hints string removes the block.
+func injectHint(stmt string, hints string) (string, bool) {
+	hints = strings.TrimSpace(hints)
+	loc := shellSelectKeyword.FindStringIndex(stmt)
+	if loc == nil {
+		return stmt, false
+	}
+	head, tail := stmt[:loc[1]], stmt[loc[1]:]
+	if m := shellLeadingHint.FindStringSubmatchIndex(tail); m != nil {
+		existing := strings.TrimSpace(tail[m[2]:m[3]])
+		tail = tail[m[1]:]
+		if hints == "" {
+			return head + tail, true
+		}
+		if existing != "" {
+			hints = existing + " " + hints
+		}
+	} else if hints == "" {
+		return stmt, false
+	}
+	return head + " /*+ " + hints + " */" + tail, true
+}
+
+func splitShellWord(input string) (string, string) {
+	input = strings.TrimSpace(input)
+	word, rest, _ := strings.Cut(input, " ")
+	return strings.ToLower(word), strings.TrimSpace(rest)
+}
+
+// queryRows reads a full result set, rendering NULL as "NULL".
+func queryRows(ctx context.Context, conn *sql.Conn, stmt string) ([]string, [][]sql.NullString, error) {
+	rows, err := conn.QueryContext(ctx, stmt)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer util.CloseWithErr(rows, "repro shell rows")
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, nil, err
+	}
+	var out [][]sql.NullString
+	for rows.Next() {
+		row := make([]sql.NullString, len(cols))
+		dest := make([]any, len(cols))
+		for i := range row {
+			dest[i] = &row[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, nil, err
+		}
+		out = append(out, row)
+	}
+	return cols, out, rows.Err()
+}
+
+// returnsRows reports whether a statement produces a result set. DML steps
+// are classified as queries but are run with Exec to report affected rows.
+func returnsRows(stmt string) bool {
+	upper := strings.ToUpper(strings.TrimSpace(stmt))
+	for _, prefix := range []string{"SELECT", "WITH", "(", "EXPLAIN", "DESC", "SHOW", "EXECUTE", "ADMIN", "TRACE", "TABLE", "VALUES"} {
+		if strings.HasPrefix(upper, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func digestRows(rows [][]sql.NullString) rowsDigest {
+	digest := rowsDigest{Count: len(rows)}
+	for _, row := range rows {
+		h := fnv.New64a()
+		for _, v := range row {
+			if v.Valid {
+				_, _ = h.Write([]byte(v.String))
+			} else {
+				_, _ = h.Write([]byte{0})
+			}
+			_, _ = h.Write([]byte{0x1f})
+		}
+		digest.Sum += h.Sum64()
+	}
+	return digest
+}
+
+func printRows(out io.Writer, cols []string, rows [][]sql.NullString) {
+	if len(rows) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "  %s\n", strings.Join(cols, "\t"))
+	for i, row := range rows {
+		if i == shellPreviewRows {
+			fmt.Fprintf(out, "  ... %d more rows\n", len(rows)-shellPreviewRows)
+			break
+		}
+		values := make([]string, len(row))
+		for j, v := range row {
+			if v.Valid {
+				values[j] = v.String
+			} else {
+				values[j] = "NULL"
+			}
+		}
+		fmt.Fprintf(out, "  %s\n", strings.Join(values, "\t"))
+	}
+}
@@ -0,0 +1,96 @@
+package repro
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"shiro/internal/sqlstep"
+)
+
+func TestInjectHint(t *testing.T) {
+	cases := []struct {
+		stmt    string
+		hints   string
+		want    string
+		changed bool
+	}{
+		{"SELECT a FROM t", "HASH_JOIN(t)", "SELECT /*+ HASH_JOIN(t) */ a FROM t", true},
+		{"select /*+ MERGE() */ a from t", "NO_INDEX_MERGE()", "select /*+ MERGE() NO_INDEX_MERGE() */ a from t", true},
+		{"SELECT /*+ MERGE() */ a FROM t", "", "SELECT a FROM t", true},
+		{"SELECT a FROM t", "", "SELECT a FROM t", false},
+		{"UPDATE t SET a = 1", "HASH_JOIN(t)", "UPDATE t SET a = 1", false},
+	}
+	for _, tc := range cases {
+		got, changed := injectHint(tc.stmt, tc.hints)
+		if got != tc.want || changed != tc.changed {
+			t.Fatalf("injectHint(%q, %q)=%q,%v want=%q,%v", tc.stmt, tc.hints, got, changed, tc.want, tc.changed)
+		}
+	}
+}
+
+func TestShellEditCommands(t *testing.T) {
+	var out bytes.Buffer
+	s := &shell{
+		out: &out,
+		steps: []sqlstep.Step{
+			sqlstep.New(sqlstep.KindQuery, sqlstep.RoleExpected, "SELECT a FROM t"),
+			sqlstep.New(sqlstep.KindQuery, sqlstep.RoleActual, "SELECT a FROM t WHERE 1"),
+		},
+		original: []string{"SELECT a FROM t", "SELECT a FROM t WHERE 1"},
+	}
+	ctx := context.Background()
+	s.dispatch(ctx, ":hint 2 HASH_AGG()")
+	if got := s.steps[1].SQL; got != "SELECT /*+ HASH_AGG() */ a FROM t WHERE 1" {
+		t.Fatalf("hint step=%q", got)
+	}
+	s.dispatch(ctx, ":edit 1 SELECT b FROM t;")
+	if got := s.steps[0].SQL; got != "SELECT b FROM t" {
+		t.Fatalf("edit step=%q", got)
+	}
+	s.dispatch(ctx, ":goto 2")
+	if s.cursor != 1 {
+		t.Fatalf("cursor=%d want=1", s.cursor)
+	}
+	out.Reset()
+	s.dispatch(ctx, ":list")
+	if !strings.Contains(out.String(), ">   2 [query/actual] (edited)") {
+		t.Fatalf("list output=%q", out.String())
+	}
+	s.dispatch(ctx, ":restore 1")
+	if got := s.steps[0].SQL; got != "SELECT a FROM t" {
+		t.Fatalf("restore step=%q", got)
+	}
+	out.Reset()
+	s.dispatch(ctx, ":goto 3")
+	if s.cursor != 1 || !strings.Contains(out.String(), "between 1 and 2") {
+		t.Fatalf("goto out of range cursor=%d output=%q", s.cursor, out.String())
+	}
+	if !s.dispatch(ctx, ":quit") {
+		t.Fatalf("quit did not end the shell")
+	}
+}
+
+func TestDigestRowsOrderInsensitive(t *testing.T) {
+	row := func(values ...string) []sql.NullString {
+		out := make([]sql.NullString, len(values))
+		for i, v := range values {
+			out[i] = sql.NullString{String: v, Valid: v != "NULL"}
+		}
+		return out
+	}
+	a := digestRows([][]sql.NullString{row("1", "x"), row("2", "NULL")})
+	b := digestRows([][]sql.NullString{row("2", "NULL"), row("1", "x")})
+	if a != b {
+		t.Fatalf("digest differs by order: %s vs %s", a, b)
+	}
+	dup := digestRows([][]sql.NullString{row("1", "x"), row("1", "x")})
+	if dup == digestRows(nil) {
+		t.Fatalf("duplicate rows cancelled out: %s", dup)
+	}
+	if digestRows([][]sql.NullString{row("NULL")}) == digestRows([][]sql.NullString{row("")}) {
+		t.Fatalf("NULL and empty string share a digest")
+	}
+}
@@ -2,6 +2,8 @@ package repro
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -18,6 +20,26 @@ type Options struct {
 	DSN      string
 	Database string
 	UseMin   bool
+	// Interactive opens the repro shell instead of replaying the case once.
+	Interactive bool
+	// TimeTravel replays on the original data at the case TSO instead of the
+	// dumps: TimeTravelSnapshot or TimeTravelFlashback. Empty disables it.
+	TimeTravel string
+	// SnapshotTSO and SourceDatabase override the case TSO and database
+	// recorded in summary.json.
+	SnapshotTSO    uint64
+	SourceDatabase string
+	// ConfirmFlashback must be set for TimeTravelFlashback.
+	ConfirmFlashback bool
+	// SessionInit runs on every new repro connection. Nil uses the
+	// session_init recorded in the case session.json; an empty non-nil slice
+	// runs nothing.
+	SessionInit []string
+}
+
+// sqlExecer is satisfied by both the pooled DB and a dedicated connection.
+type sqlExecer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
 }
 
 // Run executes the reproduction flow for a case directory.
@@ -28,6 +50,9 @@ func Run(ctx context.Context, opts Options) error {
 	if opts.DSN == "" {
 		return fmt.Errorf("dsn is required")
 	}
+	if opts.TimeTravel != "" {
+		return runTimeTravel(ctx, opts)
+	}
 	if opts.Database == "" {
 		opts.Database = "shiro_repro"
 	}
@@ -35,14 +60,22 @@ func Run(ctx context.Context, opts Options) error {
 		return err
 	}
 	dsn := config.UpdateDatabaseInDSN(opts.DSN, opts.Database)
-	exec, err := db.Open(dsn)
+	sessionInit, err := resolveSessionInit(opts)
+	if err != nil {
+		return err
+	}
+	exec, err := db.Open(dsn, sessionInit...)
 	if err != nil {
 		return err
 	}
 	defer util.CloseWithErr(exec, "repro db")
 
 	fmt.Printf("database=%s dsn=%s\n", opts.Database, dsn)
+	printSessionInit(sessionInit)
 	printVersion(ctx, exec)
+	if opts.Interactive {
+		return runShell(ctx, exec, opts, os.Stdin, os.Stdout)
+	}
 
 	schemaPath := filepath.Join(opts.CaseDir, "schema.sql")
 	if err := execSQLFile(ctx, exec, schemaPath); err != nil {
@@ -53,12 +86,57 @@ func Run(ctx context.Context, opts Options) error {
 		return fmt.Errorf("inserts: %w", err)
 	}
 	casePath, label := pickCaseSQL(opts.CaseDir, opts.UseMin)
+	if label == "case" {
+		steps, err := loadCaseSteps(opts.CaseDir)
+		if err != nil {
+			return fmt.Errorf("summary: %w", err)
+		}
+		if len(steps) > 0 {
+			covered, err := stepsCoverSQLFile(steps, casePath)
+			if err != nil {
+				return fmt.Errorf("%s: %w", label, err)
+			}
+			if covered {
+				if err := execSteps(ctx, exec, steps); err != nil {
+					return fmt.Errorf("steps: %w", err)
+				}
+				return nil
+			}
+			fmt.Printf("steps do not cover %s; replaying it instead\n", casePath)
+		}
+	}
 	if err := execSQLFile(ctx, exec, casePath); err != nil {
 		return fmt.Errorf("%s: %w", label, err)
 	}
 	return nil
 }
 
+// caseSessionState carries the session.json fields the repro connections need.
+type caseSessionState struct {
+	SessionInit []string `json:"session_init"`
+}
+
+// resolveSessionInit returns the statements each repro connection runs before
+// the case: opts.SessionInit when set, otherwise the session_init the case ran
+// with. Cases without session.json run none.
+func resolveSessionInit(opts Options) ([]string, error) {
+	if opts.SessionInit != nil {
+		return config.NormalizeSessionInit(opts.SessionInit), nil
+	}
+	content, err := os.ReadFile(filepath.Join(opts.CaseDir, "session.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("session: %w", err)
+	}
+	var state caseSessionState
+	if err := json.Unmarshal(content, &state); err != nil {
+		return nil, fmt.Errorf("session: %w", err)
+	}
+	return config.NormalizeSessionInit(state.SessionInit), nil
+}
+
 func pickCaseSQL(caseDir string, useMin bool) (path string, label string) {
 	if useMin {
 		minPath := filepath.Join(caseDir, "min", "repro.sql")
@@ -69,6 +147,12 @@ func pickCaseSQL(caseDir string, useMin bool) (path string, label string) {
 	return filepath.Join(caseDir, "case.sql"), "case"
 }
 
+func printSessionInit(statements []string) {
+	for _, stmt := range statements {
+		fmt.Printf("session_init: %s\n", stmt)
+	}
+}
+
 func fileExists(path string) bool {
 	info, err := os.Stat(path)
 	if err != nil {
@@ -77,7 +161,7 @@ func fileExists(path string) bool {
 	return !info.IsDir()
 }
 
-func execSQLFile(ctx context.Context, exec *db.DB, path string) error {
+func execSQLFile(ctx context.Context, exec sqlExecer, path string) error {
 	content, err := os.ReadFile(path)
 	if err != nil {
 		return err
@@ -0,0 +1,42 @@
+package repro
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResolveSessionInit(t *testing.T) {
+	dir := t.TempDir()
+	got, err := resolveSessionInit(Options{CaseDir: dir})
+	if err != nil || got != nil {
+		t.Fatalf("case without session.json should run nothing: %v err=%v", got, err)
+	}
+
+	state := `{"sql_mode":"","time_zone":"SYSTEM","session_init":["SET SESSION tidb_mem_quota_query=1073741824","SET NAMES utf8mb4"]}`
+	if err := os.WriteFile(filepath.Join(dir, "session.json"), []byte(state), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err = resolveSessionInit(Options{CaseDir: dir})
+	want := []string{"SET SESSION tidb_mem_quota_query=1073741824", "SET NAMES utf8mb4"}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Fatalf("case session_init: got %v err=%v, want %v", got, err, want)
+	}
+
+	got, err = resolveSessionInit(Options{CaseDir: dir, SessionInit: []string{"sql_mode=''"}})
+	if err != nil || !reflect.DeepEqual(got, []string{"SET SESSION sql_mode=''"}) {
+		t.Fatalf("option should override and normalize the case: %v err=%v", got, err)
+	}
+	got, err = resolveSessionInit(Options{CaseDir: dir, SessionInit: []string{}})
+	if err != nil || len(got) != 0 {
+		t.Fatalf("empty option should run nothing: %v err=%v", got, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "session.json"), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := resolveSessionInit(Options{CaseDir: dir}); err == nil {
+		t.Fatalf("malformed session.json should fail")
+	}
+}
@@ -0,0 +1,129 @@
+package repro
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"shiro/internal/db"
+	"shiro/internal/sqlfmt"
+	"shiro/internal/sqlstep"
+	"shiro/internal/util"
+)
+
+type caseSummary struct {
+	Steps []sqlstep.Step `json:"steps"`
+}
+
+// loadCaseSteps reads typed steps from summary.json. Older cases without steps
+// return nil so the caller can fall back to case.sql.
+func loadCaseSteps(caseDir string) ([]sqlstep.Step, error) {
+	content, err := os.ReadFile(filepath.Join(caseDir, "summary.json"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var summary caseSummary
+	if err := json.Unmarshal(content, &summary); err != nil {
+		return nil, err
+	}
+	return summary.Steps, nil
+}
+
+// stepsCoverSQLFile reports whether every statement of the case file at path is
+// one of the steps. Steps replace the file only then; otherwise a statement
+// such as a setup DDL would be lost. A missing file leaves the steps as the
+// only record of the case.
+func stepsCoverSQLFile(steps []sqlstep.Step, path string) (bool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return true, nil
+		}
+		return false, err
+	}
+	have := make(map[string]struct{}, len(steps))
+	for _, step := range steps {
+		have[normalizeStepSQL(step.SQL)] = struct{}{}
+	}
+	for _, stmt := range splitSQL(string(content)) {
+		key := normalizeStepSQL(stmt)
+		if key == "" {
+			continue
+		}
+		if _, ok := have[key]; !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// normalizeStepSQL formats stmt the way case.sql was written and collapses
+// whitespace, so a step matches its pretty-printed statement.
+func normalizeStepSQL(stmt string) string {
+	stmt = strings.TrimSuffix(strings.TrimSpace(stmt), ";")
+	return strings.Join(strings.Fields(sqlfmt.Format(stmt)), " ")
+}
+
+// execSteps runs steps on one connection so session variables and prepared
+// statements stay visible to later steps. Verify steps are best effort because
+// they often reference the original session (for example EXPLAIN FOR CONNECTION).
+func execSteps(ctx context.Context, exec *db.DB, steps []sqlstep.Step) error {
+	conn, err := exec.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer util.CloseWithErr(conn, "repro conn")
+	return execStepsOnConn(ctx, conn, steps)
+}
+
+func execStepsOnConn(ctx context.Context, conn *sql.Conn, steps []sqlstep.Step) error {
+	fmt.Printf("exec_steps=%d\n", len(steps))
+	for idx, step := range steps {
+		stmt := strings.TrimSpace(step.SQL)
+		if stmt == "" {
+			continue
+		}
+		label := fmt.Sprintf("step=%d kind=%s", idx+1, step.Kind)
+		if step.Role != "" {
+			label += " role=" + string(step.Role)
+		}
+		switch step.Kind {
+		case sqlstep.KindQuery, sqlstep.KindExecute, sqlstep.KindVerify:
+			rowCount, err := queryRowCount(ctx, conn, stmt)
+			if err != nil {
+				if step.Kind == sqlstep.KindVerify {
+					fmt.Printf("%s skipped err=%v\n", label, err)
+					continue
+				}
+				return fmt.Errorf("%s err=%v sql=%s", label, err, stmt)
+			}
+			fmt.Printf("%s rows=%d\n", label, rowCount)
+		default:
+			if _, err := conn.ExecContext(ctx, stmt); err != nil {
+				return fmt.Errorf("%s err=%v sql=%s", label, err, stmt)
+			}
+		}
+	}
+	return nil
+}
+
+func queryRowCount(ctx context.Context, conn *sql.Conn, stmt string) (int, error) {
+	rows, err := conn.QueryContext(ctx, stmt)
+	if err != nil {
+		return 0, err
+	}
+	defer util.CloseWithErr(rows, "repro rows")
+	count := 0
+	for rows.Next() {
+		count++
+	}
+	return count, rows.Err()
+}
@@ -0,0 +1,161 @@
+package repro
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"shiro/internal/db"
+	"shiro/internal/sqlstep"
+)
+
+// stepConnector hands out connections that record every statement and fail
+// the one named in fail.
+type stepConnector struct {
+	stmts []string
+	fail  string
+}
+
+func (c *stepConnector) Connect(context.Context) (driver.Conn, error) { return &stepConn{c}, nil }
+func (c *stepConnector) Driver() driver.Driver                        { return nil }
+
+func (c *stepConnector) record(query string) error {
+	c.stmts = append(c.stmts, query)
+	if query == c.fail {
+		return errors.New("boom")
+	}
+	return nil
+}
+
+type stepConn struct{ connector *stepConnector }
+
+func (c *stepConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *stepConn) Close() error                        { return nil }
+func (c *stepConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c *stepConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	if err := c.connector.record(query); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (c *stepConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	if err := c.connector.record(query); err != nil {
+		return nil, err
+	}
+	return &emptyRows{}, nil
+}
+
+type emptyRows struct{}
+
+func (r *emptyRows) Columns() []string           { return []string{"a"} }
+func (r *emptyRows) Close() error                { return nil }
+func (r *emptyRows) Next(_ []driver.Value) error { return io.EOF }
+
+func TestLoadCaseSteps(t *testing.T) {
+	cases := []struct {
+		name    string
+		summary string
+		want    []sqlstep.Step
+		wantErr bool
+	}{
+		{name: "missing summary"},
+		{name: "no steps", summary: `{"oracle": "NoREC"}`},
+		{
+			name:    "steps",
+			summary: `{"steps": [{"kind": "set_var", "sql": "SET @a = 1"}, {"kind": "query", "role": "expected", "sql": "SELECT 1"}]}`,
+			want: []sqlstep.Step{
+				{Kind: sqlstep.KindSetVar, SQL: "SET @a = 1"},
+				{Kind: sqlstep.KindQuery, Role: sqlstep.RoleExpected, SQL: "SELECT 1"},
+			},
+		},
+		{name: "torn summary", summary: `{"steps": [`, wantErr: true},
+	}
+	for _, tc := range cases {
+		dir := t.TempDir()
+		if tc.summary != "" {
+			if err := os.WriteFile(filepath.Join(dir, "summary.json"), []byte(tc.summary), 0o644); err != nil {
+				t.Fatalf("%s: write summary: %v", tc.name, err)
+			}
+		}
+		got, err := loadCaseSteps(dir)
+		if (err != nil) != tc.wantErr {
+			t.Fatalf("%s: err=%v wantErr=%t", tc.name, err, tc.wantErr)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("%s: steps=%+v want=%+v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestExecSteps(t *testing.T) {
+	steps := []sqlstep.Step{
+		sqlstep.New(sqlstep.KindSetVar, "", "SET @a = 1"),
+		sqlstep.New(sqlstep.KindSetup, "", " "),
+		sqlstep.New(sqlstep.KindVerify, "", "EXPLAIN FOR CONNECTION 1"),
+		sqlstep.New(sqlstep.KindQuery, sqlstep.RoleActual, "SELECT 1"),
+	}
+	cases := []struct {
+		name    string
+		fail    string
+		want    []string
+		wantErr string
+	}{
+		{name: "all steps", want: []string{"SET @a = 1", "EXPLAIN FOR CONNECTION 1", "SELECT 1"}},
+		{name: "verify is best effort", fail: "EXPLAIN FOR CONNECTION 1", want: []string{"SET @a = 1", "EXPLAIN FOR CONNECTION 1", "SELECT 1"}},
+		{name: "failing set", fail: "SET @a = 1", want: []string{"SET @a = 1"}, wantErr: "step=1 kind=set_var"},
+		{name: "failing query", fail: "SELECT 1", want: []string{"SET @a = 1", "EXPLAIN FOR CONNECTION 1", "SELECT 1"}, wantErr: "step=4 kind=query role=actual"},
+	}
+	for _, tc := range cases {
+		connector := &stepConnector{fail: tc.fail}
+		exec := &db.DB{DB: sql.OpenDB(connector)}
+		err := execSteps(context.Background(), exec, steps)
+		_ = exec.Close()
+		if tc.wantErr == "" && err != nil {
+			t.Fatalf("%s: unexpected error %v", tc.name, err)
+		}
+		if tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+			t.Fatalf("%s: err=%v want %q", tc.name, err, tc.wantErr)
+		}
+		if !reflect.DeepEqual(connector.stmts, tc.want) {
+			t.Fatalf("%s: statements=%q want=%q", tc.name, connector.stmts, tc.want)
+		}
+	}
+}
+
+func TestStepsCoverSQLFile(t *testing.T) {
+	steps := []sqlstep.Step{
+		sqlstep.New(sqlstep.KindQuery, sqlstep.RoleExpected, "select a from t where b > 1"),
+		sqlstep.New(sqlstep.KindQuery, sqlstep.RoleActual, "SELECT a FROM t"),
+	}
+	dir := t.TempDir()
+	cases := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{name: "missing file", want: true},
+		{name: "formatted statements", content: "SELECT a\nFROM t\nWHERE b > 1;\nSELECT a\nFROM t;\n", want: true},
+		{name: "extra setup statement", content: "CREATE TABLE t2 (a INT);\nSELECT a\nFROM t;\n"},
+	}
+	for i, tc := range cases {
+		path := filepath.Join(dir, tc.name+".sql")
+		if tc.content != "" {
+			if err := os.WriteFile(path, []byte(tc.content), 0o644); err != nil {
+				t.Fatalf("write case %d: %v", i, err)
+			}
+		}
+		got, err := stepsCoverSQLFile(steps, path)
+		if err != nil || got != tc.want {
+			t.Fatalf("%s: covered=%t err=%v want=%t", tc.name, got, err, tc.want)
+		}
+	}
+}
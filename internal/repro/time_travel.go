@@ -0,0 +1,146 @@
+package repro
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"shiro/internal/db"
+	"shiro/internal/util"
+)
+
+// Time-travel modes replay a case against the data the runner saw instead of
+// re-importing schema.sql and inserts.sql into a fresh database.
+const (
+	// TimeTravelSnapshot reads the original database at the case TSO through
+	// tidb_snapshot. It is read-only, so write statements fail.
+	TimeTravelSnapshot = "snapshot"
+	// TimeTravelFlashback rewinds the whole cluster with FLASHBACK CLUSTER and
+	// replays the case on the restored database, including its statistics.
+	TimeTravelFlashback = "flashback"
+)
+
+// timeTravelSummary carries the summary.json details that locate the data a
+// case ran on. TSOs are decoded as json.Number, since float64 loses precision.
+type timeTravelSummary struct {
+	Details struct {
+		SnapshotTSO  json.Number `json:"snapshot_tso"`
+		CaseTSO      json.Number `json:"case_tso"`
+		CaseDatabase string      `json:"case_database"`
+	} `json:"details"`
+}
+
+type timeTravelTarget struct {
+	TSO      uint64
+	Database string
+}
+
+// resolveTimeTravel picks the TSO and database to travel to. Flags win over
+// the summary; a pinned snapshot_tso wins over the report-time case_tso.
+func resolveTimeTravel(opts Options) (timeTravelTarget, error) {
+	var summary timeTravelSummary
+	content, err := os.ReadFile(filepath.Join(opts.CaseDir, "summary.json"))
+	if err == nil {
+		if err := json.Unmarshal(content, &summary); err != nil {
+			return timeTravelTarget{}, fmt.Errorf("summary: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return timeTravelTarget{}, fmt.Errorf("summary: %w", err)
+	}
+	target := timeTravelTarget{TSO: opts.SnapshotTSO, Database: strings.TrimSpace(opts.SourceDatabase)}
+	for _, raw := range []json.Number{summary.Details.SnapshotTSO, summary.Details.CaseTSO} {
+		if target.TSO != 0 || raw == "" {
+			continue
+		}
+		tso, err := strconv.ParseUint(raw.String(), 10, 64)
+		if err != nil {
+			return timeTravelTarget{}, fmt.Errorf("summary tso %q: %w", raw, err)
+		}
+		target.TSO = tso
+	}
+	if target.Database == "" {
+		target.Database = strings.TrimSpace(summary.Details.CaseDatabase)
+	}
+	if target.TSO == 0 {
+		return timeTravelTarget{}, fmt.Errorf("case has no snapshot_tso/case_tso, pass -snapshot_tso")
+	}
+	if target.Database == "" {
+		return timeTravelTarget{}, fmt.Errorf("case has no case_database, pass -source_database")
+	}
+	return target, nil
+}
+
+// timeTravelSetupSQL returns the statements that move a session to target.
+func timeTravelSetupSQL(mode string, target timeTravelTarget) ([]string, error) {
+	use := "USE `" + strings.ReplaceAll(target.Database, "`", "``") + "`"
+	switch mode {
+	case TimeTravelSnapshot:
+		return []string{fmt.Sprintf("SET @@tidb_snapshot = '%d'", target.TSO), use}, nil
+	case TimeTravelFlashback:
+		return []string{fmt.Sprintf("FLASHBACK CLUSTER TO TSO %d", target.TSO), use}, nil
+	default:
+		return nil, fmt.Errorf("unknown time_travel mode %q (want %s or %s)", mode, TimeTravelSnapshot, TimeTravelFlashback)
+	}
+}
+
+// runTimeTravel replays the case on one session moved to the case TSO.
+func runTimeTravel(ctx context.Context, opts Options) error {
+	if opts.Interactive {
+		return fmt.Errorf("-interactive does not support -time_travel")
+	}
+	if opts.TimeTravel == TimeTravelFlashback && !opts.ConfirmFlashback {
+		return fmt.Errorf("flashback rewinds every database in the cluster, pass -confirm_flashback to proceed")
+	}
+	target, err := resolveTimeTravel(opts)
+	if err != nil {
+		return err
+	}
+	setup, err := timeTravelSetupSQL(opts.TimeTravel, target)
+	if err != nil {
+		return err
+	}
+	sessionInit, err := resolveSessionInit(opts)
+	if err != nil {
+		return err
+	}
+	exec, err := db.Open(opts.DSN, sessionInit...)
+	if err != nil {
+		return err
+	}
+	defer util.CloseWithErr(exec, "repro db")
+	fmt.Printf("time_travel=%s tso=%d database=%s\n", opts.TimeTravel, target.TSO, target.Database)
+	printSessionInit(sessionInit)
+	printVersion(ctx, exec)
+
+	conn, err := exec.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer util.CloseWithErr(conn, "repro time travel conn")
+	for _, stmt := range setup {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("time travel: %w sql=%s", err, stmt)
+		}
+	}
+	casePath, label := pickCaseSQL(opts.CaseDir, opts.UseMin)
+	if label == "case" {
+		steps, err := loadCaseSteps(opts.CaseDir)
+		if err != nil {
+			return fmt.Errorf("summary: %w", err)
+		}
+		if len(steps) > 0 {
+			if err := execStepsOnConn(ctx, conn, steps); err != nil {
+				return fmt.Errorf("steps: %w", err)
+			}
+			return nil
+		}
+	}
+	if err := execSQLFile(ctx, conn, casePath); err != nil {
+		return fmt.Errorf("%s: %w", label, err)
+	}
+	return nil
+}
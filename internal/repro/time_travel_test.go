@@ -0,0 +1,56 @@
+package repro
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResolveTimeTravel(t *testing.T) {
+	dir := t.TempDir()
+	summary := `{"details":{"case_tso":449572913577443329,"snapshot_tso":449572913577443000,"case_database":"shiro_fuzz_3"}}`
+	if err := os.WriteFile(filepath.Join(dir, "summary.json"), []byte(summary), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	target, err := resolveTimeTravel(Options{CaseDir: dir})
+	if err != nil {
+		t.Fatalf("resolveTimeTravel: %v", err)
+	}
+	if target != (timeTravelTarget{TSO: 449572913577443000, Database: "shiro_fuzz_3"}) {
+		t.Fatalf("pinned snapshot_tso should win: %+v", target)
+	}
+	target, err = resolveTimeTravel(Options{CaseDir: dir, SnapshotTSO: 7, SourceDatabase: "other"})
+	if err != nil || target != (timeTravelTarget{TSO: 7, Database: "other"}) {
+		t.Fatalf("flags should override the summary: %+v err=%v", target, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "summary.json"), []byte(`{"details":{"case_tso":449572913577443329}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if target, err := resolveTimeTravel(Options{CaseDir: dir}); err == nil {
+		t.Fatalf("missing database should fail: %+v", target)
+	}
+	target, err = resolveTimeTravel(Options{CaseDir: dir, SourceDatabase: "shiro_fuzz_3"})
+	if err != nil || target.TSO != 449572913577443329 {
+		t.Fatalf("case_tso must keep full precision: %+v err=%v", target, err)
+	}
+	if _, err := resolveTimeTravel(Options{CaseDir: t.TempDir(), SourceDatabase: "db"}); err == nil {
+		t.Fatalf("case without summary or -snapshot_tso should fail")
+	}
+}
+
+func TestTimeTravelSetupSQL(t *testing.T) {
+	target := timeTravelTarget{TSO: 42, Database: "shiro`db"}
+	got, err := timeTravelSetupSQL(TimeTravelSnapshot, target)
+	if err != nil || !reflect.DeepEqual(got, []string{"SET @@tidb_snapshot = '42'", "USE `shiro``db`"}) {
+		t.Fatalf("snapshot setup=%v err=%v", got, err)
+	}
+	got, err = timeTravelSetupSQL(TimeTravelFlashback, target)
+	if err != nil || got[0] != "FLASHBACK CLUSTER TO TSO 42" {
+		t.Fatalf("flashback setup=%v err=%v", got, err)
+	}
+	if _, err := timeTravelSetupSQL("rewind", target); err == nil {
+		t.Fatalf("unknown mode should fail")
+	}
+}
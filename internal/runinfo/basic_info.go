@@ -1,6 +1,7 @@
 package runinfo
 
 import (
+	"net/url"
 	"os"
 	"regexp"
 	"strings"
@@ -89,6 +90,14 @@ func detectBase() BasicInfo {
 		info.CI = true
 		if info.Provider == "" {
 			info.Provider = "gitlab_ci"
+			applyGitLabCI(&info)
+		}
+	}
+	if isTruthy(env("TF_BUILD")) {
+		info.CI = true
+		if info.Provider == "" {
+			info.Provider = "azure_pipelines"
+			applyAzurePipelines(&info)
 		}
 	}
 	if isTruthy(env("BUILDKITE")) {
@@ -123,6 +132,44 @@ func detectBase() BasicInfo {
 	return info
 }
 
+// applyGitLabCI fills run metadata from GitLab CI predefined variables.
+// Merge request pipelines report the source branch and the MR IID.
+func applyGitLabCI(info *BasicInfo) {
+	setIfEmpty(&info.Repository, env("CI_PROJECT_PATH"))
+	setIfEmpty(&info.Branch, envFirst("CI_MERGE_REQUEST_SOURCE_BRANCH_NAME", "CI_COMMIT_REF_NAME"))
+	setIfEmpty(&info.Commit, env("CI_COMMIT_SHA"))
+	setIfEmpty(&info.Workflow, envFirst("CI_PIPELINE_NAME", "CI_PROJECT_NAME"))
+	setIfEmpty(&info.Job, env("CI_JOB_NAME"))
+	setIfEmpty(&info.RunID, env("CI_PIPELINE_ID"))
+	setIfEmpty(&info.RunNumber, env("CI_PIPELINE_IID"))
+	setIfEmpty(&info.Event, env("CI_PIPELINE_SOURCE"))
+	setIfEmpty(&info.PullRequest, env("CI_MERGE_REQUEST_IID"))
+	setIfEmpty(&info.Actor, env("GITLAB_USER_LOGIN"))
+	setIfEmpty(&info.BuildURL, envFirst("CI_JOB_URL", "CI_PIPELINE_URL"))
+}
+
+// applyAzurePipelines fills run metadata from Azure Pipelines predefined
+// variables. Pull request builds check out a merge ref, so the branch comes
+// from the PR source branch. The build URL is assembled from the collection
+// and project because Azure Pipelines does not expose a web URL.
+func applyAzurePipelines(info *BasicInfo) {
+	setIfEmpty(&info.Repository, env("BUILD_REPOSITORY_NAME"))
+	setIfEmpty(&info.Branch, envFirst("SYSTEM_PULLREQUEST_SOURCEBRANCH", "BUILD_SOURCEBRANCH"))
+	setIfEmpty(&info.Commit, env("BUILD_SOURCEVERSION"))
+	setIfEmpty(&info.Workflow, env("BUILD_DEFINITIONNAME"))
+	setIfEmpty(&info.Job, envFirst("SYSTEM_JOBDISPLAYNAME", "AGENT_JOBNAME"))
+	setIfEmpty(&info.RunID, env("BUILD_BUILDID"))
+	setIfEmpty(&info.RunNumber, env("BUILD_BUILDNUMBER"))
+	setIfEmpty(&info.Event, env("BUILD_REASON"))
+	setIfEmpty(&info.PullRequest, envFirst("SYSTEM_PULLREQUEST_PULLREQUESTNUMBER", "SYSTEM_PULLREQUEST_PULLREQUESTID"))
+	setIfEmpty(&info.Actor, env("BUILD_REQUESTEDFOR"))
+	collection := env("SYSTEM_COLLECTIONURI")
+	project := env("SYSTEM_TEAMPROJECT")
+	if collection != "" && project != "" && info.RunID != "" {
+		info.BuildURL = strings.TrimRight(collection, "/") + "/" + url.PathEscape(project) + "/_build/results?buildId=" + url.QueryEscape(info.RunID)
+	}
+}
+
 func applyShiroOverrides(info *BasicInfo) {
 	if info == nil {
 		return
@@ -38,6 +38,82 @@ func TestFromEnvGitHubActions(t *testing.T) {
 	}
 }
 
+func TestFromEnvGitLabCI(t *testing.T) {
+	clearKnownEnv(t)
+	t.Setenv("GITLAB_CI", "true")
+	t.Setenv("CI", "true")
+	t.Setenv("CI_PROJECT_PATH", "qa/shiro")
+	t.Setenv("CI_PROJECT_NAME", "shiro")
+	t.Setenv("CI_COMMIT_REF_NAME", "refs/merge-requests/17/head")
+	t.Setenv("CI_MERGE_REQUEST_SOURCE_BRANCH_NAME", "feature/fuzz")
+	t.Setenv("CI_COMMIT_SHA", "cafef00d")
+	t.Setenv("CI_JOB_NAME", "fuzz")
+	t.Setenv("CI_PIPELINE_ID", "9001")
+	t.Setenv("CI_PIPELINE_IID", "12")
+	t.Setenv("CI_PIPELINE_SOURCE", "merge_request_event")
+	t.Setenv("CI_MERGE_REQUEST_IID", "17")
+	t.Setenv("GITLAB_USER_LOGIN", "tester")
+	t.Setenv("CI_PIPELINE_URL", "https://gitlab.example.com/qa/shiro/-/pipelines/9001")
+
+	info := mustFromEnv(t)
+	want := BasicInfo{
+		CI:          true,
+		Provider:    "gitlab_ci",
+		Repository:  "qa/shiro",
+		Branch:      "feature/fuzz",
+		Commit:      "cafef00d",
+		Workflow:    "shiro",
+		Job:         "fuzz",
+		RunID:       "9001",
+		RunNumber:   "12",
+		Event:       "merge_request_event",
+		PullRequest: "17",
+		Actor:       "tester",
+		BuildURL:    "https://gitlab.example.com/qa/shiro/-/pipelines/9001",
+	}
+	if info != want {
+		t.Fatalf("unexpected run info:\n%+v\nwant:\n%+v", info, want)
+	}
+}
+
+func TestFromEnvAzurePipelines(t *testing.T) {
+	clearKnownEnv(t)
+	t.Setenv("TF_BUILD", "True")
+	t.Setenv("BUILD_REPOSITORY_NAME", "qa/shiro")
+	t.Setenv("BUILD_SOURCEBRANCH", "refs/pull/5/merge")
+	t.Setenv("SYSTEM_PULLREQUEST_SOURCEBRANCH", "refs/heads/feature/fuzz")
+	t.Setenv("BUILD_SOURCEVERSION", "0badc0de")
+	t.Setenv("BUILD_DEFINITIONNAME", "shiro-nightly")
+	t.Setenv("SYSTEM_JOBDISPLAYNAME", "Fuzz TiDB")
+	t.Setenv("BUILD_BUILDID", "314")
+	t.Setenv("BUILD_BUILDNUMBER", "20261016.3")
+	t.Setenv("BUILD_REASON", "PullRequest")
+	t.Setenv("SYSTEM_PULLREQUEST_PULLREQUESTID", "5")
+	t.Setenv("BUILD_REQUESTEDFOR", "Test User")
+	t.Setenv("SYSTEM_COLLECTIONURI", "https://dev.azure.com/example/")
+	t.Setenv("SYSTEM_TEAMPROJECT", "Database QA")
+
+	info := mustFromEnv(t)
+	want := BasicInfo{
+		CI:          true,
+		Provider:    "azure_pipelines",
+		Repository:  "qa/shiro",
+		Branch:      "feature/fuzz",
+		Commit:      "0badc0de",
+		Workflow:    "shiro-nightly",
+		Job:         "Fuzz TiDB",
+		RunID:       "314",
+		RunNumber:   "20261016.3",
+		Event:       "PullRequest",
+		PullRequest: "5",
+		Actor:       "Test User",
+		BuildURL:    "https://dev.azure.com/example/Database%20QA/_build/results?buildId=314",
+	}
+	if info != want {
+		t.Fatalf("unexpected run info:\n%+v\nwant:\n%+v", info, want)
+	}
+}
+
 func TestFromEnvShiroOverrides(t *testing.T) {
 	clearKnownEnv(t)
 	t.Setenv("SHIRO_CI_PROVIDER", "manual")
@@ -124,11 +200,25 @@ func clearKnownEnv(t *testing.T) {
 		"CI_PIPELINE_ID",
 		"CI_PIPELINE_IID",
 		"CI_JOB_URL",
+		"CI_PIPELINE_URL",
+		"CI_PIPELINE_NAME",
+		"CI_PROJECT_NAME",
+		"CI_MERGE_REQUEST_IID",
+		"CI_MERGE_REQUEST_SOURCE_BRANCH_NAME",
 		"GITLAB_CI",
 		"GITLAB_USER_LOGIN",
 		"BUILDKITE",
 		"JENKINS_URL",
+		"TF_BUILD",
 		"BUILD_REPOSITORY_NAME",
+		"BUILD_SOURCEBRANCH",
+		"BUILD_REASON",
+		"SYSTEM_PULLREQUEST_SOURCEBRANCH",
+		"SYSTEM_PULLREQUEST_PULLREQUESTID",
+		"SYSTEM_JOBDISPLAYNAME",
+		"AGENT_JOBNAME",
+		"SYSTEM_COLLECTIONURI",
+		"SYSTEM_TEAMPROJECT",
 		"BUILD_SOURCEVERSION",
 		"BUILD_DEFINITIONNAME",
 		"BUILD_BUILDID",
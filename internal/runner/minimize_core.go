@@ -29,11 +29,16 @@ type minimizeOutput struct {
 	caseSQL   []string
 	insertSQL []string
 	reproSQL  []string
-	minimized bool
-	status    string
-	reason    string
-	flaky     bool
-	details   map[string]any
+	// reproSetupSQL and spec are the schema/insert prefix of reproSQL and the
+	// reduced replay spec, kept so the minimized case can be verified.
+	reproSetupSQL []string
+	spec          replaySpec
+	minimized     bool
+	status        string
+	reason        string
+	verify        string
+	flaky         bool
+	details       map[string]any
 }
 
 const minimizeReasonBaseReplayNotReproducible = "base_replay_not_reproducible"
@@ -75,7 +80,7 @@ func (r *Runner) minimizeCase(ctx context.Context, result oracle.Result, spec re
 	if len(tablesUsed) > 0 {
 		origInserts = filterInsertsByTables(origInserts, tablesUsed)
 	}
-	origCase := append([]string{}, result.SQL...)
+	origCase := result.SQL()
 
 	origInserts = expandInsertStatements(origInserts)
 	baseReplay := minimizeBaseReplayGateDetailed(func() replayAttemptResult {
@@ -140,12 +145,14 @@ func (r *Runner) minimizeCase(ctx context.Context, result oracle.Result, spec re
 
 	reproSQL := buildReproSQL(schemaSQL, minInserts, minCase, specReduced)
 	return minimizeOutput{
-		caseSQL:   minCase,
-		insertSQL: minInserts,
-		reproSQL:  reproSQL,
-		minimized: true,
-		status:    "success",
-		flaky:     baseReplay.flaky,
+		caseSQL:       minCase,
+		insertSQL:     minInserts,
+		reproSQL:      reproSQL,
+		reproSetupSQL: reproSQL[:len(schemaSQL)+len(minInserts)],
+		spec:          specReduced,
+		minimized:     true,
+		status:        "success",
+		flaky:         baseReplay.flaky,
 	}
 }
 
@@ -637,7 +644,7 @@ func (v *quantifiedSubqueryFinder) Leave(in ast.Node) (ast.Node, bool) {
 func tablesForMinimize(result oracle.Result) map[string]struct{} {
 	p := parser.New()
 	tables := map[string]struct{}{}
-	collectTables(p, tables, result.SQL...)
+	collectTables(p, tables, result.SQL()...)
 	if result.Details != nil {
 		if sqlText, ok := result.Details["replay_expected_sql"].(string); ok {
 			collectTables(p, tables, sqlText)
@@ -731,19 +738,44 @@ func filterInsertsByTables(stmts []string, tables map[string]struct{}) []string
 	return out
 }
 
+// filterInsertsExcludingTable removes the journal statements that target
+// table.
+func filterInsertsExcludingTable(stmts []string, table string) []string {
+	p := parser.New()
+	out := make([]string, 0, len(stmts))
+	for _, stmt := range stmts {
+		if !strings.EqualFold(insertTargetTable(p, stmt), table) {
+			out = append(out, stmt)
+		}
+	}
+	return out
+}
+
+// insertTargetTable returns the table an insert log statement writes: the
+// INSERT target, or the table of a journaled TRUNCATE or DROP TABLE.
 func insertTargetTable(p *parser.Parser, stmt string) string {
 	node, err := p.ParseOneStmt(stmt, "", "")
 	if err != nil {
 		return ""
 	}
-	ins, ok := node.(*ast.InsertStmt)
-	if !ok || ins.Table == nil {
-		return ""
-	}
-	collector := &tableCollector{tables: map[string]struct{}{}}
-	ins.Table.Accept(collector)
-	for name := range collector.tables {
-		return name
+	switch n := node.(type) {
+	case *ast.InsertStmt:
+		if n.Table == nil {
+			return ""
+		}
+		collector := &tableCollector{tables: map[string]struct{}{}}
+		n.Table.Accept(collector)
+		for name := range collector.tables {
+			return name
+		}
+	case *ast.TruncateTableStmt:
+		if n.Table != nil {
+			return strings.ToLower(n.Table.Name.O)
+		}
+	case *ast.DropTableStmt:
+		if len(n.Tables) == 1 && !n.IsView {
+			return strings.ToLower(n.Tables[0].Name.O)
+		}
 	}
 	return ""
 }
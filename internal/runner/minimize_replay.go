@@ -106,16 +106,22 @@ func (r *Runner) replayCase(ctx context.Context, schemaSQL, inserts, caseSQL []s
 }
 
 func (r *Runner) replayCaseDetailed(ctx context.Context, schemaSQL, inserts, caseSQL []string, result oracle.Result, spec replaySpec) replayAttemptResult {
+	return r.replayCaseOn(ctx, r.exec, r.baseDB+"_min", schemaSQL, inserts, caseSQL, result, spec)
+}
+
+// replayCaseOn replays a case in minDB of exec, which is dropped and
+// recreated first, and reports whether the replay matched the original
+// result.
+func (r *Runner) replayCaseOn(ctx context.Context, exec *db.DB, minDB string, schemaSQL, inserts, caseSQL []string, result oracle.Result, spec replaySpec) replayAttemptResult {
 	if err := ctx.Err(); err != nil {
 		return failReplayAttempt(result, spec, nil, "context", "context_error", err)
 	}
-	conn, err := r.exec.Conn(ctx)
+	conn, err := exec.Conn(ctx)
 	if err != nil {
 		return failReplayAttempt(result, spec, nil, "connect", "setup_error", err)
 	}
 	trace := &replayTrace{}
 	defer closeReplayConn(conn, trace)
-	minDB := r.baseDB + "_min"
 	if err := r.resetDatabaseOnConn(ctx, conn, minDB, trace); err != nil {
 		return failReplayAttempt(result, spec, trace, "reset_database", "setup_error", err)
 	}
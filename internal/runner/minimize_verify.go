@@ -0,0 +1,59 @@
+package runner
+
+import (
+	"context"
+	"time"
+
+	"shiro/internal/oracle"
+)
+
+const minimizeReasonMinVerifyNotReproducible = "min_verify_not_reproducible"
+
+// Values of report.Summary.MinimizeVerify.
+const (
+	minVerifyPassed = "passed"
+	minVerifyFailed = "failed"
+)
+
+// verifyMinimized replays what min/repro.sql holds, its schema and inserts
+// followed by the reduced replay spec, in a freshly reset scratch database.
+// It uses the same consensus as the base replay gate, under a fresh timeout
+// because minimization may have used up its own budget.
+func (r *Runner) verifyMinimized(ctx context.Context, result oracle.Result, output minimizeOutput) minimizeBaseReplayGateResult {
+	timeout := time.Duration(r.cfg.Minimize.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	verifyCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return minimizeBaseReplayGateDetailed(func() replayAttemptResult {
+		return r.replayCaseDetailed(verifyCtx, output.reproSetupSQL, nil, output.caseSQL, result, output.spec)
+	}, output.spec.kind)
+}
+
+// applyMinVerify folds a verification outcome into the minimize output. A
+// minimization that does not reproduce is dropped, so the case ships the full
+// SQL instead of min files that would mislead the bug report.
+func applyMinVerify(output minimizeOutput, verify minimizeBaseReplayGateResult) minimizeOutput {
+	if verify.ok {
+		output.verify = minVerifyPassed
+		return output
+	}
+	details := map[string]any{
+		"min_verify_attempts":  verify.attempts,
+		"min_verify_successes": verify.successes,
+		"min_verify_required":  verify.required,
+	}
+	if verify.diag.outcome != "" {
+		details["min_verify_outcome"] = verify.diag.outcome
+	}
+	if verify.diag.failureStage != "" {
+		details["min_verify_failure_stage"] = verify.diag.failureStage
+	}
+	return minimizeOutput{
+		status:  "unverified",
+		reason:  minimizeReasonMinVerifyNotReproducible,
+		verify:  minVerifyFailed,
+		details: details,
+	}
+}
@@ -0,0 +1,40 @@
+package runner
+
+import (
+	"testing"
+
+	"shiro/internal/report"
+)
+
+func TestApplyMinVerify(t *testing.T) {
+	minimized := minimizeOutput{
+		caseSQL:   []string{"SELECT 1"},
+		reproSQL:  []string{"CREATE TABLE t0 (id INT)", "SELECT 1"},
+		minimized: true,
+		status:    "success",
+	}
+	passed := applyMinVerify(minimized, minimizeBaseReplayGateResult{ok: true, attempts: 3, successes: 2, required: 2})
+	if !passed.minimized || passed.status != "success" || passed.verify != minVerifyPassed {
+		t.Fatalf("verified minimization should be kept: %+v", passed)
+	}
+
+	failed := applyMinVerify(minimized, minimizeBaseReplayGateResult{
+		attempts:  3,
+		successes: 0,
+		required:  2,
+		diag:      replayFailureDiagnostic{outcome: "comparison_not_reproduced", failureStage: "compare_signature"},
+	})
+	if failed.minimized || len(failed.reproSQL) != 0 || len(failed.caseSQL) != 0 {
+		t.Fatalf("unverified minimization must not ship min files: %+v", failed)
+	}
+
+	summary := report.Summary{MinimizeStatus: "in_progress"}
+	details := map[string]any{}
+	applyMinimizeOutcome(&summary, details, failed, nil)
+	if summary.MinimizeStatus != "unverified" || summary.MinimizeVerify != minVerifyFailed || summary.Flaky {
+		t.Fatalf("unexpected summary: status=%q verify=%q flaky=%v", summary.MinimizeStatus, summary.MinimizeVerify, summary.Flaky)
+	}
+	if details["minimize_reason"] != minimizeReasonMinVerifyNotReproducible || details["min_verify_failure_stage"] != "compare_signature" {
+		t.Fatalf("unexpected details: %v", details)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -15,6 +16,7 @@ import (
 	"shiro/internal/replayer"
 	"shiro/internal/report"
 	"shiro/internal/schema"
+	"shiro/internal/sqllog"
 	"shiro/internal/tqs"
 	"shiro/internal/uploader"
 	"shiro/internal/util"
@@ -34,6 +36,8 @@ type Runner struct {
 	uploader                 uploader.Uploader
 	oracles                  []oracle.Oracle
 	insertLog                []string
+	sqlLog                   *sqllog.Writer
+	reportDiskThrottled      bool
 	statsMu                  sync.Mutex
 	genMu                    sync.Mutex
 	qpgMu                    sync.Mutex
@@ -64,6 +68,7 @@ type Runner struct {
 	genSQLFullJoinAttempted  int64
 	genSQLFullJoinRejected   map[string]int64
 	genSQLRecursiveCTE       int64
+	featureCoverage          map[string]*oracleFeatureCoverage
 	// sqlInSubquery tracks IN(subquery) occurrences aggregated from plan-cache SQL parsing.
 	sqlInSubquery int64
 	// sqlNotInSubquery tracks NOT IN(subquery) occurrences aggregated from plan-cache SQL parsing.
@@ -75,6 +80,8 @@ type Runner struct {
 	impoTotal                       int64
 	impoSkips                       int64
 	impoTrunc                       int64
+	resultTruncatedTotal            int64
+	hangChecks                      int64
 	dqpHintInjectedTotal            int64
 	dqpHintFallbackTotal            int64
 	dqpSetVarVariantTotal           int64
@@ -86,6 +93,12 @@ type Runner struct {
 	dqpHintLengthIntervalCount      int64
 	dqpHintLengthIntervalMin        int64
 	dqpHintLengthIntervalMax        int64
+	dqpPlanChangedTotal             int64
+	dqpPlanSameSkipTotal            int64
+	dqpPlanCheckErrTotal            int64
+	dqpHintPlanChanged              map[string]int64
+	dqpHintPlanSame                 map[string]int64
+	dqpFixControls                  map[string]*fixControlSummary
 	impoSkipReasons                 map[string]int64
 	impoSkipErrCodes                map[string]int64
 	impoLastFailSQL                 string
@@ -132,6 +145,7 @@ type Runner struct {
 	capturedMinimizeReasons         map[string]int64
 	capturedErrorSignatures         map[string]int64
 	capturedReplayFailureStages     map[string]int64
+	junitCases                      map[string][]junitCase
 	capturedReplaySetupSignatures   map[string]int64
 	minimizeInFlight                int64
 	throughputLowSampleStreak       int64
@@ -143,9 +157,31 @@ type Runner struct {
 	infraUnhealthyTTL               int64
 	infraErrorCounts                map[string]int64
 	qpgState                        *qpgState
+	planStability                   *planStabilityState
+	selectivity                     *selectivityState
+	oracleBudget                    *oracleBudget
+	stateSnapshots                  int
+	driftSeq                        int
+	pacing                          *pacingState
+	latency                         *latencyTracker
+	resourceGroup                   *resourceGroupStats
+	chaos                           *chaosState
+	querySampler                    *querySampler
+	workloadSummary                 *workloadSummary
+	topology                        *report.ClusterTopology
+	unsupportedHints                []string
+	shadowExec                      *db.DB
+	shadowVersion                   string
+	shadowOutcomes                  map[string]int64
+	stop                            *stopControl
+	stopReason                      string
+	targetScheduler                 *TargetScheduler
+	targetTurnHeld                  bool
 	kqeState                        *kqeState
+	pipeline                        *oraclePipeline
 	tqsHistory                      *tqs.History
 	oracleStats                     map[string]*oracleFunnel
+	oracleApplicability             map[string]*oracleApplicabilityStat
 	baseActions                     config.ActionWeights
 	baseDMLWeights                  config.DMLWeights
 	baseDQEWeight                   int
@@ -165,6 +201,19 @@ type Runner struct {
 
 	featureBandit   *featureBandits
 	lastFeatureArms featureArms
+
+	// scaleApplied counts the scale_schedule steps applied so far.
+	scaleApplied int
+
+	// Null density of generated predicates, guarded by statsMu.
+	nullAwareQueries int64
+	nullPredicates   int64
+	predicateLeaves  int64
+
+	// Query size distributions, guarded by statsMu. The interval copy is
+	// logged and reset by the stats ticker.
+	queryShape         queryShapeStats
+	queryShapeInterval queryShapeStats
 }
 
 func (r *Runner) baseTables() []*schema.Table {
@@ -188,33 +237,52 @@ const tiflashReplicaReadyTimeout = 2 * time.Minute
 
 var errWaitTiFlashReplicaReadyTimeout = errors.New("wait tiflash replica ready timeout")
 
+// recoveredOutputDirs holds one *sync.Once per output directory so workers
+// sharing a directory never recover cases another worker is still staging.
+var recoveredOutputDirs sync.Map
+
+// RecoverOutputDir recovers staged and interrupted-minimize cases left in
+// outputDir by a killed process. It runs at most once per directory per
+// process; call it before starting the workers that share outputDir.
+func RecoverOutputDir(outputDir string, maxRows int) {
+	key := filepath.Clean(outputDir)
+	if abs, err := filepath.Abs(key); err == nil {
+		key = abs
+	}
+	once, _ := recoveredOutputDirs.LoadOrStore(key, &sync.Once{})
+	once.(*sync.Once).Do(func() {
+		reporter := report.New(outputDir, maxRows)
+		if recovery, err := reporter.RecoverStagedCases(); err != nil {
+			util.Warnf("recover staged cases failed output_dir=%s err=%v", outputDir, err)
+		} else if recovery.Recovered > 0 || recovery.Discarded > 0 {
+			util.Warnf("recovered staged cases output_dir=%s recovered=%d discarded=%d", outputDir, recovery.Recovered, recovery.Discarded)
+		}
+		if recovered, err := reporter.RecoverInterruptedMinimizeCases(minimizeReasonRunnerRecoveredInterrupted); err != nil {
+			util.Warnf("recover interrupted minimize cases failed output_dir=%s err=%v", outputDir, err)
+		} else if recovered > 0 {
+			util.Warnf("recovered interrupted minimize cases output_dir=%s count=%d", outputDir, recovered)
+		}
+	})
+}
+
 // New constructs a Runner for the given config and DB.
 func New(cfg config.Config, exec *db.DB) *Runner {
 	state := &schema.State{}
 	gen := generator.New(cfg, state, cfg.Seed)
 	caseReporter := report.New(cfg.PlanReplayer.OutputDir, cfg.MaxDataDumpRows)
-	// Use UUID-based report directory layout when cloud storage is enabled.
-	caseReporter.UseUUIDPath = cfg.Storage.CloudEnabled()
+	// Use UUID-based report directory layout when a remote store is enabled.
+	caseReporter.UseUUIDPath = cfg.Storage.RemoteEnabled()
 	if cfg.Storage.GCS.Enabled && cfg.Storage.S3.Enabled {
 		util.Warnf("both storage.gcs and storage.s3 are enabled; using gcs")
 	}
 	var up uploader.Uploader = uploader.NoopUploader{}
-	if cfg.Storage.GCS.Enabled {
-		gcsUploader, err := uploader.NewGCS(cfg.Storage.GCS)
-		if err != nil {
-			util.Warnf("gcs uploader init failed: %v", err)
-		} else if gcsUploader != nil && gcsUploader.Enabled() {
-			up = gcsUploader
-		}
-	}
-	if !up.Enabled() && cfg.Storage.S3.Enabled {
-		s3Uploader, err := uploader.NewS3(cfg.Storage.S3)
-		if err != nil {
-			util.Warnf("s3 uploader init failed: %v", err)
-		} else if s3Uploader != nil && s3Uploader.Enabled() {
-			up = s3Uploader
-		}
+	store, prefix, err := uploader.NewStore(context.Background(), cfg.Storage)
+	if err != nil {
+		util.Warnf("case uploader init failed: %v", err)
+	} else if store != nil {
+		up = uploader.NewStoreUploader(store, prefix)
 	}
+	stop := newStopControl(cfg.Stop, store, prefix)
 	r := &Runner{
 		cfg:                             cfg,
 		exec:                            exec,
@@ -225,10 +293,13 @@ func New(cfg config.Config, exec *db.DB) *Runner {
 		reporter:                        caseReporter,
 		replayer:                        replayer.New(cfg.PlanReplayer),
 		uploader:                        up,
+		stop:                            stop,
 		impoSkipReasons:                 make(map[string]int64),
 		impoSkipErrCodes:                make(map[string]int64),
 		impoMutationCounts:              make(map[string]int64),
 		impoMutationExecCounts:          make(map[string]int64),
+		dqpHintPlanChanged:              make(map[string]int64),
+		dqpHintPlanSame:                 make(map[string]int64),
 		joinCounts:                      make(map[int]int64),
 		joinTypeSeqs:                    make(map[string]int64),
 		joinGraphSigs:                   make(map[string]int64),
@@ -252,18 +323,7 @@ func New(cfg config.Config, exec *db.DB) *Runner {
 		baseTQSEnabled:                  cfg.TQS.Enabled,
 		baseDSGEnabled:                  cfg.Features.DSG,
 		dbSeq:                           0,
-		oracles: []oracle.Oracle{
-			oracle.NoREC{},
-			oracle.TLP{},
-			oracle.EET{},
-			oracle.DQP{},
-			oracle.PQS{},
-			oracle.CERT{MinBaseRows: cfg.Oracles.CertMinBaseRows},
-			oracle.CODDTest{},
-			oracle.DQE{},
-			oracle.Impo{},
-			oracle.GroundTruth{},
-		},
+		oracles:                         oracle.All(cfg),
 	}
 	r.initOracleIndices()
 	util.Infof("runner config loaded tqs.enabled=%v base_tqs_enabled=%v dqe_weight=%d dsg_enabled=%v db=%s",
@@ -273,9 +333,30 @@ func New(cfg config.Config, exec *db.DB) *Runner {
 		r.baseDSGEnabled,
 		cfg.Database,
 	)
+	for _, line := range oracle.FormatCompatMatrix(cfg.Oracles.Compat) {
+		util.Infof("oracle compat %s", line)
+	}
+	if unknown := oracle.UnknownCompatOverrides(cfg.Oracles.Compat); len(unknown) > 0 {
+		util.Warnf("oracles.compat ignores unknown oracles: %s", strings.Join(unknown, ", "))
+	}
+	if cfg.Oracles.PipelineDepth > 1 {
+		r.pipeline = newOraclePipeline(cfg.Oracles.PipelineDepth)
+	}
 	if cfg.QPG.Enabled {
 		r.qpgState = newQPGState(cfg.QPG)
 	}
+	if cfg.Pacing.Enabled {
+		r.pacing = newPacingState(cfg.Pacing)
+	}
+	if cfg.Latency.Enabled {
+		r.latency = newLatencyTracker(cfg.Latency)
+	}
+	if cfg.ResourceGroup.Enabled {
+		r.resourceGroup = &resourceGroupStats{}
+	}
+	if cfg.Oracles.Budget.Enabled {
+		r.oracleBudget = newOracleBudget(cfg.Oracles.Budget)
+	}
 	if cfg.Features.Joins && cfg.KQE.Enabled {
 		r.kqeState = newKQELiteState()
 	}
@@ -300,41 +381,81 @@ func (r *Runner) initOracleIndices() {
 func (r *Runner) Run(ctx context.Context) error {
 	r.exec.Validate = r.validator.Validate
 	r.exec.Observe = r.observeSQL
+	r.exec.Guard = r.resultGuard()
+	started := time.Now()
 	stop := r.startStatsLogger()
 	defer stop()
+	defer r.dumpFeatureCoverage()
+	defer r.dumpRunSummary(started)
+	defer r.dumpJUnit(started)
+	stopSQLLog := r.startSQLLog()
+	defer stopSQLLog()
+	stopQuerySampling := r.startQuerySampling()
+	defer stopQuerySampling()
 
 	r.applyRuntimeToggles()
 	r.initBandits()
 	util.Infof("runner start database=%s iterations=%d plan_cache_only=%t", r.cfg.Database, r.cfg.Iterations, r.cfg.PlanCacheOnly)
-	if recovered, err := r.reporter.RecoverInterruptedMinimizeCases(minimizeReasonRunnerRecoveredInterrupted); err != nil {
-		util.Warnf("recover interrupted minimize cases failed output_dir=%s err=%v", r.reporter.OutputDir, err)
-	} else if recovered > 0 {
-		util.Warnf("recovered interrupted minimize cases output_dir=%s count=%d", r.reporter.OutputDir, recovered)
-	}
+	RecoverOutputDir(r.reporter.OutputDir, r.cfg.MaxDataDumpRows)
 	if err := r.setupDatabase(ctx); err != nil {
 		return err
 	}
+	r.captureTopology(ctx)
+	r.probeHintSupport(ctx)
+	r.applyScaleSchedule(ctx, 0)
 	if err := r.initState(ctx); err != nil {
 		return err
 	}
+	r.warmUp(ctx)
+	r.runLifecycleHooks(ctx, hookStageRunStart, r.cfg.Hooks.RunStart)
+	stopWorkload := r.startBackgroundWorkload(ctx)
+	defer stopWorkload()
+	stopShadow := r.openShadow(ctx)
+	defer stopShadow()
 	if r.cfg.PlanCacheOnly {
 		return r.runPlanCacheOnly(ctx)
 	}
 
+	defer r.drainPipeline(ctx)
+	defer r.releaseTargetTurn()
 	for i := 0; i < r.cfg.Iterations; i++ {
+		if !r.awaitTargetTurn(ctx) {
+			break
+		}
+		if r.stopRequested(ctx, i) {
+			break
+		}
+		r.reapPipeline(ctx)
+		r.paceIteration(ctx)
+		r.reportLatencyRegressions(ctx)
+		r.applyScaleSchedule(ctx, i)
 		action := r.pickAction()
 		var reward float64
 		switch action {
-		case 0:
+		case actionDDL:
+			r.drainPipeline(ctx)
 			r.runDDL(ctx)
-		case 1:
+		case actionDML:
+			r.drainPipeline(ctx)
 			r.runDML(ctx)
+		case actionStoryline:
+			r.drainPipeline(ctx)
+			if r.runStoryline(ctx) {
+				reward = 1
+			}
 		default:
-			if r.runQuery(ctx) {
+			found, queued := r.runQuery(ctx)
+			if queued {
+				// The action bandit is updated when the pipelined run is reaped.
+				r.checkPlanStability(ctx)
+				continue
+			}
+			if found {
 				reward = 1
 			}
 		}
 		r.updateActionBandit(action, reward)
+		r.checkPlanStability(ctx)
 	}
 	return nil
 }
@@ -374,18 +495,8 @@ func (r *Runner) initState(ctx context.Context) error {
 		if err := r.applyTiFlashReplica(ctx, tablePtr); err != nil {
 			return err
 		}
-		insertCount := max(1, r.cfg.MaxRowsPerTable/5)
-		for j := 0; j < insertCount; j++ {
-			insertSQL := r.gen.InsertSQL(tablePtr)
-			if strings.TrimSpace(insertSQL) == "" {
-				continue
-			}
-			if err := r.execSQL(ctx, insertSQL); err != nil {
-				if _, ok := isWhitelistedSQLError(err); ok {
-					continue
-				}
-				return err
-			}
+		if err := r.fillTableRows(ctx, tablePtr, max(1, r.cfg.MaxRowsPerTable)); err != nil {
+			return err
 		}
 	}
 	return nil
@@ -465,6 +576,8 @@ func (r *Runner) runDDL(ctx context.Context) {
 	} else {
 		if len(baseTables) < r.cfg.MaxTables {
 			actions = append(actions, "create_table")
+		} else if len(baseTables) > 0 {
+			actions = append(actions, ddlActionDropTable, ddlActionTruncateTable)
 		}
 		if r.cfg.Features.Indexes && len(baseTables) > 0 {
 			actions = append(actions, "create_index")
@@ -507,9 +620,7 @@ func (r *Runner) runDDLAction(ctx context.Context, action string, baseTables []*
 			r.state.Tables = r.state.Tables[:len(r.state.Tables)-1]
 			return
 		}
-		if insertSQL := r.gen.InsertSQL(tablePtr); strings.TrimSpace(insertSQL) != "" {
-			_ = r.execSQL(ctx, insertSQL)
-		}
+		_ = r.fillTableRows(ctx, tablePtr, max(1, r.cfg.MaxRowsPerTable))
 		if r.cfg.TQS.Enabled && r.tqsHistory != nil {
 			r.tqsHistory.Refresh(r.state)
 		}
@@ -571,6 +682,8 @@ func (r *Runner) runDDLAction(ctx context.Context, action string, baseTables []*
 		tbl := baseTables[r.gen.Rand.Intn(len(baseTables))]
 		sql := r.gen.AddCheckConstraintSQL(*tbl)
 		_ = r.execSQL(ctx, sql)
+	case ddlActionDropTable, ddlActionTruncateTable:
+		r.runShrinkAction(ctx, action, baseTables)
 	}
 }
 
@@ -599,13 +712,21 @@ func (r *Runner) applyTiFlashReplica(ctx context.Context, tbl *schema.Table) err
 	if err := r.execSQL(ctx, sql); err != nil {
 		return err
 	}
-	if err := r.waitTiFlashReplicaReady(ctx); err != nil {
+	if err := r.waitTiFlashReplicaReady(ctx, tbl.Name); err != nil {
 		return err
 	}
+	tbl.TiFlashReplica = replicas
+	if mode := r.cfg.MPP.TiFlashMode; mode != "" {
+		if err := r.execSQL(ctx, tiFlashModeSQL(tbl.Name, mode)); err != nil {
+			util.Warnf("set tiflash mode failed table=%s mode=%s err=%v", tbl.Name, mode, err)
+		}
+	}
 	return nil
 }
 
-func (r *Runner) waitTiFlashReplicaReady(ctx context.Context) error {
+// waitTiFlashReplicaReady polls until the TiFlash replica of tableName is
+// available.
+func (r *Runner) waitTiFlashReplicaReady(ctx context.Context, tableName string) error {
 	if r == nil || r.exec == nil {
 		return nil
 	}
@@ -615,7 +736,7 @@ func (r *Runner) waitTiFlashReplicaReady(ctx context.Context) error {
 	ticker := time.NewTicker(tiflashReplicaReadyPollInterval)
 	defer ticker.Stop()
 	for {
-		pending, err := r.tiFlashReplicaPending(waitCtx)
+		pending, err := r.tiFlashReplicaPending(waitCtx, tableName)
 		if err != nil {
 			return err
 		}
@@ -633,9 +754,9 @@ func (r *Runner) waitTiFlashReplicaReady(ctx context.Context) error {
 	}
 }
 
-func (r *Runner) tiFlashReplicaPending(ctx context.Context) (int, error) {
+func (r *Runner) tiFlashReplicaPending(ctx context.Context, tableName string) (int, error) {
 	var pending int
-	row := r.exec.QueryRowContext(ctx, tiFlashReplicaPendingSQL())
+	row := r.exec.QueryRowContext(ctx, tiFlashReplicaPendingSQL(r.cfg.Database, tableName))
 	if err := row.Scan(&pending); err != nil {
 		return 0, err
 	}
@@ -701,10 +822,9 @@ func (r *Runner) runDML(ctx context.Context) {
 	r.updateDMLBandit(choice, reward)
 }
 
-func (r *Runner) runQuery(ctx context.Context) bool {
-	if r.cfg.Features.PlanCache && util.Chance(r.gen.Rand, r.cfg.PlanCacheProb) {
-		return r.runPrepared(ctx)
-	}
+// runQuery runs one oracle. found reports a reportable result; queued reports
+// that a read-only oracle was handed to the pipeline and is reaped later.
+func (r *Runner) runQuery(ctx context.Context) (found bool, queued bool) {
 	r.prepareFeatureWeights()
 	appliedQPG := r.applyQPGWeights()
 	appliedKQE := false
@@ -721,8 +841,15 @@ func (r *Runner) runQuery(ctx context.Context) bool {
 	if appliedTemplate {
 		defer r.clearTemplateWeights()
 	}
+	defer r.prepareSelectivityTarget()()
 	oracleIdx := r.pickOracle()
 	oracleName := r.oracles[oracleIdx].Name()
+	pipelined := r.pipelineAccepts(oracleName)
+	if pipelined {
+		r.awaitPipelineSlot(ctx)
+	} else {
+		r.drainPipeline(ctx)
+	}
 	r.observeOracleRun(oracleName)
 	restoreOracleBias := r.applyOracleBias(oracleName)
 	if restoreOracleBias != nil {
@@ -730,14 +857,34 @@ func (r *Runner) runQuery(ctx context.Context) bool {
 	}
 	restoreOracleOverrides := r.applyOracleOverrides(oracleName)
 	defer restoreOracleOverrides()
-	var queryReward float64
 	qctx, cancel := r.withTimeoutForOracle(ctx, oracleName)
+	qctx = db.WithStatementTag(qctx, oracleName)
+	if pipelined {
+		r.launchPipelined(qctx, cancel, oracleIdx)
+		return false, true
+	}
 	defer cancel()
 	r.gen.ResetBuilderStats()
+	// Oracles that do not generate a query must not inherit the last one's features.
+	r.gen.LastFeatures = nil
+	snapshotTSO, unpinSnapshot := r.pinOracleSnapshot(qctx, r.exec, oracleName)
+	drift := r.armDrift(r.exec, oracleName)
 	result := r.oracles[oracleIdx].Run(qctx, r.exec, r.gen, r.state)
+	unpinSnapshot()
+	r.finishDrift(qctx, r.exec, drift, &result)
+	return r.finishQuery(ctx, oracleIdx, result, snapshotTSO, r.gen.BuilderStats()), false
+}
+
+// finishQuery classifies, records, and reports one oracle result. Feature
+// observations read r.gen.LastFeatures, so pipelined runs install their
+// fork's features before calling it.
+func (r *Runner) finishQuery(ctx context.Context, oracleIdx int, result oracle.Result, snapshotTSO uint64, builderStats generator.BuilderStats) bool {
+	oracleName := r.oracles[oracleIdx].Name()
+	var queryReward float64
+	recordSnapshotTSO(&result, snapshotTSO)
 	r.observeOracleTimeoutControl(oracleName, result.Err)
 	r.observeInfraErrorControl(result.Err)
-	builderStats := r.gen.BuilderStats()
+	r.escalateTimeout(ctx, &result)
 	r.observeBuilderStats(oracleName, builderStats)
 	if result.Err != nil {
 		if tbl, ok := missingTableName(result.Err); ok && r.removeViewFromState(tbl) {
@@ -752,6 +899,7 @@ func (r *Runner) runQuery(ctx context.Context) bool {
 	_ = downgradeMissingColumnFalsePositive(&result, r.cfg.Oracles.DowngradeMissingColumnToSkip)
 	_ = downgradeGroundTruthLowConfidenceFalsePositive(&result)
 	_ = downgradeDQPTimeoutFalsePositive(&result)
+	_ = downgradeResultTruncated(&result)
 	annotateResultForReporting(&result)
 	annotateEffectiveErrorMetadata(&result)
 	captureSkippedForMinimize := shouldCaptureSkipForMinimize(result)
@@ -759,15 +907,22 @@ func (r *Runner) runQuery(ctx context.Context) bool {
 	isPanic := isPanicError(result.Err)
 	reported := captureSkippedForMinimize || !result.OK || isPanic
 	r.observeOracleResult(oracleName, result, skipReason, reported, isPanic)
-	r.observeVariantSubqueryCounts(result.SQL, result.SQLFeatures)
+	r.observeOracleBudget(oracleName, result, skipReason)
+	r.observeVariantSubqueryCounts(result.SQL(), result.SQLFeatures)
 	if r.gen.LastFeatures != nil {
 		r.observeJoinCountValue(r.gen.LastFeatures.JoinCount)
 		r.observeJoinSignature(r.gen.LastFeatures, oracleName)
+		r.observeQueryShape(r.gen.LastFeatures)
+		r.observeFeatureCoverage(oracleName, r.gen.LastFeatures, skipReason == "")
 		r.observeKQELite(r.gen.LastFeatures)
+		r.observeSelectivity(ctx, r.gen.LastFeatures)
 	}
 	r.applyResultMetrics(result)
 	oracleReward := oracleBanditImmediateReward(result, skipReason)
 	if result.OK {
+		if !reported {
+			r.maybeSampleQuery(ctx, result, skipReason)
+		}
 		r.maybeObservePlan(ctx, result)
 		if isPanicError(result.Err) {
 			r.handleResult(ctx, result)
@@ -803,7 +958,8 @@ func oracleBanditImmediateReward(result oracle.Result, skipReason string) float6
 		return 1.0
 	}
 	if isSkipClassifiedResult(result, skipReason) {
-		if strings.Contains(skipReason, ":timeout") || isInfraReason(skipReason) {
+		// Truncated and timed-out verifications say nothing about the oracle.
+		if strings.Contains(skipReason, ":timeout") || strings.HasSuffix(skipReason, resultTruncatedSkipSuffix) || isInfraReason(skipReason) {
 			return 0.0
 		}
 		return 0.05
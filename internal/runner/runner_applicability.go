@@ -0,0 +1,137 @@
+package runner
+
+import (
+	"sort"
+	"strings"
+
+	"shiro/internal/oracle"
+	"shiro/internal/report"
+)
+
+// oracleApplicabilityStat tracks oracle activity within one database epoch.
+// It is reset on every rotation so a captured case only carries the coverage
+// that ran against the same schema and data.
+type oracleApplicabilityStat struct {
+	runs        int64
+	effective   int64
+	skips       int64
+	errors      int64
+	skipReasons map[string]int64
+	variants    map[string]int64
+}
+
+func newOracleApplicabilityStat() *oracleApplicabilityStat {
+	return &oracleApplicabilityStat{
+		skipReasons: make(map[string]int64),
+		variants:    make(map[string]int64),
+	}
+}
+
+// observeOracleApplicabilityLocked records one oracle run for the current epoch.
+// Caller must hold statsMu.
+func (r *Runner) observeOracleApplicabilityLocked(name string, result oracle.Result, skipReason string) {
+	if name == "" {
+		return
+	}
+	if r.oracleApplicability == nil {
+		r.oracleApplicability = make(map[string]*oracleApplicabilityStat)
+	}
+	stat := r.oracleApplicability[name]
+	if stat == nil {
+		stat = newOracleApplicabilityStat()
+		r.oracleApplicability[name] = stat
+	}
+	stat.runs++
+	switch {
+	case skipReason != "":
+		stat.skips++
+		stat.skipReasons[skipReason]++
+	case result.Err != nil:
+		stat.errors++
+	default:
+		stat.effective++
+	}
+	for key, value := range result.Metrics {
+		if value <= 0 || !isApplicabilityVariantMetric(key) {
+			continue
+		}
+		stat.variants[key] += value
+	}
+}
+
+// isApplicabilityVariantMetric keeps counter-style metrics and drops
+// distribution metrics (min/max/sum) that do not describe variant coverage.
+func isApplicabilityVariantMetric(key string) bool {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return false
+	}
+	for _, suffix := range []string{"_min", "_max", "_sum", "_count"} {
+		if strings.HasSuffix(key, suffix) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Runner) resetOracleApplicability() {
+	r.statsMu.Lock()
+	r.oracleApplicability = nil
+	r.statsMu.Unlock()
+}
+
+// oracleApplicabilitySnapshot returns the epoch coverage sorted by oracle name.
+// Registered oracles that never ran in the epoch are listed with zero runs.
+func (r *Runner) oracleApplicabilitySnapshot() []report.OracleApplicability {
+	r.statsMu.Lock()
+	defer r.statsMu.Unlock()
+	seen := make(map[string]struct{}, len(r.oracles)+len(r.oracleApplicability))
+	names := make([]string, 0, len(r.oracles)+len(r.oracleApplicability))
+	for _, o := range r.oracles {
+		if _, ok := seen[o.Name()]; ok {
+			continue
+		}
+		seen[o.Name()] = struct{}{}
+		names = append(names, o.Name())
+	}
+	for name := range r.oracleApplicability {
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+	out := make([]report.OracleApplicability, 0, len(names))
+	for _, name := range names {
+		stat := r.oracleApplicability[name]
+		if stat == nil {
+			out = append(out, report.OracleApplicability{Oracle: name})
+			continue
+		}
+		entry := report.OracleApplicability{
+			Oracle:    name,
+			Runs:      stat.runs,
+			Effective: stat.effective,
+			Skips:     stat.skips,
+			Errors:    stat.errors,
+		}
+		if len(stat.skipReasons) > 0 {
+			entry.SkipReasons = make(map[string]int64, len(stat.skipReasons))
+			for reason, count := range stat.skipReasons {
+				entry.SkipReasons[reason] = count
+			}
+		}
+		if len(stat.variants) > 0 {
+			entry.Variants = make(map[string]int64, len(stat.variants))
+			for variant, count := range stat.variants {
+				entry.Variants[variant] = count
+			}
+		}
+		out = append(out, entry)
+	}
+	return out
+}
@@ -0,0 +1,61 @@
+package runner
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"shiro/internal/oracle"
+)
+
+func TestOracleApplicabilitySnapshot(t *testing.T) {
+	r := &Runner{
+		oracleStats: make(map[string]*oracleFunnel),
+		oracles:     []oracle.Oracle{oracle.NoREC{}, oracle.DQP{}, oracle.TLP{}},
+	}
+	r.observeOracleResult("NoREC", oracle.Result{Oracle: "NoREC", OK: true}, "norec:guardrail", false, false)
+	r.observeOracleResult("NoREC", oracle.Result{Oracle: "NoREC", OK: true}, "norec:guardrail", false, false)
+	r.observeOracleResult("DQP", oracle.Result{
+		Oracle: "DQP",
+		OK:     true,
+		Metrics: map[string]int64{
+			"dqp_hint_injected_total": 3,
+			"dqp_hint_length_sum":     42,
+			"dqp_hint_fallback_total": 0,
+		},
+	}, "", false, false)
+	r.observeOracleResult("DQP", oracle.Result{Oracle: "DQP", Err: errors.New("boom")}, "", true, false)
+
+	got := r.oracleApplicabilitySnapshot()
+	if len(got) != 3 {
+		t.Fatalf("snapshot len=%d want=3: %+v", len(got), got)
+	}
+	if got[0].Oracle != "DQP" || got[1].Oracle != "NoREC" || got[2].Oracle != "TLP" {
+		t.Fatalf("unexpected oracle order: %+v", got)
+	}
+	if got[0].Runs != 2 || got[0].Effective != 1 || got[0].Errors != 1 {
+		t.Fatalf("unexpected DQP applicability: %+v", got[0])
+	}
+	if !reflect.DeepEqual(got[0].Variants, map[string]int64{"dqp_hint_injected_total": 3}) {
+		t.Fatalf("unexpected DQP variants: %v", got[0].Variants)
+	}
+	if got[1].Runs != 2 || got[1].Skips != 2 || got[1].Effective != 0 {
+		t.Fatalf("unexpected NoREC applicability: %+v", got[1])
+	}
+	if got[1].SkipReasons["norec:guardrail"] != 2 {
+		t.Fatalf("unexpected NoREC skip reasons: %v", got[1].SkipReasons)
+	}
+	if got[2].Runs != 0 || got[2].SkipReasons != nil {
+		t.Fatalf("expected TLP to be listed as not exercised: %+v", got[2])
+	}
+
+	r.resetOracleApplicability()
+	for _, entry := range r.oracleApplicabilitySnapshot() {
+		if entry.Runs != 0 {
+			t.Fatalf("expected reset applicability, got %+v", entry)
+		}
+	}
+	if r.oracleStats["NoREC"].Runs != 0 || r.oracleStats["NoREC"].Skips != 2 {
+		t.Fatalf("reset must not touch global oracle funnel: %+v", r.oracleStats["NoREC"])
+	}
+}
@@ -10,16 +10,25 @@ import (
 
 const certSampleRate = 1e-6
 
+// Action bandit arms, in config.ActionWeights order.
+const (
+	actionDDL = iota
+	actionDML
+	actionQuery
+	actionStoryline
+)
+
 func (r *Runner) initBandits() {
 	if !r.cfg.Adaptive.Enabled {
 		return
 	}
 	if r.cfg.Adaptive.AdaptActions {
-		r.actionBandit = util.NewBanditWithWindow(3, r.cfg.Adaptive.UCBExploration, r.cfg.Adaptive.WindowSize)
+		r.actionBandit = util.NewBanditWithWindow(4, r.cfg.Adaptive.UCBExploration, r.cfg.Adaptive.WindowSize)
 		r.actionEnabled = []bool{
 			r.cfg.Weights.Actions.DDL > 0,
 			r.cfg.Weights.Actions.DML > 0,
 			r.cfg.Weights.Actions.Query > 0,
+			r.cfg.Weights.Actions.Storyline > 0,
 		}
 	}
 	if r.cfg.Adaptive.AdaptOracles {
@@ -42,6 +51,12 @@ func (r *Runner) initBandits() {
 }
 
 func (r *Runner) oracleWeightByName(name string) int {
+	if r.cfg.MPP.ColumnarOnly {
+		if name == "TiFlashOnly" {
+			return max(r.cfg.Weights.Oracles.TiFlashOnly, 1)
+		}
+		return 0
+	}
 	base := 0
 	switch name {
 	case "NoREC":
@@ -62,12 +77,55 @@ func (r *Runner) oracleWeightByName(name string) int {
 		base = r.cfg.Weights.Oracles.Impo
 	case "GroundTruth":
 		base = r.cfg.Weights.Oracles.GroundTruth
+	case "TxnRYW":
+		base = r.cfg.Weights.Oracles.TxnRYW
+	case "CTEInline":
+		base = r.cfg.Weights.Oracles.CTEInline
+	case "FKCascade":
+		base = r.cfg.Weights.Oracles.FKCascade
+	case "Savepoint":
+		base = r.cfg.Weights.Oracles.Savepoint
+	case "TiFlashOnly":
+		base = r.cfg.Weights.Oracles.TiFlashOnly
+	case "AutoID":
+		base = r.cfg.Weights.Oracles.AutoID
+	case "ResultType":
+		base = r.cfg.Weights.Oracles.ResultType
+	case "BatchDML":
+		base = r.cfg.Weights.Oracles.BatchDML
+	case "DecimalArith":
+		base = r.cfg.Weights.Oracles.DecimalArith
+	case "FullGroupBy":
+		base = r.cfg.Weights.Oracles.FullGroupBy
+	case "LargeRow":
+		base = r.cfg.Weights.Oracles.LargeRow
+	case "FullJoin":
+		base = r.cfg.Weights.Oracles.FullJoin
+	case "Privilege":
+		base = r.cfg.Weights.Oracles.Privilege
+	case "CursorFetch":
+		base = r.cfg.Weights.Oracles.CursorFetch
+	case "InList":
+		base = r.cfg.Weights.Oracles.InList
+	case "PartitionRange":
+		if !r.cfg.Features.PartitionTables {
+			return 0
+		}
+		base = r.cfg.Weights.Oracles.PartitionRange
+	case "PlanCache":
+		if !r.cfg.Features.PlanCache {
+			return 0
+		}
+		base = r.cfg.Weights.Oracles.PlanCache
 	default:
 		return 0
 	}
 	if base <= 0 {
 		return base
 	}
+	if r.oracleBudget.parked(name) {
+		return 0
+	}
 	if name == "DQP" && r.isDQPTimeoutCooldownActive() {
 		return 0
 	}
@@ -117,7 +175,7 @@ func (r *Runner) pickAction() int {
 	if r.actionBandit != nil {
 		return r.actionBandit.Pick(r.gen.Rand, r.actionEnabled)
 	}
-	return util.PickWeighted(r.gen.Rand, []int{r.cfg.Weights.Actions.DDL, r.cfg.Weights.Actions.DML, r.cfg.Weights.Actions.Query})
+	return util.PickWeighted(r.gen.Rand, []int{r.cfg.Weights.Actions.DDL, r.cfg.Weights.Actions.DML, r.cfg.Weights.Actions.Query, r.cfg.Weights.Actions.Storyline})
 }
 
 func (r *Runner) updateActionBandit(action int, reward float64) {
@@ -127,7 +185,7 @@ func (r *Runner) updateActionBandit(action int, reward float64) {
 }
 
 func (r *Runner) pickOracle() int {
-	shouldPickCert := r.certOracleIdx >= 0 && r.gen.Rand.Float64() < certSampleRate
+	shouldPickCert := r.certOracleIdx >= 0 && !r.cfg.MPP.ColumnarOnly && r.gen.Rand.Float64() < certSampleRate
 	r.statsMu.Lock()
 	r.oraclePickTotal++
 	if shouldPickCert {
@@ -272,7 +330,8 @@ func isInfraReason(reason string) bool {
 	switch {
 	case strings.Contains(reason, "region_unavailable"),
 		strings.Contains(reason, "schema_out_of_date"),
-		strings.Contains(reason, "tikv_connectivity"):
+		strings.Contains(reason, "tikv_connectivity"),
+		strings.Contains(reason, "resource_group_throttled"):
 		return true
 	default:
 		return false
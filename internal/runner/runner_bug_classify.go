@@ -0,0 +1,196 @@
+package runner
+
+import (
+	"context"
+	"database/sql"
+
+	"shiro/internal/util"
+)
+
+const (
+	bugClassOptimizer     = "optimizer"
+	bugClassExecutor      = "executor"
+	bugClassPushdown      = "pushdown"
+	bugClassUnknown       = "unknown"
+	bugClassNotReproduced = "not_reproduced"
+
+	bugProbeMismatch = "mismatch"
+	bugProbeMatch    = "match"
+	bugProbeError    = "error"
+
+	bugPlanSame      = "same"
+	bugPlanDifferent = "different"
+)
+
+// bugClassProbe re-runs a mismatch with session variables that switch off one
+// layer. If the mismatch disappears, that layer is blamed.
+type bugClassProbe struct {
+	name  string
+	class string
+	vars  []string
+}
+
+// bugClassProbes are tried in order; the first probe that makes the mismatch
+// disappear decides the class. The coprocessor cache is a tidb-server config
+// (tikv-client.copr-cache) without a session switch, so it cannot be probed here.
+var bugClassProbes = []bugClassProbe{
+	{
+		name:  "no_vectorized",
+		class: bugClassExecutor,
+		vars:  []string{"tidb_enable_vectorized_expression=OFF"},
+	},
+	{
+		name:  "no_pushdown",
+		class: bugClassPushdown,
+		vars: []string{
+			"tidb_allow_mpp=OFF",
+			"tidb_isolation_read_engines='tikv,tidb'",
+			"tidb_enable_paging=OFF",
+		},
+	},
+}
+
+// classifyMismatch re-runs a DQP/EET signature mismatch under bugClassProbes
+// and compares the plan shapes of both sides, then stores a best-effort
+// optimizer/executor/pushdown verdict in details["bug_class"] with the probe
+// outcomes in details["bug_class_probes"]. It runs before minimization so the
+// verdict describes the original case.
+func (r *Runner) classifyMismatch(ctx context.Context, oracleName string, details map[string]any) {
+	if oracleName != "DQP" && oracleName != "EET" {
+		return
+	}
+	if detailString(details, "replay_kind") != "signature" {
+		return
+	}
+	expectedSQL := detailString(details, "replay_expected_sql")
+	actualSQL := detailString(details, "replay_actual_sql")
+	if expectedSQL == "" || actualSQL == "" {
+		return
+	}
+	setVar := detailString(details, "replay_set_var")
+	conn, err := r.exec.Conn(ctx)
+	if err != nil {
+		details["bug_class"] = bugClassUnknown
+		details["bug_class_error"] = err.Error()
+		return
+	}
+	defer util.CloseWithErr(conn, "bug classify conn")
+	if err := r.prepareConn(ctx, conn, r.cfg.Database); err != nil {
+		details["bug_class"] = bugClassUnknown
+		details["bug_class_error"] = err.Error()
+		return
+	}
+	outcomes := make(map[string]string, len(bugClassProbes)+1)
+	outcomes["baseline"] = r.bugProbeOutcome(ctx, conn, nil, expectedSQL, actualSQL, setVar)
+	if outcomes["baseline"] == bugProbeMismatch {
+		for _, probe := range bugClassProbes {
+			outcomes[probe.name] = r.bugProbeOutcome(ctx, conn, probe.vars, expectedSQL, actualSQL, setVar)
+			if outcomes[probe.name] == bugProbeMatch {
+				break
+			}
+		}
+	}
+	plan := ""
+	if classifyBug(outcomes, "") == "" {
+		plan = r.bugPlanOutcome(ctx, conn, expectedSQL, actualSQL, setVar)
+		outcomes["plan"] = plan
+	}
+	details["bug_class"] = classifyBug(outcomes, plan)
+	details["bug_class_probes"] = outcomes
+}
+
+// classifyBug maps probe outcomes to a bug class. It returns "" when the
+// probes alone are inconclusive and the plan comparison is still missing.
+func classifyBug(outcomes map[string]string, plan string) string {
+	switch outcomes["baseline"] {
+	case bugProbeMismatch:
+	case bugProbeMatch:
+		return bugClassNotReproduced
+	default:
+		return bugClassUnknown
+	}
+	for _, probe := range bugClassProbes {
+		if outcomes[probe.name] == bugProbeMatch {
+			return probe.class
+		}
+	}
+	switch plan {
+	case bugPlanSame:
+		// Identical plans with different results leave only evaluation to blame.
+		return bugClassExecutor
+	case bugPlanDifferent:
+		return bugClassOptimizer
+	case "":
+		return ""
+	default:
+		return bugClassUnknown
+	}
+}
+
+// bugProbeOutcome compares both signatures with vars applied to the session.
+// setVar is applied to the actual side only, as in replay.
+func (r *Runner) bugProbeOutcome(ctx context.Context, conn *sql.Conn, vars []string, expectedSQL, actualSQL, setVar string) string {
+	for _, assignment := range vars {
+		defer resetVarOnConn(ctx, conn, assignment, nil)
+		if err := r.execOnConn(ctx, conn, "SET SESSION "+assignment); err != nil {
+			return bugProbeError
+		}
+	}
+	roundScale := r.signatureRoundScale()
+	expectedSig, err := r.signatureForSQLOnConn(ctx, conn, expectedSQL, roundScale)
+	if err != nil {
+		return bugProbeError
+	}
+	if setVar != "" {
+		defer resetVarOnConn(ctx, conn, setVar, nil)
+		if err := r.execOnConn(ctx, conn, "SET SESSION "+setVar); err != nil {
+			return bugProbeError
+		}
+	}
+	actualSig, err := r.signatureForSQLOnConn(ctx, conn, actualSQL, roundScale)
+	if err != nil {
+		return bugProbeError
+	}
+	if expectedSig != actualSig {
+		return bugProbeMismatch
+	}
+	return bugProbeMatch
+}
+
+// bugPlanOutcome compares the operator shapes of both plans, ignoring
+// estimates and operator ids.
+func (r *Runner) bugPlanOutcome(ctx context.Context, conn *sql.Conn, expectedSQL, actualSQL, setVar string) string {
+	expectedShape, err := r.planShapeOnConn(ctx, conn, expectedSQL)
+	if err != nil || expectedShape == "" {
+		return bugProbeError
+	}
+	if setVar != "" {
+		defer resetVarOnConn(ctx, conn, setVar, nil)
+		if err := r.execOnConn(ctx, conn, "SET SESSION "+setVar); err != nil {
+			return bugProbeError
+		}
+	}
+	actualShape, err := r.planShapeOnConn(ctx, conn, actualSQL)
+	if err != nil || actualShape == "" {
+		return bugProbeError
+	}
+	if expectedShape == actualShape {
+		return bugPlanSame
+	}
+	return bugPlanDifferent
+}
+
+func (r *Runner) planShapeOnConn(ctx context.Context, conn *sql.Conn, sqlText string) (string, error) {
+	qctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	rows, err := conn.QueryContext(qctx, "EXPLAIN "+sqlText)
+	if err != nil {
+		return "", err
+	}
+	defer util.CloseWithErr(rows, "bug classify explain rows")
+	cols, planRows, err := scanStringRows(rows)
+	if err != nil {
+		return "", err
+	}
+	return planStabilityShape(cols, planRows), nil
+}
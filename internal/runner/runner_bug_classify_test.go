@@ -0,0 +1,43 @@
+package runner
+
+import (
+	"context"
+	"testing"
+)
+
+func TestClassifyBug(t *testing.T) {
+	cases := []struct {
+		name     string
+		outcomes map[string]string
+		plan     string
+		want     string
+	}{
+		{name: "flaky", outcomes: map[string]string{"baseline": bugProbeMatch}, want: bugClassNotReproduced},
+		{name: "baseline error", outcomes: map[string]string{"baseline": bugProbeError}, want: bugClassUnknown},
+		{name: "vectorized", outcomes: map[string]string{"baseline": bugProbeMismatch, "no_vectorized": bugProbeMatch}, want: bugClassExecutor},
+		{name: "pushdown", outcomes: map[string]string{"baseline": bugProbeMismatch, "no_vectorized": bugProbeMismatch, "no_pushdown": bugProbeMatch}, want: bugClassPushdown},
+		{name: "needs plan", outcomes: map[string]string{"baseline": bugProbeMismatch, "no_vectorized": bugProbeError, "no_pushdown": bugProbeMismatch}, want: ""},
+		{name: "same plan", outcomes: map[string]string{"baseline": bugProbeMismatch, "no_vectorized": bugProbeMismatch, "no_pushdown": bugProbeMismatch}, plan: bugPlanSame, want: bugClassExecutor},
+		{name: "different plan", outcomes: map[string]string{"baseline": bugProbeMismatch, "no_vectorized": bugProbeMismatch, "no_pushdown": bugProbeMismatch}, plan: bugPlanDifferent, want: bugClassOptimizer},
+		{name: "plan error", outcomes: map[string]string{"baseline": bugProbeMismatch}, plan: bugProbeError, want: bugClassUnknown},
+	}
+	for _, tc := range cases {
+		if got := classifyBug(tc.outcomes, tc.plan); got != tc.want {
+			t.Fatalf("%s: classifyBug=%q want=%q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestClassifyMismatchSkipsOtherCases(t *testing.T) {
+	r := &Runner{}
+	details := map[string]any{"replay_kind": "signature", "replay_expected_sql": "SELECT 1", "replay_actual_sql": "SELECT 2"}
+	r.classifyMismatch(context.Background(), "NoREC", details)
+	if _, ok := details["bug_class"]; ok {
+		t.Fatalf("NoREC case should not be classified: %v", details)
+	}
+	details = map[string]any{"replay_kind": "error_sql"}
+	r.classifyMismatch(context.Background(), "DQP", details)
+	if _, ok := details["bug_class"]; ok {
+		t.Fatalf("error replay should not be classified: %v", details)
+	}
+}
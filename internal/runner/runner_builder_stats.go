@@ -0,0 +1,77 @@
+package runner
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// builderSummary is the per-oracle select builder section of the run
+// summary. Rejections counts candidates each constraint rejected, and
+// RejectionRates divides them by the attempts, so guard thresholds can be
+// tuned from run data.
+type builderSummary struct {
+	Builds         int64              `json:"builds"`
+	Attempts       int64              `json:"attempts"`
+	FailedBuilds   int64              `json:"failed_builds"`
+	Rejections     map[string]int64   `json:"rejections,omitempty"`
+	RejectionRates map[string]float64 `json:"rejection_rates,omitempty"`
+}
+
+// builderSummaryLocked summarizes builder stats per oracle. The caller holds
+// statsMu.
+func (r *Runner) builderSummaryLocked() map[string]builderSummary {
+	if len(r.builderStats) == 0 {
+		return nil
+	}
+	out := make(map[string]builderSummary, len(r.builderStats))
+	for name, stat := range r.builderStats {
+		if stat == nil || stat.Builds == 0 {
+			continue
+		}
+		summary := builderSummary{Builds: stat.Builds, Attempts: stat.Attempts}
+		for _, count := range stat.FailureReasons {
+			summary.FailedBuilds += count
+		}
+		if len(stat.Rejections) > 0 && stat.Attempts > 0 {
+			summary.Rejections = make(map[string]int64, len(stat.Rejections))
+			summary.RejectionRates = make(map[string]float64, len(stat.Rejections))
+			for reason, count := range stat.Rejections {
+				summary.Rejections[reason] = count
+				summary.RejectionRates[reason] = math.Round(float64(count)/float64(stat.Attempts)*1000) / 1000
+			}
+		}
+		out[name] = summary
+	}
+	return out
+}
+
+// formatBuilderRejectionRates renders the rejections added since prev as
+// "reason=count(rate%)", most frequent first.
+func formatBuilderRejectionRates(total map[string]int64, prev map[string]int64, attempts int64, topN int) string {
+	if attempts <= 0 || topN <= 0 {
+		return ""
+	}
+	type rejection struct {
+		reason string
+		count  int64
+	}
+	items := make([]rejection, 0, len(total))
+	for reason, count := range total {
+		if delta := count - prev[reason]; delta > 0 {
+			items = append(items, rejection{reason: reason, count: delta})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].count == items[j].count {
+			return items[i].reason < items[j].reason
+		}
+		return items[i].count > items[j].count
+	})
+	parts := make([]string, 0, min(topN, len(items)))
+	for _, item := range items[:min(topN, len(items))] {
+		parts = append(parts, fmt.Sprintf("%s=%d(%.1f%%)", item.reason, item.count, float64(item.count)*100/float64(attempts)))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,36 @@
+package runner
+
+import (
+	"testing"
+
+	"shiro/internal/generator"
+)
+
+func TestBuilderSummary(t *testing.T) {
+	r := &Runner{builderStats: make(map[string]*builderAttemptStats)}
+	r.observeBuilderStats("DQP", generator.BuilderStats{
+		Builds:         2,
+		Attempts:       8,
+		FailureReasons: map[string]int64{"constraint:query_guard": 1},
+		Rejections:     map[string]int64{"constraint:query_guard": 6, "constraint:limit": 1},
+	})
+	summary := r.builderSummaryLocked()["DQP"]
+	if summary.Builds != 2 || summary.Attempts != 8 || summary.FailedBuilds != 1 {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+	if summary.Rejections["constraint:query_guard"] != 6 || summary.RejectionRates["constraint:query_guard"] != 0.75 {
+		t.Fatalf("unexpected rejections: %+v", summary)
+	}
+}
+
+func TestFormatBuilderRejectionRates(t *testing.T) {
+	total := map[string]int64{"constraint:query_guard": 9, "constraint:limit": 2, "constraint:cte": 1}
+	prev := map[string]int64{"constraint:query_guard": 3, "constraint:cte": 1}
+	got := formatBuilderRejectionRates(total, prev, 20, 5)
+	if want := "constraint:query_guard=6(30.0%) constraint:limit=2(10.0%)"; got != want {
+		t.Fatalf("got %q want %q", got, want)
+	}
+	if got := formatBuilderRejectionRates(total, prev, 0, 5); got != "" {
+		t.Fatalf("expected empty output without attempts, got %q", got)
+	}
+}
@@ -0,0 +1,98 @@
+package runner
+
+import (
+	"context"
+	"strings"
+
+	"shiro/internal/oracle"
+	"shiro/internal/report"
+	"shiro/internal/sqlstep"
+	"shiro/internal/util"
+)
+
+// captureCasePlans explains both sides of a mismatch with
+// EXPLAIN FORMAT='tidb_json' and returns their canonical plans. It returns nil
+// when neither side is an explainable query.
+func (r *Runner) captureCasePlans(ctx context.Context, result oracle.Result) *report.CasePlans {
+	expectedSQL, actualSQL := casePlanSides(result)
+	plans := report.CasePlans{Version: report.PlansVersion, Format: report.PlanFormatTiDBJSON}
+	if isExplainableQuery(expectedSQL) {
+		plans.Expected = r.explainCanonicalPlan(ctx, expectedSQL)
+	}
+	if isExplainableQuery(actualSQL) {
+		plans.Actual = r.explainCanonicalPlan(ctx, actualSQL)
+	}
+	if plans.Expected == nil && plans.Actual == nil {
+		return nil
+	}
+	return &plans
+}
+
+// casePlanSides picks the compared statements of a mismatch: role-tagged
+// steps first, then the replay and NoREC details.
+func casePlanSides(result oracle.Result) (expectedSQL string, actualSQL string) {
+	if step, ok := sqlstep.FindRole(result.Steps, sqlstep.RoleExpected); ok {
+		expectedSQL = step.SQL
+	}
+	if step, ok := sqlstep.FindRole(result.Steps, sqlstep.RoleActual); ok {
+		actualSQL = step.SQL
+	}
+	if expectedSQL == "" {
+		expectedSQL = detailString(result.Details, "replay_expected_sql", "norec_unoptimized_sql")
+	}
+	if actualSQL == "" {
+		actualSQL = detailString(result.Details, "replay_actual_sql", "norec_optimized_sql")
+	}
+	return strings.TrimSpace(expectedSQL), strings.TrimSpace(actualSQL)
+}
+
+func isExplainableQuery(sqlText string) bool {
+	upper := strings.ToUpper(strings.TrimSpace(sqlText))
+	return strings.HasPrefix(upper, "SELECT") || strings.HasPrefix(upper, "WITH")
+}
+
+func (r *Runner) explainCanonicalPlan(ctx context.Context, sqlText string) *report.CasePlan {
+	plan := &report.CasePlan{SQL: sqlText}
+	raw, err := r.explainTiDBJSON(ctx, sqlText)
+	if err == nil {
+		plan.Roots, err = report.ParseTiDBJSONPlan(raw)
+	}
+	if err != nil {
+		plan.Error = err.Error()
+	}
+	return plan
+}
+
+// explainTiDBJSON returns the JSON text of EXPLAIN FORMAT='tidb_json'. TiDB
+// returns it in the first column of a single row.
+func (r *Runner) explainTiDBJSON(ctx context.Context, sqlText string) (string, error) {
+	qctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	rows, err := r.exec.QueryContext(qctx, "EXPLAIN FORMAT='"+report.PlanFormatTiDBJSON+"' "+sqlText)
+	if err != nil {
+		return "", err
+	}
+	defer util.CloseWithErr(rows, "case plan rows")
+	cols, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+	values := make([][]byte, len(cols))
+	scanArgs := make([]any, len(cols))
+	for i := range values {
+		scanArgs[i] = &values[i]
+	}
+	var b strings.Builder
+	for rows.Next() {
+		if err := rows.Scan(scanArgs...); err != nil {
+			return "", err
+		}
+		if len(values) > 0 {
+			b.Write(values[0])
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
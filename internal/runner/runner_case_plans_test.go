@@ -0,0 +1,71 @@
+package runner
+
+import (
+	"testing"
+
+	"shiro/internal/oracle"
+	"shiro/internal/sqlstep"
+)
+
+func TestCasePlanSides(t *testing.T) {
+	tests := []struct {
+		name         string
+		result       oracle.Result
+		wantExpected string
+		wantActual   string
+	}{
+		{
+			name: "replay details",
+			result: oracle.Result{
+				Details: map[string]any{
+					"replay_expected_sql": "SELECT a FROM t",
+					"replay_actual_sql":   "SELECT b FROM t",
+				},
+			},
+			wantExpected: "SELECT a FROM t",
+			wantActual:   "SELECT b FROM t",
+		},
+		{
+			name: "norec",
+			result: oracle.Result{
+				Details: map[string]any{
+					"norec_optimized_sql":   "SELECT COUNT(*) FROM t WHERE p",
+					"norec_unoptimized_sql": "SELECT SUM(p) FROM t",
+				},
+			},
+			wantExpected: "SELECT SUM(p) FROM t",
+			wantActual:   "SELECT COUNT(*) FROM t WHERE p",
+		},
+		{
+			name: "role-tagged steps",
+			result: oracle.Result{
+				Steps: []sqlstep.Step{
+					sqlstep.New(sqlstep.KindQuery, sqlstep.RoleActual, "SELECT y"),
+					sqlstep.New(sqlstep.KindQuery, sqlstep.RoleExpected, "SELECT x"),
+				},
+				Details: map[string]any{"replay_expected_sql": "SELECT z"},
+			},
+			wantExpected: "SELECT x",
+			wantActual:   "SELECT y",
+		},
+		{
+			name: "untagged steps",
+			result: oracle.Result{Steps: []sqlstep.Step{
+				sqlstep.New(sqlstep.KindQuery, "", "SELECT 1"),
+				sqlstep.New(sqlstep.KindQuery, "", "SELECT 2"),
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected, actual := casePlanSides(tt.result)
+			if expected != tt.wantExpected || actual != tt.wantActual {
+				t.Fatalf("casePlanSides() = (%q, %q), want (%q, %q)", expected, actual, tt.wantExpected, tt.wantActual)
+			}
+		})
+	}
+
+	if isExplainableQuery("INSERT INTO t VALUES (1)") || !isExplainableQuery(" with cte AS (SELECT 1) SELECT * FROM cte") {
+		t.Fatalf("unexpected explainable query classification")
+	}
+}
@@ -0,0 +1,100 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"shiro/internal/report"
+)
+
+const (
+	certStatsFile = "cert_stats.txt"
+	// certStatsMaxRows caps the rows kept per SHOW statement; a wide table
+	// has up to 256 buckets per column and index.
+	certStatsMaxRows = 10000
+)
+
+// certStatsShows are the statistics the optimizer read for the estimate:
+// row counts, per-column and per-index histograms, their buckets, and TopN.
+var certStatsShows = []string{"STATS_META", "STATS_HISTOGRAMS", "STATS_BUCKETS", "STATS_TOPN"}
+
+// captureCERTStats writes the statistics of the tables a CERT case read into
+// cert_stats.txt. Estimation bugs rarely reproduce without the exact
+// histograms, and the plan replayer dump that carries them can fail.
+func (r *Runner) captureCERTStats(ctx context.Context, caseData report.Case, details map[string]any) {
+	tables := detailStrings(details, "cert_tables")
+	if len(tables) == 0 {
+		return
+	}
+	qctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	var database string
+	if err := r.exec.QueryRowContext(qctx, "SELECT DATABASE()").Scan(&database); err != nil {
+		details["cert_stats_error"] = err.Error()
+		return
+	}
+	var b strings.Builder
+	var failed []string
+	for _, show := range certStatsShows {
+		query := certStatsQuery(show, database, tables)
+		cols, rows, err := r.queryStringRows(qctx, query)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", show, err))
+			continue
+		}
+		fmt.Fprintf(&b, "-- %s;\n", query)
+		truncated := len(rows) > certStatsMaxRows
+		if truncated {
+			rows = rows[:certStatsMaxRows]
+		}
+		b.WriteString(planText(cols, rows))
+		b.WriteString("\n")
+		if truncated {
+			fmt.Fprintf(&b, "-- truncated to %d rows\n", certStatsMaxRows)
+		}
+		b.WriteString("\n")
+	}
+	if len(failed) > 0 {
+		details["cert_stats_error"] = strings.Join(failed, "; ")
+	}
+	if b.Len() == 0 {
+		return
+	}
+	if err := r.reporter.WriteText(caseData, certStatsFile, b.String()); err == nil {
+		details["cert_stats_file"] = certStatsFile
+	}
+}
+
+// certStatsQuery returns the SHOW statement for one statistics kind of the
+// given tables.
+func certStatsQuery(show string, database string, tables []string) string {
+	quoted := make([]string, 0, len(tables))
+	for _, table := range tables {
+		quoted = append(quoted, sqlStringLiteral(table))
+	}
+	return fmt.Sprintf("SHOW %s WHERE Db_name = %s AND Table_name IN (%s)", show, sqlStringLiteral(database), strings.Join(quoted, ", "))
+}
+
+func sqlStringLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// detailStrings returns a string list detail, as set by an oracle or read
+// back from JSON.
+func detailStrings(details map[string]any, key string) []string {
+	switch value := details[key].(type) {
+	case []string:
+		return value
+	case []any:
+		out := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok && s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,24 @@
+package runner
+
+import "testing"
+
+func TestCERTStatsQuery(t *testing.T) {
+	got := certStatsQuery("STATS_BUCKETS", "shiro_fuzz", []string{"t0", "t'1"})
+	want := "SHOW STATS_BUCKETS WHERE Db_name = 'shiro_fuzz' AND Table_name IN ('t0', 't''1')"
+	if got != want {
+		t.Fatalf("unexpected query:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestDetailStrings(t *testing.T) {
+	details := map[string]any{"a": []string{"t0"}, "b": []any{"t1", 3, ""}, "c": "t2"}
+	if got := detailStrings(details, "a"); len(got) != 1 || got[0] != "t0" {
+		t.Fatalf("unexpected []string detail: %v", got)
+	}
+	if got := detailStrings(details, "b"); len(got) != 1 || got[0] != "t1" {
+		t.Fatalf("unexpected []any detail: %v", got)
+	}
+	if got := detailStrings(details, "c"); got != nil {
+		t.Fatalf("expected nil for a scalar detail, got %v", got)
+	}
+}
@@ -0,0 +1,419 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"shiro/internal/config"
+	"shiro/internal/util"
+)
+
+const (
+	chaosAPIVersion     = "chaos-mesh.org/v1alpha1"
+	chaosRequestTimeout = 30 * time.Second
+	// chaosLinger keeps an ended experiment in case annotations for a while,
+	// since leader elections and retries outlast the fault itself.
+	chaosLinger       = 30 * time.Second
+	chaosResponseMax  = 1 << 20
+	chaosManagedLabel = "app.kubernetes.io/managed-by"
+)
+
+// chaosState runs the chaos schedule and remembers the experiments that are
+// active or ended within chaosLinger, for case annotations.
+type chaosState struct {
+	cfg    config.ChaosConfig
+	client *http.Client
+
+	mu      sync.Mutex
+	runs    []chaosRun
+	summary chaosSummary
+}
+
+// chaosRun is one started experiment. EndedAt is zero while it is active.
+type chaosRun struct {
+	Name      string
+	Kind      string
+	Resource  string
+	UID       string
+	StartedAt time.Time
+	EndedAt   time.Time
+}
+
+// chaosSummary is the chaos block of the run summary.
+type chaosSummary struct {
+	Started       int64   `json:"started"`
+	Failed        int64   `json:"failed"`
+	ActiveSeconds float64 `json:"active_seconds"`
+	LastError     string  `json:"last_error,omitempty"`
+}
+
+func newChaosState(cfg config.ChaosConfig) *chaosState {
+	return &chaosState{cfg: cfg, client: &http.Client{Timeout: chaosRequestTimeout}}
+}
+
+// Chaos is the chaos schedule of the process. One Chaos serves the runners
+// of every worker and target, so experiments never overlap and every case
+// is annotated with the faults around it, whichever runner caught it.
+type Chaos struct {
+	state *chaosState
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewChaos returns the chaos schedule of cfg. It returns nil when
+// chaos.enabled is off or no dashboard is configured; a nil Chaos does
+// nothing.
+func NewChaos(cfg config.ChaosConfig) *Chaos {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.DashboardURL == "" {
+		util.Warnf("chaos disabled: chaos.dashboard_url is empty")
+		return nil
+	}
+	return &Chaos{state: newChaosState(cfg)}
+}
+
+// Start runs the schedule in the background. Each slice waits interval
+// minus duration without faults, then runs the next experiment for duration.
+// Calls after the first do nothing.
+func (c *Chaos) Start(ctx context.Context) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.done != nil {
+		return
+	}
+	cctx, cancel := context.WithCancel(ctx)
+	c.cancel = cancel
+	c.done = make(chan struct{})
+	go func() {
+		defer close(c.done)
+		c.state.loop(cctx)
+	}()
+	cfg := c.state.cfg
+	util.Infof("chaos started dashboard=%s experiments=%d interval_seconds=%d duration_seconds=%d",
+		cfg.DashboardURL, len(cfg.Experiments), cfg.IntervalSeconds, cfg.DurationSeconds)
+}
+
+// Stop ends the schedule and removes the running experiment.
+func (c *Chaos) Stop() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.done == nil {
+		return
+	}
+	c.cancel()
+	<-c.done
+}
+
+// SetChaos makes the runner annotate its cases with the experiments of the
+// shared chaos schedule and report it in the run summary.
+func (r *Runner) SetChaos(c *Chaos) {
+	if c == nil {
+		r.chaos = nil
+		return
+	}
+	r.chaos = c.state
+}
+
+func (s *chaosState) loop(ctx context.Context) {
+	interval := time.Duration(s.cfg.IntervalSeconds) * time.Second
+	duration := time.Duration(s.cfg.DurationSeconds) * time.Second
+	for seq := 0; ; seq++ {
+		if !sleepContext(ctx, interval-duration) {
+			return
+		}
+		exp := s.cfg.Experiments[seq%len(s.cfg.Experiments)]
+		run, err := s.start(ctx, exp, seq)
+		if err != nil {
+			s.fail(err)
+			util.Warnf("chaos experiment failed to start name=%s err=%v", exp.Name, err)
+			continue
+		}
+		util.Warnf("chaos experiment started name=%s kind=%s resource=%s", run.Name, run.Kind, run.Resource)
+		sleepContext(ctx, duration)
+		stopCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), chaosRequestTimeout)
+		err = s.stop(stopCtx, run)
+		cancel()
+		if err != nil {
+			s.fail(err)
+			util.Warnf("chaos experiment failed to stop name=%s resource=%s err=%v", run.Name, run.Resource, err)
+		} else {
+			util.Infof("chaos experiment stopped name=%s resource=%s", run.Name, run.Resource)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// sleepContext waits for d and reports false when ctx ends first.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (s *chaosState) start(ctx context.Context, exp config.ChaosExperiment, seq int) (chaosRun, error) {
+	object, err := chaosExperimentObject(s.cfg, exp, seq)
+	if err != nil {
+		return chaosRun{}, err
+	}
+	body, err := json.Marshal(object)
+	if err != nil {
+		return chaosRun{}, err
+	}
+	resp, err := s.request(ctx, http.MethodPost, "/api/experiments", body)
+	if err != nil {
+		return chaosRun{}, err
+	}
+	metadata := object["metadata"].(map[string]any)
+	run := chaosRun{
+		Name:      exp.Name,
+		Kind:      object["kind"].(string),
+		Resource:  metadata["name"].(string),
+		UID:       chaosResponseUID(resp),
+		StartedAt: time.Now(),
+	}
+	s.mu.Lock()
+	s.runs = append(s.runs, run)
+	s.summary.Started++
+	s.mu.Unlock()
+	return run, nil
+}
+
+// stop deletes the experiment. Without a UID from the dashboard, the
+// experiment's own duration recovers the fault.
+func (s *chaosState) stop(ctx context.Context, run chaosRun) error {
+	var err error
+	if run.UID != "" {
+		_, err = s.request(ctx, http.MethodDelete, "/api/experiments/"+url.PathEscape(run.UID), nil)
+	}
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.runs {
+		if s.runs[i].Resource == run.Resource && s.runs[i].EndedAt.IsZero() {
+			s.runs[i].EndedAt = now
+			s.summary.ActiveSeconds += now.Sub(s.runs[i].StartedAt).Seconds()
+		}
+	}
+	return err
+}
+
+func (s *chaosState) fail(err error) {
+	s.mu.Lock()
+	s.summary.Failed++
+	s.summary.LastError = err.Error()
+	s.mu.Unlock()
+}
+
+func (s *chaosState) request(ctx context.Context, method string, path string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.cfg.DashboardURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.cfg.Token)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer util.CloseWithErr(resp.Body, "chaos response")
+	data, err := io.ReadAll(io.LimitReader(resp.Body, chaosResponseMax))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("%s %s: status %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
+
+// chaosResponseUID reads the experiment UID from a dashboard response, which
+// is either the created object or {"uid": ...} depending on the version.
+func chaosResponseUID(data []byte) string {
+	var resp struct {
+		UID      string `json:"uid"`
+		Metadata struct {
+			UID string `json:"uid"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return ""
+	}
+	if resp.Metadata.UID != "" {
+		return resp.Metadata.UID
+	}
+	return resp.UID
+}
+
+// chaosExperimentObject renders an experiment as a chaos-mesh object. Spec
+// keys override the template spec, and the slice duration is set so
+// chaos-mesh recovers the fault even if the runner dies mid-slice.
+func chaosExperimentObject(cfg config.ChaosConfig, exp config.ChaosExperiment, seq int) (map[string]any, error) {
+	kind, spec, err := chaosTemplate(cfg, exp.Template)
+	if err != nil {
+		return nil, err
+	}
+	if exp.Template == "" {
+		if exp.Kind == "" {
+			return nil, fmt.Errorf("chaos experiment %q has neither template nor kind", exp.Name)
+		}
+		kind = exp.Kind
+		spec = map[string]any{}
+	}
+	for key, value := range exp.Spec {
+		spec[key] = value
+	}
+	if _, ok := spec["duration"]; !ok {
+		spec["duration"] = fmt.Sprintf("%ds", cfg.DurationSeconds)
+	}
+	return map[string]any{
+		"apiVersion": chaosAPIVersion,
+		"kind":       kind,
+		"metadata": map[string]any{
+			"name":      fmt.Sprintf("shiro-%s-%d", chaosResourceName(exp.Name), seq),
+			"namespace": cfg.Namespace,
+			"labels":    map[string]any{chaosManagedLabel: "shiro"},
+		},
+		"spec": spec,
+	}, nil
+}
+
+// chaosTemplate returns the kind and spec of a built-in experiment. All of
+// them target one TiKV pod of the cluster:
+//
+//   - leader_transfer takes the pod down (pod-failure), so its region leaders
+//     transfer to the other stores and back.
+//   - network_partition cuts it off from the other TiKV pods.
+//   - io_delay adds latency to its data directory.
+func chaosTemplate(cfg config.ChaosConfig, template string) (string, map[string]any, error) {
+	tikv := func() map[string]any {
+		labels := map[string]any{"app.kubernetes.io/component": "tikv"}
+		if cfg.Cluster != "" {
+			labels["app.kubernetes.io/instance"] = cfg.Cluster
+		}
+		return map[string]any{"namespaces": []any{cfg.Namespace}, "labelSelectors": labels}
+	}
+	switch template {
+	case "":
+		return "", nil, nil
+	case config.ChaosTemplateLeaderTransfer:
+		return "PodChaos", map[string]any{"action": "pod-failure", "mode": "one", "selector": tikv()}, nil
+	case config.ChaosTemplateNetworkPartition:
+		return "NetworkChaos", map[string]any{
+			"action":    "partition",
+			"mode":      "one",
+			"selector":  tikv(),
+			"direction": "both",
+			"target":    map[string]any{"mode": "all", "selector": tikv()},
+		}, nil
+	case config.ChaosTemplateIODelay:
+		return "IOChaos", map[string]any{
+			"action":     "latency",
+			"mode":       "one",
+			"selector":   tikv(),
+			"volumePath": "/var/lib/tikv",
+			"path":       "/var/lib/tikv/**/*",
+			"delay":      "50ms",
+			"percent":    100,
+		}, nil
+	default:
+		return "", nil, fmt.Errorf("unknown chaos template %q", template)
+	}
+}
+
+// chaosResourceName turns an experiment name into a Kubernetes name segment.
+func chaosResourceName(name string) string {
+	var b strings.Builder
+	for _, c := range strings.ToLower(name) {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+			b.WriteRune(c)
+		default:
+			b.WriteByte('-')
+		}
+	}
+	out := strings.Trim(b.String(), "-")
+	if out == "" {
+		return "experiment"
+	}
+	return out
+}
+
+// activeRuns returns the experiments that are active at now or ended within
+// chaosLinger, and drops older ones.
+func (s *chaosState) activeRuns(now time.Time) []chaosRun {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.runs[:0]
+	for _, run := range s.runs {
+		if run.EndedAt.IsZero() || now.Sub(run.EndedAt) <= chaosLinger {
+			kept = append(kept, run)
+		}
+	}
+	s.runs = kept
+	return append([]chaosRun(nil), kept...)
+}
+
+// annotateChaos records the chaos experiments around a case in its details.
+func (r *Runner) annotateChaos(details map[string]any) {
+	if r.chaos == nil {
+		return
+	}
+	runs := r.chaos.activeRuns(time.Now())
+	if len(runs) == 0 {
+		return
+	}
+	out := make([]map[string]any, 0, len(runs))
+	for _, run := range runs {
+		entry := map[string]any{
+			"name":       run.Name,
+			"kind":       run.Kind,
+			"resource":   run.Resource,
+			"started_at": run.StartedAt.UTC().Format(time.RFC3339),
+		}
+		if !run.EndedAt.IsZero() {
+			entry["ended_at"] = run.EndedAt.UTC().Format(time.RFC3339)
+		}
+		out = append(out, entry)
+	}
+	details["chaos_experiments"] = out
+}
+
+func (s *chaosState) summarySnapshot() *chaosSummary {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	summary := s.summary
+	return &summary
+}
@@ -0,0 +1,186 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"shiro/internal/config"
+)
+
+func testChaosConfig(url string) config.ChaosConfig {
+	return config.ChaosConfig{
+		Enabled:         true,
+		DashboardURL:    url,
+		Token:           "secret",
+		Namespace:       "tidb-cluster",
+		Cluster:         "basic",
+		IntervalSeconds: 300,
+		DurationSeconds: 60,
+	}
+}
+
+func TestChaosExperimentObject(t *testing.T) {
+	cfg := testChaosConfig("")
+	exp := config.ChaosExperiment{
+		Name:     "io_delay",
+		Template: config.ChaosTemplateIODelay,
+		Spec:     map[string]any{"delay": "100ms"},
+	}
+	object, err := chaosExperimentObject(cfg, exp, 3)
+	if err != nil {
+		t.Fatalf("object: %v", err)
+	}
+	if object["kind"] != "IOChaos" || object["apiVersion"] != chaosAPIVersion {
+		t.Fatalf("unexpected header: %v", object)
+	}
+	metadata := object["metadata"].(map[string]any)
+	if metadata["name"] != "shiro-io-delay-3" || metadata["namespace"] != "tidb-cluster" {
+		t.Fatalf("unexpected metadata: %v", metadata)
+	}
+	spec := object["spec"].(map[string]any)
+	if spec["delay"] != "100ms" || spec["duration"] != "60s" || spec["action"] != "latency" {
+		t.Fatalf("unexpected spec: %v", spec)
+	}
+	labels := spec["selector"].(map[string]any)["labelSelectors"].(map[string]any)
+	if labels["app.kubernetes.io/component"] != "tikv" || labels["app.kubernetes.io/instance"] != "basic" {
+		t.Fatalf("unexpected selector: %v", labels)
+	}
+
+	custom := config.ChaosExperiment{Name: "cpu", Kind: "StressChaos", Spec: map[string]any{"duration": "10s"}}
+	object, err = chaosExperimentObject(cfg, custom, 0)
+	if err != nil {
+		t.Fatalf("custom object: %v", err)
+	}
+	if object["kind"] != "StressChaos" || object["spec"].(map[string]any)["duration"] != "10s" {
+		t.Fatalf("unexpected custom object: %v", object)
+	}
+	if _, err := chaosExperimentObject(cfg, config.ChaosExperiment{Name: "x", Template: "unknown"}, 0); err == nil {
+		t.Fatalf("expected unknown template error")
+	}
+	if _, err := chaosExperimentObject(cfg, config.ChaosExperiment{Name: "x"}, 0); err == nil {
+		t.Fatalf("expected missing kind error")
+	}
+}
+
+func TestChaosStartStop(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	var created map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, req.Method+" "+req.URL.Path)
+		if req.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if req.Method == http.MethodPost {
+			body, _ := io.ReadAll(req.Body)
+			_ = json.Unmarshal(body, &created)
+			_, _ = w.Write([]byte(`{"metadata":{"uid":"uid-1"}}`))
+		}
+	}))
+	defer server.Close()
+
+	state := newChaosState(testChaosConfig(server.URL))
+	exp := config.ChaosExperiment{Name: "leader_transfer", Template: config.ChaosTemplateLeaderTransfer}
+	run, err := state.start(context.Background(), exp, 0)
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if run.UID != "uid-1" || run.Kind != "PodChaos" || created["kind"] != "PodChaos" {
+		t.Fatalf("unexpected run=%+v created=%v", run, created)
+	}
+	if runs := state.activeRuns(time.Now()); len(runs) != 1 || !runs[0].EndedAt.IsZero() {
+		t.Fatalf("expected one active run, got %+v", runs)
+	}
+	if err := state.stop(context.Background(), run); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	if len(calls) != 2 || calls[0] != "POST /api/experiments" || calls[1] != "DELETE /api/experiments/uid-1" {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+	summary := state.summarySnapshot()
+	if summary.Started != 1 || summary.Failed != 0 {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+	if runs := state.activeRuns(time.Now().Add(2 * chaosLinger)); len(runs) != 0 {
+		t.Fatalf("expected ended run to expire, got %+v", runs)
+	}
+}
+
+func TestChaosStartError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	state := newChaosState(testChaosConfig(server.URL))
+	exp := config.ChaosExperiment{Name: "partition", Template: config.ChaosTemplateNetworkPartition}
+	if _, err := state.start(context.Background(), exp, 0); err == nil {
+		t.Fatalf("expected start error")
+	}
+	if runs := state.activeRuns(time.Now()); len(runs) != 0 {
+		t.Fatalf("expected no runs, got %+v", runs)
+	}
+}
+
+func TestAnnotateChaos(t *testing.T) {
+	r := &Runner{}
+	details := map[string]any{}
+	r.annotateChaos(details)
+	if _, ok := details["chaos_experiments"]; ok {
+		t.Fatalf("expected no annotation without chaos")
+	}
+	now := time.Now()
+	r.chaos = newChaosState(testChaosConfig(""))
+	r.chaos.runs = []chaosRun{
+		{Name: "old", Kind: "PodChaos", Resource: "shiro-old-0", StartedAt: now.Add(-5 * time.Minute), EndedAt: now.Add(-4 * time.Minute)},
+		{Name: "recent", Kind: "IOChaos", Resource: "shiro-recent-1", StartedAt: now.Add(-time.Minute), EndedAt: now.Add(-time.Second)},
+		{Name: "active", Kind: "NetworkChaos", Resource: "shiro-active-2", StartedAt: now},
+	}
+	r.annotateChaos(details)
+	entries, ok := details["chaos_experiments"].([]map[string]any)
+	if !ok || len(entries) != 2 {
+		t.Fatalf("unexpected annotation: %v", details["chaos_experiments"])
+	}
+	if entries[0]["name"] != "recent" || entries[0]["ended_at"] == nil {
+		t.Fatalf("unexpected recent entry: %v", entries[0])
+	}
+	if entries[1]["name"] != "active" || entries[1]["ended_at"] != nil {
+		t.Fatalf("unexpected active entry: %v", entries[1])
+	}
+}
+
+func TestChaosSharedAcrossRunners(t *testing.T) {
+	if NewChaos(config.ChaosConfig{}) != nil || NewChaos(config.ChaosConfig{Enabled: true}) != nil {
+		t.Fatalf("expected no chaos without chaos.enabled and a dashboard")
+	}
+	var disabled *Chaos
+	disabled.Start(context.Background())
+	disabled.Stop()
+
+	chaos := NewChaos(testChaosConfig("http://127.0.0.1:1"))
+	workers := []*Runner{{}, {}}
+	for _, r := range workers {
+		r.SetChaos(chaos)
+	}
+	chaos.state.runs = []chaosRun{{Name: "active", Kind: "PodChaos", Resource: "shiro-active-0", StartedAt: time.Now()}}
+	for i, r := range workers {
+		details := map[string]any{}
+		r.annotateChaos(details)
+		if entries, ok := details["chaos_experiments"].([]map[string]any); !ok || len(entries) != 1 {
+			t.Fatalf("worker %d: unexpected annotation: %v", i, details["chaos_experiments"])
+		}
+	}
+	chaos.Start(context.Background())
+	chaos.Start(context.Background())
+	chaos.Stop()
+	chaos.Stop()
+}
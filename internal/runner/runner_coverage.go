@@ -0,0 +1,177 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"shiro/internal/generator"
+)
+
+// featureCoverageFilePattern names the coverage report of one database, so
+// workers sharing an output directory do not overwrite each other.
+const featureCoverageFilePattern = "feature_coverage-%s.json"
+
+// coverageFeatureNames lists the QueryFeatures flags tracked in the coverage
+// report, in report order.
+var coverageFeatureNames = []string{
+	"join",
+	"natural_join",
+	"full_join_emulation",
+	"name_ref",
+	"null_aware",
+	"set_operations",
+	"derived_tables",
+	"recursive_cte",
+	"subquery",
+	"in_subquery",
+	"not_in_subquery",
+	"exists_subquery",
+	"not_exists_subquery",
+	"quantified_subquery",
+	"in_list",
+	"not_in_list",
+	"aggregate",
+	"window",
+	"window_frame",
+	"interval_arith",
+	"view",
+}
+
+// oracleFeatureCoverage aggregates feature usage for one oracle. Generated
+// counts every query the oracle built; Features only counts executed queries
+// (results without a skip reason).
+type oracleFeatureCoverage struct {
+	Generated int64            `json:"generated"`
+	Executed  int64            `json:"executed"`
+	Features  map[string]int64 `json:"features"`
+}
+
+type featureCoverageReport struct {
+	Version   int                               `json:"version"`
+	Timestamp string                            `json:"timestamp"`
+	Seed      int64                             `json:"seed"`
+	Database  string                            `json:"database"`
+	Total     oracleFeatureCoverage             `json:"total"`
+	Oracles   map[string]*oracleFeatureCoverage `json:"oracles"`
+	// Uncovered lists tracked features that no executed query has used.
+	Uncovered []string `json:"uncovered"`
+}
+
+// queryFeatureFlags returns the tracked feature names set in features.
+func queryFeatureFlags(features *generator.QueryFeatures) []string {
+	if features == nil {
+		return nil
+	}
+	flags := map[string]bool{
+		"join":                features.JoinCount > 0,
+		"natural_join":        features.HasNaturalJoin,
+		"full_join_emulation": features.HasFullJoinEmulation,
+		"name_ref":            features.HasNameRefs,
+		"null_aware":          features.NullPredicates > 0,
+		"set_operations":      features.HasSetOperations,
+		"derived_tables":      features.HasDerivedTables,
+		"recursive_cte":       features.HasRecursiveCTE,
+		"subquery":            features.HasSubquery,
+		"in_subquery":         features.HasInSubquery,
+		"not_in_subquery":     features.HasNotInSubquery,
+		"exists_subquery":     features.HasExistsSubquery,
+		"not_exists_subquery": features.HasNotExistsSubquery,
+		"quantified_subquery": features.HasQuantifiedSubqueries,
+		"in_list":             features.HasInList,
+		"not_in_list":         features.HasNotInList,
+		"aggregate":           features.HasAggregate,
+		"window":              features.HasWindow,
+		"window_frame":        features.HasWindowFrame,
+		"interval_arith":      features.HasIntervalArith,
+		"view":                features.ViewCount > 0,
+	}
+	out := make([]string, 0, len(flags))
+	for _, name := range coverageFeatureNames {
+		if flags[name] {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// observeFeatureCoverage records the generated query features of one oracle run.
+func (r *Runner) observeFeatureCoverage(oracleName string, features *generator.QueryFeatures, executed bool) {
+	if features == nil || oracleName == "" {
+		return
+	}
+	flags := queryFeatureFlags(features)
+	r.statsMu.Lock()
+	defer r.statsMu.Unlock()
+	if r.featureCoverage == nil {
+		r.featureCoverage = make(map[string]*oracleFeatureCoverage)
+	}
+	cov := r.featureCoverage[oracleName]
+	if cov == nil {
+		cov = &oracleFeatureCoverage{Features: make(map[string]int64)}
+		r.featureCoverage[oracleName] = cov
+	}
+	cov.Generated++
+	if !executed {
+		return
+	}
+	cov.Executed++
+	for _, name := range flags {
+		cov.Features[name]++
+	}
+}
+
+// snapshotFeatureCoverage builds the coverage report from the per-oracle counts.
+func (r *Runner) snapshotFeatureCoverage() featureCoverageReport {
+	report := featureCoverageReport{
+		Version:   1,
+		Timestamp: time.Now().Format(time.RFC3339),
+		Database:  r.cfg.Database,
+		Total:     oracleFeatureCoverage{Features: make(map[string]int64)},
+		Oracles:   make(map[string]*oracleFeatureCoverage),
+	}
+	r.statsMu.Lock()
+	for name, cov := range r.featureCoverage {
+		clone := &oracleFeatureCoverage{Generated: cov.Generated, Executed: cov.Executed, Features: make(map[string]int64, len(cov.Features))}
+		for feature, count := range cov.Features {
+			clone.Features[feature] = count
+			report.Total.Features[feature] += count
+		}
+		report.Oracles[name] = clone
+		report.Total.Generated += cov.Generated
+		report.Total.Executed += cov.Executed
+	}
+	r.statsMu.Unlock()
+	for _, name := range coverageFeatureNames {
+		if report.Total.Features[name] == 0 {
+			report.Uncovered = append(report.Uncovered, name)
+		}
+	}
+	return report
+}
+
+// dumpFeatureCoverage writes feature_coverage-<database>.json under the
+// plan replayer output directory.
+func (r *Runner) dumpFeatureCoverage() {
+	report := r.snapshotFeatureCoverage()
+	if r.gen != nil {
+		report.Seed = r.seedSnapshot()
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(r.featureCoveragePath(), data, 0o644)
+}
+
+func (r *Runner) featureCoveragePath() string {
+	dir := r.cfg.PlanReplayer.OutputDir
+	if dir == "" {
+		dir = "."
+	} else if err := os.MkdirAll(dir, 0o755); err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, fmt.Sprintf(featureCoverageFilePattern, r.baseDB))
+}
@@ -0,0 +1,56 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"shiro/internal/generator"
+)
+
+func TestQueryFeatureFlags(t *testing.T) {
+	features := &generator.QueryFeatures{JoinCount: 2, HasWindowFrame: true, HasRecursiveCTE: true, ViewCount: 1}
+	got := queryFeatureFlags(features)
+	want := []string{"join", "recursive_cte", "window_frame", "view"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("queryFeatureFlags()=%v want=%v", got, want)
+	}
+	if got := queryFeatureFlags(nil); got != nil {
+		t.Fatalf("queryFeatureFlags(nil)=%v want=nil", got)
+	}
+}
+
+func TestSnapshotFeatureCoverage(t *testing.T) {
+	r := &Runner{}
+	r.observeFeatureCoverage("NoREC", &generator.QueryFeatures{HasNaturalJoin: true}, true)
+	r.observeFeatureCoverage("NoREC", &generator.QueryFeatures{HasNaturalJoin: true, HasWindow: true}, false)
+	r.observeFeatureCoverage("TLP", &generator.QueryFeatures{HasNaturalJoin: true, HasIntervalArith: true}, true)
+	r.observeFeatureCoverage("TLP", nil, true)
+
+	report := r.snapshotFeatureCoverage()
+	norec := report.Oracles["NoREC"]
+	if norec == nil || norec.Generated != 2 || norec.Executed != 1 || norec.Features["window"] != 0 {
+		t.Fatalf("unexpected NoREC coverage: %+v", norec)
+	}
+	if report.Total.Generated != 3 || report.Total.Executed != 2 {
+		t.Fatalf("unexpected totals: %+v", report.Total)
+	}
+	if report.Total.Features["natural_join"] != 2 || report.Total.Features["interval_arith"] != 1 {
+		t.Fatalf("unexpected feature totals: %v", report.Total.Features)
+	}
+	if slices.Contains(report.Uncovered, "natural_join") || !slices.Contains(report.Uncovered, "window") {
+		t.Fatalf("unexpected uncovered features: %v", report.Uncovered)
+	}
+}
+
+func TestDumpFeatureCoverageUnderOutputDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "reports")
+	r := &Runner{baseDB: "shiro_fuzz_2"}
+	r.cfg.PlanReplayer.OutputDir = dir
+	r.observeFeatureCoverage("NoREC", &generator.QueryFeatures{JoinCount: 1}, true)
+	r.dumpFeatureCoverage()
+	if _, err := os.Stat(filepath.Join(dir, "feature_coverage-shiro_fuzz_2.json")); err != nil {
+		t.Fatalf("expected the coverage report under the output dir: %v", err)
+	}
+}
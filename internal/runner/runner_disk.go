@@ -0,0 +1,32 @@
+package runner
+
+import (
+	"shiro/internal/report"
+	"shiro/internal/util"
+)
+
+// reportDiskLow reports whether free space under the report output dir is
+// below plan_replayer.min_free_disk_mb, along with the free space in MB. Only
+// transitions are logged so a long low-disk stretch does not flood the log.
+func (r *Runner) reportDiskLow() (bool, int64) {
+	minMB := r.cfg.PlanReplayer.MinFreeDiskMB
+	if minMB <= 0 {
+		return false, 0
+	}
+	free, err := report.FreeDiskBytes(r.cfg.PlanReplayer.OutputDir)
+	if err != nil {
+		util.Detailf("report disk check failed dir=%s err=%v", r.cfg.PlanReplayer.OutputDir, err)
+		return false, 0
+	}
+	freeMB := int64(free >> 20)
+	low := freeMB < minMB
+	if low != r.reportDiskThrottled {
+		r.reportDiskThrottled = low
+		if low {
+			util.Warnf("report disk low dir=%s free_mb=%d min_free_disk_mb=%d; capturing case metadata only", r.cfg.PlanReplayer.OutputDir, freeMB, minMB)
+		} else {
+			util.Infof("report disk recovered dir=%s free_mb=%d min_free_disk_mb=%d; full case capture resumed", r.cfg.PlanReplayer.OutputDir, freeMB, minMB)
+		}
+	}
+	return low, freeMB
+}
@@ -0,0 +1,23 @@
+package runner
+
+import (
+	"testing"
+
+	"shiro/internal/config"
+)
+
+func TestReportDiskLow(t *testing.T) {
+	r := &Runner{cfg: config.Config{PlanReplayer: config.PlanReplayer{OutputDir: t.TempDir()}}}
+	if low, _ := r.reportDiskLow(); low {
+		t.Fatalf("expected disabled check when min_free_disk_mb=0")
+	}
+	r.cfg.PlanReplayer.MinFreeDiskMB = 1 << 40
+	low, freeMB := r.reportDiskLow()
+	if !low || !r.reportDiskThrottled {
+		t.Fatalf("expected low disk with free_mb=%d", freeMB)
+	}
+	r.cfg.PlanReplayer.MinFreeDiskMB = 1
+	if low, _ := r.reportDiskLow(); low || r.reportDiskThrottled {
+		t.Fatalf("expected disk recovery")
+	}
+}
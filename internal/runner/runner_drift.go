@@ -0,0 +1,199 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"shiro/internal/config"
+	"shiro/internal/db"
+	"shiro/internal/oracle"
+	"shiro/internal/util"
+)
+
+const (
+	driftBugHint     = "tidb:metadata_drift"
+	driftIndexPrefix = "shiro_drift_"
+	driftFlushSQL    = "ADMIN FLUSH INSTANCE PLAN_CACHE"
+)
+
+// driftInjection is one metadata change planned for a pair oracle run. The
+// statement is built when the hook fires, because add_index needs the first
+// comparison query to pick a column that query does not name.
+type driftInjection struct {
+	kind    string
+	table   string
+	columns []string
+	index   string
+
+	fired   bool
+	sql     string
+	cleanup string
+	err     error
+}
+
+// armDrift plans a drift injection for oracles.drift and installs it as the
+// AfterFirstRead hook of exec. It runs on the worker goroutine, since it
+// reads r.state and r.gen, and only for sequential runs, since the change
+// would race pipelined ones; it returns nil when nothing was armed.
+func (r *Runner) armDrift(exec *db.DB, oracleName string) *driftInjection {
+	d := r.planDrift(oracleName)
+	if d == nil {
+		return nil
+	}
+	exec.AfterFirstRead = func(ctx context.Context, query string) {
+		r.injectDrift(ctx, exec, d, query)
+	}
+	return d
+}
+
+func (r *Runner) planDrift(oracleName string) *driftInjection {
+	cfg := r.cfg.Oracles.Drift
+	if !cfg.Enabled || len(cfg.Kinds) == 0 || r.gen == nil || r.state == nil {
+		return nil
+	}
+	if _, ok := snapshotPairOracles[oracleName]; !ok {
+		return nil
+	}
+	if !util.Chance(r.gen.Rand, cfg.Probability) {
+		return nil
+	}
+	d := &driftInjection{kind: cfg.Kinds[r.gen.Rand.Intn(len(cfg.Kinds))]}
+	if d.kind == config.DriftPlanCacheFlush {
+		return d
+	}
+	tables := r.state.BaseTables()
+	if len(tables) == 0 {
+		return nil
+	}
+	tbl := tables[r.gen.Rand.Intn(len(tables))]
+	d.table = tbl.Name
+	if d.kind == config.DriftAddIndex {
+		for _, col := range tbl.Columns {
+			if !col.HasIndex {
+				d.columns = append(d.columns, col.Name)
+			}
+		}
+		r.gen.Rand.Shuffle(len(d.columns), func(i, j int) {
+			d.columns[i], d.columns[j] = d.columns[j], d.columns[i]
+		})
+		r.driftSeq++
+		d.index = fmt.Sprintf("%s%d", driftIndexPrefix, r.driftSeq)
+	}
+	return d
+}
+
+// statements returns the drift statement for the first comparison query and
+// the statement that undoes it, if any. add_index falls back to analyze when
+// the query names every unindexed column of the table.
+func (d *driftInjection) statements(query string) (string, string) {
+	switch d.kind {
+	case config.DriftPlanCacheFlush:
+		return driftFlushSQL, ""
+	case config.DriftAddIndex:
+		idents := queryIdentifiers(query)
+		for _, col := range d.columns {
+			if _, ok := idents[strings.ToLower(col)]; ok {
+				continue
+			}
+			table, index := quoteIdent(d.table), quoteIdent(d.index)
+			return fmt.Sprintf("ALTER TABLE %s ADD INDEX %s (%s)", table, index, quoteIdent(col)),
+				fmt.Sprintf("ALTER TABLE %s DROP INDEX %s", table, index)
+		}
+		d.kind = config.DriftAnalyze
+	}
+	return "ANALYZE TABLE " + quoteIdent(d.table), ""
+}
+
+// queryIdentifiers returns the lowercased identifier tokens of query, with
+// backquoted names unquoted, so a column c1 is not mistaken for c10.
+// Keywords and words inside string literals are included, which at worst
+// skips a column that was free.
+func queryIdentifiers(query string) map[string]struct{} {
+	out := map[string]struct{}{}
+	isIdent := func(c byte) bool {
+		return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+	}
+	for i := 0; i < len(query); {
+		switch c := query[i]; {
+		case c == '`':
+			var name strings.Builder
+			for i++; i < len(query); i++ {
+				if query[i] == '`' {
+					if i+1 < len(query) && query[i+1] == '`' {
+						name.WriteByte('`')
+						i++
+						continue
+					}
+					i++
+					break
+				}
+				name.WriteByte(query[i])
+			}
+			out[strings.ToLower(name.String())] = struct{}{}
+		case isIdent(c):
+			start := i
+			for i < len(query) && isIdent(query[i]) {
+				i++
+			}
+			out[strings.ToLower(query[start:i])] = struct{}{}
+		default:
+			i++
+		}
+	}
+	return out
+}
+
+// injectDrift runs the drift statement through the raw pool, so it stays out
+// of SQL validity stats and never reads at the pinned snapshot.
+func (r *Runner) injectDrift(ctx context.Context, exec *db.DB, d *driftInjection, query string) {
+	d.fired = true
+	d.sql, d.cleanup = d.statements(query)
+	dctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	if _, d.err = exec.DB.ExecContext(dctx, d.sql); d.err != nil {
+		d.cleanup = ""
+		util.Detailf("drift injection failed kind=%s sql=%s err=%v", d.kind, d.sql, d.err)
+	}
+}
+
+// finishDrift clears the hook, undoes the injection, and records it on the
+// result. Runs whose first comparison query never succeeded are left as is.
+func (r *Runner) finishDrift(ctx context.Context, exec *db.DB, d *driftInjection, result *oracle.Result) {
+	if d == nil {
+		return
+	}
+	exec.AfterFirstRead = nil
+	if !d.fired {
+		return
+	}
+	if d.cleanup != "" {
+		cctx, cancel := r.withTimeout(context.WithoutCancel(ctx))
+		if _, err := exec.DB.ExecContext(cctx, d.cleanup); err != nil {
+			util.Detailf("drift cleanup failed sql=%s err=%v", d.cleanup, err)
+		}
+		cancel()
+	}
+	recordDriftInjection(result, d)
+}
+
+// recordDriftInjection stores the injected change in details.drift_injection
+// and labels mismatches with driftBugHint, so drift cases can be triaged apart
+// from plan bugs that reproduce without it.
+func recordDriftInjection(result *oracle.Result, d *driftInjection) {
+	if result.Details == nil {
+		result.Details = map[string]any{}
+	}
+	entry := map[string]any{"kind": d.kind, "sql": d.sql}
+	if d.err != nil {
+		entry["error"] = d.err.Error()
+	}
+	result.Details["drift_injection"] = entry
+	if result.Metrics == nil {
+		result.Metrics = map[string]int64{}
+	}
+	result.Metrics["drift_"+d.kind+"_total"]++
+	if !result.OK && result.Err == nil && d.err == nil {
+		result.Details["bug_hint"] = driftBugHint
+	}
+}
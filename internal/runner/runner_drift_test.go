@@ -0,0 +1,100 @@
+package runner
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"shiro/internal/config"
+	"shiro/internal/db"
+	"shiro/internal/generator"
+	"shiro/internal/oracle"
+	"shiro/internal/schema"
+)
+
+func driftTestRunner(kind string) *Runner {
+	state := &schema.State{Tables: []schema.Table{{
+		Name: "t0",
+		Columns: []schema.Column{
+			{Name: "id", HasIndex: true},
+			{Name: "c0"},
+			{Name: "c1"},
+		},
+	}}}
+	r := &Runner{gen: &generator.Generator{Rand: rand.New(rand.NewSource(1))}, state: state}
+	r.cfg.Oracles.Drift = config.OracleDriftConfig{Enabled: true, Probability: 100, Kinds: []string{kind}}
+	return r
+}
+
+func TestArmDriftOnlyForPairOracles(t *testing.T) {
+	r := driftTestRunner(config.DriftAnalyze)
+	exec := &db.DB{}
+	if d := r.armDrift(exec, "PQS"); d != nil || exec.AfterFirstRead != nil {
+		t.Fatalf("PQS should not get a drift injection")
+	}
+	r.cfg.Oracles.Drift.Enabled = false
+	if d := r.armDrift(exec, "TLP"); d != nil {
+		t.Fatalf("drift armed while disabled")
+	}
+	r.cfg.Oracles.Drift.Enabled = true
+	d := r.armDrift(exec, "TLP")
+	if d == nil || exec.AfterFirstRead == nil || d.table != "t0" {
+		t.Fatalf("expected an armed analyze injection: %+v", d)
+	}
+	if stmt, cleanup := d.statements("SELECT 1"); stmt != "ANALYZE TABLE `t0`" || cleanup != "" {
+		t.Fatalf("unexpected analyze statements %q %q", stmt, cleanup)
+	}
+}
+
+func TestDriftAddIndexAvoidsQueryColumns(t *testing.T) {
+	r := driftTestRunner(config.DriftAddIndex)
+	d := r.planDrift("NoREC")
+	if d == nil || d.index != "shiro_drift_1" || len(d.columns) != 2 {
+		t.Fatalf("unexpected add_index plan: %+v", d)
+	}
+	stmt, cleanup := d.statements("SELECT COUNT(*) FROM t0 WHERE t0.c0 > 1")
+	if stmt != "ALTER TABLE `t0` ADD INDEX `shiro_drift_1` (`c1`)" || cleanup != "ALTER TABLE `t0` DROP INDEX `shiro_drift_1`" {
+		t.Fatalf("unexpected add_index statements %q %q", stmt, cleanup)
+	}
+	stmt, _ = d.statements("SELECT COUNT(*) FROM t0 WHERE t0.c10 > 1 AND `C0` < 3")
+	if stmt != "ALTER TABLE `t0` ADD INDEX `shiro_drift_1` (`c1`)" {
+		t.Fatalf("c10 must not count as c1, got %q", stmt)
+	}
+	stmt, cleanup = d.statements("SELECT t0.c0, t0.c1 FROM t0")
+	if stmt != "ANALYZE TABLE `t0`" || cleanup != "" || d.kind != config.DriftAnalyze {
+		t.Fatalf("expected analyze fallback, got %q %q kind=%s", stmt, cleanup, d.kind)
+	}
+}
+
+func TestDriftPlanCacheFlushNeedsNoTable(t *testing.T) {
+	r := driftTestRunner(config.DriftPlanCacheFlush)
+	r.state = &schema.State{}
+	d := r.planDrift("DQP")
+	if d == nil {
+		t.Fatalf("expected a plan cache flush without tables")
+	}
+	if stmt, _ := d.statements("SELECT 1"); stmt != driftFlushSQL {
+		t.Fatalf("unexpected flush statement %q", stmt)
+	}
+}
+
+func TestRecordDriftInjection(t *testing.T) {
+	d := &driftInjection{kind: config.DriftAnalyze, sql: "ANALYZE TABLE `t0`"}
+	result := oracle.Result{Oracle: "TLP", OK: false}
+	recordDriftInjection(&result, d)
+	entry, ok := result.Details["drift_injection"].(map[string]any)
+	if !ok || entry["kind"] != "analyze" || !strings.Contains(entry["sql"].(string), "ANALYZE") {
+		t.Fatalf("unexpected drift_injection %v", result.Details)
+	}
+	if result.Details["bug_hint"] != driftBugHint || result.Metrics["drift_analyze_total"] != 1 {
+		t.Fatalf("mismatch should be labeled as drift: %v %v", result.Details, result.Metrics)
+	}
+
+	d.err = errors.New("analyze failed")
+	result = oracle.Result{Oracle: "TLP", OK: false}
+	recordDriftInjection(&result, d)
+	if _, ok := result.Details["bug_hint"]; ok {
+		t.Fatalf("failed injection should not label the mismatch: %v", result.Details)
+	}
+}
@@ -67,6 +67,7 @@ type qpgDump struct {
 	Override       *generator.AdaptiveWeights `json:"override,omitempty"`
 	OverrideTTL    int                        `json:"override_ttl"`
 	LastOverride   string                     `json:"last_override"`
+	Focus          string                     `json:"focus,omitempty"`
 }
 
 type impoDump struct {
@@ -149,6 +150,10 @@ func (r *Runner) snapshotQPG() *qpgDump {
 	r.qpgMu.Lock()
 	defer r.qpgMu.Unlock()
 	plans, shapes, ops, joins, joinOrders, opSigs, seenSQL, seenSQLAdded := r.qpgState.stats()
+	focus := ""
+	if r.qpgState.focus != nil {
+		focus = r.qpgState.focus.summary()
+	}
 	var override *generator.AdaptiveWeights
 	if r.qpgState.override != nil {
 		overrideCopy := *r.qpgState.override
@@ -174,6 +179,7 @@ func (r *Runner) snapshotQPG() *qpgDump {
 		Override:       override,
 		OverrideTTL:    r.qpgState.overrideTTL,
 		LastOverride:   r.qpgState.lastOverride,
+		Focus:          focus,
 	}
 }
 
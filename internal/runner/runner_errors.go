@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"shiro/internal/db"
 	"shiro/internal/oracle"
 	"shiro/internal/util"
 
@@ -12,11 +13,7 @@ import (
 )
 
 func isPanicError(err error) bool {
-	if err == nil {
-		return false
-	}
-	msg := strings.ToLower(err.Error())
-	return strings.Contains(msg, "panic") || strings.Contains(msg, "assert") || strings.Contains(msg, "internal error")
+	return classifyPanic(err).class != ""
 }
 
 func isRuntimeError(err error) bool {
@@ -125,6 +122,8 @@ func classifyInfraIssue(err error) (string, bool) {
 		strings.Contains(msg, "connection refused"),
 		strings.Contains(msg, "connection reset by peer"):
 		return "tikv_connectivity", true
+	case isResourceGroupThrottled(err):
+		return "resource_group_throttled", true
 	default:
 		return "", false
 	}
@@ -302,7 +301,7 @@ func downgradeDQPTimeoutFalsePositive(result *oracle.Result) bool {
 	if !shouldDowngradeDQPTimeout(result.Oracle) {
 		return false
 	}
-	if !isTimeoutError(result.Err) {
+	if !isTimeoutError(result.Err) || isHangResult(*result) {
 		return false
 	}
 	if result.Details == nil {
@@ -322,6 +321,44 @@ func downgradeDQPTimeoutFalsePositive(result *oracle.Result) bool {
 	return true
 }
 
+const (
+	resultTruncatedSkipSuffix = ":result_truncated"
+	resultTruncatedMetric     = "result_truncated_total"
+)
+
+// resultGuard maps the oracle result size settings onto the executor.
+func (r *Runner) resultGuard() db.ResultGuard {
+	return db.ResultGuard{MaxRows: r.cfg.Oracles.ResultMaxRows, MaxBytes: r.cfg.Oracles.ResultMaxBytes}
+}
+
+// downgradeResultTruncated turns a verification stopped by the result size
+// guard into a skip: the oracle never saw the full result, so it is neither a
+// pass nor a bug.
+func downgradeResultTruncated(result *oracle.Result) bool {
+	if result == nil || result.Err == nil || !errors.Is(result.Err, db.ErrResultTruncated) {
+		return false
+	}
+	if result.Details == nil {
+		result.Details = map[string]any{}
+	}
+	reason := errorReasonPrefix(result.Oracle) + resultTruncatedSkipSuffix
+	result.Details["skip_reason"] = reason
+	result.Details["skip_error_reason"] = reason
+	if _, ok := result.Details["skip_error"]; !ok {
+		result.Details["skip_error"] = result.Err.Error()
+	}
+	delete(result.Details, "error_reason")
+	delete(result.Details, "error_code")
+	delete(result.Details, "bug_hint")
+	if result.Metrics == nil {
+		result.Metrics = map[string]int64{}
+	}
+	result.Metrics[resultTruncatedMetric]++
+	result.OK = true
+	result.Err = nil
+	return true
+}
+
 func normalizeErrorReason(reason string) string {
 	return strings.ToLower(strings.TrimSpace(reason))
 }
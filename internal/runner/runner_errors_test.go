@@ -2,9 +2,11 @@ package runner
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 	"testing"
 
+	"shiro/internal/db"
 	"shiro/internal/oracle"
 
 	"github.com/go-sql-driver/mysql"
@@ -327,6 +329,36 @@ func TestDowngradeDQPTimeoutFalsePositiveByMySQLErrorCode(t *testing.T) {
 	}
 }
 
+func TestDowngradeResultTruncated(t *testing.T) {
+	result := oracle.Result{
+		Oracle:  "TLP",
+		Err:     fmt.Errorf("%w: more than 10 rows", db.ErrResultTruncated),
+		Details: map[string]any{"error_reason": "tlp:base_signature_error"},
+	}
+	if !downgradeResultTruncated(&result) {
+		t.Fatalf("expected truncation downgrade to apply")
+	}
+	if !result.OK || result.Err != nil {
+		t.Fatalf("expected downgraded result to be OK without error: %+v", result)
+	}
+	if skip, _ := result.Details["skip_reason"].(string); skip != "tlp:result_truncated" {
+		t.Fatalf("unexpected skip_reason: %s", skip)
+	}
+	if _, ok := result.Details["error_reason"]; ok {
+		t.Fatalf("error_reason should be removed")
+	}
+	if result.Metrics[resultTruncatedMetric] != 1 {
+		t.Fatalf("unexpected metrics: %v", result.Metrics)
+	}
+	if reward := oracleBanditImmediateReward(result, "tlp:result_truncated"); reward != 0 {
+		t.Fatalf("truncated skip reward=%v want=0", reward)
+	}
+	other := oracle.Result{Oracle: "TLP", Err: errors.New("boom")}
+	if downgradeResultTruncated(&other) {
+		t.Fatalf("unexpected downgrade for unrelated error")
+	}
+}
+
 func TestDowngradeDQPTimeoutFalsePositiveKeepsOtherOracles(t *testing.T) {
 	result := oracle.Result{
 		Oracle: "NoREC",
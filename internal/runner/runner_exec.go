@@ -39,6 +39,8 @@ func (r *Runner) execSQL(ctx context.Context, sql string) error {
 	_, err = conn.ExecContext(qctx, sql)
 	if err == nil {
 		r.recordInsert(sql)
+		r.notePlanStabilityStatement(sql)
+		r.noteSchemaChange(sql)
 		return nil
 	}
 	return err
@@ -60,3 +62,31 @@ func (r *Runner) withTimeoutForOracle(ctx context.Context, oracleName string) (c
 	}
 	return context.WithTimeout(ctx, time.Duration(timeoutMs)*time.Millisecond)
 }
+
+func (r *Runner) connectionID(ctx context.Context, conn *sql.Conn) (int64, error) {
+	row := conn.QueryRowContext(ctx, "SELECT CONNECTION_ID()")
+	var v int64
+	if err := row.Scan(&v); err != nil {
+		return 0, err
+	}
+	return v, nil
+}
+
+// noteSchemaChange moves the schema state to a new epoch after DDL, so oracle
+// caches keyed by the epoch are rebuilt. The runner updates the state right
+// after the statement, before any oracle runs.
+func (r *Runner) noteSchemaChange(sqlText string) {
+	if r.state != nil && isSchemaChange(sqlText) {
+		r.state.BumpEpoch()
+	}
+}
+
+func isSchemaChange(sqlText string) bool {
+	upper := strings.ToUpper(strings.TrimSpace(sqlText))
+	for _, prefix := range []string{"CREATE ", "ALTER ", "DROP ", "TRUNCATE ", "RENAME "} {
+		if strings.HasPrefix(upper, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,244 @@
+package runner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"shiro/internal/report"
+)
+
+// explainDiffPairs lists the (expected, actual) EXPLAIN detail keys that are
+// diffed, in order of preference. NoREC stores its plans as unoptimized and
+// optimized.
+var explainDiffPairs = [][2]string{
+	{"expected_explain", "actual_explain"},
+	{"unoptimized_explain", "optimized_explain"},
+}
+
+// explainNode is one operator of a plan, from a tab-separated EXPLAIN row or
+// a canonical plan node.
+type explainNode struct {
+	op    string
+	task  string
+	table string
+	index string
+}
+
+// explainDiff is the structural difference between two plans.
+type explainDiff struct {
+	added     []string
+	removed   []string
+	access    []string
+	joinOrder string
+}
+
+// annotateExplainDiff stores a compact diff of the expected and actual plans
+// in details["explain_diff"], so a reader does not have to compare the raw
+// EXPLAIN blobs. It prefers the canonical case plans and falls back to the
+// EXPLAIN texts the oracle recorded. Nothing is stored when either plan is
+// missing or the plans have the same structure.
+func annotateExplainDiff(details map[string]any, plans *report.CasePlans) {
+	if details == nil {
+		return
+	}
+	if left, right, ok := casePlanNodes(plans); ok {
+		if diff := diffExplainNodes(left, right).String(); diff != "" {
+			details["explain_diff"] = diff
+		}
+		return
+	}
+	for _, pair := range explainDiffPairs {
+		expected := detailString(details, pair[0])
+		actual := detailString(details, pair[1])
+		if expected == "" || actual == "" {
+			continue
+		}
+		if diff := diffExplainNodes(parseExplainNodes(expected), parseExplainNodes(actual)).String(); diff != "" {
+			details["explain_diff"] = diff
+		}
+		return
+	}
+}
+
+// casePlanNodes flattens both canonical plans in pre-order, the order EXPLAIN
+// prints rows. It fails unless both sides were explained.
+func casePlanNodes(plans *report.CasePlans) (left []explainNode, right []explainNode, ok bool) {
+	if plans == nil || plans.Expected == nil || plans.Actual == nil ||
+		len(plans.Expected.Roots) == 0 || len(plans.Actual.Roots) == 0 {
+		return nil, nil, false
+	}
+	return flattenPlanNodes(nil, plans.Expected.Roots), flattenPlanNodes(nil, plans.Actual.Roots), true
+}
+
+func flattenPlanNodes(out []explainNode, nodes []report.PlanNode) []explainNode {
+	for _, node := range nodes {
+		flat := explainNode{op: node.Operator, task: node.TaskType}
+		flat.table, flat.index = parseAccessObject(node.AccessObject)
+		out = append(out, flat)
+		out = flattenPlanNodes(out, node.Children)
+	}
+	return out
+}
+
+func diffExplainNodes(left []explainNode, right []explainNode) explainDiff {
+	var diff explainDiff
+	diff.added, diff.removed = diffOperatorCounts(left, right)
+	leftAccess, leftOrder := explainAccessPaths(left)
+	rightAccess, rightOrder := explainAccessPaths(right)
+	tables := make([]string, 0, len(leftAccess)+len(rightAccess))
+	for table := range leftAccess {
+		tables = append(tables, table)
+	}
+	for table := range rightAccess {
+		if _, ok := leftAccess[table]; !ok {
+			tables = append(tables, table)
+		}
+	}
+	sort.Strings(tables)
+	for _, table := range tables {
+		before, after := leftAccess[table], rightAccess[table]
+		if before == after {
+			continue
+		}
+		diff.access = append(diff.access, fmt.Sprintf("%s: %s -> %s", table, orNone(before), orNone(after)))
+	}
+	if leftOrder != rightOrder {
+		diff.joinOrder = fmt.Sprintf("%s -> %s", orNone(leftOrder), orNone(rightOrder))
+	}
+	return diff
+}
+
+// String renders the diff on one line, for example
+// "ops +HashJoin -IndexJoin; access t1: IndexRangeScan(idx_c0)@cop[tikv] -> TableFullScan@cop[tikv]; join order t0,t1 -> t1,t0".
+func (d explainDiff) String() string {
+	var parts []string
+	if len(d.added) > 0 || len(d.removed) > 0 {
+		ops := make([]string, 0, len(d.added)+len(d.removed))
+		for _, op := range d.added {
+			ops = append(ops, "+"+op)
+		}
+		for _, op := range d.removed {
+			ops = append(ops, "-"+op)
+		}
+		parts = append(parts, "ops "+strings.Join(ops, " "))
+	}
+	if len(d.access) > 0 {
+		parts = append(parts, "access "+strings.Join(d.access, ", "))
+	}
+	if d.joinOrder != "" {
+		parts = append(parts, "join order "+d.joinOrder)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// parseExplainNodes reads the id, task, and access object columns of an
+// EXPLAIN text as written by the oracles (one row per line, tab-separated).
+func parseExplainNodes(text string) []explainNode {
+	var nodes []explainNode
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		cols := strings.Split(line, "\t")
+		_, op := parsePlanNode(cols[0])
+		if op == "" {
+			continue
+		}
+		node := explainNode{op: op}
+		if len(cols) > 2 {
+			node.task = strings.TrimSpace(cols[2])
+		}
+		if len(cols) > 3 {
+			node.table, node.index = parseAccessObject(cols[3])
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// parseAccessObject extracts the table and index from an access object such
+// as "table:t0, index:idx_c0(c0)".
+func parseAccessObject(text string) (table string, index string) {
+	for _, part := range strings.Split(text, ",") {
+		part = strings.TrimSpace(part)
+		switch {
+		case strings.HasPrefix(part, "table:"):
+			table = strings.TrimPrefix(part, "table:")
+		case strings.HasPrefix(part, "index:"):
+			index = strings.TrimPrefix(part, "index:")
+			if i := strings.IndexByte(index, '('); i >= 0 {
+				index = index[:i]
+			}
+		}
+	}
+	return table, index
+}
+
+// diffOperatorCounts returns the operators that appear more often in right
+// (added) and in left (removed), with a *N suffix for repeats.
+func diffOperatorCounts(left []explainNode, right []explainNode) (added []string, removed []string) {
+	counts := make(map[string]int)
+	for _, node := range left {
+		counts[node.op]--
+	}
+	for _, node := range right {
+		counts[node.op]++
+	}
+	ops := make([]string, 0, len(counts))
+	for op := range counts {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+	for _, op := range ops {
+		n := counts[op]
+		label := op
+		if n > 1 || n < -1 {
+			label = fmt.Sprintf("%s*%d", op, max(n, -n))
+		}
+		switch {
+		case n > 0:
+			added = append(added, label)
+		case n < 0:
+			removed = append(removed, label)
+		}
+	}
+	return added, removed
+}
+
+// explainAccessPaths maps each table to how it is read (scan operator, index,
+// and task) and returns the tables in order of first appearance, which is
+// the join order.
+func explainAccessPaths(nodes []explainNode) (map[string]string, string) {
+	paths := make(map[string][]string)
+	var order []string
+	for _, node := range nodes {
+		if node.table == "" {
+			continue
+		}
+		path := node.op
+		if node.index != "" {
+			path += "(" + node.index + ")"
+		}
+		if node.task != "" {
+			path += "@" + node.task
+		}
+		if _, seen := paths[node.table]; !seen {
+			order = append(order, node.table)
+		}
+		paths[node.table] = append(paths[node.table], path)
+	}
+	out := make(map[string]string, len(paths))
+	for table, list := range paths {
+		sort.Strings(list)
+		out[table] = strings.Join(list, "+")
+	}
+	return out, strings.Join(order, ",")
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
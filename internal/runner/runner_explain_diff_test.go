@@ -0,0 +1,79 @@
+package runner
+
+import (
+	"testing"
+
+	"shiro/internal/report"
+)
+
+func TestAnnotateExplainDiff(t *testing.T) {
+	expected := "HashJoin_8\t12.50\troot\t\tinner join, equal:[eq(t0.c0, t1.c0)]\n" +
+		"├─TableReader_11(Build)\t10.00\troot\t\tdata:Selection_10\n" +
+		"│ └─TableFullScan_9\t10000.00\tcop[tikv]\ttable:t1\tkeep order:false\n" +
+		"└─IndexLookUp_14(Probe)\t10.00\troot\t\t\n" +
+		"  ├─IndexRangeScan_12(Build)\t10.00\tcop[tikv]\ttable:t0, index:idx_c0(c0)\trange:[1,1]\n" +
+		"  └─TableRowIDScan_13(Probe)\t10.00\tcop[tikv]\ttable:t0\tkeep order:false\n"
+	actual := "IndexJoin_9\t12.50\troot\t\tinner join, inner:TableReader_8\n" +
+		"├─TableReader_20(Build)\t10.00\troot\t\tdata:TableFullScan_19\n" +
+		"│ └─TableFullScan_19\t10000.00\tcop[tikv]\ttable:t0\tkeep order:false\n" +
+		"└─TableReader_8(Probe)\t10.00\troot\t\tdata:TableRangeScan_7\n" +
+		"  └─TableRangeScan_7\t10.00\tcop[tikv]\ttable:t1\trange: decided by [t0.c0]\n"
+	details := map[string]any{"expected_explain": expected, "actual_explain": actual}
+	annotateExplainDiff(details, nil)
+	want := "ops +IndexJoin +TableRangeScan +TableReader -HashJoin -IndexLookUp -IndexRangeScan -TableRowIDScan; " +
+		"access t0: IndexRangeScan(idx_c0)@cop[tikv]+TableRowIDScan@cop[tikv] -> TableFullScan@cop[tikv], " +
+		"t1: TableFullScan@cop[tikv] -> TableRangeScan@cop[tikv]; join order t1,t0 -> t0,t1"
+	if got := details["explain_diff"]; got != want {
+		t.Fatalf("explain_diff=%q\nwant=%q", got, want)
+	}
+
+	same := map[string]any{"unoptimized_explain": expected, "optimized_explain": expected}
+	annotateExplainDiff(same, nil)
+	if _, ok := same["explain_diff"]; ok {
+		t.Fatalf("identical plans should not get a diff: %v", same["explain_diff"])
+	}
+
+	norec := map[string]any{"unoptimized_explain": expected, "optimized_explain": actual}
+	annotateExplainDiff(norec, nil)
+	if norec["explain_diff"] != want {
+		t.Fatalf("NoREC explains should be diffed: %v", norec["explain_diff"])
+	}
+}
+
+func TestAnnotateExplainDiffPrefersCasePlans(t *testing.T) {
+	plans := &report.CasePlans{
+		Expected: &report.CasePlan{Roots: []report.PlanNode{{
+			Operator: "TableReader",
+			TaskType: "root",
+			Children: []report.PlanNode{{Operator: "TableFullScan", TaskType: "cop[tikv]", AccessObject: "table:t0"}},
+		}}},
+		Actual: &report.CasePlan{Roots: []report.PlanNode{{
+			Operator: "IndexReader",
+			TaskType: "root",
+			Children: []report.PlanNode{{Operator: "IndexFullScan", TaskType: "cop[tikv]", AccessObject: "table:t0, index:idx_c0(c0)"}},
+		}}},
+	}
+	details := map[string]any{"expected_explain": "HashJoin_8\t1\troot\t\t", "actual_explain": "HashJoin_8\t1\troot\t\t"}
+	annotateExplainDiff(details, plans)
+	want := "ops +IndexFullScan +IndexReader -TableFullScan -TableReader; " +
+		"access t0: TableFullScan@cop[tikv] -> IndexFullScan(idx_c0)@cop[tikv]"
+	if got := details["explain_diff"]; got != want {
+		t.Fatalf("explain_diff=%q\nwant=%q", got, want)
+	}
+
+	plans.Actual = &report.CasePlan{Error: "explain failed"}
+	delete(details, "explain_diff")
+	annotateExplainDiff(details, plans)
+	if _, ok := details["explain_diff"]; ok {
+		t.Fatalf("identical EXPLAIN texts should be used when a case plan is missing: %v", details["explain_diff"])
+	}
+}
+
+func TestDiffOperatorCountsRepeats(t *testing.T) {
+	left := []explainNode{{op: "Selection"}}
+	right := []explainNode{{op: "Selection"}, {op: "Selection"}, {op: "Selection"}, {op: "Projection"}}
+	added, removed := diffOperatorCounts(left, right)
+	if len(removed) != 0 || len(added) != 2 || added[0] != "Projection" || added[1] != "Selection*2" {
+		t.Fatalf("added=%v removed=%v", added, removed)
+	}
+}
@@ -0,0 +1,67 @@
+package runner
+
+import (
+	"strings"
+
+	"shiro/internal/oracle"
+)
+
+// fixControlSummary is the per-ID section of the DQP fix-control campaign in
+// the run summary. Runs counts the ON/OFF pairs tried, PlanChanged the pairs
+// whose plans differed, and Mismatches the pairs whose results differed.
+type fixControlSummary struct {
+	Runs        int64 `json:"runs"`
+	PlanChanged int64 `json:"plan_changed"`
+	Mismatches  int64 `json:"mismatches"`
+	Errors      int64 `json:"errors"`
+}
+
+// recordFixControlMetricLocked adds a DQPFixControl*MetricPrefix counter to
+// the per-ID stats and reports whether key was one. The caller holds statsMu.
+func (r *Runner) recordFixControlMetricLocked(key string, v int64) bool {
+	prefixes := []string{
+		oracle.DQPFixControlRunMetricPrefix,
+		oracle.DQPFixControlPlanChangedMetricPrefix,
+		oracle.DQPFixControlMismatchMetricPrefix,
+		oracle.DQPFixControlErrorMetricPrefix,
+	}
+	for _, prefix := range prefixes {
+		id, ok := strings.CutPrefix(key, prefix)
+		if !ok {
+			continue
+		}
+		if r.dqpFixControls == nil {
+			r.dqpFixControls = make(map[string]*fixControlSummary)
+		}
+		stat := r.dqpFixControls[id]
+		if stat == nil {
+			stat = &fixControlSummary{}
+			r.dqpFixControls[id] = stat
+		}
+		switch prefix {
+		case oracle.DQPFixControlRunMetricPrefix:
+			stat.Runs += v
+		case oracle.DQPFixControlPlanChangedMetricPrefix:
+			stat.PlanChanged += v
+		case oracle.DQPFixControlMismatchMetricPrefix:
+			stat.Mismatches += v
+		default:
+			stat.Errors += v
+		}
+		return true
+	}
+	return false
+}
+
+// fixControlSummaryLocked copies the per-ID fix-control stats. The caller
+// holds statsMu.
+func (r *Runner) fixControlSummaryLocked() map[string]fixControlSummary {
+	if len(r.dqpFixControls) == 0 {
+		return nil
+	}
+	out := make(map[string]fixControlSummary, len(r.dqpFixControls))
+	for id, stat := range r.dqpFixControls {
+		out[id] = *stat
+	}
+	return out
+}
@@ -0,0 +1,236 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"shiro/internal/oracle"
+	"shiro/internal/report"
+	"shiro/internal/sqlstep"
+	"shiro/internal/util"
+)
+
+const (
+	hangStatusCompleted = "completed"
+	hangStatusHang      = "hang"
+	hangStatusFailed    = "failed"
+
+	hangExplainFile       = "hang_explain.txt"
+	hangGoroutineFile     = "goroutines.txt"
+	hangGoroutinePath     = "/debug/pprof/goroutine?debug=2"
+	hangGoroutineMaxBytes = 8 << 20
+	hangGoroutineTimeout  = 10 * time.Second
+)
+
+// escalateTimeout is the timeout escalation ladder. When an oracle statement
+// hits the statement timeout, the read-only failing statement is re-run once
+// on a quarantined connection with hang.timeout_seconds. A statement that
+// completes is only slow and keeps its timeout classification; one that still
+// does not finish turns the result into an <oracle>:hang case.
+func (r *Runner) escalateTimeout(ctx context.Context, result *oracle.Result) {
+	if !r.cfg.Hang.Enabled || result == nil || !isTimeoutError(result.Err) || ctx.Err() != nil {
+		return
+	}
+	idx := hangCandidateStep(*result)
+	if idx < 0 || !r.reserveHangCheck() {
+		return
+	}
+	sqlText := result.Steps[idx].SQL
+	timeout := time.Duration(r.cfg.Hang.TimeoutSeconds) * time.Second
+	status, elapsed, err := r.rerunWithTimeout(ctx, sqlText, timeout)
+	if result.Details == nil {
+		result.Details = map[string]any{}
+	}
+	result.Details["hang_status"] = status
+	result.Details["hang_sql"] = sqlText
+	result.Details["hang_timeout_ms"] = timeout.Milliseconds()
+	result.Details["hang_elapsed_ms"] = elapsed.Milliseconds()
+	switch status {
+	case hangStatusFailed:
+		if err != nil {
+			result.Details["hang_error"] = err.Error()
+		}
+	case hangStatusHang:
+		markHangResult(result, idx)
+		util.Warnf("hang detected oracle=%s timeout=%s sql=%s", result.Oracle, timeout, sqlText)
+	}
+}
+
+// markHangResult turns a timed-out result into a reportable hang case. The
+// hanging step becomes the replay step and replay hints for the original
+// mismatch check are dropped, so plan replayer dumps and minimization replay
+// the hanging statement instead.
+func markHangResult(result *oracle.Result, idx int) {
+	steps := slices.Clone(result.Steps)
+	for i := range steps {
+		if steps[i].Role == sqlstep.RoleReplay {
+			steps[i].Role = ""
+		}
+	}
+	steps[idx].Role = sqlstep.RoleReplay
+	result.Steps = steps
+	prefix := errorReasonPrefix(result.Oracle)
+	result.Details["error_reason"] = prefix + ":hang"
+	result.Details["bug_hint"] = "tidb:hang"
+	result.Details["replay_sql"] = steps[idx].SQL
+	for _, key := range []string{"skip_reason", "skip_error_reason", "skip_error", "replay_kind", "replay_expected_sql", "replay_actual_sql", "replay_set_var"} {
+		delete(result.Details, key)
+	}
+	result.OK = false
+}
+
+func isHangResult(result oracle.Result) bool {
+	status, _ := result.Details["hang_status"].(string)
+	return status == hangStatusHang
+}
+
+// reserveHangCheck consumes one escalation from the per-run budget.
+func (r *Runner) reserveHangCheck() bool {
+	r.statsMu.Lock()
+	defer r.statsMu.Unlock()
+	if r.cfg.Hang.MaxChecks > 0 && r.hangChecks >= int64(r.cfg.Hang.MaxChecks) {
+		return false
+	}
+	r.hangChecks++
+	return true
+}
+
+// hangCandidateStep returns the index of the step that timed out: the failing
+// query, else the replay statement, or -1 when there is none. Only SELECT/WITH
+// statements are re-run because a retried write would change the data.
+func hangCandidateStep(result oracle.Result) int {
+	idx := -1
+	for i, step := range result.Steps {
+		if step.Role == sqlstep.RoleFailing && step.Kind == sqlstep.KindQuery {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		idx = replayStepIndex(result.Steps)
+	}
+	if idx < 0 {
+		return -1
+	}
+	upper := strings.ToUpper(result.Steps[idx].SQL)
+	if !strings.HasPrefix(upper, "SELECT") && !strings.HasPrefix(upper, "WITH") {
+		return -1
+	}
+	return idx
+}
+
+// rerunWithTimeout runs sqlText on a fresh connection and drains its rows. On
+// timeout the server-side statement is killed so it does not keep running.
+func (r *Runner) rerunWithTimeout(ctx context.Context, sqlText string, timeout time.Duration) (string, time.Duration, error) {
+	setupCtx, cancelSetup := r.withTimeout(ctx)
+	defer cancelSetup()
+	conn, err := r.exec.Conn(setupCtx)
+	if err != nil {
+		return hangStatusFailed, 0, err
+	}
+	defer util.CloseWithErr(conn, "hang check conn")
+	if err := r.prepareConn(setupCtx, conn, r.cfg.Database); err != nil {
+		return hangStatusFailed, 0, err
+	}
+	connID, idErr := r.connectionID(setupCtx, conn)
+
+	qctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	rows, err := conn.QueryContext(qctx, sqlText)
+	if err == nil {
+		for rows.Next() {
+		}
+		err = rows.Err()
+		util.CloseWithErr(rows, "hang check rows")
+	}
+	elapsed := time.Since(start)
+	if err == nil {
+		return hangStatusCompleted, elapsed, nil
+	}
+	if errors.Is(qctx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		if idErr == nil {
+			r.killQuery(ctx, connID)
+		}
+		return hangStatusHang, elapsed, err
+	}
+	return hangStatusFailed, elapsed, err
+}
+
+func (r *Runner) killQuery(ctx context.Context, connID int64) {
+	kctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	if _, err := r.exec.ExecContext(kctx, fmt.Sprintf("KILL TIDB QUERY %d", connID)); err != nil {
+		util.Warnf("kill hung query failed conn_id=%d err=%v", connID, err)
+	}
+}
+
+// captureHangDiagnostics stores EXPLAIN output and a tidb-server goroutine
+// dump in a hang case. Both are best effort.
+func (r *Runner) captureHangDiagnostics(ctx context.Context, caseData report.Case, details map[string]any) {
+	sqlText := detailString(details, "hang_sql")
+	if sqlText == "" {
+		return
+	}
+	if cols, rows, err := r.explainRows(ctx, sqlText); err != nil {
+		details["hang_explain_error"] = err.Error()
+	} else if err := r.reporter.WriteText(caseData, hangExplainFile, planText(cols, rows)); err == nil {
+		details["hang_explain_file"] = hangExplainFile
+	}
+	dumpURL := hangGoroutineURL(r.cfg.Hang.GoroutineURL, r.cfg.PlanReplayer.DownloadURLTemplate)
+	if dumpURL == "" {
+		return
+	}
+	details["hang_goroutine_url"] = dumpURL
+	gctx, cancel := context.WithTimeout(ctx, hangGoroutineTimeout)
+	defer cancel()
+	dump, err := fetchGoroutineDump(gctx, dumpURL)
+	if err != nil {
+		details["hang_goroutine_error"] = err.Error()
+		return
+	}
+	if err := r.reporter.WriteText(caseData, hangGoroutineFile, dump); err == nil {
+		details["hang_goroutine_file"] = hangGoroutineFile
+	}
+}
+
+// hangGoroutineURL returns the configured goroutine dump URL, or derives one
+// from the status address in the plan replayer download template.
+func hangGoroutineURL(configured string, downloadTemplate string) string {
+	if configured != "" {
+		return configured
+	}
+	// The template holds a %s verb, which is not a valid URL escape.
+	parsed, err := url.Parse(strings.ReplaceAll(strings.TrimSpace(downloadTemplate), "%s", "x"))
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return ""
+	}
+	return parsed.Scheme + "://" + parsed.Host + hangGoroutinePath
+}
+
+func fetchGoroutineDump(ctx context.Context, dumpURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, dumpURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer util.CloseWithErr(resp.Body, "goroutine dump response")
+	if resp.StatusCode/100 != 2 {
+		return "", fmt.Errorf("status %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, hangGoroutineMaxBytes))
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
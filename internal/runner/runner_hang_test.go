@@ -0,0 +1,146 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"shiro/internal/oracle"
+	"shiro/internal/sqlstep"
+)
+
+func TestHangCandidateStep(t *testing.T) {
+	tests := []struct {
+		name   string
+		result oracle.Result
+		want   int
+	}{
+		{
+			name: "replay step",
+			result: oracle.Result{Steps: []sqlstep.Step{
+				sqlstep.New(sqlstep.KindQuery, sqlstep.RoleExpected, "SELECT 1"),
+				sqlstep.New(sqlstep.KindQuery, sqlstep.RoleActual, "SELECT 2"),
+			}},
+			want: 0,
+		},
+		{
+			name: "failing step",
+			result: oracle.Result{Steps: []sqlstep.Step{
+				sqlstep.New(sqlstep.KindQuery, sqlstep.RoleExpected, "SELECT 1"),
+				sqlstep.New(sqlstep.KindQuery, sqlstep.RoleFailing, "WITH c AS (SELECT 1) SELECT * FROM c"),
+			}},
+			want: 1,
+		},
+		{
+			name: "write is not retried",
+			result: oracle.Result{Steps: []sqlstep.Step{
+				sqlstep.New(sqlstep.KindQuery, sqlstep.RoleFailing, "UPDATE t0 SET c0 = 1"),
+			}},
+			want: -1,
+		},
+		{
+			name:   "untagged steps",
+			result: oracle.Result{Steps: []sqlstep.Step{sqlstep.New(sqlstep.KindQuery, "", "SELECT 1")}},
+			want:   -1,
+		},
+	}
+	for _, tt := range tests {
+		if got := hangCandidateStep(tt.result); got != tt.want {
+			t.Fatalf("%s: hangCandidateStep()=%d want=%d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMarkHangResultSurvivesDQPTimeoutDowngrade(t *testing.T) {
+	result := oracle.Result{
+		Oracle: "DQP",
+		OK:     true,
+		Err:    context.DeadlineExceeded,
+		Steps: []sqlstep.Step{
+			sqlstep.New(sqlstep.KindQuery, sqlstep.RoleReplay, "SELECT 0"),
+			sqlstep.New(sqlstep.KindQuery, sqlstep.RoleExpected, "SELECT 1"),
+		},
+		Details: map[string]any{
+			"hang_status":         hangStatusHang,
+			"skip_reason":         "dqp:timeout",
+			"replay_kind":         "signature",
+			"replay_expected_sql": "SELECT 1",
+		},
+	}
+	markHangResult(&result, 1)
+	if result.OK || result.Details["error_reason"] != "dqp:hang" || result.Details["replay_sql"] != "SELECT 1" {
+		t.Fatalf("unexpected hang result: ok=%v details=%v", result.OK, result.Details)
+	}
+	if got := pickReplaySQL(result.Steps); got != "SELECT 1" {
+		t.Fatalf("hanging step should become the replay step, got %q", got)
+	}
+	if _, ok := result.Details["replay_kind"]; ok {
+		t.Fatalf("expected replay_kind to be dropped")
+	}
+	if downgradeDQPTimeoutFalsePositive(&result) {
+		t.Fatalf("hang result must not be downgraded to a dqp timeout skip")
+	}
+	annotateResultForReporting(&result)
+	if got := effectiveResultErrorReason(result); got != "dqp:hang" {
+		t.Fatalf("effective error reason=%q want=dqp:hang", got)
+	}
+}
+
+func TestReserveHangCheck(t *testing.T) {
+	r := &Runner{}
+	r.cfg.Hang.MaxChecks = 2
+	for i := 0; i < 2; i++ {
+		if !r.reserveHangCheck() {
+			t.Fatalf("check %d should be within budget", i)
+		}
+	}
+	if r.reserveHangCheck() {
+		t.Fatalf("expected budget to be exhausted")
+	}
+	r.cfg.Hang.MaxChecks = 0
+	if !r.reserveHangCheck() {
+		t.Fatalf("max_checks=0 should be unlimited")
+	}
+}
+
+func TestHangGoroutineURL(t *testing.T) {
+	if got := hangGoroutineURL("", "http://127.0.0.1:10080/plan_replayer/dump/%s.zip"); got != "http://127.0.0.1:10080/debug/pprof/goroutine?debug=2" {
+		t.Fatalf("derived url=%q", got)
+	}
+	if got := hangGoroutineURL("http://tidb:10080/debug/pprof/goroutine", "http://x/%s"); got != "http://tidb:10080/debug/pprof/goroutine" {
+		t.Fatalf("configured url=%q", got)
+	}
+	if got := hangGoroutineURL("", ""); got != "" {
+		t.Fatalf("expected empty url, got %q", got)
+	}
+}
+
+func TestFetchGoroutineDump(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("debug") != "2" {
+			http.Error(w, "missing debug", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte("goroutine 1 [running]:\nmain.main()\n"))
+	}))
+	defer server.Close()
+	dump, err := fetchGoroutineDump(context.Background(), server.URL+hangGoroutinePath)
+	if err != nil || dump != "goroutine 1 [running]:\nmain.main()\n" {
+		t.Fatalf("fetchGoroutineDump()=%q err=%v", dump, err)
+	}
+	if _, err := fetchGoroutineDump(context.Background(), server.URL); err == nil {
+		t.Fatalf("expected non-2xx status error")
+	}
+}
+
+func TestEscalateTimeoutSkipsNonTimeout(t *testing.T) {
+	r := &Runner{}
+	r.cfg.Hang.Enabled = true
+	result := oracle.Result{Err: errors.New("Error 1064: syntax"), Steps: []sqlstep.Step{sqlstep.New(sqlstep.KindQuery, sqlstep.RoleFailing, "SELECT 1")}}
+	r.escalateTimeout(context.Background(), &result)
+	if _, ok := result.Details["hang_status"]; ok || r.hangChecks != 0 {
+		t.Fatalf("non-timeout error must not be escalated: %v", result.Details)
+	}
+}
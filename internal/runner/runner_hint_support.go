@@ -0,0 +1,49 @@
+package runner
+
+import (
+	"context"
+	"strings"
+
+	"shiro/internal/oracle"
+	"shiro/internal/util"
+)
+
+// probeHintSupport asks the server once which DQP hints and SET_VARs it
+// ignores, so DQP does not spend variants on hints that always fall back
+// with a warning. The results stay on this runner's DQP, so other targets
+// in the same process keep their own candidates. Hints rejected before a
+// probe error stay pruned.
+func (r *Runner) probeHintSupport(ctx context.Context) {
+	if !r.cfg.Oracles.DQPHintProbe || r.cfg.Weights.Oracles.DQP <= 0 {
+		return
+	}
+	dqp, ok := r.dqpOracle()
+	if !ok {
+		return
+	}
+	qctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	report, err := dqp.ProbeHintSupport(qctx, r.exec, r.cfg)
+	r.unsupportedHints = report.UnsupportedHints()
+	if err != nil {
+		util.Warnf("dqp hint probe failed probed=%d err=%v", report.Probed, err)
+	}
+	if len(r.unsupportedHints) == 0 {
+		util.Infof("dqp hint probe probed=%d unsupported=none", report.Probed)
+		return
+	}
+	util.Infof("dqp hint probe probed=%d unsupported=%s", report.Probed, strings.Join(r.unsupportedHints, ", "))
+	for _, hint := range r.unsupportedHints {
+		util.Detailf("dqp hint unsupported hint=%s warning=%s", hint, report.Unsupported[hint])
+	}
+}
+
+// dqpOracle returns the DQP registered on this runner.
+func (r *Runner) dqpOracle() (oracle.DQP, bool) {
+	for _, o := range r.oracles {
+		if dqp, ok := o.(oracle.DQP); ok {
+			return dqp, true
+		}
+	}
+	return oracle.DQP{}, false
+}
@@ -0,0 +1,28 @@
+package runner
+
+import (
+	"context"
+	"testing"
+)
+
+func TestProbeHintSupportSkipsWithoutDQP(t *testing.T) {
+	r := &Runner{}
+	r.cfg.Oracles.DQPHintProbe = true
+	// Without a DQP weight the probe must not touch the (nil) executor.
+	r.probeHintSupport(context.Background())
+	if r.unsupportedHints != nil {
+		t.Fatalf("unexpected unsupported hints: %v", r.unsupportedHints)
+	}
+	r.cfg.Oracles.DQPHintProbe = false
+	r.cfg.Weights.Oracles.DQP = 3
+	r.probeHintSupport(context.Background())
+	if r.unsupportedHints != nil {
+		t.Fatalf("disabled probe must not record hints: %v", r.unsupportedHints)
+	}
+	r.cfg.Oracles.DQPHintProbe = true
+	// Without a registered DQP there is nothing to prune.
+	r.probeHintSupport(context.Background())
+	if r.unsupportedHints != nil {
+		t.Fatalf("probe without DQP must not record hints: %v", r.unsupportedHints)
+	}
+}
@@ -0,0 +1,234 @@
+package runner
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"shiro/internal/config"
+	"shiro/internal/report"
+	"shiro/internal/util"
+)
+
+const (
+	hookStageRunStart       = "run_start"
+	hookStageDatabaseRotate = "database_rotate"
+	hookStageCaseCaptured   = "case_captured"
+
+	hookOutputMaxBytes  = 1 << 20
+	hookSQLMaxRows      = 1000
+	hookLogPreviewBytes = 512
+	hookShellWaitDelay  = 2 * time.Second
+)
+
+// hookEnv describes where a hook runs; case fields are empty outside case capture.
+type hookEnv struct {
+	stage    string
+	database string
+	caseID   string
+	caseDir  string
+	// finalDir is where the case lives once committed; caseDir is the
+	// staging directory the hook may write to.
+	finalDir string
+	oracle   string
+}
+
+func (e hookEnv) environ() []string {
+	return []string{
+		"SHIRO_HOOK_STAGE=" + e.stage,
+		"SHIRO_DATABASE=" + e.database,
+		"SHIRO_CASE_ID=" + e.caseID,
+		"SHIRO_CASE_DIR=" + e.caseDir,
+		"SHIRO_CASE_FINAL_DIR=" + e.finalDir,
+		"SHIRO_ORACLE=" + e.oracle,
+	}
+}
+
+// runLifecycleHooks runs run_start/database_rotate hooks and logs their output.
+func (r *Runner) runLifecycleHooks(ctx context.Context, stage string, hooks []config.HookConfig) {
+	if len(hooks) == 0 {
+		return
+	}
+	env := hookEnv{stage: stage, database: r.cfg.Database}
+	for _, hook := range hooks {
+		output, err := r.runHook(ctx, hook, env)
+		if err != nil {
+			util.Warnf("hook failed stage=%s name=%s db=%s err=%v output=%s", stage, hook.Name, env.database, err, hookLogPreview(output))
+			continue
+		}
+		util.Infof("hook done stage=%s name=%s db=%s output=%s", stage, hook.Name, env.database, hookLogPreview(output))
+	}
+}
+
+// runCaseHooks runs case_captured hooks, writes each output to hooks/<name>.out
+// in the case directory, and records per-hook status in details["hooks"].
+func (r *Runner) runCaseHooks(ctx context.Context, caseData report.Case, oracleName string, details map[string]any) {
+	hooks := r.cfg.Hooks.CaseCaptured
+	if len(hooks) == 0 {
+		return
+	}
+	env := hookEnv{
+		stage:    hookStageCaseCaptured,
+		database: r.cfg.Database,
+		caseID:   caseData.ID,
+		caseDir:  caseData.Dir,
+		finalDir: r.reporter.CommittedDir(caseData),
+		oracle:   oracleName,
+	}
+	statuses := make(map[string]any, len(hooks))
+	for _, hook := range hooks {
+		output, err := r.runHook(ctx, hook, env)
+		status := "ok"
+		if err != nil {
+			status = "error: " + err.Error()
+			util.Warnf("hook failed stage=%s name=%s case_id=%s err=%v", env.stage, hook.Name, caseData.ID, err)
+		}
+		statuses[hook.Name] = status
+		if output == "" {
+			continue
+		}
+		if writeErr := r.reporter.WriteText(caseData, "hooks/"+hookFileName(hook.Name)+".out", output); writeErr != nil {
+			util.Warnf("hook output write failed name=%s dir=%s err=%v", hook.Name, caseData.Dir, writeErr)
+		}
+	}
+	if details != nil {
+		details["hooks"] = statuses
+	}
+}
+
+// runHook runs the shell command and then the SQL statements of one hook under
+// the hook timeout. The combined output is returned even when a step fails.
+func (r *Runner) runHook(ctx context.Context, hook config.HookConfig, env hookEnv) (string, error) {
+	timeout := time.Duration(hook.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	hctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var b strings.Builder
+	var firstErr error
+	if hook.Shell != "" {
+		output, err := runShellHook(hctx, hook.Shell, env)
+		b.WriteString(output)
+		if err != nil {
+			firstErr = fmt.Errorf("shell: %w", err)
+		}
+	}
+	for _, stmt := range hook.SQL {
+		if b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "-- %s\n", stmt)
+		output, err := r.runSQLHook(hctx, stmt)
+		b.WriteString(output)
+		if err != nil {
+			fmt.Fprintf(&b, "-- error: %v\n", err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("sql: %w", err)
+			}
+		}
+	}
+	return truncateHookOutput(b.String()), firstErr
+}
+
+func runShellHook(ctx context.Context, command string, env hookEnv) (string, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Env = append(os.Environ(), env.environ()...)
+	// Background children may keep the output pipe open after the shell exits.
+	cmd.WaitDelay = hookShellWaitDelay
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
+
+// runSQLHook bypasses the validator and SQL observers: hook statements are
+// user-provided diagnostics and must not count toward fuzz statistics.
+func (r *Runner) runSQLHook(ctx context.Context, stmt string) (string, error) {
+	if r.exec == nil || r.exec.DB == nil {
+		return "", fmt.Errorf("no database connection")
+	}
+	rows, err := r.exec.DB.QueryContext(ctx, stmt)
+	if err != nil {
+		return "", err
+	}
+	defer util.CloseWithErr(rows, "hook rows")
+	return formatHookRows(rows, hookSQLMaxRows)
+}
+
+// formatHookRows renders rows as TSV with a header line.
+func formatHookRows(rows *sql.Rows, maxRows int) (string, error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+	if len(cols) == 0 {
+		return "", rows.Err()
+	}
+	values := make([]sql.RawBytes, len(cols))
+	scanArgs := make([]any, len(cols))
+	for i := range values {
+		scanArgs[i] = &values[i]
+	}
+	var b strings.Builder
+	b.WriteString(strings.Join(cols, "\t"))
+	b.WriteString("\n")
+	rowCount := 0
+	for rows.Next() {
+		if rowCount >= maxRows {
+			fmt.Fprintf(&b, "-- truncated after %d rows\n", maxRows)
+			break
+		}
+		if err := rows.Scan(scanArgs...); err != nil {
+			return b.String(), err
+		}
+		row := make([]string, 0, len(cols))
+		for _, v := range values {
+			if v == nil {
+				row = append(row, "NULL")
+			} else {
+				row = append(row, string(v))
+			}
+		}
+		b.WriteString(strings.Join(row, "\t"))
+		b.WriteString("\n")
+		rowCount++
+	}
+	return b.String(), rows.Err()
+}
+
+// hookFileName maps a hook name to a safe file name.
+func hookFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, strings.TrimSpace(name))
+	name = strings.Trim(name, ".")
+	if name == "" {
+		return "hook"
+	}
+	return name
+}
+
+func truncateHookOutput(output string) string {
+	if len(output) <= hookOutputMaxBytes {
+		return output
+	}
+	return output[:hookOutputMaxBytes] + "\n-- output truncated\n"
+}
+
+func hookLogPreview(output string) string {
+	output = strings.TrimSpace(output)
+	output = strings.ReplaceAll(output, "\n", " | ")
+	if len(output) > hookLogPreviewBytes {
+		output = output[:hookLogPreviewBytes] + "..."
+	}
+	return fmt.Sprintf("%q", output)
+}
@@ -0,0 +1,78 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"shiro/internal/config"
+	"shiro/internal/report"
+)
+
+func TestRunCaseHooksWritesOutput(t *testing.T) {
+	reporter := report.New(t.TempDir(), 10)
+	caseData, err := reporter.NewCase()
+	if err != nil {
+		t.Fatalf("new case: %v", err)
+	}
+	r := &Runner{reporter: reporter}
+	r.cfg.Database = "shiro_fuzz"
+	r.cfg.Hooks.CaseCaptured = []config.HookConfig{
+		{Name: "env/dump", Shell: `echo "$SHIRO_HOOK_STAGE $SHIRO_CASE_ID $SHIRO_ORACLE $SHIRO_DATABASE"`, TimeoutSeconds: 5},
+		{Name: "fail", Shell: "echo partial; exit 3", TimeoutSeconds: 5},
+	}
+	details := map[string]any{}
+	r.runCaseHooks(context.Background(), caseData, "NoREC", details)
+
+	data, err := os.ReadFile(filepath.Join(caseData.Dir, "hooks", "env_dump.out"))
+	if err != nil {
+		t.Fatalf("read hook output: %v", err)
+	}
+	want := "case_captured " + caseData.ID + " NoREC shiro_fuzz"
+	if strings.TrimSpace(string(data)) != want {
+		t.Fatalf("hook output=%q want=%q", strings.TrimSpace(string(data)), want)
+	}
+	if _, err := os.Stat(filepath.Join(caseData.Dir, "hooks", "fail.out")); err != nil {
+		t.Fatalf("expected failing hook output: %v", err)
+	}
+	statuses, ok := details["hooks"].(map[string]any)
+	if !ok {
+		t.Fatalf("missing hook statuses: %v", details)
+	}
+	if statuses["env/dump"] != "ok" {
+		t.Fatalf("unexpected env/dump status: %v", statuses["env/dump"])
+	}
+	if status, _ := statuses["fail"].(string); !strings.HasPrefix(status, "error: shell:") {
+		t.Fatalf("unexpected fail status: %v", statuses["fail"])
+	}
+}
+
+func TestRunHookSQLWithoutConnection(t *testing.T) {
+	r := &Runner{}
+	output, err := r.runHook(context.Background(), config.HookConfig{Name: "vars", SQL: []string{"SELECT 1"}}, hookEnv{})
+	if err == nil {
+		t.Fatalf("expected error without connection")
+	}
+	if !strings.Contains(output, "-- SELECT 1\n") || !strings.Contains(output, "-- error:") {
+		t.Fatalf("unexpected output: %q", output)
+	}
+}
+
+func TestHookFileName(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "tidb_log", want: "tidb_log"},
+		{in: "../etc/passwd", want: "_etc_passwd"},
+		{in: " a b ", want: "a_b"},
+		{in: "..", want: "hook"},
+	}
+	for _, tt := range tests {
+		if got := hookFileName(tt.in); got != tt.want {
+			t.Fatalf("hookFileName(%q)=%q want=%q", tt.in, got, tt.want)
+		}
+	}
+}
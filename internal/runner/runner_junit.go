@@ -0,0 +1,198 @@
+package runner
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"shiro/internal/util"
+)
+
+// junitCaseListMax caps the case lines listed in one failure body; the
+// message still counts every case.
+const junitCaseListMax = 50
+
+// junitCase is a captured case as listed in the JUnit report.
+type junitCase struct {
+	ID     string
+	Title  string
+	Reason string
+	Link   string
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// recordJUnitCase remembers a captured case for the JUnit report.
+func (r *Runner) recordJUnitCase(oracleName string, c junitCase) {
+	if !r.cfg.JUnit.Enabled {
+		return
+	}
+	r.statsMu.Lock()
+	defer r.statsMu.Unlock()
+	if r.junitCases == nil {
+		r.junitCases = make(map[string][]junitCase)
+	}
+	r.junitCases[oracleName] = append(r.junitCases[oracleName], c)
+}
+
+// dumpJUnit writes junit-<database>.xml when the run ends.
+func (r *Runner) dumpJUnit(started time.Time) {
+	if !r.cfg.JUnit.Enabled {
+		return
+	}
+	names := make([]string, 0, len(r.oracles))
+	for _, o := range r.oracles {
+		names = append(names, o.Name())
+	}
+	r.statsMu.Lock()
+	funnels := make(map[string]oracleFunnel, len(r.oracleStats))
+	for name, stat := range r.oracleStats {
+		funnels[name] = *stat
+	}
+	suites := buildJUnitReport(r.baseDB, names, funnels, r.junitCases, started, time.Since(started))
+	r.statsMu.Unlock()
+	if r.gen != nil {
+		suites.Suites[0].Properties = append(suites.Suites[0].Properties, junitProperty{Name: "seed", Value: fmt.Sprintf("%d", r.seedSnapshot())})
+	}
+	if r.topology != nil {
+		suites.Suites[0].Properties = append(suites.Suites[0].Properties, junitProperty{Name: "topology", Value: r.topology.String()})
+	}
+	data, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return
+	}
+	dir := r.cfg.JUnit.Dir
+	if dir == "" {
+		if dir, err = os.Getwd(); err != nil {
+			dir = "."
+		}
+	}
+	path := filepath.Join(dir, fmt.Sprintf("junit-%s.xml", r.baseDB))
+	if err := os.WriteFile(path, append([]byte(xml.Header), data...), 0o644); err != nil {
+		util.Warnf("junit report write failed path=%s err=%v", path, err)
+	}
+}
+
+// buildJUnitReport renders one test per oracle. An oracle with captured cases
+// fails and lists them; one that never ran without a skip is skipped.
+func buildJUnitReport(database string, oracles []string, funnels map[string]oracleFunnel, cases map[string][]junitCase, started time.Time, elapsed time.Duration) junitTestSuites {
+	names := append([]string(nil), oracles...)
+	for name := range cases {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	suite := junitTestSuite{
+		Name:      "shiro." + database,
+		Time:      junitSeconds(elapsed),
+		Timestamp: started.UTC().Format(time.RFC3339),
+	}
+	for _, name := range names {
+		stat := funnels[name]
+		tc := junitTestCase{
+			Name:      name,
+			Classname: "shiro.oracle",
+			Time:      "0",
+			SystemOut: fmt.Sprintf("runs=%d effective=%d skips=%d errors=%d mismatches=%d reports=%d",
+				stat.Runs, stat.Effective, stat.Skips, stat.Errors, stat.Mismatches, stat.Reports),
+		}
+		switch found := cases[name]; {
+		case len(found) > 0:
+			tc.Failure = junitCaseFailure(found)
+			suite.Failures++
+		case stat.Effective == 0:
+			tc.Skipped = &junitSkipped{Message: "no effective runs"}
+			suite.Skipped++
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	suite.Tests = len(suite.Cases)
+	return junitTestSuites{
+		Name:     "shiro",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Skipped:  suite.Skipped,
+		Time:     suite.Time,
+		Suites:   []junitTestSuite{suite},
+	}
+}
+
+func junitCaseFailure(cases []junitCase) *junitFailure {
+	lines := make([]string, 0, min(len(cases), junitCaseListMax)+1)
+	for i, c := range cases {
+		if i == junitCaseListMax {
+			lines = append(lines, fmt.Sprintf("... %d more", len(cases)-junitCaseListMax))
+			break
+		}
+		line := c.ID
+		for _, part := range []string{c.Reason, c.Title, c.Link} {
+			if part = strings.TrimSpace(part); part != "" {
+				line += " | " + part
+			}
+		}
+		lines = append(lines, line)
+	}
+	failureType := "mismatch"
+	if reason := strings.TrimSpace(cases[0].Reason); reason != "" {
+		failureType = reason
+	}
+	return &junitFailure{
+		Message: fmt.Sprintf("%d case(s) captured", len(cases)),
+		Type:    failureType,
+		Body:    strings.Join(lines, "\n"),
+	}
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,80 @@
+package runner
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"shiro/internal/config"
+	"shiro/internal/oracle"
+)
+
+func TestBuildJUnitReport(t *testing.T) {
+	funnels := map[string]oracleFunnel{
+		"NoREC": {Runs: 10, Effective: 8, Mismatches: 1, Reports: 1},
+		"TLP":   {Runs: 5, Effective: 5},
+		"DQE":   {Runs: 3, Skips: 3},
+	}
+	cases := map[string][]junitCase{
+		"NoREC": {{ID: "case-1", Title: "NoREC count mismatch", Reason: "result_mismatch", Link: "s3://bucket/case-1/"}},
+	}
+	started := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	suites := buildJUnitReport("shiro_fuzz", []string{"TLP", "NoREC", "DQE"}, funnels, cases, started, 90*time.Second)
+	if suites.Tests != 3 || suites.Failures != 1 || suites.Skipped != 1 || suites.Time != "90.000" {
+		t.Fatalf("unexpected totals: %+v", suites)
+	}
+	suite := suites.Suites[0]
+	if suite.Name != "shiro.shiro_fuzz" || suite.Timestamp != "2026-10-16T08:00:00Z" {
+		t.Fatalf("unexpected suite: %+v", suite)
+	}
+	byName := map[string]junitTestCase{}
+	for _, tc := range suite.Cases {
+		byName[tc.Name] = tc
+	}
+	norec := byName["NoREC"]
+	if norec.Failure == nil || norec.Failure.Type != "result_mismatch" ||
+		norec.Failure.Body != "case-1 | result_mismatch | NoREC count mismatch | s3://bucket/case-1/" {
+		t.Fatalf("unexpected NoREC failure: %+v", norec.Failure)
+	}
+	if byName["DQE"].Skipped == nil || byName["TLP"].Failure != nil || byName["TLP"].Skipped != nil {
+		t.Fatalf("unexpected DQE/TLP results: %+v %+v", byName["DQE"], byName["TLP"])
+	}
+}
+
+func TestJUnitCaseFailureCapsList(t *testing.T) {
+	cases := make([]junitCase, junitCaseListMax+3)
+	for i := range cases {
+		cases[i] = junitCase{ID: "c"}
+	}
+	failure := junitCaseFailure(cases)
+	lines := strings.Split(failure.Body, "\n")
+	if len(lines) != junitCaseListMax+1 || lines[len(lines)-1] != "... 3 more" || failure.Type != "mismatch" {
+		t.Fatalf("unexpected capped failure: message=%q type=%q last=%q", failure.Message, failure.Type, lines[len(lines)-1])
+	}
+}
+
+func TestDumpJUnitWritesFile(t *testing.T) {
+	dir := t.TempDir()
+	r := &Runner{
+		cfg:         config.Config{JUnit: config.JUnitConfig{Enabled: true, Dir: dir}},
+		baseDB:      "shiro_fuzz",
+		oracles:     []oracle.Oracle{oracle.NoREC{}},
+		oracleStats: map[string]*oracleFunnel{"NoREC": {Runs: 1, Effective: 1}},
+	}
+	r.recordJUnitCase("NoREC", junitCase{ID: "case-1"})
+	r.dumpJUnit(time.Now())
+	data, err := os.ReadFile(filepath.Join(dir, "junit-shiro_fuzz.xml"))
+	if err != nil {
+		t.Fatalf("read junit: %v", err)
+	}
+	var parsed junitTestSuites
+	if err := xml.Unmarshal(data, &parsed); err != nil {
+		t.Fatalf("parse junit: %v", err)
+	}
+	if parsed.Failures != 1 || len(parsed.Suites) != 1 || parsed.Suites[0].Cases[0].Failure == nil {
+		t.Fatalf("unexpected junit report: %s", data)
+	}
+}
@@ -0,0 +1,409 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"shiro/internal/config"
+	"shiro/internal/db"
+	"shiro/internal/oracle"
+	"shiro/internal/sqlstep"
+	"shiro/internal/util"
+)
+
+const (
+	// latencySubBuckets splits every power of two into linear buckets, so a
+	// recorded value keeps about 6% precision.
+	latencySubBuckets = 16
+	// latencyMaxCorrections caps the synthetic samples one stalled statement
+	// adds to its histogram.
+	latencyMaxCorrections = 1000
+	latencyOracleRunner   = "runner"
+	latencyBugHint        = "tidb:slow_query"
+	latencyErrorReason    = "latency:regression"
+)
+
+// latencyHistogram is a log-linear histogram of microsecond latencies in the
+// spirit of HdrHistogram. Values below latencySubBuckets get their own
+// bucket; above that, each power of two holds latencySubBuckets buckets.
+type latencyHistogram struct {
+	counts []int64
+	total  int64
+	max    int64
+	// raw and rawSum cover only measured samples, not corrections.
+	raw    int64
+	rawSum int64
+}
+
+func latencyBucket(us int64) int {
+	if us < latencySubBuckets {
+		return int(max(us, 0))
+	}
+	shift := bits.Len64(uint64(us)) - 5
+	return latencySubBuckets*shift + int(us>>shift)
+}
+
+// latencyBucketValue returns the highest value that falls into a bucket.
+func latencyBucketValue(idx int) int64 {
+	if idx < latencySubBuckets {
+		return int64(idx)
+	}
+	shift := idx/latencySubBuckets - 1
+	top := int64(idx - latencySubBuckets*shift)
+	return (top+1)<<shift - 1
+}
+
+func (h *latencyHistogram) record(us int64) {
+	idx := latencyBucket(us)
+	if idx >= len(h.counts) {
+		grown := make([]int64, idx+latencySubBuckets)
+		copy(grown, h.counts)
+		h.counts = grown
+	}
+	h.counts[idx]++
+	h.total++
+	h.max = max(h.max, us)
+}
+
+// recordCorrected records a measured latency and back-fills the samples a
+// closed-loop client never issued while the statement stalled, the way
+// HdrHistogram corrects for coordinated omission. The expected interval is
+// the mean measured latency so far, so a statement ten times slower than the
+// mean also adds the nine statements that would have run meanwhile.
+func (h *latencyHistogram) recordCorrected(us int64) {
+	var expected int64
+	if h.raw > 0 {
+		expected = h.rawSum / h.raw
+	}
+	h.raw++
+	h.rawSum += us
+	h.record(us)
+	if expected <= 0 {
+		return
+	}
+	for missing, n := us-expected, 0; missing >= expected && n < latencyMaxCorrections; missing, n = missing-expected, n+1 {
+		h.record(missing)
+	}
+}
+
+// quantile returns the latency at q (0 < q <= 1) in microseconds.
+func (h *latencyHistogram) quantile(q float64) int64 {
+	if h.total == 0 {
+		return 0
+	}
+	target := int64(math.Ceil(q * float64(h.total)))
+	var seen int64
+	for idx, count := range h.counts {
+		seen += count
+		if seen >= target {
+			return min(latencyBucketValue(idx), h.max)
+		}
+	}
+	return h.max
+}
+
+type latencyKey struct {
+	oracle string
+	class  string
+}
+
+// latencyDigest tracks the runs of one normalized statement.
+type latencyDigest struct {
+	runs    int
+	fastest time.Duration
+	flagged bool
+}
+
+type latencyRegression struct {
+	oracle  string
+	class   string
+	digest  string
+	text    string
+	sql     string
+	latency time.Duration
+	fastest time.Duration
+	runs    int
+}
+
+// latencyTracker receives every traced statement. It keeps one histogram per
+// oracle and statement class, and queues regressions of repeated statements
+// until the runner reports them between iterations.
+type latencyTracker struct {
+	cfg config.LatencyConfig
+
+	mu          sync.Mutex
+	histograms  map[latencyKey]*latencyHistogram
+	digests     map[string]*latencyDigest
+	pending     []latencyRegression
+	regressions int
+}
+
+// latencySummary is the latency block of the run summary.
+type latencySummary struct {
+	Statements  int64                     `json:"statements"`
+	Regressions int                       `json:"regressions"`
+	Histograms  []latencyHistogramSummary `json:"histograms"`
+}
+
+type latencyHistogramSummary struct {
+	Oracle    string  `json:"oracle"`
+	Class     string  `json:"class"`
+	Count     int64   `json:"count"`
+	Corrected int64   `json:"corrected_count"`
+	P50Ms     float64 `json:"p50_ms"`
+	P90Ms     float64 `json:"p90_ms"`
+	P99Ms     float64 `json:"p99_ms"`
+	MaxMs     float64 `json:"max_ms"`
+}
+
+func newLatencyTracker(cfg config.LatencyConfig) *latencyTracker {
+	return &latencyTracker{
+		cfg:        cfg,
+		histograms: map[latencyKey]*latencyHistogram{},
+		digests:    map[string]*latencyDigest{},
+	}
+}
+
+// TraceStatement implements db.StatementTracer. Failed and timed-out
+// statements are recorded too, so stalls are never dropped from the
+// histograms; only successful SELECT and DML runs count toward regressions.
+func (t *latencyTracker) TraceStatement(trace db.StatementTrace) {
+	class := latencyClass(trace.Kind, trace.SQL)
+	oracleName := trace.Tag
+	if oracleName == "" {
+		oracleName = latencyOracleRunner
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := latencyKey{oracle: oracleName, class: class}
+	hist := t.histograms[key]
+	if hist == nil {
+		hist = &latencyHistogram{}
+		t.histograms[key] = hist
+	}
+	hist.recordCorrected(trace.Duration.Microseconds())
+	if trace.Err != nil || len(trace.Args) > 0 || (class != "select" && class != "dml") {
+		return
+	}
+	t.observeDigestLocked(oracleName, class, trace.SQL, trace.Duration)
+}
+
+func (t *latencyTracker) observeDigestLocked(oracleName string, class string, sqlText string, latency time.Duration) {
+	text := latencyDigestText(sqlText)
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(text))
+	digest := fmt.Sprintf("%016x", hash.Sum64())
+	state := t.digests[digest]
+	if state == nil {
+		if len(t.digests) >= t.cfg.MaxDigests {
+			return
+		}
+		t.digests[digest] = &latencyDigest{runs: 1, fastest: latency}
+		return
+	}
+	threshold := max(state.fastest*time.Duration(t.cfg.RegressionFactor), time.Duration(t.cfg.MinRegressionMs)*time.Millisecond)
+	if !state.flagged && state.runs >= t.cfg.MinSamples && latency >= threshold && t.regressions < t.cfg.MaxCases {
+		state.flagged = true
+		t.regressions++
+		t.pending = append(t.pending, latencyRegression{
+			oracle:  oracleName,
+			class:   class,
+			digest:  digest,
+			text:    text,
+			sql:     sqlText,
+			latency: latency,
+			fastest: state.fastest,
+			runs:    state.runs,
+		})
+	}
+	state.runs++
+	state.fastest = min(state.fastest, latency)
+}
+
+// resetDigests forgets the digests when the database rotates, since the same
+// statement text then runs against different data.
+func (t *latencyTracker) resetDigests() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.digests = map[string]*latencyDigest{}
+	t.mu.Unlock()
+}
+
+func (t *latencyTracker) takePending() []latencyRegression {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	pending := t.pending
+	t.pending = nil
+	return pending
+}
+
+func (t *latencyTracker) summary() *latencySummary {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := &latencySummary{Regressions: t.regressions}
+	for key, hist := range t.histograms {
+		out.Statements += hist.raw
+		out.Histograms = append(out.Histograms, latencyHistogramSummary{
+			Oracle:    key.oracle,
+			Class:     key.class,
+			Count:     hist.raw,
+			Corrected: hist.total,
+			P50Ms:     latencyMs(hist.quantile(0.5)),
+			P90Ms:     latencyMs(hist.quantile(0.9)),
+			P99Ms:     latencyMs(hist.quantile(0.99)),
+			MaxMs:     latencyMs(hist.max),
+		})
+	}
+	sort.Slice(out.Histograms, func(i, j int) bool {
+		a, b := out.Histograms[i], out.Histograms[j]
+		if a.Oracle != b.Oracle {
+			return a.Oracle < b.Oracle
+		}
+		return a.Class < b.Class
+	})
+	return out
+}
+
+func latencyMs(us int64) float64 {
+	return float64(us) / 1000
+}
+
+// latencyClass buckets a statement by its trace kind and leading keyword.
+func latencyClass(kind string, sqlText string) string {
+	switch kind {
+	case db.TraceKindBegin, db.TraceKindCommit, db.TraceKindRollback:
+		return "txn"
+	case db.TraceKindPrepare:
+		return "prepare"
+	}
+	trimmed := strings.TrimLeft(sqlText, " \t\r\n(")
+	keyword, _, _ := strings.Cut(trimmed, " ")
+	switch strings.ToUpper(keyword) {
+	case "SELECT", "WITH", "TABLE", "VALUES":
+		return "select"
+	case "INSERT", "REPLACE", "UPDATE", "DELETE":
+		return "dml"
+	case "CREATE", "ALTER", "DROP", "TRUNCATE", "RENAME":
+		return "ddl"
+	case "EXPLAIN", "DESC", "DESCRIBE", "TRACE":
+		return "explain"
+	case "SET":
+		return "set"
+	case "ANALYZE":
+		return "analyze"
+	case "BEGIN", "START", "COMMIT", "ROLLBACK", "SAVEPOINT", "RELEASE":
+		return "txn"
+	default:
+		return "other"
+	}
+}
+
+// latencyDigestText normalizes a statement for digesting: string and numeric
+// literals become ?, whitespace collapses, and keywords are lowercased, so
+// runs of the same statement shape with different constants share a digest.
+func latencyDigestText(sqlText string) string {
+	var out strings.Builder
+	out.Grow(len(sqlText))
+	space := false
+	for i := 0; i < len(sqlText); i++ {
+		c := sqlText[i]
+		switch {
+		case c == '\'' || c == '"':
+			for i++; i < len(sqlText); i++ {
+				if sqlText[i] == '\\' {
+					i++
+					continue
+				}
+				if sqlText[i] != c {
+					continue
+				}
+				// A doubled quote is an escaped quote inside the literal.
+				if i+1 < len(sqlText) && sqlText[i+1] == c {
+					i++
+					continue
+				}
+				break
+			}
+			c = '?'
+		case isLatencyDigit(c) && (space || !latencyIdentTail(out.String())):
+			for i+1 < len(sqlText) && (isLatencyDigit(sqlText[i+1]) || sqlText[i+1] == '.') {
+				i++
+			}
+			c = '?'
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			space = true
+			continue
+		}
+		if space && out.Len() > 0 {
+			out.WriteByte(' ')
+		}
+		space = false
+		if c >= 'A' && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		out.WriteByte(c)
+	}
+	return out.String()
+}
+
+// latencyIdentTail reports whether the normalized text ends inside an
+// identifier, so the digits of names like t10 or c2 are kept.
+func latencyIdentTail(text string) bool {
+	if text == "" {
+		return false
+	}
+	c := text[len(text)-1]
+	return c == '_' || c == '`' || (c >= 'a' && c <= 'z') || isLatencyDigit(c)
+}
+
+func isLatencyDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// reportLatencyRegressions turns queued latency regressions into low-severity
+// slow-query cases. It runs between iterations so the cases are reported on
+// the runner goroutine.
+func (r *Runner) reportLatencyRegressions(ctx context.Context) {
+	for _, reg := range r.latency.takePending() {
+		util.Warnf("latency regression oracle=%s class=%s digest=%s latency_ms=%d fastest_ms=%d runs=%d",
+			reg.oracle, reg.class, reg.digest, reg.latency.Milliseconds(), reg.fastest.Milliseconds(), reg.runs)
+		oracleName := reg.oracle
+		if oracleName == latencyOracleRunner {
+			oracleName = "Latency"
+		}
+		steps := []sqlstep.Step{sqlstep.New(sqlstep.KindQuery, sqlstep.RoleReplay, reg.sql)}
+		r.handleResult(ctx, oracle.Result{
+			OK:       false,
+			Oracle:   oracleName,
+			Steps:    steps,
+			Expected: fmt.Sprintf("latency below %dx the fastest run (%dms)", r.cfg.Latency.RegressionFactor, reg.fastest.Milliseconds()),
+			Actual:   fmt.Sprintf("latency %dms", reg.latency.Milliseconds()),
+			Details: map[string]any{
+				"error_reason":        latencyErrorReason,
+				"bug_hint":            latencyBugHint,
+				"severity":            "low",
+				"latency_class":       reg.class,
+				"latency_digest":      reg.digest,
+				"latency_digest_text": reg.text,
+				"latency_ms":          reg.latency.Milliseconds(),
+				"latency_fastest_ms":  reg.fastest.Milliseconds(),
+				"latency_runs":        reg.runs,
+			},
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package runner
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"shiro/internal/config"
+	"shiro/internal/db"
+)
+
+func TestLatencyBucketRoundTrip(t *testing.T) {
+	for _, us := range []int64{0, 1, 15, 16, 31, 32, 33, 1000, 123456, 9876543} {
+		idx := latencyBucket(us)
+		upper := latencyBucketValue(idx)
+		if upper < us {
+			t.Fatalf("bucket %d upper %d below value %d", idx, upper, us)
+		}
+		if us >= latencySubBuckets && float64(upper-us) > float64(us)/latencySubBuckets {
+			t.Fatalf("bucket %d upper %d too coarse for %d", idx, upper, us)
+		}
+		if idx > 0 && latencyBucketValue(idx-1) >= us {
+			t.Fatalf("value %d belongs in an earlier bucket than %d", us, idx)
+		}
+	}
+}
+
+func TestLatencyHistogramCorrectsStalls(t *testing.T) {
+	var hist latencyHistogram
+	for i := 0; i < 99; i++ {
+		hist.recordCorrected(1000)
+	}
+	if got := hist.quantile(0.99); got > 1100 {
+		t.Fatalf("expected p99 near 1ms, got %dus", got)
+	}
+	hist.recordCorrected(100000)
+	if hist.raw != 100 {
+		t.Fatalf("expected 100 measured samples, got %d", hist.raw)
+	}
+	if hist.total != 199 {
+		t.Fatalf("expected 99 back-filled samples, got %d", hist.total-hist.raw)
+	}
+	if got := hist.quantile(0.9); got < 10000 {
+		t.Fatalf("expected the stall to lift p90, got %dus", got)
+	}
+	if hist.quantile(1) != 100000 {
+		t.Fatalf("expected max at p100, got %d", hist.quantile(1))
+	}
+}
+
+func TestLatencyDigestText(t *testing.T) {
+	a := latencyDigestText("SELECT  t10.c0 FROM t10 WHERE t10.c1 = 42 AND c2 IN ('a''', \"b\", -3.5)")
+	b := latencyDigestText("select t10.c0 from t10\nwhere t10.c1 = 7 and c2 in ('zz', \"q\", -1)")
+	if a != b {
+		t.Fatalf("expected equal digests:\n%s\n%s", a, b)
+	}
+	if want := "select t10.c0 from t10 where t10.c1 = ? and c2 in (?, ?, -?)"; a != want {
+		t.Fatalf("unexpected digest text %q", a)
+	}
+}
+
+func TestLatencyClass(t *testing.T) {
+	cases := map[string]string{
+		"SELECT 1":                             "select",
+		"(SELECT 1) UNION (SELECT 2)":          "select",
+		"WITH c AS (SELECT 1) SELECT * FROM c": "select",
+		"INSERT INTO t0 VALUES (1)":            "dml",
+		"ALTER TABLE t0 ADD INDEX i0 (c0)":     "ddl",
+		"EXPLAIN SELECT 1":                     "explain",
+		"ADMIN CHECK TABLE t0":                 "other",
+	}
+	for sqlText, want := range cases {
+		if got := latencyClass(db.TraceKindQuery, sqlText); got != want {
+			t.Fatalf("class of %q = %s, want %s", sqlText, got, want)
+		}
+	}
+	if got := latencyClass(db.TraceKindCommit, "COMMIT"); got != "txn" {
+		t.Fatalf("expected txn, got %s", got)
+	}
+}
+
+func TestLatencyTrackerFlagsRegression(t *testing.T) {
+	tracker := newLatencyTracker(config.LatencyConfig{Enabled: true, RegressionFactor: 10, MinSamples: 3, MinRegressionMs: 500, MaxDigests: 10, MaxCases: 1})
+	trace := func(sqlText string, d time.Duration, err error) {
+		tracker.TraceStatement(db.StatementTrace{Kind: db.TraceKindQuery, Tag: "NoREC", SQL: sqlText, Duration: d, Err: err})
+	}
+	for i := 0; i < 3; i++ {
+		trace("SELECT c0 FROM t0 WHERE c1 = 1", 60*time.Millisecond, nil)
+	}
+	trace("SELECT c0 FROM t0 WHERE c1 = 2", 400*time.Millisecond, nil)
+	if pending := tracker.takePending(); len(pending) != 0 {
+		t.Fatalf("expected no regression below the factor, got %+v", pending)
+	}
+	trace("SELECT c0 FROM t0 WHERE c1 = 3", 5*time.Second, errors.New("timeout"))
+	if pending := tracker.takePending(); len(pending) != 0 {
+		t.Fatalf("expected failed statements to be ignored, got %+v", pending)
+	}
+	trace("SELECT c0 FROM t0 WHERE c1 = 4", 700*time.Millisecond, nil)
+	pending := tracker.takePending()
+	if len(pending) != 1 || pending[0].oracle != "NoREC" || pending[0].class != "select" || pending[0].runs != 4 || pending[0].fastest != 60*time.Millisecond {
+		t.Fatalf("unexpected regressions %+v", pending)
+	}
+	trace("SELECT c9 FROM t9", time.Millisecond, nil)
+	for i := 0; i < 3; i++ {
+		trace("SELECT c9 FROM t9", time.Millisecond, nil)
+	}
+	trace("SELECT c9 FROM t9", time.Second, nil)
+	if pending := tracker.takePending(); len(pending) != 0 {
+		t.Fatalf("expected max_cases to cap regressions, got %+v", pending)
+	}
+	summary := tracker.summary()
+	if summary.Regressions != 1 || summary.Statements != 11 || len(summary.Histograms) != 1 {
+		t.Fatalf("unexpected summary %+v", summary)
+	}
+	if summary.Histograms[0].Oracle != "NoREC" || summary.Histograms[0].Corrected <= summary.Histograms[0].Count {
+		t.Fatalf("unexpected histogram summary %+v", summary.Histograms[0])
+	}
+}
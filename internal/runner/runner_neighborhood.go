@@ -0,0 +1,428 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"shiro/internal/oracle"
+	"shiro/internal/report"
+	"shiro/internal/util"
+
+	"github.com/pingcap/tidb/pkg/parser"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/opcode"
+)
+
+const neighborhoodFile = "neighborhood.json"
+
+// Kinds of case mutations tried around a captured failure.
+const (
+	mutationSwapJoin        = "swap_join"
+	mutationNegatePredicate = "negate_predicate"
+	mutationDropPredicate   = "drop_predicate"
+	mutationLiteralInc      = "literal_inc"
+	mutationLiteralDec      = "literal_dec"
+)
+
+// Outcomes of one mutant replay.
+const (
+	neighborhoodFails  = "fails"
+	neighborhoodPasses = "passes"
+	neighborhoodError  = "error"
+)
+
+// caseMutation is one local edit of the case SQL. target is the restored text
+// of the join, WHERE conjunct, or integer literal it edits; every occurrence
+// in every replayed statement is edited the same way, so the statements an
+// oracle compares stay comparable.
+type caseMutation struct {
+	kind   string
+	target string
+}
+
+type neighborhoodMutant struct {
+	Kind    string   `json:"kind"`
+	Target  string   `json:"target"`
+	SQL     []string `json:"sql"`
+	Outcome string   `json:"outcome"`
+	Stage   string   `json:"stage,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+type neighborhoodReport struct {
+	ReplayKind string               `json:"replay_kind"`
+	BaseSQL    []string             `json:"base_sql"`
+	Minimized  bool                 `json:"minimized"`
+	Mutants    []neighborhoodMutant `json:"mutants"`
+}
+
+// exploreNeighborhood replays small mutations of a captured case and records
+// which mutants still fail. The mutants that pass mark the boundary of the
+// bug: the join order, predicate, or constant it depends on. It replays the
+// minimized case when minimization succeeded, since that replays fastest.
+func (r *Runner) exploreNeighborhood(ctx context.Context, caseData report.Case, result oracle.Result, minimized minimizeOutput, details map[string]any) {
+	spec := buildReplaySpec(result)
+	caseSQL := result.SQL()
+	var setupSQL, inserts []string
+	if minimized.minimized {
+		spec = minimized.spec
+		caseSQL = minimized.caseSQL
+		setupSQL = minimized.reproSetupSQL
+	} else if spec.kind != "" {
+		tables := r.expandMinimizeTablesForViewDependencies(tablesForMinimize(result))
+		setupSQL = r.schemaSQL(ctx, tables)
+		inserts = append([]string{}, r.insertLog...)
+		if len(tables) > 0 {
+			inserts = filterInsertsByTables(inserts, tables)
+		}
+	}
+	if spec.kind == "" || len(setupSQL) == 0 {
+		details["neighborhood_status"] = "not_applicable"
+		return
+	}
+	mutations := caseMutations(neighborhoodStatements(spec, caseSQL), r.cfg.Minimize.NeighborhoodMutants)
+	if len(mutations) == 0 {
+		details["neighborhood_status"] = "no_mutations"
+		return
+	}
+
+	timeout := time.Duration(r.cfg.Minimize.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	nctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	replay := func(current replaySpec, stmts []string) replayAttemptResult {
+		return r.replayCaseDetailed(nctx, setupSQL, inserts, stmts, result, current)
+	}
+	if !replay(spec, caseSQL).matched {
+		details["neighborhood_status"] = "base_not_reproducible"
+		return
+	}
+
+	out := neighborhoodReport{ReplayKind: spec.kind, BaseSQL: neighborhoodStatements(spec, caseSQL), Minimized: minimized.minimized}
+	for _, m := range mutations {
+		if nctx.Err() != nil {
+			break
+		}
+		mutSpec, mutSQL, ok := mutateReplayCase(spec, caseSQL, m)
+		if !ok {
+			continue
+		}
+		mutant := neighborhoodMutant{Kind: m.kind, Target: m.target, SQL: neighborhoodStatements(mutSpec, mutSQL)}
+		attempt := replay(mutSpec, mutSQL)
+		mutant.Outcome = neighborhoodOutcome(attempt)
+		if mutant.Outcome != neighborhoodFails {
+			mutant.Stage = attempt.diag.failureStage
+			mutant.Error = attempt.diag.actualError
+		}
+		out.Mutants = append(out.Mutants, mutant)
+	}
+	annotateNeighborhood(details, out.Mutants)
+	if nctx.Err() != nil {
+		details["neighborhood_status"] = "timeout"
+	}
+	util.Detailf("case neighborhood dir=%s mutants=%d failing=%v status=%s", caseData.Dir, len(out.Mutants), details["neighborhood_failing"], details["neighborhood_status"])
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := r.reporter.WriteText(caseData, neighborhoodFile, string(data)); err != nil {
+		util.Warnf("neighborhood write failed dir=%s err=%v", caseData.Dir, err)
+		return
+	}
+	details["neighborhood_file"] = neighborhoodFile
+}
+
+// annotateNeighborhood summarizes the mutant outcomes into case details.
+// neighborhood_boundary lists the mutations that make the failure go away.
+func annotateNeighborhood(details map[string]any, mutants []neighborhoodMutant) {
+	failing := 0
+	var boundary []string
+	for _, mutant := range mutants {
+		switch mutant.Outcome {
+		case neighborhoodFails:
+			failing++
+		case neighborhoodPasses:
+			boundary = append(boundary, mutant.Kind+" "+mutant.Target)
+		}
+	}
+	details["neighborhood_status"] = "done"
+	details["neighborhood_mutants"] = len(mutants)
+	details["neighborhood_failing"] = failing
+	if len(boundary) > 0 {
+		details["neighborhood_boundary"] = boundary
+	}
+}
+
+// neighborhoodOutcome maps a replay attempt to fails, passes, or error. A
+// mutant that errors where the original compared fine is not a pass: it says
+// nothing about where the bug stops.
+func neighborhoodOutcome(attempt replayAttemptResult) string {
+	if attempt.matched {
+		return neighborhoodFails
+	}
+	switch attempt.diag.outcome {
+	case "comparison_not_reproduced", "error_mismatch":
+		return neighborhoodPasses
+	default:
+		return neighborhoodError
+	}
+}
+
+// neighborhoodStatements returns the statements a replay of spec runs after
+// setup.
+func neighborhoodStatements(spec replaySpec, caseSQL []string) []string {
+	if usesCaseSQL(spec) {
+		return caseSQL
+	}
+	return minimalCaseSQL(spec)
+}
+
+func usesCaseSQL(spec replaySpec) bool {
+	return spec.expectedSQL == "" && spec.actualSQL == ""
+}
+
+// mutateReplayCase applies m to the statements of the replay. It reports
+// false when no statement changed.
+func mutateReplayCase(spec replaySpec, caseSQL []string, m caseMutation) (replaySpec, []string, bool) {
+	p := parser.New()
+	if usesCaseSQL(spec) {
+		out := make([]string, len(caseSQL))
+		changed := false
+		for i, stmt := range caseSQL {
+			var ok bool
+			out[i], ok = applyCaseMutation(p, stmt, m)
+			changed = changed || ok
+		}
+		return spec, out, changed
+	}
+	mutated := spec
+	expectedOK, actualOK := false, false
+	if spec.expectedSQL != "" {
+		mutated.expectedSQL, expectedOK = applyCaseMutation(p, spec.expectedSQL, m)
+	}
+	if spec.actualSQL != "" {
+		mutated.actualSQL, actualOK = applyCaseMutation(p, spec.actualSQL, m)
+	}
+	return mutated, minimalCaseSQL(mutated), expectedOK || actualOK
+}
+
+// caseMutations lists up to limit mutations of the given statements: join
+// swaps first, then predicate toggles, then literal steps, since literals are
+// the most numerous and the least telling.
+func caseMutations(stmts []string, limit int) []caseMutation {
+	p := parser.New()
+	collector := &mutationTargetCollector{seen: map[string]struct{}{}}
+	for _, stmt := range stmts {
+		node, err := p.ParseOneStmt(stmt, "", "")
+		if err != nil {
+			continue
+		}
+		node.Accept(collector)
+	}
+	var out []caseMutation
+	for _, join := range collector.joins {
+		out = append(out, caseMutation{kind: mutationSwapJoin, target: join})
+	}
+	for _, pred := range collector.predicates {
+		out = append(out, caseMutation{kind: mutationNegatePredicate, target: pred}, caseMutation{kind: mutationDropPredicate, target: pred})
+	}
+	for _, literal := range collector.literals {
+		out = append(out, caseMutation{kind: mutationLiteralInc, target: literal}, caseMutation{kind: mutationLiteralDec, target: literal})
+	}
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+type mutationTargetCollector struct {
+	joins      []string
+	predicates []string
+	literals   []string
+	seen       map[string]struct{}
+}
+
+func (c *mutationTargetCollector) add(list *[]string, kind, text string) {
+	if text == "" {
+		return
+	}
+	key := kind + "\x00" + text
+	if _, ok := c.seen[key]; ok {
+		return
+	}
+	c.seen[key] = struct{}{}
+	*list = append(*list, text)
+}
+
+func (c *mutationTargetCollector) Enter(in ast.Node) (ast.Node, bool) {
+	if isCountStar(in) {
+		return in, true
+	}
+	switch n := in.(type) {
+	case *ast.Join:
+		if n.Left != nil && n.Right != nil {
+			c.add(&c.joins, mutationSwapJoin, restoreSQL(n))
+		}
+	case *ast.SelectStmt:
+		if n.Where != nil {
+			for _, conj := range splitWhereConjuncts(n.Where) {
+				c.add(&c.predicates, mutationNegatePredicate, restoreSQL(conj))
+			}
+		}
+	case ast.ValueExpr:
+		if value, ok := intLiteral(n); ok {
+			c.add(&c.literals, mutationLiteralInc, value.String())
+		}
+	}
+	return in, false
+}
+
+func (c *mutationTargetCollector) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
+
+// isCountStar reports COUNT(*), which the parser stores as COUNT(1); its
+// literal is not worth a mutant.
+func isCountStar(node ast.Node) bool {
+	agg, ok := node.(*ast.AggregateFuncExpr)
+	if !ok || !strings.EqualFold(agg.F, ast.AggFuncCount) || len(agg.Args) != 1 {
+		return false
+	}
+	_, ok = agg.Args[0].(ast.ValueExpr)
+	return ok
+}
+
+func splitWhereConjuncts(expr ast.ExprNode) []ast.ExprNode {
+	if bin, ok := expr.(*ast.BinaryOperationExpr); ok && bin.Op == opcode.LogicAnd {
+		return append(splitWhereConjuncts(bin.L), splitWhereConjuncts(bin.R)...)
+	}
+	return []ast.ExprNode{expr}
+}
+
+// intLiteralValue is an integer literal; unsigned keeps uint64 values above
+// the int64 range.
+type intLiteralValue struct {
+	signed   int64
+	unsigned uint64
+	isUint   bool
+}
+
+func (v intLiteralValue) String() string {
+	if v.isUint {
+		return strconv.FormatUint(v.unsigned, 10)
+	}
+	return strconv.FormatInt(v.signed, 10)
+}
+
+// step returns the literal moved by delta, or false when it would overflow.
+func (v intLiteralValue) step(delta int64) (any, bool) {
+	if v.isUint {
+		if (delta > 0 && v.unsigned == math.MaxUint64) || (delta < 0 && v.unsigned == 0) {
+			return nil, false
+		}
+		if delta > 0 {
+			return v.unsigned + uint64(delta), true
+		}
+		return v.unsigned - uint64(-delta), true
+	}
+	if (delta > 0 && v.signed == math.MaxInt64) || (delta < 0 && v.signed == math.MinInt64) {
+		return nil, false
+	}
+	return v.signed + delta, true
+}
+
+func intLiteral(expr ast.ValueExpr) (intLiteralValue, bool) {
+	switch v := expr.GetValue().(type) {
+	case int64:
+		return intLiteralValue{signed: v}, true
+	case uint64:
+		return intLiteralValue{unsigned: v, isUint: true}, true
+	default:
+		return intLiteralValue{}, false
+	}
+}
+
+// applyCaseMutation applies m to one statement and reports whether it
+// changed. Statements that do not parse are returned unchanged.
+func applyCaseMutation(p *parser.Parser, stmt string, m caseMutation) (string, bool) {
+	node, err := p.ParseOneStmt(stmt, "", "")
+	if err != nil {
+		return stmt, false
+	}
+	mutator := &caseMutator{mutation: m}
+	node.Accept(mutator)
+	if !mutator.changed {
+		return stmt, false
+	}
+	mutated := restoreSQL(node)
+	if mutated == "" {
+		return stmt, false
+	}
+	return mutated, true
+}
+
+type caseMutator struct {
+	mutation caseMutation
+	changed  bool
+}
+
+func (v *caseMutator) Enter(in ast.Node) (ast.Node, bool) {
+	return in, isCountStar(in)
+}
+
+func (v *caseMutator) Leave(in ast.Node) (ast.Node, bool) {
+	switch v.mutation.kind {
+	case mutationSwapJoin:
+		if join, ok := in.(*ast.Join); ok && join.Right != nil && restoreSQL(join) == v.mutation.target {
+			swapJoin(join)
+			v.changed = true
+		}
+	case mutationNegatePredicate, mutationDropPredicate:
+		if _, ok := in.(ast.ValueExpr); ok {
+			return in, true
+		}
+		if expr, ok := in.(ast.ExprNode); ok && restoreSQL(expr) == v.mutation.target {
+			v.changed = true
+			if v.mutation.kind == mutationDropPredicate {
+				return ast.NewValueExpr(int64(1), "", ""), true
+			}
+			return &ast.UnaryOperationExpr{Op: opcode.Not, V: &ast.ParenthesesExpr{Expr: expr}}, true
+		}
+	case mutationLiteralInc, mutationLiteralDec:
+		expr, ok := in.(ast.ValueExpr)
+		if !ok {
+			return in, true
+		}
+		value, ok := intLiteral(expr)
+		if !ok || value.String() != v.mutation.target {
+			return in, true
+		}
+		delta := int64(1)
+		if v.mutation.kind == mutationLiteralDec {
+			delta = -1
+		}
+		if next, ok := value.step(delta); ok {
+			v.changed = true
+			return ast.NewValueExpr(next, "", ""), true
+		}
+	}
+	return in, true
+}
+
+// swapJoin swaps the operands of a join, turning LEFT into RIGHT JOIN and
+// back so the result set is unchanged for a correct optimizer.
+func swapJoin(join *ast.Join) {
+	join.Left, join.Right = join.Right, join.Left
+	switch join.Tp {
+	case ast.LeftJoin:
+		join.Tp = ast.RightJoin
+	case ast.RightJoin:
+		join.Tp = ast.LeftJoin
+	}
+}
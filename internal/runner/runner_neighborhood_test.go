@@ -0,0 +1,110 @@
+package runner
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCaseMutationsOrderAndLimit(t *testing.T) {
+	stmts := []string{
+		"SELECT COUNT(*) FROM t0 JOIN t1 ON t0.c0 = t1.c0 WHERE t0.c1 > 5 AND t1.c2 = 7",
+		"SELECT SUM((t0.c1 > 5 AND t1.c2 = 7) IS TRUE) FROM t0 JOIN t1 ON t0.c0 = t1.c0",
+	}
+	var kinds []string
+	for _, m := range caseMutations(stmts, 0) {
+		kinds = append(kinds, m.kind+" "+m.target)
+	}
+	want := []string{
+		"swap_join `t0` JOIN `t1` ON `t0`.`c0`=`t1`.`c0`",
+		"negate_predicate `t0`.`c1`>5",
+		"drop_predicate `t0`.`c1`>5",
+		"negate_predicate `t1`.`c2`=7",
+		"drop_predicate `t1`.`c2`=7",
+		"literal_inc 5",
+		"literal_dec 5",
+		"literal_inc 7",
+		"literal_dec 7",
+	}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Fatalf("unexpected mutations:\n%s", strings.Join(kinds, "\n"))
+	}
+	if got := caseMutations(stmts, 3); len(got) != 3 {
+		t.Fatalf("expected the limit to cap mutations, got %d", len(got))
+	}
+}
+
+func TestMutateReplayCaseEditsBothSides(t *testing.T) {
+	spec := replaySpec{
+		kind:        "signature",
+		expectedSQL: "SELECT COUNT(*) FROM t0 LEFT JOIN t1 ON t0.c0 = t1.c0 WHERE t0.c1 > 5",
+		actualSQL:   "SELECT SUM((t0.c1 > 5) IS TRUE) FROM t0 LEFT JOIN t1 ON t0.c0 = t1.c0",
+	}
+	cases := []struct {
+		mutation caseMutation
+		expected string
+		actual   string
+	}{
+		{
+			mutation: caseMutation{kind: mutationSwapJoin, target: "`t0` LEFT JOIN `t1` ON `t0`.`c0`=`t1`.`c0`"},
+			expected: "SELECT COUNT(1) FROM `t1` RIGHT JOIN `t0` ON `t0`.`c0`=`t1`.`c0` WHERE `t0`.`c1`>5",
+			actual:   "SELECT SUM((`t0`.`c1`>5) IS TRUE) FROM `t1` RIGHT JOIN `t0` ON `t0`.`c0`=`t1`.`c0`",
+		},
+		{
+			mutation: caseMutation{kind: mutationNegatePredicate, target: "`t0`.`c1`>5"},
+			expected: "SELECT COUNT(1) FROM `t0` LEFT JOIN `t1` ON `t0`.`c0`=`t1`.`c0` WHERE NOT (`t0`.`c1`>5)",
+			actual:   "SELECT SUM((NOT (`t0`.`c1`>5)) IS TRUE) FROM `t0` LEFT JOIN `t1` ON `t0`.`c0`=`t1`.`c0`",
+		},
+		{
+			mutation: caseMutation{kind: mutationLiteralDec, target: "5"},
+			expected: "SELECT COUNT(1) FROM `t0` LEFT JOIN `t1` ON `t0`.`c0`=`t1`.`c0` WHERE `t0`.`c1`>4",
+			actual:   "SELECT SUM((`t0`.`c1`>4) IS TRUE) FROM `t0` LEFT JOIN `t1` ON `t0`.`c0`=`t1`.`c0`",
+		},
+	}
+	for _, tc := range cases {
+		mutated, stmts, ok := mutateReplayCase(spec, nil, tc.mutation)
+		if !ok {
+			t.Fatalf("%s: expected a change", tc.mutation.kind)
+		}
+		if mutated.expectedSQL != tc.expected || mutated.actualSQL != tc.actual {
+			t.Fatalf("%s: unexpected mutant:\n%s\n%s", tc.mutation.kind, mutated.expectedSQL, mutated.actualSQL)
+		}
+		if len(stmts) != 2 {
+			t.Fatalf("%s: unexpected replay statements: %v", tc.mutation.kind, stmts)
+		}
+	}
+	if _, _, ok := mutateReplayCase(spec, nil, caseMutation{kind: mutationLiteralInc, target: "42"}); ok {
+		t.Fatalf("expected no change for a missing literal")
+	}
+}
+
+func TestMutateReplayCaseDropsPredicateInCaseSQL(t *testing.T) {
+	spec := replaySpec{kind: "case_error"}
+	caseSQL := []string{"SET @a = 1", "SELECT c0 FROM t0 WHERE c1 IS NULL AND c2 < 3"}
+	_, stmts, ok := mutateReplayCase(spec, caseSQL, caseMutation{kind: mutationDropPredicate, target: "`c1` IS NULL"})
+	if !ok {
+		t.Fatalf("expected a change")
+	}
+	want := []string{"SET @a = 1", "SELECT `c0` FROM `t0` WHERE 1 AND `c2`<3"}
+	if !reflect.DeepEqual(stmts, want) {
+		t.Fatalf("unexpected mutant: %v", stmts)
+	}
+}
+
+func TestAnnotateNeighborhood(t *testing.T) {
+	details := map[string]any{}
+	annotateNeighborhood(details, []neighborhoodMutant{
+		{Kind: mutationSwapJoin, Target: "t0 JOIN t1", Outcome: neighborhoodFails},
+		{Kind: mutationLiteralInc, Target: "5", Outcome: neighborhoodPasses},
+		{Kind: mutationDropPredicate, Target: "c0 > 1", Outcome: neighborhoodError},
+	})
+	if details["neighborhood_status"] != "done" || details["neighborhood_mutants"] != 3 || details["neighborhood_failing"] != 1 {
+		t.Fatalf("unexpected neighborhood counts: %v", details)
+	}
+	if !reflect.DeepEqual(details["neighborhood_boundary"], []string{"literal_inc 5"}) {
+		t.Fatalf("unexpected boundary: %v", details["neighborhood_boundary"])
+	}
+	if got := neighborhoodOutcome(replayAttemptResult{diag: replayFailureDiagnostic{outcome: "execution_error"}}); got != neighborhoodError {
+		t.Fatalf("expected execution errors to be reported as error, got %s", got)
+	}
+}
@@ -0,0 +1,182 @@
+package runner
+
+import (
+	"sync"
+
+	"shiro/internal/config"
+	"shiro/internal/oracle"
+	"shiro/internal/util"
+)
+
+// oracleBudgetMaxBackoff caps the cooldown growth after repeated failed probes.
+const oracleBudgetMaxBackoff = 8
+
+// oracleBudget parks oracles whose recent runs are mostly skips or
+// whitelisted SQL errors, so their share goes to oracles that apply to the
+// current schema. Parked oracles get weight 0; after a cooldown they probe a
+// few runs and either resume or park again with a longer cooldown.
+type oracleBudget struct {
+	mu      sync.Mutex
+	cfg     config.OracleBudgetConfig
+	entries map[string]*oracleBudgetEntry
+}
+
+type oracleBudgetEntry struct {
+	window     []bool
+	next       int
+	filled     int
+	productive int
+	// remaining counts the oracle runs left in the cooldown; 0 when active.
+	remaining int
+	backoff   int
+	probing   bool
+	probeRuns int
+	probeHits int
+	parks     int64
+}
+
+// oracleBudgetEvent reports a park or resume decision for logging.
+type oracleBudgetEvent struct {
+	oracle   string
+	parked   bool
+	cooldown int
+}
+
+func newOracleBudget(cfg config.OracleBudgetConfig) *oracleBudget {
+	return &oracleBudget{cfg: cfg, entries: make(map[string]*oracleBudgetEntry)}
+}
+
+func (b *oracleBudget) entry(name string) *oracleBudgetEntry {
+	e := b.entries[name]
+	if e == nil {
+		e = &oracleBudgetEntry{window: make([]bool, b.cfg.Window), backoff: 1}
+		b.entries[name] = e
+	}
+	return e
+}
+
+// parked reports whether name is in its cooldown.
+func (b *oracleBudget) parked(name string) bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	e := b.entries[name]
+	return e != nil && e.remaining > 0
+}
+
+// observe records one run of name and advances every cooldown by one run.
+// canPark is false when parking name would leave no oracle to pick.
+func (b *oracleBudget) observe(name string, productive bool, canPark bool) []oracleBudgetEvent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var events []oracleBudgetEvent
+	for other, e := range b.entries {
+		if e.remaining == 0 {
+			continue
+		}
+		e.remaining--
+		if e.remaining == 0 {
+			e.probing = true
+			e.probeRuns, e.probeHits = 0, 0
+			events = append(events, oracleBudgetEvent{oracle: other})
+		}
+	}
+	e := b.entry(name)
+	if e.remaining > 0 {
+		return events
+	}
+	if e.probing {
+		e.probeRuns++
+		if productive {
+			e.probeHits++
+		}
+		if e.probeRuns < b.cfg.ProbeRuns {
+			return events
+		}
+		e.probing = false
+		if e.probeHits*100 >= b.cfg.MinProductivePercent*e.probeRuns || !canPark {
+			e.backoff = 1
+			e.resetWindow()
+			return events
+		}
+		e.backoff = min(e.backoff*2, oracleBudgetMaxBackoff)
+		return append(events, b.park(name, e))
+	}
+	if e.filled == len(e.window) && e.window[e.next] {
+		e.productive--
+	}
+	e.window[e.next] = productive
+	if productive {
+		e.productive++
+	}
+	e.next = (e.next + 1) % len(e.window)
+	e.filled = min(e.filled+1, len(e.window))
+	if e.filled < len(e.window) || e.productive*100 >= b.cfg.MinProductivePercent*len(e.window) || !canPark {
+		return events
+	}
+	return append(events, b.park(name, e))
+}
+
+func (b *oracleBudget) park(name string, e *oracleBudgetEntry) oracleBudgetEvent {
+	e.remaining = b.cfg.CooldownRuns * e.backoff
+	e.parks++
+	e.resetWindow()
+	return oracleBudgetEvent{oracle: name, parked: true, cooldown: e.remaining}
+}
+
+func (e *oracleBudgetEntry) resetWindow() {
+	clear(e.window)
+	e.next, e.filled, e.productive = 0, 0, 0
+}
+
+// reset unparks every oracle. The schema changes on database rotation, so
+// past skips no longer predict applicability.
+func (b *oracleBudget) reset() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.entries = make(map[string]*oracleBudgetEntry)
+	b.mu.Unlock()
+}
+
+// observeOracleBudget feeds one oracle result to the budget and refreshes the
+// oracle bandit arms when an oracle was parked or starts probing.
+func (r *Runner) observeOracleBudget(name string, result oracle.Result, skipReason string) {
+	if r.oracleBudget == nil || name == "" || name == "CERT" {
+		return
+	}
+	_, whitelisted := isWhitelistedSQLError(result.Err)
+	productive := skipReason == "" && !whitelisted
+	events := r.oracleBudget.observe(name, productive, r.activeOracleCount(name) > 0)
+	if len(events) == 0 {
+		return
+	}
+	for _, event := range events {
+		if event.parked {
+			util.Infof("oracle budget parked oracle=%s cooldown_runs=%d", event.oracle, event.cooldown)
+		} else {
+			util.Infof("oracle budget probing oracle=%s", event.oracle)
+		}
+	}
+	if r.oracleBandit != nil {
+		r.statsMu.Lock()
+		r.refreshOracleEnabled()
+		r.statsMu.Unlock()
+	}
+}
+
+// activeOracleCount counts the non-CERT oracles other than name that can be
+// picked.
+func (r *Runner) activeOracleCount(name string) int {
+	count := 0
+	for _, idx := range r.nonCertOracleIdx {
+		other := r.oracles[idx].Name()
+		if other != name && r.oracleWeightByName(other) > 0 {
+			count++
+		}
+	}
+	return count
+}
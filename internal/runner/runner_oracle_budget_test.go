@@ -0,0 +1,51 @@
+package runner
+
+import (
+	"testing"
+
+	"shiro/internal/config"
+)
+
+func TestOracleBudgetParksAndProbes(t *testing.T) {
+	b := newOracleBudget(config.OracleBudgetConfig{Enabled: true, Window: 10, MinProductivePercent: 20, CooldownRuns: 5, ProbeRuns: 2})
+	for i := 0; i < 9; i++ {
+		if events := b.observe("DQP", i == 0, true); len(events) != 0 {
+			t.Fatalf("parked before the window filled: %+v", events)
+		}
+	}
+	events := b.observe("DQP", false, true)
+	if len(events) != 1 || !events[0].parked || events[0].cooldown != 5 || !b.parked("DQP") {
+		t.Fatalf("expected DQP parked after 1/10 productive runs: %+v", events)
+	}
+	for i := 0; i < 4; i++ {
+		b.observe("TLP", true, true)
+	}
+	if events := b.observe("TLP", true, true); len(events) != 1 || events[0].oracle != "DQP" || events[0].parked {
+		t.Fatalf("expected DQP to start probing: %+v", events)
+	}
+	if b.parked("DQP") {
+		t.Fatalf("probing oracles must be pickable")
+	}
+	b.observe("DQP", false, true)
+	events = b.observe("DQP", false, true)
+	if len(events) != 1 || !events[0].parked || events[0].cooldown != 10 {
+		t.Fatalf("expected a failed probe to double the cooldown: %+v", events)
+	}
+	b.reset()
+	if b.parked("DQP") {
+		t.Fatalf("reset must unpark every oracle")
+	}
+}
+
+func TestOracleBudgetKeepsLastOracle(t *testing.T) {
+	b := newOracleBudget(config.OracleBudgetConfig{Enabled: true, Window: 4, MinProductivePercent: 50, CooldownRuns: 5, ProbeRuns: 2})
+	for i := 0; i < 8; i++ {
+		if events := b.observe("TLP", false, false); len(events) != 0 {
+			t.Fatalf("the last pickable oracle must not be parked: %+v", events)
+		}
+	}
+	var nilBudget *oracleBudget
+	if nilBudget.parked("TLP") {
+		t.Fatalf("a disabled budget parks nothing")
+	}
+}
@@ -1,6 +1,9 @@
 package runner
 
-import "shiro/internal/oracle"
+import (
+	"shiro/internal/generator"
+	"shiro/internal/oracle"
+)
 
 func (r *Runner) applyOracleOverrides(name string) func() {
 	origCfg := r.gen.Config
@@ -26,9 +29,13 @@ func (r *Runner) applyOracleOverrides(name string) func() {
 	}
 
 	profile := oracle.ProfileByName(name)
-	if profile == nil {
+	compat, hasCompat := oracle.ResolveOracleCompat(name, origCfg.Oracles.Compat)
+	if profile == nil && !hasCompat {
 		return restore
 	}
+	if profile == nil {
+		profile = &oracle.Profile{}
+	}
 
 	cfg := origCfg
 	profile.Features.Apply(&cfg.Features)
@@ -60,6 +67,11 @@ func (r *Runner) applyOracleOverrides(name string) func() {
 	if explicitNotIn != nil {
 		cfg.Features.NotIn = *explicitNotIn
 	}
+	// The compatibility matrix row goes last, so its overrides can allow
+	// what the profile would otherwise restrict.
+	if hasCompat {
+		compat.ApplyFeatures(&cfg.Features)
+	}
 	if r.isThroughputGuardActive() {
 		cfg.Features.SetOperations = false
 		cfg.Features.DerivedTables = false
@@ -75,6 +87,9 @@ func (r *Runner) applyOracleOverrides(name string) func() {
 	if profile.PredicateMode != nil {
 		r.gen.SetPredicateMode(*profile.PredicateMode)
 	}
+	if hasCompat && compat.PredicateMode != generator.PredicateModeDefault {
+		r.gen.SetPredicateMode(compat.PredicateMode)
+	}
 	if profile.JoinTypeOverride != nil {
 		r.gen.SetJoinTypeOverride(*profile.JoinTypeOverride)
 	}
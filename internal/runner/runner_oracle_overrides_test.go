@@ -5,6 +5,7 @@ import (
 
 	"shiro/internal/config"
 	"shiro/internal/generator"
+	"shiro/internal/oracle"
 	"shiro/internal/schema"
 )
 
@@ -256,8 +257,8 @@ func TestApplyOracleOverridesGroupByExtensionFallback(t *testing.T) {
 	if !r.gen.Config.Features.GroupByRollup {
 		t.Fatalf("eet override should keep rollup fallback enabled")
 	}
-	if r.gen.Config.Features.SetOperations {
-		t.Fatalf("eet override should disable set operations")
+	if !r.gen.Config.Features.SetOperations {
+		t.Fatalf("eet override should keep set operations enabled")
 	}
 	if r.gen.Config.Features.GroupByCube {
 		t.Fatalf("eet override should disable group_by_cube for fallback")
@@ -343,3 +344,36 @@ func TestApplyOracleOverridesThroughputGuardDisablesHeavyFeatures(t *testing.T)
 		t.Fatalf("throughput guard should cap max join tables, got %d", r.gen.Config.MaxJoinTables)
 	}
 }
+
+func TestApplyOracleOverridesCompatMatrixGoesLast(t *testing.T) {
+	cfg, err := config.Load("../../config.example.yaml")
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	cfg.Features.Aggregates = true
+	cfg.Features.OrderBy = true
+	cfg.Features.Limit = true
+	state := &schema.State{}
+	r := &Runner{gen: generator.New(cfg, state, 14)}
+
+	restore := r.applyOracleOverrides("NoREC")
+	if r.gen.Config.Features.Aggregates || r.gen.Config.Features.OrderBy || r.gen.Config.Features.Limit {
+		t.Fatalf("norec row should disable aggregates, order by and limit")
+	}
+	if r.gen.PredicateMode() != generator.PredicateModeSimple {
+		t.Fatalf("norec row should set predicate mode simple")
+	}
+	restore()
+
+	r.gen.Config.Oracles.Compat = map[string]config.OracleCompatOverride{
+		"norec": {Aggregate: oracle.BoolPtr(true), OrderBy: oracle.BoolPtr(true)},
+	}
+	restore = r.applyOracleOverrides("NoREC")
+	defer restore()
+	if !r.gen.Config.Features.Aggregates || !r.gen.Config.Features.OrderBy {
+		t.Fatalf("oracles.compat override should allow aggregates and order by")
+	}
+	if r.gen.Config.Features.Limit {
+		t.Fatalf("norec row should still disable limit")
+	}
+}
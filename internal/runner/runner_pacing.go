@@ -0,0 +1,155 @@
+package runner
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"time"
+
+	"shiro/internal/config"
+	"shiro/internal/util"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// pacingAdjustStatements is how many statements pass between delay changes,
+// so one slow iteration cannot double the delay several times.
+const pacingAdjustStatements = 20
+
+// pacingState tracks the transient error rate over the last Window
+// statements and the sleep applied between iterations.
+type pacingState struct {
+	cfg         config.PacingConfig
+	window      []bool
+	next        int
+	filled      int
+	errors      int
+	sinceAdjust int
+	delay       time.Duration
+	summary     pacingSummary
+}
+
+// pacingSummary is the pacing block of the run summary.
+type pacingSummary struct {
+	Activations      int64   `json:"activations"`
+	SlowedIterations int64   `json:"slowed_iterations"`
+	SleepSeconds     float64 `json:"sleep_seconds"`
+	MaxDelayMs       int64   `json:"max_delay_ms"`
+	PeakErrorPercent float64 `json:"peak_error_percent"`
+}
+
+func newPacingState(cfg config.PacingConfig) *pacingState {
+	return &pacingState{cfg: cfg, window: make([]bool, cfg.Window)}
+}
+
+// isTransientSQLError reports timeouts, lost connections, and the infra
+// errors of an unhealthy cluster.
+func isTransientSQLError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if _, ok := classifyInfraIssue(err); ok || isTimeoutError(err) {
+		return true
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "invalid connection") ||
+		strings.Contains(msg, "server has gone away") ||
+		strings.Contains(msg, "lost connection")
+}
+
+func (p *pacingState) observe(transient bool) {
+	if p.filled == len(p.window) && p.window[p.next] {
+		p.errors--
+	}
+	p.window[p.next] = transient
+	if transient {
+		p.errors++
+	}
+	p.next = (p.next + 1) % len(p.window)
+	p.filled = min(p.filled+1, len(p.window))
+	p.sinceAdjust++
+}
+
+func (p *pacingState) errorPercent() float64 {
+	if p.filled == 0 {
+		return 0
+	}
+	return 100 * float64(p.errors) / float64(p.filled)
+}
+
+// adjust doubles the delay while the error rate is at or above the threshold
+// and halves it once the rate is below half of it. It reports whether pacing
+// started or stopped.
+func (p *pacingState) adjust() (started bool, stopped bool) {
+	if p.sinceAdjust < pacingAdjustStatements {
+		return false, false
+	}
+	p.sinceAdjust = 0
+	rate := p.errorPercent()
+	p.summary.PeakErrorPercent = max(p.summary.PeakErrorPercent, rate)
+	minDelay := time.Duration(p.cfg.MinDelayMs) * time.Millisecond
+	maxDelay := time.Duration(p.cfg.MaxDelayMs) * time.Millisecond
+	switch {
+	case rate >= float64(p.cfg.ErrorPercent):
+		if p.delay == 0 {
+			p.delay = minDelay
+			p.summary.Activations++
+			started = true
+		} else {
+			p.delay = min(p.delay*2, maxDelay)
+		}
+		p.summary.MaxDelayMs = max(p.summary.MaxDelayMs, p.delay.Milliseconds())
+	case p.delay > 0 && rate*2 < float64(p.cfg.ErrorPercent):
+		p.delay /= 2
+		if p.delay < minDelay {
+			p.delay = 0
+			stopped = true
+		}
+	}
+	return started, stopped
+}
+
+// paceIteration sleeps for the current pacing delay before an iteration.
+func (r *Runner) paceIteration(ctx context.Context) {
+	if r.pacing == nil {
+		return
+	}
+	r.statsMu.Lock()
+	started, stopped := r.pacing.adjust()
+	delay := r.pacing.delay
+	rate := r.pacing.errorPercent()
+	if delay > 0 {
+		r.pacing.summary.SlowedIterations++
+		r.pacing.summary.SleepSeconds += delay.Seconds()
+	}
+	r.statsMu.Unlock()
+	if started {
+		util.Warnf("pacing slowed error_percent=%.1f delay_ms=%d", rate, delay.Milliseconds())
+	}
+	if stopped {
+		util.Infof("pacing recovered error_percent=%.1f", rate)
+	}
+	if delay <= 0 {
+		return
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+// pacingSummaryLocked returns the pacing metrics when pacing ever started.
+// Caller must hold statsMu.
+func (r *Runner) pacingSummaryLocked() *pacingSummary {
+	if r.pacing == nil || r.pacing.summary.Activations == 0 {
+		return nil
+	}
+	out := r.pacing.summary
+	return &out
+}
@@ -0,0 +1,63 @@
+package runner
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"shiro/internal/config"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestPacingSlowsAndRecovers(t *testing.T) {
+	p := newPacingState(config.PacingConfig{Enabled: true, Window: 40, ErrorPercent: 20, MinDelayMs: 100, MaxDelayMs: 300})
+	observe := func(n int, transient bool) {
+		for i := 0; i < n; i++ {
+			p.observe(transient)
+		}
+	}
+	observe(19, true)
+	if started, _ := p.adjust(); started || p.delay != 0 {
+		t.Fatalf("must wait for %d statements before adjusting", pacingAdjustStatements)
+	}
+	observe(1, true)
+	if started, _ := p.adjust(); !started || p.delay != 100*time.Millisecond {
+		t.Fatalf("expected pacing to start at the minimum delay, got %s", p.delay)
+	}
+	observe(20, true)
+	p.adjust()
+	observe(20, true)
+	p.adjust()
+	if p.delay != 300*time.Millisecond || p.summary.MaxDelayMs != 300 {
+		t.Fatalf("expected the delay capped at the maximum, got %s", p.delay)
+	}
+	observe(40, false)
+	if _, stopped := p.adjust(); stopped || p.delay != 150*time.Millisecond {
+		t.Fatalf("expected the delay to halve, got %s", p.delay)
+	}
+	observe(20, false)
+	if _, stopped := p.adjust(); !stopped || p.delay != 0 {
+		t.Fatalf("expected full speed after recovery, got %s", p.delay)
+	}
+	if p.summary.Activations != 1 || p.summary.PeakErrorPercent != 100 {
+		t.Fatalf("unexpected pacing summary: %+v", p.summary)
+	}
+}
+
+func TestIsTransientSQLError(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{mysql.ErrInvalidConn, true},
+		{errors.New("Region is unavailable"), true},
+		{&mysql.MySQLError{Number: 3024, Message: "Query execution was interrupted, maximum statement execution time exceeded"}, true},
+		{&mysql.MySQLError{Number: 1054, Message: "Unknown column 'c9' in 'where clause'"}, false},
+	} {
+		if got := isTransientSQLError(tc.err); got != tc.want {
+			t.Fatalf("isTransientSQLError(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"shiro/internal/db"
+	"shiro/internal/report"
+	"shiro/internal/util"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	panicClassRuntime       = "runtime_error"
+	panicClassPanic         = "panic"
+	panicClassInternal      = "internal_error"
+	panicClassAssertion     = "assertion"
+	panicClassBuildExecutor = "build_executor"
+
+	mysqlErrCodeInternal        = 1815
+	mysqlErrCodeBuildExecutor   = 8118
+	mysqlErrCodeAssertionFailed = 8141
+
+	panicStackFile = "panic_stack.txt"
+)
+
+// sqlEchoErrCodes are errors whose message quotes the failing SQL text, so a
+// column named "panic" or a string literal must not make them look like panics.
+var sqlEchoErrCodes = map[uint16]struct{}{
+	1054: {}, // unknown column
+	1064: {}, // syntax error
+	1146: {}, // table doesn't exist
+	1305: {}, // function doesn't exist
+	1582: {}, // incorrect parameter count
+}
+
+// plannerInternalErrMarkers are 1815 messages the planner returns when a
+// hint or tidb_isolation_read_engines leaves no usable plan. They are expected
+// rejections, not internal failures.
+var plannerInternalErrMarkers = []string{
+	"can't find a proper physical plan",
+	"no access path",
+}
+
+// runtimeErrorKinds maps Go runtime panic messages to a short kind.
+var runtimeErrorKinds = []struct {
+	marker string
+	kind   string
+}{
+	{"index out of range", "index_out_of_range"},
+	{"slice bounds out of range", "slice_bounds_out_of_range"},
+	{"nil pointer dereference", "nil_pointer"},
+	{"invalid memory address", "nil_pointer"},
+	{"integer divide by zero", "divide_by_zero"},
+	{"interface conversion", "interface_conversion"},
+	{"makeslice", "makeslice"},
+}
+
+// panicInfo is the structured classification of an error that TiDB recovered
+// from a panic or reported as an internal failure.
+type panicInfo struct {
+	class string
+	kind  string
+	code  uint16
+}
+
+// classifyPanic classifies err by MySQL error code first and falls back to the
+// message text. It returns a zero panicInfo for ordinary SQL errors.
+func classifyPanic(err error) panicInfo {
+	if err == nil {
+		return panicInfo{}
+	}
+	msg := strings.ToLower(err.Error())
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		if _, echo := sqlEchoErrCodes[mysqlErr.Number]; echo {
+			return panicInfo{}
+		}
+		info := panicInfo{code: mysqlErr.Number}
+		msg = strings.ToLower(mysqlErr.Message)
+		switch mysqlErr.Number {
+		case mysqlErrCodeInternal:
+			if isPlannerInternalErr(msg) {
+				return panicInfo{}
+			}
+			info.class = panicClassInternal
+		case mysqlErrCodeAssertionFailed:
+			info.class = panicClassAssertion
+		case mysqlErrCodeBuildExecutor:
+			info.class = panicClassBuildExecutor
+		default:
+			info.class = panicClassFromText(msg)
+		}
+		if info.class == "" {
+			return panicInfo{}
+		}
+		info.kind = runtimeErrorKind(msg)
+		return info
+	}
+	class := panicClassFromText(msg)
+	if class == "" {
+		return panicInfo{}
+	}
+	return panicInfo{class: class, kind: runtimeErrorKind(msg)}
+}
+
+func isPlannerInternalErr(msg string) bool {
+	for _, marker := range plannerInternalErrMarkers {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func panicClassFromText(msg string) string {
+	switch {
+	case strings.Contains(msg, "runtime error"):
+		return panicClassRuntime
+	case strings.Contains(msg, "panic"):
+		return panicClassPanic
+	case strings.Contains(msg, "assert"):
+		return panicClassAssertion
+	case strings.Contains(msg, "internal error"):
+		return panicClassInternal
+	default:
+		return ""
+	}
+}
+
+func runtimeErrorKind(msg string) string {
+	for _, item := range runtimeErrorKinds {
+		if strings.Contains(msg, item.marker) {
+			return item.kind
+		}
+	}
+	return ""
+}
+
+// annotatePanic stores the panic classification in details. It returns false
+// when err is not a panic.
+func annotatePanic(details map[string]any, err error) bool {
+	info := classifyPanic(err)
+	if info.class == "" || details == nil {
+		return info.class != ""
+	}
+	details["panic_class"] = info.class
+	if info.kind != "" {
+		details["panic_kind"] = info.kind
+	}
+	if info.code != 0 {
+		details["panic_code"] = int(info.code)
+	}
+	return true
+}
+
+// capturePanicDiagnostics re-runs a read-only failing statement on a fresh
+// connection and reads the session diagnostics TiDB keeps for the last
+// statement. It is best effort: a failed diagnostic query only leaves its key
+// unset.
+func (r *Runner) capturePanicDiagnostics(ctx context.Context, sqlText string, details map[string]any) {
+	sqlText = strings.TrimSpace(sqlText)
+	upper := strings.ToUpper(sqlText)
+	if details == nil || (!strings.HasPrefix(upper, "SELECT") && !strings.HasPrefix(upper, "WITH")) {
+		return
+	}
+	qctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	conn, err := r.exec.Conn(qctx)
+	if err != nil {
+		details["panic_diagnostics_error"] = err.Error()
+		return
+	}
+	defer util.CloseWithErr(conn, "panic diagnostics conn")
+	if err := r.prepareConn(qctx, conn, r.cfg.Database); err != nil {
+		details["panic_diagnostics_error"] = err.Error()
+		return
+	}
+	_, runErr := conn.ExecContext(qctx, sqlText)
+	details["panic_reproduced"] = classifyPanic(runErr).class != ""
+	var info string
+	if err := conn.QueryRowContext(qctx, "SELECT @@tidb_last_query_info").Scan(&info); err == nil {
+		details["panic_last_query_info"] = info
+	}
+	if warnings, err := db.WarningsOnConn(qctx, conn); err == nil && len(warnings) > 0 {
+		details["panic_warnings"] = warnings
+	}
+}
+
+// tidbLogStackPattern matches the stack field of a TiDB log line, for example
+// [stack="goroutine 1 [running]:\n..."].
+var tidbLogStackPattern = regexp.MustCompile(`\[stack="((?:[^"\\]|\\.)*)"\]`)
+
+// extractPanicStack returns the goroutine stack of the last TiDB log line that
+// mentions a panic, or the raw "panic:" block of a crashed process.
+func extractPanicStack(logText string) string {
+	lines := strings.Split(logText, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := lines[i]
+		if !strings.Contains(strings.ToLower(line), "panic") {
+			continue
+		}
+		if m := tidbLogStackPattern.FindStringSubmatch(line); m != nil {
+			if stack, err := strconv.Unquote(`"` + m[1] + `"`); err == nil {
+				return stack
+			}
+			return m[1]
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), "panic:") {
+			return strings.Join(lines[i:], "\n")
+		}
+	}
+	return ""
+}
+
+// capturePanicStack writes the first panic stack found in the captured TiDB
+// logs to the case and records the file name in details.
+func (r *Runner) capturePanicStack(caseData report.Case, details map[string]any, logs []string) {
+	for _, text := range logs {
+		stack := extractPanicStack(text)
+		if stack == "" {
+			continue
+		}
+		if err := r.reporter.WriteText(caseData, panicStackFile, stack); err != nil {
+			util.Warnf("panic stack write failed dir=%s err=%v", caseData.Dir, err)
+			return
+		}
+		details["panic_stack_file"] = panicStackFile
+		return
+	}
+}
@@ -0,0 +1,76 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestClassifyPanic(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want panicInfo
+	}{
+		{name: "nil", err: nil},
+		{
+			name: "runtime 1105",
+			err:  &mysql.MySQLError{Number: 1105, Message: "runtime error: index out of range [3] with length 3"},
+			want: panicInfo{class: panicClassRuntime, kind: "index_out_of_range", code: 1105},
+		},
+		{
+			name: "wrapped nil pointer",
+			err:  fmt.Errorf("query: %w", &mysql.MySQLError{Number: 1105, Message: "runtime error: invalid memory address or nil pointer dereference"}),
+			want: panicInfo{class: panicClassRuntime, kind: "nil_pointer", code: 1105},
+		},
+		{name: "build executor", err: &mysql.MySQLError{Number: 8118, Message: "Failed to build executor"}, want: panicInfo{class: panicClassBuildExecutor, code: 8118}},
+		{name: "internal", err: &mysql.MySQLError{Number: 1815, Message: "Internal : unexpected state"}, want: panicInfo{class: panicClassInternal, code: 1815}},
+		{name: "planner no plan", err: &mysql.MySQLError{Number: 1815, Message: "Internal : Can't find a proper physical plan for this query"}},
+		{name: "planner no access path", err: &mysql.MySQLError{Number: 1815, Message: "Internal : No access path for table 't0' is found with 'tidb_isolation_read_engines' = 'tiflash,tidb'"}},
+		{name: "assertion", err: &mysql.MySQLError{Number: 8141, Message: "assertion failed: key: 7480"}, want: panicInfo{class: panicClassAssertion, code: 8141}},
+		{name: "plain 1105", err: &mysql.MySQLError{Number: 1105, Message: "Can't find column Column#5"}},
+		{name: "syntax echo", err: &mysql.MySQLError{Number: 1064, Message: "You have an error near 'panic_col) assert'"}},
+		{name: "unknown column echo", err: &mysql.MySQLError{Number: 1054, Message: "Unknown column 'runtime error' in 'field list'"}},
+		{name: "non mysql", err: errors.New("panic: boom"), want: panicInfo{class: panicClassPanic}},
+	}
+	for _, tc := range cases {
+		if got := classifyPanic(tc.err); got != tc.want {
+			t.Fatalf("%s: classifyPanic=%+v want=%+v", tc.name, got, tc.want)
+		}
+		if isPanicError(tc.err) != (tc.want.class != "") {
+			t.Fatalf("%s: isPanicError mismatch", tc.name)
+		}
+	}
+}
+
+func TestAnnotatePanic(t *testing.T) {
+	details := map[string]any{}
+	if !annotatePanic(details, &mysql.MySQLError{Number: 1105, Message: "runtime error: integer divide by zero"}) {
+		t.Fatalf("expected panic")
+	}
+	if details["panic_class"] != panicClassRuntime || details["panic_kind"] != "divide_by_zero" || details["panic_code"] != 1105 {
+		t.Fatalf("unexpected details: %v", details)
+	}
+	if annotatePanic(map[string]any{}, &mysql.MySQLError{Number: 1292, Message: "Truncated incorrect DOUBLE value"}) {
+		t.Fatalf("expected no panic for truncation error")
+	}
+}
+
+func TestExtractPanicStack(t *testing.T) {
+	logText := "[2026/10/16] [INFO] [server.go:1] [\"start\"]\n" +
+		"[2026/10/16] [ERROR] [conn.go:1105] [\"connection running loop panic\"] [conn=7] [err=\"runtime error: index out of range\"] [stack=\"goroutine 1 [running]:\\nexecutor.(*HashJoinExec).Next\\n\\tjoin.go:42\"]\n" +
+		"[2026/10/16] [INFO] [conn.go:1] [\"close\"]\n"
+	want := "goroutine 1 [running]:\nexecutor.(*HashJoinExec).Next\n\tjoin.go:42"
+	if got := extractPanicStack(logText); got != want {
+		t.Fatalf("extractPanicStack=%q want=%q", got, want)
+	}
+	crash := "started\npanic: runtime error: slice bounds out of range\n\ngoroutine 9 [running]:\nmain.main()\n"
+	if got := extractPanicStack(crash); got != "panic: runtime error: slice bounds out of range\n\ngoroutine 9 [running]:\nmain.main()\n" {
+		t.Fatalf("unexpected crash stack: %q", got)
+	}
+	if got := extractPanicStack("[INFO] ok\n"); got != "" {
+		t.Fatalf("expected no stack, got %q", got)
+	}
+}
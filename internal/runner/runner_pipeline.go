@@ -0,0 +1,175 @@
+package runner
+
+import (
+	"context"
+
+	"shiro/internal/generator"
+	"shiro/internal/oracle"
+)
+
+// pipelineOracles only read the shared tables, so several of them can run at
+// once against one schema. DQE, TxnRYW, FKCascade, Savepoint, AutoID,
+// BatchDML, DecimalArith, LargeRow, Privilege, and PartitionRange write and
+// run alone after the pipeline drains.
+var pipelineOracles = map[string]struct{}{
+	"NoREC":       {},
+	"TLP":         {},
+	"EET":         {},
+	"DQP":         {},
+	"PQS":         {},
+	"CERT":        {},
+	"CODDTest":    {},
+	"Impo":        {},
+	"GroundTruth": {},
+	"TiFlashOnly": {},
+	"CTEInline":   {},
+	"ResultType":  {},
+	"FullJoin":    {},
+	"CursorFetch": {},
+	"InList":      {},
+}
+
+// oraclePipeline runs up to depth read-only oracles concurrently in one
+// worker. Each run gets a generator fork, an executor fork, and a copy of the
+// table list; results are reaped and reported on the worker goroutine. DDL,
+// DML, prepared-statement checks, and writing oracles drain the pipeline
+// first, so schema and data only change while no oracle is in flight.
+//
+// Reporting a run can change the schema too: a case rotates the database,
+// and QPG and plan stability probes add indexes or analyze tables. Finished
+// runs are therefore held in reaped and only reported once the pipeline has
+// drained.
+type oraclePipeline struct {
+	depth    int
+	inflight int
+	done     chan pipelinedRun
+	reaped   []pipelinedRun
+}
+
+type pipelinedRun struct {
+	oracleIdx    int
+	result       oracle.Result
+	snapshotTSO  uint64
+	features     *generator.QueryFeatures
+	builderStats generator.BuilderStats
+	arms         featureArms
+}
+
+func newOraclePipeline(depth int) *oraclePipeline {
+	return &oraclePipeline{depth: depth, done: make(chan pipelinedRun, depth)}
+}
+
+// pipelineAccepts reports whether oracleName can run in the pipeline. TQS
+// records walked join paths in a shared history, and the generator's Truth
+// and TQS walker are shared with forks, so it keeps the loop sequential.
+func (r *Runner) pipelineAccepts(oracleName string) bool {
+	if r.pipeline == nil || r.cfg.TQS.Enabled {
+		return false
+	}
+	_, ok := pipelineOracles[oracleName]
+	return ok
+}
+
+// launchPipelined starts an oracle run in the background. The caller has
+// already waited for a free slot; cancel is released when the run ends.
+func (r *Runner) launchPipelined(qctx context.Context, cancel context.CancelFunc, oracleIdx int) {
+	state := r.state.Clone()
+	gen := r.gen.Fork(state)
+	exec := r.exec.Fork()
+	run := pipelinedRun{oracleIdx: oracleIdx, arms: r.lastFeatureArms}
+	if r.featureBandit != nil {
+		// The fork keeps the picked weights; the next query picks its own.
+		r.clearAdaptiveWeights()
+	}
+	// Truth and the TQS walker are shared; only TQS sets them, and TQS keeps
+	// the loop sequential.
+	gen.SetTruth(nil)
+	gen.SetTQSWalker(nil)
+	// Drift is never armed here: an index added or statistics changed mid-run
+	// would also hit the other in-flight runs and mislabel their results.
+	o := r.oracles[oracleIdx]
+	r.pipeline.inflight++
+	go func() {
+		defer cancel()
+		snapshotTSO, unpinSnapshot := r.pinOracleSnapshot(qctx, exec, o.Name())
+		run.result = o.Run(qctx, exec, gen, state)
+		unpinSnapshot()
+		run.snapshotTSO = snapshotTSO
+		run.features = gen.LastFeatures
+		run.builderStats = gen.BuilderStats()
+		r.pipeline.done <- run
+	}()
+}
+
+// awaitPipelineSlot collects finished runs until a slot is free. They are
+// reported by the next drain.
+func (r *Runner) awaitPipelineSlot(_ context.Context) {
+	for r.pipeline.inflight >= r.pipeline.depth {
+		r.collectPipelined(<-r.pipeline.done)
+	}
+}
+
+// reapPipeline collects the runs that already finished without blocking. If
+// any did, it drains the pipeline so they can be reported.
+func (r *Runner) reapPipeline(ctx context.Context) {
+	if r.pipeline == nil {
+		return
+	}
+	// Only the worker receives from done, so a buffered run cannot vanish
+	// between the length check and the receive.
+	for len(r.pipeline.done) > 0 {
+		r.collectPipelined(<-r.pipeline.done)
+	}
+	if len(r.pipeline.reaped) > 0 {
+		r.drainPipeline(ctx)
+	}
+}
+
+// drainPipeline waits for every in-flight run and then reports the finished
+// runs in completion order.
+func (r *Runner) drainPipeline(ctx context.Context) {
+	if r.pipeline == nil {
+		return
+	}
+	for r.pipeline.inflight > 0 {
+		r.collectPipelined(<-r.pipeline.done)
+	}
+	reaped := r.pipeline.reaped
+	r.pipeline.reaped = nil
+	for _, run := range reaped {
+		r.reportPipelined(ctx, run)
+	}
+}
+
+// collectPipelined takes a finished run out of flight without reporting it.
+func (r *Runner) collectPipelined(run pipelinedRun) {
+	r.pipeline.inflight--
+	r.pipeline.reaped = append(r.pipeline.reaped, run)
+}
+
+// reportPipelined reports one finished run and rewards the query action. It
+// only runs while no other run is in flight.
+func (r *Runner) reportPipelined(ctx context.Context, run pipelinedRun) {
+	var reward float64
+	if r.finishForkedRun(ctx, run) {
+		reward = 1
+	}
+	r.updateActionBandit(actionQuery, reward)
+}
+
+// finishForkedRun reports a run made on a generator fork. The run's features
+// and bandit arms are installed for finishQuery and the worker's own are
+// restored afterwards, since a run can finish while the next query is being
+// prepared.
+func (r *Runner) finishForkedRun(ctx context.Context, run pipelinedRun) bool {
+	arms, features := r.lastFeatureArms, r.gen.LastFeatures
+	adaptive := r.adaptiveSnapshot()
+	r.lastFeatureArms = run.arms
+	r.gen.LastFeatures = run.features
+	found := r.finishQuery(ctx, run.oracleIdx, run.result, run.snapshotTSO, run.builderStats)
+	r.lastFeatureArms, r.gen.LastFeatures = arms, features
+	if adaptive != nil {
+		r.setAdaptiveWeights(*adaptive)
+	}
+	return found
+}
@@ -0,0 +1,141 @@
+package runner
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"shiro/internal/config"
+	"shiro/internal/db"
+	"shiro/internal/generator"
+	"shiro/internal/oracle"
+	"shiro/internal/schema"
+)
+
+// barrierOracle blocks until every expected run has started, so a sequential
+// pipeline times out instead of passing.
+type barrierOracle struct {
+	started *sync.WaitGroup
+	states  chan *schema.State
+}
+
+func (o barrierOracle) Name() string { return "NoREC" }
+
+func (o barrierOracle) Run(ctx context.Context, _ *db.DB, gen *generator.Generator, state *schema.State) oracle.Result {
+	o.started.Done()
+	o.states <- state
+	waited := make(chan struct{})
+	go func() {
+		o.started.Wait()
+		close(waited)
+	}()
+	select {
+	case <-waited:
+		if gen.State != state {
+			return oracle.Result{Oracle: o.Name(), OK: true, Details: map[string]any{"skip_reason": "norec:state_mismatch"}}
+		}
+		return oracle.Result{Oracle: o.Name(), OK: true}
+	case <-ctx.Done():
+		return oracle.Result{Oracle: o.Name(), OK: true, Err: ctx.Err()}
+	}
+}
+
+// instantOracle finishes right away.
+type instantOracle struct{}
+
+func (instantOracle) Name() string { return "NoREC" }
+
+func (instantOracle) Run(context.Context, *db.DB, *generator.Generator, *schema.State) oracle.Result {
+	return oracle.Result{Oracle: "NoREC", OK: true}
+}
+
+func TestPipelineAccepts(t *testing.T) {
+	r := &Runner{}
+	if r.pipelineAccepts("NoREC") {
+		t.Fatalf("pipeline must be off without oracles.pipeline_depth")
+	}
+	r.pipeline = newOraclePipeline(2)
+	if !r.pipelineAccepts("NoREC") || !r.pipelineAccepts("DQP") {
+		t.Fatalf("expected read-only oracles to be pipelined")
+	}
+	for _, name := range []string{"DQE", "TxnRYW", "FKCascade", "Savepoint", "AutoID", "BatchDML", "DecimalArith", "LargeRow"} {
+		if r.pipelineAccepts(name) {
+			t.Fatalf("%s writes and must not be pipelined", name)
+		}
+	}
+	r.cfg.TQS.Enabled = true
+	if r.pipelineAccepts("NoREC") {
+		t.Fatalf("TQS must keep the loop sequential")
+	}
+}
+
+func TestPipelineRunsOraclesConcurrently(t *testing.T) {
+	cfg := config.Config{}
+	state := &schema.State{Tables: []schema.Table{{Name: "t0", Columns: []schema.Column{{Name: "c0", Type: schema.TypeInt}}}}}
+	started := &sync.WaitGroup{}
+	started.Add(2)
+	o := barrierOracle{started: started, states: make(chan *schema.State, 2)}
+	r := &Runner{
+		cfg:         cfg,
+		gen:         generator.New(cfg, state, 1),
+		state:       state,
+		exec:        &db.DB{},
+		oracles:     []oracle.Oracle{o},
+		oracleStats: make(map[string]*oracleFunnel),
+		pipeline:    newOraclePipeline(2),
+	}
+	for i := 0; i < 2; i++ {
+		r.awaitPipelineSlot(context.Background())
+		qctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		r.launchPipelined(qctx, cancel, 0)
+	}
+	if r.pipeline.inflight != 2 {
+		t.Fatalf("inflight=%d want=2", r.pipeline.inflight)
+	}
+	r.drainPipeline(context.Background())
+	if r.pipeline.inflight != 0 {
+		t.Fatalf("drain left %d runs in flight", r.pipeline.inflight)
+	}
+	stat := r.oracleStats["NoREC"]
+	if stat == nil || stat.Errors != 0 || stat.Skips != 0 {
+		t.Fatalf("expected two clean concurrent runs, got %+v", stat)
+	}
+	for i := 0; i < 2; i++ {
+		if got := <-o.states; got == state {
+			t.Fatalf("pipelined run must read a copy of the table list")
+		}
+	}
+}
+
+func TestPipelineReportsOnlyAfterDrain(t *testing.T) {
+	cfg := config.Config{}
+	state := &schema.State{Tables: []schema.Table{{Name: "t0", Columns: []schema.Column{{Name: "c0", Type: schema.TypeInt}}}}}
+	r := &Runner{
+		cfg:         cfg,
+		gen:         generator.New(cfg, state, 1),
+		state:       state,
+		exec:        &db.DB{},
+		oracles:     []oracle.Oracle{instantOracle{}},
+		oracleStats: make(map[string]*oracleFunnel),
+		pipeline:    newOraclePipeline(1),
+	}
+	for i := 0; i < 2; i++ {
+		r.awaitPipelineSlot(context.Background())
+		qctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		r.launchPipelined(qctx, cancel, 0)
+	}
+	if r.pipeline.inflight != 1 || len(r.pipeline.reaped) != 1 {
+		t.Fatalf("inflight=%d reaped=%d, want 1 and 1", r.pipeline.inflight, len(r.pipeline.reaped))
+	}
+	if stat := r.oracleStats["NoREC"]; stat != nil && stat.Effective != 0 {
+		t.Fatalf("a run was reported while another was in flight: %+v", stat)
+	}
+	r.drainPipeline(context.Background())
+	if r.pipeline.inflight != 0 || len(r.pipeline.reaped) != 0 {
+		t.Fatalf("drain left inflight=%d reaped=%d", r.pipeline.inflight, len(r.pipeline.reaped))
+	}
+	if stat := r.oracleStats["NoREC"]; stat == nil || stat.Effective != 2 {
+		t.Fatalf("expected both runs reported after the drain, got %+v", stat)
+	}
+}
@@ -1,72 +1,40 @@
 package runner
 
 import (
-	"errors"
 	"testing"
 
-	"github.com/go-sql-driver/mysql"
+	"shiro/internal/config"
+	"shiro/internal/oracle"
 )
 
-func TestPlanCacheWarningReason(t *testing.T) {
-	testCases := []struct {
-		name    string
-		warning string
-		expect  string
-	}{
-		{
-			name:    "prepared skip reason",
-			warning: "Warning:1105:skip plan-cache: sub-queries are un-cacheable",
-			expect:  "sub-queries are un-cacheable",
-		},
-		{
-			name:    "non prepared skip reason",
-			warning: "Warning:1105:skip non-prepared plan-cache: queries that have sub-queries are not supported",
-			expect:  "queries that have sub-queries are not supported",
-		},
-		{
-			name:    "plain warning message",
-			warning: "Warning:1105:query has 'order by ?' is un-cacheable",
-			expect:  "query has 'order by ?' is un-cacheable",
-		},
-		{
-			name:    "empty warning message",
-			warning: "Warning:1105:",
-			expect:  "unknown",
-		},
-		{
-			name:    "invalid warning format",
-			warning: "invalid-warning",
-			expect:  "invalid-warning",
-		},
+func TestPlanCacheOracleWeightFollowsFeature(t *testing.T) {
+	r := &Runner{cfg: config.Config{Weights: config.Weights{Oracles: config.OracleWeights{PlanCache: 3}}}}
+	if got := r.oracleWeightByName("PlanCache"); got != 0 {
+		t.Fatalf("plan cache oracle should be off without features.plan_cache, got %d", got)
 	}
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			got := planCacheWarningReason(tc.warning)
-			if got != tc.expect {
-				t.Fatalf("warning reason mismatch: got=%q expect=%q", got, tc.expect)
-			}
-		})
+	r.cfg.Features.PlanCache = true
+	if got := r.oracleWeightByName("PlanCache"); got != 3 {
+		t.Fatalf("unexpected plan cache weight: %d", got)
 	}
 }
 
-func TestObservePlanCacheWarnings(t *testing.T) {
-	reasons := make(map[string]int)
-	observePlanCacheWarnings(reasons, []string{
-		"Warning:1105:skip plan-cache: sub-queries are un-cacheable",
-		"Warning:1105:skip plan-cache: sub-queries are un-cacheable",
-		"Warning:1105:query has 'order by ?' is un-cacheable",
+func TestPlanCacheOnlyStats(t *testing.T) {
+	stats := newPlanCacheOnlyStats()
+	stats.observe(oracle.Result{
+		OK:      true,
+		Metrics: map[string]int64{oracle.PlanCacheOnlyMissSecondMetric: 1, oracle.PlanCacheOnlyMissSecondWithWarningsMetric: 1},
+		Details: map[string]any{oracle.PlanCacheWarningReasonsKey: []string{"sub-queries are un-cacheable"}},
 	})
-	if reasons["sub-queries are un-cacheable"] != 1 {
-		t.Fatalf("unexpected count for subquery reason: %d", reasons["sub-queries are un-cacheable"])
-	}
-	if reasons["query has 'order by ?' is un-cacheable"] != 1 {
-		t.Fatalf("unexpected count for order by reason: %d", reasons["query has 'order by ?' is un-cacheable"])
-	}
-	observePlanCacheWarnings(reasons, []string{
-		"Warning:1105:skip plan-cache: sub-queries are un-cacheable",
+	stats.observe(oracle.Result{OK: true, Metrics: map[string]int64{oracle.PlanCacheOnlyHitSecondMetric: 1}})
+	stats.observe(oracle.Result{
+		OK:      true,
+		Metrics: map[string]int64{oracle.PlanCacheOnlyFirstSkipWithWarningsMetric: 1},
+		Details: map[string]any{oracle.PlanCacheWarningReasonsKey: []string{"sub-queries are un-cacheable", "limit ? is un-cacheable"}},
 	})
-	if reasons["sub-queries are un-cacheable"] != 2 {
-		t.Fatalf("unexpected count after second event: %d", reasons["sub-queries are un-cacheable"])
+	want := "plan_cache_only stats total=3 invalid=0 exec_errors=0 hit_first_unexpected=0 hit_second=1 miss_second=1 " +
+		"miss_second_with_warnings=1 first_skip_with_warnings=1 warning_reasons=limit ? is un-cacheable=1,sub-queries are un-cacheable=2"
+	if got := stats.String(); got != want {
+		t.Fatalf("unexpected stats:\n got %s\nwant %s", got, want)
 	}
 }
 
@@ -85,55 +53,3 @@ func TestFormatPlanCacheWarningReasons(t *testing.T) {
 		t.Fatalf("unexpected formatted reasons: %q", got)
 	}
 }
-
-func TestPlanCacheMPPDisableStatements(t *testing.T) {
-	got := planCacheMPPDisableStatements()
-	want := []string{
-		"SET SESSION tidb_allow_mpp=OFF",
-		"SET SESSION tidb_enforce_mpp=OFF",
-	}
-	if len(got) != len(want) {
-		t.Fatalf("unexpected statements count: got=%d want=%d", len(got), len(want))
-	}
-	for i := range want {
-		if got[i] != want[i] {
-			t.Fatalf("unexpected statement at %d: got=%q want=%q", i, got[i], want[i])
-		}
-	}
-}
-
-func TestShouldIgnorePlanCacheMPPDisableError(t *testing.T) {
-	cases := []struct {
-		name   string
-		err    error
-		expect bool
-	}{
-		{
-			name:   "nil",
-			err:    nil,
-			expect: false,
-		},
-		{
-			name:   "unknown system variable code",
-			err:    &mysql.MySQLError{Number: 1193, Message: "Unknown system variable"},
-			expect: true,
-		},
-		{
-			name:   "unknown system variable text",
-			err:    errors.New("Error 1105 (HY000): Unknown system variable 'tidb_enforce_mpp'"),
-			expect: true,
-		},
-		{
-			name:   "other mysql error",
-			err:    &mysql.MySQLError{Number: 1064, Message: "syntax error"},
-			expect: false,
-		},
-	}
-	for _, tc := range cases {
-		t.Run(tc.name, func(t *testing.T) {
-			if got := shouldIgnorePlanCacheMPPDisableError(tc.err); got != tc.expect {
-				t.Fatalf("unexpected result: got=%t expect=%t", got, tc.expect)
-			}
-		})
-	}
-}
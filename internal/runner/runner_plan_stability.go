@@ -0,0 +1,303 @@
+package runner
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/bits"
+	"sort"
+	"strconv"
+	"strings"
+
+	"shiro/internal/generator"
+	"shiro/internal/oracle"
+	"shiro/internal/sqlstep"
+	"shiro/internal/util"
+)
+
+const (
+	planStabilityGenerateAttempts = 4
+	planStabilityBugHint          = "tidb:plan_stability"
+)
+
+// planStabilityState tracks the plans of a fixed probe query set between
+// schema and statistics changes. It is reset when the database rotates.
+type planStabilityState struct {
+	probes     []planStabilityProbe
+	stats      string
+	stale      bool
+	iterations int
+	checks     int
+	cases      int
+}
+
+type planStabilityProbe struct {
+	sql   string
+	shape string
+	plan  string
+}
+
+// notePlanStabilityStatement marks the probe plans stale after DDL or ANALYZE
+// so the next check re-records them instead of comparing.
+func (r *Runner) notePlanStabilityStatement(sqlText string) {
+	if r.planStability == nil || !isPlanStabilityChange(sqlText) {
+		return
+	}
+	r.planStability.stale = true
+}
+
+func isPlanStabilityChange(sqlText string) bool {
+	return isSchemaChange(sqlText) || strings.HasPrefix(strings.ToUpper(strings.TrimSpace(sqlText)), "ANALYZE ")
+}
+
+// checkPlanStability runs every CheckInterval iterations. It records the probe
+// plans when they are stale or when statistics changed since the recording,
+// and otherwise compares the current plans with the recorded ones.
+func (r *Runner) checkPlanStability(ctx context.Context) {
+	if !r.cfg.PlanStability.Enabled || r.gen == nil {
+		return
+	}
+	if r.planStability == nil {
+		r.planStability = &planStabilityState{stale: true}
+	}
+	state := r.planStability
+	state.iterations++
+	if state.iterations%r.cfg.PlanStability.CheckInterval != 0 {
+		return
+	}
+	// A flip is reported as a case, which can rotate the database.
+	r.drainPipeline(ctx)
+	stats, err := r.planStabilityStatsFingerprint(ctx)
+	if err != nil {
+		util.Detailf("plan stability stats fingerprint failed: %v", err)
+		return
+	}
+	if state.stale || stats != state.stats || len(state.probes) == 0 {
+		r.recordPlanStabilityProbes(ctx, stats)
+		return
+	}
+	state.checks++
+	kept := state.probes[:0]
+	for _, probe := range state.probes {
+		shape, plan, err := r.explainPlanStabilityProbe(ctx, probe.sql)
+		if err != nil {
+			continue
+		}
+		if shape != probe.shape {
+			util.Warnf("plan stability flip without schema/stats change sql=%s", probe.sql)
+			r.reportPlanStabilityFlip(ctx, probe, planStabilityProbe{sql: probe.sql, shape: shape, plan: plan}, state.checks)
+			if r.planStability != state {
+				// Capturing a case rotates the database and resets the state.
+				return
+			}
+			probe.shape, probe.plan = shape, plan
+		}
+		kept = append(kept, probe)
+	}
+	state.probes = kept
+}
+
+// recordPlanStabilityProbes fills the probe set up to the configured size and
+// records the current plan of every probe.
+func (r *Runner) recordPlanStabilityProbes(ctx context.Context, stats string) {
+	state := r.planStability
+	probes := make([]planStabilityProbe, 0, r.cfg.PlanStability.Probes)
+	for _, probe := range state.probes {
+		shape, plan, err := r.explainPlanStabilityProbe(ctx, probe.sql)
+		if err != nil {
+			continue
+		}
+		probes = append(probes, planStabilityProbe{sql: probe.sql, shape: shape, plan: plan})
+	}
+	for attempts := 0; len(probes) < r.cfg.PlanStability.Probes && attempts < r.cfg.PlanStability.Probes*planStabilityGenerateAttempts; attempts++ {
+		query := r.gen.GenerateSelectQuery()
+		if query == nil || generator.AnalyzeQueryFeatures(query).HasSubquery {
+			// Uncorrelated subqueries may be evaluated while planning, which
+			// ties the plan to the data instead of the statistics.
+			continue
+		}
+		sqlText := query.SQLString()
+		shape, plan, err := r.explainPlanStabilityProbe(ctx, sqlText)
+		if err != nil {
+			continue
+		}
+		probes = append(probes, planStabilityProbe{sql: sqlText, shape: shape, plan: plan})
+	}
+	state.probes = probes
+	state.stats = stats
+	state.stale = false
+	state.checks = 0
+	util.Detailf("plan stability probes recorded count=%d", len(probes))
+}
+
+func (r *Runner) reportPlanStabilityFlip(ctx context.Context, before planStabilityProbe, after planStabilityProbe, checks int) {
+	state := r.planStability
+	if state.cases >= r.cfg.PlanStability.MaxCases {
+		return
+	}
+	state.cases++
+	explain := "EXPLAIN " + before.sql
+	steps := []sqlstep.Step{
+		sqlstep.New(sqlstep.KindQuery, sqlstep.RoleReplay, before.sql),
+		sqlstep.New(sqlstep.KindVerify, "", explain),
+	}
+	r.handleResult(ctx, oracle.Result{
+		OK:       false,
+		Oracle:   "CERT",
+		Steps:    steps,
+		Expected: before.plan,
+		Actual:   after.plan,
+		Details: map[string]any{
+			"cert_check":                  "plan_stability",
+			"severity":                    "low",
+			"bug_hint":                    planStabilityBugHint,
+			"plan_stability_checks":       checks,
+			"plan_stability_shape_before": before.shape,
+			"plan_stability_shape_after":  after.shape,
+		},
+	})
+}
+
+// explainPlanStabilityProbe returns the plan shape and the plan text of a
+// probe. The shape keeps operator, depth, task, and access object, and drops
+// estimates and operator ids so row-count drift alone is not a flip.
+func (r *Runner) explainPlanStabilityProbe(ctx context.Context, sqlText string) (string, string, error) {
+	cols, rows, err := r.explainRows(ctx, sqlText)
+	if err != nil {
+		return "", "", err
+	}
+	return planStabilityShape(cols, rows), planText(cols, rows), nil
+}
+
+// explainRows runs EXPLAIN for a statement and returns its rows as strings.
+func (r *Runner) explainRows(ctx context.Context, sqlText string) ([]string, [][]string, error) {
+	qctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	return r.queryStringRows(qctx, "EXPLAIN "+sqlText)
+}
+
+// planText renders EXPLAIN rows as tab-separated lines with a header.
+func planText(cols []string, rows [][]string) string {
+	lines := make([]string, 0, len(rows)+1)
+	lines = append(lines, strings.Join(cols, "\t"))
+	for _, row := range rows {
+		lines = append(lines, strings.Join(row, "\t"))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func planStabilityShape(cols []string, rows [][]string) string {
+	idIdx, taskIdx, accessIdx := -1, -1, -1
+	for i, col := range cols {
+		switch strings.ToLower(col) {
+		case "id":
+			idIdx = i
+		case "task":
+			taskIdx = i
+		case "access object":
+			accessIdx = i
+		}
+	}
+	if idIdx < 0 {
+		return ""
+	}
+	var b strings.Builder
+	for _, row := range rows {
+		depth, op := parsePlanNode(row[idIdx])
+		if op == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "%d:%s", depth, op)
+		if taskIdx >= 0 {
+			b.WriteString("@" + row[taskIdx])
+		}
+		if accessIdx >= 0 && row[accessIdx] != "" {
+			b.WriteString("[" + row[accessIdx] + "]")
+		}
+		b.WriteByte(';')
+	}
+	return b.String()
+}
+
+// planStabilityStatsFingerprint summarizes the statistics that may move plans:
+// histogram versions (changed by manual and auto ANALYZE) and table row counts
+// bucketed by power of two, so ordinary DML does not count as a change.
+func (r *Runner) planStabilityStatsFingerprint(ctx context.Context) (string, error) {
+	qctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	dbName := strings.ReplaceAll(r.cfg.Database, "'", "''")
+	entries := make([]string, 0, 16)
+	metaCols, metaRows, err := r.queryStringRows(qctx, fmt.Sprintf("SHOW STATS_META WHERE Db_name = '%s'", dbName))
+	if err != nil {
+		return "", err
+	}
+	for _, row := range metaRows {
+		count, _ := strconv.ParseInt(rowValue(metaCols, row, "row_count"), 10, 64)
+		entries = append(entries, fmt.Sprintf("meta:%s:%s:%d", rowValue(metaCols, row, "table_name"), rowValue(metaCols, row, "partition_name"), planStabilityRowBucket(count)))
+	}
+	histCols, histRows, err := r.queryStringRows(qctx, fmt.Sprintf("SHOW STATS_HISTOGRAMS WHERE Db_name = '%s'", dbName))
+	if err != nil {
+		return "", err
+	}
+	for _, row := range histRows {
+		entries = append(entries, fmt.Sprintf("hist:%s:%s:%s:%s:%s",
+			rowValue(histCols, row, "table_name"),
+			rowValue(histCols, row, "partition_name"),
+			rowValue(histCols, row, "column_name"),
+			rowValue(histCols, row, "is_index"),
+			rowValue(histCols, row, "update_time"),
+		))
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, "\n"), nil
+}
+
+func planStabilityRowBucket(count int64) int {
+	if count <= 0 {
+		return 0
+	}
+	return bits.Len64(uint64(count))
+}
+
+func (r *Runner) queryStringRows(ctx context.Context, query string) ([]string, [][]string, error) {
+	rows, err := r.exec.QueryContext(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer util.CloseWithErr(rows, "plan stability rows")
+	return scanStringRows(rows)
+}
+
+func scanStringRows(rows *sql.Rows) ([]string, [][]string, error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, nil, err
+	}
+	values := make([]sql.RawBytes, len(cols))
+	scanArgs := make([]any, len(values))
+	for i := range values {
+		scanArgs[i] = &values[i]
+	}
+	var out [][]string
+	for rows.Next() {
+		if err := rows.Scan(scanArgs...); err != nil {
+			return nil, nil, err
+		}
+		row := make([]string, len(values))
+		for i, v := range values {
+			row[i] = string(v)
+		}
+		out = append(out, row)
+	}
+	return cols, out, rows.Err()
+}
+
+// rowValue returns the value of a column matched case-insensitively.
+func rowValue(cols []string, row []string, name string) string {
+	for i, col := range cols {
+		if strings.EqualFold(col, name) && i < len(row) {
+			return row[i]
+		}
+	}
+	return ""
+}
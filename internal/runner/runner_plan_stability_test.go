@@ -0,0 +1,98 @@
+package runner
+
+import (
+	"testing"
+
+	"shiro/internal/schema"
+)
+
+func TestPlanStabilityShapeIgnoresEstimates(t *testing.T) {
+	cols := []string{"id", "estRows", "task", "access object", "operator info"}
+	before := [][]string{
+		{"Projection_4", "10.00", "root", "", "t0.c0"},
+		{"└─IndexReader_7", "10.00", "root", "", "index:IndexRangeScan_6"},
+		{"  └─IndexRangeScan_6", "10.00", "cop[tikv]", "table:t0, index:idx_c0(c0)", "range:[1,+inf]"},
+	}
+	drift := [][]string{
+		{"Projection_5", "120.00", "root", "", "t0.c0"},
+		{"└─IndexReader_8", "120.00", "root", "", "index:IndexRangeScan_7"},
+		{"  └─IndexRangeScan_7", "120.00", "cop[tikv]", "table:t0, index:idx_c0(c0)", "range:[1,+inf]"},
+	}
+	flipped := [][]string{
+		{"Projection_4", "10.00", "root", "", "t0.c0"},
+		{"└─IndexReader_7", "10.00", "root", "", "index:IndexRangeScan_6"},
+		{"  └─IndexRangeScan_6", "10.00", "cop[tikv]", "table:t0, index:idx_c1(c1, c0)", "range:[1,+inf]"},
+	}
+	base := planStabilityShape(cols, before)
+	if base == "" {
+		t.Fatalf("empty shape")
+	}
+	if got := planStabilityShape(cols, drift); got != base {
+		t.Fatalf("estimate drift changed shape: %q vs %q", got, base)
+	}
+	if got := planStabilityShape(cols, flipped); got == base {
+		t.Fatalf("index flip kept shape %q", got)
+	}
+}
+
+func TestIsPlanStabilityChange(t *testing.T) {
+	cases := map[string]bool{
+		"ANALYZE TABLE t0":                  true,
+		"create index idx_c0 on t0(c0)":     true,
+		"ALTER TABLE t1 ADD CONSTRAINT fk0": true,
+		"INSERT INTO t0 VALUES (1)":         false,
+		"UPDATE t0 SET c0 = 1":              false,
+	}
+	for sqlText, want := range cases {
+		if got := isPlanStabilityChange(sqlText); got != want {
+			t.Fatalf("isPlanStabilityChange(%q)=%v want=%v", sqlText, got, want)
+		}
+	}
+}
+
+func TestNoteSchemaChangeBumpsEpoch(t *testing.T) {
+	r := &Runner{state: &schema.State{}}
+	r.noteSchemaChange("INSERT INTO t0 VALUES (1)")
+	r.noteSchemaChange("ANALYZE TABLE t0")
+	if r.state.Epoch() != 0 {
+		t.Fatalf("DML and ANALYZE must not bump the epoch")
+	}
+	r.noteSchemaChange("CREATE TABLE t0 (id BIGINT)")
+	first := r.state.Epoch()
+	r.noteSchemaChange("alter table t0 add index idx_c0 (c0)")
+	if first == 0 || r.state.Epoch() <= first {
+		t.Fatalf("DDL should bump the epoch: %d then %d", first, r.state.Epoch())
+	}
+	if clone := r.state.Clone(); clone.Epoch() != r.state.Epoch() {
+		t.Fatalf("clone should keep the epoch")
+	}
+}
+
+func TestPlanStabilityRowBucket(t *testing.T) {
+	if planStabilityRowBucket(0) != 0 {
+		t.Fatalf("empty table bucket=%d", planStabilityRowBucket(0))
+	}
+	if planStabilityRowBucket(40) != planStabilityRowBucket(60) {
+		t.Fatalf("40 and 60 rows should share a bucket")
+	}
+	if planStabilityRowBucket(60) == planStabilityRowBucket(70) {
+		t.Fatalf("60 and 70 rows should not share a bucket")
+	}
+}
+
+func TestNotePlanStabilityStatement(t *testing.T) {
+	r := &Runner{}
+	r.notePlanStabilityStatement("ANALYZE TABLE t0")
+	if r.planStability != nil {
+		t.Fatalf("state created before the first check")
+	}
+	r.planStability = &planStabilityState{}
+	r.notePlanStabilityStatement("INSERT INTO t0 VALUES (1)")
+	if r.planStability.stale {
+		t.Fatalf("DML marked probes stale")
+	}
+	r.notePlanStabilityStatement("ANALYZE TABLE t0")
+	if !r.planStability.stale {
+		t.Fatalf("ANALYZE did not mark probes stale")
+	}
+}
@@ -97,9 +97,19 @@ func (r *Runner) observePlanInfo(ctx context.Context, info planInfo) {
 	if r.qpgState == nil {
 		return
 	}
+	var active *generator.AdaptiveWeights
+	if r.qpgState.focus != nil {
+		active = r.adaptiveSnapshot()
+	}
 	r.qpgMu.Lock()
 	obs := r.qpgState.observe(info)
+	if r.qpgState.focus != nil {
+		obs.focusHit = r.qpgState.focus.observe(info, active, r.cfg.QPG.FocusMissReset)
+	}
 	r.qpgMu.Unlock()
+	if obs.focusHit {
+		return
+	}
 	if !obs.newPlan && r.cfg.QPG.MutationProb > 0 && util.Chance(r.gen.Rand, r.cfg.QPG.MutationProb) {
 		r.qpgMutate(ctx)
 	}
@@ -145,12 +155,14 @@ type qpgState struct {
 	seenSQLTTL         int64
 	seenSQLMax         int
 	seenSQLSweep       int64
+	focus              *qpgFocus
 }
 
 type qpgObservation struct {
 	newPlan     bool
 	newOp       bool
 	newJoinType bool
+	focusHit    bool
 }
 
 func newQPGState(cfg config.QPGConfig) *qpgState {
@@ -177,6 +189,7 @@ func newQPGState(cfg config.QPGConfig) *qpgState {
 		seenSQLTTL:    int64(ttl),
 		seenSQLMax:    maxEntries,
 		seenSQLSweep:  int64(sweep),
+		focus:         newQPGFocus(cfg.FocusPlanSignature),
 	}
 }
 
@@ -395,6 +408,12 @@ func (r *Runner) applyQPGWeights() bool {
 		base = *snapshot
 	}
 	r.qpgMu.Lock()
+	if focus := r.qpgState.focus; focus != nil {
+		weights := focus.weights(base, r.cfg.MaxJoinTables)
+		r.qpgMu.Unlock()
+		r.setAdaptiveWeights(weights)
+		return true
+	}
 	if r.qpgState.overrideTTL <= 0 {
 		setOverride := false
 		if r.qpgState.noJoin >= tuning.NoJoinThreshold {
@@ -504,6 +523,16 @@ func (r *Runner) applyQPGTemplateWeights() bool {
 	tuning := r.cfg.QPG.TemplateOverride
 	base := generator.DefaultTemplateWeights()
 	r.qpgMu.Lock()
+	if focus := r.qpgState.focus; focus != nil {
+		override, ok := focus.templateWeights()
+		r.qpgMu.Unlock()
+		if !ok {
+			r.clearTemplateWeights()
+			return false
+		}
+		r.setTemplateWeights(override)
+		return true
+	}
 	if r.qpgState.templateTTL <= 0 {
 		setOverride := false
 		override := base
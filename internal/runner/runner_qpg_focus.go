@@ -0,0 +1,154 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	"shiro/internal/generator"
+)
+
+const (
+	qpgFocusJoinAggProb   = 80
+	qpgFocusSubqCount     = 3
+	qpgFocusTemplateBoost = 10
+	qpgFocusTemplateProb  = 60
+)
+
+// qpgFocus steers QPG toward plans whose signature starts with a fixed prefix.
+// Unlike the novelty overrides it never expires: weights that produced a hit
+// are kept until the focus stops matching for a while.
+type qpgFocus struct {
+	prefix     string
+	joinCount  int
+	wantAgg    bool
+	wantSubq   bool
+	hits       int64
+	misses     int64
+	missStreak int
+	matched    *generator.AdaptiveWeights
+	lastLogged string
+}
+
+// newQPGFocus parses a focus prefix. The prefix may be a plan hash prefix or an
+// operator signature prefix such as "HashJoin;TableReader" or "0:HashAgg;1:Projection".
+func newQPGFocus(prefix string) *qpgFocus {
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" {
+		return nil
+	}
+	focus := &qpgFocus{prefix: prefix}
+	joins := 0
+	for _, token := range strings.Split(prefix, ";") {
+		op := strings.ToLower(strings.TrimSpace(token))
+		if idx := strings.Index(op, ":"); idx >= 0 {
+			op = op[idx+1:]
+		}
+		if op == "" {
+			continue
+		}
+		switch {
+		case strings.Contains(op, "apply"), strings.Contains(op, "semi"), strings.Contains(op, "anti"):
+			focus.wantSubq = true
+		case strings.Contains(op, "join"):
+			joins++
+		}
+		if strings.Contains(op, "agg") {
+			focus.wantAgg = true
+		}
+	}
+	if joins > 0 {
+		focus.joinCount = joins + 1
+	}
+	return focus
+}
+
+// matches reports whether a plan falls under the focus prefix.
+func (f *qpgFocus) matches(info planInfo) bool {
+	if f == nil || info.signature == "" {
+		return false
+	}
+	lowerPrefix := strings.ToLower(f.prefix)
+	if strings.HasPrefix(info.signature, lowerPrefix) {
+		return true
+	}
+	if hasFoldPrefix(info.shapeSig, f.prefix) || hasFoldPrefix(info.opSig, f.prefix) {
+		return true
+	}
+	return false
+}
+
+func hasFoldPrefix(value string, prefix string) bool {
+	return len(value) >= len(prefix) && strings.EqualFold(value[:len(prefix)], prefix)
+}
+
+// observe records a plan against the focus. Weights active when a hit occurs
+// are remembered so the next queries reuse them.
+func (f *qpgFocus) observe(info planInfo, active *generator.AdaptiveWeights, missReset int) bool {
+	if f.matches(info) {
+		f.hits++
+		f.missStreak = 0
+		if active != nil {
+			weights := *active
+			f.matched = &weights
+		}
+		return true
+	}
+	f.misses++
+	f.missStreak++
+	if missReset > 0 && f.missStreak >= missReset {
+		f.matched = nil
+		f.missStreak = 0
+	}
+	return false
+}
+
+// weights returns the adaptive weights to apply for the next query.
+func (f *qpgFocus) weights(base generator.AdaptiveWeights, maxJoinTables int) generator.AdaptiveWeights {
+	if f.matched != nil {
+		return *f.matched
+	}
+	if f.joinCount > 0 {
+		base.JoinCount = max(base.JoinCount, f.joinCount)
+		if maxJoinTables > 0 {
+			base.JoinCount = min(base.JoinCount, maxJoinTables)
+		}
+	}
+	if f.wantAgg {
+		base.AggProb = max(base.AggProb, qpgFocusJoinAggProb)
+	}
+	if f.wantSubq {
+		base.SubqCount = max(base.SubqCount, qpgFocusSubqCount)
+	}
+	return base
+}
+
+// templateWeights boosts the template family that is most likely to produce
+// the focused operators. ok is false when the prefix carries no operator hint.
+func (f *qpgFocus) templateWeights() (weights generator.TemplateWeights, ok bool) {
+	weights = generator.DefaultTemplateWeights()
+	if f.joinCount > 0 {
+		weights.JoinReorder = max(weights.JoinReorder, qpgFocusTemplateBoost)
+		ok = true
+	}
+	if f.wantAgg {
+		weights.AggPushdown = max(weights.AggPushdown, qpgFocusTemplateBoost)
+		ok = true
+	}
+	if f.wantSubq {
+		weights.SemiAnti = max(weights.SemiAnti, qpgFocusTemplateBoost)
+		ok = true
+	}
+	if ok {
+		weights.EnabledProb = max(weights.EnabledProb, qpgFocusTemplateProb)
+	}
+	return weights, ok
+}
+
+func (f *qpgFocus) summary() string {
+	total := f.hits + f.misses
+	rate := 0.0
+	if total > 0 {
+		rate = float64(f.hits) / float64(total)
+	}
+	return fmt.Sprintf("prefix=%s hits=%d misses=%d hit_rate=%.3f converged=%t", f.prefix, f.hits, f.misses, rate, f.matched != nil)
+}
@@ -145,3 +145,76 @@ func TestQPGAnalyzeCandidatesFallbackToViewDependencies(t *testing.T) {
 		t.Fatalf("unexpected fallback analyze candidates: %#v", candidates)
 	}
 }
+
+func TestQPGFocusMatchesAndConverges(t *testing.T) {
+	focus := newQPGFocus("HashJoin;HashAgg")
+	if focus == nil {
+		t.Fatalf("expected focus to be created")
+	}
+	if focus.joinCount != 2 || !focus.wantAgg || focus.wantSubq {
+		t.Fatalf("unexpected focus hints: join=%d agg=%t subq=%t", focus.joinCount, focus.wantAgg, focus.wantSubq)
+	}
+	if newQPGFocus("  ") != nil {
+		t.Fatalf("expected empty prefix to disable focus")
+	}
+	hit := planInfo{signature: "abc123", opSig: "HashJoin;HashAgg;TableReader;"}
+	miss := planInfo{signature: "def456", opSig: "Projection;TableReader;"}
+	if !focus.matches(hit) {
+		t.Fatalf("expected op signature prefix to match")
+	}
+	if focus.matches(miss) {
+		t.Fatalf("unexpected match for %q", miss.opSig)
+	}
+	if !newQPGFocus("ABC").matches(hit) {
+		t.Fatalf("expected plan hash prefix to match")
+	}
+
+	active := &generator.AdaptiveWeights{JoinCount: 4, AggProb: 90}
+	if !focus.observe(hit, active, 2) {
+		t.Fatalf("expected focus hit")
+	}
+	weights := focus.weights(generator.AdaptiveWeights{JoinCount: 1}, 8)
+	if weights != *active {
+		t.Fatalf("expected matched weights to be reused, got %+v", weights)
+	}
+	focus.observe(miss, nil, 2)
+	focus.observe(miss, nil, 2)
+	if focus.matched != nil {
+		t.Fatalf("expected matched weights to reset after miss streak")
+	}
+	weights = focus.weights(generator.AdaptiveWeights{JoinCount: 1, AggProb: 10}, 8)
+	if weights.JoinCount != 2 || weights.AggProb != qpgFocusJoinAggProb {
+		t.Fatalf("unexpected hinted weights: %+v", weights)
+	}
+	if focus.hits != 1 || focus.misses != 2 {
+		t.Fatalf("unexpected focus counters hits=%d misses=%d", focus.hits, focus.misses)
+	}
+}
+
+func TestApplyQPGWeightsUsesFocusInsteadOfNovelty(t *testing.T) {
+	cfg := config.Config{
+		MaxJoinTables: 8,
+		Weights: config.Weights{
+			Features: config.FeatureWeights{JoinCount: 1, SubqCount: 1, AggProb: 20},
+		},
+		QPG: config.QPGConfig{
+			Enabled:            true,
+			NoJoinThreshold:    1,
+			OverrideTTL:        2,
+			FocusPlanSignature: "0:Apply",
+			FocusMissReset:     5,
+		},
+	}
+	r := newTestRunnerForQPG(cfg)
+	r.qpgState.noJoin = 1
+
+	if !r.applyQPGWeights() {
+		t.Fatalf("expected focus weights to be applied")
+	}
+	if r.qpgState.override != nil {
+		t.Fatalf("expected novelty override to stay inactive in focus mode")
+	}
+	if r.gen.Adaptive == nil || r.gen.Adaptive.SubqCount != qpgFocusSubqCount {
+		t.Fatalf("unexpected focus adaptive weights: %+v", r.gen.Adaptive)
+	}
+}
@@ -0,0 +1,132 @@
+package runner
+
+import (
+	"fmt"
+	"sort"
+
+	"shiro/internal/generator"
+)
+
+// intHistogram counts exact integer values. The values it tracks (joins,
+// predicate nodes, subquery depth, SQL length) have few distinct values, so
+// exact counts stay small and percentiles need no bucketing.
+type intHistogram struct {
+	counts map[int]int64
+	total  int64
+	sum    int64
+	max    int
+}
+
+func (h *intHistogram) observe(v int) {
+	if h.counts == nil {
+		h.counts = make(map[int]int64)
+	}
+	h.counts[v]++
+	h.total++
+	h.sum += int64(v)
+	h.max = max(h.max, v)
+}
+
+// percentile returns the nearest-rank percentile.
+func (h *intHistogram) percentile(p float64) int {
+	if h.total == 0 {
+		return 0
+	}
+	values := make([]int, 0, len(h.counts))
+	for v := range h.counts {
+		values = append(values, v)
+	}
+	sort.Ints(values)
+	rank := int64(p * float64(h.total))
+	if float64(rank) < p*float64(h.total) {
+		rank++
+	}
+	rank = max(rank, 1)
+	var seen int64
+	for _, v := range values {
+		seen += h.counts[v]
+		if seen >= rank {
+			return v
+		}
+	}
+	return h.max
+}
+
+func (h *intHistogram) summary() queryShapeDist {
+	out := queryShapeDist{
+		P50: h.percentile(0.50),
+		P90: h.percentile(0.90),
+		P99: h.percentile(0.99),
+		Max: h.max,
+	}
+	if h.total > 0 {
+		out.Mean = float64(h.sum) / float64(h.total)
+	}
+	return out
+}
+
+// queryShapeStats holds the size distributions of generated queries.
+type queryShapeStats struct {
+	joins          intHistogram
+	predicateNodes intHistogram
+	subqueryDepth  intHistogram
+	sqlLength      intHistogram
+}
+
+func (s *queryShapeStats) observe(features *generator.QueryFeatures) {
+	s.joins.observe(features.JoinCount)
+	s.predicateNodes.observe(features.PredicateNodes)
+	s.subqueryDepth.observe(features.SubqueryDepth)
+	s.sqlLength.observe(features.SQLLength)
+}
+
+// queryShapeSummary is the run summary form of queryShapeStats.
+type queryShapeSummary struct {
+	Queries        int64          `json:"queries"`
+	Joins          queryShapeDist `json:"joins"`
+	PredicateNodes queryShapeDist `json:"predicate_nodes"`
+	SubqueryDepth  queryShapeDist `json:"subquery_depth"`
+	SQLLength      queryShapeDist `json:"sql_length"`
+}
+
+type queryShapeDist struct {
+	P50  int     `json:"p50"`
+	P90  int     `json:"p90"`
+	P99  int     `json:"p99"`
+	Max  int     `json:"max"`
+	Mean float64 `json:"mean"`
+}
+
+func (s *queryShapeStats) summary() *queryShapeSummary {
+	if s.joins.total == 0 {
+		return nil
+	}
+	return &queryShapeSummary{
+		Queries:        s.joins.total,
+		Joins:          s.joins.summary(),
+		PredicateNodes: s.predicateNodes.summary(),
+		SubqueryDepth:  s.subqueryDepth.summary(),
+		SQLLength:      s.sqlLength.summary(),
+	}
+}
+
+// logLine formats the distributions as p50/p90/p99/max per dimension.
+func (s *queryShapeSummary) logLine() string {
+	dist := func(d queryShapeDist) string {
+		return fmt.Sprintf("%d/%d/%d/%d", d.P50, d.P90, d.P99, d.Max)
+	}
+	return fmt.Sprintf("queries=%d joins=%s predicate_nodes=%s subquery_depth=%s sql_len=%s (p50/p90/p99/max)",
+		s.Queries, dist(s.Joins), dist(s.PredicateNodes), dist(s.SubqueryDepth), dist(s.SQLLength))
+}
+
+// observeQueryShape records the query in the run-wide and the interval
+// distributions.
+func (r *Runner) observeQueryShape(features *generator.QueryFeatures) {
+	if features == nil {
+		return
+	}
+	r.statsMu.Lock()
+	defer r.statsMu.Unlock()
+	r.queryShape.observe(features)
+	r.queryShapeInterval.observe(features)
+}
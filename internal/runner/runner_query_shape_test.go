@@ -0,0 +1,46 @@
+package runner
+
+import (
+	"testing"
+
+	"shiro/internal/generator"
+)
+
+func TestIntHistogramPercentile(t *testing.T) {
+	var h intHistogram
+	if h.percentile(0.5) != 0 {
+		t.Fatalf("empty histogram should report 0")
+	}
+	for v := 1; v <= 100; v++ {
+		h.observe(v)
+	}
+	if got := h.percentile(0.50); got != 50 {
+		t.Fatalf("p50=%d want=50", got)
+	}
+	if got := h.percentile(0.99); got != 99 {
+		t.Fatalf("p99=%d want=99", got)
+	}
+	if got := h.summary(); got.Max != 100 || got.Mean != 50.5 {
+		t.Fatalf("unexpected summary: %+v", got)
+	}
+}
+
+func TestQueryShapeSummary(t *testing.T) {
+	r := &Runner{}
+	if r.queryShape.summary() != nil {
+		t.Fatalf("summary without queries should be nil")
+	}
+	r.observeQueryShape(&generator.QueryFeatures{JoinCount: 2, PredicateNodes: 7, SubqueryDepth: 1, SQLLength: 120})
+	r.observeQueryShape(&generator.QueryFeatures{JoinCount: 0, PredicateNodes: 3, SQLLength: 40})
+	summary := r.queryShape.summary()
+	if summary == nil || summary.Queries != 2 || summary.Joins.Max != 2 || summary.SQLLength.P50 != 40 || summary.SubqueryDepth.Max != 1 {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+	if r.queryShapeInterval.summary().Queries != 2 {
+		t.Fatalf("interval stats should see the same queries")
+	}
+	want := "queries=2 joins=0/2/2/2 predicate_nodes=3/7/7/7 subquery_depth=0/1/1/1 sql_len=40/120/120/120 (p50/p90/p99/max)"
+	if got := summary.logLine(); got != want {
+		t.Fatalf("logLine()=%q want=%q", got, want)
+	}
+}
@@ -0,0 +1,207 @@
+package runner
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"shiro/internal/config"
+	"shiro/internal/db"
+	"shiro/internal/generator"
+	"shiro/internal/util"
+)
+
+const readinessProbeTimeout = 10 * time.Second
+
+// readinessStatus is one round of readiness probes.
+type readinessStatus struct {
+	pingErr    error
+	pdMembers  int
+	tikvUp     int
+	tiflashUp  int
+	storesErr  error
+	schemaErr  error
+	statsErr   error
+	storesSeen bool
+	// noPD is set when CLUSTER_INFO lists no PD member, as on unistore and
+	// mocktikv. Such a server has no TiKV stores to wait for.
+	noPD bool
+}
+
+// WaitReady blocks until the cluster behind cfg.DSN passes the readiness
+// probes. Runs started right after a deployment otherwise spend their first
+// minutes on connection refusals, region errors, and pseudo statistics.
+func WaitReady(ctx context.Context, cfg config.Config) error {
+	rc := cfg.Readiness
+	if !rc.Enabled {
+		return nil
+	}
+	exec, err := db.Open(config.AdminDSN(cfg.DSN))
+	if err != nil {
+		return err
+	}
+	defer util.CloseWithErr(exec, "readiness db")
+	timeout := time.Duration(rc.TimeoutSeconds) * time.Second
+	interval := time.Duration(rc.PollIntervalSeconds) * time.Second
+	started := time.Now()
+	for attempt := 1; ; attempt++ {
+		probeCtx, cancel := context.WithTimeout(ctx, readinessProbeTimeout)
+		status := probeReadiness(probeCtx, exec, rc)
+		cancel()
+		reason := status.notReadyReason(rc)
+		if reason == "" {
+			if status.noPD {
+				util.Infof("readiness skipped the store check: no pd member in CLUSTER_INFO")
+			}
+			util.Infof("cluster ready after %s attempts=%d pd=%d tikv_up=%d tiflash_up=%d",
+				time.Since(started).Round(time.Second), attempt, status.pdMembers, status.tikvUp, status.tiflashUp)
+			return nil
+		}
+		if time.Since(started) >= timeout {
+			err := fmt.Errorf("cluster not ready after %s: %s", timeout, reason)
+			if rc.FailOnTimeout {
+				return err
+			}
+			util.Warnf("%v; starting anyway", err)
+			return nil
+		}
+		util.Infof("cluster not ready attempt=%d reason=%s", attempt, reason)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// probeReadiness runs the probes in dependency order and stops at the first
+// one that cannot succeed yet.
+func probeReadiness(ctx context.Context, exec *db.DB, rc config.ReadinessConfig) readinessStatus {
+	var status readinessStatus
+	var one int
+	if status.pingErr = exec.QueryRowContext(ctx, "SELECT 1").Scan(&one); status.pingErr != nil {
+		return status
+	}
+	if rc.MinTiKVStores > 0 || rc.MinTiFlashStores > 0 {
+		if status.storesErr = exec.QueryRowContext(ctx, "SELECT COUNT(*) FROM information_schema.CLUSTER_INFO WHERE TYPE = 'pd'").Scan(&status.pdMembers); status.storesErr != nil {
+			return status
+		}
+		status.noPD = status.pdMembers == 0
+		if !status.noPD {
+			status.storesSeen = true
+			if status.tikvUp, status.tiflashUp, status.storesErr = countUpStores(ctx, exec); status.storesErr != nil {
+				return status
+			}
+		}
+	}
+	var schemas int
+	status.schemaErr = exec.QueryRowContext(ctx, "SELECT COUNT(*) FROM information_schema.SCHEMATA WHERE SCHEMA_NAME = 'mysql'").Scan(&schemas)
+	if status.schemaErr == nil && schemas == 0 {
+		status.schemaErr = fmt.Errorf("mysql schema not loaded")
+	}
+	if status.schemaErr != nil {
+		return status
+	}
+	rows, err := exec.QueryContext(ctx, "SHOW STATS_META WHERE Db_name = 'mysql'")
+	if err != nil {
+		status.statsErr = err
+		return status
+	}
+	_, _, status.statsErr = scanStringRows(rows)
+	util.CloseWithErr(rows, "readiness stats rows")
+	return status
+}
+
+func countUpStores(ctx context.Context, exec *db.DB) (tikv int, tiflash int, err error) {
+	rows, err := exec.QueryContext(ctx, "SELECT STORE_STATE_NAME, LABEL FROM information_schema.TIKV_STORE_STATUS")
+	if err != nil {
+		return 0, 0, err
+	}
+	defer util.CloseWithErr(rows, "readiness store rows")
+	_, out, err := scanStringRows(rows)
+	if err != nil {
+		return 0, 0, err
+	}
+	tikv, tiflash = countStoresByEngine(out)
+	return tikv, tiflash, nil
+}
+
+// countStoresByEngine counts Up stores from (STORE_STATE_NAME, LABEL) rows.
+// TiFlash stores carry an engine=tiflash label.
+func countStoresByEngine(rows [][]string) (tikv int, tiflash int) {
+	for _, row := range rows {
+		if len(row) < 2 || !strings.EqualFold(strings.TrimSpace(row[0]), "Up") {
+			continue
+		}
+		label := strings.ToLower(strings.ReplaceAll(row[1], " ", ""))
+		if strings.Contains(label, `"value":"tiflash"`) {
+			tiflash++
+			continue
+		}
+		tikv++
+	}
+	return tikv, tiflash
+}
+
+// notReadyReason returns why the cluster is not ready, or "" when it is.
+func (s readinessStatus) notReadyReason(rc config.ReadinessConfig) string {
+	switch {
+	case s.pingErr != nil:
+		return fmt.Sprintf("connect: %v", s.pingErr)
+	case s.storesErr != nil:
+		return fmt.Sprintf("stores: %v", s.storesErr)
+	case s.storesSeen && s.tikvUp < rc.MinTiKVStores:
+		return fmt.Sprintf("tikv stores up %d < %d", s.tikvUp, rc.MinTiKVStores)
+	case s.storesSeen && s.tiflashUp < rc.MinTiFlashStores:
+		return fmt.Sprintf("tiflash stores up %d < %d", s.tiflashUp, rc.MinTiFlashStores)
+	case s.schemaErr != nil:
+		return fmt.Sprintf("schema: %v", s.schemaErr)
+	case s.statsErr != nil:
+		return fmt.Sprintf("stats: %v", s.statsErr)
+	default:
+		return ""
+	}
+}
+
+// warmUp runs sacrificial statements on the initial tables before the first
+// iteration: a full count per table, then generated SELECTs. They go straight
+// to the pool, so they skip validation and statistics, and use their own
+// generator so the seeded query stream is unchanged.
+func (r *Runner) warmUp(ctx context.Context) {
+	n := r.cfg.Readiness.WarmupQueries
+	if !r.cfg.Readiness.Enabled || n <= 0 {
+		return
+	}
+	queries := make([]string, 0, len(r.state.Tables)+n)
+	for _, tbl := range r.baseTables() {
+		queries = append(queries, fmt.Sprintf("SELECT COUNT(*) FROM %s", tbl.Name))
+	}
+	gen := generator.New(r.gen.Config, r.state, r.cfg.Seed)
+	for i := 0; i < n; i++ {
+		if query := gen.GenerateSelectQuery(); query != nil {
+			queries = append(queries, query.SQLString())
+		}
+	}
+	failed := 0
+	for _, query := range queries {
+		if err := drainQuery(ctx, r.exec.DB, query); err != nil {
+			failed++
+			util.Detailf("warm-up query failed sql=%s err=%v", query, err)
+		}
+	}
+	util.Infof("warm-up done queries=%d failed=%d", len(queries), failed)
+}
+
+func drainQuery(ctx context.Context, exec *sql.DB, query string) error {
+	qctx, cancel := context.WithTimeout(ctx, readinessProbeTimeout)
+	defer cancel()
+	rows, err := exec.QueryContext(qctx, query)
+	if err != nil {
+		return err
+	}
+	defer util.CloseWithErr(rows, "warm-up rows")
+	_, _, err = scanStringRows(rows)
+	return err
+}
@@ -0,0 +1,125 @@
+package runner
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"shiro/internal/config"
+	"shiro/internal/db"
+)
+
+func TestCountStoresByEngine(t *testing.T) {
+	rows := [][]string{
+		{"Up", `[]`},
+		{"Up", `[{"key": "zone", "value": "z1"}]`},
+		{"Down", `[]`},
+		{"Up", `[{"key": "engine", "value": "tiflash"}]`},
+		{"Offline", `[{"key": "engine", "value": "tiflash"}]`},
+	}
+	tikv, tiflash := countStoresByEngine(rows)
+	if tikv != 2 || tiflash != 1 {
+		t.Fatalf("unexpected store counts tikv=%d tiflash=%d", tikv, tiflash)
+	}
+}
+
+func TestReadinessNotReadyReason(t *testing.T) {
+	rc := config.ReadinessConfig{MinTiKVStores: 3, MinTiFlashStores: 1}
+	cases := []struct {
+		status readinessStatus
+		want   string
+	}{
+		{readinessStatus{pingErr: errors.New("connection refused")}, "connect: connection refused"},
+		{readinessStatus{noPD: true}, ""},
+		{readinessStatus{storesSeen: true, pdMembers: 1, tikvUp: 2}, "tikv stores up 2 < 3"},
+		{readinessStatus{storesSeen: true, pdMembers: 1, tikvUp: 3}, "tiflash stores up 0 < 1"},
+		{readinessStatus{storesSeen: true, pdMembers: 1, tikvUp: 3, tiflashUp: 1, statsErr: errors.New("stats not loaded")}, "stats: stats not loaded"},
+		{readinessStatus{storesSeen: true, pdMembers: 1, tikvUp: 3, tiflashUp: 1}, ""},
+	}
+	for _, tc := range cases {
+		if got := tc.status.notReadyReason(rc); got != tc.want {
+			t.Fatalf("status %+v: got %q, want %q", tc.status, got, tc.want)
+		}
+	}
+	// Store counts are not checked when both minimums are zero.
+	if got := (readinessStatus{}).notReadyReason(config.ReadinessConfig{}); got != "" {
+		t.Fatalf("unexpected reason without store checks: %q", got)
+	}
+	if got := (readinessStatus{schemaErr: errors.New("mysql schema not loaded")}).notReadyReason(config.ReadinessConfig{}); !strings.HasPrefix(got, "schema:") {
+		t.Fatalf("unexpected schema reason: %q", got)
+	}
+}
+
+// readinessConnector answers each probe query with the single-column rows in
+// answers, keyed by a query substring, and records every query it sees.
+type readinessConnector struct {
+	answers map[string][][]driver.Value
+	queries []string
+}
+
+func (c *readinessConnector) Connect(context.Context) (driver.Conn, error) {
+	return readinessConn{c}, nil
+}
+func (c *readinessConnector) Driver() driver.Driver { return nil }
+
+type readinessConn struct{ connector *readinessConnector }
+
+func (c readinessConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c readinessConn) Close() error                        { return nil }
+func (c readinessConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c readinessConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	c.connector.queries = append(c.connector.queries, query)
+	for key, rows := range c.connector.answers {
+		if strings.Contains(query, key) {
+			return &readinessRows{rows: rows}, nil
+		}
+	}
+	return &readinessRows{}, nil
+}
+
+type readinessRows struct{ rows [][]driver.Value }
+
+func (r *readinessRows) Columns() []string { return []string{"c"} }
+func (r *readinessRows) Close() error      { return nil }
+func (r *readinessRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestProbeReadinessSkipsStoresWithoutPD(t *testing.T) {
+	connector := &readinessConnector{answers: map[string][][]driver.Value{
+		"SELECT 1":     {{int64(1)}},
+		"CLUSTER_INFO": {{int64(0)}},
+		"SCHEMATA":     {{int64(1)}},
+	}}
+	exec := &db.DB{DB: sql.OpenDB(connector)}
+	defer exec.Close()
+	rc := config.ReadinessConfig{MinTiKVStores: 1}
+	status := probeReadiness(context.Background(), exec, rc)
+	if !status.noPD || status.storesSeen {
+		t.Fatalf("expected the store check to be skipped: %+v", status)
+	}
+	if reason := status.notReadyReason(rc); reason != "" {
+		t.Fatalf("a server without pd must be ready, got %q", reason)
+	}
+	for _, query := range connector.queries {
+		if strings.Contains(query, "TIKV_STORE_STATUS") {
+			t.Fatalf("stores must not be queried without pd: %v", connector.queries)
+		}
+	}
+
+	connector.answers["CLUSTER_INFO"] = [][]driver.Value{{int64(1)}}
+	status = probeReadiness(context.Background(), exec, rc)
+	if status.noPD || !status.storesSeen || status.notReadyReason(rc) != "tikv stores up 0 < 1" {
+		t.Fatalf("a cluster with pd must wait for its stores: %+v", status)
+	}
+}
@@ -7,8 +7,11 @@ import (
 	"strings"
 	"time"
 
+	"shiro/internal/db"
 	"shiro/internal/oracle"
 	"shiro/internal/report"
+	"shiro/internal/sqlfmt"
+	"shiro/internal/sqlstep"
 	"shiro/internal/util"
 )
 
@@ -21,7 +24,7 @@ func (r *Runner) maybeObservePlan(ctx context.Context, result oracle.Result) {
 	if !r.cfg.QPG.Enabled || result.Err != nil || r.qpgState == nil {
 		return
 	}
-	target := pickExplainTarget(result.SQL)
+	target := pickExplainTarget(result.SQL())
 	if target == "" {
 		return
 	}
@@ -45,28 +48,27 @@ func pickExplainTarget(sqls []string) string {
 	return ""
 }
 
-func pickReplaySQL(result oracle.Result) string {
-	if result.Details != nil {
-		if v, ok := result.Details["replay_sql"]; ok {
-			if s, ok := v.(string); ok && strings.TrimSpace(s) != "" {
-				return s
+// replayRoles lists, by precedence, the step roles that name the statement
+// used for plan replayer dumps.
+var replayRoles = []sqlstep.Role{sqlstep.RoleReplay, sqlstep.RoleFailing, sqlstep.RoleExpected}
+
+// replayStepIndex returns the index of the first query step carrying a
+// replayRoles role, or -1 when no query step is tagged.
+func replayStepIndex(steps []sqlstep.Step) int {
+	for _, role := range replayRoles {
+		for i, step := range steps {
+			if step.Role == role && step.Kind == sqlstep.KindQuery {
+				return i
 			}
 		}
 	}
-	for _, sqlText := range result.SQL {
-		trimmed := strings.TrimSpace(sqlText)
-		if trimmed == "" {
-			continue
-		}
-		upper := strings.ToUpper(trimmed)
-		switch {
-		case strings.HasPrefix(upper, "SELECT"),
-			strings.HasPrefix(upper, "WITH"),
-			strings.HasPrefix(upper, "INSERT"),
-			strings.HasPrefix(upper, "UPDATE"),
-			strings.HasPrefix(upper, "DELETE"):
-			return trimmed
-		}
+	return -1
+}
+
+// pickReplaySQL returns the statement used for plan replayer dumps.
+func pickReplaySQL(steps []sqlstep.Step) string {
+	if i := replayStepIndex(steps); i >= 0 {
+		return steps[i].SQL
 	}
 	return ""
 }
@@ -104,11 +106,13 @@ func (r *Runner) handleResult(ctx context.Context, result oracle.Result) {
 		return
 	}
 	util.Warnf("case allocated oracle=%s case_id=%s dir=%s", result.Oracle, caseData.ID, caseData.Dir)
+	diskLow, diskFreeMB := r.reportDiskLow()
 	planPath := ""
 	planSignature := ""
 	planSigFormat := ""
-	replaySQL := pickReplaySQL(result)
-	if replaySQL != "" {
+	steps := result.Steps
+	replaySQL := pickReplaySQL(steps)
+	if replaySQL != "" && !diskLow {
 		var planErr error
 		planPath, planErr = r.replayer.DumpAndDownload(ctx, r.exec, replaySQL, caseData.Dir, r.cfg.Database)
 		if planErr != nil {
@@ -124,6 +128,19 @@ func (r *Runner) handleResult(ctx context.Context, result oracle.Result) {
 	if details == nil {
 		details = map[string]any{}
 	}
+	if diskLow {
+		details["report_throttled"] = "disk_low"
+		details["report_disk_free_mb"] = diskFreeMB
+	}
+	caseTSO, tsoErr := r.currentTSO(ctx, r.exec)
+	if tsoErr != nil {
+		util.Detailf("case tso unavailable dir=%s err=%v", caseData.Dir, tsoErr)
+	}
+	recordCaseLocation(details, r.cfg.Database, caseTSO)
+	if r.cfg.Target != "" {
+		details["target"] = r.cfg.Target
+	}
+	r.annotateChaos(details)
 	result.Details = details
 	annotateResultForReporting(&result)
 	annotateEffectiveErrorMetadata(&result)
@@ -136,12 +153,14 @@ func (r *Runner) handleResult(ctx context.Context, result oracle.Result) {
 
 	summary := report.Summary{
 		Oracle:                       result.Oracle,
-		SQL:                          result.SQL,
+		SQL:                          result.SQL(),
+		Steps:                        steps,
 		Expected:                     result.Expected,
 		Actual:                       result.Actual,
 		ErrorReason:                  errorReason,
 		ErrorSignature:               errorSignature,
 		BugHint:                      bugHint,
+		Severity:                     detailString(details, "severity"),
 		GroundTruthDSGMismatchReason: groundTruthDSGMismatchReason,
 		ReplaySQL:                    replaySQL,
 		Flaky:                        flaky,
@@ -149,10 +168,13 @@ func (r *Runner) handleResult(ctx context.Context, result oracle.Result) {
 		Seed:                         r.gen.Seed,
 		RunInfo:                      r.cfg.RunInfo,
 		Timestamp:                    time.Now().Format(time.RFC3339),
-		PlanReplay:                   planPath,
+		PlanReplay:                   caseRelativePath(caseData.Dir, planPath),
 		TiDBVersion:                  r.tidbVersion(ctx),
+		Topology:                     r.topology,
 		PlanSignature:                planSignature,
 		PlanSigFormat:                planSigFormat,
+		OracleApplicability:          r.oracleApplicabilitySnapshot(),
+		SQLLog:                       r.sqlLogRef(),
 	}
 	defer func() {
 		if summary.MinimizeStatus != "in_progress" {
@@ -170,7 +192,7 @@ func (r *Runner) handleResult(ctx context.Context, result oracle.Result) {
 	}
 	summary.CaseID = caseData.ID
 	summary.CaseDir = filepath.Base(caseData.Dir)
-	if r.cfg.Storage.CloudEnabled() {
+	if r.cfg.Storage.RemoteEnabled() {
 		summary.CaseDir = caseData.ID
 		summary.ArchiveName = report.CaseArchiveName
 		summary.ArchiveCodec = report.CaseArchiveCodec
@@ -200,20 +222,16 @@ func (r *Runner) handleResult(ctx context.Context, result oracle.Result) {
 	if result.Err != nil {
 		summary.Error = result.Err.Error()
 		if summary.ErrorSQL == "" {
-			if replaySQL != "" {
-				summary.ErrorSQL = replaySQL
-			} else if len(result.SQL) > 0 {
-				summary.ErrorSQL = result.SQL[0]
-			}
+			summary.ErrorSQL = replaySQL
 		}
 	}
-	if shouldReportRows(result) {
+	if expectedSQL, actualSQL, ok := reportRowsSQL(result); ok {
 		maxRows := r.cfg.MaxRowsPerTable
 		if maxRows <= 0 {
 			maxRows = 50
 		}
-		expectedRows, expectedTrunc, expectedErr := r.queryResultRows(ctx, result.SQL[0], maxRows)
-		actualRows, actualTrunc, actualErr := r.queryResultRows(ctx, result.SQL[1], maxRows)
+		expectedRows, expectedTrunc, expectedErr := r.queryResultRows(ctx, expectedSQL, maxRows)
+		actualRows, actualTrunc, actualErr := r.queryResultRows(ctx, actualSQL, maxRows)
 		if expectedErr == nil && expectedRows != "" {
 			details["signature_expected"] = result.Expected
 			summary.Expected = expectedRows
@@ -227,6 +245,41 @@ func (r *Runner) handleResult(ctx context.Context, result oracle.Result) {
 			_ = r.reporter.WriteText(caseData, "actual.tsv", actualRows)
 		}
 	}
+	if isWrongResultMismatch(result) {
+		var plans *report.CasePlans
+		if !diskLow {
+			plans = r.captureCasePlans(ctx, result)
+		}
+		if plans != nil {
+			if err := r.reporter.WritePlans(caseData, *plans); err != nil {
+				util.Warnf("case plans write failed dir=%s err=%v", caseData.Dir, err)
+			} else {
+				summary.PlansFile = report.PlansFile
+			}
+		}
+		annotateExplainDiff(details, plans)
+	}
+	if annotatePanic(details, result.Err) {
+		logs := r.captureTiDBLogs(ctx, caseData, details)
+		r.capturePanicStack(caseData, details, logs)
+		if r.cfg.Oracles.PanicDiagnostics {
+			r.capturePanicDiagnostics(ctx, summary.ErrorSQL, details)
+		}
+	}
+	if isHangResult(result) {
+		r.captureHangDiagnostics(ctx, caseData, details)
+	}
+	if result.Oracle == "CERT" && !diskLow {
+		r.captureCERTStats(ctx, caseData, details)
+	}
+	r.captureSessionState(ctx, caseData, details)
+	if kind := severeCaseKind(result.Err); kind != "" {
+		r.captureStateSnapshot(ctx, caseData, kind, caseTSO, diskLow, details)
+	}
+	if r.cfg.Oracles.ClassifyMismatch {
+		r.classifyMismatch(ctx, result.Oracle, details)
+	}
+	summary.Title = caseTitle(result, errorReason, r.gen.LastFeatures)
 	spec := replaySpec{}
 	minimizeStatus := "disabled"
 	if r.cfg.Minimize.Enabled {
@@ -243,10 +296,13 @@ func (r *Runner) handleResult(ctx context.Context, result oracle.Result) {
 		applyRuntime1105ReproMeta(&summary, details)
 	}
 	_ = r.reporter.WriteSummary(caseData, summary)
-	_ = r.reporter.WriteSQL(caseData, "case.sql", result.SQL)
+	_ = r.reporter.WriteSQL(caseData, "case.sql", sqlfmt.Statements(result.SQL()))
 	_ = r.reporter.WriteSQL(caseData, "inserts.sql", wrapInsertsWithForeignKeyChecks(r.insertLog))
 	_ = r.reporter.DumpSchema(ctx, caseData, r.exec, r.state)
-	_ = r.reporter.DumpData(ctx, caseData, r.exec, r.state)
+	if !diskLow {
+		_ = r.reporter.DumpData(ctx, caseData, r.exec, r.state)
+	}
+	var minimized minimizeOutput
 	if minimizeEnabled {
 		r.statsMu.Lock()
 		r.minimizeInFlight++
@@ -258,12 +314,15 @@ func (r *Runner) handleResult(ctx context.Context, result oracle.Result) {
 			}
 			r.statsMu.Unlock()
 		}()
-		minimized := r.minimizeCase(ctx, result, spec)
+		minimized = r.minimizeCase(ctx, result, spec)
+		if minimized.minimized && r.cfg.Minimize.VerifyMin {
+			minimized = applyMinVerify(minimized, r.verifyMinimized(ctx, result, minimized))
+		}
 		applyMinimizeOutcome(&summary, details, minimized, result.Err)
 		applyRuntime1105ReproMeta(&summary, details)
 		if minimized.minimized {
 			if len(minimized.caseSQL) > 0 {
-				_ = r.reporter.WriteSQL(caseData, "min/case.sql", minimized.caseSQL)
+				_ = r.reporter.WriteSQL(caseData, "min/case.sql", sqlfmt.Statements(minimized.caseSQL))
 			}
 			if len(minimized.insertSQL) > 0 {
 				_ = r.reporter.WriteSQL(caseData, "min/inserts.sql", wrapInsertsWithForeignKeyChecks(minimized.insertSQL))
@@ -275,8 +334,19 @@ func (r *Runner) handleResult(ctx context.Context, result oracle.Result) {
 		_ = r.reporter.WriteSummary(caseData, summary)
 	}
 
+	if r.cfg.Minimize.Neighborhood && !diskLow && !isHangResult(result) {
+		r.exploreNeighborhood(ctx, caseData, result, minimized, details)
+		_ = r.reporter.WriteSummary(caseData, summary)
+	}
+
+	if r.shadowExec != nil {
+		r.shadowReplay(ctx, result, details)
+		_ = r.reporter.WriteSummary(caseData, summary)
+	}
+
+	r.runCaseHooks(ctx, caseData, result.Oracle, details)
 	_ = r.reporter.WriteSummary(caseData, summary)
-	if r.cfg.Storage.CloudEnabled() {
+	if r.cfg.Storage.RemoteEnabled() {
 		_ = r.reporter.WriteReport(caseData, summary)
 		if _, _, archiveErr := r.reporter.WriteCaseArchive(caseData); archiveErr != nil {
 			util.Warnf("case archive failed dir=%s err=%v", caseData.Dir, archiveErr)
@@ -284,6 +354,10 @@ func (r *Runner) handleResult(ctx context.Context, result oracle.Result) {
 			summary.ArchiveCodec = ""
 			_ = r.reporter.WriteSummary(caseData, summary)
 			_ = r.reporter.WriteReport(caseData, summary)
+		} else if sum, sumErr := r.reporter.ArchiveSHA256(caseData); sumErr == nil {
+			summary.ArchiveSHA256 = sum
+			_ = r.reporter.WriteSummary(caseData, summary)
+			_ = r.reporter.WriteReport(caseData, summary)
 		}
 	}
 
@@ -292,12 +366,18 @@ func (r *Runner) handleResult(ctx context.Context, result oracle.Result) {
 		if err == nil {
 			summary.UploadLocation = location
 			_ = r.reporter.WriteSummary(caseData, summary)
-			if r.cfg.Storage.CloudEnabled() {
+			if r.cfg.Storage.RemoteEnabled() {
 				_ = r.reporter.WriteReport(caseData, summary)
 			}
 		}
 	}
 
+	if committed, err := r.reporter.CommitCase(caseData); err != nil {
+		util.Warnf("case commit failed dir=%s err=%v", caseData.Dir, err)
+	} else {
+		caseData = committed
+	}
+
 	minimizeReason := ""
 	if details != nil {
 		if reason, ok := details["minimize_reason"].(string); ok {
@@ -306,6 +386,11 @@ func (r *Runner) handleResult(ctx context.Context, result oracle.Result) {
 	}
 	replayLogSuffix := formatBaseReplayLogSuffix(details)
 	r.observeReproducibilitySummary(summary.MinimizeStatus, minimizeReason, errorSignature, details)
+	caseLink := summary.UploadLocation
+	if caseLink == "" {
+		caseLink = caseData.Dir
+	}
+	r.recordJUnitCase(result.Oracle, junitCase{ID: caseData.ID, Title: summary.Title, Reason: errorReason, Link: caseLink})
 	if result.Err != nil {
 		util.Errorf(
 			"case captured oracle=%s case_id=%s dir=%s error_reason=%s error_signature=%s minimize_status=%s minimize_reason=%s err=%v%s",
@@ -368,21 +453,28 @@ func (r *Runner) handleResult(ctx context.Context, result oracle.Result) {
 	}
 }
 
-func shouldReportRows(result oracle.Result) bool {
+// reportRowsSQL returns the expected and actual statements of a signature
+// mismatch whose result rows are worth saving next to the case.
+func reportRowsSQL(result oracle.Result) (expectedSQL string, actualSQL string, ok bool) {
 	if strings.TrimSpace(result.Expected) == "" && strings.TrimSpace(result.Actual) == "" {
-		return false
+		return "", "", false
 	}
 	if result.Details == nil {
-		return false
+		return "", "", false
 	}
 	kind, ok := result.Details["replay_kind"].(string)
 	if !ok || kind != "signature" {
-		return false
+		return "", "", false
 	}
-	if len(result.SQL) < 2 {
-		return false
+	expected, ok := sqlstep.FindRole(result.Steps, sqlstep.RoleExpected)
+	if !ok {
+		return "", "", false
+	}
+	actual, ok := sqlstep.FindRole(result.Steps, sqlstep.RoleActual)
+	if !ok {
+		return "", "", false
 	}
-	return true
+	return expected.SQL, actual.SQL, true
 }
 
 func (r *Runner) queryResultRows(ctx context.Context, sqlText string, maxRows int) (string, bool, error) {
@@ -441,7 +533,7 @@ func (r *Runner) queryResultRows(ctx context.Context, sqlText string, maxRows in
 		rowCount++
 	}
 	if truncated {
-		_ = drainRows(rows)
+		_ = db.ResultGuard{}.DrainRows(rows)
 	}
 	if err := rows.Err(); err != nil {
 		return "", false, err
@@ -449,6 +541,19 @@ func (r *Runner) queryResultRows(ctx context.Context, sqlText string, maxRows in
 	return b.String(), truncated, nil
 }
 
+// caseRelativePath records path relative to caseDir so that it survives the
+// rename out of staging. Paths outside caseDir are kept as they are.
+func caseRelativePath(caseDir string, path string) string {
+	if path == "" {
+		return ""
+	}
+	rel, err := filepath.Rel(caseDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return rel
+}
+
 func hasErrno(err error) bool {
 	if err == nil {
 		return false
@@ -519,6 +624,9 @@ func applyMinimizeOutcome(summary *report.Summary, details map[string]any, outpu
 	if status != "" {
 		summary.MinimizeStatus = status
 	}
+	if output.verify != "" {
+		summary.MinimizeVerify = output.verify
+	}
 	reason := strings.TrimSpace(output.reason)
 	if details != nil && reason != "" {
 		details["minimize_reason"] = reason
@@ -1,9 +1,12 @@
 package runner
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"shiro/internal/report"
+	"shiro/internal/sqlstep"
 
 	"github.com/go-sql-driver/mysql"
 )
@@ -323,3 +326,64 @@ func TestApplyRuntime1105ReproMetaAnnotatesNotApplicableKeepsGateReason(t *testi
 		t.Fatalf("runtime_bug_hint_gate_reason=%q want=manual_triage", reason)
 	}
 }
+
+func TestPickReplaySQLFollowsRoles(t *testing.T) {
+	steps := []sqlstep.Step{
+		sqlstep.New(sqlstep.KindSetVar, "", "SET @p1=1"),
+		sqlstep.New(sqlstep.KindVerify, sqlstep.RoleFailing, "SELECT @@last_plan_from_cache"),
+		sqlstep.New(sqlstep.KindQuery, "", "SELECT * FROM t0 WHERE c0 = 1"),
+	}
+	if got := pickReplaySQL(steps); got != "" {
+		t.Fatalf("untagged queries and failing verify steps must not be replayed, got %q", got)
+	}
+	steps = append(steps,
+		sqlstep.New(sqlstep.KindQuery, sqlstep.RoleExpected, "SELECT 1"),
+		sqlstep.New(sqlstep.KindQuery, sqlstep.RoleFailing, "SELECT 2"),
+	)
+	if got := pickReplaySQL(steps); got != "SELECT 2" {
+		t.Fatalf("pickReplaySQL()=%q want=%q", got, "SELECT 2")
+	}
+	steps = append(steps, sqlstep.New(sqlstep.KindQuery, sqlstep.RoleReplay, "SELECT 3"))
+	if got := pickReplaySQL(steps); got != "SELECT 3" {
+		t.Fatalf("pickReplaySQL()=%q want=%q", got, "SELECT 3")
+	}
+}
+
+func TestCaseRelativePath(t *testing.T) {
+	dir := filepath.Join("out", ".staging", "c1")
+	for _, tc := range []struct{ path, want string }{
+		{"", ""},
+		{filepath.Join(dir, "plan_replayer.zip"), "plan_replayer.zip"},
+		{filepath.Join(dir, "state_snapshot", "x.sql"), filepath.Join("state_snapshot", "x.sql")},
+		{filepath.Join("out", "other.zip"), filepath.Join("out", "other.zip")},
+	} {
+		if got := caseRelativePath(dir, tc.path); got != tc.want {
+			t.Fatalf("caseRelativePath(%q)=%q want=%q", tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestRecoverOutputDirRunsOnce(t *testing.T) {
+	outputDir := t.TempDir()
+	reporter := report.New(outputDir, 16)
+	staged, err := reporter.NewCase()
+	if err != nil {
+		t.Fatalf("new case: %v", err)
+	}
+	if err := reporter.WriteSummary(staged, report.Summary{CaseID: staged.ID}); err != nil {
+		t.Fatalf("write summary: %v", err)
+	}
+	RecoverOutputDir(outputDir, 16)
+	if _, err := os.Stat(reporter.CommittedDir(staged)); err != nil {
+		t.Fatalf("expected the staged case to be recovered: %v", err)
+	}
+	// A case staged by a live worker must survive later calls.
+	live, err := reporter.NewCase()
+	if err != nil {
+		t.Fatalf("new case: %v", err)
+	}
+	RecoverOutputDir(outputDir, 16)
+	if _, err := os.Stat(live.Dir); err != nil {
+		t.Fatalf("expected the live staged case to stay: %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+package runner
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"shiro/internal/config"
+	"shiro/internal/db"
+	"shiro/internal/util"
+)
+
+const (
+	resourceGroupSetupTimeout = 30 * time.Second
+	// resourceGroupQuotaErrCode is returned when a statement waited too long
+	// for request units of its resource group.
+	resourceGroupQuotaErrCode = 8252
+)
+
+// SetupResourceGroup creates or alters the resource group of cfg on an admin
+// connection and adds SET RESOURCE GROUP to the session init statements, so
+// every fuzzing connection opened from cfg joins the group. It fails when
+// resource control is off, since the quota would silently not apply.
+func SetupResourceGroup(ctx context.Context, cfg *config.Config) error {
+	rg := cfg.ResourceGroup
+	if !rg.Enabled {
+		return nil
+	}
+	exec, err := db.Open(config.AdminDSN(cfg.DSN))
+	if err != nil {
+		return err
+	}
+	defer util.CloseWithErr(exec, "resource group db")
+	ctx, cancel := context.WithTimeout(ctx, resourceGroupSetupTimeout)
+	defer cancel()
+	var enabled string
+	if err := exec.QueryRowContext(ctx, "SELECT @@GLOBAL.tidb_enable_resource_control").Scan(&enabled); err != nil {
+		return fmt.Errorf("resource group: read tidb_enable_resource_control: %w", err)
+	}
+	if !isEnabledValue(enabled) {
+		return fmt.Errorf("resource group: tidb_enable_resource_control is %s", enabled)
+	}
+	for _, stmt := range resourceGroupSetupSQL(rg) {
+		if _, err := exec.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("resource group: %s: %w", stmt, err)
+		}
+	}
+	// Per-target configs share the SessionInit backing array, so append to a
+	// copy instead of writing into spare capacity another target can see.
+	cfg.SessionInit = append(slices.Clone(cfg.SessionInit), resourceGroupBindSQL(rg.Name))
+	util.Infof("resource group ready name=%s ru_per_sec=%d priority=%s burstable=%t", rg.Name, rg.RUPerSec, rg.Priority, rg.Burstable)
+	return nil
+}
+
+func isEnabledValue(value string) bool {
+	switch strings.ToUpper(strings.TrimSpace(value)) {
+	case "1", "ON", "TRUE":
+		return true
+	default:
+		return false
+	}
+}
+
+// resourceGroupSetupSQL creates the group if needed and then applies the
+// configured settings, so a group left by an earlier run picks up changes.
+// BURSTABLE is only ever added: older TiDB versions cannot turn it off.
+func resourceGroupSetupSQL(rg config.ResourceGroupConfig) []string {
+	options := fmt.Sprintf("RU_PER_SEC = %d PRIORITY = %s", rg.RUPerSec, strings.ToUpper(rg.Priority))
+	if rg.Burstable {
+		options += " BURSTABLE"
+	}
+	name := quoteIdent(rg.Name)
+	return []string{
+		fmt.Sprintf("CREATE RESOURCE GROUP IF NOT EXISTS %s %s", name, options),
+		fmt.Sprintf("ALTER RESOURCE GROUP %s %s", name, options),
+	}
+}
+
+func resourceGroupBindSQL(name string) string {
+	return "SET RESOURCE GROUP " + quoteIdent(name)
+}
+
+func quoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// resourceGroupStats counts statements the server rejected because the
+// resource group ran out of request units.
+type resourceGroupStats struct {
+	throttled atomic.Int64
+	lastNanos atomic.Int64
+}
+
+func (s *resourceGroupStats) TraceStatement(trace db.StatementTrace) {
+	if !isResourceGroupThrottled(trace.Err) {
+		return
+	}
+	if s.throttled.Add(1) == 1 {
+		util.Warnf("resource group quota exceeded tag=%s err=%v", trace.Tag, trace.Err)
+	}
+	s.lastNanos.Store(trace.Time.UnixNano())
+}
+
+func isResourceGroupThrottled(err error) bool {
+	if err == nil {
+		return false
+	}
+	if code, ok := mysqlErrCode(err); ok && code == resourceGroupQuotaErrCode {
+		return true
+	}
+	return strings.Contains(strings.ToLower(err.Error()), "exceeded resource group quota limitation")
+}
+
+// resourceGroupSummary is the resource_group block of the run summary. The
+// request units and queue time come from STATEMENTS_SUMMARY for the run's
+// databases; Error is set when they could not be read.
+type resourceGroupSummary struct {
+	Name          string  `json:"name"`
+	RUPerSec      int     `json:"ru_per_sec"`
+	Priority      string  `json:"priority"`
+	Burstable     bool    `json:"burstable"`
+	Throttled     int64   `json:"throttled"`
+	LastThrottled string  `json:"last_throttled,omitempty"`
+	RequestUnits  float64 `json:"request_units"`
+	QueuedSeconds float64 `json:"queued_seconds"`
+	MaxQueuedMs   float64 `json:"max_queued_ms"`
+	Error         string  `json:"error,omitempty"`
+}
+
+func (r *Runner) collectResourceGroup(ctx context.Context, started time.Time) *resourceGroupSummary {
+	if r.resourceGroup == nil {
+		return nil
+	}
+	rg := r.cfg.ResourceGroup
+	out := &resourceGroupSummary{
+		Name:      rg.Name,
+		RUPerSec:  rg.RUPerSec,
+		Priority:  rg.Priority,
+		Burstable: rg.Burstable,
+		Throttled: r.resourceGroup.throttled.Load(),
+	}
+	if last := r.resourceGroup.lastNanos.Load(); last > 0 {
+		out.LastThrottled = time.Unix(0, last).Format(time.RFC3339)
+	}
+	if r.exec == nil {
+		return out
+	}
+	query := fmt.Sprintf(
+		"SELECT IFNULL(SUM(EXEC_COUNT * (AVG_REQUEST_UNIT_READ + AVG_REQUEST_UNIT_WRITE)), 0), IFNULL(SUM(EXEC_COUNT * AVG_QUEUED_RC_TIME), 0), IFNULL(MAX(MAX_QUEUED_RC_TIME), 0) FROM information_schema.cluster_statements_summary WHERE RESOURCE_GROUP = '%s' AND %s AND UNIX_TIMESTAMP(SUMMARY_END_TIME) >= %d",
+		strings.ReplaceAll(rg.Name, "'", "''"), clusterImpactSchemaFilter(r.baseDB), started.Unix(),
+	)
+	var units, queuedNs, maxQueuedNs sql.NullFloat64
+	if err := r.exec.QueryRowContext(ctx, query).Scan(&units, &queuedNs, &maxQueuedNs); err != nil {
+		util.Warnf("resource group usage query failed name=%s err=%v", rg.Name, err)
+		out.Error = err.Error()
+		return out
+	}
+	out.RequestUnits = units.Float64
+	out.QueuedSeconds = queuedNs.Float64 / float64(time.Second)
+	out.MaxQueuedMs = maxQueuedNs.Float64 / float64(time.Millisecond)
+	return out
+}
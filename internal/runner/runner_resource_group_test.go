@@ -0,0 +1,55 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"shiro/internal/config"
+	"shiro/internal/db"
+)
+
+func TestResourceGroupSetupSQL(t *testing.T) {
+	rg := config.ResourceGroupConfig{Name: "shi`ro", RUPerSec: 500, Priority: "high", Burstable: true}
+	stmts := resourceGroupSetupSQL(rg)
+	want := []string{
+		"CREATE RESOURCE GROUP IF NOT EXISTS `shi``ro` RU_PER_SEC = 500 PRIORITY = HIGH BURSTABLE",
+		"ALTER RESOURCE GROUP `shi``ro` RU_PER_SEC = 500 PRIORITY = HIGH BURSTABLE",
+	}
+	if len(stmts) != len(want) || stmts[0] != want[0] || stmts[1] != want[1] {
+		t.Fatalf("unexpected setup sql: %q", stmts)
+	}
+	if got := resourceGroupBindSQL("shiro"); got != "SET RESOURCE GROUP `shiro`" {
+		t.Fatalf("unexpected bind sql: %s", got)
+	}
+}
+
+func TestResourceGroupStatsCountsThrottled(t *testing.T) {
+	stats := &resourceGroupStats{}
+	now := time.Now()
+	stats.TraceStatement(db.StatementTrace{SQL: "SELECT 1", Time: now})
+	stats.TraceStatement(db.StatementTrace{SQL: "SELECT 2", Time: now, Err: errors.New("boom")})
+	quota := &mysql.MySQLError{Number: resourceGroupQuotaErrCode, Message: "Exceed resource group quota limitation"}
+	stats.TraceStatement(db.StatementTrace{SQL: "SELECT 3", Time: now, Err: fmt.Errorf("query: %w", quota)})
+	stats.TraceStatement(db.StatementTrace{SQL: "SELECT 4", Time: now.Add(time.Second), Err: errors.New("Error 8252: Exceeded resource group quota limitation")})
+	if got := stats.throttled.Load(); got != 2 {
+		t.Fatalf("expected 2 throttled statements, got %d", got)
+	}
+	if got := stats.lastNanos.Load(); got != now.Add(time.Second).UnixNano() {
+		t.Fatalf("unexpected last throttled time %d", got)
+	}
+}
+
+func TestClassifyInfraIssueResourceGroup(t *testing.T) {
+	err := &mysql.MySQLError{Number: resourceGroupQuotaErrCode, Message: "quota"}
+	reason, ok := classifyInfraIssue(err)
+	if !ok || reason != "resource_group_throttled" {
+		t.Fatalf("unexpected classification %q %v", reason, ok)
+	}
+	if !isInfraReason("norec:" + reason) {
+		t.Fatalf("expected %s to be an infra reason", reason)
+	}
+}
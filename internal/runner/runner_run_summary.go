@@ -0,0 +1,284 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"shiro/internal/report"
+	"shiro/internal/util"
+)
+
+const clusterImpactTimeout = 30 * time.Second
+
+// runSummary is written to run_summary-<database>.json when the runner exits.
+type runSummary struct {
+	Version         int                       `json:"version"`
+	Timestamp       string                    `json:"timestamp"`
+	Seed            int64                     `json:"seed"`
+	Database        string                    `json:"database"`
+	StartedAt       string                    `json:"started_at"`
+	DurationSeconds float64                   `json:"duration_seconds"`
+	SQLTotal        int64                     `json:"sql_total"`
+	SQLValid        int64                     `json:"sql_valid"`
+	CapturedCases   int64                     `json:"captured_cases"`
+	ResultTruncated int64                     `json:"result_truncated"`
+	Workload        *workloadSummary          `json:"background_workload,omitempty"`
+	ClusterImpact   *clusterImpactReport      `json:"cluster_impact,omitempty"`
+	NullDensity     *nullDensitySummary       `json:"null_density,omitempty"`
+	QueryShape      *queryShapeSummary        `json:"query_shape,omitempty"`
+	Topology        *report.ClusterTopology   `json:"topology,omitempty"`
+	Builder         map[string]builderSummary `json:"builder,omitempty"`
+	StopReason      string                    `json:"stop_reason,omitempty"`
+	Pacing          *pacingSummary            `json:"pacing,omitempty"`
+	Latency         *latencySummary           `json:"latency,omitempty"`
+	Chaos           *chaosSummary             `json:"chaos,omitempty"`
+	ResourceGroup   *resourceGroupSummary     `json:"resource_group,omitempty"`
+	// UnsupportedHints lists the DQP hints and SET_VARs the startup probe
+	// found the server ignores.
+	UnsupportedHints []string `json:"unsupported_hints,omitempty"`
+	// DQPFixControls holds the per-ID stats of the DQP fix-control campaign.
+	DQPFixControls map[string]fixControlSummary `json:"dqp_fix_controls,omitempty"`
+	// ShadowOutcomes counts the captured cases per shadow replay outcome.
+	ShadowOutcomes map[string]int64 `json:"shadow_outcomes,omitempty"`
+}
+
+// nullDensitySummary counts NULL-involving predicate leaves (<=>, IS NULL,
+// NULL in IN lists, NULLIF) across the generated queries.
+type nullDensitySummary struct {
+	Queries          int64   `json:"queries"`
+	NullAwareQueries int64   `json:"null_aware_queries"`
+	NullPredicates   int64   `json:"null_predicates"`
+	PredicateLeaves  int64   `json:"predicate_leaves"`
+	Density          float64 `json:"density"`
+}
+
+// clusterImpactReport summarizes the server-side cost of the statements the
+// runner issued, aggregated per digest across instances and summary windows.
+type clusterImpactReport struct {
+	Digests    int                   `json:"digests"`
+	Executions int64                 `json:"executions"`
+	LatencyMs  clusterImpactLatency  `json:"latency_ms"`
+	TopLatency []clusterImpactDigest `json:"top_latency"`
+	TopMemory  []clusterImpactDigest `json:"top_memory"`
+	TopCop     []clusterImpactDigest `json:"top_cop_tasks"`
+	Error      string                `json:"error,omitempty"`
+}
+
+// clusterImpactLatency holds percentiles of the per-digest average latency,
+// plus the largest single execution.
+type clusterImpactLatency struct {
+	P50 float64 `json:"p50"`
+	P95 float64 `json:"p95"`
+	P99 float64 `json:"p99"`
+	Max float64 `json:"max"`
+}
+
+type clusterImpactDigest struct {
+	Digest       string  `json:"digest"`
+	StmtType     string  `json:"stmt_type"`
+	DigestText   string  `json:"digest_text"`
+	SampleSQL    string  `json:"sample_sql,omitempty"`
+	ExecCount    int64   `json:"exec_count"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+	MaxLatencyMs float64 `json:"max_latency_ms"`
+	MaxMemBytes  int64   `json:"max_mem_bytes"`
+	CopTasks     int64   `json:"cop_tasks"`
+
+	sumLatencyNs int64
+}
+
+// dumpRunSummary writes the run summary into the working directory.
+func (r *Runner) dumpRunSummary(started time.Time) {
+	r.statsMu.Lock()
+	summary := runSummary{
+		Version:          1,
+		Timestamp:        time.Now().Format(time.RFC3339),
+		Database:         r.baseDB,
+		StartedAt:        started.Format(time.RFC3339),
+		DurationSeconds:  time.Since(started).Seconds(),
+		SQLTotal:         r.sqlTotal,
+		SQLValid:         r.sqlValid,
+		CapturedCases:    r.capturedCases,
+		ResultTruncated:  r.resultTruncatedTotal,
+		Workload:         r.workloadSummary,
+		NullDensity:      r.nullDensitySummaryLocked(),
+		QueryShape:       r.queryShape.summary(),
+		Topology:         r.topology,
+		Builder:          r.builderSummaryLocked(),
+		StopReason:       r.stopReason,
+		Pacing:           r.pacingSummaryLocked(),
+		UnsupportedHints: r.unsupportedHints,
+		DQPFixControls:   r.fixControlSummaryLocked(),
+		ShadowOutcomes:   maps.Clone(r.shadowOutcomes),
+	}
+	r.statsMu.Unlock()
+	summary.Latency = r.latency.summary()
+	summary.Chaos = r.chaos.summarySnapshot()
+	if r.gen != nil {
+		summary.Seed = r.seedSnapshot()
+	}
+	if r.cfg.ClusterImpact.Enabled && r.exec != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), clusterImpactTimeout)
+		summary.ClusterImpact = r.collectClusterImpact(ctx, started)
+		cancel()
+	}
+	if r.resourceGroup != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), clusterImpactTimeout)
+		summary.ResourceGroup = r.collectResourceGroup(ctx, started)
+		cancel()
+	}
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		wd = "."
+	}
+	_ = os.WriteFile(filepath.Join(wd, fmt.Sprintf("run_summary-%s.json", r.baseDB)), data, 0o644)
+}
+
+func (r *Runner) nullDensitySummaryLocked() *nullDensitySummary {
+	if r.genSQLTotal == 0 {
+		return nil
+	}
+	out := &nullDensitySummary{
+		Queries:          r.genSQLTotal,
+		NullAwareQueries: r.nullAwareQueries,
+		NullPredicates:   r.nullPredicates,
+		PredicateLeaves:  r.predicateLeaves,
+	}
+	if r.predicateLeaves > 0 {
+		out.Density = float64(r.nullPredicates) / float64(r.predicateLeaves)
+	}
+	return out
+}
+
+// collectClusterImpact reads the current and historical statement summaries of
+// every database this runner used (the base database and its rotations) for
+// summary windows that ended after the run started.
+func (r *Runner) collectClusterImpact(ctx context.Context, started time.Time) *clusterImpactReport {
+	report := &clusterImpactReport{}
+	digests := make(map[string]*clusterImpactDigest)
+	for _, table := range []string{"cluster_statements_summary", "cluster_statements_summary_history"} {
+		query := fmt.Sprintf(
+			"SELECT DIGEST, STMT_TYPE, DIGEST_TEXT, QUERY_SAMPLE_TEXT, EXEC_COUNT, SUM_LATENCY, MAX_LATENCY, MAX_MEM, SUM_COP_TASK_NUM FROM information_schema.%s WHERE %s AND UNIX_TIMESTAMP(SUMMARY_END_TIME) >= %d",
+			table, clusterImpactSchemaFilter(r.baseDB), started.Unix(),
+		)
+		cols, rows, err := r.queryStringRows(ctx, query)
+		if err != nil {
+			util.Warnf("cluster impact query failed table=%s err=%v", table, err)
+			report.Error = err.Error()
+			continue
+		}
+		for _, row := range rows {
+			mergeClusterImpactRow(digests, cols, row)
+		}
+	}
+	summarizeClusterImpact(report, digests, r.cfg.ClusterImpact.TopN)
+	return report
+}
+
+// clusterImpactSchemaFilter matches the base database and its rotations
+// (<base>_r<N>).
+func clusterImpactSchemaFilter(baseDB string) string {
+	quoted := strings.ReplaceAll(baseDB, "'", "''")
+	pattern := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(quoted)
+	return fmt.Sprintf("(SCHEMA_NAME = '%s' OR SCHEMA_NAME LIKE '%s\\_r%%')", quoted, pattern)
+}
+
+func mergeClusterImpactRow(digests map[string]*clusterImpactDigest, cols []string, row []string) {
+	digest := rowValue(cols, row, "DIGEST")
+	if digest == "" {
+		return
+	}
+	entry := digests[digest]
+	if entry == nil {
+		entry = &clusterImpactDigest{
+			Digest:     digest,
+			StmtType:   rowValue(cols, row, "STMT_TYPE"),
+			DigestText: rowValue(cols, row, "DIGEST_TEXT"),
+			SampleSQL:  rowValue(cols, row, "QUERY_SAMPLE_TEXT"),
+		}
+		digests[digest] = entry
+	}
+	entry.ExecCount += parseRowInt(rowValue(cols, row, "EXEC_COUNT"))
+	entry.sumLatencyNs += parseRowInt(rowValue(cols, row, "SUM_LATENCY"))
+	entry.MaxLatencyMs = math.Max(entry.MaxLatencyMs, nanosToMillis(parseRowInt(rowValue(cols, row, "MAX_LATENCY"))))
+	entry.MaxMemBytes = max(entry.MaxMemBytes, parseRowInt(rowValue(cols, row, "MAX_MEM")))
+	entry.CopTasks += parseRowInt(rowValue(cols, row, "SUM_COP_TASK_NUM"))
+}
+
+func summarizeClusterImpact(report *clusterImpactReport, digests map[string]*clusterImpactDigest, topN int) {
+	entries := make([]clusterImpactDigest, 0, len(digests))
+	avgLatencies := make([]float64, 0, len(digests))
+	for _, entry := range digests {
+		if entry.ExecCount > 0 {
+			entry.AvgLatencyMs = nanosToMillis(entry.sumLatencyNs / entry.ExecCount)
+		}
+		report.Executions += entry.ExecCount
+		report.LatencyMs.Max = math.Max(report.LatencyMs.Max, entry.MaxLatencyMs)
+		avgLatencies = append(avgLatencies, entry.AvgLatencyMs)
+		entries = append(entries, *entry)
+	}
+	report.Digests = len(entries)
+	sort.Float64s(avgLatencies)
+	report.LatencyMs.P50 = percentile(avgLatencies, 0.50)
+	report.LatencyMs.P95 = percentile(avgLatencies, 0.95)
+	report.LatencyMs.P99 = percentile(avgLatencies, 0.99)
+	report.TopLatency = topClusterImpact(entries, topN, func(d clusterImpactDigest) float64 { return d.MaxLatencyMs })
+	report.TopMemory = topClusterImpact(entries, topN, func(d clusterImpactDigest) float64 { return float64(d.MaxMemBytes) })
+	report.TopCop = topClusterImpact(entries, topN, func(d clusterImpactDigest) float64 { return float64(d.CopTasks) })
+}
+
+// topClusterImpact returns the topN entries with the largest non-zero key,
+// ties broken by digest so the report is stable.
+func topClusterImpact(entries []clusterImpactDigest, topN int, key func(clusterImpactDigest) float64) []clusterImpactDigest {
+	out := make([]clusterImpactDigest, 0, len(entries))
+	for _, entry := range entries {
+		if key(entry) > 0 {
+			out = append(out, entry)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		ki, kj := key(out[i]), key(out[j])
+		if ki != kj {
+			return ki > kj
+		}
+		return out[i].Digest < out[j].Digest
+	})
+	if len(out) > topN {
+		out = out[:topN]
+	}
+	return out
+}
+
+// percentile returns the nearest-rank percentile of sorted values.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[min(max(idx, 0), len(sorted)-1)]
+}
+
+func parseRowInt(value string) int64 {
+	v, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+func nanosToMillis(ns int64) float64 {
+	return math.Round(float64(ns)/1e3) / 1e3
+}
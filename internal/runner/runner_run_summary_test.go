@@ -0,0 +1,55 @@
+package runner
+
+import "testing"
+
+func TestClusterImpactSchemaFilter(t *testing.T) {
+	got := clusterImpactSchemaFilter("shiro_fuzz")
+	want := `(SCHEMA_NAME = 'shiro_fuzz' OR SCHEMA_NAME LIKE 'shiro\_fuzz\_r%')`
+	if got != want {
+		t.Fatalf("clusterImpactSchemaFilter=%q want=%q", got, want)
+	}
+}
+
+func TestSummarizeClusterImpact(t *testing.T) {
+	cols := []string{"DIGEST", "STMT_TYPE", "DIGEST_TEXT", "QUERY_SAMPLE_TEXT", "EXEC_COUNT", "SUM_LATENCY", "MAX_LATENCY", "MAX_MEM", "SUM_COP_TASK_NUM"}
+	rows := [][]string{
+		{"d1", "Select", "select ? from t0", "SELECT 1 FROM t0", "2", "4000000", "3000000", "100", "4"},
+		// Same digest on another instance or summary window.
+		{"d1", "Select", "select ? from t0", "SELECT 2 FROM t0", "2", "4000000", "1000000", "300", "0"},
+		{"d2", "Insert", "insert into t0 values (?)", "INSERT INTO t0 VALUES (1)", "1", "9000000", "9000000", "0", "0"},
+		{"", "Select", "ignored", "", "1", "1", "1", "1", "1"},
+	}
+	digests := make(map[string]*clusterImpactDigest)
+	for _, row := range rows {
+		mergeClusterImpactRow(digests, cols, row)
+	}
+	report := &clusterImpactReport{}
+	summarizeClusterImpact(report, digests, 1)
+	if report.Digests != 2 || report.Executions != 5 {
+		t.Fatalf("digests=%d executions=%d want=2,5", report.Digests, report.Executions)
+	}
+	if report.LatencyMs.Max != 9 || report.LatencyMs.P50 != 2 || report.LatencyMs.P99 != 9 {
+		t.Fatalf("unexpected latency: %+v", report.LatencyMs)
+	}
+	if len(report.TopLatency) != 1 || report.TopLatency[0].Digest != "d2" {
+		t.Fatalf("unexpected top latency: %+v", report.TopLatency)
+	}
+	if len(report.TopMemory) != 1 || report.TopMemory[0].Digest != "d1" || report.TopMemory[0].MaxMemBytes != 300 {
+		t.Fatalf("unexpected top memory: %+v", report.TopMemory)
+	}
+	if len(report.TopCop) != 1 || report.TopCop[0].CopTasks != 4 || report.TopCop[0].AvgLatencyMs != 2 {
+		t.Fatalf("unexpected top cop tasks: %+v", report.TopCop)
+	}
+}
+
+func TestClusterTopologyFromRows(t *testing.T) {
+	cols := []string{"TYPE", "VERSION"}
+	rows := [][]string{{"tidb", "8.5.0"}, {"pd", "8.5.0"}, {"tikv", "8.5.0"}, {"tikv", "8.5.0"}, {"tiflash", "8.5.0"}}
+	topo := clusterTopologyFromRows(cols, rows)
+	if got := topo.String(); got != "pd=1 tidb=1 tikv=2 tiflash=1" {
+		t.Fatalf("unexpected topology: %q", got)
+	}
+	if clusterTopologyFromRows(cols, nil) != nil {
+		t.Fatalf("expected nil topology without rows")
+	}
+}
@@ -0,0 +1,141 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"time"
+
+	"shiro/internal/oracle"
+	"shiro/internal/sqlstep"
+	"shiro/internal/util"
+)
+
+// querySampler appends sampled oracle runs to one JSON-lines file per runner.
+// It draws from its own random source so enabling sampling does not change the
+// generated queries of a seed.
+type querySampler struct {
+	rng     *rand.Rand
+	file    *os.File
+	path    string
+	written int
+}
+
+// querySample is one line of the sample file.
+type querySample struct {
+	Timestamp   string         `json:"timestamp"`
+	Database    string         `json:"database"`
+	Oracle      string         `json:"oracle"`
+	Outcome     string         `json:"outcome"`
+	SkipReason  string         `json:"skip_reason,omitempty"`
+	ErrorReason string         `json:"error_reason,omitempty"`
+	Error       string         `json:"error,omitempty"`
+	Steps       []sqlstep.Step `json:"steps,omitempty"`
+	Expected    string         `json:"expected,omitempty"`
+	Actual      string         `json:"actual,omitempty"`
+	Plan        string         `json:"plan,omitempty"`
+	Features    []string       `json:"features,omitempty"`
+	Details     map[string]any `json:"details,omitempty"`
+	// NullPredicates and PredicateLeaves give the query's NULL density.
+	NullPredicates  int `json:"null_predicates,omitempty"`
+	PredicateLeaves int `json:"predicate_leaves,omitempty"`
+}
+
+// Query sample outcomes.
+const (
+	querySampleOK    = "ok"
+	querySampleSkip  = "skip"
+	querySampleError = "error"
+)
+
+// startQuerySampling opens the sample file when logging.query_sample.rate is
+// positive. Failures only disable sampling. The returned func closes the file.
+func (r *Runner) startQuerySampling() func() {
+	cfg := r.cfg.Logging.QuerySample
+	if cfg.Rate <= 0 {
+		return func() {}
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		util.Warnf("query sampling disabled dir=%s err=%v", cfg.Dir, err)
+		return func() {}
+	}
+	name := fmt.Sprintf("samples-%s-%s.jsonl", r.baseDB, time.Now().Format("20060102-150405"))
+	path := filepath.Join(cfg.Dir, name)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		util.Warnf("query sampling disabled dir=%s err=%v", cfg.Dir, err)
+		return func() {}
+	}
+	r.querySampler = &querySampler{rng: rand.New(rand.NewSource(r.cfg.Seed)), file: file, path: path}
+	util.Infof("query sampling enabled file=%s rate=%g max_samples=%d", path, cfg.Rate, cfg.MaxSamples)
+	return func() {
+		util.CloseWithErr(file, "query sample file")
+		r.querySampler = nil
+	}
+}
+
+// maybeSampleQuery persists a non-bug oracle run with probability
+// logging.query_sample.rate, up to max_samples per runner.
+func (r *Runner) maybeSampleQuery(ctx context.Context, result oracle.Result, skipReason string) {
+	sampler := r.querySampler
+	cfg := r.cfg.Logging.QuerySample
+	if sampler == nil || sampler.written >= cfg.MaxSamples || sampler.rng.Float64() >= cfg.Rate {
+		return
+	}
+	sample := querySample{
+		Timestamp:   time.Now().Format(time.RFC3339),
+		Database:    r.cfg.Database,
+		Oracle:      result.Oracle,
+		Outcome:     querySampleOutcome(result, skipReason),
+		SkipReason:  skipReason,
+		ErrorReason: effectiveResultErrorReason(result),
+		Steps:       result.Steps,
+		Expected:    result.Expected,
+		Actual:      result.Actual,
+		Details:     result.Details,
+	}
+	if result.Err != nil {
+		sample.Error = result.Err.Error()
+	}
+	if r.gen != nil {
+		sample.Features = queryFeatureFlags(r.gen.LastFeatures)
+		if features := r.gen.LastFeatures; features != nil {
+			sample.NullPredicates = features.NullPredicates
+			sample.PredicateLeaves = features.PredicateLeaves
+		}
+	}
+	if cfg.Explain && sample.Outcome == querySampleOK {
+		if replaySQL := pickReplaySQL(result.Steps); replaySQL != "" {
+			if cols, rows, err := r.explainRows(ctx, replaySQL); err == nil {
+				sample.Plan = planText(cols, rows)
+			}
+		}
+	}
+	line, err := json.Marshal(sample)
+	if err != nil {
+		// Details may hold values that do not marshal; keep the rest.
+		sample.Details = nil
+		if line, err = json.Marshal(sample); err != nil {
+			return
+		}
+	}
+	if _, err := sampler.file.Write(append(line, '\n')); err != nil {
+		util.Warnf("query sample write failed file=%s err=%v", sampler.path, err)
+		return
+	}
+	sampler.written++
+}
+
+func querySampleOutcome(result oracle.Result, skipReason string) string {
+	switch {
+	case result.Err != nil:
+		return querySampleError
+	case skipReason != "":
+		return querySampleSkip
+	default:
+		return querySampleOK
+	}
+}
@@ -0,0 +1,53 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"shiro/internal/config"
+	"shiro/internal/oracle"
+	"shiro/internal/sqlstep"
+)
+
+func TestMaybeSampleQueryWritesJSONLines(t *testing.T) {
+	dir := t.TempDir()
+	r := &Runner{baseDB: "shiro_fuzz", cfg: config.Config{Database: "shiro_fuzz"}}
+	r.cfg.Logging.QuerySample = config.QuerySampleConfig{Rate: 1, Dir: dir, MaxSamples: 2}
+	stop := r.startQuerySampling()
+	ctx := context.Background()
+	r.maybeSampleQuery(ctx, oracle.Result{OK: true, Oracle: "TLP", Steps: []sqlstep.Step{sqlstep.New(sqlstep.KindQuery, "", "SELECT 1")}, Details: map[string]any{"skip_reason": "tlp:no_tables"}}, "tlp:no_tables")
+	r.maybeSampleQuery(ctx, oracle.Result{OK: true, Oracle: "NoREC", Steps: []sqlstep.Step{sqlstep.New(sqlstep.KindQuery, "", "SELECT 2")}, Err: errors.New("boom")}, "")
+	r.maybeSampleQuery(ctx, oracle.Result{OK: true, Oracle: "DQP", Steps: []sqlstep.Step{sqlstep.New(sqlstep.KindQuery, "", "SELECT 3")}}, "")
+	stop()
+
+	files, err := filepath.Glob(filepath.Join(dir, "samples-shiro_fuzz-*.jsonl"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("sample files=%v err=%v", files, err)
+	}
+	content, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("read samples: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("samples=%d want=2 (max_samples)", len(lines))
+	}
+	var first, second querySample
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("decode sample: %v", err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &second); err != nil {
+		t.Fatalf("decode sample: %v", err)
+	}
+	if first.Oracle != "TLP" || first.Outcome != querySampleSkip || first.SkipReason != "tlp:no_tables" {
+		t.Fatalf("first sample=%+v", first)
+	}
+	if second.Outcome != querySampleError || second.Error != "boom" || len(second.Steps) != 1 {
+		t.Fatalf("second sample=%+v", second)
+	}
+}
@@ -0,0 +1,110 @@
+package runner
+
+import (
+	"context"
+	"strings"
+
+	"shiro/internal/generator"
+	"shiro/internal/schema"
+	"shiro/internal/util"
+)
+
+const (
+	// scaleInsertBatchDivisor derives the INSERT batch size from the row
+	// budget of a scale step that does not set insert_batch_rows.
+	scaleInsertBatchDivisor = 16
+	scaleInsertBatchMax     = 200
+)
+
+// applyScaleSchedule applies the scale_schedule steps reached at iteration.
+// A grown row budget tops up the existing base tables right away; a lower
+// table budget only stops CREATE TABLE, existing tables are kept.
+func (r *Runner) applyScaleSchedule(ctx context.Context, iteration int) {
+	steps := r.cfg.ScaleSchedule
+	if r.scaleApplied >= len(steps) || steps[r.scaleApplied].AtIteration > iteration {
+		return
+	}
+	// Queued oracles read the generator config and the tables.
+	r.drainPipeline(ctx)
+	prevRows := r.cfg.MaxRowsPerTable
+	for r.scaleApplied < len(steps) && steps[r.scaleApplied].AtIteration <= iteration {
+		step := steps[r.scaleApplied]
+		r.scaleApplied++
+		if step.MaxTables > 0 {
+			r.cfg.MaxTables = step.MaxTables
+		}
+		if step.MaxRowsPerTable > 0 {
+			r.cfg.MaxRowsPerTable = step.MaxRowsPerTable
+		}
+		switch {
+		case step.InsertBatchRows > 0:
+			r.cfg.InsertBatchRows = step.InsertBatchRows
+		case step.MaxRowsPerTable > 0:
+			r.cfg.InsertBatchRows = scaleInsertBatchRows(step.MaxRowsPerTable)
+		}
+	}
+	r.gen.Config.MaxTables = r.cfg.MaxTables
+	r.gen.Config.MaxRowsPerTable = r.cfg.MaxRowsPerTable
+	r.gen.Config.InsertBatchRows = r.cfg.InsertBatchRows
+	util.Infof("scale step applied iteration=%d max_tables=%d max_rows_per_table=%d insert_batch_rows=%d",
+		iteration, r.cfg.MaxTables, r.cfg.MaxRowsPerTable, r.cfg.InsertBatchRows)
+	if r.cfg.MaxRowsPerTable > prevRows {
+		r.topUpTableRows(ctx, r.cfg.MaxRowsPerTable-prevRows)
+	}
+}
+
+// scaleInsertBatchRows grows the INSERT batch with the row budget, so larger
+// tables are filled with fewer statements and stay within the insert log.
+func scaleInsertBatchRows(maxRows int) int {
+	return min(max(maxRows/scaleInsertBatchDivisor, generator.InsertRowCountMax), scaleInsertBatchMax)
+}
+
+// topUpTableRows inserts rows more rows into every base table. TQS tables
+// are skipped because their ground truth is built from the initial rows.
+func (r *Runner) topUpTableRows(ctx context.Context, rows int) {
+	if r.cfg.TQS.Enabled {
+		return
+	}
+	for _, tbl := range r.baseTables() {
+		if err := r.fillTableRows(ctx, tbl, rows); err != nil {
+			util.Warnf("scale top-up stopped table=%s err=%v", tbl.Name, err)
+		}
+	}
+}
+
+// fillTableRows inserts rows rows into tbl with INSERTs of
+// insert_fill_batch_rows rows each. Whitelisted errors skip a batch; other
+// errors stop the fill and are returned.
+func (r *Runner) fillTableRows(ctx context.Context, tbl *schema.Table, rows int) error {
+	batch := max(r.cfg.InsertFillBatchRows, 1)
+	for _, size := range fillBatchSizes(rows, batch) {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		insertSQL, _ := r.gen.InsertRowsSQL(tbl, size)
+		if strings.TrimSpace(insertSQL) == "" {
+			continue
+		}
+		if err := r.execSQL(ctx, insertSQL); err != nil {
+			if _, ok := isWhitelistedSQLError(err); ok {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// fillBatchSizes splits rows into INSERT batches of at most batch rows.
+func fillBatchSizes(rows, batch int) []int {
+	if rows <= 0 || batch <= 0 {
+		return nil
+	}
+	sizes := make([]int, 0, (rows+batch-1)/batch)
+	for rows > 0 {
+		size := min(rows, batch)
+		sizes = append(sizes, size)
+		rows -= size
+	}
+	return sizes
+}
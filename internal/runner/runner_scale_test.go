@@ -0,0 +1,63 @@
+package runner
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"shiro/internal/config"
+	"shiro/internal/generator"
+	"shiro/internal/schema"
+)
+
+func TestApplyScaleSchedule(t *testing.T) {
+	cfg := config.Config{
+		MaxTables:       5,
+		MaxRowsPerTable: 50,
+		ScaleSchedule: []config.ScaleStep{
+			{AtIteration: 0, MaxTables: 2, MaxRowsPerTable: 20},
+			{AtIteration: 100, MaxRowsPerTable: 1600},
+			{AtIteration: 100, MaxTables: 8},
+			{AtIteration: 500, InsertBatchRows: 7},
+		},
+	}
+	state := &schema.State{}
+	r := &Runner{cfg: cfg, gen: generator.New(cfg, state, 1), state: state}
+	ctx := context.Background()
+
+	r.applyScaleSchedule(ctx, 0)
+	if r.cfg.MaxTables != 2 || r.cfg.MaxRowsPerTable != 20 || r.cfg.InsertBatchRows != generator.InsertRowCountMax {
+		t.Fatalf("unexpected first step: tables=%d rows=%d batch=%d", r.cfg.MaxTables, r.cfg.MaxRowsPerTable, r.cfg.InsertBatchRows)
+	}
+	r.applyScaleSchedule(ctx, 99)
+	if r.scaleApplied != 1 {
+		t.Fatalf("step applied too early: %d", r.scaleApplied)
+	}
+	// Later iterations apply every reached step, and zero fields keep values.
+	r.applyScaleSchedule(ctx, 120)
+	if r.scaleApplied != 3 || r.cfg.MaxTables != 8 || r.cfg.MaxRowsPerTable != 1600 || r.cfg.InsertBatchRows != 100 {
+		t.Fatalf("unexpected grown scale: applied=%d tables=%d rows=%d batch=%d", r.scaleApplied, r.cfg.MaxTables, r.cfg.MaxRowsPerTable, r.cfg.InsertBatchRows)
+	}
+	r.applyScaleSchedule(ctx, 500)
+	if r.cfg.MaxRowsPerTable != 1600 || r.cfg.InsertBatchRows != 7 {
+		t.Fatalf("explicit insert_batch_rows should win: rows=%d batch=%d", r.cfg.MaxRowsPerTable, r.cfg.InsertBatchRows)
+	}
+	if r.gen.Config.MaxTables != 8 || r.gen.Config.MaxRowsPerTable != 1600 || r.gen.Config.InsertBatchRows != 7 {
+		t.Fatalf("generator config not updated: %+v", r.gen.Config)
+	}
+}
+
+func TestScaleInsertBatching(t *testing.T) {
+	if got := scaleInsertBatchRows(50); got != generator.InsertRowCountMax {
+		t.Fatalf("small budgets keep the default batch, got %d", got)
+	}
+	if got := scaleInsertBatchRows(1 << 20); got != scaleInsertBatchMax {
+		t.Fatalf("batch should be capped, got %d", got)
+	}
+	if got := fillBatchSizes(100, 30); !slices.Equal(got, []int{30, 30, 30, 10}) {
+		t.Fatalf("fillBatchSizes(100, 30) = %v", got)
+	}
+	if got := fillBatchSizes(0, 30); got != nil {
+		t.Fatalf("no growth needs no statements, got %v", got)
+	}
+}
@@ -0,0 +1,145 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"shiro/internal/schema"
+	"shiro/internal/util"
+)
+
+// Schema shrink actions replace create_table once MaxTables base tables
+// exist, so the DDL space keeps changing instead of freezing at the cap.
+const (
+	ddlActionDropTable     = "drop_table"
+	ddlActionTruncateTable = "truncate_table"
+)
+
+// shrinkCandidates returns the base tables no other table references through
+// a foreign key. TiDB refuses to drop or truncate a referenced parent.
+func shrinkCandidates(baseTables []*schema.Table) []*schema.Table {
+	referenced := make(map[string]struct{})
+	for _, tbl := range baseTables {
+		for _, fk := range tbl.ForeignKeys {
+			if !strings.EqualFold(fk.RefTable, tbl.Name) {
+				referenced[strings.ToLower(fk.RefTable)] = struct{}{}
+			}
+		}
+	}
+	out := make([]*schema.Table, 0, len(baseTables))
+	for _, tbl := range baseTables {
+		if _, ok := referenced[strings.ToLower(tbl.Name)]; !ok {
+			out = append(out, tbl)
+		}
+	}
+	return out
+}
+
+// runShrinkAction drops or truncates a random table that is safe to shrink.
+// Dropped tables must also be unused by every view, since the state does not
+// track view dependencies.
+func (r *Runner) runShrinkAction(ctx context.Context, action string, baseTables []*schema.Table) {
+	candidates := shrinkCandidates(baseTables)
+	if action == ddlActionDropTable && len(candidates) > 0 && r.viewCount() > 0 {
+		definitions, err := r.viewDefinitions(ctx)
+		if err != nil {
+			return
+		}
+		candidates = tablesUnusedByViews(candidates, definitions)
+	}
+	if len(candidates) == 0 {
+		return
+	}
+	name := candidates[r.gen.Rand.Intn(len(candidates))].Name
+	switch action {
+	case ddlActionDropTable:
+		if err := r.execSQL(ctx, fmt.Sprintf("DROP TABLE %s", name)); err != nil {
+			return
+		}
+		r.state.Tables = removeStateTable(r.state.Tables, name)
+		r.state.ForgetKeys(name)
+		r.journalTableShrink(name, fmt.Sprintf("DROP TABLE IF EXISTS %s", name), true)
+	case ddlActionTruncateTable:
+		stmt := fmt.Sprintf("TRUNCATE TABLE %s", name)
+		if err := r.execSQL(ctx, stmt); err != nil {
+			return
+		}
+		for i := range r.state.Tables {
+			if r.state.Tables[i].Name == name {
+				// Generated child keys assume ids 1..NextID-1 exist.
+				r.state.Tables[i].NextID = 1
+			}
+		}
+		r.state.ForgetKeys(name)
+		r.journalTableShrink(name, stmt, false)
+	}
+	util.Detailf("schema shrink action=%s table=%s", action, name)
+}
+
+// journalTableShrink records a drop or truncate in the insert log so
+// inserts.sql replays to the same data. A drop also removes the earlier
+// inserts into the table, whose schema is no longer dumped with the case.
+func (r *Runner) journalTableShrink(table string, stmt string, drop bool) {
+	if r.cfg.MaxInsertStatements <= 0 {
+		return
+	}
+	if drop {
+		r.insertLog = filterInsertsExcludingTable(r.insertLog, table)
+	}
+	if len(r.insertLog) >= r.cfg.MaxInsertStatements {
+		r.insertLog = r.insertLog[1:]
+	}
+	r.insertLog = append(r.insertLog, stmt)
+}
+
+func (r *Runner) viewDefinitions(ctx context.Context) ([]string, error) {
+	qctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	rows, err := r.exec.QueryContext(qctx, "SELECT VIEW_DEFINITION FROM information_schema.VIEWS WHERE TABLE_SCHEMA = DATABASE()")
+	if err != nil {
+		return nil, err
+	}
+	defer util.CloseWithErr(rows, "view definitions")
+	var out []string
+	for rows.Next() {
+		var definition string
+		if err := rows.Scan(&definition); err != nil {
+			return nil, err
+		}
+		out = append(out, definition)
+	}
+	return out, rows.Err()
+}
+
+// tablesUnusedByViews drops the tables whose name appears as an identifier in
+// any view definition. Matching is by name only, so it may keep a table out
+// needlessly but never lets a referenced one through.
+func tablesUnusedByViews(tables []*schema.Table, definitions []string) []*schema.Table {
+	out := make([]*schema.Table, 0, len(tables))
+	for _, tbl := range tables {
+		pattern := regexp.MustCompile(`(?i)(^|[^0-9a-z_$])` + regexp.QuoteMeta(tbl.Name) + `([^0-9a-z_$]|$)`)
+		used := false
+		for _, definition := range definitions {
+			if pattern.MatchString(definition) {
+				used = true
+				break
+			}
+		}
+		if !used {
+			out = append(out, tbl)
+		}
+	}
+	return out
+}
+
+func removeStateTable(tables []schema.Table, name string) []schema.Table {
+	out := tables[:0]
+	for _, tbl := range tables {
+		if tbl.Name != name {
+			out = append(out, tbl)
+		}
+	}
+	return out
+}
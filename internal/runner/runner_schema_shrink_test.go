@@ -0,0 +1,71 @@
+package runner
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"shiro/internal/config"
+	"shiro/internal/schema"
+
+	"github.com/pingcap/tidb/pkg/parser"
+)
+
+func TestShrinkCandidatesSkipReferencedParents(t *testing.T) {
+	tables := []schema.Table{
+		{Name: "t0"},
+		{Name: "t1", ForeignKeys: []schema.ForeignKey{{Table: "t1", RefTable: "t0"}}},
+		{Name: "t2", ForeignKeys: []schema.ForeignKey{{Table: "t2", RefTable: "t2"}}},
+	}
+	ptrs := []*schema.Table{&tables[0], &tables[1], &tables[2]}
+	var names []string
+	for _, tbl := range shrinkCandidates(ptrs) {
+		names = append(names, tbl.Name)
+	}
+	if strings.Join(names, ",") != "t1,t2" {
+		t.Fatalf("unexpected shrink candidates: %v", names)
+	}
+	definitions := []string{"SELECT `t1`.`id` AS `id` FROM `shiro`.`t1`", "select t10.c0 from t10"}
+	unused := tablesUnusedByViews(ptrs, definitions)
+	if len(unused) != 2 || unused[0].Name != "t0" || unused[1].Name != "t2" {
+		t.Fatalf("unexpected tables unused by views: %+v", unused)
+	}
+	remaining := removeStateTable(tables, "t1")
+	if len(remaining) != 2 || remaining[0].Name != "t0" || remaining[1].Name != "t2" {
+		t.Fatalf("unexpected tables after drop: %+v", remaining)
+	}
+}
+
+func TestJournalTableShrink(t *testing.T) {
+	r := &Runner{cfg: config.Config{MaxInsertStatements: 4}}
+	r.insertLog = []string{
+		"INSERT INTO t0 VALUES (1)",
+		"INSERT INTO t1 VALUES (1)",
+		"INSERT INTO t0 VALUES (2)",
+	}
+	r.journalTableShrink("t1", "TRUNCATE TABLE t1", false)
+	r.journalTableShrink("t0", "DROP TABLE IF EXISTS t0", true)
+	want := "INSERT INTO t1 VALUES (1)|TRUNCATE TABLE t1|DROP TABLE IF EXISTS t0"
+	if got := strings.Join(r.insertLog, "|"); got != want {
+		t.Fatalf("unexpected insert log:\n got %s\nwant %s", got, want)
+	}
+	used := map[string]struct{}{"t1": {}}
+	if got := strings.Join(filterInsertsByTables(r.insertLog, used), "|"); got != "INSERT INTO t1 VALUES (1)|TRUNCATE TABLE t1" {
+		t.Fatalf("minimize must keep only journal entries of used tables: %s", got)
+	}
+	p := parser.New()
+	if got := insertTargetTable(p, "DROP VIEW v0"); got != "" {
+		t.Fatalf("views are not journal targets: %s", got)
+	}
+}
+
+func TestStateForgetKeys(t *testing.T) {
+	state := &schema.State{}
+	r := rand.New(rand.NewSource(1))
+	state.RecordKey(r, "t1", "id", "1")
+	state.RecordKey(r, "t10", "id", "2")
+	state.ForgetKeys("t1")
+	if state.HasKey("t1", "id", "1") || !state.HasKey("t10", "id", "2") {
+		t.Fatalf("ForgetKeys must drop only the samples of t1")
+	}
+}
@@ -0,0 +1,116 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"shiro/internal/config"
+	"shiro/internal/generator"
+	"shiro/internal/util"
+)
+
+const (
+	// selectivityWarmup is how many measurements pass before the controller
+	// starts aiming predicates at a bucket.
+	selectivityWarmup = 20
+	// selectivityLogEvery is how many measurements pass between mix log lines.
+	selectivityLogEvery = 100
+)
+
+// selectivityState counts the measured selectivity buckets. It outlives
+// database rotation since it describes the generator, not the data.
+type selectivityState struct {
+	counts map[generator.SelectivityBucket]int64
+	total  int64
+}
+
+// prepareSelectivityTarget aims the next query's predicates at the bucket
+// furthest below its configured share and samples whether it is measured.
+// The returned func clears the target so DML predicates stay unbiased.
+func (r *Runner) prepareSelectivityTarget() func() {
+	if !r.cfg.Selectivity.Enabled || r.gen == nil {
+		return func() {}
+	}
+	if r.selectivity == nil {
+		r.selectivity = &selectivityState{counts: make(map[generator.SelectivityBucket]int64)}
+	}
+	target := r.selectivity.target(r.cfg.Selectivity.Mix)
+	probe := util.Chance(r.gen.Rand, r.cfg.Selectivity.SampleProb)
+	gen := r.gen
+	gen.SetSelectivityTarget(target, probe)
+	return func() { gen.SetSelectivityTarget("", false) }
+}
+
+// target returns the bucket with the largest deficit between its desired and
+// observed share, or no target during warmup.
+func (s *selectivityState) target(mix config.SelectivityMix) generator.SelectivityBucket {
+	if s.total < selectivityWarmup {
+		return ""
+	}
+	weights := []int{mix.Empty, mix.Rare, mix.Moderate, mix.All}
+	weightSum := 0
+	for _, weight := range weights {
+		weightSum += weight
+	}
+	if weightSum <= 0 {
+		return ""
+	}
+	var best generator.SelectivityBucket
+	bestDeficit := 0.0
+	for i, bucket := range generator.SelectivityBuckets {
+		desired := float64(weights[i]) / float64(weightSum)
+		observed := float64(s.counts[bucket]) / float64(s.total)
+		if deficit := desired - observed; deficit > bestDeficit {
+			best, bestDeficit = bucket, deficit
+		}
+	}
+	return best
+}
+
+func (s *selectivityState) record(bucket generator.SelectivityBucket) {
+	s.counts[bucket]++
+	s.total++
+}
+
+func (s *selectivityState) summary() string {
+	parts := make([]string, 0, len(generator.SelectivityBuckets))
+	for _, bucket := range generator.SelectivityBuckets {
+		parts = append(parts, fmt.Sprintf("%s=%.0f%%", bucket, 100*float64(s.counts[bucket])/float64(max(s.total, 1))))
+	}
+	return strings.Join(parts, " ")
+}
+
+// observeSelectivity runs the COUNT(*) probes of a sampled query and records
+// its bucket. Probe errors and empty FROM clauses are not recorded.
+func (r *Runner) observeSelectivity(ctx context.Context, features *generator.QueryFeatures) {
+	if r.selectivity == nil || features == nil || features.SelectivityMatchSQL == "" {
+		return
+	}
+	matched, err := r.selectivityCount(ctx, features.SelectivityMatchSQL)
+	if err != nil {
+		util.Detailf("selectivity probe failed: %v", err)
+		return
+	}
+	total, err := r.selectivityCount(ctx, features.SelectivityTotalSQL)
+	if err != nil {
+		util.Detailf("selectivity probe failed: %v", err)
+		return
+	}
+	bucket, ok := generator.ClassifySelectivity(matched, total, r.cfg.Selectivity.RareMaxPercent)
+	if !ok {
+		return
+	}
+	r.selectivity.record(bucket)
+	if r.selectivity.total%selectivityLogEvery == 0 {
+		util.Infof("selectivity mix measured=%d %s target=%s", r.selectivity.total, r.selectivity.summary(), features.SelectivityTarget)
+	}
+}
+
+func (r *Runner) selectivityCount(ctx context.Context, sqlText string) (int64, error) {
+	qctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	var count int64
+	err := r.exec.QueryRowContext(qctx, sqlText).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,28 @@
+package runner
+
+import (
+	"testing"
+
+	"shiro/internal/config"
+	"shiro/internal/generator"
+)
+
+func TestSelectivityTargetPicksLargestDeficit(t *testing.T) {
+	state := &selectivityState{counts: make(map[generator.SelectivityBucket]int64)}
+	mix := config.SelectivityMix{Empty: 10, Rare: 30, Moderate: 40, All: 20}
+	for i := 0; i < 10; i++ {
+		state.record(generator.SelectivityAll)
+	}
+	if got := state.target(mix); got != "" {
+		t.Fatalf("expected no target during warmup, got %s", got)
+	}
+	for i := 0; i < 10; i++ {
+		state.record(generator.SelectivityModerate)
+	}
+	if got := state.target(mix); got != generator.SelectivityRare {
+		t.Fatalf("expected the rare bucket, got %s", got)
+	}
+	if got := state.summary(); got != "empty=0% rare=0% moderate=50% all=50%" {
+		t.Fatalf("unexpected summary: %s", got)
+	}
+}
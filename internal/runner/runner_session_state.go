@@ -0,0 +1,171 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"shiro/internal/report"
+	"shiro/internal/util"
+)
+
+const sessionStateFile = "session.json"
+
+// sessionVolatileVars change on every statement or name the connection
+// itself, so they never explain a failed repro.
+var sessionVolatileVars = map[string]struct{}{
+	"error_count":                   {},
+	"identity":                      {},
+	"insert_id":                     {},
+	"last_insert_id":                {},
+	"last_plan_from_binding":        {},
+	"last_plan_from_cache":          {},
+	"pseudo_thread_id":              {},
+	"rand_seed1":                    {},
+	"rand_seed2":                    {},
+	"tidb_current_ts":               {},
+	"tidb_last_ddl_info":            {},
+	"tidb_last_plan_replayer_token": {},
+	"tidb_last_query_info":          {},
+	"tidb_last_txn_info":            {},
+	"timestamp":                     {},
+	"warning_count":                 {},
+}
+
+// sessionState is the session context written to session.json.
+type sessionState struct {
+	SQLMode         string            `json:"sql_mode"`
+	TimeZone        string            `json:"time_zone"`
+	SystemTimeZone  string            `json:"system_time_zone,omitempty"`
+	OptimizerSwitch map[string]string `json:"optimizer_switch,omitempty"`
+	PlanCache       map[string]string `json:"plan_cache,omitempty"`
+	// Overrides holds the session variables whose value differs from the
+	// global one, NonDefault those whose value differs from the built-in
+	// default.
+	Overrides   map[string]sessionVarValue `json:"session_overrides,omitempty"`
+	NonDefault  map[string]sessionVarValue `json:"non_default,omitempty"`
+	SessionInit []string                   `json:"session_init,omitempty"`
+	CaseSetVar  string                     `json:"case_set_var,omitempty"`
+	Errors      []string                   `json:"errors,omitempty"`
+}
+
+type sessionVarValue struct {
+	Value   string `json:"value"`
+	Global  string `json:"global,omitempty"`
+	Default string `json:"default,omitempty"`
+}
+
+// captureSessionState writes the session context of a runner connection to
+// session.json: sql_mode, time zone, optimizer switches, plan cache settings,
+// and every variable that differs from its global value or its default.
+// Pool connections share the session init statements, so any connection
+// shows the state the case ran with; the case's own SET is recorded too.
+func (r *Runner) captureSessionState(ctx context.Context, caseData report.Case, details map[string]any) {
+	qctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	conn, err := r.exec.Conn(qctx)
+	if err != nil {
+		details["session_state_error"] = err.Error()
+		return
+	}
+	defer util.CloseWithErr(conn, "session state conn")
+	queryVars := func(query string) (map[string]string, error) {
+		rows, err := conn.QueryContext(qctx, query)
+		if err != nil {
+			return nil, err
+		}
+		defer util.CloseWithErr(rows, "session state rows")
+		_, values, err := scanStringRows(rows)
+		if err != nil {
+			return nil, err
+		}
+		out := make(map[string]string, len(values))
+		for _, row := range values {
+			if len(row) >= 2 {
+				out[strings.ToLower(row[0])] = row[1]
+			}
+		}
+		return out, nil
+	}
+	session, err := queryVars("SHOW SESSION VARIABLES")
+	if err != nil {
+		details["session_state_error"] = err.Error()
+		return
+	}
+	var errs []string
+	global, err := queryVars("SHOW GLOBAL VARIABLES")
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("global variables: %v", err))
+	}
+	defaults, err := queryVars("SELECT VARIABLE_NAME, DEFAULT_VALUE FROM INFORMATION_SCHEMA.VARIABLES_INFO")
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("variable defaults: %v", err))
+	}
+	state := buildSessionState(session, global, defaults)
+	state.SessionInit = r.cfg.SessionInit
+	state.CaseSetVar = detailString(details, "replay_set_var")
+	state.Errors = errs
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := r.reporter.WriteText(caseData, sessionStateFile, string(data)); err != nil {
+		details["session_state_error"] = err.Error()
+		return
+	}
+	details["session_state_file"] = sessionStateFile
+}
+
+// buildSessionState derives the session context from the session and global
+// variables and the built-in defaults. Values compare case-insensitively, so
+// ON and on match.
+func buildSessionState(session, global, defaults map[string]string) sessionState {
+	state := sessionState{
+		SQLMode:         session["sql_mode"],
+		TimeZone:        session["time_zone"],
+		SystemTimeZone:  session["system_time_zone"],
+		OptimizerSwitch: parseOptimizerSwitch(session["optimizer_switch"]),
+		PlanCache:       map[string]string{},
+		Overrides:       map[string]sessionVarValue{},
+		NonDefault:      map[string]sessionVarValue{},
+	}
+	names := make([]string, 0, len(session))
+	for name := range session {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := session[name]
+		if strings.Contains(name, "plan_cache") {
+			state.PlanCache[name] = value
+		}
+		if _, ok := sessionVolatileVars[name]; ok {
+			continue
+		}
+		if g, ok := global[name]; ok && !strings.EqualFold(g, value) {
+			state.Overrides[name] = sessionVarValue{Value: value, Global: g}
+		}
+		if d, ok := defaults[name]; ok && !strings.EqualFold(d, value) {
+			state.NonDefault[name] = sessionVarValue{Value: value, Default: d}
+		}
+	}
+	return state
+}
+
+// parseOptimizerSwitch splits an optimizer_switch value such as
+// "index_merge=on,mrr=off" into flags.
+func parseOptimizerSwitch(value string) map[string]string {
+	flags := map[string]string{}
+	for _, part := range strings.Split(value, ",") {
+		name, setting, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if ok && name != "" {
+			flags[name] = setting
+		}
+	}
+	if len(flags) == 0 {
+		return nil
+	}
+	return flags
+}
@@ -0,0 +1,65 @@
+package runner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildSessionState(t *testing.T) {
+	session := map[string]string{
+		"sql_mode":                            "ONLY_FULL_GROUP_BY,STRICT_TRANS_TABLES",
+		"time_zone":                           "+08:00",
+		"system_time_zone":                    "UTC",
+		"optimizer_switch":                    "index_merge=on, mrr=off",
+		"tidb_enable_prepared_plan_cache":     "ON",
+		"tidb_enable_non_prepared_plan_cache": "off",
+		"tidb_opt_enable_hash_join":           "OFF",
+		"tidb_isolation_read_engines":         "tikv,tiflash,tidb",
+		"timestamp":                           "1760000000.123",
+		"max_execution_time":                  "0",
+	}
+	global := map[string]string{
+		"sql_mode":                            "ONLY_FULL_GROUP_BY,STRICT_TRANS_TABLES",
+		"time_zone":                           "SYSTEM",
+		"tidb_enable_prepared_plan_cache":     "ON",
+		"tidb_enable_non_prepared_plan_cache": "OFF",
+		"tidb_opt_enable_hash_join":           "ON",
+		"timestamp":                           "0",
+		"max_execution_time":                  "0",
+	}
+	defaults := map[string]string{
+		"sql_mode":                    "ONLY_FULL_GROUP_BY,STRICT_TRANS_TABLES,NO_ZERO_IN_DATE",
+		"tidb_opt_enable_hash_join":   "ON",
+		"tidb_isolation_read_engines": "tikv,tiflash,tidb",
+	}
+	state := buildSessionState(session, global, defaults)
+	if state.SQLMode != "ONLY_FULL_GROUP_BY,STRICT_TRANS_TABLES" || state.TimeZone != "+08:00" || state.SystemTimeZone != "UTC" {
+		t.Fatalf("unexpected session basics: %+v", state)
+	}
+	if !reflect.DeepEqual(state.OptimizerSwitch, map[string]string{"index_merge": "on", "mrr": "off"}) {
+		t.Fatalf("unexpected optimizer switch: %v", state.OptimizerSwitch)
+	}
+	if len(state.PlanCache) != 2 || state.PlanCache["tidb_enable_non_prepared_plan_cache"] != "off" {
+		t.Fatalf("unexpected plan cache state: %v", state.PlanCache)
+	}
+	wantOverrides := map[string]sessionVarValue{
+		"time_zone":                 {Value: "+08:00", Global: "SYSTEM"},
+		"tidb_opt_enable_hash_join": {Value: "OFF", Global: "ON"},
+	}
+	if !reflect.DeepEqual(state.Overrides, wantOverrides) {
+		t.Fatalf("unexpected overrides: %v", state.Overrides)
+	}
+	wantNonDefault := map[string]sessionVarValue{
+		"sql_mode":                  {Value: "ONLY_FULL_GROUP_BY,STRICT_TRANS_TABLES", Default: "ONLY_FULL_GROUP_BY,STRICT_TRANS_TABLES,NO_ZERO_IN_DATE"},
+		"tidb_opt_enable_hash_join": {Value: "OFF", Default: "ON"},
+	}
+	if !reflect.DeepEqual(state.NonDefault, wantNonDefault) {
+		t.Fatalf("unexpected non-default variables: %v", state.NonDefault)
+	}
+}
+
+func TestParseOptimizerSwitchEmpty(t *testing.T) {
+	if flags := parseOptimizerSwitch(""); flags != nil {
+		t.Fatalf("expected no flags, got %v", flags)
+	}
+}
@@ -0,0 +1,126 @@
+package runner
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"shiro/internal/db"
+	"shiro/internal/oracle"
+	"shiro/internal/util"
+)
+
+const shadowDBSuffix = "_shadow"
+
+// Values of details["shadow_outcome"]. Reproduced means the bug does not need
+// TiKV or TiFlash; not_reproduced points at the storage layer or the cluster
+// setup; error means a statement of the case failed on the shadow server.
+const (
+	shadowReproduced    = "reproduced"
+	shadowNotReproduced = "not_reproduced"
+	shadowError         = "error"
+	shadowSetupFailed   = "setup_failed"
+	shadowNotApplicable = "not_applicable"
+)
+
+// openShadow connects to the shadow server and creates the runner's shadow
+// database. Shadow replay stays off when it is disabled or the server cannot
+// be reached. The returned function closes the connection.
+func (r *Runner) openShadow(ctx context.Context) func() {
+	cfg := r.cfg.Shadow
+	if !cfg.Enabled {
+		return func() {}
+	}
+	if strings.TrimSpace(cfg.DSN) == "" {
+		util.Warnf("shadow replay disabled: shadow.dsn is empty")
+		return func() {}
+	}
+	dbName := r.baseDB + shadowDBSuffix
+	if err := db.EnsureDatabase(ctx, cfg.DSN, dbName); err != nil {
+		util.Warnf("shadow replay disabled db=%s err=%v", dbName, err)
+		return func() {}
+	}
+	exec, err := db.Open(cfg.DSN, r.cfg.SessionInit...)
+	if err != nil {
+		util.Warnf("shadow replay disabled db=%s err=%v", dbName, err)
+		return func() {}
+	}
+	var version string
+	if err := exec.QueryRowContext(ctx, "SELECT tidb_version()").Scan(&version); err == nil {
+		version, _, _ = strings.Cut(version, "\n")
+	}
+	r.shadowExec = exec
+	r.shadowVersion = strings.TrimSpace(version)
+	util.Infof("shadow replay enabled db=%s version=%s", dbName, r.shadowVersion)
+	return func() {
+		r.shadowExec = nil
+		util.CloseWithErr(exec, "shadow db")
+	}
+}
+
+// shadowReplay replays the case of result on the shadow server with the
+// minimizer's replay check and consensus, and records the outcome in details.
+// Cases without a replay spec cannot be judged and are marked not_applicable.
+func (r *Runner) shadowReplay(ctx context.Context, result oracle.Result, details map[string]any) {
+	if r.shadowExec == nil || details == nil {
+		return
+	}
+	outcome := r.shadowReplayOutcome(ctx, result, details)
+	details["shadow_outcome"] = outcome
+	if r.shadowVersion != "" {
+		details["shadow_version"] = r.shadowVersion
+	}
+	r.statsMu.Lock()
+	if r.shadowOutcomes == nil {
+		r.shadowOutcomes = make(map[string]int64)
+	}
+	r.shadowOutcomes[outcome]++
+	r.statsMu.Unlock()
+}
+
+func (r *Runner) shadowReplayOutcome(ctx context.Context, result oracle.Result, details map[string]any) string {
+	spec := buildReplaySpec(result)
+	if spec.kind == "" {
+		return shadowNotApplicable
+	}
+	tablesUsed := r.expandMinimizeTablesForViewDependencies(tablesForMinimize(result))
+	schemaSQL := r.schemaSQL(ctx, tablesUsed)
+	if len(schemaSQL) == 0 {
+		return shadowSetupFailed
+	}
+	inserts := append([]string{}, r.insertLog...)
+	if len(tablesUsed) > 0 {
+		inserts = filterInsertsByTables(inserts, tablesUsed)
+	}
+	inserts = expandInsertStatements(inserts)
+
+	sctx, cancel := context.WithTimeout(ctx, time.Duration(r.cfg.Shadow.TimeoutSeconds)*time.Second)
+	defer cancel()
+	gate := minimizeBaseReplayGateDetailed(func() replayAttemptResult {
+		return r.replayCaseOn(sctx, r.shadowExec, r.baseDB+shadowDBSuffix, schemaSQL, inserts, result.SQL(), result, spec)
+	}, spec.kind)
+	details["shadow_replay_successes"] = gate.successes
+	details["shadow_replay_attempts"] = gate.attempts
+	if gate.ok {
+		return shadowReproduced
+	}
+	if gate.diag.failureStage != "" {
+		details["shadow_failure_stage"] = gate.diag.failureStage
+	}
+	if gate.diag.actualError != "" {
+		details["shadow_error"] = gate.diag.actualError
+	}
+	return shadowOutcomeFor(gate.diag.outcome)
+}
+
+// shadowOutcomeFor maps the outcome of a failed replay to a shadow outcome.
+func shadowOutcomeFor(replayOutcome string) string {
+	switch replayOutcome {
+	case "setup_error", "context_error", "spec_invalid":
+		return shadowSetupFailed
+	case "execution_error":
+		return shadowError
+	default:
+		return shadowNotReproduced
+	}
+}
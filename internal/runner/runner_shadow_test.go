@@ -0,0 +1,57 @@
+package runner
+
+import (
+	"context"
+	"testing"
+
+	"shiro/internal/config"
+	"shiro/internal/db"
+	"shiro/internal/oracle"
+)
+
+func TestShadowOutcomeFor(t *testing.T) {
+	cases := map[string]string{
+		"setup_error":     shadowSetupFailed,
+		"context_error":   shadowSetupFailed,
+		"spec_invalid":    shadowSetupFailed,
+		"execution_error": shadowError,
+		"mismatch":        shadowNotReproduced,
+		"":                shadowNotReproduced,
+	}
+	for in, want := range cases {
+		if got := shadowOutcomeFor(in); got != want {
+			t.Fatalf("shadowOutcomeFor(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestOpenShadowEmptyDSN(t *testing.T) {
+	r := &Runner{cfg: config.Config{Shadow: config.ShadowConfig{Enabled: true}}, baseDB: "shiro_fuzz"}
+	stop := r.openShadow(context.Background())
+	defer stop()
+	if r.shadowExec != nil {
+		t.Fatalf("expected shadow replay to stay off without a DSN")
+	}
+}
+
+func TestShadowReplayNotApplicable(t *testing.T) {
+	r := &Runner{shadowExec: &db.DB{}, shadowVersion: "Release Version: v8.5.0"}
+	details := map[string]any{}
+	r.shadowReplay(context.Background(), oracle.Result{Oracle: "NoREC"}, details)
+	if details["shadow_outcome"] != shadowNotApplicable {
+		t.Fatalf("unexpected outcome: %v", details["shadow_outcome"])
+	}
+	if details["shadow_version"] != "Release Version: v8.5.0" {
+		t.Fatalf("unexpected version: %v", details["shadow_version"])
+	}
+	if r.shadowOutcomes[shadowNotApplicable] != 1 {
+		t.Fatalf("unexpected outcome counts: %v", r.shadowOutcomes)
+	}
+
+	r.shadowExec = nil
+	details = map[string]any{}
+	r.shadowReplay(context.Background(), oracle.Result{Oracle: "NoREC"}, details)
+	if _, ok := details["shadow_outcome"]; ok {
+		t.Fatalf("expected no shadow outcome without a shadow server")
+	}
+}
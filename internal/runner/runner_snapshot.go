@@ -0,0 +1,70 @@
+package runner
+
+import (
+	"context"
+
+	"shiro/internal/db"
+	"shiro/internal/oracle"
+	"shiro/internal/util"
+)
+
+// snapshotPairOracles compare two executions over the same data, so both sides
+// can be pinned to one snapshot.
+var snapshotPairOracles = map[string]struct{}{
+	"NoREC": {},
+	"TLP":   {},
+	"DQP":   {},
+	"EET":   {},
+}
+
+// pinOracleSnapshot pins the comparison queries of pair oracles to the current
+// TSO when oracles.snapshot_pairs is enabled. It returns the TSO (0 when not
+// pinned) and a function that unpins exec.
+func (r *Runner) pinOracleSnapshot(ctx context.Context, exec *db.DB, oracleName string) (uint64, func()) {
+	if !r.cfg.Oracles.SnapshotPairs {
+		return 0, func() {}
+	}
+	if _, ok := snapshotPairOracles[oracleName]; !ok {
+		return 0, func() {}
+	}
+	tso, err := r.currentTSO(ctx, exec)
+	if err != nil || tso == 0 {
+		util.Detailf("snapshot pin skipped oracle=%s err=%v", oracleName, err)
+		return 0, func() {}
+	}
+	exec.SnapshotTSO = tso
+	return tso, func() {
+		exec.SnapshotTSO = 0
+	}
+}
+
+// currentTSO reads TIDB_CURRENT_TSO() through the raw pool, so the probe stays
+// out of SQL validity stats.
+func (r *Runner) currentTSO(ctx context.Context, exec *db.DB) (uint64, error) {
+	qctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	var tso uint64
+	err := exec.DB.QueryRowContext(qctx, "SELECT TIDB_CURRENT_TSO()").Scan(&tso)
+	return tso, err
+}
+
+// recordCaseLocation stores the database and a report-time TSO, so
+// shiro-repro -time_travel can replay the case on the original data.
+func recordCaseLocation(details map[string]any, database string, tso uint64) {
+	details["case_database"] = database
+	if tso != 0 {
+		details["case_tso"] = tso
+	}
+}
+
+// recordSnapshotTSO stores the pinned TSO so a case can be re-read with
+// tidb_snapshot set to the same value.
+func recordSnapshotTSO(result *oracle.Result, tso uint64) {
+	if tso == 0 {
+		return
+	}
+	if result.Details == nil {
+		result.Details = map[string]any{}
+	}
+	result.Details["snapshot_tso"] = tso
+}
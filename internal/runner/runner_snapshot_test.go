@@ -0,0 +1,48 @@
+package runner
+
+import (
+	"context"
+	"testing"
+
+	"shiro/internal/config"
+	"shiro/internal/oracle"
+)
+
+func TestRecordSnapshotTSO(t *testing.T) {
+	result := oracle.Result{}
+	recordSnapshotTSO(&result, 0)
+	if result.Details != nil {
+		t.Fatalf("unpinned run should not add details: %v", result.Details)
+	}
+	recordSnapshotTSO(&result, 449572913577443329)
+	if got := result.Details["snapshot_tso"]; got != uint64(449572913577443329) {
+		t.Fatalf("snapshot_tso=%v", got)
+	}
+}
+
+func TestRecordCaseLocation(t *testing.T) {
+	details := map[string]any{}
+	recordCaseLocation(details, "shiro_fuzz_3", 0)
+	if details["case_database"] != "shiro_fuzz_3" {
+		t.Fatalf("case_database=%v", details["case_database"])
+	}
+	if _, ok := details["case_tso"]; ok {
+		t.Fatalf("zero tso should not be recorded: %v", details)
+	}
+	recordCaseLocation(details, "shiro_fuzz_3", 449572913577443329)
+	if got := details["case_tso"]; got != uint64(449572913577443329) {
+		t.Fatalf("case_tso=%v", got)
+	}
+}
+
+func TestPinOracleSnapshotDisabled(t *testing.T) {
+	r := &Runner{cfg: config.Config{}}
+	r.cfg.Oracles.SnapshotPairs = true
+	if tso, unpin := r.pinOracleSnapshot(context.Background(), nil, "PQS"); tso != 0 || unpin == nil {
+		t.Fatalf("PQS should not be pinned: tso=%d", tso)
+	}
+	r.cfg.Oracles.SnapshotPairs = false
+	if tso, _ := r.pinOracleSnapshot(context.Background(), nil, "NoREC"); tso != 0 {
+		t.Fatalf("NoREC pinned with snapshot_pairs off: tso=%d", tso)
+	}
+}
@@ -0,0 +1,87 @@
+package runner
+
+import (
+	"path/filepath"
+
+	"shiro/internal/db"
+	"shiro/internal/report"
+	"shiro/internal/sqllog"
+	"shiro/internal/util"
+)
+
+const sqlLogBytesPerMB = 1 << 20
+
+// startSQLLog opens the statement log when logging.sql_log.enabled is set.
+// When the log or latency tracking is on, it reopens the runner connection
+// pool through the tracing driver. Failures only disable the log. The
+// returned func closes the log.
+func (r *Runner) startSQLLog() func() {
+	stop := func() {}
+	cfg := r.cfg.Logging.SQLLog
+	if cfg.Enabled {
+		writer, err := sqllog.Open(cfg.Dir, r.baseDB, int64(cfg.MaxSizeMB)*sqlLogBytesPerMB, cfg.MaxFiles)
+		if err != nil {
+			util.Warnf("sql log disabled dir=%s err=%v", cfg.Dir, err)
+		} else {
+			r.sqlLog = writer
+			stop = func() {
+				util.CloseWithErr(writer, "sql log")
+			}
+		}
+	}
+	tracer := r.statementTracer()
+	if tracer == nil {
+		return stop
+	}
+	exec, err := db.OpenTraced(r.cfg.DSN, tracer, r.cfg.SessionInit...)
+	if err != nil {
+		util.Warnf("statement tracing disabled err=%v", err)
+		stop()
+		r.sqlLog = nil
+		r.latency = nil
+		return func() {}
+	}
+	if r.exec != nil {
+		exec.Validate = r.exec.Validate
+		exec.Observe = r.exec.Observe
+		exec.Guard = r.exec.Guard
+		util.CloseWithErr(r.exec, "db exec")
+	}
+	r.exec = exec
+	if r.sqlLog != nil {
+		file, _ := r.sqlLog.Position()
+		util.Infof("sql log enabled dir=%s file=%s max_size_mb=%d max_files=%d", cfg.Dir, file, cfg.MaxSizeMB, cfg.MaxFiles)
+	}
+	return stop
+}
+
+// statementTracer combines the statement log and the latency tracker, or
+// returns nil when neither is on.
+func (r *Runner) statementTracer() db.StatementTracer {
+	var tracers []db.StatementTracer
+	if r.sqlLog != nil {
+		tracers = append(tracers, r.sqlLog)
+	}
+	if r.latency != nil {
+		tracers = append(tracers, r.latency)
+	}
+	if r.resourceGroup != nil {
+		tracers = append(tracers, r.resourceGroup)
+	}
+	return db.JoinTracers(tracers...)
+}
+
+// openExec opens a connection pool, traced when the statement log, latency
+// tracking, or the resource group is on.
+func (r *Runner) openExec(dsn string) (*db.DB, error) {
+	return db.OpenTraced(dsn, r.statementTracer(), r.cfg.SessionInit...)
+}
+
+// sqlLogRef records the statement log position for a case summary.
+func (r *Runner) sqlLogRef() *report.SQLLogRef {
+	if r.sqlLog == nil {
+		return nil
+	}
+	file, seq := r.sqlLog.Position()
+	return &report.SQLLogRef{Dir: filepath.ToSlash(r.sqlLog.Dir()), File: file, Seq: seq}
+}
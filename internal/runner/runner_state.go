@@ -38,7 +38,7 @@ func (r *Runner) rotateDatabase(ctx context.Context) error {
 	util.Infof("database rotated db=%s mode=%s", r.cfg.Database, r.oracleModeLabel())
 	r.cfg.DSN = config.UpdateDatabaseInDSN(r.cfg.DSN, r.cfg.Database)
 	util.CloseWithErr(r.exec, "db exec")
-	exec, err := db.Open(r.cfg.DSN)
+	exec, err := r.openExec(r.cfg.DSN)
 	if err != nil {
 		return err
 	}
@@ -49,7 +49,17 @@ func (r *Runner) rotateDatabase(ctx context.Context) error {
 	r.genMu.Unlock()
 	r.exec.Validate = r.validator.Validate
 	r.exec.Observe = r.observeSQL
+	r.exec.Guard = r.resultGuard()
 	r.insertLog = nil
+	r.planStability = nil
+	r.oracleBudget.reset()
+	r.latency.resetDigests()
+	if r.oracleBandit != nil {
+		r.statsMu.Lock()
+		r.refreshOracleEnabled()
+		r.statsMu.Unlock()
+	}
+	r.resetOracleApplicability()
 	if r.cfg.QPG.Enabled {
 		r.qpgMu.Lock()
 		r.qpgState = newQPGState(r.cfg.QPG)
@@ -58,7 +68,11 @@ func (r *Runner) rotateDatabase(ctx context.Context) error {
 	if err := r.setupDatabase(ctx); err != nil {
 		return err
 	}
-	return r.initState(ctx)
+	if err := r.initState(ctx); err != nil {
+		return err
+	}
+	r.runLifecycleHooks(ctx, hookStageDatabaseRotate, r.cfg.Hooks.DatabaseRotate)
+	return nil
 }
 
 func (r *Runner) rotateDatabaseWithRetry(ctx context.Context) error {
@@ -104,6 +118,7 @@ func (r *Runner) applyRuntimeToggles() {
 	if tqsEnabled {
 		r.cfg.Features.DSG = true
 		r.cfg.Weights.Actions.DML = 0
+		r.cfg.Weights.Actions.Storyline = 0
 		if r.cfg.Weights.Oracles.DQE > 0 {
 			util.Detailf("tqs config adjusted: disable DQE oracle")
 		}
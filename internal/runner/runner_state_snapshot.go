@@ -0,0 +1,206 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"shiro/internal/config"
+	"shiro/internal/report"
+	"shiro/internal/util"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	stateSnapshotDir     = "state_snapshot"
+	stateSnapshotLogFile = "state_snapshot.log"
+
+	severeCasePanic      = "panic"
+	severeCaseCorruption = "corruption"
+)
+
+// corruptionErrCodes are TiDB errors that report index/record inconsistency
+// or a failed ADMIN CHECK, where the stored data itself is suspect.
+var corruptionErrCodes = map[uint16]struct{}{
+	8003: {}, // admin check table failed
+	8133: {}, // data inconsistency: index and record disagree
+	8134: {}, // data inconsistency: column value mismatch
+	8223: {}, // data inconsistency: index and record counts differ
+}
+
+// severeCaseKind returns panic or corruption for errors worth a full database
+// snapshot, and "" otherwise.
+func severeCaseKind(err error) string {
+	if err == nil {
+		return ""
+	}
+	if classifyPanic(err).class != "" {
+		return severeCasePanic
+	}
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		if _, ok := corruptionErrCodes[mysqlErr.Number]; ok {
+			return severeCaseCorruption
+		}
+		return ""
+	}
+	if strings.Contains(strings.ToLower(err.Error()), "data inconsistency") {
+		return severeCaseCorruption
+	}
+	return ""
+}
+
+// stateSnapshotTarget is where one snapshot goes and how it is taken.
+type stateSnapshotTarget struct {
+	location string
+	// recorded is the location written to the case details: relative to the
+	// case directory for dumpling, so the staging rename keeps it valid.
+	recorded string
+	name     string
+	args     []string
+	env      []string
+}
+
+// buildStateSnapshotTarget builds the dumpling or br command for a case. A
+// configured command runs through `sh -c` instead. The connection comes from
+// the first host of dsn; its password is passed only in the environment. caseDir is the staging directory the tool writes to;
+// finalDir is where the case lives once committed.
+func buildStateSnapshotTarget(cfg config.StateSnapshotConfig, dsn string, database string, caseID string, caseDir string, finalDir string, tso uint64) (stateSnapshotTarget, error) {
+	hosts := config.SplitDSNHosts(dsn)
+	conn, err := mysql.ParseDSN(hosts[0])
+	if err != nil {
+		return stateSnapshotTarget{}, err
+	}
+	host, port, err := net.SplitHostPort(conn.Addr)
+	if err != nil {
+		host, port = conn.Addr, "4000"
+	}
+	target := stateSnapshotTarget{}
+	switch cfg.Tool {
+	case config.StateSnapshotBR:
+		if cfg.BRStorage == "" || (cfg.PDAddr == "" && cfg.Command == "") {
+			return stateSnapshotTarget{}, fmt.Errorf("br needs br_storage and pd_addr")
+		}
+		target.location = cfg.BRStorage + "/" + caseID
+		target.recorded = target.location
+		target.name = "br"
+		target.args = []string{"backup", "db", "--pd", cfg.PDAddr, "--db", database, "--storage", target.location}
+		if tso > 0 {
+			target.args = append(target.args, "--backupts", strconv.FormatUint(tso, 10))
+		}
+	default:
+		target.location = filepath.Join(caseDir, stateSnapshotDir)
+		target.recorded = stateSnapshotDir
+		target.name = "dumpling"
+		// The password never goes on argv, where any user can read it from
+		// the process list. dumpling takes it nowhere else, so a DSN with a
+		// password needs a command that reads SHIRO_SNAPSHOT_PASSWORD.
+		if conn.Passwd != "" && cfg.Command == "" {
+			return stateSnapshotTarget{}, fmt.Errorf("dumpling takes a password only on its command line; set state_snapshot.command to read SHIRO_SNAPSHOT_PASSWORD")
+		}
+		target.args = []string{"-h", host, "-P", port, "-u", conn.User, "-B", database, "-o", target.location}
+		if tso > 0 {
+			target.args = append(target.args, "--snapshot", strconv.FormatUint(tso, 10))
+		}
+	}
+	target.env = []string{
+		"SHIRO_DATABASE=" + database,
+		"SHIRO_CASE_ID=" + caseID,
+		"SHIRO_CASE_DIR=" + caseDir,
+		"SHIRO_CASE_FINAL_DIR=" + finalDir,
+		"SHIRO_SNAPSHOT_DIR=" + filepath.Join(caseDir, stateSnapshotDir),
+		"SHIRO_SNAPSHOT_LOCATION=" + target.location,
+		"SHIRO_SNAPSHOT_TSO=" + strconv.FormatUint(tso, 10),
+		"SHIRO_SNAPSHOT_HOST=" + host,
+		"SHIRO_SNAPSHOT_PORT=" + port,
+		"SHIRO_SNAPSHOT_USER=" + conn.User,
+		"SHIRO_SNAPSHOT_PASSWORD=" + conn.Passwd,
+		"SHIRO_PD_ADDR=" + cfg.PDAddr,
+	}
+	if cfg.Command != "" {
+		target.name = "sh"
+		target.args = []string{"-c", cfg.Command}
+	}
+	return target, nil
+}
+
+// captureStateSnapshot exports the database of a severe case within the size
+// and per-run limits and records the outcome in details["state_snapshot"].
+// The tool output is kept in state_snapshot.log.
+func (r *Runner) captureStateSnapshot(ctx context.Context, caseData report.Case, kind string, tso uint64, diskLow bool, details map[string]any) {
+	cfg := r.cfg.StateSnapshot
+	if !cfg.Enabled || details == nil {
+		return
+	}
+	status := map[string]any{"kind": kind, "tool": cfg.Tool}
+	details["state_snapshot"] = status
+	if reason := r.stateSnapshotSkipReason(ctx, cfg, diskLow, status); reason != "" {
+		status["status"] = "skipped: " + reason
+		return
+	}
+	target, err := buildStateSnapshotTarget(cfg, r.cfg.DSN, r.cfg.Database, caseData.ID, caseData.Dir, r.reporter.CommittedDir(caseData), tso)
+	if err != nil {
+		status["status"] = "error: " + err.Error()
+		return
+	}
+	r.stateSnapshots++
+	sctx, cancel := context.WithTimeout(ctx, time.Duration(cfg.TimeoutSeconds)*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(sctx, target.name, target.args...)
+	cmd.Env = append(os.Environ(), target.env...)
+	cmd.WaitDelay = hookShellWaitDelay
+	started := time.Now()
+	output, err := cmd.CombinedOutput()
+	status["seconds"] = time.Since(started).Round(time.Second).Seconds()
+	if len(output) > 0 {
+		if writeErr := r.reporter.WriteText(caseData, stateSnapshotLogFile, truncateHookOutput(string(output))); writeErr != nil {
+			util.Warnf("state snapshot log write failed dir=%s err=%v", caseData.Dir, writeErr)
+		}
+	}
+	if err != nil {
+		status["status"] = "error: " + err.Error()
+		util.Warnf("state snapshot failed tool=%s case_id=%s err=%v", cfg.Tool, caseData.ID, err)
+		return
+	}
+	status["status"] = "ok"
+	status["location"] = target.recorded
+	util.Infof("state snapshot done tool=%s case_id=%s location=%s", cfg.Tool, caseData.ID, target.location)
+}
+
+// stateSnapshotSkipReason applies the per-run, disk, and size limits. The
+// measured size is recorded in status.
+func (r *Runner) stateSnapshotSkipReason(ctx context.Context, cfg config.StateSnapshotConfig, diskLow bool, status map[string]any) string {
+	if r.stateSnapshots >= cfg.MaxPerRun {
+		return "max_per_run"
+	}
+	if diskLow && cfg.Tool == config.StateSnapshotDumpling {
+		return "disk_low"
+	}
+	bytes, err := r.databaseBytes(ctx)
+	if err != nil {
+		return "size_unknown"
+	}
+	status["bytes"] = bytes
+	if bytes > int64(cfg.MaxMB)<<20 {
+		return "too_large"
+	}
+	return ""
+}
+
+// databaseBytes estimates the data and index size of the fuzz database from
+// the table statistics.
+func (r *Runner) databaseBytes(ctx context.Context) (int64, error) {
+	qctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	var bytes int64
+	err := r.exec.QueryRowContext(qctx, "SELECT IFNULL(SUM(DATA_LENGTH + INDEX_LENGTH), 0) FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE()").Scan(&bytes)
+	return bytes, err
+}
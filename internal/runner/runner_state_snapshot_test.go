@@ -0,0 +1,81 @@
+package runner
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"shiro/internal/config"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestSevereCaseKind(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want string
+	}{
+		{nil, ""},
+		{&mysql.MySQLError{Number: 1105, Message: "runtime error: index out of range [3] with length 3"}, severeCasePanic},
+		{&mysql.MySQLError{Number: 8133, Message: "data inconsistency in table: t0, index: idx0"}, severeCaseCorruption},
+		{&mysql.MySQLError{Number: 1054, Message: "Unknown column 'data inconsistency'"}, ""},
+		{errors.New("data inconsistency in table: t1"), severeCaseCorruption},
+		{errors.New("deadlock found"), ""},
+	} {
+		if got := severeCaseKind(tc.err); got != tc.want {
+			t.Fatalf("severeCaseKind(%v) = %q, want %q", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestBuildStateSnapshotTarget(t *testing.T) {
+	cfg := config.StateSnapshotConfig{Tool: config.StateSnapshotDumpling}
+	target, err := buildStateSnapshotTarget(cfg, "root@tcp(tidb-0:4000,tidb-1:4000)/test", "shiro_1", "c1", "/cases/.staging/c1", "/cases/c1", 42)
+	if err != nil {
+		t.Fatalf("dumpling target failed: %v", err)
+	}
+	want := "-h tidb-0 -P 4000 -u root -B shiro_1 -o /cases/.staging/c1/state_snapshot --snapshot 42"
+	if target.name != "dumpling" || strings.Join(target.args, " ") != want || target.recorded != stateSnapshotDir {
+		t.Fatalf("unexpected dumpling target: %+v", target)
+	}
+	if env := strings.Join(target.env, " "); !strings.Contains(env, "SHIRO_CASE_FINAL_DIR=/cases/c1") {
+		t.Fatalf("env must carry the committed case dir: %v", target.env)
+	}
+	dsn := "root:pw@tcp(tidb-0:4000,tidb-1:4000)/test"
+	if _, err := buildStateSnapshotTarget(cfg, dsn, "shiro_1", "c1", "/cases/.staging/c1", "/cases/c1", 42); err == nil {
+		t.Fatalf("dumpling with a password and no command must fail instead of putting it on argv")
+	}
+	cfg.Command = "my-dump"
+	target, err = buildStateSnapshotTarget(cfg, dsn, "shiro_1", "c1", "/cases/.staging/c1", "/cases/c1", 42)
+	if err != nil {
+		t.Fatalf("dumpling command target failed: %v", err)
+	}
+	if args := strings.Join(target.args, " "); strings.Contains(args, "pw") {
+		t.Fatalf("password must stay off argv: %s", args)
+	}
+	if env := strings.Join(target.env, " "); !strings.Contains(env, "SHIRO_SNAPSHOT_PASSWORD=pw") {
+		t.Fatalf("env must carry the password: %v", target.env)
+	}
+
+	cfg = config.StateSnapshotConfig{Tool: config.StateSnapshotBR, BRStorage: "s3://b/snap"}
+	if _, err := buildStateSnapshotTarget(cfg, dsn, "shiro_1", "c1", "/cases/.staging/c1", "/cases/c1", 42); err == nil {
+		t.Fatalf("br without pd_addr must fail")
+	}
+	cfg.PDAddr = "pd-0:2379"
+	target, err = buildStateSnapshotTarget(cfg, dsn, "shiro_1", "c1", "/cases/.staging/c1", "/cases/c1", 0)
+	if err != nil {
+		t.Fatalf("br target failed: %v", err)
+	}
+	if target.recorded != "s3://b/snap/c1" || strings.Join(target.args, " ") != "backup db --pd pd-0:2379 --db shiro_1 --storage s3://b/snap/c1" {
+		t.Fatalf("unexpected br target: %+v", target)
+	}
+
+	cfg.Command = "my-backup"
+	target, err = buildStateSnapshotTarget(cfg, dsn, "shiro_1", "c1", "/cases/.staging/c1", "/cases/c1", 7)
+	if err != nil || target.name != "sh" || strings.Join(target.args, " ") != "-c my-backup" {
+		t.Fatalf("unexpected command target: %+v err=%v", target, err)
+	}
+	if !strings.Contains(strings.Join(target.env, " "), "SHIRO_SNAPSHOT_TSO=7") {
+		t.Fatalf("command env must carry the snapshot TSO: %v", target.env)
+	}
+}
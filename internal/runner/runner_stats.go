@@ -2,6 +2,7 @@ package runner
 
 import (
 	"fmt"
+	"maps"
 	"sort"
 	"strings"
 	"sync/atomic"
@@ -10,6 +11,7 @@ import (
 	"shiro/internal/db"
 	"shiro/internal/generator"
 	"shiro/internal/oracle"
+	"shiro/internal/sqlstep"
 	"shiro/internal/tqs"
 	"shiro/internal/util"
 )
@@ -72,6 +74,7 @@ type builderAttemptStats struct {
 	MaxAttempts    int
 	Histogram      map[int]int64
 	FailureReasons map[string]int64
+	Rejections     map[string]int64
 }
 
 func newOracleFunnel() *oracleFunnel {
@@ -89,6 +92,9 @@ func (r *Runner) observeSQL(sql string, err error, features *db.SQLSubqueryFeatu
 	r.statsMu.Lock()
 	defer r.statsMu.Unlock()
 	r.sqlTotal++
+	if r.pacing != nil {
+		r.pacing.observe(isTransientSQLError(err))
+	}
 	if err == nil {
 		r.sqlValid++
 		if features != nil {
@@ -155,6 +161,11 @@ func (r *Runner) observeJoinSignature(features *generator.QueryFeatures, oracleN
 	r.statsMu.Lock()
 	defer r.statsMu.Unlock()
 	r.genSQLTotal++
+	if features.NullPredicates > 0 {
+		r.nullAwareQueries++
+	}
+	r.nullPredicates += int64(features.NullPredicates)
+	r.predicateLeaves += int64(features.PredicateLeaves)
 	if features.HasExistsSubquery {
 		r.genSQLExists++
 	}
@@ -358,6 +369,7 @@ func (r *Runner) observeBuilderStats(name string, stats generator.BuilderStats)
 		stat = &builderAttemptStats{
 			Histogram:      make(map[int]int64),
 			FailureReasons: make(map[string]int64),
+			Rejections:     make(map[string]int64),
 		}
 		r.builderStats[name] = stat
 	}
@@ -376,6 +388,9 @@ func (r *Runner) observeBuilderStats(name string, stats generator.BuilderStats)
 	for reason, count := range stats.FailureReasons {
 		stat.FailureReasons[reason] += count
 	}
+	for reason, count := range stats.Rejections {
+		stat.Rejections[reason] += count
+	}
 }
 
 func (r *Runner) observeOracleResult(name string, result oracle.Result, skipReason string, reported bool, isPanic bool) {
@@ -418,14 +433,14 @@ func (r *Runner) observeOracleResult(name string, result oracle.Result, skipReas
 		}
 		if !isSkipClassifiedResult(result, skipReason) {
 			if result.Oracle == "CERT" {
-				if len(result.SQL) > 0 {
-					r.certLastErrSQL = result.SQL[len(result.SQL)-1]
+				if step, ok := sqlstep.FindRole(result.Steps, sqlstep.RoleFailing); ok {
+					r.certLastErrSQL = step.SQL
 				}
 				r.certLastErr = result.Err.Error()
 			}
 			if result.Oracle == "TLP" {
-				if len(result.SQL) > 0 {
-					r.tlpLastErrSQL = result.SQL[len(result.SQL)-1]
+				if step, ok := sqlstep.FindRole(result.Steps, sqlstep.RoleFailing); ok {
+					r.tlpLastErrSQL = step.SQL
 				}
 				r.tlpLastErr = result.Err.Error()
 			}
@@ -443,6 +458,7 @@ func (r *Runner) observeOracleResult(name string, result oracle.Result, skipReas
 	if reported {
 		stat.Reports++
 	}
+	r.observeOracleApplicabilityLocked(name, result, skipReason)
 }
 
 func (r *Runner) observeReproducibilitySummary(
@@ -589,6 +605,9 @@ func (r *Runner) applyResultMetrics(result oracle.Result) {
 	if v, ok := result.Metrics["impo_trunc"]; ok {
 		r.impoTrunc += v
 	}
+	if v, ok := result.Metrics[resultTruncatedMetric]; ok {
+		r.resultTruncatedTotal += v
+	}
 	if v, ok := result.Metrics["dqp_hint_injected_total"]; ok {
 		r.dqpHintInjectedTotal += v
 	}
@@ -598,6 +617,31 @@ func (r *Runner) applyResultMetrics(result oracle.Result) {
 	if v, ok := result.Metrics["dqp_set_var_variant_total"]; ok {
 		r.dqpSetVarVariantTotal += v
 	}
+	if v, ok := result.Metrics["dqp_plan_changed_total"]; ok {
+		r.dqpPlanChangedTotal += v
+	}
+	if v, ok := result.Metrics["dqp_plan_same_skip_total"]; ok {
+		r.dqpPlanSameSkipTotal += v
+	}
+	if v, ok := result.Metrics["dqp_plan_check_err_total"]; ok {
+		r.dqpPlanCheckErrTotal += v
+	}
+	for key, v := range result.Metrics {
+		if r.recordFixControlMetricLocked(key, v) {
+			continue
+		}
+		if hint, ok := strings.CutPrefix(key, oracle.DQPPlanChangedHintMetricPrefix); ok {
+			if r.dqpHintPlanChanged == nil {
+				r.dqpHintPlanChanged = make(map[string]int64)
+			}
+			r.dqpHintPlanChanged[hint] += v
+		} else if hint, ok := strings.CutPrefix(key, oracle.DQPPlanSameHintMetricPrefix); ok {
+			if r.dqpHintPlanSame == nil {
+				r.dqpHintPlanSame = make(map[string]int64)
+			}
+			r.dqpHintPlanSame[hint] += v
+		}
+	}
 	hintLenCount, hasHintLenCount := result.Metrics["dqp_hint_length_count"]
 	hintLenSum, hasHintLenSum := result.Metrics["dqp_hint_length_sum"]
 	hintLenMin, hasHintLenMin := result.Metrics["dqp_hint_length_min"]
@@ -722,6 +766,9 @@ func (r *Runner) startStatsLogger() func() {
 		var lastDQPHintInjectedTotal int64
 		var lastDQPHintFallbackTotal int64
 		var lastDQPSetVarVariantTotal int64
+		var lastDQPPlanChangedTotal int64
+		var lastDQPPlanSameSkipTotal int64
+		var lastDQPPlanCheckErrTotal int64
 		var lastViewQueries int64
 		var lastViewTableRefs int64
 		var lastPlans int
@@ -815,6 +862,11 @@ func (r *Runner) startStatsLogger() func() {
 				dqpHintInjectedTotal := r.dqpHintInjectedTotal
 				dqpHintFallbackTotal := r.dqpHintFallbackTotal
 				dqpSetVarVariantTotal := r.dqpSetVarVariantTotal
+				dqpPlanChangedTotal := r.dqpPlanChangedTotal
+				dqpPlanSameSkipTotal := r.dqpPlanSameSkipTotal
+				dqpPlanCheckErrTotal := r.dqpPlanCheckErrTotal
+				dqpHintPlanSameTop := formatTopJoinSigs(r.dqpHintPlanSame, 5)
+				dqpHintPlanChangedTop := formatTopJoinSigs(r.dqpHintPlanChanged, 5)
 				dqpHintLengthIntervalSum := r.dqpHintLengthIntervalSum
 				dqpHintLengthIntervalCount := r.dqpHintLengthIntervalCount
 				dqpHintLengthIntervalMin := r.dqpHintLengthIntervalMin
@@ -823,6 +875,8 @@ func (r *Runner) startStatsLogger() func() {
 				r.dqpHintLengthIntervalCount = 0
 				r.dqpHintLengthIntervalMin = 0
 				r.dqpHintLengthIntervalMax = 0
+				queryShape := r.queryShapeInterval.summary()
+				r.queryShapeInterval = queryShapeStats{}
 				oraclePickTotal := r.oraclePickTotal
 				certPickTotal := r.certPickTotal
 				viewQueries := r.viewQueries
@@ -996,6 +1050,7 @@ func (r *Runner) startStatsLogger() func() {
 						MaxAttempts:    stat.MaxAttempts,
 						Histogram:      histCopy,
 						FailureReasons: reasonCopy,
+						Rejections:     maps.Clone(stat.Rejections),
 					}
 					builderStats[name] = copyStat
 				}
@@ -1035,6 +1090,9 @@ func (r *Runner) startStatsLogger() func() {
 				deltaDQPHintInjectedTotal := dqpHintInjectedTotal - lastDQPHintInjectedTotal
 				deltaDQPHintFallbackTotal := dqpHintFallbackTotal - lastDQPHintFallbackTotal
 				deltaDQPSetVarVariantTotal := dqpSetVarVariantTotal - lastDQPSetVarVariantTotal
+				deltaDQPPlanChangedTotal := dqpPlanChangedTotal - lastDQPPlanChangedTotal
+				deltaDQPPlanSameSkipTotal := dqpPlanSameSkipTotal - lastDQPPlanSameSkipTotal
+				deltaDQPPlanCheckErrTotal := dqpPlanCheckErrTotal - lastDQPPlanCheckErrTotal
 				deltaDQPHintLengthSum := dqpHintLengthIntervalSum
 				deltaDQPHintLengthCount := dqpHintLengthIntervalCount
 				deltaDQPHintLengthMin := dqpHintLengthIntervalMin
@@ -1165,6 +1223,9 @@ func (r *Runner) startStatsLogger() func() {
 				lastDQPHintInjectedTotal = dqpHintInjectedTotal
 				lastDQPHintFallbackTotal = dqpHintFallbackTotal
 				lastDQPSetVarVariantTotal = dqpSetVarVariantTotal
+				lastDQPPlanChangedTotal = dqpPlanChangedTotal
+				lastDQPPlanSameSkipTotal = dqpPlanSameSkipTotal
+				lastDQPPlanCheckErrTotal = dqpPlanCheckErrTotal
 				lastOraclePickTotal = oraclePickTotal
 				lastCertPickTotal = certPickTotal
 				lastViewQueries = viewQueries
@@ -1373,6 +1434,9 @@ func (r *Runner) startStatsLogger() func() {
 						minimizeInFlight,
 						stalledReason(controlState.LowSample, controlState.InfraUnhealthy, minimizeInFlight, deltaOracleTimeoutCounts),
 					)
+					if queryShape != nil {
+						util.Infof("query_shape last interval: %s", queryShape.logLine())
+					}
 					if deltaDQPHintInjectedTotal > 0 ||
 						deltaDQPHintFallbackTotal > 0 ||
 						deltaDQPSetVarVariantTotal > 0 ||
@@ -1392,6 +1456,22 @@ func (r *Runner) startStatsLogger() func() {
 							deltaDQPHintLengthCount,
 						)
 					}
+					if deltaDQPPlanChangedTotal > 0 || deltaDQPPlanSameSkipTotal > 0 || deltaDQPPlanCheckErrTotal > 0 {
+						planChecked := deltaDQPPlanChangedTotal + deltaDQPPlanSameSkipTotal
+						planSameRatio := 0.0
+						if planChecked > 0 {
+							planSameRatio = float64(deltaDQPPlanSameSkipTotal) / float64(planChecked)
+						}
+						util.Infof(
+							"dqp_plan_change last interval: changed=%d same_skipped=%d check_err=%d same_ratio=%.3f top_changed_total=[%s] top_same_total=[%s]",
+							deltaDQPPlanChangedTotal,
+							deltaDQPPlanSameSkipTotal,
+							deltaDQPPlanCheckErrTotal,
+							planSameRatio,
+							dqpHintPlanChangedTop,
+							dqpHintPlanSameTop,
+						)
+					}
 					deltaSubqueryDisallowReasons := make(map[string]int64, len(subqueryDisallowReasons))
 					for reason, total := range subqueryDisallowReasons {
 						prev := lastSubqueryDisallowReasons[reason]
@@ -1816,6 +1896,17 @@ func (r *Runner) startStatsLogger() func() {
 									)
 								}
 							}
+							if r.cfg.Logging.Verbose && deltaAttempts > 0 {
+								if rates := formatBuilderRejectionRates(stat.Rejections, prev.Rejections, deltaAttempts, topOracleReasonsN); rates != "" {
+									util.Detailf(
+										"builder_rejections last interval oracle=%s attempts=%d top=%d: %s",
+										name,
+										deltaAttempts,
+										topOracleReasonsN,
+										rates,
+									)
+								}
+							}
 						}
 						lastBuilderStats = builderStats
 					}
@@ -2032,7 +2123,18 @@ func (r *Runner) startStatsLogger() func() {
 						}
 						r.qpgMu.Unlock()
 					}
+					if r.cfg.QPG.Enabled && r.qpgState != nil {
+						r.qpgMu.Lock()
+						if focus := r.qpgState.focus; focus != nil {
+							if summary := focus.summary(); summary != focus.lastLogged {
+								util.Infof("qpg focus %s", summary)
+								focus.lastLogged = summary
+							}
+						}
+						r.qpgMu.Unlock()
+					}
 					r.dumpDynamicState()
+					r.dumpFeatureCoverage()
 				}
 			case <-done:
 				return
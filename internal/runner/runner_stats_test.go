@@ -427,6 +427,58 @@ func TestCountMapTotal(t *testing.T) {
 	}
 }
 
+func TestApplyResultMetricsDQPPlanChangeCounters(t *testing.T) {
+	r := &Runner{}
+	for i := 0; i < 2; i++ {
+		r.applyResultMetrics(oracle.Result{
+			Oracle: "DQP",
+			Metrics: map[string]int64{
+				"dqp_plan_changed_total":                            1,
+				"dqp_plan_same_skip_total":                          2,
+				"dqp_plan_check_err_total":                          0,
+				oracle.DQPPlanChangedHintMetricPrefix + "HASH_JOIN": 1,
+				oracle.DQPPlanSameHintMetricPrefix + "MERGE_JOIN":   2,
+			},
+		})
+	}
+	if r.dqpPlanChangedTotal != 2 || r.dqpPlanSameSkipTotal != 4 || r.dqpPlanCheckErrTotal != 0 {
+		t.Fatalf("unexpected plan totals changed=%d same=%d err=%d", r.dqpPlanChangedTotal, r.dqpPlanSameSkipTotal, r.dqpPlanCheckErrTotal)
+	}
+	if r.dqpHintPlanChanged["HASH_JOIN"] != 2 || r.dqpHintPlanSame["MERGE_JOIN"] != 4 {
+		t.Fatalf("unexpected per-hint counters changed=%v same=%v", r.dqpHintPlanChanged, r.dqpHintPlanSame)
+	}
+}
+
+func TestApplyResultMetricsDQPFixControlCounters(t *testing.T) {
+	r := &Runner{}
+	for i := 0; i < 3; i++ {
+		r.applyResultMetrics(oracle.Result{
+			Oracle: "DQP",
+			Metrics: map[string]int64{
+				oracle.DQPFixControlRunMetricPrefix + "44830":         1,
+				oracle.DQPFixControlPlanChangedMetricPrefix + "44830": 1,
+				oracle.DQPFixControlRunMetricPrefix + "52869":         1,
+				oracle.DQPFixControlErrorMetricPrefix + "52869":       1,
+			},
+		})
+	}
+	r.applyResultMetrics(oracle.Result{
+		Oracle:  "DQP",
+		Metrics: map[string]int64{oracle.DQPFixControlMismatchMetricPrefix + "44830": 1},
+	})
+	got := r.fixControlSummaryLocked()
+	want := map[string]fixControlSummary{
+		"44830": {Runs: 3, PlanChanged: 3, Mismatches: 1},
+		"52869": {Runs: 3, Errors: 3},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected fix control stats: %+v", got)
+	}
+	if len(r.dqpHintPlanChanged) != 0 {
+		t.Fatalf("fix control metrics leaked into per-hint counters: %v", r.dqpHintPlanChanged)
+	}
+}
+
 func TestApplyResultMetricsDQPVariantCounters(t *testing.T) {
 	r := &Runner{}
 	r.applyResultMetrics(oracle.Result{
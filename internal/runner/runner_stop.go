@@ -0,0 +1,90 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"shiro/internal/config"
+	"shiro/internal/uploader"
+	"shiro/internal/util"
+)
+
+// stopNoteMaxBytes caps how much of the stop object is read as the stop note.
+const stopNoteMaxBytes = 256
+
+// stopControl watches the stop sentinels of config.StopConfig. The file is
+// checked on every call; the object is polled at most once per poll interval,
+// since each check is a remote request.
+type stopControl struct {
+	file     string
+	store    uploader.ObjectStore
+	key      string
+	poll     time.Duration
+	lastPoll time.Time
+	warned   bool
+}
+
+// newStopControl returns nil when no sentinel is configured. The object key is
+// resolved under prefix, the key prefix of the storage backend.
+func newStopControl(cfg config.StopConfig, store uploader.ObjectStore, prefix string) *stopControl {
+	s := &stopControl{file: cfg.File, poll: time.Duration(cfg.PollSeconds) * time.Second}
+	if cfg.ObjectKey != "" {
+		if store == nil {
+			util.Warnf("stop.object_key=%s is set but no storage backend is enabled; ignoring it", cfg.ObjectKey)
+		} else {
+			s.store = store
+			s.key = path.Join(prefix, cfg.ObjectKey)
+		}
+	}
+	if s.file == "" && s.store == nil {
+		return nil
+	}
+	return s
+}
+
+// requested reports whether a sentinel exists, and describes it.
+func (s *stopControl) requested(ctx context.Context, now time.Time) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	if s.file != "" {
+		if _, err := os.Stat(s.file); err == nil {
+			return "file " + s.file, true
+		}
+	}
+	if s.store == nil || (!s.lastPoll.IsZero() && now.Sub(s.lastPoll) < s.poll) {
+		return "", false
+	}
+	s.lastPoll = now
+	data, _, err := s.store.Get(ctx, s.key, stopNoteMaxBytes)
+	if err != nil {
+		if !errors.Is(err, uploader.ErrNotExist) && !s.warned {
+			util.Warnf("stop object check failed location=%s err=%v", s.store.Location(s.key), err)
+			s.warned = true
+		}
+		return "", false
+	}
+	reason := "object " + s.store.Location(s.key)
+	if note := strings.TrimSpace(string(data)); note != "" {
+		reason += " (" + note + ")"
+	}
+	return reason, true
+}
+
+// stopRequested checks the stop sentinels before an iteration. On a stop it
+// logs the sentinel and records it for the run summary.
+func (r *Runner) stopRequested(ctx context.Context, iteration int) bool {
+	reason, ok := r.stop.requested(ctx, time.Now())
+	if !ok {
+		return false
+	}
+	util.Infof("stop requested by %s; finishing run database=%s iteration=%d", reason, r.baseDB, iteration)
+	r.statsMu.Lock()
+	r.stopReason = reason
+	r.statsMu.Unlock()
+	return true
+}
@@ -0,0 +1,67 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"shiro/internal/config"
+	"shiro/internal/uploader"
+)
+
+func TestStopControlFile(t *testing.T) {
+	if newStopControl(config.StopConfig{PollSeconds: 30}, nil, "") != nil {
+		t.Fatalf("expected no stop control without sentinels")
+	}
+	file := filepath.Join(t.TempDir(), "STOP")
+	stop := newStopControl(config.StopConfig{File: file, PollSeconds: 30}, nil, "")
+	if _, ok := stop.requested(context.Background(), time.Now()); ok {
+		t.Fatalf("stop requested before the file exists")
+	}
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if reason, ok := stop.requested(context.Background(), time.Now()); !ok || reason != "file "+file {
+		t.Fatalf("unexpected stop: reason=%q ok=%v", reason, ok)
+	}
+}
+
+func TestStopControlObjectPolls(t *testing.T) {
+	store, err := uploader.NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop := newStopControl(config.StopConfig{ObjectKey: "control/stop", PollSeconds: 30}, store, "campaign")
+	ctx := context.Background()
+	now := time.Now()
+	if _, ok := stop.requested(ctx, now); ok {
+		t.Fatalf("stop requested before the object exists")
+	}
+	if err := store.Put(ctx, "campaign/control/stop", strings.NewReader("release cut\n"), 12, "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := stop.requested(ctx, now.Add(10*time.Second)); ok {
+		t.Fatalf("object must not be polled before the poll interval")
+	}
+	reason, ok := stop.requested(ctx, now.Add(30*time.Second))
+	if !ok || !strings.HasPrefix(reason, "object file://") || !strings.HasSuffix(reason, "campaign/control/stop (release cut)") {
+		t.Fatalf("unexpected stop: reason=%q ok=%v", reason, ok)
+	}
+}
+
+func TestStopRequestedRecordsReason(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "STOP")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r := &Runner{stop: newStopControl(config.StopConfig{File: file, PollSeconds: 30}, nil, "")}
+	if !r.stopRequested(context.Background(), 3) || r.stopReason != "file "+file {
+		t.Fatalf("expected a recorded stop, got %q", r.stopReason)
+	}
+	if (&Runner{}).stopRequested(context.Background(), 0) {
+		t.Fatalf("runner without stop control must not stop")
+	}
+}
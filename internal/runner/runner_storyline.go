@@ -0,0 +1,276 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"shiro/internal/oracle"
+	"shiro/internal/schema"
+	"shiro/internal/sqlstep"
+	"shiro/internal/util"
+)
+
+const (
+	storylineFillStatements = 3
+	storylineChecksPerStep  = 2
+)
+
+// Storyline steps, in the order they run.
+const (
+	storylineStepCreate       = "create"
+	storylineStepFill         = "fill"
+	storylineStepAddColumn    = "add_column"
+	storylineStepAddIndex     = "add_index"
+	storylineStepModifyColumn = "modify_column"
+	storylineStepPartition    = "add_partition"
+	storylineStepDropColumn   = "drop_column"
+)
+
+var storylineSteps = []string{
+	storylineStepCreate,
+	storylineStepFill,
+	storylineStepAddColumn,
+	storylineStepAddIndex,
+	storylineStepModifyColumn,
+	storylineStepPartition,
+	storylineStepDropColumn,
+}
+
+// storyline is one scripted schema evolution of a fresh table. statements
+// holds every successful statement so far, so a case can rebuild the table.
+type storyline struct {
+	table      string
+	statements []string
+	// backfill is the predicate every existing row must satisfy after the
+	// add_column step.
+	backfill string
+}
+
+// runStoryline evolves a fresh table through storylineSteps and runs
+// read-only oracles against it after every step. Bugs that need a specific
+// DDL order (an index added before a type change, a partitioning after a
+// drop) are practically never formed by the random single-step DDL chooser.
+// A step that cannot be generated or fails is skipped; the table is kept when
+// the schema has room under max_tables. It reports whether a check found a
+// case.
+func (r *Runner) runStoryline(ctx context.Context) bool {
+	if r.cfg.TQS.Enabled {
+		return false
+	}
+	tbl := r.gen.GenerateTable()
+	tbl.Partitioned = false
+	tbl.PartitionCount = 0
+	createSQL := r.gen.CreateTableSQL(tbl)
+	if err := r.execSQL(ctx, createSQL); err != nil {
+		util.Detailf("storyline create failed table=%s err=%v", tbl.Name, err)
+		return false
+	}
+	r.state.Tables = append(r.state.Tables, tbl)
+	if err := r.applyTiFlashReplica(ctx, r.storylineTable(tbl.Name)); err != nil {
+		r.dropStorylineTable(ctx, tbl.Name)
+		return false
+	}
+	story := &storyline{table: tbl.Name, statements: []string{createSQL}}
+	found := r.runStorylineChecks(ctx, story, storylineStepCreate)
+	completed := 1
+	for _, step := range storylineSteps[1:] {
+		tablePtr := r.storylineTable(story.table)
+		if tablePtr == nil {
+			return found
+		}
+		next := *tablePtr
+		statements := r.storylineStepSQL(story, step, &next)
+		if len(statements) == 0 {
+			continue
+		}
+		executed := 0
+		for _, sqlText := range statements {
+			if err := r.execSQL(ctx, sqlText); err != nil {
+				util.Detailf("storyline step failed table=%s step=%s err=%v", story.table, step, err)
+				break
+			}
+			executed++
+		}
+		if executed == 0 {
+			continue
+		}
+		*tablePtr = next
+		story.statements = append(story.statements, statements[:executed]...)
+		completed++
+		if step == storylineStepAddColumn && r.checkAddColumnBackfill(ctx, story) {
+			found = true
+		}
+		if r.runStorylineChecks(ctx, story, step) {
+			found = true
+		}
+	}
+	util.Detailf("storyline done table=%s steps=%d/%d", story.table, completed, len(storylineSteps))
+	if len(r.baseTables()) > r.cfg.MaxTables {
+		r.dropStorylineTable(ctx, story.table)
+	}
+	return found
+}
+
+// storylineStepSQL renders the statements of one step and applies the
+// matching metadata change to tbl.
+func (r *Runner) storylineStepSQL(story *storyline, step string, tbl *schema.Table) []string {
+	switch step {
+	case storylineStepFill:
+		out := make([]string, 0, storylineFillStatements)
+		for i := 0; i < storylineFillStatements; i++ {
+			if sqlText := r.gen.InsertSQL(tbl); sqlText != "" {
+				out = append(out, sqlText)
+			}
+		}
+		return out
+	case storylineStepAddColumn:
+		if sqlText, predicate, ok := r.gen.AddColumnSQL(tbl); ok {
+			story.backfill = predicate
+			return []string{sqlText}
+		}
+	case storylineStepAddIndex:
+		if !r.cfg.Features.Indexes {
+			return nil
+		}
+		tbl.Columns = slices.Clone(tbl.Columns)
+		if sqlText, ok := r.gen.CreateIndexSQL(tbl); ok {
+			return []string{sqlText}
+		}
+	case storylineStepModifyColumn:
+		if sqlText, ok := r.gen.ModifyColumnSQL(tbl); ok {
+			return []string{sqlText}
+		}
+	case storylineStepPartition:
+		if !r.cfg.Features.PartitionTables {
+			return nil
+		}
+		if sqlText, ok := r.gen.PartitionTableSQL(tbl); ok {
+			return []string{sqlText}
+		}
+	case storylineStepDropColumn:
+		if sqlText, ok := r.gen.DropColumnSQL(tbl); ok {
+			return []string{sqlText}
+		}
+	}
+	return nil
+}
+
+// runStorylineChecks runs storylineChecksPerStep read-only oracles over the
+// storyline table alone, so every generated query reads it.
+func (r *Runner) runStorylineChecks(ctx context.Context, story *storyline, step string) bool {
+	candidates, weights := r.storylineOracles()
+	if len(candidates) == 0 {
+		return false
+	}
+	found := false
+	for i := 0; i < storylineChecksPerStep; i++ {
+		tablePtr := r.storylineTable(story.table)
+		if tablePtr == nil {
+			return found
+		}
+		oracleIdx := candidates[util.PickWeighted(r.gen.Rand, weights)]
+		oracleName := r.oracles[oracleIdx].Name()
+		state := &schema.State{Tables: []schema.Table{*tablePtr}}
+		gen := r.gen.Fork(state)
+		r.observeOracleRun(oracleName)
+		qctx, cancel := r.withTimeoutForOracle(ctx, oracleName)
+		snapshotTSO, unpinSnapshot := r.pinOracleSnapshot(qctx, r.exec, oracleName)
+		result := r.oracles[oracleIdx].Run(qctx, r.exec, gen, state)
+		unpinSnapshot()
+		cancel()
+		if result.Details == nil {
+			result.Details = map[string]any{}
+		}
+		result.Details["storyline_step"] = step
+		result.Details["storyline_table"] = story.table
+		result.Details["storyline_sql"] = slices.Clone(story.statements)
+		run := pipelinedRun{
+			oracleIdx:    oracleIdx,
+			result:       result,
+			snapshotTSO:  snapshotTSO,
+			features:     gen.LastFeatures,
+			builderStats: gen.BuilderStats(),
+			arms:         r.lastFeatureArms,
+		}
+		if r.finishForkedRun(ctx, run) {
+			found = true
+		}
+	}
+	return found
+}
+
+// storylineOracles returns the enabled read-only oracles and their weights.
+func (r *Runner) storylineOracles() ([]int, []int) {
+	var candidates, weights []int
+	for i, o := range r.oracles {
+		if _, ok := pipelineOracles[o.Name()]; !ok {
+			continue
+		}
+		if weight := r.oracleWeightByName(o.Name()); weight > 0 {
+			candidates = append(candidates, i)
+			weights = append(weights, weight)
+		}
+	}
+	return candidates, weights
+}
+
+func (r *Runner) storylineTable(name string) *schema.Table {
+	for i := range r.state.Tables {
+		if r.state.Tables[i].Name == name {
+			return &r.state.Tables[i]
+		}
+	}
+	return nil
+}
+
+func (r *Runner) dropStorylineTable(ctx context.Context, name string) {
+	_ = r.execSQL(ctx, fmt.Sprintf("DROP TABLE IF EXISTS %s", name))
+	r.state.Tables = slices.DeleteFunc(r.state.Tables, func(tbl schema.Table) bool {
+		return tbl.Name == name
+	})
+}
+
+// checkAddColumnBackfill counts the rows that match the backfill predicate
+// of the column just added, right after the ALTER. Every row must match:
+// rows inserted before the ALTER read the DEFAULT (or NULL), and the
+// storyline inserts nothing after it. It reports whether it found a case.
+func (r *Runner) checkAddColumnBackfill(ctx context.Context, story *storyline) bool {
+	if story.backfill == "" {
+		return false
+	}
+	totalSQL := fmt.Sprintf("SELECT COUNT(*) FROM %s", story.table)
+	matchedSQL := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", story.table, story.backfill)
+	qctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	var total, matched int64
+	if err := r.exec.QueryRowContext(qctx, totalSQL).Scan(&total); err != nil {
+		util.Detailf("storyline backfill check failed table=%s err=%v", story.table, err)
+		return false
+	}
+	if err := r.exec.QueryRowContext(qctx, matchedSQL).Scan(&matched); err != nil {
+		util.Detailf("storyline backfill check failed table=%s err=%v", story.table, err)
+		return false
+	}
+	if total == matched {
+		return false
+	}
+	r.handleResult(ctx, oracle.Result{
+		OK:     false,
+		Oracle: "DDLBackfill",
+		Steps: []sqlstep.Step{
+			sqlstep.New(sqlstep.KindQuery, sqlstep.RoleExpected, totalSQL),
+			sqlstep.New(sqlstep.KindQuery, sqlstep.RoleActual, matchedSQL),
+		},
+		Expected: fmt.Sprintf("cnt=%d", total),
+		Actual:   fmt.Sprintf("cnt=%d", matched),
+		Details: map[string]any{
+			"storyline_step":     storylineStepAddColumn,
+			"storyline_table":    story.table,
+			"storyline_sql":      slices.Clone(story.statements),
+			"backfill_predicate": story.backfill,
+			"bug_hint":           "tidb:add_column_backfill",
+		},
+	})
+	return true
+}
@@ -0,0 +1,73 @@
+package runner
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"shiro/internal/config"
+	"shiro/internal/generator"
+	"shiro/internal/oracle"
+	"shiro/internal/schema"
+)
+
+func TestStorylineStepSQL(t *testing.T) {
+	cfg := config.Config{}
+	cfg.Features.Indexes = true
+	cfg.Features.PartitionTables = true
+	state := &schema.State{}
+	r := &Runner{cfg: cfg, state: state, gen: generator.New(cfg, state, 5)}
+	base := schema.Table{
+		Name:   "t0",
+		HasPK:  true,
+		NextID: 1,
+		Columns: []schema.Column{
+			{Name: "id", Type: schema.TypeInt},
+			{Name: "c0", Type: schema.TypeInt, Nullable: true},
+			{Name: "c1", Type: schema.TypeFloat, Nullable: true},
+		},
+	}
+	story := &storyline{table: base.Name}
+	tbl := base
+	fill := r.storylineStepSQL(story, storylineStepFill, &tbl)
+	if len(fill) != storylineFillStatements || !strings.HasPrefix(fill[0], "INSERT INTO t0") || tbl.NextID <= base.NextID {
+		t.Fatalf("unexpected fill step: next_id=%d sql=%v", tbl.NextID, fill)
+	}
+	tbl = base
+	if got := r.storylineStepSQL(story, storylineStepAddColumn, &tbl); len(got) != 1 || len(tbl.Columns) != 4 || story.backfill == "" {
+		t.Fatalf("unexpected add_column step: %v backfill=%q", got, story.backfill)
+	}
+	tbl = base
+	if got := r.storylineStepSQL(story, storylineStepAddIndex, &tbl); len(got) != 1 || !strings.HasPrefix(got[0], "CREATE INDEX") {
+		t.Fatalf("unexpected add_index step: %v", got)
+	}
+	if slices.ContainsFunc(base.Columns, func(col schema.Column) bool { return col.HasIndex }) {
+		t.Fatalf("add_index mutated the state table before the DDL ran")
+	}
+	tbl = base
+	if got := r.storylineStepSQL(story, storylineStepPartition, &tbl); len(got) != 1 || !tbl.Partitioned {
+		t.Fatalf("unexpected add_partition step: %v", got)
+	}
+	tbl = base
+	if got := r.storylineStepSQL(story, storylineStepDropColumn, &tbl); len(got) != 1 || len(tbl.Columns) != 2 {
+		t.Fatalf("unexpected drop_column step: %v", got)
+	}
+
+	r.cfg.Features.PartitionTables = false
+	tbl = base
+	if got := r.storylineStepSQL(story, storylineStepPartition, &tbl); got != nil {
+		t.Fatalf("partitioning disabled, got %v", got)
+	}
+}
+
+func TestStorylineOracles(t *testing.T) {
+	r := &Runner{oracles: []oracle.Oracle{oracle.NoREC{}, oracle.DQE{}, oracle.TLP{}, oracle.CERT{}, oracle.PQS{}}}
+	r.cfg.Weights.Oracles.NoREC = 2
+	r.cfg.Weights.Oracles.DQE = 5
+	r.cfg.Weights.Oracles.TLP = 0
+	r.cfg.Weights.Oracles.PQS = 1
+	candidates, weights := r.storylineOracles()
+	if !slices.Equal(candidates, []int{0, 4}) || !slices.Equal(weights, []int{2, 1}) {
+		t.Fatalf("storylineOracles()=%v %v", candidates, weights)
+	}
+}
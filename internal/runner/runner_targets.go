@@ -0,0 +1,148 @@
+package runner
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// TargetScheduler interleaves the iterations of runners that fuzz different
+// targets in one process. At most slots iterations run at once, and a free
+// slot goes to the waiting target that is furthest behind its weighted share
+// (smooth weighted round-robin), so a target still setting up or finished
+// never holds the others back.
+type TargetScheduler struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	slots   int
+	running int
+	targets map[string]*targetTurns
+	names   []string
+}
+
+type targetTurns struct {
+	weight  int
+	current int
+	waiting int
+	granted int64
+}
+
+// NewTargetScheduler returns a scheduler running up to slots iterations at
+// once across the targets of weights.
+func NewTargetScheduler(slots int, weights map[string]int) *TargetScheduler {
+	s := &TargetScheduler{slots: max(slots, 1), targets: make(map[string]*targetTurns, len(weights))}
+	s.cond = sync.NewCond(&s.mu)
+	for name, weight := range weights {
+		s.targets[name] = &targetTurns{weight: max(weight, 1)}
+		s.names = append(s.names, name)
+	}
+	sort.Strings(s.names)
+	return s
+}
+
+// Acquire blocks until target may run an iteration and reports false when
+// ctx ends first. Each successful Acquire must be paired with a Release.
+func (s *TargetScheduler) Acquire(ctx context.Context, target string) bool {
+	stop := context.AfterFunc(ctx, func() {
+		s.mu.Lock()
+		s.cond.Broadcast()
+		s.mu.Unlock()
+	})
+	defer stop()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	turns := s.targets[target]
+	if turns == nil {
+		turns = &targetTurns{weight: 1}
+		s.targets[target] = turns
+		s.names = append(s.names, target)
+		sort.Strings(s.names)
+	}
+	turns.waiting++
+	defer func() { turns.waiting-- }()
+	for {
+		if ctx.Err() != nil {
+			return false
+		}
+		if s.running < s.slots && s.next() == target {
+			s.grant(target)
+			return true
+		}
+		s.cond.Wait()
+	}
+}
+
+// Release frees the slot of an iteration of target.
+func (s *TargetScheduler) Release() {
+	s.mu.Lock()
+	s.running = max(s.running-1, 0)
+	s.cond.Broadcast()
+	s.mu.Unlock()
+}
+
+// Granted returns the iterations each target was granted so far.
+func (s *TargetScheduler) Granted() map[string]int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]int64, len(s.targets))
+	for name, turns := range s.targets {
+		out[name] = turns.granted
+	}
+	return out
+}
+
+// next returns the waiting target with the highest current weight after this
+// round's increment, without changing any state.
+func (s *TargetScheduler) next() string {
+	best, bestScore := "", 0
+	for _, name := range s.names {
+		turns := s.targets[name]
+		if turns.waiting == 0 {
+			continue
+		}
+		if score := turns.current + turns.weight; best == "" || score > bestScore {
+			best, bestScore = name, score
+		}
+	}
+	return best
+}
+
+// grant applies one smooth weighted round-robin round over the waiting
+// targets with target as the winner.
+func (s *TargetScheduler) grant(target string) {
+	total := 0
+	for _, turns := range s.targets {
+		if turns.waiting > 0 {
+			turns.current += turns.weight
+			total += turns.weight
+		}
+	}
+	winner := s.targets[target]
+	winner.current -= total
+	winner.granted++
+	s.running++
+}
+
+// SetTargetScheduler makes the runner take a turn from scheduler before each
+// iteration. The runner's target is the config's Target.
+func (r *Runner) SetTargetScheduler(scheduler *TargetScheduler) {
+	r.targetScheduler = scheduler
+}
+
+// awaitTargetTurn frees the turn of the previous iteration and waits for the
+// next one. Without a scheduler it always returns true.
+func (r *Runner) awaitTargetTurn(ctx context.Context) bool {
+	r.releaseTargetTurn()
+	if r.targetScheduler == nil {
+		return true
+	}
+	r.targetTurnHeld = r.targetScheduler.Acquire(ctx, r.cfg.Target)
+	return r.targetTurnHeld
+}
+
+func (r *Runner) releaseTargetTurn() {
+	if r.targetTurnHeld {
+		r.targetScheduler.Release()
+		r.targetTurnHeld = false
+	}
+}
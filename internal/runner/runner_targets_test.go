@@ -0,0 +1,85 @@
+package runner
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTargetSchedulerWeightedOrder(t *testing.T) {
+	s := NewTargetScheduler(1, map[string]int{"a": 3, "b": 1})
+	s.targets["a"].waiting = 1
+	s.targets["b"].waiting = 1
+	var order []string
+	for i := 0; i < 8; i++ {
+		next := s.next()
+		s.grant(next)
+		s.running--
+		order = append(order, next)
+	}
+	if got := strings.Join(order, ""); got != "aabaaaba" {
+		t.Fatalf("unexpected turn order %q", got)
+	}
+	// A target that is not waiting, for example one still setting up, is
+	// skipped instead of stalling the others.
+	s.targets["a"].waiting = 0
+	if next := s.next(); next != "b" {
+		t.Fatalf("expected the only waiting target, got %q", next)
+	}
+}
+
+func TestTargetSchedulerLimitsSlots(t *testing.T) {
+	s := NewTargetScheduler(2, map[string]int{"a": 1, "b": 1, "c": 2})
+	var running, peak atomic.Int32
+	var wg sync.WaitGroup
+	for _, target := range []string{"a", "b", "c"} {
+		for worker := 0; worker < 2; worker++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 20; i++ {
+					if !s.Acquire(context.Background(), target) {
+						t.Errorf("Acquire(%s) failed", target)
+						return
+					}
+					if n := running.Add(1); n > peak.Load() {
+						peak.Store(n)
+					}
+					time.Sleep(50 * time.Microsecond)
+					running.Add(-1)
+					s.Release()
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	if p := peak.Load(); p > 2 {
+		t.Fatalf("expected at most 2 iterations at once, peak %d", p)
+	}
+	granted := s.Granted()
+	if granted["a"] != 40 || granted["b"] != 40 || granted["c"] != 40 {
+		t.Fatalf("unexpected grants: %v", granted)
+	}
+}
+
+func TestTargetSchedulerAcquireCanceled(t *testing.T) {
+	s := NewTargetScheduler(1, map[string]int{"a": 1, "b": 1})
+	if !s.Acquire(context.Background(), "a") {
+		t.Fatalf("expected a free slot")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if s.Acquire(ctx, "b") {
+		t.Fatalf("expected Acquire to give up when the context ends")
+	}
+	s.Release()
+	if !s.Acquire(context.Background(), "b") {
+		t.Fatalf("expected the released slot")
+	}
+	if s.targets["b"].waiting != 0 {
+		t.Fatalf("expected no waiters left, got %d", s.targets["b"].waiting)
+	}
+}
@@ -0,0 +1,138 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"shiro/internal/config"
+	"shiro/internal/report"
+	"shiro/internal/util"
+)
+
+// tidbLogLineBytes estimates the bytes per log line when reading the tail of a
+// local log file, so a tail of N lines reads at most N*tidbLogLineBytes bytes.
+const tidbLogLineBytes = 1024
+
+// captureTiDBLogs fetches the last tidb_logs.lines lines of every configured
+// tidb-server log into tidb_logs/<name>.log and records per-source status in
+// details["tidb_logs"]. It runs before minimization, whose replays would push
+// the stack trace out of the tail.
+func (r *Runner) captureTiDBLogs(ctx context.Context, caseData report.Case, details map[string]any) []string {
+	cfg := r.cfg.TiDBLogs
+	if len(cfg.Sources) == 0 {
+		return nil
+	}
+	var outputs []string
+	statuses := make(map[string]any, len(cfg.Sources))
+	for _, src := range cfg.Sources {
+		lctx, cancel := context.WithTimeout(ctx, time.Duration(cfg.TimeoutSeconds)*time.Second)
+		output, err := fetchTiDBLog(lctx, src, cfg.Lines)
+		cancel()
+		if err != nil {
+			statuses[src.Name] = "error: " + err.Error()
+			util.Warnf("tidb log capture failed source=%s case_id=%s err=%v", src.Name, caseData.ID, err)
+			continue
+		}
+		statuses[src.Name] = "ok"
+		outputs = append(outputs, output)
+		if writeErr := r.reporter.WriteText(caseData, "tidb_logs/"+hookFileName(src.Name)+".log", output); writeErr != nil {
+			util.Warnf("tidb log write failed source=%s dir=%s err=%v", src.Name, caseData.Dir, writeErr)
+		}
+	}
+	if details != nil {
+		details["tidb_logs"] = statuses
+	}
+	return outputs
+}
+
+func fetchTiDBLog(ctx context.Context, src config.TiDBLogSource, lines int) (string, error) {
+	switch {
+	case src.Path != "":
+		return tailFile(src.Path, lines)
+	case src.URL != "":
+		return fetchTiDBLogURL(ctx, src.URL, lines)
+	default:
+		cmd := exec.CommandContext(ctx, "sh", "-c", src.Command)
+		cmd.Env = append(os.Environ(), "SHIRO_LOG_LINES="+strconv.Itoa(lines))
+		cmd.WaitDelay = hookShellWaitDelay
+		output, err := cmd.Output()
+		if err != nil {
+			return "", err
+		}
+		return lastLines(string(output), lines), nil
+	}
+}
+
+func fetchTiDBLogURL(ctx context.Context, rawURL string, lines int) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	query := parsed.Query()
+	query.Set("lines", strconv.Itoa(lines))
+	parsed.RawQuery = query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, parsed.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer util.CloseWithErr(resp.Body, "tidb log response")
+	if resp.StatusCode/100 != 2 {
+		return "", fmt.Errorf("status %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, int64(lines)*tidbLogLineBytes))
+	if err != nil {
+		return "", err
+	}
+	return lastLines(string(body), lines), nil
+}
+
+// tailFile returns the last lines of a file without reading all of it.
+func tailFile(path string, lines int) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer util.CloseWithErr(file, "tidb log file")
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+	offset := max(info.Size()-int64(lines)*tidbLogLineBytes, 0)
+	data, err := io.ReadAll(io.NewSectionReader(file, offset, info.Size()-offset))
+	if err != nil {
+		return "", err
+	}
+	text := string(data)
+	if offset > 0 {
+		// Drop the partial first line.
+		if idx := strings.IndexByte(text, '\n'); idx >= 0 {
+			text = text[idx+1:]
+		}
+	}
+	return lastLines(text, lines), nil
+}
+
+// lastLines keeps the last n lines of text.
+func lastLines(text string, n int) string {
+	text = strings.TrimRight(text, "\n")
+	if text == "" {
+		return ""
+	}
+	lines := strings.Split(text, "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
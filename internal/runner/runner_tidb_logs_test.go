@@ -0,0 +1,86 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"shiro/internal/config"
+	"shiro/internal/report"
+)
+
+func TestCaptureTiDBLogs(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "tidb.log")
+	var b strings.Builder
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&b, "[INFO] line %d\n", i)
+	}
+	if err := os.WriteFile(logPath, []byte(b.String()), 0o644); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "head\npanic: lines=%s\n", req.URL.Query().Get("lines"))
+	}))
+	defer server.Close()
+
+	reporter := report.New(filepath.Join(dir, "cases"), 10)
+	caseData, err := reporter.NewCase()
+	if err != nil {
+		t.Fatalf("new case: %v", err)
+	}
+	r := &Runner{reporter: reporter}
+	r.cfg.TiDBLogs = config.TiDBLogsConfig{
+		Lines:          2,
+		TimeoutSeconds: 5,
+		Sources: []config.TiDBLogSource{
+			{Name: "file", Path: logPath},
+			{Name: "http", URL: server.URL + "/log?since=1h"},
+			{Name: "cmd", Command: `printf 'a\nb\nc\n'; echo "n=$SHIRO_LOG_LINES"`},
+			{Name: "missing", Path: filepath.Join(dir, "missing.log")},
+		},
+	}
+	details := map[string]any{}
+	r.captureTiDBLogs(context.Background(), caseData, details)
+
+	want := map[string]string{
+		"file": "[INFO] line 4998\n[INFO] line 4999\n",
+		"http": "head\npanic: lines=2\n",
+		"cmd":  "c\nn=2\n",
+	}
+	for name, content := range want {
+		data, err := os.ReadFile(filepath.Join(caseData.Dir, "tidb_logs", name+".log"))
+		if err != nil {
+			t.Fatalf("read %s log: %v", name, err)
+		}
+		if string(data) != content {
+			t.Fatalf("%s log=%q want=%q", name, data, content)
+		}
+	}
+	statuses, ok := details["tidb_logs"].(map[string]any)
+	if !ok {
+		t.Fatalf("missing tidb_logs statuses: %v", details)
+	}
+	if status, _ := statuses["missing"].(string); !strings.HasPrefix(status, "error:") {
+		t.Fatalf("unexpected missing status: %v", statuses["missing"])
+	}
+}
+
+func TestLastLines(t *testing.T) {
+	cases := map[string]string{
+		"":          "",
+		"a\n":       "a\n",
+		"a\nb\nc":   "b\nc\n",
+		"a\nb\nc\n": "b\nc\n",
+	}
+	for in, want := range cases {
+		if got := lastLines(in, 2); got != want {
+			t.Fatalf("lastLines(%q)=%q want=%q", in, got, want)
+		}
+	}
+}
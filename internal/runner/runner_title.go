@@ -0,0 +1,167 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	"shiro/internal/generator"
+	"shiro/internal/oracle"
+)
+
+const caseTitleMaxLen = 160
+
+// caseTitle builds a one-line summary of a case from the oracle result and
+// the generated query features, for example
+// "DQP mismatch: HASH_JOIN vs base on 3-way inner join with agg".
+func caseTitle(result oracle.Result, errorReason string, features *generator.QueryFeatures) string {
+	title := result.Oracle
+	if title == "" {
+		title = "case"
+	}
+	title += " " + caseTitleKind(result, errorReason)
+	if variant := caseTitleVariant(result.Details); variant != "" {
+		title += ": " + variant
+	}
+	if step := detailString(result.Details, "storyline_step"); step != "" {
+		title += " after " + step
+	}
+	if shape := queryShapeLabel(features); shape != "" {
+		title += " on " + shape
+	}
+	if len(title) > caseTitleMaxLen {
+		title = title[:caseTitleMaxLen-3] + "..."
+	}
+	return title
+}
+
+func caseTitleKind(result oracle.Result, errorReason string) string {
+	switch {
+	case isHangResult(result):
+		return "hang"
+	case result.Err == nil:
+		return "mismatch"
+	case isPanicError(result.Err):
+		if class := detailString(result.Details, "panic_class"); class != "" {
+			return "panic (" + class + ")"
+		}
+		return "panic"
+	case strings.HasSuffix(errorReason, ":timeout"):
+		return "timeout"
+	}
+	if code, ok := result.Details["error_code"].(int); ok && code != 0 {
+		return fmt.Sprintf("error %d", code)
+	}
+	return "error"
+}
+
+// caseTitleVariant names what the oracle compared: the hint or variant that
+// diverged from the base query, or the phase of a stateful check.
+func caseTitleVariant(details map[string]any) string {
+	if hint := detailString(details, "hint"); hint != "" {
+		return compactHintLabel(hint) + " vs base"
+	}
+	if variant := detailString(details, "tiflash_variant"); variant != "" {
+		return variant + " vs tikv"
+	}
+	if variant := detailString(details, "cte_inline_variant"); variant != "" {
+		return variant + " vs base"
+	}
+	if rewrite := detailString(details, "rewrite"); rewrite != "" {
+		return rewrite + " rewrite"
+	}
+	return detailString(details, "savepoint_phase", "txn_ryw_phase", "autoid_phase", "batch_dml_kind")
+}
+
+// compactHintLabel drops table arguments from optimizer hints and keeps the
+// assignment of SET_VAR hints: "HASH_JOIN(t0, t1), SET_VAR(a=1)" becomes
+// "HASH_JOIN+a=1".
+func compactHintLabel(hint string) string {
+	parts := make([]string, 0, 2)
+	depth := 0
+	start := 0
+	flush := func(end int) {
+		token := strings.TrimSpace(hint[start:end])
+		if token == "" {
+			return
+		}
+		name, args, _ := strings.Cut(token, "(")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		if name == "SET_VAR" {
+			parts = append(parts, strings.TrimSuffix(strings.TrimSpace(args), ")"))
+			return
+		}
+		parts = append(parts, name)
+	}
+	for i, ch := range hint {
+		switch ch {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',', ' ':
+			if depth == 0 {
+				flush(i)
+				start = i + 1
+			}
+		}
+	}
+	flush(len(hint))
+	return strings.Join(parts, "+")
+}
+
+// queryShapeLabel describes the generated query, for example
+// "3-way inner/left join with agg, subquery".
+func queryShapeLabel(features *generator.QueryFeatures) string {
+	if features == nil {
+		return ""
+	}
+	shape := "single table"
+	if features.JoinCount > 0 {
+		shape = fmt.Sprintf("%d-way %s join", features.JoinCount+1, joinTypeLabel(features.JoinTypeSeq))
+	}
+	extras := make([]string, 0, 4)
+	if features.HasAggregate {
+		extras = append(extras, "agg")
+	}
+	if features.HasWindow {
+		extras = append(extras, "window")
+	}
+	if features.HasSubquery || features.HasQuantifiedSubqueries {
+		extras = append(extras, "subquery")
+	}
+	if features.HasSetOperations {
+		extras = append(extras, "set op")
+	}
+	if features.HasDerivedTables {
+		extras = append(extras, "derived table")
+	}
+	if features.HasRecursiveCTE {
+		extras = append(extras, "recursive cte")
+	}
+	if features.ViewCount > 0 {
+		extras = append(extras, "view")
+	}
+	if len(extras) > 0 {
+		shape += " with " + strings.Join(extras, ", ")
+	}
+	return shape
+}
+
+// joinTypeLabel turns a join type sequence such as "JOIN-LEFT JOIN" into
+// "inner/left", keeping first-seen order.
+func joinTypeLabel(seq string) string {
+	seen := map[string]struct{}{}
+	labels := make([]string, 0, 2)
+	for _, part := range strings.Split(seq, "-") {
+		label := strings.ToLower(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(part), "JOIN")))
+		if label == "" {
+			label = "inner"
+		}
+		if _, ok := seen[label]; ok {
+			continue
+		}
+		seen[label] = struct{}{}
+		labels = append(labels, label)
+	}
+	return strings.Join(labels, "/")
+}
@@ -0,0 +1,76 @@
+package runner
+
+import (
+	"errors"
+	"testing"
+
+	"shiro/internal/generator"
+	"shiro/internal/oracle"
+)
+
+func TestCaseTitle(t *testing.T) {
+	features := &generator.QueryFeatures{JoinCount: 2, JoinTypeSeq: "JOIN-JOIN", HasAggregate: true}
+	tests := []struct {
+		name     string
+		result   oracle.Result
+		reason   string
+		features *generator.QueryFeatures
+		want     string
+	}{
+		{
+			name:     "dqp hint",
+			result:   oracle.Result{Oracle: "DQP", Details: map[string]any{"hint": "HASH_JOIN(t0, t1, t2)"}},
+			features: features,
+			want:     "DQP mismatch: HASH_JOIN vs base on 3-way inner join with agg",
+		},
+		{
+			name:   "stateful phase",
+			result: oracle.Result{Oracle: "Savepoint", Details: map[string]any{"savepoint_phase": "rollback_to"}},
+			want:   "Savepoint mismatch: rollback_to",
+		},
+		{
+			name:     "sql error",
+			result:   oracle.Result{Oracle: "TLP", Err: errors.New("Error 1105: boom"), Details: map[string]any{"error_code": 1105}},
+			reason:   "tlp:sql_error_1105",
+			features: &generator.QueryFeatures{JoinTypeSeq: "base", HasSubquery: true},
+			want:     "TLP error 1105 on single table with subquery",
+		},
+		{
+			name:     "storyline step",
+			result:   oracle.Result{Oracle: "TLP", Details: map[string]any{"storyline_step": "modify_column"}},
+			features: &generator.QueryFeatures{JoinTypeSeq: "base"},
+			want:     "TLP mismatch after modify_column on single table",
+		},
+		{
+			name:   "timeout",
+			result: oracle.Result{Oracle: "DQP", Err: errors.New("context deadline exceeded")},
+			reason: "dqp:timeout",
+			want:   "DQP timeout",
+		},
+	}
+	for _, tt := range tests {
+		if got := caseTitle(tt.result, tt.reason, tt.features); got != tt.want {
+			t.Fatalf("%s: caseTitle()=%q want=%q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCompactHintLabel(t *testing.T) {
+	tests := map[string]string{
+		"HASH_JOIN(t0, t1)":                           "HASH_JOIN",
+		"SET_VAR(tidb_opt_enable_hash_join=OFF)":      "tidb_opt_enable_hash_join=OFF",
+		"SET_VAR(tidb_allow_mpp=ON), LEADING(t1, t0)": "tidb_allow_mpp=ON+LEADING",
+		"use_index(t0, PRIMARY) STREAM_AGG()":         "USE_INDEX+STREAM_AGG",
+	}
+	for in, want := range tests {
+		if got := compactHintLabel(in); got != want {
+			t.Fatalf("compactHintLabel(%q)=%q want=%q", in, got, want)
+		}
+	}
+}
+
+func TestJoinTypeLabel(t *testing.T) {
+	if got := joinTypeLabel("JOIN-LEFT JOIN-JOIN-NATURAL RIGHT JOIN"); got != "inner/left/natural right" {
+		t.Fatalf("joinTypeLabel()=%q", got)
+	}
+}
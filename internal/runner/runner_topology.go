@@ -0,0 +1,34 @@
+package runner
+
+import (
+	"context"
+
+	"shiro/internal/report"
+	"shiro/internal/util"
+)
+
+const clusterTopologySQL = "SELECT TYPE, VERSION FROM information_schema.CLUSTER_INFO"
+
+// captureTopology records the cluster instances once at startup. Case
+// summaries and the run summary carry it; a failed query leaves it unset.
+func (r *Runner) captureTopology(ctx context.Context) {
+	qctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	cols, rows, err := r.queryStringRows(qctx, clusterTopologySQL)
+	if err != nil {
+		util.Warnf("cluster topology unavailable err=%v", err)
+		return
+	}
+	r.topology = clusterTopologyFromRows(cols, rows)
+	if r.topology != nil {
+		util.Infof("cluster topology %s", r.topology)
+	}
+}
+
+func clusterTopologyFromRows(cols []string, rows [][]string) *report.ClusterTopology {
+	pairs := make([][2]string, 0, len(rows))
+	for _, row := range rows {
+		pairs = append(pairs, [2]string{rowValue(cols, row, "TYPE"), rowValue(cols, row, "VERSION")})
+	}
+	return report.NewClusterTopology(pairs)
+}
@@ -3,6 +3,7 @@ package runner
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"shiro/internal/schema"
 )
@@ -37,6 +38,8 @@ func formatArgs(args []any) []string {
 			out = append(out, "NULL")
 		case string:
 			out = append(out, fmt.Sprintf("'%s'", strings.ReplaceAll(v, "'", "''")))
+		case time.Time:
+			out = append(out, fmt.Sprintf("'%s'", v.Format(time.DateTime)))
 		default:
 			out = append(out, fmt.Sprintf("%v", v))
 		}
@@ -79,6 +82,10 @@ func tiFlashReplicaSQL(tableName string, replicas int) string {
 	return fmt.Sprintf("ALTER TABLE %s SET TIFLASH REPLICA %d", tableName, replicas)
 }
 
-func tiFlashReplicaPendingSQL() string {
-	return "SELECT COUNT(*) FROM information_schema.tiflash_replica WHERE AVAILABLE = 0"
+func tiFlashReplicaPendingSQL(database string, tableName string) string {
+	return fmt.Sprintf("SELECT COUNT(*) FROM information_schema.tiflash_replica WHERE TABLE_SCHEMA = '%s' AND TABLE_NAME = '%s' AND AVAILABLE = 0", database, tableName)
+}
+
+func tiFlashModeSQL(tableName string, mode string) string {
+	return fmt.Sprintf("ALTER TABLE %s SET TIFLASH MODE %s", tableName, strings.ToUpper(mode))
 }
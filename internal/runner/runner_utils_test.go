@@ -39,13 +39,21 @@ func TestTiFlashReplicaSQL(t *testing.T) {
 }
 
 func TestTiFlashReplicaPendingSQL(t *testing.T) {
-	got := tiFlashReplicaPendingSQL()
-	want := "SELECT COUNT(*) FROM information_schema.tiflash_replica WHERE AVAILABLE = 0"
+	got := tiFlashReplicaPendingSQL("shiro_fuzz", "t1")
+	want := "SELECT COUNT(*) FROM information_schema.tiflash_replica WHERE TABLE_SCHEMA = 'shiro_fuzz' AND TABLE_NAME = 't1' AND AVAILABLE = 0"
 	if got != want {
 		t.Fatalf("unexpected tiflash pending sql: got %q want %q", got, want)
 	}
 }
 
+func TestTiFlashModeSQL(t *testing.T) {
+	got := tiFlashModeSQL("t1", "fast")
+	want := "ALTER TABLE t1 SET TIFLASH MODE FAST"
+	if got != want {
+		t.Fatalf("unexpected tiflash mode sql: got %q want %q", got, want)
+	}
+}
+
 func TestForeignKeyCompatibilitySQL(t *testing.T) {
 	fk := &schema.ForeignKey{
 		Name:      "fk_43",
@@ -0,0 +1,271 @@
+package runner
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"shiro/internal/config"
+	"shiro/internal/db"
+	"shiro/internal/util"
+)
+
+const (
+	workloadDBSuffix      = "_bg"
+	workloadLoadBatchRows = 100
+	workloadKeySpread     = 64
+)
+
+// workloadStmt is one parameterized background statement. Args are bound by
+// the driver, so TiDB prepares the statement and the plan cache sees reuse.
+type workloadStmt struct {
+	sql   string
+	args  []any
+	query bool
+}
+
+// workloadSummary is reported in the run summary when the background workload ran.
+type workloadSummary struct {
+	Database   string `json:"database"`
+	Workers    int    `json:"workers"`
+	Txns       int64  `json:"txns"`
+	Commits    int64  `json:"commits"`
+	Conflicts  int64  `json:"conflicts"`
+	Errors     int64  `json:"errors"`
+	Statements int64  `json:"statements"`
+	LastError  string `json:"last_error,omitempty"`
+}
+
+type workloadStats struct {
+	txns       atomic.Int64
+	commits    atomic.Int64
+	conflicts  atomic.Int64
+	errors     atomic.Int64
+	statements atomic.Int64
+
+	mu        sync.Mutex
+	lastError string
+}
+
+func (s *workloadStats) recordError(err error) {
+	if isWorkloadConflict(err) {
+		s.conflicts.Add(1)
+		return
+	}
+	s.errors.Add(1)
+	s.mu.Lock()
+	s.lastError = err.Error()
+	s.mu.Unlock()
+}
+
+// startBackgroundWorkload creates the workload tables in <base>_bg and starts
+// cfg.Workload.Workers workers on their own connection pool, so session state
+// and the current database of the fuzzing connections are untouched. The
+// returned function stops the workers and records the summary.
+func (r *Runner) startBackgroundWorkload(ctx context.Context) func() {
+	cfg := r.cfg.Workload
+	if !cfg.Enabled {
+		return func() {}
+	}
+	dbName := r.baseDB + workloadDBSuffix
+	if err := db.EnsureDatabase(ctx, r.cfg.DSN, dbName); err != nil {
+		util.Warnf("background workload disabled db=%s err=%v", dbName, err)
+		return func() {}
+	}
+	exec, err := db.Open(config.UpdateDatabaseInDSN(r.cfg.DSN, dbName), r.cfg.SessionInit...)
+	if err != nil {
+		util.Warnf("background workload disabled db=%s err=%v", dbName, err)
+		return func() {}
+	}
+	exec.SetMaxOpenConns(cfg.Workers)
+	if err := r.setupWorkloadTables(ctx, exec, cfg); err != nil {
+		util.Warnf("background workload disabled db=%s err=%v", dbName, err)
+		util.CloseWithErr(exec, "workload db")
+		return func() {}
+	}
+	stats := &workloadStats{}
+	wctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.Workers; i++ {
+		wg.Add(1)
+		rng := rand.New(rand.NewSource(r.cfg.Seed + int64(i) + 1))
+		go func() {
+			defer wg.Done()
+			r.runWorkloadWorker(wctx, exec, cfg, rng, stats)
+		}()
+	}
+	util.Infof("background workload started db=%s workers=%d tables=%d rows=%d read_percent=%d txn_per_second=%d",
+		dbName, cfg.Workers, cfg.Tables, cfg.Rows, cfg.ReadPercent, cfg.TxnPerSecond)
+	return func() {
+		cancel()
+		wg.Wait()
+		util.CloseWithErr(exec, "workload db")
+		summary := &workloadSummary{
+			Database:   dbName,
+			Workers:    cfg.Workers,
+			Txns:       stats.txns.Load(),
+			Commits:    stats.commits.Load(),
+			Conflicts:  stats.conflicts.Load(),
+			Errors:     stats.errors.Load(),
+			Statements: stats.statements.Load(),
+		}
+		stats.mu.Lock()
+		summary.LastError = stats.lastError
+		stats.mu.Unlock()
+		r.statsMu.Lock()
+		r.workloadSummary = summary
+		r.statsMu.Unlock()
+		util.Infof("background workload stopped db=%s txns=%d commits=%d conflicts=%d errors=%d",
+			dbName, summary.Txns, summary.Commits, summary.Conflicts, summary.Errors)
+	}
+}
+
+func (r *Runner) setupWorkloadTables(ctx context.Context, exec *db.DB, cfg config.WorkloadConfig) error {
+	for t := 0; t < cfg.Tables; t++ {
+		name := workloadTableName(t)
+		stmts := []string{
+			fmt.Sprintf("DROP TABLE IF EXISTS %s", name),
+			fmt.Sprintf("CREATE TABLE %s (id BIGINT PRIMARY KEY, k INT NOT NULL, c VARCHAR(64) NOT NULL, v DECIMAL(12,2) NOT NULL, KEY idx_k (k))", name),
+		}
+		for _, stmt := range stmts {
+			if err := r.execWorkload(ctx, exec, stmt); err != nil {
+				return err
+			}
+		}
+		for start := 0; start < cfg.Rows; start += workloadLoadBatchRows {
+			end := min(start+workloadLoadBatchRows, cfg.Rows)
+			values := make([]string, 0, end-start)
+			for id := start; id < end; id++ {
+				values = append(values, fmt.Sprintf("(%d, %d, 'c%d', %d.%02d)", id, id%workloadKeySpread, id, id%1000, id%100))
+			}
+			stmt := fmt.Sprintf("INSERT INTO %s (id, k, c, v) VALUES %s", name, strings.Join(values, ", "))
+			if err := r.execWorkload(ctx, exec, stmt); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *Runner) execWorkload(ctx context.Context, exec *db.DB, stmt string) error {
+	qctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	_, err := exec.ExecContext(qctx, stmt)
+	return err
+}
+
+func (r *Runner) runWorkloadWorker(ctx context.Context, exec *db.DB, cfg config.WorkloadConfig, rng *rand.Rand, stats *workloadStats) {
+	var ticker *time.Ticker
+	if cfg.TxnPerSecond > 0 {
+		ticker = time.NewTicker(time.Second / time.Duration(cfg.TxnPerSecond))
+		defer ticker.Stop()
+	}
+	for {
+		if ticker != nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		} else if ctx.Err() != nil {
+			return
+		}
+		stmts := make([]workloadStmt, 0, cfg.TxnStatements)
+		for i := 0; i < cfg.TxnStatements; i++ {
+			stmts = append(stmts, pickWorkloadStmt(rng, cfg))
+		}
+		stats.txns.Add(1)
+		if err := r.runWorkloadTxn(ctx, exec, stmts, stats); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			stats.recordError(err)
+			continue
+		}
+		stats.commits.Add(1)
+	}
+}
+
+func (r *Runner) runWorkloadTxn(ctx context.Context, exec *db.DB, stmts []workloadStmt, stats *workloadStats) error {
+	qctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	tx, err := exec.BeginTx(qctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range stmts {
+		stats.statements.Add(1)
+		if stmt.query {
+			err = drainWorkloadQuery(qctx, tx, stmt)
+		} else {
+			_, err = tx.ExecContext(qctx, stmt.sql, stmt.args...)
+		}
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func drainWorkloadQuery(ctx context.Context, tx *sql.Tx, stmt workloadStmt) error {
+	rows, err := tx.QueryContext(ctx, stmt.sql, stmt.args...)
+	if err != nil {
+		return err
+	}
+	defer util.CloseWithErr(rows, "workload rows")
+	for rows.Next() {
+	}
+	return rows.Err()
+}
+
+// pickWorkloadStmt picks a point read, a short index range read, or a grouped
+// aggregate for reads, and an update, upsert, or delete for writes. Writes
+// keep ids within [0, rows) so the table size stays roughly stable.
+func pickWorkloadStmt(rng *rand.Rand, cfg config.WorkloadConfig) workloadStmt {
+	table := workloadTableName(rng.Intn(cfg.Tables))
+	id := rng.Int63n(int64(cfg.Rows))
+	k := rng.Intn(workloadKeySpread)
+	if rng.Intn(100) < cfg.ReadPercent {
+		switch rng.Intn(3) {
+		case 0:
+			return workloadStmt{sql: fmt.Sprintf("SELECT c, v FROM %s WHERE id = ?", table), args: []any{id}, query: true}
+		case 1:
+			return workloadStmt{sql: fmt.Sprintf("SELECT id, v FROM %s WHERE k BETWEEN ? AND ? ORDER BY id LIMIT 10", table), args: []any{k, k + 2}, query: true}
+		default:
+			return workloadStmt{sql: fmt.Sprintf("SELECT k, COUNT(*), SUM(v) FROM %s WHERE k BETWEEN ? AND ? GROUP BY k", table), args: []any{k, k + 8}, query: true}
+		}
+	}
+	switch rng.Intn(4) {
+	case 0, 1:
+		return workloadStmt{sql: fmt.Sprintf("UPDATE %s SET v = v + ?, k = ? WHERE id = ?", table), args: []any{rng.Intn(10), k, id}}
+	case 2:
+		return workloadStmt{sql: fmt.Sprintf("REPLACE INTO %s (id, k, c, v) VALUES (?, ?, ?, ?)", table), args: []any{id, k, fmt.Sprintf("c%d", rng.Intn(1000)), rng.Intn(1000)}}
+	default:
+		return workloadStmt{sql: fmt.Sprintf("DELETE FROM %s WHERE id = ?", table), args: []any{id}}
+	}
+}
+
+func workloadTableName(i int) string {
+	return fmt.Sprintf("bg_t%d", i)
+}
+
+// isWorkloadConflict reports transaction conflicts that concurrent OLTP load
+// produces by design: deadlocks, lock wait timeouts, and write conflicts.
+func isWorkloadConflict(err error) bool {
+	code, ok := mysqlErrCode(err)
+	if !ok {
+		return false
+	}
+	switch code {
+	case 1205, 1213, 8002, 8022, 9007:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,49 @@
+package runner
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"shiro/internal/config"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestPickWorkloadStmt(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	cfg := config.WorkloadConfig{Tables: 2, Rows: 50, TxnStatements: 4}
+	for _, readPercent := range []int{0, 100} {
+		cfg.ReadPercent = readPercent
+		for i := 0; i < 200; i++ {
+			stmt := pickWorkloadStmt(rng, cfg)
+			if got := strings.Count(stmt.sql, "?"); got != len(stmt.args) {
+				t.Fatalf("placeholders=%d args=%d sql=%s", got, len(stmt.args), stmt.sql)
+			}
+			if stmt.query != (readPercent == 100) {
+				t.Fatalf("read_percent=%d picked query=%t sql=%s", readPercent, stmt.query, stmt.sql)
+			}
+			if !strings.Contains(stmt.sql, "bg_t0") && !strings.Contains(stmt.sql, "bg_t1") {
+				t.Fatalf("unexpected table: %s", stmt.sql)
+			}
+			for _, arg := range stmt.args {
+				if id, ok := arg.(int64); ok && (id < 0 || id >= int64(cfg.Rows)) {
+					t.Fatalf("id %d outside [0,%d): %s", id, cfg.Rows, stmt.sql)
+				}
+			}
+		}
+	}
+}
+
+func TestIsWorkloadConflict(t *testing.T) {
+	if !isWorkloadConflict(&mysql.MySQLError{Number: 9007, Message: "Write conflict"}) {
+		t.Fatalf("write conflict should be a workload conflict")
+	}
+	if isWorkloadConflict(&mysql.MySQLError{Number: 1146, Message: "Table doesn't exist"}) {
+		t.Fatalf("missing table should be an error")
+	}
+	if isWorkloadConflict(errors.New("driver: bad connection")) {
+		t.Fatalf("non-MySQL errors should not be conflicts")
+	}
+}
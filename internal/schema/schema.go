@@ -3,6 +3,10 @@ package schema
 
 import (
 	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"sync/atomic"
 )
 
 // ColumnType enumerates column data types.
@@ -20,8 +24,24 @@ const (
 	TypeDatetime
 	TypeTimestamp
 	TypeBool
+	TypeBit
+	TypeEnum
+	TypeSet
+	TypeYear
 )
 
+// EnumMembers and SetMembers are the member lists of generated ENUM and SET
+// columns. They are deliberately not in alphabetical order: ENUM and SET values
+// sort by member position but compare as strings, which is a classic source of
+// wrong results.
+var (
+	EnumMembers = []string{"b", "d", "a", "c"}
+	SetMembers  = []string{"c", "a", "b"}
+)
+
+// BitWidth is the width of generated BIT columns.
+const BitWidth = 8
+
 // Column describes a table column.
 type Column struct {
 	Name     string
@@ -43,24 +63,184 @@ type ForeignKey struct {
 	Column    string
 	RefTable  string
 	RefColumn string
+	// OnDelete and OnUpdate hold the referential action (CASCADE or SET NULL);
+	// empty means the default RESTRICT behavior.
+	OnDelete string
+	OnUpdate string
+}
+
+// Referential actions for foreign keys.
+const (
+	FKActionCascade = "CASCADE"
+	FKActionSetNull = "SET NULL"
+)
+
+// HasAction reports whether the foreign key declares ON DELETE or ON UPDATE.
+func (fk ForeignKey) HasAction() bool {
+	return fk.OnDelete != "" || fk.OnUpdate != ""
+}
+
+// ActionSQL renders the ON DELETE/ON UPDATE clauses, with a leading space.
+func (fk ForeignKey) ActionSQL() string {
+	out := ""
+	if fk.OnDelete != "" {
+		out += " ON DELETE " + fk.OnDelete
+	}
+	if fk.OnUpdate != "" {
+		out += " ON UPDATE " + fk.OnUpdate
+	}
+	return out
 }
 
 // Table describes a database table.
 type Table struct {
-	Name           string
-	Columns        []Column
-	Indexes        []Index
-	ForeignKeys    []ForeignKey
-	HasPK          bool
+	Name        string
+	Columns     []Column
+	Indexes     []Index
+	ForeignKeys []ForeignKey
+	HasPK       bool
+	// PrimaryKey lists the primary key columns in key order; empty means (id).
+	// Generated keys always include id so inserted rows stay unique.
+	PrimaryKey []string
+	// PKClustering is PKClustered, PKNonClustered, or empty when the server
+	// default (tidb_enable_clustered_index) decides.
+	PKClustering   string
 	NextID         int64
 	Partitioned    bool
 	PartitionCount int
 	IsView         bool
+	// ViewDeterministic marks a view whose definition returns the same rows
+	// on every read. Only such views may appear in signature comparisons.
+	ViewDeterministic bool
+	// TiFlashReplica is the available TiFlash replica count; 0 means the
+	// table is readable from TiKV only.
+	TiFlashReplica int
 }
 
 // State tracks the current schema state.
 type State struct {
 	Tables []Table
+	// keys holds inserted values per "table.column" as SQL literals, so
+	// child rows can reference existing parent keys. Forked states start
+	// without samples.
+	keys map[string][]string
+	// epoch identifies the schema version; see Epoch.
+	epoch uint64
+}
+
+// epochSeq hands out schema epochs. It is process-wide, so two states never
+// share a non-zero epoch even across database rotations and runners.
+var epochSeq atomic.Uint64
+
+// Epoch returns the schema epoch. It changes on every BumpEpoch, so artifacts
+// derived from the schema can be cached by epoch. A state that was never
+// bumped has epoch 0, which callers must not cache under.
+func (s *State) Epoch() uint64 {
+	if s == nil {
+		return 0
+	}
+	return s.epoch
+}
+
+// BumpEpoch moves the state to a new epoch. Call it on every DDL.
+func (s *State) BumpEpoch() {
+	if s != nil {
+		s.epoch = epochSeq.Add(1)
+	}
+}
+
+// Clone returns a state with a copy of the table list and the same epoch.
+// Key samples are not copied.
+func (s *State) Clone() *State {
+	return &State{Tables: slices.Clone(s.Tables), epoch: s.epoch}
+}
+
+// KeySampleMax caps the values RecordKey keeps per column.
+const KeySampleMax = 64
+
+// RecordKey remembers an inserted value of table.column. Once the column holds
+// KeySampleMax values, a random one is replaced.
+func (s *State) RecordKey(r *rand.Rand, table string, column string, literal string) {
+	if s == nil || table == "" || column == "" || literal == "" {
+		return
+	}
+	if s.keys == nil {
+		s.keys = make(map[string][]string)
+	}
+	key := table + "." + column
+	samples := s.keys[key]
+	if len(samples) < KeySampleMax {
+		s.keys[key] = append(samples, literal)
+		return
+	}
+	samples[r.Intn(KeySampleMax)] = literal
+}
+
+// KeySample returns a random recorded value of table.column.
+func (s *State) KeySample(r *rand.Rand, table string, column string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	samples := s.keys[table+"."+column]
+	if len(samples) == 0 {
+		return "", false
+	}
+	return samples[r.Intn(len(samples))], true
+}
+
+// ForgetKeys drops the recorded values of every column of table, for example
+// after the table is truncated or dropped.
+func (s *State) ForgetKeys(table string) {
+	if s == nil {
+		return
+	}
+	prefix := table + "."
+	for key := range s.keys {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.keys, key)
+		}
+	}
+}
+
+// HasKey reports whether literal was recorded for table.column.
+func (s *State) HasKey(table string, column string, literal string) bool {
+	return s != nil && slices.Contains(s.keys[table+"."+column], literal)
+}
+
+// Primary key clustering keywords.
+const (
+	PKClustered    = "CLUSTERED"
+	PKNonClustered = "NONCLUSTERED"
+)
+
+// PrimaryKeyColumns returns the primary key columns, or nil without a PK.
+func (t Table) PrimaryKeyColumns() []string {
+	if !t.HasPK {
+		return nil
+	}
+	if len(t.PrimaryKey) == 0 {
+		return []string{"id"}
+	}
+	return t.PrimaryKey
+}
+
+// PrimaryKeySQL renders the PRIMARY KEY clause with its clustering keyword.
+func (t Table) PrimaryKeySQL() string {
+	cols := t.PrimaryKeyColumns()
+	if len(cols) == 0 {
+		return ""
+	}
+	out := "PRIMARY KEY (" + strings.Join(cols, ", ") + ")"
+	if t.PKClustering != "" {
+		out += " " + t.PKClustering
+	}
+	return out
+}
+
+// IsClustered reports whether the primary key was declared CLUSTERED, so rows
+// are keyed by it rather than by the hidden _tidb_rowid handle.
+func (t Table) IsClustered() bool {
+	return t.HasPK && t.PKClustering == PKClustered
 }
 
 // SplitTablesByView separates base tables from views.
@@ -122,11 +302,27 @@ func (c Column) SQLType() string {
 		return "TIMESTAMP"
 	case TypeBool:
 		return "BOOLEAN"
+	case TypeBit:
+		return fmt.Sprintf("BIT(%d)", BitWidth)
+	case TypeEnum:
+		return "ENUM(" + quotedMembers(EnumMembers) + ")"
+	case TypeSet:
+		return "SET(" + quotedMembers(SetMembers) + ")"
+	case TypeYear:
+		return "YEAR"
 	default:
 		return "INT"
 	}
 }
 
+func quotedMembers(members []string) string {
+	quoted := make([]string, 0, len(members))
+	for _, m := range members {
+		quoted = append(quoted, "'"+m+"'")
+	}
+	return strings.Join(quoted, ",")
+}
+
 // ColumnByName returns a column by name if present.
 func (t Table) ColumnByName(name string) (Column, bool) {
 	for _, col := range t.Columns {
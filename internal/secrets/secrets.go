@@ -0,0 +1,360 @@
+// Package secrets resolves secret references in config values, so CI systems
+// can hand Shiro a config.yaml without plaintext credentials.
+//
+// A reference is a value with one of these prefixes; any other value is used
+// as is:
+//
+//	vault:<path>#<field>           HashiCorp Vault, KV v1 or v2
+//	aws-sm:<secret-id>[#<key>]     AWS Secrets Manager
+//	gcp-sm:<secret-name>[#<key>]   GCP Secret Manager
+//
+// For AWS and GCP, #<key> reads one key of a JSON secret; without it the whole
+// secret string is used. A GCP name without /versions/ reads the latest version.
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"shiro/internal/config"
+
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
+)
+
+const (
+	vaultPrefix = "vault:"
+	awsPrefix   = "aws-sm:"
+	gcpPrefix   = "gcp-sm:"
+
+	requestTimeout = 30 * time.Second
+	maxSecretBytes = 1 << 20
+	gcpScope       = "https://www.googleapis.com/auth/cloud-platform"
+)
+
+// IsRef reports whether value is a secret reference.
+func IsRef(value string) bool {
+	value = strings.TrimSpace(value)
+	for _, prefix := range []string{vaultPrefix, awsPrefix, gcpPrefix} {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolver fetches secret references. The zero value reads VAULT_ADDR and
+// VAULT_TOKEN (or ~/.vault-token), the default AWS credential chain, and GCP
+// application default credentials, each only when a reference needs it.
+type Resolver struct {
+	// VaultAddr and VaultToken override VAULT_ADDR and VAULT_TOKEN.
+	VaultAddr  string
+	VaultToken string
+	// AWSEndpoint and GCPEndpoint override the service endpoints.
+	AWSEndpoint string
+	GCPEndpoint string
+	// HTTPClient sends Vault and AWS requests; GCPClient sends GCP requests
+	// and must add credentials. Nil uses http.DefaultClient and an ADC
+	// client.
+	HTTPClient *http.Client
+	GCPClient  *http.Client
+
+	cache map[string]string
+}
+
+// Resolve returns the secret a reference names and any other value as is. A
+// reference that appears twice is fetched once.
+func (r *Resolver) Resolve(ctx context.Context, value string) (string, error) {
+	ref := strings.TrimSpace(value)
+	if !IsRef(ref) {
+		return value, nil
+	}
+	if cached, ok := r.cache[ref]; ok {
+		return cached, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	var (
+		secret string
+		err    error
+	)
+	switch {
+	case strings.HasPrefix(ref, vaultPrefix):
+		secret, err = r.resolveVault(ctx, strings.TrimPrefix(ref, vaultPrefix))
+	case strings.HasPrefix(ref, awsPrefix):
+		secret, err = r.resolveAWS(ctx, strings.TrimPrefix(ref, awsPrefix))
+	default:
+		secret, err = r.resolveGCP(ctx, strings.TrimPrefix(ref, gcpPrefix))
+	}
+	if err != nil {
+		return "", fmt.Errorf("resolve %s: %w", ref, err)
+	}
+	if r.cache == nil {
+		r.cache = map[string]string{}
+	}
+	r.cache[ref] = secret
+	return secret, nil
+}
+
+// splitField splits "<name>#<field>" at the last '#'.
+func splitField(ref string) (string, string) {
+	if i := strings.LastIndex(ref, "#"); i >= 0 {
+		return ref[:i], ref[i+1:]
+	}
+	return ref, ""
+}
+
+func (r *Resolver) httpClient() *http.Client {
+	if r.HTTPClient != nil {
+		return r.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+func (r *Resolver) resolveVault(ctx context.Context, ref string) (string, error) {
+	path, field := splitField(ref)
+	path = strings.Trim(path, "/")
+	if path == "" || field == "" {
+		return "", fmt.Errorf("vault reference needs <path>#<field>")
+	}
+	addr := strings.TrimRight(firstNonEmpty(r.VaultAddr, os.Getenv("VAULT_ADDR")), "/")
+	if addr == "" {
+		return "", fmt.Errorf("VAULT_ADDR is not set")
+	}
+	token := firstNonEmpty(r.VaultToken, os.Getenv("VAULT_TOKEN"))
+	if token == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			if data, err := os.ReadFile(filepath.Join(home, ".vault-token")); err == nil {
+				token = strings.TrimSpace(string(data))
+			}
+		}
+	}
+	if token == "" {
+		return "", fmt.Errorf("VAULT_TOKEN is not set")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, addr+"/v1/"+path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+	body, err := doRequest(r.httpClient(), req)
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", err
+	}
+	// KV v2 nests the secret in data.data next to data.metadata.
+	data := resp.Data
+	if nested, ok := data["data"].(map[string]any); ok {
+		if _, meta := data["metadata"]; meta {
+			data = nested
+		}
+	}
+	return secretField(data, field)
+}
+
+func (r *Resolver) resolveAWS(ctx context.Context, ref string) (string, error) {
+	secretID, field := splitField(ref)
+	if secretID == "" {
+		return "", fmt.Errorf("aws-sm reference needs a secret id")
+	}
+	var opts []func(*awsconfig.LoadOptions) error
+	// arn:aws:secretsmanager:<region>:<account>:secret:<name>
+	if parts := strings.Split(secretID, ":"); len(parts) > 3 && parts[0] == "arn" {
+		opts = append(opts, awsconfig.WithRegion(parts[3]))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return "", err
+	}
+	if awsCfg.Region == "" {
+		return "", fmt.Errorf("aws region is not set")
+	}
+	creds, err := awsCfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return "", err
+	}
+	endpoint := firstNonEmpty(r.AWSEndpoint, fmt.Sprintf("https://secretsmanager.%s.amazonaws.com", awsCfg.Region))
+	payload, err := json.Marshal(map[string]string{"SecretId": secretID})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	hash := sha256.Sum256(payload)
+	if err := v4.NewSigner().SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), "secretsmanager", awsCfg.Region, time.Now()); err != nil {
+		return "", err
+	}
+	body, err := doRequest(r.httpClient(), req)
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		SecretString string `json:"SecretString"`
+		SecretBinary []byte `json:"SecretBinary"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", err
+	}
+	secret := resp.SecretString
+	if secret == "" {
+		secret = string(resp.SecretBinary)
+	}
+	return jsonField(secret, field)
+}
+
+func (r *Resolver) resolveGCP(ctx context.Context, ref string) (string, error) {
+	name, field := splitField(ref)
+	name = strings.Trim(name, "/")
+	if !strings.HasPrefix(name, "projects/") || !strings.Contains(name, "/secrets/") {
+		return "", fmt.Errorf("gcp-sm reference needs projects/<project>/secrets/<secret>")
+	}
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+	client := r.GCPClient
+	if client == nil {
+		var err error
+		client, _, err = htransport.NewClient(ctx, option.WithScopes(gcpScope))
+		if err != nil {
+			return "", err
+		}
+	}
+	endpoint := strings.TrimRight(firstNonEmpty(r.GCPEndpoint, "https://secretmanager.googleapis.com"), "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"/v1/"+name+":access", nil)
+	if err != nil {
+		return "", err
+	}
+	body, err := doRequest(client, req)
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(resp.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("decode payload: %w", err)
+	}
+	return jsonField(string(data), field)
+}
+
+// doRequest sends req and returns the body of a 2xx response. Error bodies
+// are left out of the error, since they may echo request details.
+func doRequest(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSecretBytes))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("%s %s: %s", req.Method, req.URL.Host, resp.Status)
+	}
+	return body, nil
+}
+
+// jsonField returns secret, or one key of it parsed as a JSON object.
+func jsonField(secret string, field string) (string, error) {
+	if field == "" {
+		return secret, nil
+	}
+	var data map[string]any
+	if err := json.Unmarshal([]byte(secret), &data); err != nil {
+		return "", fmt.Errorf("secret is not a JSON object: %w", err)
+	}
+	return secretField(data, field)
+}
+
+func secretField(data map[string]any, field string) (string, error) {
+	value, ok := data[field]
+	if !ok || value == nil {
+		return "", fmt.Errorf("secret has no field %q", field)
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	// Service account keys and other structured values stay JSON.
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// ResolveConfig replaces the secret references in the credential fields of
+// cfg: dsn_password and that of every target, the S3 keys, the GCS credentials
+// JSON, and the chaos token. Each dsn_password, resolved or plain, is then
+// written into its DSN. Call it after the config is logged, so resolved values
+// never reach the log.
+func ResolveConfig(ctx context.Context, cfg *config.Config) error {
+	var r Resolver
+	fields := []*string{
+		&cfg.DSNPassword,
+		&cfg.Storage.S3.AccessKeyID,
+		&cfg.Storage.S3.SecretAccessKey,
+		&cfg.Storage.S3.SessionToken,
+		&cfg.Storage.GCS.CredentialsJSON,
+		&cfg.Chaos.Token,
+	}
+	for i := range cfg.Targets {
+		fields = append(fields, &cfg.Targets[i].DSNPassword)
+	}
+	for _, field := range fields {
+		value, err := r.Resolve(ctx, *field)
+		if err != nil {
+			return err
+		}
+		*field = value
+	}
+	if cfg.DSNPassword != "" {
+		cfg.DSN = config.SetDSNPassword(cfg.DSN, cfg.DSNPassword)
+	}
+	for i := range cfg.Targets {
+		if target := &cfg.Targets[i]; target.DSNPassword != "" {
+			target.DSN = config.SetDSNPassword(target.DSN, target.DSNPassword)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"shiro/internal/config"
+)
+
+func TestIsRef(t *testing.T) {
+	for value, want := range map[string]bool{
+		"vault:secret/data/shiro#password": true,
+		" aws-sm:shiro/s3":                 true,
+		"gcp-sm:projects/p/secrets/s":      true,
+		"plain-password":                   false,
+		"":                                 false,
+	} {
+		if got := IsRef(value); got != want {
+			t.Fatalf("IsRef(%q)=%v want=%v", value, got, want)
+		}
+	}
+}
+
+func TestResolveVault(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		if req.Header.Get("X-Vault-Token") != "root-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch req.URL.Path {
+		case "/v1/secret/data/shiro":
+			_, _ = io.WriteString(w, `{"data":{"data":{"password":"kv2-pass"},"metadata":{"version":3}}}`)
+		case "/v1/kv/shiro":
+			_, _ = io.WriteString(w, `{"data":{"token":"kv1-token"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	r := Resolver{VaultAddr: server.URL, VaultToken: "root-token"}
+	ctx := context.Background()
+	for ref, want := range map[string]string{
+		"vault:secret/data/shiro#password": "kv2-pass",
+		"vault:kv/shiro#token":             "kv1-token",
+		"plain":                            "plain",
+	} {
+		got, err := r.Resolve(ctx, ref)
+		if err != nil || got != want {
+			t.Fatalf("Resolve(%q)=%q, %v want=%q", ref, got, err, want)
+		}
+	}
+	if _, err := r.Resolve(ctx, "vault:secret/data/shiro#password"); err != nil || requests != 2 {
+		t.Fatalf("expected a cached second read: requests=%d err=%v", requests, err)
+	}
+	if _, err := r.Resolve(ctx, "vault:secret/data/shiro#missing"); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Fatalf("expected a missing field error, got %v", err)
+	}
+	if _, err := r.Resolve(ctx, "vault:secret/data/other#password"); err == nil || strings.Contains(err.Error(), "kv2-pass") {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+}
+
+func TestResolveAWS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		if req.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" ||
+			!strings.Contains(req.Header.Get("Authorization"), "/us-west-2/secretsmanager/aws4_request") ||
+			string(body) != `{"SecretId":"shiro/s3"}` {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = io.WriteString(w, `{"Name":"shiro/s3","SecretString":"{\"access_key_id\":\"AKIA\",\"secret_access_key\":\"s3cr3t\"}"}`)
+	}))
+	defer server.Close()
+	t.Setenv("AWS_ACCESS_KEY_ID", "test-id")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test-secret")
+	t.Setenv("AWS_REGION", "us-west-2")
+	t.Setenv("AWS_CONFIG_FILE", "/nonexistent")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/nonexistent")
+	r := Resolver{AWSEndpoint: server.URL}
+	got, err := r.Resolve(context.Background(), "aws-sm:shiro/s3#secret_access_key")
+	if err != nil || got != "s3cr3t" {
+		t.Fatalf("Resolve()=%q, %v", got, err)
+	}
+	got, err = r.Resolve(context.Background(), "aws-sm:shiro/s3")
+	if err != nil || !strings.HasPrefix(got, `{"access_key_id"`) {
+		t.Fatalf("expected the whole secret string, got %q, %v", got, err)
+	}
+}
+
+func TestResolveGCP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/v1/projects/p/secrets/gcs-key/versions/latest:access" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		data := base64.StdEncoding.EncodeToString([]byte(`{"type":"service_account","key":{"id":"k1"}}`))
+		_, _ = io.WriteString(w, `{"payload":{"data":"`+data+`"}}`)
+	}))
+	defer server.Close()
+	r := Resolver{GCPEndpoint: server.URL, GCPClient: server.Client()}
+	got, err := r.Resolve(context.Background(), "gcp-sm:projects/p/secrets/gcs-key#key")
+	if err != nil || got != `{"id":"k1"}` {
+		t.Fatalf("Resolve()=%q, %v", got, err)
+	}
+	if _, err := r.Resolve(context.Background(), "gcp-sm:gcs-key"); err == nil {
+		t.Fatalf("expected an error for a short secret name")
+	}
+}
+
+func TestResolveConfig(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"data":{"data":{"password":"db-pass","secret_access_key":"s3cr3t"},"metadata":{}}}`)
+	}))
+	defer server.Close()
+	t.Setenv("VAULT_ADDR", server.URL)
+	t.Setenv("VAULT_TOKEN", "root-token")
+	cfg := config.Config{
+		DSN:         "root:@tcp(127.0.0.1:4000)/shiro",
+		DSNPassword: "vault:secret/data/shiro#password",
+		Targets: []config.TargetConfig{
+			{Name: "a", DSN: "root:@tcp(127.0.0.1:4000)/shiro_a", DSNPassword: "vault:secret/data/shiro#password"},
+			{Name: "b", DSN: "root:@tcp(127.0.0.2:4000)/shiro_b"},
+		},
+	}
+	cfg.Storage.S3.AccessKeyID = "AKIA"
+	cfg.Storage.S3.SecretAccessKey = "vault:secret/data/shiro#secret_access_key"
+	if err := ResolveConfig(context.Background(), &cfg); err != nil {
+		t.Fatalf("ResolveConfig() failed: %v", err)
+	}
+	if cfg.DSN != "root:db-pass@tcp(127.0.0.1:4000)/shiro" || cfg.Storage.S3.SecretAccessKey != "s3cr3t" || cfg.Storage.S3.AccessKeyID != "AKIA" {
+		t.Fatalf("unexpected resolved config: dsn=%q s3=%+v", cfg.DSN, cfg.Storage.S3)
+	}
+	if cfg.Targets[0].DSN != "root:db-pass@tcp(127.0.0.1:4000)/shiro_a" || cfg.Targets[1].DSN != "root:@tcp(127.0.0.2:4000)/shiro_b" {
+		t.Fatalf("unexpected target dsns: %+v", cfg.Targets)
+	}
+}
@@ -0,0 +1,411 @@
+// Package sqlfmt pretty-prints generated SQL for case files and the report
+// site. It starts every clause and join on its own line, indents subqueries,
+// normalizes spacing, and upper-cases reserved words. Only whitespace and the
+// case of reserved words change, so the output runs exactly like the input.
+package sqlfmt
+
+import "strings"
+
+const indentUnit = "  "
+
+type tokenKind int
+
+const (
+	// tokWord is an identifier, keyword, number, or @variable.
+	tokWord tokenKind = iota
+	// tokQuoted is a '...', "...", or `...` literal.
+	tokQuoted
+	// tokComment is a /* */ comment, including optimizer hints.
+	tokComment
+	// tokLineComment is a -- or # comment up to the end of the line.
+	tokLineComment
+	// tokPunct is one of ( ) , ; .
+	tokPunct
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	// spaced reports whether whitespace preceded the token in the input.
+	spaced bool
+}
+
+// reservedWords are upper-cased. They cannot be unquoted identifiers, so the
+// change is safe; function names and non-reserved words keep their case.
+var reservedWords = toSet(
+	"ALL", "AND", "AS", "ASC", "BETWEEN", "BY", "CASE", "CAST", "CONVERT",
+	"CROSS", "DELETE", "DESC", "DISTINCT", "DIV", "ELSE", "END", "ESCAPE",
+	"EXCEPT", "EXISTS", "FALSE", "FOR", "FROM", "GROUP", "HAVING", "IF", "IN",
+	"INNER", "INSERT", "INTERSECT", "INTERVAL", "INTO", "IS", "JOIN", "LATERAL",
+	"LEFT", "LIKE", "LIMIT", "MOD", "NATURAL", "NOT", "NULL", "ON", "OR",
+	"ORDER", "OUTER", "OVER", "PARTITION", "RANGE", "RECURSIVE", "REGEXP",
+	"REPLACE", "RIGHT", "ROWS", "SELECT", "SET", "STRAIGHT_JOIN", "THEN",
+	"TRUE", "UNION", "UPDATE", "USING", "VALUES", "WHEN", "WHERE", "WINDOW",
+	"WITH", "XOR",
+)
+
+// valueWords are reserved words that end an operand, so a following - or +
+// is binary.
+var valueWords = toSet("NULL", "TRUE", "FALSE", "END")
+
+// clauseWords start a new line at query level.
+var clauseWords = toSet("SELECT", "FROM", "WHERE", "HAVING", "LIMIT", "WINDOW", "SET", "VALUES")
+
+var setOpWords = toSet("UNION", "EXCEPT", "INTERSECT")
+
+var joinWords = toSet("JOIN", "INNER", "CROSS", "NATURAL", "STRAIGHT_JOIN", "LEFT", "RIGHT", "OUTER")
+
+var multiCharOps = []string{"<=>", "->>", "<=", ">=", "<>", "!=", ":=", "||", "&&", "<<", ">>", "->"}
+
+func toSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		set[word] = true
+	}
+	return set
+}
+
+// Format returns sql pretty-printed. Input it cannot tokenize, such as an
+// unterminated string, is returned unchanged.
+func Format(sql string) string {
+	tokens, ok := lex(sql)
+	if !ok || len(tokens) == 0 {
+		return sql
+	}
+	return render(tokens)
+}
+
+// Statements formats each statement.
+func Statements(statements []string) []string {
+	out := make([]string, len(statements))
+	for i, stmt := range statements {
+		out[i] = Format(stmt)
+	}
+	return out
+}
+
+func lex(sql string) ([]token, bool) {
+	var tokens []token
+	spaced := false
+	emit := func(kind tokenKind, text string) {
+		tokens = append(tokens, token{kind: kind, text: text, spaced: spaced})
+		spaced = false
+	}
+	for i := 0; i < len(sql); {
+		ch := sql[i]
+		next := byte(0)
+		if i+1 < len(sql) {
+			next = sql[i+1]
+		}
+		switch {
+		case isSpace(ch):
+			spaced = true
+			i++
+		case ch == '#' || (ch == '-' && next == '-' && (i+2 == len(sql) || isSpace(sql[i+2]))):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				end = len(sql)
+			} else {
+				end += i
+			}
+			emit(tokLineComment, strings.TrimRight(sql[i:end], " \t\r"))
+			i = end
+		case ch == '/' && next == '*':
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				return nil, false
+			}
+			end += i + 4
+			emit(tokComment, sql[i:end])
+			i = end
+		case ch == '\'' || ch == '"' || ch == '`':
+			end, ok := scanQuoted(sql, i)
+			if !ok {
+				return nil, false
+			}
+			emit(tokQuoted, sql[i:end])
+			i = end
+		case ch == '.' && isDigit(next) && !endsOperand(tokens):
+			end := scanWord(sql, i+1)
+			emit(tokWord, sql[i:end])
+			i = end
+		case isWordByte(ch):
+			end := scanWord(sql, i)
+			emit(tokWord, sql[i:end])
+			i = end
+		case strings.IndexByte("(),;.", ch) >= 0:
+			emit(tokPunct, sql[i:i+1])
+			i++
+		default:
+			op := sql[i : i+1]
+			for _, candidate := range multiCharOps {
+				if strings.HasPrefix(sql[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			emit(tokOp, op)
+			i += len(op)
+		}
+	}
+	return tokens, true
+}
+
+// scanQuoted returns the end of the literal starting at sql[start]. Quotes
+// are escaped by doubling them, and inside strings also by a backslash.
+func scanQuoted(sql string, start int) (int, bool) {
+	quote := sql[start]
+	for i := start + 1; i < len(sql); i++ {
+		switch sql[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case quote:
+			if i+1 < len(sql) && sql[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1, true
+		}
+	}
+	return 0, false
+}
+
+// scanWord returns the end of the word starting at sql[start]. Numbers keep
+// their decimal point and exponent sign, as in 1.5e-05.
+func scanWord(sql string, start int) int {
+	numeric := isDigit(sql[start]) || sql[start] == '.'
+	i := start
+	for i < len(sql) {
+		ch := sql[i]
+		switch {
+		case isWordByte(ch):
+		case numeric && ch == '.':
+		case numeric && (ch == '-' || ch == '+') && i > start && (sql[i-1] == 'e' || sql[i-1] == 'E') && i+1 < len(sql) && isDigit(sql[i+1]):
+		default:
+			return i
+		}
+		i++
+	}
+	return i
+}
+
+// endsOperand reports whether the last token ends an operand, so a '.' after
+// it qualifies a name rather than starting a number.
+func endsOperand(tokens []token) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+	last := tokens[len(tokens)-1]
+	return last.kind == tokWord || last.kind == tokQuoted || last.text == ")"
+}
+
+func isSpace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func isWordByte(ch byte) bool {
+	return ch == '_' || ch == '$' || ch == '@' || isDigit(ch) || (ch|0x20 >= 'a' && ch|0x20 <= 'z') || ch >= 0x80
+}
+
+type printer struct {
+	b strings.Builder
+	// parens records, per open parenthesis, whether it holds a subquery.
+	parens    []bool
+	depth     int
+	lineStart bool
+	// glue suppresses the space before the next token.
+	glue bool
+	// breakNext starts a new line before the next token.
+	breakNext bool
+}
+
+func render(tokens []token) string {
+	p := &printer{lineStart: true}
+	prev := token{}
+	for i, tok := range tokens {
+		next := token{}
+		if i+1 < len(tokens) {
+			next = tokens[i+1]
+		}
+		upper := ""
+		if tok.kind == tokWord {
+			upper = strings.ToUpper(tok.text)
+			if reservedWords[upper] && prev.text != "." && next.text != "." {
+				tok.text = upper
+			} else {
+				upper = ""
+			}
+		}
+		if p.breakNext && upper != "ALL" && upper != "DISTINCT" {
+			p.newline()
+			p.breakNext = false
+		}
+		if p.queryLevel() && breaksBefore(upper, prev, next) {
+			p.newline()
+		}
+		switch {
+		case tok.text == "(" && tok.kind == tokPunct:
+			subquery := isSubqueryStart(next)
+			if !p.lineStart && !p.glue && !isCall(prev) && prev.text != "(" {
+				p.b.WriteByte(' ')
+			}
+			p.write("(")
+			p.parens = append(p.parens, subquery)
+			if subquery {
+				p.depth++
+				p.newline()
+			} else {
+				p.glue = true
+			}
+		case tok.text == ")" && tok.kind == tokPunct:
+			subquery := false
+			if n := len(p.parens); n > 0 {
+				subquery = p.parens[n-1]
+				p.parens = p.parens[:n-1]
+			}
+			if subquery {
+				p.depth = max(p.depth-1, 0)
+				p.newline()
+			}
+			p.write(")")
+		case tok.kind == tokPunct && (tok.text == "," || tok.text == "."):
+			p.write(tok.text)
+			p.glue = tok.text == "."
+		case tok.kind == tokPunct && tok.text == ";":
+			p.write(";")
+			p.parens, p.depth = nil, 0
+			p.newline()
+		case tok.kind == tokOp:
+			p.space()
+			p.write(tok.text)
+			p.glue = isUnary(tok.text) && !endsValue(prev)
+		case tok.kind == tokQuoted && prev.kind == tokWord && !tok.spaced:
+			// Charset introducers and X'..' or b'..' literals.
+			p.write(tok.text)
+		case tok.kind == tokLineComment:
+			p.space()
+			p.write(tok.text)
+			p.newline()
+		default:
+			p.space()
+			p.write(tok.text)
+		}
+		if setOpWords[upper] {
+			p.breakNext = true
+		}
+		prev = tok
+	}
+	return strings.TrimRight(p.b.String(), " \n")
+}
+
+func (p *printer) queryLevel() bool {
+	return len(p.parens) == 0 || p.parens[len(p.parens)-1]
+}
+
+func (p *printer) space() {
+	if !p.lineStart && !p.glue {
+		p.b.WriteByte(' ')
+	}
+}
+
+func (p *printer) write(text string) {
+	p.b.WriteString(text)
+	p.lineStart = false
+	p.glue = false
+}
+
+func (p *printer) newline() {
+	if p.lineStart {
+		return
+	}
+	text := strings.TrimRight(p.b.String(), " ")
+	p.b.Reset()
+	p.b.WriteString(text)
+	p.b.WriteByte('\n')
+	p.b.WriteString(strings.Repeat(indentUnit, p.depth))
+	p.lineStart = true
+	p.glue = false
+}
+
+// breaksBefore reports whether the reserved word upper starts a clause or
+// join at query level.
+func breaksBefore(upper string, prev token, next token) bool {
+	if upper == "" {
+		return false
+	}
+	prevUpper := strings.ToUpper(prev.text)
+	nextUpper := strings.ToUpper(next.text)
+	switch {
+	case upper == "FROM":
+		return prevUpper != "DELETE"
+	case upper == "VALUES":
+		return prev.text != "="
+	case clauseWords[upper], setOpWords[upper]:
+		return true
+	case upper == "GROUP" || upper == "ORDER":
+		return nextUpper == "BY"
+	case upper == "LEFT" || upper == "RIGHT":
+		return (nextUpper == "JOIN" || nextUpper == "OUTER") && !joinWords[prevUpper]
+	case upper == "OUTER":
+		return false
+	case joinWords[upper]:
+		return !joinWords[prevUpper]
+	}
+	return false
+}
+
+func isSubqueryStart(tok token) bool {
+	if tok.kind != tokWord {
+		return false
+	}
+	switch strings.ToUpper(tok.text) {
+	case "SELECT", "WITH":
+		return true
+	}
+	return false
+}
+
+// isCall reports whether a '(' after prev opens an argument list, so no
+// space goes between them.
+func isCall(prev token) bool {
+	switch prev.kind {
+	case tokWord:
+		upper := strings.ToUpper(prev.text)
+		if !reservedWords[upper] {
+			return true
+		}
+		switch upper {
+		case "CAST", "CONVERT", "IF", "LEFT", "RIGHT", "REPLACE", "MOD", "INSERT":
+			return true
+		}
+	case tokQuoted:
+		return strings.HasPrefix(prev.text, "`")
+	}
+	return false
+}
+
+func isUnary(op string) bool {
+	return op == "-" || op == "+" || op == "~" || op == "!"
+}
+
+// endsValue reports whether prev ends an operand, so a following - or + is
+// a binary operator.
+func endsValue(prev token) bool {
+	switch prev.kind {
+	case tokWord:
+		upper := strings.ToUpper(prev.text)
+		return !reservedWords[upper] || valueWords[upper]
+	case tokQuoted:
+		return true
+	case tokPunct:
+		return prev.text == ")"
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package sqlfmt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pingcap/tidb/pkg/parser"
+	"github.com/pingcap/tidb/pkg/parser/format"
+	_ "github.com/pingcap/tidb/pkg/types/parser_driver"
+)
+
+func TestFormatLayout(t *testing.T) {
+	sql := "select /*+ HASH_JOIN(t0) */ t0.c0, count(*) as cnt from t0 left join t1 on t0.c0=t1.c0 where t0.c1 in (select c1 from t2 where c2>-1) and t0.c2 is not null group by t0.c0 having cnt>1 order by t0.c0 limit 10"
+	want := strings.Join([]string{
+		"SELECT /*+ HASH_JOIN(t0) */ t0.c0, count(*) AS cnt",
+		"FROM t0",
+		"LEFT JOIN t1 ON t0.c0 = t1.c0",
+		"WHERE t0.c1 IN (",
+		"  SELECT c1",
+		"  FROM t2",
+		"  WHERE c2 > -1",
+		") AND t0.c2 IS NOT NULL",
+		"GROUP BY t0.c0",
+		"HAVING cnt > 1",
+		"ORDER BY t0.c0",
+		"LIMIT 10",
+	}, "\n")
+	if got := Format(sql); got != want {
+		t.Fatalf("unexpected layout:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFormatKeepsInlineParens(t *testing.T) {
+	sql := "SELECT sum(c0) OVER (PARTITION BY c1 ORDER BY c2 ROWS BETWEEN 1 PRECEDING AND CURRENT ROW), LEFT(c3, 2), _utf8mb4'a''b', X'0A', 1.5e-05, .5 FROM t0 UNION ALL SELECT 1, 2, 3, 4, 5, 6"
+	want := strings.Join([]string{
+		"SELECT sum(c0) OVER (PARTITION BY c1 ORDER BY c2 ROWS BETWEEN 1 PRECEDING AND CURRENT ROW), LEFT(c3, 2), _utf8mb4'a''b', X'0A', 1.5e-05, .5",
+		"FROM t0",
+		"UNION ALL",
+		"SELECT 1, 2, 3, 4, 5, 6",
+	}, "\n")
+	if got := Format(sql); got != want {
+		t.Fatalf("unexpected layout:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFormatLeavesBrokenInput(t *testing.T) {
+	sql := "SELECT 'unterminated FROM t0"
+	if got := Format(sql); got != sql {
+		t.Fatalf("expected input unchanged, got %q", got)
+	}
+}
+
+// TestFormatPreservesStatements checks that the formatted SQL parses to the
+// same statement as the input.
+func TestFormatPreservesStatements(t *testing.T) {
+	cases := []string{
+		"WITH cte0 AS (SELECT c0 FROM t0 WHERE c1 = 'a;b') SELECT * FROM cte0 NATURAL JOIN t1",
+		"WITH RECURSIVE r AS (SELECT 1 AS n UNION ALL SELECT n+1 FROM r WHERE n<5) SELECT n FROM r",
+		"SELECT t0.* FROM t0 CROSS JOIN t1 STRAIGHT_JOIN t2 ON t1.c0=t2.c0 WHERE NOT EXISTS (SELECT 1 FROM t3 WHERE t3.c0=t0.c0)",
+		"SELECT c0 FROM t0 WHERE c1 BETWEEN -3 AND +4 AND c2 <=> NULL OR c3 != - c4 -- trailing\n AND c5 LIKE 'x\\'%'",
+		"SELECT (SELECT max(c0) FROM t1) - 1, CASE WHEN c0 > 1 THEN 'a' ELSE `b` END FROM `t0` AS x",
+		"INSERT INTO t0 (c0, c1) VALUES (1, 'a'), (2, NULL)",
+		"UPDATE /*+ USE_INDEX(t0, idx) */ t0 SET c0 = c0 + 1 WHERE c1 = 2",
+		"DELETE FROM t0 WHERE c0 IN (1, 2) ORDER BY c0 LIMIT 1",
+		"SELECT c0, row_number() OVER w FROM t0 WINDOW w AS (ORDER BY c0) ORDER BY c0 DESC",
+		"SELECT c0 FROM t0 GROUP BY c0 WITH ROLLUP",
+		"SET @@session.tidb_enable_lazy_cursor_fetch = ON",
+	}
+	for _, sql := range cases {
+		formatted := Format(sql)
+		if got, want := restore(t, formatted), restore(t, sql); got != want {
+			t.Fatalf("formatting changed the statement:\n%s\nformatted:\n%s\nrestored:\n%s\nwant:\n%s", sql, formatted, got, want)
+		}
+		if again := Format(formatted); again != formatted {
+			t.Fatalf("format is not idempotent:\n%s\nthen:\n%s", formatted, again)
+		}
+	}
+}
+
+func restore(t *testing.T, sql string) string {
+	t.Helper()
+	stmts, _, err := parser.New().ParseSQL(sql)
+	if err != nil {
+		t.Fatalf("parse %q: %v", sql, err)
+	}
+	var b strings.Builder
+	for _, stmt := range stmts {
+		if err := stmt.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &b)); err != nil {
+			t.Fatalf("restore %q: %v", sql, err)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,186 @@
+// Package sqllog writes every executed statement to size-rotated JSONL files
+// so the statement history before a crash can be recovered after the fact.
+package sqllog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"shiro/internal/db"
+	"shiro/internal/util"
+)
+
+// Entry is one line of the statement log.
+type Entry struct {
+	Seq          int64    `json:"seq"`
+	Time         string   `json:"ts"`
+	Kind         string   `json:"kind"`
+	Tag          string   `json:"tag,omitempty"`
+	ConnID       uint64   `json:"conn"`
+	ServerConnID int64    `json:"server_conn,omitempty"`
+	DurationUS   int64    `json:"dur_us"`
+	OK           bool     `json:"ok"`
+	Error        string   `json:"error,omitempty"`
+	SQL          string   `json:"sql"`
+	Args         []string `json:"args,omitempty"`
+}
+
+// Writer appends statement traces to <dir>/<prefix>-<start>-<part>.jsonl and
+// starts a new part once the current one exceeds maxBytes. When maxFiles > 0,
+// the oldest parts written by this Writer are removed.
+type Writer struct {
+	mu       sync.Mutex
+	dir      string
+	prefix   string
+	start    string
+	maxBytes int64
+	maxFiles int
+	file     *os.File
+	name     string
+	size     int64
+	part     int
+	seq      int64
+	files    []string
+	failed   bool
+}
+
+// Open creates the log directory and the first log part.
+func Open(dir string, prefix string, maxBytes int64, maxFiles int) (*Writer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	w := &Writer{
+		dir:      dir,
+		prefix:   prefix,
+		start:    time.Now().UTC().Format("20060102T150405"),
+		maxBytes: maxBytes,
+		maxFiles: maxFiles,
+	}
+	if err := w.rotateLocked(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// TraceStatement implements db.StatementTracer.
+func (w *Writer) TraceStatement(trace db.StatementTrace) {
+	entry := Entry{
+		Time:         trace.Time.UTC().Format(time.RFC3339Nano),
+		Kind:         trace.Kind,
+		Tag:          trace.Tag,
+		ConnID:       trace.ConnID,
+		ServerConnID: trace.ServerConnID,
+		DurationUS:   trace.Duration.Microseconds(),
+		OK:           trace.Err == nil,
+		SQL:          trace.SQL,
+		Args:         formatArgs(trace.Args),
+	}
+	if trace.Err != nil {
+		entry.Error = trace.Err.Error()
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return
+	}
+	w.seq++
+	entry.Seq = w.seq
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	line = append(line, '\n')
+	if w.maxBytes > 0 && w.size > 0 && w.size+int64(len(line)) > w.maxBytes {
+		if err := w.rotateLocked(); err != nil {
+			w.warnLocked(err)
+			return
+		}
+	}
+	n, err := w.file.Write(line)
+	w.size += int64(n)
+	if err != nil {
+		w.warnLocked(err)
+	}
+}
+
+// Position returns the current log file and the sequence number of the last
+// written statement.
+func (w *Writer) Position() (file string, seq int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.name, w.seq
+}
+
+// Dir returns the log directory.
+func (w *Writer) Dir() string {
+	return w.dir
+}
+
+// Close closes the current log part.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+func (w *Writer) rotateLocked() error {
+	if w.file != nil {
+		if err := w.file.Close(); err != nil {
+			w.warnLocked(err)
+		}
+		w.file = nil
+	}
+	w.part++
+	name := fmt.Sprintf("%s-%s-%04d.jsonl", w.prefix, w.start, w.part)
+	file, err := os.OpenFile(filepath.Join(w.dir, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	w.file = file
+	w.name = name
+	w.size = 0
+	w.files = append(w.files, name)
+	for w.maxFiles > 0 && len(w.files) > w.maxFiles {
+		if err := os.Remove(filepath.Join(w.dir, w.files[0])); err != nil && !os.IsNotExist(err) {
+			w.warnLocked(err)
+		}
+		w.files = w.files[1:]
+	}
+	return nil
+}
+
+// warnLocked logs the first write failure only, so a full disk does not flood the log.
+func (w *Writer) warnLocked(err error) {
+	if w.failed {
+		return
+	}
+	w.failed = true
+	util.Warnf("sql log write failed dir=%s file=%s err=%v", w.dir, w.name, err)
+}
+
+func formatArgs(args []any) []string {
+	if len(args) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(args))
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case nil:
+			out = append(out, "NULL")
+		case []byte:
+			out = append(out, string(v))
+		default:
+			out = append(out, fmt.Sprintf("%v", v))
+		}
+	}
+	return out
+}
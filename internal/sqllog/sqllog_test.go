@@ -0,0 +1,95 @@
+package sqllog
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"shiro/internal/db"
+)
+
+func TestWriterRotatesAndPrunes(t *testing.T) {
+	dir := t.TempDir()
+	w, err := Open(dir, "shiro", 300, 2)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		w.TraceStatement(db.StatementTrace{Kind: db.TraceKindExec, Time: time.Now(), ConnID: 1, SQL: "INSERT INTO t0 VALUES (1)"})
+	}
+	file, seq := w.Position()
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if seq != 10 {
+		t.Fatalf("seq=%d want=10", seq)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 retained parts, got %d", len(entries))
+	}
+	if entries[len(entries)-1].Name() != file {
+		t.Fatalf("last part=%s want=%s", entries[len(entries)-1].Name(), file)
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			t.Fatalf("stat: %v", err)
+		}
+		if info.Size() > 300 {
+			t.Fatalf("part %s exceeds max size: %d", entry.Name(), info.Size())
+		}
+	}
+}
+
+func TestWriterEntryFields(t *testing.T) {
+	dir := t.TempDir()
+	w, err := Open(dir, "shiro", 0, 0)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	w.TraceStatement(db.StatementTrace{
+		Kind:         db.TraceKindExecute,
+		Time:         time.Date(2026, 10, 16, 1, 2, 3, 0, time.UTC),
+		ConnID:       3,
+		ServerConnID: 42,
+		SQL:          "SELECT * FROM t0 WHERE c0 = ?",
+		Args:         []any{int64(7), []byte("x"), nil},
+		Duration:     1500 * time.Microsecond,
+		Err:          errors.New("Error 1105: boom"),
+	})
+	file, _ := w.Position()
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	f, err := os.Open(filepath.Join(dir, file))
+	if err != nil {
+		t.Fatalf("open log: %v", err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	if !scanner.Scan() {
+		t.Fatalf("missing log line")
+	}
+	var entry Entry
+	if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if entry.Seq != 1 || entry.Kind != "execute" || entry.ConnID != 3 || entry.ServerConnID != 42 || entry.DurationUS != 1500 || entry.OK {
+		t.Fatalf("unexpected entry: %+v", entry)
+	}
+	if entry.Time != "2026-10-16T01:02:03Z" || !strings.Contains(entry.Error, "1105") {
+		t.Fatalf("unexpected entry: %+v", entry)
+	}
+	if strings.Join(entry.Args, ",") != "7,x,NULL" {
+		t.Fatalf("unexpected args: %v", entry.Args)
+	}
+}
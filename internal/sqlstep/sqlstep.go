@@ -0,0 +1,73 @@
+package sqlstep
+
+import "strings"
+
+// Kind classifies how a statement participates in a case.
+// Statements loaded from plain SQL files carry no kind.
+type Kind string
+
+const (
+	// KindSetup prepares schema or data (DDL, DML that is not under test).
+	KindSetup Kind = "setup"
+	// KindSetVar changes session state before the statements under test.
+	KindSetVar Kind = "set_var"
+	// KindPrepare creates a prepared statement.
+	KindPrepare Kind = "prepare"
+	// KindExecute runs a prepared statement.
+	KindExecute Kind = "execute"
+	// KindQuery is a statement whose result is compared by the oracle.
+	KindQuery Kind = "query"
+	// KindVerify inspects engine state (EXPLAIN, SHOW WARNINGS, @@last_plan_from_cache).
+	KindVerify Kind = "verify"
+)
+
+// Role marks the part a step plays in the oracle comparison.
+type Role string
+
+const (
+	// RoleExpected is the reference side of a comparison.
+	RoleExpected Role = "expected"
+	// RoleActual is the side that is checked against the reference.
+	RoleActual Role = "actual"
+	// RoleFailing is the statement that returned the captured error.
+	RoleFailing Role = "failing"
+	// RoleReplay is the statement used for plan replayer dumps and minimization.
+	RoleReplay Role = "replay"
+)
+
+// Step is one statement in an ordered case sequence.
+type Step struct {
+	Kind Kind   `json:"kind"`
+	Role Role   `json:"role,omitempty"`
+	SQL  string `json:"sql"`
+}
+
+// New builds a step with trimmed SQL.
+func New(kind Kind, role Role, sqlText string) Step {
+	return Step{Kind: kind, Role: role, SQL: strings.TrimSpace(sqlText)}
+}
+
+// SQL flattens steps into statement text in order.
+func SQL(steps []Step) []string {
+	if len(steps) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(steps))
+	for _, step := range steps {
+		if strings.TrimSpace(step.SQL) == "" {
+			continue
+		}
+		out = append(out, step.SQL)
+	}
+	return out
+}
+
+// FindRole returns the first step with the given role.
+func FindRole(steps []Step, role Role) (Step, bool) {
+	for _, step := range steps {
+		if step.Role == role {
+			return step, true
+		}
+	}
+	return Step{}, false
+}
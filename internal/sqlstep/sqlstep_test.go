@@ -0,0 +1,25 @@
+package sqlstep
+
+import "testing"
+
+func TestSQLAndFindRole(t *testing.T) {
+	steps := []Step{
+		New(KindSetVar, "", "SET @a=1"),
+		New(KindSetup, "", " "),
+		New(KindQuery, RoleExpected, " SELECT 1 "),
+		New(KindQuery, RoleActual, "SELECT 2"),
+	}
+	if steps[2].SQL != "SELECT 1" {
+		t.Fatalf("New() did not trim SQL: %q", steps[2].SQL)
+	}
+	if step, ok := FindRole(steps, RoleActual); !ok || step.SQL != "SELECT 2" {
+		t.Fatalf("FindRole()=%+v,%t", step, ok)
+	}
+	if _, ok := FindRole(steps, RoleReplay); ok {
+		t.Fatalf("FindRole() matched a missing role")
+	}
+	got := SQL(steps)
+	if len(got) != 3 || got[0] != "SET @a=1" || got[2] != "SELECT 2" {
+		t.Fatalf("SQL()=%v", got)
+	}
+}
@@ -0,0 +1,125 @@
+package uploader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"shiro/internal/util"
+)
+
+// DirStore stores objects as files under a local directory. It stands in for
+// a bucket in tests and air-gapped setups, and its file:// locations can be
+// read back by shiro-report like gs:// or s3:// ones.
+type DirStore struct {
+	root string
+}
+
+// NewDirStore opens a directory store rooted at dir, creating it if needed.
+func NewDirStore(dir string) (*DirStore, error) {
+	if strings.TrimSpace(dir) == "" {
+		return nil, fmt.Errorf("local storage dir is empty")
+	}
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	return &DirStore{root: root}, nil
+}
+
+// Location returns the file:// URL of key.
+func (s *DirStore) Location(key string) string {
+	return "file://" + filepath.ToSlash(filepath.Join(s.root, filepath.FromSlash(key)))
+}
+
+// List returns the keys under prefix.
+func (s *DirStore) List(_ context.Context, prefix string) ([]string, error) {
+	var keys []string
+	err := filepath.WalkDir(s.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(s.root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Get reads at most maxBytes of key.
+func (s *DirStore) Get(_ context.Context, key string, maxBytes int) ([]byte, bool, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, false, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, false, fmt.Errorf("missing object %s: %w", key, ErrNotExist)
+		}
+		return nil, false, err
+	}
+	defer util.CloseWithErr(f, "dir store object")
+	return readLimited(f, maxBytes)
+}
+
+// Put writes body to the file of key through a temporary file, so readers
+// never see a partial object.
+func (s *DirStore) Put(_ context.Context, key string, body io.Reader, _ int64, _ string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), "."+filepath.Base(p)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(tmp, body); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+// Close is a no-op for directory stores.
+func (s *DirStore) Close() error {
+	return nil
+}
+
+// path maps key to a file under root and rejects keys that would escape it.
+func (s *DirStore) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" || clean != "/"+key {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(clean[1:])), nil
+}
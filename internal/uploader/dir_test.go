@@ -0,0 +1,90 @@
+package uploader
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	cfg "shiro/internal/config"
+)
+
+func TestDirStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDirStore() failed: %v", err)
+	}
+	for key, body := range map[string]string{
+		"runs/a/summary.json": "{}",
+		"runs/a/case.sql":     "SELECT 1;",
+		"other/b.txt":         "b",
+	} {
+		if err := store.Put(ctx, key, strings.NewReader(body), int64(len(body)), ""); err != nil {
+			t.Fatalf("Put(%s) failed: %v", key, err)
+		}
+	}
+	keys, err := store.List(ctx, "runs/")
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	if !slices.Equal(keys, []string{"runs/a/case.sql", "runs/a/summary.json"}) {
+		t.Fatalf("unexpected keys: %v", keys)
+	}
+	data, truncated, err := store.Get(ctx, "runs/a/case.sql", 6)
+	if err != nil || !truncated || string(data) != "SELECT" {
+		t.Fatalf("unexpected limited get: data=%q truncated=%v err=%v", data, truncated, err)
+	}
+	if _, _, err := store.Get(ctx, "runs/missing", 10); !errors.Is(err, ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got %v", err)
+	}
+	for _, key := range []string{"", "../escape", "runs/../../escape", "/abs", "runs/"} {
+		if err := store.Put(ctx, key, strings.NewReader("x"), 1, ""); err == nil {
+			t.Fatalf("Put(%q) should reject the key", key)
+		}
+	}
+}
+
+func TestStoreUploaderToLocalRemote(t *testing.T) {
+	ctx := context.Background()
+	caseDir := filepath.Join(t.TempDir(), "case-1")
+	if err := os.MkdirAll(filepath.Join(caseDir, "min"), 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	for _, name := range []string{"summary.json", "case.sql", filepath.Join("min", "case.sql")} {
+		if err := os.WriteFile(filepath.Join(caseDir, name), []byte("x"), 0o644); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+	remote := t.TempDir()
+	store, prefix, err := NewStore(ctx, cfg.StorageConfig{Local: cfg.LocalStorageConfig{Enabled: true, Dir: remote, Prefix: "/shiro/"}})
+	if err != nil || store == nil {
+		t.Fatalf("NewStore() = %v, %v", store, err)
+	}
+	location, err := NewStoreUploader(store, prefix).UploadDir(ctx, caseDir)
+	if err != nil {
+		t.Fatalf("UploadDir() failed: %v", err)
+	}
+	if want := "file://" + filepath.ToSlash(filepath.Join(remote, "shiro", "case-1")) + "/"; location != want {
+		t.Fatalf("location = %q, want %q", location, want)
+	}
+
+	opened, openPrefix, err := OpenLocation(ctx, location, cfg.StorageConfig{})
+	if err != nil || openPrefix != "" {
+		t.Fatalf("OpenLocation() prefix=%q err=%v", openPrefix, err)
+	}
+	keys, err := opened.List(ctx, "")
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	// Only top-level files are uploaded.
+	if !slices.Equal(keys, []string{"case.sql", "summary.json"}) {
+		t.Fatalf("unexpected uploaded keys: %v", keys)
+	}
+	if _, _, err := OpenLocation(ctx, "gs://bucket/prefix", cfg.StorageConfig{}); err == nil {
+		t.Fatalf("gcs location without storage.gcs should fail")
+	}
+}
@@ -2,86 +2,99 @@ package uploader
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
-	"os"
-	"path/filepath"
 	"strings"
 
 	cfg "shiro/internal/config"
 	"shiro/internal/util"
 
 	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 )
 
-// GCSUploader uploads case directories to Google Cloud Storage.
-type GCSUploader struct {
-	cfg    cfg.GCSConfig
+// GCSStore stores objects in one Google Cloud Storage bucket.
+type GCSStore struct {
+	bucket string
 	client *storage.Client
 }
 
-// NewGCS constructs an uploader from GCS configuration.
-func NewGCS(cfg cfg.GCSConfig) (*GCSUploader, error) {
-	if !cfg.Enabled {
-		return &GCSUploader{cfg: cfg}, nil
-	}
+// NewGCSClient builds a GCS client from configuration. It uses ADC when no
+// credentials JSON or file is set.
+func NewGCSClient(ctx context.Context, cfg cfg.GCSConfig) (*storage.Client, error) {
 	opts := []option.ClientOption{}
-	if strings.TrimSpace(cfg.CredentialsFile) != "" {
+	switch {
+	case strings.TrimSpace(cfg.CredentialsJSON) != "":
+		opts = append(opts, option.WithCredentialsJSON([]byte(cfg.CredentialsJSON)))
+	case strings.TrimSpace(cfg.CredentialsFile) != "":
 		opts = append(opts, option.WithCredentialsFile(strings.TrimSpace(cfg.CredentialsFile)))
 	}
-	client, err := storage.NewClient(context.Background(), opts...)
+	return storage.NewClient(ctx, opts...)
+}
+
+// NewGCSStore opens the configured bucket.
+func NewGCSStore(ctx context.Context, cfg cfg.GCSConfig) (*GCSStore, error) {
+	if strings.TrimSpace(cfg.Bucket) == "" {
+		return nil, fmt.Errorf("gcs bucket is empty")
+	}
+	client, err := NewGCSClient(ctx, cfg)
 	if err != nil {
 		return nil, err
 	}
-	return &GCSUploader{cfg: cfg, client: client}, nil
+	return &GCSStore{bucket: cfg.Bucket, client: client}, nil
 }
 
-// Enabled reports whether GCS uploads are configured.
-func (u *GCSUploader) Enabled() bool {
-	return u.cfg.Enabled
+// Location returns the gs:// URL of key.
+func (s *GCSStore) Location(key string) string {
+	return fmt.Sprintf("gs://%s/%s", s.bucket, key)
 }
 
-// UploadDir uploads a case directory and returns its GCS URL prefix.
-func (u *GCSUploader) UploadDir(ctx context.Context, dir string) (string, error) {
-	if !u.cfg.Enabled {
-		return "", nil
-	}
-	if u.client == nil {
-		return "", fmt.Errorf("gcs uploader is not initialized")
-	}
-	entries, err := os.ReadDir(dir)
-	if err != nil {
-		return "", err
-	}
-	base := filepath.Base(dir)
-	prefix := strings.Trim(u.cfg.Prefix, "/")
-	if prefix != "" {
-		prefix = prefix + "/"
-	}
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
+// List returns the keys under prefix.
+func (s *GCSStore) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	it := s.client.Bucket(s.bucket).Objects(ctx, &storage.Query{Prefix: prefix})
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
 		}
-		path := filepath.Join(dir, entry.Name())
-		if err := u.uploadFile(ctx, path, prefix+base+"/"+entry.Name()); err != nil {
-			return "", err
+		if err != nil {
+			return nil, err
 		}
+		keys = append(keys, attrs.Name)
 	}
-	return fmt.Sprintf("gs://%s/%s%s/", u.cfg.Bucket, prefix, base), nil
+	return keys, nil
 }
 
-func (u *GCSUploader) uploadFile(ctx context.Context, path, key string) error {
-	file, err := os.Open(path)
+// Get reads at most maxBytes of key.
+func (s *GCSStore) Get(ctx context.Context, key string, maxBytes int) ([]byte, bool, error) {
+	rc, err := s.client.Bucket(s.bucket).Object(key).NewReader(ctx)
 	if err != nil {
-		return err
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			return nil, false, fmt.Errorf("missing object %s: %w", key, ErrNotExist)
+		}
+		return nil, false, err
 	}
-	defer util.CloseWithErr(file, "gcs upload file")
+	defer util.CloseWithErr(rc, "gcs response body")
+	return readLimited(rc, maxBytes)
+}
 
-	writer := u.client.Bucket(u.cfg.Bucket).Object(key).NewWriter(ctx)
-	if _, err := io.Copy(writer, file); err != nil {
+// Put uploads body to key.
+func (s *GCSStore) Put(ctx context.Context, key string, body io.Reader, _ int64, contentType string) error {
+	writer := s.client.Bucket(s.bucket).Object(key).NewWriter(ctx)
+	if contentType != "" {
+		writer.ContentType = contentType
+	}
+	if _, err := io.Copy(writer, body); err != nil {
 		_ = writer.Close()
 		return err
 	}
 	return writer.Close()
 }
+
+// Close closes the GCS client.
+func (s *GCSStore) Close() error {
+	return s.client.Close()
+}
@@ -2,9 +2,9 @@ package uploader
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"io"
 	"strings"
 
 	cfg "shiro/internal/config"
@@ -14,19 +14,18 @@ import (
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
-// S3Uploader uploads case directories to S3-compatible storage.
-type S3Uploader struct {
-	cfg    cfg.S3Config
+// S3Store stores objects in one bucket of S3-compatible storage.
+type S3Store struct {
+	bucket string
 	client *s3.Client
 }
 
-// NewS3 constructs an uploader from S3 configuration.
-func NewS3(cfg cfg.S3Config) (*S3Uploader, error) {
-	if !cfg.Enabled {
-		return &S3Uploader{cfg: cfg}, nil
-	}
+// NewS3Client builds an S3 client from configuration, honoring custom
+// endpoints and static credentials.
+func NewS3Client(ctx context.Context, cfg cfg.S3Config) (*s3.Client, error) {
 	opts := []func(*awsconfig.LoadOptions) error{}
 	if cfg.Region != "" {
 		opts = append(opts, awsconfig.WithRegion(cfg.Region))
@@ -48,66 +47,85 @@ func NewS3(cfg cfg.S3Config) (*S3Uploader, error) {
 		creds := credentials.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.SecretAccessKey, cfg.SessionToken)
 		opts = append(opts, awsconfig.WithCredentialsProvider(creds))
 	}
-	awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), opts...)
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
 	if err != nil {
 		return nil, err
 	}
 	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
 		o.UsePathStyle = cfg.UsePathStyle
 	})
-	return &S3Uploader{cfg: cfg, client: client}, nil
-}
-
-// Enabled reports whether S3 uploads are configured.
-func (u *S3Uploader) Enabled() bool {
-	return u.cfg.Enabled
+	return client, nil
 }
 
-// UploadDir uploads a case directory and returns its S3 URL prefix.
-func (u *S3Uploader) UploadDir(ctx context.Context, dir string) (string, error) {
-	if !u.cfg.Enabled {
-		return "", nil
+// NewS3Store opens the configured bucket.
+func NewS3Store(ctx context.Context, cfg cfg.S3Config) (*S3Store, error) {
+	if strings.TrimSpace(cfg.Bucket) == "" {
+		return nil, fmt.Errorf("s3 bucket is empty")
 	}
-	if u.client == nil {
-		return "", fmt.Errorf("s3 uploader is not initialized")
-	}
-	entries, err := os.ReadDir(dir)
+	client, err := NewS3Client(ctx, cfg)
 	if err != nil {
-		return "", err
-	}
-	base := filepath.Base(dir)
-	prefix := strings.Trim(u.cfg.Prefix, "/")
-	if prefix != "" {
-		prefix = prefix + "/"
+		return nil, err
 	}
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
+	return &S3Store{bucket: cfg.Bucket, client: client}, nil
+}
+
+// Location returns the s3:// URL of key.
+func (s *S3Store) Location(key string) string {
+	return fmt.Sprintf("s3://%s/%s", s.bucket, key)
+}
+
+// List returns the keys under prefix.
+func (s *S3Store) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
 		}
-		path := filepath.Join(dir, entry.Name())
-		if err := u.uploadFile(ctx, path, prefix+base+"/"+entry.Name()); err != nil {
-			return "", err
+		for _, obj := range page.Contents {
+			keys = append(keys, aws.ToString(obj.Key))
 		}
 	}
-	return fmt.Sprintf("s3://%s/%s%s/", u.cfg.Bucket, prefix, base), nil
+	return keys, nil
 }
 
-func (u *S3Uploader) uploadFile(ctx context.Context, path, key string) error {
-	file, err := os.Open(path)
+// Get reads at most maxBytes of key.
+func (s *S3Store) Get(ctx context.Context, key string, maxBytes int) ([]byte, bool, error) {
+	resp, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
 	if err != nil {
-		return err
+		var nsk *types.NoSuchKey
+		if strings.Contains(err.Error(), "NotFound") || strings.Contains(err.Error(), "NoSuchKey") || errors.As(err, &nsk) {
+			return nil, false, fmt.Errorf("missing object %s: %w", key, ErrNotExist)
+		}
+		return nil, false, err
 	}
-	defer util.CloseWithErr(file, "s3 upload file")
+	defer util.CloseWithErr(resp.Body, "s3 response body")
+	return readLimited(resp.Body, maxBytes)
+}
 
-	info, err := file.Stat()
-	if err != nil {
-		return err
-	}
-	_, err = u.client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket:        aws.String(u.cfg.Bucket),
+// Put uploads body to key.
+func (s *S3Store) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	input := &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
 		Key:           aws.String(key),
-		Body:          file,
-		ContentLength: aws.Int64(info.Size()),
-	})
+		Body:          body,
+		ContentLength: aws.Int64(size),
+	}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+	_, err := s.client.PutObject(ctx, input)
 	return err
 }
+
+// Close is a no-op; the S3 client holds no resources.
+func (s *S3Store) Close() error {
+	return nil
+}
@@ -0,0 +1,125 @@
+package uploader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	cfg "shiro/internal/config"
+)
+
+// ErrNotExist reports a Get of a key that is not in the store.
+var ErrNotExist = errors.New("object does not exist")
+
+// ObjectStore is a flat key space of objects, such as an S3 or GCS bucket or
+// a local directory. Keys use "/" separators and never start with "/".
+type ObjectStore interface {
+	// Location returns the URL of key, for example s3://bucket/key.
+	Location(key string) string
+	// List returns all keys under prefix in lexical order.
+	List(ctx context.Context, prefix string) ([]string, error)
+	// Get reads at most maxBytes of key and reports whether it was truncated.
+	Get(ctx context.Context, key string, maxBytes int) ([]byte, bool, error)
+	// Put writes size bytes of body to key, replacing any existing object.
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Close releases the client of the store.
+	Close() error
+}
+
+// NewStore opens the store of the first enabled backend in gcs, s3, local
+// order. It returns a nil store when no backend is enabled.
+func NewStore(ctx context.Context, storage cfg.StorageConfig) (ObjectStore, string, error) {
+	switch {
+	case storage.GCS.Enabled:
+		store, err := NewGCSStore(ctx, storage.GCS)
+		return store, storage.GCS.Prefix, err
+	case storage.S3.Enabled:
+		store, err := NewS3Store(ctx, storage.S3)
+		return store, storage.S3.Prefix, err
+	case storage.Local.Enabled:
+		store, err := NewDirStore(storage.Local.Dir)
+		return store, storage.Local.Prefix, err
+	}
+	return nil, "", nil
+}
+
+// OpenLocation opens the store behind a gs://bucket/prefix,
+// s3://bucket/prefix, or file:///dir location and returns the key prefix of
+// the location. Cloud locations need their backend enabled in storage, whose
+// credentials are used with the bucket of the location.
+func OpenLocation(ctx context.Context, location string, storage cfg.StorageConfig) (ObjectStore, string, error) {
+	trimmed := strings.TrimSpace(location)
+	lower := strings.ToLower(trimmed)
+	switch {
+	case strings.HasPrefix(lower, "gs://"):
+		bucket, prefix, err := splitBucketURI(trimmed[len("gs://"):])
+		if err != nil {
+			return nil, "", fmt.Errorf("parse gcs location: %w", err)
+		}
+		if !storage.GCS.Enabled {
+			return nil, "", fmt.Errorf("gcs location requested but storage.gcs.enabled is false")
+		}
+		gcsCfg := storage.GCS
+		gcsCfg.Bucket = bucket
+		store, err := NewGCSStore(ctx, gcsCfg)
+		return store, prefix, err
+	case strings.HasPrefix(lower, "s3://"):
+		bucket, prefix, err := splitBucketURI(trimmed[len("s3://"):])
+		if err != nil {
+			return nil, "", fmt.Errorf("parse s3 location: %w", err)
+		}
+		if !storage.S3.Enabled {
+			return nil, "", fmt.Errorf("s3 location requested but storage.s3.enabled is false")
+		}
+		s3Cfg := storage.S3
+		s3Cfg.Bucket = bucket
+		store, err := NewS3Store(ctx, s3Cfg)
+		return store, prefix, err
+	case strings.HasPrefix(lower, "file://"):
+		parsed, err := url.Parse(trimmed)
+		if err != nil {
+			return nil, "", fmt.Errorf("parse file location: %w", err)
+		}
+		if parsed.Host != "" && parsed.Host != "localhost" {
+			return nil, "", fmt.Errorf("file location must not name a host: %s", trimmed)
+		}
+		store, err := NewDirStore(filepath.FromSlash(parsed.Path))
+		return store, "", err
+	}
+	return nil, "", fmt.Errorf("unsupported storage location %q", location)
+}
+
+func splitBucketURI(rest string) (bucket string, prefix string, err error) {
+	if rest == "" {
+		return "", "", fmt.Errorf("missing bucket")
+	}
+	parts := strings.SplitN(rest, "/", 2)
+	bucket = parts[0]
+	if bucket == "" {
+		return "", "", fmt.Errorf("missing bucket")
+	}
+	if len(parts) == 2 {
+		prefix = strings.TrimPrefix(parts[1], "/")
+		if prefix != "" && !strings.HasSuffix(prefix, "/") {
+			prefix += "/"
+		}
+	}
+	return bucket, prefix, nil
+}
+
+// readLimited reads at most maxBytes of r and reports whether more followed.
+func readLimited(r io.Reader, maxBytes int) ([]byte, bool, error) {
+	data, err := io.ReadAll(io.LimitReader(r, int64(maxBytes)+1))
+	if err != nil {
+		return nil, false, err
+	}
+	truncated := len(data) > maxBytes
+	if truncated {
+		data = data[:maxBytes]
+	}
+	return data, truncated, nil
+}
@@ -1,6 +1,14 @@
 package uploader
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"shiro/internal/util"
+)
 
 // Uploader uploads a directory and returns a location.
 type Uploader interface {
@@ -20,3 +28,57 @@ func (n NoopUploader) Enabled() bool {
 func (n NoopUploader) UploadDir(_ context.Context, _ string) (string, error) {
 	return "", nil
 }
+
+// StoreUploader uploads case directories to an ObjectStore under a prefix.
+type StoreUploader struct {
+	store  ObjectStore
+	prefix string
+}
+
+// NewStoreUploader constructs an uploader that writes under prefix in store.
+func NewStoreUploader(store ObjectStore, prefix string) *StoreUploader {
+	return &StoreUploader{store: store, prefix: strings.Trim(prefix, "/")}
+}
+
+// Enabled reports whether the uploader has a store.
+func (u *StoreUploader) Enabled() bool {
+	return u != nil && u.store != nil
+}
+
+// UploadDir uploads the top-level files of a case directory and returns the
+// location of the uploaded directory, ending in "/".
+func (u *StoreUploader) UploadDir(ctx context.Context, dir string) (string, error) {
+	if !u.Enabled() {
+		return "", fmt.Errorf("uploader has no object store")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	base := filepath.Base(dir)
+	if u.prefix != "" {
+		base = u.prefix + "/" + base
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if err := u.uploadFile(ctx, filepath.Join(dir, entry.Name()), base+"/"+entry.Name()); err != nil {
+			return "", err
+		}
+	}
+	return u.store.Location(base) + "/", nil
+}
+
+func (u *StoreUploader) uploadFile(ctx context.Context, path, key string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer util.CloseWithErr(file, "upload file")
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	return u.store.Put(ctx, key, file, info.Size(), "")
+}
@@ -1,12 +1,16 @@
 package validator
 
 import (
+	"sync"
+
 	"github.com/pingcap/tidb/pkg/parser"
 	_ "github.com/pingcap/tidb/pkg/types/parser_driver" // Register TiDB parser driver.
 )
 
-// Validator wraps the TiDB parser for SQL validation.
+// Validator wraps the TiDB parser for SQL validation. The parser is not safe
+// for concurrent use, so Validate serializes callers.
 type Validator struct {
+	mu     sync.Mutex
 	parser *parser.Parser
 }
 
@@ -17,6 +21,8 @@ func New() *Validator {
 
 // Validate parses a SQL statement and returns any syntax error.
 func (v *Validator) Validate(sql string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
 	_, _, err := v.parser.Parse(sql, "", "")
 	return err
 }
@@ -0,0 +1,306 @@
+// Package shirotest runs individual shiro oracles from Go tests, without the
+// runner, bandits, or case reporting. A test opens a Target on a TiDB server
+// (for example an in-process test server), which creates a fresh database
+// with generated tables and rows, and then calls RunOracle as often as it
+// likes:
+//
+//	target, err := shirotest.OpenTarget(ctx, dsn, shirotest.Options{Seed: 1})
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer target.Close()
+//	for i := 0; i < 100; i++ {
+//		res, err := target.RunOracle(ctx, "NoREC")
+//		if err != nil {
+//			t.Fatal(err)
+//		}
+//		if res.Failed() {
+//			t.Fatal(res)
+//		}
+//	}
+//
+// The API in this package is kept stable; everything it wraps is internal.
+package shirotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"shiro/internal/config"
+	"shiro/internal/db"
+	"shiro/internal/generator"
+	"shiro/internal/oracle"
+	"shiro/internal/schema"
+	"shiro/internal/util"
+	"shiro/internal/validator"
+)
+
+// DefaultDatabase is the database a Target uses when Options.Database is empty.
+const DefaultDatabase = "shiro_test"
+
+// Options configures OpenTarget. The zero value is usable.
+type Options struct {
+	// ConfigPath loads a shiro config file for generator features and oracle
+	// knobs. Empty uses the built-in defaults.
+	ConfigPath string
+	// Database is dropped and recreated by OpenTarget. Empty uses DefaultDatabase.
+	Database string
+	// Seed makes generated schemas and queries reproducible.
+	Seed int64
+	// Tables is the number of tables created; 0 means 2, like a runner start.
+	Tables int
+	// Inserts is the number of INSERT statements per table; 0 means
+	// max(1, max_rows_per_table/5), like a runner start.
+	Inserts int
+	// Timeout bounds each RunOracle call; 0 uses statement_timeout_ms.
+	Timeout time.Duration
+}
+
+// Target is a database with a generated schema that oracles run against. It
+// is not safe for concurrent use.
+type Target struct {
+	cfg     config.Config
+	exec    *db.DB
+	gen     *generator.Generator
+	state   *schema.State
+	oracles map[string]oracle.Oracle
+	timeout time.Duration
+}
+
+// Result is the outcome of one oracle run.
+type Result struct {
+	Oracle string
+	// OK is false when the oracle found a mismatch or a statement failed in a
+	// way the oracle does not tolerate.
+	OK bool
+	// SkipReason is set when the oracle gave up on the generated query, for
+	// example because it did not fit the oracle's preconditions.
+	SkipReason string
+	SQL        []string
+	Expected   string
+	Actual     string
+	Details    map[string]any
+	Err        error
+}
+
+// Oracles lists the oracle names RunOracle accepts.
+func Oracles() []string {
+	all := oracle.All(config.Default())
+	names := make([]string, 0, len(all))
+	for _, o := range all {
+		names = append(names, o.Name())
+	}
+	return names
+}
+
+// OpenTarget connects to dsn, recreates the target database, and fills it
+// with generated tables and rows.
+func OpenTarget(ctx context.Context, dsn string, opts Options) (*Target, error) {
+	cfg := config.Default()
+	if opts.ConfigPath != "" {
+		loaded, err := config.Load(opts.ConfigPath)
+		if err != nil {
+			return nil, fmt.Errorf("load config: %w", err)
+		}
+		cfg = loaded
+	}
+	if opts.Database == "" {
+		opts.Database = DefaultDatabase
+	}
+	// Generated tables are created directly; TQS builds its own schema.
+	cfg.TQS.Enabled = false
+	cfg.DSN = config.UpdateDatabaseInDSN(dsn, opts.Database)
+	cfg.Database = opts.Database
+	cfg.Seed = opts.Seed
+	if err := recreateDatabase(ctx, dsn, opts.Database); err != nil {
+		return nil, fmt.Errorf("recreate database %s: %w", opts.Database, err)
+	}
+	exec, err := db.Open(cfg.DSN, cfg.SessionInit...)
+	if err != nil {
+		return nil, err
+	}
+	exec.Validate = validator.New().Validate
+	exec.Guard = db.ResultGuard{MaxRows: cfg.Oracles.ResultMaxRows, MaxBytes: cfg.Oracles.ResultMaxBytes}
+	state := &schema.State{}
+	t := &Target{
+		cfg:     cfg,
+		exec:    exec,
+		gen:     generator.New(cfg, state, cfg.Seed),
+		state:   state,
+		oracles: make(map[string]oracle.Oracle),
+		timeout: opts.Timeout,
+	}
+	for _, o := range oracle.All(cfg) {
+		t.oracles[o.Name()] = o
+	}
+	if t.timeout <= 0 && cfg.StatementTimeoutMs > 0 {
+		t.timeout = time.Duration(cfg.StatementTimeoutMs) * time.Millisecond
+	}
+	if err := t.populate(ctx, opts.Tables, opts.Inserts); err != nil {
+		util.CloseWithErr(exec, "shirotest db")
+		return nil, err
+	}
+	return t, nil
+}
+
+// Close releases the connection pool. The database is left in place so a
+// failing test can be inspected.
+func (t *Target) Close() error {
+	return t.exec.Close()
+}
+
+// Database returns the name of the target database.
+func (t *Target) Database() string {
+	return t.cfg.Database
+}
+
+// Tables returns the names of the generated tables.
+func (t *Target) Tables() []string {
+	names := make([]string, 0, len(t.state.Tables))
+	for _, tbl := range t.state.Tables {
+		names = append(names, tbl.Name)
+	}
+	return names
+}
+
+// RunOracle runs the named oracle once on a freshly generated query. The
+// error is only set for an unknown oracle name; oracle failures are reported
+// in the Result.
+func (t *Target) RunOracle(ctx context.Context, name string) (Result, error) {
+	o, ok := t.oracles[name]
+	if !ok {
+		return Result{}, fmt.Errorf("unknown oracle %q (known: %s)", name, strings.Join(Oracles(), ", "))
+	}
+	if t.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.timeout)
+		defer cancel()
+	}
+	t.gen.LastFeatures = nil
+	return newResult(o.Name(), o.Run(ctx, t.exec, t.gen, t.state)), nil
+}
+
+// Failed reports whether the run found something worth a test failure.
+func (r Result) Failed() bool {
+	return !r.OK
+}
+
+// Skipped reports whether the oracle gave up without a verdict.
+func (r Result) Skipped() bool {
+	return r.OK && r.SkipReason != ""
+}
+
+// String formats the result for test failure messages.
+func (r Result) String() string {
+	var b strings.Builder
+	status := "ok"
+	switch {
+	case r.Failed():
+		status = "failed"
+	case r.Skipped():
+		status = "skipped: " + r.SkipReason
+	}
+	fmt.Fprintf(&b, "%s %s", r.Oracle, status)
+	if r.Err != nil {
+		fmt.Fprintf(&b, "\nerror: %v", r.Err)
+	}
+	if r.Expected != "" || r.Actual != "" {
+		fmt.Fprintf(&b, "\nexpected: %s\nactual: %s", r.Expected, r.Actual)
+	}
+	for _, sql := range r.SQL {
+		fmt.Fprintf(&b, "\n  %s;", sql)
+	}
+	return b.String()
+}
+
+func newResult(name string, res oracle.Result) Result {
+	if res.Oracle == "" {
+		res.Oracle = name
+	}
+	out := Result{
+		Oracle:   res.Oracle,
+		OK:       res.OK,
+		SQL:      res.SQL(),
+		Expected: res.Expected,
+		Actual:   res.Actual,
+		Details:  res.Details,
+		Err:      res.Err,
+	}
+	if reason, ok := res.Details["skip_reason"].(string); ok {
+		out.SkipReason = reason
+	}
+	return out
+}
+
+// populate creates tables and rows the way a runner start does. Statements
+// the server rejects for an unsupported generated feature are skipped.
+func (t *Target) populate(ctx context.Context, tables int, inserts int) error {
+	if tables <= 0 {
+		tables = 2
+	}
+	if inserts <= 0 {
+		inserts = max(1, t.cfg.MaxRowsPerTable/5)
+	}
+	for i := 0; i < tables; i++ {
+		tbl := t.gen.GenerateTable()
+		if _, err := t.exec.ExecContext(ctx, t.gen.CreateTableSQL(tbl)); err != nil {
+			if isUnsupported(err) {
+				continue
+			}
+			return fmt.Errorf("create table %s: %w", tbl.Name, err)
+		}
+		t.state.Tables = append(t.state.Tables, tbl)
+		tablePtr := &t.state.Tables[len(t.state.Tables)-1]
+		for j := 0; j < inserts; j++ {
+			insertSQL := t.gen.InsertSQL(tablePtr)
+			if strings.TrimSpace(insertSQL) == "" {
+				continue
+			}
+			if _, err := t.exec.ExecContext(ctx, insertSQL); err != nil && !isUnsupported(err) {
+				return fmt.Errorf("insert into %s: %w", tbl.Name, err)
+			}
+		}
+	}
+	if len(t.state.Tables) == 0 {
+		return fmt.Errorf("no generated table was accepted by the server")
+	}
+	return nil
+}
+
+// setupErrorCodes mirrors the runner's setup whitelist: syntax errors from
+// generated features the server lacks, out-of-range values, and foreign key
+// violations.
+var setupErrorCodes = map[uint16]struct{}{
+	1064: {},
+	1292: {},
+	1451: {},
+	1452: {},
+}
+
+func isUnsupported(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return false
+	}
+	_, ok := setupErrorCodes[mysqlErr.Number]
+	return ok
+}
+
+func recreateDatabase(ctx context.Context, dsn string, name string) error {
+	admin, err := db.Open(config.AdminDSN(dsn))
+	if err != nil {
+		return err
+	}
+	defer util.CloseWithErr(admin, "shirotest admin db")
+	escaped := strings.ReplaceAll(name, "`", "``")
+	if _, err := admin.ExecContext(ctx, fmt.Sprintf("DROP DATABASE IF EXISTS `%s`", escaped)); err != nil {
+		return err
+	}
+	_, err = admin.ExecContext(ctx, fmt.Sprintf("CREATE DATABASE `%s`", escaped))
+	return err
+}
@@ -0,0 +1,57 @@
+package shirotest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"shiro/internal/oracle"
+	"shiro/internal/sqlstep"
+)
+
+func TestOracles(t *testing.T) {
+	names := Oracles()
+	seen := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		if _, ok := seen[name]; ok {
+			t.Fatalf("duplicate oracle %s", name)
+		}
+		seen[name] = struct{}{}
+	}
+	for _, want := range []string{"NoREC", "TLP", "CERT", "GroundTruth"} {
+		if _, ok := seen[want]; !ok {
+			t.Fatalf("missing oracle %s in %v", want, names)
+		}
+	}
+
+	target := &Target{oracles: map[string]oracle.Oracle{}}
+	if _, err := target.RunOracle(context.Background(), "nope"); err == nil || !strings.Contains(err.Error(), "NoREC") {
+		t.Fatalf("unknown oracle should list known names, got %v", err)
+	}
+}
+
+func TestNewResult(t *testing.T) {
+	skipped := newResult("TLP", oracle.Result{OK: true, Details: map[string]any{"skip_reason": "tlp:no_where"}})
+	if skipped.Oracle != "TLP" || skipped.Failed() || !skipped.Skipped() || skipped.SkipReason != "tlp:no_where" {
+		t.Fatalf("unexpected skipped result: %+v", skipped)
+	}
+
+	failed := newResult("NoREC", oracle.Result{
+		Oracle: "NoREC",
+		Steps: []sqlstep.Step{
+			sqlstep.New(sqlstep.KindQuery, sqlstep.RoleExpected, "SELECT COUNT(*) FROM t0 WHERE c0 > 1"),
+			sqlstep.New(sqlstep.KindQuery, sqlstep.RoleActual, "SELECT SUM(c0 > 1) FROM t0"),
+		},
+		Expected: "cnt=2",
+		Actual:   "cnt=3",
+		Err:      errors.New("boom"),
+	})
+	if !failed.Failed() || failed.Skipped() {
+		t.Fatalf("mismatch should fail: %+v", failed)
+	}
+	want := "NoREC failed\nerror: boom\nexpected: cnt=2\nactual: cnt=3\n  SELECT COUNT(*) FROM t0 WHERE c0 > 1;\n  SELECT SUM(c0 > 1) FROM t0;"
+	if got := failed.String(); got != want {
+		t.Fatalf("unexpected string:\n%s", got)
+	}
+}
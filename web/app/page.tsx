@@ -7,9 +7,23 @@ import {
   caseArchiveURL,
   caseID,
   caseReportURL,
+  emptySiteConfig,
+  expandCaseLink,
+  fingerprintHistorySummary,
   isHTTPURL,
+  normalizeFingerprintHistory,
+  normalizeSiteConfig,
+  normalizeTrends,
+  normalizeTypedDetails,
   objectURL,
   similarCasesURL,
+  siteFieldHidden,
+  siteLabel,
+  trendSparkline,
+  type CaseTypedDetails,
+  type FingerprintHistory,
+  type SiteConfig,
+  type TrendsData,
 } from "../lib/report-utils";
 
 type FileContent = {
@@ -18,13 +32,25 @@ type FileContent = {
   truncated?: boolean;
 };
 
+type OracleApplicability = {
+  oracle: string;
+  runs: number;
+  effective: number;
+  skips: number;
+  errors: number;
+  skip_reasons?: Record<string, number>;
+  variants?: Record<string, number>;
+};
+
 type CaseEntry = {
   id: string;
   dir: string;
   oracle: string;
+  title?: string;
   timestamp: string;
   tidb_version: string;
   tidb_commit: string;
+  topology?: string;
   error_reason?: string;
   plan_signature: string;
   plan_signature_format: string;
@@ -46,6 +72,7 @@ type CaseEntry = {
   plan_replayer: string;
   upload_location: string;
   details: Record<string, unknown> | null;
+  typed_details: CaseTypedDetails;
   files: Record<string, FileContent>;
   summary_url?: string;
   search_blob?: string;
@@ -54,11 +81,13 @@ type CaseEntry = {
   linked_issue?: string;
   replay_sql?: string;
   minimize_status?: string;
+  oracle_applicability?: OracleApplicability[];
 };
 
 type CaseMetaState = {
   labels: string[];
   linkedIssue: string;
+  history: FingerprintHistory | null;
   draftLabels: string;
   draftIssue: string;
   newLabel: string;
@@ -154,12 +183,13 @@ const workerAuthHeaders = (token: string): Record<string, string> => {
 type BootstrapResult = {
   ok: boolean;
   complete: boolean;
-  collected: Map<string, { labels: string[]; linkedIssue: string }>;
+  collected: Map<string, { labels: string[]; linkedIssue: string; history: FingerprintHistory | null }>;
 };
 
 const emptyCaseMeta = (): CaseMetaState => ({
   labels: [],
   linkedIssue: "",
+  history: null,
   draftLabels: "",
   draftIssue: "",
   newLabel: "",
@@ -458,6 +488,7 @@ const caseRenderKey = (c: CaseEntry, index: number): string => {
 const buildCaseSearchBlob = (c: CaseEntry): string => {
   return [
     c.oracle,
+    c.title,
     c.error_reason,
     c.error,
     c.expected,
@@ -468,6 +499,7 @@ const buildCaseSearchBlob = (c: CaseEntry): string => {
     c.norec_predicate,
     c.tidb_version,
     c.tidb_commit,
+    c.topology,
     c.plan_signature,
     c.plan_signature_format,
     c.case_id,
@@ -507,6 +539,85 @@ const asStringRecord = (value: unknown): Record<string, unknown> | null => {
   return value as Record<string, unknown>;
 };
 
+const asNumber = (value: unknown): number => {
+  return typeof value === "number" && Number.isFinite(value) ? value : 0;
+};
+
+const asCountRecord = (value: unknown): Record<string, number> => {
+  const record = asStringRecord(value);
+  if (!record) {
+    return {};
+  }
+  const out: Record<string, number> = {};
+  for (const [key, item] of Object.entries(record)) {
+    if (typeof item === "number" && Number.isFinite(item)) {
+      out[key] = item;
+    }
+  }
+  return out;
+};
+
+const normalizeOracleApplicability = (value: unknown): OracleApplicability[] => {
+  if (!Array.isArray(value)) {
+    return [];
+  }
+  const out: OracleApplicability[] = [];
+  for (const item of value) {
+    const record = asStringRecord(item);
+    if (!record) {
+      continue;
+    }
+    const oracle = asString(record.oracle).trim();
+    if (!oracle) {
+      continue;
+    }
+    out.push({
+      oracle,
+      runs: asNumber(record.runs),
+      effective: asNumber(record.effective),
+      skips: asNumber(record.skips),
+      errors: asNumber(record.errors),
+      skip_reasons: asCountRecord(record.skip_reasons),
+      variants: asCountRecord(record.variants),
+    });
+  }
+  return out;
+};
+
+const formatCountRecord = (record: Record<string, number> | undefined): string => {
+  if (!record) {
+    return "";
+  }
+  return Object.entries(record)
+    .sort((a, b) => b[1] - a[1] || a[0].localeCompare(b[0]))
+    .map(([key, count]) => `${key}=${count}`)
+    .join(", ");
+};
+
+const formatOracleApplicability = (entries: OracleApplicability[] | undefined): string => {
+  if (!entries || entries.length === 0) {
+    return "";
+  }
+  return entries
+    .map((entry) => {
+      const status = entry.runs === 0 ? "not exercised" : entry.effective > 0 ? "exercised" : "skipped";
+      const parts = [
+        `${entry.oracle}: ${status}`,
+        `runs=${entry.runs} effective=${entry.effective} skips=${entry.skips} errors=${entry.errors}`,
+      ];
+      const reasons = formatCountRecord(entry.skip_reasons);
+      if (reasons) {
+        parts.push(`skip_reasons: ${reasons}`);
+      }
+      const variants = formatCountRecord(entry.variants);
+      if (variants) {
+        parts.push(`variants: ${variants}`);
+      }
+      return parts.join(" | ");
+    })
+    .join("\n");
+};
+
 const normalizeFiles = (value: unknown): Record<string, FileContent> => {
   const record = asStringRecord(value);
   if (!record) {
@@ -554,9 +665,11 @@ const normalizeCaseEntry = (value: unknown): CaseEntry | null => {
     id: asString(record.id),
     dir: asString(record.dir),
     oracle: asString(record.oracle),
+    title: asString(record.title),
     timestamp: asString(record.timestamp),
     tidb_version: asString(record.tidb_version),
     tidb_commit: asString(record.tidb_commit),
+    topology: asString(record.topology),
     error_reason: asString(record.error_reason),
     plan_signature: asString(record.plan_signature),
     plan_signature_format: asString(record.plan_signature_format),
@@ -578,6 +691,7 @@ const normalizeCaseEntry = (value: unknown): CaseEntry | null => {
     plan_replayer: asString(record.plan_replayer),
     upload_location: asString(record.upload_location),
     details,
+    typed_details: normalizeTypedDetails(record.typed_details, details),
     files,
     summary_url: asString(record.summary_url),
     search_blob: asString(record.search_blob),
@@ -586,6 +700,7 @@ const normalizeCaseEntry = (value: unknown): CaseEntry | null => {
     linked_issue: asString(record.linked_issue),
     replay_sql: asString(record.replay_sql),
     minimize_status: asString(record.minimize_status),
+    oracle_applicability: normalizeOracleApplicability(record.oracle_applicability),
   };
 
   if (!normalized.summary_url) {
@@ -644,6 +759,8 @@ const resolveSummaryURL = (summaryURL: string, manifestBaseURL: string): string
 export default function Page() {
   const [payload, setPayload] = useState<ReportPayload | null>(null);
   const [manifestBaseURL, setManifestBaseURL] = useState(reportsBaseURL || ".");
+  const [siteConfig, setSiteConfig] = useState<SiteConfig>(emptySiteConfig);
+  const [trends, setTrends] = useState<TrendsData | null>(null);
   const [caseDetailLoadingByKey, setCaseDetailLoadingByKey] = useState<Record<string, boolean>>({});
   const [caseDetailErrorByKey, setCaseDetailErrorByKey] = useState<Record<string, string>>({});
   const [similarByCase, setSimilarByCase] = useState<Record<string, SimilarPayload>>({});
@@ -836,6 +953,64 @@ export default function Page() {
     };
   }, []);
 
+  const payloadLoaded = payload !== null;
+  useEffect(() => {
+    if (!payloadLoaded) {
+      return;
+    }
+    let canceled = false;
+    const loadSiteConfig = async () => {
+      try {
+        const res = await fetch(`${manifestBaseURL}/site-config.json`, { cache: "no-cache" });
+        if (!res.ok) {
+          return;
+        }
+        const cfg = normalizeSiteConfig(await res.json());
+        if (canceled) {
+          return;
+        }
+        setSiteConfig(cfg);
+        if (cfg.title) {
+          document.title = cfg.title;
+        }
+        if (cfg.locale) {
+          document.documentElement.lang = cfg.locale;
+        }
+      } catch {
+        // site-config.json is optional; older report outputs do not have it.
+      }
+    };
+    void loadSiteConfig();
+    return () => {
+      canceled = true;
+    };
+  }, [payloadLoaded, manifestBaseURL]);
+
+  useEffect(() => {
+    if (!payloadLoaded) {
+      return;
+    }
+    let canceled = false;
+    const loadTrends = async () => {
+      try {
+        const res = await fetch(`${manifestBaseURL}/trends.json`, { cache: "no-cache" });
+        if (!res.ok) {
+          return;
+        }
+        const data = normalizeTrends(await res.json());
+        if (!canceled) {
+          setTrends(data);
+        }
+      } catch {
+        // trends.json is optional; older report outputs do not have it.
+      }
+    };
+    void loadTrends();
+    return () => {
+      canceled = true;
+    };
+  }, [payloadLoaded, manifestBaseURL]);
+
   const patchCaseEntry = (caseKey: string, updater: (current: CaseEntry) => CaseEntry) => {
     if (!caseKey) {
       return;
@@ -1012,10 +1187,12 @@ export default function Page() {
       const payload = (await resp.json()) as Record<string, unknown>;
       const labels = normalizeLabels(payload.labels);
       const linkedIssue = typeof payload.linked_issue === "string" ? payload.linked_issue.trim() : "";
+      const history = normalizeFingerprintHistory(payload.history);
       updateCaseMetaState(caseID, (state) => ({
         ...state,
         labels,
         linkedIssue,
+        history,
         draftLabels: labels.join(", "),
         draftIssue: linkedIssue,
         loading: false,
@@ -1079,7 +1256,7 @@ export default function Page() {
 
     const loadAllMetadata = async (): Promise<BootstrapResult> => {
       const caseIDSet = new Set(caseIDs);
-      const collected = new Map<string, { labels: string[]; linkedIssue: string }>();
+      const collected = new Map<string, { labels: string[]; linkedIssue: string; history: FingerprintHistory | null }>();
       const limit = 500;
       let offset = 0;
       let complete = false;
@@ -1106,6 +1283,7 @@ export default function Page() {
           collected.set(cid, {
             labels: normalizeLabels(row.labels),
             linkedIssue: typeof row.linked_issue === "string" ? row.linked_issue.trim() : "",
+            history: normalizeFingerprintHistory(row.history),
           });
         }
         const total = typeof payload.total === "number" ? payload.total : 0;
@@ -1150,6 +1328,7 @@ export default function Page() {
                 ...current,
                 labels: loaded.labels,
                 linkedIssue: loaded.linkedIssue,
+                history: loaded.history,
                 draftLabels: keepDraftLabels ? currentDraftLabels : loadedDraftLabels,
                 draftIssue: keepDraftIssue ? currentDraftIssue : loadedDraftIssue,
                 loaded: true,
@@ -1418,6 +1597,9 @@ export default function Page() {
   const searchPending = query.trim() !== debouncedQuery.trim();
   const indexModeLabel = payload?.index_version ? `index v${payload.index_version}` : "full payload";
 
+  const fieldHidden = (field: string) => siteFieldHidden(siteConfig, field);
+  const uiLabel = (key: string, fallback: string) => siteLabel(siteConfig, key, fallback);
+
   if (error) {
     return <div className="page"><div className="error">Failed to load reports.index.json/reports.json/report.json: {error}</div></div>;
   }
@@ -1426,8 +1608,8 @@ export default function Page() {
     <div className="page">
       <header className="hero">
         <div className="hero__text">
-          <div className="hero__kicker">Shiro Fuzzing</div>
-          <h1>Case Report Index</h1>
+          <div className="hero__kicker">{uiLabel("kicker", "Shiro Fuzzing")}</div>
+          <h1>{siteConfig.title || uiLabel("title", "Case Report Index")}</h1>
           <p className="hero__sub">
             Static frontend reads <code>reports.index.json</code> first, then falls back to <code>reports.json</code> and <code>report.json</code>. Expand a case to load full detail on demand from <code>summary_url</code>. Set <code>NEXT_PUBLIC_REPORTS_BASE_URL</code> to load reports from a public bucket or CDN.
           </p>
@@ -1587,6 +1769,26 @@ export default function Page() {
             ))}
           </div>
         </div>
+        {trends && (
+          <div className="summary__card">
+            <div className="summary__title">
+              Trends ({trends.buckets[0]} to {trends.buckets[trends.buckets.length - 1]})
+            </div>
+            <div className="summary__list">
+              <div>
+                <span className="summary__label">all cases</span>
+                <span className="summary__spark">{trendSparkline(trends.total)}</span>
+              </div>
+              {trends.by_oracle.slice(0, 6).map((series) => (
+                <div key={series.key} title={`${series.first_seen} to ${series.last_seen}`}>
+                  <span className="summary__label">{series.key}</span>
+                  <span className="summary__spark">{trendSparkline(series.counts)}</span>
+                  <span className="summary__value">{series.total}</span>
+                </div>
+              ))}
+            </div>
+          </div>
+        )}
       </section>
 
       <section className="pager">
@@ -1637,25 +1839,32 @@ export default function Page() {
           const downloadURL = archiveURL;
           const archiveName = isExpanded ? (c.archive_name || "").trim() : "";
           const similarURL = isExpanded ? similarCasesURL(workerBaseURL, c) : "";
+          const caseLinks = isExpanded
+            ? siteConfig.case_links
+                .map((link) => ({ label: link.label, href: expandCaseLink(link.url, c) }))
+                .filter((link) => link.href)
+            : [];
           const similarPayload = isExpanded && cid ? similarByCase[cid] : undefined;
           const similarList = isExpanded ? similarPayload?.matches || [] : [];
           const similarAnswer = isExpanded ? (similarPayload?.answer || "").trim() : "";
           const similarLoading = isExpanded && cid ? Boolean(similarLoadingByCase[cid]) : false;
           const similarError = isExpanded && cid ? (similarErrorByCase[cid] || "").trim() : "";
           const reasonLabel = item.reasonLabel;
-          const expectedSQL = isExpanded ? detailString(c.details, "replay_expected_sql") || c.norec_optimized_sql || "" : "";
-          const actualSQL = isExpanded ? detailString(c.details, "replay_actual_sql") || c.norec_unoptimized_sql || "" : "";
-          const replaySQL = isExpanded ? detailString(c.details, "replay_sql") || c.replay_sql || "" : "";
+          const typed = c.typed_details;
+          const expectedSQL = isExpanded ? typed.replay.expected_sql || c.norec_optimized_sql || "" : "";
+          const actualSQL = isExpanded ? typed.replay.actual_sql || c.norec_unoptimized_sql || "" : "";
+          const replaySQL = isExpanded ? typed.replay.sql || c.replay_sql || "" : "";
           const minimizeStatus = isExpanded
             ? detailString(c.details, "minimize_status") || c.minimize_status || ""
             : c.minimize_status || "";
           const norecPredicate = isExpanded ? c.norec_predicate || "" : "";
+          const oracleCoverage = isExpanded ? formatOracleApplicability(c.oracle_applicability) : "";
           const expectedRowsTruncated = detailBool(c.details, "expected_rows_truncated");
           const actualRowsTruncated = detailBool(c.details, "actual_rows_truncated");
-          const expectedExplainRaw = isExpanded ? detailString(c.details, "expected_explain") : "";
-          const actualExplainRaw = isExpanded ? detailString(c.details, "actual_explain") : "";
-          const unoptimizedExplainRaw = isExpanded ? detailString(c.details, "unoptimized_explain") : "";
-          const optimizedExplainRaw = isExpanded ? detailString(c.details, "optimized_explain") : "";
+          const expectedExplainRaw = isExpanded ? typed.explains.expected : "";
+          const actualExplainRaw = isExpanded ? typed.explains.actual : "";
+          const unoptimizedExplainRaw = isExpanded ? typed.explains.unoptimized : "";
+          const optimizedExplainRaw = isExpanded ? typed.explains.optimized : "";
           const expectedExplain = isExpanded ? formatExplain(expectedExplainRaw) : "";
           const actualExplain = isExpanded ? formatExplain(actualExplainRaw) : "";
           const optimizedExplain = isExpanded ? formatExplain(optimizedExplainRaw) : "";
@@ -1666,72 +1875,74 @@ export default function Page() {
             optimizedExplain && unoptimizedExplain ? { oldValue: unoptimizedExplain, newValue: optimizedExplain } : null;
           const expectedText = c.expected || "";
           const actualText = c.actual || "";
-          const expectedBlock: CaseBlock | null = expectedText
+          const expectedBlock: CaseBlock | null = expectedText && !fieldHidden("expected")
             ? {
-                label: "Expected",
+                label: uiLabel("expected", "Expected"),
                 content: <pre>{expectedText}</pre>,
                 copyText: expectedText,
               }
             : null;
-          const actualBlock: CaseBlock | null = actualText
+          const actualBlock: CaseBlock | null = actualText && !fieldHidden("actual")
             ? {
-                label: "Actual",
+                label: uiLabel("actual", "Actual"),
                 content: <pre>{actualText}</pre>,
                 copyText: actualText,
               }
             : null;
-          const expectedSQLBlock: CaseBlock | null = expectedSQL
+          const expectedSQLBlock: CaseBlock | null = expectedSQL && !fieldHidden("expected_sql")
             ? {
-                label: "Expected SQL",
+                label: uiLabel("expected_sql", "Expected SQL"),
                 content: <pre>{formatSQL(expectedSQL)}</pre>,
                 copyText: expectedSQL,
               }
             : null;
-          const actualSQLBlock: CaseBlock | null = actualSQL
+          const actualSQLBlock: CaseBlock | null = actualSQL && !fieldHidden("actual_sql")
             ? {
-                label: "Actual SQL",
+                label: uiLabel("actual_sql", "Actual SQL"),
                 content: <pre>{formatSQL(actualSQL)}</pre>,
                 copyText: actualSQL,
               }
             : null;
-          const replaySQLBlock: CaseBlock | null = replaySQL
+          const replaySQLBlock: CaseBlock | null = replaySQL && !fieldHidden("replay_sql")
             ? {
-                label: minimizeStatus ? `Min Repro SQL (${minimizeStatus})` : "Min Repro SQL",
+                label: minimizeStatus
+                  ? `${uiLabel("replay_sql", "Min Repro SQL")} (${minimizeStatus})`
+                  : uiLabel("replay_sql", "Min Repro SQL"),
                 content: <pre>{formatSQL(replaySQL)}</pre>,
                 copyText: replaySQL,
               }
             : null;
-          const expectedExplainBlock: CaseBlock | null = expectedExplain
+          const expectedExplainBlock: CaseBlock | null = expectedExplain && !fieldHidden("expected_explain")
             ? {
-                label: "Expected EXPLAIN",
+                label: uiLabel("expected_explain", "Expected EXPLAIN"),
                 content: <pre>{expectedExplain}</pre>,
                 copyText: expectedExplain,
               }
             : null;
-          const actualExplainBlock: CaseBlock | null = actualExplain
+          const actualExplainBlock: CaseBlock | null = actualExplain && !fieldHidden("actual_explain")
             ? {
-                label: "Actual EXPLAIN",
+                label: uiLabel("actual_explain", "Actual EXPLAIN"),
                 content: <pre>{actualExplain}</pre>,
                 copyText: actualExplain,
               }
             : null;
-          const optimizedExplainBlock: CaseBlock | null = optimizedExplain
+          const optimizedExplainBlock: CaseBlock | null = optimizedExplain && !fieldHidden("optimized_explain")
             ? {
-                label: "Optimized EXPLAIN",
+                label: uiLabel("optimized_explain", "Optimized EXPLAIN"),
                 content: <pre>{optimizedExplain}</pre>,
                 copyText: optimizedExplain,
               }
             : null;
-          const unoptimizedExplainBlock: CaseBlock | null = unoptimizedExplain
+          const unoptimizedExplainBlock: CaseBlock | null = unoptimizedExplain && !fieldHidden("unoptimized_explain")
             ? {
-                label: "Unoptimized EXPLAIN",
+                label: uiLabel("unoptimized_explain", "Unoptimized EXPLAIN"),
                 content: <pre>{unoptimizedExplain}</pre>,
                 copyText: unoptimizedExplain,
               }
             : null;
-          const optimizedDiffBlock: CaseBlock | null = optimizedDiff
+          const optimizedDiffBlock: CaseBlock | null = optimizedDiff && !fieldHidden("explain_diff")
             ? {
-                label: "EXPLAIN Diff (Unoptimized | Optimized)",
+                label: uiLabel("optimized_explain_diff", "EXPLAIN Diff (Unoptimized | Optimized)"),
                 content: (
                   <div className="diff-viewer">
                     <ReactDiffViewer
@@ -1749,9 +1960,9 @@ export default function Page() {
                 copyText: optimizedDiff.newValue,
               }
             : null;
-          const expectedActualDiffBlock: CaseBlock | null = expectedActualDiff
+          const expectedActualDiffBlock: CaseBlock | null = expectedActualDiff && !fieldHidden("explain_diff")
             ? {
-                label: "EXPLAIN Diff (Expected | Actual)",
+                label: uiLabel("explain_diff", "EXPLAIN Diff (Expected | Actual)"),
                 content: (
                   <div className="diff-viewer">
                     <ReactDiffViewer
@@ -1773,7 +1984,15 @@ export default function Page() {
             { left: expectedBlock, right: actualBlock },
             { left: expectedSQLBlock, right: actualSQLBlock },
           ].filter((row) => row.left || row.right);
+          const planChanges = isExpanded ? typed.explains.diff : "";
           const diffBlocks: CaseBlock[] = [];
+          if (planChanges && !fieldHidden("explain_diff")) {
+            diffBlocks.push({
+              label: uiLabel("plan_changes", "Plan changes"),
+              content: <pre>{planChanges.split("; ").join("\n")}</pre>,
+              copyText: planChanges,
+            });
+          }
           if (expectedActualDiffBlock) {
             diffBlocks.push(expectedActualDiffBlock);
           }
@@ -1804,7 +2023,7 @@ export default function Page() {
             >
               <summary>
                 <span className="case__title">
-                  {c.timestamp} {c.oracle}
+                  {c.timestamp} {c.title || c.oracle}
                 </span>
                 <span className="case__toggle" aria-hidden="true" />
                 {cid && <span className="pill">{cid}</span>}
@@ -1820,10 +2039,21 @@ export default function Page() {
                       : "actual rows truncated"}
                   </span>
                 )}
-                {c.tidb_commit && <span className="pill">commit {c.tidb_commit.slice(0, 10)}</span>}
-                {c.tidb_version && <span className="pill">{c.tidb_version.split("\n")[0]}</span>}
-                {c.plan_signature && <span className="pill">plan {c.plan_signature.slice(0, 10)}</span>}
-                {c.plan_signature_format && <span className="pill">{c.plan_signature_format}</span>}
+                {c.tidb_commit && !fieldHidden("tidb_commit") && (
+                  <span className="pill">commit {c.tidb_commit.slice(0, 10)}</span>
+                )}
+                {c.tidb_version && !fieldHidden("tidb_version") && (
+                  <span className="pill">{c.tidb_version.split("\n")[0]}</span>
+                )}
+                {c.topology && !fieldHidden("topology") && (
+                  <span className="pill">{c.topology}</span>
+                )}
+                {c.plan_signature && !fieldHidden("plan_signature") && (
+                  <span className="pill">plan {c.plan_signature.slice(0, 10)}</span>
+                )}
+                {c.plan_signature_format && !fieldHidden("plan_signature") && (
+                  <span className="pill">{c.plan_signature_format}</span>
+                )}
                 {metaLabelPreview.map((label) => (
                   <span className="pill pill--meta" key={`${cid || "case"}-label-${label}`}>
                     tag {label}
@@ -1844,8 +2074,13 @@ export default function Page() {
                 <div className="case__grid">
                 {!detailLoaded && detailLoading && <div className="hint">Loading case details...</div>}
                 {!detailLoaded && detailError && <div className="error">{detailError}</div>}
-                {(downloadURL || similarURL) && (
+                {(downloadURL || similarURL || caseLinks.length > 0) && (
                   <div className="case__actions">
+                    {caseLinks.map((link) => (
+                      <a className="action-link" href={link.href} target="_blank" rel="noreferrer" key={`${caseKey}-link-${link.label}`}>
+                        {link.label}
+                      </a>
+                    ))}
                     {downloadURL && (
                       <a className="action-link" href={downloadURL} rel="noreferrer" download>
                         Download case
@@ -1931,6 +2166,11 @@ export default function Page() {
                         <LabelRow label="Tags & Issue" />
                         {workerBaseURL && cid && currentMeta.loading && <div className="hint">Loading metadata...</div>}
                         {workerBaseURL && cid && showMetaError && <div className="error">{currentMeta.error}</div>}
+                        {currentMeta.history && (
+                          <div className="hint" title={currentMeta.history.fingerprint}>
+                            {fingerprintHistorySummary(currentMeta.history)}
+                          </div>
+                        )}
                         {metaLabels.length > 0 && (
                           <div className="pill-row">
                             {metaLabels.map((label) => (
@@ -1961,9 +2201,9 @@ export default function Page() {
                       </div>
                     );
                   })()}
-                  {c.error && (
+                  {c.error && !fieldHidden("error") && (
                     <>
-                      <LabelRow label="Error" onCopy={() => copyText("error", c.error || "")} />
+                      <LabelRow label={uiLabel("error", "Error")} onCopy={() => copyText("error", c.error || "")} />
                       <pre>{c.error}</pre>
                     </>
                   )}
@@ -1973,6 +2213,17 @@ export default function Page() {
                       <pre>{norecPredicate}</pre>
                     </>
                   )}
+                  {oracleCoverage && (
+                    <details className="fold" key="oracle-coverage" open={false}>
+                      <summary>
+                        <div className="fold__summary">
+                          <span className="fold__icon" aria-hidden="true" />
+                          <LabelRow label="Oracle Coverage" onCopy={() => copyText("oracle coverage", oracleCoverage)} />
+                        </div>
+                      </summary>
+                      <pre>{oracleCoverage}</pre>
+                    </details>
+                  )}
                   {replaySQLBlock && (
                     <>
                       <LabelRow
@@ -3,3 +3,32 @@ CREATE TABLE IF NOT EXISTS cases (
   labels_json TEXT NOT NULL DEFAULT '[]',
   linked_issue TEXT NOT NULL DEFAULT ''
 );
+
+-- One row per synced case that carries a fingerprint. Re-syncing a case does
+-- not add an observation; only its flaky flag is refreshed.
+CREATE TABLE IF NOT EXISTS case_observations (
+  case_id TEXT PRIMARY KEY,
+  fingerprint TEXT NOT NULL,
+  oracle TEXT NOT NULL DEFAULT '',
+  run_id TEXT NOT NULL DEFAULT '',
+  tidb_version TEXT NOT NULL DEFAULT '',
+  tidb_commit TEXT NOT NULL DEFAULT '',
+  flaky INTEGER NOT NULL DEFAULT 0,
+  observed_at TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS case_observations_fingerprint ON case_observations (fingerprint, observed_at);
+
+-- Aggregates of case_observations per fingerprint, recomputed on sync.
+CREATE TABLE IF NOT EXISTS fingerprints (
+  fingerprint TEXT PRIMARY KEY,
+  oracle TEXT NOT NULL DEFAULT '',
+  occurrences INTEGER NOT NULL DEFAULT 0,
+  runs INTEGER NOT NULL DEFAULT 0,
+  flaky_occurrences INTEGER NOT NULL DEFAULT 0,
+  first_seen_at TEXT NOT NULL DEFAULT '',
+  last_seen_at TEXT NOT NULL DEFAULT '',
+  first_seen_version TEXT NOT NULL DEFAULT '',
+  last_seen_version TEXT NOT NULL DEFAULT '',
+  last_seen_commit TEXT NOT NULL DEFAULT ''
+);
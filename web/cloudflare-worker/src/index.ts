@@ -14,6 +14,18 @@ interface Env {
 
 type SyncCaseInput = {
   case_id?: string;
+  oracle?: string;
+  timestamp?: string;
+  fingerprint?: string;
+  run_id?: string;
+  tidb_version?: string;
+  tidb_commit?: string;
+  flaky?: boolean;
+};
+
+type SyncResult = {
+  upserted: number;
+  fingerprints: number;
 };
 
 type SyncPayload = {
@@ -37,6 +49,20 @@ type CaseRow = {
   case_id: string;
   linked_issue: string;
   labels_json: string;
+} & Partial<HistoryRow>;
+
+// HistoryRow holds the fingerprints columns joined onto a case row; they are
+// null when the case has no observation.
+type HistoryRow = {
+  fingerprint: string | null;
+  occurrences: number | null;
+  runs: number | null;
+  flaky_occurrences: number | null;
+  first_seen_at: string | null;
+  last_seen_at: string | null;
+  first_seen_version: string | null;
+  last_seen_version: string | null;
+  last_seen_commit: string | null;
 };
 
 type ParseJSONResult<T> =
@@ -112,10 +138,11 @@ const worker = {
         if (payload.cases.length > MAX_SYNC_CASES) {
           return jsonResponse(env, 413, { error: `invalid payload: cases[] exceeds limit ${MAX_SYNC_CASES}` });
         }
-        const upserted = await syncCases(env, payload);
+        const result = await syncCases(env, payload);
         return jsonResponse(env, 200, {
           ok: true,
-          upserted,
+          upserted: result.upserted,
+          fingerprints: result.fingerprints,
           manifest_url: clean(payload.manifest_url),
         });
       }
@@ -391,8 +418,10 @@ function parseOffset(value: unknown): number {
   return Math.max(0, parsed);
 }
 
-async function syncCases(env: Env, payload: SyncPayload): Promise<number> {
+async function syncCases(env: Env, payload: SyncPayload): Promise<SyncResult> {
   const statements: D1PreparedStatement[] = [];
+  const observations: D1PreparedStatement[] = [];
+  const fingerprints = new Set<string>();
 
   for (const item of payload.cases || []) {
     const caseID = clean(item.case_id);
@@ -407,15 +436,95 @@ async function syncCases(env: Env, payload: SyncPayload): Promise<number> {
         ON CONFLICT(case_id) DO NOTHING
       `).bind(caseID),
     );
+    const fingerprint = clean(item.fingerprint);
+    if (!fingerprint) {
+      continue;
+    }
+    // Every sync resends the whole manifest, so a case is one observation no
+    // matter how often it is synced; only its flaky flag can change.
+    observations.push(
+      env.DB.prepare(`
+        INSERT INTO case_observations (case_id, fingerprint, oracle, run_id, tidb_version, tidb_commit, flaky, observed_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+        ON CONFLICT(case_id) DO UPDATE SET flaky = excluded.flaky
+      `).bind(
+        caseID,
+        fingerprint,
+        clean(item.oracle),
+        clean(item.run_id),
+        clean(item.tidb_version),
+        clean(item.tidb_commit),
+        item.flaky === true ? 1 : 0,
+        clean(item.timestamp),
+      ),
+    );
+    fingerprints.add(fingerprint);
   }
 
   if (statements.length === 0) {
-    return 0;
+    return { upserted: 0, fingerprints: 0 };
   }
-  await env.DB.batch(statements);
-  return statements.length;
+  const refresh = Array.from(fingerprints, (fingerprint) => refreshFingerprintStatement(env, fingerprint));
+  await env.DB.batch([...statements, ...observations, ...refresh]);
+  return { upserted: statements.length, fingerprints: fingerprints.size };
 }
 
+// refreshFingerprintStatement recomputes the aggregates of one fingerprint
+// from its observations.
+function refreshFingerprintStatement(env: Env, fingerprint: string): D1PreparedStatement {
+  return env.DB.prepare(`
+    INSERT INTO fingerprints (
+      fingerprint, oracle, occurrences, runs, flaky_occurrences,
+      first_seen_at, last_seen_at, first_seen_version, last_seen_version, last_seen_commit
+    )
+    SELECT
+      o.fingerprint,
+      MAX(o.oracle),
+      COUNT(*),
+      COUNT(DISTINCT NULLIF(o.run_id, '')),
+      SUM(o.flaky),
+      MIN(o.observed_at),
+      MAX(o.observed_at),
+      COALESCE((SELECT f.tidb_version FROM case_observations f
+        WHERE f.fingerprint = o.fingerprint AND f.tidb_version != ''
+        ORDER BY f.observed_at ASC, f.case_id ASC LIMIT 1), ''),
+      COALESCE((SELECT l.tidb_version FROM case_observations l
+        WHERE l.fingerprint = o.fingerprint AND l.tidb_version != ''
+        ORDER BY l.observed_at DESC, l.case_id DESC LIMIT 1), ''),
+      COALESCE((SELECT c.tidb_commit FROM case_observations c
+        WHERE c.fingerprint = o.fingerprint AND c.tidb_commit != ''
+        ORDER BY c.observed_at DESC, c.case_id DESC LIMIT 1), '')
+    FROM case_observations o
+    WHERE o.fingerprint = ?
+    GROUP BY o.fingerprint
+    ON CONFLICT(fingerprint) DO UPDATE SET
+      oracle = excluded.oracle,
+      occurrences = excluded.occurrences,
+      runs = excluded.runs,
+      flaky_occurrences = excluded.flaky_occurrences,
+      first_seen_at = excluded.first_seen_at,
+      last_seen_at = excluded.last_seen_at,
+      first_seen_version = excluded.first_seen_version,
+      last_seen_version = excluded.last_seen_version,
+      last_seen_commit = excluded.last_seen_commit
+  `).bind(fingerprint);
+}
+
+// HISTORY_COLUMNS and HISTORY_JOIN add the fingerprint history to case reads.
+const HISTORY_COLUMNS = `
+      f.fingerprint,
+      f.occurrences,
+      f.runs,
+      f.flaky_occurrences,
+      f.first_seen_at,
+      f.last_seen_at,
+      f.first_seen_version,
+      f.last_seen_version,
+      f.last_seen_commit`;
+const HISTORY_JOIN = `
+    LEFT JOIN case_observations o ON o.case_id = cases.case_id
+    LEFT JOIN fingerprints f ON f.fingerprint = o.fingerprint`;
+
 async function listCases(env: Env, params: URLSearchParams): Promise<{ total: number; cases: unknown[] }> {
   const limit = clampLimit(params.get("limit"));
   const offset = parseOffset(params.get("offset"));
@@ -427,11 +536,11 @@ async function listCases(env: Env, params: URLSearchParams): Promise<{ total: nu
 
   if (q) {
     const like = `%${q}%`;
-    where.push("(case_id LIKE ? OR labels_json LIKE ? OR linked_issue LIKE ?)");
+    where.push("(cases.case_id LIKE ? OR cases.labels_json LIKE ? OR cases.linked_issue LIKE ?)");
     args.push(like, like, like);
   }
   if (label) {
-    where.push("instr(labels_json, ?) > 0");
+    where.push("instr(cases.labels_json, ?) > 0");
     args.push(`"${label}"`);
   }
 
@@ -442,12 +551,12 @@ async function listCases(env: Env, params: URLSearchParams): Promise<{ total: nu
   // NOTE: case_id is UUIDv7 time-ordered; DESC keeps newest-first ordering.
   const listStmt = env.DB.prepare(`
     SELECT
-      case_id,
-      linked_issue,
-      labels_json
-    FROM cases
+      cases.case_id,
+      cases.linked_issue,
+      cases.labels_json,${HISTORY_COLUMNS}
+    FROM cases${HISTORY_JOIN}
     WHERE ${whereSQL}
-    ORDER BY case_id DESC
+    ORDER BY cases.case_id DESC
     LIMIT ? OFFSET ?
   `).bind(...args, limit, offset);
 
@@ -464,16 +573,16 @@ type CaseMetadataRow = {
   case_id: string;
   labels_json: string;
   linked_issue: string;
-};
+} & Partial<HistoryRow>;
 
 async function getCaseMetadata(env: Env, caseID: string): Promise<Record<string, unknown> | null> {
   const row = await env.DB.prepare(`
     SELECT
-      case_id,
-      labels_json,
-      linked_issue
-    FROM cases
-    WHERE case_id = ?
+      cases.case_id,
+      cases.labels_json,
+      cases.linked_issue,${HISTORY_COLUMNS}
+    FROM cases${HISTORY_JOIN}
+    WHERE cases.case_id = ?
   `).bind(clean(caseID)).first<CaseMetadataRow>();
   if (!row) {
     return null;
@@ -482,6 +591,26 @@ async function getCaseMetadata(env: Env, caseID: string): Promise<Record<string,
     case_id: row.case_id,
     labels: parseLabels(row.labels_json),
     linked_issue: clean(row.linked_issue),
+    history: historyFromRow(row),
+  };
+}
+
+// historyFromRow returns the fingerprint history of a case row, or null when
+// the case was synced without a fingerprint.
+function historyFromRow(row: Partial<HistoryRow>): Record<string, unknown> | null {
+  if (!row.fingerprint) {
+    return null;
+  }
+  return {
+    fingerprint: row.fingerprint,
+    occurrences: Number(row.occurrences || 0),
+    runs: Number(row.runs || 0),
+    flaky_occurrences: Number(row.flaky_occurrences || 0),
+    first_seen_at: clean(row.first_seen_at),
+    last_seen_at: clean(row.last_seen_at),
+    first_seen_version: clean(row.first_seen_version),
+    last_seen_version: clean(row.last_seen_version),
+    last_seen_commit: clean(row.last_seen_commit),
   };
 }
 
@@ -674,6 +803,7 @@ function normalizeCaseRow(row: CaseRow): Record<string, unknown> {
     case_id: row.case_id,
     labels: parseLabels(row.labels_json),
     linked_issue: row.linked_issue,
+    history: historyFromRow(row),
   };
 }
 
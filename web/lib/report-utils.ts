@@ -105,3 +105,307 @@ export const similarCasesURL = (workerBaseURL: string, c: CaseLike): string => {
   if (!base || !cid) return "";
   return `${base}/api/v1/cases/${encodeURIComponent(cid)}/similar?limit=20&ai=1`;
 };
+
+export type SiteCaseLink = {
+  label: string;
+  url: string;
+};
+
+// SiteConfig mirrors site-config.json written by cmd/shiro-report.
+export type SiteConfig = {
+  title: string;
+  locale: string;
+  labels: Record<string, string>;
+  hidden_fields: string[];
+  case_links: SiteCaseLink[];
+};
+
+export type CaseLinkFields = CaseLike & {
+  oracle?: string;
+  error_reason?: string;
+  tidb_commit?: string;
+  plan_signature?: string;
+};
+
+export const emptySiteConfig = (): SiteConfig => ({
+  title: "",
+  locale: "",
+  labels: {},
+  hidden_fields: [],
+  case_links: [],
+});
+
+export const normalizeSiteConfig = (value: unknown): SiteConfig => {
+  const cfg = emptySiteConfig();
+  if (!value || typeof value !== "object") {
+    return cfg;
+  }
+  const raw = value as Record<string, unknown>;
+  cfg.title = typeof raw.title === "string" ? raw.title.trim() : "";
+  cfg.locale = typeof raw.locale === "string" ? raw.locale.trim() : "";
+  if (raw.labels && typeof raw.labels === "object") {
+    for (const [key, label] of Object.entries(raw.labels as Record<string, unknown>)) {
+      if (typeof label === "string" && label.trim()) {
+        cfg.labels[key] = label;
+      }
+    }
+  }
+  if (Array.isArray(raw.hidden_fields)) {
+    cfg.hidden_fields = raw.hidden_fields
+      .filter((item): item is string => typeof item === "string")
+      .map((item) => item.trim().toLowerCase())
+      .filter(Boolean);
+  }
+  if (Array.isArray(raw.case_links)) {
+    for (const item of raw.case_links) {
+      if (!item || typeof item !== "object") continue;
+      const link = item as Record<string, unknown>;
+      const label = typeof link.label === "string" ? link.label.trim() : "";
+      const url = typeof link.url === "string" ? link.url.trim() : "";
+      if (label && isHTTPURL(url)) {
+        cfg.case_links.push({ label, url });
+      }
+    }
+  }
+  return cfg;
+};
+
+export const siteLabel = (cfg: SiteConfig, key: string, fallback: string): string => {
+  return cfg.labels[key] || fallback;
+};
+
+export const siteFieldHidden = (cfg: SiteConfig, field: string): boolean => {
+  return cfg.hidden_fields.includes(field);
+};
+
+const caseLinkValue = (c: CaseLinkFields, name: string): string | null => {
+  switch (name) {
+    case "case_id":
+      return caseID(c);
+    case "oracle":
+      return (c.oracle || "").trim();
+    case "error_reason":
+      return (c.error_reason || "").trim();
+    case "tidb_commit":
+      return (c.tidb_commit || "").trim();
+    case "plan_signature":
+      return (c.plan_signature || "").trim();
+    default:
+      return null;
+  }
+};
+
+// expandCaseLink fills {placeholder} fields in a case link template with
+// URL-escaped case values. Unknown placeholders are left as-is.
+export const expandCaseLink = (template: string, c: CaseLinkFields): string => {
+  if (!isHTTPURL(template)) return "";
+  return template.replace(/\{([a-z_]+)\}/g, (match, name: string) => {
+    const value = caseLinkValue(c, name);
+    return value === null ? match : encodeURIComponent(value);
+  });
+};
+
+// CaseTypedDetails mirrors typed_details (details-schema.json) written by cmd/shiro-report.
+export type CaseTypedDetails = {
+  version: number;
+  replay: {
+    kind: string;
+    sql: string;
+    expected_sql: string;
+    actual_sql: string;
+  };
+  explains: {
+    expected: string;
+    actual: string;
+    optimized: string;
+    unoptimized: string;
+    for_connection: string;
+    diff: string;
+  };
+  warnings: string[];
+  warnings_error: string;
+  args_first: string[];
+  args_second: string[];
+};
+
+const recordOf = (value: unknown): Record<string, unknown> => {
+  return value && typeof value === "object" && !Array.isArray(value) ? (value as Record<string, unknown>) : {};
+};
+
+const textOf = (value: unknown): string => (typeof value === "string" ? value : "");
+
+const textsOf = (value: unknown): string[] => {
+  return Array.isArray(value) ? value.filter((item): item is string => typeof item === "string") : [];
+};
+
+// normalizeTypedDetails reads typed_details when present and falls back to the
+// legacy details keys, so older manifests render the same way.
+export const normalizeTypedDetails = (typed: unknown, details: Record<string, unknown> | null): CaseTypedDetails => {
+  const raw = recordOf(typed);
+  const legacy = details || {};
+  const replay = recordOf(raw.replay);
+  const explains = recordOf(raw.explains);
+  const warnings = recordOf(raw.warnings);
+  const args = recordOf(raw.args);
+  const pick = (value: unknown, key: string) => textOf(value) || textOf(legacy[key]);
+  const pickList = (value: unknown, key: string) => {
+    const list = textsOf(value);
+    return list.length > 0 ? list : textsOf(legacy[key]);
+  };
+  return {
+    version: typeof raw.version === "number" ? raw.version : 0,
+    replay: {
+      kind: pick(replay.kind, "replay_kind"),
+      sql: pick(replay.sql, "replay_sql"),
+      expected_sql: pick(replay.expected_sql, "replay_expected_sql"),
+      actual_sql: pick(replay.actual_sql, "replay_actual_sql"),
+    },
+    explains: {
+      expected: pick(explains.expected, "expected_explain"),
+      actual: pick(explains.actual, "actual_explain"),
+      optimized: pick(explains.optimized, "optimized_explain"),
+      unoptimized: pick(explains.unoptimized, "unoptimized_explain"),
+      for_connection: pick(explains.for_connection, "explain_for_connection"),
+      diff: pick(explains.diff, "explain_diff"),
+    },
+    warnings: pickList(warnings.messages, "warnings"),
+    warnings_error: pick(warnings.error, "warnings_err"),
+    args_first: pickList(args.first, "args_first"),
+    args_second: pickList(args.second, "args_second"),
+  };
+};
+
+export type TrendSeries = {
+  key: string;
+  total: number;
+  first_seen: string;
+  last_seen: string;
+  counts: number[];
+};
+
+// TrendsData mirrors trends.json written by cmd/shiro-report. Series counts
+// are aligned with buckets (one UTC day each).
+export type TrendsData = {
+  buckets: string[];
+  total: number[];
+  by_oracle: TrendSeries[];
+  by_error_reason: TrendSeries[];
+  by_tidb_commit: TrendSeries[];
+};
+
+const normalizeCounts = (value: unknown, size: number): number[] => {
+  const raw = Array.isArray(value) ? value : [];
+  const out: number[] = [];
+  for (let i = 0; i < size; i++) {
+    const n = raw[i];
+    out.push(typeof n === "number" && Number.isFinite(n) && n > 0 ? n : 0);
+  }
+  return out;
+};
+
+const normalizeTrendSeries = (value: unknown, size: number): TrendSeries[] => {
+  if (!Array.isArray(value)) return [];
+  const out: TrendSeries[] = [];
+  for (const item of value) {
+    if (!item || typeof item !== "object") continue;
+    const raw = item as Record<string, unknown>;
+    const key = typeof raw.key === "string" ? raw.key : "";
+    if (!key) continue;
+    const counts = normalizeCounts(raw.counts, size);
+    out.push({
+      key,
+      total: counts.reduce((sum, n) => sum + n, 0),
+      first_seen: typeof raw.first_seen === "string" ? raw.first_seen : "",
+      last_seen: typeof raw.last_seen === "string" ? raw.last_seen : "",
+      counts,
+    });
+  }
+  return out;
+};
+
+// normalizeTrends returns null when the payload has no usable buckets.
+export const normalizeTrends = (value: unknown): TrendsData | null => {
+  if (!value || typeof value !== "object") return null;
+  const raw = value as Record<string, unknown>;
+  if (!Array.isArray(raw.buckets)) return null;
+  const buckets = raw.buckets.filter((item): item is string => typeof item === "string");
+  if (buckets.length === 0 || buckets.length !== raw.buckets.length) return null;
+  const size = buckets.length;
+  return {
+    buckets,
+    total: normalizeCounts(raw.total, size),
+    by_oracle: normalizeTrendSeries(raw.by_oracle, size),
+    by_error_reason: normalizeTrendSeries(raw.by_error_reason, size),
+    by_tidb_commit: normalizeTrendSeries(raw.by_tidb_commit, size),
+  };
+};
+
+const sparkBlocks = "▁▂▃▄▅▆▇█";
+
+// trendSparkline renders the last `width` counts as a text sparkline scaled
+// to the largest count in that window.
+export const trendSparkline = (counts: number[], width = 30): string => {
+  const recent = counts.slice(-width);
+  const peak = Math.max(0, ...recent);
+  if (peak === 0) return sparkBlocks[0].repeat(recent.length);
+  return recent
+    .map((n) => sparkBlocks[Math.min(sparkBlocks.length - 1, Math.floor((n / peak) * (sparkBlocks.length - 1)))])
+    .join("");
+};
+
+// FingerprintHistory mirrors the `history` object the worker returns with
+// case metadata: how often the case's fingerprint was seen across runs.
+export type FingerprintHistory = {
+  fingerprint: string;
+  occurrences: number;
+  runs: number;
+  flaky_occurrences: number;
+  first_seen_at: string;
+  last_seen_at: string;
+  first_seen_version: string;
+  last_seen_version: string;
+  last_seen_commit: string;
+};
+
+const historyCount = (value: unknown): number =>
+  typeof value === "number" && Number.isFinite(value) && value > 0 ? Math.floor(value) : 0;
+
+const historyText = (value: unknown): string => (typeof value === "string" ? value.trim() : "");
+
+// normalizeFingerprintHistory returns null without a fingerprint.
+export const normalizeFingerprintHistory = (value: unknown): FingerprintHistory | null => {
+  if (!value || typeof value !== "object") return null;
+  const raw = value as Record<string, unknown>;
+  const fingerprint = historyText(raw.fingerprint);
+  if (!fingerprint) return null;
+  return {
+    fingerprint,
+    occurrences: historyCount(raw.occurrences),
+    runs: historyCount(raw.runs),
+    flaky_occurrences: historyCount(raw.flaky_occurrences),
+    first_seen_at: historyText(raw.first_seen_at),
+    last_seen_at: historyText(raw.last_seen_at),
+    first_seen_version: historyText(raw.first_seen_version),
+    last_seen_version: historyText(raw.last_seen_version),
+    last_seen_commit: historyText(raw.last_seen_commit),
+  };
+};
+
+// fingerprintHistorySummary renders a history as one line, for example
+// "seen in 14 runs since v7.5.0 · 3/20 flaky · last seen at abc1234".
+// Cases synced without a CI run id count no run, so a history without runs
+// falls back to the number of occurrences.
+export const fingerprintHistorySummary = (history: FingerprintHistory): string => {
+  const runs =
+    history.runs > 0
+      ? `seen in ${history.runs} run${history.runs === 1 ? "" : "s"}`
+      : `seen ${history.occurrences} time${history.occurrences === 1 ? "" : "s"}`;
+  const parts = [history.first_seen_version ? `${runs} since ${history.first_seen_version}` : runs];
+  if (history.flaky_occurrences > 0) {
+    parts.push(`${history.flaky_occurrences}/${history.occurrences} flaky`);
+  }
+  if (history.last_seen_commit) {
+    parts.push(`last seen at ${history.last_seen_commit.slice(0, 12)}`);
+  }
+  return parts.join(" · ");
+};
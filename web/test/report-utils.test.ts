@@ -5,10 +5,20 @@ import {
   caseArchiveURL,
   caseID,
   caseReportURL,
+  emptySiteConfig,
+  expandCaseLink,
+  fingerprintHistorySummary,
   isGCSURL,
   isHTTPURL,
+  normalizeFingerprintHistory,
+  normalizeSiteConfig,
+  normalizeTrends,
+  normalizeTypedDetails,
   objectURL,
   similarCasesURL,
+  siteFieldHidden,
+  siteLabel,
+  trendSparkline,
 } from "../lib/report-utils";
 
 test("objectURL trims slashes", () => {
@@ -85,3 +95,89 @@ test("similar URL generation", () => {
     "https://worker.example.com/api/v1/cases/0194d4f8-b6ce-7d4e-b13d-3be7446954d4/similar?limit=20&ai=1",
   );
 });
+
+test("normalizeSiteConfig drops invalid fields and unsafe links", () => {
+  const cfg = normalizeSiteConfig({
+    title: " Nightly ",
+    labels: { expected: "Erwartet", actual: 3 },
+    hidden_fields: [" TiDB_Version ", 1],
+    case_links: [
+      { label: "Jira", url: "https://jira.example.com/browse?q={case_id}" },
+      { label: "bad", url: "javascript:alert(1)" },
+    ],
+  });
+  assert.equal(cfg.title, "Nightly");
+  assert.deepEqual(cfg.labels, { expected: "Erwartet" });
+  assert.deepEqual(cfg.hidden_fields, ["tidb_version"]);
+  assert.equal(cfg.case_links.length, 1);
+  assert.equal(siteLabel(cfg, "expected", "Expected"), "Erwartet");
+  assert.equal(siteLabel(cfg, "actual", "Actual"), "Actual");
+  assert.equal(siteFieldHidden(cfg, "tidb_version"), true);
+  assert.deepEqual(normalizeSiteConfig(null), emptySiteConfig());
+});
+
+test("expandCaseLink escapes case values", () => {
+  const c = { case_id: "id 1", oracle: "DQP", error_reason: "a&b" };
+  assert.equal(
+    expandCaseLink("https://jira.example.com/search?q={case_id}&o={oracle}&r={error_reason}&x={unknown}", c),
+    "https://jira.example.com/search?q=id%201&o=DQP&r=a%26b&x={unknown}",
+  );
+  assert.equal(expandCaseLink("javascript:{case_id}", c), "");
+});
+
+test("normalizeTypedDetails prefers typed_details and falls back to details keys", () => {
+  const typed = normalizeTypedDetails(
+    { version: 1, replay: { kind: "signature", expected_sql: "SELECT 1" }, warnings: { messages: ["Warning:1105:x"] } },
+    { replay_expected_sql: "SELECT 2", actual_explain: "TableReader", args_first: ["1"] },
+  );
+  assert.equal(typed.version, 1);
+  assert.equal(typed.replay.kind, "signature");
+  assert.equal(typed.replay.expected_sql, "SELECT 1");
+  assert.equal(typed.explains.actual, "TableReader");
+  assert.deepEqual(typed.warnings, ["Warning:1105:x"]);
+  assert.deepEqual(typed.args_first, ["1"]);
+  assert.equal(normalizeTypedDetails(null, null).version, 0);
+});
+
+test("normalizeTrends aligns series counts with buckets", () => {
+  const trends = normalizeTrends({
+    buckets: ["2026-10-14", "2026-10-15"],
+    total: [3, "x"],
+    by_oracle: [{ key: "DQP", counts: [2, 1, 9], first_seen: "2026-10-14" }, { counts: [1] }],
+  });
+  assert.ok(trends);
+  assert.deepEqual(trends.total, [3, 0]);
+  assert.equal(trends.by_oracle.length, 1);
+  assert.deepEqual(trends.by_oracle[0].counts, [2, 1]);
+  assert.equal(trends.by_oracle[0].total, 3);
+  assert.deepEqual(trends.by_tidb_commit, []);
+  assert.equal(normalizeTrends({ buckets: [] }), null);
+  assert.equal(normalizeTrends(null), null);
+});
+
+test("trendSparkline scales to the window peak", () => {
+  assert.equal(trendSparkline([0, 4, 8]), "▁▄█");
+  assert.equal(trendSparkline([0, 0]), "▁▁");
+  assert.equal(trendSparkline([1, 2, 3], 2), "▅█");
+});
+
+test("fingerprint history normalizes worker rows and renders a summary", () => {
+  assert.equal(normalizeFingerprintHistory(null), null);
+  assert.equal(normalizeFingerprintHistory({ runs: 3 }), null);
+  const history = normalizeFingerprintHistory({
+    fingerprint: "NoREC|result_mismatch|sig",
+    occurrences: 20,
+    runs: 14,
+    flaky_occurrences: 3,
+    first_seen_version: "v7.5.0",
+    last_seen_commit: "abc1234def567890",
+  });
+  assert.ok(history);
+  assert.equal(fingerprintHistorySummary(history), "seen in 14 runs since v7.5.0 · 3/20 flaky · last seen at abc1234def56");
+  const once = normalizeFingerprintHistory({ fingerprint: "x", occurrences: 1, runs: 1 });
+  assert.ok(once);
+  assert.equal(fingerprintHistorySummary(once), "seen in 1 run");
+  const noRunID = normalizeFingerprintHistory({ fingerprint: "x", occurrences: 3, runs: 0 });
+  assert.ok(noRunID);
+  assert.equal(fingerprintHistorySummary(noRunID), "seen 3 times");
+});